@@ -0,0 +1,85 @@
+// Command anonymize scrambles the recipients, message bodies, emails and credentials of a database in place, keeping
+// its rows, statuses and timestamps, so a clone of a production snapshot can serve as a realistic staging
+// environment. It reads the DB_* settings of .env and refuses to run with GO_ENV=production. The name of the
+// database has to be confirmed:
+//
+//	go run ./cmd/anonymize -confirm staging_clone -password "$STAGING_PASSWORD" -keep-users ops@example.com
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"go-multi-chat-api/src/infrastructure/anonymize"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	confirm := flag.String("confirm", "", "name of the database to anonymize, required")
+	password := flag.String("password", os.Getenv("ANONYMIZE_PASSWORD"), "password of every user afterwards, random when empty, defaults to ANONYMIZE_PASSWORD")
+	keepUsers := flag.String("keep-users", os.Getenv("ANONYMIZE_KEEP_USERS"), "comma separated emails of the users kept as they are, defaults to ANONYMIZE_KEEP_USERS")
+	envFile := flag.String("env", ".env", "file the DB_* settings are loaded from, if it exists")
+	flag.Parse()
+
+	if _, err := os.Stat(*envFile); err == nil {
+		if err := godotenv.Load(*envFile); err != nil {
+			fail("Error loading %s: %v", *envFile, err)
+		}
+	}
+	if os.Getenv("GO_ENV") == "production" {
+		fail("Refusing to anonymize with GO_ENV=production")
+	}
+	if *confirm == "" {
+		fail("Set -confirm to the name of the database to anonymize")
+	}
+
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		fail("Error creating the logger: %v", err)
+	}
+	db, err := mysql.ConnectMySQLDB(loggerInstance)
+	if err != nil {
+		fail("Error connecting to the database: %v", err)
+	}
+
+	config := anonymize.Config{Password: *password}
+	for _, email := range strings.Split(*keepUsers, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			config.KeepUsers = append(config.KeepUsers, email)
+		}
+	}
+	anonymizer, err := anonymize.New(db, config, loggerInstance)
+	if err != nil {
+		fail("Error creating the anonymizer: %v", err)
+	}
+	if database := anonymizer.DatabaseName(); database != *confirm {
+		fail("Refusing to anonymize %s, -confirm names %s", database, *confirm)
+	}
+
+	// An interrupted run leaves the tables done so far anonymized, run it again to anonymize every table
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := anonymizer.Run(ctx, nil)
+	if err != nil {
+		fail("Error anonymizing the database: %v", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fail("Error writing the result: %v", err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
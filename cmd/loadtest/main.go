@@ -0,0 +1,67 @@
+// Command loadtest drives the send endpoint of a running API and reports its throughput and latencies. Point it at
+// a deployment with SANDBOX_PROVIDER_ENABLED=true and a provider of the sandbox type to load the API and the
+// processor without sending to a vendor:
+//
+//	go run ./cmd/loadtest -token "$TOKEN" -body post-message.json -rate 200 -duration 1m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/loadtest"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:8080/v1/send/message", "endpoint the messages are posted to")
+	token := flag.String("token", os.Getenv("LOADTEST_TOKEN"), "bearer token of the sending user, defaults to LOADTEST_TOKEN")
+	bodyFile := flag.String("body", "post-message.json", "file with the JSON body of every request")
+	rate := flag.Int("rate", 0, "requests started per second, 0 sends as fast as the workers go")
+	workers := flag.Int("workers", 10, "requests in flight at most")
+	duration := flag.Duration("duration", 30*time.Second, "how long requests are started for")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of each request")
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+
+	body, err := os.ReadFile(*bodyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading the request body: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Interrupting the test still reports the requests sent so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := loadtest.Run(ctx, &http.Client{}, loadtest.Config{
+		URL:      *url,
+		Token:    *token,
+		Body:     body,
+		Rate:     *rate,
+		Workers:  *workers,
+		Duration: *duration,
+		Timeout:  *timeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running the load test: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	} else {
+		err = result.Write(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the result: %v\n", err)
+		os.Exit(1)
+	}
+}
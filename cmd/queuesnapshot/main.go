@@ -0,0 +1,134 @@
+// Command queuesnapshot writes the messages waiting to be sent to a JSON lines snapshot and replays a snapshot into
+// the queue, e.g. to recover after a bad deploy corrupted the messages in flight. It works on the database of the
+// DB_* settings of .env, so it still helps while the API is down:
+//
+//	go run ./cmd/queuesnapshot snapshot -out queue.jsonl
+//	go run ./cmd/queuesnapshot replay -in queue.jsonl -status failed,unconfirmed -from 2024-05-01T10:00:00Z -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/events"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/queuesnapshot"
+	"go-multi-chat-api/src/infrastructure/repository/mysql"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fail("Usage: queuesnapshot snapshot|replay [flags]")
+	}
+	command, args := os.Args[1], os.Args[2:]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	envFile := flags.String("env", ".env", "file the DB_* settings are loaded from, if it exists")
+
+	var run func(ctx context.Context, snapshotter *queuesnapshot.Snapshotter) (interface{}, error)
+	switch command {
+	case "snapshot":
+		out := flags.String("out", "", "file the snapshot is written to, stdout when empty")
+		_ = flags.Parse(args)
+		run = func(ctx context.Context, snapshotter *queuesnapshot.Snapshotter) (interface{}, error) {
+			var w io.Writer = os.Stdout
+			if *out != "" {
+				file, err := os.OpenFile(*out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if err != nil {
+					return nil, err
+				}
+				defer file.Close()
+				w = file
+			}
+			return snapshotter.Snapshot(ctx, w)
+		}
+	case "replay":
+		in := flags.String("in", "", "snapshot file to replay, required")
+		statuses := flags.String("status", "", "comma separated statuses the messages had in the snapshot, all when empty")
+		userID := flags.Int("user", 0, "only the messages of this user")
+		providerID := flags.Int("provider", 0, "only the messages of this provider")
+		errorCode := flags.String("error-code", "", "only the messages that failed with this error code")
+		from := flags.String("from", "", "only the messages created at or after this RFC 3339 time")
+		to := flags.String("to", "", "only the messages created before this RFC 3339 time")
+		dryRun := flags.Bool("dry-run", false, "only count what the replay would do")
+		resend := flags.Bool("resend", false, "also replay messages whose send was started after the snapshot")
+		_ = flags.Parse(args)
+		if *in == "" {
+			fail("Set -in to the snapshot file to replay")
+		}
+		filter := queuesnapshot.Filter{UserID: *userID, ProviderID: *providerID, ErrorCode: *errorCode, From: parseTime(*from), To: parseTime(*to)}
+		for _, status := range strings.Split(*statuses, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+		run = func(ctx context.Context, snapshotter *queuesnapshot.Snapshotter) (interface{}, error) {
+			file, err := os.Open(*in)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			return snapshotter.Replay(ctx, file, filter, queuesnapshot.Options{DryRun: *dryRun, Resend: *resend}, nil)
+		}
+	default:
+		fail("Unknown command %s, use snapshot or replay", command)
+	}
+
+	if _, err := os.Stat(*envFile); err == nil {
+		if err := godotenv.Load(*envFile); err != nil {
+			fail("Error loading %s: %v", *envFile, err)
+		}
+	}
+	// Warnings and errors go to stderr, a snapshot may be written to stdout
+	logConfig := zap.NewProductionConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	zapLogger, err := logConfig.Build()
+	if err != nil {
+		fail("Error creating the logger: %v", err)
+	}
+	loggerInstance := &logger.Logger{Log: zapLogger}
+	db, err := mysql.ConnectMySQLDB(loggerInstance)
+	if err != nil {
+		fail("Error connecting to the database: %v", err)
+	}
+	snapshotter := queuesnapshot.New(
+		providerRepo.NewMessageTransactionRepository(db, loggerInstance, events.LoadPublisherConfig().Enabled()),
+		providerRepo.NewMessageTransactionHistoryRepository(db, loggerInstance),
+		loggerInstance)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := run(ctx, snapshotter)
+	if err != nil {
+		fail("Error running %s: %v", command, err)
+	}
+	encoder := json.NewEncoder(os.Stderr)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(result)
+}
+
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		fail("Invalid time %s, use RFC 3339 like 2024-05-01T10:00:00Z", value)
+	}
+	return &parsed
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/joho/godotenv"
 	"log"
@@ -8,12 +9,11 @@ import (
 	"os"
 	"time"
 
+	"go-multi-chat-api/src/infrastructure/configcheck"
 	"go-multi-chat-api/src/infrastructure/di"
 	logger "go-multi-chat-api/src/infrastructure/logger"
-	"go-multi-chat-api/src/infrastructure/rest/middlewares"
-	"go-multi-chat-api/src/infrastructure/rest/routes"
+	"go-multi-chat-api/src/infrastructure/rest/server"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -56,6 +56,11 @@ func main() {
 		}
 	}()
 
+	// Dry run for deploy pipelines: validate the configuration and exit without serving traffic
+	if validateConfigRequested() {
+		os.Exit(validateConfig(loggerInstance))
+	}
+
 	loggerInstance.Info("Starting go-multi-chat-api application")
 
 	// Load server configuration
@@ -68,10 +73,14 @@ func main() {
 	}
 
 	// Setup router
-	router := setupRouter(appContext, loggerInstance)
+	routerConfig, err := server.LoadConfig()
+	if err != nil {
+		loggerInstance.Panic("Error loading router configuration", zap.Error(err))
+	}
+	router := server.NewRouter(appContext, loggerInstance, routerConfig.Options()...)
 
 	// Setup server
-	server := setupServer(router, serverConfig.Port)
+	httpServer := setupServer(router, serverConfig.Port)
 
 	// Process pending messages on startup
 	loggerInstance.Info("Processing pending messages on startup")
@@ -79,40 +88,11 @@ func main() {
 
 	// Start server
 	loggerInstance.Info("Server starting", zap.String("port", serverConfig.Port))
-	if err := server.ListenAndServe(); err != nil {
+	if err := httpServer.ListenAndServe(); err != nil {
 		loggerInstance.Panic("Server failed to start", zap.Error(err))
 	}
 }
 
-func setupRouter(appContext *di.ApplicationContext, logger *logger.Logger) *gin.Engine {
-	// Configurar Gin para usar el logger de Zap basado en el entorno
-	env := getEnvOrDefault("GO_ENV", "development")
-	if env == "development" {
-		logger.SetupGinWithZapLoggerInDevelopment()
-	} else {
-		logger.SetupGinWithZapLogger()
-	}
-
-	// Crear el router después de configurar el logger
-	router := gin.New()
-
-	// Agregar middlewares de recuperación y logger personalizados
-	router.Use(gin.Recovery())
-	router.Use(cors.Default())
-
-	// Add middlewares
-	router.Use(middlewares.ErrorHandler())
-	router.Use(middlewares.GinBodyLogMiddleware)
-	router.Use(middlewares.CommonHeaders)
-
-	// Add logger middleware
-	router.Use(logger.GinZapLogger())
-
-	// Setup routes
-	routes.ApplicationRouter(router, appContext)
-	return router
-}
-
 func setupServer(router *gin.Engine, port string) *http.Server {
 	return &http.Server{
 		Addr:           ":" + port,
@@ -123,6 +103,35 @@ func setupServer(router *gin.Engine, port string) *http.Server {
 	}
 }
 
+// validateConfigRequested reports whether the --validate-config flag or VALIDATE_CONFIG=true is set. The
+// flag is looked up directly, the remaining flags are parsed by the DI container which isn't set up in this mode.
+func validateConfigRequested() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "--validate-config" || arg == "-validate-config" {
+			return true
+		}
+	}
+	return getEnvOrDefault("VALIDATE_CONFIG", "false") == "true"
+}
+
+// validateConfig prints the configuration report as JSON and returns the exit code, 1 if any check failed
+func validateConfig(loggerInstance *logger.Logger) int {
+	report := configcheck.Run(loggerInstance)
+	_ = loggerInstance.Log.Sync()
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		loggerInstance.Error("Error marshaling configuration report", zap.Error(err))
+		return 1
+	}
+	fmt.Println(string(output))
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
 // Helper function
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
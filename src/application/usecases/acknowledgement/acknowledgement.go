@@ -0,0 +1,132 @@
+package acknowledgement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/escalation"
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// Notifier reports the outcome of an acknowledgement to the sender of the message
+type Notifier interface {
+	NotifyAcknowledgement(msg *provider.MessageTransaction, acknowledged bool)
+}
+
+// IAcknowledgementUseCase defines the interface for message acknowledgement use cases
+type IAcknowledgementUseCase interface {
+	AcknowledgeByReply(text string, sender string) (bool, error)
+	ProcessExpiredAcks(now time.Time) error
+}
+
+// AcknowledgementUseCase implements the IAcknowledgementUseCase interface
+type AcknowledgementUseCase struct {
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	notifier                     Notifier
+	escalationUseCase            escalation.IEscalationUseCase
+	Logger                       *logger.Logger
+}
+
+// NewAcknowledgementUseCase creates a new AcknowledgementUseCase
+func NewAcknowledgementUseCase(
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	notifier Notifier,
+	escalationUseCase escalation.IEscalationUseCase,
+	loggerInstance *logger.Logger,
+) IAcknowledgementUseCase {
+	return &AcknowledgementUseCase{
+		messageTransactionRepository: messageTransactionRepository,
+		notifier:                     notifier,
+		escalationUseCase:            escalationUseCase,
+		Logger:                       loggerInstance,
+	}
+}
+
+// AcknowledgeByReply acknowledges the message a received reply refers to. A reply of the keyword followed by
+// the ack token acknowledges the message with that token, the keyword alone acknowledges the most recent
+// message sent to the sender that awaits it. It reports whether the reply acknowledged a message.
+func (a *AcknowledgementUseCase) AcknowledgeByReply(text string, sender string) (bool, error) {
+	fields := strings.Fields(text)
+
+	var msg *provider.MessageTransaction
+	var err error
+	switch len(fields) {
+	case 1:
+		msg, err = a.messageTransactionRepository.GetLatestPendingAckForRecipient(sender, strings.ToUpper(fields[0]))
+	case 2:
+		msg, err = a.messageTransactionRepository.GetPendingAckByToken(strings.ToUpper(fields[1]))
+		if err == nil && !strings.EqualFold(fields[0], msg.AckKeyword) {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	acknowledgedAt := time.Now()
+	acknowledged, err := a.messageTransactionRepository.AcknowledgeMessage(msg.ID, sender, acknowledgedAt)
+	if err != nil || !acknowledged {
+		return false, err
+	}
+	msg.AckStatus = message.AckStatusAcknowledged
+	msg.AcknowledgedBy = sender
+	msg.AcknowledgedAt = &acknowledgedAt
+
+	a.Logger.Info("Message acknowledged by reply", zap.Int("messageID", msg.ID), zap.String("acknowledgedBy", sender))
+	a.notifier.NotifyAcknowledgement(msg, true)
+	return true, nil
+}
+
+// ProcessExpiredAcks expires the messages whose acknowledgement deadline passed, notifies their senders and
+// triggers the escalation chain demanded on expiry
+func (a *AcknowledgementUseCase) ProcessExpiredAcks(now time.Time) error {
+	messages, err := a.messageTransactionRepository.GetExpiredAcks(now)
+	if err != nil {
+		return err
+	}
+
+	for i := range *messages {
+		msg := &(*messages)[i]
+		expired, err := a.messageTransactionRepository.ExpireAck(msg.ID)
+		if err != nil || !expired {
+			// Either failed or the message was acknowledged or expired by another instance
+			continue
+		}
+		msg.AckStatus = message.AckStatusExpired
+
+		a.Logger.Info("Message acknowledgement expired", zap.Int("messageID", msg.ID), zap.Int("userID", msg.UserID))
+		a.notifier.NotifyAcknowledgement(msg, false)
+
+		if msg.AckEscalationChainID == 0 {
+			continue
+		}
+		_, err = a.escalationUseCase.Trigger(msg.UserID, msg.AckEscalationChainID, EscalationMessage(msg))
+		if err != nil {
+			a.Logger.Error("Error triggering escalation for unacknowledged message",
+				zap.Error(err),
+				zap.Int("messageID", msg.ID),
+				zap.Int("chainID", msg.AckEscalationChainID))
+		}
+	}
+	return nil
+}
+
+// EscalationMessage renders the text of the escalation triggered for an unacknowledged message
+func EscalationMessage(msg *provider.MessageTransaction) string {
+	text := strings.TrimSuffix(msg.Message, message.AckInstructions(msg.AckKeyword, msg.AckToken))
+	return fmt.Sprintf("Message #%d was not acknowledged in time: %s", msg.ID, text)
+}
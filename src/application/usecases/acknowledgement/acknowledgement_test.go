@@ -0,0 +1,191 @@
+package acknowledgement
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/escalation"
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockMessageTransactionRepository implements the acknowledgement queries, the embedded interface
+// panics on any other call
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	messages []provider.MessageTransaction
+}
+
+func (m *mockMessageTransactionRepository) find(match func(msg *provider.MessageTransaction) bool) (*provider.MessageTransaction, error) {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if match(&m.messages[i]) {
+			msg := m.messages[i]
+			return &msg, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockMessageTransactionRepository) GetPendingAckByToken(ackToken string) (*provider.MessageTransaction, error) {
+	return m.find(func(msg *provider.MessageTransaction) bool {
+		return msg.AckStatus == message.AckStatusPending && msg.AckToken == ackToken
+	})
+}
+
+func (m *mockMessageTransactionRepository) GetLatestPendingAckForRecipient(recipient string, ackKeyword string) (*provider.MessageTransaction, error) {
+	return m.find(func(msg *provider.MessageTransaction) bool {
+		return msg.AckStatus == message.AckStatusPending && msg.AckKeyword == ackKeyword && msg.Recipients == `["`+recipient+`"]`
+	})
+}
+
+func (m *mockMessageTransactionRepository) AcknowledgeMessage(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error) {
+	for i := range m.messages {
+		if m.messages[i].ID == id && m.messages[i].AckStatus == message.AckStatusPending {
+			m.messages[i].AckStatus = message.AckStatusAcknowledged
+			m.messages[i].AcknowledgedBy = acknowledgedBy
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockMessageTransactionRepository) GetExpiredAcks(now time.Time) (*[]provider.MessageTransaction, error) {
+	expired := []provider.MessageTransaction{}
+	for _, msg := range m.messages {
+		if msg.AckStatus == message.AckStatusPending && !msg.AckDeadline.After(now) {
+			expired = append(expired, msg)
+		}
+	}
+	return &expired, nil
+}
+
+func (m *mockMessageTransactionRepository) ExpireAck(id int) (bool, error) {
+	for i := range m.messages {
+		if m.messages[i].ID == id && m.messages[i].AckStatus == message.AckStatusPending {
+			m.messages[i].AckStatus = message.AckStatusExpired
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type notification struct {
+	messageID    int
+	acknowledged bool
+}
+
+type mockNotifier struct {
+	notifications []notification
+}
+
+func (m *mockNotifier) NotifyAcknowledgement(msg *provider.MessageTransaction, acknowledged bool) {
+	m.notifications = append(m.notifications, notification{messageID: msg.ID, acknowledged: acknowledged})
+}
+
+type trigger struct {
+	userID  int
+	chainID int
+	text    string
+}
+
+// mockEscalationUseCase records triggered escalations, the embedded interface panics on any other call
+type mockEscalationUseCase struct {
+	escalation.IEscalationUseCase
+	triggers []trigger
+}
+
+func (m *mockEscalationUseCase) Trigger(userID int, chainID int, text string) (*provider.Escalation, error) {
+	m.triggers = append(m.triggers, trigger{userID: userID, chainID: chainID, text: text})
+	return &provider.Escalation{ID: len(m.triggers)}, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func pendingMessage(id int, recipient string, token string, deadline time.Time, chainID int) provider.MessageTransaction {
+	return provider.MessageTransaction{
+		ID:                   id,
+		UserID:               1,
+		Recipients:           `["` + recipient + `"]`,
+		Message:              "Disk full on db-01" + message.AckInstructions(message.DefaultAckKeyword, token),
+		AckStatus:            message.AckStatusPending,
+		AckToken:             token,
+		AckKeyword:           message.DefaultAckKeyword,
+		AckDeadline:          &deadline,
+		AckEscalationChainID: chainID,
+	}
+}
+
+func TestAcknowledgeByReply(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	repository := &mockMessageTransactionRepository{messages: []provider.MessageTransaction{
+		pendingMessage(1, "+491111", "AAAAAA", deadline, 0),
+		pendingMessage(2, "+491111", "BBBBBB", deadline, 0),
+		pendingMessage(3, "+492222", "CCCCCC", deadline, 0),
+	}}
+	notifier := &mockNotifier{}
+	useCase := NewAcknowledgementUseCase(repository, notifier, &mockEscalationUseCase{}, setupLogger(t))
+
+	t.Run("Unrelated replies are ignored", func(t *testing.T) {
+		for _, text := range []string{"on my way", "OK", "ACK ZZZZZZ", "NOPE CCCCCC"} {
+			acknowledged, err := useCase.AcknowledgeByReply(text, "+491111")
+			assert.NoError(t, err)
+			assert.False(t, acknowledged, text)
+		}
+	})
+
+	t.Run("The keyword alone acknowledges the most recent message to the sender", func(t *testing.T) {
+		acknowledged, err := useCase.AcknowledgeByReply("ack", "+491111")
+		assert.NoError(t, err)
+		assert.True(t, acknowledged)
+		assert.Equal(t, message.AckStatusAcknowledged, repository.messages[1].AckStatus)
+		assert.Equal(t, message.AckStatusPending, repository.messages[0].AckStatus)
+	})
+
+	t.Run("The token acknowledges its message", func(t *testing.T) {
+		acknowledged, err := useCase.AcknowledgeByReply("ACK cccccc", "+493333")
+		assert.NoError(t, err)
+		assert.True(t, acknowledged)
+		assert.Equal(t, message.AckStatusAcknowledged, repository.messages[2].AckStatus)
+		assert.Equal(t, "+493333", repository.messages[2].AcknowledgedBy)
+	})
+
+	assert.Equal(t, []notification{{messageID: 2, acknowledged: true}, {messageID: 3, acknowledged: true}}, notifier.notifications)
+}
+
+func TestProcessExpiredAcks(t *testing.T) {
+	now := time.Now()
+	repository := &mockMessageTransactionRepository{messages: []provider.MessageTransaction{
+		pendingMessage(1, "+491111", "AAAAAA", now.Add(-time.Minute), 7),
+		pendingMessage(2, "+491111", "BBBBBB", now.Add(-time.Minute), 0),
+		pendingMessage(3, "+491111", "CCCCCC", now.Add(time.Minute), 7),
+	}}
+	notifier := &mockNotifier{}
+	escalationUseCase := &mockEscalationUseCase{}
+	useCase := NewAcknowledgementUseCase(repository, notifier, escalationUseCase, setupLogger(t))
+
+	assert.NoError(t, useCase.ProcessExpiredAcks(now))
+
+	assert.Equal(t, message.AckStatusExpired, repository.messages[0].AckStatus)
+	assert.Equal(t, message.AckStatusExpired, repository.messages[1].AckStatus)
+	assert.Equal(t, message.AckStatusPending, repository.messages[2].AckStatus)
+	assert.Equal(t, []notification{{messageID: 1}, {messageID: 2}}, notifier.notifications)
+
+	// Only the message demanding it escalates, without the acknowledgement instructions
+	assert.Equal(t, []trigger{{userID: 1, chainID: 7, text: "Message #1 was not acknowledged in time: Disk full on db-01"}}, escalationUseCase.triggers)
+
+	// Expired messages can't be acknowledged anymore
+	acknowledged, err := useCase.AcknowledgeByReply("ACK AAAAAA", "+491111")
+	assert.NoError(t, err)
+	assert.False(t, acknowledged)
+}
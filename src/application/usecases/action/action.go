@@ -0,0 +1,203 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// InteractionPath is the path, below the API base URL, vendors post the button clicks of a provider to, followed
+// by the vendor and the provider ID
+const InteractionPath = "/v1/interactions/"
+
+// ReplyWindow is how long after a message was sent a numbered reply of a recipient chooses one of its actions
+const ReplyWindow = 24 * time.Hour
+
+// InteractionParser verifies the interactions a vendor posts for the buttons of messages and reads the actions
+// they choose, with the body the vendor expects as response. Interactions it can't verify fail with a
+// NotAuthenticated error.
+type InteractionParser interface {
+	ParseInteraction(providerConfig string, header http.Header, body []byte) ([]provider.ActionChoice, []byte, error)
+}
+
+// Vendor posts the interactions of the providers of a type
+type Vendor struct {
+	ProviderType string
+	Parser       InteractionParser
+}
+
+// ActionHandler is told about an action chosen by a recipient, with the user who sent the message
+type ActionHandler func(userID int, choice *provider.ActionChoice)
+
+// IActionUseCase defines the interface for the actions recipients choose from the messages they are sent
+type IActionUseCase interface {
+	// ReceiveInteraction verifies an interaction of a vendor and reports the actions it chooses, it returns the
+	// response body the vendor expects
+	ReceiveInteraction(vendor string, providerID int, header http.Header, body []byte) ([]byte, error)
+	// ChooseByReply chooses the action of the latest message with actions sent to the recipient whose number the
+	// reply is, chosenBy is the sender of the reply. It reports whether the reply chose an action.
+	ChooseByReply(channel string, text string, recipient string, chosenBy string) (bool, error)
+}
+
+// ActionUseCase implements the IActionUseCase interface
+type ActionUseCase struct {
+	providerRepository           providerRepo.ProviderRepositoryInterface
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	vendors                      map[string]Vendor
+	handler                      ActionHandler
+	Logger                       *logger.Logger
+}
+
+// NewActionUseCase creates a new ActionUseCase, vendors are keyed by the name in their interaction path
+func NewActionUseCase(
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	vendors map[string]Vendor,
+	handler ActionHandler,
+	loggerInstance *logger.Logger,
+) IActionUseCase {
+	return &ActionUseCase{
+		providerRepository:           providerRepository,
+		messageTransactionRepository: messageTransactionRepository,
+		vendors:                      vendors,
+		handler:                      handler,
+		Logger:                       loggerInstance,
+	}
+}
+
+// ReceiveInteraction verifies an interaction and reports the actions it chooses. Clicks on buttons of messages
+// the provider didn't send to the clicking recipient, or of actions the message doesn't offer, are ignored.
+func (a *ActionUseCase) ReceiveInteraction(vendorName string, providerID int, header http.Header, body []byte) ([]byte, error) {
+	vendor, ok := a.vendors[vendorName]
+	if !ok {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	providerDetails, err := a.providerRepository.GetByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if providerDetails.Type != vendor.ProviderType {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+
+	choices, response, err := vendor.Parser.ParseInteraction(providerDetails.Config, header, body)
+	if err != nil {
+		a.Logger.Warn("Rejected interaction", zap.Error(err), zap.String("vendor", vendorName), zap.Int("providerID", providerID))
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+
+	for i := range choices {
+		choice := &choices[i]
+		msg, err := a.messageTransactionRepository.GetByID(choice.MessageTransactionID)
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if msg.ProviderID != providerID || !sentTo(msg, choice.Recipient) {
+			a.Logger.Warn("Interaction for a message the provider didn't send to the recipient", zap.Int("providerID", providerID),
+				zap.Int("messageID", msg.ID), zap.String("recipient", choice.Recipient))
+			continue
+		}
+		action, ok := findAction(decodeActions(msg), choice.ActionID)
+		if !ok {
+			continue
+		}
+		choice.Channel = vendor.ProviderType
+		choice.Via = provider.ActionViaButton
+		a.chosen(msg, action, choice)
+	}
+	return response, nil
+}
+
+// ChooseByReply chooses an action by the number a recipient replied with, the options of the latest message with
+// actions sent to them within the ReplyWindow are numbered from 1
+func (a *ActionUseCase) ChooseByReply(channel string, text string, recipient string, chosenBy string) (bool, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || number < 1 {
+		return false, nil
+	}
+	msg, err := a.messageTransactionRepository.GetLatestWithActionsForRecipient(recipient, time.Now().Add(-ReplyWindow))
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	actions := decodeActions(msg)
+	if number > len(actions) {
+		return false, nil
+	}
+	action := actions[number-1]
+	a.chosen(msg, action, &provider.ActionChoice{
+		MessageTransactionID: msg.ID,
+		ActionID:             action.ID,
+		Recipient:            recipient,
+		ChosenBy:             chosenBy,
+		Channel:              channel,
+		Via:                  provider.ActionViaReply,
+	})
+	return true, nil
+}
+
+// chosen tells the handler about an action chosen from a message
+func (a *ActionUseCase) chosen(msg *provider.MessageTransaction, action provider.MessageAction, choice *provider.ActionChoice) {
+	choice.Label = action.Label
+	choice.ChosenAt = time.Now()
+	a.Logger.Info("Message action chosen", zap.Int("messageID", msg.ID), zap.String("actionID", action.ID),
+		zap.String("recipient", choice.Recipient), zap.String("via", choice.Via))
+	if a.handler != nil {
+		a.handler(msg.UserID, choice)
+	}
+}
+
+// decodeActions reads the actions offered with a message
+func decodeActions(msg *provider.MessageTransaction) []provider.MessageAction {
+	var actions []provider.MessageAction
+	if msg.Actions != "" {
+		_ = json.Unmarshal([]byte(msg.Actions), &actions)
+	}
+	return actions
+}
+
+// findAction returns the action of the given ID
+func findAction(actions []provider.MessageAction, id string) (provider.MessageAction, bool) {
+	for _, action := range actions {
+		if action.ID == id {
+			return action, true
+		}
+	}
+	return provider.MessageAction{}, false
+}
+
+// sentTo reports whether a message was sent to the recipient
+func sentTo(msg *provider.MessageTransaction, recipient string) bool {
+	var recipients []string
+	if json.Unmarshal([]byte(msg.Recipients), &recipients) != nil {
+		return false
+	}
+	for _, r := range recipients {
+		if r == recipient {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package action
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testActions = `[{"id":"approve","label":"Approve"},{"id":"reject","label":"Reject"}]`
+
+// mockProviderRepository implements GetByID, the embedded interface panics on any other call
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []provider.Provider
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	for _, p := range m.providers {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockMessageTransactionRepository knows the messages by ID, the latest one with actions is the last one
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	messages []provider.MessageTransaction
+	since    time.Time
+}
+
+func (m *mockMessageTransactionRepository) GetByID(id int) (*provider.MessageTransaction, error) {
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			return &msg, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockMessageTransactionRepository) GetLatestWithActionsForRecipient(recipient string, since time.Time) (*provider.MessageTransaction, error) {
+	m.since = since
+	if len(m.messages) == 0 {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &m.messages[len(m.messages)-1], nil
+}
+
+type mockParser struct {
+	choices []provider.ActionChoice
+}
+
+func (m *mockParser) ParseInteraction(providerConfig string, header http.Header, body []byte) ([]provider.ActionChoice, []byte, error) {
+	return m.choices, []byte(`{"type":6}`), nil
+}
+
+type chosenAction struct {
+	userID int
+	choice provider.ActionChoice
+}
+
+func setupActionUseCase(parser InteractionParser, messages ...provider.MessageTransaction) (IActionUseCase, *mockMessageTransactionRepository, *[]chosenAction) {
+	var chosen []chosenAction
+	messageTransactionRepository := &mockMessageTransactionRepository{messages: messages}
+	useCase := NewActionUseCase(
+		&mockProviderRepository{providers: []provider.Provider{{ID: 3, Type: "discord"}, {ID: 4, Type: "sms"}}},
+		messageTransactionRepository,
+		map[string]Vendor{"discord": {ProviderType: "discord", Parser: parser}},
+		func(userID int, choice *provider.ActionChoice) {
+			chosen = append(chosen, chosenAction{userID: userID, choice: *choice})
+		},
+		&logger.Logger{Log: zap.NewNop()},
+	)
+	return useCase, messageTransactionRepository, &chosen
+}
+
+func TestReceiveInteraction_ReportsChosenActions(t *testing.T) {
+	parser := &mockParser{choices: []provider.ActionChoice{
+		{MessageTransactionID: 42, ActionID: "reject", Recipient: "123456789012345678", ChosenBy: "80351110224678912"},
+		// an action the message doesn't offer, a message of another provider and a message that doesn't exist
+		{MessageTransactionID: 42, ActionID: "escalate", Recipient: "123456789012345678"},
+		{MessageTransactionID: 43, ActionID: "approve", Recipient: "123456789012345678"},
+		{MessageTransactionID: 44, ActionID: "approve", Recipient: "123456789012345678"},
+	}}
+	useCase, _, chosen := setupActionUseCase(parser,
+		provider.MessageTransaction{ID: 42, UserID: 7, ProviderID: 3, Recipients: `["123456789012345678"]`, Actions: testActions},
+		provider.MessageTransaction{ID: 43, UserID: 7, ProviderID: 9, Recipients: `["123456789012345678"]`, Actions: testActions},
+	)
+
+	response, err := useCase.ReceiveInteraction("discord", 3, http.Header{}, []byte(`{}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":6}`, string(response))
+	require.Len(t, *chosen, 1)
+	choice := (*chosen)[0]
+	assert.Equal(t, 7, choice.userID)
+	assert.Equal(t, "Reject", choice.choice.Label)
+	assert.Equal(t, "discord", choice.choice.Channel)
+	assert.Equal(t, provider.ActionViaButton, choice.choice.Via)
+	assert.Equal(t, "80351110224678912", choice.choice.ChosenBy)
+	assert.False(t, choice.choice.ChosenAt.IsZero())
+}
+
+func TestReceiveInteraction_RejectsUnknownVendorsAndProviders(t *testing.T) {
+	useCase, _, _ := setupActionUseCase(&mockParser{})
+
+	for _, call := range []struct {
+		vendor     string
+		providerID int
+	}{{"slack", 3}, {"discord", 4}, {"discord", 5}} {
+		_, err := useCase.ReceiveInteraction(call.vendor, call.providerID, http.Header{}, []byte(`{}`))
+		assert.Error(t, err, call.vendor)
+	}
+}
+
+func TestChooseByReply(t *testing.T) {
+	useCase, repository, chosen := setupActionUseCase(&mockParser{},
+		provider.MessageTransaction{ID: 42, UserID: 7, ProviderID: 4, Recipients: `["+491111"]`, Actions: testActions})
+
+	for _, text := range []string{"ok", "0", "3", "-1", "1 2"} {
+		ok, err := useCase.ChooseByReply("sms", text, "+491111", "+491111")
+		require.NoError(t, err)
+		assert.False(t, ok, text)
+	}
+	assert.Empty(t, *chosen)
+
+	ok, err := useCase.ChooseByReply("sms", " 2\n", "+491111", "+491111")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(-ReplyWindow), repository.since, time.Minute)
+	require.Len(t, *chosen, 1)
+	assert.Equal(t, provider.ActionChoice{
+		MessageTransactionID: 42, ActionID: "reject", Label: "Reject", Recipient: "+491111", ChosenBy: "+491111",
+		Channel: "sms", Via: provider.ActionViaReply, ChosenAt: (*chosen)[0].choice.ChosenAt,
+	}, (*chosen)[0].choice)
+
+	// Without a message with actions the reply chooses nothing
+	useCase, _, _ = setupActionUseCase(&mockParser{})
+	ok, err = useCase.ChooseByReply("sms", "1", "+491111", "+491111")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
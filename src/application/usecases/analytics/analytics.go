@@ -0,0 +1,236 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRollupRange is the period reported when no range is given
+	defaultRollupRange = 30 * 24 * time.Hour
+	// maxRollupRange bounds the range of a rollup to keep the aggregation query cheap
+	maxRollupRange = 366 * 24 * time.Hour
+)
+
+// TagRollupRequest represents a request to aggregate delivery outcomes by tag value
+type TagRollupRequest struct {
+	UserID      int
+	TagKey      string
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+// TagRollupResponse holds the aggregated delivery outcomes of a tag key
+type TagRollupResponse struct {
+	TagKey      string
+	From        time.Time
+	To          time.Time
+	Granularity string
+	Buckets     []provider.TagDeliveryRollup
+}
+
+// Groups of the engagement report
+const (
+	EngagementGroupProvider = "provider"
+	EngagementGroupTag      = "tag"
+	// EngagementGroupCampaign groups by the campaign tag
+	EngagementGroupCampaign = "campaign"
+
+	campaignTagKey = "campaign"
+)
+
+// EngagementRequest represents a request to report the engagement of recipients with the messages of a user
+type EngagementRequest struct {
+	UserID  int
+	GroupBy string
+	TagKey  string // tag key the messages are grouped by, only for the tag group
+	From    time.Time
+	To      time.Time
+}
+
+// EngagementGroup holds the engagement of the recipients of the messages of a group and its rates, as fractions
+// of the recipients
+type EngagementGroup struct {
+	provider.EngagementRollup
+	DeliveryRate float64
+	ReadRate     float64
+	ClickRate    float64
+}
+
+// EngagementResponse holds the engagement of the recipients of a user's messages per group
+type EngagementResponse struct {
+	GroupBy string
+	TagKey  string
+	From    time.Time
+	To      time.Time
+	Groups  []EngagementGroup
+}
+
+// RecipientCapsRequest represents a request to report the sends of a user held or rejected by recipient caps
+type RecipientCapsRequest struct {
+	UserID int
+	From   time.Time
+	To     time.Time
+}
+
+// RecipientCapsResponse holds the capped sends of a user per recipient
+type RecipientCapsResponse struct {
+	From       time.Time
+	To         time.Time
+	Recipients []provider.RecipientCapRollup
+}
+
+// IAnalyticsUseCase defines the interface for delivery analytics use cases
+type IAnalyticsUseCase interface {
+	GetTagRollup(request *TagRollupRequest) (*TagRollupResponse, error)
+	GetEngagement(request *EngagementRequest) (*EngagementResponse, error)
+	GetRecipientCaps(request *RecipientCapsRequest) (*RecipientCapsResponse, error)
+}
+
+// AnalyticsUseCase implements the IAnalyticsUseCase interface
+type AnalyticsUseCase struct {
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	messageEngagementRepository         providerRepo.MessageEngagementRepositoryInterface
+	recipientCapViolationRepository     providerRepo.RecipientCapViolationRepositoryInterface
+	Logger                              *logger.Logger
+}
+
+// NewAnalyticsUseCase creates a new AnalyticsUseCase
+func NewAnalyticsUseCase(
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	messageEngagementRepository providerRepo.MessageEngagementRepositoryInterface,
+	recipientCapViolationRepository providerRepo.RecipientCapViolationRepositoryInterface,
+	loggerInstance *logger.Logger,
+) IAnalyticsUseCase {
+	return &AnalyticsUseCase{
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		messageEngagementRepository:         messageEngagementRepository,
+		recipientCapViolationRepository:     recipientCapViolationRepository,
+		Logger:                              loggerInstance,
+	}
+}
+
+// GetTagRollup reports delivery outcomes grouped by the values of a tag key. Without a range the last
+// 30 days up to now are reported, without a granularity the outcomes are grouped by day.
+func (a *AnalyticsUseCase) GetTagRollup(request *TagRollupRequest) (*TagRollupResponse, error) {
+	if request.TagKey == "" {
+		return nil, domainErrors.NewAppError(errors.New("tag key is required"), domainErrors.ValidationError)
+	}
+
+	granularity := request.Granularity
+	if granularity == "" {
+		granularity = providerRepo.RollupGranularityDay
+	}
+	switch granularity {
+	case providerRepo.RollupGranularityDay, providerRepo.RollupGranularityWeek, providerRepo.RollupGranularityTotal:
+	default:
+		return nil, domainErrors.NewAppError(fmt.Errorf("granularity must be %s, %s or %s",
+			providerRepo.RollupGranularityDay, providerRepo.RollupGranularityWeek, providerRepo.RollupGranularityTotal), domainErrors.ValidationError)
+	}
+
+	from, to, err := rollupRange(request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := a.messageTransactionHistoryRepository.GetUserTagRollup(request.UserID, request.TagKey, from, to, granularity)
+	if err != nil {
+		a.Logger.Error("Error getting tag rollup", zap.Error(err), zap.Int("userID", request.UserID), zap.String("tagKey", request.TagKey))
+		return nil, err
+	}
+
+	return &TagRollupResponse{
+		TagKey:      request.TagKey,
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Buckets:     *buckets,
+	}, nil
+}
+
+// GetEngagement reports how many recipients of the user's messages received, read and clicked them, grouped by
+// provider, by the values of a tag key or by campaign. Only the messages sent while the user tracked engagement
+// are counted. Without a range the last 30 days up to now are reported.
+func (a *AnalyticsUseCase) GetEngagement(request *EngagementRequest) (*EngagementResponse, error) {
+	groupBy, tagKey := providerRepo.EngagementGroupTag, request.TagKey
+	switch request.GroupBy {
+	case "", EngagementGroupProvider:
+		groupBy, tagKey = providerRepo.EngagementGroupProvider, ""
+	case EngagementGroupCampaign:
+		tagKey = campaignTagKey
+	case EngagementGroupTag:
+		if tagKey == "" {
+			return nil, domainErrors.NewAppError(errors.New("tag key is required to group by tag"), domainErrors.ValidationError)
+		}
+	default:
+		return nil, domainErrors.NewAppError(fmt.Errorf("group_by must be %s, %s or %s",
+			EngagementGroupProvider, EngagementGroupTag, EngagementGroupCampaign), domainErrors.ValidationError)
+	}
+	from, to, err := rollupRange(request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+
+	rollups, err := a.messageEngagementRepository.GetUserRollup(request.UserID, groupBy, tagKey, from, to)
+	if err != nil {
+		a.Logger.Error("Error getting engagement", zap.Error(err), zap.Int("userID", request.UserID), zap.String("groupBy", groupBy))
+		return nil, err
+	}
+
+	groups := make([]EngagementGroup, len(*rollups))
+	for i, rollup := range *rollups {
+		groups[i] = EngagementGroup{EngagementRollup: rollup}
+		if rollup.Recipients > 0 {
+			recipients := float64(rollup.Recipients)
+			groups[i].DeliveryRate = float64(rollup.Delivered) / recipients
+			groups[i].ReadRate = float64(rollup.Read) / recipients
+			groups[i].ClickRate = float64(rollup.Clicked) / recipients
+		}
+	}
+	groupName := request.GroupBy
+	if groupName == "" {
+		groupName = EngagementGroupProvider
+	}
+	return &EngagementResponse{GroupBy: groupName, TagKey: tagKey, From: from, To: to, Groups: groups}, nil
+}
+
+// GetRecipientCaps reports the recipients whose frequency caps held or rejected sends of the user, the
+// recipients capped most often first. Without a range the last 30 days up to now are reported.
+func (a *AnalyticsUseCase) GetRecipientCaps(request *RecipientCapsRequest) (*RecipientCapsResponse, error) {
+	from, to, err := rollupRange(request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := a.recipientCapViolationRepository.GetUserRollup(request.UserID, from, to)
+	if err != nil {
+		a.Logger.Error("Error getting recipient caps", zap.Error(err), zap.Int("userID", request.UserID))
+		return nil, err
+	}
+	return &RecipientCapsResponse{From: from, To: to, Recipients: *rollups}, nil
+}
+
+// rollupRange defaults and checks the range of a report, without a range the last 30 days up to now are reported
+func rollupRange(from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultRollupRange)
+	}
+	if !from.Before(to) {
+		return from, to, domainErrors.NewAppError(errors.New("from must be before to"), domainErrors.ValidationError)
+	}
+	if to.Sub(from) > maxRollupRange {
+		return from, to, domainErrors.NewAppError(errors.New("range must not exceed 366 days"), domainErrors.ValidationError)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,209 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHistoryRepository struct {
+	rollups     []provider.TagDeliveryRollup
+	err         error
+	from        time.Time
+	to          time.Time
+	granularity string
+}
+
+func (m *mockHistoryRepository) Create(history *provider.MessageTransactionHistory) (*provider.MessageTransactionHistory, error) {
+	return history, nil
+}
+
+func (m *mockHistoryRepository) CreateBatch(histories []provider.MessageTransactionHistory) error {
+	return nil
+}
+
+func (m *mockHistoryRepository) GetByID(id int) (*provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetByMessageID(messageID int) (*[]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserMessageTransactionHistory(userID int) (*[]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserDeliveryStats(userID int, from time.Time, to time.Time, topErrorLimit int) (*provider.DeliveryStats, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) SearchUserHistory(userID int, status string, tags map[string]string, page domain.PageRequest) (*domain.Page[provider.MessageTransactionHistory], error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserTagRollup(userID int, tagKey string, from time.Time, to time.Time, granularity string) (*[]provider.TagDeliveryRollup, error) {
+	m.from, m.to, m.granularity = from, to, granularity
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.rollups, nil
+}
+
+func (m *mockHistoryRepository) GetProviderTypeOutcomes(from time.Time) ([]provider.ProviderTypeOutcomes, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetAfter(afterID int, limit int) ([]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetOldestCreatedAt() (*time.Time, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) DeleteRange(firstID int, lastID int) (int64, error) {
+	return 0, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestGetTagRollup_Defaults(t *testing.T) {
+	repo := &mockHistoryRepository{rollups: []provider.TagDeliveryRollup{{TagValue: "spring-sale", Total: 3, Sent: 2, Failed: 1}}}
+	useCase := NewAnalyticsUseCase(repo, &mockEngagementRepository{}, &mockRecipientCapRepository{}, setupLogger(t))
+
+	response, err := useCase.GetTagRollup(&TagRollupRequest{UserID: 1, TagKey: "campaign"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "day", repo.granularity)
+	assert.Equal(t, defaultRollupRange, repo.to.Sub(repo.from))
+	assert.Equal(t, "campaign", response.TagKey)
+	assert.Len(t, response.Buckets, 1)
+	assert.Equal(t, 2, response.Buckets[0].Sent)
+}
+
+func TestGetTagRollup_ValidationErrors(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		request TagRollupRequest
+	}{
+		{"missing key", TagRollupRequest{UserID: 1}},
+		{"invalid granularity", TagRollupRequest{UserID: 1, TagKey: "campaign", Granularity: "hour"}},
+		{"from after to", TagRollupRequest{UserID: 1, TagKey: "campaign", From: now, To: now.Add(-time.Hour)}},
+		{"range too long", TagRollupRequest{UserID: 1, TagKey: "campaign", From: now.AddDate(-2, 0, 0), To: now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := NewAnalyticsUseCase(&mockHistoryRepository{}, &mockEngagementRepository{}, &mockRecipientCapRepository{}, setupLogger(t))
+
+			_, err := useCase.GetTagRollup(&tt.request)
+
+			var appErr *domainErrors.AppError
+			assert.True(t, errors.As(err, &appErr))
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+}
+
+func TestGetTagRollup_RepositoryError(t *testing.T) {
+	repo := &mockHistoryRepository{err: domainErrors.NewAppErrorWithType(domainErrors.UnknownError)}
+	useCase := NewAnalyticsUseCase(repo, &mockEngagementRepository{}, &mockRecipientCapRepository{}, setupLogger(t))
+
+	_, err := useCase.GetTagRollup(&TagRollupRequest{UserID: 1, TagKey: "campaign", Granularity: "week"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "week", repo.granularity)
+}
+
+type mockEngagementRepository struct {
+	providerRepo.MessageEngagementRepositoryInterface
+	rollups []provider.EngagementRollup
+	groupBy string
+	tagKey  string
+}
+
+func (m *mockEngagementRepository) GetUserRollup(userID int, groupBy string, tagKey string, from time.Time, to time.Time) (*[]provider.EngagementRollup, error) {
+	m.groupBy, m.tagKey = groupBy, tagKey
+	return &m.rollups, nil
+}
+
+func TestGetEngagement(t *testing.T) {
+	repo := &mockEngagementRepository{rollups: []provider.EngagementRollup{
+		{Group: "spring", Recipients: 4, Delivered: 4, Read: 2, Clicked: 1},
+		{Group: "summer"},
+	}}
+	useCase := NewAnalyticsUseCase(&mockHistoryRepository{}, repo, &mockRecipientCapRepository{}, setupLogger(t))
+
+	response, err := useCase.GetEngagement(&EngagementRequest{UserID: 1, GroupBy: "campaign"})
+	assert.NoError(t, err)
+	assert.Equal(t, "tag", repo.groupBy)
+	assert.Equal(t, "campaign", repo.tagKey)
+	assert.Equal(t, "campaign", response.GroupBy)
+	assert.Equal(t, defaultRollupRange, response.To.Sub(response.From))
+	assert.Equal(t, 1.0, response.Groups[0].DeliveryRate)
+	assert.Equal(t, 0.5, response.Groups[0].ReadRate)
+	assert.Equal(t, 0.25, response.Groups[0].ClickRate)
+	assert.Zero(t, response.Groups[1].ReadRate)
+
+	response, err = useCase.GetEngagement(&EngagementRequest{UserID: 1, TagKey: "team"})
+	assert.NoError(t, err)
+	assert.Equal(t, "provider", repo.groupBy)
+	assert.Empty(t, repo.tagKey)
+	assert.Equal(t, "provider", response.GroupBy)
+}
+
+func TestGetEngagement_Invalid(t *testing.T) {
+	useCase := NewAnalyticsUseCase(&mockHistoryRepository{}, &mockEngagementRepository{}, &mockRecipientCapRepository{}, setupLogger(t))
+	for _, request := range []EngagementRequest{
+		{GroupBy: "tag"},
+		{GroupBy: "recipient"},
+		{From: time.Now(), To: time.Now().Add(-time.Hour)},
+	} {
+		_, err := useCase.GetEngagement(&request)
+		var appErr *domainErrors.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	}
+}
+
+type mockRecipientCapRepository struct {
+	providerRepo.RecipientCapViolationRepositoryInterface
+	rollups []provider.RecipientCapRollup
+	from    time.Time
+	to      time.Time
+}
+
+func (m *mockRecipientCapRepository) GetUserRollup(userID int, from time.Time, to time.Time) (*[]provider.RecipientCapRollup, error) {
+	m.from, m.to = from, to
+	return &m.rollups, nil
+}
+
+func TestGetRecipientCaps(t *testing.T) {
+	repo := &mockRecipientCapRepository{rollups: []provider.RecipientCapRollup{{Recipient: "+4911", Held: 2, Rejected: 1}}}
+	useCase := NewAnalyticsUseCase(&mockHistoryRepository{}, &mockEngagementRepository{}, repo, setupLogger(t))
+
+	response, err := useCase.GetRecipientCaps(&RecipientCapsRequest{UserID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRollupRange, repo.to.Sub(repo.from))
+	assert.Equal(t, repo.rollups, response.Recipients)
+
+	_, err = useCase.GetRecipientCaps(&RecipientCapsRequest{UserID: 1, From: time.Now(), To: time.Now().Add(-time.Hour)})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
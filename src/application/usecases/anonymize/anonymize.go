@@ -0,0 +1,83 @@
+package anonymize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/anonymize"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// JobType is the type of the jobs anonymizing the database
+const JobType = "anonymize"
+
+// Anonymizer anonymizes the database in place
+type Anonymizer interface {
+	DatabaseName() string
+	Run(ctx context.Context, report func(percent int, result *anonymize.Result)) (*anonymize.Result, error)
+}
+
+// Payload is the payload of an anonymize job
+type Payload struct {
+	Database string `json:"database"`
+}
+
+// IAnonymizeUseCase defines the interface for anonymizing a copy of a production database
+type IAnonymizeUseCase interface {
+	// Start queues an anonymize job, confirm has to be the name of the database
+	Start(createdBy int, confirm string) (*provider.Job, error)
+	// Run is the job handler anonymizing the database
+	Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+}
+
+// AnonymizeUseCase implements the IAnonymizeUseCase interface
+type AnonymizeUseCase struct {
+	jobQueue   jobs.Queue
+	anonymizer Anonymizer
+	enabled    bool
+	Logger     *logger.Logger
+}
+
+// NewAnonymizeUseCase creates a new AnonymizeUseCase, jobs are only started when enabled
+func NewAnonymizeUseCase(jobQueue jobs.Queue, anonymizer Anonymizer, enabled bool, loggerInstance *logger.Logger) IAnonymizeUseCase {
+	return &AnonymizeUseCase{
+		jobQueue:   jobQueue,
+		anonymizer: anonymizer,
+		enabled:    enabled,
+		Logger:     loggerInstance,
+	}
+}
+
+func (u *AnonymizeUseCase) Start(createdBy int, confirm string) (*provider.Job, error) {
+	if !u.enabled {
+		return nil, domainErrors.NewAppError(errors.New("anonymizing is disabled, set ANONYMIZE_ENABLED on the staging environment"), domainErrors.NotAuthorized)
+	}
+	database := u.anonymizer.DatabaseName()
+	if confirm != database {
+		return nil, domainErrors.NewAppError(fmt.Errorf("confirm must be the name of the database, %s", database), domainErrors.ValidationError)
+	}
+	job, err := u.jobQueue.Enqueue(JobType, Payload{Database: database}, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Warn("Queued database anonymization", zap.Int("jobID", job.ID), zap.String("database", database), zap.Int("createdBy", createdBy))
+	return job, nil
+}
+
+// Run anonymizes the tables of the database one after the other, reporting the rows anonymized so far
+func (u *AnonymizeUseCase) Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	result, err := u.anonymizer.Run(ctx, func(percent int, result *anonymize.Result) {
+		progress.Report(percent, result)
+	})
+	if err != nil {
+		return result, err
+	}
+	u.Logger.Info("Anonymized database", zap.Int("jobID", job.ID), zap.Int64("rows", result.Rows), zap.Strings("skipped", result.Skipped))
+	return result, nil
+}
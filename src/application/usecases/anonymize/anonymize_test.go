@@ -0,0 +1,71 @@
+package anonymize
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/anonymize"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockJobQueue struct {
+	payloads []interface{}
+}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	m.payloads = append(m.payloads, payload)
+	return &provider.Job{ID: 1, Type: jobType, Status: "queued", CreatedBy: createdBy}, nil
+}
+
+type mockAnonymizer struct{}
+
+func (m *mockAnonymizer) DatabaseName() string {
+	return "staging_clone"
+}
+
+func (m *mockAnonymizer) Run(ctx context.Context, report func(percent int, result *anonymize.Result)) (*anonymize.Result, error) {
+	return &anonymize.Result{Tables: map[string]int64{"users": 2}, Rows: 2}, nil
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		confirm  string
+		wantType domainErrors.ErrorType
+	}{
+		{"disabled", false, "staging_clone", domainErrors.NotAuthorized},
+		{"wrong database", true, "production", domainErrors.ValidationError},
+		{"missing confirmation", true, "", domainErrors.ValidationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &mockJobQueue{}
+			useCase := NewAnonymizeUseCase(queue, &mockAnonymizer{}, tt.enabled, &logger.Logger{Log: zap.NewNop()})
+
+			_, err := useCase.Start(1, tt.confirm)
+			var appErr *domainErrors.AppError
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, tt.wantType, appErr.Type)
+			assert.Empty(t, queue.payloads)
+		})
+	}
+}
+
+func TestStart_QueuesJobForConfirmedDatabase(t *testing.T) {
+	queue := &mockJobQueue{}
+	useCase := NewAnonymizeUseCase(queue, &mockAnonymizer{}, true, &logger.Logger{Log: zap.NewNop()})
+
+	job, err := useCase.Start(7, "staging_clone")
+	require.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+	assert.Equal(t, 7, job.CreatedBy)
+	assert.Equal(t, []interface{}{Payload{Database: "staging_clone"}}, queue.payloads)
+}
@@ -0,0 +1,312 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/archive"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/payload"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// JobType is the type of the jobs archiving the message history
+const JobType = "message_archive"
+
+// deleteBatchLimit is the number of archived batches whose entries are deleted per pass
+const deleteBatchLimit = 100
+
+// Where the history entries of a range are kept
+const (
+	// LocationWarm entries are queried from the database
+	LocationWarm = "warm"
+	// LocationCold entries are only in the archived Parquet files
+	LocationCold = "cold"
+	// LocationSplit ranges start in the archived files and end in the database
+	LocationSplit = "split"
+)
+
+// Result reports what an archive job archived
+type Result struct {
+	Cutoff   time.Time `json:"cutoff"`   // entries created before it were archived
+	Files    int       `json:"files"`    // files written
+	Archived int       `json:"archived"` // entries written to the files
+	Deleted  int64     `json:"deleted"`  // archived entries deleted from the database
+}
+
+// Location tells where the history entries created in a range are kept
+type Location struct {
+	Location string
+	// WarmFrom is when the oldest entry still in the database was created, nil without entries
+	WarmFrom *time.Time
+	// ColdFrom and ColdUntil bound the days archived to cold storage, nil when nothing was archived
+	ColdFrom  *time.Time
+	ColdUntil *time.Time
+	// Files is the number of archived files holding entries of the range
+	Files int64
+}
+
+// IArchiveUseCase defines the interface for archiving the message history to cold storage
+type IArchiveUseCase interface {
+	// Start queues an archive job
+	Start(createdBy int) (*provider.Job, error)
+	// Run is the job handler archiving the entries older than the configured age
+	Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+	// GetBatches returns the archived files of the days from through to, newest first. Zero times leave the
+	// range open.
+	GetBatches(from time.Time, to time.Time, limit int) ([]provider.MessageArchiveBatch, error)
+	// Locate tells whether the entries created from from until to are in the database or in cold storage
+	Locate(from time.Time, to time.Time) (*Location, error)
+}
+
+// ArchiveUseCase implements the IArchiveUseCase interface
+type ArchiveUseCase struct {
+	jobQueue          jobs.Queue
+	historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	batchRepository   providerRepo.MessageArchiveBatchRepositoryInterface
+	target            payload.Store
+	config            archive.Config
+	Logger            *logger.Logger
+	now               func() time.Time
+}
+
+// NewArchiveUseCase creates a new ArchiveUseCase, target is nil when the archive isn't configured
+func NewArchiveUseCase(jobQueue jobs.Queue, historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	batchRepository providerRepo.MessageArchiveBatchRepositoryInterface, target payload.Store, config archive.Config,
+	loggerInstance *logger.Logger) IArchiveUseCase {
+	return &ArchiveUseCase{
+		jobQueue:          jobQueue,
+		historyRepository: historyRepository,
+		batchRepository:   batchRepository,
+		target:            target,
+		config:            config,
+		Logger:            loggerInstance,
+		now:               time.Now,
+	}
+}
+
+func (a *ArchiveUseCase) Start(createdBy int) (*provider.Job, error) {
+	if a.target == nil {
+		return nil, domainErrors.NewAppError(errors.New("the message archive is not configured, set ARCHIVE_TARGET"), domainErrors.ValidationError)
+	}
+	job, err := a.jobQueue.Enqueue(JobType, nil, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	a.Logger.Info("Queued message archive", zap.Int("jobID", job.ID), zap.String("target", a.config.Target))
+	return job, nil
+}
+
+// Run archives the history entries created before the cutoff day, in ID order from the last archived entry on.
+// History entries are copies of messages that left the queue and never change, so every entry before the cutoff
+// is closed. The entries are written as one Parquet file per run of entries of the same day; a file is written
+// before it is recorded as the new checkpoint, so a failed archive is resumed by the next one and the entries of
+// a file written but not recorded are written again. The archival stops at the first entry newer than the cutoff,
+// which keeps the archived ID ranges free of gaps. With deletion enabled the entries of every recorded
+// file are deleted afterwards, including those archived while it was disabled.
+func (a *ArchiveUseCase) Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	if a.target == nil {
+		return nil, jobs.Permanent(errors.New("the message archive is not configured"))
+	}
+	result := &Result{Cutoff: a.config.Cutoff(a.now())}
+	checkpoint, err := a.batchRepository.GetCheckpoint()
+	if err != nil {
+		return result, err
+	}
+	afterID := 0
+	if checkpoint != nil {
+		afterID = checkpoint.LastID
+	}
+
+	var start time.Time
+	for ctx.Err() == nil {
+		entries, err := a.historyRepository.GetAfter(afterID, a.config.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		closed := closedEntries(entries, result.Cutoff)
+		if len(closed) == 0 {
+			break
+		}
+		if start.IsZero() {
+			start = closed[0].CreatedAt
+		}
+		for _, day := range splitByDay(closed) {
+			if err := a.archiveDay(day, result); err != nil {
+				return result, err
+			}
+		}
+		afterID = closed[len(closed)-1].ID
+		progress.Report(percentDone(start, closed[len(closed)-1].CreatedAt, result.Cutoff), result)
+		if len(closed) < len(entries) || len(entries) < a.config.BatchSize {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if a.config.Delete {
+		if err := a.deleteArchived(ctx, result); err != nil {
+			return result, err
+		}
+	}
+	a.Logger.Info("Archived message history", zap.Int("jobID", job.ID), zap.Time("cutoff", result.Cutoff),
+		zap.Int("files", result.Files), zap.Int("archived", result.Archived), zap.Int64("deleted", result.Deleted))
+	return result, nil
+}
+
+// archiveDay writes the entries of a day to a file and records it as the checkpoint
+func (a *ArchiveUseCase) archiveDay(entries []provider.MessageTransactionHistory, result *Result) error {
+	rows := make([][]interface{}, len(entries))
+	for i, entry := range entries {
+		rows[i] = archive.HistoryRow(entry)
+	}
+	content, err := archive.EncodeParquet(archive.HistoryColumns, rows)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	first, last := entries[0].ID, entries[len(entries)-1].ID
+	day := entries[0].CreatedAt.UTC().Truncate(24 * time.Hour)
+	location, err := a.target.Put(archive.Key(a.config.Prefix, a.config.Org, day, first, last), content)
+	if err != nil {
+		a.Logger.Error("Error writing message archive file", zap.Error(err), zap.Int("firstID", first))
+		return err
+	}
+	if _, err := a.batchRepository.Create(&provider.MessageArchiveBatch{
+		Org:      a.config.Org,
+		Day:      day,
+		Target:   a.config.Target,
+		FirstID:  first,
+		LastID:   last,
+		Records:  len(entries),
+		Location: location,
+	}); err != nil {
+		return err
+	}
+	result.Files++
+	result.Archived += len(entries)
+	return nil
+}
+
+// deleteArchived deletes the entries of the archived files from the database, oldest file first
+func (a *ArchiveUseCase) deleteArchived(ctx context.Context, result *Result) error {
+	for ctx.Err() == nil {
+		batches, err := a.batchRepository.GetUndeleted(deleteBatchLimit)
+		if err != nil {
+			return err
+		}
+		for _, batch := range batches {
+			deleted, err := a.historyRepository.DeleteRange(batch.FirstID, batch.LastID)
+			if err != nil {
+				return err
+			}
+			if err := a.batchRepository.MarkDeleted(batch.ID); err != nil {
+				return err
+			}
+			result.Deleted += deleted
+		}
+		if len(batches) < deleteBatchLimit {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (a *ArchiveUseCase) GetBatches(from time.Time, to time.Time, limit int) ([]provider.MessageArchiveBatch, error) {
+	return a.batchRepository.GetBetween(from, to, limit)
+}
+
+// Locate compares a range with the days archived and the oldest entry left in the database. A range is warm
+// when the database holds all of it, which is the case for archived days whose entries weren't deleted.
+func (a *ArchiveUseCase) Locate(from time.Time, to time.Time) (*Location, error) {
+	if from.IsZero() || to.IsZero() || !to.After(from) {
+		return nil, domainErrors.NewAppError(errors.New("from and to are required and to must be after from"), domainErrors.ValidationError)
+	}
+	warmFrom, err := a.historyRepository.GetOldestCreatedAt()
+	if err != nil {
+		return nil, err
+	}
+	first, err := a.batchRepository.GetFirst()
+	if err != nil {
+		return nil, err
+	}
+	last, err := a.batchRepository.GetCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	location := &Location{Location: LocationWarm, WarmFrom: warmFrom}
+	inWarm := warmFrom != nil && to.After(*warmFrom)
+	inCold := false
+	if first != nil && last != nil {
+		coldFrom, coldUntil := first.Day, last.Day.AddDate(0, 0, 1)
+		location.ColdFrom, location.ColdUntil = &coldFrom, &coldUntil
+		inCold = from.Before(coldUntil) && to.After(coldFrom)
+	}
+	switch {
+	case inWarm && (!inCold || !from.Before(*warmFrom)):
+		return location, nil
+	case inCold && inWarm:
+		location.Location = LocationSplit
+	case inCold:
+		location.Location = LocationCold
+	default:
+		// Nothing of the range was archived, the database answers even if it holds nothing of it
+		return location, nil
+	}
+
+	// The last day of the range is included unless the range ends at its start
+	location.Files, err = a.batchRepository.CountBetween(from, to.Add(-time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// closedEntries returns the entries up to the first one created on or after the cutoff
+func closedEntries(entries []provider.MessageTransactionHistory, cutoff time.Time) []provider.MessageTransactionHistory {
+	for i, entry := range entries {
+		if !entry.CreatedAt.Before(cutoff) {
+			return entries[:i]
+		}
+	}
+	return entries
+}
+
+// splitByDay splits entries in ID order into runs of entries created on the same UTC day
+func splitByDay(entries []provider.MessageTransactionHistory) [][]provider.MessageTransactionHistory {
+	var days [][]provider.MessageTransactionHistory
+	start := 0
+	for i := 1; i <= len(entries); i++ {
+		if i == len(entries) || !sameDay(entries[i].CreatedAt, entries[start].CreatedAt) {
+			days = append(days, entries[start:i])
+			start = i
+		}
+	}
+	return days
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	return a.UTC().Truncate(24 * time.Hour).Equal(b.UTC().Truncate(24 * time.Hour))
+}
+
+// percentDone estimates the progress of an archive from the age of the last archived entry
+func percentDone(start time.Time, current time.Time, cutoff time.Time) int {
+	total := cutoff.Sub(start)
+	if total <= 0 {
+		return 99
+	}
+	percent := int(current.Sub(start) * 100 / total)
+	if percent > 99 {
+		return 99
+	}
+	return percent
+}
@@ -0,0 +1,229 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/archive"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockJobQueue struct{}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	return &provider.Job{ID: 1, Type: jobType, CreatedBy: createdBy}, nil
+}
+
+type mockHistoryRepository struct {
+	providerRepo.MessageTransactionHistoryRepositoryInterface
+	entries []provider.MessageTransactionHistory
+}
+
+func (m *mockHistoryRepository) GetAfter(afterID int, limit int) ([]provider.MessageTransactionHistory, error) {
+	var entries []provider.MessageTransactionHistory
+	for _, entry := range m.entries {
+		if entry.ID > afterID && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockHistoryRepository) GetOldestCreatedAt() (*time.Time, error) {
+	if len(m.entries) == 0 {
+		return nil, nil
+	}
+	return &m.entries[0].CreatedAt, nil
+}
+
+func (m *mockHistoryRepository) DeleteRange(firstID int, lastID int) (int64, error) {
+	var kept []provider.MessageTransactionHistory
+	for _, entry := range m.entries {
+		if entry.ID < firstID || entry.ID > lastID {
+			kept = append(kept, entry)
+		}
+	}
+	deleted := int64(len(m.entries) - len(kept))
+	m.entries = kept
+	return deleted, nil
+}
+
+type mockBatchRepository struct {
+	providerRepo.MessageArchiveBatchRepositoryInterface
+	batches []provider.MessageArchiveBatch
+}
+
+func (m *mockBatchRepository) Create(batch *provider.MessageArchiveBatch) (*provider.MessageArchiveBatch, error) {
+	batch.ID = len(m.batches) + 1
+	m.batches = append(m.batches, *batch)
+	return batch, nil
+}
+
+func (m *mockBatchRepository) GetCheckpoint() (*provider.MessageArchiveBatch, error) {
+	if len(m.batches) == 0 {
+		return nil, nil
+	}
+	return &m.batches[len(m.batches)-1], nil
+}
+
+func (m *mockBatchRepository) GetFirst() (*provider.MessageArchiveBatch, error) {
+	if len(m.batches) == 0 {
+		return nil, nil
+	}
+	return &m.batches[0], nil
+}
+
+func (m *mockBatchRepository) CountBetween(from time.Time, to time.Time) (int64, error) {
+	var count int64
+	for _, batch := range m.batches {
+		if !batch.Day.Before(from.Truncate(24*time.Hour)) && !batch.Day.After(to) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockBatchRepository) GetUndeleted(limit int) ([]provider.MessageArchiveBatch, error) {
+	var batches []provider.MessageArchiveBatch
+	for _, batch := range m.batches {
+		if !batch.Deleted && len(batches) < limit {
+			batches = append(batches, batch)
+		}
+	}
+	return batches, nil
+}
+
+func (m *mockBatchRepository) MarkDeleted(id int) error {
+	m.batches[id-1].Deleted = true
+	return nil
+}
+
+// mockTarget keeps the written files by key, it fails once after the given number of writes
+type mockTarget struct {
+	written map[string][]byte
+	failAt  int
+}
+
+func (m *mockTarget) Put(key string, data []byte) (string, error) {
+	if m.failAt > 0 && len(m.written) == m.failAt {
+		m.failAt = 0
+		return "", errors.New("connection refused")
+	}
+	m.written[key] = data
+	return "s3://archive/" + key, nil
+}
+
+var testNow = time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+// historyEntries returns entries created at the given hours before testNow
+func historyEntries(hoursAgo ...int) []provider.MessageTransactionHistory {
+	entries := make([]provider.MessageTransactionHistory, len(hoursAgo))
+	for i, hours := range hoursAgo {
+		created := testNow.Add(-time.Duration(hours) * time.Hour)
+		entries[i] = provider.MessageTransactionHistory{ID: i + 1, MessageID: i + 1, UserID: 1, Status: "success", CreatedAt: created, UpdatedAt: created}
+	}
+	return entries
+}
+
+func newUseCase(history *mockHistoryRepository, batches *mockBatchRepository, target *mockTarget, deleteArchived bool) *ArchiveUseCase {
+	config := archive.Config{Target: archive.TargetS3, AfterDays: 2, Delete: deleteArchived, BatchSize: 2, Prefix: "archive", Org: "acme"}
+	useCase := NewArchiveUseCase(&mockJobQueue{}, history, batches, target, config, &logger.Logger{Log: zap.NewNop()}).(*ArchiveUseCase)
+	useCase.now = func() time.Time { return testNow }
+	return useCase
+}
+
+func TestRun_ArchivesClosedEntriesPerDay(t *testing.T) {
+	// Cutoff is 2026-10-14, the last entry is newer and stops the archive
+	history := &mockHistoryRepository{entries: historyEntries(100, 99, 80, 70, 10)}
+	batches, target := &mockBatchRepository{}, &mockTarget{written: map[string][]byte{}}
+	useCase := newUseCase(history, batches, target, false)
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Cutoff: time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC), Files: 2, Archived: 4}, result)
+	require.Len(t, batches.batches, 2)
+	assert.Equal(t, []int{1, 2}, []int{batches.batches[0].FirstID, batches.batches[0].LastID})
+	assert.Equal(t, []int{3, 4}, []int{batches.batches[1].FirstID, batches.batches[1].LastID})
+	assert.Contains(t, target.written, "archive/message_history/org=acme/date=2026-10-12/0000000001-0000000002.parquet")
+	assert.Contains(t, target.written, "archive/message_history/org=acme/date=2026-10-13/0000000003-0000000004.parquet")
+	assert.Len(t, history.entries, 5)
+
+	// A later run starts from the checkpoint and finds nothing new before the cutoff
+	result, err = useCase.Run(context.Background(), &provider.Job{ID: 2}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.(*Result).Files)
+}
+
+func TestRun_ResumesAfterFailedWrite(t *testing.T) {
+	history := &mockHistoryRepository{entries: historyEntries(100, 99, 98, 97)}
+	batches, target := &mockBatchRepository{}, &mockTarget{written: map[string][]byte{}, failAt: 1}
+	useCase := newUseCase(history, batches, target, false)
+
+	_, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.Error(t, err)
+	require.Len(t, batches.batches, 1)
+
+	_, err = useCase.Run(context.Background(), &provider.Job{ID: 2}, nil)
+	require.NoError(t, err)
+	require.Len(t, batches.batches, 2)
+	assert.Equal(t, 3, batches.batches[1].FirstID)
+	assert.Equal(t, 4, batches.batches[1].LastID)
+}
+
+func TestRun_DeletesArchivedEntries(t *testing.T) {
+	history := &mockHistoryRepository{entries: historyEntries(100, 99, 10)}
+	batches, target := &mockBatchRepository{}, &mockTarget{written: map[string][]byte{}}
+	useCase := newUseCase(history, batches, target, true)
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.(*Result).Deleted)
+	require.Len(t, history.entries, 1)
+	assert.Equal(t, 3, history.entries[0].ID)
+	assert.True(t, batches.batches[0].Deleted)
+}
+
+func TestLocate(t *testing.T) {
+	history := &mockHistoryRepository{entries: historyEntries(100, 99, 10)}
+	batches, target := &mockBatchRepository{}, &mockTarget{written: map[string][]byte{}}
+	useCase := newUseCase(history, batches, target, true)
+
+	// Nothing archived yet, the database holds everything
+	location, err := useCase.Locate(testNow.AddDate(0, 0, -30), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, LocationWarm, location.Location)
+	assert.Nil(t, location.ColdFrom)
+
+	_, err = useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+
+	location, err = useCase.Locate(testNow.AddDate(0, 0, -5), testNow.AddDate(0, 0, -3))
+	require.NoError(t, err)
+	assert.Equal(t, LocationCold, location.Location)
+	assert.Equal(t, time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), *location.ColdFrom)
+	assert.Equal(t, time.Date(2026, time.October, 13, 0, 0, 0, 0, time.UTC), *location.ColdUntil)
+	assert.Equal(t, int64(1), location.Files)
+
+	location, err = useCase.Locate(testNow.AddDate(0, 0, -5), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, LocationSplit, location.Location)
+
+	location, err = useCase.Locate(testNow.Add(-time.Hour), testNow)
+	require.NoError(t, err)
+	assert.Equal(t, LocationWarm, location.Location)
+	assert.Equal(t, int64(0), location.Files)
+
+	_, err = useCase.Locate(testNow, testNow.Add(-time.Hour))
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
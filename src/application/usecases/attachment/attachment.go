@@ -0,0 +1,236 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/attachment"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// Store keeps the parts and the assembled files of the attachments
+type Store interface {
+	WritePart(id int, number int, body io.Reader, size int64) error
+	Parts(id int) (map[int]bool, error)
+	Assemble(id int, count int) (string, error)
+	Read(id int) ([]byte, error)
+	Remove(id int) error
+}
+
+// StartRequest declares an attachment before its parts are uploaded
+type StartRequest struct {
+	Filename string
+	MimeType string
+	Size     int64
+	// SHA256 is the hex digest of the file, the assembled file is checked against it when set
+	SHA256 string
+}
+
+// IAttachmentUseCase defines the interface for uploading attachments in parts and sending them by reference
+type IAttachmentUseCase interface {
+	// Start creates an upload, its parts are uploaded in any order and resent when their upload failed
+	Start(userID int, request StartRequest) (*provider.Attachment, error)
+	UploadPart(userID int, id int, number int, body io.Reader) error
+	// Get returns an attachment of the user with the numbers of the parts received so far
+	Get(userID int, id int) (*provider.Attachment, []int, error)
+	// Complete assembles the parts once they're all uploaded, the attachment can be sent from then on
+	Complete(userID int, id int) (*provider.Attachment, error)
+	// Abort deletes an attachment and its parts
+	Abort(userID int, id int) error
+	// Load returns a complete attachment of the user with its content
+	Load(userID int, id int) (*provider.Attachment, []byte, error)
+}
+
+// AttachmentUseCase implements the IAttachmentUseCase interface
+type AttachmentUseCase struct {
+	attachmentRepository providerRepo.AttachmentRepositoryInterface
+	store                Store
+	config               attachment.Config
+	Logger               *logger.Logger
+	now                  func() time.Time
+}
+
+// NewAttachmentUseCase creates a new AttachmentUseCase
+func NewAttachmentUseCase(attachmentRepository providerRepo.AttachmentRepositoryInterface, store Store, config attachment.Config, loggerInstance *logger.Logger) IAttachmentUseCase {
+	return &AttachmentUseCase{
+		attachmentRepository: attachmentRepository,
+		store:                store,
+		config:               config,
+		Logger:               loggerInstance,
+		now:                  time.Now,
+	}
+}
+
+func (a *AttachmentUseCase) Start(userID int, request StartRequest) (*provider.Attachment, error) {
+	if request.Size <= 0 || request.Size > a.config.MaxBytes {
+		return nil, domainErrors.NewAppError(fmt.Errorf("size must be between 1 byte and %d MiB", a.config.MaxBytes>>20), domainErrors.ValidationError)
+	}
+	filename := filepath.Base(strings.ReplaceAll(request.Filename, `\`, "/"))
+	if filename == "." || filename == "/" || strings.ContainsAny(filename, ";,") {
+		return nil, domainErrors.NewAppError(errors.New("filename must be a file name without ; or ,"), domainErrors.ValidationError)
+	}
+	if strings.ContainsAny(request.MimeType, ";, ") || !strings.Contains(request.MimeType, "/") {
+		return nil, domainErrors.NewAppError(errors.New("mime_type must be a MIME type like image/png"), domainErrors.ValidationError)
+	}
+
+	created, err := a.attachmentRepository.Create(&provider.Attachment{
+		UserID:    userID,
+		Filename:  filename,
+		MimeType:  strings.ToLower(request.MimeType),
+		Size:      request.Size,
+		ChunkSize: a.config.ChunkBytes,
+		SHA256:    strings.ToLower(request.SHA256),
+		Status:    providerRepo.AttachmentStatusUploading,
+		ExpiresAt: a.now().Add(a.config.UploadExpiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.Logger.Info("Started attachment upload", zap.Int("attachmentID", created.ID), zap.Int("userID", userID),
+		zap.Int64("size", created.Size), zap.Int("parts", created.Parts()))
+	return created, nil
+}
+
+func (a *AttachmentUseCase) UploadPart(userID int, id int, number int, body io.Reader) error {
+	upload, err := a.getUpload(userID, id)
+	if err != nil {
+		return err
+	}
+	if number < 1 || number > upload.Parts() {
+		return domainErrors.NewAppError(fmt.Errorf("part must be between 1 and %d", upload.Parts()), domainErrors.ValidationError)
+	}
+	size := upload.PartSize(number)
+	if err := a.store.WritePart(id, number, body, size); err != nil {
+		if errors.Is(err, attachment.ErrPartSize) {
+			return domainErrors.NewAppError(fmt.Errorf("part %d must be %d bytes", number, size), domainErrors.ValidationError)
+		}
+		a.Logger.Error("Error storing attachment part", zap.Error(err), zap.Int("attachmentID", id), zap.Int("part", number))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (a *AttachmentUseCase) Get(userID int, id int) (*provider.Attachment, []int, error) {
+	found, err := a.get(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found.Status != providerRepo.AttachmentStatusUploading {
+		return found, nil, nil
+	}
+	parts, err := a.store.Parts(id)
+	if err != nil {
+		a.Logger.Error("Error listing attachment parts", zap.Error(err), zap.Int("attachmentID", id))
+		return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	received := make([]int, 0, len(parts))
+	for number := range parts {
+		received = append(received, number)
+	}
+	sort.Ints(received)
+	return found, received, nil
+}
+
+// Complete assembles the parts and checks the file against the digest declared by the client. A file not matching
+// it is deleted, the client uploads it again.
+func (a *AttachmentUseCase) Complete(userID int, id int) (*provider.Attachment, error) {
+	upload, err := a.getUpload(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := a.store.Parts(id)
+	if err != nil {
+		a.Logger.Error("Error listing attachment parts", zap.Error(err), zap.Int("attachmentID", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if missing := upload.Parts() - len(parts); missing > 0 {
+		return nil, domainErrors.NewAppError(fmt.Errorf("%d of %d parts are missing", missing, upload.Parts()), domainErrors.ValidationError)
+	}
+
+	digest, err := a.store.Assemble(id, upload.Parts())
+	if err != nil {
+		a.Logger.Error("Error assembling attachment", zap.Error(err), zap.Int("attachmentID", id))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if upload.SHA256 != "" && upload.SHA256 != digest {
+		a.Logger.Warn("Attachment doesn't match its digest", zap.Int("attachmentID", id), zap.String("expected", upload.SHA256), zap.String("actual", digest))
+		if err := a.remove(id); err != nil {
+			return nil, err
+		}
+		return nil, domainErrors.NewAppError(errors.New("the uploaded file doesn't match sha256, upload it again"), domainErrors.ValidationError)
+	}
+	completed, err := a.attachmentRepository.Complete(id, digest, a.now().Add(a.config.Retention))
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		return nil, domainErrors.NewAppError(errors.New("the attachment was already completed"), domainErrors.Conflict)
+	}
+	a.Logger.Info("Completed attachment upload", zap.Int("attachmentID", id), zap.Int("userID", userID))
+	return a.attachmentRepository.GetByID(id)
+}
+
+func (a *AttachmentUseCase) Abort(userID int, id int) error {
+	if _, err := a.get(userID, id); err != nil {
+		return err
+	}
+	return a.remove(id)
+}
+
+func (a *AttachmentUseCase) Load(userID int, id int) (*provider.Attachment, []byte, error) {
+	found, err := a.get(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found.Status != providerRepo.AttachmentStatusComplete {
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("attachment %d isn't complete", id), domainErrors.ValidationError)
+	}
+	data, err := a.store.Read(id)
+	if err != nil {
+		a.Logger.Error("Error reading attachment", zap.Error(err), zap.Int("attachmentID", id))
+		return nil, nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return found, data, nil
+}
+
+// get returns an attachment of the user, attachments of other users and expired attachments are not found
+func (a *AttachmentUseCase) get(userID int, id int) (*provider.Attachment, error) {
+	found, err := a.attachmentRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if found.UserID != userID || !found.ExpiresAt.After(a.now()) {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return found, nil
+}
+
+// getUpload returns an attachment of the user that is still uploading
+func (a *AttachmentUseCase) getUpload(userID int, id int) (*provider.Attachment, error) {
+	found, err := a.get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if found.Status != providerRepo.AttachmentStatusUploading {
+		return nil, domainErrors.NewAppError(errors.New("the attachment was already completed"), domainErrors.Conflict)
+	}
+	return found, nil
+}
+
+func (a *AttachmentUseCase) remove(id int) error {
+	if err := a.store.Remove(id); err != nil {
+		a.Logger.Error("Error removing attachment files", zap.Error(err), zap.Int("attachmentID", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return a.attachmentRepository.Delete(id)
+}
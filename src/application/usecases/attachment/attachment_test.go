@@ -0,0 +1,146 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/attachment"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAttachmentRepository struct {
+	attachments map[int]*provider.Attachment
+}
+
+func (m *mockAttachmentRepository) Create(attachment *provider.Attachment) (*provider.Attachment, error) {
+	attachment.ID = len(m.attachments) + 1
+	m.attachments[attachment.ID] = attachment
+	return attachment, nil
+}
+
+func (m *mockAttachmentRepository) GetByID(id int) (*provider.Attachment, error) {
+	if attachment, ok := m.attachments[id]; ok {
+		found := *attachment
+		return &found, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockAttachmentRepository) Complete(id int, sha256 string, expiresAt time.Time) (bool, error) {
+	attachment := m.attachments[id]
+	if attachment.Status != providerRepo.AttachmentStatusUploading {
+		return false, nil
+	}
+	attachment.Status, attachment.SHA256, attachment.ExpiresAt = providerRepo.AttachmentStatusComplete, sha256, expiresAt
+	return true, nil
+}
+
+func (m *mockAttachmentRepository) Delete(id int) error {
+	delete(m.attachments, id)
+	return nil
+}
+
+func (m *mockAttachmentRepository) ExpireCreatedBefore(before time.Time, expiresAt time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockAttachmentRepository) GetExpired(before time.Time, limit int) ([]provider.Attachment, error) {
+	return nil, nil
+}
+
+var now = time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+func newUseCase(t *testing.T) (*AttachmentUseCase, *mockAttachmentRepository) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := &mockAttachmentRepository{attachments: map[int]*provider.Attachment{}}
+	config := attachment.Config{MaxBytes: 1 << 20, ChunkBytes: 4, UploadExpiry: time.Hour, Retention: 72 * time.Hour}
+	useCase := NewAttachmentUseCase(repository, attachment.NewDirStore(t.TempDir()), config, loggerInstance).(*AttachmentUseCase)
+	useCase.now = func() time.Time { return now }
+	return useCase, repository
+}
+
+func digest(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUpload_InParts(t *testing.T) {
+	useCase, _ := newUseCase(t)
+
+	started, err := useCase.Start(7, StartRequest{Filename: "C:\\reports\\q3.pdf", MimeType: "application/PDF", Size: 10, SHA256: digest("0123456789")})
+	require.NoError(t, err)
+	assert.Equal(t, "q3.pdf", started.Filename)
+	assert.Equal(t, "application/pdf", started.MimeType)
+	assert.Equal(t, 3, started.Parts())
+	assert.Equal(t, now.Add(time.Hour), started.ExpiresAt)
+
+	require.NoError(t, useCase.UploadPart(7, started.ID, 3, strings.NewReader("89")))
+	require.NoError(t, useCase.UploadPart(7, started.ID, 1, strings.NewReader("0123")))
+	assert.EqualError(t, useCase.UploadPart(7, started.ID, 2, strings.NewReader("45")), "part 2 must be 4 bytes")
+	assert.EqualError(t, useCase.UploadPart(7, started.ID, 4, strings.NewReader("45")), "part must be between 1 and 3")
+
+	// An interrupted upload is resumed with the parts not received
+	_, received, err := useCase.Get(7, started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, received)
+	_, err = useCase.Complete(7, started.ID)
+	assert.EqualError(t, err, "1 of 3 parts are missing")
+
+	require.NoError(t, useCase.UploadPart(7, started.ID, 2, strings.NewReader("4567")))
+	completed, err := useCase.Complete(7, started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, providerRepo.AttachmentStatusComplete, completed.Status)
+	assert.Equal(t, now.Add(72*time.Hour), completed.ExpiresAt)
+
+	loaded, data, err := useCase.Load(7, started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "q3.pdf", loaded.Filename)
+	assert.Equal(t, "0123456789", string(data))
+
+	// Completed attachments take no more parts, and other users don't find them
+	assert.EqualError(t, useCase.UploadPart(7, started.ID, 1, strings.NewReader("0123")), "the attachment was already completed")
+	_, _, err = useCase.Load(8, started.ID)
+	assert.EqualError(t, err, "record not found")
+}
+
+func TestComplete_RemovesFileNotMatchingDigest(t *testing.T) {
+	useCase, repository := newUseCase(t)
+	started, err := useCase.Start(7, StartRequest{Filename: "a.png", MimeType: "image/png", Size: 3, SHA256: digest("abd")})
+	require.NoError(t, err)
+	require.NoError(t, useCase.UploadPart(7, started.ID, 1, strings.NewReader("abc")))
+
+	_, err = useCase.Complete(7, started.ID)
+	assert.EqualError(t, err, "the uploaded file doesn't match sha256, upload it again")
+	assert.Empty(t, repository.attachments)
+}
+
+func TestStart_Validates(t *testing.T) {
+	useCase, _ := newUseCase(t)
+
+	_, err := useCase.Start(7, StartRequest{Filename: "a.png", MimeType: "image/png", Size: 2 << 20})
+	assert.EqualError(t, err, "size must be between 1 byte and 1 MiB")
+	_, err = useCase.Start(7, StartRequest{Filename: "a;b.png", MimeType: "image/png", Size: 3})
+	assert.EqualError(t, err, "filename must be a file name without ; or ,")
+	_, err = useCase.Start(7, StartRequest{Filename: "a.png", MimeType: "image/png;charset=x", Size: 3})
+	assert.EqualError(t, err, "mime_type must be a MIME type like image/png")
+}
+
+func TestGet_ExpiredAttachmentsAreNotFound(t *testing.T) {
+	useCase, _ := newUseCase(t)
+	started, err := useCase.Start(7, StartRequest{Filename: "a.png", MimeType: "image/png", Size: 3})
+	require.NoError(t, err)
+
+	useCase.now = func() time.Time { return now.Add(2 * time.Hour) }
+	_, _, err = useCase.Get(7, started.ID)
+	assert.EqualError(t, err, "record not found")
+}
@@ -0,0 +1,243 @@
+package auditexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	controlUseCase "go-multi-chat-api/src/application/usecases/control"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	"go-multi-chat-api/src/infrastructure/auditexport"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+)
+
+// JobType is the type of the jobs exporting the audit logs
+const JobType = "audit_export"
+
+// Audit logs that are exported
+const (
+	SourceLoginEvents     = "login_events"
+	SourceControlCommands = "control_commands"
+)
+
+// Sources are the exported audit logs, in export order
+var Sources = []string{SourceLoginEvents, SourceControlCommands}
+
+// Result reports what an export job exported
+type Result struct {
+	Batches  int            `json:"batches"`
+	Exported map[string]int `json:"exported"` // records exported per source
+}
+
+// IAuditExportUseCase defines the interface for exporting the audit logs to a SIEM
+type IAuditExportUseCase interface {
+	// Start queues an export job
+	Start(createdBy int) (*provider.Job, error)
+	// Run is the job handler exporting the records added since the last export
+	Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+	// GetBatches returns the most recently exported batches, newest first
+	GetBatches(limit int) ([]provider.AuditExportBatch, error)
+}
+
+// AuditExportUseCase implements the IAuditExportUseCase interface
+type AuditExportUseCase struct {
+	jobQueue                 jobs.Queue
+	loginActivityRepository  userRepo.LoginActivityRepositoryInterface
+	controlCommandRepository providerRepo.ControlCommandRepositoryInterface
+	batchRepository          providerRepo.AuditExportBatchRepositoryInterface
+	target                   auditexport.Target
+	config                   auditexport.Config
+	Logger                   *logger.Logger
+	now                      func() time.Time
+}
+
+// NewAuditExportUseCase creates a new AuditExportUseCase, target is nil when the export isn't configured
+func NewAuditExportUseCase(jobQueue jobs.Queue, loginActivityRepository userRepo.LoginActivityRepositoryInterface,
+	controlCommandRepository providerRepo.ControlCommandRepositoryInterface, batchRepository providerRepo.AuditExportBatchRepositoryInterface,
+	target auditexport.Target, config auditexport.Config, loggerInstance *logger.Logger) IAuditExportUseCase {
+	return &AuditExportUseCase{
+		jobQueue:                 jobQueue,
+		loginActivityRepository:  loginActivityRepository,
+		controlCommandRepository: controlCommandRepository,
+		batchRepository:          batchRepository,
+		target:                   target,
+		config:                   config,
+		Logger:                   loggerInstance,
+		now:                      time.Now,
+	}
+}
+
+func (a *AuditExportUseCase) Start(createdBy int) (*provider.Job, error) {
+	if a.target == nil {
+		return nil, domainErrors.NewAppError(errors.New("the audit export is not configured, set AUDIT_EXPORT_TARGET"), domainErrors.ValidationError)
+	}
+	job, err := a.jobQueue.Enqueue(JobType, nil, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	a.Logger.Info("Queued audit export", zap.Int("jobID", job.ID), zap.String("target", a.config.Target))
+	return job, nil
+}
+
+// Run exports the records of every source added after its checkpoint, in batches of the configured size. Every
+// batch is written to the target before it is recorded as the new checkpoint, so a failed export is resumed by the
+// next one without losing records. A batch written but not recorded is written again with the same content.
+func (a *AuditExportUseCase) Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	if a.target == nil {
+		return nil, jobs.Permanent(errors.New("the audit export is not configured"))
+	}
+	result := &Result{Exported: make(map[string]int)}
+	for i, source := range Sources {
+		if err := a.exportSource(ctx, source, result); err != nil {
+			return result, err
+		}
+		progress.Report((i+1)*100/len(Sources), result)
+	}
+
+	a.Logger.Info("Exported audit logs", zap.Int("jobID", job.ID), zap.Int("batches", result.Batches),
+		zap.Int("loginEvents", result.Exported[SourceLoginEvents]), zap.Int("controlCommands", result.Exported[SourceControlCommands]))
+	return result, nil
+}
+
+func (a *AuditExportUseCase) exportSource(ctx context.Context, source string, result *Result) error {
+	checkpoint, err := a.batchRepository.GetCheckpoint(source)
+	if err != nil {
+		return err
+	}
+	afterID, previousHash := 0, ""
+	if checkpoint != nil {
+		afterID, previousHash = checkpoint.LastID, checkpoint.Hash
+	}
+
+	for ctx.Err() == nil {
+		records, err := a.records(source, afterID)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		batch, err := a.exportBatch(source, records, previousHash)
+		if err != nil {
+			return err
+		}
+		result.Batches++
+		result.Exported[source] += batch.Records
+		if len(records) < a.config.BatchSize {
+			return nil
+		}
+		afterID, previousHash = batch.LastID, batch.Hash
+	}
+	return ctx.Err()
+}
+
+// exportBatch writes a batch of records to the target and records it as the checkpoint of the source
+func (a *AuditExportUseCase) exportBatch(source string, records []auditexport.Record, previousHash string) (*provider.AuditExportBatch, error) {
+	content, err := auditexport.Encode(a.config.Format, records)
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	first, last := records[0].ID, records[len(records)-1].ID
+	key := fmt.Sprintf("%s/%s/%s/%010d-%010d.%s", a.config.Prefix, source, a.now().UTC().Format("2006/01/02"), first, last, a.config.Format)
+	location, err := a.target.Put(key, content)
+	if err != nil {
+		a.Logger.Error("Error writing audit export batch", zap.Error(err), zap.String("source", source), zap.Int("firstID", first))
+		return nil, err
+	}
+	return a.batchRepository.Create(&provider.AuditExportBatch{
+		Source:       source,
+		Format:       a.config.Format,
+		Target:       a.config.Target,
+		FirstID:      first,
+		LastID:       last,
+		Records:      len(records),
+		Location:     location,
+		Hash:         auditexport.BatchHash(previousHash, content),
+		PreviousHash: previousHash,
+	})
+}
+
+// records returns the next batch of records of a source after an ID
+func (a *AuditExportUseCase) records(source string, afterID int) ([]auditexport.Record, error) {
+	var records []auditexport.Record
+	switch source {
+	case SourceLoginEvents:
+		events, err := a.loginActivityRepository.GetEventsAfter(afterID, a.config.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			records = append(records, loginRecord(event))
+		}
+	case SourceControlCommands:
+		commands, err := a.controlCommandRepository.GetAfter(afterID, a.config.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, command := range commands {
+			records = append(records, controlCommandRecord(command))
+		}
+	}
+	return records, nil
+}
+
+func (a *AuditExportUseCase) GetBatches(limit int) ([]provider.AuditExportBatch, error) {
+	return a.batchRepository.GetRecent(limit)
+}
+
+// loginRecord maps a login event, failed logins and logins from new locations are more severe
+func loginRecord(event domainUser.LoginEvent) auditexport.Record {
+	record := auditexport.Record{
+		Source:      SourceLoginEvents,
+		ID:          event.ID,
+		Time:        event.CreatedAt,
+		Event:       "login",
+		Outcome:     "success",
+		Severity:    3,
+		UserID:      event.UserID,
+		IPAddress:   event.IPAddress,
+		UserAgent:   event.UserAgent,
+		Action:      event.Method,
+		Reason:      event.FailureReason,
+		NewLocation: event.NewLocation,
+	}
+	if !event.Success {
+		record.Outcome, record.Severity = "failure", 5
+	} else if event.NewLocation {
+		record.Severity = 6
+	}
+	return record
+}
+
+// controlCommandRecord maps a control command, commands of senders that aren't allowed are the most severe
+func controlCommandRecord(command provider.ControlCommand) auditexport.Record {
+	record := auditexport.Record{
+		Source:    SourceControlCommands,
+		ID:        command.ID,
+		Time:      command.CreatedAt,
+		Event:     "control_command",
+		Outcome:   "success",
+		Severity:  5,
+		Actor:     command.Sender,
+		ActorUUID: command.SenderUUID,
+		Action:    command.Command,
+		Text:      command.Text,
+		Reason:    command.Result,
+	}
+	switch command.Status {
+	case controlUseCase.StatusExecuted:
+	case controlUseCase.StatusRejected:
+		record.Outcome, record.Severity = "failure", 8
+	default:
+		record.Outcome, record.Severity = "failure", 6
+	}
+	return record
+}
@@ -0,0 +1,181 @@
+package auditexport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	controlUseCase "go-multi-chat-api/src/application/usecases/control"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	"go-multi-chat-api/src/infrastructure/auditexport"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJobQueue struct {
+	jobType string
+}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	m.jobType = jobType
+	return &provider.Job{ID: 1, Type: jobType, CreatedBy: createdBy}, nil
+}
+
+type mockLoginActivityRepository struct {
+	userRepo.LoginActivityRepositoryInterface
+	events []domainUser.LoginEvent
+}
+
+func (m *mockLoginActivityRepository) GetEventsAfter(afterID int, limit int) ([]domainUser.LoginEvent, error) {
+	var events []domainUser.LoginEvent
+	for _, event := range m.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type mockControlCommandRepository struct {
+	providerRepo.ControlCommandRepositoryInterface
+	commands []provider.ControlCommand
+}
+
+func (m *mockControlCommandRepository) GetAfter(afterID int, limit int) ([]provider.ControlCommand, error) {
+	var commands []provider.ControlCommand
+	for _, command := range m.commands {
+		if command.ID > afterID && len(commands) < limit {
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+type mockBatchRepository struct {
+	batches []provider.AuditExportBatch
+}
+
+func (m *mockBatchRepository) Create(batch *provider.AuditExportBatch) (*provider.AuditExportBatch, error) {
+	batch.ID = len(m.batches) + 1
+	m.batches = append(m.batches, *batch)
+	return batch, nil
+}
+
+func (m *mockBatchRepository) GetCheckpoint(source string) (*provider.AuditExportBatch, error) {
+	var checkpoint *provider.AuditExportBatch
+	for i, batch := range m.batches {
+		if batch.Source == source {
+			checkpoint = &m.batches[i]
+		}
+	}
+	return checkpoint, nil
+}
+
+func (m *mockBatchRepository) GetRecent(limit int) ([]provider.AuditExportBatch, error) {
+	return m.batches, nil
+}
+
+// mockTarget keeps the written batches by key, it fails once after the given number of writes
+type mockTarget struct {
+	written map[string][]byte
+	failAt  int
+}
+
+func (m *mockTarget) Put(key string, data []byte) (string, error) {
+	if m.failAt > 0 && len(m.written) == m.failAt {
+		m.failAt = 0
+		return "", errors.New("connection refused")
+	}
+	m.written[key] = data
+	return "s3://audit/" + key, nil
+}
+
+func loginEvents(count int) []domainUser.LoginEvent {
+	events := make([]domainUser.LoginEvent, count)
+	for i := range events {
+		events[i] = domainUser.LoginEvent{ID: i + 1, UserID: 1, Method: "password", Success: true, CreatedAt: time.Date(2026, time.October, 16, 9, i, 0, 0, time.UTC)}
+	}
+	return events
+}
+
+func newUseCase(t *testing.T, logins *mockLoginActivityRepository, commands *mockControlCommandRepository, batches *mockBatchRepository, target auditexport.Target) *AuditExportUseCase {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	config := auditexport.Config{Target: auditexport.TargetS3, Format: auditexport.FormatJSONL, BatchSize: 2, Prefix: "audit"}
+	useCase := NewAuditExportUseCase(&mockJobQueue{}, logins, commands, batches, target, config, loggerInstance).(*AuditExportUseCase)
+	useCase.now = func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC) }
+	return useCase
+}
+
+func TestRun_ExportsChainedBatches(t *testing.T) {
+	logins := &mockLoginActivityRepository{events: loginEvents(3)}
+	commands := &mockControlCommandRepository{commands: []provider.ControlCommand{
+		{ID: 4, Sender: "+4912345678", Command: "pause", Status: controlUseCase.StatusRejected},
+	}}
+	batches := &mockBatchRepository{}
+	target := &mockTarget{written: map[string][]byte{}}
+	useCase := newUseCase(t, logins, commands, batches, target)
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Batches: 3, Exported: map[string]int{SourceLoginEvents: 3, SourceControlCommands: 1}}, result)
+
+	require.Len(t, batches.batches, 3)
+	first, second := batches.batches[0], batches.batches[1]
+	assert.Equal(t, 1, first.FirstID)
+	assert.Equal(t, 2, first.LastID)
+	assert.Equal(t, "s3://audit/audit/login_events/2026/10/16/0000000001-0000000002.jsonl", first.Location)
+	assert.Equal(t, auditexport.BatchHash("", target.written["audit/login_events/2026/10/16/0000000001-0000000002.jsonl"]), first.Hash)
+	// The next batch of the source chains to the hash of the previous one
+	assert.Equal(t, 3, second.FirstID)
+	assert.Equal(t, first.Hash, second.PreviousHash)
+	assert.Equal(t, auditexport.BatchHash(first.Hash, target.written["audit/login_events/2026/10/16/0000000003-0000000003.jsonl"]), second.Hash)
+	assert.Empty(t, batches.batches[2].PreviousHash)
+	assert.Contains(t, string(target.written["audit/control_commands/2026/10/16/0000000004-0000000004.jsonl"]), `"outcome":"failure","severity":8`)
+
+	// Exporting again only exports what was added since
+	logins.events = loginEvents(4)
+	result, err = useCase.Run(context.Background(), &provider.Job{ID: 2}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Batches: 1, Exported: map[string]int{SourceLoginEvents: 1}}, result)
+	assert.Equal(t, 4, batches.batches[3].FirstID)
+	assert.Equal(t, second.Hash, batches.batches[3].PreviousHash)
+}
+
+func TestRun_ResumesAfterFailedWrite(t *testing.T) {
+	logins := &mockLoginActivityRepository{events: loginEvents(3)}
+	batches := &mockBatchRepository{}
+	target := &mockTarget{written: map[string][]byte{}, failAt: 1}
+	useCase := newUseCase(t, logins, &mockControlCommandRepository{}, batches, target)
+
+	_, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	assert.Error(t, err)
+	require.Len(t, batches.batches, 1)
+
+	// The retry continues after the last recorded batch
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Batches: 1, Exported: map[string]int{SourceLoginEvents: 1}}, result)
+	assert.Equal(t, 3, batches.batches[1].FirstID)
+}
+
+func TestStart(t *testing.T) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	// Without a target the export isn't configured
+	_, err = NewAuditExportUseCase(&mockJobQueue{}, nil, nil, nil, nil, auditexport.Config{}, loggerInstance).Start(1)
+	assert.Error(t, err)
+
+	queue := &mockJobQueue{}
+	job, err := NewAuditExportUseCase(queue, nil, nil, nil, &mockTarget{}, auditexport.Config{Target: auditexport.TargetS3}, loggerInstance).Start(1)
+	require.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+	assert.Equal(t, JobType, queue.jobType)
+}
@@ -13,6 +13,7 @@ import (
 	"go-multi-chat-api/src/infrastructure/repository/mysql/user"
 	"go-multi-chat-api/src/infrastructure/security"
 
+	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -22,29 +23,37 @@ type IAuthUseCase interface {
 	AccessTokenByRefreshToken(refreshToken string) (*domainUser.User, *AuthTokens, error)
 	InitiateAzureADAuth() (string, string, error)
 	CompleteAzureADAuth(code, state string) (*domainUser.User, *AuthTokens, error)
+	// ExternalTokenClaims verifies an access token of the external identity provider and returns the claims of
+	// an access token of its user
+	ExternalTokenClaims(tokenString string) (jwt.MapClaims, error)
 }
 
 type AuthUseCase struct {
-	UserRepository user.UserRepositoryInterface
-	JWTService     security.IJWTService
-	LDAPService    security.ILDAPService
-	AzureADService security.IAzureADService
-	Logger         *logger.Logger
+	UserRepository         user.UserRepositoryInterface
+	JWTService             security.IJWTService
+	LDAPService            security.ILDAPService
+	AzureADService         security.IAzureADService
+	ExternalTokenValidator security.IExternalTokenValidator
+	Logger                 *logger.Logger
 }
 
+// NewAuthUseCase creates a new AuthUseCase, the external token validator is nil unless external tokens are
+// accepted
 func NewAuthUseCase(
 	userRepository user.UserRepositoryInterface,
 	jwtService security.IJWTService,
 	ldapService security.ILDAPService,
 	azureADService security.IAzureADService,
+	externalTokenValidator security.IExternalTokenValidator,
 	loggerInstance *logger.Logger,
 ) IAuthUseCase {
 	return &AuthUseCase{
-		UserRepository: userRepository,
-		JWTService:     jwtService,
-		LDAPService:    ldapService,
-		AzureADService: azureADService,
-		Logger:         loggerInstance,
+		UserRepository:         userRepository,
+		JWTService:             jwtService,
+		LDAPService:            ldapService,
+		AzureADService:         azureADService,
+		ExternalTokenValidator: externalTokenValidator,
+		Logger:                 loggerInstance,
 	}
 }
 
@@ -55,6 +64,9 @@ type AuthTokens struct {
 	ExpirationRefreshDateTime time.Time
 }
 
+// errUserDeactivated refuses the logins and token refreshes of deactivated users
+var errUserDeactivated = domainErrors.NewAppError(errors.New("account deactivated"), domainErrors.NotAuthorized)
+
 func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthTokens, error) {
 	s.Logger.Info("User login attempt", zap.String("email", email))
 
@@ -123,6 +135,11 @@ func (s *AuthUseCase) Login(email, password string) (*domainUser.User, *AuthToke
 		user = dbUser
 	}
 
+	if !user.Status {
+		s.Logger.Warn("Login failed: user is deactivated", zap.String("email", email), zap.Int("userID", user.ID))
+		return nil, nil, errUserDeactivated
+	}
+
 	// Generate tokens for authenticated user
 	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access", user.Role)
 	if err != nil {
@@ -159,6 +176,15 @@ func (s *AuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*domainUse
 		s.Logger.Error("Error getting user for token refresh", zap.Error(err), zap.Int("userID", userID))
 		return nil, nil, err
 	}
+	// Refresh tokens issued before the user was deactivated are revoked
+	var issuedAt time.Time
+	if iat, ok := claimsMap["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iat), 0)
+	}
+	if !user.Active(issuedAt) {
+		s.Logger.Warn("Token refresh failed: user is deactivated", zap.Int("userID", userID))
+		return nil, nil, errUserDeactivated
+	}
 
 	accessTokenClaims, err := s.JWTService.GenerateJWTToken(user.ID, "access", user.Role)
 	if err != nil {
@@ -179,6 +205,34 @@ func (s *AuthUseCase) AccessTokenByRefreshToken(refreshToken string) (*domainUse
 	return user, authTokens, nil
 }
 
+// ExternalTokenClaims maps an access token issued by the external identity provider, e.g. Azure AD, to the user
+// with its email. Users aren't created from external tokens, they must exist or have signed in once.
+func (s *AuthUseCase) ExternalTokenClaims(tokenString string) (jwt.MapClaims, error) {
+	if s.ExternalTokenValidator == nil {
+		return nil, domainErrors.NewAppError(errors.New("external tokens are not accepted"), domainErrors.NotAuthenticated)
+	}
+	identity, err := s.ExternalTokenValidator.Validate(tokenString)
+	if err != nil {
+		s.Logger.Warn("External token rejected", zap.Error(err))
+		return nil, err
+	}
+	dbUser, err := s.UserRepository.GetByEmail(identity.Email)
+	if err != nil || dbUser.ID == 0 {
+		s.Logger.Warn("External token of unknown user", zap.String("email", identity.Email))
+		return nil, domainErrors.NewAppError(errors.New("no user for the token"), domainErrors.NotAuthenticated)
+	}
+	if !dbUser.Status {
+		return nil, errUserDeactivated
+	}
+	return jwt.MapClaims{
+		"id":   float64(dbUser.ID),
+		"type": security.Access,
+		"role": dbUser.Role,
+		"exp":  float64(identity.ExpiresAt.Unix()),
+		"iat":  float64(identity.IssuedAt.Unix()),
+	}, nil
+}
+
 func checkPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
@@ -245,6 +299,10 @@ func (s *AuthUseCase) CompleteAzureADAuth(code, state string) (*domainUser.User,
 			return nil, nil, dbErr
 		}
 	}
+	if !dbUser.Status {
+		s.Logger.Warn("Azure AD login failed: user is deactivated", zap.String("email", dbUser.Email), zap.Int("userID", dbUser.ID))
+		return nil, nil, errUserDeactivated
+	}
 
 	// Generate tokens for authenticated user
 	accessTokenClaims, err := s.JWTService.GenerateJWTToken(dbUser.ID, "access", dbUser.Role)
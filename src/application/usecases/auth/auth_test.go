@@ -252,7 +252,7 @@ func TestAuthUseCase_Login(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, nil, nil, logger)
+			uc := NewAuthUseCase(userRepoMock, jwtMock, nil, nil, nil, logger)
 
 			user, authTokens, err := uc.Login(tt.inputEmail, tt.inputPassword)
 			if (err != nil) != tt.wantErr {
@@ -324,7 +324,7 @@ func TestAuthUseCase_InitiateAzureADAuth(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, ldapMock, azureADMock, logger)
+			uc := NewAuthUseCase(userRepoMock, jwtMock, ldapMock, azureADMock, nil, logger)
 
 			authURL, state, err := uc.InitiateAzureADAuth()
 			if (err != nil) != tt.wantErr {
@@ -551,7 +551,7 @@ func TestAuthUseCase_CompleteAzureADAuth(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, ldapMock, azureADMock, logger)
+			uc := NewAuthUseCase(userRepoMock, jwtMock, ldapMock, azureADMock, nil, logger)
 
 			user, authTokens, err := uc.CompleteAzureADAuth(tt.inputCode, tt.inputState)
 			if (err != nil) != tt.wantErr {
@@ -691,7 +691,7 @@ func TestAuthUseCase_AccessTokenByRefreshToken(t *testing.T) {
 			}
 
 			logger := setupLogger(t)
-			uc := NewAuthUseCase(userRepoMock, jwtMock, nil, nil, logger)
+			uc := NewAuthUseCase(userRepoMock, jwtMock, nil, nil, nil, logger)
 
 			user, authTokens, err := uc.AccessTokenByRefreshToken(tt.inputRefreshToken)
 			if (err != nil) != tt.wantErr {
@@ -0,0 +1,373 @@
+package bootstrap
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	domainBootstrap "go-multi-chat-api/src/domain/bootstrap"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	emailRenderer "go-multi-chat-api/src/infrastructure/emailtemplate"
+	"go-multi-chat-api/src/infrastructure/i18n"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	bootstrapRepo "go-multi-chat-api/src/infrastructure/repository/mysql/bootstrap"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxHTMLLength is the longest HTML body a template can have
+const maxHTMLLength = 1 << 20
+
+// roles are the roles users can have
+var roles = []string{"admin", "member"}
+
+// HookVerifier performs the verification handshake with the target URL of a new webhook
+type HookVerifier interface {
+	Verify(targetURL string, secret string) error
+}
+
+// TemplateValidator checks that the subject and body of an email template parse
+type TemplateValidator interface {
+	Validate(t emailRenderer.Template) error
+}
+
+// User is a user of a document with a plain password or a bcrypt hash of it, only needed to create the user
+type User struct {
+	domainBootstrap.User
+	Password string
+}
+
+// Document is a bootstrap document as it is sent. Roles lists the users of each role by email, users may be
+// listed in Users or already exist.
+type Document struct {
+	Org           string
+	Users         []User
+	Roles         map[string][]string
+	Providers     []domainBootstrap.Provider
+	UserProviders []domainBootstrap.UserProvider
+	Webhooks      []domainBootstrap.Webhook
+	Templates     []domainBootstrap.Template
+}
+
+// IBootstrapUseCase defines the interface for bootstrap use cases
+type IBootstrapUseCase interface {
+	// Apply validates a whole document and applies it atomically, returning what changed. A dry run only
+	// reports the changes. Applying the same document again reports every resource unchanged.
+	Apply(document *Document, dryRun bool) ([]domainBootstrap.Change, error)
+}
+
+// BootstrapUseCase implements the IBootstrapUseCase interface
+type BootstrapUseCase struct {
+	bootstrapRepository bootstrapRepo.BootstrapRepositoryInterface
+	providerRepository  providerRepo.ProviderRepositoryInterface
+	verifier            HookVerifier
+	templates           TemplateValidator
+	org                 string
+	Logger              *logger.Logger
+}
+
+// NewBootstrapUseCase creates a new BootstrapUseCase. When org is set, documents must name it, so a document
+// meant for another deployment isn't applied by mistake.
+func NewBootstrapUseCase(
+	bootstrapRepository bootstrapRepo.BootstrapRepositoryInterface,
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	verifier HookVerifier,
+	templates TemplateValidator,
+	org string,
+	loggerInstance *logger.Logger,
+) IBootstrapUseCase {
+	return &BootstrapUseCase{
+		bootstrapRepository: bootstrapRepository,
+		providerRepository:  providerRepository,
+		verifier:            verifier,
+		templates:           templates,
+		org:                 org,
+		Logger:              loggerInstance,
+	}
+}
+
+// Apply plans the document with a dry run first, so the targets of the webhooks it creates complete the
+// verification handshake before anything is stored. The handshake can't be rolled back with the transaction.
+func (u *BootstrapUseCase) Apply(document *Document, dryRun bool) ([]domainBootstrap.Change, error) {
+	plan, err := u.validate(document)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := u.bootstrapRepository.Apply(plan, true)
+	if err != nil || dryRun {
+		return changes, err
+	}
+
+	for _, change := range changes {
+		if change.Kind != domainBootstrap.KindWebhook || change.Action != domainBootstrap.ActionCreated {
+			continue
+		}
+		for i := range plan.Webhooks {
+			webhook := &plan.Webhooks[i]
+			if webhook.User != change.User || webhook.Event+" "+webhook.TargetURL != change.Name {
+				continue
+			}
+			if webhook.Secret, err = generateSecret(); err != nil {
+				u.Logger.Error("Error generating hook secret", zap.Error(err))
+				return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+			}
+			if err := u.verifier.Verify(webhook.TargetURL, webhook.Secret); err != nil {
+				u.Logger.Info("Bootstrap hook verification failed", zap.Error(err), zap.String("email", webhook.User), zap.String("event", webhook.Event))
+				return nil, domainErrors.NewAppError(fmt.Errorf("webhooks[%d]: verification failed: %w", i, err), domainErrors.ValidationError)
+			}
+		}
+	}
+
+	changes, err = u.bootstrapRepository.Apply(plan, false)
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Info("Applied bootstrap document",
+		zap.String("org", document.Org),
+		zap.Int("users", len(plan.Users)),
+		zap.Int("providers", len(plan.Providers)),
+		zap.Int("userProviders", len(plan.UserProviders)),
+		zap.Int("webhooks", len(plan.Webhooks)),
+		zap.Int("templates", len(plan.Templates)))
+	return changes, nil
+}
+
+// validate checks every resource of a document, reporting violations with the path of the resource in the
+// document, e.g. users[1].email, and returns the document as it is applied
+func (u *BootstrapUseCase) validate(document *Document) (*domainBootstrap.Document, error) {
+	if u.org != "" && document.Org != u.org {
+		return nil, validationError("org: the document is for org %q, this deployment is %q", document.Org, u.org)
+	}
+
+	roleOf := map[string]string{}
+	for role, emails := range document.Roles {
+		if !contains(roles, role) {
+			return nil, validationError("roles: role must be one of %s", strings.Join(roles, ", "))
+		}
+		for _, email := range emails {
+			if email == "" {
+				return nil, validationError("roles.%s: email is required", role)
+			}
+			if other, ok := roleOf[email]; ok {
+				return nil, validationError("roles.%s: %s already has the role %s", role, email, other)
+			}
+			roleOf[email] = role
+		}
+	}
+
+	plan := &domainBootstrap.Document{Org: document.Org, Roles: map[string]string{}}
+	emails := map[string]bool{}
+	userNames := map[string]bool{}
+	for i, user := range document.Users {
+		path := fmt.Sprintf("users[%d]", i)
+		switch {
+		case user.Email == "":
+			return nil, validationError("%s: email is required", path)
+		case emails[user.Email]:
+			return nil, validationError("%s: duplicate email %s", path, user.Email)
+		case user.UserName == "":
+			return nil, validationError("%s: user_name is required", path)
+		case userNames[user.UserName]:
+			return nil, validationError("%s: duplicate user_name %s", path, user.UserName)
+		case user.Password != "" && user.PasswordHash != "":
+			return nil, validationError("%s: only one of password and password_hash can be set", path)
+		case user.Role != "" && !contains(roles, user.Role):
+			return nil, validationError("%s: role must be one of %s", path, strings.Join(roles, ", "))
+		case user.Role != "" && roleOf[user.Email] != "" && roleOf[user.Email] != user.Role:
+			return nil, validationError("%s: role %s contradicts roles.%s", path, user.Role, roleOf[user.Email])
+		case user.Locale != "" && !i18n.IsSupported(user.Locale):
+			return nil, validationError("%s: locale must be one of %s", path, strings.Join(i18n.Supported(), ", "))
+		case user.MessageRateLimit < 0:
+			return nil, validationError("%s: message_rate_limit must be at least 0", path)
+		}
+		emails[user.Email] = true
+		userNames[user.UserName] = true
+
+		applied := user.User
+		if applied.Role == "" {
+			applied.Role = roleOf[user.Email]
+		}
+		if applied.Role == "" {
+			applied.Role = "member"
+		}
+		if applied.MessageRateLimit == 0 {
+			applied.MessageRateLimit = 1000
+		}
+		if applied.PasswordHash != "" {
+			if _, err := bcrypt.Cost([]byte(applied.PasswordHash)); err != nil {
+				return nil, validationError("%s: password_hash is not a bcrypt hash", path)
+			}
+		}
+		if user.Password != "" {
+			hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, domainErrors.NewAppError(fmt.Errorf("%s: %w", path, err), domainErrors.ValidationError)
+			}
+			applied.PasswordHash = string(hash)
+		}
+		plan.Users = append(plan.Users, applied)
+	}
+	for email, role := range roleOf {
+		if !emails[email] {
+			plan.Roles[email] = role
+		}
+	}
+
+	types := map[string]string{}
+	for i, p := range document.Providers {
+		path := fmt.Sprintf("providers[%d]", i)
+		if p.Name == "" {
+			return nil, validationError("%s: name is required", path)
+		}
+		if _, ok := types[p.Name]; ok {
+			return nil, validationError("%s: duplicate provider %q", path, p.Name)
+		}
+		providerType, ok := providerconfig.Lookup(p.Type)
+		if !ok {
+			return nil, validationError("%s: unknown provider type %q", path, p.Type)
+		}
+		if err := providerType.ProviderSchema.Validate(p.Config); err != nil {
+			return nil, prefixFields(err, path+".config")
+		}
+		types[p.Name] = p.Type
+	}
+	plan.Providers = document.Providers
+
+	links := map[string]bool{}
+	for i, up := range document.UserProviders {
+		path := fmt.Sprintf("user_providers[%d]", i)
+		switch {
+		case up.User == "":
+			return nil, validationError("%s: user is required", path)
+		case up.Provider == "":
+			return nil, validationError("%s: provider is required", path)
+		case links[up.User+"\x00"+up.Provider]:
+			return nil, validationError("%s: duplicate user provider %s of %s", path, up.Provider, up.User)
+		case up.Priority < 1:
+			return nil, validationError("%s: priority must be at least 1", path)
+		}
+		links[up.User+"\x00"+up.Provider] = true
+
+		providerType, ok := types[up.Provider]
+		if !ok {
+			stored, err := u.providerRepository.GetByName(up.Provider)
+			if err != nil {
+				if isNotFound(err) {
+					return nil, validationError("%s: unknown provider %s", path, up.Provider)
+				}
+				return nil, err
+			}
+			providerType = stored.Type
+			types[up.Provider] = providerType
+		}
+		if err := schemasOf(providerType).UserProviderSchema.Validate(up.Config); err != nil {
+			return nil, prefixFields(err, path+".config")
+		}
+	}
+	plan.UserProviders = document.UserProviders
+
+	webhooks := map[string]bool{}
+	for i, w := range document.Webhooks {
+		path := fmt.Sprintf("webhooks[%d]", i)
+		if w.User == "" {
+			return nil, validationError("%s: user is required", path)
+		}
+		if !messaging.IsHookEvent(w.Event) {
+			return nil, validationError("%s: event must be one of %s", path, strings.Join(messaging.HookEvents, ", "))
+		}
+		parsed, err := url.Parse(w.TargetURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, validationError("%s: target_url must be an absolute http or https url", path)
+		}
+		key := w.User + "\x00" + w.Event + "\x00" + w.TargetURL
+		if webhooks[key] {
+			return nil, validationError("%s: duplicate webhook %s %s of %s", path, w.Event, w.TargetURL, w.User)
+		}
+		webhooks[key] = true
+		// Secrets are generated once the document is planned, never taken from the document
+		w.Secret = ""
+		plan.Webhooks = append(plan.Webhooks, w)
+	}
+
+	templates := map[string]bool{}
+	for i, t := range document.Templates {
+		path := fmt.Sprintf("templates[%d]", i)
+		switch {
+		case t.User == "":
+			return nil, validationError("%s: user is required", path)
+		case strings.TrimSpace(t.Name) == "":
+			return nil, validationError("%s: name is required", path)
+		case templates[t.User+"\x00"+t.Name]:
+			return nil, validationError("%s: duplicate template %s of %s", path, t.Name, t.User)
+		case strings.TrimSpace(t.HTML) == "":
+			return nil, validationError("%s: html is required", path)
+		case len(t.HTML) > maxHTMLLength:
+			return nil, validationError("%s: html can be at most 1 MiB", path)
+		}
+		if err := u.templates.Validate(emailRenderer.Template{Subject: t.Subject, HTML: t.HTML}); err != nil {
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: %w", path, err), domainErrors.ValidationError)
+		}
+		templates[t.User+"\x00"+t.Name] = true
+	}
+	plan.Templates = document.Templates
+	return plan, nil
+}
+
+// schemasOf returns the config schemas of a provider type, the generic ones for types without their own
+func schemasOf(providerType string) *providerconfig.ProviderType {
+	if t, ok := providerconfig.Lookup(providerType); ok {
+		return t
+	}
+	return providerconfig.Generic()
+}
+
+// prefixFields moves the fields of a config validation error below the path of the config in the document
+func prefixFields(err error, path string) error {
+	var validationErr *providerconfig.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	fields := make([]providerconfig.FieldError, len(validationErr.Errors))
+	for i, fieldError := range validationErr.Errors {
+		fields[i] = fieldError
+		if fieldError.Field == "" {
+			fields[i].Field = path
+		} else {
+			fields[i].Field = path + "." + fieldError.Field
+		}
+	}
+	return &providerconfig.ValidationError{Errors: fields}
+}
+
+func validationError(format string, args ...interface{}) error {
+	return domainErrors.NewAppError(fmt.Errorf(format, args...), domainErrors.ValidationError)
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,170 @@
+package bootstrap
+
+import (
+	"errors"
+	"testing"
+
+	domainBootstrap "go-multi-chat-api/src/domain/bootstrap"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	emailRenderer "go-multi-chat-api/src/infrastructure/emailtemplate"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mockBootstrapRepository plans every webhook as created and records the documents it was asked to apply
+type mockBootstrapRepository struct {
+	applied []domainBootstrap.Document
+	dryRuns []bool
+}
+
+func (m *mockBootstrapRepository) Apply(document *domainBootstrap.Document, dryRun bool) ([]domainBootstrap.Change, error) {
+	applied := *document
+	applied.Webhooks = append([]domainBootstrap.Webhook(nil), document.Webhooks...)
+	m.applied = append(m.applied, applied)
+	m.dryRuns = append(m.dryRuns, dryRun)
+	changes := []domainBootstrap.Change{}
+	for _, w := range document.Webhooks {
+		changes = append(changes, domainBootstrap.Change{Kind: domainBootstrap.KindWebhook, Name: w.Event + " " + w.TargetURL, User: w.User, Action: domainBootstrap.ActionCreated, Secret: w.Secret})
+	}
+	return changes, nil
+}
+
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers map[string]*domainProvider.Provider
+}
+
+func (m *mockProviderRepository) GetByName(name string) (*domainProvider.Provider, error) {
+	if provider, ok := m.providers[name]; ok {
+		return provider, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type mockVerifier struct {
+	verified []string
+	err      error
+}
+
+func (m *mockVerifier) Verify(targetURL string, secret string) error {
+	m.verified = append(m.verified, targetURL)
+	return m.err
+}
+
+func setupUseCase(org string) (*BootstrapUseCase, *mockBootstrapRepository, *mockVerifier) {
+	repository := &mockBootstrapRepository{}
+	verifier := &mockVerifier{}
+	providers := &mockProviderRepository{providers: map[string]*domainProvider.Provider{"primary": {ID: 1, Name: "primary", Type: "signal"}}}
+	useCase := NewBootstrapUseCase(repository, providers, verifier, emailRenderer.NewRenderer(nil), org, &logger.Logger{Log: zap.NewNop()})
+	return useCase.(*BootstrapUseCase), repository, verifier
+}
+
+func document() *Document {
+	return &Document{
+		Org: "acme",
+		Users: []User{
+			{User: domainBootstrap.User{Email: "ops@example.com", UserName: "ops", Status: true}, Password: "secret123"},
+			{User: domainBootstrap.User{Email: "dev@example.com", UserName: "dev", Status: true}},
+		},
+		Roles:         map[string][]string{"admin": {"ops@example.com", "lead@example.com"}},
+		Providers:     []domainBootstrap.Provider{{Name: "alerts", Type: "signal", Status: true}},
+		UserProviders: []domainBootstrap.UserProvider{{User: "ops@example.com", Provider: "primary", Priority: 1, Status: true}},
+		Webhooks:      []domainBootstrap.Webhook{{User: "ops@example.com", Event: "message.delivery", TargetURL: "https://hooks.example.com/delivery", Secret: "chosen"}},
+		Templates:     []domainBootstrap.Template{{User: "ops@example.com", Name: "welcome", Subject: "Hi {{.name}}", HTML: "<p>Welcome</p>"}},
+	}
+}
+
+func TestApply_DryRunOnlyPlans(t *testing.T) {
+	useCase, repository, verifier := setupUseCase("acme")
+
+	changes, err := useCase.Apply(document(), true)
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, []bool{true}, repository.dryRuns)
+	assert.Empty(t, verifier.verified)
+}
+
+func TestApply_VerifiesNewWebhooksBeforeApplying(t *testing.T) {
+	useCase, repository, verifier := setupUseCase("acme")
+
+	changes, err := useCase.Apply(document(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, repository.dryRuns)
+	assert.Equal(t, []string{"https://hooks.example.com/delivery"}, verifier.verified)
+
+	// The secret of the document is ignored, a generated one is verified and returned
+	planned, applied := repository.applied[0], repository.applied[1]
+	assert.Empty(t, planned.Webhooks[0].Secret)
+	assert.Len(t, applied.Webhooks[0].Secret, 64)
+	assert.Equal(t, applied.Webhooks[0].Secret, changes[0].Secret)
+
+	// Roles of listed users are applied with them, the others on their own, and defaults are filled in
+	assert.Equal(t, "admin", applied.Users[0].Role)
+	assert.Equal(t, "member", applied.Users[1].Role)
+	assert.Equal(t, 1000, applied.Users[0].MessageRateLimit)
+	assert.Equal(t, map[string]string{"lead@example.com": "admin"}, applied.Roles)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(applied.Users[0].PasswordHash), []byte("secret123")))
+	assert.Empty(t, applied.Users[1].PasswordHash)
+}
+
+func TestApply_FailedVerificationChangesNothing(t *testing.T) {
+	useCase, repository, verifier := setupUseCase("")
+	verifier.err = errors.New("secret not echoed")
+
+	_, err := useCase.Apply(document(), false)
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Contains(t, err.Error(), "webhooks[0]")
+	assert.Equal(t, []bool{true}, repository.dryRuns)
+}
+
+func TestApply_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		org    string
+		modify func(d *Document)
+		want   string
+	}{
+		{"other org", "globex", func(d *Document) {}, "org:"},
+		{"unknown role", "", func(d *Document) { d.Roles["owner"] = []string{"dev@example.com"} }, "roles: role must be one of"},
+		{"contradicting role", "", func(d *Document) { d.Users[0].Role = "member" }, "users[0]: role member contradicts roles.admin"},
+		{"duplicate email", "", func(d *Document) { d.Users[1].Email = "ops@example.com" }, "users[1]: duplicate email"},
+		{"password and hash", "", func(d *Document) { d.Users[0].PasswordHash = "$2a$10$x" }, "users[0]: only one of"},
+		{"unknown provider type", "", func(d *Document) { d.Providers[0].Type = "pager" }, "providers[0]: unknown provider type"},
+		{"unknown provider", "", func(d *Document) { d.UserProviders[0].Provider = "other" }, "user_providers[0]: unknown provider other"},
+		{"unknown event", "", func(d *Document) { d.Webhooks[0].Event = "message.lost" }, "webhooks[0]: event must be one of"},
+		{"relative target", "", func(d *Document) { d.Webhooks[0].TargetURL = "/hooks" }, "webhooks[0]: target_url"},
+		{"invalid template", "", func(d *Document) { d.Templates[0].HTML = "{{.name" }, "templates[0]: invalid html template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, repository, _ := setupUseCase(tt.org)
+			d := document()
+			tt.modify(d)
+
+			_, err := useCase.Apply(d, true)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.Empty(t, repository.applied)
+		})
+	}
+}
+
+func TestApply_ConfigErrorsHaveTheirPath(t *testing.T) {
+	useCase, _, _ := setupUseCase("")
+	d := document()
+	d.Providers = []domainBootstrap.Provider{{Name: "mail", Type: "email", Config: `{"host":"smtp.example.com"}`}}
+
+	_, err := useCase.Apply(d, true)
+	var validationErr *providerconfig.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "providers[0].config.from", validationErr.Errors[0].Field)
+}
@@ -0,0 +1,179 @@
+package bulkoperation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// JobType is the type of the jobs running bulk operations
+const JobType = "bulk_operation"
+
+// batchSize is the number of messages changed at once by a bulk operation
+const batchSize = 500
+
+// actionStatuses lists the message statuses each action applies to. Pending messages can't be requeued and
+// messages whose send may have reached the provider can't be cancelled.
+var actionStatuses = map[string][]string{
+	provider.BulkActionRequeue: {provider.MessageStatusFailed, provider.MessageStatusUnconfirmed, provider.MessageStatusHeld,
+		provider.MessageStatusHeldSchedule, provider.MessageStatusRateLimited},
+	provider.BulkActionCancel: {provider.MessageStatusPending, provider.MessageStatusFailed, provider.MessageStatusHeld,
+		provider.MessageStatusHeldSchedule, provider.MessageStatusRateLimited},
+}
+
+// Payload is the input of a bulk operation job
+type Payload struct {
+	Action  string                       `json:"action"`
+	Filter  provider.BulkOperationFilter `json:"filter"`
+	Matched int                          `json:"matched"` // messages matching the filter when the job was queued
+}
+
+// Result counts the messages of a bulk operation job, reported while it runs and when it finished
+type Result struct {
+	Matched   int `json:"matched"`
+	Processed int `json:"processed"` // messages looked at so far
+	Affected  int `json:"affected"`  // messages requeued or cancelled
+	Skipped   int `json:"skipped"`   // messages that changed status before the job reached them
+}
+
+// IBulkOperationUseCase defines the interface for bulk requeue and cancel operations
+type IBulkOperationUseCase interface {
+	// Start validates a bulk operation and queues it as a job
+	Start(action string, filter provider.BulkOperationFilter, createdBy int) (*provider.Job, *Payload, error)
+	// Run is the job handler running bulk operations
+	Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+}
+
+// BulkOperationUseCase implements the IBulkOperationUseCase interface
+type BulkOperationUseCase struct {
+	jobQueue                            jobs.Queue
+	messageTransactionRepository        providerRepo.MessageTransactionRepositoryInterface
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	Logger                              *logger.Logger
+}
+
+// NewBulkOperationUseCase creates a new BulkOperationUseCase
+func NewBulkOperationUseCase(
+	jobQueue jobs.Queue,
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	loggerInstance *logger.Logger,
+) IBulkOperationUseCase {
+	return &BulkOperationUseCase{
+		jobQueue:                            jobQueue,
+		messageTransactionRepository:        messageTransactionRepository,
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		Logger:                              loggerInstance,
+	}
+}
+
+func (b *BulkOperationUseCase) Start(action string, filter provider.BulkOperationFilter, createdBy int) (*provider.Job, *Payload, error) {
+	statuses, ok := actionStatuses[action]
+	if !ok {
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("action must be %s or %s", provider.BulkActionRequeue, provider.BulkActionCancel), domainErrors.ValidationError)
+	}
+	if !containsStatus(statuses, filter.Status) {
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("%s applies to messages with status %s", action, strings.Join(statuses, ", ")), domainErrors.ValidationError)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, nil, domainErrors.NewAppError(errors.New("from must be before to"), domainErrors.ValidationError)
+	}
+
+	matched, err := b.messageTransactionRepository.CountForBulk(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload := &Payload{Action: action, Filter: filter, Matched: matched}
+	job, err := b.jobQueue.Enqueue(JobType, payload, createdBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Logger.Info("Queued bulk operation", zap.Int("jobID", job.ID), zap.String("action", action),
+		zap.String("status", filter.Status), zap.Int("matched", matched), zap.Int("createdBy", createdBy))
+	return job, payload, nil
+}
+
+// Run changes the matching messages batch by batch, reporting the progress after each batch. Messages that
+// changed status since they were selected, e.g. because a worker picked them up, are skipped. Running a bulk
+// operation again, e.g. on retry, only changes the messages still matching.
+func (b *BulkOperationUseCase) Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	var payload Payload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return nil, jobs.Permanent(fmt.Errorf("invalid bulk operation payload: %w", err))
+	}
+
+	result := &Result{Matched: payload.Matched}
+	afterID := 0
+	for ctx.Err() == nil {
+		ids, err := b.messageTransactionRepository.GetIDsForBulk(payload.Filter, afterID, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		changed, err := b.apply(payload.Action, ids, payload.Filter.Status)
+		if err != nil {
+			return result, err
+		}
+		afterID = ids[len(ids)-1]
+		result.Processed += len(ids)
+		result.Affected += len(changed)
+		result.Skipped = result.Processed - result.Affected
+		progress.Report(percentDone(result), result)
+		if len(ids) < batchSize {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	b.Logger.Info("Bulk operation completed", zap.Int("jobID", job.ID), zap.Int("processed", result.Processed), zap.Int("affected", result.Affected))
+	return result, nil
+}
+
+// percentDone estimates the progress from the messages matched when the job was queued, messages queued since
+// can make the job run longer
+func percentDone(result *Result) int {
+	if result.Matched == 0 {
+		return 99
+	}
+	return min(result.Processed*100/result.Matched, 99)
+}
+
+// apply requeues or cancels a batch of messages and returns the IDs it changed. Cancelled messages are copied
+// to the history like messages that finished sending.
+func (b *BulkOperationUseCase) apply(action string, ids []int, status string) ([]int, error) {
+	if action == provider.BulkActionRequeue {
+		return b.messageTransactionRepository.RequeueBatch(ids, status)
+	}
+	changed, err := b.messageTransactionRepository.CancelBatch(ids, status)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.messageTransactionRepository.MoveToHistoryBatch(changed, b.messageTransactionHistoryRepository); err != nil {
+		b.Logger.Error("Error moving cancelled messages to history", zap.Error(err), zap.Int("count", len(changed)))
+	}
+	return changed, nil
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package bulkoperation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJobQueue struct {
+	jobType   string
+	payload   []byte
+	createdBy int
+}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	m.jobType = jobType
+	m.payload = payloadJSON
+	m.createdBy = createdBy
+	return &provider.Job{ID: 1, Type: jobType, Payload: string(payloadJSON), Status: providerRepo.JobStatusQueued, CreatedBy: createdBy}, nil
+}
+
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	ids       []int
+	skip      map[int]bool // messages that changed status since they were selected
+	requeued  []int
+	cancelled []int
+	history   []int
+	err       error
+}
+
+func (m *mockMessageTransactionRepository) CountForBulk(filter provider.BulkOperationFilter) (int, error) {
+	return len(m.ids), nil
+}
+
+func (m *mockMessageTransactionRepository) GetIDsForBulk(filter provider.BulkOperationFilter, afterID int, limit int) ([]int, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var ids []int
+	for _, id := range m.ids {
+		if id > afterID && len(ids) < limit {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockMessageTransactionRepository) changed(ids []int) []int {
+	var changed []int
+	for _, id := range ids {
+		if !m.skip[id] {
+			changed = append(changed, id)
+		}
+	}
+	return changed
+}
+
+func (m *mockMessageTransactionRepository) RequeueBatch(ids []int, status string) ([]int, error) {
+	changed := m.changed(ids)
+	m.requeued = append(m.requeued, changed...)
+	return changed, nil
+}
+
+func (m *mockMessageTransactionRepository) CancelBatch(ids []int, status string) ([]int, error) {
+	changed := m.changed(ids)
+	m.cancelled = append(m.cancelled, changed...)
+	return changed, nil
+}
+
+func (m *mockMessageTransactionRepository) MoveToHistoryBatch(ids []int, historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface) error {
+	m.history = append(m.history, ids...)
+	return nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+// startJob queues a bulk operation and returns the job the runner would pass to Run
+func startJob(t *testing.T, useCase IBulkOperationUseCase, action string, filter provider.BulkOperationFilter) *provider.Job {
+	job, _, err := useCase.Start(action, filter, 1)
+	assert.NoError(t, err)
+	return job
+}
+
+func TestStart_Validation(t *testing.T) {
+	queue := &mockJobQueue{}
+	useCase := NewBulkOperationUseCase(queue, &mockMessageTransactionRepository{}, nil, setupLogger(t))
+	from := time.Now()
+	to := from.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		action string
+		filter provider.BulkOperationFilter
+	}{
+		{"unknown action", "delete", provider.BulkOperationFilter{Status: "failed"}},
+		{"requeue pending", provider.BulkActionRequeue, provider.BulkOperationFilter{Status: "pending"}},
+		{"cancel sent", provider.BulkActionCancel, provider.BulkOperationFilter{Status: "success"}},
+		{"empty range", provider.BulkActionCancel, provider.BulkOperationFilter{Status: "failed", From: &from, To: &to}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := useCase.Start(tt.action, tt.filter, 1)
+			var appErr *domainErrors.AppError
+			assert.True(t, errors.As(err, &appErr))
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		})
+	}
+	assert.Empty(t, queue.jobType)
+}
+
+func TestStart_QueuesJob(t *testing.T) {
+	queue := &mockJobQueue{}
+	messages := &mockMessageTransactionRepository{ids: []int{1, 2, 3}}
+	useCase := NewBulkOperationUseCase(queue, messages, nil, setupLogger(t))
+
+	job, payload, err := useCase.Start(provider.BulkActionRequeue, provider.BulkOperationFilter{Status: "failed", ProviderID: 2}, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+	assert.Equal(t, 9, queue.createdBy)
+	assert.Equal(t, 3, payload.Matched)
+	assert.JSONEq(t, `{"action":"requeue","filter":{"status":"failed","provider_id":2},"matched":3}`, string(queue.payload))
+	assert.Empty(t, messages.requeued)
+}
+
+func TestRun_RequeuesInBatches(t *testing.T) {
+	messages := &mockMessageTransactionRepository{skip: map[int]bool{7: true}}
+	for id := 1; id <= batchSize+3; id++ {
+		messages.ids = append(messages.ids, id)
+	}
+	useCase := NewBulkOperationUseCase(&mockJobQueue{}, messages, nil, setupLogger(t))
+	job := startJob(t, useCase, provider.BulkActionRequeue, provider.BulkOperationFilter{Status: "failed"})
+
+	result, err := useCase.Run(context.Background(), job, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Matched: batchSize + 3, Processed: batchSize + 3, Affected: batchSize + 2, Skipped: 1}, result)
+	assert.Len(t, messages.requeued, batchSize+2)
+	assert.NotContains(t, messages.requeued, 7)
+	assert.Empty(t, messages.cancelled)
+}
+
+func TestRun_CancelMovesToHistory(t *testing.T) {
+	messages := &mockMessageTransactionRepository{ids: []int{3, 4, 5}, skip: map[int]bool{4: true}}
+	useCase := NewBulkOperationUseCase(&mockJobQueue{}, messages, nil, setupLogger(t))
+	job := startJob(t, useCase, provider.BulkActionCancel, provider.BulkOperationFilter{Status: "pending"})
+
+	result, err := useCase.Run(context.Background(), job, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Matched: 3, Processed: 3, Affected: 2, Skipped: 1}, result)
+	assert.Equal(t, []int{3, 5}, messages.cancelled)
+	assert.Equal(t, []int{3, 5}, messages.history)
+}
+
+func TestRun_ReturnsError(t *testing.T) {
+	messages := &mockMessageTransactionRepository{}
+	useCase := NewBulkOperationUseCase(&mockJobQueue{}, messages, nil, setupLogger(t))
+	job := startJob(t, useCase, provider.BulkActionCancel, provider.BulkOperationFilter{Status: "held"})
+
+	messages.err = errors.New("database unavailable")
+	_, err := useCase.Run(context.Background(), job, nil)
+	assert.EqualError(t, err, "database unavailable")
+}
+
+func TestRun_StopsWhenCancelled(t *testing.T) {
+	messages := &mockMessageTransactionRepository{ids: []int{1, 2}}
+	useCase := NewBulkOperationUseCase(&mockJobQueue{}, messages, nil, setupLogger(t))
+	job := startJob(t, useCase, provider.BulkActionRequeue, provider.BulkOperationFilter{Status: "failed"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := useCase.Run(ctx, job, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, messages.requeued)
+}
+
+func TestRun_InvalidPayload(t *testing.T) {
+	useCase := NewBulkOperationUseCase(&mockJobQueue{}, &mockMessageTransactionRepository{}, nil, setupLogger(t))
+
+	_, err := useCase.Run(context.Background(), &provider.Job{ID: 1, Type: JobType, Payload: "{"}, nil)
+	assert.ErrorContains(t, err, "invalid bulk operation payload")
+}
@@ -0,0 +1,82 @@
+package contenttransform
+
+import (
+	"fmt"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/transform"
+
+	"go.uber.org/zap"
+)
+
+// TransformValidator checks the type and config of a content transformation
+type TransformValidator interface {
+	Validate(t *provider.ContentTransform) error
+}
+
+// ContentTransformer applies the content transformations of a user to a message
+type ContentTransformer interface {
+	Transform(userID int, messageType string, message string, dryRun bool) (string, error)
+}
+
+// IContentTransformUseCase defines the interface for content transformation use cases
+type IContentTransformUseCase interface {
+	GetTransforms(userID int) (*[]provider.ContentTransform, error)
+	// SetTransforms replaces the transformations of a user, they are applied in the order given
+	SetTransforms(userID int, transforms []provider.ContentTransform) (*[]provider.ContentTransform, error)
+	// Test applies the stored transformations of a user to a message without storing anything, URLs aren't
+	// shortened
+	Test(userID int, messageType string, message string) (string, error)
+}
+
+// ContentTransformUseCase implements the IContentTransformUseCase interface
+type ContentTransformUseCase struct {
+	contentTransformRepository providerRepo.ContentTransformRepositoryInterface
+	validator                  TransformValidator
+	transformer                ContentTransformer
+	Logger                     *logger.Logger
+}
+
+// NewContentTransformUseCase creates a new ContentTransformUseCase
+func NewContentTransformUseCase(
+	contentTransformRepository providerRepo.ContentTransformRepositoryInterface,
+	validator TransformValidator,
+	transformer ContentTransformer,
+	loggerInstance *logger.Logger,
+) IContentTransformUseCase {
+	return &ContentTransformUseCase{
+		contentTransformRepository: contentTransformRepository,
+		validator:                  validator,
+		transformer:                transformer,
+		Logger:                     loggerInstance,
+	}
+}
+
+func (c *ContentTransformUseCase) GetTransforms(userID int) (*[]provider.ContentTransform, error) {
+	return c.contentTransformRepository.GetByUserID(userID)
+}
+
+func (c *ContentTransformUseCase) SetTransforms(userID int, transforms []provider.ContentTransform) (*[]provider.ContentTransform, error) {
+	if len(transforms) > transform.MaxTransforms {
+		return nil, domainErrors.NewAppError(fmt.Errorf("a user can have at most %d content transforms", transform.MaxTransforms), domainErrors.ValidationError)
+	}
+	for i := range transforms {
+		transforms[i].UserID = userID
+		if err := c.validator.Validate(&transforms[i]); err != nil {
+			return nil, domainErrors.NewAppError(fmt.Errorf("transforms[%d]: %w", i, err), domainErrors.ValidationError)
+		}
+	}
+	saved, err := c.contentTransformRepository.ReplaceForUser(userID, transforms)
+	if err != nil {
+		return nil, err
+	}
+	c.Logger.Info("Content transforms set", zap.Int("userID", userID), zap.Int("count", len(transforms)))
+	return saved, nil
+}
+
+func (c *ContentTransformUseCase) Test(userID int, messageType string, message string) (string, error) {
+	return c.transformer.Transform(userID, messageType, message, true)
+}
@@ -0,0 +1,299 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-multi-chat-api/src/domain"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/control"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusExecuted = "executed"
+	StatusFailed   = "failed"
+	StatusInvalid  = "invalid"
+	StatusRejected = "rejected"
+
+	// maxTextLength bounds the message stored in the audit log
+	maxTextLength = 1024
+)
+
+// providerPattern matches the ID or the name of a provider
+var providerPattern = regexp.MustCompile(`^[\w.-]{1,64}$`)
+
+// Arg describes an argument of a command, its value must match the pattern
+type Arg struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Command is the schema of a control command, messages must name the command and give exactly its arguments
+type Command struct {
+	Name        string
+	Args        []Arg
+	Description string
+}
+
+// Usage returns how the command is written, without the prefix
+func (c Command) Usage() string {
+	usage := c.Name
+	for _, arg := range c.Args {
+		usage += " <" + arg.Name + ">"
+	}
+	return usage
+}
+
+// Commands lists the control commands operators can send
+var Commands = []Command{
+	{Name: "help", Description: "lists the commands"},
+	{Name: "status", Description: "reports the queue and the providers"},
+	{Name: "pause", Args: []Arg{{Name: "provider", Pattern: providerPattern}}, Description: "takes a provider out of routing"},
+	{Name: "resume", Args: []Arg{{Name: "provider", Pattern: providerPattern}}, Description: "puts a paused provider back into routing"},
+}
+
+// Parse validates a control message, without the prefix, against the schema of its command
+func Parse(text string) (*Command, map[string]string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, nil, errors.New("empty command")
+	}
+	name := strings.ToLower(fields[0])
+	for _, command := range Commands {
+		if command.Name != name {
+			continue
+		}
+		if len(fields)-1 != len(command.Args) {
+			return &command, nil, fmt.Errorf("usage: %s", command.Usage())
+		}
+		args := make(map[string]string, len(command.Args))
+		for i, arg := range command.Args {
+			value := fields[i+1]
+			if !arg.Pattern.MatchString(value) {
+				return &command, nil, fmt.Errorf("invalid %s %q, usage: %s", arg.Name, value, command.Usage())
+			}
+			args[arg.Name] = value
+		}
+		return &command, args, nil
+	}
+	return nil, nil, fmt.Errorf("unknown command %q, send help for the commands", fields[0])
+}
+
+// ReplySender sends the replies to the operators
+type ReplySender interface {
+	Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error)
+}
+
+// QueueMetricsSource counts the active messages per queue state
+type QueueMetricsSource interface {
+	GetQueueMetrics() (*provider.QueueMetrics, error)
+}
+
+// IControlUseCase defines the interface for the control commands operators send through Signal
+type IControlUseCase interface {
+	// Handle runs a message received on the Signal number if it is a control message and reports whether it
+	// was one, control messages aren't routed any further
+	Handle(sender string, senderUUID string, text string) bool
+	GetCommands(page domain.PageRequest) (*domain.Page[provider.ControlCommand], error)
+}
+
+// ControlUseCase implements the IControlUseCase interface
+type ControlUseCase struct {
+	controlCommandRepository providerRepo.ControlCommandRepositoryInterface
+	providerRepository       providerRepo.ProviderRepositoryInterface
+	queueMetrics             QueueMetricsSource
+	replySender              ReplySender
+	config                   control.Config
+	Logger                   *logger.Logger
+}
+
+// NewControlUseCase creates a new ControlUseCase
+func NewControlUseCase(
+	controlCommandRepository providerRepo.ControlCommandRepositoryInterface,
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	queueMetrics QueueMetricsSource,
+	replySender ReplySender,
+	config control.Config,
+	loggerInstance *logger.Logger,
+) IControlUseCase {
+	return &ControlUseCase{
+		controlCommandRepository: controlCommandRepository,
+		providerRepository:       providerRepository,
+		queueMetrics:             queueMetrics,
+		replySender:              replySender,
+		config:                   config,
+		Logger:                   loggerInstance,
+	}
+}
+
+// Handle authenticates the sender of a control message, runs its command, replies with the result and
+// records it in the audit log. Messages of other numbers aren't control messages. Operators whose Signal
+// account doesn't match the pinned one get no reply, so the rejection tells an impostor nothing.
+func (c *ControlUseCase) Handle(sender string, senderUUID string, text string) bool {
+	if !c.config.Enabled() || !c.config.IsOperator(sender) {
+		return false
+	}
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, c.config.Prefix) {
+		return false
+	}
+
+	record := &provider.ControlCommand{
+		Sender:     sender,
+		SenderUUID: senderUUID,
+		Text:       truncate(text, maxTextLength),
+	}
+	fields := []zap.Field{zap.String("sender", sender), zap.String("senderUUID", senderUUID)}
+
+	if err := c.config.Verify(sender, senderUUID); err != nil {
+		record.Status = StatusRejected
+		record.Result = err.Error()
+		c.Logger.Warn("Rejected control command", append(fields, zap.Error(err))...)
+		c.audit(record)
+		return true
+	}
+
+	command, args, err := Parse(strings.TrimPrefix(text, c.config.Prefix))
+	if command != nil {
+		record.Command = command.Name
+	}
+	if err != nil {
+		record.Status = StatusInvalid
+		record.Result = err.Error()
+	} else if result, err := c.run(command.Name, args); err != nil {
+		record.Status = StatusFailed
+		record.Result = err.Error()
+	} else {
+		record.Status = StatusExecuted
+		record.Result = result
+	}
+	c.Logger.Info("Handled control command", append(fields, zap.String("command", record.Command), zap.String("status", record.Status))...)
+
+	c.audit(record)
+	c.reply(sender, record.Result)
+	return true
+}
+
+// GetCommands returns a page of the audit log of control commands, newest first
+func (c *ControlUseCase) GetCommands(page domain.PageRequest) (*domain.Page[provider.ControlCommand], error) {
+	return c.controlCommandRepository.GetAll(page.WithDefaults())
+}
+
+func (c *ControlUseCase) run(name string, args map[string]string) (string, error) {
+	switch name {
+	case "help":
+		return c.help(), nil
+	case "status":
+		return c.status()
+	case "pause":
+		return c.setProviderStatus(args["provider"], false)
+	case "resume":
+		return c.setProviderStatus(args["provider"], true)
+	}
+	return "", fmt.Errorf("unknown command %q", name)
+}
+
+func (c *ControlUseCase) help() string {
+	lines := make([]string, len(Commands))
+	for i, command := range Commands {
+		lines[i] = c.config.Prefix + command.Usage() + " - " + command.Description
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *ControlUseCase) status() (string, error) {
+	metrics, err := c.queueMetrics.GetQueueMetrics()
+	if err != nil {
+		return "", errors.New("the queue metrics can't be read")
+	}
+	providers, err := c.providerRepository.GetAll()
+	if err != nil {
+		return "", errors.New("the providers can't be read")
+	}
+
+	queue := fmt.Sprintf("queue: %d pending, %d processing, %d awaiting retry, %d held",
+		metrics.Pending, metrics.Processing, metrics.FailedAwaitingRetry, metrics.Held)
+	if metrics.OldestPendingAt != nil {
+		queue += fmt.Sprintf(", oldest pending for %s", time.Since(*metrics.OldestPendingAt).Round(time.Second))
+	}
+	lines := []string{queue, "providers:"}
+	for _, p := range *providers {
+		state := "active"
+		if !p.Status {
+			state = "paused"
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s (%s) %s", p.ID, p.Name, p.Type, state))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// setProviderStatus pauses or resumes the provider with the given ID or name
+func (c *ControlUseCase) setProviderStatus(nameOrID string, active bool) (string, error) {
+	providers, err := c.providerRepository.GetAll()
+	if err != nil {
+		return "", errors.New("the providers can't be read")
+	}
+	target := findProvider(*providers, nameOrID)
+	if target == nil {
+		return "", fmt.Errorf("no provider %q", nameOrID)
+	}
+
+	state, verb := "paused", "paused"
+	if active {
+		state, verb = "active", "resumed"
+	}
+	if target.Status == active {
+		return fmt.Sprintf("provider #%d %s is already %s", target.ID, target.Name, state), nil
+	}
+	if _, err := c.providerRepository.Update(target.ID, map[string]interface{}{"status": active}); err != nil {
+		return "", fmt.Errorf("provider #%d %s couldn't be %s", target.ID, target.Name, verb)
+	}
+	return fmt.Sprintf("%s provider #%d %s", verb, target.ID, target.Name), nil
+}
+
+func findProvider(providers []provider.Provider, nameOrID string) *provider.Provider {
+	id, err := strconv.Atoi(nameOrID)
+	for i, p := range providers {
+		if (err == nil && p.ID == id) || strings.EqualFold(p.Name, nameOrID) {
+			return &providers[i]
+		}
+	}
+	return nil
+}
+
+func (c *ControlUseCase) audit(record *provider.ControlCommand) {
+	// The repository logs the failure, the command already ran
+	_, _ = c.controlCommandRepository.Create(record)
+}
+
+func (c *ControlUseCase) reply(recipient string, text string) {
+	_, err := c.replySender.Send(domainSignal.SendRequest{
+		Number:     c.config.Number,
+		Recipients: []string{recipient},
+		Message:    text,
+	})
+	if err != nil {
+		c.Logger.Error("Error replying to control command", zap.Error(err), zap.String("sender", recipient))
+	}
+}
+
+// truncate cuts a text to at most length bytes without splitting a character
+func truncate(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+	for length > 0 && !utf8.RuneStart(text[length]) {
+		length--
+	}
+	return text[:length]
+}
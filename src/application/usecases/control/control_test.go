@@ -0,0 +1,210 @@
+package control
+
+import (
+	"errors"
+	"testing"
+
+	"go-multi-chat-api/src/domain"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/control"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	operator     = "+491701234567"
+	operatorUUID = "1c8b0f2e-5d4a-4c3b-9a8e-7f6d5c4b3a21"
+)
+
+type mockControlCommandRepository struct {
+	providerRepo.ControlCommandRepositoryInterface
+	created []provider.ControlCommand
+}
+
+func (m *mockControlCommandRepository) Create(command *provider.ControlCommand) (*provider.ControlCommand, error) {
+	m.created = append(m.created, *command)
+	return command, nil
+}
+
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []provider.Provider
+	updates   map[int]map[string]interface{}
+}
+
+func (m *mockProviderRepository) GetAll() (*[]provider.Provider, error) {
+	return &m.providers, nil
+}
+
+func (m *mockProviderRepository) Update(id int, providerMap map[string]interface{}) (*provider.Provider, error) {
+	if m.updates == nil {
+		m.updates = make(map[int]map[string]interface{})
+	}
+	m.updates[id] = providerMap
+	return &provider.Provider{ID: id}, nil
+}
+
+type mockQueueMetrics struct {
+	err error
+}
+
+func (m *mockQueueMetrics) GetQueueMetrics() (*provider.QueueMetrics, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &provider.QueueMetrics{Pending: 3, Processing: 1, Held: 2}, nil
+}
+
+type mockReplySender struct {
+	sent []domainSignal.SendRequest
+}
+
+func (m *mockReplySender) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	m.sent = append(m.sent, request)
+	return &[]domainSignal.SendResponse{}, nil
+}
+
+type fixture struct {
+	useCase   IControlUseCase
+	commands  *mockControlCommandRepository
+	providers *mockProviderRepository
+	replies   *mockReplySender
+}
+
+func setup(t *testing.T, queueMetrics *mockQueueMetrics) fixture {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	f := fixture{
+		commands: &mockControlCommandRepository{},
+		providers: &mockProviderRepository{providers: []provider.Provider{
+			{ID: 1, Name: "Signal", Type: "signal", Status: true},
+			{ID: 2, Name: "Email", Type: "email", Status: false},
+		}},
+		replies: &mockReplySender{},
+	}
+	config := control.Config{
+		Number:    "+4930123456",
+		Operators: map[string]string{operator: operatorUUID, "+491709876543": ""},
+		Prefix:    "!",
+	}
+	f.useCase = NewControlUseCase(f.commands, f.providers, queueMetrics, f.replies, config, loggerInstance)
+	return f
+}
+
+func TestParse(t *testing.T) {
+	command, args, err := Parse("PAUSE Signal")
+	require.NoError(t, err)
+	assert.Equal(t, "pause", command.Name)
+	assert.Equal(t, map[string]string{"provider": "Signal"}, args)
+
+	_, _, err = Parse("pause")
+	assert.EqualError(t, err, "usage: pause <provider>")
+	_, _, err = Parse("pause Signal now")
+	assert.Error(t, err)
+	_, _, err = Parse("pause 'Signal;'")
+	assert.Error(t, err)
+	command, _, err = Parse("reboot")
+	assert.Nil(t, command)
+	assert.Error(t, err)
+	_, _, err = Parse("")
+	assert.Error(t, err)
+}
+
+func TestHandle_IgnoresOtherMessages(t *testing.T) {
+	f := setup(t, &mockQueueMetrics{})
+
+	assert.False(t, f.useCase.Handle("+15550100", "", "!status"))
+	assert.False(t, f.useCase.Handle(operator, operatorUUID, "ACK"))
+	assert.Empty(t, f.commands.created)
+	assert.Empty(t, f.replies.sent)
+}
+
+func TestHandle_RejectsUnverifiedSender(t *testing.T) {
+	f := setup(t, &mockQueueMetrics{})
+
+	assert.True(t, f.useCase.Handle(operator, "00000000-0000-0000-0000-000000000000", "!pause Signal"))
+	require.Len(t, f.commands.created, 1)
+	assert.Equal(t, StatusRejected, f.commands.created[0].Status)
+	assert.Empty(t, f.replies.sent)
+	assert.Empty(t, f.providers.updates)
+}
+
+func TestHandle_RunsCommands(t *testing.T) {
+	t.Run("pauses a provider by name", func(t *testing.T) {
+		f := setup(t, &mockQueueMetrics{})
+
+		assert.True(t, f.useCase.Handle(operator, operatorUUID, "!pause signal"))
+		assert.Equal(t, map[int]map[string]interface{}{1: {"status": false}}, f.providers.updates)
+		require.Len(t, f.commands.created, 1)
+		assert.Equal(t, provider.ControlCommand{
+			Sender:     operator,
+			SenderUUID: operatorUUID,
+			Text:       "!pause signal",
+			Command:    "pause",
+			Status:     StatusExecuted,
+			Result:     "paused provider #1 Signal",
+		}, f.commands.created[0])
+		require.Len(t, f.replies.sent, 1)
+		assert.Equal(t, []string{operator}, f.replies.sent[0].Recipients)
+		assert.Equal(t, "+4930123456", f.replies.sent[0].Number)
+		assert.Equal(t, "paused provider #1 Signal", f.replies.sent[0].Message)
+	})
+
+	t.Run("resumes a provider by id from an unpinned number", func(t *testing.T) {
+		f := setup(t, &mockQueueMetrics{})
+
+		assert.True(t, f.useCase.Handle("+491709876543", "", "!resume 2"))
+		assert.Equal(t, map[int]map[string]interface{}{2: {"status": true}}, f.providers.updates)
+		assert.Equal(t, StatusExecuted, f.commands.created[0].Status)
+	})
+
+	t.Run("reports the status", func(t *testing.T) {
+		f := setup(t, &mockQueueMetrics{})
+
+		assert.True(t, f.useCase.Handle(operator, operatorUUID, "!status"))
+		assert.Equal(t, "queue: 3 pending, 1 processing, 0 awaiting retry, 2 held\nproviders:\n#1 Signal (signal) active\n#2 Email (email) paused", f.replies.sent[0].Message)
+	})
+
+	t.Run("records failed commands", func(t *testing.T) {
+		f := setup(t, &mockQueueMetrics{err: errors.New("database is down")})
+
+		assert.True(t, f.useCase.Handle(operator, operatorUUID, "!status"))
+		assert.Equal(t, StatusFailed, f.commands.created[0].Status)
+		assert.Equal(t, "the queue metrics can't be read", f.replies.sent[0].Message)
+	})
+
+	t.Run("answers invalid commands with the usage", func(t *testing.T) {
+		f := setup(t, &mockQueueMetrics{})
+
+		assert.True(t, f.useCase.Handle(operator, operatorUUID, "!pause"))
+		assert.Equal(t, StatusInvalid, f.commands.created[0].Status)
+		assert.Equal(t, "pause", f.commands.created[0].Command)
+		assert.Equal(t, "usage: pause <provider>", f.replies.sent[0].Message)
+		assert.Empty(t, f.providers.updates)
+	})
+}
+
+func TestGetCommands_AppliesPageDefaults(t *testing.T) {
+	repository := &pagingRepository{}
+	useCase := NewControlUseCase(repository, &mockProviderRepository{}, &mockQueueMetrics{}, &mockReplySender{}, control.Config{}, nil)
+
+	_, err := useCase.GetCommands(domain.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DefaultPageLimit, repository.page.Limit)
+}
+
+type pagingRepository struct {
+	providerRepo.ControlCommandRepositoryInterface
+	page domain.PageRequest
+}
+
+func (m *pagingRepository) GetAll(page domain.PageRequest) (*domain.Page[provider.ControlCommand], error) {
+	m.page = page
+	return &domain.Page[provider.ControlCommand]{}, nil
+}
@@ -0,0 +1,189 @@
+package conversation
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// rebuildBatchSize is the number of message transactions read at once by a rebuild
+const rebuildBatchSize = 500
+
+// IConversationUseCase defines the interface for the conversation read model
+type IConversationUseCase interface {
+	GetConversations(userID int, channel string, limit int) (*[]provider.Conversation, error)
+	GetMessages(userID int, conversationID int, page domain.PageRequest) (*domain.Page[provider.ConversationMessage], error)
+	// Project applies a message event published on the event bus to the conversations
+	Project(event *provider.MessageEvent)
+	// Rebuild replays the message transactions of a user, or of every user when userID is 0, into the
+	// conversations in the background
+	Rebuild(userID int) (*RebuildStatus, error)
+	GetRebuildStatus() *RebuildStatus
+}
+
+// RebuildStatus reports the last rebuild started on this instance
+type RebuildStatus struct {
+	Running    bool
+	UserID     int
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	Messages   int // message transactions replayed so far
+	Error      string
+}
+
+// ConversationUseCase implements the IConversationUseCase interface
+type ConversationUseCase struct {
+	conversationRepository       providerRepo.ConversationRepositoryInterface
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	providerRepository           providerRepo.ProviderRepositoryInterface
+	Logger                       *logger.Logger
+
+	// channels caches the type of the providers outbound messages are sent with
+	channelsMu sync.Mutex
+	channels   map[int]string
+
+	rebuildMu sync.Mutex
+	rebuild   RebuildStatus
+}
+
+// NewConversationUseCase creates a new ConversationUseCase
+func NewConversationUseCase(
+	conversationRepository providerRepo.ConversationRepositoryInterface,
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	loggerInstance *logger.Logger,
+) IConversationUseCase {
+	return &ConversationUseCase{
+		conversationRepository:       conversationRepository,
+		messageTransactionRepository: messageTransactionRepository,
+		providerRepository:           providerRepository,
+		Logger:                       loggerInstance,
+		channels:                     map[int]string{},
+	}
+}
+
+// GetConversations returns the conversations of a user with the most recent activity first
+func (c *ConversationUseCase) GetConversations(userID int, channel string, limit int) (*[]provider.Conversation, error) {
+	return c.conversationRepository.GetUserConversations(userID, channel, limit)
+}
+
+// GetMessages returns a page of the messages of a conversation of the user, newest first. Conversations of
+// other users are not found.
+func (c *ConversationUseCase) GetMessages(userID int, conversationID int, page domain.PageRequest) (*domain.Page[provider.ConversationMessage], error) {
+	conversation, err := c.conversationRepository.GetByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.UserID != userID {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return c.conversationRepository.GetMessages(conversationID, page.WithDefaults())
+}
+
+// Project applies a message event to the conversations. A failure leaves the conversations behind until the
+// next event of the message or a rebuild.
+func (c *ConversationUseCase) Project(event *provider.MessageEvent) {
+	if err := c.project(event); err != nil {
+		c.Logger.Error("Error projecting message into conversations", zap.Error(err),
+			zap.Int("userID", event.UserID), zap.Int("messageID", event.MessageID), zap.String("externalID", event.ExternalID))
+	}
+}
+
+func (c *ConversationUseCase) project(event *provider.MessageEvent) error {
+	if event.Channel == "" {
+		channel, err := c.channelOf(event.ProviderID)
+		if err != nil {
+			return err
+		}
+		event.Channel = channel
+	}
+	return c.conversationRepository.Apply(event)
+}
+
+// channelOf returns the type of a provider, provider types don't change once messages were sent with them
+func (c *ConversationUseCase) channelOf(providerID int) (string, error) {
+	c.channelsMu.Lock()
+	defer c.channelsMu.Unlock()
+	if channel, ok := c.channels[providerID]; ok {
+		return channel, nil
+	}
+	providerDetails, err := c.providerRepository.GetByID(providerID)
+	if err != nil {
+		return "", err
+	}
+	c.channels[providerID] = providerDetails.Type
+	return providerDetails.Type, nil
+}
+
+// Rebuild starts replaying the message transactions into the conversations. Replaying is idempotent, so it
+// repairs conversations that missed events while new events keep being projected. Received messages are only
+// known from their events and are kept as they are.
+func (c *ConversationUseCase) Rebuild(userID int) (*RebuildStatus, error) {
+	c.rebuildMu.Lock()
+	defer c.rebuildMu.Unlock()
+	if c.rebuild.Running {
+		return nil, domainErrors.NewAppError(errors.New("a conversation rebuild is running already"), domainErrors.Conflict)
+	}
+	now := time.Now()
+	c.rebuild = RebuildStatus{Running: true, UserID: userID, StartedAt: &now}
+	status := c.rebuild
+
+	go c.runRebuild(userID)
+	return &status, nil
+}
+
+func (c *ConversationUseCase) runRebuild(userID int) {
+	c.Logger.Info("Rebuilding conversations", zap.Int("userID", userID))
+	afterID := 0
+	var rebuildErr error
+	for {
+		messageTransactions, err := c.messageTransactionRepository.GetAfterID(afterID, userID, rebuildBatchSize)
+		if err != nil {
+			rebuildErr = err
+			break
+		}
+		for i := range *messageTransactions {
+			msg := &(*messageTransactions)[i]
+			if err := c.project(messaging.NewOutboundMessageEvent(msg, msg.Status)); err != nil {
+				rebuildErr = err
+				break
+			}
+			afterID = msg.ID
+			c.rebuildMu.Lock()
+			c.rebuild.Messages++
+			c.rebuildMu.Unlock()
+		}
+		if rebuildErr != nil || len(*messageTransactions) < rebuildBatchSize {
+			break
+		}
+	}
+
+	c.rebuildMu.Lock()
+	defer c.rebuildMu.Unlock()
+	now := time.Now()
+	c.rebuild.Running = false
+	c.rebuild.FinishedAt = &now
+	if rebuildErr != nil {
+		c.rebuild.Error = rebuildErr.Error()
+		c.Logger.Error("Error rebuilding conversations", zap.Error(rebuildErr), zap.Int("userID", userID), zap.Int("afterID", afterID))
+		return
+	}
+	c.Logger.Info("Conversations rebuilt", zap.Int("userID", userID), zap.Int("messages", c.rebuild.Messages))
+}
+
+// GetRebuildStatus returns the status of the last rebuild started on this instance
+func (c *ConversationUseCase) GetRebuildStatus() *RebuildStatus {
+	c.rebuildMu.Lock()
+	defer c.rebuildMu.Unlock()
+	status := c.rebuild
+	return &status
+}
@@ -0,0 +1,175 @@
+package conversation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConversationRepository struct {
+	mu           sync.Mutex
+	applied      []provider.MessageEvent
+	conversation provider.Conversation
+}
+
+func (m *mockConversationRepository) Apply(event *provider.MessageEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = append(m.applied, *event)
+	return nil
+}
+
+func (m *mockConversationRepository) GetUserConversations(userID int, channel string, limit int) (*[]provider.Conversation, error) {
+	return &[]provider.Conversation{m.conversation}, nil
+}
+
+func (m *mockConversationRepository) GetByID(id int) (*provider.Conversation, error) {
+	if id != m.conversation.ID {
+		return &provider.Conversation{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &m.conversation, nil
+}
+
+func (m *mockConversationRepository) GetMessages(conversationID int, page domain.PageRequest) (*domain.Page[provider.ConversationMessage], error) {
+	return &domain.Page[provider.ConversationMessage]{Items: []provider.ConversationMessage{{ConversationID: conversationID}}}, nil
+}
+
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	lookups int
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	m.lookups++
+	return &provider.Provider{ID: id, Type: "sms"}, nil
+}
+
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	messages []provider.MessageTransaction
+	err      error
+	release  chan struct{}
+}
+
+func (m *mockMessageTransactionRepository) GetAfterID(afterID int, userID int, limit int) (*[]provider.MessageTransaction, error) {
+	if m.release != nil {
+		<-m.release
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	var result []provider.MessageTransaction
+	for _, msg := range m.messages {
+		if msg.ID > afterID && (userID == 0 || msg.UserID == userID) && len(result) < limit {
+			result = append(result, msg)
+		}
+	}
+	return &result, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func waitForRebuild(t *testing.T, useCase IConversationUseCase) *RebuildStatus {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := useCase.GetRebuildStatus(); !status.Running {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("rebuild did not finish")
+	return nil
+}
+
+func TestProject_ResolvesAndCachesChannel(t *testing.T) {
+	conversations := &mockConversationRepository{}
+	providers := &mockProviderRepository{}
+	useCase := NewConversationUseCase(conversations, &mockMessageTransactionRepository{}, providers, setupLogger(t))
+
+	useCase.Project(&provider.MessageEvent{UserID: 1, ProviderID: 4, MessageID: 1, Direction: provider.DirectionOutbound})
+	useCase.Project(&provider.MessageEvent{UserID: 1, ProviderID: 4, MessageID: 2, Direction: provider.DirectionOutbound})
+	useCase.Project(&provider.MessageEvent{UserID: 1, Channel: "matrix", ExternalID: "$e", Direction: provider.DirectionInbound})
+
+	assert.Len(t, conversations.applied, 3)
+	assert.Equal(t, "sms", conversations.applied[0].Channel)
+	assert.Equal(t, "sms", conversations.applied[1].Channel)
+	assert.Equal(t, "matrix", conversations.applied[2].Channel)
+	assert.Equal(t, 1, providers.lookups)
+}
+
+func TestGetMessages_OtherUser(t *testing.T) {
+	conversations := &mockConversationRepository{conversation: provider.Conversation{ID: 3, UserID: 1}}
+	useCase := NewConversationUseCase(conversations, &mockMessageTransactionRepository{}, &mockProviderRepository{}, setupLogger(t))
+
+	messages, err := useCase.GetMessages(1, 3, domain.PageRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, messages.Items, 1)
+
+	_, err = useCase.GetMessages(2, 3, domain.PageRequest{})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestRebuild_ReplaysMessageTransactions(t *testing.T) {
+	conversations := &mockConversationRepository{}
+	messageTransactions := &mockMessageTransactionRepository{messages: []provider.MessageTransaction{
+		{ID: 1, UserID: 1, ProviderID: 4, Recipients: `["+15550001"]`, Message: "hello", Status: "success"},
+		{ID: 2, UserID: 2, ProviderID: 4, Recipients: `["+15550002"]`, Message: "other user", Status: "success"},
+		{ID: 3, UserID: 1, ProviderID: 4, Recipients: `["+15550001","+15550003"]`, Message: "again", Status: "failed"},
+	}}
+	useCase := NewConversationUseCase(conversations, messageTransactions, &mockProviderRepository{}, setupLogger(t))
+
+	started, err := useCase.Rebuild(1)
+	assert.NoError(t, err)
+	assert.True(t, started.Running)
+
+	status := waitForRebuild(t, useCase)
+	assert.Equal(t, 2, status.Messages)
+	assert.Empty(t, status.Error)
+	assert.NotNil(t, status.FinishedAt)
+	assert.Len(t, conversations.applied, 2)
+	assert.Equal(t, []string{"+15550001", "+15550003"}, conversations.applied[1].Participants)
+	assert.Equal(t, "failed", conversations.applied[1].Status)
+	assert.Equal(t, "sms", conversations.applied[1].Channel)
+}
+
+func TestRebuild_ConflictWhileRunning(t *testing.T) {
+	messageTransactions := &mockMessageTransactionRepository{release: make(chan struct{})}
+	useCase := NewConversationUseCase(&mockConversationRepository{}, messageTransactions, &mockProviderRepository{}, setupLogger(t))
+
+	_, err := useCase.Rebuild(0)
+	assert.NoError(t, err)
+	_, err = useCase.Rebuild(0)
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.Conflict, appErr.Type)
+
+	close(messageTransactions.release)
+	waitForRebuild(t, useCase)
+}
+
+func TestRebuild_ReportsError(t *testing.T) {
+	messageTransactions := &mockMessageTransactionRepository{err: errors.New("database unavailable")}
+	useCase := NewConversationUseCase(&mockConversationRepository{}, messageTransactions, &mockProviderRepository{}, setupLogger(t))
+
+	_, err := useCase.Rebuild(0)
+	assert.NoError(t, err)
+	status := waitForRebuild(t, useCase)
+	assert.Equal(t, "database unavailable", status.Error)
+}
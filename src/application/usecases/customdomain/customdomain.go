@@ -0,0 +1,182 @@
+package customdomain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ChallengePrefix is prepended to a custom domain to get the name of the DNS TXT record proving control of it
+	ChallengePrefix = "_multichat-challenge."
+	// challengeValuePrefix is prepended to the verification token in the value of the TXT record
+	challengeValuePrefix = "multichat-verification="
+)
+
+// hostnamePattern matches lowercase DNS hostnames with at least two labels
+var hostnamePattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TXTLookup returns the TXT records of a DNS name, net.LookupTXT by default
+type TXTLookup func(name string) ([]string, error)
+
+// Challenge is the DNS TXT record that proves control of a custom domain
+type Challenge struct {
+	Name  string
+	Value string
+}
+
+// ChallengeOf returns the TXT record that verifies a custom domain
+func ChallengeOf(customDomain *provider.CustomDomain) Challenge {
+	return Challenge{
+		Name:  ChallengePrefix + customDomain.Domain,
+		Value: challengeValuePrefix + customDomain.VerificationToken,
+	}
+}
+
+// ICustomDomainUseCase defines the interface for the custom domains of users
+type ICustomDomainUseCase interface {
+	GetDomain(userID int) (*provider.CustomDomain, error)
+	SetDomain(userID int, domain string) (*provider.CustomDomain, error)
+	Verify(userID int) (*provider.CustomDomain, error)
+	RemoveDomain(userID int) error
+	// VerifiedDomain returns the custom domain of a user once it was verified, or an empty string
+	VerifiedDomain(userID int) (string, error)
+}
+
+// CustomDomainUseCase implements the ICustomDomainUseCase interface
+type CustomDomainUseCase struct {
+	customDomainRepository providerRepo.CustomDomainRepositoryInterface
+	lookupTXT              TXTLookup
+	Logger                 *logger.Logger
+}
+
+// NewCustomDomainUseCase creates a new CustomDomainUseCase, looking up the challenges with lookupTXT or
+// net.LookupTXT when it is nil
+func NewCustomDomainUseCase(
+	customDomainRepository providerRepo.CustomDomainRepositoryInterface,
+	lookupTXT TXTLookup,
+	loggerInstance *logger.Logger,
+) ICustomDomainUseCase {
+	if lookupTXT == nil {
+		lookupTXT = net.LookupTXT
+	}
+	return &CustomDomainUseCase{
+		customDomainRepository: customDomainRepository,
+		lookupTXT:              lookupTXT,
+		Logger:                 loggerInstance,
+	}
+}
+
+// GetDomain returns the custom domain of a user
+func (c *CustomDomainUseCase) GetDomain(userID int) (*provider.CustomDomain, error) {
+	return c.customDomainRepository.GetByUserID(userID)
+}
+
+// SetDomain sets the custom domain of a user with a new verification token. The domain isn't used until it
+// was verified again, even if it didn't change.
+func (c *CustomDomainUseCase) SetDomain(userID int, domain string) (*provider.CustomDomain, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) > 253 || !hostnamePattern.MatchString(domain) {
+		return nil, domainErrors.NewAppError(errors.New("domain must be a hostname like links.example.com"), domainErrors.ValidationError)
+	}
+
+	existing, err := c.customDomainRepository.GetByDomain(domain)
+	if err == nil && existing.UserID != userID {
+		return nil, domainErrors.NewAppError(errors.New("domain is already used by another account"), domainErrors.Conflict)
+	}
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		c.Logger.Error("Error generating custom domain verification token", zap.Error(err))
+		return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	return c.customDomainRepository.Save(&provider.CustomDomain{
+		UserID:            userID,
+		Domain:            domain,
+		VerificationToken: token,
+	})
+}
+
+// Verify looks up the challenge of the custom domain of a user and marks the domain verified when the TXT
+// record holds the verification token
+func (c *CustomDomainUseCase) Verify(userID int) (*provider.CustomDomain, error) {
+	customDomain, err := c.customDomainRepository.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if customDomain.VerifiedAt != nil {
+		return customDomain, nil
+	}
+
+	challenge := ChallengeOf(customDomain)
+	records, err := c.lookupTXT(challenge.Name)
+	if err != nil {
+		c.Logger.Info("Custom domain challenge lookup failed", zap.Error(err), zap.Int("userID", userID), zap.String("domain", customDomain.Domain))
+		return nil, domainErrors.NewAppError(fmt.Errorf("no TXT record found at %s", challenge.Name), domainErrors.ValidationError)
+	}
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == challenge.Value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, domainErrors.NewAppError(fmt.Errorf("the TXT records at %s don't contain %s", challenge.Name, challenge.Value), domainErrors.ValidationError)
+	}
+
+	verifiedAt := time.Now()
+	if err := c.customDomainRepository.MarkVerified(userID, verifiedAt); err != nil {
+		return nil, err
+	}
+	customDomain.VerifiedAt = &verifiedAt
+	c.Logger.Info("Custom domain verified", zap.Int("userID", userID), zap.String("domain", customDomain.Domain))
+	return customDomain, nil
+}
+
+// RemoveDomain removes the custom domain of a user, their links go back to the default domain
+func (c *CustomDomainUseCase) RemoveDomain(userID int) error {
+	return c.customDomainRepository.Delete(userID)
+}
+
+func (c *CustomDomainUseCase) VerifiedDomain(userID int) (string, error) {
+	customDomain, err := c.customDomainRepository.GetByUserID(userID)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if customDomain.VerifiedAt == nil {
+		return "", nil
+	}
+	return customDomain.Domain, nil
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
+
+func generateToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
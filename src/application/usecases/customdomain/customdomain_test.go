@@ -0,0 +1,129 @@
+package customdomain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockCustomDomainRepository struct {
+	providerRepo.CustomDomainRepositoryInterface
+	domains map[int]provider.CustomDomain
+}
+
+func (m *mockCustomDomainRepository) GetByUserID(userID int) (*provider.CustomDomain, error) {
+	customDomain, ok := m.domains[userID]
+	if !ok {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &customDomain, nil
+}
+
+func (m *mockCustomDomainRepository) GetByDomain(domain string) (*provider.CustomDomain, error) {
+	for _, customDomain := range m.domains {
+		if customDomain.Domain == domain {
+			return &customDomain, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockCustomDomainRepository) Save(customDomain *provider.CustomDomain) (*provider.CustomDomain, error) {
+	m.domains[customDomain.UserID] = *customDomain
+	return customDomain, nil
+}
+
+func (m *mockCustomDomainRepository) MarkVerified(userID int, verifiedAt time.Time) error {
+	customDomain := m.domains[userID]
+	customDomain.VerifiedAt = &verifiedAt
+	m.domains[userID] = customDomain
+	return nil
+}
+
+func setup(t *testing.T, records map[string][]string) (ICustomDomainUseCase, *mockCustomDomainRepository) {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	repository := &mockCustomDomainRepository{domains: map[int]provider.CustomDomain{}}
+	lookup := func(name string) ([]string, error) {
+		txt, ok := records[name]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return txt, nil
+	}
+	return NewCustomDomainUseCase(repository, lookup, loggerInstance), repository
+}
+
+func assertErrorType(t *testing.T, err error, errorType domainErrors.ErrorType) {
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, errorType, appErr.Type)
+}
+
+func TestSetDomain(t *testing.T) {
+	useCase, repository := setup(t, nil)
+
+	customDomain, err := useCase.SetDomain(1, " Links.Acme.com. ")
+	require.NoError(t, err)
+	assert.Equal(t, "links.acme.com", customDomain.Domain)
+	assert.Len(t, customDomain.VerificationToken, 32)
+	assert.Equal(t, "_multichat-challenge.links.acme.com", ChallengeOf(customDomain).Name)
+
+	for _, domain := range []string{"localhost", "acme.com/path", "https://acme.com", "-acme.com", ""} {
+		_, err := useCase.SetDomain(1, domain)
+		assertErrorType(t, err, domainErrors.ValidationError)
+	}
+
+	_, err = useCase.SetDomain(2, "links.acme.com")
+	assertErrorType(t, err, domainErrors.Conflict)
+
+	// Setting the domain again starts a new verification
+	verifiedAt := time.Now()
+	repository.domains[1] = provider.CustomDomain{UserID: 1, Domain: "links.acme.com", VerifiedAt: &verifiedAt}
+	customDomain, err = useCase.SetDomain(1, "links.acme.com")
+	require.NoError(t, err)
+	assert.Nil(t, customDomain.VerifiedAt)
+}
+
+func TestVerify(t *testing.T) {
+	records := map[string][]string{}
+	useCase, _ := setup(t, records)
+
+	customDomain, err := useCase.SetDomain(1, "acme.com")
+	require.NoError(t, err)
+	challenge := ChallengeOf(customDomain)
+
+	_, err = useCase.Verify(1)
+	assertErrorType(t, err, domainErrors.ValidationError)
+
+	records[challenge.Name] = []string{"multichat-verification=wrong"}
+	_, err = useCase.Verify(1)
+	assertErrorType(t, err, domainErrors.ValidationError)
+	domain, err := useCase.VerifiedDomain(1)
+	require.NoError(t, err)
+	assert.Empty(t, domain)
+
+	records[challenge.Name] = []string{"v=spf1 -all", challenge.Value}
+	customDomain, err = useCase.Verify(1)
+	require.NoError(t, err)
+	assert.NotNil(t, customDomain.VerifiedAt)
+	domain, err = useCase.VerifiedDomain(1)
+	require.NoError(t, err)
+	assert.Equal(t, "acme.com", domain)
+
+	_, err = useCase.Verify(2)
+	assertErrorType(t, err, domainErrors.NotFound)
+	domain, err = useCase.VerifiedDomain(2)
+	require.NoError(t, err)
+	assert.Empty(t, domain)
+}
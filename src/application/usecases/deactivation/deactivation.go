@@ -0,0 +1,217 @@
+package deactivation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+)
+
+// What happens to the messages a deactivated user still had waiting to be sent
+const (
+	// PendingHold suspends the messages, they are requeued when the user is reactivated
+	PendingHold = "hold"
+	// PendingCancel cancels the messages
+	PendingCancel = "cancel"
+)
+
+// batchSize is the number of messages changed at once
+const batchSize = 500
+
+// pendingStatuses are the statuses of the messages that would still be sent. Messages whose send may have
+// reached the provider are left alone.
+var pendingStatuses = []string{provider.MessageStatusPending, provider.MessageStatusFailed,
+	provider.MessageStatusHeld, provider.MessageStatusHeldSchedule, provider.MessageStatusRateLimited}
+
+// Result reports what a deactivation or reactivation changed
+type Result struct {
+	User      *domainUser.User
+	Messages  int // messages cancelled, suspended or requeued
+	Providers int // user providers disabled or enabled again
+}
+
+// IDeactivationUseCase defines the interface for deactivating and reactivating users
+type IDeactivationUseCase interface {
+	// Deactivate deactivates a user: the user's tokens are revoked, new messages are refused, the user's
+	// providers are disabled and the messages still waiting are suspended or cancelled. Deactivating a
+	// deactivated user again catches the messages queued in between.
+	Deactivate(userID int, pending string) (*Result, error)
+	// Reactivate activates a user again, enabling the providers and requeueing the messages the deactivation
+	// suspended. The tokens revoked by the deactivation stay revoked, the user logs in again.
+	Reactivate(userID int) (*Result, error)
+	// Active reports whether a user may call the API with a token issued at issuedAt
+	Active(userID int, issuedAt time.Time) bool
+}
+
+// DeactivationUseCase implements the IDeactivationUseCase interface
+type DeactivationUseCase struct {
+	userRepository                      userRepo.UserRepositoryInterface
+	userProviderRepository              providerRepo.UserProviderRepositoryInterface
+	messageTransactionRepository        providerRepo.MessageTransactionRepositoryInterface
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	Logger                              *logger.Logger
+}
+
+// NewDeactivationUseCase creates a new DeactivationUseCase
+func NewDeactivationUseCase(
+	userRepository userRepo.UserRepositoryInterface,
+	userProviderRepository providerRepo.UserProviderRepositoryInterface,
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	loggerInstance *logger.Logger,
+) IDeactivationUseCase {
+	return &DeactivationUseCase{
+		userRepository:                      userRepository,
+		userProviderRepository:              userProviderRepository,
+		messageTransactionRepository:        messageTransactionRepository,
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		Logger:                              loggerInstance,
+	}
+}
+
+func (d *DeactivationUseCase) Deactivate(userID int, pending string) (*Result, error) {
+	if pending == "" {
+		pending = PendingHold
+	}
+	if pending != PendingHold && pending != PendingCancel {
+		return nil, domainErrors.NewAppError(fmt.Errorf("pending_messages must be %s or %s", PendingHold, PendingCancel), domainErrors.ValidationError)
+	}
+	if _, err := d.userRepository.GetByID(userID); err != nil {
+		return nil, err
+	}
+
+	// The user is deactivated first, so no message is queued while the waiting ones are changed
+	user, err := d.userRepository.Update(userID, map[string]interface{}{"status": false, "deactivatedAt": time.Now()})
+	if err != nil {
+		d.Logger.Error("Error deactivating user", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+	result := &Result{User: user}
+
+	userProviders, err := d.userProviderRepository.GetUserProviders(userID)
+	if err != nil {
+		return result, err
+	}
+	for _, userProvider := range *userProviders {
+		if !userProvider.Status {
+			continue
+		}
+		if _, err := d.userProviderRepository.Update(userProvider.ID, map[string]interface{}{"status": false, "suspended": true}); err != nil {
+			d.Logger.Error("Error disabling user provider", zap.Error(err), zap.Int("userProviderID", userProvider.ID))
+			return result, err
+		}
+		result.Providers++
+	}
+
+	for _, status := range pendingStatuses {
+		changed, err := d.changeMessages(userID, status, func(ids []int) ([]int, error) {
+			if pending == PendingHold {
+				return d.messageTransactionRepository.SuspendBatch(ids, status)
+			}
+			return d.cancel(ids, status)
+		})
+		result.Messages += changed
+		if err != nil {
+			return result, err
+		}
+	}
+
+	d.Logger.Info("Deactivated user", zap.Int("userID", userID), zap.String("pending", pending),
+		zap.Int("messages", result.Messages), zap.Int("providers", result.Providers))
+	return result, nil
+}
+
+func (d *DeactivationUseCase) Reactivate(userID int) (*Result, error) {
+	if _, err := d.userRepository.GetByID(userID); err != nil {
+		return nil, err
+	}
+	user, err := d.userRepository.Update(userID, map[string]interface{}{"status": true})
+	if err != nil {
+		d.Logger.Error("Error reactivating user", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+	result := &Result{User: user}
+
+	userProviders, err := d.userProviderRepository.GetUserProviders(userID)
+	if err != nil {
+		return result, err
+	}
+	for _, userProvider := range *userProviders {
+		if !userProvider.Suspended {
+			continue
+		}
+		if _, err := d.userProviderRepository.Update(userProvider.ID, map[string]interface{}{"status": true, "suspended": false}); err != nil {
+			d.Logger.Error("Error enabling user provider", zap.Error(err), zap.Int("userProviderID", userProvider.ID))
+			return result, err
+		}
+		result.Providers++
+	}
+
+	changed, err := d.changeMessages(userID, provider.MessageStatusSuspended, func(ids []int) ([]int, error) {
+		return d.messageTransactionRepository.RequeueBatch(ids, provider.MessageStatusSuspended)
+	})
+	result.Messages = changed
+	if err != nil {
+		return result, err
+	}
+
+	d.Logger.Info("Reactivated user", zap.Int("userID", userID), zap.Int("messages", result.Messages), zap.Int("providers", result.Providers))
+	return result, nil
+}
+
+func (d *DeactivationUseCase) Active(userID int, issuedAt time.Time) bool {
+	user, err := d.userRepository.GetByID(userID)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return false
+		}
+		// A failing lookup doesn't lock every user out
+		d.Logger.Error("Error checking the status of user", zap.Error(err), zap.Int("userID", userID))
+		return true
+	}
+	return user.Active(issuedAt)
+}
+
+// changeMessages applies change to the messages of the user with the status batch by batch and returns how many
+// it changed
+func (d *DeactivationUseCase) changeMessages(userID int, status string, change func(ids []int) ([]int, error)) (int, error) {
+	filter := provider.BulkOperationFilter{Status: status, UserID: userID}
+	changed := 0
+	afterID := 0
+	for {
+		ids, err := d.messageTransactionRepository.GetIDsForBulk(filter, afterID, batchSize)
+		if err != nil || len(ids) == 0 {
+			return changed, err
+		}
+		batch, err := change(ids)
+		if err != nil {
+			return changed, err
+		}
+		changed += len(batch)
+		afterID = ids[len(ids)-1]
+		if len(ids) < batchSize {
+			return changed, nil
+		}
+	}
+}
+
+// cancel cancels a batch of messages and copies them to the history like messages that finished sending
+func (d *DeactivationUseCase) cancel(ids []int, status string) ([]int, error) {
+	changed, err := d.messageTransactionRepository.CancelBatch(ids, status)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.messageTransactionRepository.MoveToHistoryBatch(changed, d.messageTransactionHistoryRepository); err != nil {
+		d.Logger.Error("Error moving cancelled messages to history", zap.Error(err), zap.Int("count", len(changed)))
+	}
+	return changed, nil
+}
@@ -0,0 +1,117 @@
+package deactivation
+
+import (
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageStatuses returns the status of each message of the user
+func messageStatuses(t *testing.T, messages *testsupport.MessageTransactionRepository) map[int]string {
+	userMessages, err := messages.GetUserMessageTransactions(1)
+	require.NoError(t, err)
+	statuses := make(map[int]string)
+	for _, message := range *userMessages {
+		statuses[message.ID] = message.Status
+	}
+	return statuses
+}
+
+func setup(t *testing.T) (IDeactivationUseCase, *testsupport.UserRepository, *testsupport.UserProviderRepository, *testsupport.MessageTransactionRepository, *testsupport.MessageTransactionHistoryRepository) {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	users := testsupport.NewUserRepository(domainUser.User{ID: 1, UserName: "ops", Email: "ops@example.com", Status: true})
+	userProviders := testsupport.NewUserProviderRepository(nil,
+		provider.UserProvider{ID: 1, UserID: 1, Status: true},
+		provider.UserProvider{ID: 2, UserID: 1, Status: false},
+	)
+	messages := testsupport.NewMessageTransactionRepository(
+		provider.MessageTransaction{ID: 1, UserID: 1, Status: "pending"},
+		provider.MessageTransaction{ID: 2, UserID: 1, Status: "success"},
+		provider.MessageTransaction{ID: 3, UserID: 1, Status: "held_schedule"},
+		provider.MessageTransaction{ID: 4, UserID: 1, Status: "failed"},
+		provider.MessageTransaction{ID: 5, UserID: 1, Status: "unconfirmed"},
+	)
+	history := testsupport.NewMessageTransactionHistoryRepository(nil)
+	useCase := NewDeactivationUseCase(users, userProviders, messages, history, loggerInstance)
+	return useCase, users, userProviders, messages, history
+}
+
+func TestDeactivateHoldsMessages(t *testing.T) {
+	useCase, users, userProviders, messages, _ := setup(t)
+
+	result, err := useCase.Deactivate(1, "")
+	require.NoError(t, err)
+	assert.False(t, result.User.Status)
+	assert.NotNil(t, result.User.DeactivatedAt)
+	assert.Equal(t, 3, result.Messages)
+	assert.Equal(t, 1, result.Providers)
+	assert.Equal(t, map[int]string{1: "suspended", 2: "success", 3: "suspended", 4: "suspended", 5: "unconfirmed"}, messageStatuses(t, messages))
+	suspended, err := userProviders.GetByID(1)
+	require.NoError(t, err)
+	assert.False(t, suspended.Status)
+	assert.True(t, suspended.Suspended)
+
+	// Tokens issued before the deactivation stay revoked after the reactivation
+	user, err := users.GetByID(1)
+	require.NoError(t, err)
+	issuedBefore := user.DeactivatedAt.Add(-time.Second)
+	assert.False(t, useCase.Active(1, time.Now().Add(time.Second)))
+
+	result, err = useCase.Reactivate(1)
+	require.NoError(t, err)
+	assert.True(t, result.User.Status)
+	assert.Equal(t, 3, result.Messages)
+	assert.Equal(t, 1, result.Providers)
+	assert.Equal(t, map[int]string{1: "pending", 2: "success", 3: "pending", 4: "pending", 5: "unconfirmed"}, messageStatuses(t, messages))
+	// The provider that was disabled before stays disabled
+	restored, err := userProviders.GetUserProviders(1)
+	require.NoError(t, err)
+	require.Len(t, *restored, 2)
+	assert.True(t, (*restored)[0].Status)
+	assert.False(t, (*restored)[0].Suspended)
+	assert.False(t, (*restored)[1].Status)
+	assert.False(t, useCase.Active(1, issuedBefore))
+	assert.True(t, useCase.Active(1, time.Now().Add(time.Second)))
+}
+
+func TestDeactivateCancelsMessages(t *testing.T) {
+	useCase, _, _, messages, history := setup(t)
+
+	result, err := useCase.Deactivate(1, PendingCancel)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Messages)
+	assert.Equal(t, map[int]string{1: "cancelled", 2: "success", 3: "cancelled", 4: "cancelled", 5: "unconfirmed"}, messageStatuses(t, messages))
+	histories, err := history.GetUserMessageTransactionHistory(1)
+	require.NoError(t, err)
+	var moved []int
+	for _, entry := range *histories {
+		moved = append(moved, entry.MessageID)
+	}
+	assert.ElementsMatch(t, []int{1, 3, 4}, moved)
+}
+
+func TestDeactivateValidation(t *testing.T) {
+	useCase, _, _, _, _ := setup(t)
+
+	_, err := useCase.Deactivate(1, "drop")
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	_, err = useCase.Deactivate(9, PendingHold)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	// The tokens of deleted users are refused too
+	assert.False(t, useCase.Active(9, time.Now()))
+}
@@ -0,0 +1,191 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// CallbackPath is the path, below the API base URL, vendors post the delivery callbacks of a provider to,
+// followed by the vendor and the provider ID
+const CallbackPath = "/v1/callbacks/"
+
+// signalProviderType is the type of the providers sending through the Signal number, their deliveries are
+// updated from the receipts it receives
+const signalProviderType = "signal"
+
+// CallbackParser verifies the delivery callbacks of a vendor and reads the delivery statuses they report.
+// Callbacks it can't verify fail with a NotAuthenticated error.
+type CallbackParser interface {
+	ParseCallback(providerConfig string, callbackURL string, header http.Header, body []byte) ([]provider.DeliveryUpdate, error)
+}
+
+// Vendor posts the delivery callbacks of the providers of a type
+type Vendor struct {
+	ProviderType string
+	Parser       CallbackParser
+}
+
+// DeliveryHandler is told about a delivery whose status changed, with the user who sent the message
+type DeliveryHandler func(userID int, delivery *provider.MessageDelivery)
+
+// Config controls where vendors post delivery callbacks
+type Config struct {
+	// CallbackBaseURL is the public base URL of the API, vendors post delivery callbacks below it
+	CallbackBaseURL string
+}
+
+// IDeliveryUseCase defines the interface for the delivery callbacks of providers
+type IDeliveryUseCase interface {
+	// ReceiveCallback verifies a delivery callback of a vendor posted to requestURI and updates the deliveries
+	// it reports on
+	ReceiveCallback(vendor string, providerID int, requestURI string, header http.Header, body []byte) error
+	// ReceiveSignalReceipt updates the deliveries of the Signal messages a delivery or read receipt of the sender
+	// reports on
+	ReceiveSignalReceipt(sender string, receipt domainSignal.ReceiptMessage) error
+}
+
+// DeliveryUseCase implements the IDeliveryUseCase interface
+type DeliveryUseCase struct {
+	providerRepository           providerRepo.ProviderRepositoryInterface
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	messageDeliveryRepository    providerRepo.MessageDeliveryRepositoryInterface
+	vendors                      map[string]Vendor
+	handler                      DeliveryHandler
+	config                       Config
+	Logger                       *logger.Logger
+}
+
+// NewDeliveryUseCase creates a new DeliveryUseCase, vendors are keyed by the name in their callback path
+func NewDeliveryUseCase(
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	messageDeliveryRepository providerRepo.MessageDeliveryRepositoryInterface,
+	vendors map[string]Vendor,
+	handler DeliveryHandler,
+	config Config,
+	loggerInstance *logger.Logger,
+) IDeliveryUseCase {
+	return &DeliveryUseCase{
+		providerRepository:           providerRepository,
+		messageTransactionRepository: messageTransactionRepository,
+		messageDeliveryRepository:    messageDeliveryRepository,
+		vendors:                      vendors,
+		handler:                      handler,
+		config:                       config,
+		Logger:                       loggerInstance,
+	}
+}
+
+// CallbackURL returns the URL a vendor posts the delivery callbacks of a provider to, empty without a base URL
+func CallbackURL(baseURL string, vendor string, providerID int) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + CallbackPath + vendor + "/" + strconv.Itoa(providerID)
+}
+
+// ReceiveCallback verifies a delivery callback and applies the delivery statuses it reports. Callbacks about
+// messages whose delivery isn't recorded, e.g. sent before deliveries were tracked, are ignored.
+func (d *DeliveryUseCase) ReceiveCallback(vendorName string, providerID int, requestURI string, header http.Header, body []byte) error {
+	vendor, ok := d.vendors[vendorName]
+	if !ok {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	providerDetails, err := d.providerRepository.GetByID(providerID)
+	if err != nil {
+		return err
+	}
+	if providerDetails.Type != vendor.ProviderType {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+
+	updates, err := vendor.Parser.ParseCallback(providerDetails.Config, strings.TrimSuffix(d.config.CallbackBaseURL, "/")+requestURI, header, body)
+	if err != nil {
+		d.Logger.Warn("Rejected delivery callback", zap.Error(err), zap.String("vendor", vendorName), zap.Int("providerID", providerID))
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+
+	for _, update := range updates {
+		delivery, changed, err := d.messageDeliveryRepository.Apply(providerID, update)
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			d.Logger.Debug("Delivery callback for an untracked message", zap.Int("providerID", providerID),
+				zap.String("providerMessageID", update.ProviderMessageID), zap.Int("messageID", update.MessageTransactionID))
+			continue
+		}
+		if err != nil {
+			// The vendor retries callbacks that fail
+			return err
+		}
+		if changed {
+			d.deliveryChanged(delivery)
+		}
+	}
+	return nil
+}
+
+// ReceiveSignalReceipt applies a receipt the Signal number received. Signal has no callbacks, the receipt names
+// the sender and the timestamps of the messages it is about, and those identify the message of each recipient
+// in whichever signal provider sent it. Viewed receipts of attachments count as reads.
+func (d *DeliveryUseCase) ReceiveSignalReceipt(sender string, receipt domainSignal.ReceiptMessage) error {
+	status := providerRepo.DeliveryStatusDelivered
+	if receipt.IsRead || receipt.IsViewed {
+		status = providerRepo.DeliveryStatusRead
+	} else if !receipt.IsDelivery {
+		return nil
+	}
+	providers, err := d.providerRepository.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range *providers {
+		if p.Type != signalProviderType {
+			continue
+		}
+		for _, timestamp := range receipt.Timestamps {
+			update := provider.DeliveryUpdate{ProviderMessageID: domainSignal.DeliveryID(timestamp, sender), Status: status}
+			delivery, changed, err := d.messageDeliveryRepository.Apply(p.ID, update)
+			var appErr *domainErrors.AppError
+			if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if changed {
+				d.deliveryChanged(delivery)
+			}
+		}
+	}
+	return nil
+}
+
+// deliveryChanged tells the handler about a delivery whose status changed, with the user who sent the message
+func (d *DeliveryUseCase) deliveryChanged(delivery *provider.MessageDelivery) {
+	d.Logger.Info("Message delivery updated", zap.Int("messageID", delivery.MessageTransactionID),
+		zap.String("recipient", delivery.Recipient), zap.String("status", delivery.Status))
+	if d.handler == nil {
+		return
+	}
+	messageTransaction, err := d.messageTransactionRepository.GetByID(delivery.MessageTransactionID)
+	if err != nil {
+		d.Logger.Error("Error getting message of delivery", zap.Error(err), zap.Int("messageID", delivery.MessageTransactionID))
+		return
+	}
+	d.handler(messageTransaction.UserID, delivery)
+}
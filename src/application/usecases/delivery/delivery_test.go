@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProviderRepository implements GetByID and GetAll, the embedded interface panics on any other call
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []provider.Provider
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	for _, p := range m.providers {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockProviderRepository) GetAll() (*[]provider.Provider, error) {
+	return &m.providers, nil
+}
+
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+}
+
+func (m *mockMessageTransactionRepository) GetByID(id int) (*provider.MessageTransaction, error) {
+	return &provider.MessageTransaction{ID: id, UserID: 7}, nil
+}
+
+// mockMessageDeliveryRepository knows the deliveries by provider message ID
+type mockMessageDeliveryRepository struct {
+	providerRepo.MessageDeliveryRepositoryInterface
+	deliveries map[string]*provider.MessageDelivery
+	err        error
+}
+
+func (m *mockMessageDeliveryRepository) Apply(providerID int, update provider.DeliveryUpdate) (*provider.MessageDelivery, bool, error) {
+	if m.err != nil {
+		return nil, false, m.err
+	}
+	delivery, ok := m.deliveries[update.ProviderMessageID]
+	if !ok {
+		return nil, false, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if delivery.Status == update.Status {
+		return delivery, false, nil
+	}
+	delivery.Status = update.Status
+	return delivery, true, nil
+}
+
+type mockParser struct {
+	callbackURL string
+	updates     []provider.DeliveryUpdate
+	err         error
+}
+
+func (m *mockParser) ParseCallback(providerConfig string, callbackURL string, header http.Header, body []byte) ([]provider.DeliveryUpdate, error) {
+	m.callbackURL = callbackURL
+	return m.updates, m.err
+}
+
+type notification struct {
+	userID int
+	status string
+}
+
+func setupUseCase(t *testing.T, parser *mockParser, deliveries *mockMessageDeliveryRepository) (IDeliveryUseCase, *[]notification) {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	providers := &mockProviderRepository{providers: []provider.Provider{{ID: 3, Type: "sms"}, {ID: 4, Type: "signal"}}}
+	var notifications []notification
+	handler := func(userID int, delivery *provider.MessageDelivery) {
+		notifications = append(notifications, notification{userID: userID, status: delivery.Status})
+	}
+	useCase := NewDeliveryUseCase(providers, &mockMessageTransactionRepository{}, deliveries,
+		map[string]Vendor{"twilio": {ProviderType: "sms", Parser: parser}}, handler,
+		Config{CallbackBaseURL: "https://api.example.com/"}, loggerInstance)
+	return useCase, &notifications
+}
+
+func TestCallbackURL(t *testing.T) {
+	assert.Equal(t, "https://api.example.com/v1/callbacks/twilio/3", CallbackURL("https://api.example.com/", "twilio", 3))
+	assert.Empty(t, CallbackURL("", "twilio", 3))
+}
+
+func TestReceiveCallback_UpdatesDeliveries(t *testing.T) {
+	parser := &mockParser{updates: []provider.DeliveryUpdate{
+		{ProviderMessageID: "SM1", Status: "delivered"},
+		{ProviderMessageID: "SM2", Status: "sent"},
+		{ProviderMessageID: "SM-untracked", Status: "delivered"},
+	}}
+	deliveries := &mockMessageDeliveryRepository{deliveries: map[string]*provider.MessageDelivery{
+		"SM1": {MessageTransactionID: 10, Recipient: "+1", Status: "sent"},
+		"SM2": {MessageTransactionID: 10, Recipient: "+2", Status: "sent"},
+	}}
+	useCase, notifications := setupUseCase(t, parser, deliveries)
+
+	err := useCase.ReceiveCallback("twilio", 3, "/v1/callbacks/twilio/3", http.Header{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1/callbacks/twilio/3", parser.callbackURL)
+	// Only the delivery whose status changed is reported
+	assert.Equal(t, []notification{{userID: 7, status: "delivered"}}, *notifications)
+}
+
+func TestReceiveCallback_Rejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		vendor     string
+		providerID int
+		parser     *mockParser
+		deliveries *mockMessageDeliveryRepository
+		errorType  domainErrors.ErrorType
+	}{
+		{"unknown vendor", "plivo", 3, &mockParser{}, &mockMessageDeliveryRepository{}, domainErrors.NotFound},
+		{"provider of another type", "twilio", 4, &mockParser{}, &mockMessageDeliveryRepository{}, domainErrors.NotFound},
+		{"invalid signature", "twilio", 3, &mockParser{err: domainErrors.NewAppError(errors.New("invalid signature"), domainErrors.NotAuthenticated)}, &mockMessageDeliveryRepository{}, domainErrors.NotAuthenticated},
+		{"invalid config", "twilio", 3, &mockParser{err: errors.New("twilio config needs an account_sid and an auth_token")}, &mockMessageDeliveryRepository{}, domainErrors.ValidationError},
+		{"database error", "twilio", 3, &mockParser{updates: []provider.DeliveryUpdate{{ProviderMessageID: "SM1", Status: "delivered"}}},
+			&mockMessageDeliveryRepository{err: domainErrors.NewAppErrorWithType(domainErrors.UnknownError)}, domainErrors.UnknownError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, notifications := setupUseCase(t, tt.parser, tt.deliveries)
+			err := useCase.ReceiveCallback(tt.vendor, tt.providerID, "/v1/callbacks/twilio/3", http.Header{}, nil)
+			var appErr *domainErrors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.errorType, appErr.Type)
+			assert.Empty(t, *notifications)
+		})
+	}
+}
+
+func TestReceiveSignalReceipt_UpdatesDeliveries(t *testing.T) {
+	deliveries := &mockMessageDeliveryRepository{deliveries: map[string]*provider.MessageDelivery{
+		"1700000000000:+491701234567": {MessageTransactionID: 10, Recipient: "+491701234567", Status: "sent"},
+		"1700000000001:+491701234567": {MessageTransactionID: 11, Recipient: "+491701234567", Status: "read"},
+	}}
+	useCase, notifications := setupUseCase(t, &mockParser{}, deliveries)
+
+	err := useCase.ReceiveSignalReceipt("+491701234567", domainSignal.ReceiptMessage{
+		IsRead:     true,
+		Timestamps: []int64{1700000000000, 1700000000001, 1700000000002},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []notification{{userID: 7, status: "read"}}, *notifications)
+
+	// Receipts that are neither delivery nor read receipts change nothing
+	require.NoError(t, useCase.ReceiveSignalReceipt("+491701234567", domainSignal.ReceiptMessage{Timestamps: []int64{1700000000000}}))
+	assert.Len(t, *notifications, 1)
+}
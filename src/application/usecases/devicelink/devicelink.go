@@ -0,0 +1,294 @@
+package devicelink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+
+	"go.uber.org/zap"
+)
+
+// Statuses of a link attempt
+const (
+	// LinkAttemptWaiting waits for the QR code to be scanned with the primary device
+	LinkAttemptWaiting = "waiting"
+	// LinkAttemptLinked found the linked number among the accounts of the backend
+	LinkAttemptLinked = "linked"
+	// LinkAttemptExpired wasn't scanned in time, or was replaced by a newer attempt
+	LinkAttemptExpired = "expired"
+)
+
+// Client is the subset of the signal client used to link devices
+type Client interface {
+	GetAccounts() ([]string, error)
+	GetQrCodeLink(deviceName string, qrCodeVersion int) ([]byte, error)
+	UnregisterNumber(number string, deleteAccount bool, deleteLocalData bool) error
+}
+
+// Config controls how long a link attempt waits for its QR code to be scanned
+type Config struct {
+	Timeout      time.Duration
+	PollInterval time.Duration
+}
+
+// LinkAttempt is a QR code shown to link a device, one attempt waits at a time
+type LinkAttempt struct {
+	DeviceName string
+	// Number is the number being linked again, empty for a new link
+	Number    string
+	Status    string
+	StartedAt time.Time
+	ExpiresAt time.Time
+	// LinkedNumber is the number the device was linked as
+	LinkedNumber string
+}
+
+// AccountMode is the mode a number operates in, the link fields are set for linked numbers
+type AccountMode struct {
+	Number     string
+	Mode       string
+	Status     string
+	DeviceName string
+	LinkedAt   *time.Time
+	UnlinkedAt *time.Time
+	LastError  string
+}
+
+// IDeviceLinkUseCase defines the interface for device link use cases
+type IDeviceLinkUseCase interface {
+	// StartLink returns the QR code PNG to link a new device with, the number it is linked as is recorded once
+	// the code is scanned
+	StartLink(deviceName string, qrCodeVersion int) ([]byte, error)
+	// GetLinkAttempt returns the latest link attempt, nil before the first one
+	GetLinkAttempt() *LinkAttempt
+	// Relink removes the local data of a number its primary device unlinked and returns the QR code PNG to link
+	// it again with
+	Relink(number string, qrCodeVersion int) ([]byte, error)
+	GetDevices() (*[]domainSignal.DeviceLink, error)
+	GetMode(number string) (*AccountMode, error)
+	// LinkStatus returns the link status of a number, empty for numbers that aren't linked devices
+	LinkStatus(number string) string
+	// MarkUnlinked records that the primary device unlinked a number, reason is the error that showed it
+	MarkUnlinked(number string, reason string) error
+}
+
+// DeviceLinkUseCase implements the IDeviceLinkUseCase interface
+type DeviceLinkUseCase struct {
+	client               Client
+	deviceLinkRepository signalRepo.DeviceLinkRepositoryInterface
+	config               Config
+	Logger               *logger.Logger
+	now                  func() time.Time
+
+	mu      sync.Mutex
+	attempt *LinkAttempt
+	// cancel stops the watch of the waiting attempt
+	cancel chan struct{}
+}
+
+// NewDeviceLinkUseCase creates a new DeviceLinkUseCase
+func NewDeviceLinkUseCase(client Client, deviceLinkRepository signalRepo.DeviceLinkRepositoryInterface, config Config, loggerInstance *logger.Logger) IDeviceLinkUseCase {
+	return &DeviceLinkUseCase{
+		client:               client,
+		deviceLinkRepository: deviceLinkRepository,
+		config:               config,
+		Logger:               loggerInstance,
+		now:                  time.Now,
+	}
+}
+
+func (u *DeviceLinkUseCase) StartLink(deviceName string, qrCodeVersion int) ([]byte, error) {
+	return u.startLink(deviceName, "", qrCodeVersion)
+}
+
+func (u *DeviceLinkUseCase) Relink(number string, qrCodeVersion int) ([]byte, error) {
+	link, err := u.deviceLinkRepository.GetByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != domainSignal.DeviceLinkStatusUnlinked {
+		return nil, domainErrors.NewAppError(fmt.Errorf("Signal number %s is still linked", number), domainErrors.Conflict)
+	}
+
+	// signal-cli keeps the data of the unlinked account, it has to go before the number can be linked again
+	if err := u.client.UnregisterNumber(number, false, true); err != nil {
+		u.Logger.Error("Error removing the local data of an unlinked number", zap.Error(err), zap.String("number", number))
+		return nil, domainErrors.NewAppError(fmt.Errorf("couldn't remove the local data of %s: %w", number, err), domainErrors.ValidationError)
+	}
+	return u.startLink(link.DeviceName, number, qrCodeVersion)
+}
+
+// startLink shows a QR code and watches the accounts of the backend for the number it links, which is the
+// expected number when set
+func (u *DeviceLinkUseCase) startLink(deviceName string, number string, qrCodeVersion int) ([]byte, error) {
+	accounts, err := u.client.GetAccounts()
+	if err != nil {
+		u.Logger.Error("Error getting Signal accounts", zap.Error(err))
+		return nil, err
+	}
+	known := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		known[account] = true
+	}
+
+	png, err := u.client.GetQrCodeLink(deviceName, qrCodeVersion)
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+
+	now := u.now()
+	attempt := &LinkAttempt{DeviceName: deviceName, Number: number, Status: LinkAttemptWaiting, StartedAt: now, ExpiresAt: now.Add(u.config.Timeout)}
+	cancel := make(chan struct{})
+	u.mu.Lock()
+	if u.cancel != nil {
+		// The newer QR code replaces the one still waiting
+		close(u.cancel)
+		u.attempt.Status = LinkAttemptExpired
+	}
+	u.attempt, u.cancel = attempt, cancel
+	u.mu.Unlock()
+
+	u.Logger.Info("Waiting for a device to be linked", zap.String("deviceName", deviceName), zap.String("number", number))
+	go u.watch(attempt, known, cancel)
+	return png, nil
+}
+
+// watch polls the accounts of the backend until a number that wasn't known before shows up, the attempt expires
+// or is replaced
+func (u *DeviceLinkUseCase) watch(attempt *LinkAttempt, known map[string]bool, cancel chan struct{}) {
+	ticker := time.NewTicker(u.config.PollInterval)
+	defer ticker.Stop()
+	expired := time.NewTimer(u.config.Timeout)
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-cancel:
+			return
+		case <-expired.C:
+			u.finish(attempt, cancel, LinkAttemptExpired, "")
+			u.Logger.Warn("Device link expired before the QR code was scanned", zap.String("deviceName", attempt.DeviceName))
+			return
+		case <-ticker.C:
+			accounts, err := u.client.GetAccounts()
+			if err != nil {
+				u.Logger.Error("Error getting Signal accounts", zap.Error(err))
+				continue
+			}
+			for _, account := range accounts {
+				if known[account] || (attempt.Number != "" && account != attempt.Number) {
+					continue
+				}
+				if u.finish(attempt, cancel, LinkAttemptLinked, account) {
+					u.saveLinked(attempt.DeviceName, account)
+				}
+				return
+			}
+		}
+	}
+}
+
+// finish ends an attempt unless it was replaced meanwhile, it reports whether it did
+func (u *DeviceLinkUseCase) finish(attempt *LinkAttempt, cancel chan struct{}, status string, linkedNumber string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cancel != cancel {
+		return false
+	}
+	attempt.Status, attempt.LinkedNumber = status, linkedNumber
+	u.cancel = nil
+	return true
+}
+
+func (u *DeviceLinkUseCase) saveLinked(deviceName string, number string) {
+	_, err := u.deviceLinkRepository.Save(&domainSignal.DeviceLink{
+		Number:     number,
+		DeviceName: deviceName,
+		Status:     domainSignal.DeviceLinkStatusLinked,
+		LinkedAt:   u.now(),
+	})
+	if err != nil {
+		u.Logger.Error("Error saving linked device", zap.Error(err), zap.String("number", number))
+		return
+	}
+	u.Logger.Info("Device linked", zap.String("number", number), zap.String("deviceName", deviceName))
+}
+
+func (u *DeviceLinkUseCase) GetLinkAttempt() *LinkAttempt {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.attempt == nil {
+		return nil
+	}
+	attempt := *u.attempt
+	return &attempt
+}
+
+func (u *DeviceLinkUseCase) GetDevices() (*[]domainSignal.DeviceLink, error) {
+	return u.deviceLinkRepository.GetAll()
+}
+
+// GetMode reports a number as linked when it was linked through this API and as primary when it is otherwise an
+// account of the backend
+func (u *DeviceLinkUseCase) GetMode(number string) (*AccountMode, error) {
+	link, err := u.deviceLinkRepository.GetByNumber(number)
+	if err == nil {
+		linkedAt := link.LinkedAt
+		return &AccountMode{
+			Number:     number,
+			Mode:       domainSignal.AccountModeLinked,
+			Status:     link.Status,
+			DeviceName: link.DeviceName,
+			LinkedAt:   &linkedAt,
+			UnlinkedAt: link.UnlinkedAt,
+			LastError:  link.LastError,
+		}, nil
+	}
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) || appErr.Type != domainErrors.NotFound {
+		return nil, err
+	}
+
+	accounts, err := u.client.GetAccounts()
+	if err != nil {
+		u.Logger.Error("Error getting Signal accounts", zap.Error(err))
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account == number {
+			return &AccountMode{Number: number, Mode: domainSignal.AccountModePrimary}, nil
+		}
+	}
+	return nil, domainErrors.NewAppError(fmt.Errorf("Signal number %s isn't an account of this backend", number), domainErrors.NotFound)
+}
+
+func (u *DeviceLinkUseCase) LinkStatus(number string) string {
+	link, err := u.deviceLinkRepository.GetByNumber(number)
+	if err != nil {
+		return ""
+	}
+	return link.Status
+}
+
+func (u *DeviceLinkUseCase) MarkUnlinked(number string, reason string) error {
+	link, err := u.deviceLinkRepository.GetByNumber(number)
+	if err != nil {
+		return err
+	}
+	if link.Status == domainSignal.DeviceLinkStatusUnlinked {
+		return nil
+	}
+	now := u.now()
+	link.Status, link.UnlinkedAt, link.LastError = domainSignal.DeviceLinkStatusUnlinked, &now, reason
+	if _, err := u.deviceLinkRepository.Save(link); err != nil {
+		return err
+	}
+	u.Logger.Error("Signal number was unlinked by its primary device, link it again", zap.String("number", number), zap.String("reason", reason))
+	return nil
+}
@@ -0,0 +1,171 @@
+package devicelink
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeClient struct {
+	mu          sync.Mutex
+	accounts    []string
+	unregisters []string
+}
+
+func (c *fakeClient) GetAccounts() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.accounts...), nil
+}
+
+func (c *fakeClient) GetQrCodeLink(deviceName string, qrCodeVersion int) ([]byte, error) {
+	return []byte("png"), nil
+}
+
+func (c *fakeClient) UnregisterNumber(number string, deleteAccount bool, deleteLocalData bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unregisters = append(c.unregisters, number)
+	var accounts []string
+	for _, account := range c.accounts {
+		if account != number {
+			accounts = append(accounts, account)
+		}
+	}
+	c.accounts = accounts
+	return nil
+}
+
+func (c *fakeClient) link(number string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts = append(c.accounts, number)
+}
+
+type fakeDeviceLinkRepository struct {
+	mu    sync.Mutex
+	links map[string]domainSignal.DeviceLink
+}
+
+func (r *fakeDeviceLinkRepository) GetByNumber(number string) (*domainSignal.DeviceLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link, ok := r.links[number]
+	if !ok {
+		return &domainSignal.DeviceLink{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &link, nil
+}
+
+func (r *fakeDeviceLinkRepository) GetAll() (*[]domainSignal.DeviceLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	links := make([]domainSignal.DeviceLink, 0, len(r.links))
+	for _, link := range r.links {
+		links = append(links, link)
+	}
+	return &links, nil
+}
+
+func (r *fakeDeviceLinkRepository) Save(link *domainSignal.DeviceLink) (*domainSignal.DeviceLink, error) {
+	r.mu.Lock()
+	r.links[link.Number] = *link
+	r.mu.Unlock()
+	return r.GetByNumber(link.Number)
+}
+
+func setupDeviceLinkUseCase(timeout time.Duration) (*DeviceLinkUseCase, *fakeClient, *fakeDeviceLinkRepository) {
+	client := &fakeClient{accounts: []string{"+4911111"}}
+	repository := &fakeDeviceLinkRepository{links: map[string]domainSignal.DeviceLink{}}
+	config := Config{Timeout: timeout, PollInterval: 5 * time.Millisecond}
+	return NewDeviceLinkUseCase(client, repository, config, &logger.Logger{Log: zap.NewNop()}).(*DeviceLinkUseCase), client, repository
+}
+
+func waitForAttempt(t *testing.T, useCase *DeviceLinkUseCase, status string) *LinkAttempt {
+	var attempt *LinkAttempt
+	require.Eventually(t, func() bool {
+		attempt = useCase.GetLinkAttempt()
+		return attempt.Status == status
+	}, time.Second, 5*time.Millisecond)
+	return attempt
+}
+
+func TestStartLink_RecordsTheLinkedNumber(t *testing.T) {
+	useCase, client, repository := setupDeviceLinkUseCase(time.Minute)
+
+	png, err := useCase.StartLink("api", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png"), png)
+	assert.Equal(t, LinkAttemptWaiting, useCase.GetLinkAttempt().Status)
+
+	client.link("+4922222")
+	attempt := waitForAttempt(t, useCase, LinkAttemptLinked)
+	assert.Equal(t, "+4922222", attempt.LinkedNumber)
+	require.Eventually(t, func() bool { return useCase.LinkStatus("+4922222") == domainSignal.DeviceLinkStatusLinked }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "api", repository.links["+4922222"].DeviceName)
+
+	// The registered number is the primary device of its account
+	mode, err := useCase.GetMode("+4911111")
+	require.NoError(t, err)
+	assert.Equal(t, domainSignal.AccountModePrimary, mode.Mode)
+	mode, err = useCase.GetMode("+4922222")
+	require.NoError(t, err)
+	assert.Equal(t, domainSignal.AccountModeLinked, mode.Mode)
+	_, err = useCase.GetMode("+4933333")
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestStartLink_ExpiresAndIsReplaced(t *testing.T) {
+	useCase, _, _ := setupDeviceLinkUseCase(20 * time.Millisecond)
+
+	_, err := useCase.StartLink("api", 10)
+	require.NoError(t, err)
+	waitForAttempt(t, useCase, LinkAttemptExpired)
+
+	useCase.config.Timeout = time.Minute
+	_, err = useCase.StartLink("first", 10)
+	require.NoError(t, err)
+	first := useCase.attempt
+	_, err = useCase.StartLink("second", 10)
+	require.NoError(t, err)
+	assert.Equal(t, LinkAttemptExpired, first.Status)
+	assert.Equal(t, "second", useCase.GetLinkAttempt().DeviceName)
+}
+
+func TestRelink(t *testing.T) {
+	useCase, client, repository := setupDeviceLinkUseCase(time.Minute)
+	client.link("+4922222")
+	repository.links["+4922222"] = domainSignal.DeviceLink{Number: "+4922222", DeviceName: "api", Status: domainSignal.DeviceLinkStatusLinked}
+
+	// A linked number can't be linked again
+	_, err := useCase.Relink("+4922222", 10)
+	assert.Error(t, err)
+
+	require.NoError(t, useCase.MarkUnlinked("+4922222", "Authorization failed"))
+	link := repository.links["+4922222"]
+	assert.Equal(t, domainSignal.DeviceLinkStatusUnlinked, link.Status)
+	assert.Equal(t, "Authorization failed", link.LastError)
+	assert.NotNil(t, link.UnlinkedAt)
+
+	// The local data goes, the attempt waits for the number alone
+	_, err = useCase.Relink("+4922222", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+4922222"}, client.unregisters)
+	client.link("+4933333")
+	client.link("+4922222")
+	attempt := waitForAttempt(t, useCase, LinkAttemptLinked)
+	assert.Equal(t, "+4922222", attempt.LinkedNumber)
+	require.Eventually(t, func() bool { return useCase.LinkStatus("+4922222") == domainSignal.DeviceLinkStatusLinked }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "", useCase.LinkStatus("+4933333"))
+}
@@ -0,0 +1,207 @@
+package digest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+
+	// topErrorLimit is the number of most frequent error reasons included in a digest
+	topErrorLimit = 5
+)
+
+// IDigestUseCase defines the interface for delivery digest use cases
+type IDigestUseCase interface {
+	GetSubscription(userID int) (*provider.DigestSubscription, error)
+	UpdateSubscription(subscription *provider.DigestSubscription) (*provider.DigestSubscription, error)
+	GetDigests(userID int, limit int) (*[]provider.DeliveryDigest, error)
+	GenerateDueDigests(now time.Time) error
+}
+
+// DigestUseCase implements the IDigestUseCase interface
+type DigestUseCase struct {
+	digestRepository                    providerRepo.DigestRepositoryInterface
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	shortLinkRepository                 providerRepo.ShortLinkRepositoryInterface
+	messageUseCase                      message.IMessageUseCase
+	Logger                              *logger.Logger
+}
+
+// NewDigestUseCase creates a new DigestUseCase
+func NewDigestUseCase(
+	digestRepository providerRepo.DigestRepositoryInterface,
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	shortLinkRepository providerRepo.ShortLinkRepositoryInterface,
+	messageUseCase message.IMessageUseCase,
+	loggerInstance *logger.Logger,
+) IDigestUseCase {
+	return &DigestUseCase{
+		digestRepository:                    digestRepository,
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		shortLinkRepository:                 shortLinkRepository,
+		messageUseCase:                      messageUseCase,
+		Logger:                              loggerInstance,
+	}
+}
+
+// GetSubscription returns the digest subscription of a user, users without one are opted out
+func (d *DigestUseCase) GetSubscription(userID int) (*provider.DigestSubscription, error) {
+	subscription, err := d.digestRepository.GetSubscription(userID)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return &provider.DigestSubscription{UserID: userID, Frequency: FrequencyDaily, Enabled: false}, nil
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// UpdateSubscription opts a user in or out of digests
+func (d *DigestUseCase) UpdateSubscription(subscription *provider.DigestSubscription) (*provider.DigestSubscription, error) {
+	if subscription.Frequency != FrequencyDaily && subscription.Frequency != FrequencyWeekly {
+		return nil, domainErrors.NewAppError(fmt.Errorf("frequency must be %s or %s", FrequencyDaily, FrequencyWeekly), domainErrors.ValidationError)
+	}
+	if subscription.Enabled && (subscription.Channel == "" || subscription.Recipient == "") {
+		return nil, domainErrors.NewAppError(errors.New("channel and recipient are required to enable digests"), domainErrors.ValidationError)
+	}
+
+	d.Logger.Info("Updating digest subscription",
+		zap.Int("userID", subscription.UserID),
+		zap.String("frequency", subscription.Frequency),
+		zap.Bool("enabled", subscription.Enabled))
+	return d.digestRepository.SaveSubscription(subscription)
+}
+
+// GetDigests returns the most recent digests compiled for a user
+func (d *DigestUseCase) GetDigests(userID int, limit int) (*[]provider.DeliveryDigest, error) {
+	return d.digestRepository.GetUserDigests(userID, limit)
+}
+
+// GenerateDueDigests compiles and sends the digest of every subscription whose last period has ended
+// and was not reported yet
+func (d *DigestUseCase) GenerateDueDigests(now time.Time) error {
+	subscriptions, err := d.digestRepository.GetEnabledSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range *subscriptions {
+		periodStart, periodEnd := DigestPeriod(subscription.Frequency, now)
+
+		exists, err := d.digestRepository.DigestExists(subscription.UserID, subscription.Frequency, periodEnd)
+		if err != nil || exists {
+			continue
+		}
+
+		if err := d.generateDigest(subscription, periodStart, periodEnd); err != nil {
+			d.Logger.Error("Error generating delivery digest", zap.Error(err), zap.Int("userID", subscription.UserID))
+		}
+	}
+	return nil
+}
+
+func (d *DigestUseCase) generateDigest(subscription provider.DigestSubscription, periodStart time.Time, periodEnd time.Time) error {
+	stats, err := d.messageTransactionHistoryRepository.GetUserDeliveryStats(subscription.UserID, periodStart, periodEnd, topErrorLimit)
+	if err != nil {
+		return err
+	}
+	linkClicks, linkClickers, err := d.shortLinkRepository.CountUserClicks(subscription.UserID, periodStart, periodEnd)
+	if err != nil {
+		return err
+	}
+
+	digest := &provider.DeliveryDigest{
+		UserID:       subscription.UserID,
+		Frequency:    subscription.Frequency,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		Total:        stats.Total,
+		Sent:         stats.Sent,
+		Failed:       stats.Failed,
+		Fallbacks:    stats.Fallbacks,
+		TopErrors:    stats.TopErrors,
+		LinkClicks:   linkClicks,
+		LinkClickers: linkClickers,
+		Channel:      subscription.Channel,
+		Status:       "sent",
+	}
+	if stats.Total > 0 {
+		digest.FallbackRate = float64(stats.Fallbacks) / float64(stats.Total)
+	}
+
+	response, err := d.messageUseCase.SendMessage(&message.MessageRequest{
+		Type:       subscription.Channel,
+		Message:    FormatDigest(digest),
+		Recipients: []string{subscription.Recipient},
+		UserID:     subscription.UserID,
+	})
+	if err != nil {
+		digest.Status = "failed"
+		digest.ErrorMessage = err.Error()
+	} else {
+		digest.MessageID = response.ID
+	}
+
+	// The digest is stored even if sending failed so it is not regenerated on every run
+	_, err = d.digestRepository.CreateDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	d.Logger.Info("Delivery digest generated",
+		zap.Int("userID", subscription.UserID),
+		zap.String("frequency", subscription.Frequency),
+		zap.String("status", digest.Status))
+	return nil
+}
+
+// DigestPeriod returns the last completed reporting period before now. Daily periods cover the previous
+// UTC day, weekly periods the previous week from Monday to Monday.
+func DigestPeriod(frequency string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if frequency == FrequencyWeekly {
+		daysSinceMonday := (int(end.Weekday()) + 6) % 7
+		end = end.AddDate(0, 0, -daysSinceMonday)
+		return end.AddDate(0, 0, -7), end
+	}
+	return end.AddDate(0, 0, -1), end
+}
+
+// FormatDigest renders a digest as a plain text message
+func FormatDigest(digest *provider.DeliveryDigest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Delivery digest %s - %s\n", digest.PeriodStart.Format("2006-01-02"), digest.PeriodEnd.Format("2006-01-02"))
+	fmt.Fprintf(&sb, "Total: %d\n", digest.Total)
+	fmt.Fprintf(&sb, "Sent: %d\n", digest.Sent)
+	fmt.Fprintf(&sb, "Failed: %d\n", digest.Failed)
+	fmt.Fprintf(&sb, "Fallback rate: %.1f%%\n", digest.FallbackRate*100)
+	if len(digest.TopErrors) > 0 {
+		sb.WriteString("Top errors:\n")
+		for _, topError := range digest.TopErrors {
+			if topError.Code != "" {
+				fmt.Fprintf(&sb, "- [%s] %s (%d)\n", topError.Code, topError.Reason, topError.Count)
+			} else {
+				fmt.Fprintf(&sb, "- %s (%d)\n", topError.Reason, topError.Count)
+			}
+		}
+	}
+	if digest.LinkClicks > 0 {
+		fmt.Fprintf(&sb, "Link clicks: %d by %d recipients\n", digest.LinkClicks, digest.LinkClickers)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,277 @@
+package digest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDigestRepository struct {
+	subscriptions []provider.DigestSubscription
+	digests       []provider.DeliveryDigest
+	existing      map[int]bool
+}
+
+func (m *mockDigestRepository) GetSubscription(userID int) (*provider.DigestSubscription, error) {
+	for _, subscription := range m.subscriptions {
+		if subscription.UserID == userID {
+			return &subscription, nil
+		}
+	}
+	return &provider.DigestSubscription{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockDigestRepository) SaveSubscription(subscription *provider.DigestSubscription) (*provider.DigestSubscription, error) {
+	m.subscriptions = append(m.subscriptions, *subscription)
+	return subscription, nil
+}
+
+func (m *mockDigestRepository) GetEnabledSubscriptions() (*[]provider.DigestSubscription, error) {
+	return &m.subscriptions, nil
+}
+
+func (m *mockDigestRepository) CreateDigest(digest *provider.DeliveryDigest) (*provider.DeliveryDigest, error) {
+	m.digests = append(m.digests, *digest)
+	return digest, nil
+}
+
+func (m *mockDigestRepository) GetUserDigests(userID int, limit int) (*[]provider.DeliveryDigest, error) {
+	return &m.digests, nil
+}
+
+func (m *mockDigestRepository) DigestExists(userID int, frequency string, periodEnd time.Time) (bool, error) {
+	return m.existing[userID], nil
+}
+
+type mockHistoryRepository struct {
+	stats *provider.DeliveryStats
+}
+
+func (m *mockHistoryRepository) Create(history *provider.MessageTransactionHistory) (*provider.MessageTransactionHistory, error) {
+	return history, nil
+}
+
+func (m *mockHistoryRepository) CreateBatch(histories []provider.MessageTransactionHistory) error {
+	return nil
+}
+
+func (m *mockHistoryRepository) GetByID(id int) (*provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetByMessageID(messageID int) (*[]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserMessageTransactionHistory(userID int) (*[]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserDeliveryStats(userID int, from time.Time, to time.Time, topErrorLimit int) (*provider.DeliveryStats, error) {
+	return m.stats, nil
+}
+
+func (m *mockHistoryRepository) SearchUserHistory(userID int, status string, tags map[string]string, page domain.PageRequest) (*domain.Page[provider.MessageTransactionHistory], error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetUserTagRollup(userID int, tagKey string, from time.Time, to time.Time, granularity string) (*[]provider.TagDeliveryRollup, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetProviderTypeOutcomes(from time.Time) ([]provider.ProviderTypeOutcomes, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetAfter(afterID int, limit int) ([]provider.MessageTransactionHistory, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) GetOldestCreatedAt() (*time.Time, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryRepository) DeleteRange(firstID int, lastID int) (int64, error) {
+	return 0, nil
+}
+
+type mockShortLinkRepository struct {
+	clicks   int
+	clickers int
+}
+
+func (m *mockShortLinkRepository) CreateBatch(links []provider.ShortLink) (*[]provider.ShortLink, error) {
+	return &links, nil
+}
+
+func (m *mockShortLinkRepository) GetByID(id int) (*provider.ShortLink, error) {
+	return nil, nil
+}
+
+func (m *mockShortLinkRepository) GetByMessageID(messageID int) (*[]provider.ShortLink, error) {
+	return nil, nil
+}
+
+func (m *mockShortLinkRepository) RecordClick(click *provider.LinkClick) error {
+	return nil
+}
+
+func (m *mockShortLinkRepository) GetMessageClickStats(messageID int) (*provider.LinkClickStats, error) {
+	return nil, nil
+}
+
+func (m *mockShortLinkRepository) CountUserClicks(userID int, from time.Time, to time.Time) (int, int, error) {
+	return m.clicks, m.clickers, nil
+}
+
+type mockMessageUseCase struct {
+	sendMessageFn func(request *message.MessageRequest) (*message.MessageResponse, error)
+}
+
+func (m *mockMessageUseCase) SendMessage(request *message.MessageRequest) (*message.MessageResponse, error) {
+	return m.sendMessageFn(request)
+}
+
+func (m *mockMessageUseCase) RetryFailedMessages() error {
+	return nil
+}
+
+func (m *mockMessageUseCase) GetMessageStatus(request *message.MessageStatusRequest) (*message.MessageStatusResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) EditMessage(request *message.EditMessageRequest) (*message.EditMessageResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) GetMessageHistory(request *message.MessageHistoryRequest) (*domain.Page[message.MessageHistoryItem], error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) GetQueueStats() (*message.QueueStatsResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) PreviewMessage(request *message.MessageRequest) (*message.PreviewResponse, error) {
+	return nil, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestDigestPeriod(t *testing.T) {
+	// Thursday
+	now := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+
+	start, end := DigestPeriod(FrequencyDaily, now)
+	assert.Equal(t, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), end)
+
+	start, end = DigestPeriod(FrequencyWeekly, now)
+	assert.Equal(t, time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), end)
+
+	// On a Monday the weekly period ends today
+	_, end = DigestPeriod(FrequencyWeekly, time.Date(2026, 10, 12, 1, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestGenerateDueDigests(t *testing.T) {
+	digestRepository := &mockDigestRepository{
+		subscriptions: []provider.DigestSubscription{
+			{UserID: 1, Frequency: FrequencyDaily, Channel: "signal", Recipient: "+491234", Enabled: true},
+			{UserID: 2, Frequency: FrequencyDaily, Channel: "signal", Recipient: "+495678", Enabled: true},
+		},
+		existing: map[int]bool{2: true},
+	}
+	historyRepository := &mockHistoryRepository{stats: &provider.DeliveryStats{
+		Total:     10,
+		Sent:      7,
+		Failed:    2,
+		Fallbacks: 1,
+		TopErrors: []provider.ErrorReasonCount{{Reason: "timeout", Count: 2}},
+	}}
+	var sentRequests []*message.MessageRequest
+	messageUseCase := &mockMessageUseCase{sendMessageFn: func(request *message.MessageRequest) (*message.MessageResponse, error) {
+		sentRequests = append(sentRequests, request)
+		return &message.MessageResponse{ID: 42, Status: "pending"}, nil
+	}}
+	useCase := NewDigestUseCase(digestRepository, historyRepository, &mockShortLinkRepository{clicks: 5, clickers: 3}, messageUseCase, setupLogger(t))
+
+	err := useCase.GenerateDueDigests(time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	// User 2 already has a digest for the period
+	assert.Len(t, sentRequests, 1)
+	assert.Equal(t, 1, sentRequests[0].UserID)
+	assert.Equal(t, []string{"+491234"}, sentRequests[0].Recipients)
+	assert.True(t, strings.Contains(sentRequests[0].Message, "Fallback rate: 10.0%"))
+	assert.True(t, strings.Contains(sentRequests[0].Message, "- timeout (2)"))
+	assert.True(t, strings.Contains(sentRequests[0].Message, "Link clicks: 5 by 3 recipients"))
+
+	assert.Len(t, digestRepository.digests, 1)
+	assert.Equal(t, "sent", digestRepository.digests[0].Status)
+	assert.Equal(t, 42, digestRepository.digests[0].MessageID)
+	assert.InDelta(t, 0.1, digestRepository.digests[0].FallbackRate, 0.0001)
+}
+
+func TestGenerateDueDigests_SendFailureIsRecorded(t *testing.T) {
+	digestRepository := &mockDigestRepository{
+		subscriptions: []provider.DigestSubscription{{UserID: 1, Frequency: FrequencyWeekly, Channel: "email", Recipient: "a@b.c", Enabled: true}},
+	}
+	historyRepository := &mockHistoryRepository{stats: &provider.DeliveryStats{}}
+	messageUseCase := &mockMessageUseCase{sendMessageFn: func(request *message.MessageRequest) (*message.MessageResponse, error) {
+		return nil, errors.New("daily message rate limit exceeded")
+	}}
+	useCase := NewDigestUseCase(digestRepository, historyRepository, &mockShortLinkRepository{}, messageUseCase, setupLogger(t))
+
+	err := useCase.GenerateDueDigests(time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, digestRepository.digests, 1)
+	assert.Equal(t, "failed", digestRepository.digests[0].Status)
+	assert.Equal(t, "daily message rate limit exceeded", digestRepository.digests[0].ErrorMessage)
+}
+
+func TestSubscription(t *testing.T) {
+	digestRepository := &mockDigestRepository{}
+	useCase := NewDigestUseCase(digestRepository, &mockHistoryRepository{}, &mockShortLinkRepository{}, &mockMessageUseCase{}, setupLogger(t))
+
+	t.Run("Users without subscription are opted out", func(t *testing.T) {
+		subscription, err := useCase.GetSubscription(7)
+		assert.NoError(t, err)
+		assert.False(t, subscription.Enabled)
+		assert.Equal(t, 7, subscription.UserID)
+	})
+
+	t.Run("Invalid frequency is rejected", func(t *testing.T) {
+		_, err := useCase.UpdateSubscription(&provider.DigestSubscription{UserID: 7, Frequency: "monthly"})
+		var appErr *domainErrors.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+
+	t.Run("Enabling requires a channel and recipient", func(t *testing.T) {
+		_, err := useCase.UpdateSubscription(&provider.DigestSubscription{UserID: 7, Frequency: FrequencyDaily, Enabled: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("Opt in", func(t *testing.T) {
+		subscription, err := useCase.UpdateSubscription(&provider.DigestSubscription{UserID: 7, Frequency: FrequencyDaily, Channel: "signal", Recipient: "+491234", Enabled: true})
+		assert.NoError(t, err)
+		assert.True(t, subscription.Enabled)
+	})
+}
@@ -0,0 +1,317 @@
+package distributionlist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	signalChannel     = "signal"
+	signalGroupPrefix = "group."
+	maxMembers        = 1000
+)
+
+// GroupService reads and changes the membership of Signal groups
+type GroupService interface {
+	GetGroup(number string, groupId string) (*domainSignal.GroupEntry, error)
+	AddMembersToGroup(number string, groupId string, members []string) error
+	RemoveMembersFromGroup(number string, groupId string, members []string) error
+}
+
+// Config controls how the Signal groups of distribution lists are synced
+type Config struct {
+	// Number is the registered number that administers the groups
+	Number string
+	// Reconcile changes the groups to match the stored members, otherwise drift is only reported
+	Reconcile bool
+}
+
+// SendResult reports the message sent to one channel of a distribution list
+type SendResult struct {
+	Channel    string
+	Recipients []string
+	MessageID  int
+	Status     string
+	Error      string
+}
+
+// IDistributionListUseCase defines the interface for distribution list use cases
+type IDistributionListUseCase interface {
+	CreateList(list *provider.DistributionList) (*provider.DistributionList, error)
+	GetLists(userID int) (*[]provider.DistributionList, error)
+	GetList(userID int, id int) (*provider.DistributionList, error)
+	UpdateList(list *provider.DistributionList) (*provider.DistributionList, error)
+	DeleteList(userID int, id int) error
+	Send(userID int, id int, text string, tags map[string]string) ([]SendResult, error)
+	SyncList(id int) (*provider.DistributionList, error)
+	SyncAll() error
+	GetDrifted() (*[]provider.DistributionList, error)
+}
+
+// DistributionListUseCase implements the IDistributionListUseCase interface
+type DistributionListUseCase struct {
+	distributionListRepository providerRepo.DistributionListRepositoryInterface
+	messageUseCase             message.IMessageUseCase
+	groupService               GroupService
+	config                     Config
+	Logger                     *logger.Logger
+}
+
+// NewDistributionListUseCase creates a new DistributionListUseCase
+func NewDistributionListUseCase(
+	distributionListRepository providerRepo.DistributionListRepositoryInterface,
+	messageUseCase message.IMessageUseCase,
+	groupService GroupService,
+	config Config,
+	loggerInstance *logger.Logger,
+) IDistributionListUseCase {
+	return &DistributionListUseCase{
+		distributionListRepository: distributionListRepository,
+		messageUseCase:             messageUseCase,
+		groupService:               groupService,
+		config:                     config,
+		Logger:                     loggerInstance,
+	}
+}
+
+// CreateList validates and stores a distribution list
+func (d *DistributionListUseCase) CreateList(list *provider.DistributionList) (*provider.DistributionList, error) {
+	if err := validateList(list); err != nil {
+		return nil, err
+	}
+	return d.distributionListRepository.Create(list)
+}
+
+// GetLists returns the distribution lists of a user
+func (d *DistributionListUseCase) GetLists(userID int) (*[]provider.DistributionList, error) {
+	return d.distributionListRepository.GetUserLists(userID)
+}
+
+// GetList returns a distribution list of a user
+func (d *DistributionListUseCase) GetList(userID int, id int) (*provider.DistributionList, error) {
+	return d.distributionListRepository.GetUserList(userID, id)
+}
+
+// UpdateList validates and replaces the name, group and members of a distribution list of a user. The
+// group is brought in line with the new members by the next sync.
+func (d *DistributionListUseCase) UpdateList(list *provider.DistributionList) (*provider.DistributionList, error) {
+	if err := validateList(list); err != nil {
+		return nil, err
+	}
+	return d.distributionListRepository.Update(list)
+}
+
+// DeleteList removes a distribution list of a user, the Signal group is left as it is
+func (d *DistributionListUseCase) DeleteList(userID int, id int) error {
+	return d.distributionListRepository.Delete(userID, id)
+}
+
+// Send sends a message to a distribution list of a user, once to the Signal group and once per channel of
+// the extra members. Every message is sent through the user's own providers like any other message. It
+// fails only if no message could be queued, the outcome per channel is reported in the results.
+func (d *DistributionListUseCase) Send(userID int, id int, text string, tags map[string]string) ([]SendResult, error) {
+	list, err := d.distributionListRepository.GetUserList(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SendResult
+	if list.SignalGroupID != "" {
+		results = append(results, SendResult{Channel: signalChannel, Recipients: []string{list.SignalGroupID}})
+	}
+	channels := make(map[string]int)
+	for _, member := range list.ExtraMembers {
+		index, ok := channels[member.Channel]
+		if !ok {
+			index = len(results)
+			channels[member.Channel] = index
+			results = append(results, SendResult{Channel: member.Channel})
+		}
+		results[index].Recipients = append(results[index].Recipients, member.Address)
+	}
+	if len(results) == 0 {
+		return nil, domainErrors.NewAppError(errors.New("the distribution list has no members"), domainErrors.ValidationError)
+	}
+
+	var firstErr error
+	queued := 0
+	for i := range results {
+		response, err := d.messageUseCase.SendMessage(&message.MessageRequest{
+			Type:       results[i].Channel,
+			Message:    text,
+			Recipients: results[i].Recipients,
+			Tags:       tags,
+			UserID:     userID,
+		})
+		if err != nil {
+			d.Logger.Warn("Error sending to distribution list channel", zap.Error(err),
+				zap.Int("listID", list.ID), zap.String("channel", results[i].Channel))
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		results[i].MessageID = response.ID
+		results[i].Status = response.Status
+		queued++
+	}
+	if queued == 0 {
+		return nil, firstErr
+	}
+
+	d.Logger.Info("Sent to distribution list", zap.Int("listID", list.ID), zap.Int("userID", userID), zap.Int("messages", queued))
+	return results, nil
+}
+
+// SyncList syncs the Signal group membership of a distribution list and returns the list with the result
+func (d *DistributionListUseCase) SyncList(id int) (*provider.DistributionList, error) {
+	list, err := d.distributionListRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if list.SignalGroupID == "" {
+		return nil, domainErrors.NewAppError(errors.New("the distribution list has no signal group"), domainErrors.ValidationError)
+	}
+	if err := d.sync(list, time.Now()); err != nil {
+		return nil, err
+	}
+	return d.distributionListRepository.GetByID(id)
+}
+
+// SyncAll syncs the Signal group membership of every distribution list with a group
+func (d *DistributionListUseCase) SyncAll() error {
+	lists, err := d.distributionListRepository.GetSignalLists()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range *lists {
+		if err := d.sync(&(*lists)[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDrifted returns the distribution lists whose last sync found drift or failed
+func (d *DistributionListUseCase) GetDrifted() (*[]provider.DistributionList, error) {
+	return d.distributionListRepository.GetDrifted()
+}
+
+// sync compares the members of the Signal group with the stored members, reconciles the group if
+// enabled and stores the drift found. Pending invites count as members. Failing to read or change the
+// group is stored as the sync error of the list, only failing to store the result is returned.
+func (d *DistributionListUseCase) sync(list *provider.DistributionList, now time.Time) error {
+	drift, syncErr := d.compare(list)
+	if syncErr == nil && drift != nil && d.config.Reconcile {
+		syncErr = d.reconcile(list, drift)
+		drift.Reconciled = syncErr == nil
+	}
+
+	syncError := ""
+	if syncErr != nil {
+		syncError = syncErr.Error()
+		d.Logger.Warn("Error syncing distribution list", zap.Error(syncErr), zap.Int("listID", list.ID))
+	} else if drift != nil {
+		d.Logger.Info("Distribution list drifted from its signal group",
+			zap.Int("listID", list.ID),
+			zap.Strings("missing", drift.Missing),
+			zap.Strings("unexpected", drift.Unexpected),
+			zap.Bool("reconciled", drift.Reconciled))
+	}
+	return d.distributionListRepository.UpdateSyncResult(list.ID, drift, syncError, now)
+}
+
+func (d *DistributionListUseCase) compare(list *provider.DistributionList) (*provider.MembershipDrift, error) {
+	group, err := d.groupService.GetGroup(d.config.Number, list.SignalGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read signal group: %w", err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("signal group %s not found", list.SignalGroupID)
+	}
+
+	inGroup := make(map[string]bool)
+	for _, member := range group.Members {
+		inGroup[member] = true
+	}
+	for _, member := range group.PendingMembers {
+		inGroup[member] = true
+	}
+	stored := make(map[string]bool)
+	for _, member := range list.SignalMembers {
+		stored[member] = true
+	}
+
+	drift := &provider.MembershipDrift{Missing: []string{}, Unexpected: []string{}}
+	for _, member := range list.SignalMembers {
+		if !inGroup[member] {
+			drift.Missing = append(drift.Missing, member)
+		}
+	}
+	for _, member := range group.Members {
+		// The administering number is a member of every group it syncs
+		if !stored[member] && member != d.config.Number {
+			drift.Unexpected = append(drift.Unexpected, member)
+		}
+	}
+	if len(drift.Missing) == 0 && len(drift.Unexpected) == 0 {
+		return nil, nil
+	}
+	return drift, nil
+}
+
+func (d *DistributionListUseCase) reconcile(list *provider.DistributionList, drift *provider.MembershipDrift) error {
+	if len(drift.Missing) > 0 {
+		if err := d.groupService.AddMembersToGroup(d.config.Number, list.SignalGroupID, drift.Missing); err != nil {
+			return fmt.Errorf("couldn't add members to signal group: %w", err)
+		}
+	}
+	if len(drift.Unexpected) > 0 {
+		if err := d.groupService.RemoveMembersFromGroup(d.config.Number, list.SignalGroupID, drift.Unexpected); err != nil {
+			return fmt.Errorf("couldn't remove members from signal group: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateList(list *provider.DistributionList) error {
+	if strings.TrimSpace(list.Name) == "" {
+		return domainErrors.NewAppError(errors.New("name is required"), domainErrors.ValidationError)
+	}
+	if list.SignalGroupID != "" && !strings.HasPrefix(list.SignalGroupID, signalGroupPrefix) {
+		return domainErrors.NewAppError(fmt.Errorf("signal_group_id must start with %q", signalGroupPrefix), domainErrors.ValidationError)
+	}
+	if list.SignalGroupID == "" && len(list.SignalMembers) > 0 {
+		return domainErrors.NewAppError(errors.New("signal_members need a signal_group_id"), domainErrors.ValidationError)
+	}
+	if list.SignalGroupID == "" && len(list.ExtraMembers) == 0 {
+		return domainErrors.NewAppError(errors.New("a distribution list needs a signal group or extra members"), domainErrors.ValidationError)
+	}
+	if len(list.SignalMembers)+len(list.ExtraMembers) > maxMembers {
+		return domainErrors.NewAppError(fmt.Errorf("a distribution list can have at most %d members", maxMembers), domainErrors.ValidationError)
+	}
+	for i, member := range list.ExtraMembers {
+		if member.Channel == "" || member.Address == "" {
+			return domainErrors.NewAppError(fmt.Errorf("extra member %d needs a channel and an address", i+1), domainErrors.ValidationError)
+		}
+		if member.Channel == signalChannel {
+			return domainErrors.NewAppError(fmt.Errorf("extra member %d is on signal, add it to signal_members instead", i+1), domainErrors.ValidationError)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package distributionlist
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGroupID = "group.dGVzdA=="
+
+type mockDistributionListRepository struct {
+	providerRepo.DistributionListRepositoryInterface
+	lists       map[int]provider.DistributionList
+	syncResults map[int]*provider.MembershipDrift
+	syncErrors  map[int]string
+}
+
+func newMockDistributionListRepository(lists ...provider.DistributionList) *mockDistributionListRepository {
+	m := &mockDistributionListRepository{
+		lists:       make(map[int]provider.DistributionList),
+		syncResults: make(map[int]*provider.MembershipDrift),
+		syncErrors:  make(map[int]string),
+	}
+	for _, list := range lists {
+		m.lists[list.ID] = list
+	}
+	return m
+}
+
+func (m *mockDistributionListRepository) GetByID(id int) (*provider.DistributionList, error) {
+	list, ok := m.lists[id]
+	if !ok {
+		return &provider.DistributionList{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &list, nil
+}
+
+func (m *mockDistributionListRepository) GetUserList(userID int, id int) (*provider.DistributionList, error) {
+	list, ok := m.lists[id]
+	if !ok || list.UserID != userID {
+		return &provider.DistributionList{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &list, nil
+}
+
+func (m *mockDistributionListRepository) GetSignalLists() (*[]provider.DistributionList, error) {
+	var lists []provider.DistributionList
+	for _, list := range m.lists {
+		if list.SignalGroupID != "" {
+			lists = append(lists, list)
+		}
+	}
+	return &lists, nil
+}
+
+func (m *mockDistributionListRepository) UpdateSyncResult(id int, drift *provider.MembershipDrift, syncError string, syncedAt time.Time) error {
+	m.syncResults[id] = drift
+	m.syncErrors[id] = syncError
+	return nil
+}
+
+type mockMessageUseCase struct {
+	message.IMessageUseCase
+	sent []*message.MessageRequest
+	fail map[string]bool
+}
+
+func (m *mockMessageUseCase) SendMessage(request *message.MessageRequest) (*message.MessageResponse, error) {
+	if m.fail[request.Type] {
+		return nil, errors.New("no provider")
+	}
+	m.sent = append(m.sent, request)
+	return &message.MessageResponse{ID: len(m.sent), Status: "pending"}, nil
+}
+
+type mockGroupService struct {
+	group   *domainSignal.GroupEntry
+	added   []string
+	removed []string
+}
+
+func (m *mockGroupService) GetGroup(number string, groupId string) (*domainSignal.GroupEntry, error) {
+	return m.group, nil
+}
+
+func (m *mockGroupService) AddMembersToGroup(number string, groupId string, members []string) error {
+	m.added = append(m.added, members...)
+	return nil
+}
+
+func (m *mockGroupService) RemoveMembersFromGroup(number string, groupId string, members []string) error {
+	m.removed = append(m.removed, members...)
+	return nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func testList() provider.DistributionList {
+	return provider.DistributionList{
+		ID:            1,
+		UserID:        7,
+		Name:          "On call",
+		SignalGroupID: testGroupID,
+		SignalMembers: []string{"+4911111", "+4922222"},
+		ExtraMembers: []provider.DistributionListMember{
+			{Channel: "sms", Address: "+4933333"},
+			{Channel: "email", Address: "ops@example.com"},
+			{Channel: "sms", Address: "+4944444"},
+		},
+	}
+}
+
+func TestSendFansOutPerChannel(t *testing.T) {
+	messageUseCase := &mockMessageUseCase{fail: map[string]bool{"email": true}}
+	useCase := NewDistributionListUseCase(newMockDistributionListRepository(testList()), messageUseCase, &mockGroupService{}, Config{}, setupLogger(t))
+
+	results, err := useCase.Send(7, 1, "Server down", map[string]string{"team": "ops"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Equal(t, "signal", results[0].Channel)
+	assert.Equal(t, []string{testGroupID}, results[0].Recipients)
+	assert.Equal(t, "sms", results[1].Channel)
+	assert.Equal(t, []string{"+4933333", "+4944444"}, results[1].Recipients)
+	assert.Equal(t, "pending", results[1].Status)
+	assert.Equal(t, "email", results[2].Channel)
+	assert.Equal(t, "failed", results[2].Status)
+
+	assert.Len(t, messageUseCase.sent, 2)
+	assert.Equal(t, 7, messageUseCase.sent[0].UserID)
+	assert.Equal(t, "ops", messageUseCase.sent[0].Tags["team"])
+}
+
+func TestSendToListOfAnotherUser(t *testing.T) {
+	useCase := NewDistributionListUseCase(newMockDistributionListRepository(testList()), &mockMessageUseCase{}, &mockGroupService{}, Config{}, setupLogger(t))
+
+	_, err := useCase.Send(8, 1, "Server down", nil)
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestSyncReconcilesDrift(t *testing.T) {
+	repository := newMockDistributionListRepository(testList())
+	groupService := &mockGroupService{group: &domainSignal.GroupEntry{
+		ID:      testGroupID,
+		Members: []string{"+4900000", "+4911111", "+4955555"},
+	}}
+	useCase := NewDistributionListUseCase(repository, &mockMessageUseCase{}, groupService, Config{Number: "+4900000", Reconcile: true}, setupLogger(t))
+
+	assert.NoError(t, useCase.SyncAll())
+
+	drift := repository.syncResults[1]
+	assert.Equal(t, []string{"+4922222"}, drift.Missing)
+	assert.Equal(t, []string{"+4955555"}, drift.Unexpected)
+	assert.True(t, drift.Reconciled)
+	assert.Equal(t, []string{"+4922222"}, groupService.added)
+	assert.Equal(t, []string{"+4955555"}, groupService.removed)
+}
+
+func TestSyncOnlyReportsDriftWithoutReconcile(t *testing.T) {
+	repository := newMockDistributionListRepository(testList())
+	groupService := &mockGroupService{group: &domainSignal.GroupEntry{
+		ID:             testGroupID,
+		Members:        []string{"+4900000", "+4911111"},
+		PendingMembers: []string{"+4922222"},
+	}}
+	useCase := NewDistributionListUseCase(repository, &mockMessageUseCase{}, groupService, Config{Number: "+4900000"}, setupLogger(t))
+
+	// Pending invites count as members
+	assert.NoError(t, useCase.SyncAll())
+	assert.Nil(t, repository.syncResults[1])
+
+	groupService.group.PendingMembers = nil
+	assert.NoError(t, useCase.SyncAll())
+	assert.Equal(t, []string{"+4922222"}, repository.syncResults[1].Missing)
+	assert.False(t, repository.syncResults[1].Reconciled)
+	assert.Empty(t, groupService.added)
+}
+
+func TestSyncRecordsMissingGroup(t *testing.T) {
+	repository := newMockDistributionListRepository(testList())
+	useCase := NewDistributionListUseCase(repository, &mockMessageUseCase{}, &mockGroupService{}, Config{Reconcile: true}, setupLogger(t))
+
+	_, err := useCase.SyncList(1)
+	assert.NoError(t, err)
+	assert.Contains(t, repository.syncErrors[1], "not found")
+}
+
+func TestCreateListValidation(t *testing.T) {
+	useCase := NewDistributionListUseCase(newMockDistributionListRepository(), &mockMessageUseCase{}, &mockGroupService{}, Config{}, setupLogger(t))
+
+	invalid := []provider.DistributionList{
+		{Name: ""},
+		{Name: "no members"},
+		{Name: "bad group", SignalGroupID: "abc"},
+		{Name: "members without group", SignalMembers: []string{"+4911111"}},
+		{Name: "signal extra", ExtraMembers: []provider.DistributionListMember{{Channel: "signal", Address: "+4911111"}}},
+		{Name: "no address", ExtraMembers: []provider.DistributionListMember{{Channel: "sms"}}},
+	}
+	for _, list := range invalid {
+		_, err := useCase.CreateList(&list)
+		appErr, ok := err.(*domainErrors.AppError)
+		if assert.True(t, ok, list.Name) {
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type, list.Name)
+		}
+	}
+}
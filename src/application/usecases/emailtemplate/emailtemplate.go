@@ -0,0 +1,145 @@
+package emailtemplate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	emailRenderer "go-multi-chat-api/src/infrastructure/emailtemplate"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// maxHTMLLength is the longest HTML body a template can have
+const maxHTMLLength = 1 << 20
+
+// IEmailTemplateUseCase defines the interface for email template use cases
+type IEmailTemplateUseCase interface {
+	CreateTemplate(template *provider.EmailTemplate) (*provider.EmailTemplate, error)
+	GetTemplates(userID int) (*[]provider.EmailTemplate, error)
+	GetTemplate(userID int, id int) (*provider.EmailTemplate, error)
+	UpdateTemplate(template *provider.EmailTemplate) (*provider.EmailTemplate, error)
+	DeleteTemplate(userID int, id int) error
+	// Render renders a stored template of a user with the variables
+	Render(userID int, id int, variables map[string]interface{}) (*emailRenderer.Rendered, error)
+	// RenderDraft renders a template that isn't stored, to preview it while it is written
+	RenderDraft(subject string, html string, variables map[string]interface{}) (*emailRenderer.Rendered, error)
+}
+
+// EmailTemplateUseCase implements the IEmailTemplateUseCase interface
+type EmailTemplateUseCase struct {
+	emailTemplateRepository providerRepo.EmailTemplateRepositoryInterface
+	renderer                *emailRenderer.Renderer
+	Logger                  *logger.Logger
+}
+
+// NewEmailTemplateUseCase creates a new EmailTemplateUseCase
+func NewEmailTemplateUseCase(
+	emailTemplateRepository providerRepo.EmailTemplateRepositoryInterface,
+	renderer *emailRenderer.Renderer,
+	loggerInstance *logger.Logger,
+) IEmailTemplateUseCase {
+	return &EmailTemplateUseCase{
+		emailTemplateRepository: emailTemplateRepository,
+		renderer:                renderer,
+		Logger:                  loggerInstance,
+	}
+}
+
+// CreateTemplate validates and stores an email template
+func (e *EmailTemplateUseCase) CreateTemplate(template *provider.EmailTemplate) (*provider.EmailTemplate, error) {
+	if err := e.validateTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := e.checkNameAvailable(template); err != nil {
+		return nil, err
+	}
+	return e.emailTemplateRepository.Create(template)
+}
+
+// GetTemplates returns the email templates of a user
+func (e *EmailTemplateUseCase) GetTemplates(userID int) (*[]provider.EmailTemplate, error) {
+	return e.emailTemplateRepository.GetUserTemplates(userID)
+}
+
+// GetTemplate returns an email template of a user
+func (e *EmailTemplateUseCase) GetTemplate(userID int, id int) (*provider.EmailTemplate, error) {
+	return e.emailTemplateRepository.GetUserTemplate(userID, id)
+}
+
+// UpdateTemplate validates and replaces the name, subject and body of an email template of a user
+func (e *EmailTemplateUseCase) UpdateTemplate(template *provider.EmailTemplate) (*provider.EmailTemplate, error) {
+	if err := e.validateTemplate(template); err != nil {
+		return nil, err
+	}
+	if err := e.checkNameAvailable(template); err != nil {
+		return nil, err
+	}
+	return e.emailTemplateRepository.Update(template)
+}
+
+// DeleteTemplate removes an email template of a user
+func (e *EmailTemplateUseCase) DeleteTemplate(userID int, id int) error {
+	return e.emailTemplateRepository.Delete(userID, id)
+}
+
+// Render renders a stored template of a user with the variables
+func (e *EmailTemplateUseCase) Render(userID int, id int, variables map[string]interface{}) (*emailRenderer.Rendered, error) {
+	template, err := e.emailTemplateRepository.GetUserTemplate(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return e.render(template.Subject, template.HTML, variables)
+}
+
+// RenderDraft renders a template that isn't stored
+func (e *EmailTemplateUseCase) RenderDraft(subject string, html string, variables map[string]interface{}) (*emailRenderer.Rendered, error) {
+	if err := e.validateTemplate(&provider.EmailTemplate{Name: "draft", Subject: subject, HTML: html}); err != nil {
+		return nil, err
+	}
+	return e.render(subject, html, variables)
+}
+
+// render renders a template, errors of the template or its variables, like an image missing from the
+// attachment store, are validation errors of the request
+func (e *EmailTemplateUseCase) render(subject string, html string, variables map[string]interface{}) (*emailRenderer.Rendered, error) {
+	rendered, err := e.renderer.Render(emailRenderer.Template{Subject: subject, HTML: html}, variables)
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return rendered, nil
+}
+
+func (e *EmailTemplateUseCase) validateTemplate(template *provider.EmailTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return domainErrors.NewAppError(errors.New("name is required"), domainErrors.ValidationError)
+	}
+	if strings.TrimSpace(template.HTML) == "" {
+		return domainErrors.NewAppError(errors.New("html is required"), domainErrors.ValidationError)
+	}
+	if len(template.HTML) > maxHTMLLength {
+		return domainErrors.NewAppError(errors.New("html can be at most 1 MiB"), domainErrors.ValidationError)
+	}
+	if err := e.renderer.Validate(emailRenderer.Template{Subject: template.Subject, HTML: template.HTML}); err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// checkNameAvailable rejects a name another template of the user already has
+func (e *EmailTemplateUseCase) checkNameAvailable(template *provider.EmailTemplate) error {
+	existing, err := e.emailTemplateRepository.GetUserTemplateByName(template.UserID, template.Name)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != template.ID {
+		return domainErrors.NewAppError(fmt.Errorf("a template named %q already exists", template.Name), domainErrors.ValidationError)
+	}
+	return nil
+}
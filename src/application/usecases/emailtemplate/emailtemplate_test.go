@@ -0,0 +1,87 @@
+package emailtemplate
+
+import (
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	emailRenderer "go-multi-chat-api/src/infrastructure/emailtemplate"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEmailTemplateRepository struct {
+	providerRepo.EmailTemplateRepositoryInterface
+	templates map[int]provider.EmailTemplate
+}
+
+func (m *mockEmailTemplateRepository) Create(template *provider.EmailTemplate) (*provider.EmailTemplate, error) {
+	template.ID = len(m.templates) + 1
+	m.templates[template.ID] = *template
+	return template, nil
+}
+
+func (m *mockEmailTemplateRepository) GetUserTemplate(userID int, id int) (*provider.EmailTemplate, error) {
+	template, ok := m.templates[id]
+	if !ok || template.UserID != userID {
+		return &provider.EmailTemplate{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &template, nil
+}
+
+func (m *mockEmailTemplateRepository) GetUserTemplateByName(userID int, name string) (*provider.EmailTemplate, error) {
+	for _, template := range m.templates {
+		if template.UserID == userID && template.Name == name {
+			return &template, nil
+		}
+	}
+	return &provider.EmailTemplate{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func newTestUseCase() (*mockEmailTemplateRepository, IEmailTemplateUseCase) {
+	repository := &mockEmailTemplateRepository{templates: make(map[int]provider.EmailTemplate)}
+	return repository, NewEmailTemplateUseCase(repository, emailRenderer.NewRenderer(nil), nil)
+}
+
+func TestCreateTemplate_Validates(t *testing.T) {
+	repository, useCase := newTestUseCase()
+
+	_, err := useCase.CreateTemplate(&provider.EmailTemplate{UserID: 1, Name: "welcome", HTML: "{{ if .name }}"})
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Empty(t, repository.templates)
+
+	_, err = useCase.CreateTemplate(&provider.EmailTemplate{UserID: 1, Name: " ", HTML: "<p>Hi</p>"})
+	assert.EqualError(t, err, "name is required")
+
+	template, err := useCase.CreateTemplate(&provider.EmailTemplate{UserID: 1, Name: "welcome", Subject: "Hi {{ .name }}", HTML: "<p>Hi {{ .name }}</p>"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, template.ID)
+
+	_, err = useCase.CreateTemplate(&provider.EmailTemplate{UserID: 1, Name: "welcome", HTML: "<p>Hello</p>"})
+	assert.EqualError(t, err, `a template named "welcome" already exists`)
+	_, err = useCase.CreateTemplate(&provider.EmailTemplate{UserID: 2, Name: "welcome", HTML: "<p>Hello</p>"})
+	assert.NoError(t, err)
+}
+
+func TestRender(t *testing.T) {
+	repository, useCase := newTestUseCase()
+	repository.templates[1] = provider.EmailTemplate{ID: 1, UserID: 1, Name: "welcome", Subject: "Hi {{ .name }}", HTML: "<p>Hi {{ .name }}</p>"}
+
+	rendered, err := useCase.Render(1, 1, map[string]interface{}{"name": "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ada", rendered.Subject)
+	assert.Equal(t, "Hi Ada", rendered.Text)
+
+	_, err = useCase.Render(2, 1, nil)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+
+	_, err = useCase.RenderDraft("", `<img src="{{ image "logo.png" }}">`, nil)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
@@ -0,0 +1,119 @@
+package engagement
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+)
+
+// statusSuccess is the status of the outbound message event of a message handed to its provider
+const statusSuccess = "success"
+
+// IEngagementUseCase defines the interface for recording the engagement of recipients with sent messages
+type IEngagementUseCase interface {
+	// RecordSent records the recipients of an outbound message event published on the event bus once the
+	// message was sent
+	RecordSent(event *provider.MessageEvent)
+	// RecordDelivery records a delivery or read reported by the provider of a message
+	RecordDelivery(userID int, delivery *provider.MessageDelivery)
+	// RecordClick records a click of a recipient on a short link of a message
+	RecordClick(click *provider.LinkClick)
+}
+
+// EngagementUseCase implements the IEngagementUseCase interface
+type EngagementUseCase struct {
+	messageEngagementRepository providerRepo.MessageEngagementRepositoryInterface
+	userRepository              userRepo.UserRepositoryInterface
+	Logger                      *logger.Logger
+}
+
+// NewEngagementUseCase creates a new EngagementUseCase
+func NewEngagementUseCase(
+	messageEngagementRepository providerRepo.MessageEngagementRepositoryInterface,
+	userRepository userRepo.UserRepositoryInterface,
+	loggerInstance *logger.Logger,
+) IEngagementUseCase {
+	return &EngagementUseCase{
+		messageEngagementRepository: messageEngagementRepository,
+		userRepository:              userRepository,
+		Logger:                      loggerInstance,
+	}
+}
+
+func (e *EngagementUseCase) RecordSent(event *provider.MessageEvent) {
+	if event.Direction != provider.DirectionOutbound || event.Status != statusSuccess || !e.tracks(event.UserID) {
+		return
+	}
+	now := time.Now()
+	for _, recipient := range event.Participants {
+		e.record(provider.EngagementEvent{
+			MessageTransactionID: event.MessageID,
+			UserID:               event.UserID,
+			ProviderID:           event.ProviderID,
+			Recipient:            recipient,
+			Tags:                 event.Tags,
+			Event:                providerRepo.EngagementSent,
+			OccurredAt:           now,
+		})
+	}
+}
+
+func (e *EngagementUseCase) RecordDelivery(userID int, delivery *provider.MessageDelivery) {
+	var event string
+	switch delivery.Status {
+	case providerRepo.DeliveryStatusDelivered:
+		event = providerRepo.EngagementDelivered
+	case providerRepo.DeliveryStatusRead:
+		event = providerRepo.EngagementRead
+	default:
+		return
+	}
+	if !e.tracks(userID) {
+		return
+	}
+	e.record(provider.EngagementEvent{
+		MessageTransactionID: delivery.MessageTransactionID,
+		UserID:               userID,
+		ProviderID:           delivery.ProviderID,
+		Recipient:            delivery.Recipient,
+		Event:                event,
+		OccurredAt:           time.Now(),
+	})
+}
+
+func (e *EngagementUseCase) RecordClick(click *provider.LinkClick) {
+	if !e.tracks(click.UserID) {
+		return
+	}
+	e.record(provider.EngagementEvent{
+		MessageTransactionID: click.MessageID,
+		UserID:               click.UserID,
+		Recipient:            click.Recipient,
+		Event:                providerRepo.EngagementClicked,
+		OccurredAt:           click.ClickedAt,
+	})
+}
+
+// tracks reports whether the user opted into engagement tracking, nothing is recorded about the recipients of
+// the other users
+func (e *EngagementUseCase) tracks(userID int) bool {
+	user, err := e.userRepository.GetByID(userID)
+	if err != nil {
+		e.Logger.Error("Error getting user of engagement", zap.Error(err), zap.Int("userID", userID))
+		return false
+	}
+	return user.EngagementTracking
+}
+
+// record records an engagement event, a failure is logged and loses only that event
+func (e *EngagementUseCase) record(event provider.EngagementEvent) {
+	if err := e.messageEngagementRepository.Record(event); err != nil {
+		e.Logger.Error("Error recording engagement", zap.Error(err), zap.Int("messageID", event.MessageTransactionID),
+			zap.String("event", event.Event))
+	}
+}
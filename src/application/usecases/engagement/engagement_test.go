@@ -0,0 +1,97 @@
+package engagement
+
+import (
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMessageEngagementRepository struct {
+	providerRepo.MessageEngagementRepositoryInterface
+	events []provider.EngagementEvent
+}
+
+func (m *mockMessageEngagementRepository) Record(event provider.EngagementEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+// mockUserRepository knows user 1, who tracks engagement, and user 2, who doesn't
+type mockUserRepository struct {
+	userRepo.UserRepositoryInterface
+}
+
+func (m *mockUserRepository) GetByID(id int) (*domainUser.User, error) {
+	switch id {
+	case 1:
+		return &domainUser.User{ID: 1, EngagementTracking: true}, nil
+	case 2:
+		return &domainUser.User{ID: 2}, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func setup(t *testing.T) (IEngagementUseCase, *mockMessageEngagementRepository) {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	repository := &mockMessageEngagementRepository{}
+	return NewEngagementUseCase(repository, &mockUserRepository{}, loggerInstance), repository
+}
+
+func TestRecordSent(t *testing.T) {
+	useCase, repository := setup(t)
+
+	useCase.RecordSent(&provider.MessageEvent{
+		UserID: 1, ProviderID: 3, MessageID: 10, Direction: provider.DirectionOutbound, Status: "success",
+		Participants: []string{"+491111", "+492222"}, Tags: `{"campaign":"spring"}`,
+	})
+	require.Len(t, repository.events, 2)
+	assert.Equal(t, "+492222", repository.events[1].Recipient)
+	assert.Equal(t, providerRepo.EngagementSent, repository.events[1].Event)
+	assert.Equal(t, `{"campaign":"spring"}`, repository.events[1].Tags)
+	assert.Equal(t, 3, repository.events[1].ProviderID)
+
+	// Failed, inbound and untracked messages aren't recorded
+	useCase.RecordSent(&provider.MessageEvent{UserID: 1, Direction: provider.DirectionOutbound, Status: "failed", Participants: []string{"+491111"}})
+	useCase.RecordSent(&provider.MessageEvent{Direction: provider.DirectionInbound, Participants: []string{"+491111"}})
+	useCase.RecordSent(&provider.MessageEvent{UserID: 2, Direction: provider.DirectionOutbound, Status: "success", Participants: []string{"+491111"}})
+	assert.Len(t, repository.events, 2)
+}
+
+func TestRecordDelivery(t *testing.T) {
+	useCase, repository := setup(t)
+
+	useCase.RecordDelivery(1, &provider.MessageDelivery{MessageTransactionID: 10, ProviderID: 3, Recipient: "+491111", Status: "delivered"})
+	useCase.RecordDelivery(1, &provider.MessageDelivery{MessageTransactionID: 10, ProviderID: 3, Recipient: "+491111", Status: "read"})
+	useCase.RecordDelivery(1, &provider.MessageDelivery{MessageTransactionID: 10, ProviderID: 3, Recipient: "+492222", Status: "failed"})
+	useCase.RecordDelivery(2, &provider.MessageDelivery{MessageTransactionID: 11, ProviderID: 3, Recipient: "+491111", Status: "read"})
+
+	require.Len(t, repository.events, 2)
+	assert.Equal(t, providerRepo.EngagementDelivered, repository.events[0].Event)
+	assert.Equal(t, providerRepo.EngagementRead, repository.events[1].Event)
+}
+
+func TestRecordClick(t *testing.T) {
+	useCase, repository := setup(t)
+	clickedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	useCase.RecordClick(&provider.LinkClick{MessageID: 10, UserID: 1, Recipient: "+491111", ClickedAt: clickedAt})
+	useCase.RecordClick(&provider.LinkClick{MessageID: 11, UserID: 2, Recipient: "+491111", ClickedAt: clickedAt})
+	useCase.RecordClick(&provider.LinkClick{MessageID: 12, UserID: 9, Recipient: "+491111", ClickedAt: clickedAt})
+
+	require.Len(t, repository.events, 1)
+	assert.Equal(t, provider.EngagementEvent{
+		MessageTransactionID: 10, UserID: 1, Recipient: "+491111", Event: providerRepo.EngagementClicked, OccurredAt: clickedAt,
+	}, repository.events[0])
+}
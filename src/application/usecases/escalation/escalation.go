@@ -0,0 +1,246 @@
+package escalation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusActive       = "active"
+	StatusAcknowledged = "acknowledged"
+	// StatusExhausted means every step was notified without acknowledgement, it can still be acknowledged
+	StatusExhausted = "exhausted"
+
+	// AckKeyword starts a reply acknowledging an escalation, e.g. "ACK K7QX2M"
+	AckKeyword = "ACK"
+
+	maxSteps = 10
+)
+
+// IEscalationUseCase defines the interface for escalation use cases
+type IEscalationUseCase interface {
+	CreateChain(chain *provider.EscalationChain) (*provider.EscalationChain, error)
+	GetChains(userID int) (*[]provider.EscalationChain, error)
+	DeleteChain(userID int, id int) error
+	Trigger(userID int, chainID int, text string) (*provider.Escalation, error)
+	GetEscalation(userID int, id int) (*provider.Escalation, error)
+	GetEscalations(userID int, limit int) (*[]provider.Escalation, error)
+	Acknowledge(userID int, id int, acknowledgedBy string) (*provider.Escalation, error)
+	AcknowledgeByKeyword(text string, sender string) (bool, error)
+	ProcessDueEscalations(now time.Time) error
+}
+
+// EscalationUseCase implements the IEscalationUseCase interface
+type EscalationUseCase struct {
+	escalationRepository providerRepo.EscalationRepositoryInterface
+	messageUseCase       message.IMessageUseCase
+	Logger               *logger.Logger
+}
+
+// NewEscalationUseCase creates a new EscalationUseCase
+func NewEscalationUseCase(
+	escalationRepository providerRepo.EscalationRepositoryInterface,
+	messageUseCase message.IMessageUseCase,
+	loggerInstance *logger.Logger,
+) IEscalationUseCase {
+	return &EscalationUseCase{
+		escalationRepository: escalationRepository,
+		messageUseCase:       messageUseCase,
+		Logger:               loggerInstance,
+	}
+}
+
+// CreateChain validates and stores an escalation chain
+func (e *EscalationUseCase) CreateChain(chain *provider.EscalationChain) (*provider.EscalationChain, error) {
+	if len(chain.Steps) == 0 || len(chain.Steps) > maxSteps {
+		return nil, domainErrors.NewAppError(fmt.Errorf("a chain needs between 1 and %d steps", maxSteps), domainErrors.ValidationError)
+	}
+	for i, step := range chain.Steps {
+		if step.Channel == "" || len(step.Recipients) == 0 {
+			return nil, domainErrors.NewAppError(fmt.Errorf("step %d needs a channel and recipients", i+1), domainErrors.ValidationError)
+		}
+		if step.DelayMinutes < 0 {
+			return nil, domainErrors.NewAppError(fmt.Errorf("step %d has a negative delay", i+1), domainErrors.ValidationError)
+		}
+	}
+	return e.escalationRepository.CreateChain(chain)
+}
+
+// GetChains returns the escalation chains of a user
+func (e *EscalationUseCase) GetChains(userID int) (*[]provider.EscalationChain, error) {
+	return e.escalationRepository.GetUserChains(userID)
+}
+
+// DeleteChain removes an escalation chain of a user
+func (e *EscalationUseCase) DeleteChain(userID int, id int) error {
+	return e.escalationRepository.DeleteChain(userID, id)
+}
+
+// Trigger starts an escalation of a chain. The first step is notified right away unless it has a delay.
+func (e *EscalationUseCase) Trigger(userID int, chainID int, text string) (*provider.Escalation, error) {
+	chain, err := e.escalationRepository.GetChain(userID, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	ackCode, err := message.GenerateAckCode()
+	if err != nil {
+		e.Logger.Error("Error generating acknowledgement code", zap.Error(err))
+		return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+
+	now := time.Now()
+	nextStepAt := now.Add(time.Duration(chain.Steps[0].DelayMinutes) * time.Minute)
+	escalation, err := e.escalationRepository.CreateEscalation(&provider.Escalation{
+		UserID:      userID,
+		ChainID:     chain.ID,
+		Steps:       chain.Steps,
+		Message:     text,
+		Status:      StatusActive,
+		CurrentStep: -1,
+		NextStepAt:  &nextStepAt,
+		AckCode:     ackCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.Logger.Info("Escalation triggered", zap.Int("escalationID", escalation.ID), zap.Int("chainID", chain.ID), zap.Int("userID", userID))
+	if !nextStepAt.After(now) {
+		e.advance(escalation, now)
+		return e.escalationRepository.GetEscalation(userID, escalation.ID)
+	}
+	return escalation, nil
+}
+
+// GetEscalation returns an escalation of a user
+func (e *EscalationUseCase) GetEscalation(userID int, id int) (*provider.Escalation, error) {
+	return e.escalationRepository.GetEscalation(userID, id)
+}
+
+// GetEscalations returns the most recent escalations of a user
+func (e *EscalationUseCase) GetEscalations(userID int, limit int) (*[]provider.Escalation, error) {
+	return e.escalationRepository.GetUserEscalations(userID, limit)
+}
+
+// Acknowledge acknowledges an escalation of a user, no further steps are notified
+func (e *EscalationUseCase) Acknowledge(userID int, id int, acknowledgedBy string) (*provider.Escalation, error) {
+	escalation, err := e.escalationRepository.GetEscalation(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if escalation.Status == StatusAcknowledged {
+		return nil, domainErrors.NewAppError(errors.New("escalation is already acknowledged"), domainErrors.Conflict)
+	}
+
+	if _, err := e.escalationRepository.AcknowledgeEscalation(id, acknowledgedBy, time.Now()); err != nil {
+		return nil, err
+	}
+	e.Logger.Info("Escalation acknowledged", zap.Int("escalationID", id), zap.String("acknowledgedBy", acknowledgedBy))
+	return e.escalationRepository.GetEscalation(userID, id)
+}
+
+// AcknowledgeByKeyword acknowledges the escalation referenced by a received "ACK <code>" reply. It reports
+// whether the text was an acknowledgement of an open escalation.
+func (e *EscalationUseCase) AcknowledgeByKeyword(text string, sender string) (bool, error) {
+	ackCode, ok := ParseAckKeyword(text)
+	if !ok {
+		return false, nil
+	}
+
+	escalation, err := e.escalationRepository.GetOpenEscalationByAckCode(ackCode)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	acknowledged, err := e.escalationRepository.AcknowledgeEscalation(escalation.ID, sender, time.Now())
+	if err != nil || !acknowledged {
+		return false, err
+	}
+	e.Logger.Info("Escalation acknowledged by reply", zap.Int("escalationID", escalation.ID), zap.String("acknowledgedBy", sender))
+	return true, nil
+}
+
+// ProcessDueEscalations notifies the next step of every active escalation whose delay passed
+func (e *EscalationUseCase) ProcessDueEscalations(now time.Time) error {
+	escalations, err := e.escalationRepository.GetDueEscalations(now)
+	if err != nil {
+		return err
+	}
+	for i := range *escalations {
+		e.advance(&(*escalations)[i], now)
+	}
+	return nil
+}
+
+// advance moves an escalation to its next step and notifies the step. The step is claimed before it is
+// notified, so it is never notified twice.
+func (e *EscalationUseCase) advance(escalation *provider.Escalation, now time.Time) {
+	next := escalation.CurrentStep + 1
+	if next >= len(escalation.Steps) {
+		_, _ = e.escalationRepository.AdvanceEscalation(escalation.ID, escalation.CurrentStep, escalation.CurrentStep, StatusExhausted, nil)
+		return
+	}
+
+	status := StatusActive
+	var nextStepAt *time.Time
+	if next+1 < len(escalation.Steps) {
+		at := now.Add(time.Duration(escalation.Steps[next+1].DelayMinutes) * time.Minute)
+		nextStepAt = &at
+	} else {
+		status = StatusExhausted
+	}
+
+	claimed, err := e.escalationRepository.AdvanceEscalation(escalation.ID, escalation.CurrentStep, next, status, nextStepAt)
+	if err != nil || !claimed {
+		// Either failed or the escalation was acknowledged or advanced by another instance
+		return
+	}
+
+	step := escalation.Steps[next]
+	_, err = e.messageUseCase.SendMessage(&message.MessageRequest{
+		Type:       step.Channel,
+		Message:    FormatNotification(escalation, next),
+		Recipients: step.Recipients,
+		Tags:       map[string]string{"escalation": strconv.Itoa(escalation.ID)},
+		UserID:     escalation.UserID,
+	})
+	if err != nil {
+		e.Logger.Error("Error notifying escalation step", zap.Error(err), zap.Int("escalationID", escalation.ID), zap.Int("step", next))
+		return
+	}
+	e.Logger.Info("Escalation step notified",
+		zap.Int("escalationID", escalation.ID),
+		zap.Int("step", next),
+		zap.String("channel", step.Channel))
+}
+
+// FormatNotification renders the message sent for a step of an escalation
+func FormatNotification(escalation *provider.Escalation, step int) string {
+	return fmt.Sprintf("%s\n\nEscalation #%d, step %d of %d. Reply %s %s to acknowledge.",
+		escalation.Message, escalation.ID, step+1, len(escalation.Steps), AckKeyword, escalation.AckCode)
+}
+
+// ParseAckKeyword extracts the acknowledgement code from an "ACK <code>" reply, case insensitive
+func ParseAckKeyword(text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], AckKeyword) {
+		return "", false
+	}
+	return strings.ToUpper(fields[1]), true
+}
@@ -0,0 +1,268 @@
+package escalation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEscalationRepository struct {
+	chains      []provider.EscalationChain
+	escalations []provider.Escalation
+}
+
+func (m *mockEscalationRepository) CreateChain(chain *provider.EscalationChain) (*provider.EscalationChain, error) {
+	chain.ID = len(m.chains) + 1
+	m.chains = append(m.chains, *chain)
+	return chain, nil
+}
+
+func (m *mockEscalationRepository) GetChain(userID int, id int) (*provider.EscalationChain, error) {
+	for _, chain := range m.chains {
+		if chain.UserID == userID && chain.ID == id {
+			return &chain, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockEscalationRepository) GetUserChains(userID int) (*[]provider.EscalationChain, error) {
+	return &m.chains, nil
+}
+
+func (m *mockEscalationRepository) DeleteChain(userID int, id int) error {
+	return nil
+}
+
+func (m *mockEscalationRepository) CreateEscalation(escalation *provider.Escalation) (*provider.Escalation, error) {
+	escalation.ID = len(m.escalations) + 1
+	m.escalations = append(m.escalations, *escalation)
+	return escalation, nil
+}
+
+func (m *mockEscalationRepository) GetEscalation(userID int, id int) (*provider.Escalation, error) {
+	for _, escalation := range m.escalations {
+		if escalation.UserID == userID && escalation.ID == id {
+			return &escalation, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockEscalationRepository) GetUserEscalations(userID int, limit int) (*[]provider.Escalation, error) {
+	return &m.escalations, nil
+}
+
+func (m *mockEscalationRepository) GetOpenEscalationByAckCode(ackCode string) (*provider.Escalation, error) {
+	for _, escalation := range m.escalations {
+		if escalation.AckCode == ackCode && escalation.Status != StatusAcknowledged {
+			return &escalation, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockEscalationRepository) GetDueEscalations(now time.Time) (*[]provider.Escalation, error) {
+	due := []provider.Escalation{}
+	for _, escalation := range m.escalations {
+		if escalation.Status == StatusActive && escalation.NextStepAt != nil && !escalation.NextStepAt.After(now) {
+			due = append(due, escalation)
+		}
+	}
+	return &due, nil
+}
+
+func (m *mockEscalationRepository) AdvanceEscalation(id int, fromStep int, nextStep int, status string, nextStepAt *time.Time) (bool, error) {
+	for i := range m.escalations {
+		escalation := &m.escalations[i]
+		if escalation.ID == id && escalation.Status == StatusActive && escalation.CurrentStep == fromStep {
+			escalation.CurrentStep = nextStep
+			escalation.Status = status
+			escalation.NextStepAt = nextStepAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockEscalationRepository) AcknowledgeEscalation(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error) {
+	for i := range m.escalations {
+		escalation := &m.escalations[i]
+		if escalation.ID == id && escalation.Status != StatusAcknowledged {
+			escalation.Status = StatusAcknowledged
+			escalation.AcknowledgedBy = acknowledgedBy
+			escalation.AcknowledgedAt = &acknowledgedAt
+			escalation.NextStepAt = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type mockMessageUseCase struct {
+	sent []*message.MessageRequest
+}
+
+func (m *mockMessageUseCase) SendMessage(request *message.MessageRequest) (*message.MessageResponse, error) {
+	m.sent = append(m.sent, request)
+	return &message.MessageResponse{ID: len(m.sent), Status: "pending"}, nil
+}
+
+func (m *mockMessageUseCase) RetryFailedMessages() error {
+	return nil
+}
+
+func (m *mockMessageUseCase) GetMessageStatus(request *message.MessageStatusRequest) (*message.MessageStatusResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) EditMessage(request *message.EditMessageRequest) (*message.EditMessageResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) GetMessageHistory(request *message.MessageHistoryRequest) (*domain.Page[message.MessageHistoryItem], error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) GetQueueStats() (*message.QueueStatsResponse, error) {
+	return nil, nil
+}
+
+func (m *mockMessageUseCase) PreviewMessage(request *message.MessageRequest) (*message.PreviewResponse, error) {
+	return nil, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func onCallChain() provider.EscalationChain {
+	return provider.EscalationChain{
+		ID:     1,
+		UserID: 1,
+		Name:   "on-call",
+		Steps: []provider.EscalationStep{
+			{Channel: "signal", Recipients: []string{"+491111"}},
+			{Channel: "sms", Recipients: []string{"+492222"}, DelayMinutes: 5},
+			{Channel: "email", Recipients: []string{"team@example.com"}, DelayMinutes: 10},
+		},
+	}
+}
+
+func TestCreateChain_Validation(t *testing.T) {
+	useCase := NewEscalationUseCase(&mockEscalationRepository{}, &mockMessageUseCase{}, setupLogger(t))
+
+	_, err := useCase.CreateChain(&provider.EscalationChain{UserID: 1, Name: "empty"})
+	assert.Error(t, err)
+
+	_, err = useCase.CreateChain(&provider.EscalationChain{UserID: 1, Name: "no recipients", Steps: []provider.EscalationStep{{Channel: "signal"}}})
+	assert.Error(t, err)
+
+	_, err = useCase.CreateChain(&provider.EscalationChain{UserID: 1, Name: "negative", Steps: []provider.EscalationStep{{Channel: "signal", Recipients: []string{"+49"}, DelayMinutes: -1}}})
+	assert.Error(t, err)
+
+	chain := onCallChain()
+	created, err := useCase.CreateChain(&chain)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.ID)
+}
+
+func TestEscalation_StepsUntilExhausted(t *testing.T) {
+	repository := &mockEscalationRepository{chains: []provider.EscalationChain{onCallChain()}}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewEscalationUseCase(repository, messageUseCase, setupLogger(t))
+
+	escalation, err := useCase.Trigger(1, 1, "db-01 is down")
+	assert.NoError(t, err)
+
+	// The first step has no delay and is notified right away
+	assert.Equal(t, 0, escalation.CurrentStep)
+	assert.Equal(t, StatusActive, escalation.Status)
+	assert.Len(t, messageUseCase.sent, 1)
+	assert.Equal(t, "signal", messageUseCase.sent[0].Type)
+	assert.Equal(t, "1", messageUseCase.sent[0].Tags["escalation"])
+	assert.True(t, strings.Contains(messageUseCase.sent[0].Message, "Reply ACK "+escalation.AckCode))
+
+	// Nothing is due before the delay of the second step passed
+	assert.NoError(t, useCase.ProcessDueEscalations(time.Now().Add(4*time.Minute)))
+	assert.Len(t, messageUseCase.sent, 1)
+
+	assert.NoError(t, useCase.ProcessDueEscalations(time.Now().Add(6*time.Minute)))
+	assert.Len(t, messageUseCase.sent, 2)
+	assert.Equal(t, "sms", messageUseCase.sent[1].Type)
+
+	assert.NoError(t, useCase.ProcessDueEscalations(time.Now().Add(17*time.Minute)))
+	assert.Len(t, messageUseCase.sent, 3)
+	assert.Equal(t, "email", messageUseCase.sent[2].Type)
+	assert.Equal(t, StatusExhausted, repository.escalations[0].Status)
+	assert.Nil(t, repository.escalations[0].NextStepAt)
+
+	// Exhausted escalations aren't due anymore
+	assert.NoError(t, useCase.ProcessDueEscalations(time.Now().Add(time.Hour)))
+	assert.Len(t, messageUseCase.sent, 3)
+}
+
+func TestEscalation_AcknowledgeStopsSteps(t *testing.T) {
+	repository := &mockEscalationRepository{chains: []provider.EscalationChain{onCallChain()}}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewEscalationUseCase(repository, messageUseCase, setupLogger(t))
+
+	escalation, err := useCase.Trigger(1, 1, "db-01 is down")
+	assert.NoError(t, err)
+
+	acknowledged, err := useCase.Acknowledge(1, escalation.ID, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusAcknowledged, acknowledged.Status)
+	assert.Equal(t, "alice", acknowledged.AcknowledgedBy)
+
+	assert.NoError(t, useCase.ProcessDueEscalations(time.Now().Add(time.Hour)))
+	assert.Len(t, messageUseCase.sent, 1)
+
+	_, err = useCase.Acknowledge(1, escalation.ID, "bob")
+	assert.Error(t, err)
+}
+
+func TestAcknowledgeByKeyword(t *testing.T) {
+	repository := &mockEscalationRepository{chains: []provider.EscalationChain{onCallChain()}}
+	useCase := NewEscalationUseCase(repository, &mockMessageUseCase{}, setupLogger(t))
+
+	escalation, err := useCase.Trigger(1, 1, "db-01 is down")
+	assert.NoError(t, err)
+
+	ok, err := useCase.AcknowledgeByKeyword("thanks, on it", "+491111")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = useCase.AcknowledgeByKeyword("ack ZZZZZZ", "+491111")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = useCase.AcknowledgeByKeyword(" ack "+strings.ToLower(escalation.AckCode)+" ", "+491111")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, StatusAcknowledged, repository.escalations[0].Status)
+	assert.Equal(t, "+491111", repository.escalations[0].AcknowledgedBy)
+}
+
+func TestParseAckKeyword(t *testing.T) {
+	code, ok := ParseAckKeyword("ACK k7qx2m")
+	assert.True(t, ok)
+	assert.Equal(t, "K7QX2M", code)
+
+	_, ok = ParseAckKeyword("ACK")
+	assert.False(t, ok)
+	_, ok = ParseAckKeyword("please ACK K7QX2M")
+	assert.False(t, ok)
+}
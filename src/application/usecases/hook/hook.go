@@ -0,0 +1,382 @@
+package hook
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"go.uber.org/zap"
+)
+
+// HookVerifier performs the verification handshake with the target URL of a new subscription, with its stored
+// delivery settings
+type HookVerifier interface {
+	VerifySubscription(subscription provider.HookSubscription, secret string) error
+}
+
+// HookDispatcher verifies new subscriptions, re-delivers stored events and drops the clients kept for
+// subscriptions whose delivery settings were updated
+type HookDispatcher interface {
+	HookVerifier
+	Replay(event *provider.WebhookEvent) ([]provider.WebhookReplayResult, error)
+	ForgetClient(subscriptionID int)
+}
+
+// HookDomainResolver returns the verified custom domain of a user, or an empty string if they have none
+type HookDomainResolver interface {
+	VerifiedDomain(userID int) (string, error)
+}
+
+// HookSecretEncrypter encrypts the secrets, header values and client keys of subscriptions with the key of their
+// user before they are stored
+type HookSecretEncrypter interface {
+	Encrypt(userID int, plaintext string) (string, error)
+}
+
+// headerNamePattern matches the names of custom delivery headers
+var headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
+// IHookUseCase defines the interface for REST hook subscription use cases
+type IHookUseCase interface {
+	Subscribe(userID int, event string, targetURL string, envelopeTypes []string, delivery provider.HookDelivery) (*provider.HookSubscription, error)
+	RotateSecret(userID int, id int) (*provider.HookSubscription, error)
+	EncryptStoredSecrets() (int, error)
+	Unsubscribe(userID int, id int) error
+	GetSubscriptions(userID int) (*[]provider.HookSubscription, error)
+	GetEvents(userID int, event string, page domain.PageRequest) (*domain.Page[provider.WebhookEvent], error)
+	ReplayEvent(userID int, id int) ([]provider.WebhookReplayResult, error)
+}
+
+// HookUseCase implements the IHookUseCase interface
+type HookUseCase struct {
+	hookSubscriptionRepository providerRepo.HookSubscriptionRepositoryInterface
+	webhookEventRepository     providerRepo.WebhookEventRepositoryInterface
+	dispatcher                 HookDispatcher
+	domains                    HookDomainResolver
+	secrets                    HookSecretEncrypter
+	Logger                     *logger.Logger
+}
+
+// NewHookUseCase creates a new HookUseCase. When domains is set, users can only subscribe target URLs on their
+// verified custom domain or its subdomains. When secrets is set, the secrets of subscriptions are stored
+// encrypted with the key of their user, otherwise in plaintext.
+func NewHookUseCase(
+	hookSubscriptionRepository providerRepo.HookSubscriptionRepositoryInterface,
+	webhookEventRepository providerRepo.WebhookEventRepositoryInterface,
+	dispatcher HookDispatcher,
+	domains HookDomainResolver,
+	secrets HookSecretEncrypter,
+	loggerInstance *logger.Logger,
+) IHookUseCase {
+	return &HookUseCase{
+		hookSubscriptionRepository: hookSubscriptionRepository,
+		webhookEventRepository:     webhookEventRepository,
+		dispatcher:                 dispatcher,
+		domains:                    domains,
+		secrets:                    secrets,
+		Logger:                     loggerInstance,
+	}
+}
+
+// Subscribe verifies the target URL and subscribes it to an event of the user. The subscription is only
+// stored once the target completed the handshake by echoing the generated secret. The returned subscription
+// holds the plaintext secret, it is only stored encrypted. Subscriptions to message.received can be limited to
+// some envelope types. The delivery settings apply to the handshake already.
+func (h *HookUseCase) Subscribe(userID int, event string, targetURL string, envelopeTypes []string, delivery provider.HookDelivery) (*provider.HookSubscription, error) {
+	if !messaging.IsHookEvent(event) {
+		return nil, domainErrors.NewAppError(fmt.Errorf("event must be one of %s", strings.Join(messaging.HookEvents, ", ")), domainErrors.ValidationError)
+	}
+	if err := validateEnvelopeTypes(event, envelopeTypes); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(targetURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, domainErrors.NewAppError(errors.New("target_url must be an absolute http or https url"), domainErrors.ValidationError)
+	}
+	if err := h.checkDomain(userID, parsed.Hostname()); err != nil {
+		return nil, err
+	}
+	if err := validateDelivery(parsed.Scheme, delivery); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		h.Logger.Error("Error generating hook secret", zap.Error(err))
+		return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	storedDelivery, err := h.encryptDelivery(userID, delivery)
+	if err != nil {
+		return nil, err
+	}
+	subscription := &provider.HookSubscription{
+		UserID:        userID,
+		Event:         event,
+		TargetURL:     targetURL,
+		EnvelopeTypes: envelopeTypes,
+		Delivery:      storedDelivery,
+	}
+
+	if err := h.dispatcher.VerifySubscription(*subscription, secret); err != nil {
+		h.Logger.Info("Hook verification failed", zap.Error(err), zap.Int("userID", userID), zap.String("event", event))
+		return nil, domainErrors.NewAppError(fmt.Errorf("verification failed: %w", err), domainErrors.ValidationError)
+	}
+
+	if subscription.Secret, err = h.encryptSecret(userID, secret); err != nil {
+		return nil, err
+	}
+	subscription, err = h.hookSubscriptionRepository.Create(subscription)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+	return subscription, nil
+}
+
+// reservedHookHeaders are the headers of deliveries a subscription can't set
+var reservedHookHeaders = []string{"Content-Type", "Content-Length", "Host", "User-Agent", "Transfer-Encoding", "Connection"}
+
+// validateDelivery checks the delivery settings of a subscription. The TLS settings need an https target, and the
+// headers can't replace the headers of the hook protocol.
+func validateDelivery(scheme string, delivery provider.HookDelivery) error {
+	for name, value := range delivery.Headers {
+		if !headerNamePattern.MatchString(name) || strings.ContainsAny(value, "\r\n") {
+			return domainErrors.NewAppError(fmt.Errorf("invalid header %q", name), domainErrors.ValidationError)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if strings.HasPrefix(canonical, "X-Hook-") || slices.Contains(reservedHookHeaders, canonical) {
+			return domainErrors.NewAppError(fmt.Errorf("the %s header can't be set", canonical), domainErrors.ValidationError)
+		}
+	}
+	if delivery.HasTLS() && scheme != "https" {
+		return domainErrors.NewAppError(errors.New("the TLS settings need an https target_url"), domainErrors.ValidationError)
+	}
+	if (delivery.ClientCertificate == "") != (delivery.ClientKey == "") {
+		return domainErrors.NewAppError(errors.New("client_certificate and client_key must be set together"), domainErrors.ValidationError)
+	}
+	if delivery.ClientCertificate != "" {
+		if _, err := tls.X509KeyPair([]byte(delivery.ClientCertificate), []byte(delivery.ClientKey)); err != nil {
+			return domainErrors.NewAppError(fmt.Errorf("invalid client certificate: %w", err), domainErrors.ValidationError)
+		}
+	}
+	if delivery.CACertificate != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(delivery.CACertificate)) {
+		return domainErrors.NewAppError(errors.New("ca_certificate contains no PEM certificate"), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// validateEnvelopeTypes checks the envelope type filter of a subscription
+func validateEnvelopeTypes(event string, envelopeTypes []string) error {
+	if len(envelopeTypes) == 0 {
+		return nil
+	}
+	if event != messaging.HookEventMessageReceived {
+		return domainErrors.NewAppError(fmt.Errorf("envelope_types can only be set for %s", messaging.HookEventMessageReceived), domainErrors.ValidationError)
+	}
+	for _, envelopeType := range envelopeTypes {
+		if !messaging.IsHookEnvelopeType(envelopeType) {
+			names := make([]string, len(messaging.HookEnvelopeTypes))
+			for i, t := range messaging.HookEnvelopeTypes {
+				names[i] = string(t)
+			}
+			return domainErrors.NewAppError(fmt.Errorf("envelope_types must be of %s", strings.Join(names, ", ")), domainErrors.ValidationError)
+		}
+	}
+	return nil
+}
+
+// RotateSecret replaces the secret of a subscription of the user. The target must accept the new secret in the
+// verification handshake, deliveries are signed with the old secret until it did. The returned subscription
+// holds the new plaintext secret, it can't be read again.
+func (h *HookUseCase) RotateSecret(userID int, id int) (*provider.HookSubscription, error) {
+	subscription, err := h.hookSubscriptionRepository.GetUserSubscription(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		h.Logger.Error("Error generating hook secret", zap.Error(err))
+		return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	if err := h.dispatcher.VerifySubscription(*subscription, secret); err != nil {
+		h.Logger.Info("Hook verification of rotated secret failed", zap.Error(err), zap.Int("userID", userID), zap.Int("subscriptionID", id))
+		return nil, domainErrors.NewAppError(fmt.Errorf("verification failed: %w", err), domainErrors.ValidationError)
+	}
+
+	storedSecret, err := h.encryptSecret(userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.hookSubscriptionRepository.UpdateSecret(id, storedSecret); err != nil {
+		return nil, err
+	}
+	h.Logger.Info("Rotated hook secret", zap.Int("userID", userID), zap.Int("subscriptionID", id))
+	subscription.Secret = secret
+	return subscription, nil
+}
+
+// EncryptStoredSecrets encrypts the secrets, header values and client keys stored in plaintext, before they were
+// encrypted or while no encryption key was set, and returns the number of subscriptions it encrypted
+func (h *HookUseCase) EncryptStoredSecrets() (int, error) {
+	if h.secrets == nil {
+		return 0, nil
+	}
+	subscriptions, err := h.hookSubscriptionRepository.GetAll()
+	if err != nil {
+		return 0, err
+	}
+	encrypted := 0
+	for _, subscription := range *subscriptions {
+		secretEncrypted := subscription.Secret == "" || security.IsEncryptedUserSecret(subscription.Secret)
+		if !secretEncrypted {
+			storedSecret, err := h.encryptSecret(subscription.UserID, subscription.Secret)
+			if err != nil {
+				return encrypted, err
+			}
+			if err := h.hookSubscriptionRepository.UpdateSecret(subscription.ID, storedSecret); err != nil {
+				return encrypted, err
+			}
+		}
+		storedDelivery, err := h.encryptDelivery(subscription.UserID, subscription.Delivery)
+		if err != nil {
+			return encrypted, err
+		}
+		deliveryEncrypted := reflect.DeepEqual(storedDelivery, subscription.Delivery)
+		if !deliveryEncrypted {
+			if err := h.hookSubscriptionRepository.UpdateDelivery(subscription.ID, storedDelivery); err != nil {
+				return encrypted, err
+			}
+			h.dispatcher.ForgetClient(subscription.ID)
+		}
+		if !secretEncrypted || !deliveryEncrypted {
+			encrypted++
+		}
+	}
+	return encrypted, nil
+}
+
+// encryptDelivery returns the delivery settings of a subscription as they are stored, with the header values and
+// the client key encrypted. Values already encrypted are kept.
+func (h *HookUseCase) encryptDelivery(userID int, delivery provider.HookDelivery) (provider.HookDelivery, error) {
+	stored := delivery
+	if len(delivery.Headers) > 0 {
+		stored.Headers = make(map[string]string, len(delivery.Headers))
+		for name, value := range delivery.Headers {
+			encrypted, err := h.encryptValue(userID, value)
+			if err != nil {
+				return provider.HookDelivery{}, err
+			}
+			stored.Headers[name] = encrypted
+		}
+	}
+	var err error
+	if stored.ClientKey, err = h.encryptValue(userID, delivery.ClientKey); err != nil {
+		return provider.HookDelivery{}, err
+	}
+	return stored, nil
+}
+
+// encryptValue encrypts a value stored in plaintext, empty and encrypted values are kept
+func (h *HookUseCase) encryptValue(userID int, value string) (string, error) {
+	if value == "" || security.IsEncryptedUserSecret(value) {
+		return value, nil
+	}
+	return h.encryptSecret(userID, value)
+}
+
+// encryptSecret returns the secret of a subscription as it is stored, in plaintext when no cipher is set
+func (h *HookUseCase) encryptSecret(userID int, secret string) (string, error) {
+	if h.secrets == nil {
+		return secret, nil
+	}
+	encrypted, err := h.secrets.Encrypt(userID, secret)
+	if err != nil {
+		h.Logger.Error("Error encrypting hook secret", zap.Error(err), zap.Int("userID", userID))
+		return "", domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	return encrypted, nil
+}
+
+// checkDomain verifies that a target host is on the verified custom domain of the user, when targets are
+// restricted to it
+func (h *HookUseCase) checkDomain(userID int, host string) error {
+	if h.domains == nil {
+		return nil
+	}
+	domain, err := h.domains.VerifiedDomain(userID)
+	if err != nil {
+		h.Logger.Error("Error getting custom domain of hook target", zap.Error(err), zap.Int("userID", userID))
+		return err
+	}
+	if domain == "" {
+		return domainErrors.NewAppError(errors.New("hook targets must be on a verified custom domain, the account has none"), domainErrors.ValidationError)
+	}
+	host = strings.ToLower(host)
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return domainErrors.NewAppError(fmt.Errorf("target_url must be on the verified custom domain %s", domain), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// Unsubscribe removes a subscription of the user
+func (h *HookUseCase) Unsubscribe(userID int, id int) error {
+	return h.hookSubscriptionRepository.Delete(userID, id)
+}
+
+// GetSubscriptions returns the subscriptions of the user
+func (h *HookUseCase) GetSubscriptions(userID int) (*[]provider.HookSubscription, error) {
+	return h.hookSubscriptionRepository.GetUserSubscriptions(userID)
+}
+
+// GetEvents returns a page of the events emitted to the subscriptions of the user within the retention
+// period, optionally of one event type
+func (h *HookUseCase) GetEvents(userID int, event string, page domain.PageRequest) (*domain.Page[provider.WebhookEvent], error) {
+	if event != "" && !messaging.IsHookEvent(event) {
+		return nil, domainErrors.NewAppError(fmt.Errorf("event must be one of %s", strings.Join(messaging.HookEvents, ", ")), domainErrors.ValidationError)
+	}
+	return h.webhookEventRepository.GetUserEvents(userID, event, page.WithDefaults())
+}
+
+// ReplayEvent re-delivers a stored event of the user to its current subscriptions to the event
+func (h *HookUseCase) ReplayEvent(userID int, id int) ([]provider.WebhookReplayResult, error) {
+	event, err := h.webhookEventRepository.GetUserEvent(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := h.dispatcher.Replay(event)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, domainErrors.NewAppError(errors.New("there is no subscription to the event"), domainErrors.ValidationError)
+	}
+	h.Logger.Info("Replayed hook event", zap.Int("userID", userID), zap.Int("eventID", id), zap.Int("targets", len(results)))
+	return results, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,383 @@
+package hook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockHookSubscriptionRepository struct {
+	created []provider.HookSubscription
+}
+
+func (m *mockHookSubscriptionRepository) Create(subscription *provider.HookSubscription) (*provider.HookSubscription, error) {
+	subscription.ID = len(m.created) + 1
+	m.created = append(m.created, *subscription)
+	return subscription, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscriptions(userID int) (*[]provider.HookSubscription, error) {
+	return &m.created, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscriptionsForEvent(userID int, event string) (*[]provider.HookSubscription, error) {
+	return &m.created, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetSubscriptionsForProviderType(event string, providerType string) (*[]provider.HookSubscription, error) {
+	return &m.created, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscription(userID int, id int) (*provider.HookSubscription, error) {
+	for _, subscription := range m.created {
+		if subscription.ID == id && subscription.UserID == userID {
+			return &subscription, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockHookSubscriptionRepository) GetAll() (*[]provider.HookSubscription, error) {
+	return &m.created, nil
+}
+
+func (m *mockHookSubscriptionRepository) UpdateSecret(id int, secret string) error {
+	m.created[id-1].Secret = secret
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) UpdateDelivery(id int, delivery provider.HookDelivery) error {
+	m.created[id-1].Delivery = delivery
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) Delete(userID int, id int) error {
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) DeleteByID(id int) error {
+	return nil
+}
+
+type mockWebhookEventRepository struct {
+	events []provider.WebhookEvent
+}
+
+func (m *mockWebhookEventRepository) Create(event *provider.WebhookEvent) (*provider.WebhookEvent, error) {
+	event.ID = len(m.events) + 1
+	m.events = append(m.events, *event)
+	return event, nil
+}
+
+func (m *mockWebhookEventRepository) GetUserEvents(userID int, event string, page domain.PageRequest) (*domain.Page[provider.WebhookEvent], error) {
+	return &domain.Page[provider.WebhookEvent]{Items: m.events}, nil
+}
+
+func (m *mockWebhookEventRepository) GetUserEvent(userID int, id int) (*provider.WebhookEvent, error) {
+	for _, event := range m.events {
+		if event.ID == id && event.UserID == userID {
+			return &event, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockWebhookEventRepository) DeleteBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type mockDispatcher struct {
+	err           error
+	secrets       []string
+	subscriptions []provider.HookSubscription
+	results       []provider.WebhookReplayResult
+	replayed      []int
+	forgotten     []int
+}
+
+func (m *mockDispatcher) VerifySubscription(subscription provider.HookSubscription, secret string) error {
+	m.secrets = append(m.secrets, secret)
+	m.subscriptions = append(m.subscriptions, subscription)
+	return m.err
+}
+
+func (m *mockDispatcher) Replay(event *provider.WebhookEvent) ([]provider.WebhookReplayResult, error) {
+	m.replayed = append(m.replayed, event.ID)
+	return m.results, nil
+}
+
+func (m *mockDispatcher) ForgetClient(subscriptionID int) {
+	m.forgotten = append(m.forgotten, subscriptionID)
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestSubscribe_StoresVerifiedSubscription(t *testing.T) {
+	repo := &mockHookSubscriptionRepository{}
+	verifier := &mockDispatcher{}
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, verifier, nil, nil, setupLogger(t))
+
+	subscription, err := useCase.Subscribe(7, "message.received", "https://hooks.example.com/catch", nil, provider.HookDelivery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, subscription.ID)
+	assert.Len(t, repo.created, 1)
+	assert.Len(t, verifier.secrets, 1)
+	assert.Len(t, verifier.secrets[0], 64)
+	assert.Equal(t, verifier.secrets[0], repo.created[0].Secret)
+}
+
+func TestSubscribe_StoresEncryptedSecret(t *testing.T) {
+	repo := &mockHookSubscriptionRepository{}
+	verifier := &mockDispatcher{}
+	secrets := newTestSecretCipher(t)
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, verifier, nil, secrets, setupLogger(t))
+
+	subscription, err := useCase.Subscribe(7, "message.received", "https://hooks.example.com/catch", nil, provider.HookDelivery{})
+	require.NoError(t, err)
+	// The plaintext secret is only returned, the stored one is encrypted with the key of the user
+	assert.Equal(t, verifier.secrets[0], subscription.Secret)
+	assert.True(t, security.IsEncryptedUserSecret(repo.created[0].Secret))
+	decrypted, err := secrets.Decrypt(7, repo.created[0].Secret)
+	require.NoError(t, err)
+	assert.Equal(t, verifier.secrets[0], decrypted)
+}
+
+func TestRotateSecret(t *testing.T) {
+	repo := &mockHookSubscriptionRepository{created: []provider.HookSubscription{{ID: 1, UserID: 7, Event: "message.failed", TargetURL: "https://hooks.example.com/catch", Secret: "old"}}}
+	verifier := &mockDispatcher{}
+	secrets := newTestSecretCipher(t)
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, verifier, nil, secrets, setupLogger(t))
+
+	subscription, err := useCase.RotateSecret(7, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{subscription.Secret}, verifier.secrets)
+	decrypted, err := secrets.Decrypt(7, repo.created[0].Secret)
+	require.NoError(t, err)
+	assert.Equal(t, subscription.Secret, decrypted)
+
+	// The subscriptions of other users can't be rotated
+	_, err = useCase.RotateSecret(8, 1)
+	assert.Error(t, err)
+
+	// A target refusing the new secret keeps the old one
+	stored := repo.created[0].Secret
+	useCase = NewHookUseCase(repo, &mockWebhookEventRepository{}, &mockDispatcher{err: errors.New("no echo")}, nil, secrets, setupLogger(t))
+	_, err = useCase.RotateSecret(7, 1)
+	assert.Error(t, err)
+	assert.Equal(t, stored, repo.created[0].Secret)
+}
+
+func TestEncryptStoredSecrets(t *testing.T) {
+	secrets := newTestSecretCipher(t)
+	encrypted, err := secrets.Encrypt(8, "second")
+	require.NoError(t, err)
+	repo := &mockHookSubscriptionRepository{created: []provider.HookSubscription{
+		{ID: 1, UserID: 7, Secret: "first"},
+		{ID: 2, UserID: 8, Secret: encrypted},
+		{ID: 3, UserID: 8, Secret: encrypted, Delivery: provider.HookDelivery{Headers: map[string]string{"Authorization": "Bearer static"}}},
+	}}
+	dispatcher := &mockDispatcher{}
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, dispatcher, nil, secrets, setupLogger(t))
+
+	count, err := useCase.EncryptStoredSecrets()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	decrypted, err := secrets.Decrypt(7, repo.created[0].Secret)
+	require.NoError(t, err)
+	assert.Equal(t, "first", decrypted)
+	assert.Equal(t, encrypted, repo.created[1].Secret)
+	decrypted, err = secrets.Decrypt(8, repo.created[2].Delivery.Headers["Authorization"])
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer static", decrypted)
+	assert.Equal(t, []int{3}, dispatcher.forgotten)
+}
+
+func TestSubscribe_EncryptsDeliverySettings(t *testing.T) {
+	repo := &mockHookSubscriptionRepository{}
+	verifier := &mockDispatcher{}
+	secrets := newTestSecretCipher(t)
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, verifier, nil, secrets, setupLogger(t))
+	certificate, key := newTestClientCertificate(t)
+	delivery := provider.HookDelivery{Headers: map[string]string{"Authorization": "Bearer static"}, ClientCertificate: certificate, ClientKey: key}
+
+	_, err := useCase.Subscribe(7, "message.failed", "https://hooks.example.com/catch", nil, delivery)
+	require.NoError(t, err)
+	stored := repo.created[0].Delivery
+	assert.Equal(t, certificate, stored.ClientCertificate)
+	decrypted, err := secrets.Decrypt(7, stored.ClientKey)
+	require.NoError(t, err)
+	assert.Equal(t, key, decrypted)
+	decrypted, err = secrets.Decrypt(7, stored.Headers["Authorization"])
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer static", decrypted)
+	// The handshake already uses the settings
+	assert.Equal(t, stored, verifier.subscriptions[0].Delivery)
+}
+
+func TestSubscribe_ValidatesDeliverySettings(t *testing.T) {
+	verifier := &mockDispatcher{}
+	useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, &mockWebhookEventRepository{}, verifier, nil, nil, setupLogger(t))
+	certificate, key := newTestClientCertificate(t)
+
+	invalid := []provider.HookDelivery{
+		{Headers: map[string]string{"X-Hook-Signature": "forged"}},
+		{Headers: map[string]string{"content-type": "text/plain"}},
+		{Headers: map[string]string{"Bad Header": "value"}},
+		{Headers: map[string]string{"Authorization": "Bearer a\r\nX-Injected: b"}},
+		{ClientCertificate: certificate},
+		{ClientCertificate: certificate, ClientKey: "not a key"},
+		{CACertificate: "not a certificate"},
+	}
+	for _, delivery := range invalid {
+		_, err := useCase.Subscribe(7, "message.failed", "https://hooks.example.com/catch", nil, delivery)
+		assert.Error(t, err)
+	}
+	// TLS settings need an https target
+	_, err := useCase.Subscribe(7, "message.failed", "http://hooks.example.com/catch", nil, provider.HookDelivery{InsecureSkipVerify: true})
+	assert.Error(t, err)
+	assert.Empty(t, verifier.secrets)
+
+	_, err = useCase.Subscribe(7, "message.failed", "https://hooks.example.com/catch", nil, provider.HookDelivery{ClientCertificate: certificate, ClientKey: key, InsecureSkipVerify: true})
+	assert.NoError(t, err)
+}
+
+// newTestClientCertificate returns a self-signed PEM certificate and key
+func newTestClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "client"}, NotAfter: time.Now().Add(time.Hour)}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func newTestSecretCipher(t *testing.T) security.IUserSecretCipher {
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	return secrets
+}
+
+func TestSubscribe_RejectsFailedVerification(t *testing.T) {
+	repo := &mockHookSubscriptionRepository{}
+	useCase := NewHookUseCase(repo, &mockWebhookEventRepository{}, &mockDispatcher{err: errors.New("no echo")}, nil, nil, setupLogger(t))
+
+	_, err := useCase.Subscribe(7, "message.failed", "https://hooks.example.com/catch", nil, provider.HookDelivery{})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Empty(t, repo.created)
+}
+
+func TestSubscribe_ValidatesRequest(t *testing.T) {
+	verifier := &mockDispatcher{}
+	useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, &mockWebhookEventRepository{}, verifier, nil, nil, setupLogger(t))
+
+	_, err := useCase.Subscribe(7, "message.deleted", "https://hooks.example.com/catch", nil, provider.HookDelivery{})
+	assert.Error(t, err)
+	_, err = useCase.Subscribe(7, "message.failed", "ftp://hooks.example.com/catch", nil, provider.HookDelivery{})
+	assert.Error(t, err)
+	// Envelope types only filter received messages
+	_, err = useCase.Subscribe(7, "message.failed", "https://hooks.example.com/catch", []string{"reaction"}, provider.HookDelivery{})
+	assert.Error(t, err)
+	_, err = useCase.Subscribe(7, "message.received", "https://hooks.example.com/catch", []string{"receipt"}, provider.HookDelivery{})
+	assert.Error(t, err)
+	assert.Empty(t, verifier.secrets)
+
+	subscription, err := useCase.Subscribe(7, "message.received", "https://hooks.example.com/catch", []string{"reaction", "payment"}, provider.HookDelivery{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reaction", "payment"}, subscription.EnvelopeTypes)
+}
+
+type mockDomainResolver map[int]string
+
+func (m mockDomainResolver) VerifiedDomain(userID int) (string, error) {
+	return m[userID], nil
+}
+
+func TestSubscribe_RestrictsTargetsToVerifiedDomain(t *testing.T) {
+	verifier := &mockDispatcher{}
+	useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, &mockWebhookEventRepository{}, verifier, mockDomainResolver{7: "acme.com"}, nil, setupLogger(t))
+
+	_, err := useCase.Subscribe(7, "message.failed", "https://hooks.acme.com/catch", nil, provider.HookDelivery{})
+	assert.NoError(t, err)
+	_, err = useCase.Subscribe(7, "message.failed", "https://ACME.com/catch", nil, provider.HookDelivery{})
+	assert.NoError(t, err)
+
+	_, err = useCase.Subscribe(7, "message.failed", "https://notacme.com/catch", nil, provider.HookDelivery{})
+	assert.Error(t, err)
+	_, err = useCase.Subscribe(8, "message.failed", "https://hooks.acme.com/catch", nil, provider.HookDelivery{})
+	assert.Error(t, err)
+	assert.Len(t, verifier.secrets, 2)
+}
+
+func TestGetEvents_ValidatesEvent(t *testing.T) {
+	events := &mockWebhookEventRepository{events: []provider.WebhookEvent{{ID: 1, UserID: 7, Event: "message.failed"}}}
+	useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, events, &mockDispatcher{}, nil, nil, setupLogger(t))
+
+	page, err := useCase.GetEvents(7, "message.failed", domain.PageRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	_, err = useCase.GetEvents(7, "message.deleted", domain.PageRequest{})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
+
+func TestReplayEvent(t *testing.T) {
+	events := &mockWebhookEventRepository{events: []provider.WebhookEvent{{ID: 1, UserID: 7, Event: "message.failed", Payload: `{}`}}}
+
+	t.Run("re-delivers an event of the user", func(t *testing.T) {
+		dispatcher := &mockDispatcher{results: []provider.WebhookReplayResult{{SubscriptionID: 3, StatusCode: 200}}}
+		useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, events, dispatcher, nil, nil, setupLogger(t))
+
+		results, err := useCase.ReplayEvent(7, 1)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, []int{1}, dispatcher.replayed)
+	})
+
+	t.Run("doesn't replay events of other users", func(t *testing.T) {
+		dispatcher := &mockDispatcher{}
+		useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, events, dispatcher, nil, nil, setupLogger(t))
+
+		_, err := useCase.ReplayEvent(8, 1)
+		var appErr *domainErrors.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, domainErrors.NotFound, appErr.Type)
+		assert.Empty(t, dispatcher.replayed)
+	})
+
+	t.Run("fails without subscriptions to the event", func(t *testing.T) {
+		useCase := NewHookUseCase(&mockHookSubscriptionRepository{}, events, &mockDispatcher{}, nil, nil, setupLogger(t))
+
+		_, err := useCase.ReplayEvent(7, 1)
+		var appErr *domainErrors.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	})
+}
@@ -0,0 +1,317 @@
+package inboundnumber
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	smsProviderType = "sms"
+	// defaultVendor is the vendor of SMS providers whose config doesn't name one
+	defaultVendor = "twilio"
+	// inboundNumbersKey is the key of the provisioned numbers in the user provider config
+	inboundNumbersKey = "inbound_numbers"
+	// InboundPath is the path, below the API base URL, SMS vendors post the SMS of a provider to
+	InboundPath    = "/v1/sms/inbound/"
+	maxSearchLimit = 50
+)
+
+// NumberProvisioner provisions inbound numbers through the API of an SMS vendor and reads the inbound SMS
+// webhooks of the vendor. The provisioner of an SMS provider is looked up by the vendor of its config, so a new
+// vendor only needs a provisioner registered in the application context.
+type NumberProvisioner interface {
+	Search(providerConfig string, search provider.NumberSearch) ([]provider.AvailableNumber, error)
+	// Provision buys a number, the SMS it receives are posted to webhookURL
+	Provision(providerConfig string, phoneNumber string, webhookURL string) (*provider.InboundNumber, error)
+	Release(providerConfig string, number provider.InboundNumber) error
+	// ParseInbound verifies and reads an inbound SMS webhook posted to webhookURL, webhooks it can't verify
+	// fail with a NotAuthenticated error
+	ParseInbound(providerConfig string, webhookURL string, header http.Header, form url.Values) (*provider.InboundSMS, error)
+}
+
+// InboundHandler receives the SMS sent to a number provisioned for the user of the user provider
+type InboundHandler func(userProvider *provider.UserProvider, sms *provider.InboundSMS)
+
+// Config controls how inbound numbers are provisioned
+type Config struct {
+	// WebhookBaseURL is the public base URL of the API, SMS vendors post inbound SMS below it
+	WebhookBaseURL string
+}
+
+// IInboundNumberUseCase defines the interface for inbound number use cases
+type IInboundNumberUseCase interface {
+	SearchNumbers(userID int, providerID int, search provider.NumberSearch) ([]provider.AvailableNumber, error)
+	GetNumbers(userID int, providerID int) ([]provider.InboundNumber, error)
+	ProvisionNumber(userID int, providerID int, phoneNumber string) (*provider.InboundNumber, error)
+	ReleaseNumber(userID int, providerID int, phoneNumber string) error
+	// ReceiveSMS verifies an inbound SMS webhook posted to requestURI and routes the SMS to the user the
+	// number it was sent to is provisioned for
+	ReceiveSMS(providerID int, requestURI string, header http.Header, form url.Values) error
+}
+
+// InboundNumberUseCase implements the IInboundNumberUseCase interface
+type InboundNumberUseCase struct {
+	providerRepository     providerRepo.ProviderRepositoryInterface
+	userProviderRepository providerRepo.UserProviderRepositoryInterface
+	provisioners           map[string]NumberProvisioner
+	handler                InboundHandler
+	config                 Config
+	Logger                 *logger.Logger
+}
+
+// NewInboundNumberUseCase creates a new InboundNumberUseCase, provisioners are keyed by SMS vendor
+func NewInboundNumberUseCase(
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	userProviderRepository providerRepo.UserProviderRepositoryInterface,
+	provisioners map[string]NumberProvisioner,
+	handler InboundHandler,
+	config Config,
+	loggerInstance *logger.Logger,
+) IInboundNumberUseCase {
+	return &InboundNumberUseCase{
+		providerRepository:     providerRepository,
+		userProviderRepository: userProviderRepository,
+		provisioners:           provisioners,
+		handler:                handler,
+		config:                 config,
+		Logger:                 loggerInstance,
+	}
+}
+
+// SearchNumbers lists the numbers the SMS provider of a user offers
+func (i *InboundNumberUseCase) SearchNumbers(userID int, providerID int, search provider.NumberSearch) ([]provider.AvailableNumber, error) {
+	if len(search.Country) != 2 {
+		return nil, domainErrors.NewAppError(errors.New("country must be an ISO 3166 country code like US"), domainErrors.ValidationError)
+	}
+	if search.Limit <= 0 || search.Limit > maxSearchLimit {
+		search.Limit = maxSearchLimit
+	}
+	_, providerDetails, provisioner, err := i.userSMSProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+	numbers, err := provisioner.Search(providerDetails.Config, search)
+	if err != nil {
+		return nil, vendorError(err)
+	}
+	return numbers, nil
+}
+
+// GetNumbers returns the numbers provisioned for a user on an SMS provider
+func (i *InboundNumberUseCase) GetNumbers(userID int, providerID int) ([]provider.InboundNumber, error) {
+	userProvider, _, _, err := i.userSMSProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+	return inboundNumbers(userProvider.Config), nil
+}
+
+// ProvisionNumber buys a number through the SMS provider of a user and stores it on the user provider config,
+// the SMS sent to it are routed to the user
+func (i *InboundNumberUseCase) ProvisionNumber(userID int, providerID int, phoneNumber string) (*provider.InboundNumber, error) {
+	if i.config.WebhookBaseURL == "" {
+		return nil, domainErrors.NewAppError(errors.New("inbound numbers need INBOUND_WEBHOOK_BASE_URL to be configured"), domainErrors.ValidationError)
+	}
+	userProvider, providerDetails, provisioner, err := i.userSMSProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+	numbers := inboundNumbers(userProvider.Config)
+	for _, number := range numbers {
+		if number.PhoneNumber == phoneNumber {
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s is provisioned already", phoneNumber), domainErrors.ValidationError)
+		}
+	}
+
+	number, err := provisioner.Provision(providerDetails.Config, phoneNumber, i.webhookURL(providerID))
+	if err != nil {
+		return nil, vendorError(err)
+	}
+	i.Logger.Info("Provisioned inbound number", zap.Int("userID", userID), zap.Int("providerID", providerID), zap.String("phoneNumber", number.PhoneNumber))
+
+	if err := i.storeNumbers(userProvider, append(numbers, *number)); err != nil {
+		// A number that isn't stored receives SMS no one gets, so it is given back right away
+		i.Logger.Error("Error storing provisioned number, releasing it", zap.Error(err), zap.Int("userID", userID), zap.String("phoneNumber", number.PhoneNumber))
+		if releaseErr := provisioner.Release(providerDetails.Config, *number); releaseErr != nil {
+			i.Logger.Error("Error releasing unstored number", zap.Error(releaseErr), zap.String("phoneNumber", number.PhoneNumber))
+		}
+		return nil, err
+	}
+	return number, nil
+}
+
+// ReleaseNumber gives a number provisioned for a user back to the SMS provider and removes it from the user
+// provider config
+func (i *InboundNumberUseCase) ReleaseNumber(userID int, providerID int, phoneNumber string) error {
+	userProvider, providerDetails, provisioner, err := i.userSMSProvider(userID, providerID)
+	if err != nil {
+		return err
+	}
+	numbers := inboundNumbers(userProvider.Config)
+	remaining := make([]provider.InboundNumber, 0, len(numbers))
+	var released *provider.InboundNumber
+	for _, number := range numbers {
+		if number.PhoneNumber == phoneNumber && released == nil {
+			released = &number
+			continue
+		}
+		remaining = append(remaining, number)
+	}
+	if released == nil {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+
+	if err := provisioner.Release(providerDetails.Config, *released); err != nil {
+		return vendorError(err)
+	}
+	i.Logger.Info("Released inbound number", zap.Int("userID", userID), zap.Int("providerID", providerID), zap.String("phoneNumber", phoneNumber))
+	return i.storeNumbers(userProvider, remaining)
+}
+
+// ReceiveSMS verifies an inbound SMS webhook and routes the SMS to the user the number is provisioned for
+func (i *InboundNumberUseCase) ReceiveSMS(providerID int, requestURI string, header http.Header, form url.Values) error {
+	providerDetails, err := i.providerRepository.GetByID(providerID)
+	if err != nil {
+		return err
+	}
+	if providerDetails.Type != smsProviderType {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	provisioner, err := i.provisionerFor(providerDetails)
+	if err != nil {
+		return err
+	}
+
+	sms, err := provisioner.ParseInbound(providerDetails.Config, strings.TrimSuffix(i.config.WebhookBaseURL, "/")+requestURI, header, form)
+	if err != nil {
+		i.Logger.Warn("Rejected inbound sms", zap.Error(err), zap.Int("providerID", providerID))
+		return vendorError(err)
+	}
+
+	userProviders, err := i.userProviderRepository.GetActiveByProviderType(smsProviderType)
+	if err != nil {
+		return err
+	}
+	for _, userProvider := range *userProviders {
+		if userProvider.ProviderID != providerID {
+			continue
+		}
+		for _, number := range inboundNumbers(userProvider.Config) {
+			if number.PhoneNumber == sms.To {
+				i.handler(&userProvider, sms)
+				return nil
+			}
+		}
+	}
+	i.Logger.Warn("Received sms for a number no active user provider has", zap.Int("providerID", providerID), zap.String("to", sms.To))
+	return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// userSMSProvider returns the user provider of a user for an SMS provider, with the provider and its provisioner
+func (i *InboundNumberUseCase) userSMSProvider(userID int, providerID int) (*provider.UserProvider, *provider.Provider, NumberProvisioner, error) {
+	userProviders, err := i.userProviderRepository.GetUserProviders(userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var userProvider *provider.UserProvider
+	for _, up := range *userProviders {
+		if up.ProviderID == providerID {
+			userProvider = &up
+			break
+		}
+	}
+	if userProvider == nil {
+		return nil, nil, nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+
+	providerDetails, err := i.providerRepository.GetByID(providerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if providerDetails.Type != smsProviderType {
+		return nil, nil, nil, domainErrors.NewAppError(fmt.Errorf("provider %d is not an sms provider", providerID), domainErrors.ValidationError)
+	}
+	provisioner, err := i.provisionerFor(providerDetails)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return userProvider, providerDetails, provisioner, nil
+}
+
+// provisionerFor returns the provisioner of the vendor an SMS provider sends through
+func (i *InboundNumberUseCase) provisionerFor(providerDetails *provider.Provider) (NumberProvisioner, error) {
+	var config struct {
+		Vendor string `json:"vendor"`
+	}
+	if providerDetails.Config != "" {
+		_ = json.Unmarshal([]byte(providerDetails.Config), &config)
+	}
+	if config.Vendor == "" {
+		config.Vendor = defaultVendor
+	}
+	provisioner, ok := i.provisioners[config.Vendor]
+	if !ok {
+		return nil, domainErrors.NewAppError(fmt.Errorf("sms vendor %s can't provision inbound numbers", config.Vendor), domainErrors.ValidationError)
+	}
+	return provisioner, nil
+}
+
+func (i *InboundNumberUseCase) webhookURL(providerID int) string {
+	return strings.TrimSuffix(i.config.WebhookBaseURL, "/") + InboundPath + strconv.Itoa(providerID)
+}
+
+// storeNumbers replaces the numbers in the user provider config, keeping its other settings. The update is
+// checked against the version read, so a concurrent config change isn't overwritten.
+func (i *InboundNumberUseCase) storeNumbers(userProvider *provider.UserProvider, numbers []provider.InboundNumber) error {
+	config := map[string]json.RawMessage{}
+	if strings.TrimSpace(userProvider.Config) != "" {
+		if err := json.Unmarshal([]byte(userProvider.Config), &config); err != nil {
+			return domainErrors.NewAppError(fmt.Errorf("invalid user provider config: %w", err), domainErrors.ValidationError)
+		}
+	}
+	encoded, _ := json.Marshal(numbers)
+	config[inboundNumbersKey] = encoded
+	data, _ := json.Marshal(config)
+
+	_, err := i.userProviderRepository.Update(userProvider.ID, map[string]interface{}{
+		"config":  string(data),
+		"version": userProvider.Version,
+	})
+	return err
+}
+
+// inboundNumbers reads the numbers provisioned for a user from the user provider config
+func inboundNumbers(userProviderConfig string) []provider.InboundNumber {
+	var config struct {
+		InboundNumbers []provider.InboundNumber `json:"inbound_numbers"`
+	}
+	if userProviderConfig != "" {
+		_ = json.Unmarshal([]byte(userProviderConfig), &config)
+	}
+	if config.InboundNumbers == nil {
+		return []provider.InboundNumber{}
+	}
+	return config.InboundNumbers
+}
+
+// vendorError reports an error of the SMS vendor, e.g. a number that is no longer available, to the caller
+// as a validation error
+func vendorError(err error) error {
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return domainErrors.NewAppError(err, domainErrors.ValidationError)
+}
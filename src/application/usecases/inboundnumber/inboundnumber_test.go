@@ -0,0 +1,196 @@
+package inboundnumber
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProviderRepository implements GetByID, the embedded interface panics on any other call
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []provider.Provider
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	for _, p := range m.providers {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockUserProviderRepository keeps user providers in memory, the embedded interface panics on any other call
+type mockUserProviderRepository struct {
+	providerRepo.UserProviderRepositoryInterface
+	userProviders []provider.UserProvider
+	failUpdate    bool
+}
+
+func (m *mockUserProviderRepository) GetUserProviders(userID int) (*[]provider.UserProvider, error) {
+	userProviders := []provider.UserProvider{}
+	for _, up := range m.userProviders {
+		if up.UserID == userID {
+			userProviders = append(userProviders, up)
+		}
+	}
+	return &userProviders, nil
+}
+
+func (m *mockUserProviderRepository) GetActiveByProviderType(providerType string) (*[]provider.UserProvider, error) {
+	return &m.userProviders, nil
+}
+
+func (m *mockUserProviderRepository) Update(id int, userProviderMap map[string]interface{}) (*provider.UserProvider, error) {
+	if m.failUpdate {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.Conflict)
+	}
+	for i := range m.userProviders {
+		if m.userProviders[i].ID == id {
+			m.userProviders[i].Config = userProviderMap["config"].(string)
+			m.userProviders[i].Version++
+			return &m.userProviders[i], nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockProvisioner hands out the numbers asked for and accepts webhooks signed "valid"
+type mockProvisioner struct {
+	provisionedURL string
+	released       []string
+}
+
+func (m *mockProvisioner) Search(providerConfig string, search provider.NumberSearch) ([]provider.AvailableNumber, error) {
+	return []provider.AvailableNumber{{PhoneNumber: "+14155550100", Country: search.Country}}, nil
+}
+
+func (m *mockProvisioner) Provision(providerConfig string, phoneNumber string, webhookURL string) (*provider.InboundNumber, error) {
+	m.provisionedURL = webhookURL
+	return &provider.InboundNumber{ID: "PN" + phoneNumber, PhoneNumber: phoneNumber, ProvisionedAt: time.Now()}, nil
+}
+
+func (m *mockProvisioner) Release(providerConfig string, number provider.InboundNumber) error {
+	m.released = append(m.released, number.PhoneNumber)
+	return nil
+}
+
+func (m *mockProvisioner) ParseInbound(providerConfig string, webhookURL string, header http.Header, form url.Values) (*provider.InboundSMS, error) {
+	if header.Get("Signature") != "valid" {
+		return nil, domainErrors.NewAppError(errors.New("invalid signature"), domainErrors.NotAuthenticated)
+	}
+	return &provider.InboundSMS{From: form.Get("From"), To: form.Get("To"), Body: form.Get("Body")}, nil
+}
+
+type received struct {
+	userID int
+	sms    *provider.InboundSMS
+}
+
+func newTestUseCase(t *testing.T) (*mockUserProviderRepository, *mockProvisioner, *[]received, IInboundNumberUseCase) {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	providers := &mockProviderRepository{providers: []provider.Provider{
+		{ID: 3, Type: "sms", Config: `{"account_sid":"AC123","auth_token":"secret"}`},
+		{ID: 4, Type: "matrix"},
+	}}
+	userProviders := &mockUserProviderRepository{userProviders: []provider.UserProvider{
+		{ID: 10, UserID: 1, ProviderID: 3, Config: `{"webhook_enabled":true}`, Version: 2},
+		{ID: 11, UserID: 1, ProviderID: 4},
+	}}
+	provisioner := &mockProvisioner{}
+	var receivedSMS []received
+	handler := func(userProvider *provider.UserProvider, sms *provider.InboundSMS) {
+		receivedSMS = append(receivedSMS, received{userID: userProvider.UserID, sms: sms})
+	}
+	useCase := NewInboundNumberUseCase(providers, userProviders, map[string]NumberProvisioner{"twilio": provisioner}, handler,
+		Config{WebhookBaseURL: "https://api.example.com/"}, loggerInstance)
+	return userProviders, provisioner, &receivedSMS, useCase
+}
+
+func TestProvisionAndReleaseNumber(t *testing.T) {
+	userProviders, provisioner, _, useCase := newTestUseCase(t)
+
+	number, err := useCase.ProvisionNumber(1, 3, "+14155550100")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1/sms/inbound/3", provisioner.provisionedURL)
+	assert.Contains(t, userProviders.userProviders[0].Config, `"webhook_enabled":true`)
+
+	numbers, err := useCase.GetNumbers(1, 3)
+	require.NoError(t, err)
+	require.Len(t, numbers, 1)
+	assert.Equal(t, number.ID, numbers[0].ID)
+
+	_, err = useCase.ProvisionNumber(1, 3, "+14155550100")
+	assert.EqualError(t, err, "+14155550100 is provisioned already")
+
+	require.NoError(t, useCase.ReleaseNumber(1, 3, "+14155550100"))
+	assert.Equal(t, []string{"+14155550100"}, provisioner.released)
+	numbers, err = useCase.GetNumbers(1, 3)
+	require.NoError(t, err)
+	assert.Empty(t, numbers)
+
+	var appErr *domainErrors.AppError
+	err = useCase.ReleaseNumber(1, 3, "+14155550100")
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestProvisionNumber_ReleasesUnstoredNumber(t *testing.T) {
+	userProviders, provisioner, _, useCase := newTestUseCase(t)
+	userProviders.failUpdate = true
+
+	_, err := useCase.ProvisionNumber(1, 3, "+14155550100")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"+14155550100"}, provisioner.released)
+}
+
+func TestProvisionNumber_RequiresSMSProvider(t *testing.T) {
+	_, _, _, useCase := newTestUseCase(t)
+
+	var appErr *domainErrors.AppError
+	_, err := useCase.ProvisionNumber(1, 4, "+14155550100")
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	// Users can only provision numbers on providers they have
+	_, err = useCase.ProvisionNumber(2, 3, "+14155550100")
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestReceiveSMS(t *testing.T) {
+	_, _, receivedSMS, useCase := newTestUseCase(t)
+	_, err := useCase.ProvisionNumber(1, 3, "+14155550100")
+	require.NoError(t, err)
+
+	signed := http.Header{"Signature": {"valid"}}
+	form := url.Values{"From": {"+14155550199"}, "To": {"+14155550100"}, "Body": {"ACK"}}
+	require.NoError(t, useCase.ReceiveSMS(3, "/v1/sms/inbound/3", signed, form))
+	require.Len(t, *receivedSMS, 1)
+	assert.Equal(t, 1, (*receivedSMS)[0].userID)
+	assert.Equal(t, "ACK", (*receivedSMS)[0].sms.Body)
+
+	var appErr *domainErrors.AppError
+	err = useCase.ReceiveSMS(3, "/v1/sms/inbound/3", http.Header{}, form)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+
+	// SMS to numbers no user has are not routed
+	form.Set("To", "+14155550111")
+	err = useCase.ReceiveSMS(3, "/v1/sms/inbound/3", signed, form)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.Len(t, *receivedSMS, 1)
+}
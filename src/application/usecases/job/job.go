@@ -0,0 +1,79 @@
+package job
+
+import (
+	"errors"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// IJobUseCase defines the interface for following and controlling the background jobs of a user
+type IJobUseCase interface {
+	GetJobs(userID int, jobType string, limit int) (*[]provider.Job, error)
+	GetJob(userID int, id int) (*provider.Job, error)
+	// Cancel cancels a queued job, or asks the worker running it to stop
+	Cancel(userID int, id int) (*provider.Job, error)
+	// Retry queues a failed or cancelled job again
+	Retry(userID int, id int) (*provider.Job, error)
+}
+
+// JobUseCase implements the IJobUseCase interface
+type JobUseCase struct {
+	jobRepository providerRepo.JobRepositoryInterface
+	Logger        *logger.Logger
+}
+
+// NewJobUseCase creates a new JobUseCase
+func NewJobUseCase(jobRepository providerRepo.JobRepositoryInterface, loggerInstance *logger.Logger) IJobUseCase {
+	return &JobUseCase{jobRepository: jobRepository, Logger: loggerInstance}
+}
+
+func (j *JobUseCase) GetJobs(userID int, jobType string, limit int) (*[]provider.Job, error) {
+	return j.jobRepository.GetUserJobs(userID, jobType, limit)
+}
+
+// GetJob returns a job created by the user, jobs of other users are not found
+func (j *JobUseCase) GetJob(userID int, id int) (*provider.Job, error) {
+	job, err := j.jobRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.CreatedBy != userID {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return job, nil
+}
+
+func (j *JobUseCase) Cancel(userID int, id int) (*provider.Job, error) {
+	if _, err := j.GetJob(userID, id); err != nil {
+		return nil, err
+	}
+	cancelled, err := j.jobRepository.RequestCancel(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, domainErrors.NewAppError(errors.New("the job already finished"), domainErrors.Conflict)
+	}
+	j.Logger.Info("Job cancellation requested", zap.Int("jobID", id), zap.Int("userID", userID))
+	return j.jobRepository.GetByID(id)
+}
+
+func (j *JobUseCase) Retry(userID int, id int) (*provider.Job, error) {
+	if _, err := j.GetJob(userID, id); err != nil {
+		return nil, err
+	}
+	retried, err := j.jobRepository.Retry(id)
+	if err != nil {
+		return nil, err
+	}
+	if !retried {
+		return nil, domainErrors.NewAppError(errors.New("only failed or cancelled jobs can be retried"), domainErrors.Conflict)
+	}
+	j.Logger.Info("Job queued for retry", zap.Int("jobID", id), zap.Int("userID", userID))
+	return j.jobRepository.GetByID(id)
+}
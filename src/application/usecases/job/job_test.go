@@ -0,0 +1,96 @@
+package job
+
+import (
+	"errors"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJobRepository struct {
+	providerRepo.JobRepositoryInterface
+	job       provider.Job
+	cancelled bool
+	retried   bool
+}
+
+func (m *mockJobRepository) GetByID(id int) (*provider.Job, error) {
+	if id != m.job.ID {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	job := m.job
+	return &job, nil
+}
+
+func (m *mockJobRepository) RequestCancel(id int) (bool, error) {
+	if m.job.Status != providerRepo.JobStatusQueued && m.job.Status != providerRepo.JobStatusRunning {
+		return false, nil
+	}
+	m.cancelled = true
+	return true, nil
+}
+
+func (m *mockJobRepository) Retry(id int) (bool, error) {
+	if m.job.Status != providerRepo.JobStatusFailed && m.job.Status != providerRepo.JobStatusCancelled {
+		return false, nil
+	}
+	m.retried = true
+	return true, nil
+}
+
+func setupUseCase(t *testing.T, status string) (IJobUseCase, *mockJobRepository) {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	repository := &mockJobRepository{job: provider.Job{ID: 4, Status: status, CreatedBy: 7}}
+	return NewJobUseCase(repository, loggerInstance), repository
+}
+
+func assertErrorType(t *testing.T, err error, errorType domainErrors.ErrorType) {
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, errorType, appErr.Type)
+}
+
+func TestGetJob_OtherUserNotFound(t *testing.T) {
+	useCase, _ := setupUseCase(t, providerRepo.JobStatusRunning)
+
+	_, err := useCase.GetJob(8, 4)
+	assertErrorType(t, err, domainErrors.NotFound)
+
+	job, err := useCase.GetJob(7, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, job.ID)
+}
+
+func TestCancel(t *testing.T) {
+	useCase, repository := setupUseCase(t, providerRepo.JobStatusRunning)
+	_, err := useCase.Cancel(7, 4)
+	assert.NoError(t, err)
+	assert.True(t, repository.cancelled)
+
+	useCase, repository = setupUseCase(t, providerRepo.JobStatusCompleted)
+	_, err = useCase.Cancel(7, 4)
+	assertErrorType(t, err, domainErrors.Conflict)
+	assert.False(t, repository.cancelled)
+
+	_, err = useCase.Cancel(8, 4)
+	assertErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestRetry(t *testing.T) {
+	useCase, repository := setupUseCase(t, providerRepo.JobStatusFailed)
+	_, err := useCase.Retry(7, 4)
+	assert.NoError(t, err)
+	assert.True(t, repository.retried)
+
+	useCase, _ = setupUseCase(t, providerRepo.JobStatusRunning)
+	_, err = useCase.Retry(7, 4)
+	assertErrorType(t, err, domainErrors.Conflict)
+}
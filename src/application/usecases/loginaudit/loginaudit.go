@@ -0,0 +1,201 @@
+package loginaudit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+)
+
+const (
+	MethodPassword = "password"
+	MethodAzureAD  = "azure_ad"
+
+	// maxUserAgentLength is the length user agents are truncated to before they are stored
+	maxUserAgentLength = 255
+)
+
+// Config controls when repeated failed logins are reported
+type Config struct {
+	// FailureThreshold is the number of failed logins within FailureWindow that triggers a notification
+	FailureThreshold int
+	FailureWindow    time.Duration
+}
+
+// LoginAttempt describes a login attempt as seen by the API. UserID is set for successful logins, failed
+// logins are attributed to the user by Email.
+type LoginAttempt struct {
+	UserID        int
+	Email         string
+	Method        string
+	IPAddress     string
+	UserAgent     string
+	Success       bool
+	FailureReason string
+}
+
+// ILoginAuditUseCase defines the interface for login audit use cases
+type ILoginAuditUseCase interface {
+	RecordLogin(attempt LoginAttempt)
+	GetActivity(userID int, page domain.PageRequest) (*domain.Page[domainUser.LoginEvent], error)
+	GetSettings(userID int) (*domainUser.LoginNotificationSettings, error)
+	UpdateSettings(settings *domainUser.LoginNotificationSettings) (*domainUser.LoginNotificationSettings, error)
+}
+
+// LoginAuditUseCase implements the ILoginAuditUseCase interface
+type LoginAuditUseCase struct {
+	loginActivityRepository user.LoginActivityRepositoryInterface
+	userRepository          user.UserRepositoryInterface
+	messageUseCase          message.IMessageUseCase
+	config                  Config
+	Logger                  *logger.Logger
+}
+
+// NewLoginAuditUseCase creates a new LoginAuditUseCase
+func NewLoginAuditUseCase(
+	loginActivityRepository user.LoginActivityRepositoryInterface,
+	userRepository user.UserRepositoryInterface,
+	messageUseCase message.IMessageUseCase,
+	config Config,
+	loggerInstance *logger.Logger,
+) ILoginAuditUseCase {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = 15 * time.Minute
+	}
+	return &LoginAuditUseCase{
+		loginActivityRepository: loginActivityRepository,
+		userRepository:          userRepository,
+		messageUseCase:          messageUseCase,
+		config:                  config,
+		Logger:                  loggerInstance,
+	}
+}
+
+// RecordLogin stores a login attempt and notifies the user through their own channel about a login from a
+// new IP address or device, or when the failed logins within the window reach the threshold. Errors are only
+// logged, auditing never fails a login.
+func (l *LoginAuditUseCase) RecordLogin(attempt LoginAttempt) {
+	userID := attempt.UserID
+	if userID == 0 {
+		existing, err := l.userRepository.GetByEmail(attempt.Email)
+		if err != nil || existing.ID == 0 {
+			// Attempts for unknown emails can't be attributed to a user
+			return
+		}
+		userID = existing.ID
+	}
+
+	userAgent := attempt.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	event := &domainUser.LoginEvent{
+		UserID:        userID,
+		Method:        attempt.Method,
+		IPAddress:     attempt.IPAddress,
+		UserAgent:     userAgent,
+		Success:       attempt.Success,
+		FailureReason: attempt.FailureReason,
+	}
+
+	if attempt.Success {
+		knownIP, knownDevice, hasLogins, err := l.loginActivityRepository.GetKnownLocation(userID, event.IPAddress, event.UserAgent)
+		if err != nil {
+			return
+		}
+		// The first login of a user has nothing to compare with
+		event.NewLocation = hasLogins && (!knownIP || !knownDevice)
+	}
+
+	if _, err := l.loginActivityRepository.CreateEvent(event); err != nil {
+		return
+	}
+
+	if event.Success {
+		if event.NewLocation {
+			l.Logger.Info("Login from a new location", zap.Int("userID", userID), zap.String("ip", event.IPAddress))
+			l.notify(userID, fmt.Sprintf("New login to your account from IP %s (%s) at %s. If this wasn't you, change your password.",
+				event.IPAddress, describeDevice(event.UserAgent), time.Now().UTC().Format(time.RFC3339)))
+		}
+		return
+	}
+
+	failures, err := l.loginActivityRepository.CountFailedLoginsSince(userID, time.Now().Add(-l.config.FailureWindow))
+	if err != nil {
+		return
+	}
+	// Notify once when the threshold is reached, not on every further failure
+	if failures == int64(l.config.FailureThreshold) {
+		l.Logger.Warn("Repeated failed logins", zap.Int("userID", userID), zap.Int64("failures", failures))
+		l.notify(userID, fmt.Sprintf("%d failed logins to your account within %s, the last from IP %s. If this wasn't you, change your password.",
+			failures, l.config.FailureWindow, event.IPAddress))
+	}
+}
+
+// notify sends a login notification through the channel configured by the user, if enabled
+func (l *LoginAuditUseCase) notify(userID int, text string) {
+	settings, err := l.loginActivityRepository.GetSettings(userID)
+	if err != nil || !settings.Enabled || settings.Channel == "" || settings.Recipient == "" {
+		return
+	}
+
+	_, err = l.messageUseCase.SendMessage(&message.MessageRequest{
+		Type:       settings.Channel,
+		Message:    text,
+		Recipients: []string{settings.Recipient},
+		UserID:     userID,
+	})
+	if err != nil {
+		l.Logger.Error("Error sending login notification", zap.Error(err), zap.Int("userID", userID))
+	}
+}
+
+// GetActivity returns a page of the login events of a user, newest first
+func (l *LoginAuditUseCase) GetActivity(userID int, page domain.PageRequest) (*domain.Page[domainUser.LoginEvent], error) {
+	return l.loginActivityRepository.GetUserEvents(userID, page.WithDefaults())
+}
+
+// GetSettings returns the login notification settings of a user, users without settings aren't notified
+func (l *LoginAuditUseCase) GetSettings(userID int) (*domainUser.LoginNotificationSettings, error) {
+	settings, err := l.loginActivityRepository.GetSettings(userID)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			return &domainUser.LoginNotificationSettings{UserID: userID, Enabled: false}, nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateSettings sets where a user is notified about suspicious logins, or disables the notifications
+func (l *LoginAuditUseCase) UpdateSettings(settings *domainUser.LoginNotificationSettings) (*domainUser.LoginNotificationSettings, error) {
+	if settings.Enabled && (settings.Channel == "" || settings.Recipient == "") {
+		return nil, domainErrors.NewAppError(errors.New("channel and recipient are required to enable login notifications"), domainErrors.ValidationError)
+	}
+
+	l.Logger.Info("Updating login notification settings", zap.Int("userID", settings.UserID), zap.Bool("enabled", settings.Enabled))
+	return l.loginActivityRepository.SaveSettings(settings)
+}
+
+// describeDevice shortens a user agent for a notification
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "unknown device"
+	}
+	if len(userAgent) > 80 {
+		return userAgent[:80] + "..."
+	}
+	return userAgent
+}
@@ -0,0 +1,179 @@
+package loginaudit
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLoginActivityRepository struct {
+	events      []domainUser.LoginEvent
+	settings    *domainUser.LoginNotificationSettings
+	knownIP     bool
+	knownDevice bool
+	hasLogins   bool
+	failures    int64
+}
+
+func (m *mockLoginActivityRepository) CreateEvent(event *domainUser.LoginEvent) (*domainUser.LoginEvent, error) {
+	m.events = append(m.events, *event)
+	return event, nil
+}
+
+func (m *mockLoginActivityRepository) GetUserEvents(userID int, page domain.PageRequest) (*domain.Page[domainUser.LoginEvent], error) {
+	return &domain.Page[domainUser.LoginEvent]{Items: m.events}, nil
+}
+
+func (m *mockLoginActivityRepository) GetKnownLocation(userID int, ipAddress string, userAgent string) (bool, bool, bool, error) {
+	return m.knownIP, m.knownDevice, m.hasLogins, nil
+}
+
+func (m *mockLoginActivityRepository) CountFailedLoginsSince(userID int, since time.Time) (int64, error) {
+	return m.failures, nil
+}
+
+func (m *mockLoginActivityRepository) GetEventsAfter(afterID int, limit int) ([]domainUser.LoginEvent, error) {
+	return nil, nil
+}
+
+func (m *mockLoginActivityRepository) GetSettings(userID int) (*domainUser.LoginNotificationSettings, error) {
+	if m.settings == nil {
+		return &domainUser.LoginNotificationSettings{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return m.settings, nil
+}
+
+func (m *mockLoginActivityRepository) SaveSettings(settings *domainUser.LoginNotificationSettings) (*domainUser.LoginNotificationSettings, error) {
+	m.settings = settings
+	return settings, nil
+}
+
+type mockUserRepository struct {
+	user.UserRepositoryInterface
+	users map[string]domainUser.User
+}
+
+func (m *mockUserRepository) GetByEmail(email string) (*domainUser.User, error) {
+	if existing, ok := m.users[email]; ok {
+		return &existing, nil
+	}
+	return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type mockMessageUseCase struct {
+	message.IMessageUseCase
+	sent []*message.MessageRequest
+}
+
+func (m *mockMessageUseCase) SendMessage(request *message.MessageRequest) (*message.MessageResponse, error) {
+	m.sent = append(m.sent, request)
+	return &message.MessageResponse{ID: 1, Status: "pending"}, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func enabledSettings() *domainUser.LoginNotificationSettings {
+	return &domainUser.LoginNotificationSettings{UserID: 7, Channel: "signal", Recipient: "+4915112345678", Enabled: true}
+}
+
+func TestRecordLoginNotifiesAboutNewLocation(t *testing.T) {
+	repository := &mockLoginActivityRepository{settings: enabledSettings(), knownIP: false, knownDevice: true, hasLogins: true}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewLoginAuditUseCase(repository, &mockUserRepository{}, messageUseCase, Config{}, setupLogger(t))
+
+	useCase.RecordLogin(LoginAttempt{UserID: 7, Method: MethodPassword, IPAddress: "203.0.113.9", UserAgent: "curl/8.0", Success: true})
+
+	assert.Len(t, repository.events, 1)
+	assert.True(t, repository.events[0].NewLocation)
+	assert.Len(t, messageUseCase.sent, 1)
+	assert.Equal(t, "signal", messageUseCase.sent[0].Type)
+	assert.Equal(t, []string{"+4915112345678"}, messageUseCase.sent[0].Recipients)
+	assert.Equal(t, 7, messageUseCase.sent[0].UserID)
+	assert.Contains(t, messageUseCase.sent[0].Message, "203.0.113.9")
+}
+
+func TestRecordLoginFirstLoginIsNotNewLocation(t *testing.T) {
+	repository := &mockLoginActivityRepository{settings: enabledSettings(), hasLogins: false}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewLoginAuditUseCase(repository, &mockUserRepository{}, messageUseCase, Config{}, setupLogger(t))
+
+	useCase.RecordLogin(LoginAttempt{UserID: 7, Method: MethodPassword, IPAddress: "203.0.113.9", Success: true})
+
+	assert.Len(t, repository.events, 1)
+	assert.False(t, repository.events[0].NewLocation)
+	assert.Empty(t, messageUseCase.sent)
+}
+
+func TestRecordLoginNotifiesOnceWhenFailuresReachThreshold(t *testing.T) {
+	repository := &mockLoginActivityRepository{settings: enabledSettings()}
+	userRepository := &mockUserRepository{users: map[string]domainUser.User{"jane@example.com": {ID: 7}}}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewLoginAuditUseCase(repository, userRepository, messageUseCase, Config{FailureThreshold: 3, FailureWindow: time.Minute}, setupLogger(t))
+
+	for failures := int64(1); failures <= 4; failures++ {
+		repository.failures = failures
+		useCase.RecordLogin(LoginAttempt{Email: "jane@example.com", Method: MethodPassword, IPAddress: "198.51.100.1", FailureReason: "invalid credentials"})
+	}
+
+	assert.Len(t, repository.events, 4)
+	assert.Equal(t, 7, repository.events[0].UserID)
+	assert.False(t, repository.events[0].Success)
+	assert.Len(t, messageUseCase.sent, 1)
+	assert.Contains(t, messageUseCase.sent[0].Message, "3 failed logins")
+}
+
+func TestRecordLoginSkipsUnknownEmail(t *testing.T) {
+	repository := &mockLoginActivityRepository{settings: enabledSettings()}
+	useCase := NewLoginAuditUseCase(repository, &mockUserRepository{}, &mockMessageUseCase{}, Config{}, setupLogger(t))
+
+	useCase.RecordLogin(LoginAttempt{Email: "nobody@example.com", Method: MethodPassword})
+
+	assert.Empty(t, repository.events)
+}
+
+func TestRecordLoginDoesNotNotifyWhenDisabled(t *testing.T) {
+	repository := &mockLoginActivityRepository{hasLogins: true}
+	messageUseCase := &mockMessageUseCase{}
+	useCase := NewLoginAuditUseCase(repository, &mockUserRepository{}, messageUseCase, Config{}, setupLogger(t))
+
+	useCase.RecordLogin(LoginAttempt{UserID: 7, Method: MethodAzureAD, IPAddress: "203.0.113.9", Success: true})
+
+	assert.True(t, repository.events[0].NewLocation)
+	assert.Empty(t, messageUseCase.sent)
+}
+
+func TestGetSettingsDefaultsToDisabled(t *testing.T) {
+	useCase := NewLoginAuditUseCase(&mockLoginActivityRepository{}, &mockUserRepository{}, &mockMessageUseCase{}, Config{}, setupLogger(t))
+
+	settings, err := useCase.GetSettings(7)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, settings.UserID)
+	assert.False(t, settings.Enabled)
+}
+
+func TestUpdateSettingsRequiresChannelAndRecipient(t *testing.T) {
+	useCase := NewLoginAuditUseCase(&mockLoginActivityRepository{}, &mockUserRepository{}, &mockMessageUseCase{}, Config{}, setupLogger(t))
+
+	_, err := useCase.UpdateSettings(&domainUser.LoginNotificationSettings{UserID: 7, Channel: "signal", Enabled: true})
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	settings, err := useCase.UpdateSettings(&domainUser.LoginNotificationSettings{UserID: 7, Enabled: false})
+	assert.NoError(t, err)
+	assert.False(t, settings.Enabled)
+}
@@ -0,0 +1,69 @@
+package maintenance
+
+import (
+	"errors"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// maxReasonLength is the longest reason stored with the maintenance mode
+const maxReasonLength = 255
+
+// Disconnector closes the connections that don't stay open during maintenance, like the receive streams
+type Disconnector interface {
+	DisconnectAll(reason string)
+}
+
+// IMaintenanceUseCase defines the interface for reading and changing the maintenance mode
+type IMaintenanceUseCase interface {
+	Get() (*provider.MaintenanceMode, error)
+	// Set enables or disables maintenance mode. Enabling it closes the connections of this instance right away,
+	// the other instances notice within their check interval.
+	Set(enabled bool, reason string, updatedBy int) (*provider.MaintenanceMode, error)
+}
+
+// MaintenanceUseCase implements the IMaintenanceUseCase interface
+type MaintenanceUseCase struct {
+	repository   providerRepo.MaintenanceModeRepositoryInterface
+	disconnector Disconnector
+	Logger       *logger.Logger
+}
+
+// NewMaintenanceUseCase creates a new MaintenanceUseCase, disconnector may be nil
+func NewMaintenanceUseCase(repository providerRepo.MaintenanceModeRepositoryInterface, disconnector Disconnector, loggerInstance *logger.Logger) IMaintenanceUseCase {
+	return &MaintenanceUseCase{repository: repository, disconnector: disconnector, Logger: loggerInstance}
+}
+
+func (u *MaintenanceUseCase) Get() (*provider.MaintenanceMode, error) {
+	return u.repository.Get()
+}
+
+func (u *MaintenanceUseCase) Set(enabled bool, reason string, updatedBy int) (*provider.MaintenanceMode, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxReasonLength {
+		return nil, domainErrors.NewAppError(errors.New("reason must be at most 255 characters"), domainErrors.ValidationError)
+	}
+	mode, err := u.repository.Save(&provider.MaintenanceMode{Enabled: enabled, Reason: reason, UpdatedBy: updatedBy})
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Warn("Changed maintenance mode", zap.Bool("enabled", enabled), zap.String("reason", reason), zap.Int("updatedBy", updatedBy))
+	if enabled && u.disconnector != nil {
+		u.disconnector.DisconnectAll(DisconnectReason(mode))
+	}
+	return mode, nil
+}
+
+// DisconnectReason is the reason the connections closed for the maintenance mode are given
+func DisconnectReason(mode *provider.MaintenanceMode) string {
+	if mode.Reason == "" {
+		return "maintenance mode enabled"
+	}
+	return "maintenance mode enabled: " + mode.Reason
+}
@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockMaintenanceModeRepository struct {
+	providerRepo.MaintenanceModeRepositoryInterface
+	mode provider.MaintenanceMode
+}
+
+func (m *mockMaintenanceModeRepository) Get() (*provider.MaintenanceMode, error) {
+	mode := m.mode
+	return &mode, nil
+}
+
+func (m *mockMaintenanceModeRepository) Save(mode *provider.MaintenanceMode) (*provider.MaintenanceMode, error) {
+	m.mode = *mode
+	return m.Get()
+}
+
+type mockDisconnector struct {
+	reasons []string
+}
+
+func (m *mockDisconnector) DisconnectAll(reason string) {
+	m.reasons = append(m.reasons, reason)
+}
+
+func TestSet_EnablingDisconnects(t *testing.T) {
+	repository, disconnector := &mockMaintenanceModeRepository{}, &mockDisconnector{}
+	useCase := NewMaintenanceUseCase(repository, disconnector, &logger.Logger{Log: zap.NewNop()})
+
+	mode, err := useCase.Set(true, " database upgrade ", 3)
+	require.NoError(t, err)
+	assert.Equal(t, provider.MaintenanceMode{Enabled: true, Reason: "database upgrade", UpdatedBy: 3}, *mode)
+	assert.Equal(t, []string{"maintenance mode enabled: database upgrade"}, disconnector.reasons)
+
+	_, err = useCase.Set(false, "", 3)
+	require.NoError(t, err)
+	assert.Len(t, disconnector.reasons, 1)
+}
+
+func TestSet_ReasonTooLong(t *testing.T) {
+	repository := &mockMaintenanceModeRepository{}
+	useCase := NewMaintenanceUseCase(repository, nil, &logger.Logger{Log: zap.NewNop()})
+
+	_, err := useCase.Set(true, strings.Repeat("x", 256), 3)
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.False(t, repository.mode.Enabled)
+}
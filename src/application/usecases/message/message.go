@@ -1,24 +1,85 @@
 package message
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
 	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/directory"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
 	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
 	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	AckStatusPending      = "pending"
+	AckStatusAcknowledged = "acknowledged"
+	AckStatusExpired      = "expired"
+
+	// DefaultAckKeyword is replied by a recipient to acknowledge a message, alone or followed by the ack token
+	DefaultAckKeyword   = "ACK"
+	maxAckKeywordLength = 32
+
+	// ackCodeAlphabet leaves out characters that are easily confused when typed
+	ackCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	ackCodeLength   = 6
+
+	maxSignalAttachments = 10
+	// maxSignalAttachmentsSize bounds the encoded attachments of a message, they're stored with it until it is sent
+	maxSignalAttachmentsSize = 12 << 20
+
+	// maxEmailSubjectLength is the longest line RFC 5322 allows, maxEmailHTMLSize bounds the HTML stored with a message
+	maxEmailSubjectLength = 998
+	maxEmailHTMLSize      = 1 << 20
+	maxEmailAttachments   = 10
+
+	// maxActions and maxActionLabelLength fit the quick replies of LINE, the tightest provider showing buttons
+	maxActions           = 10
+	maxActionLabelLength = 20
+)
+
+// actionIDPattern keeps action IDs short enough for the button payloads of the providers
+var actionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
 // MessageRequest represents a request to send a message
 type MessageRequest struct {
 	Type       string
 	Message    string
 	Recipients []string
+	Tags       map[string]string
 	UserID     int
+	// Ack demands an acknowledgement from a recipient, nil if none is needed
+	Ack *AckRequest
+	// TrackLinks sends the URLs of the message as short links that record the clicks of each recipient
+	TrackLinks bool
+	// Extensions are provider specific options, nil if none are set. The selected provider type must
+	// support each of them.
+	Extensions *provider.MessageExtensions
+	// Actions are offered to the recipients as buttons, or as numbered options by providers without buttons
+	Actions []provider.MessageAction
+}
+
+// AckRequest demands that a recipient acknowledges a message before a deadline
+type AckRequest struct {
+	// Keyword is replied to acknowledge, defaults to DefaultAckKeyword
+	Keyword string
+	Timeout time.Duration
+	// EscalationChainID is triggered when the deadline passes unacknowledged, 0 for none
+	EscalationChainID int
 }
 
 // MessageResponse represents the response from sending a message
@@ -26,6 +87,89 @@ type MessageResponse struct {
 	ID      int
 	Status  string
 	Message string
+	// UnresolvedRecipients are the directory identifiers that couldn't be resolved, the message isn't sent to them
+	UnresolvedRecipients []directory.Failure
+	// AckToken is the token a recipient replies with to acknowledge the message, empty if no ack was demanded
+	AckToken    string
+	AckDeadline *time.Time
+	// Segmentation is how the message is sent through the selected provider, the messages billed per recipient
+	Segmentation messaging.Segmentation
+	// CappedRecipients are the recipients whose frequency caps held the message, empty unless it is held
+	CappedRecipients []CappedRecipient
+	// Recipients are the initial validation results of the requested recipients, in the order requested
+	Recipients []RecipientResult
+}
+
+// RecipientResolutionError is returned by SendMessage when none of the recipients could be resolved or is valid
+type RecipientResolutionError struct {
+	Failures   []directory.Failure
+	Recipients []RecipientResult
+}
+
+func (e *RecipientResolutionError) Error() string {
+	return fmt.Sprintf("none of the recipients could be resolved or is valid, %d failed", len(e.Failures))
+}
+
+// UserDeactivatedError is returned by SendMessage when the sending user was deactivated
+type UserDeactivatedError struct {
+	UserID int
+}
+
+func (e *UserDeactivatedError) Error() string {
+	return fmt.Sprintf("user %d is deactivated", e.UserID)
+}
+
+// MessageTooLongError is returned by SendMessage when the message is longer than the selected provider sends and
+// the length policy of the provider refuses it
+type MessageTooLongError struct {
+	ProviderType string
+	Segmentation messaging.Segmentation
+}
+
+func (e *MessageTooLongError) Error() string {
+	if e.Segmentation.MaxSegments > 0 && e.Segmentation.Segments > e.Segmentation.MaxSegments {
+		return fmt.Sprintf("the message takes %d segments, the %s provider sends at most %d", e.Segmentation.Segments, e.ProviderType, e.Segmentation.MaxSegments)
+	}
+	return fmt.Sprintf("the message is %d characters long, %s providers send at most %d", e.Segmentation.Characters, e.ProviderType, e.Segmentation.MaxCharacters)
+}
+
+// EditMessageRequest represents a request to change the text of a sent message
+type EditMessageRequest struct {
+	ID      int
+	UserID  int
+	Message string
+}
+
+// EditMessageResponse represents the response from editing a message
+type EditMessageResponse struct {
+	ID           int
+	ProviderType string
+	Message      string
+	// Edits is the edit history of the message, the oldest first
+	Edits []provider.MessageEdit
+}
+
+// EditNotSupportedError is returned by EditMessage when the provider that sent the message can't edit sent messages
+type EditNotSupportedError struct {
+	ProviderType string
+}
+
+func (e *EditNotSupportedError) Error() string {
+	return fmt.Sprintf("messages sent through %s providers can't be edited", e.ProviderType)
+}
+
+// EditFailedError is returned by EditMessage when the provider refused the edit
+type EditFailedError struct {
+	ProviderType string
+	Err          error
+}
+
+func (e *EditFailedError) Error() string {
+	return fmt.Sprintf("the %s provider couldn't edit the message: %v", e.ProviderType, e.Err)
+}
+
+func (e *EditFailedError) Unwrap() error {
+	return e.Err
 }
 
 // MessageStatusRequest represents a request to check message status
@@ -40,9 +184,94 @@ type MessageStatusResponse struct {
 	Message      string
 	Recipients   string
 	ErrorMessage string
+	ErrorCode    string
 	RetryCount   int
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Tags         map[string]string
+	// Ack* report the acknowledgement demanded by the sender, AckStatus is empty when none was demanded
+	AckStatus      string
+	AckDeadline    *time.Time
+	AcknowledgedBy string
+	AcknowledgedAt *time.Time
+	// LinkClicks summarizes the clicks on the tracked links of the message, nil when it doesn't track links
+	LinkClicks *provider.LinkClickStats
+	// Deliveries report the delivery to each recipient, for providers with delivery callbacks
+	Deliveries []provider.MessageDelivery
+	// Edits is the edit history of the message, the oldest first
+	Edits []provider.MessageEdit
+	// Segments is the number of messages billed per recipient by the provider
+	Segments int
+	// Actions are the actions offered with the message, chosen ones are reported by message.action hooks
+	Actions   []provider.MessageAction
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// BacklogConfig controls when SendMessage refuses new messages because too many are waiting to be sent
+type BacklogConfig struct {
+	// Threshold is the number of pending messages from which new messages are refused, 0 disables the check
+	Threshold int
+	// RetryAfter is suggested to refused callers as the time to wait before sending again
+	RetryAfter time.Duration
+}
+
+// BacklogExceededError is returned by SendMessage when the pending backlog exceeds the configured threshold
+type BacklogExceededError struct {
+	Backlog    int
+	RetryAfter time.Duration
+}
+
+func (e *BacklogExceededError) Error() string {
+	return fmt.Sprintf("message backlog of %d pending messages exceeded, retry after %s", e.Backlog, e.RetryAfter)
+}
+
+// QueueStatsResponse reports the saturation of the message pipeline
+type QueueStatsResponse struct {
+	QueueDepth    int
+	QueueCapacity int
+	Backlog       int
+	Threshold     int
+	Deferred      int64
+	Rejected      int64
+	// MaxInFlightPerUser is the limit of workers one user can take, 0 when it isn't limited
+	MaxInFlightPerUser int
+	// Users are the users with messages being processed or queued on this instance
+	Users []messaging.UserLoad
+	// Gauges of the queue states, refreshed periodically by the queue monitor
+	Processing          int
+	FailedAwaitingRetry int
+	Held                int
+	Lag                 time.Duration
+	LagLevel            string
+	RefreshedAt         time.Time
+}
+
+// MessageHistoryRequest represents a request to search the processed messages of a user
+type MessageHistoryRequest struct {
+	UserID int
+	Status string
+	Tags   map[string]string
+	Page   domain.PageRequest
+}
+
+// MessageHistoryItem represents a processed message returned by a history search
+type MessageHistoryItem struct {
+	ID           int
+	MessageID    int
+	ProviderID   int
+	Status       string
+	Message      string
+	Recipients   string
+	ErrorMessage string
+	ErrorCode    string
+	RetryCount   int
+	Tags         map[string]string
+	ProcessedAt  time.Time
+}
+
+// ContentTransformer applies the content transformations of a user to a message before it is stored. On a dry
+// run nothing is stored by the transformations.
+type ContentTransformer interface {
+	Transform(userID int, messageType string, message string, dryRun bool) (string, error)
 }
 
 // IMessageUseCase defines the interface for message use cases
@@ -50,6 +279,12 @@ type IMessageUseCase interface {
 	SendMessage(request *MessageRequest) (*MessageResponse, error)
 	RetryFailedMessages() error
 	GetMessageStatus(request *MessageStatusRequest) (*MessageStatusResponse, error)
+	// EditMessage changes the text of a sent message of the user on the provider that sent it
+	EditMessage(request *EditMessageRequest) (*EditMessageResponse, error)
+	GetMessageHistory(request *MessageHistoryRequest) (*domain.Page[MessageHistoryItem], error)
+	GetQueueStats() (*QueueStatsResponse, error)
+	// PreviewMessage plans a send request like SendMessage without storing or sending the message
+	PreviewMessage(request *MessageRequest) (*PreviewResponse, error)
 }
 
 // MessageUseCase implements the IMessageUseCase interface
@@ -57,9 +292,22 @@ type MessageUseCase struct {
 	providerRepository           providerRepo.ProviderRepositoryInterface
 	userProviderRepository       providerRepo.UserProviderRepositoryInterface
 	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	historyRepository            providerRepo.MessageTransactionHistoryRepositoryInterface
 	messageProcessor             *messaging.MessageProcessor
+	queueMonitor                 *messaging.QueueMonitor
 	userRepository               userRepo.UserRepositoryInterface
-	Logger                       *logger.Logger
+	backlog                      BacklogConfig
+	recipientCaps                RecipientCapConfig
+	recipientResolver            directory.Resolver
+	linkTracker                  *shortlink.Tracker
+	messageDeliveryRepository    providerRepo.MessageDeliveryRepositoryInterface
+	messageEditRepository        providerRepo.MessageEditRepositoryInterface
+	attachmentRepository         providerRepo.AttachmentRepositoryInterface
+	// recipientCapViolationRepository records the sends held or rejected by the recipient caps, nil to not record them
+	recipientCapViolationRepository providerRepo.RecipientCapViolationRepositoryInterface
+	// contentTransformer transforms the messages of users before they are stored, nil to send them as requested
+	contentTransformer ContentTransformer
+	Logger             *logger.Logger
 }
 
 // NewMessageUseCase creates a new MessageUseCase
@@ -67,28 +315,58 @@ func NewMessageUseCase(
 	providerRepository providerRepo.ProviderRepositoryInterface,
 	userProviderRepository providerRepo.UserProviderRepositoryInterface,
 	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
 	messageProcessor *messaging.MessageProcessor,
+	queueMonitor *messaging.QueueMonitor,
 	userRepository userRepo.UserRepositoryInterface,
+	backlog BacklogConfig,
+	recipientCaps RecipientCapConfig,
+	recipientResolver directory.Resolver,
+	linkTracker *shortlink.Tracker,
+	messageDeliveryRepository providerRepo.MessageDeliveryRepositoryInterface,
+	messageEditRepository providerRepo.MessageEditRepositoryInterface,
+	attachmentRepository providerRepo.AttachmentRepositoryInterface,
+	recipientCapViolationRepository providerRepo.RecipientCapViolationRepositoryInterface,
+	contentTransformer ContentTransformer,
 	loggerInstance *logger.Logger,
 ) IMessageUseCase {
 	return &MessageUseCase{
-		providerRepository:           providerRepository,
-		userProviderRepository:       userProviderRepository,
-		messageTransactionRepository: messageTransactionRepository,
-		messageProcessor:             messageProcessor,
-		userRepository:               userRepository,
-		Logger:                       loggerInstance,
+		providerRepository:              providerRepository,
+		userProviderRepository:          userProviderRepository,
+		messageTransactionRepository:    messageTransactionRepository,
+		historyRepository:               historyRepository,
+		messageProcessor:                messageProcessor,
+		queueMonitor:                    queueMonitor,
+		userRepository:                  userRepository,
+		backlog:                         backlog,
+		recipientCaps:                   recipientCaps,
+		recipientResolver:               recipientResolver,
+		linkTracker:                     linkTracker,
+		messageDeliveryRepository:       messageDeliveryRepository,
+		messageEditRepository:           messageEditRepository,
+		attachmentRepository:            attachmentRepository,
+		recipientCapViolationRepository: recipientCapViolationRepository,
+		contentTransformer:              contentTransformer,
+		Logger:                          loggerInstance,
 	}
 }
 
 // SendMessage sends a message using the appropriate provider
 func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse, error) {
+	if err := m.validateRequest(request); err != nil {
+		return nil, err
+	}
+
 	// Check user's daily message rate limit
 	user, err := m.userRepository.GetByID(request.UserID)
 	if err != nil {
 		m.Logger.Error("Error getting user", zap.Error(err), zap.Int("userID", request.UserID))
 		return nil, err
 	}
+	if !user.Status {
+		m.Logger.Warn("Refused message of deactivated user", zap.Int("userID", request.UserID))
+		return nil, &UserDeactivatedError{UserID: request.UserID}
+	}
 
 	// Count messages sent by user today
 	messageCount, err := m.messageTransactionRepository.CountUserMessagesForToday(request.UserID)
@@ -106,21 +384,701 @@ func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse,
 		return nil, errors.New("daily message rate limit exceeded")
 	}
 
-	// Get user providers by priority
-	userProviders, err := m.userProviderRepository.GetUserProvidersByPriority(request.UserID)
+	// Refuse new messages while the pipeline is saturated
+	if m.backlog.Threshold > 0 {
+		backlog, err := m.messageTransactionRepository.CountPendingMessages()
+		if err != nil {
+			m.Logger.Error("Error counting pending messages", zap.Error(err))
+			return nil, err
+		}
+		if backlog >= m.backlog.Threshold {
+			m.messageProcessor.RecordRejected()
+			m.Logger.Warn("Message backlog exceeded, refusing message",
+				zap.Int("userID", request.UserID),
+				zap.Int("backlog", backlog),
+				zap.Int("threshold", m.backlog.Threshold))
+			return nil, &BacklogExceededError{Backlog: backlog, RetryAfter: m.backlog.RetryAfter}
+		}
+	}
+
+	selected, err := m.selectRoute(request.UserID, request.Type)
+	if err != nil {
+		return nil, err
+	}
+	selectedProvider := selected.userProvider
+	selectedProviderDetails := selected.provider
+	if err := checkExtensionsSupported(request.Extensions, selectedProviderDetails.Type); err != nil {
+		return nil, err
+	}
+
+	// Resolve directory identifiers to the addresses of the recipients on the selected provider
+	recipients, unresolved := directory.ResolveAll(m.recipientResolver, request.Recipients, selectedProviderDetails.Type)
+	if len(unresolved) > 0 {
+		m.Logger.Warn("Recipients couldn't be resolved",
+			zap.Int("userID", request.UserID),
+			zap.Int("unresolved", len(unresolved)),
+			zap.Int("resolved", len(recipients)))
+	}
+	// Blank and duplicate recipients are dropped, the response tells which recipients are never attempted
+	results, recipients := recipientResults(request.Recipients, recipients, unresolved)
+	if len(recipients) == 0 {
+		return nil, &RecipientResolutionError{Failures: unresolved, Recipients: results}
+	}
+
+	// Refuse or hold the message when a recipient was already sent the capped number of messages
+	now := time.Now()
+	capped, err := m.checkRecipientCaps(user, recipients, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(capped) > 0 && m.recipientCaps.Action != provider.RecipientCapActionHold {
+		m.Logger.Warn("Recipient frequency cap exceeded, refusing message",
+			zap.Int("userID", request.UserID),
+			zap.Int("cappedRecipients", len(capped)))
+		m.recordCapViolations(request.UserID, capped, provider.RecipientCapActionReject, 0)
+		return nil, &RecipientCapExceededError{Recipients: capped, RetryAfter: latestRelease(capped).Sub(now)}
+	}
+
+	// Transform the message before it is stored, so the stored request is the content that is sent
+	if m.contentTransformer != nil {
+		transformed, err := m.contentTransformer.Transform(request.UserID, request.Type, request.Message, false)
+		if err != nil {
+			return nil, err
+		}
+		request.Message = transformed
+	}
+
+	// Create message transaction record
+	recipientsJSON, _ := json.Marshal(recipients)
+	messageTransaction := &provider.MessageTransaction{
+		UserID:     request.UserID,
+		ProviderID: selectedProvider.ProviderID,
+		Recipients: string(recipientsJSON),
+		Tags:       encodeTags(request.Tags),
+		Extensions: encodeExtensions(request.Extensions),
+		Status:     provider.MessageStatusPending,
+		RetryCount: 0,
+		TrackLinks: request.TrackLinks && len(shortlink.FindURLs(request.Message)) > 0,
+		Actions:    encodeActions(request.Actions),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	// Tell the recipients how to acknowledge, a reply is matched by the token or by the keyword alone
+	var ackInstructions string
+	if request.Ack != nil {
+		ackToken, err := GenerateAckCode()
+		if err != nil {
+			m.Logger.Error("Error generating acknowledgement token", zap.Error(err))
+			return nil, err
+		}
+		keyword := ackKeyword(request.Ack)
+		deadline := time.Now().Add(request.Ack.Timeout)
+		ackInstructions = AckInstructions(keyword, ackToken)
+		messageTransaction.AckStatus = AckStatusPending
+		messageTransaction.AckToken = ackToken
+		messageTransaction.AckKeyword = keyword
+		messageTransaction.AckDeadline = &deadline
+		messageTransaction.AckEscalationChainID = request.Ack.EscalationChainID
+	}
+
+	// Refuse or truncate messages longer than the provider sends, the acknowledgement instructions and the options
+	// of the actions are kept whole. The options are appended when the message is sent, so they follow it through
+	// a fallback to a provider with buttons.
+	options := actionOptions(request.Actions, selectedProviderDetails.Type)
+	text, segmentation, ok := m.segmentMessage(selectedProviderDetails, request.Message, ackInstructions+options)
+	if !ok {
+		m.Logger.Warn("Message too long for provider",
+			zap.Int("userID", request.UserID),
+			zap.Int("providerID", selectedProviderDetails.ID),
+			zap.Int("characters", segmentation.Characters),
+			zap.Int("segments", segmentation.Segments))
+		return nil, &MessageTooLongError{ProviderType: selectedProviderDetails.Type, Segmentation: segmentation}
+	}
+	messageTransaction.Message = strings.TrimSuffix(text, options)
+	messageTransaction.Segments = segmentation.Segments
+
+	// A capped message is held like a message of a number warming up, and released once every recipient is
+	// under its caps again
+	var releaseAt time.Time
+	if len(capped) > 0 {
+		releaseAt = latestRelease(capped)
+		messageTransaction.Status = provider.MessageStatusHeld
+		messageTransaction.NextRetryAt = &releaseAt
+		messageTransaction.ErrorMessage = fmt.Sprintf("recipient frequency cap exceeded, message held until %s", releaseAt.Format(time.RFC3339))
+	}
+
+	// Save initial transaction record
+	messageTransaction, err = m.messageTransactionRepository.Create(messageTransaction)
 	if err != nil {
-		m.Logger.Error("Error getting user providers", zap.Error(err), zap.Int("userID", request.UserID))
+		m.Logger.Error("Error creating message transaction", zap.Error(err))
 		return nil, err
 	}
+	m.trackLinks(messageTransaction)
+
+	if len(capped) > 0 {
+		m.recordCapViolations(request.UserID, capped, provider.RecipientCapActionHold, messageTransaction.ID)
+		m.Logger.Warn("Recipient frequency cap exceeded, message held",
+			zap.Int("userID", request.UserID),
+			zap.Int("transactionID", messageTransaction.ID),
+			zap.Int("cappedRecipients", len(capped)),
+			zap.Time("releaseAt", releaseAt))
+		return &MessageResponse{
+			ID:                   messageTransaction.ID,
+			Status:               provider.MessageStatusHeld,
+			Message:              messageTransaction.ErrorMessage,
+			UnresolvedRecipients: unresolved,
+			AckToken:             messageTransaction.AckToken,
+			AckDeadline:          messageTransaction.AckDeadline,
+			Segmentation:         segmentation,
+			CappedRecipients:     capped,
+			Recipients:           results,
+		}, nil
+	}
+
+	// Enqueue the message for processing by the message processor, if the queue is full the message is
+	// already persisted as pending and picked up by the pending message watcher
+	m.messageProcessor.EnqueueMessage(messageTransaction)
+
+	// Return immediate response to the user
+	response := &MessageResponse{
+		ID:                   messageTransaction.ID,
+		Status:               provider.MessageStatusPending,
+		Message:              "Message queued for processing",
+		UnresolvedRecipients: unresolved,
+		AckToken:             messageTransaction.AckToken,
+		AckDeadline:          messageTransaction.AckDeadline,
+		Segmentation:         segmentation,
+		Recipients:           results,
+	}
+
+	m.Logger.Info("Message queued for processing",
+		zap.Int("userID", request.UserID),
+		zap.Int("providerID", selectedProvider.ProviderID),
+		zap.Int("transactionID", messageTransaction.ID))
+
+	return response, nil
+}
 
-	if len(*userProviders) == 0 {
-		m.Logger.Error("No providers configured for user", zap.Int("userID", request.UserID))
+// GetMessageStatus retrieves the status of a message by its ID
+func (m *MessageUseCase) GetMessageStatus(request *MessageStatusRequest) (*MessageStatusResponse, error) {
+	// Get the message transaction by ID
+	messageTransaction, err := m.messageTransactionRepository.GetByID(request.ID)
+	if err != nil {
+		m.Logger.Error("Error getting message status", zap.Error(err), zap.Int("messageID", request.ID))
 		return nil, err
 	}
 
+	// Convert to response
+	response := &MessageStatusResponse{
+		ID:             messageTransaction.ID,
+		Status:         messageTransaction.Status,
+		Message:        messageTransaction.Message,
+		Recipients:     messageTransaction.Recipients,
+		ErrorMessage:   messageTransaction.ErrorMessage,
+		ErrorCode:      messageTransaction.ErrorCode,
+		RetryCount:     messageTransaction.RetryCount,
+		Tags:           decodeTags(messageTransaction.Tags),
+		AckStatus:      messageTransaction.AckStatus,
+		AckDeadline:    messageTransaction.AckDeadline,
+		AcknowledgedBy: messageTransaction.AcknowledgedBy,
+		AcknowledgedAt: messageTransaction.AcknowledgedAt,
+		Segments:       messageTransaction.Segments,
+		Actions:        decodeActions(messageTransaction.Actions),
+		CreatedAt:      messageTransaction.CreatedAt,
+		UpdatedAt:      messageTransaction.UpdatedAt,
+	}
+	if messageTransaction.TrackLinks && m.linkTracker != nil {
+		response.LinkClicks, err = m.linkTracker.ClickStats(messageTransaction.ID)
+		if err != nil {
+			m.Logger.Error("Error getting link clicks", zap.Error(err), zap.Int("messageID", request.ID))
+			return nil, err
+		}
+	}
+	if m.messageDeliveryRepository != nil {
+		deliveries, err := m.messageDeliveryRepository.GetByMessageTransactionID(messageTransaction.ID)
+		if err != nil {
+			m.Logger.Error("Error getting message deliveries", zap.Error(err), zap.Int("messageID", request.ID))
+			return nil, err
+		}
+		response.Deliveries = *deliveries
+	}
+	if m.messageEditRepository != nil {
+		edits, err := m.messageEditRepository.GetByMessageTransactionID(messageTransaction.ID)
+		if err != nil {
+			m.Logger.Error("Error getting message edits", zap.Error(err), zap.Int("messageID", request.ID))
+			return nil, err
+		}
+		response.Edits = *edits
+	}
+
+	m.Logger.Info("Retrieved message status", zap.Int("messageID", request.ID), zap.String("status", messageTransaction.Status))
+	return response, nil
+}
+
+// EditMessage changes the text of a sent message through the provider that sent it and records the edit. Only
+// messages sent successfully can be edited, the recipients see the new text in place of the old one.
+func (m *MessageUseCase) EditMessage(request *EditMessageRequest) (*EditMessageResponse, error) {
+	if strings.TrimSpace(request.Message) == "" {
+		return nil, domainErrors.NewAppError(errors.New("message is required"), domainErrors.ValidationError)
+	}
+
+	messageTransaction, err := m.messageTransactionRepository.GetByID(request.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Messages of other users are reported as missing
+	if messageTransaction.UserID != request.UserID {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if messageTransaction.Status != provider.MessageStatusSuccess {
+		return nil, domainErrors.NewAppError(fmt.Errorf("only sent messages can be edited, the message is %s", messageTransaction.Status), domainErrors.Conflict)
+	}
+
+	providerDetails, err := m.providerRepository.GetByID(messageTransaction.ProviderID)
+	if err != nil {
+		m.Logger.Error("Error getting provider of message", zap.Error(err), zap.Int("messageID", request.ID))
+		return nil, err
+	}
+	if !m.messageProcessor.CanEdit(providerDetails.Type) {
+		return nil, &EditNotSupportedError{ProviderType: providerDetails.Type}
+	}
+
+	// The recipients still need the instructions to acknowledge the edited message
+	text := request.Message
+	if messageTransaction.AckToken != "" {
+		text += AckInstructions(messageTransaction.AckKeyword, messageTransaction.AckToken)
+	}
+
+	responseData, err := m.messageProcessor.EditSentMessage(messageTransaction, providerDetails, text)
+	if err != nil {
+		m.Logger.Error("Error editing message", zap.Error(err), zap.Int("messageID", request.ID), zap.String("providerType", providerDetails.Type))
+		return nil, &EditFailedError{ProviderType: providerDetails.Type, Err: err}
+	}
+
+	if _, err := m.messageTransactionRepository.Update(messageTransaction.ID, map[string]interface{}{"message": text}); err != nil {
+		m.Logger.Error("Error storing edited message", zap.Error(err), zap.Int("messageID", request.ID))
+		return nil, err
+	}
+	if _, err := m.messageEditRepository.Create(&provider.MessageEdit{
+		MessageTransactionID: messageTransaction.ID,
+		PreviousMessage:      messageTransaction.Message,
+		Message:              text,
+		ResponseData:         string(responseData),
+	}); err != nil {
+		return nil, err
+	}
+	edits, err := m.messageEditRepository.GetByMessageTransactionID(messageTransaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Logger.Info("Message edited", zap.Int("messageID", request.ID), zap.String("providerType", providerDetails.Type), zap.Int("edits", len(*edits)))
+	return &EditMessageResponse{ID: messageTransaction.ID, ProviderType: providerDetails.Type, Message: text, Edits: *edits}, nil
+}
+
+// GetMessageHistory searches a page of the processed messages of a user, filtered by status and tags
+func (m *MessageUseCase) GetMessageHistory(request *MessageHistoryRequest) (*domain.Page[MessageHistoryItem], error) {
+	histories, err := m.historyRepository.SearchUserHistory(request.UserID, request.Status, request.Tags, request.Page.WithDefaults())
+	if err != nil {
+		m.Logger.Error("Error searching message history", zap.Error(err), zap.Int("userID", request.UserID))
+		return nil, err
+	}
+
+	items := make([]MessageHistoryItem, 0, len(histories.Items))
+	for _, history := range histories.Items {
+		items = append(items, MessageHistoryItem{
+			ID:           history.ID,
+			MessageID:    history.MessageID,
+			ProviderID:   history.ProviderID,
+			Status:       history.Status,
+			Message:      history.Message,
+			Recipients:   history.Recipients,
+			ErrorMessage: history.ErrorMessage,
+			ErrorCode:    history.ErrorCode,
+			RetryCount:   history.RetryCount,
+			Tags:         decodeTags(history.Tags),
+			ProcessedAt:  history.ProcessedAt,
+		})
+	}
+
+	m.Logger.Info("Retrieved message history", zap.Int("userID", request.UserID), zap.Int("count", len(items)))
+	return &domain.Page[MessageHistoryItem]{Items: items, Next: histories.Next}, nil
+}
+
+// GetQueueStats reports the saturation of the message pipeline
+func (m *MessageUseCase) GetQueueStats() (*QueueStatsResponse, error) {
+	backlog, err := m.messageTransactionRepository.CountPendingMessages()
+	if err != nil {
+		m.Logger.Error("Error counting pending messages", zap.Error(err))
+		return nil, err
+	}
+
+	stats := m.messageProcessor.Stats()
+	gauges := m.queueMonitor.Gauges()
+	return &QueueStatsResponse{
+		QueueDepth:          stats.Depth,
+		QueueCapacity:       stats.Capacity,
+		Backlog:             backlog,
+		Threshold:           m.backlog.Threshold,
+		Deferred:            stats.Deferred,
+		Rejected:            stats.Rejected,
+		MaxInFlightPerUser:  stats.MaxInFlightPerUser,
+		Users:               stats.Users,
+		Processing:          gauges.Processing,
+		FailedAwaitingRetry: gauges.FailedAwaitingRetry,
+		Held:                gauges.Held,
+		Lag:                 gauges.Lag,
+		LagLevel:            string(gauges.LagLevel),
+		RefreshedAt:         gauges.RefreshedAt,
+	}, nil
+}
+
+// validateRequest checks the options of a send request that don't depend on the provider it is routed to
+func (m *MessageUseCase) validateRequest(request *MessageRequest) error {
+	if request.Ack != nil {
+		if err := validateAckRequest(request.Ack); err != nil {
+			return err
+		}
+	}
+	if request.Extensions != nil {
+		if err := validateExtensions(request.Extensions); err != nil {
+			return err
+		}
+		if request.Extensions.Signal != nil && request.Extensions.Signal.ResolveMentions && !hasGroupRecipient(request.Recipients) {
+			return domainErrors.NewAppError(errors.New("signal resolve_mentions needs a group recipient"), domainErrors.ValidationError)
+		}
+		if signal := request.Extensions.Signal; signal != nil && len(signal.AttachmentIDs) > 0 {
+			if err := m.checkAttachments(request.UserID, "signal", signal.AttachmentIDs, signal.ViewOnce); err != nil {
+				return err
+			}
+		}
+		if email := request.Extensions.Email; email != nil && len(email.AttachmentIDs) > 0 {
+			if err := m.checkAttachments(request.UserID, "email", email.AttachmentIDs, false); err != nil {
+				return err
+			}
+		}
+	}
+	if request.TrackLinks && (m.linkTracker == nil || !m.linkTracker.Enabled()) {
+		return domainErrors.NewAppError(errors.New("link tracking needs SHORT_LINK_BASE_URL to be configured"), domainErrors.ValidationError)
+	}
+	return validateActions(request.Actions)
+}
+
+// validateActions checks the actions of a send request
+func validateActions(actions []provider.MessageAction) error {
+	if len(actions) > maxActions {
+		return domainErrors.NewAppError(fmt.Errorf("a message offers at most %d actions", maxActions), domainErrors.ValidationError)
+	}
+	ids := make(map[string]bool, len(actions))
+	for i, action := range actions {
+		if !actionIDPattern.MatchString(action.ID) {
+			return domainErrors.NewAppError(fmt.Errorf("action %d id must be 1 to 64 letters, digits, _ or -", i+1), domainErrors.ValidationError)
+		}
+		if ids[action.ID] {
+			return domainErrors.NewAppError(fmt.Errorf("action id %s is not unique", action.ID), domainErrors.ValidationError)
+		}
+		ids[action.ID] = true
+		label := strings.TrimSpace(action.Label)
+		if label == "" || len([]rune(label)) > maxActionLabelLength || strings.ContainsAny(label, "\r\n") {
+			return domainErrors.NewAppError(fmt.Errorf("action %d label must be a single line of 1 to %d characters", i+1, maxActionLabelLength), domainErrors.ValidationError)
+		}
+	}
+	return nil
+}
+
+// validateAckRequest checks an acknowledgement demand of a send request
+func validateAckRequest(ack *AckRequest) error {
+	if ack.Timeout <= 0 {
+		return domainErrors.NewAppError(errors.New("ack timeout must be positive"), domainErrors.ValidationError)
+	}
+	if len(ack.Keyword) > maxAckKeywordLength || strings.ContainsAny(ack.Keyword, " \t\r\n") {
+		return domainErrors.NewAppError(fmt.Errorf("ack keyword must be a single word of at most %d characters", maxAckKeywordLength), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// validateExtensions checks the provider specific options of a send request
+func validateExtensions(extensions *provider.MessageExtensions) error {
+	if email := extensions.Email; email != nil {
+		if len(email.Subject) > maxEmailSubjectLength || strings.ContainsAny(email.Subject, "\r\n") {
+			return domainErrors.NewAppError(fmt.Errorf("email subject must be a single line of at most %d characters", maxEmailSubjectLength), domainErrors.ValidationError)
+		}
+		if len(email.HTML) > maxEmailHTMLSize {
+			return domainErrors.NewAppError(fmt.Errorf("email html exceeds %d MiB", maxEmailHTMLSize>>20), domainErrors.ValidationError)
+		}
+		if len(email.AttachmentIDs) > maxEmailAttachments {
+			return domainErrors.NewAppError(fmt.Errorf("email extension takes at most %d attachments", maxEmailAttachments), domainErrors.ValidationError)
+		}
+	}
+	signal := extensions.Signal
+	if signal == nil {
+		return nil
+	}
+	if len(signal.Base64Attachments)+len(signal.AttachmentIDs) > maxSignalAttachments {
+		return domainErrors.NewAppError(fmt.Errorf("signal extension takes at most %d attachments", maxSignalAttachments), domainErrors.ValidationError)
+	}
+	size := 0
+	for i, attachment := range signal.Base64Attachments {
+		if size += len(attachment); size > maxSignalAttachmentsSize {
+			return domainErrors.NewAppError(fmt.Errorf("signal attachments exceed %d MiB", maxSignalAttachmentsSize>>20), domainErrors.ValidationError)
+		}
+		mimeType, data := splitDataURI(attachment)
+		if _, err := base64.StdEncoding.DecodeString(data); err != nil || data == "" {
+			return domainErrors.NewAppError(fmt.Errorf("signal attachment %d is not base64 encoded", i+1), domainErrors.ValidationError)
+		}
+		if signal.ViewOnce && mimeType != "" && !strings.HasPrefix(mimeType, "image/") && !strings.HasPrefix(mimeType, "video/") {
+			return domainErrors.NewAppError(fmt.Errorf("signal attachment %d is a %s, only images and videos can be viewed once", i+1, mimeType), domainErrors.ValidationError)
+		}
+	}
+	if signal.ViewOnce && len(signal.Base64Attachments) == 0 && len(signal.AttachmentIDs) == 0 {
+		return domainErrors.NewAppError(errors.New("signal view_once needs an image or video attachment"), domainErrors.ValidationError)
+	}
+	if signal.TextMode != "" && signal.TextMode != "normal" && signal.TextMode != "styled" {
+		return domainErrors.NewAppError(errors.New("signal text_mode must be normal or styled"), domainErrors.ValidationError)
+	}
+	// Read receipts are counted on the tracked deliveries
+	if signal.ReadReceipts != nil && *signal.ReadReceipts && !signal.WantsDeliveryConfirmation() {
+		return domainErrors.NewAppError(errors.New("signal read_receipts need delivery_confirmation"), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// checkAttachments checks that the uploaded attachments of an extension are complete attachments of the user, and
+// images or videos for view-once Signal messages
+func (m *MessageUseCase) checkAttachments(userID int, extension string, ids []int, viewOnce bool) error {
+	if m.attachmentRepository == nil {
+		return domainErrors.NewAppError(errors.New("attachment uploads are not enabled"), domainErrors.ValidationError)
+	}
+	for _, id := range ids {
+		attachment, err := m.attachmentRepository.GetByID(id)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if err != nil || attachment.UserID != userID || !attachment.ExpiresAt.After(time.Now()) {
+			return domainErrors.NewAppError(fmt.Errorf("%s attachment %d was not found", extension, id), domainErrors.ValidationError)
+		}
+		if attachment.Status != providerRepo.AttachmentStatusComplete {
+			return domainErrors.NewAppError(fmt.Errorf("%s attachment %d isn't complete", extension, id), domainErrors.ValidationError)
+		}
+		if viewOnce && !strings.HasPrefix(attachment.MimeType, "image/") && !strings.HasPrefix(attachment.MimeType, "video/") {
+			return domainErrors.NewAppError(fmt.Errorf("%s attachment %d is a %s, only images and videos can be viewed once", extension, id, attachment.MimeType), domainErrors.ValidationError)
+		}
+	}
+	return nil
+}
+
+// isNotFound reports whether an error of a repository is NotFound
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
+
+// hasGroupRecipient reports whether one of the recipients is a Signal group, the members mentions resolve to
+func hasGroupRecipient(recipients []string) bool {
+	for _, recipient := range recipients {
+		if domainSignal.IsGroupRecipient(recipient) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDataURI splits an attachment given as data:<mime>;filename=<name>;base64,<data> into its MIME type and
+// data, attachments that aren't data URIs are the data alone
+func splitDataURI(attachment string) (string, string) {
+	if !strings.HasPrefix(attachment, "data:") {
+		return "", attachment
+	}
+	metadata, data, ok := strings.Cut(strings.TrimPrefix(attachment, "data:"), ";base64,")
+	if !ok {
+		return "", ""
+	}
+	mimeType, _, _ := strings.Cut(metadata, ";")
+	return mimeType, data
+}
+
+// checkExtensionsSupported refuses extensions the selected provider type can't send, rather than dropping them
+func checkExtensionsSupported(extensions *provider.MessageExtensions, providerType string) error {
+	if extensions == nil {
+		return nil
+	}
+	providerTypeInfo, known := providerconfig.Lookup(providerType)
+	for _, extension := range []struct {
+		name string
+		set  bool
+	}{{"signal", extensions.Signal != nil}, {"email", extensions.Email != nil}} {
+		if extension.set && (!known || !providerTypeInfo.Capabilities.SupportsExtension(extension.name)) {
+			return domainErrors.NewAppError(fmt.Errorf("the %s extension can't be sent through the selected %s provider", extension.name, providerType), domainErrors.ValidationError)
+		}
+	}
+	return nil
+}
+
+// segmentMessage fits a message with its suffix to the length limits of the provider it is sent through, see
+// messaging.Segment
+func (m *MessageUseCase) segmentMessage(providerDetails *provider.Provider, message string, suffix string) (string, messaging.Segmentation, bool) {
+	maxCharacters := 0
+	if providerType, ok := providerconfig.Lookup(providerDetails.Type); ok {
+		maxCharacters = providerType.Capabilities.MaxMessageLength
+	}
+	limits, err := messaging.ParseLengthLimits(providerDetails.Config, maxCharacters)
+	if err != nil {
+		m.Logger.Warn("Invalid length policy of provider, refusing longer messages", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+	}
+	return messaging.Segment(providerDetails.Type, limits, message, suffix)
+}
+
+// encodeExtensions serializes the extensions of a message for storage, none are stored as an empty string
+func encodeExtensions(extensions *provider.MessageExtensions) string {
+	if extensions == nil || (extensions.Signal == nil && extensions.Email == nil) {
+		return ""
+	}
+	extensionsJSON, _ := json.Marshal(extensions)
+	return string(extensionsJSON)
+}
+
+// encodeActions serializes the actions of a message for storage, none are stored as an empty string
+func encodeActions(actions []provider.MessageAction) string {
+	if len(actions) == 0 {
+		return ""
+	}
+	actionsJSON, _ := json.Marshal(actions)
+	return string(actionsJSON)
+}
+
+// decodeActions reads the stored actions of a message, none for an empty string
+func decodeActions(actionsJSON string) []provider.MessageAction {
+	var actions []provider.MessageAction
+	if actionsJSON != "" {
+		_ = json.Unmarshal([]byte(actionsJSON), &actions)
+	}
+	return actions
+}
+
+// actionOptions returns the numbered options of the actions appended to a message sent through a provider type
+// without buttons, empty for types with buttons
+func actionOptions(actions []provider.MessageAction, providerType string) string {
+	if providerTypeInfo, ok := providerconfig.Lookup(providerType); ok && providerTypeInfo.Capabilities.Actions {
+		return ""
+	}
+	return provider.ActionOptions(actions)
+}
+
+// ackKeyword returns the keyword acknowledging a message, keywords are matched case insensitive
+func ackKeyword(ack *AckRequest) string {
+	if ack.Keyword == "" {
+		return DefaultAckKeyword
+	}
+	return strings.ToUpper(ack.Keyword)
+}
+
+// AckInstructions returns the line appended to a message that demands an acknowledgement
+func AckInstructions(keyword string, ackToken string) string {
+	return fmt.Sprintf("\n\nReply %s %s to acknowledge.", keyword, ackToken)
+}
+
+// GenerateAckCode returns a random code a recipient replies with to acknowledge
+func GenerateAckCode() (string, error) {
+	code := make([]byte, ackCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(ackCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = ackCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// encodeTags serializes caller supplied tags for storage, an empty set is stored as an empty string
+func encodeTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	tagsJSON, _ := json.Marshal(tags)
+	return string(tagsJSON)
+}
+
+// decodeTags parses stored tags, returning nil when none were set
+func decodeTags(tags string) map[string]string {
+	if tags == "" {
+		return nil
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(tags), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// trackLinks stores the URLs of a message that tracks links before it is queued. Without them the message is
+// sent with its original links, a message is never held back for its click tracking.
+func (m *MessageUseCase) trackLinks(messageTransaction *provider.MessageTransaction) {
+	if !messageTransaction.TrackLinks || m.linkTracker == nil {
+		return
+	}
+	count, err := m.linkTracker.Track(messageTransaction)
+	if err != nil {
+		m.Logger.Error("Error tracking message links", zap.Error(err), zap.Int("messageID", messageTransaction.ID))
+		return
+	}
+	m.Logger.Debug("Tracking message links", zap.Int("messageID", messageTransaction.ID), zap.Int("links", count))
+}
+
+// Reasons a message is routed to its provider
+const (
+	// RouteRequestedType is the highest priority active provider of the requested type
+	RouteRequestedType = "requested_type"
+	// RouteTypeFallback is the highest priority active provider, no active provider has the requested type
+	RouteTypeFallback = "type_fallback"
+	// RouteHighestPriority is the highest priority active provider, no type was requested
+	RouteHighestPriority = "highest_priority"
+)
+
+// route is the provider a message of a user is sent through, and why
+type route struct {
+	userProvider provider.UserProvider
+	provider     *provider.Provider
+	reason       string
+	// userProviders are the routable providers of the user by priority, the message falls back through them
+	userProviders []provider.UserProvider
+	// drilled are the providers of the user skipped for a failover drill
+	drilled []provider.UserProvider
+}
+
+// selectRoute picks the provider of the user a message of the requested type is sent through, the highest
+// priority active provider of the type or the highest priority active provider when none has the type
+func (m *MessageUseCase) selectRoute(userID int, providerType string) (*route, error) {
+	// Get user providers by priority
+	allUserProviders, err := m.userProviderRepository.GetUserProvidersByPriority(userID)
+	if err != nil {
+		m.Logger.Error("Error getting user providers", zap.Error(err), zap.Int("userID", userID))
+		return nil, err
+	}
+
+	if len(*allUserProviders) == 0 {
+		m.Logger.Error("No providers configured for user", zap.Int("userID", userID))
+		return nil, domainErrors.NewAppError(errors.New("no providers are configured for the user"), domainErrors.ValidationError)
+	}
+	userProviders := m.skipDrilledProviders(userID, allUserProviders)
+	var drilled []provider.UserProvider
+	if len(*userProviders) < len(*allUserProviders) {
+		routable := make(map[int]bool, len(*userProviders))
+		for _, up := range *userProviders {
+			routable[up.ProviderID] = true
+		}
+		for _, up := range *allUserProviders {
+			if !routable[up.ProviderID] {
+				drilled = append(drilled, up)
+			}
+		}
+	}
+
 	// If user specified a provider type, try that provider first
 	var selectedProvider provider.UserProvider
-	if request.Type != "" {
+	reason := RouteHighestPriority
+	if providerType != "" {
 		// Find providers matching the requested type
 		var matchingProviders []provider.UserProvider
 		for _, up := range *userProviders {
@@ -128,7 +1086,7 @@ func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse,
 			if err != nil {
 				continue
 			}
-			if providerDetails.Type == request.Type && providerDetails.Status && up.Status {
+			if providerDetails.Type == providerType && providerDetails.Status && up.Status {
 				matchingProviders = append(matchingProviders, up)
 			}
 		}
@@ -136,6 +1094,7 @@ func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse,
 		// If we found matching providers, use the highest priority one
 		if len(matchingProviders) > 0 {
 			selectedProvider = matchingProviders[0]
+			reason = RouteRequestedType
 		} else {
 			// No matching providers, fall back to highest priority provider
 			for _, up := range *userProviders {
@@ -148,10 +1107,11 @@ func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse,
 					break
 				}
 			}
+			reason = RouteTypeFallback
 
 			m.Logger.Warn("No matching providers found for requested type, using highest priority provider",
-				zap.String("type", request.Type),
-				zap.Int("userID", request.UserID),
+				zap.String("type", providerType),
+				zap.Int("userID", userID),
 				zap.Int("providerID", selectedProvider.ProviderID))
 		}
 	} else {
@@ -169,76 +1129,41 @@ func (m *MessageUseCase) SendMessage(request *MessageRequest) (*MessageResponse,
 	}
 
 	// Verify that the provider exists
-	_, err = m.providerRepository.GetByID(selectedProvider.ProviderID)
+	selectedProviderDetails, err := m.providerRepository.GetByID(selectedProvider.ProviderID)
 	if err != nil {
 		m.Logger.Error("Error getting provider details", zap.Error(err), zap.Int("providerID", selectedProvider.ProviderID))
 		return nil, err
 	}
-
-	// Create message transaction record
-	recipientsJSON, _ := json.Marshal(request.Recipients)
-	messageTransaction := &provider.MessageTransaction{
-		UserID:     request.UserID,
-		ProviderID: selectedProvider.ProviderID,
-		Recipients: string(recipientsJSON),
-		Message:    request.Message,
-		Status:     "pending",
-		RetryCount: 0,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	// Save initial transaction record
-	messageTransaction, err = m.messageTransactionRepository.Create(messageTransaction)
-	if err != nil {
-		m.Logger.Error("Error creating message transaction", zap.Error(err))
-		return nil, err
-	}
-
-	// Enqueue the message for processing by the message processor
-	m.messageProcessor.EnqueueMessage(messageTransaction)
-
-	// Return immediate response to the user
-	response := &MessageResponse{
-		ID:      messageTransaction.ID,
-		Status:  "pending",
-		Message: "Message queued for processing",
-	}
-
-	m.Logger.Info("Message queued for processing",
-		zap.Int("userID", request.UserID),
-		zap.Int("providerID", selectedProvider.ProviderID),
-		zap.Int("transactionID", messageTransaction.ID))
-
-	return response, nil
+	return &route{
+		userProvider:  selectedProvider,
+		provider:      selectedProviderDetails,
+		reason:        reason,
+		userProviders: *userProviders,
+		drilled:       drilled,
+	}, nil
 }
 
-// GetMessageStatus retrieves the status of a message by its ID
-func (m *MessageUseCase) GetMessageStatus(request *MessageStatusRequest) (*MessageStatusResponse, error) {
-	// Get the message transaction by ID
-	messageTransaction, err := m.messageTransactionRepository.GetByID(request.ID)
-	if err != nil {
-		m.Logger.Error("Error getting message status", zap.Error(err), zap.Int("messageID", request.ID))
-		return nil, err
+// skipDrilledProviders drops the providers of a user that are in a failover drill from routing. When every
+// provider is in a drill they are all kept, the message then fails on the drilled provider like in a real outage.
+func (m *MessageUseCase) skipDrilledProviders(userID int, userProviders *[]provider.UserProvider) *[]provider.UserProvider {
+	var routable []provider.UserProvider
+	for _, up := range *userProviders {
+		if !m.messageProcessor.InDrill(userID, up.ProviderID) {
+			routable = append(routable, up)
+		}
 	}
-
-	// Convert to response
-	response := &MessageStatusResponse{
-		ID:           messageTransaction.ID,
-		Status:       messageTransaction.Status,
-		Message:      messageTransaction.Message,
-		Recipients:   messageTransaction.Recipients,
-		ErrorMessage: messageTransaction.ErrorMessage,
-		RetryCount:   messageTransaction.RetryCount,
-		CreatedAt:    messageTransaction.CreatedAt,
-		UpdatedAt:    messageTransaction.UpdatedAt,
+	if len(routable) == 0 || len(routable) == len(*userProviders) {
+		return userProviders
 	}
-
-	m.Logger.Info("Retrieved message status", zap.Int("messageID", request.ID), zap.String("status", messageTransaction.Status))
-	return response, nil
+	m.Logger.Info("Skipping providers in a failover drill",
+		zap.Int("userID", userID),
+		zap.Int("skipped", len(*userProviders)-len(routable)))
+	return &routable
 }
 
-// RetryFailedMessages checks for failed messages that are ready for retry
+// RetryFailedMessages checks for failed messages that are ready for retry. Every failed message is retried,
+// handed to the next provider or suppressed once: its retry time is cleared after the decision, the retry
+// is a new message that gets a retry time of its own when it fails.
 func (m *MessageUseCase) RetryFailedMessages() error {
 	// Get failed messages ready for retry
 	failedMessages, err := m.messageTransactionRepository.GetFailedMessagesForRetry()
@@ -256,6 +1181,25 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 
 	// Process each failed message
 	for _, failedMsg := range *failedMessages {
+		// The retry policy of the failed provider for the error code decides whether the message is retried
+		// through the same provider, handed to the next one or not sent again
+		policies, _ := messaging.ParseRetryPolicies("")
+		if failedProvider, err := m.providerRepository.GetByID(failedMsg.ProviderID); err == nil {
+			policies = m.messageProcessor.RetryPolicies(failedProvider)
+		}
+		action, _ := policies.Decide(failedMsg.ErrorCode, failedMsg.RetryCount)
+		if action == provider.FailureSuppress {
+			m.Logger.Info("Suppressing retry of failed message",
+				zap.Int("messageID", failedMsg.ID),
+				zap.String("errorCode", failedMsg.ErrorCode))
+			m.finishRetry(&failedMsg, map[string]interface{}{})
+			continue
+		}
+		offset := 1
+		if action == provider.FailureRetry && !m.messageProcessor.InDrill(failedMsg.UserID, failedMsg.ProviderID) {
+			offset = 0
+		}
+
 		// Get user providers by priority
 		userProviders, err := m.userProviderRepository.GetUserProvidersByPriority(failedMsg.UserID)
 		if err != nil {
@@ -268,17 +1212,17 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 			continue
 		}
 
-		// Find the next provider to try (after the one that failed)
+		// Find the provider to try, the one that failed or the next one
 		var nextProviderFound bool = false
 		for i, userProvider := range *userProviders {
 			// Skip providers until we find the one that failed
 			if userProvider.ProviderID == failedMsg.ProviderID {
-				// If there's a next provider in the list, use it
-				if i+1 < len(*userProviders) {
+				// If there's a provider at the offset in the list, use it
+				if i+offset < len(*userProviders) {
 					nextProviderFound = true
 
 					// Get the next provider
-					nextProvider := (*userProviders)[i+1]
+					nextProvider := (*userProviders)[i+offset]
 
 					// Get provider details
 					providerDetails, err := m.providerRepository.GetByID(nextProvider.ProviderID)
@@ -287,11 +1231,15 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 						continue
 					}
 
-					// Skip inactive providers
+					// Skip inactive providers and providers in a failover drill
 					if !providerDetails.Status || !nextProvider.Status {
 						m.Logger.Warn("Next provider is inactive, skipping", zap.Int("providerID", nextProvider.ProviderID))
 						continue
 					}
+					if m.messageProcessor.InDrill(failedMsg.UserID, nextProvider.ProviderID) {
+						m.Logger.Warn("Next provider is in a failover drill, skipping", zap.Int("providerID", nextProvider.ProviderID))
+						continue
+					}
 
 					// Create a new message transaction for the retry
 					var recipients []string
@@ -302,10 +1250,20 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 						ProviderID: nextProvider.ProviderID,
 						Recipients: failedMsg.Recipients,
 						Message:    failedMsg.Message,
-						Status:     "pending",
+						Tags:       failedMsg.Tags,
+						Extensions: failedMsg.Extensions,
+						Status:     provider.MessageStatusPending,
 						RetryCount: failedMsg.RetryCount + 1,
-						CreatedAt:  time.Now(),
-						UpdatedAt:  time.Now(),
+						// The retry takes over a pending acknowledgement, the reply then matches the message that was delivered
+						AckStatus:            failedMsg.AckStatus,
+						AckToken:             failedMsg.AckToken,
+						AckKeyword:           failedMsg.AckKeyword,
+						AckDeadline:          failedMsg.AckDeadline,
+						AckEscalationChainID: failedMsg.AckEscalationChainID,
+						TrackLinks:           failedMsg.TrackLinks,
+						Actions:              failedMsg.Actions,
+						CreatedAt:            time.Now(),
+						UpdatedAt:            time.Now(),
 					}
 
 					// Save initial transaction record
@@ -314,6 +1272,14 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 						m.Logger.Error("Error creating message transaction for retry", zap.Error(err))
 						continue
 					}
+					m.trackLinks(newTransaction)
+
+					finished := map[string]interface{}{}
+					if failedMsg.AckStatus == AckStatusPending {
+						// The acknowledgement is handed over to the retry
+						finished["ackStatus"] = ""
+					}
+					m.finishRetry(&failedMsg, finished)
 
 					// Enqueue the message for processing
 					m.messageProcessor.EnqueueMessage(newTransaction)
@@ -333,8 +1299,18 @@ func (m *MessageUseCase) RetryFailedMessages() error {
 			m.Logger.Warn("No next provider found for retry",
 				zap.Int("userID", failedMsg.UserID),
 				zap.Int("failedProviderID", failedMsg.ProviderID))
+			m.finishRetry(&failedMsg, map[string]interface{}{})
 		}
 	}
 
 	return nil
 }
+
+// finishRetry clears the retry time of a failed message the retry decision was taken for, with the other
+// changes of updateData, so the next run doesn't pick it up again
+func (m *MessageUseCase) finishRetry(failedMsg *provider.MessageTransaction, updateData map[string]interface{}) {
+	updateData["nextRetryAt"] = nil
+	if _, err := m.messageTransactionRepository.Update(failedMsg.ID, updateData); err != nil {
+		m.Logger.Error("Error clearing retry time of failed message", zap.Error(err), zap.Int("messageID", failedMsg.ID))
+	}
+}
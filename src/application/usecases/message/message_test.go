@@ -0,0 +1,199 @@
+package message
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExtensions(t *testing.T) {
+	image := "data:image/png;filename=a.png;base64,aGk="
+	tooMany := make([]string, maxSignalAttachments+1)
+	for i := range tooMany {
+		tooMany[i] = image
+	}
+	on, off := true, false
+	tests := []struct {
+		name      string
+		extension provider.SignalExtension
+		err       string
+	}{
+		{"view once image", provider.SignalExtension{Base64Attachments: []string{image}, ViewOnce: true, TextMode: "styled"}, ""},
+		{"plain base64 attachment", provider.SignalExtension{Base64Attachments: []string{"aGk="}, ViewOnce: true}, ""},
+		{"view once without attachment", provider.SignalExtension{ViewOnce: true}, "signal view_once needs an image or video attachment"},
+		{"view once document", provider.SignalExtension{Base64Attachments: []string{"data:application/pdf;base64,aGk="}, ViewOnce: true}, "signal attachment 1 is a application/pdf, only images and videos can be viewed once"},
+		{"malformed attachment", provider.SignalExtension{Base64Attachments: []string{image, "not base64!"}}, "signal attachment 2 is not base64 encoded"},
+		{"data URI without data", provider.SignalExtension{Base64Attachments: []string{"data:image/png,aGk="}}, "signal attachment 1 is not base64 encoded"},
+		{"too many attachments", provider.SignalExtension{Base64Attachments: tooMany}, "signal extension takes at most 10 attachments"},
+		{"attachments too large", provider.SignalExtension{Base64Attachments: []string{strings.Repeat("aGk=", maxSignalAttachmentsSize/4+1)}}, "signal attachments exceed 12 MiB"},
+		{"unknown text mode", provider.SignalExtension{TextMode: "markdown"}, "signal text_mode must be normal or styled"},
+		{"untracked without read receipts", provider.SignalExtension{ReadReceipts: &off, DeliveryConfirmation: &off}, ""},
+		{"read receipts without delivery confirmation", provider.SignalExtension{ReadReceipts: &on, DeliveryConfirmation: &off}, "signal read_receipts need delivery_confirmation"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extension := test.extension
+			err := validateExtensions(&provider.MessageExtensions{Signal: &extension})
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestValidateRequest_ResolveMentionsNeedsGroup(t *testing.T) {
+	useCase := &MessageUseCase{}
+	extensions := &provider.MessageExtensions{Signal: &provider.SignalExtension{ResolveMentions: true}}
+
+	assert.NoError(t, useCase.validateRequest(&MessageRequest{Recipients: []string{"+4912345", "group.abc"}, Extensions: extensions}))
+	assert.EqualError(t, useCase.validateRequest(&MessageRequest{Recipients: []string{"+4912345"}, Extensions: extensions}),
+		"signal resolve_mentions needs a group recipient")
+}
+
+type mockAttachmentRepository struct {
+	providerRepo.AttachmentRepositoryInterface
+	attachments map[int]*provider.Attachment
+}
+
+func (m *mockAttachmentRepository) GetByID(id int) (*provider.Attachment, error) {
+	if attachment, ok := m.attachments[id]; ok {
+		return attachment, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func TestValidateRequest_ChecksUploadedAttachments(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	useCase := &MessageUseCase{attachmentRepository: &mockAttachmentRepository{attachments: map[int]*provider.Attachment{
+		1: {ID: 1, UserID: 7, MimeType: "image/png", Status: providerRepo.AttachmentStatusComplete, ExpiresAt: expiresAt},
+		2: {ID: 2, UserID: 7, MimeType: "application/pdf", Status: providerRepo.AttachmentStatusComplete, ExpiresAt: expiresAt},
+		3: {ID: 3, UserID: 7, MimeType: "image/png", Status: providerRepo.AttachmentStatusUploading, ExpiresAt: expiresAt},
+		4: {ID: 4, UserID: 8, MimeType: "image/png", Status: providerRepo.AttachmentStatusComplete, ExpiresAt: expiresAt},
+	}}}
+	request := func(viewOnce bool, ids ...int) *MessageRequest {
+		return &MessageRequest{UserID: 7, Recipients: []string{"+4912345"}, Extensions: &provider.MessageExtensions{
+			Signal: &provider.SignalExtension{AttachmentIDs: ids, ViewOnce: viewOnce},
+		}}
+	}
+
+	assert.NoError(t, useCase.validateRequest(request(true, 1)))
+	assert.NoError(t, useCase.validateRequest(request(false, 1, 2)))
+	assert.EqualError(t, useCase.validateRequest(request(true, 2)), "signal attachment 2 is a application/pdf, only images and videos can be viewed once")
+	assert.EqualError(t, useCase.validateRequest(request(false, 3)), "signal attachment 3 isn't complete")
+	// Attachments of other users aren't found
+	assert.EqualError(t, useCase.validateRequest(request(false, 4)), "signal attachment 4 was not found")
+	assert.EqualError(t, useCase.validateRequest(request(false, 5)), "signal attachment 5 was not found")
+
+	// Emails attach any complete attachment of the user
+	emailRequest := &MessageRequest{UserID: 7, Recipients: []string{"bob@example.com"}, Extensions: &provider.MessageExtensions{
+		Email: &provider.EmailExtension{AttachmentIDs: []int{1, 2}},
+	}}
+	assert.NoError(t, useCase.validateRequest(emailRequest))
+	emailRequest.Extensions.Email.AttachmentIDs = []int{3}
+	assert.EqualError(t, useCase.validateRequest(emailRequest), "email attachment 3 isn't complete")
+}
+
+func TestCheckExtensionsSupported(t *testing.T) {
+	extensions := &provider.MessageExtensions{Signal: &provider.SignalExtension{TextMode: "styled"}}
+
+	assert.NoError(t, checkExtensionsSupported(extensions, "signal"))
+	assert.NoError(t, checkExtensionsSupported(nil, "email"))
+	assert.EqualError(t, checkExtensionsSupported(extensions, "email"), "the signal extension can't be sent through the selected email provider")
+
+	emailExtensions := &provider.MessageExtensions{Email: &provider.EmailExtension{Subject: "Invoice"}}
+	assert.NoError(t, checkExtensionsSupported(emailExtensions, "email"))
+	assert.EqualError(t, checkExtensionsSupported(emailExtensions, "signal"), "the email extension can't be sent through the selected signal provider")
+}
+
+func TestValidateExtensions_Email(t *testing.T) {
+	assert.NoError(t, validateExtensions(&provider.MessageExtensions{Email: &provider.EmailExtension{Subject: "Invoice", HTML: "<p>Paid</p>"}}))
+	assert.EqualError(t, validateExtensions(&provider.MessageExtensions{Email: &provider.EmailExtension{Subject: "Invoice\r\nBcc: eve@example.com"}}),
+		"email subject must be a single line of at most 998 characters")
+	assert.EqualError(t, validateExtensions(&provider.MessageExtensions{Email: &provider.EmailExtension{HTML: strings.Repeat("a", maxEmailHTMLSize+1)}}),
+		"email html exceeds 1 MiB")
+	assert.EqualError(t, validateExtensions(&provider.MessageExtensions{Email: &provider.EmailExtension{AttachmentIDs: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}}),
+		"email extension takes at most 10 attachments")
+}
+
+func TestEncodeExtensions(t *testing.T) {
+	assert.Equal(t, "", encodeExtensions(nil))
+	assert.Equal(t, "", encodeExtensions(&provider.MessageExtensions{}))
+	assert.JSONEq(t, `{"signal":{"view_once":true,"base64_attachments":["aGk="]}}`,
+		encodeExtensions(&provider.MessageExtensions{Signal: &provider.SignalExtension{Base64Attachments: []string{"aGk="}, ViewOnce: true}}))
+}
+
+func TestValidateActions(t *testing.T) {
+	tooMany := make([]provider.MessageAction, maxActions+1)
+	for i := range tooMany {
+		tooMany[i] = provider.MessageAction{ID: string(rune('a' + i)), Label: "Option"}
+	}
+	tests := []struct {
+		name    string
+		actions []provider.MessageAction
+		err     string
+	}{
+		{"none", nil, ""},
+		{"valid", []provider.MessageAction{{ID: "approve", Label: "Approve"}, {ID: "reject_2", Label: "Reject"}}, ""},
+		{"too many", tooMany, "a message offers at most 10 actions"},
+		{"invalid id", []provider.MessageAction{{ID: "approve:now", Label: "Approve"}}, "action 1 id must be 1 to 64 letters, digits, _ or -"},
+		{"duplicate id", []provider.MessageAction{{ID: "approve", Label: "Approve"}, {ID: "approve", Label: "Yes"}}, "action id approve is not unique"},
+		{"empty label", []provider.MessageAction{{ID: "approve", Label: " "}}, "action 1 label must be a single line of 1 to 20 characters"},
+		{"long label", []provider.MessageAction{{ID: "approve", Label: strings.Repeat("a", maxActionLabelLength+1)}}, "action 1 label must be a single line of 1 to 20 characters"},
+		{"multiline label", []provider.MessageAction{{ID: "approve", Label: "Approve\nnow"}}, "action 1 label must be a single line of 1 to 20 characters"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateActions(test.actions)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestActionOptions_OnlyForTypesWithoutButtons(t *testing.T) {
+	actions := []provider.MessageAction{{ID: "approve", Label: "Approve"}}
+
+	assert.Equal(t, provider.ActionOptions(actions), actionOptions(actions, "sms"))
+	assert.Empty(t, actionOptions(actions, "discord"))
+	assert.Empty(t, actionOptions(nil, "sms"))
+}
+
+func TestEditMessage(t *testing.T) {
+	useCase := newPreviewUseCase(t, &mockMessageTransactionRepository{messages: []provider.MessageTransaction{
+		{ID: 1, UserID: 1, ProviderID: 2, Status: "success", Message: "hello"},
+		{ID: 2, UserID: 1, ProviderID: 1, Status: "pending", Message: "hello"},
+	}})
+	useCase.messageProcessor = &messaging.MessageProcessor{}
+	var appErr *domainErrors.AppError
+
+	_, err := useCase.EditMessage(&EditMessageRequest{ID: 1, UserID: 1, Message: " "})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	// Messages of other users are missing
+	_, err = useCase.EditMessage(&EditMessageRequest{ID: 1, UserID: 2, Message: "fixed"})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+
+	_, err = useCase.EditMessage(&EditMessageRequest{ID: 2, UserID: 1, Message: "fixed"})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.Conflict, appErr.Type)
+
+	_, err = useCase.EditMessage(&EditMessageRequest{ID: 1, UserID: 1, Message: "fixed"})
+	var notSupportedErr *EditNotSupportedError
+	require.ErrorAs(t, err, &notSupportedErr)
+	assert.Equal(t, "sms", notSupportedErr.ProviderType)
+}
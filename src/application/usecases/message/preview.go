@@ -0,0 +1,201 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/directory"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+
+	"go.uber.org/zap"
+)
+
+// previewAckCode stands in for the ack code of a previewed message, it is only generated once the message is sent
+var previewAckCode = strings.Repeat("X", ackCodeLength)
+
+// PreviewResponse is the plan of a send request: what would be sent, to whom and through which provider
+type PreviewResponse struct {
+	// Message is the text as it would be sent, with the acknowledgement instructions and a placeholder ack code
+	Message string
+	// Recipients are the addresses the recipients resolve to on the selected provider
+	Recipients           []string
+	UnresolvedRecipients []directory.Failure
+	Provider             PreviewProvider
+	// Route is why the provider was selected, see RouteRequestedType
+	Route string
+	// Fallbacks are the active providers the message falls back through when the provider fails, by priority
+	Fallbacks []PreviewProvider
+	// Drilled are the providers skipped because they're in a failover drill
+	Drilled []PreviewProvider
+	// TrackedLinks is the number of links sent as short links
+	TrackedLinks int
+	Estimate     CostEstimate
+	// Warnings describe what a send would run into, like a refusal or a fallback to another type
+	Warnings []string
+}
+
+// PreviewProvider identifies a provider in a preview
+type PreviewProvider struct {
+	ID   int
+	Name string
+	Type string
+}
+
+// CostEstimate estimates what sending a message costs
+type CostEstimate struct {
+	// Segments is the number of messages billed per recipient, SMS are billed per segment
+	Segments int
+	// Messages is the number of messages billed for every recipient together
+	Messages int
+	// CostPerMessage is the price from the provider config, nil when the provider has no price
+	CostPerMessage *float64
+	// Cost is the price of the messages, nil when the provider has no price
+	Cost *float64
+}
+
+// PreviewMessage runs a send request through validation, routing and recipient resolution and returns the plan.
+// Invalid requests fail like they do on SendMessage, while the limits a send would be refused by are reported as
+// warnings.
+func (m *MessageUseCase) PreviewMessage(request *MessageRequest) (*PreviewResponse, error) {
+	if err := m.validateRequest(request); err != nil {
+		return nil, err
+	}
+
+	selected, err := m.selectRoute(request.UserID, request.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExtensionsSupported(request.Extensions, selected.provider.Type); err != nil {
+		return nil, err
+	}
+
+	preview := &PreviewResponse{
+		Provider: previewProvider(selected.provider),
+		Route:    selected.reason,
+	}
+	if m.contentTransformer != nil {
+		transformed, err := m.contentTransformer.Transform(request.UserID, request.Type, request.Message, true)
+		if err != nil {
+			preview.Warnings = append(preview.Warnings, err.Error()+", the message would be refused")
+		} else {
+			request.Message = transformed
+		}
+	}
+	var ackInstructions string
+	if request.Ack != nil {
+		ackInstructions = AckInstructions(ackKeyword(request.Ack), previewAckCode)
+	}
+	text, segmentation, ok := m.segmentMessage(selected.provider, request.Message, ackInstructions+actionOptions(request.Actions, selected.provider.Type))
+	preview.Message = text
+	switch {
+	case !ok:
+		preview.Warnings = append(preview.Warnings, (&MessageTooLongError{ProviderType: selected.provider.Type, Segmentation: segmentation}).Error()+", the message would be refused")
+	case segmentation.Truncated:
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("the message is longer than the %s provider sends and is truncated to %d characters", selected.provider.Type, segmentation.Characters))
+	}
+	if request.TrackLinks {
+		preview.TrackedLinks = len(shortlink.FindURLs(request.Message))
+	}
+	if selected.reason == RouteTypeFallback {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("no active %s provider, the message is sent through the highest priority provider", request.Type))
+	}
+
+	recipients, unresolved := directory.ResolveAll(m.recipientResolver, request.Recipients, selected.provider.Type)
+	_, recipients = recipientResults(request.Recipients, recipients, unresolved)
+	preview.Recipients = recipients
+	preview.UnresolvedRecipients = unresolved
+	if len(recipients) == 0 {
+		preview.Warnings = append(preview.Warnings, "none of the recipients could be resolved, the message would be refused")
+	}
+
+	preview.Fallbacks, preview.Drilled = m.previewFallbacks(selected)
+	preview.Estimate = m.estimateCost(selected.provider, segmentation.Segments, len(recipients), &preview.Warnings)
+	preview.Warnings = append(preview.Warnings, m.limitWarnings(request.UserID, recipients)...)
+
+	return preview, nil
+}
+
+// previewFallbacks returns the active providers after the selected one the message falls back through, and the
+// providers skipped for a failover drill
+func (m *MessageUseCase) previewFallbacks(selected *route) ([]PreviewProvider, []PreviewProvider) {
+	var fallbacks []PreviewProvider
+	after := false
+	for _, up := range selected.userProviders {
+		if up.ProviderID == selected.userProvider.ProviderID {
+			after = true
+			continue
+		}
+		if !after || !up.Status {
+			continue
+		}
+		providerDetails, err := m.providerRepository.GetByID(up.ProviderID)
+		if err != nil || !providerDetails.Status {
+			continue
+		}
+		fallbacks = append(fallbacks, previewProvider(providerDetails))
+	}
+
+	var drilled []PreviewProvider
+	for _, up := range selected.drilled {
+		if providerDetails, err := m.providerRepository.GetByID(up.ProviderID); err == nil {
+			drilled = append(drilled, previewProvider(providerDetails))
+		}
+	}
+	return fallbacks, drilled
+}
+
+// estimateCost estimates the messages billed for sending a message of the segments to the recipients through the
+// provider
+func (m *MessageUseCase) estimateCost(providerDetails *provider.Provider, segments int, recipients int, warnings *[]string) CostEstimate {
+	estimate := CostEstimate{Segments: segments, Messages: segments * recipients}
+	costPerMessage, err := messaging.ParseCostPerMessage(providerDetails.Config)
+	if err != nil {
+		m.Logger.Warn("Invalid cost of provider", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		*warnings = append(*warnings, "the cost_per_message of the provider is invalid, the cost isn't estimated")
+		return estimate
+	}
+	if costPerMessage != nil {
+		cost := *costPerMessage * float64(estimate.Messages)
+		estimate.CostPerMessage = costPerMessage
+		estimate.Cost = &cost
+	}
+	return estimate
+}
+
+// limitWarnings reports the deactivation, the daily rate limit, the recipient caps and the backlog a send of the
+// user would be refused or held by
+func (m *MessageUseCase) limitWarnings(userID int, recipients []string) []string {
+	var warnings []string
+	user, err := m.userRepository.GetByID(userID)
+	if err == nil && !user.Status {
+		warnings = append(warnings, "the user is deactivated, the message would be refused")
+	}
+	if err == nil {
+		messageCount, err := m.messageTransactionRepository.CountUserMessagesForToday(userID)
+		if err == nil && messageCount >= user.MessageRateLimit {
+			warnings = append(warnings, fmt.Sprintf("the daily message rate limit of %d is exceeded, the message would be refused", user.MessageRateLimit))
+		}
+		capped, err := m.checkRecipientCaps(user, recipients, time.Now())
+		if err == nil && len(capped) > 0 {
+			outcome := "refused"
+			if m.recipientCaps.Action == provider.RecipientCapActionHold {
+				outcome = "held until " + latestRelease(capped).Format(time.RFC3339)
+			}
+			warnings = append(warnings, fmt.Sprintf("%s, the message would be %s", (&RecipientCapExceededError{Recipients: capped}).Error(), outcome))
+		}
+	}
+	if m.backlog.Threshold > 0 {
+		backlog, err := m.messageTransactionRepository.CountPendingMessages()
+		if err == nil && backlog >= m.backlog.Threshold {
+			warnings = append(warnings, fmt.Sprintf("%d messages are pending, the message would be refused until the backlog drops below %d", backlog, m.backlog.Threshold))
+		}
+	}
+	return warnings
+}
+
+func previewProvider(providerDetails *provider.Provider) PreviewProvider {
+	return PreviewProvider{ID: providerDetails.ID, Name: providerDetails.Name, Type: providerDetails.Type}
+}
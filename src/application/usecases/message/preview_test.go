@@ -0,0 +1,157 @@
+package message
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProviderRepository knows an active Signal and SMS provider and an inactive email provider
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	switch id {
+	case 1:
+		return &provider.Provider{ID: 1, Name: "signal-main", Type: "signal", Status: true}, nil
+	case 2:
+		return &provider.Provider{ID: 2, Name: "twilio", Type: "sms", Status: true, Config: `{"cost_per_message":0.05}`}, nil
+	case 3:
+		return &provider.Provider{ID: 3, Name: "mail", Type: "email"}, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	today     int
+	pending   int
+	messages  []provider.MessageTransaction
+	sendTimes map[string][]time.Time
+}
+
+func (m *mockMessageTransactionRepository) GetRecipientSendTimes(userID int, recipients []string, since time.Time) (map[string][]time.Time, error) {
+	return m.sendTimes, nil
+}
+
+func (m *mockMessageTransactionRepository) CountUserMessagesForToday(userID int) (int, error) {
+	return m.today, nil
+}
+
+func (m *mockMessageTransactionRepository) GetByID(id int) (*provider.MessageTransaction, error) {
+	for i := range m.messages {
+		if m.messages[i].ID == id {
+			return &m.messages[i], nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockMessageTransactionRepository) CountPendingMessages() (int, error) {
+	return m.pending, nil
+}
+
+// mockUserRepository knows every user, user 2 is deactivated
+type mockUserRepository struct {
+	userRepo.UserRepositoryInterface
+}
+
+func (m *mockUserRepository) GetByID(id int) (*domainUser.User, error) {
+	return &domainUser.User{ID: id, MessageRateLimit: 100, Status: id != 2}, nil
+}
+
+func newPreviewUseCase(t *testing.T, transactions *mockMessageTransactionRepository) *MessageUseCase {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return &MessageUseCase{
+		providerRepository:           &mockProviderRepository{},
+		messageTransactionRepository: transactions,
+		userRepository:               &mockUserRepository{},
+		backlog:                      BacklogConfig{Threshold: 50},
+		Logger:                       loggerInstance,
+	}
+}
+
+func TestPreviewFallbacks(t *testing.T) {
+	useCase := newPreviewUseCase(t, &mockMessageTransactionRepository{})
+	selected := &route{
+		userProvider: provider.UserProvider{ProviderID: 1, Status: true},
+		userProviders: []provider.UserProvider{
+			{ProviderID: 1, Status: true}, {ProviderID: 3, Status: true}, {ProviderID: 2, Status: true},
+		},
+		drilled: []provider.UserProvider{{ProviderID: 2}},
+	}
+
+	fallbacks, drilled := useCase.previewFallbacks(selected)
+	// The inactive email provider is skipped
+	assert.Equal(t, []PreviewProvider{{ID: 2, Name: "twilio", Type: "sms"}}, fallbacks)
+	assert.Equal(t, []PreviewProvider{{ID: 2, Name: "twilio", Type: "sms"}}, drilled)
+}
+
+func TestEstimateCost(t *testing.T) {
+	useCase := newPreviewUseCase(t, &mockMessageTransactionRepository{})
+	var warnings []string
+
+	sms, _ := useCase.providerRepository.GetByID(2)
+	estimate := useCase.estimateCost(sms, 2, 3, &warnings)
+	assert.Equal(t, 2, estimate.Segments)
+	assert.Equal(t, 6, estimate.Messages)
+	require.NotNil(t, estimate.Cost)
+	assert.InDelta(t, 0.3, *estimate.Cost, 1e-9)
+
+	signal, _ := useCase.providerRepository.GetByID(1)
+	estimate = useCase.estimateCost(signal, 1, 3, &warnings)
+	assert.Equal(t, CostEstimate{Segments: 1, Messages: 3}, estimate)
+
+	estimate = useCase.estimateCost(&provider.Provider{Type: "sms", Config: `{"cost_per_message":-1}`}, 1, 1, &warnings)
+	assert.Nil(t, estimate.Cost)
+	assert.Len(t, warnings, 1)
+}
+
+func TestSegmentMessage(t *testing.T) {
+	useCase := newPreviewUseCase(t, &mockMessageTransactionRepository{})
+	signal, _ := useCase.providerRepository.GetByID(1)
+
+	// Signal providers refuse messages over 2000 characters by default
+	_, segmentation, ok := useCase.segmentMessage(signal, strings.Repeat("a", 2001), "")
+	assert.False(t, ok)
+	assert.Equal(t, "the message is 2001 characters long, signal providers send at most 2000",
+		(&MessageTooLongError{ProviderType: "signal", Segmentation: segmentation}).Error())
+
+	// SMS providers truncate to their segment limit and keep the acknowledgement instructions
+	sms := &provider.Provider{ID: 2, Type: "sms", Config: `{"length_policy":"truncate","max_segments":1}`}
+	text, segmentation, ok := useCase.segmentMessage(sms, strings.Repeat("a", 200), AckInstructions("ACK", "K7QX2M"))
+	require.True(t, ok)
+	assert.Equal(t, strings.Repeat("a", 126)+AckInstructions("ACK", "K7QX2M"), text)
+	assert.True(t, segmentation.Truncated)
+	assert.Equal(t, 1, segmentation.Segments)
+}
+
+func TestLimitWarnings(t *testing.T) {
+	assert.Empty(t, newPreviewUseCase(t, &mockMessageTransactionRepository{today: 99, pending: 49}).limitWarnings(1, nil))
+	assert.Equal(t, []string{
+		"the daily message rate limit of 100 is exceeded, the message would be refused",
+		"50 messages are pending, the message would be refused until the backlog drops below 50",
+	}, newPreviewUseCase(t, &mockMessageTransactionRepository{today: 100, pending: 50}).limitWarnings(1, nil))
+	assert.Equal(t, []string{
+		"the user is deactivated, the message would be refused",
+	}, newPreviewUseCase(t, &mockMessageTransactionRepository{}).limitWarnings(2, nil))
+}
+
+func TestSendMessageDeactivatedUser(t *testing.T) {
+	_, err := newPreviewUseCase(t, &mockMessageTransactionRepository{}).SendMessage(&MessageRequest{UserID: 2, Message: "hello"})
+	var deactivatedErr *UserDeactivatedError
+	require.ErrorAs(t, err, &deactivatedErr)
+	assert.Equal(t, 2, deactivatedErr.UserID)
+}
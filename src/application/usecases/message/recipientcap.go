@@ -0,0 +1,151 @@
+package message
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+
+	"go.uber.org/zap"
+)
+
+// RecipientCapConfig holds the global caps of the messages a user sends to the same recipient, protecting the
+// recipients from runaway integrations. The caps of a user override them.
+type RecipientCapConfig struct {
+	// PerHour and PerDay are the messages a user may send to a recipient within an hour and a day, 0 for no cap
+	PerHour int
+	PerDay  int
+	// Action is what happens to a send exceeding a cap, provider.RecipientCapActionReject or RecipientCapActionHold
+	Action string
+}
+
+// caps returns the hourly and daily caps of a user, 0 for no cap
+func (c RecipientCapConfig) caps(user *domainUser.User) (int, int) {
+	return userCap(user.RecipientHourlyCap, c.PerHour), userCap(user.RecipientDailyCap, c.PerDay)
+}
+
+// userCap applies the cap of a user: 0 keeps the global cap and a negative cap lifts it
+func userCap(userValue int, global int) int {
+	switch {
+	case userValue < 0:
+		return 0
+	case userValue > 0:
+		return userValue
+	default:
+		return global
+	}
+}
+
+// CappedRecipient is a recipient a send would exceed the frequency cap of
+type CappedRecipient struct {
+	Recipient string
+	Window    string // hour or day
+	Cap       int
+	// ReleaseAt is when enough of the recent messages left the window for the send to be under the cap
+	ReleaseAt time.Time
+}
+
+// RecipientCapExceededError is returned by SendMessage when a recipient was already sent the capped number of
+// messages and the cap action rejects the send
+type RecipientCapExceededError struct {
+	Recipients []CappedRecipient
+	RetryAfter time.Duration
+}
+
+func (e *RecipientCapExceededError) Error() string {
+	capped := make([]string, len(e.Recipients))
+	for i, recipient := range e.Recipients {
+		capped[i] = fmt.Sprintf("%s (%d per %s)", recipient.Recipient, recipient.Cap, recipient.Window)
+	}
+	return "recipient frequency cap exceeded for " + strings.Join(capped, ", ")
+}
+
+// cappedRecipients returns the recipients a send at now would exceed the hourly or the daily cap of, given the
+// times they were sent messages, the oldest first. A recipient exceeding both caps is reported for each.
+func cappedRecipients(recipients []string, sendTimes map[string][]time.Time, perHour int, perDay int, now time.Time) []CappedRecipient {
+	windows := []struct {
+		name   string
+		length time.Duration
+		cap    int
+	}{
+		{provider.RecipientCapWindowHour, time.Hour, perHour},
+		{provider.RecipientCapWindowDay, 24 * time.Hour, perDay},
+	}
+
+	var capped []CappedRecipient
+	for _, recipient := range recipients {
+		times := sendTimes[recipient]
+		for _, window := range windows {
+			if window.cap <= 0 {
+				continue
+			}
+			start := now.Add(-window.length)
+			inWindow := times[sort.Search(len(times), func(i int) bool { return times[i].After(start) }):]
+			if len(inWindow) < window.cap {
+				continue
+			}
+			// The send is under the cap once all but cap-1 of the messages in the window left it
+			capped = append(capped, CappedRecipient{
+				Recipient: recipient,
+				Window:    window.name,
+				Cap:       window.cap,
+				ReleaseAt: inWindow[len(inWindow)-window.cap].Add(window.length),
+			})
+		}
+	}
+	return capped
+}
+
+// checkRecipientCaps returns the recipients a send of the user now would exceed the frequency caps of
+func (m *MessageUseCase) checkRecipientCaps(user *domainUser.User, recipients []string, now time.Time) ([]CappedRecipient, error) {
+	perHour, perDay := m.recipientCaps.caps(user)
+	if perHour <= 0 && perDay <= 0 {
+		return nil, nil
+	}
+	since := now.Add(-time.Hour)
+	if perDay > 0 {
+		since = now.Add(-24 * time.Hour)
+	}
+	sendTimes, err := m.messageTransactionRepository.GetRecipientSendTimes(user.ID, recipients, since)
+	if err != nil {
+		m.Logger.Error("Error getting recipient send times", zap.Error(err), zap.Int("userID", user.ID))
+		return nil, err
+	}
+	return cappedRecipients(recipients, sendTimes, perHour, perDay, now), nil
+}
+
+// recordCapViolations records the capped recipients of a send for the analytics, the message is 0 for a rejected
+// send
+func (m *MessageUseCase) recordCapViolations(userID int, capped []CappedRecipient, action string, messageID int) {
+	if m.recipientCapViolationRepository == nil {
+		return
+	}
+	violations := make([]provider.RecipientCapViolation, len(capped))
+	for i, recipient := range capped {
+		violations[i] = provider.RecipientCapViolation{
+			UserID:               userID,
+			Recipient:            recipient.Recipient,
+			Window:               recipient.Window,
+			Cap:                  recipient.Cap,
+			Action:               action,
+			MessageTransactionID: messageID,
+		}
+	}
+	if err := m.recipientCapViolationRepository.CreateBatch(violations); err != nil {
+		m.Logger.Error("Error recording recipient cap violations", zap.Error(err), zap.Int("userID", userID))
+	}
+}
+
+// latestRelease returns when every capped recipient is under its caps again
+func latestRelease(capped []CappedRecipient) time.Time {
+	var releaseAt time.Time
+	for _, recipient := range capped {
+		if recipient.ReleaseAt.After(releaseAt) {
+			releaseAt = recipient.ReleaseAt
+		}
+	}
+	return releaseAt
+}
@@ -0,0 +1,52 @@
+package message
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCappedRecipients(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	sendTimes := map[string][]time.Time{
+		// 3 messages this hour, 1 earlier today
+		"+4911": {now.Add(-5 * time.Hour), now.Add(-50 * time.Minute), now.Add(-20 * time.Minute), now.Add(-time.Minute)},
+		// 1 message this hour
+		"+4922": {now.Add(-30 * time.Minute)},
+	}
+
+	capped := cappedRecipients([]string{"+4911", "+4922", "+4933"}, sendTimes, 3, 4, now)
+	assert.Equal(t, []CappedRecipient{
+		// The oldest message of the hour leaves it in 10 minutes
+		{Recipient: "+4911", Window: provider.RecipientCapWindowHour, Cap: 3, ReleaseAt: now.Add(10 * time.Minute)},
+		{Recipient: "+4911", Window: provider.RecipientCapWindowDay, Cap: 4, ReleaseAt: now.Add(19 * time.Hour)},
+	}, capped)
+	assert.Equal(t, now.Add(19*time.Hour), latestRelease(capped))
+
+	// A send under the cap, or without caps, isn't capped
+	assert.Empty(t, cappedRecipients([]string{"+4911"}, sendTimes, 4, 0, now))
+	assert.Empty(t, cappedRecipients([]string{"+4911"}, sendTimes, 0, 0, now))
+}
+
+func TestRecipientCapConfig_UserCapsOverrideGlobalCaps(t *testing.T) {
+	config := RecipientCapConfig{PerHour: 5, PerDay: 20}
+
+	perHour, perDay := config.caps(&domainUser.User{})
+	assert.Equal(t, []int{5, 20}, []int{perHour, perDay})
+	perHour, perDay = config.caps(&domainUser.User{RecipientHourlyCap: 2, RecipientDailyCap: -1})
+	assert.Equal(t, []int{2, 0}, []int{perHour, perDay})
+}
+
+func TestLimitWarnings_RecipientCaps(t *testing.T) {
+	now := time.Now()
+	transactions := &mockMessageTransactionRepository{sendTimes: map[string][]time.Time{"+4911": {now.Add(-time.Minute)}}}
+	useCase := newPreviewUseCase(t, transactions)
+	useCase.recipientCaps = RecipientCapConfig{PerHour: 1, Action: provider.RecipientCapActionReject}
+
+	assert.Equal(t, []string{"recipient frequency cap exceeded for +4911 (1 per hour), the message would be refused"},
+		useCase.limitWarnings(1, []string{"+4911"}))
+}
@@ -0,0 +1,73 @@
+package message
+
+import (
+	"strings"
+
+	"go-multi-chat-api/src/infrastructure/directory"
+)
+
+// Initial validation results of the recipients of a send, only accepted recipients are attempted
+const (
+	RecipientAccepted   = "accepted"
+	RecipientSuppressed = "suppressed"
+	RecipientInvalid    = "invalid"
+)
+
+// Reasons a recipient isn't accepted
+const (
+	RecipientReasonDuplicate  = "duplicate"
+	RecipientReasonEmpty      = "empty"
+	RecipientReasonUnresolved = "unresolved"
+)
+
+// RecipientResult is the initial validation result of a recipient of a send request
+type RecipientResult struct {
+	// Recipient is the recipient as requested, Address the address it resolved to on the selected provider
+	Recipient string
+	Address   string
+	Result    string
+	// Reason tells why the recipient isn't accepted, Error the resolution error of an unresolved recipient
+	Reason string
+	Error  string
+}
+
+// recipientResults validates the requested recipients against their resolution, in the order they were requested.
+// Blank and unresolved recipients are invalid, and a recipient resolving to an address already accepted is
+// suppressed so no recipient is sent the message twice. It returns the results and the accepted addresses.
+func recipientResults(requested []string, resolved []string, unresolved []directory.Failure) ([]RecipientResult, []string) {
+	failures := make(map[string][]string, len(unresolved))
+	for _, failure := range unresolved {
+		failures[failure.Recipient] = append(failures[failure.Recipient], failure.Error)
+	}
+
+	results := make([]RecipientResult, 0, len(requested))
+	accepted := make([]string, 0, len(resolved))
+	seen := make(map[string]bool, len(resolved))
+	next := 0
+	for _, recipient := range requested {
+		result := RecipientResult{Recipient: recipient}
+		// ResolveAll keeps the order of the recipients, the resolved ones follow it with the failures left out
+		if errs := failures[recipient]; len(errs) > 0 {
+			failures[recipient] = errs[1:]
+			result.Result, result.Reason, result.Error = RecipientInvalid, RecipientReasonUnresolved, errs[0]
+			results = append(results, result)
+			continue
+		}
+		if next < len(resolved) {
+			result.Address = resolved[next]
+			next++
+		}
+		switch {
+		case strings.TrimSpace(result.Address) == "":
+			result.Result, result.Reason = RecipientInvalid, RecipientReasonEmpty
+		case seen[result.Address]:
+			result.Result, result.Reason = RecipientSuppressed, RecipientReasonDuplicate
+		default:
+			seen[result.Address] = true
+			result.Result = RecipientAccepted
+			accepted = append(accepted, result.Address)
+		}
+		results = append(results, result)
+	}
+	return results, accepted
+}
@@ -0,0 +1,36 @@
+package message
+
+import (
+	"testing"
+
+	"go-multi-chat-api/src/infrastructure/directory"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecipientResults(t *testing.T) {
+	requested := []string{"+4911", "contact:anna", "contact:bob", " ", "+4922", "contact:bob"}
+	// contact:anna resolves to +4911, which is already a recipient
+	resolved := []string{"+4911", "+4911", " ", "+4922"}
+	unresolved := []directory.Failure{
+		{Recipient: "contact:bob", Error: "recipient not found"},
+		{Recipient: "contact:bob", Error: "recipient not found"},
+	}
+
+	results, accepted := recipientResults(requested, resolved, unresolved)
+	assert.Equal(t, []string{"+4911", "+4922"}, accepted)
+	assert.Equal(t, []RecipientResult{
+		{Recipient: "+4911", Address: "+4911", Result: RecipientAccepted},
+		{Recipient: "contact:anna", Address: "+4911", Result: RecipientSuppressed, Reason: RecipientReasonDuplicate},
+		{Recipient: "contact:bob", Result: RecipientInvalid, Reason: RecipientReasonUnresolved, Error: "recipient not found"},
+		{Recipient: " ", Address: " ", Result: RecipientInvalid, Reason: RecipientReasonEmpty},
+		{Recipient: "+4922", Address: "+4922", Result: RecipientAccepted},
+		{Recipient: "contact:bob", Result: RecipientInvalid, Reason: RecipientReasonUnresolved, Error: "recipient not found"},
+	}, results)
+}
+
+func TestRecipientResults_NoneAccepted(t *testing.T) {
+	results, accepted := recipientResults([]string{""}, []string{""}, nil)
+	assert.Empty(t, accepted)
+	assert.Equal(t, RecipientInvalid, results[0].Result)
+}
@@ -0,0 +1,288 @@
+package numberhealth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	alertingProvider "go-multi-chat-api/src/infrastructure/alerting/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+
+	"go.uber.org/zap"
+)
+
+// guidance tells operators how to take the action of an action code
+var guidance = map[string]string{
+	domainSignal.NumberActionNeedsCaptcha: "Solve the captcha at https://signalcaptchas.org/challenge/generate and submit it with " +
+		"POST /v1/signal/accounts/{number}/rate-limit-challenge.",
+	domainSignal.NumberActionNeedsReverify: "Register the number with POST /v1/signal/register/{number} and verify it, or link it " +
+		"again with POST /v1/signal/accounts/{number}/relink if it is a linked device.",
+	domainSignal.NumberActionRateLimited: "Send less from the number until Signal lifts the rate limit, the next successful send " +
+		"clears it.",
+}
+
+// Guidance returns what an operator has to do for an action code of a number, empty when there is nothing to do
+func Guidance(actionCode string, number string) string {
+	return strings.ReplaceAll(guidance[actionCode], "{number}", number)
+}
+
+// Config controls when a number counts as degraded and who is alerted when its health changes
+type Config struct {
+	// FailureThreshold is the number of failures in a row that degrade a number
+	FailureThreshold int
+	// AlertRecipients receive the alerts, without recipients the alerts are only logged
+	AlertRecipients []string
+}
+
+// INumberHealthUseCase defines the interface for number health use cases
+type INumberHealthUseCase interface {
+	// RecordSendSuccess clears the failure streak and action code of a number
+	RecordSendSuccess(number string)
+	// RecordSendFailure classifies the error of a send from a number, errors caused by the recipients only count
+	// when they had no Signal account
+	RecordSendFailure(number string, err error)
+	// RecordReceiveError counts an error of the receive stream of a number
+	RecordReceiveError(number string, err error)
+	GetAll() (*[]domainSignal.NumberHealth, error)
+	Get(number string) (*domainSignal.NumberHealth, error)
+	// Resolve marks a number healthy again after an operator took its action, resetting its counters
+	Resolve(number string) (*domainSignal.NumberHealth, error)
+}
+
+// NumberHealthUseCase implements the INumberHealthUseCase interface
+type NumberHealthUseCase struct {
+	numberHealthRepository signalRepo.NumberHealthRepositoryInterface
+	alertProvider          alertingProvider.AlertProvider
+	config                 Config
+	Logger                 *logger.Logger
+	now                    func() time.Time
+
+	mu sync.Mutex
+	// healthy are the numbers last seen healthy, their successes don't have to be stored
+	healthy map[string]bool
+}
+
+// NewNumberHealthUseCase creates a new NumberHealthUseCase. alertProvider may be nil, the alerts are only
+// logged then.
+func NewNumberHealthUseCase(numberHealthRepository signalRepo.NumberHealthRepositoryInterface, alertProvider alertingProvider.AlertProvider, config Config, loggerInstance *logger.Logger) INumberHealthUseCase {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	return &NumberHealthUseCase{
+		numberHealthRepository: numberHealthRepository,
+		alertProvider:          alertProvider,
+		config:                 config,
+		Logger:                 loggerInstance,
+		now:                    time.Now,
+		healthy:                map[string]bool{},
+	}
+}
+
+func (u *NumberHealthUseCase) RecordSendSuccess(number string) {
+	u.mu.Lock()
+	healthy := u.healthy[number]
+	u.mu.Unlock()
+	if healthy {
+		return
+	}
+
+	u.update(number, func(health *domainSignal.NumberHealth) bool {
+		if health.Status == domainSignal.NumberHealthHealthy && health.ConsecutiveFailures == 0 && health.LastSuccessAt != nil {
+			return false
+		}
+		now := u.now()
+		health.ConsecutiveFailures, health.ActionCode, health.LastSuccessAt = 0, "", &now
+		return true
+	})
+}
+
+func (u *NumberHealthUseCase) RecordSendFailure(number string, err error) {
+	code := signalClient.ErrorCode(err)
+	if code == domainProvider.ErrorCodeInvalidRecipient {
+		return
+	}
+	u.update(number, func(health *domainSignal.NumberHealth) bool {
+		if code == domainProvider.ErrorCodeUnregistered {
+			// The recipient has no Signal account, it says nothing about the number sent from
+			health.UnregisteredErrors++
+			return true
+		}
+		health.SendFailures++
+		u.recordFailure(health, err, actionCode(err, code))
+		return true
+	})
+}
+
+func (u *NumberHealthUseCase) RecordReceiveError(number string, err error) {
+	code := signalClient.ErrorCode(err)
+	u.update(number, func(health *domainSignal.NumberHealth) bool {
+		health.ReceiveErrors++
+		u.recordFailure(health, err, actionCode(err, code))
+		return true
+	})
+}
+
+func (u *NumberHealthUseCase) GetAll() (*[]domainSignal.NumberHealth, error) {
+	return u.numberHealthRepository.GetAll()
+}
+
+func (u *NumberHealthUseCase) Get(number string) (*domainSignal.NumberHealth, error) {
+	health, err := u.numberHealthRepository.GetByNumber(number)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, domainErrors.NewAppError(fmt.Errorf("no health was recorded for Signal number %s", number), domainErrors.NotFound)
+		}
+		return nil, err
+	}
+	return health, nil
+}
+
+func (u *NumberHealthUseCase) Resolve(number string) (*domainSignal.NumberHealth, error) {
+	health, err := u.Get(number)
+	if err != nil {
+		return nil, err
+	}
+	previous := *health
+	health.Status, health.ActionCode = domainSignal.NumberHealthHealthy, ""
+	health.SendFailures, health.UnregisteredErrors, health.ReceiveErrors, health.ConsecutiveFailures = 0, 0, 0, 0
+	saved, err := u.numberHealthRepository.Save(health)
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Info("Signal number health resolved", zap.String("number", number), zap.String("previousStatus", previous.Status))
+	u.setHealthy(number, true)
+	if previous.Status != domainSignal.NumberHealthHealthy {
+		u.alert(&previous, saved)
+	}
+	return saved, nil
+}
+
+// recordFailure extends the failure streak of a number and sets its status. An action code replaces the one
+// of an earlier failure, failures without one keep it.
+func (u *NumberHealthUseCase) recordFailure(health *domainSignal.NumberHealth, err error, code string) {
+	now := u.now()
+	health.ConsecutiveFailures++
+	health.LastError, health.LastErrorAt = err.Error(), &now
+	if code != "" {
+		health.ActionCode = code
+	}
+}
+
+// update applies a change to the stored health of a number, stores it when change reports it changed
+// something and alerts when its status or action code changed
+func (u *NumberHealthUseCase) update(number string, change func(health *domainSignal.NumberHealth) bool) {
+	health, err := u.numberHealthRepository.GetByNumber(number)
+	if err != nil {
+		if !isNotFound(err) {
+			return
+		}
+		health = &domainSignal.NumberHealth{Number: number, Status: domainSignal.NumberHealthHealthy}
+	}
+	previous := *health
+	if !change(health) {
+		u.setHealthy(number, health.Status == domainSignal.NumberHealthHealthy)
+		return
+	}
+	health.Status = u.status(health)
+
+	saved, err := u.numberHealthRepository.Save(health)
+	if err != nil {
+		return
+	}
+	u.setHealthy(number, saved.Status == domainSignal.NumberHealthHealthy && saved.ConsecutiveFailures == 0)
+	if saved.Status != previous.Status || saved.ActionCode != previous.ActionCode {
+		u.alert(&previous, saved)
+	}
+}
+
+// status grades a number by its action code and failure streak
+func (u *NumberHealthUseCase) status(health *domainSignal.NumberHealth) string {
+	switch {
+	case health.ActionCode != "":
+		return domainSignal.NumberHealthNeedsAttention
+	case health.ConsecutiveFailures >= u.config.FailureThreshold:
+		return domainSignal.NumberHealthDegraded
+	default:
+		return domainSignal.NumberHealthHealthy
+	}
+}
+
+// alert tells operators about a change of the status or action code of a number, including its recovery
+func (u *NumberHealthUseCase) alert(previous *domainSignal.NumberHealth, health *domainSignal.NumberHealth) {
+	subject := fmt.Sprintf("Signal number %s is %s", health.Number, health.Status)
+	if health.ActionCode != "" {
+		subject = fmt.Sprintf("Signal number %s %s", health.Number, strings.ReplaceAll(health.ActionCode, "_", " "))
+	}
+	description := fmt.Sprintf("Signal number %s went from %s to %s after %d failures in a row.", health.Number, previous.Status, health.Status, health.ConsecutiveFailures)
+	if health.LastError != "" && health.Status != domainSignal.NumberHealthHealthy {
+		description += " Last error: " + health.LastError + "."
+	}
+	if text := Guidance(health.ActionCode, health.Number); text != "" {
+		description += " " + text
+	}
+
+	log := u.Logger.Warn
+	if health.Status == domainSignal.NumberHealthHealthy {
+		log = u.Logger.Info
+	}
+	log(subject,
+		zap.String("number", health.Number),
+		zap.String("status", health.Status),
+		zap.String("previousStatus", previous.Status),
+		zap.String("actionCode", health.ActionCode),
+		zap.Int("consecutiveFailures", health.ConsecutiveFailures))
+
+	if u.alertProvider == nil || len(u.config.AlertRecipients) == 0 {
+		return
+	}
+	// Quotes and backslashes aren't allowed in descriptions of alerts
+	description = strings.NewReplacer(`"`, "'", `\`, "/").Replace(description)
+	err := u.alertProvider.Send(&alert.Alert{
+		Type:        alert.TypeEmail,
+		Subject:     &subject,
+		Description: &description,
+		Recipients:  u.config.AlertRecipients,
+	})
+	if err != nil {
+		u.Logger.Error("Error sending number health alert", zap.Error(err), zap.String("number", health.Number))
+	}
+}
+
+func (u *NumberHealthUseCase) setHealthy(number string, healthy bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if healthy {
+		u.healthy[number] = true
+	} else {
+		delete(u.healthy, number)
+	}
+}
+
+// actionCode returns what an operator has to do after an error of a number, empty when nothing can be done
+// yet. A rate limit with a challenge is lifted by solving its captcha.
+func actionCode(err error, code string) string {
+	var rateLimitErr *domainSignal.RateLimitError
+	if errors.As(err, &rateLimitErr) && len(rateLimitErr.ChallengeTokens) > 0 {
+		return domainSignal.NumberActionNeedsCaptcha
+	}
+	switch code {
+	case domainProvider.ErrorCodeRateLimited:
+		return domainSignal.NumberActionRateLimited
+	case domainProvider.ErrorCodeAuthFailed, domainProvider.ErrorCodeDeviceUnlinked:
+		return domainSignal.NumberActionNeedsReverify
+	}
+	return ""
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
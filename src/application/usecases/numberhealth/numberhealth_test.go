@@ -0,0 +1,162 @@
+package numberhealth
+
+import (
+	"errors"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockNumberHealthRepository struct {
+	healths map[string]domainSignal.NumberHealth
+	saves   int
+}
+
+func (m *mockNumberHealthRepository) GetByNumber(number string) (*domainSignal.NumberHealth, error) {
+	health, ok := m.healths[number]
+	if !ok {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &health, nil
+}
+
+func (m *mockNumberHealthRepository) GetAll() (*[]domainSignal.NumberHealth, error) {
+	healths := []domainSignal.NumberHealth{}
+	for _, health := range m.healths {
+		healths = append(healths, health)
+	}
+	return &healths, nil
+}
+
+func (m *mockNumberHealthRepository) Save(health *domainSignal.NumberHealth) (*domainSignal.NumberHealth, error) {
+	m.saves++
+	m.healths[health.Number] = *health
+	return health, nil
+}
+
+type mockAlertProvider struct {
+	alerts []*alert.Alert
+}
+
+func (m *mockAlertProvider) Validate() error {
+	return nil
+}
+
+func (m *mockAlertProvider) Send(a *alert.Alert) error {
+	m.alerts = append(m.alerts, a)
+	return nil
+}
+
+func (m *mockAlertProvider) GetDefaultAlert() *alert.Alert {
+	return nil
+}
+
+func (m *mockAlertProvider) ValidateOverrides(a *alert.Alert) error {
+	return nil
+}
+
+func setupUseCase(threshold int) (*NumberHealthUseCase, *mockNumberHealthRepository, *mockAlertProvider) {
+	repository := &mockNumberHealthRepository{healths: map[string]domainSignal.NumberHealth{}}
+	alerts := &mockAlertProvider{}
+	useCase := NewNumberHealthUseCase(repository, alerts, Config{FailureThreshold: threshold, AlertRecipients: []string{"ops@example.com"}}, &logger.Logger{Log: zap.NewNop()})
+	return useCase.(*NumberHealthUseCase), repository, alerts
+}
+
+func TestRecordSendFailure_DegradesAfterThreshold(t *testing.T) {
+	useCase, repository, alerts := setupUseCase(2)
+
+	useCase.RecordSendFailure("+100", errors.New("connection reset"))
+	assert.Equal(t, domainSignal.NumberHealthHealthy, repository.healths["+100"].Status)
+	assert.Empty(t, alerts.alerts)
+
+	useCase.RecordSendFailure("+100", errors.New("connection reset"))
+	health := repository.healths["+100"]
+	assert.Equal(t, domainSignal.NumberHealthDegraded, health.Status)
+	assert.Equal(t, 2, health.SendFailures)
+	require.Len(t, alerts.alerts, 1)
+	assert.Equal(t, "Signal number +100 is degraded", *alerts.alerts[0].Subject)
+
+	// The next success recovers the number and says so
+	useCase.RecordSendSuccess("+100")
+	assert.Equal(t, domainSignal.NumberHealthHealthy, repository.healths["+100"].Status)
+	assert.Len(t, alerts.alerts, 2)
+
+	// Healthy numbers aren't stored again on every success
+	saves := repository.saves
+	useCase.RecordSendSuccess("+100")
+	assert.Equal(t, saves, repository.saves)
+}
+
+func TestRecordSendFailure_ActionCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"captcha", &domainSignal.RateLimitError{ChallengeTokens: []string{"token"}, Err: errors.New("rate limit exceeded")}, domainSignal.NumberActionNeedsCaptcha},
+		{"rate limited", errors.New("[429] rate limit exceeded"), domainSignal.NumberActionRateLimited},
+		{"not registered", errors.New("user +100 is not registered"), domainSignal.NumberActionNeedsReverify},
+		{"unlinked", &domainSignal.DeviceUnlinkedError{Err: errors.New("device unlinked")}, domainSignal.NumberActionNeedsReverify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, repository, alerts := setupUseCase(3)
+
+			useCase.RecordSendFailure("+100", tt.err)
+			health := repository.healths["+100"]
+			assert.Equal(t, domainSignal.NumberHealthNeedsAttention, health.Status)
+			assert.Equal(t, tt.want, health.ActionCode)
+			require.Len(t, alerts.alerts, 1)
+			assert.Contains(t, *alerts.alerts[0].Description, Guidance(tt.want, "+100"))
+		})
+	}
+}
+
+func TestRecordSendFailure_RecipientErrorsDontDegrade(t *testing.T) {
+	useCase, repository, alerts := setupUseCase(1)
+
+	useCase.RecordSendFailure("+100", errors.New("invalid phone number"))
+	_, stored := repository.healths["+100"]
+	assert.False(t, stored)
+
+	useCase.RecordSendFailure("+100", errors.New("+200: unregistered user"))
+	health := repository.healths["+100"]
+	assert.Equal(t, 1, health.UnregisteredErrors)
+	assert.Equal(t, 0, health.ConsecutiveFailures)
+	assert.Equal(t, domainSignal.NumberHealthHealthy, health.Status)
+	assert.Empty(t, alerts.alerts)
+}
+
+func TestRecordReceiveError(t *testing.T) {
+	useCase, repository, _ := setupUseCase(1)
+
+	useCase.RecordReceiveError("+100", errors.New("websocket closed"))
+	health := repository.healths["+100"]
+	assert.Equal(t, 1, health.ReceiveErrors)
+	assert.Equal(t, domainSignal.NumberHealthDegraded, health.Status)
+	assert.Equal(t, "websocket closed", health.LastError)
+}
+
+func TestResolve(t *testing.T) {
+	useCase, repository, alerts := setupUseCase(3)
+
+	_, err := useCase.Resolve("+100")
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+
+	useCase.RecordSendFailure("+100", errors.New("authorization failed"))
+	health, err := useCase.Resolve("+100")
+	require.NoError(t, err)
+	assert.Equal(t, domainSignal.NumberHealthHealthy, health.Status)
+	assert.Empty(t, health.ActionCode)
+	assert.Zero(t, repository.healths["+100"].SendFailures)
+	assert.Len(t, alerts.alerts, 2)
+}
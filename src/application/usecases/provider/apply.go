@@ -0,0 +1,424 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+
+	"go.uber.org/zap"
+)
+
+// Kinds of the objects a desired state is reconciled on
+const (
+	ChangeKindProvider     = "provider"
+	ChangeKindUserProvider = "user_provider"
+)
+
+// Actions taken to reconcile an object with its desired state
+const (
+	ChangeCreated   = "created"
+	ChangeUpdated   = "updated"
+	ChangeUnchanged = "unchanged"
+	ChangeDeleted   = "deleted"
+)
+
+// DesiredProvider is the desired state of a provider, matched to the stored provider by name
+type DesiredProvider struct {
+	Name        string
+	Type        string
+	Description string
+	Config      string
+	Status      bool
+}
+
+// DesiredUserProvider is the desired state of the link of a user, by email, to a provider, by name
+type DesiredUserProvider struct {
+	User     string
+	Provider string
+	Priority int
+	Config   string
+	Status   bool
+}
+
+// DesiredState is the full provider setup of a deployment. Providers and user providers missing from the
+// state are left alone, unless Prune removes the user providers of the listed users that the state doesn't list.
+// Providers are never deleted, since their messages refer to them, they are deactivated with status false.
+type DesiredState struct {
+	Providers     []DesiredProvider
+	UserProviders []DesiredUserProvider
+	Prune         bool
+}
+
+// Change is what reconciling one object with its desired state did, or would do on a dry run
+type Change struct {
+	Kind     string
+	Provider string
+	User     string // email of the user of a user provider
+	Action   string
+	ID       int // ID of the object, 0 for objects a dry run would create
+}
+
+// UpsertProvider creates the provider of a name or updates it to the given type, description, config and
+// status. It reports whether the provider was created. Reapplying the same provider changes nothing.
+func (p *ProviderUseCase) UpsertProvider(desired *DesiredProvider) (*domainProvider.Provider, bool, error) {
+	existing, err := p.findProviderByName(desired.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := p.validateDesiredProvider(desired, existing, ""); err != nil {
+		return nil, false, err
+	}
+	change, provider, err := p.applyProvider(desired, existing, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return provider, change.Action == ChangeCreated, nil
+}
+
+// ApplyDesiredState reconciles the providers and user providers with a desired state. The whole state is
+// validated before anything is changed, and a dry run only reports the changes. Applying the same state
+// again reports every object unchanged.
+func (p *ProviderUseCase) ApplyDesiredState(state *DesiredState, dryRun bool) ([]Change, error) {
+	existing, err := p.validateDesiredState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	providers := map[string]*domainProvider.Provider{}
+	for i := range state.Providers {
+		desired := &state.Providers[i]
+		change, provider, err := p.applyProvider(desired, existing.providers[desired.Name], dryRun)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change)
+		providers[desired.Name] = provider
+	}
+
+	for _, email := range existing.users {
+		userID := existing.userIDs[email]
+		userChanges, err := p.applyUserProviders(userID, email, state, providers, existing.providers, dryRun)
+		changes = append(changes, userChanges...)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	p.Logger.Info("Applied desired provider state",
+		zap.Int("providers", len(state.Providers)),
+		zap.Int("userProviders", len(state.UserProviders)),
+		zap.Bool("dryRun", dryRun))
+	return changes, nil
+}
+
+// existingState is what validating a desired state looked up: the stored providers it names and the users
+// of its user providers, in the order they first appear
+type existingState struct {
+	providers map[string]*domainProvider.Provider
+	users     []string
+	userIDs   map[string]int
+}
+
+// validateDesiredState checks every object of a desired state, reporting config violations with the path of
+// the object in the state, e.g. providers[1].config.from
+func (p *ProviderUseCase) validateDesiredState(state *DesiredState) (*existingState, error) {
+	existing := &existingState{providers: map[string]*domainProvider.Provider{}, userIDs: map[string]int{}}
+	types := map[string]string{}
+	for i := range state.Providers {
+		desired := &state.Providers[i]
+		path := fmt.Sprintf("providers[%d]", i)
+		if _, ok := types[desired.Name]; ok {
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: duplicate provider %q", path, desired.Name), domainErrors.ValidationError)
+		}
+		provider, err := p.findProviderByName(desired.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.validateDesiredProvider(desired, provider, path+"."); err != nil {
+			return nil, err
+		}
+		existing.providers[desired.Name] = provider
+		types[desired.Name] = desired.Type
+	}
+
+	links := map[string]bool{}
+	for i, desired := range state.UserProviders {
+		path := fmt.Sprintf("user_providers[%d]", i)
+		switch {
+		case desired.User == "":
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: user is required", path), domainErrors.ValidationError)
+		case desired.Provider == "":
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: provider is required", path), domainErrors.ValidationError)
+		case links[desired.User+"\x00"+desired.Provider]:
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: duplicate user provider %s of %s", path, desired.Provider, desired.User), domainErrors.ValidationError)
+		case desired.Priority < 1:
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s: priority must be at least 1", path), domainErrors.ValidationError)
+		}
+		links[desired.User+"\x00"+desired.Provider] = true
+
+		if _, ok := existing.userIDs[desired.User]; !ok {
+			user, err := p.userRepository.GetByEmail(desired.User)
+			if err != nil {
+				if isNotFound(err) {
+					return nil, domainErrors.NewAppError(fmt.Errorf("%s: unknown user %s", path, desired.User), domainErrors.ValidationError)
+				}
+				return nil, err
+			}
+			existing.userIDs[desired.User] = user.ID
+			existing.users = append(existing.users, desired.User)
+		}
+
+		providerType, ok := types[desired.Provider]
+		if !ok {
+			provider, err := p.findProviderByName(desired.Provider)
+			if err != nil {
+				return nil, err
+			}
+			if provider == nil {
+				return nil, domainErrors.NewAppError(fmt.Errorf("%s: unknown provider %s", path, desired.Provider), domainErrors.ValidationError)
+			}
+			existing.providers[desired.Provider] = provider
+			types[desired.Provider] = provider.Type
+			providerType = provider.Type
+		}
+		if err := p.schemaFor(providerType).UserProviderSchema.Validate(desired.Config); err != nil {
+			return nil, prefixFields(err, path+".config")
+		}
+		if err := p.validateWebhookConfig(desired.Config); err != nil {
+			return nil, domainErrors.NewAppError(fmt.Errorf("%s.config: %s", path, err.Error()), domainErrors.ValidationError)
+		}
+	}
+	return existing, nil
+}
+
+// validateDesiredProvider checks a desired provider against the schema of its type and the stored provider of
+// its name, if any. path prefixes the fields of the errors.
+func (p *ProviderUseCase) validateDesiredProvider(desired *DesiredProvider, existing *domainProvider.Provider, path string) error {
+	if desired.Name == "" {
+		return domainErrors.NewAppError(fmt.Errorf("%sname is required", path), domainErrors.ValidationError)
+	}
+	providerType, ok := providerconfig.Lookup(desired.Type)
+	if !ok {
+		return domainErrors.NewAppError(fmt.Errorf("%sunknown provider type %q", path, desired.Type), domainErrors.ValidationError)
+	}
+	if existing != nil && existing.Type != desired.Type {
+		return domainErrors.NewAppError(fmt.Errorf("%sthe type of provider %q can't be changed from %s", path, desired.Name, existing.Type), domainErrors.ValidationError)
+	}
+	if err := providerType.ProviderSchema.Validate(desired.Config); err != nil {
+		if path == "" {
+			return err
+		}
+		return prefixFields(err, path+"config")
+	}
+	return nil
+}
+
+// applyProvider creates or updates a provider to its desired state, the provider is nil when a dry run would
+// create it
+func (p *ProviderUseCase) applyProvider(desired *DesiredProvider, existing *domainProvider.Provider, dryRun bool) (Change, *domainProvider.Provider, error) {
+	change := Change{Kind: ChangeKindProvider, Provider: desired.Name}
+	if existing == nil {
+		change.Action = ChangeCreated
+		if dryRun {
+			return change, nil, nil
+		}
+		created, err := p.providerRepository.Create(&domainProvider.Provider{
+			Name:        desired.Name,
+			Type:        desired.Type,
+			Description: desired.Description,
+			Config:      desired.Config,
+			Status:      desired.Status,
+		})
+		if err != nil {
+			return change, nil, err
+		}
+		change.ID = created.ID
+		p.Logger.Info("Provider created from desired state", zap.Int("providerID", created.ID), zap.String("name", desired.Name))
+		return change, created, nil
+	}
+
+	change.ID = existing.ID
+	updates := map[string]interface{}{}
+	if existing.Description != desired.Description {
+		updates["description"] = desired.Description
+	}
+	if !sameConfig(existing.Config, desired.Config) {
+		updates["config"] = desired.Config
+	}
+	if existing.Status != desired.Status {
+		updates["status"] = desired.Status
+	}
+	if len(updates) == 0 {
+		change.Action = ChangeUnchanged
+		return change, existing, nil
+	}
+	change.Action = ChangeUpdated
+	if dryRun {
+		return change, existing, nil
+	}
+	// The version read guards against an update through the API in between
+	updates["version"] = existing.Version
+	updated, err := p.providerRepository.Update(existing.ID, updates)
+	if err != nil {
+		return change, nil, err
+	}
+	p.Logger.Info("Provider updated from desired state", zap.Int("providerID", existing.ID), zap.String("name", desired.Name))
+	return change, updated, nil
+}
+
+// applyUserProviders reconciles the user providers of one user with the desired state. providers are the
+// providers of the state after applying them, existing the stored providers the state names.
+func (p *ProviderUseCase) applyUserProviders(userID int, email string, state *DesiredState, providers map[string]*domainProvider.Provider, existing map[string]*domainProvider.Provider, dryRun bool) ([]Change, error) {
+	stored, err := p.userProviderRepository.GetUserProviders(userID)
+	if err != nil {
+		return nil, err
+	}
+	byProviderID := map[int]*domainProvider.UserProvider{}
+	for i := range *stored {
+		byProviderID[(*stored)[i].ProviderID] = &(*stored)[i]
+	}
+
+	changes := []Change{}
+	kept := map[int]bool{}
+	for _, desired := range state.UserProviders {
+		if desired.User != email {
+			continue
+		}
+		change := Change{Kind: ChangeKindUserProvider, Provider: desired.Provider, User: email}
+		provider := providers[desired.Provider]
+		if provider == nil {
+			provider = existing[desired.Provider]
+		}
+		if provider == nil {
+			// a provider a dry run would create has no user providers yet
+			change.Action = ChangeCreated
+			changes = append(changes, change)
+			continue
+		}
+		kept[provider.ID] = true
+
+		userProvider := byProviderID[provider.ID]
+		if userProvider == nil {
+			change.Action = ChangeCreated
+			if !dryRun {
+				storedConfig, err := p.sealWebhookSecrets(userID, desired.Config)
+				if err != nil {
+					return changes, err
+				}
+				created, err := p.userProviderRepository.Create(&domainProvider.UserProvider{
+					UserID:     userID,
+					ProviderID: provider.ID,
+					Priority:   desired.Priority,
+					Config:     storedConfig,
+					Status:     desired.Status,
+				})
+				if err != nil {
+					return changes, err
+				}
+				change.ID = created.ID
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		change.ID = userProvider.ID
+		updates := map[string]interface{}{}
+		if userProvider.Priority != desired.Priority {
+			updates["priority"] = desired.Priority
+		}
+		// The webhook secrets are stored encrypted, so they are compared in plaintext
+		if !sameConfig(p.openWebhookSecrets(userID, userProvider.Config), desired.Config) {
+			storedConfig, err := p.sealWebhookSecrets(userID, desired.Config)
+			if err != nil {
+				return changes, err
+			}
+			updates["config"] = storedConfig
+		}
+		if userProvider.Status != desired.Status {
+			updates["status"] = desired.Status
+		}
+		change.Action = ChangeUnchanged
+		if len(updates) > 0 {
+			change.Action = ChangeUpdated
+			if !dryRun {
+				updates["version"] = userProvider.Version
+				if _, err := p.userProviderRepository.Update(userProvider.ID, updates); err != nil {
+					return changes, err
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if state.Prune {
+		for _, userProvider := range *stored {
+			if kept[userProvider.ProviderID] {
+				continue
+			}
+			change := Change{Kind: ChangeKindUserProvider, User: email, Action: ChangeDeleted, ID: userProvider.ID}
+			if provider, err := p.providerRepository.GetByID(userProvider.ProviderID); err == nil {
+				change.Provider = provider.Name
+			}
+			if !dryRun {
+				if err := p.userProviderRepository.Delete(userProvider.ID); err != nil {
+					return changes, err
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// findProviderByName returns the stored provider of a name, nil when there is none
+func (p *ProviderUseCase) findProviderByName(name string) (*domainProvider.Provider, error) {
+	provider, err := p.providerRepository.GetByName(name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return provider, nil
+}
+
+// sameConfig reports whether two configs hold the same JSON, whatever the order of their keys
+func sameConfig(a string, b string) bool {
+	if a == b {
+		return true
+	}
+	var valueA, valueB interface{}
+	if json.Unmarshal([]byte(a), &valueA) != nil || json.Unmarshal([]byte(b), &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
+
+// prefixFields moves the fields of a config validation error below the path of the config in a desired state
+func prefixFields(err error, path string) error {
+	var validationErr *providerconfig.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	fields := make([]providerconfig.FieldError, len(validationErr.Errors))
+	for i, fieldError := range validationErr.Errors {
+		fields[i] = fieldError
+		if fieldError.Field == "" {
+			fields[i].Field = path
+		} else {
+			fields[i].Field = path + "." + fieldError.Field
+		}
+	}
+	return &providerconfig.ValidationError{Errors: fields}
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
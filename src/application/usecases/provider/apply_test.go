@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertProvider(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	created, isNew, err := useCase.UpsertProvider(&DesiredProvider{Name: "alerts", Type: "signal", Status: true})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, "alerts", providerRepository.created.Name)
+
+	// Reapplying the same provider, with the keys of its config in any order, changes nothing
+	providerRepository.providers[0].Config = `{"warmup":{"ramp":[50],"enabled":true}}`
+	_, isNew, err = useCase.UpsertProvider(&DesiredProvider{Name: "primary", Type: "signal", Config: `{"warmup":{"enabled":true,"ramp":[50]}}`, Status: true})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Nil(t, providerRepository.updates)
+
+	_, _, err = useCase.UpsertProvider(&DesiredProvider{Name: "backup", Type: "signal", Description: "Backup number", Status: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"description": "Backup number", "status": true, "version": 0}, providerRepository.updates)
+	assert.NotZero(t, created.ID)
+}
+
+func TestUpsertProvider_Validation(t *testing.T) {
+	useCase := setupUseCase(t, &mockSender{})
+
+	_, _, err := useCase.UpsertProvider(&DesiredProvider{Name: "primary", Type: "email"})
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	_, _, err = useCase.UpsertProvider(&DesiredProvider{Name: "mail", Type: "email", Config: `{"host":"smtp.example.com"}`})
+	var validationErr *providerconfig.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "from", validationErr.Errors[0].Field)
+}
+
+func TestApplyDesiredState(t *testing.T) {
+	useCase, providerRepository, userProviderRepository := setupUseCaseWithRepositories(t, &mockSender{})
+	state := &DesiredState{
+		Providers: []DesiredProvider{
+			{Name: "primary", Type: "signal", Status: true},
+			{Name: "alerts", Type: "signal", Status: true},
+		},
+		UserProviders: []DesiredUserProvider{
+			{User: "ops@example.com", Provider: "primary", Priority: 2, Status: true},
+			{User: "ops@example.com", Provider: "alerts", Priority: 3, Status: true},
+			{User: "sales@example.com", Provider: "other", Priority: 2, Status: true},
+		},
+		Prune: true,
+	}
+
+	// A dry run reports the changes without making them
+	changes, err := useCase.ApplyDesiredState(state, true)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Kind: ChangeKindProvider, Provider: "primary", Action: ChangeUnchanged, ID: 1},
+		{Kind: ChangeKindProvider, Provider: "alerts", Action: ChangeCreated},
+		{Kind: ChangeKindUserProvider, Provider: "primary", User: "ops@example.com", Action: ChangeUnchanged, ID: 11},
+		{Kind: ChangeKindUserProvider, Provider: "alerts", User: "ops@example.com", Action: ChangeCreated},
+		{Kind: ChangeKindUserProvider, Provider: "backup", User: "ops@example.com", Action: ChangeDeleted, ID: 12},
+		{Kind: ChangeKindUserProvider, Provider: "other", User: "sales@example.com", Action: ChangeUpdated, ID: 13},
+	}, changes)
+	assert.Nil(t, providerRepository.created)
+	assert.Empty(t, userProviderRepository.created)
+	assert.Empty(t, userProviderRepository.deletedIDs)
+
+	_, err = useCase.ApplyDesiredState(state, false)
+	require.NoError(t, err)
+	assert.Equal(t, "alerts", providerRepository.created.Name)
+	require.Len(t, userProviderRepository.created, 1)
+	assert.Equal(t, providerRepository.created.ID, userProviderRepository.created[0].ProviderID)
+	assert.Equal(t, 3, userProviderRepository.created[0].Priority)
+	assert.Equal(t, []int{12}, userProviderRepository.deletedIDs)
+	assert.Equal(t, 13, userProviderRepository.updatedID)
+	assert.Equal(t, map[string]interface{}{"priority": 2, "version": 0}, userProviderRepository.updates)
+}
+
+func TestApplyDesiredState_ValidatesBeforeChanging(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.ApplyDesiredState(&DesiredState{
+		Providers:     []DesiredProvider{{Name: "alerts", Type: "signal", Status: true}},
+		UserProviders: []DesiredUserProvider{{User: "nobody@example.com", Provider: "alerts", Priority: 1}},
+	}, false)
+	assert.EqualError(t, err, "user_providers[0]: unknown user nobody@example.com")
+	assert.Nil(t, providerRepository.created)
+
+	_, err = useCase.ApplyDesiredState(&DesiredState{
+		Providers: []DesiredProvider{
+			{Name: "alerts", Type: "signal"},
+			{Name: "mail", Type: "email", Config: `{"from":"ops@example.com","host":"smtp.example.com","port":0}`},
+		},
+	}, false)
+	var validationErr *providerconfig.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "providers[1].config.port", validationErr.Errors[0].Field)
+	assert.Nil(t, providerRepository.created)
+
+	_, err = useCase.ApplyDesiredState(&DesiredState{
+		UserProviders: []DesiredUserProvider{{User: "ops@example.com", Provider: "missing", Priority: 1}},
+	}, false)
+	assert.EqualError(t, err, "user_providers[0]: unknown provider missing")
+}
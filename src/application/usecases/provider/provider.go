@@ -0,0 +1,324 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+)
+
+// Sender sends a message of a user through a given provider, bypassing the priority routing of the user
+type Sender interface {
+	SendThroughProvider(userID int, providerDetails *domainProvider.Provider, message string, recipients []string) ([]byte, []byte, error)
+	// CheckCredentials checks the credentials the provider sends with for a user holding the given user provider
+	// config, it reports false when the provider type can't be checked without sending
+	CheckCredentials(providerDetails *domainProvider.Provider, userProviderConfig string) (bool, error)
+}
+
+// TestSendResult is the outcome of a test message sent through a provider
+type TestSendResult struct {
+	ProviderID   int
+	ProviderType string
+	Active       bool
+	Recipient    string
+	Success      bool
+	Error        string
+	// Request and Response are the raw provider request and response, nil when the provider returned none
+	Request  json.RawMessage
+	Response json.RawMessage
+}
+
+// maxDrillMinutes bounds a failover drill, so a forgotten drill doesn't keep a provider out of routing for long
+const maxDrillMinutes = 24 * 60
+
+// IProviderUseCase defines the interface for provider use cases
+type IProviderUseCase interface {
+	TestSend(userID int, providerID int, recipient string) (*TestSendResult, error)
+	GetProviderTypes() []providerconfig.ProviderType
+	GetProviders() (*[]domainProvider.Provider, error)
+	CreateProvider(providerDomain *domainProvider.Provider) (*domainProvider.Provider, error)
+	UpdateProvider(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error)
+	UpdateUserProviderConfig(userID int, providerID int, config string, version *int, force bool) (*domainProvider.UserProvider, error)
+	ValidateUserProviderCredentials(userID int, providerID int) (*domainProvider.UserProvider, error)
+	StartDrill(userID int, providerID int, startedBy int, durationMinutes int, reason string) (*domainProvider.ProviderDrill, error)
+	GetActiveDrills() (*[]domainProvider.ProviderDrill, error)
+	EndDrill(id int) (*domainProvider.ProviderDrill, error)
+	UpsertProvider(desired *DesiredProvider) (*domainProvider.Provider, bool, error)
+	ApplyDesiredState(state *DesiredState, dryRun bool) ([]Change, error)
+}
+
+// ProviderUseCase implements the IProviderUseCase interface
+type ProviderUseCase struct {
+	providerRepository      providerRepo.ProviderRepositoryInterface
+	userProviderRepository  providerRepo.UserProviderRepositoryInterface
+	providerDrillRepository providerRepo.ProviderDrillRepositoryInterface
+	userRepository          userRepo.UserRepositoryInterface
+	sender                  Sender
+	secrets                 WebhookSecretCipher
+	Logger                  *logger.Logger
+}
+
+// NewProviderUseCase creates a new ProviderUseCase
+func NewProviderUseCase(
+	providerRepository providerRepo.ProviderRepositoryInterface,
+	userProviderRepository providerRepo.UserProviderRepositoryInterface,
+	providerDrillRepository providerRepo.ProviderDrillRepositoryInterface,
+	userRepository userRepo.UserRepositoryInterface,
+	sender Sender,
+	secrets WebhookSecretCipher,
+	loggerInstance *logger.Logger,
+) IProviderUseCase {
+	return &ProviderUseCase{
+		providerRepository:      providerRepository,
+		userProviderRepository:  userProviderRepository,
+		providerDrillRepository: providerDrillRepository,
+		userRepository:          userRepository,
+		sender:                  sender,
+		secrets:                 secrets,
+		Logger:                  loggerInstance,
+	}
+}
+
+// TestSend sends a canned test message to a recipient through exactly the given provider of the user, so the
+// provider's credentials can be verified. Inactive providers can be tested too, nothing is stored.
+func (p *ProviderUseCase) TestSend(userID int, providerID int, recipient string) (*TestSendResult, error) {
+	userProvider, err := p.findUserProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	providerDetails, err := p.providerRepository.GetByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := TestMessage(providerDetails, time.Now())
+	requestData, responseData, sendErr := p.sender.SendThroughProvider(userID, providerDetails, message, []string{recipient})
+
+	result := &TestSendResult{
+		ProviderID:   providerDetails.ID,
+		ProviderType: providerDetails.Type,
+		Active:       providerDetails.Status && userProvider.Status,
+		Recipient:    recipient,
+		Success:      sendErr == nil,
+		Request:      rawJSON(requestData),
+		Response:     rawJSON(responseData),
+	}
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+		p.Logger.Warn("Provider test message failed", zap.Error(sendErr), zap.Int("providerID", providerID), zap.Int("userID", userID))
+	} else {
+		p.Logger.Info("Provider test message sent", zap.Int("providerID", providerID), zap.Int("userID", userID))
+	}
+	return result, nil
+}
+
+// GetProviderTypes returns the known provider types with the schemas of their configs
+func (p *ProviderUseCase) GetProviderTypes() []providerconfig.ProviderType {
+	return providerconfig.Types()
+}
+
+// GetProviders returns every provider, active or not
+func (p *ProviderUseCase) GetProviders() (*[]domainProvider.Provider, error) {
+	return p.providerRepository.GetAll()
+}
+
+// CreateProvider creates a provider of a known type after validating its config against the type's schema
+func (p *ProviderUseCase) CreateProvider(providerDomain *domainProvider.Provider) (*domainProvider.Provider, error) {
+	providerType, ok := providerconfig.Lookup(providerDomain.Type)
+	if !ok {
+		return nil, domainErrors.NewAppError(fmt.Errorf("unknown provider type %q", providerDomain.Type), domainErrors.ValidationError)
+	}
+	if err := providerType.ProviderSchema.Validate(providerDomain.Config); err != nil {
+		return nil, err
+	}
+	return p.providerRepository.Create(providerDomain)
+}
+
+// UpdateProvider updates a provider, validating a new config against the schema of the provider's type. The
+// type can't be changed since the config and the user provider configs were validated against it.
+func (p *ProviderUseCase) UpdateProvider(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error) {
+	existing, err := p.providerRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if providerType, ok := providerMap["type"]; ok && providerType != existing.Type {
+		return nil, domainErrors.NewAppError(errors.New("the type of a provider can't be changed"), domainErrors.ValidationError)
+	}
+	if config, ok := providerMap["config"].(string); ok {
+		if err := p.schemaFor(existing.Type).ProviderSchema.Validate(config); err != nil {
+			return nil, err
+		}
+	}
+	return p.providerRepository.Update(id, providerMap)
+}
+
+// UpdateUserProviderConfig replaces the config of a user for one of the user's providers after validating
+// it against the user provider schema of the provider's type. The credentials are checked with the new config and
+// the result is stored with it, a config whose credentials are refused is saved but only routed to when forced.
+// The webhook client key and header values are stored encrypted.
+func (p *ProviderUseCase) UpdateUserProviderConfig(userID int, providerID int, config string, version *int, force bool) (*domainProvider.UserProvider, error) {
+	userProvider, err := p.findUserProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+	providerDetails, err := p.providerRepository.GetByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.schemaFor(providerDetails.Type).UserProviderSchema.Validate(config); err != nil {
+		return nil, err
+	}
+	if err := p.validateWebhookConfig(config); err != nil {
+		return nil, err
+	}
+	storedConfig, err := p.sealWebhookSecrets(userID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := p.checkCredentials(userID, providerDetails, config)
+	updates["config"] = storedConfig
+	updates["credentialsForced"] = force
+	if version != nil {
+		updates["version"] = *version
+	}
+	return p.userProviderRepository.Update(userProvider.ID, updates)
+}
+
+// ValidateUserProviderCredentials checks the credentials of a user provider again with its stored config, e.g.
+// after a token was fixed at the provider, and stores the result
+func (p *ProviderUseCase) ValidateUserProviderCredentials(userID int, providerID int) (*domainProvider.UserProvider, error) {
+	userProvider, err := p.findUserProvider(userID, providerID)
+	if err != nil {
+		return nil, err
+	}
+	providerDetails, err := p.providerRepository.GetByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return p.userProviderRepository.Update(userProvider.ID, p.checkCredentials(userID, providerDetails, userProvider.Config))
+}
+
+// checkCredentials checks the credentials a provider sends with for a user holding the config and returns the
+// user provider fields recording the result
+func (p *ProviderUseCase) checkCredentials(userID int, providerDetails *domainProvider.Provider, config string) map[string]interface{} {
+	checkedAt := time.Now()
+	updates := map[string]interface{}{
+		"credentialStatus":     domainProvider.CredentialStatusValid,
+		"credentialError":      "",
+		"credentialsCheckedAt": checkedAt,
+	}
+	checked, err := p.sender.CheckCredentials(providerDetails, config)
+	switch {
+	case err != nil:
+		updates["credentialStatus"] = domainProvider.CredentialStatusInvalid
+		updates["credentialError"] = err.Error()
+		p.Logger.Warn("Provider credentials were refused", zap.Error(err), zap.Int("providerID", providerDetails.ID), zap.Int("userID", userID))
+	case !checked:
+		updates["credentialStatus"] = domainProvider.CredentialStatusUnchecked
+	}
+	return updates
+}
+
+// StartDrill simulates an outage of one of a user's providers for the given number of minutes. The provider
+// is skipped when routing the user's messages and messages already routed to it fail, so failover, webhooks
+// and alerting can be rehearsed. A provider can only be in one drill at a time.
+func (p *ProviderUseCase) StartDrill(userID int, providerID int, startedBy int, durationMinutes int, reason string) (*domainProvider.ProviderDrill, error) {
+	if durationMinutes < 1 || durationMinutes > maxDrillMinutes {
+		return nil, domainErrors.NewAppError(fmt.Errorf("duration_minutes must be between 1 and %d", maxDrillMinutes), domainErrors.ValidationError)
+	}
+	if _, err := p.findUserProvider(userID, providerID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inDrill, err := p.providerDrillRepository.GetActiveProviderIDs(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if inDrill[providerID] {
+		return nil, domainErrors.NewAppError(errors.New("the provider is already in a failover drill for this user"), domainErrors.Conflict)
+	}
+
+	drill, err := p.providerDrillRepository.Create(&domainProvider.ProviderDrill{
+		UserID:     userID,
+		ProviderID: providerID,
+		StartedBy:  startedBy,
+		Reason:     reason,
+		StartsAt:   now,
+		EndsAt:     now.Add(time.Duration(durationMinutes) * time.Minute),
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.Logger.Warn("Provider failover drill started",
+		zap.Int("drillID", drill.ID),
+		zap.Int("userID", userID),
+		zap.Int("providerID", providerID),
+		zap.Int("startedBy", startedBy),
+		zap.Time("endsAt", drill.EndsAt))
+	return drill, nil
+}
+
+// GetActiveDrills returns the failover drills running now
+func (p *ProviderUseCase) GetActiveDrills() (*[]domainProvider.ProviderDrill, error) {
+	return p.providerDrillRepository.GetActive(time.Now())
+}
+
+// EndDrill ends a running failover drill early, the provider is routed to again right away
+func (p *ProviderUseCase) EndDrill(id int) (*domainProvider.ProviderDrill, error) {
+	drill, err := p.providerDrillRepository.End(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	p.Logger.Info("Provider failover drill ended", zap.Int("drillID", id), zap.Int("userID", drill.UserID), zap.Int("providerID", drill.ProviderID))
+	return drill, nil
+}
+
+// schemaFor returns the schemas of a provider type. Providers of types without a schema, created before
+// schemas existed, are held to the schemas every type shares.
+func (p *ProviderUseCase) schemaFor(providerType string) *providerconfig.ProviderType {
+	if t, ok := providerconfig.Lookup(providerType); ok {
+		return t
+	}
+	p.Logger.Warn("Provider type has no config schema", zap.String("type", providerType))
+	return providerconfig.Generic()
+}
+
+// findUserProvider returns the link of a user to a provider, NotFound when the user doesn't have the provider
+func (p *ProviderUseCase) findUserProvider(userID int, providerID int) (*domainProvider.UserProvider, error) {
+	userProviders, err := p.userProviderRepository.GetUserProviders(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *userProviders {
+		if (*userProviders)[i].ProviderID == providerID {
+			return &(*userProviders)[i], nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// TestMessage renders the canned message sent by a provider test
+func TestMessage(providerDetails *domainProvider.Provider, now time.Time) string {
+	return fmt.Sprintf("Test message from go-multi-chat-api through provider %q (%s), sent at %s.",
+		providerDetails.Name, providerDetails.Type, now.UTC().Format(time.RFC3339))
+}
+
+// rawJSON passes provider data through as JSON, data that isn't valid JSON is dropped
+func rawJSON(data []byte) json.RawMessage {
+	if len(data) == 0 || !json.Valid(data) {
+		return nil
+	}
+	return json.RawMessage(data)
+}
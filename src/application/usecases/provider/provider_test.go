@@ -0,0 +1,410 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProviderRepository implements GetByID, GetByName, Create and Update, the embedded interface panics on any
+// other call
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []domainProvider.Provider
+	created   *domainProvider.Provider
+	updates   map[string]interface{}
+}
+
+func (m *mockProviderRepository) Create(providerDomain *domainProvider.Provider) (*domainProvider.Provider, error) {
+	providerDomain.ID = 100 + len(m.providers)
+	m.created = providerDomain
+	m.providers = append(m.providers, *providerDomain)
+	return providerDomain, nil
+}
+
+func (m *mockProviderRepository) Update(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error) {
+	m.updates = providerMap
+	return m.GetByID(id)
+}
+
+func (m *mockProviderRepository) GetAll() (*[]domainProvider.Provider, error) {
+	return &m.providers, nil
+}
+
+func (m *mockProviderRepository) GetByName(name string) (*domainProvider.Provider, error) {
+	for _, p := range m.providers {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockProviderRepository) GetByID(id int) (*domainProvider.Provider, error) {
+	for _, p := range m.providers {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockUserProviderRepository implements GetUserProviders, Create, Update and Delete, the embedded interface panics
+// on any other call
+type mockUserProviderRepository struct {
+	providerRepo.UserProviderRepositoryInterface
+	userProviders []domainProvider.UserProvider
+	created       []domainProvider.UserProvider
+	updatedID     int
+	updates       map[string]interface{}
+	deletedIDs    []int
+}
+
+func (m *mockUserProviderRepository) Create(userProviderDomain *domainProvider.UserProvider) (*domainProvider.UserProvider, error) {
+	userProviderDomain.ID = 100 + len(m.created)
+	m.created = append(m.created, *userProviderDomain)
+	return userProviderDomain, nil
+}
+
+func (m *mockUserProviderRepository) Update(id int, userProviderMap map[string]interface{}) (*domainProvider.UserProvider, error) {
+	m.updatedID = id
+	m.updates = userProviderMap
+	config, _ := userProviderMap["config"].(string)
+	return &domainProvider.UserProvider{ID: id, Config: config}, nil
+}
+
+func (m *mockUserProviderRepository) Delete(id int) error {
+	m.deletedIDs = append(m.deletedIDs, id)
+	return nil
+}
+
+func (m *mockUserProviderRepository) GetUserProviders(userID int) (*[]domainProvider.UserProvider, error) {
+	userProviders := []domainProvider.UserProvider{}
+	for _, up := range m.userProviders {
+		if up.UserID == userID {
+			userProviders = append(userProviders, up)
+		}
+	}
+	return &userProviders, nil
+}
+
+// mockProviderDrillRepository keeps drills in memory, the embedded interface panics on any other call
+type mockProviderDrillRepository struct {
+	providerRepo.ProviderDrillRepositoryInterface
+	drills []domainProvider.ProviderDrill
+}
+
+func (m *mockProviderDrillRepository) Create(drill *domainProvider.ProviderDrill) (*domainProvider.ProviderDrill, error) {
+	drill.ID = len(m.drills) + 1
+	m.drills = append(m.drills, *drill)
+	return drill, nil
+}
+
+func (m *mockProviderDrillRepository) GetActiveProviderIDs(userID int, now time.Time) (map[int]bool, error) {
+	providerIDs := make(map[int]bool)
+	for _, drill := range m.drills {
+		if drill.UserID == userID && drill.EndedAt == nil && now.Before(drill.EndsAt) {
+			providerIDs[drill.ProviderID] = true
+		}
+	}
+	return providerIDs, nil
+}
+
+func (m *mockProviderDrillRepository) End(id int, now time.Time) (*domainProvider.ProviderDrill, error) {
+	for i := range m.drills {
+		if m.drills[i].ID == id && m.drills[i].EndedAt == nil {
+			m.drills[i].EndedAt = &now
+			return &m.drills[i], nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+// mockUserRepository knows the users 7 and 8, the embedded interface panics on any other call
+type mockUserRepository struct {
+	userRepo.UserRepositoryInterface
+}
+
+func (m *mockUserRepository) GetByEmail(email string) (*domainUser.User, error) {
+	switch email {
+	case "ops@example.com":
+		return &domainUser.User{ID: 7, Email: email}, nil
+	case "sales@example.com":
+		return &domainUser.User{ID: 8, Email: email}, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type mockSender struct {
+	err        error
+	response   []byte
+	message    string
+	recipients []string
+	providerID int
+	userID     int
+	// checks makes CheckCredentials check the credentials, refusing them with credentialErr
+	checks        bool
+	credentialErr error
+}
+
+func (m *mockSender) SendThroughProvider(userID int, providerDetails *domainProvider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	m.userID = userID
+	m.providerID = providerDetails.ID
+	m.message = message
+	m.recipients = recipients
+	if m.err != nil {
+		return []byte(`{"number":"+490000"}`), nil, m.err
+	}
+	return []byte(`{"number":"+490000"}`), m.response, nil
+}
+
+func (m *mockSender) CheckCredentials(providerDetails *domainProvider.Provider, userProviderConfig string) (bool, error) {
+	return m.checks, m.credentialErr
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func setupUseCase(t *testing.T, sender *mockSender) IProviderUseCase {
+	useCase, _, _ := setupUseCaseWithRepositories(t, sender)
+	return useCase
+}
+
+func setupUseCaseWithRepositories(t *testing.T, sender *mockSender) (IProviderUseCase, *mockProviderRepository, *mockUserProviderRepository) {
+	providerRepository := &mockProviderRepository{providers: []domainProvider.Provider{
+		{ID: 1, Name: "primary", Type: "signal", Status: true},
+		{ID: 2, Name: "backup", Type: "signal", Status: false},
+		{ID: 3, Name: "other", Type: "signal", Status: true},
+		{ID: 4, Name: "mail", Type: "email", Status: true},
+	}}
+	userProviderRepository := &mockUserProviderRepository{userProviders: []domainProvider.UserProvider{
+		{ID: 11, UserID: 7, ProviderID: 1, Priority: 2, Status: true},
+		{ID: 12, UserID: 7, ProviderID: 2, Priority: 1, Status: true},
+		{ID: 13, UserID: 8, ProviderID: 3, Priority: 1, Status: true},
+	}}
+	return NewProviderUseCase(providerRepository, userProviderRepository, &mockProviderDrillRepository{}, &mockUserRepository{}, sender, nil, setupLogger(t)), providerRepository, userProviderRepository
+}
+
+func TestTestSend_UsesExactlyTheGivenProvider(t *testing.T) {
+	sender := &mockSender{response: []byte(`{"timestamp":1790856000}`)}
+	useCase := setupUseCase(t, sender)
+
+	result, err := useCase.TestSend(7, 1, "+491111")
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.True(t, result.Active)
+	assert.Equal(t, 1, sender.providerID)
+	assert.Equal(t, 7, sender.userID)
+	assert.Equal(t, []string{"+491111"}, sender.recipients)
+	assert.True(t, strings.Contains(sender.message, `provider "primary" (signal)`))
+	assert.JSONEq(t, `{"timestamp":1790856000}`, string(result.Response))
+	assert.JSONEq(t, `{"number":"+490000"}`, string(result.Request))
+}
+
+func TestTestSend_InactiveProviderReportsFailure(t *testing.T) {
+	sender := &mockSender{err: errors.New("invalid credentials")}
+	useCase := setupUseCase(t, sender)
+
+	result, err := useCase.TestSend(7, 2, "+491111")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sender.providerID)
+	assert.False(t, result.Active)
+	assert.False(t, result.Success)
+	assert.Equal(t, "invalid credentials", result.Error)
+	assert.Nil(t, result.Response)
+}
+
+func TestTestSend_ProviderOfAnotherUserIsNotFound(t *testing.T) {
+	sender := &mockSender{}
+	useCase := setupUseCase(t, sender)
+
+	_, err := useCase.TestSend(7, 3, "+491111")
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	assert.Equal(t, 0, sender.providerID)
+}
+
+func TestGetProviders_ListsInactiveProvidersToo(t *testing.T) {
+	useCase := setupUseCase(t, &mockSender{})
+
+	providers, err := useCase.GetProviders()
+	assert.NoError(t, err)
+	assert.Len(t, *providers, 4)
+	assert.False(t, (*providers)[1].Status)
+}
+
+func TestCreateProvider_RejectsInvalidConfigWithFieldErrors(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.CreateProvider(&domainProvider.Provider{
+		Name:   "mail",
+		Type:   "email",
+		Config: `{"from":"not an address","port":70000}`,
+	})
+	var validationErr *providerconfig.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []providerconfig.FieldError{
+		{Field: "host", Message: "is required"},
+		{Field: "from", Message: "must be an email address"},
+		{Field: "port", Message: "must be at most 65535"},
+	}, validationErr.Errors)
+	assert.Nil(t, providerRepository.created)
+}
+
+func TestCreateProvider_UnknownTypeIsRejected(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.CreateProvider(&domainProvider.Provider{Name: "pager", Type: "pager"})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Nil(t, providerRepository.created)
+}
+
+func TestCreateProvider_ValidConfigIsStored(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.CreateProvider(&domainProvider.Provider{
+		Name:   "signal",
+		Type:   "signal",
+		Config: `{"warmup":{"enabled":true,"ramp":[50,100]}}`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "signal", providerRepository.created.Name)
+}
+
+func TestUpdateProvider_ValidatesConfigAgainstExistingType(t *testing.T) {
+	useCase, providerRepository, _ := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.UpdateProvider(1, map[string]interface{}{"config": `{"host":"smtp.example.com"}`})
+	var validationErr *providerconfig.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "host", validationErr.Errors[0].Field)
+	assert.Nil(t, providerRepository.updates)
+
+	_, err = useCase.UpdateProvider(1, map[string]interface{}{"type": "email"})
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+
+	_, err = useCase.UpdateProvider(4, map[string]interface{}{"config": `{"from":"ops@example.com","host":"smtp.example.com","port":587}`})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"from":"ops@example.com","host":"smtp.example.com","port":587}`, providerRepository.updates["config"])
+}
+
+func TestUpdateUserProviderConfig(t *testing.T) {
+	useCase, _, userProviderRepository := setupUseCaseWithRepositories(t, &mockSender{})
+
+	_, err := useCase.UpdateUserProviderConfig(7, 1, `{"webhook_url":"ftp://example.com","webhook_version":"v3"}`, nil, false)
+	var validationErr *providerconfig.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Errors, 2)
+
+	_, err = useCase.UpdateUserProviderConfig(7, 3, `{}`, nil, false)
+	var appErr *domainErrors.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+
+	version := 3
+	updated, err := useCase.UpdateUserProviderConfig(7, 1, `{"webhook_url":"https://example.com/hook","webhook_enabled":true}`, &version, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, userProviderRepository.updatedID)
+	assert.Equal(t, 3, userProviderRepository.updates["version"])
+	assert.Equal(t, `{"webhook_url":"https://example.com/hook","webhook_enabled":true}`, updated.Config)
+	// The sender can't check the credentials of the type
+	assert.Equal(t, domainProvider.CredentialStatusUnchecked, userProviderRepository.updates["credentialStatus"])
+}
+
+func TestUpdateUserProviderConfig_ChecksCredentials(t *testing.T) {
+	sender := &mockSender{checks: true}
+	useCase, _, userProviderRepository := setupUseCaseWithRepositories(t, sender)
+
+	_, err := useCase.UpdateUserProviderConfig(7, 1, `{}`, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, domainProvider.CredentialStatusValid, userProviderRepository.updates["credentialStatus"])
+	assert.Equal(t, "", userProviderRepository.updates["credentialError"])
+	assert.NotNil(t, userProviderRepository.updates["credentialsCheckedAt"])
+
+	// Refused credentials are saved with the reason, routed to only when forced
+	sender.credentialErr = errors.New("twilio answered 401: Authenticate (code 20003)")
+	_, err = useCase.UpdateUserProviderConfig(7, 1, `{}`, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, domainProvider.CredentialStatusInvalid, userProviderRepository.updates["credentialStatus"])
+	assert.Equal(t, "twilio answered 401: Authenticate (code 20003)", userProviderRepository.updates["credentialError"])
+	assert.Equal(t, true, userProviderRepository.updates["credentialsForced"])
+
+	// Validating again keeps the config and whether it was forced
+	sender.credentialErr = nil
+	_, err = useCase.ValidateUserProviderCredentials(7, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 11, userProviderRepository.updatedID)
+	assert.Equal(t, domainProvider.CredentialStatusValid, userProviderRepository.updates["credentialStatus"])
+	assert.NotContains(t, userProviderRepository.updates, "config")
+	assert.NotContains(t, userProviderRepository.updates, "credentialsForced")
+}
+
+func TestStartDrill(t *testing.T) {
+	useCase := setupUseCase(t, &mockSender{})
+
+	drill, err := useCase.StartDrill(7, 1, 99, 30, "quarterly failover rehearsal")
+	assert.NoError(t, err)
+	assert.Equal(t, 99, drill.StartedBy)
+	assert.Equal(t, 30*time.Minute, drill.EndsAt.Sub(drill.StartsAt))
+
+	// A provider is in one drill at a time
+	_, err = useCase.StartDrill(7, 1, 99, 30, "")
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.Conflict, appErr.Type)
+
+	// Ending the drill allows another one
+	_, err = useCase.EndDrill(drill.ID)
+	assert.NoError(t, err)
+	_, err = useCase.StartDrill(7, 1, 99, 30, "")
+	assert.NoError(t, err)
+}
+
+func TestStartDrill_Validation(t *testing.T) {
+	useCase := setupUseCase(t, &mockSender{})
+
+	for _, duration := range []int{0, -5, 24*60 + 1} {
+		_, err := useCase.StartDrill(7, 1, 99, duration, "")
+		appErr, ok := err.(*domainErrors.AppError)
+		if assert.True(t, ok) {
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+		}
+	}
+
+	// The user must have the provider
+	_, err := useCase.StartDrill(7, 3, 99, 30, "")
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
+
+func TestEndDrill_NotRunning(t *testing.T) {
+	useCase := setupUseCase(t, &mockSender{})
+
+	_, err := useCase.EndDrill(42)
+	appErr, ok := err.(*domainErrors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, domainErrors.NotFound, appErr.Type)
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"go.uber.org/zap"
+)
+
+// WebhookSecretCipher encrypts the client key and the header values of the webhook of a user provider config with
+// the key of their user before they are stored, and decrypts them to compare a desired config with the stored one
+type WebhookSecretCipher interface {
+	Encrypt(userID int, plaintext string) (string, error)
+	Decrypt(userID int, ciphertext string) (string, error)
+}
+
+// maxWebhookHeaders is the most webhook_headers a config can set, as many as a hook subscription
+const maxWebhookHeaders = 20
+
+// webhookHeaderNamePattern matches the names of custom webhook headers
+var webhookHeaderNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
+// reservedWebhookHeaders are the headers of webhook requests the webhook_headers of a config can't set
+var reservedWebhookHeaders = []string{"Content-Type", "Content-Length", "Host", "User-Agent", "Transfer-Encoding", "Connection", messaging.WebhookVersionHeader}
+
+// validateWebhookConfig checks the delivery settings of the webhook of a user provider config. The TLS settings
+// need an https webhook_url, and a client key can't be stored without CREDENTIAL_ENCRYPTION_KEY.
+func (p *ProviderUseCase) validateWebhookConfig(config string) error {
+	if strings.TrimSpace(config) == "" {
+		return nil
+	}
+	var webhook messaging.WebhookConfig
+	if err := json.Unmarshal([]byte(config), &webhook); err != nil {
+		return domainErrors.NewAppError(fmt.Errorf("invalid webhook settings: %w", err), domainErrors.ValidationError)
+	}
+	delivery := webhook.Delivery()
+	if len(delivery.Headers) > maxWebhookHeaders {
+		return domainErrors.NewAppError(fmt.Errorf("webhook_headers can hold at most %d headers", maxWebhookHeaders), domainErrors.ValidationError)
+	}
+	for name, value := range delivery.Headers {
+		if !webhookHeaderNamePattern.MatchString(name) || strings.ContainsAny(value, "\r\n") {
+			return domainErrors.NewAppError(fmt.Errorf("invalid webhook header %q", name), domainErrors.ValidationError)
+		}
+		if canonical := http.CanonicalHeaderKey(name); slices.Contains(reservedWebhookHeaders, canonical) {
+			return domainErrors.NewAppError(fmt.Errorf("the %s webhook header can't be set", canonical), domainErrors.ValidationError)
+		}
+	}
+	if delivery.HasTLS() {
+		if parsed, err := url.Parse(webhook.WebhookURL); err != nil || parsed.Scheme != "https" {
+			return domainErrors.NewAppError(errors.New("the webhook TLS settings need an https webhook_url"), domainErrors.ValidationError)
+		}
+	}
+	if (delivery.ClientCertificate == "") != (delivery.ClientKey == "") {
+		return domainErrors.NewAppError(errors.New("webhook_client_certificate and webhook_client_key must be set together"), domainErrors.ValidationError)
+	}
+	if delivery.ClientKey != "" && p.secrets == nil {
+		return domainErrors.NewAppError(errors.New("webhook_client_key can't be stored, CREDENTIAL_ENCRYPTION_KEY is not set"), domainErrors.ValidationError)
+	}
+	if delivery.ClientCertificate != "" && !security.IsEncryptedUserSecret(delivery.ClientKey) {
+		if _, err := tls.X509KeyPair([]byte(delivery.ClientCertificate), []byte(delivery.ClientKey)); err != nil {
+			return domainErrors.NewAppError(fmt.Errorf("invalid webhook client certificate: %w", err), domainErrors.ValidationError)
+		}
+	}
+	if delivery.CACertificate != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(delivery.CACertificate)) {
+		return domainErrors.NewAppError(errors.New("webhook_ca_certificate contains no PEM certificate"), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// sealWebhookSecrets returns a user provider config as it is stored, with the webhook client key and header
+// values encrypted. Values already encrypted are kept, header values stay in plaintext when no cipher is set.
+func (p *ProviderUseCase) sealWebhookSecrets(userID int, config string) (string, error) {
+	return mapWebhookSecrets(config, func(value string) (string, error) {
+		if p.secrets == nil || value == "" || security.IsEncryptedUserSecret(value) {
+			return value, nil
+		}
+		encrypted, err := p.secrets.Encrypt(userID, value)
+		if err != nil {
+			p.Logger.Error("Error encrypting webhook secret", zap.Error(err), zap.Int("userID", userID))
+			return "", domainErrors.NewAppError(err, domainErrors.UnknownError)
+		}
+		return encrypted, nil
+	})
+}
+
+// openWebhookSecrets returns a stored user provider config with the webhook client key and header values
+// decrypted, the config is returned as it is when they can't be decrypted
+func (p *ProviderUseCase) openWebhookSecrets(userID int, config string) string {
+	opened, err := mapWebhookSecrets(config, func(value string) (string, error) {
+		if !security.IsEncryptedUserSecret(value) {
+			return value, nil
+		}
+		if p.secrets == nil {
+			return "", errors.New("the webhook secret is encrypted but CREDENTIAL_ENCRYPTION_KEY is not set")
+		}
+		return p.secrets.Decrypt(userID, value)
+	})
+	if err != nil {
+		return config
+	}
+	return opened
+}
+
+// mapWebhookSecrets replaces the webhook client key and header values of a config by the results of convert,
+// configs without them are returned as they are
+func mapWebhookSecrets(config string, convert func(value string) (string, error)) (string, error) {
+	if !strings.Contains(config, `"webhook_client_key"`) && !strings.Contains(config, `"webhook_headers"`) {
+		return config, nil
+	}
+	decoder := json.NewDecoder(bytes.NewBufferString(config))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return "", domainErrors.NewAppError(fmt.Errorf("invalid config: %w", err), domainErrors.ValidationError)
+	}
+
+	if key, ok := fields["webhook_client_key"].(string); ok {
+		converted, err := convert(key)
+		if err != nil {
+			return "", err
+		}
+		fields["webhook_client_key"] = converted
+	}
+	if headers, ok := fields["webhook_headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			if value, ok := value.(string); ok {
+				converted, err := convert(value)
+				if err != nil {
+					return "", err
+				}
+				headers[name] = converted
+			}
+		}
+	}
+	mapped, err := json.Marshal(fields)
+	if err != nil {
+		return "", domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	return string(mapped), nil
+}
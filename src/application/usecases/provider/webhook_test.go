@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClientCertificate returns a self-signed PEM certificate and key
+func newTestClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "client"}, NotAfter: time.Now().Add(time.Hour)}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func setupWebhookUseCase(t *testing.T, secrets WebhookSecretCipher) (IProviderUseCase, *mockUserProviderRepository) {
+	providerRepository := &mockProviderRepository{providers: []domainProvider.Provider{{ID: 1, Name: "primary", Type: "signal", Status: true}}}
+	userProviderRepository := &mockUserProviderRepository{userProviders: []domainProvider.UserProvider{{ID: 11, UserID: 7, ProviderID: 1, Priority: 1, Status: true}}}
+	return NewProviderUseCase(providerRepository, userProviderRepository, &mockProviderDrillRepository{}, &mockUserRepository{}, &mockSender{}, secrets, setupLogger(t)), userProviderRepository
+}
+
+func webhookConfig(t *testing.T, fields map[string]interface{}) string {
+	config, err := json.Marshal(fields)
+	require.NoError(t, err)
+	return string(config)
+}
+
+func TestUpdateUserProviderConfig_EncryptsWebhookSecrets(t *testing.T) {
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	useCase, userProviderRepository := setupWebhookUseCase(t, secrets)
+	certificate, key := newTestClientCertificate(t)
+
+	_, err = useCase.UpdateUserProviderConfig(7, 1, webhookConfig(t, map[string]interface{}{
+		"webhook_url":                "https://example.com/hook",
+		"webhook_enabled":            true,
+		"webhook_headers":            map[string]string{"Authorization": "Bearer static"},
+		"webhook_client_certificate": certificate,
+		"webhook_client_key":         key,
+	}), nil, false)
+	require.NoError(t, err)
+
+	var stored messaging.WebhookConfig
+	require.NoError(t, json.Unmarshal([]byte(userProviderRepository.updates["config"].(string)), &stored))
+	assert.Equal(t, "https://example.com/hook", stored.WebhookURL)
+	assert.Equal(t, certificate, stored.ClientCertificate)
+	decrypted, err := secrets.Decrypt(7, stored.ClientKey)
+	require.NoError(t, err)
+	assert.Equal(t, key, decrypted)
+	decrypted, err = secrets.Decrypt(7, stored.Headers["Authorization"])
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer static", decrypted)
+
+	// A stored config sent back keeps its encrypted values
+	_, err = useCase.UpdateUserProviderConfig(7, 1, userProviderRepository.updates["config"].(string), nil, false)
+	require.NoError(t, err)
+	var resent messaging.WebhookConfig
+	require.NoError(t, json.Unmarshal([]byte(userProviderRepository.updates["config"].(string)), &resent))
+	assert.Equal(t, stored.ClientKey, resent.ClientKey)
+}
+
+func TestUpdateUserProviderConfig_ValidatesWebhookSettings(t *testing.T) {
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	certificate, key := newTestClientCertificate(t)
+	tests := []struct {
+		name    string
+		secrets WebhookSecretCipher
+		webhook map[string]interface{}
+	}{
+		{name: "client key without encryption key", webhook: map[string]interface{}{"webhook_url": "https://example.com/hook", "webhook_client_certificate": certificate, "webhook_client_key": key}},
+		{name: "certificate without key", secrets: secrets, webhook: map[string]interface{}{"webhook_url": "https://example.com/hook", "webhook_client_certificate": certificate}},
+		{name: "TLS settings of an http url", secrets: secrets, webhook: map[string]interface{}{"webhook_url": "http://example.com/hook", "webhook_insecure_skip_verify": true}},
+		{name: "invalid CA certificate", secrets: secrets, webhook: map[string]interface{}{"webhook_url": "https://example.com/hook", "webhook_ca_certificate": "not a certificate"}},
+		{name: "protocol header", secrets: secrets, webhook: map[string]interface{}{"webhook_url": "https://example.com/hook", "webhook_headers": map[string]string{"x-webhook-version": "v9"}}},
+		{name: "header with a line break", secrets: secrets, webhook: map[string]interface{}{"webhook_url": "https://example.com/hook", "webhook_headers": map[string]string{"X-Token": "a\r\nb"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase, userProviderRepository := setupWebhookUseCase(t, tt.secrets)
+			_, err := useCase.UpdateUserProviderConfig(7, 1, webhookConfig(t, tt.webhook), nil, false)
+			var appErr *domainErrors.AppError
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+			assert.Nil(t, userProviderRepository.updates)
+		})
+	}
+
+	// Headers are stored in plaintext without an encryption key, like the headers of hook subscriptions
+	useCase, userProviderRepository := setupWebhookUseCase(t, nil)
+	_, err = useCase.UpdateUserProviderConfig(7, 1, `{"webhook_url":"https://example.com/hook","webhook_headers":{"Authorization":"Bearer static"}}`, nil, false)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"webhook_url":"https://example.com/hook","webhook_headers":{"Authorization":"Bearer static"}}`, userProviderRepository.updates["config"].(string))
+}
+
+func TestApplyDesiredState_EncryptsWebhookSecrets(t *testing.T) {
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	useCase, userProviderRepository := setupWebhookUseCase(t, secrets)
+	state := &DesiredState{
+		Providers: []DesiredProvider{{Name: "primary", Type: "signal", Status: true}},
+		UserProviders: []DesiredUserProvider{{User: "ops@example.com", Provider: "primary", Priority: 1, Status: true,
+			Config: `{"webhook_url":"https://example.com/hook","webhook_headers":{"Authorization":"Bearer static"}}`}},
+	}
+
+	_, err = useCase.ApplyDesiredState(state, false)
+	require.NoError(t, err)
+	var stored messaging.WebhookConfig
+	require.NoError(t, json.Unmarshal([]byte(userProviderRepository.updates["config"].(string)), &stored))
+	decrypted, err := secrets.Decrypt(7, stored.Headers["Authorization"])
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer static", decrypted)
+
+	// The encrypted config is compared in plaintext, so applying the state again changes nothing
+	userProviderRepository.userProviders[0].Config = userProviderRepository.updates["config"].(string)
+	changes, err := useCase.ApplyDesiredState(state, true)
+	require.NoError(t, err)
+	assert.Equal(t, ChangeUnchanged, changes[len(changes)-1].Action)
+}
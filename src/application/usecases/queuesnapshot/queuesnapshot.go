@@ -0,0 +1,258 @@
+package queuesnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/queuesnapshot"
+
+	"go.uber.org/zap"
+)
+
+// Types of the jobs taking and replaying queue snapshots
+const (
+	SnapshotJobType = "queue_snapshot"
+	ReplayJobType   = "queue_replay"
+)
+
+// namePattern matches the names of snapshot files, which can't leave the snapshot directory
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\.jsonl$`)
+
+// Snapshotter writes the queue to snapshots and replays them
+type Snapshotter interface {
+	Snapshot(ctx context.Context, w io.Writer) (*queuesnapshot.SnapshotResult, error)
+	Replay(ctx context.Context, r io.Reader, filter queuesnapshot.Filter, options queuesnapshot.Options, report func(result *queuesnapshot.ReplayResult)) (*queuesnapshot.ReplayResult, error)
+}
+
+// SnapshotPayload is the payload of a snapshot job
+type SnapshotPayload struct {
+	Name string `json:"name"`
+}
+
+// SnapshotResult is the result of a snapshot job
+type SnapshotResult struct {
+	Name string `json:"name"`
+	*queuesnapshot.SnapshotResult
+}
+
+// ReplayPayload is the payload of a replay job
+type ReplayPayload struct {
+	Name   string               `json:"name"`
+	Filter queuesnapshot.Filter `json:"filter"`
+	DryRun bool                 `json:"dry_run"`
+	Resend bool                 `json:"resend"`
+}
+
+// SnapshotFile is a snapshot in the snapshot directory
+type SnapshotFile struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// IQueueSnapshotUseCase defines the interface for taking queue snapshots and replaying them
+type IQueueSnapshotUseCase interface {
+	// StartSnapshot queues a job writing the messages waiting to be sent to a new snapshot, it returns its name
+	StartSnapshot(createdBy int) (*provider.Job, string, error)
+	// StartReplay queues a job replaying the messages of a snapshot selected by the filter
+	StartReplay(name string, filter queuesnapshot.Filter, options queuesnapshot.Options, createdBy int) (*provider.Job, error)
+	// GetSnapshots lists the snapshots, newest first
+	GetSnapshots() ([]SnapshotFile, error)
+	// OpenSnapshot opens a snapshot for download, the caller closes it
+	OpenSnapshot(name string) (io.ReadCloser, *SnapshotFile, error)
+	// RunSnapshot and RunReplay are the handlers of the jobs
+	RunSnapshot(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+	RunReplay(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+}
+
+// QueueSnapshotUseCase implements the IQueueSnapshotUseCase interface
+type QueueSnapshotUseCase struct {
+	jobQueue    jobs.Queue
+	snapshotter Snapshotter
+	dir         string
+	Logger      *logger.Logger
+	now         func() time.Time
+}
+
+// NewQueueSnapshotUseCase creates a new QueueSnapshotUseCase keeping the snapshots in dir
+func NewQueueSnapshotUseCase(jobQueue jobs.Queue, snapshotter Snapshotter, dir string, loggerInstance *logger.Logger) IQueueSnapshotUseCase {
+	return &QueueSnapshotUseCase{
+		jobQueue:    jobQueue,
+		snapshotter: snapshotter,
+		dir:         dir,
+		Logger:      loggerInstance,
+		now:         time.Now,
+	}
+}
+
+func (u *QueueSnapshotUseCase) StartSnapshot(createdBy int) (*provider.Job, string, error) {
+	name := "queue-" + u.now().UTC().Format("20060102T150405.000Z") + ".jsonl"
+	job, err := u.jobQueue.Enqueue(SnapshotJobType, SnapshotPayload{Name: name}, createdBy)
+	if err != nil {
+		return nil, "", err
+	}
+	u.Logger.Info("Queued queue snapshot", zap.Int("jobID", job.ID), zap.String("name", name), zap.Int("createdBy", createdBy))
+	return job, name, nil
+}
+
+func (u *QueueSnapshotUseCase) StartReplay(name string, filter queuesnapshot.Filter, options queuesnapshot.Options, createdBy int) (*provider.Job, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	path, err := u.path(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, notFound(name, err)
+	}
+	payload := ReplayPayload{Name: name, Filter: filter, DryRun: options.DryRun, Resend: options.Resend}
+	job, err := u.jobQueue.Enqueue(ReplayJobType, payload, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	u.Logger.Warn("Queued queue snapshot replay", zap.Int("jobID", job.ID), zap.String("name", name),
+		zap.Bool("dryRun", options.DryRun), zap.Bool("resend", options.Resend), zap.Int("createdBy", createdBy))
+	return job, nil
+}
+
+func (u *QueueSnapshotUseCase) GetSnapshots() ([]SnapshotFile, error) {
+	entries, err := os.ReadDir(u.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []SnapshotFile{}, nil
+	}
+	if err != nil {
+		u.Logger.Error("Error listing queue snapshots", zap.Error(err), zap.String("dir", u.dir))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	files := []SnapshotFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !namePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, SnapshotFile{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+func (u *QueueSnapshotUseCase) OpenSnapshot(name string) (io.ReadCloser, *SnapshotFile, error) {
+	path, err := u.path(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, notFound(name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, &SnapshotFile{Name: name, Size: info.Size(), CreatedAt: info.ModTime()}, nil
+}
+
+// RunSnapshot writes the snapshot to a temporary file renamed once it is complete, so a snapshot listed is never
+// partial. Snapshots hold message contents, only the user running the API can read them.
+func (u *QueueSnapshotUseCase) RunSnapshot(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	var payload SnapshotPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return nil, jobs.Permanent(fmt.Errorf("invalid queue snapshot payload: %w", err))
+	}
+	path, err := u.path(payload.Name)
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	if err := os.MkdirAll(u.dir, 0o700); err != nil {
+		return nil, err
+	}
+	partial := path + ".partial"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	result, err := u.snapshotter.Snapshot(ctx, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partial, path)
+	}
+	if err != nil {
+		_ = os.Remove(partial)
+		return nil, err
+	}
+	u.Logger.Info("Wrote queue snapshot", zap.Int("jobID", job.ID), zap.String("name", payload.Name), zap.Int("records", result.Records))
+	return &SnapshotResult{Name: payload.Name, SnapshotResult: result}, nil
+}
+
+// RunReplay replays a snapshot, reporting the share of the file read as progress. A failed replay isn't retried.
+func (u *QueueSnapshotUseCase) RunReplay(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	var payload ReplayPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return nil, jobs.Permanent(fmt.Errorf("invalid queue replay payload: %w", err))
+	}
+	file, info, err := u.OpenSnapshot(payload.Name)
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	defer file.Close()
+
+	reader := &countingReader{reader: file}
+	options := queuesnapshot.Options{DryRun: payload.DryRun, Resend: payload.Resend}
+	result, err := u.snapshotter.Replay(ctx, reader, payload.Filter, options, func(result *queuesnapshot.ReplayResult) {
+		if info.Size > 0 {
+			progress.Report(min(int(reader.read.Load()*100/info.Size), 99), result)
+		}
+	})
+	if err != nil {
+		// A retry would queue the messages recreated so far once more, the result tells what was done instead
+		return result, jobs.Permanent(err)
+	}
+	return result, nil
+}
+
+// path returns the path of a snapshot in the snapshot directory
+func (u *QueueSnapshotUseCase) path(name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", domainErrors.NewAppError(fmt.Errorf("invalid snapshot name %q", name), domainErrors.ValidationError)
+	}
+	return filepath.Join(u.dir, name), nil
+}
+
+func notFound(name string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return domainErrors.NewAppError(fmt.Errorf("queue snapshot %s not found", name), domainErrors.NotFound)
+	}
+	return err
+}
+
+// countingReader counts the bytes read from a reader
+type countingReader struct {
+	reader io.Reader
+	read   atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
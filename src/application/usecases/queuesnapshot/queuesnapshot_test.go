@@ -0,0 +1,109 @@
+package queuesnapshot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/queuesnapshot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockJobQueue struct {
+	payloads []interface{}
+}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	m.payloads = append(m.payloads, payload)
+	return &provider.Job{ID: len(m.payloads), Type: jobType, Status: "queued", CreatedBy: createdBy}, nil
+}
+
+// mockSnapshotter writes a fixed snapshot and records the snapshots it replayed
+type mockSnapshotter struct {
+	err      error
+	replayed []string
+}
+
+func (m *mockSnapshotter) Snapshot(ctx context.Context, w io.Writer) (*queuesnapshot.SnapshotResult, error) {
+	_, _ = io.WriteString(w, `{"id":1,"status":"failed"}`+"\n")
+	return &queuesnapshot.SnapshotResult{Records: 1, Statuses: map[string]int{"failed": 1}}, m.err
+}
+
+func (m *mockSnapshotter) Replay(ctx context.Context, r io.Reader, filter queuesnapshot.Filter, options queuesnapshot.Options, report func(result *queuesnapshot.ReplayResult)) (*queuesnapshot.ReplayResult, error) {
+	content, _ := io.ReadAll(r)
+	m.replayed = append(m.replayed, string(content))
+	return &queuesnapshot.ReplayResult{Read: 1}, m.err
+}
+
+func setupUseCase(t *testing.T) (*QueueSnapshotUseCase, *mockJobQueue, *mockSnapshotter) {
+	queue, snapshotter := &mockJobQueue{}, &mockSnapshotter{}
+	useCase := NewQueueSnapshotUseCase(queue, snapshotter, filepath.Join(t.TempDir(), "snapshots"), &logger.Logger{Log: zap.NewNop()}).(*QueueSnapshotUseCase)
+	useCase.now = func() time.Time { return time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC) }
+	return useCase, queue, snapshotter
+}
+
+func TestSnapshotAndReplay(t *testing.T) {
+	useCase, queue, snapshotter := setupUseCase(t)
+
+	_, name, err := useCase.StartSnapshot(1)
+	require.NoError(t, err)
+	assert.Equal(t, "queue-20240501T100000.000Z.jsonl", name)
+
+	_, err = useCase.RunSnapshot(context.Background(), &provider.Job{ID: 1, Payload: `{"name":"` + name + `"}`}, nil)
+	require.NoError(t, err)
+	snapshots, err := useCase.GetSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, name, snapshots[0].Name)
+	info, err := os.Stat(filepath.Join(useCase.dir, name))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = useCase.StartReplay(name, queuesnapshot.Filter{Statuses: []string{"failed"}}, queuesnapshot.Options{DryRun: true}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayPayload{Name: name, Filter: queuesnapshot.Filter{Statuses: []string{"failed"}}, DryRun: true}, queue.payloads[1])
+
+	_, err = useCase.RunReplay(context.Background(), &provider.Job{ID: 2, Payload: `{"name":"` + name + `"}`}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1,"status":"failed"}` + "\n"}, snapshotter.replayed)
+}
+
+func TestRunSnapshot_FailedSnapshotLeavesNoFile(t *testing.T) {
+	useCase, _, snapshotter := setupUseCase(t)
+	snapshotter.err = errors.New("database gone")
+
+	_, err := useCase.RunSnapshot(context.Background(), &provider.Job{ID: 1, Payload: `{"name":"queue-1.jsonl"}`}, nil)
+	require.Error(t, err)
+	entries, err := os.ReadDir(useCase.dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSnapshotNames(t *testing.T) {
+	useCase, queue, _ := setupUseCase(t)
+
+	_, _, err := useCase.OpenSnapshot("../../etc/passwd")
+	assertErrorType(t, err, domainErrors.ValidationError)
+	_, err = useCase.StartReplay("missing.jsonl", queuesnapshot.Filter{}, queuesnapshot.Options{}, 1)
+	assertErrorType(t, err, domainErrors.NotFound)
+	_, err = useCase.StartReplay("missing.jsonl", queuesnapshot.Filter{Statuses: []string{"delivered"}}, queuesnapshot.Options{}, 1)
+	assertErrorType(t, err, domainErrors.ValidationError)
+	assert.Empty(t, queue.payloads)
+}
+
+func assertErrorType(t *testing.T, err error, errorType domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr), "%v", err)
+	assert.Equal(t, errorType, appErr.Type)
+}
@@ -0,0 +1,120 @@
+package receivedenvelope
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+
+	"go.uber.org/zap"
+)
+
+// pruneInterval is how often the envelopes older than the retention are deleted
+const pruneInterval = time.Hour
+
+// StoredTypes are the types of received envelopes whose normalized content is stored. Text messages aren't
+// stored, they reach the conversations instead.
+var StoredTypes = []domainSignal.EnvelopeType{
+	domainSignal.EnvelopeTypeContact,
+	domainSignal.EnvelopeTypePayment,
+	domainSignal.EnvelopeTypeReaction,
+}
+
+// IReceivedEnvelopeUseCase defines the interface for the stored content of received envelopes
+type IReceivedEnvelopeUseCase interface {
+	// Record stores the normalized content of a received contact card, payment notification or reaction, other
+	// envelopes are skipped
+	Record(receivedMessage *domainSignal.ReceivedMessage) error
+	// GetEnvelopes returns the latest stored envelopes, optionally of one account and type, newest first
+	GetEnvelopes(account string, envelopeType string, limit int) ([]domainSignal.ReceivedEnvelope, error)
+}
+
+// ReceivedEnvelopeUseCase implements the IReceivedEnvelopeUseCase interface
+type ReceivedEnvelopeUseCase struct {
+	repository signalRepo.ReceivedEnvelopeRepositoryInterface
+	retention  time.Duration
+	Logger     *logger.Logger
+	mutex      sync.Mutex
+	lastPrune  time.Time
+	now        func() time.Time
+}
+
+// NewReceivedEnvelopeUseCase creates a new ReceivedEnvelopeUseCase keeping the envelopes for the retention
+func NewReceivedEnvelopeUseCase(repository signalRepo.ReceivedEnvelopeRepositoryInterface, retention time.Duration, loggerInstance *logger.Logger) IReceivedEnvelopeUseCase {
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour // Default to keeping the envelopes of 30 days if not specified
+	}
+	return &ReceivedEnvelopeUseCase{
+		repository: repository,
+		retention:  retention,
+		Logger:     loggerInstance,
+		now:        time.Now,
+	}
+}
+
+func (u *ReceivedEnvelopeUseCase) Record(receivedMessage *domainSignal.ReceivedMessage) error {
+	envelope := receivedMessage.Envelope
+	envelopeType := envelope.Type()
+	if !isStoredType(envelopeType) {
+		return nil
+	}
+	if _, err := u.repository.Create(&domainSignal.ReceivedEnvelope{
+		Account:   receivedMessage.Account,
+		Source:    envelope.SenderID(),
+		Timestamp: envelope.Timestamp,
+		Type:      envelopeType,
+		Content:   *envelope.Content(),
+	}); err != nil {
+		return err
+	}
+	u.prune()
+	return nil
+}
+
+func (u *ReceivedEnvelopeUseCase) GetEnvelopes(account string, envelopeType string, limit int) ([]domainSignal.ReceivedEnvelope, error) {
+	if envelopeType != "" && !isStoredType(domainSignal.EnvelopeType(envelopeType)) {
+		names := make([]string, len(StoredTypes))
+		for i, t := range StoredTypes {
+			names[i] = string(t)
+		}
+		return nil, domainErrors.NewAppError(fmt.Errorf("type must be one of %s", strings.Join(names, ", ")), domainErrors.ValidationError)
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return u.repository.GetRecent(account, envelopeType, limit)
+}
+
+// prune deletes the envelopes older than the retention, at most once per interval
+func (u *ReceivedEnvelopeUseCase) prune() {
+	now := u.now()
+	u.mutex.Lock()
+	if now.Sub(u.lastPrune) < pruneInterval {
+		u.mutex.Unlock()
+		return
+	}
+	u.lastPrune = now
+	u.mutex.Unlock()
+
+	deleted, err := u.repository.DeleteBefore(now.Add(-u.retention))
+	if err != nil {
+		return
+	}
+	if deleted > 0 {
+		u.Logger.Info("Pruned received envelopes", zap.Int64("deleted", deleted))
+	}
+}
+
+func isStoredType(envelopeType domainSignal.EnvelopeType) bool {
+	for _, t := range StoredTypes {
+		if t == envelopeType {
+			return true
+		}
+	}
+	return false
+}
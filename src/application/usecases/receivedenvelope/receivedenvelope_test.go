@@ -0,0 +1,88 @@
+package receivedenvelope
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockReceivedEnvelopeRepository struct {
+	envelopes     []domainSignal.ReceivedEnvelope
+	deletedBefore []time.Time
+}
+
+func (m *mockReceivedEnvelopeRepository) Create(envelope *domainSignal.ReceivedEnvelope) (*domainSignal.ReceivedEnvelope, error) {
+	envelope.ID = len(m.envelopes) + 1
+	m.envelopes = append(m.envelopes, *envelope)
+	return envelope, nil
+}
+
+func (m *mockReceivedEnvelopeRepository) GetRecent(account string, envelopeType string, limit int) ([]domainSignal.ReceivedEnvelope, error) {
+	var envelopes []domainSignal.ReceivedEnvelope
+	for i := len(m.envelopes) - 1; i >= 0 && len(envelopes) < limit; i-- {
+		if envelopeType == "" || string(m.envelopes[i].Type) == envelopeType {
+			envelopes = append(envelopes, m.envelopes[i])
+		}
+	}
+	return envelopes, nil
+}
+
+func (m *mockReceivedEnvelopeRepository) DeleteBefore(before time.Time) (int64, error) {
+	m.deletedBefore = append(m.deletedBefore, before)
+	return 0, nil
+}
+
+func receivedMessage(dataMessage *domainSignal.DataMessage) *domainSignal.ReceivedMessage {
+	return &domainSignal.ReceivedMessage{
+		Account:  "+4999999",
+		Envelope: domainSignal.Envelope{Source: "+4912345", Timestamp: 1700000000000, DataMessage: dataMessage},
+	}
+}
+
+func TestRecord_StoresNonTextEnvelopes(t *testing.T) {
+	repository := &mockReceivedEnvelopeRepository{}
+	useCase := NewReceivedEnvelopeUseCase(repository, 24*time.Hour, &logger.Logger{Log: zap.NewNop()}).(*ReceivedEnvelopeUseCase)
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	useCase.now = func() time.Time { return now }
+
+	text := "hello"
+	require.NoError(t, useCase.Record(receivedMessage(&domainSignal.DataMessage{Message: &text})))
+	assert.Empty(t, repository.envelopes)
+
+	require.NoError(t, useCase.Record(receivedMessage(&domainSignal.DataMessage{Payment: &domainSignal.Payment{Note: "lunch", Receipt: "cmVjZWlwdA=="}})))
+	require.NoError(t, useCase.Record(receivedMessage(&domainSignal.DataMessage{Reaction: &domainSignal.Reaction{Emoji: "👍", TargetSentTimestamp: 5}})))
+	require.Len(t, repository.envelopes, 2)
+	assert.Equal(t, domainSignal.EnvelopeTypePayment, repository.envelopes[0].Type)
+	assert.Equal(t, "+4912345", repository.envelopes[0].Source)
+	assert.Equal(t, "lunch", repository.envelopes[0].Content.Payment.Note)
+	assert.Equal(t, "👍", repository.envelopes[1].Content.Reaction.Emoji)
+
+	// Expired envelopes are pruned once per interval
+	assert.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, repository.deletedBefore)
+}
+
+func TestGetEnvelopes(t *testing.T) {
+	repository := &mockReceivedEnvelopeRepository{envelopes: []domainSignal.ReceivedEnvelope{
+		{ID: 1, Type: domainSignal.EnvelopeTypeContact},
+		{ID: 2, Type: domainSignal.EnvelopeTypeReaction},
+	}}
+	useCase := NewReceivedEnvelopeUseCase(repository, 0, &logger.Logger{Log: zap.NewNop()})
+
+	envelopes, err := useCase.GetEnvelopes("", "contact", 0)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, 1, envelopes[0].ID)
+
+	_, err = useCase.GetEnvelopes("", "receipt", 10)
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+}
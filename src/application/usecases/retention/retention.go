@@ -0,0 +1,274 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// JobType is the type of the jobs purging old messages
+const JobType = "message_purge"
+
+// deleteBatchSize is the number of messages deleted at once from a table that isn't partitioned
+const deleteBatchSize = 1000
+
+// maxRetentionDays is the longest period a retention policy can set, ten years
+const maxRetentionDays = 3650
+
+// Config controls the purge jobs
+type Config struct {
+	MonthsAhead     int    // partitions kept ready after the current month
+	RetentionMonths int    // months of messages kept besides the current one, 0 keeps every message
+	Org             string // org whose retention policy is enforced, the JWT_ORG of the deployment
+}
+
+// Result reports what a purge job changed
+type Result struct {
+	Added   int      `json:"added"`   // partitions added for the coming months
+	Dropped []string `json:"dropped"` // expired partitions dropped, as table.partition
+	Kept    []string `json:"kept"`    // expired partitions kept because they hold messages still to be sent or acknowledged
+	Deleted int64    `json:"deleted"` // expired messages deleted from tables that aren't partitioned or by the body retention
+	// Redacted is the number of messages whose bodies were cleared by the body retention
+	Redacted int64 `json:"redacted"`
+	// AttachmentsExpired is the number of attachments set to expire now, the attachment pruner removes them
+	AttachmentsExpired int64 `json:"attachments_expired"`
+	// AuditLogsDeleted is the number of login events and control commands deleted
+	AuditLogsDeleted int64 `json:"audit_logs_deleted"`
+}
+
+// IRetentionUseCase defines the interface for purging the messages older than the retention
+type IRetentionUseCase interface {
+	// Start queues a purge job
+	Start(createdBy int) (*provider.Job, error)
+	// Run is the job handler purging the messages
+	Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error)
+	// GetPolicy returns the retention policy of the org
+	GetPolicy() (*provider.RetentionPolicy, error)
+	// SetPolicy replaces the retention policy of the org, the next purge enforces it
+	SetPolicy(policy provider.RetentionPolicy, updatedBy int) (*provider.RetentionPolicy, error)
+}
+
+// RetentionUseCase implements the IRetentionUseCase interface
+type RetentionUseCase struct {
+	jobQueue             jobs.Queue
+	partitionRepository  providerRepo.PartitionRepositoryInterface
+	policyRepository     providerRepo.RetentionPolicyRepositoryInterface
+	dataRepository       providerRepo.DataRetentionRepositoryInterface
+	attachmentRepository providerRepo.AttachmentRepositoryInterface
+	config               Config
+	Logger               *logger.Logger
+	now                  func() time.Time
+}
+
+// NewRetentionUseCase creates a new RetentionUseCase
+func NewRetentionUseCase(jobQueue jobs.Queue, partitionRepository providerRepo.PartitionRepositoryInterface,
+	policyRepository providerRepo.RetentionPolicyRepositoryInterface, dataRepository providerRepo.DataRetentionRepositoryInterface,
+	attachmentRepository providerRepo.AttachmentRepositoryInterface, config Config, loggerInstance *logger.Logger) IRetentionUseCase {
+	return &RetentionUseCase{
+		jobQueue:             jobQueue,
+		partitionRepository:  partitionRepository,
+		policyRepository:     policyRepository,
+		dataRepository:       dataRepository,
+		attachmentRepository: attachmentRepository,
+		config:               config,
+		Logger:               loggerInstance,
+		now:                  time.Now,
+	}
+}
+
+func (r *RetentionUseCase) Start(createdBy int) (*provider.Job, error) {
+	job, err := r.jobQueue.Enqueue(JobType, r.config, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	r.Logger.Info("Queued message purge", zap.Int("jobID", job.ID), zap.Int("retentionMonths", r.config.RetentionMonths))
+	return job, nil
+}
+
+// Run purges the message tables one after the other. Partitioned tables get the partitions of the coming months
+// and lose the partitions of expired months, partitions of message_transactions still holding messages to be sent
+// or acknowledged are kept. From tables that aren't partitioned the expired messages are deleted in batches.
+// Then the retention policy of the org is enforced. Running a purge again only removes what expired since.
+func (r *RetentionUseCase) Run(ctx context.Context, job *provider.Job, progress *jobs.Progress) (interface{}, error) {
+	now := r.now()
+	result := &Result{}
+	policy, err := r.policyRepository.Get(r.config.Org)
+	if err != nil {
+		return result, err
+	}
+	for i, table := range providerRepo.PartitionedTables {
+		partitions, err := r.partitionRepository.GetPartitions(table)
+		if err != nil {
+			return result, err
+		}
+		if len(partitions) > 0 {
+			err = r.purgePartitions(table, partitions, now, result)
+		} else {
+			err = r.purgeRows(ctx, table, now, result)
+		}
+		if err != nil {
+			return result, err
+		}
+		progress.Report((i+1)*50/len(providerRepo.PartitionedTables), result)
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	if err := r.enforcePolicy(ctx, policy, now, result, progress); err != nil {
+		return result, err
+	}
+
+	r.Logger.Info("Purged messages", zap.Int("jobID", job.ID), zap.Int("added", result.Added),
+		zap.Strings("dropped", result.Dropped), zap.Strings("kept", result.Kept), zap.Int64("deleted", result.Deleted),
+		zap.Int64("redacted", result.Redacted), zap.Int64("attachmentsExpired", result.AttachmentsExpired),
+		zap.Int64("auditLogsDeleted", result.AuditLogsDeleted))
+	return result, nil
+}
+
+// enforcePolicy expires the data of the org older than the periods of its policy. Message bodies are cleared or
+// their messages deleted, attachments are left to the attachment pruner, and audit logs are deleted.
+func (r *RetentionUseCase) enforcePolicy(ctx context.Context, policy *provider.RetentionPolicy, now time.Time, result *Result, progress *jobs.Progress) error {
+	if policy.BodyDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.BodyDays)
+		for _, table := range providerRepo.BodyTables {
+			expire := r.dataRepository.DeleteBefore
+			counter := &result.Deleted
+			if policy.RedactBodies {
+				expire, counter = r.dataRepository.RedactBodies, &result.Redacted
+			}
+			if err := r.inBatches(ctx, func() (int64, error) { return expire(table, cutoff, deleteBatchSize) }, counter); err != nil {
+				return err
+			}
+		}
+	}
+	progress.Report(70, result)
+
+	if policy.AttachmentDays > 0 {
+		expired, err := r.attachmentRepository.ExpireCreatedBefore(now.AddDate(0, 0, -policy.AttachmentDays), now)
+		if err != nil {
+			return err
+		}
+		result.AttachmentsExpired += expired
+	}
+	progress.Report(80, result)
+
+	if policy.AuditLogDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.AuditLogDays)
+		for _, table := range providerRepo.AuditLogTables {
+			if err := r.inBatches(ctx, func() (int64, error) { return r.dataRepository.DeleteBefore(table, cutoff, deleteBatchSize) }, &result.AuditLogsDeleted); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// inBatches repeats a batch until it changes less than a full batch, adding up the changed rows
+func (r *RetentionUseCase) inBatches(ctx context.Context, batch func() (int64, error), counter *int64) error {
+	for ctx.Err() == nil {
+		changed, err := batch()
+		if err != nil {
+			return err
+		}
+		*counter += changed
+		if changed < deleteBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *RetentionUseCase) GetPolicy() (*provider.RetentionPolicy, error) {
+	return r.policyRepository.Get(r.config.Org)
+}
+
+func (r *RetentionUseCase) SetPolicy(policy provider.RetentionPolicy, updatedBy int) (*provider.RetentionPolicy, error) {
+	for _, days := range []int{policy.BodyDays, policy.AttachmentDays, policy.AuditLogDays} {
+		if days < 0 || days > maxRetentionDays {
+			return nil, domainErrors.NewAppError(fmt.Errorf("retention periods must be from 0 to %d days", maxRetentionDays), domainErrors.ValidationError)
+		}
+	}
+	if policy.RedactBodies && policy.BodyDays == 0 {
+		return nil, domainErrors.NewAppError(errors.New("redact_bodies needs a body retention"), domainErrors.ValidationError)
+	}
+	policy.Org = r.config.Org
+	policy.UpdatedBy = updatedBy
+	saved, err := r.policyRepository.Save(&policy)
+	if err != nil {
+		return nil, err
+	}
+	r.Logger.Info("Changed retention policy", zap.String("org", saved.Org), zap.Int("bodyDays", saved.BodyDays),
+		zap.Int("attachmentDays", saved.AttachmentDays), zap.Int("auditLogDays", saved.AuditLogDays),
+		zap.Bool("redactBodies", saved.RedactBodies), zap.Int("updatedBy", updatedBy))
+	return saved, nil
+}
+
+// cutoff returns the time the expired messages were created before, zero when messages don't expire
+func (r *RetentionUseCase) cutoff(now time.Time) time.Time {
+	if r.config.RetentionMonths <= 0 {
+		return time.Time{}
+	}
+	return providerRepo.MonthStart(now).AddDate(0, -r.config.RetentionMonths, 0)
+}
+
+func (r *RetentionUseCase) purgePartitions(table string, partitions []provider.MessagePartition, now time.Time, result *Result) error {
+	added, err := r.partitionRepository.AddPartitions(table, now.AddDate(0, r.config.MonthsAhead, 0))
+	if err != nil {
+		return err
+	}
+	result.Added += added
+
+	cutoff := r.cutoff(now)
+	if cutoff.IsZero() {
+		return nil
+	}
+	for _, partition := range partitions {
+		if partition.Month.AddDate(0, 1, 0).After(cutoff) {
+			break
+		}
+		name := fmt.Sprintf("%s.%s", table, partition.Name)
+		if table == "message_transactions" {
+			active, err := r.partitionRepository.CountActive(partition.Name)
+			if err != nil {
+				return err
+			}
+			if active > 0 {
+				r.Logger.Warn("Keeping expired partition with active messages", zap.String("partition", name), zap.Int64("active", active))
+				result.Kept = append(result.Kept, name)
+				continue
+			}
+		}
+		if err := r.partitionRepository.DropPartition(table, partition.Name); err != nil {
+			return err
+		}
+		result.Dropped = append(result.Dropped, name)
+	}
+	return nil
+}
+
+func (r *RetentionUseCase) purgeRows(ctx context.Context, table string, now time.Time, result *Result) error {
+	cutoff := r.cutoff(now)
+	if cutoff.IsZero() {
+		return nil
+	}
+	for ctx.Err() == nil {
+		deleted, err := r.partitionRepository.DeleteBefore(table, cutoff, deleteBatchSize)
+		if err != nil {
+			return err
+		}
+		result.Deleted += deleted
+		if deleted < deleteBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJobQueue struct {
+	jobType string
+}
+
+func (m *mockJobQueue) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	m.jobType = jobType
+	return &provider.Job{ID: 1, Type: jobType, CreatedBy: createdBy}, nil
+}
+
+// mockPartitionRepository keeps the partition months of each table, tables without months aren't partitioned
+type mockPartitionRepository struct {
+	providerRepo.PartitionRepositoryInterface
+	months  map[string][]time.Time
+	active  map[string]int64
+	rows    int64 // rows to delete from the tables that aren't partitioned
+	through map[string]time.Time
+	dropped []string
+}
+
+func (m *mockPartitionRepository) GetPartitions(table string) ([]provider.MessagePartition, error) {
+	var partitions []provider.MessagePartition
+	for _, month := range m.months[table] {
+		partitions = append(partitions, provider.MessagePartition{Table: table, Name: "p" + month.Format("200601"), Month: month})
+	}
+	return partitions, nil
+}
+
+func (m *mockPartitionRepository) AddPartitions(table string, through time.Time) (int, error) {
+	m.through[table] = through
+	return 1, nil
+}
+
+func (m *mockPartitionRepository) CountActive(name string) (int64, error) {
+	return m.active[name], nil
+}
+
+func (m *mockPartitionRepository) DropPartition(table string, name string) error {
+	m.dropped = append(m.dropped, table+"."+name)
+	return nil
+}
+
+func (m *mockPartitionRepository) DeleteBefore(table string, before time.Time, limit int) (int64, error) {
+	deleted := min(m.rows, int64(limit))
+	m.rows -= deleted
+	return deleted, nil
+}
+
+type mockPolicyRepository struct {
+	policy provider.RetentionPolicy
+}
+
+func (m *mockPolicyRepository) Get(org string) (*provider.RetentionPolicy, error) {
+	policy := m.policy
+	policy.Org = org
+	return &policy, nil
+}
+
+func (m *mockPolicyRepository) Save(policy *provider.RetentionPolicy) (*provider.RetentionPolicy, error) {
+	m.policy = *policy
+	return policy, nil
+}
+
+// mockDataRepository has the given number of expired rows in every body and audit log table
+type mockDataRepository struct {
+	rows     map[string]int64
+	redacted map[string]time.Time
+	deleted  map[string]time.Time
+}
+
+func (m *mockDataRepository) RedactBodies(table string, before time.Time, limit int) (int64, error) {
+	m.redacted[table] = before
+	return m.take(table, limit), nil
+}
+
+func (m *mockDataRepository) DeleteBefore(table string, before time.Time, limit int) (int64, error) {
+	m.deleted[table] = before
+	return m.take(table, limit), nil
+}
+
+func (m *mockDataRepository) take(table string, limit int) int64 {
+	taken := min(m.rows[table], int64(limit))
+	m.rows[table] -= taken
+	return taken
+}
+
+type mockAttachmentRepository struct {
+	providerRepo.AttachmentRepositoryInterface
+	before time.Time
+}
+
+func (m *mockAttachmentRepository) ExpireCreatedBefore(before time.Time, expiresAt time.Time) (int64, error) {
+	m.before = before
+	return 4, nil
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.Local)
+}
+
+func newUseCase(t *testing.T, repository *mockPartitionRepository, config Config) *RetentionUseCase {
+	return newUseCaseWithPolicy(t, repository, &mockPolicyRepository{}, &mockDataRepository{}, &mockAttachmentRepository{}, config)
+}
+
+func newUseCaseWithPolicy(t *testing.T, repository *mockPartitionRepository, policies *mockPolicyRepository, data *mockDataRepository,
+	attachments *mockAttachmentRepository, config Config) *RetentionUseCase {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	useCase := NewRetentionUseCase(&mockJobQueue{}, repository, policies, data, attachments, config, loggerInstance).(*RetentionUseCase)
+	useCase.now = func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.Local) }
+	return useCase
+}
+
+func TestRun_DropsExpiredPartitions(t *testing.T) {
+	repository := &mockPartitionRepository{
+		months: map[string][]time.Time{
+			"message_transactions":        {month(2026, time.June), month(2026, time.July), month(2026, time.August), month(2026, time.September)},
+			"message_transaction_history": {month(2026, time.June), month(2026, time.July), month(2026, time.August)},
+		},
+		active:  map[string]int64{"p202606": 2},
+		through: map[string]time.Time{},
+	}
+	useCase := newUseCase(t, repository, Config{MonthsAhead: 3, RetentionMonths: 3})
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	// Messages of July and later are kept, the June partition of the transactions still has messages to send
+	assert.Equal(t, &Result{
+		Added:   2,
+		Dropped: []string{"message_transaction_history.p202606"},
+		Kept:    []string{"message_transactions.p202606"},
+	}, result)
+	assert.Equal(t, []string{"message_transaction_history.p202606"}, repository.dropped)
+	assert.Equal(t, time.Date(2027, time.January, 16, 12, 0, 0, 0, time.Local), repository.through["message_transactions"])
+}
+
+func TestRun_DeletesFromTablesNotPartitioned(t *testing.T) {
+	repository := &mockPartitionRepository{rows: 2*deleteBatchSize + 5, through: map[string]time.Time{}}
+	useCase := newUseCase(t, repository, Config{MonthsAhead: 3, RetentionMonths: 6})
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Deleted: 2*deleteBatchSize + 5}, result)
+	assert.Empty(t, repository.through)
+
+	// Without retention nothing is deleted
+	repository.rows = 10
+	result, err = newUseCase(t, repository, Config{MonthsAhead: 3}).Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{}, result)
+	assert.Equal(t, int64(10), repository.rows)
+}
+
+func TestStart_QueuesJob(t *testing.T) {
+	queue := &mockJobQueue{}
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	job, err := NewRetentionUseCase(queue, &mockPartitionRepository{}, &mockPolicyRepository{}, &mockDataRepository{}, &mockAttachmentRepository{},
+		Config{MonthsAhead: 3}, loggerInstance).Start(0)
+	require.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+	assert.Equal(t, JobType, queue.jobType)
+}
+
+func TestRun_EnforcesRetentionPolicy(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.Local)
+	newData := func() *mockDataRepository {
+		return &mockDataRepository{
+			rows:     map[string]int64{"message_transactions": deleteBatchSize + 3, "conversation_messages": 2, "login_events": 5},
+			redacted: map[string]time.Time{},
+			deleted:  map[string]time.Time{},
+		}
+	}
+	policies := &mockPolicyRepository{policy: provider.RetentionPolicy{BodyDays: 30, AttachmentDays: 90, AuditLogDays: 365, RedactBodies: true}}
+	data, attachments := newData(), &mockAttachmentRepository{}
+	useCase := newUseCaseWithPolicy(t, &mockPartitionRepository{through: map[string]time.Time{}}, policies, data, attachments, Config{Org: "acme"})
+
+	result, err := useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Redacted: deleteBatchSize + 5, AttachmentsExpired: 4, AuditLogsDeleted: 5}, result)
+	// Redacted bodies keep their messages
+	assert.Len(t, data.redacted, len(providerRepo.BodyTables))
+	assert.Equal(t, now.AddDate(0, 0, -30), data.redacted["message_edits"])
+	assert.Equal(t, now.AddDate(0, 0, -90), attachments.before)
+	assert.Equal(t, now.AddDate(0, 0, -365), data.deleted["control_commands"])
+	assert.NotContains(t, data.deleted, "message_transactions")
+
+	// Without redaction the messages are deleted with their bodies
+	policies.policy.RedactBodies = false
+	data = newData()
+	useCase = newUseCaseWithPolicy(t, &mockPartitionRepository{through: map[string]time.Time{}}, policies, data, attachments, Config{Org: "acme"})
+	result, err = useCase.Run(context.Background(), &provider.Job{ID: 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(deleteBatchSize+5), result.(*Result).Deleted)
+	assert.Empty(t, data.redacted)
+}
+
+func TestSetPolicy(t *testing.T) {
+	policies := &mockPolicyRepository{}
+	useCase := newUseCaseWithPolicy(t, &mockPartitionRepository{}, policies, &mockDataRepository{}, &mockAttachmentRepository{}, Config{Org: "acme"})
+
+	policy, err := useCase.SetPolicy(provider.RetentionPolicy{BodyDays: 90, AuditLogDays: 365, RedactBodies: true}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", policy.Org)
+	assert.Equal(t, 3, policies.policy.UpdatedBy)
+
+	_, err = useCase.SetPolicy(provider.RetentionPolicy{BodyDays: -1}, 3)
+	assert.Error(t, err)
+	_, err = useCase.SetPolicy(provider.RetentionPolicy{RedactBodies: true}, 3)
+	assert.Error(t, err)
+	assert.Equal(t, 90, policies.policy.BodyDays)
+}
@@ -0,0 +1,80 @@
+package shortlink
+
+import (
+	"errors"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+
+	"go.uber.org/zap"
+)
+
+// ClickHandler is told about a recorded click of a recipient on a short link
+type ClickHandler func(click *provider.LinkClick)
+
+// IShortLinkUseCase defines the interface for following the short links of tracked messages
+type IShortLinkUseCase interface {
+	// Follow records a click on a short link and returns the URL it redirects to
+	Follow(token string, userAgent string) (string, error)
+}
+
+// ShortLinkUseCase implements the IShortLinkUseCase interface
+type ShortLinkUseCase struct {
+	tracker             *shortlink.Tracker
+	shortLinkRepository providerRepo.ShortLinkRepositoryInterface
+	handler             ClickHandler
+	Logger              *logger.Logger
+}
+
+// NewShortLinkUseCase creates a new ShortLinkUseCase
+func NewShortLinkUseCase(
+	tracker *shortlink.Tracker,
+	shortLinkRepository providerRepo.ShortLinkRepositoryInterface,
+	handler ClickHandler,
+	loggerInstance *logger.Logger,
+) IShortLinkUseCase {
+	return &ShortLinkUseCase{
+		tracker:             tracker,
+		shortLinkRepository: shortLinkRepository,
+		handler:             handler,
+		Logger:              loggerInstance,
+	}
+}
+
+// Follow resolves a signed short link. Links that weren't signed by the API are not found, so tokens can't be
+// probed. A click that can't be recorded still redirects, the recipient shouldn't notice the tracking.
+func (s *ShortLinkUseCase) Follow(token string, userAgent string) (string, error) {
+	link, recipient, err := s.tracker.Resolve(token)
+	if err != nil {
+		if errors.Is(err, shortlink.ErrInvalidToken) {
+			return "", domainErrors.NewAppError(err, domainErrors.NotFound)
+		}
+		return "", err
+	}
+
+	click := &provider.LinkClick{
+		LinkID:    link.ID,
+		MessageID: link.MessageID,
+		UserID:    link.UserID,
+		Recipient: recipient,
+		UserAgent: truncate(userAgent, 512),
+		ClickedAt: time.Now(),
+	}
+	if err := s.shortLinkRepository.RecordClick(click); err != nil {
+		s.Logger.Error("Error recording link click", zap.Error(err), zap.Int("linkID", link.ID))
+	} else if s.handler != nil {
+		s.handler(click)
+	}
+	return link.URL, nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
@@ -0,0 +1,93 @@
+package shortlink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockShortLinkRepository struct {
+	link     provider.ShortLink
+	clicks   []provider.LinkClick
+	clickErr error
+}
+
+func (m *mockShortLinkRepository) CreateBatch(links []provider.ShortLink) (*[]provider.ShortLink, error) {
+	return &links, nil
+}
+
+func (m *mockShortLinkRepository) GetByID(id int) (*provider.ShortLink, error) {
+	if id != m.link.ID {
+		return &provider.ShortLink{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &m.link, nil
+}
+
+func (m *mockShortLinkRepository) GetByMessageID(messageID int) (*[]provider.ShortLink, error) {
+	return &[]provider.ShortLink{m.link}, nil
+}
+
+func (m *mockShortLinkRepository) RecordClick(click *provider.LinkClick) error {
+	m.clicks = append(m.clicks, *click)
+	return m.clickErr
+}
+
+func (m *mockShortLinkRepository) GetMessageClickStats(messageID int) (*provider.LinkClickStats, error) {
+	return &provider.LinkClickStats{}, nil
+}
+
+func (m *mockShortLinkRepository) CountUserClicks(userID int, from time.Time, to time.Time) (int, int, error) {
+	return 0, 0, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestFollow(t *testing.T) {
+	config := shortlink.Config{BaseURL: "https://go.example.com", Secret: "0123456789abcdef"}
+	repository := &mockShortLinkRepository{link: provider.ShortLink{
+		ID: 4, MessageID: 10, UserID: 2, URL: "https://example.com/offer", Recipients: `["+491111","+492222"]`,
+	}}
+	var handled []provider.LinkClick
+	handler := func(click *provider.LinkClick) { handled = append(handled, *click) }
+	useCase := NewShortLinkUseCase(shortlink.NewTracker(config, repository, nil, setupLogger(t)), repository, handler, setupLogger(t))
+
+	t.Run("Records the click of the recipient", func(t *testing.T) {
+		url, err := useCase.Follow(config.Sign(shortlink.Token{LinkID: 4, RecipientIndex: 1}), "curl/8.0")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/offer", url)
+		assert.Len(t, repository.clicks, 1)
+		assert.Equal(t, "+492222", repository.clicks[0].Recipient)
+		assert.Equal(t, 10, repository.clicks[0].MessageID)
+		assert.Equal(t, 2, repository.clicks[0].UserID)
+		assert.Equal(t, repository.clicks, handled)
+	})
+
+	t.Run("Redirects when the click can't be recorded", func(t *testing.T) {
+		repository.clickErr = errors.New("database is down")
+		defer func() { repository.clickErr = nil }()
+		url, err := useCase.Follow(config.Sign(shortlink.Token{LinkID: 4, RecipientIndex: 0}), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/offer", url)
+		assert.Len(t, handled, 1)
+	})
+
+	t.Run("Unsigned links are not found", func(t *testing.T) {
+		_, err := useCase.Follow("4-0.forged", "")
+		var appErr *domainErrors.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, domainErrors.NotFound, appErr.Type)
+	})
+}
@@ -16,8 +16,8 @@ type ISignalUseCase interface {
 	GetAccounts() ([]string, error)
 
 	// Messaging operations
-	Send(number string, message string, recipients []string, attachments []string, isGroup bool) (*domainSignal.SendResponse, error)
-	Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) (string, error)
+	Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error)
+	Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]domainSignal.ReceivedMessage, error)
 
 	// Group operations
 	CreateGroup(number string, name string, members []string, description string, editGroupPermission domainSignal.GroupPermission, addMembersPermission domainSignal.GroupPermission, groupLinkState domainSignal.GroupLinkState, expirationTime *int) (string, error)
@@ -77,17 +77,16 @@ func (s *SignalUseCase) GetAccounts() ([]string, error) {
 }
 
 // Send sends a message via Signal
-func (s *SignalUseCase) Send(number string, message string, recipients []string, attachments []string, isGroup bool) (*domainSignal.SendResponse, error) {
+func (s *SignalUseCase) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
 	s.Logger.Info("Sending message",
-		zap.String("from", number),
-		zap.Int("recipientsCount", len(recipients)),
-		zap.Int("attachmentsCount", len(attachments)),
-		zap.Bool("isGroup", isGroup))
-	return s.signalService.Send(number, message, recipients, attachments, isGroup)
+		zap.String("from", request.Number),
+		zap.Int("recipientsCount", len(request.Recipients)),
+		zap.Int("attachmentsCount", len(request.Base64Attachments)))
+	return s.signalService.Send(request)
 }
 
 // Receive receives messages via Signal
-func (s *SignalUseCase) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) (string, error) {
+func (s *SignalUseCase) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]domainSignal.ReceivedMessage, error) {
 	s.Logger.Info("Receiving messages", zap.String("number", number))
 	return s.signalService.Receive(number, timeout, ignoreAttachments, ignoreStories, maxMessages, sendReadReceipts)
 }
@@ -0,0 +1,238 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/statuspage"
+
+	"go.uber.org/zap"
+)
+
+const maxTitleLength = 200
+
+// healthRank orders the health levels and banner severities from best to worst
+var healthRank = map[string]int{
+	provider.BannerSeverityInfo: 0,
+	provider.HealthOperational:  0,
+	provider.HealthDegraded:     1,
+	provider.HealthOutage:       2,
+}
+
+// ProviderHealth is the reported health of a provider type
+type ProviderHealth struct {
+	Type   string
+	Status string
+}
+
+// Status is the public status page: the overall health, the health of every provider type in use and the
+// banners shown now
+type Status struct {
+	Status    string
+	Providers []ProviderHealth
+	Banners   []provider.StatusBanner
+	UpdatedAt time.Time
+}
+
+// IStatusUseCase defines the interface for the status page use cases
+type IStatusUseCase interface {
+	// GetStatus reports the status page, reused for the configured cache TTL
+	GetStatus() (*Status, error)
+	GetBanners() (*[]provider.StatusBanner, error)
+	CreateBanner(banner *provider.StatusBanner) (*provider.StatusBanner, error)
+	UpdateBanner(id int, bannerMap map[string]interface{}) (*provider.StatusBanner, error)
+	DeleteBanner(id int) error
+}
+
+// StatusUseCase implements the IStatusUseCase interface
+type StatusUseCase struct {
+	providerRepository                  providerRepo.ProviderRepositoryInterface
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	statusBannerRepository              providerRepo.StatusBannerRepositoryInterface
+	config                              statuspage.Config
+	Logger                              *logger.Logger
+	now                                 func() time.Time
+
+	mu       sync.Mutex
+	cached   *Status
+	cachedAt time.Time
+}
+
+// NewStatusUseCase creates a new StatusUseCase
+func NewStatusUseCase(providerRepository providerRepo.ProviderRepositoryInterface,
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	statusBannerRepository providerRepo.StatusBannerRepositoryInterface, config statuspage.Config, loggerInstance *logger.Logger) IStatusUseCase {
+	return &StatusUseCase{
+		providerRepository:                  providerRepository,
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		statusBannerRepository:              statusBannerRepository,
+		config:                              config,
+		Logger:                              loggerInstance,
+		now:                                 time.Now,
+	}
+}
+
+// GetStatus rates every provider type of the active providers by the messages processed within the window. A
+// banner of a provider type reports the type at least as bad as its severity, the overall status is the worst
+// of the provider types and the banners for all types.
+func (s *StatusUseCase) GetStatus() (*Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.cached != nil && now.Sub(s.cachedAt) < s.config.CacheTTL {
+		return s.cached, nil
+	}
+
+	providers, err := s.providerRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	outcomes, err := s.messageTransactionHistoryRepository.GetProviderTypeOutcomes(now.Add(-s.config.Window))
+	if err != nil {
+		return nil, err
+	}
+	banners, err := s.statusBannerRepository.GetActive(now)
+	if err != nil {
+		return nil, err
+	}
+
+	health := make(map[string]string)
+	for _, p := range *providers {
+		// The sandbox sends nowhere, it isn't a delivery channel
+		if p.Status && p.Type != string(alert.TypeSandbox) {
+			health[p.Type] = provider.HealthOperational
+		}
+	}
+	for _, outcome := range outcomes {
+		if _, ok := health[outcome.Type]; ok {
+			health[outcome.Type] = s.config.Health(outcome)
+		}
+	}
+
+	overall := provider.HealthOperational
+	for _, banner := range *banners {
+		if banner.ProviderType == "" {
+			overall = worse(overall, banner.Severity)
+		} else if current, ok := health[banner.ProviderType]; ok {
+			health[banner.ProviderType] = worse(current, banner.Severity)
+		}
+	}
+
+	status := &Status{Providers: make([]ProviderHealth, 0, len(health)), Banners: *banners, UpdatedAt: now}
+	for providerType, providerHealth := range health {
+		status.Providers = append(status.Providers, ProviderHealth{Type: providerType, Status: providerHealth})
+		overall = worse(overall, providerHealth)
+	}
+	sort.Slice(status.Providers, func(i, j int) bool { return status.Providers[i].Type < status.Providers[j].Type })
+	status.Status = overall
+
+	s.cached, s.cachedAt = status, now
+	return status, nil
+}
+
+func (s *StatusUseCase) GetBanners() (*[]provider.StatusBanner, error) {
+	return s.statusBannerRepository.GetAll()
+}
+
+// CreateBanner publishes a banner, shown from now unless it starts later
+func (s *StatusUseCase) CreateBanner(banner *provider.StatusBanner) (*provider.StatusBanner, error) {
+	if banner.StartsAt.IsZero() {
+		banner.StartsAt = s.now()
+	}
+	if err := validateBanner(banner); err != nil {
+		return nil, err
+	}
+	created, err := s.statusBannerRepository.Create(banner)
+	if err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Published status banner", zap.Int("id", created.ID), zap.Int("createdBy", created.CreatedBy),
+		zap.String("severity", created.Severity), zap.String("providerType", created.ProviderType))
+	s.invalidate()
+	return created, nil
+}
+
+// UpdateBanner changes the fields of a banner in the map, e.g. ends it by setting endsAt
+func (s *StatusUseCase) UpdateBanner(id int, bannerMap map[string]interface{}) (*provider.StatusBanner, error) {
+	banner, err := s.statusBannerRepository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	updated := *banner
+	if value, ok := bannerMap["title"].(string); ok {
+		updated.Title = value
+	}
+	if value, ok := bannerMap["severity"].(string); ok {
+		updated.Severity = value
+	}
+	if value, ok := bannerMap["providerType"].(string); ok {
+		updated.ProviderType = value
+	}
+	if value, ok := bannerMap["startsAt"].(time.Time); ok {
+		updated.StartsAt = value
+	}
+	if value, ok := bannerMap["endsAt"].(time.Time); ok {
+		updated.EndsAt = &value
+	}
+	if err := validateBanner(&updated); err != nil {
+		return nil, err
+	}
+
+	result, err := s.statusBannerRepository.Update(id, bannerMap)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return result, nil
+}
+
+func (s *StatusUseCase) DeleteBanner(id int) error {
+	if err := s.statusBannerRepository.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// invalidate drops the cached status, so changed banners are shown at once by this instance
+func (s *StatusUseCase) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = nil
+}
+
+func validateBanner(banner *provider.StatusBanner) error {
+	if banner.Title == "" || len(banner.Title) > maxTitleLength {
+		return domainErrors.NewAppError(fmt.Errorf("title is required and must be at most %d characters", maxTitleLength), domainErrors.ValidationError)
+	}
+	if _, ok := healthRank[banner.Severity]; !ok || banner.Severity == provider.HealthOperational {
+		return domainErrors.NewAppError(fmt.Errorf("severity must be %s, %s or %s",
+			provider.BannerSeverityInfo, provider.HealthDegraded, provider.HealthOutage), domainErrors.ValidationError)
+	}
+	if banner.ProviderType != "" {
+		if _, ok := providerconfig.Lookup(banner.ProviderType); !ok {
+			return domainErrors.NewAppError(fmt.Errorf("unknown provider type %q", banner.ProviderType), domainErrors.ValidationError)
+		}
+	}
+	if banner.EndsAt != nil && !banner.EndsAt.After(banner.StartsAt) {
+		return domainErrors.NewAppError(errors.New("ends_at must be after starts_at"), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// worse returns the worse of two health levels or severities
+func worse(a string, b string) string {
+	if healthRank[b] > healthRank[a] {
+		return b
+	}
+	return a
+}
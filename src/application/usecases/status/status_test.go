@@ -0,0 +1,145 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/statuspage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+	providers []provider.Provider
+}
+
+func (m *mockProviderRepository) GetAll() (*[]provider.Provider, error) {
+	return &m.providers, nil
+}
+
+type mockHistoryRepository struct {
+	providerRepo.MessageTransactionHistoryRepositoryInterface
+	outcomes []provider.ProviderTypeOutcomes
+	calls    int
+}
+
+func (m *mockHistoryRepository) GetProviderTypeOutcomes(from time.Time) ([]provider.ProviderTypeOutcomes, error) {
+	m.calls++
+	return m.outcomes, nil
+}
+
+type mockBannerRepository struct {
+	providerRepo.StatusBannerRepositoryInterface
+	banners []provider.StatusBanner
+}
+
+func (m *mockBannerRepository) GetActive(now time.Time) (*[]provider.StatusBanner, error) {
+	return &m.banners, nil
+}
+
+func (m *mockBannerRepository) Create(banner *provider.StatusBanner) (*provider.StatusBanner, error) {
+	banner.ID = len(m.banners) + 1
+	m.banners = append(m.banners, *banner)
+	return banner, nil
+}
+
+func (m *mockBannerRepository) GetByID(id int) (*provider.StatusBanner, error) {
+	banner := m.banners[id-1]
+	return &banner, nil
+}
+
+func setupStatusUseCase(history *mockHistoryRepository, banners *mockBannerRepository) *StatusUseCase {
+	providers := &mockProviderRepository{providers: []provider.Provider{
+		{ID: 1, Type: "signal", Status: true},
+		{ID: 2, Type: "sms", Status: true},
+		{ID: 3, Type: "email", Status: false},
+		{ID: 4, Type: "sandbox", Status: true},
+	}}
+	config := statuspage.Config{Window: 15 * time.Minute, CacheTTL: time.Minute, MinMessages: 10, DegradedFailurePercent: 10, OutageFailurePercent: 50}
+	return NewStatusUseCase(providers, history, banners, config, &logger.Logger{Log: zap.NewNop()}).(*StatusUseCase)
+}
+
+func TestGetStatus_RatesActiveProviderTypesAndAppliesBanners(t *testing.T) {
+	history := &mockHistoryRepository{outcomes: []provider.ProviderTypeOutcomes{
+		{Type: "signal", Sent: 100, Failed: 2},
+		{Type: "sms", Sent: 80, Failed: 20},
+		{Type: "email", Sent: 0, Failed: 50},
+	}}
+	banners := &mockBannerRepository{}
+	useCase := setupStatusUseCase(history, banners)
+
+	status, err := useCase.GetStatus()
+	require.NoError(t, err)
+	// Inactive providers and the sandbox aren't reported
+	assert.Equal(t, []ProviderHealth{{Type: "signal", Status: provider.HealthOperational}, {Type: "sms", Status: provider.HealthDegraded}}, status.Providers)
+	assert.Equal(t, provider.HealthDegraded, status.Status)
+
+	// A banner of a provider type overrides a better health, an info banner for all types changes nothing
+	banners.banners = []provider.StatusBanner{
+		{ID: 1, Title: "Signal outage", Severity: provider.HealthOutage, ProviderType: "signal"},
+		{ID: 2, Title: "Maintenance tonight", Severity: provider.BannerSeverityInfo},
+	}
+	useCase.invalidate()
+	status, err = useCase.GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, provider.HealthOutage, status.Providers[0].Status)
+	assert.Equal(t, provider.HealthOutage, status.Status)
+	assert.Len(t, status.Banners, 2)
+}
+
+func TestGetStatus_IsCached(t *testing.T) {
+	history := &mockHistoryRepository{}
+	useCase := setupStatusUseCase(history, &mockBannerRepository{})
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	useCase.now = func() time.Time { return now }
+
+	_, err := useCase.GetStatus()
+	require.NoError(t, err)
+	_, err = useCase.GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 1, history.calls)
+
+	now = now.Add(time.Minute)
+	_, err = useCase.GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 2, history.calls)
+
+	// A published banner is shown at once
+	_, err = useCase.CreateBanner(&provider.StatusBanner{Title: "SMS delays", Severity: provider.HealthDegraded, ProviderType: "sms"})
+	require.NoError(t, err)
+	status, err := useCase.GetStatus()
+	require.NoError(t, err)
+	assert.Equal(t, 3, history.calls)
+	assert.Equal(t, provider.HealthDegraded, status.Status)
+}
+
+func TestCreateBanner_Validates(t *testing.T) {
+	useCase := setupStatusUseCase(&mockHistoryRepository{}, &mockBannerRepository{})
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	invalid := []provider.StatusBanner{
+		{Severity: provider.HealthOutage},
+		{Title: "Outage", Severity: provider.HealthOperational},
+		{Title: "Outage", Severity: provider.HealthOutage, ProviderType: "pager"},
+		{Title: "Outage", Severity: provider.HealthOutage, StartsAt: now, EndsAt: &earlier},
+	}
+	for _, banner := range invalid {
+		_, err := useCase.CreateBanner(&banner)
+		assert.Error(t, err, banner)
+	}
+
+	created, err := useCase.CreateBanner(&provider.StatusBanner{Title: "Outage", Severity: provider.HealthOutage, ProviderType: "signal"})
+	require.NoError(t, err)
+	assert.False(t, created.StartsAt.IsZero())
+
+	// An update is validated with the stored fields
+	_, err = useCase.UpdateBanner(created.ID, map[string]interface{}{"endsAt": created.StartsAt.Add(-time.Minute)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package syncfeed
+
+import (
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// SyncResult holds the changes of a user after a cursor, each kind in the order it happened
+type SyncResult struct {
+	StatusChanges   []provider.MessageStatusChange
+	InboundMessages []provider.InboundMessage
+	ProviderConfigs []provider.UserProvider
+	// ProviderConfigIDs are the configs the user has now, clients drop the configs missing from it
+	ProviderConfigIDs []int
+	// Cursor is the position after the returned changes
+	Cursor provider.SyncCursor
+	// HasMore is set when a kind of change had more than the limit, the client syncs again with Cursor at once
+	HasMore bool
+}
+
+// ISyncUseCase defines the interface for the sync use cases of mobile and offline clients
+type ISyncUseCase interface {
+	// Sync returns the changes of the user after the cursor, at most limit of each kind. The zero cursor returns
+	// every change from the start.
+	Sync(userID int, cursor provider.SyncCursor, limit int) (*SyncResult, error)
+}
+
+// SyncUseCase implements the ISyncUseCase interface
+type SyncUseCase struct {
+	syncRepository providerRepo.SyncRepositoryInterface
+	Logger         *logger.Logger
+}
+
+// NewSyncUseCase creates a new SyncUseCase
+func NewSyncUseCase(syncRepository providerRepo.SyncRepositoryInterface, loggerInstance *logger.Logger) ISyncUseCase {
+	return &SyncUseCase{syncRepository: syncRepository, Logger: loggerInstance}
+}
+
+func (u *SyncUseCase) Sync(userID int, cursor provider.SyncCursor, limit int) (*SyncResult, error) {
+	result := &SyncResult{Cursor: cursor}
+
+	// One change more than the limit is read to tell whether another sync has to follow
+	statusChanges, err := u.syncRepository.GetStatusChanges(userID, cursor.StatusEventID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	result.StatusChanges, result.HasMore = cut(statusChanges, limit, result.HasMore)
+	if n := len(result.StatusChanges); n > 0 {
+		result.Cursor.StatusEventID = result.StatusChanges[n-1].EventID
+	}
+
+	inboundMessages, err := u.syncRepository.GetInboundMessages(userID, cursor.InboundMessageID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	result.InboundMessages, result.HasMore = cut(inboundMessages, limit, result.HasMore)
+	if n := len(result.InboundMessages); n > 0 {
+		result.Cursor.InboundMessageID = result.InboundMessages[n-1].ID
+	}
+
+	configs, err := u.syncRepository.GetChangedUserProviders(userID, cursor.ConfigUpdatedAt, cursor.ConfigID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	result.ProviderConfigs, result.HasMore = cut(*configs, limit, result.HasMore)
+	if n := len(result.ProviderConfigs); n > 0 {
+		last := result.ProviderConfigs[n-1]
+		result.Cursor.ConfigUpdatedAt, result.Cursor.ConfigID = last.UpdatedAt, last.ID
+	}
+
+	result.ProviderConfigIDs, err = u.syncRepository.GetUserProviderIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cut drops the changes after the limit and reports whether there were any, or more of an earlier kind
+func cut[T any](changes []T, limit int, hasMore bool) ([]T, bool) {
+	if len(changes) > limit {
+		return changes[:limit], true
+	}
+	return changes, hasMore
+}
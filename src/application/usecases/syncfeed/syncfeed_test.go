@@ -0,0 +1,95 @@
+package syncfeed
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockSyncRepository struct {
+	statusChanges   []provider.MessageStatusChange
+	inboundMessages []provider.InboundMessage
+	configs         []provider.UserProvider
+}
+
+func (m *mockSyncRepository) GetStatusChanges(userID int, afterEventID int, limit int) ([]provider.MessageStatusChange, error) {
+	var changes []provider.MessageStatusChange
+	for _, change := range m.statusChanges {
+		if change.EventID > afterEventID && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *mockSyncRepository) GetInboundMessages(userID int, afterMessageID int, limit int) ([]provider.InboundMessage, error) {
+	var messages []provider.InboundMessage
+	for _, message := range m.inboundMessages {
+		if message.ID > afterMessageID && len(messages) < limit {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (m *mockSyncRepository) GetChangedUserProviders(userID int, afterUpdatedAt time.Time, afterID int, limit int) (*[]provider.UserProvider, error) {
+	configs := []provider.UserProvider{}
+	for _, config := range m.configs {
+		after := config.UpdatedAt.After(afterUpdatedAt) || (config.UpdatedAt.Equal(afterUpdatedAt) && config.ID > afterID)
+		if after && len(configs) < limit {
+			configs = append(configs, config)
+		}
+	}
+	return &configs, nil
+}
+
+func (m *mockSyncRepository) GetUserProviderIDs(userID int) ([]int, error) {
+	ids := make([]int, len(m.configs))
+	for i, config := range m.configs {
+		ids[i] = config.ID
+	}
+	return ids, nil
+}
+
+func TestSync_PagesEachKindOfChange(t *testing.T) {
+	updatedAt := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	repository := &mockSyncRepository{
+		statusChanges: []provider.MessageStatusChange{
+			{EventID: 4, MessageID: 1, Status: provider.MessageStatusPending},
+			{EventID: 9, MessageID: 1, Status: provider.MessageStatusSuccess},
+			{EventID: 12, MessageID: 2, Status: provider.MessageStatusPending},
+		},
+		inboundMessages: []provider.InboundMessage{{ID: 3, Body: "hi"}},
+		configs:         []provider.UserProvider{{ID: 5, UpdatedAt: updatedAt}, {ID: 2, UpdatedAt: updatedAt.Add(time.Minute)}},
+	}
+	useCase := NewSyncUseCase(repository, &logger.Logger{Log: zap.NewNop()})
+
+	result, err := useCase.Sync(7, provider.SyncCursor{}, 2)
+	require.NoError(t, err)
+	assert.True(t, result.HasMore)
+	assert.Len(t, result.StatusChanges, 2)
+	assert.Len(t, result.InboundMessages, 1)
+	assert.Len(t, result.ProviderConfigs, 2)
+	assert.Equal(t, []int{5, 2}, result.ProviderConfigIDs)
+	assert.Equal(t, provider.SyncCursor{StatusEventID: 9, InboundMessageID: 3, ConfigUpdatedAt: updatedAt.Add(time.Minute), ConfigID: 2}, result.Cursor)
+
+	// The next sync returns the rest, then nothing until something changes
+	result, err = useCase.Sync(7, result.Cursor, 2)
+	require.NoError(t, err)
+	assert.False(t, result.HasMore)
+	assert.Equal(t, []provider.MessageStatusChange{{EventID: 12, MessageID: 2, Status: provider.MessageStatusPending}}, result.StatusChanges)
+	assert.Empty(t, result.InboundMessages)
+	assert.Empty(t, result.ProviderConfigs)
+
+	cursor := result.Cursor
+	result, err = useCase.Sync(7, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, result.Cursor)
+	assert.False(t, result.HasMore)
+}
@@ -0,0 +1,236 @@
+package verification
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+
+	"go.uber.org/zap"
+)
+
+// fallbackBatchSize is the number of timed out SMS attempts retried with a voice call per pass
+const fallbackBatchSize = 100
+
+// Client is the subset of the signal client used to register numbers
+type Client interface {
+	RegisterNumber(number string, useVoice bool, captcha string) error
+	VerifyRegisteredNumber(number string, token string, pin string) error
+}
+
+// Config controls when a number is called instead and how often codes may be requested for it
+type Config struct {
+	// SMSTimeout is the time a code sent by SMS has to be verified in before the number is called instead
+	SMSTimeout time.Duration
+	// Cooldown is the wait after the first attempt of a number, it doubles with every further attempt
+	Cooldown time.Duration
+	// MaxAttempts is the largest number of attempts of a number within Window
+	MaxAttempts int
+	Window      time.Duration
+}
+
+// Verification is the state of the verification of a number, from its attempts within the window
+type Verification struct {
+	Number string
+	// Status is the status of the latest attempt, empty without attempts
+	Status string
+	// Channel is the channel of the latest attempt
+	Channel string
+	// VoiceFallbackAt is when the number is called if the code sent by SMS isn't verified, nil otherwise
+	VoiceFallbackAt *time.Time
+	// NextAttemptAt is when a code may be requested again, nil when it may be requested now
+	NextAttemptAt *time.Time
+	// Attempts are the attempts within the window, newest first
+	Attempts []domainSignal.VerificationAttempt
+}
+
+// CooldownError is returned by Start when a code was requested for the number too recently or too often
+type CooldownError struct {
+	Number     string
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("verification of %s is cooling down, retry after %s", e.Number, e.RetryAfter.Round(time.Second))
+}
+
+// IVerificationUseCase defines the interface for verification use cases
+type IVerificationUseCase interface {
+	// Start requests a code for a number by SMS, or by a voice call when Signal refuses the SMS
+	Start(number string, captcha string) (*Verification, error)
+	// Verify registers a number with the code it was sent
+	Verify(number string, code string, pin string) (*Verification, error)
+	Get(number string) (*Verification, error)
+	// FallBackToVoice calls the numbers whose code sent by SMS wasn't verified in time
+	FallBackToVoice(now time.Time) error
+}
+
+// VerificationUseCase implements the IVerificationUseCase interface
+type VerificationUseCase struct {
+	client                        Client
+	verificationAttemptRepository signalRepo.VerificationAttemptRepositoryInterface
+	config                        Config
+	Logger                        *logger.Logger
+	now                           func() time.Time
+}
+
+// NewVerificationUseCase creates a new VerificationUseCase
+func NewVerificationUseCase(client Client, verificationAttemptRepository signalRepo.VerificationAttemptRepositoryInterface, config Config, loggerInstance *logger.Logger) IVerificationUseCase {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	return &VerificationUseCase{
+		client:                        client,
+		verificationAttemptRepository: verificationAttemptRepository,
+		config:                        config,
+		Logger:                        loggerInstance,
+		now:                           time.Now,
+	}
+}
+
+func (v *VerificationUseCase) Start(number string, captcha string) (*Verification, error) {
+	if number == "" {
+		return nil, domainErrors.NewAppError(errors.New("please provide a number"), domainErrors.ValidationError)
+	}
+	now := v.now()
+	attempts, err := v.verificationAttemptRepository.GetSince(number, now.Add(-v.config.Window))
+	if err != nil {
+		return nil, err
+	}
+	if next := v.nextAttemptAt(*attempts); next != nil {
+		return nil, &CooldownError{Number: number, RetryAfter: next.Sub(now)}
+	}
+	// A new attempt replaces the codes requested before
+	if _, err := v.verificationAttemptRepository.SetStatus(number, domainSignal.VerificationAttemptExpired); err != nil {
+		return nil, err
+	}
+
+	err = v.request(number, domainSignal.VerificationChannelSMS, captcha)
+	if err != nil && fallsBackToVoice(err) && len(*attempts)+1 < v.config.MaxAttempts {
+		v.Logger.Info("SMS verification refused, calling the number", zap.String("number", number), zap.Error(err))
+		err = v.request(number, domainSignal.VerificationChannelVoice, captcha)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.Get(number)
+}
+
+// request requests a code over a channel and records the attempt, a refused request is recorded as failed
+func (v *VerificationUseCase) request(number string, channel string, captcha string) error {
+	attempt := &domainSignal.VerificationAttempt{Number: number, Channel: channel, Status: domainSignal.VerificationAttemptSent}
+	requestErr := v.client.RegisterNumber(number, channel == domainSignal.VerificationChannelVoice, captcha)
+	if requestErr != nil {
+		attempt.Status = domainSignal.VerificationAttemptFailed
+		attempt.Error = requestErr.Error()
+	}
+	if _, err := v.verificationAttemptRepository.Create(attempt); err != nil {
+		return err
+	}
+	if requestErr != nil {
+		return domainErrors.NewAppError(fmt.Errorf("signal refused the %s verification: %w", channel, requestErr), domainErrors.ValidationError)
+	}
+	v.Logger.Info("Requested verification code", zap.String("number", number), zap.String("channel", channel))
+	return nil
+}
+
+func (v *VerificationUseCase) Verify(number string, code string, pin string) (*Verification, error) {
+	if number == "" || code == "" {
+		return nil, domainErrors.NewAppError(errors.New("please provide a number and its verification code"), domainErrors.ValidationError)
+	}
+	if err := v.client.VerifyRegisteredNumber(number, code, pin); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	if _, err := v.verificationAttemptRepository.SetStatus(number, domainSignal.VerificationAttemptVerified); err != nil {
+		return nil, err
+	}
+	v.Logger.Info("Verified number", zap.String("number", number))
+	return v.Get(number)
+}
+
+func (v *VerificationUseCase) Get(number string) (*Verification, error) {
+	attempts, err := v.verificationAttemptRepository.GetSince(number, v.now().Add(-v.config.Window))
+	if err != nil {
+		return nil, err
+	}
+	verification := &Verification{Number: number, Attempts: *attempts, NextAttemptAt: v.nextAttemptAt(*attempts)}
+	if len(*attempts) > 0 {
+		latest := (*attempts)[0]
+		verification.Status, verification.Channel = latest.Status, latest.Channel
+		if latest.Status == domainSignal.VerificationAttemptSent && latest.Channel == domainSignal.VerificationChannelSMS {
+			fallbackAt := latest.CreatedAt.Add(v.config.SMSTimeout)
+			verification.VoiceFallbackAt = &fallbackAt
+		}
+	}
+	return verification, nil
+}
+
+// FallBackToVoice expires the SMS attempts that weren't verified within the timeout and calls their numbers,
+// unless a number used up its attempts. The cool-down doesn't apply, the timeout is the wait before the call.
+func (v *VerificationUseCase) FallBackToVoice(now time.Time) error {
+	for {
+		pending, err := v.verificationAttemptRepository.GetPending(domainSignal.VerificationChannelSMS, now.Add(-v.config.SMSTimeout), fallbackBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, attempt := range *pending {
+			if _, err := v.verificationAttemptRepository.SetStatus(attempt.Number, domainSignal.VerificationAttemptExpired); err != nil {
+				return err
+			}
+			attempts, err := v.verificationAttemptRepository.GetSince(attempt.Number, now.Add(-v.config.Window))
+			if err != nil {
+				return err
+			}
+			if len(*attempts) >= v.config.MaxAttempts {
+				v.Logger.Warn("SMS verification timed out, the number used up its attempts", zap.String("number", attempt.Number))
+				continue
+			}
+			v.Logger.Info("SMS verification timed out, calling the number", zap.String("number", attempt.Number))
+			if err := v.request(attempt.Number, domainSignal.VerificationChannelVoice, ""); err != nil {
+				v.Logger.Warn("Error requesting voice verification", zap.String("number", attempt.Number), zap.Error(err))
+			}
+		}
+		if len(*pending) < fallbackBatchSize {
+			return nil
+		}
+	}
+}
+
+// nextAttemptAt returns when the next attempt of a number with the attempts of the window may start, nil when it
+// may start now. The cool-down after an attempt doubles with every attempt before it in the window.
+func (v *VerificationUseCase) nextAttemptAt(attempts []domainSignal.VerificationAttempt) *time.Time {
+	if len(attempts) == 0 {
+		return nil
+	}
+	var next time.Time
+	if len(attempts) >= v.config.MaxAttempts {
+		// The attempts are newest first, the oldest of the last MaxAttempts has to leave the window
+		next = attempts[v.config.MaxAttempts-1].CreatedAt.Add(v.config.Window)
+	} else {
+		cooldown := v.config.Cooldown << (len(attempts) - 1)
+		if cooldown <= 0 || cooldown > v.config.Window {
+			cooldown = v.config.Window
+		}
+		next = attempts[0].CreatedAt.Add(cooldown)
+	}
+	if !next.After(v.now()) {
+		return nil
+	}
+	return &next
+}
+
+// fallsBackToVoice reports whether a refused SMS is requested by a voice call instead. Captcha challenges and
+// rate limits refuse the call as well, calling would only use up an attempt.
+func fallsBackToVoice(err error) bool {
+	var rateLimitErr *domainSignal.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return !strings.Contains(message, "captcha") && !strings.Contains(message, "rate limit")
+}
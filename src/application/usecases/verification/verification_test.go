@@ -0,0 +1,187 @@
+package verification
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockClient struct {
+	// refused are the errors of the next requests per channel
+	refused  map[bool]error
+	requests []bool
+	verified []string
+}
+
+func (m *mockClient) RegisterNumber(number string, useVoice bool, captcha string) error {
+	m.requests = append(m.requests, useVoice)
+	return m.refused[useVoice]
+}
+
+func (m *mockClient) VerifyRegisteredNumber(number string, token string, pin string) error {
+	if token != "123456" {
+		return errors.New("verification failed: invalid code")
+	}
+	m.verified = append(m.verified, number)
+	return nil
+}
+
+type mockVerificationAttemptRepository struct {
+	attempts []domainSignal.VerificationAttempt
+	now      *time.Time
+}
+
+func (m *mockVerificationAttemptRepository) Create(attempt *domainSignal.VerificationAttempt) (*domainSignal.VerificationAttempt, error) {
+	attempt.ID = len(m.attempts) + 1
+	attempt.CreatedAt = *m.now
+	m.attempts = append(m.attempts, *attempt)
+	return attempt, nil
+}
+
+func (m *mockVerificationAttemptRepository) GetSince(number string, since time.Time) (*[]domainSignal.VerificationAttempt, error) {
+	attempts := []domainSignal.VerificationAttempt{}
+	for i := len(m.attempts) - 1; i >= 0; i-- {
+		if m.attempts[i].Number == number && !m.attempts[i].CreatedAt.Before(since) {
+			attempts = append(attempts, m.attempts[i])
+		}
+	}
+	return &attempts, nil
+}
+
+func (m *mockVerificationAttemptRepository) GetPending(channel string, before time.Time, limit int) (*[]domainSignal.VerificationAttempt, error) {
+	attempts := []domainSignal.VerificationAttempt{}
+	for _, attempt := range m.attempts {
+		if attempt.Channel == channel && attempt.Status == domainSignal.VerificationAttemptSent && attempt.CreatedAt.Before(before) && len(attempts) < limit {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return &attempts, nil
+}
+
+func (m *mockVerificationAttemptRepository) SetStatus(number string, status string) (int, error) {
+	changed := 0
+	for i := range m.attempts {
+		if m.attempts[i].Number == number && m.attempts[i].Status == domainSignal.VerificationAttemptSent {
+			m.attempts[i].Status = status
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+const testNumber = "+491701234567"
+
+func newUseCase(client *mockClient) (*VerificationUseCase, *mockVerificationAttemptRepository, *time.Time) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	repository := &mockVerificationAttemptRepository{now: &now}
+	useCase := NewVerificationUseCase(client, repository, Config{
+		SMSTimeout:  2 * time.Minute,
+		Cooldown:    time.Minute,
+		MaxAttempts: 3,
+		Window:      24 * time.Hour,
+	}, &logger.Logger{Log: zap.NewNop()}).(*VerificationUseCase)
+	useCase.now = func() time.Time { return now }
+	return useCase, repository, &now
+}
+
+func TestStart_FallsBackToVoiceAfterTimeout(t *testing.T) {
+	client := &mockClient{}
+	useCase, repository, now := newUseCase(client)
+
+	verification, err := useCase.Start(testNumber, "")
+	require.NoError(t, err)
+	assert.Equal(t, domainSignal.VerificationChannelSMS, verification.Channel)
+	assert.Equal(t, domainSignal.VerificationAttemptSent, verification.Status)
+	assert.Equal(t, now.Add(2*time.Minute), *verification.VoiceFallbackAt)
+
+	// Not timed out yet
+	require.NoError(t, useCase.FallBackToVoice(now.Add(time.Minute)))
+	assert.Equal(t, []bool{false}, client.requests)
+
+	*now = now.Add(3 * time.Minute)
+	require.NoError(t, useCase.FallBackToVoice(*now))
+	assert.Equal(t, []bool{false, true}, client.requests)
+	assert.Equal(t, domainSignal.VerificationAttemptExpired, repository.attempts[0].Status)
+	assert.Equal(t, domainSignal.VerificationChannelVoice, repository.attempts[1].Channel)
+
+	// The voice call isn't repeated
+	require.NoError(t, useCase.FallBackToVoice(now.Add(time.Hour)))
+	assert.Len(t, client.requests, 2)
+
+	verification, err = useCase.Verify(testNumber, "123456", "")
+	require.NoError(t, err)
+	assert.Equal(t, domainSignal.VerificationAttemptVerified, verification.Status)
+	assert.Equal(t, domainSignal.VerificationChannelVoice, verification.Channel)
+	assert.Nil(t, verification.VoiceFallbackAt)
+}
+
+func TestStart_FallsBackToVoiceWhenSMSRefused(t *testing.T) {
+	client := &mockClient{refused: map[bool]error{false: errors.New("SMS verification not available")}}
+	useCase, repository, now := newUseCase(client)
+
+	verification, err := useCase.Start(testNumber, "")
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, client.requests)
+	assert.Equal(t, domainSignal.VerificationChannelVoice, verification.Channel)
+	assert.Equal(t, domainSignal.VerificationAttemptFailed, repository.attempts[0].Status)
+	assert.Equal(t, "SMS verification not available", repository.attempts[0].Error)
+
+	// Captcha challenges refuse the call as well
+	client.refused[false] = errors.New("Captcha required for verification")
+	client.requests = nil
+	*now = now.Add(time.Hour)
+	_, err = useCase.Start(testNumber, "")
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+	assert.Equal(t, []bool{false}, client.requests)
+}
+
+func TestStart_CoolsDown(t *testing.T) {
+	client := &mockClient{}
+	useCase, _, now := newUseCase(client)
+
+	_, err := useCase.Start(testNumber, "")
+	require.NoError(t, err)
+
+	_, err = useCase.Start(testNumber, "")
+	var cooldownErr *CooldownError
+	require.True(t, errors.As(err, &cooldownErr))
+	assert.Equal(t, time.Minute, cooldownErr.RetryAfter)
+
+	// The cool-down doubles with every attempt
+	*now = now.Add(time.Minute)
+	verification, err := useCase.Start(testNumber, "")
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Minute), *verification.NextAttemptAt)
+
+	*now = now.Add(2 * time.Minute)
+	_, err = useCase.Start(testNumber, "")
+	require.NoError(t, err)
+
+	// The attempts are used up until the first leaves the window
+	*now = now.Add(time.Hour)
+	_, err = useCase.Start(testNumber, "")
+	require.True(t, errors.As(err, &cooldownErr))
+	assert.Equal(t, 24*time.Hour-time.Hour-3*time.Minute, cooldownErr.RetryAfter)
+	assert.Len(t, client.requests, 3)
+}
+
+func TestVerify_RejectsWrongCode(t *testing.T) {
+	client := &mockClient{}
+	useCase, repository, _ := newUseCase(client)
+	_, err := useCase.Start(testNumber, "")
+	require.NoError(t, err)
+
+	_, err = useCase.Verify(testNumber, "000000", "")
+	assert.Error(t, err)
+	assert.Equal(t, domainSignal.VerificationAttemptSent, repository.attempts[0].Status)
+}
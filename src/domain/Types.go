@@ -28,3 +28,40 @@ type DataFilters struct {
 	Page             int                 `json:"page"`
 	PageSize         int                 `json:"pageSize"`
 }
+
+const (
+	// DefaultPageLimit is the number of items of a page when a request sets no limit
+	DefaultPageLimit = 50
+	// MaxPageLimit is the largest page a request can ask for
+	MaxPageLimit = 500
+)
+
+// Cursor is the position of an item in a list ordered newest first by a timestamp, then by ID
+type Cursor struct {
+	Time time.Time
+	ID   int
+}
+
+// PageRequest asks for the items after a cursor, the first page when After is nil
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// WithDefaults returns the request with a limit between 1 and MaxPageLimit, DefaultPageLimit when none is set
+func (p PageRequest) WithDefaults() PageRequest {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	return p
+}
+
+// Page is a page of a cursor paginated list. Next is the cursor to ask for the following page with, nil on the
+// last page.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+}
@@ -0,0 +1,90 @@
+package bootstrap
+
+// Kinds of the resources of a bootstrap document
+const (
+	KindUser         = "user"
+	KindProvider     = "provider"
+	KindUserProvider = "user_provider"
+	KindWebhook      = "webhook"
+	KindTemplate     = "template"
+)
+
+// Actions taken to bring a resource to its state in the document, or that a dry run would take
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+)
+
+// Document is the declarative setup of a deployment. Resources are matched to the stored ones by their natural
+// keys, created when missing and updated when they differ. Resources missing from the document are left alone.
+type Document struct {
+	Org           string
+	Users         []User
+	Providers     []Provider
+	UserProviders []UserProvider
+	Webhooks      []Webhook
+	Templates     []Template
+	// Roles are the roles of users that exist but aren't listed in Users, by email
+	Roles map[string]string
+}
+
+// User is a user of a document, matched by email. The password hash is only used to create the user, the
+// passwords of existing users aren't changed.
+type User struct {
+	Email            string
+	UserName         string
+	FirstName        string
+	LastName         string
+	PasswordHash     string
+	Role             string
+	Locale           string
+	Status           bool
+	MessageRateLimit int
+}
+
+// Provider is a provider of a document, matched by name
+type Provider struct {
+	Name        string
+	Type        string
+	Description string
+	Config      string
+	Status      bool
+}
+
+// UserProvider links a user, by email, to a provider, by name
+type UserProvider struct {
+	User     string
+	Provider string
+	Priority int
+	Config   string
+	Status   bool
+}
+
+// Webhook is a hook subscription of a user, by email, matched by event and target URL. Secret is the
+// verified plaintext secret a new subscription is created with.
+type Webhook struct {
+	User      string
+	Event     string
+	TargetURL string
+	Secret    string
+}
+
+// Template is an email template of a user, by email, matched by name
+type Template struct {
+	User    string
+	Name    string
+	Subject string
+	HTML    string
+}
+
+// Change is what applying one resource of a document did, or would do on a dry run
+type Change struct {
+	Kind   string
+	Name   string // natural key of the resource within its user, e.g. the event and target URL of a webhook
+	User   string // email of the user the resource belongs to, empty for users and providers
+	Action string
+	ID     int // ID of the resource, 0 for resources a dry run would create
+	// Secret is the plaintext secret of a created webhook, it can't be read again
+	Secret string
+}
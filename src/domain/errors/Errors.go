@@ -30,6 +30,9 @@ const (
 	NotAuthorized             ErrorType    = "NotAuthorized"
 	notAuthorizedErrorMessage ErrorMessage = "not authorized"
 
+	Conflict             ErrorType    = "Conflict"
+	conflictErrorMessage ErrorMessage = "resource was modified by another request, reload it and retry"
+
 	UnknownError        ErrorType    = "UnknownError"
 	unknownErrorMessage ErrorMessage = "something went wrong"
 )
@@ -64,6 +67,8 @@ func NewAppErrorWithType(errType ErrorType) *AppError {
 		err = errors.New(string(notAuthorizedErrorMessage))
 	case TokenGeneratorError:
 		err = errors.New(string(tokenGeneratorErrorMessage))
+	case Conflict:
+		err = errors.New(string(conflictErrorMessage))
 	default:
 		err = errors.New(string(unknownErrorMessage))
 	}
@@ -91,6 +96,8 @@ func AppErrorToHTTP(appErr *AppError) (int, string) {
 		return http.StatusUnauthorized, appErr.Error()
 	case NotAuthorized:
 		return http.StatusForbidden, appErr.Error()
+	case Conflict:
+		return http.StatusConflict, appErr.Error()
 	default:
 		return http.StatusInternalServerError, "Internal Server Error"
 	}
@@ -73,6 +73,14 @@ func TestNewAppErrorWithType_TokenGeneratorError(t *testing.T) {
 	assert.Equal(t, "error in token generation", appError.Error())
 }
 
+func TestNewAppErrorWithType_Conflict(t *testing.T) {
+	appError := NewAppErrorWithType(Conflict)
+
+	assert.NotNil(t, appError)
+	assert.Equal(t, Conflict, appError.Type)
+	assert.Equal(t, "resource was modified by another request, reload it and retry", appError.Error())
+}
+
 func TestNewAppErrorWithType_UnknownError(t *testing.T) {
 	appError := NewAppErrorWithType(UnknownError)
 
@@ -139,6 +147,14 @@ func TestAppErrorToHTTP_NotAuthorized(t *testing.T) {
 	assert.Equal(t, "not authorized", message)
 }
 
+func TestAppErrorToHTTP_Conflict(t *testing.T) {
+	appError := NewAppErrorWithType(Conflict)
+	statusCode, message := AppErrorToHTTP(appError)
+
+	assert.Equal(t, http.StatusConflict, statusCode)
+	assert.Equal(t, "resource was modified by another request, reload it and retry", message)
+}
+
 func TestAppErrorToHTTP_UnknownError(t *testing.T) {
 	appError := NewAppErrorWithType(UnknownError)
 	statusCode, message := AppErrorToHTTP(appError)
@@ -180,5 +196,6 @@ func TestErrorTypeConstants(t *testing.T) {
 	assert.Equal(t, ErrorType("NotAuthenticated"), NotAuthenticated)
 	assert.Equal(t, ErrorType("NotAuthorized"), NotAuthorized)
 	assert.Equal(t, ErrorType("TokenGeneratorError"), TokenGeneratorError)
+	assert.Equal(t, ErrorType("Conflict"), Conflict)
 	assert.Equal(t, ErrorType("UnknownError"), UnknownError)
 }
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Statuses of a message transaction. A message only moves between them along the transitions of
+// messageStatusTransitions, see ValidateTransition.
+const (
+	// MessageStatusPending is waiting to be sent by the processor
+	MessageStatusPending = "pending"
+	// MessageStatusSuccess was accepted by the provider, delivery isn't confirmed yet
+	MessageStatusSuccess = "success"
+	// MessageStatusFailed was refused by the provider or couldn't be sent, it is retried at next_retry_at
+	MessageStatusFailed = "failed"
+	// MessageStatusDelivered was confirmed delivered
+	MessageStatusDelivered = "delivered"
+	// MessageStatusFallbackTriggered wasn't delivered in time, a copy was queued for the next provider of the user
+	MessageStatusFallbackTriggered = "fallback_triggered"
+	// MessageStatusHeld is held by the warm-up limit of its provider until next_retry_at
+	MessageStatusHeld = "held"
+	// MessageStatusHeldSchedule is held by the sending schedule of its provider until next_retry_at
+	MessageStatusHeldSchedule = "held_schedule"
+	// MessageStatusRateLimited is held until the Signal rate limit challenge of the account is solved
+	MessageStatusRateLimited = "rate_limited"
+	// MessageStatusSuspended is held while its user is deactivated
+	MessageStatusSuspended = "suspended"
+	// MessageStatusCancelled was cancelled by an admin before it was sent
+	MessageStatusCancelled = "cancelled"
+	// MessageStatusUnconfirmed was stuck mid-send, it is unknown whether the provider received it
+	MessageStatusUnconfirmed = "unconfirmed"
+	// MessageStatusRevoked was deleted for its recipients after it was sent
+	MessageStatusRevoked = "revoked"
+)
+
+// messageStatusTransitions lists the statuses a message can move to from each status. Statuses missing from
+// the keys are final.
+var messageStatusTransitions = map[string][]string{
+	MessageStatusPending: {MessageStatusSuccess, MessageStatusFailed, MessageStatusHeld, MessageStatusHeldSchedule,
+		MessageStatusRateLimited, MessageStatusCancelled, MessageStatusSuspended, MessageStatusUnconfirmed},
+	// A failed message is sent again by the retry watcher, which can fail it again
+	MessageStatusFailed: {MessageStatusPending, MessageStatusSuccess, MessageStatusFailed, MessageStatusHeld,
+		MessageStatusHeldSchedule, MessageStatusRateLimited, MessageStatusCancelled, MessageStatusSuspended,
+		MessageStatusUnconfirmed},
+	MessageStatusHeld:         {MessageStatusPending, MessageStatusCancelled, MessageStatusSuspended},
+	MessageStatusHeldSchedule: {MessageStatusPending, MessageStatusCancelled, MessageStatusSuspended},
+	MessageStatusRateLimited:  {MessageStatusPending, MessageStatusCancelled, MessageStatusSuspended},
+	MessageStatusSuspended:    {MessageStatusPending},
+	MessageStatusUnconfirmed:  {MessageStatusPending},
+	MessageStatusSuccess:      {MessageStatusDelivered, MessageStatusFallbackTriggered, MessageStatusRevoked},
+	MessageStatusDelivered:    {MessageStatusRevoked},
+}
+
+// TransitionError reports a status change a message can't make, e.g. cancelling a message that was sent
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	if !IsMessageStatus(e.To) {
+		return fmt.Sprintf("unknown message status %q", e.To)
+	}
+	return fmt.Sprintf("message can't move from %s to %s", e.From, e.To)
+}
+
+// IsMessageStatus reports whether status is one of the MessageStatus constants
+func IsMessageStatus(status string) bool {
+	if _, ok := messageStatusTransitions[status]; ok {
+		return true
+	}
+	switch status {
+	case MessageStatusFallbackTriggered, MessageStatusCancelled, MessageStatusRevoked:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a message can move from one status to another
+func CanTransition(from string, to string) bool {
+	for _, next := range messageStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns a *TransitionError unless a message can move from one status to another
+func ValidateTransition(from string, to string) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// PreviousStatuses returns the statuses a message can move to the given status from, e.g. to guard an update
+// of many messages
+func PreviousStatuses(to string) []string {
+	var statuses []string
+	for from, nexts := range messageStatusTransitions {
+		for _, next := range nexts {
+			if next == to {
+				statuses = append(statuses, from)
+				break
+			}
+		}
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// IsFinalStatus reports whether a message never changes status again
+func IsFinalStatus(status string) bool {
+	return IsMessageStatus(status) && len(messageStatusTransitions[status]) == 0
+}
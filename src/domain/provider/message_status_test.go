@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransition(t *testing.T) {
+	allowed := [][2]string{
+		{MessageStatusPending, MessageStatusSuccess},
+		{MessageStatusPending, MessageStatusHeldSchedule},
+		{MessageStatusFailed, MessageStatusFailed},
+		{MessageStatusHeld, MessageStatusPending},
+		{MessageStatusSuccess, MessageStatusFallbackTriggered},
+		{MessageStatusDelivered, MessageStatusRevoked},
+	}
+	for _, transition := range allowed {
+		assert.NoError(t, ValidateTransition(transition[0], transition[1]), transition)
+	}
+
+	rejected := [][2]string{
+		{MessageStatusSuccess, MessageStatusFailed},
+		{MessageStatusSuccess, MessageStatusCancelled},
+		{MessageStatusCancelled, MessageStatusPending},
+		{MessageStatusRevoked, MessageStatusRevoked},
+		{MessageStatusPending, MessageStatusDelivered},
+		{MessageStatusPending, "expired"},
+	}
+	for _, transition := range rejected {
+		err := ValidateTransition(transition[0], transition[1])
+		var transitionErr *TransitionError
+		assert.True(t, errors.As(err, &transitionErr), transition)
+	}
+	assert.EqualError(t, ValidateTransition(MessageStatusPending, "expired"), `unknown message status "expired"`)
+	assert.EqualError(t, ValidateTransition(MessageStatusSuccess, MessageStatusFailed), "message can't move from success to failed")
+}
+
+func TestPreviousStatuses(t *testing.T) {
+	assert.Equal(t, []string{MessageStatusDelivered, MessageStatusSuccess}, PreviousStatuses(MessageStatusRevoked))
+	assert.Equal(t, []string{MessageStatusFailed, MessageStatusHeld, MessageStatusHeldSchedule, MessageStatusPending, MessageStatusRateLimited},
+		PreviousStatuses(MessageStatusCancelled))
+	assert.Empty(t, PreviousStatuses("expired"))
+}
+
+func TestIsFinalStatus(t *testing.T) {
+	for _, status := range []string{MessageStatusFallbackTriggered, MessageStatusCancelled, MessageStatusRevoked} {
+		assert.True(t, IsFinalStatus(status), status)
+		assert.True(t, IsMessageStatus(status), status)
+	}
+	assert.False(t, IsFinalStatus(MessageStatusSuccess))
+	assert.False(t, IsFinalStatus("expired"))
+	assert.False(t, IsMessageStatus("expired"))
+}
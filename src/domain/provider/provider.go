@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +15,7 @@ type Provider struct {
 	Description string
 	Config      string // JSON configuration for the provider
 	Status      bool   // Whether the provider is active
+	Version     int    // Optimistic locking version, incremented on every update
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -24,27 +28,226 @@ type UserProvider struct {
 	Priority   int    // Lower number means higher priority
 	Config     string // JSON configuration specific to this user-provider relationship
 	Status     bool   // Whether this provider is active for this user
+	Suspended  bool   // Disabled by the deactivation of the user, enabled again on reactivation
+	Version    int    // Optimistic locking version, incremented on every update
+	// CredentialStatus is the result of checking the credentials the provider sends with for the user when the
+	// config was saved, one of the CredentialStatus constants. Empty for configs saved before credentials were
+	// checked.
+	CredentialStatus     string
+	CredentialError      string     // why the check failed
+	CredentialsCheckedAt *time.Time // when the credentials were last checked
+	CredentialsForced    bool       // routed even though its credentials failed the check
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// Results of the credential check of a user provider. Messages aren't routed to user providers whose credentials
+// are invalid unless the user forced them.
+const (
+	CredentialStatusValid   = "valid"
+	CredentialStatusInvalid = "invalid"
+	// CredentialStatusUnchecked is stored for types whose credentials can't be checked without sending
+	CredentialStatusUnchecked = "unchecked"
+)
+
+// ProviderDrill simulates an outage of a provider for one user, so failover can be rehearsed. While the drill
+// runs the provider is skipped when routing the user's messages, and messages already routed to it fail
+// like they would in a real outage.
+type ProviderDrill struct {
+	ID         int
+	UserID     int
+	ProviderID int
+	StartedBy  int // admin who started the drill
+	Reason     string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	EndedAt    *time.Time // set when the drill was ended before EndsAt
 	CreatedAt  time.Time
-	UpdatedAt  time.Time
 }
 
+// Error codes classify why a provider refused a message, whatever the provider answered
+const (
+	ErrorCodeInvalidRecipient = "invalid_recipient" // the recipient doesn't exist or can't receive messages
+	ErrorCodeUnregistered     = "unregistered"      // the recipient has no account with the provider
+	ErrorCodeRateLimited      = "rate_limited"      // the provider throttled the sender
+	ErrorCodeAuthFailed       = "auth_failed"       // the provider rejected the credentials of its config
+	ErrorCodeNetwork          = "network"           // the provider couldn't be reached or failed on its side
+	ErrorCodeDeviceUnlinked   = "device_unlinked"   // the sending account is a linked device its primary unlinked
+	ErrorCodeUnknown          = "unknown"
+)
+
+// RecipientError reports a recipient that isn't an address of the provider it was sent through, e.g. a phone
+// number given to a chat provider
+type RecipientError struct {
+	Description string
+}
+
+func (e *RecipientError) Error() string {
+	return e.Description
+}
+
+// Ways a failed message is handled, chosen by the retry policy of its error code
+const (
+	// FailureRetry sends the message again through the same provider, the failure is temporary
+	FailureRetry = "retry"
+	// FailureFallback sends the message through the next provider of the user
+	FailureFallback = "fallback"
+	// FailureSuppress gives the message up, no provider can deliver it
+	FailureSuppress = "suppress"
+)
+
 // MessageTransaction represents a message transaction
 type MessageTransaction struct {
-	ID           int
-	UserID       int
-	ProviderID   int
-	Recipients   string // JSON array of recipients
-	Message      string
-	RequestData  string // JSON request data
-	ResponseData string // JSON response data
-	Status       string // success, failed, pending
-	ErrorMessage string
-	RetryCount   int        // Number of retry attempts
-	NextRetryAt  *time.Time // When to retry next
-	Processing   bool       // Whether the message is currently being processed
-	ProcessedAt  *time.Time // When the message was last processed
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID            int
+	UserID        int
+	ProviderID    int
+	Recipients    string // JSON array of recipients
+	Message       string
+	Tags          string // JSON object of caller supplied key-value tags
+	Extensions    string // JSON object of the MessageExtensions of the message, empty when none are set
+	RequestData   string // JSON request data
+	ResponseData  string // JSON response data
+	Status        string // one of the MessageStatus constants, changed along ValidateTransition
+	ErrorMessage  string
+	ErrorCode     string     // Why the provider refused the message, one of the ErrorCode constants
+	RetryCount    int        // Number of retry attempts
+	NextRetryAt   *time.Time // When to retry next
+	Processing    bool       // Whether the message is currently being processed
+	ProcessedAt   *time.Time // When the message was last processed
+	SendStartedAt *time.Time // When the provider call was started, a message is never sent twice once set
+	// Acknowledgement demanded by the sender, AckStatus is empty when none was demanded
+	AckStatus            string     // pending, acknowledged or expired
+	AckToken             string     // Code a recipient replies with to acknowledge, e.g. "ACK K7QX2M"
+	AckKeyword           string     // Keyword that acknowledges when replied alone by a recipient
+	AckDeadline          *time.Time // When an unacknowledged message expires
+	AckEscalationChainID int        // Escalation chain triggered on expiry, 0 for none
+	AcknowledgedBy       string
+	AcknowledgedAt       *time.Time
+	TrackLinks           bool   // URLs of the message are sent as short links that record the clicks of each recipient
+	Segments             int    // Messages billed per recipient by the provider, SMS are billed per segment
+	Actions              string // JSON array of the MessageActions offered with the message, empty for none
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// MessageAction is a choice offered with a message. Providers with interactive messages show it as a button, the
+// others list the actions as numbered options the recipients reply with.
+type MessageAction struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// ActionChoice is an action of a message chosen by a recipient, by clicking its button or replying its number
+type ActionChoice struct {
+	MessageTransactionID int
+	ActionID             string
+	Label                string // label of the action, set once the choice is matched to the message
+	Recipient            string // recipient who chose, as the message was addressed to them
+	ChosenBy             string // member who chose for a channel or group recipient, the recipient otherwise
+	Channel              string // provider type the choice came through
+	Via                  string // button or reply
+	ChosenAt             time.Time
+}
+
+// Ways an action is chosen
+const (
+	ActionViaButton = "button"
+	ActionViaReply  = "reply"
+)
+
+// actionDataPrefix starts the payload of the buttons of actions, so clicks on other buttons are told apart
+const actionDataPrefix = "action:"
+
+// ActionData is the payload a button of an action is sent with and the provider posts back on a click
+func ActionData(messageTransactionID int, actionID string) string {
+	return actionDataPrefix + strconv.Itoa(messageTransactionID) + ":" + actionID
+}
+
+// ParseActionData reads the message and action of a button payload, ok is false for payloads of other buttons
+func ParseActionData(data string) (messageTransactionID int, actionID string, ok bool) {
+	rest, found := strings.CutPrefix(data, actionDataPrefix)
+	if !found {
+		return 0, "", false
+	}
+	id, actionID, found := strings.Cut(rest, ":")
+	if !found || actionID == "" {
+		return 0, "", false
+	}
+	messageTransactionID, err := strconv.Atoi(id)
+	if err != nil || messageTransactionID <= 0 {
+		return 0, "", false
+	}
+	return messageTransactionID, actionID, true
+}
+
+// ActionOptions returns the numbered options appended to the text of a message with actions for providers without
+// buttons, the recipients choose by replying the number of an action
+func ActionOptions(actions []MessageAction) string {
+	if len(actions) == 0 {
+		return ""
+	}
+	var options strings.Builder
+	options.WriteString("\n\nReply with the number of your choice:")
+	for i, action := range actions {
+		fmt.Fprintf(&options, "\n%d. %s", i+1, action.Label)
+	}
+	return options.String()
+}
+
+// MessageExtensions are the provider specific options of a message, each applies to the providers of its type only
+type MessageExtensions struct {
+	Signal *SignalExtension `json:"signal,omitempty"`
+	Email  *EmailExtension  `json:"email,omitempty"`
+}
+
+// EmailExtension carries the parts of an email besides its plain-text body, the message
+type EmailExtension struct {
+	// Subject is the subject of the email, the first line of the message when empty
+	Subject string `json:"subject,omitempty"`
+	// HTML is sent as the HTML alternative of the message
+	HTML string `json:"html,omitempty"`
+	// AttachmentIDs are attachments uploaded in parts beforehand. Those the HTML references as cid:<filename> are
+	// shown inline, the others are attached.
+	AttachmentIDs []int `json:"attachment_ids,omitempty"`
+}
+
+// SignalExtension carries the Signal features a plain text message can't express
+type SignalExtension struct {
+	// Base64Attachments are base64 encoded files, optionally as data:<mime>;filename=<name>;base64,<data> URIs
+	Base64Attachments []string `json:"base64_attachments,omitempty"`
+	// ViewOnce lets each recipient open the attachments once
+	ViewOnce bool `json:"view_once,omitempty"`
+	// TextMode is normal or styled, DEFAULT_SIGNAL_TEXT_MODE applies when it isn't set
+	TextMode string `json:"text_mode,omitempty"`
+	// ResolveMentions mentions the members of the group recipients named by @name tokens in the message
+	ResolveMentions bool `json:"resolve_mentions,omitempty"`
+	// AttachmentIDs are attachments uploaded in parts beforehand, sent after the Base64Attachments
+	AttachmentIDs []int `json:"attachment_ids,omitempty"`
+	// ReadReceipts counts the read and viewed receipts of the recipients, nil counts them. Signal clients send read
+	// receipts as their user set them up, when false the deliveries of the message stop at delivered.
+	ReadReceipts *bool `json:"read_receipts,omitempty"`
+	// DeliveryConfirmation tracks the delivery receipts of each recipient, nil tracks them. When false no
+	// deliveries are recorded for the message and its receipts are ignored.
+	DeliveryConfirmation *bool `json:"delivery_confirmation,omitempty"`
+}
+
+// WantsReadReceipts reports whether the read receipts of the recipients count, they do unless turned off
+func (e *SignalExtension) WantsReadReceipts() bool {
+	return e == nil || e.ReadReceipts == nil || *e.ReadReceipts
+}
+
+// WantsDeliveryConfirmation reports whether the deliveries to the recipients are tracked, they are unless turned off
+func (e *SignalExtension) WantsDeliveryConfirmation() bool {
+	return e == nil || e.DeliveryConfirmation == nil || *e.DeliveryConfirmation
+}
+
+// QueueMetrics counts the active message transactions per state of the queue
+type QueueMetrics struct {
+	Pending             int        // waiting to be picked up by a worker
+	Processing          int        // picked up by a worker
+	FailedAwaitingRetry int        // failed and waiting for their next retry
+	Held                int        // held by a warm-up limit, a sending schedule or a Signal rate limit
+	OldestPendingAt     *time.Time // creation time of the oldest pending message, nil when none is pending
 }
 
 // MessageTransactionHistory represents the history of a message transaction
@@ -55,11 +258,14 @@ type MessageTransactionHistory struct {
 	ProviderID   int
 	Recipients   string // JSON array of recipients
 	Message      string
+	Tags         string // JSON object of caller supplied key-value tags
 	RequestData  string // JSON request data
 	ResponseData string // JSON response data
 	Status       string // success, failed
 	ErrorMessage string
+	ErrorCode    string    // Why the provider refused the message, one of the ErrorCode constants
 	RetryCount   int       // Number of retry attempts
+	Segments     int       // Messages billed per recipient by the provider, SMS are billed per segment
 	ProcessedAt  time.Time // When the message was processed
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -99,3 +305,653 @@ type IMessageTransactionHistoryService interface {
 	GetMessageTransactionHistoryByMessageID(messageID int) (*[]MessageTransactionHistory, error)
 	GetUserMessageTransactionHistory(userID int) (*[]MessageTransactionHistory, error)
 }
+
+// OutboxEvent represents a message lifecycle event waiting to be published to an external event stream
+type OutboxEvent struct {
+	ID          int
+	EventType   string // message.queued, message.sent, message.delivered, message.failed, ...
+	MessageID   int    // Reference to the message transaction the event is about
+	UserID      int
+	ProviderID  int
+	Payload     string     // JSON event payload
+	Published   bool       // Whether the event was published successfully
+	PublishedAt *time.Time // When the event was published
+	Attempts    int        // Number of publish attempts
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// DigestSubscription represents a user's opt-in for periodic delivery digest reports
+type DigestSubscription struct {
+	ID        int
+	UserID    int
+	Frequency string // daily or weekly
+	Channel   string // provider type the digest is sent through, e.g. signal or email
+	Recipient string // where the digest is sent on the selected channel
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// HookSubscription represents a REST hook subscribing a target URL to one event type of a user
+type HookSubscription struct {
+	ID        int
+	UserID    int
+	Event     string // e.g. message.sent or message.received
+	TargetURL string
+	Secret    string // Exchanged in the verification handshake and used to sign deliveries
+	// EnvelopeTypes are the types of received envelopes delivered to a message.received subscription, the
+	// default types when empty
+	EnvelopeTypes []string
+	Delivery      HookDelivery
+	CreatedAt     time.Time
+}
+
+// HookDelivery holds the optional settings of the deliveries to the target of a subscription, for targets
+// requiring mTLS or static auth headers. The header values and the client key are stored encrypted like the secret.
+type HookDelivery struct {
+	// Headers are sent with every delivery and verification request, e.g. an Authorization header
+	Headers map[string]string
+	// ClientCertificate and ClientKey are the PEM certificate and key presented to targets asking for one
+	ClientCertificate string
+	ClientKey         string
+	// CACertificate holds PEM CAs trusted for the target besides the system roots
+	CACertificate string
+	// InsecureSkipVerify turns off the verification of the certificate of the target
+	InsecureSkipVerify bool
+}
+
+// HasTLS reports whether the deliveries need a client with TLS settings of their own
+func (d HookDelivery) HasTLS() bool {
+	return d.ClientCertificate != "" || d.CACertificate != "" || d.InsecureSkipVerify
+}
+
+// WebhookEvent is an event emitted to the REST hook subscriptions of a user, kept for the retention period so
+// it can be fetched or re-delivered after an outage of the target
+type WebhookEvent struct {
+	ID        int
+	UserID    int
+	Event     string
+	Payload   string // JSON body of the deliveries
+	CreatedAt time.Time
+}
+
+// WebhookReplayResult is the outcome of re-delivering an event to one subscription
+type WebhookReplayResult struct {
+	SubscriptionID int
+	TargetURL      string
+	StatusCode     int // 0 if the target wasn't reached
+	Error          string
+}
+
+// ControlCommand is the audit record of a control message an operator sent to the Signal number
+type ControlCommand struct {
+	ID         int
+	Sender     string
+	SenderUUID string
+	Text       string // the message as received
+	Command    string // name of the parsed command, empty when the message didn't parse
+	Status     string // executed, failed, invalid or rejected
+	Result     string // reply sent to the operator, or why the command was rejected
+	CreatedAt  time.Time
+}
+
+// EscalationChain defines who is notified, in which order and how long to wait for an acknowledgement
+// before moving on to the next step
+type EscalationChain struct {
+	ID        int
+	UserID    int
+	Name      string
+	Steps     []EscalationStep
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// EscalationStep notifies recipients through a provider type once the delay after the previous step
+// passed without acknowledgement
+type EscalationStep struct {
+	Channel      string   `json:"channel"` // provider type, e.g. signal, sms or email
+	Recipients   []string `json:"recipients"`
+	DelayMinutes int      `json:"delay_minutes"`
+}
+
+// Escalation is a running instance of an escalation chain
+type Escalation struct {
+	ID             int
+	UserID         int
+	ChainID        int
+	Steps          []EscalationStep // steps of the chain when the escalation was triggered
+	Message        string
+	Status         string // active, acknowledged or exhausted
+	CurrentStep    int    // index of the last notified step, -1 before the first step
+	NextStepAt     *time.Time
+	AckCode        string // code recipients reply with to acknowledge
+	AcknowledgedBy string
+	AcknowledgedAt *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DeliveryStats holds aggregated delivery statistics of a user for a period
+type DeliveryStats struct {
+	Total     int
+	Sent      int
+	Failed    int
+	Fallbacks int
+	TopErrors []ErrorReasonCount
+}
+
+// EngagementEvent is a step of the engagement of a recipient with a sent message: sent, delivered, read or clicked
+type EngagementEvent struct {
+	MessageTransactionID int
+	UserID               int
+	ProviderID           int
+	Recipient            string
+	Tags                 string // JSON object of the tags of the message, only known to sent events
+	Event                string
+	OccurredAt           time.Time
+}
+
+// EngagementRollup counts the recipients of the messages of a group, e.g. the messages of a provider or sharing a
+// tag value, and how many of them received, read and clicked them
+type EngagementRollup struct {
+	Group      string
+	Recipients int
+	Delivered  int
+	Read       int
+	Clicked    int
+}
+
+// TagDeliveryRollup holds the delivery outcomes of the messages sharing a tag value within a period
+type TagDeliveryRollup struct {
+	TagValue    string
+	PeriodStart time.Time
+	Total       int
+	Sent        int
+	Failed      int
+	Fallbacks   int
+}
+
+// ErrorReasonCount represents how often an error message occurred
+type ErrorReasonCount struct {
+	Code   string `json:"code,omitempty"` // error code of the failures, empty for failures recorded before the codes
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// DeliveryDigest represents a compiled delivery digest report
+type DeliveryDigest struct {
+	ID           int
+	UserID       int
+	Frequency    string
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Total        int
+	Sent         int
+	Failed       int
+	Fallbacks    int
+	FallbackRate float64
+	TopErrors    []ErrorReasonCount
+	LinkClicks   int // Clicks on the tracked links of the user during the period
+	LinkClickers int // Distinct recipients who clicked a tracked link
+	Channel      string
+	MessageID    int    // Message transaction the digest was sent with
+	Status       string // sent or failed
+	ErrorMessage string
+	CreatedAt    time.Time
+}
+
+// DistributionList is a stored recipient list of a user. It sends to a Signal group, whose membership is
+// kept in sync with SignalMembers, plus members reached through other channels.
+type DistributionList struct {
+	ID            int
+	UserID        int
+	Name          string
+	SignalGroupID string   // group the list sends to on Signal, e.g. group.ZXhhbXBsZQ==, empty for none
+	SignalMembers []string // numbers that should be members of the Signal group
+	ExtraMembers  []DistributionListMember
+	Drift         *MembershipDrift // difference found by the last sync, nil when the group matched
+	SyncError     string
+	LastSyncedAt  *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// DistributionListMember is a member of a distribution list reached outside the Signal group
+type DistributionListMember struct {
+	Channel string `json:"channel"` // provider type, e.g. sms or email
+	Address string `json:"address"`
+}
+
+// MembershipDrift is the difference between the stored members of a distribution list and its Signal group
+type MembershipDrift struct {
+	Missing    []string `json:"missing"`    // stored members that weren't in the group
+	Unexpected []string `json:"unexpected"` // group members that aren't stored
+	Reconciled bool     `json:"reconciled"` // whether the group was changed to match the stored members
+}
+
+// EmailTemplate is a stored email template of a user, rendered with variables into the subject, HTML body and
+// plain-text alternative of an email
+type EmailTemplate struct {
+	ID        int
+	UserID    int
+	Name      string
+	Subject   string // text/template of the subject
+	HTML      string // html/template of the body, its <style> rules are inlined when rendered
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// InboundNumber is a phone number provisioned for a user through an SMS provider, the SMS sent to it are
+// routed to the user. The numbers of a user are stored under "inbound_numbers" in the user provider config.
+type InboundNumber struct {
+	ID            string    `json:"id"` // id of the number at the SMS vendor, e.g. the Twilio PN... sid
+	PhoneNumber   string    `json:"phone_number"`
+	ProvisionedAt time.Time `json:"provisioned_at"`
+}
+
+// AvailableNumber is a phone number an SMS provider offers for provisioning
+type AvailableNumber struct {
+	PhoneNumber string
+	Locality    string
+	Region      string
+	Country     string
+	MMS         bool
+}
+
+// NumberSearch narrows the search for available numbers
+type NumberSearch struct {
+	Country  string // ISO country code, e.g. US
+	AreaCode string
+	Contains string
+	Limit    int
+}
+
+// InboundSMS is an SMS received on a provisioned number
+type InboundSMS struct {
+	ID         string    `json:"id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Body       string    `json:"body"`
+	MediaURLs  []string  `json:"media_urls,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ShortLink is a URL of a message with tracked links. Each recipient is sent their own signed short link for
+// it, so clicks are recorded per recipient. The recipients of the message are kept with the link, the message
+// itself may be gone by the time a link is clicked.
+type ShortLink struct {
+	ID         int
+	MessageID  int
+	UserID     int
+	Index      int // position of the URL in the message
+	URL        string
+	Recipients string // JSON array of the recipients of the message, short links refer to them by index
+	CreatedAt  time.Time
+}
+
+// LinkClick is a click of a recipient on a short link
+type LinkClick struct {
+	ID        int
+	LinkID    int
+	MessageID int
+	UserID    int
+	Recipient string
+	UserAgent string
+	ClickedAt time.Time
+}
+
+// LinkClickStats summarizes the clicks on the short links of a message
+type LinkClickStats struct {
+	Clicks           int
+	UniqueRecipients int // recipients who clicked at least once
+	Links            []LinkStats
+	Recipients       []RecipientClicks
+}
+
+// LinkStats counts the clicks on one URL of a message
+type LinkStats struct {
+	URL              string
+	Clicks           int
+	UniqueRecipients int
+}
+
+// RecipientClicks counts the clicks of one recipient on the links of a message
+type RecipientClicks struct {
+	Recipient      string
+	Clicks         int
+	FirstClickedAt time.Time
+	LastClickedAt  time.Time
+}
+
+// MessageDelivery is the delivery of a message to one recipient as reported by the provider through delivery
+// callbacks
+type MessageDelivery struct {
+	ID                   int
+	MessageTransactionID int
+	ProviderID           int
+	Recipient            string
+	ProviderMessageID    string // ID the provider gave the message of the recipient
+	Status               string // sent, delivered, read or failed
+	ErrorCode            string
+	ErrorMessage         string
+	// ReadReceipts reports whether read receipts count, without them the delivery stops at delivered
+	ReadReceipts bool
+	// DeliveredAt and ReadAt are when the recipient confirmed the delivery and read the message, nil until then
+	DeliveredAt *time.Time
+	ReadAt      *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// MessageEdit is an edit of a sent message, the text the message had before and the text it was changed to
+type MessageEdit struct {
+	ID                   int
+	MessageTransactionID int
+	PreviousMessage      string
+	Message              string
+	ResponseData         string // response of the provider to the edit
+	CreatedAt            time.Time
+}
+
+// DeliveryUpdate is a delivery status reported by a provider callback. The delivery is looked up by the ID the
+// provider gave the message, or by message transaction and recipient for providers echoing them back.
+type DeliveryUpdate struct {
+	ProviderMessageID    string
+	MessageTransactionID int
+	Recipient            string
+	Status               string
+	ErrorCode            string
+	ErrorMessage         string
+}
+
+// Directions of the messages of a conversation
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+)
+
+// MessageEvent is a message sent to or received from participants, published on the event bus when it happens
+type MessageEvent struct {
+	// UserID is the user who sent or received the message, 0 for messages received on an account shared by the
+	// users, like the Signal number
+	UserID       int
+	ProviderID   int    // provider of an outbound message
+	Channel      string // provider type, set for inbound messages
+	Participants []string
+	Direction    string // outbound or inbound
+	MessageID    int    // message transaction of an outbound message
+	ExternalID   string // id of an inbound message at the vendor
+	Body         string
+	Status       string // latest status of an outbound message
+	Tags         string // JSON object of the tags of an outbound message
+	OccurredAt   time.Time
+}
+
+// Conversation is the thread of a user with one participant on a channel, a read model projected from the
+// message events
+type Conversation struct {
+	ID             int
+	UserID         int
+	Channel        string // provider type
+	Participant    string // address of the other side, e.g. a phone number or a Matrix room
+	LastMessage    string
+	LastDirection  string
+	LastStatus     string
+	MessageCount   int
+	InboundCount   int
+	LastActivityAt time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ConversationMessage is a message of a conversation
+type ConversationMessage struct {
+	ID             int
+	ConversationID int
+	Direction      string
+	MessageID      int
+	ExternalID     string
+	Body           string
+	Status         string
+	OccurredAt     time.Time
+}
+
+// Actions of bulk operations
+const (
+	BulkActionRequeue = "requeue"
+	BulkActionCancel  = "cancel"
+)
+
+// BulkOperationFilter selects the message transactions a bulk operation applies to
+type BulkOperationFilter struct {
+	Status     string     `json:"status"`                // status of the messages, e.g. failed
+	ProviderID int        `json:"provider_id,omitempty"` // 0 for every provider
+	UserID     int        `json:"user_id,omitempty"`     // 0 for every user
+	From       *time.Time `json:"from,omitempty"`        // created at or after, inclusive
+	To         *time.Time `json:"to,omitempty"`          // created before, exclusive
+}
+
+// Job is a long-running task run in the background by the job workers of any instance
+type Job struct {
+	ID              int
+	Type            string // selects the handler running the job, e.g. bulk_operation
+	Payload         string // JSON input of the job
+	Status          string // queued, running, completed, failed or cancelled
+	Progress        int    // percent done
+	Result          string // JSON output of the job, or its progress details while it runs
+	Error           string // error of the last attempt
+	Attempts        int
+	MaxAttempts     int
+	RunAfter        time.Time // a queued job isn't started before, set when an attempt is retried
+	CancelRequested bool
+	WorkerID        string     // worker running the job
+	HeartbeatAt     *time.Time // last sign of life of the worker running the job
+	CreatedBy       int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+}
+
+// CustomDomain is a domain of a user's organization that short links are served on and hook targets may be
+// restricted to. It is only used once the organization proved it controls the domain by publishing the
+// verification token in a DNS TXT record.
+type CustomDomain struct {
+	ID                int
+	UserID            int
+	Domain            string
+	VerificationToken string
+	VerifiedAt        *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// MessagePartition is a monthly partition of a message table, holding the messages created in Month. The first
+// partition also holds every older message.
+type MessagePartition struct {
+	Table string
+	Name  string
+	Month time.Time
+	Rows  int64 // estimated by the database
+}
+
+// AuditExportBatch is a batch of audit records exported to the SIEM target. The last batch of a source is the
+// checkpoint the next export continues after, and its hash chains the batches so a removed or altered batch is
+// detected.
+type AuditExportBatch struct {
+	ID           int
+	Source       string // audit log the records come from, login_events or control_commands
+	Format       string // cef or jsonl
+	Target       string // s3, dir or syslog
+	FirstID      int    // ID of the first exported record in the source
+	LastID       int    // ID of the last exported record in the source
+	Records      int
+	Location     string // reference of the uploaded object, empty for syslog
+	Hash         string // hex SHA-256 of the previous hash followed by the batch content
+	PreviousHash string // hash of the previous batch of the source, empty for the first batch
+	CreatedAt    time.Time
+}
+
+// MessageArchiveBatch is a file of message history entries of one day archived to cold storage
+type MessageArchiveBatch struct {
+	ID        int
+	Org       string    // org partition of the file, the JWT_ORG of the deployment
+	Day       time.Time // UTC day the entries were created on
+	Target    string    // s3 or dir
+	FirstID   int       // ID of the first archived history entry
+	LastID    int       // ID of the last archived history entry
+	Records   int
+	Location  string // reference of the written object
+	Deleted   bool   // whether the entries were deleted from the database
+	CreatedAt time.Time
+}
+
+// Attachment is a file uploaded in parts ahead of the messages it is sent with, so that a send request references
+// it by ID instead of carrying it inline
+type Attachment struct {
+	ID          int
+	UserID      int
+	Filename    string
+	MimeType    string
+	Size        int64     // total size declared when the upload started
+	ChunkSize   int64     // size of every part but the last
+	SHA256      string    // hex digest of the file, checked on completion when the client declared it
+	Status      string    // uploading or complete
+	ExpiresAt   time.Time // the attachment is deleted afterwards, whether it was sent or not
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Parts returns the number of parts the attachment is uploaded in
+func (a Attachment) Parts() int {
+	if a.ChunkSize <= 0 {
+		return 0
+	}
+	return int((a.Size + a.ChunkSize - 1) / a.ChunkSize)
+}
+
+// PartSize returns the size of a part numbered from 1, the last part holds the rest of the file
+func (a Attachment) PartSize(number int) int64 {
+	if number == a.Parts() {
+		return a.Size - int64(number-1)*a.ChunkSize
+	}
+	return a.ChunkSize
+}
+
+// Health of a provider type on the status page, and severities of status banners, from best to worst
+const (
+	HealthOperational = "operational"
+	HealthDegraded    = "degraded"
+	HealthOutage      = "outage"
+	// BannerSeverityInfo announces e.g. maintenance without changing the reported health
+	BannerSeverityInfo = "info"
+)
+
+// StatusBanner is an incident or maintenance notice an admin publishes on the status page. A banner of a
+// provider type reports the type at least as bad as its severity while it is shown.
+type StatusBanner struct {
+	ID           int
+	Title        string
+	Message      string
+	Severity     string     // info, degraded or outage
+	ProviderType string     // provider type the banner is about, empty for all
+	StartsAt     time.Time  // shown from
+	EndsAt       *time.Time // shown until, nil until removed
+	CreatedBy    int        // admin who published the banner
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ProviderTypeOutcomes counts the messages of a provider type processed within a period
+type ProviderTypeOutcomes struct {
+	Type   string
+	Sent   int
+	Failed int
+}
+
+// Windows of the per-recipient frequency caps
+const (
+	RecipientCapWindowHour = "hour"
+	RecipientCapWindowDay  = "day"
+)
+
+// What happens to a send exceeding a per-recipient frequency cap
+const (
+	// RecipientCapActionReject refuses the send
+	RecipientCapActionReject = "reject"
+	// RecipientCapActionHold stores the message as held until the recipients are under their caps again
+	RecipientCapActionHold = "hold"
+)
+
+// RecipientCapViolation records a recipient whose frequency cap a send of a user exceeded
+type RecipientCapViolation struct {
+	ID        int
+	UserID    int
+	Recipient string
+	Window    string // hour or day
+	Cap       int
+	Action    string // reject or hold
+	// MessageTransactionID is the held message, 0 for rejected sends
+	MessageTransactionID int
+	CreatedAt            time.Time
+}
+
+// RecipientCapRollup counts the sends of a user held or rejected by the frequency cap of a recipient
+type RecipientCapRollup struct {
+	Recipient       string
+	Held            int
+	Rejected        int
+	LastViolationAt time.Time
+}
+
+// Types of the content transformations applied to the messages of a user before they are stored
+const (
+	// ContentTransformEmoji expands emoji shortcodes like :thumbsup:
+	ContentTransformEmoji = "emoji"
+	// ContentTransformShortenURLs replaces the URLs of a message with short links
+	ContentTransformShortenURLs = "shorten_urls"
+	// ContentTransformSignature appends a signature
+	ContentTransformSignature = "signature"
+	// ContentTransformHTTP has an external HTTP endpoint transform the message
+	ContentTransformHTTP = "http"
+)
+
+// ContentTransform is a transformation of the messages of a user. The transformations of a user are applied in
+// the order of their position, each to the text the one before returned.
+type ContentTransform struct {
+	ID       int
+	UserID   int
+	Position int
+	Type     string
+	Config   string // JSON object with the settings of the type, empty when it has none
+	// Disabled transformations are kept in the list but not applied
+	Disabled  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RetentionPolicy is the data retention of an org, the JWT_ORG of the deployment, enforced by the purge job.
+// Periods are in days, 0 keeps the data.
+type RetentionPolicy struct {
+	Org            string
+	BodyDays       int // bodies of messages, their edits and conversation messages
+	AttachmentDays int
+	AuditLogDays   int // login events and control commands
+	// RedactBodies clears the bodies of expired messages but keeps the messages with their metadata for
+	// analytics, instead of deleting them
+	RedactBodies bool
+	UpdatedBy    int // admin who changed the policy last, 0 while it was never changed
+	UpdatedAt    *time.Time
+}
+
+// MaintenanceMode tells whether the deployment is under maintenance. While it is enabled the debugging streams of
+// received messages are closed and refused.
+type MaintenanceMode struct {
+	Enabled   bool
+	Reason    string
+	UpdatedBy int // admin who changed the mode last, 0 while it was never changed
+	UpdatedAt *time.Time
+}
@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseActionData(t *testing.T) {
+	messageID, actionID, ok := ParseActionData(ActionData(42, "approve"))
+	assert.True(t, ok)
+	assert.Equal(t, 42, messageID)
+	assert.Equal(t, "approve", actionID)
+
+	for _, data := range []string{"", "approve", "action:", "action:42", "action:42:", "action:x:approve", "action:-1:approve", "menu:42:approve"} {
+		_, _, ok := ParseActionData(data)
+		assert.False(t, ok, data)
+	}
+}
+
+func TestActionOptions(t *testing.T) {
+	assert.Empty(t, ActionOptions(nil))
+	assert.Equal(t, "\n\nReply with the number of your choice:\n1. Approve\n2. Reject",
+		ActionOptions([]MessageAction{{ID: "approve", Label: "Approve"}, {ID: "reject", Label: "Reject"}}))
+}
@@ -0,0 +1,39 @@
+package provider
+
+import "time"
+
+// SyncCursor is the position of a client in the changes of its user. Each kind of change is read in its own
+// order, the cursor holds the last change of each the client has seen.
+type SyncCursor struct {
+	// StatusEventID is the last lifecycle event of a message
+	StatusEventID int
+	// InboundMessageID is the last message received in a conversation
+	InboundMessageID int
+	// ConfigUpdatedAt and ConfigID are the last provider config change, configs are ordered by update time, then ID
+	ConfigUpdatedAt time.Time
+	ConfigID        int
+}
+
+// MessageStatusChange is a status a message of the user moved to
+type MessageStatusChange struct {
+	EventID      int
+	MessageID    int
+	ProviderID   int
+	EventType    string
+	Status       string
+	ErrorCode    string
+	ErrorMessage string
+	RetryCount   int
+	OccurredAt   time.Time
+}
+
+// InboundMessage is a message the user received in one of its conversations
+type InboundMessage struct {
+	ID             int
+	ConversationID int
+	Channel        string
+	Participant    string
+	ExternalID     string
+	Body           string
+	OccurredAt     time.Time
+}
@@ -0,0 +1,55 @@
+package signal
+
+import (
+	"fmt"
+	"time"
+)
+
+// Modes a Signal number operates in
+const (
+	// AccountModePrimary is a number registered on this backend, it is the primary device of its account
+	AccountModePrimary = "primary"
+	// AccountModeLinked is a number linked to an existing account by scanning a QR code with its primary device
+	AccountModeLinked = "linked"
+)
+
+// Statuses of a linked device
+const (
+	// DeviceLinkStatusLinked can send as the account
+	DeviceLinkStatusLinked = "linked"
+	// DeviceLinkStatusUnlinked was removed by the primary device, it has to be linked again before it can send
+	DeviceLinkStatusUnlinked = "unlinked"
+)
+
+// DeviceLink is a number this backend operates as a linked device of an account
+type DeviceLink struct {
+	ID         int
+	Number     string
+	DeviceName string
+	Status     string
+	LinkedAt   time.Time
+	UnlinkedAt *time.Time
+	// LastError is why the device was found unlinked
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DeviceUnlinkedError is returned for sends from a linked number its primary device unlinked, Err is the error
+// of the send that found it unlinked
+type DeviceUnlinkedError struct {
+	Number string
+	Err    error
+}
+
+func (e *DeviceUnlinkedError) Error() string {
+	message := fmt.Sprintf("Signal number %s was unlinked by its primary device, link it again", e.Number)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *DeviceUnlinkedError) Unwrap() error {
+	return e.Err
+}
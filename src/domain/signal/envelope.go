@@ -0,0 +1,304 @@
+package signal
+
+import (
+	"strings"
+	"time"
+)
+
+// EnvelopeType classifies the content of a received envelope
+type EnvelopeType string
+
+const (
+	EnvelopeTypeDataMessage EnvelopeType = "data_message"
+	EnvelopeTypeReaction    EnvelopeType = "reaction"
+	EnvelopeTypeGroupUpdate EnvelopeType = "group_update"
+	EnvelopeTypeContact     EnvelopeType = "contact"
+	EnvelopeTypePayment     EnvelopeType = "payment"
+	EnvelopeTypeReceipt     EnvelopeType = "receipt"
+	EnvelopeTypeTyping      EnvelopeType = "typing"
+	EnvelopeTypeSync        EnvelopeType = "sync"
+	EnvelopeTypeUnknown     EnvelopeType = "unknown"
+)
+
+// ReceivedMessage represents a single message received by a registered account.
+// The JSON layout mirrors the output of signal-cli so it can be decoded directly.
+type ReceivedMessage struct {
+	Account  string   `json:"account"`
+	Envelope Envelope `json:"envelope"`
+}
+
+// Envelope represents the sender and content of a received message
+type Envelope struct {
+	Source                   string          `json:"source"`
+	SourceNumber             string          `json:"sourceNumber,omitempty"`
+	SourceUuid               string          `json:"sourceUuid,omitempty"`
+	SourceName               string          `json:"sourceName,omitempty"`
+	SourceDevice             int             `json:"sourceDevice"`
+	Timestamp                int64           `json:"timestamp"`
+	ServerReceivedTimestamp  int64           `json:"serverReceivedTimestamp,omitempty"`
+	ServerDeliveredTimestamp int64           `json:"serverDeliveredTimestamp,omitempty"`
+	DataMessage              *DataMessage    `json:"dataMessage,omitempty"`
+	ReceiptMessage           *ReceiptMessage `json:"receiptMessage,omitempty"`
+	TypingMessage            *TypingMessage  `json:"typingMessage,omitempty"`
+	SyncMessage              map[string]any  `json:"syncMessage,omitempty"`
+}
+
+// DataMessage represents a text message, reaction, group update, contact card or payment notification
+type DataMessage struct {
+	Timestamp        int64                `json:"timestamp"`
+	Message          *string              `json:"message"`
+	ExpiresInSeconds int                  `json:"expiresInSeconds"`
+	ViewOnce         bool                 `json:"viewOnce"`
+	Attachments      []ReceivedAttachment `json:"attachments,omitempty"`
+	Mentions         []ReceivedMention    `json:"mentions,omitempty"`
+	Quote            *Quote               `json:"quote,omitempty"`
+	Reaction         *Reaction            `json:"reaction,omitempty"`
+	GroupInfo        *GroupInfo           `json:"groupInfo,omitempty"`
+	Contacts         []SharedContact      `json:"contacts,omitempty"`
+	Payment          *Payment             `json:"payment,omitempty"`
+}
+
+// ReceivedAttachment represents an attachment of a received message
+type ReceivedAttachment struct {
+	ContentType string `json:"contentType"`
+	Filename    string `json:"filename,omitempty"`
+	ID          string `json:"id"`
+	Size        int64  `json:"size"`
+}
+
+// ReceivedMention represents a mention inside a received message
+type ReceivedMention struct {
+	Name   string `json:"name,omitempty"`
+	Number string `json:"number,omitempty"`
+	Uuid   string `json:"uuid,omitempty"`
+	Start  int    `json:"start"`
+	Length int    `json:"length"`
+}
+
+// Quote represents the message a received message is replying to
+type Quote struct {
+	ID           int64  `json:"id"`
+	Author       string `json:"author"`
+	AuthorNumber string `json:"authorNumber,omitempty"`
+	AuthorUuid   string `json:"authorUuid,omitempty"`
+	Text         string `json:"text"`
+}
+
+// Reaction represents an emoji reaction to a previously sent message
+type Reaction struct {
+	Emoji               string `json:"emoji"`
+	TargetAuthor        string `json:"targetAuthor"`
+	TargetAuthorNumber  string `json:"targetAuthorNumber,omitempty"`
+	TargetAuthorUuid    string `json:"targetAuthorUuid,omitempty"`
+	TargetSentTimestamp int64  `json:"targetSentTimestamp"`
+	IsRemove            bool   `json:"isRemove"`
+}
+
+// SharedContact represents a contact card shared in a data message
+type SharedContact struct {
+	Name         *ContactName     `json:"name,omitempty"`
+	Phone        []ContactDetail  `json:"phone,omitempty"`
+	Email        []ContactDetail  `json:"email,omitempty"`
+	Address      []ContactAddress `json:"address,omitempty"`
+	Organization string           `json:"organization,omitempty"`
+}
+
+// ContactName represents the name of a shared contact
+type ContactName struct {
+	Display string `json:"display,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Middle  string `json:"middle,omitempty"`
+	Family  string `json:"family,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	Suffix  string `json:"suffix,omitempty"`
+}
+
+// ContactDetail represents a phone number or email address of a shared contact, Type is e.g. "HOME" or "MOBILE"
+type ContactDetail struct {
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+	Label string `json:"label,omitempty"`
+}
+
+// ContactAddress represents a postal address of a shared contact
+type ContactAddress struct {
+	Type         string `json:"type,omitempty"`
+	Label        string `json:"label,omitempty"`
+	Street       string `json:"street,omitempty"`
+	Pobox        string `json:"pobox,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	City         string `json:"city,omitempty"`
+	Region       string `json:"region,omitempty"`
+	Postcode     string `json:"postcode,omitempty"`
+	Country      string `json:"country,omitempty"`
+}
+
+// Payment represents a payment notification, Receipt is the base64 encoded MobileCoin receipt
+type Payment struct {
+	Note    string `json:"note,omitempty"`
+	Receipt string `json:"receipt"`
+}
+
+// GroupInfo identifies the group a data message belongs to. Type is "UPDATE" for group changes.
+type GroupInfo struct {
+	GroupId   string `json:"groupId"`
+	GroupName string `json:"groupName,omitempty"`
+	Revision  int    `json:"revision,omitempty"`
+	Type      string `json:"type"`
+}
+
+// ReceiptMessage represents a delivery, read or viewed receipt for sent messages
+type ReceiptMessage struct {
+	When       int64   `json:"when"`
+	IsDelivery bool    `json:"isDelivery"`
+	IsRead     bool    `json:"isRead"`
+	IsViewed   bool    `json:"isViewed"`
+	Timestamps []int64 `json:"timestamps"`
+}
+
+// TypingMessage represents a typing indicator, Action is "STARTED" or "STOPPED"
+type TypingMessage struct {
+	Action    string `json:"action"`
+	Timestamp int64  `json:"timestamp"`
+	GroupId   string `json:"groupId,omitempty"`
+}
+
+// Type returns the kind of content carried by the envelope
+func (e *Envelope) Type() EnvelopeType {
+	switch {
+	case e.DataMessage != nil && e.DataMessage.Reaction != nil:
+		return EnvelopeTypeReaction
+	case e.DataMessage != nil && e.DataMessage.GroupInfo != nil && e.DataMessage.GroupInfo.Type == "UPDATE":
+		return EnvelopeTypeGroupUpdate
+	case e.DataMessage != nil && e.DataMessage.Payment != nil:
+		return EnvelopeTypePayment
+	case e.DataMessage != nil && len(e.DataMessage.Contacts) > 0:
+		return EnvelopeTypeContact
+	case e.DataMessage != nil:
+		return EnvelopeTypeDataMessage
+	case e.ReceiptMessage != nil:
+		return EnvelopeTypeReceipt
+	case e.TypingMessage != nil:
+		return EnvelopeTypeTyping
+	case e.SyncMessage != nil:
+		return EnvelopeTypeSync
+	default:
+		return EnvelopeTypeUnknown
+	}
+}
+
+// EnvelopeContent is the normalized content of a received envelope, stored and delivered to the hooks with the
+// envelope so subscribers don't have to pick it out of the signal-cli JSON. Only the part of the type is set.
+type EnvelopeContent struct {
+	Type     EnvelopeType     `json:"type"`
+	Text     *string          `json:"text,omitempty"`
+	Reaction *ReactionContent `json:"reaction,omitempty"`
+	Contacts []ContactCard    `json:"contacts,omitempty"`
+	Payment  *PaymentContent  `json:"payment,omitempty"`
+}
+
+// ReactionContent is the normalized content of a reaction, Removed is set when the emoji was taken back
+type ReactionContent struct {
+	Emoji               string `json:"emoji"`
+	TargetAuthor        string `json:"target_author"`
+	TargetSentTimestamp int64  `json:"target_sent_timestamp"`
+	Removed             bool   `json:"removed"`
+}
+
+// ContactCard is the normalized content of a shared contact
+type ContactCard struct {
+	Name         string   `json:"name"`
+	Phones       []string `json:"phones,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+}
+
+// PaymentContent is the normalized content of a payment notification
+type PaymentContent struct {
+	Note    string `json:"note,omitempty"`
+	Receipt string `json:"receipt"`
+}
+
+// Content returns the normalized content of a data message, reaction, contact card or payment notification, nil
+// for the other types
+func (e *Envelope) Content() *EnvelopeContent {
+	envelopeType := e.Type()
+	content := &EnvelopeContent{Type: envelopeType}
+	switch envelopeType {
+	case EnvelopeTypeDataMessage:
+		content.Text = e.DataMessage.Message
+	case EnvelopeTypeReaction:
+		reaction := e.DataMessage.Reaction
+		content.Reaction = &ReactionContent{
+			Emoji:               reaction.Emoji,
+			TargetAuthor:        reaction.TargetAuthor,
+			TargetSentTimestamp: reaction.TargetSentTimestamp,
+			Removed:             reaction.IsRemove,
+		}
+	case EnvelopeTypeContact:
+		content.Text = e.DataMessage.Message
+		content.Contacts = make([]ContactCard, len(e.DataMessage.Contacts))
+		for i, contact := range e.DataMessage.Contacts {
+			content.Contacts[i] = contact.Card()
+		}
+	case EnvelopeTypePayment:
+		content.Payment = &PaymentContent{Note: e.DataMessage.Payment.Note, Receipt: e.DataMessage.Payment.Receipt}
+	default:
+		return nil
+	}
+	return content
+}
+
+// Card returns the normalized content of a shared contact. The name is the display name if set, otherwise it is
+// put together from its parts.
+func (c SharedContact) Card() ContactCard {
+	card := ContactCard{Organization: c.Organization}
+	if c.Name != nil {
+		card.Name = c.Name.Display
+		if card.Name == "" {
+			var parts []string
+			for _, part := range []string{c.Name.Prefix, c.Name.Given, c.Name.Middle, c.Name.Family, c.Name.Suffix} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			card.Name = strings.Join(parts, " ")
+		}
+	}
+	for _, phone := range c.Phone {
+		card.Phones = append(card.Phones, phone.Value)
+	}
+	for _, email := range c.Email {
+		card.Emails = append(card.Emails, email.Value)
+	}
+	return card
+}
+
+// ReceivedEnvelope is the stored normalized content of a received envelope that isn't a text message
+type ReceivedEnvelope struct {
+	ID        int
+	Account   string
+	Source    string
+	Timestamp int64
+	Type      EnvelopeType
+	Content   EnvelopeContent
+	CreatedAt time.Time
+}
+
+// SenderID identifies the sender of the envelope, the number if known and the uuid otherwise
+func (e *Envelope) SenderID() string {
+	if e.Source != "" {
+		return e.Source
+	}
+	if e.SourceNumber != "" {
+		return e.SourceNumber
+	}
+	return e.SourceUuid
+}
+
+// ReceiveWatermark is the highest envelope timestamp routed for an account
+type ReceiveWatermark struct {
+	Account   string
+	Timestamp int64
+	UpdatedAt time.Time
+}
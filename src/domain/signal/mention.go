@@ -0,0 +1,105 @@
+package signal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// groupRecipientPrefix starts the recipients that are Signal groups
+const groupRecipientPrefix = "group."
+
+// GroupMember is a member of a Signal group with the name the account knows it by
+type GroupMember struct {
+	Number string `json:"number,omitempty"`
+	UUID   string `json:"uuid,omitempty"`
+	// Name is the contact name, or the profile name when the member isn't a contact, empty when neither is known
+	Name string `json:"name,omitempty"`
+}
+
+// Identifier is the recipient a mention of the member names, its number or its UUID when it hides its number
+func (m GroupMember) Identifier() string {
+	if m.Number != "" {
+		return m.Number
+	}
+	return m.UUID
+}
+
+// IsGroupRecipient reports whether a recipient is a Signal group id
+func IsGroupRecipient(recipient string) bool {
+	return strings.HasPrefix(recipient, groupRecipientPrefix)
+}
+
+// ResolveMentions finds the @name tokens of a message that name a group member and returns their mentions. A token
+// starts at an @ at the start of the message or after a space or punctuation and is matched against the member
+// names case-insensitively, the longest name wins so names with spaces can be mentioned. Start and Length count
+// UTF-16 code units like Signal does. Tokens naming no member are left as text, and so are tokens naming several
+// members, which are returned as ambiguous.
+func ResolveMentions(message string, members []GroupMember) ([]MessageMention, []string) {
+	var mentions []MessageMention
+	var ambiguous []string
+	runes := []rune(message)
+	offset := 0 // UTF-16 offset of runes[i]
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && !isMentionBoundary(runes[i-1])) {
+			offset += utf16.RuneLen(runes[i])
+			continue
+		}
+
+		name, matches := matchMemberName(runes[i+1:], members)
+		if len(matches) == 0 {
+			offset += utf16.RuneLen(runes[i])
+			continue
+		}
+		token := append([]rune{'@'}, runes[i+1:i+1+len(name)]...)
+		length := len(utf16.Encode(token))
+		if len(matches) > 1 {
+			ambiguous = append(ambiguous, string(token))
+		} else {
+			mentions = append(mentions, MessageMention{Start: int64(offset), Length: int64(length), Author: matches[0].Identifier()})
+		}
+		offset += length
+		i += len(name)
+	}
+	return mentions, ambiguous
+}
+
+// matchMemberName returns the longest member name text starts with, followed by the end of the text or a
+// boundary, and the members with that name
+func matchMemberName(text []rune, members []GroupMember) ([]rune, []GroupMember) {
+	var name []rune
+	var matches []GroupMember
+	for _, member := range members {
+		memberName := []rune(member.Name)
+		if len(memberName) == 0 || len(memberName) > len(text) || len(memberName) < len(name) {
+			continue
+		}
+		if !strings.EqualFold(string(text[:len(memberName)]), member.Name) {
+			continue
+		}
+		if len(memberName) < len(text) && !isMentionBoundary(text[len(memberName)]) {
+			continue
+		}
+		if len(memberName) > len(name) {
+			matches = nil
+		}
+		name = text[:len(memberName)]
+		if !containsMember(matches, member) {
+			matches = append(matches, member)
+		}
+	}
+	return name, matches
+}
+
+func containsMember(members []GroupMember, member GroupMember) bool {
+	for _, m := range members {
+		if m.Identifier() == member.Identifier() {
+			return true
+		}
+	}
+	return false
+}
+
+func isMentionBoundary(r rune) bool {
+	return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '@' && r != '_' && r != '-')
+}
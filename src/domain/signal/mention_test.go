@@ -0,0 +1,46 @@
+package signal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveMentions(t *testing.T) {
+	members := []GroupMember{
+		{Number: "+4912345", Name: "Alice"},
+		{Number: "+4954321", UUID: "0d2f6a1e", Name: "Alice Smith"},
+		{UUID: "7c9e2b4a", Name: "Bob"},
+		{Number: "+4911111", Name: "Carol"},
+		{Number: "+4922222", Name: "carol"},
+		{Number: "+4933333"},
+	}
+
+	mentions, ambiguous := ResolveMentions("@alice smith and @Alice, please call @Bob (not bob@example.com or @Dave)", members)
+	assert.Equal(t, []MessageMention{
+		{Start: 0, Length: 12, Author: "+4954321"},
+		{Start: 17, Length: 6, Author: "+4912345"},
+		{Start: 37, Length: 4, Author: "7c9e2b4a"},
+	}, mentions)
+	assert.Empty(t, ambiguous)
+
+	// Names several members share are left as text
+	mentions, ambiguous = ResolveMentions("@Carol: deploy", members)
+	assert.Empty(t, mentions)
+	assert.Equal(t, []string{"@Carol"}, ambiguous)
+
+	// A name followed by more letters names somebody else
+	mentions, _ = ResolveMentions("@Bobby", members)
+	assert.Empty(t, mentions)
+}
+
+func TestResolveMentions_CountsUTF16(t *testing.T) {
+	// The emoji takes two UTF-16 code units, like Signal counts the start of a mention
+	mentions, _ := ResolveMentions("🚨 @Bob", []GroupMember{{Number: "+4912345", Name: "Bob"}})
+	assert.Equal(t, []MessageMention{{Start: 3, Length: 4, Author: "+4912345"}}, mentions)
+}
+
+func TestIsGroupRecipient(t *testing.T) {
+	assert.True(t, IsGroupRecipient("group.abc"))
+	assert.False(t, IsGroupRecipient("+4912345"))
+}
@@ -0,0 +1,43 @@
+package signal
+
+import "time"
+
+// Health statuses of a Signal number
+const (
+	// NumberHealthHealthy sent and received without errors lately
+	NumberHealthHealthy = "healthy"
+	// NumberHealthDegraded failed repeatedly in a row, for reasons an operator can't act on yet
+	NumberHealthDegraded = "degraded"
+	// NumberHealthNeedsAttention can't send until an operator took the action of its action code
+	NumberHealthNeedsAttention = "needs_attention"
+)
+
+// Action codes telling operators what a number needs
+const (
+	// NumberActionNeedsCaptcha was rate limited with a challenge, solving its captcha lifts the rate limit
+	NumberActionNeedsCaptcha = "needs_captcha"
+	// NumberActionNeedsReverify isn't registered or linked on the backend any more, it has to be registered and
+	// verified, or linked, again
+	NumberActionNeedsReverify = "needs_reverify"
+	// NumberActionRateLimited was rate limited without a challenge, it recovers by sending less
+	NumberActionRateLimited = "rate_limited"
+)
+
+// NumberHealth is the health of a Signal number, tracked from the errors of its sends and its receive stream.
+// The counters add up since the number was last resolved, the streak of failures since its last success.
+type NumberHealth struct {
+	Number     string
+	Status     string
+	ActionCode string
+	// SendFailures are failed sends that weren't caused by their recipients
+	SendFailures int
+	// UnregisteredErrors are sends to recipients without a Signal account
+	UnregisteredErrors  int
+	ReceiveErrors       int
+	ConsecutiveFailures int
+	LastError           string
+	LastErrorAt         *time.Time
+	LastSuccessAt       *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
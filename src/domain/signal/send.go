@@ -0,0 +1,88 @@
+package signal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MessageMention mentions a member in the text of a message, from Start for Length characters
+type MessageMention struct {
+	Start  int64  `json:"start"`
+	Length int64  `json:"length"`
+	Author string `json:"author"`
+}
+
+func (m *MessageMention) ToString() string {
+	return fmt.Sprintf("%d:%d:%s", m.Start, m.Length, m.Author)
+}
+
+// LinkPreview is the preview shown for a link in the text of a message
+type LinkPreview struct {
+	Url             string `json:"url"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Base64Thumbnail string `json:"base64_thumbnail"`
+}
+
+// SendRequest is a message sent through Signal. It is shared by the REST controller, the message processor
+// and the Signal backends, the JSON form is what the processor stores as the request data of a message.
+type SendRequest struct {
+	Number            string           `json:"number"`
+	Recipients        []string         `json:"recipients"`
+	Message           string           `json:"message"`
+	Base64Attachments []string         `json:"base64_attachments"`
+	Sticker           string           `json:"sticker"`
+	Mentions          []MessageMention `json:"mentions"`
+	QuoteTimestamp    *int64           `json:"quote_timestamp"`
+	QuoteAuthor       *string          `json:"quote_author"`
+	QuoteMessage      *string          `json:"quote_message"`
+	QuoteMentions     []MessageMention `json:"quote_mentions"`
+	// TextMode is normal or styled, DEFAULT_SIGNAL_TEXT_MODE applies when it isn't set
+	TextMode      *string      `json:"text_mode"`
+	EditTimestamp *int64       `json:"edit_timestamp"`
+	NotifySelf    *bool        `json:"notify_self"`
+	LinkPreview   *LinkPreview `json:"link_preview"`
+	ViewOnce      *bool        `json:"view_once"`
+}
+
+// Validate checks the options that can't be combined or are malformed, the recipients are checked when
+// they are split up by type
+func (r *SendRequest) Validate() error {
+	if r.Number == "" {
+		return errors.New("Please provide a valid number")
+	}
+	if len(r.Recipients) == 0 {
+		return errors.New("Please provide at least one recipient")
+	}
+	if r.Sticker != "" && !strings.Contains(r.Sticker, ":") {
+		return errors.New("Please provide valid sticker delimiter")
+	}
+	if r.ViewOnce != nil && *r.ViewOnce && len(r.Base64Attachments) == 0 {
+		return errors.New("'view_once' can only be set for image attachments!")
+	}
+	return nil
+}
+
+// DeliveryID identifies the delivery of a sent message to a recipient. Signal identifies messages by their
+// timestamp, which is shared by the recipients sent to at once, and receipts name it and come from the recipient.
+func DeliveryID(timestamp int64, recipient string) string {
+	return fmt.Sprintf("%d:%s", timestamp, recipient)
+}
+
+// JoinGroupResponse is the result of joining a group from an invite link
+type JoinGroupResponse struct {
+	Id            string `json:"id"`
+	OnlyRequested bool   `json:"only_requested"`
+}
+
+// RateLimitError is returned when Signal rate limited the account. Solving the captcha of one of the
+// challenge tokens lifts the rate limit, see RateLimitChallenge.
+type RateLimitError struct {
+	ChallengeTokens []string
+	Err             error
+}
+
+func (r *RateLimitError) Error() string {
+	return r.Err.Error()
+}
@@ -0,0 +1,37 @@
+package signal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendRequest_Validate(t *testing.T) {
+	viewOnce := true
+	tests := []struct {
+		name    string
+		request SendRequest
+		err     string
+	}{
+		{"valid", SendRequest{Number: "+4999999", Recipients: []string{"+4912345"}}, ""},
+		{"no number", SendRequest{Recipients: []string{"+4912345"}}, "Please provide a valid number"},
+		{"no recipients", SendRequest{Number: "+4999999"}, "Please provide at least one recipient"},
+		{"sticker without delimiter", SendRequest{Number: "+4999999", Recipients: []string{"+4912345"}, Sticker: "abc"}, "Please provide valid sticker delimiter"},
+		{"view once without attachment", SendRequest{Number: "+4999999", Recipients: []string{"+4912345"}, ViewOnce: &viewOnce}, "'view_once' can only be set for image attachments!"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.request.Validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestMessageMention_ToString(t *testing.T) {
+	mention := MessageMention{Start: 4, Length: 6, Author: "+4912345"}
+	assert.Equal(t, "4:6:+4912345", mention.ToString())
+}
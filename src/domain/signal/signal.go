@@ -48,7 +48,7 @@ type IdentityEntry struct {
 
 // SendResponse represents a response from a send operation
 type SendResponse struct {
-	Timestamp int64
+	Timestamp int64 `json:"timestamp"`
 }
 
 // SearchResultEntry represents a search result
@@ -57,6 +57,15 @@ type SearchResultEntry struct {
 	UUID   string
 }
 
+// ResolvedRecipient is the Signal account a recipient resolves to. Number is empty when the account hides its
+// number, messages then go to the UUID.
+type ResolvedRecipient struct {
+	Recipient  string `json:"recipient"`
+	Number     string `json:"number,omitempty"`
+	UUID       string `json:"uuid,omitempty"`
+	Registered bool   `json:"registered"`
+}
+
 // ISignalService defines the interface for signal service operations
 type ISignalService interface {
 	// Account operations
@@ -64,21 +73,35 @@ type ISignalService interface {
 	VerifyRegisteredNumber(number string, token string, pin string) error
 	UnregisterNumber(number string, deleteAccount bool, deleteLocalData bool) error
 	GetAccounts() ([]string, error)
+	SetPin(number string, registrationLockPin string) error
+	RemovePin(number string) error
+	SubmitRateLimitChallenge(number string, challengeToken string, captcha string) error
 	
 	// Messaging operations
-	Send(number string, message string, recipients []string, attachments []string, isGroup bool) (*SendResponse, error)
-	Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) (string, error)
+	Send(request SendRequest) (*[]SendResponse, error)
+	Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]ReceivedMessage, error)
+	// RemoteDelete deletes a sent message, identified by the timestamp of its send, for the recipients and groups
+	RemoteDelete(number string, recipients []string, timestamp int64) error
+	// ResolveRecipients looks up the accounts of phone numbers, usernames (u:name) and phone number identities
+	// (PNI:uuid) with the account of number
+	ResolveRecipients(number string, recipients []string) ([]ResolvedRecipient, error)
 	
 	// Group operations
 	CreateGroup(number string, name string, members []string, description string, editGroupPermission GroupPermission, addMembersPermission GroupPermission, groupLinkState GroupLinkState, expirationTime *int) (string, error)
 	GetGroups(number string) ([]GroupEntry, error)
 	GetGroup(number string, groupId string) (*GroupEntry, error)
+	// GetGroupMembers lists the members of a group with their contact or profile names, to resolve mentions
+	GetGroupMembers(number string, groupId string) ([]GroupMember, error)
 	UpdateGroup(number string, groupId string, avatar *string, description *string, name *string, expirationTime *int, groupLinkState *GroupLinkState) error
 	DeleteGroup(number string, groupId string) error
 	AddMembersToGroup(number string, groupId string, members []string) error
 	RemoveMembersFromGroup(number string, groupId string, members []string) error
 	AddAdminsToGroup(number string, groupId string, admins []string) error
 	RemoveAdminsFromGroup(number string, groupId string, admins []string) error
+	GetGroupInviteLink(number string, groupId string) (string, error)
+	ResetGroupInviteLink(number string, groupId string) (string, error)
+	GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error)
+	JoinGroupByLink(number string, uri string) (*JoinGroupResponse, error)
 	
 	// Identity operations
 	ListIdentities(number string) (*[]IdentityEntry, error)
@@ -86,4 +109,24 @@ type ISignalService interface {
 	
 	// QR code operations
 	GetQrCodeLink(deviceName string, qrCodeVersion int) ([]byte, error)
-}
\ No newline at end of file
+}
+// RegistrationLock represents the registration lock state of a registered number.
+// The PIN is only ever stored encrypted.
+type RegistrationLock struct {
+	ID           int
+	Number       string
+	Enabled      bool
+	EncryptedPin string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RateLimitChallenge is a challenge token Signal returned when it rate limited an account. Solving the
+// captcha of a challenge and submitting it lifts the rate limit of the account.
+type RateLimitChallenge struct {
+	ID        int
+	Account   string
+	Token     string
+	LiftedAt  *time.Time // set once a captcha was accepted for the account
+	CreatedAt time.Time
+}
@@ -0,0 +1,32 @@
+package signal
+
+import "time"
+
+// Channels a verification code is sent over
+const (
+	VerificationChannelSMS   = "sms"
+	VerificationChannelVoice = "voice"
+)
+
+// Statuses of a verification attempt
+const (
+	// VerificationAttemptSent requested a code, which wasn't verified yet
+	VerificationAttemptSent = "sent"
+	// VerificationAttemptFailed was refused by Signal, no code was sent
+	VerificationAttemptFailed = "failed"
+	// VerificationAttemptExpired wasn't verified in time, or was replaced by a newer attempt
+	VerificationAttemptExpired = "expired"
+	// VerificationAttemptVerified was verified with its code, the number is registered
+	VerificationAttemptVerified = "verified"
+)
+
+// VerificationAttempt is a request for a verification code of a number to be registered, over SMS or a voice call
+type VerificationAttempt struct {
+	ID        int
+	Number    string
+	Channel   string
+	Status    string
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
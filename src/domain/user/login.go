@@ -0,0 +1,27 @@
+package user
+
+import "time"
+
+// LoginEvent records a login attempt of a user
+type LoginEvent struct {
+	ID            int
+	UserID        int
+	Method        string // password or azure_ad
+	IPAddress     string
+	UserAgent     string
+	Success       bool
+	FailureReason string
+	NewLocation   bool // the login came from an IP address or device not seen in earlier logins
+	CreatedAt     time.Time
+}
+
+// LoginNotificationSettings configures where a user is notified about suspicious logins
+type LoginNotificationSettings struct {
+	ID        int
+	UserID    int
+	Channel   string // provider type the notifications are sent through, e.g. signal or email
+	Recipient string // where the notifications are sent on the selected channel
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -17,8 +17,29 @@ type User struct {
 	Password         string
 	MessageRateLimit int    // Maximum number of messages allowed per day
 	Role             string // Role can be "admin" or "member"
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	Locale           string // Locale of error messages and webhook reasons when a request names none, e.g. "de"
+	// RecipientHourlyCap and RecipientDailyCap limit the messages sent to the same recipient per hour and per
+	// day, 0 applies the global caps and -1 lifts the cap for the user
+	RecipientHourlyCap int
+	RecipientDailyCap  int
+	// EngagementTracking records the deliveries, reads and clicks of each recipient of the user's messages
+	EngagementTracking bool
+	// DeactivatedAt is when the user was last deactivated, the tokens issued before stay revoked after a
+	// reactivation
+	DeactivatedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ErrorCodeDeactivated is the code of the errors refusing the requests and messages of a deactivated user
+const ErrorCodeDeactivated = "user_deactivated"
+
+// Active reports whether the user may call the API with a token issued at issuedAt
+func (u *User) Active(issuedAt time.Time) bool {
+	if !u.Status {
+		return false
+	}
+	return u.DeactivatedAt == nil || issuedAt.After(*u.DeactivatedAt)
 }
 
 type SearchResultUser struct {
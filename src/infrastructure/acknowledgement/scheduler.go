@@ -0,0 +1,77 @@
+package acknowledgement
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/acknowledgement"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically expires messages whose acknowledgement deadline passed, on the leader instance only
+type Scheduler struct {
+	acknowledgementUseCase acknowledgement.IAcknowledgementUseCase
+	elector                leader.Elector
+	Logger                 *logger.Logger
+	interval               time.Duration
+	shutdown               chan struct{}
+	done                   chan struct{}
+}
+
+// NewScheduler creates a new acknowledgement expiry scheduler and starts it
+func NewScheduler(acknowledgementUseCase acknowledgement.IAcknowledgementUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second // Default to checking every 30 seconds if not specified
+	}
+
+	scheduler := &Scheduler{
+		acknowledgementUseCase: acknowledgementUseCase,
+		elector:                elector,
+		Logger:                 loggerInstance,
+		interval:               interval,
+		shutdown:               make(chan struct{}),
+		done:                   make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting acknowledgement expiry scheduler", zap.Duration("interval", s.interval))
+
+	// Expire deadlines that passed while the service was down
+	s.processExpiredAcks()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.processExpiredAcks()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) processExpiredAcks() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.acknowledgementUseCase.ProcessExpiredAcks(time.Now()); err != nil {
+		s.Logger.Error("Error processing expired acknowledgements", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
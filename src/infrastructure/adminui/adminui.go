@@ -0,0 +1,64 @@
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Path is the path the admin UI is served at
+const Path = "/admin"
+
+// contentSecurityPolicy keeps the UI to its own scripts and styles and the API of its origin
+const contentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'self'"
+
+// assets are the files of the single-page admin UI
+//
+//go:embed static
+var assets embed.FS
+
+// Config holds the admin UI settings
+type Config struct {
+	// Enabled serves the admin UI, it is off by default
+	Enabled bool
+}
+
+// LoadConfig loads the admin UI settings from environment variables
+func LoadConfig() Config {
+	return Config{
+		Enabled: utils.GetEnv("ADMIN_UI_ENABLED", "false") == "true",
+	}
+}
+
+// Handler serves the embedded admin UI below Path. Unknown paths get the index, so the routes of the UI can be
+// reloaded and bookmarked.
+func Handler() gin.HandlerFunc {
+	files, err := fs.Sub(assets, "static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.StripPrefix(Path, http.FileServer(http.FS(files)))
+
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", contentSecurityPolicy)
+
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+		if name == "" || !exists(files, name) {
+			c.FileFromFS("/", http.FS(files))
+			return
+		}
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// exists reports whether an asset is a file
+func exists(files fs.FS, name string) bool {
+	info, err := fs.Stat(files, name)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,57 @@
+package adminui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(Path, Handler())
+	router.GET(Path+"/*filepath", Handler())
+	return router
+}
+
+func get(router *gin.Engine, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestHandler_ServesAssets(t *testing.T) {
+	router := setupRouter()
+
+	w := get(router, "/admin/app.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Contains(t, w.Body.String(), "/auth/login")
+	assert.Equal(t, contentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+
+	w = get(router, "/admin/app.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+}
+
+func TestHandler_FallsBackToIndex(t *testing.T) {
+	router := setupRouter()
+
+	for _, target := range []string{"/admin", "/admin/", "/admin/providers", "/admin/../../etc/passwd"} {
+		w := get(router, target)
+		assert.Equal(t, http.StatusOK, w.Code, target)
+		assert.Contains(t, w.Body.String(), `<script src="/admin/app.js" defer></script>`, target)
+		assert.Equal(t, contentSecurityPolicy, w.Header().Get("Content-Security-Policy"), target)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("ADMIN_UI_ENABLED", "")
+	assert.False(t, LoadConfig().Enabled)
+
+	t.Setenv("ADMIN_UI_ENABLED", "true")
+	assert.True(t, LoadConfig().Enabled)
+}
@@ -0,0 +1,366 @@
+// Admin UI of go-multi-chat-api. It talks to the REST API of its own origin with the tokens of an admin,
+// kept in the session storage of the tab.
+"use strict";
+
+const api = "/v1";
+const session = window.sessionStorage;
+
+function showError(message) {
+  const error = document.getElementById("error");
+  error.textContent = message || "";
+  error.hidden = !message;
+}
+
+function loggedIn() {
+  return Boolean(session.getItem("accessToken"));
+}
+
+function logout() {
+  session.removeItem("accessToken");
+  session.removeItem("refreshToken");
+  render();
+}
+
+async function refreshToken() {
+  const refresh = session.getItem("refreshToken");
+  if (!refresh) {
+    return false;
+  }
+  const response = await fetch(api + "/auth/access-token", {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({refreshToken: refresh}),
+  });
+  if (!response.ok) {
+    return false;
+  }
+  const body = await response.json();
+  session.setItem("accessToken", body.security.jwtAccessToken);
+  return true;
+}
+
+// request calls the API, refreshing the access token once when it expired
+async function request(method, path, body, retried) {
+  const headers = {"Authorization": "Bearer " + session.getItem("accessToken")};
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const response = await fetch(api + path, {method, headers, body: body === undefined ? undefined : JSON.stringify(body)});
+  if (response.status === 401 && !retried && await refreshToken()) {
+    return request(method, path, body, true);
+  }
+  if (response.status === 401) {
+    logout();
+    throw new Error("Your session expired, log in again");
+  }
+  const text = await response.text();
+  const data = text ? JSON.parse(text) : null;
+  if (!response.ok) {
+    let message = (data && data.error) || response.statusText;
+    if (data && Array.isArray(data.errors)) {
+      message += ": " + data.errors.map((e) => (e.field ? e.field + " " : "") + e.message).join("; ");
+    }
+    throw new Error(message);
+  }
+  return data;
+}
+
+function cell(row, value) {
+  const td = document.createElement("td");
+  td.textContent = value === undefined || value === null ? "" : String(value);
+  row.appendChild(td);
+  return td;
+}
+
+function button(parent, label, onClick) {
+  const b = document.createElement("button");
+  b.type = "button";
+  b.textContent = label;
+  b.addEventListener("click", onClick);
+  parent.appendChild(b);
+  return b;
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+// guarded runs a handler and shows what went wrong
+function guarded(handler) {
+  return async (event) => {
+    if (event) {
+      event.preventDefault();
+    }
+    showError("");
+    try {
+      await handler(event);
+    } catch (err) {
+      showError(err.message);
+    }
+  };
+}
+
+// Providers
+
+async function loadProviders() {
+  const [providers, types] = await Promise.all([request("GET", "/providers/"), request("GET", "/providers/types")]);
+  const select = document.getElementById("provider-types");
+  select.replaceChildren(...types.map((t) => new Option(t.type, t.type)));
+
+  const rows = document.getElementById("providers");
+  rows.replaceChildren();
+  for (const provider of providers) {
+    const row = rows.insertRow();
+    cell(row, provider.id);
+    cell(row, provider.name);
+    cell(row, provider.type);
+    cell(row, provider.description);
+    cell(row, provider.status ? "yes" : "no");
+    cell(row, formatTime(provider.updated_at));
+    const actions = cell(row, "");
+    button(actions, "Edit", () => editProvider(provider));
+    button(actions, provider.status ? "Deactivate" : "Activate", guarded(async () => {
+      await request("PUT", "/providers/" + provider.id, {status: !provider.status, version: provider.version});
+      await loadProviders();
+    }));
+  }
+}
+
+function editProvider(provider) {
+  const form = document.getElementById("provider-form");
+  document.getElementById("provider-form-title").textContent = "Edit " + provider.name;
+  form.elements.id.value = provider.id;
+  form.elements.version.value = provider.version;
+  form.elements.name.value = provider.name;
+  form.elements.type.value = provider.type;
+  form.elements.description.value = provider.description;
+  form.elements.config.value = "";
+  form.elements.status.checked = provider.status;
+  form.scrollIntoView();
+}
+
+async function saveProvider() {
+  const form = document.getElementById("provider-form");
+  const fields = form.elements;
+  const body = {
+    name: fields.name.value,
+    type: fields.type.value,
+    description: fields.description.value,
+    status: fields.status.checked,
+  };
+  if (fields.config.value.trim() !== "") {
+    try {
+      body.config = JSON.parse(fields.config.value);
+    } catch (err) {
+      throw new Error("The config is not valid JSON: " + err.message);
+    }
+  }
+  if (fields.id.value) {
+    body.version = Number(fields.version.value);
+    await request("PUT", "/providers/" + fields.id.value, body);
+  } else {
+    await request("POST", "/providers/", body);
+  }
+  resetProviderForm();
+  await loadProviders();
+}
+
+function resetProviderForm() {
+  const form = document.getElementById("provider-form");
+  form.reset();
+  form.elements.id.value = "";
+  form.elements.version.value = "";
+  document.getElementById("provider-form-title").textContent = "New provider";
+}
+
+// Messages
+
+let nextMessagesCursor = "";
+
+// searchMessages shows the first page of the search, or appends the next page when more is set
+async function searchMessages(more) {
+  const fields = document.getElementById("message-search").elements;
+  const query = new URLSearchParams();
+  if (fields.status.value) {
+    query.set("status", fields.status.value);
+  }
+  if (fields.tag.value) {
+    query.set("tag", fields.tag.value);
+  }
+  if (fields.limit.value) {
+    query.set("limit", fields.limit.value);
+  }
+  if (more === true && nextMessagesCursor) {
+    query.set("cursor", nextMessagesCursor);
+  }
+  const page = await request("GET", "/send/users/" + encodeURIComponent(fields.user_id.value) + "/messages?" + query);
+  const rows = document.getElementById("messages");
+  if (more !== true) {
+    rows.replaceChildren();
+  }
+  for (const message of page.data) {
+    const row = rows.insertRow();
+    cell(row, message.message_id);
+    cell(row, message.provider_id);
+    cell(row, message.status);
+    cell(row, message.recipients);
+    cell(row, message.message);
+    cell(row, message.error_message);
+    cell(row, message.retry_count);
+    cell(row, formatTime(message.processed_at));
+  }
+  if (rows.rows.length === 0) {
+    cell(rows.insertRow(), "No messages found").colSpan = 8;
+  }
+  nextMessagesCursor = page.next_cursor || "";
+  document.getElementById("more-messages").hidden = !nextMessagesCursor;
+}
+
+// Queue
+
+const queueLabels = {
+  queue_depth: "Queued in memory",
+  queue_capacity: "Queue capacity",
+  backlog: "Pending backlog",
+  backlog_threshold: "Backlog threshold",
+  deferred: "Deferred",
+  rejected: "Rejected",
+  processing: "Processing",
+  failed_awaiting_retry: "Failed, awaiting retry",
+  held: "Held",
+  lag_seconds: "Lag (seconds)",
+  lag_level: "Lag level",
+  metrics_refreshed_at: "Metrics refreshed",
+};
+
+async function loadQueue() {
+  const stats = await request("GET", "/send/queue");
+  const list = document.getElementById("queue");
+  if (!list) {
+    return;
+  }
+  list.replaceChildren();
+  for (const [key, label] of Object.entries(queueLabels)) {
+    const term = document.createElement("dt");
+    term.textContent = label;
+    const value = document.createElement("dd");
+    value.textContent = key === "metrics_refreshed_at" ? formatTime(stats[key]) : String(stats[key]);
+    if (key === "lag_level") {
+      value.className = "level-" + stats[key];
+    }
+    list.append(term, value);
+  }
+}
+
+// Users
+
+async function loadUsers() {
+  const users = await request("GET", "/user/");
+  const rows = document.getElementById("users");
+  rows.replaceChildren();
+  for (const user of users) {
+    const row = rows.insertRow();
+    cell(row, user.id);
+    cell(row, user.user);
+    cell(row, user.email);
+    cell(row, user.firstName + " " + user.lastName);
+    cell(row, user.role);
+    cell(row, user.locale || "default");
+    cell(row, user.status ? "yes" : "no");
+    const actions = cell(row, "");
+    button(actions, user.role === "admin" ? "Make member" : "Make admin", guarded(async () => {
+      await request("PUT", "/user/" + user.id, {role: user.role === "admin" ? "member" : "admin"});
+      await loadUsers();
+    }));
+    button(actions, user.status ? "Deactivate" : "Activate", guarded(async () => {
+      await request("PUT", "/user/" + user.id, {status: !user.status});
+      await loadUsers();
+    }));
+    button(actions, "Delete", guarded(async () => {
+      if (window.confirm("Delete " + user.email + "?")) {
+        await request("DELETE", "/user/" + user.id);
+        await loadUsers();
+      }
+    }));
+  }
+}
+
+async function createUser() {
+  const form = document.getElementById("user-form");
+  const body = Object.fromEntries(new FormData(form));
+  await request("POST", "/user/", body);
+  form.reset();
+  await loadUsers();
+}
+
+// Views
+
+let queueTimer = null;
+
+const views = {
+  "/providers": () => {
+    document.getElementById("provider-form").addEventListener("submit", guarded(saveProvider));
+    document.getElementById("provider-form").addEventListener("reset", () => setTimeout(resetProviderForm));
+    return loadProviders();
+  },
+  "/messages": () => {
+    document.getElementById("message-search").addEventListener("submit", guarded(() => searchMessages(false)));
+    document.getElementById("more-messages").addEventListener("click", guarded(() => searchMessages(true)));
+  },
+  "/queue": () => {
+    queueTimer = setInterval(guarded(loadQueue), 10000);
+    return loadQueue();
+  },
+  "/users": () => {
+    document.getElementById("user-form").addEventListener("submit", guarded(createUser));
+    return loadUsers();
+  },
+};
+
+function show(templateID) {
+  const view = document.getElementById("view");
+  view.replaceChildren(document.getElementById(templateID).content.cloneNode(true));
+}
+
+async function login() {
+  const fields = document.getElementById("login").elements;
+  const response = await fetch(api + "/auth/login", {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({email: fields.email.value, password: fields.password.value}),
+  });
+  const body = await response.json();
+  if (!response.ok) {
+    throw new Error(body.error || response.statusText);
+  }
+  session.setItem("accessToken", body.security.jwtAccessToken);
+  session.setItem("refreshToken", body.security.jwtRefreshToken);
+  render();
+}
+
+function render() {
+  clearInterval(queueTimer);
+  showError("");
+  const nav = document.getElementById("nav");
+  if (!loggedIn()) {
+    nav.hidden = true;
+    show("login-view");
+    document.getElementById("login").addEventListener("submit", guarded(login));
+    return;
+  }
+  nav.hidden = false;
+
+  let route = window.location.hash.slice(1);
+  if (!views[route]) {
+    route = "/providers";
+  }
+  for (const link of nav.querySelectorAll("a")) {
+    link.classList.toggle("active", link.getAttribute("href") === "#" + route);
+  }
+  show(route.slice(1) + "-view");
+  guarded(views[route])();
+}
+
+document.getElementById("logout").addEventListener("click", logout);
+window.addEventListener("hashchange", render);
+render();
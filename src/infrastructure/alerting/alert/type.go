@@ -11,4 +11,22 @@ const (
 
 	// TypeSignal is the Type for the signal alerting provider
 	TypeSignal Type = "signal"
+
+	// TypeMatrix is the Type for the matrix alerting provider
+	TypeMatrix Type = "matrix"
+
+	// TypeDiscord is the Type for the discord alerting provider
+	TypeDiscord Type = "discord"
+
+	// TypeTelegram is the Type for the telegram alerting provider
+	TypeTelegram Type = "telegram"
+
+	// TypeLine is the Type for the LINE alerting provider
+	TypeLine Type = "line"
+
+	// TypeSMS is the Type for the SMS alerting provider
+	TypeSMS Type = "sms"
+
+	// TypeSandbox is the Type for the sandbox provider, which sends nowhere and is used for load tests
+	TypeSandbox Type = "sandbox"
 )
@@ -1,12 +1,15 @@
 package alerting
 
 import (
+	"fmt"
+	"os"
 	"reflect"
 	"strings"
 
 	"go-multi-chat-api/src/infrastructure/alerting/alert"
 	"go-multi-chat-api/src/infrastructure/alerting/provider"
 	"go-multi-chat-api/src/infrastructure/alerting/provider/email"
+	"go-multi-chat-api/src/infrastructure/utils"
 )
 
 // Config is the configuration for alerting providers
@@ -16,6 +19,29 @@ type Config struct {
 	Email *email.AlertProvider `yaml:"email,omitempty"`
 }
 
+// LoadConfig loads the alerting providers of operational alerts from environment variables. The email
+// provider is only configured when ALERT_EMAIL_HOST is set.
+func LoadConfig() (*Config, error) {
+	config := &Config{}
+	if host := os.Getenv("ALERT_EMAIL_HOST"); host != "" {
+		port, err := utils.GetIntEnv("ALERT_EMAIL_PORT", 587)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ALERT_EMAIL_PORT: %w", err)
+		}
+		config.Email = &email.AlertProvider{DefaultConfig: email.Config{
+			From:     os.Getenv("ALERT_EMAIL_FROM"),
+			Username: os.Getenv("ALERT_EMAIL_USERNAME"),
+			Password: os.Getenv("ALERT_EMAIL_PASSWORD"),
+			Host:     host,
+			Port:     port,
+		}}
+		if err := config.Email.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid email alerting configuration: %w", err)
+		}
+	}
+	return config, nil
+}
+
 // GetAlertingProviderByAlertType returns an provider.AlertProvider by its corresponding alert.Type
 func (config *Config) GetAlertingProviderByAlertType(alertType alert.Type) provider.AlertProvider {
 	entityType := reflect.TypeOf(config).Elem()
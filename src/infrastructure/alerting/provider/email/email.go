@@ -2,8 +2,12 @@ package email
 
 import (
 	//"crypto/tls"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"math"
+	"mime"
 	"strings"
 
 	"go-multi-chat-api/src/infrastructure/alerting/alert"
@@ -17,14 +21,22 @@ var (
 	ErrMissingFromOrToFields  = errors.New("from and to fields are required")
 	ErrInvalidPort            = errors.New("port must be between 1 and 65535 inclusively")
 	ErrMissingHost            = errors.New("host is required")
+	ErrMessageTooLarge        = errors.New("the email exceeds the message size limit of the SMTP server")
 )
 
+// DefaultMaxMessageSize is the size limit of emails when the config sets none, that of most mail services
+const DefaultMaxMessageSize = 25 << 20
+
+// Config is the SMTP server emails are sent through. The json names are those of the config of email providers.
 type Config struct {
-	From     string `yaml:"from"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
+	From     string `yaml:"from" json:"from"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+	Host     string `yaml:"host" json:"host"`
+	Port     int    `yaml:"port" json:"port"`
+	// MaxMessageSize is the largest email in bytes the SMTP server accepts, as encoded with its attachments.
+	// DefaultMaxMessageSize applies when it isn't set.
+	MaxMessageSize int64 `yaml:"max-message-size" json:"max_message_size"`
 
 	// ClientConfig is the configuration of the client used to communicate with the provider's target
 	// ClientConfig *client.Config `yaml:"client,omitempty"`
@@ -62,6 +74,17 @@ func (cfg *Config) Merge(override *Config) {
 	if override.Port > 0 {
 		cfg.Port = override.Port
 	}
+	if override.MaxMessageSize > 0 {
+		cfg.MaxMessageSize = override.MaxMessageSize
+	}
+}
+
+// maxMessageSize returns the size limit of emails sent through the SMTP server
+func (cfg *Config) maxMessageSize() int64 {
+	if cfg.MaxMessageSize > 0 {
+		return cfg.MaxMessageSize
+	}
+	return DefaultMaxMessageSize
 }
 
 // AlertProvider is the configuration necessary for sending an alert using SMTP
@@ -101,18 +124,17 @@ func (provider *AlertProvider) Send(alert *alert.Alert) error {
 	if err != nil {
 		return err
 	}
-	var username string
-	if len(cfg.Username) > 0 {
-		username = cfg.Username
-	} else {
-		username = cfg.From
-	}
 	subject, body := provider.buildMessageSubjectAndBody(alert)
 	m := gomail.NewMessage()
 	m.SetHeader("From", cfg.From)
 	m.SetHeader("To", alert.Recipients...)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body)
+	return cfg.dialer().DialAndSend(m)
+}
+
+// dialer returns the dialer of the SMTP server, authenticating when a password is set
+func (cfg *Config) dialer() *gomail.Dialer {
 	var d *gomail.Dialer
 	if len(cfg.Password) == 0 {
 		// Get the domain in the From address
@@ -124,13 +146,147 @@ func (provider *AlertProvider) Send(alert *alert.Alert) error {
 		// Create a dialer with no authentication
 		d = &gomail.Dialer{Host: cfg.Host, Port: cfg.Port, LocalName: localName}
 	} else {
+		username := cfg.Username
+		if len(username) == 0 {
+			username = cfg.From
+		}
 		// Create an authenticated dialer
 		d = gomail.NewDialer(cfg.Host, cfg.Port, username, cfg.Password)
 	}
 	//if cfg.ClientConfig != nil && cfg.ClientConfig.Insecure {
 	//	d.TLSConfig = &tls.Config{InsecureSkipVerify: true}
 	//}
-	return d.DialAndSend(m)
+	return d
+}
+
+// userConfig holds the fields of a user provider config overriding the config of an email provider. The SMTP
+// server is always that of the provider, so the host and port of group overrides aren't among them.
+type userConfig struct {
+	From     string `json:"from"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ParseConfig reads the SMTP server of the config of an email provider. The sender address and credentials of a
+// user provider config, when set, override those of the provider.
+func ParseConfig(providerConfig string, userProviderConfig string) (*Config, error) {
+	var cfg Config
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &cfg); err != nil {
+			return nil, fmt.Errorf("invalid email provider config: %w", err)
+		}
+	}
+	if strings.TrimSpace(userProviderConfig) != "" {
+		var override userConfig
+		if err := json.Unmarshal([]byte(userProviderConfig), &override); err != nil {
+			return nil, fmt.Errorf("invalid email user provider config: %w", err)
+		}
+		cfg.Merge(&Config{From: override.From, Username: override.Username, Password: override.Password})
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// VerifyCredentials connects and authenticates to the SMTP server without sending an email
+func (cfg *Config) VerifyCredentials() error {
+	sender, err := cfg.dialer().Dial()
+	if err != nil {
+		return err
+	}
+	return sender.Close()
+}
+
+// Message is an email to one or more recipients
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	// HTML is sent as the alternative of Text when set
+	HTML string
+	// Headers are set besides From, To and Subject, e.g. Message-ID
+	Headers map[string]string
+	// Attachments are sent after the body, the inline ones as the related parts of HTML
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	// Inline attachments are images the HTML shows, referenced as cid:<Filename>
+	Inline bool
+}
+
+// SendMessages sends the messages from the From address over one connection to the SMTP server. It stops at the
+// first message that couldn't be sent and returns the number of messages sent until then.
+func (cfg *Config) SendMessages(messages []Message) (int, error) {
+	sender, err := cfg.dialer().Dial()
+	if err != nil {
+		return 0, err
+	}
+	defer sender.Close()
+	for i, message := range messages {
+		m := gomail.NewMessage()
+		m.SetHeader("From", cfg.From)
+		m.SetHeader("To", message.To...)
+		m.SetHeader("Subject", message.Subject)
+		for name, value := range message.Headers {
+			m.SetHeader(name, value)
+		}
+		m.SetBody("text/plain", message.Text)
+		if message.HTML != "" {
+			m.AddAlternative("text/html", message.HTML)
+		}
+		for _, attachment := range message.Attachments {
+			attach(m, attachment)
+		}
+		size, err := m.WriteTo(io.Discard)
+		if err != nil {
+			return i, err
+		}
+		if size > cfg.maxMessageSize() {
+			return i, fmt.Errorf("%w: the email to %s has %d bytes, at most %d are accepted", ErrMessageTooLarge, strings.Join(message.To, ", "), size, cfg.maxMessageSize())
+		}
+		if err := gomail.Send(sender, m); err != nil {
+			// The SendError of gomail doesn't wrap its cause, the reply of the server is returned instead
+			var sendErr *gomail.SendError
+			if errors.As(err, &sendErr) {
+				return i, fmt.Errorf("couldn't send to %s: %w", strings.Join(message.To, ", "), sendErr.Cause)
+			}
+			return i, err
+		}
+	}
+	return len(messages), nil
+}
+
+// attach adds an attachment to an email, inline attachments get their filename as content id
+func attach(m *gomail.Message, attachment Attachment) {
+	data := attachment.Data
+	contentType := mime.FormatMediaType(attachment.ContentType, map[string]string{"name": attachment.Filename})
+	if contentType == "" {
+		contentType = mime.FormatMediaType("application/octet-stream", map[string]string{"name": attachment.Filename})
+	}
+	disposition := "attachment"
+	if attachment.Inline {
+		disposition = "inline"
+	}
+	header := map[string][]string{
+		"Content-Type":        {contentType},
+		"Content-Disposition": {mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename})},
+	}
+	copyData := gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if attachment.Inline {
+		header["Content-ID"] = []string{"<" + attachment.Filename + ">"}
+		m.Embed(attachment.Filename, copyData, gomail.SetHeader(header))
+		return
+	}
+	m.Attach(attachment.Filename, copyData, gomail.SetHeader(header))
 }
 
 // buildMessageSubjectAndBody builds the message subject and body
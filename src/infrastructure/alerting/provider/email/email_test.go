@@ -0,0 +1,25 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	providerConfig := `{"host":"smtp.example.com","port":587,"from":"noreply@example.com","username":"noreply","password":"secret"}`
+
+	config, err := ParseConfig(providerConfig, `{"from":"ops@example.com","password":"own","webhook_enabled":true}`)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{From: "ops@example.com", Username: "noreply", Password: "own", Host: "smtp.example.com", Port: 587}, config)
+
+	// The SMTP server is always that of the provider
+	config, err = ParseConfig(providerConfig, `{"host":"smtp.other.example","port":25}`)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com", config.Host)
+	assert.Equal(t, 587, config.Port)
+
+	_, err = ParseConfig(`{"from":"noreply@example.com"}`, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,316 @@
+package anonymize
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// batchSize is the number of rows read and updated at once
+const batchSize = 500
+
+// Config holds the anonymizer settings
+type Config struct {
+	// Enabled allows anonymizing the database through the admin endpoint, never in production
+	Enabled bool
+	// Password becomes the password of every user, a random one when empty so nobody can log in with the old ones
+	Password string
+	// KeepUsers are the emails of the users that keep their email, name and password, so the operators of the
+	// staging environment can still log in
+	KeepUsers []string
+	// Salt keys the pseudonyms, a random one per run when empty
+	Salt string
+}
+
+// LoadConfig loads the anonymizer settings from environment variables
+func LoadConfig() (Config, error) {
+	config := Config{
+		Enabled:  utils.GetEnv("ANONYMIZE_ENABLED", "false") == "true",
+		Password: utils.GetEnv("ANONYMIZE_PASSWORD", ""),
+	}
+	for _, email := range strings.Split(utils.GetEnv("ANONYMIZE_KEEP_USERS", ""), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			config.KeepUsers = append(config.KeepUsers, email)
+		}
+	}
+	if env := utils.GetEnv("GO_ENV", "development"); config.Enabled && env == "production" {
+		return Config{}, fmt.Errorf("invalid ANONYMIZE_ENABLED: anonymizing isn't allowed in production, GO_ENV is %q", env)
+	}
+	return config, nil
+}
+
+// Result reports the rows anonymized per table
+type Result struct {
+	Tables  map[string]int64 `json:"tables"`
+	Rows    int64            `json:"rows"`
+	Skipped []string         `json:"skipped,omitempty"` // tables that don't exist in the database
+}
+
+// column is a column anonymized with scramble, empty values are left alone
+type column struct {
+	name     string
+	scramble func(value string) string
+}
+
+// table is a table anonymized row by row, where limits the rows
+type table struct {
+	name    string
+	where   string
+	columns []column
+}
+
+// Anonymizer replaces the personal data and credentials of a database in place, keeping every row with its
+// statuses, counts and timestamps so volumes and reports stay realistic
+type Anonymizer struct {
+	db           *gorm.DB
+	scrambler    *Scrambler
+	passwordHash string
+	keepUsers    []string
+	Logger       *logger.Logger
+}
+
+// New creates an Anonymizer for a database
+func New(db *gorm.DB, config Config, loggerInstance *logger.Logger) (*Anonymizer, error) {
+	salt := config.Salt
+	if salt == "" {
+		salt = randomHex(32)
+	}
+	password := config.Password
+	if password == "" {
+		password = randomHex(32)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &Anonymizer{
+		db:           db,
+		scrambler:    NewScrambler(salt),
+		passwordHash: string(hash),
+		keepUsers:    config.KeepUsers,
+		Logger:       loggerInstance,
+	}, nil
+}
+
+// DatabaseName returns the name of the database anonymized, it has to be confirmed before anonymizing
+func (a *Anonymizer) DatabaseName() string {
+	return a.db.Migrator().CurrentDatabase()
+}
+
+// Run anonymizes the tables one after the other in batches, reporting the percentage of tables done. Tables
+// that don't exist are skipped. Running it again scrambles the pseudonyms once more.
+func (a *Anonymizer) Run(ctx context.Context, report func(percent int, result *Result)) (*Result, error) {
+	tables := a.tables()
+	result := &Result{Tables: map[string]int64{}}
+	for i, t := range tables {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if !a.db.Migrator().HasTable(t.name) {
+			result.Skipped = append(result.Skipped, t.name)
+			continue
+		}
+		rows, err := a.anonymizeTable(ctx, &t)
+		result.Tables[t.name] = rows
+		result.Rows += rows
+		if err != nil {
+			return result, fmt.Errorf("anonymizing %s: %w", t.name, err)
+		}
+		a.Logger.Info("Anonymized table", zap.String("table", t.name), zap.Int64("rows", rows))
+		if report != nil {
+			report((i+1)*100/len(tables), result)
+		}
+	}
+	return result, nil
+}
+
+func (a *Anonymizer) anonymizeTable(ctx context.Context, t *table) (int64, error) {
+	columns := make([]column, 0, len(t.columns))
+	names := []string{"id"}
+	for _, c := range t.columns {
+		// Columns added by later versions may be missing in an older snapshot
+		if a.db.Migrator().HasColumn(t.name, c.name) {
+			columns = append(columns, c)
+			names = append(names, c.name)
+		}
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+
+	var anonymized int64
+	var lastID int64
+	for ctx.Err() == nil {
+		var rows []map[string]interface{}
+		query := a.db.Table(t.name).Select(names).Where("id > ?", lastID)
+		if t.where != "" {
+			query = query.Where(t.where)
+		}
+		if err := query.Order("id").Limit(batchSize).Find(&rows).Error; err != nil {
+			return anonymized, err
+		}
+		if len(rows) == 0 {
+			return anonymized, nil
+		}
+		err := a.db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				id, err := toInt64(row["id"])
+				if err != nil {
+					return err
+				}
+				lastID = id
+				updates := map[string]interface{}{}
+				for _, c := range columns {
+					value, ok := toString(row[c.name])
+					if !ok || value == "" {
+						continue
+					}
+					if scrambled := c.scramble(value); scrambled != value {
+						updates[c.name] = scrambled
+					}
+				}
+				if len(updates) == 0 {
+					continue
+				}
+				if err := tx.Table(t.name).Where("id = ?", id).UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+				anonymized++
+			}
+			return nil
+		})
+		if err != nil {
+			return anonymized, err
+		}
+		if len(rows) < batchSize {
+			return anonymized, nil
+		}
+	}
+	return anonymized, ctx.Err()
+}
+
+// tables lists the columns holding recipients, message contents, personal data and credentials. Statuses,
+// counts, error codes and timestamps aren't touched.
+func (a *Anonymizer) tables() []table {
+	s := a.scrambler
+	text := func(name string) column { return column{name, s.Text} }
+	recipient := func(name string) column { return column{name, s.Recipient} }
+	recipients := func(name string) column { return column{name, s.Recipients} }
+	document := func(name string) column { return column{name, s.JSON} }
+	config := func(name string) column { return column{name, s.Config} }
+	identifiers := func(name string) column { return column{name, s.Identifiers} }
+	secret := func(name string) column { return column{name, s.Secret} }
+	clear := func(name string) column { return column{name, func(string) string { return "" }} }
+
+	users := table{name: "users", columns: []column{
+		{"email", s.Email},
+		{"user_name", s.UserName},
+		text("first_name"),
+		text("last_name"),
+		{"hash_password", func(string) string { return a.passwordHash }},
+	}}
+	if len(a.keepUsers) > 0 {
+		quoted := make([]string, len(a.keepUsers))
+		for i, email := range a.keepUsers {
+			quoted[i] = "'" + strings.ReplaceAll(email, "'", "''") + "'"
+		}
+		users.where = "email NOT IN (" + strings.Join(quoted, ",") + ")"
+	}
+
+	messageColumns := []column{
+		recipients("recipients"),
+		text("message"),
+		document("tags"),
+		document("extensions"),
+		document("request_data"),
+		document("response_data"),
+		identifiers("error_message"),
+		recipient("acknowledged_by"),
+	}
+
+	return []table{
+		users,
+		{name: "login_events", columns: []column{{"ip_address", s.IP}, identifiers("failure_reason")}},
+		{name: "login_notification_settings", columns: []column{recipient("recipient")}},
+		{name: "providers", columns: []column{config("config")}},
+		{name: "user_providers", columns: []column{config("config")}},
+		{name: "message_transactions", columns: messageColumns},
+		{name: "message_transaction_history", columns: messageColumns},
+		{name: "message_deliveries", columns: []column{recipient("recipient"), identifiers("error_message")}},
+		{name: "message_edits", columns: []column{text("previous_message"), text("message"), document("response_data")}},
+		{name: "message_engagements", columns: []column{recipient("recipient"), document("tags")}},
+		{name: "conversations", columns: []column{recipient("participant"), text("last_message")}},
+		{name: "conversation_messages", columns: []column{text("body")}},
+		{name: "control_commands", columns: []column{recipient("sender"), text("sender_uuid"), text("text")}},
+		{name: "digest_subscriptions", columns: []column{recipient("recipient")}},
+		{name: "delivery_digests", columns: []column{identifiers("top_errors"), identifiers("error_message")}},
+		{name: "distribution_lists", columns: []column{recipients("signal_members"), recipients("extra_members"), document("drift"), identifiers("sync_error")}},
+		{name: "escalation_chains", columns: []column{document("steps")}},
+		{name: "escalations", columns: []column{document("steps"), text("message"), recipient("acknowledged_by")}},
+		{name: "hook_subscriptions", columns: []column{{"target_url", s.URL}, secret("secret"), clear("headers"), clear("client_certificate"), clear("client_key")}},
+		{name: "webhook_events", columns: []column{document("payload")}},
+		{name: "outbox_events", columns: []column{document("payload"), identifiers("last_error")}},
+		// The job anonymizing the database keeps its own record
+		{name: "jobs", where: "type <> 'anonymize'", columns: []column{document("payload"), document("result"), identifiers("error")}},
+		{name: "short_links", columns: []column{{"url", s.URL}, recipients("recipients")}},
+		{name: "link_clicks", columns: []column{recipient("recipient")}},
+		{name: "recipient_cap_violations", columns: []column{recipient("recipient")}},
+		{name: "custom_domains", columns: []column{{"domain", s.Domain}, secret("verification_token")}},
+		{name: "attachments", columns: []column{text("filename")}},
+		{name: "content_transforms", columns: []column{config("config")}},
+		{name: "signal_received_messages", columns: []column{recipient("account"), recipient("source")}},
+		{name: "signal_receive_watermarks", columns: []column{recipient("account")}},
+		{name: "signal_rate_limit_challenges", columns: []column{secret("token")}},
+		{name: "number_health", columns: []column{recipient("number"), identifiers("last_error")}},
+		{name: "signal_device_links", columns: []column{recipient("number"), identifiers("last_error")}},
+		{name: "signal_registration_locks", columns: []column{recipient("number"), clear("encrypted_pin")}},
+	}
+}
+
+func toString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint:
+		return int64(v), nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected id %v", value)
+}
+
+func randomHex(size int) string {
+	buffer := make([]byte, size)
+	_, _ = rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
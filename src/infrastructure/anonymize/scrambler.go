@@ -0,0 +1,278 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// anonymizedDomain is the domain of the anonymized emails, URLs and domains, .invalid never resolves
+	anonymizedDomain = "example.invalid"
+	// anonymizedCountryCode replaces the start of phone numbers, +999 isn't assigned to a country
+	anonymizedCountryCode = "999"
+	// anonymizedSecret replaces the credentials in configs
+	anonymizedSecret = "anonymized"
+	lowerLetters     = "abcdefghijklmnopqrstuvwxyz"
+	upperLetters     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits           = "0123456789"
+	// gsmLetters are the non-ASCII letters of the GSM 7-bit alphabet, they are replaced among themselves so SMS
+	// keep their encoding
+	gsmLetters = "ÄÖÜäöüßñÑéèàòìùÉÇçøØåÅæÆ"
+	// otherLetters replace the other non-ASCII letters, none of them is in the GSM 7-bit alphabet
+	otherLetters = "āēīōūčšžł"
+)
+
+var (
+	// sensitiveKeyPattern matches the keys of config values holding credentials
+	sensitiveKeyPattern = regexp.MustCompile(`(?i)(token|secret|password|passwd|pass$|key|auth|sid|credential|pin|cert|signature)`)
+	// recipientKeyPattern matches the keys of JSON values holding recipients
+	recipientKeyPattern = regexp.MustCompile(`(?i)^(recipients?|sender|source|number|account|participant|to|from|members|acknowledged_by|email|phone)$`)
+	// keptKeyPattern matches the keys of JSON values that describe rather than identify, they are kept
+	keptKeyPattern = regexp.MustCompile(`(?i)^(status|state|event|type|kind|code|error_code|direction|channel|provider_type|encoding|action|mime_type|content_type|text_mode|locale)$`)
+	// identifierPattern finds the phone numbers and emails in free text like error messages
+	identifierPattern = regexp.MustCompile(`[+]?\d[\d ]{5,}\d|[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// Scrambler replaces personal data with pseudonyms derived from it with a keyed hash. The same value gets the same
+// pseudonym everywhere within a run, so the messages of a recipient still belong together.
+type Scrambler struct {
+	key []byte
+}
+
+// NewScrambler creates a Scrambler, the salt keys the pseudonyms
+func NewScrambler(salt string) *Scrambler {
+	return &Scrambler{key: []byte(salt)}
+}
+
+// Text replaces the letters and digits of a text with others of the same kind, keeping its length, whitespace
+// and punctuation, so segmentation and encoding of the message stay the same
+func (s *Scrambler) Text(value string) string {
+	stream := s.stream(value)
+	var text strings.Builder
+	text.Grow(len(value))
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z':
+			text.WriteByte(lowerLetters[stream.next(len(lowerLetters))])
+		case r >= 'A' && r <= 'Z':
+			text.WriteByte(upperLetters[stream.next(len(upperLetters))])
+		case r >= '0' && r <= '9':
+			text.WriteByte(digits[stream.next(len(digits))])
+		case strings.ContainsRune(gsmLetters, r):
+			text.WriteRune(pick(gsmLetters, stream))
+		case unicode.IsLetter(r):
+			text.WriteRune(pick(otherLetters, stream))
+		default:
+			text.WriteRune(r)
+		}
+	}
+	return text.String()
+}
+
+// Recipient returns the pseudonym of a recipient in the form of the original: emails and Matrix users get the
+// domain example.invalid and phone numbers the unassigned country code +999, so a staging environment can't reach
+// the real recipients
+func (s *Scrambler) Recipient(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case strings.HasPrefix(value, "@") && strings.Contains(value, ":"):
+		return "@user-" + s.hash(value) + ":" + anonymizedDomain
+	case strings.Contains(value, "@"):
+		return s.Email(value)
+	case strings.HasPrefix(value, "+"):
+		scrambled := s.Text(value[1:])
+		if len(scrambled) > len(anonymizedCountryCode) {
+			scrambled = scrambled[len(anonymizedCountryCode):]
+		}
+		return "+" + anonymizedCountryCode + scrambled
+	}
+	if prefix, id, ok := strings.Cut(value, "."); ok && prefix == "group" {
+		return prefix + "." + s.Text(id)
+	}
+	return s.Text(value)
+}
+
+// Recipients returns the pseudonyms of a JSON array of recipients, a value that isn't one is taken as a recipient
+func (s *Scrambler) Recipients(value string) string {
+	var recipients []string
+	if err := json.Unmarshal([]byte(value), &recipients); err != nil {
+		return s.Recipient(value)
+	}
+	for i := range recipients {
+		recipients[i] = s.Recipient(recipients[i])
+	}
+	encoded, _ := json.Marshal(recipients)
+	return string(encoded)
+}
+
+// Email returns a pseudonymous email on example.invalid
+func (s *Scrambler) Email(value string) string {
+	return "user-" + s.hash(value) + "@" + anonymizedDomain
+}
+
+// UserName returns a pseudonymous user name
+func (s *Scrambler) UserName(value string) string {
+	return "user-" + s.hash(value)
+}
+
+// Domain returns a pseudonymous subdomain of example.invalid
+func (s *Scrambler) Domain(value string) string {
+	return s.hash(value) + "." + anonymizedDomain
+}
+
+// URL moves a URL to example.invalid and scrambles its path and query
+func (s *Scrambler) URL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return "https://" + anonymizedDomain + "/" + s.Text(value)
+	}
+	scrambled := "https://" + s.Domain(parsed.Host) + s.Text(parsed.EscapedPath())
+	if parsed.RawQuery != "" {
+		scrambled += "?" + s.Text(parsed.RawQuery)
+	}
+	return scrambled
+}
+
+// IP returns an address of the documentation range 203.0.113.0/24
+func (s *Scrambler) IP(value string) string {
+	return fmt.Sprintf("203.0.113.%d", s.stream(value).next(254)+1)
+}
+
+// Secret returns a random looking hex secret of the length of the original
+func (s *Scrambler) Secret(value string) string {
+	secret := ""
+	for counter := 0; len(secret) < len(value); counter++ {
+		secret += s.hash(fmt.Sprintf("%s#%d", value, counter))
+	}
+	return secret[:len(value)]
+}
+
+// Identifiers replaces the phone numbers and emails of a free text like an error message, keeping the rest
+func (s *Scrambler) Identifiers(value string) string {
+	return identifierPattern.ReplaceAllStringFunc(value, func(identifier string) string {
+		if strings.Contains(identifier, "@") {
+			return s.Email(identifier)
+		}
+		return s.Text(identifier)
+	})
+}
+
+// Config replaces the credentials of a JSON config, the other settings are kept. A value that isn't a JSON
+// object is replaced as a whole.
+func (s *Scrambler) Config(value string) string {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return anonymizedSecret
+	}
+	encoded, _ := json.Marshal(scrubConfig(config))
+	return string(encoded)
+}
+
+// JSON scrambles the string values of a JSON document. Recipients get their pseudonyms, values describing the
+// document like statuses and timestamps are kept. A value that isn't JSON is scrambled as text.
+func (s *Scrambler) JSON(value string) string {
+	var document interface{}
+	if err := json.Unmarshal([]byte(value), &document); err != nil {
+		return s.Text(value)
+	}
+	encoded, _ := json.Marshal(s.scrambleJSON("", document))
+	return string(encoded)
+}
+
+func (s *Scrambler) scrambleJSON(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = s.scrambleJSON(k, child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = s.scrambleJSON(key, child)
+		}
+		return v
+	case string:
+		switch {
+		case keptKeyPattern.MatchString(key) || isTimestamp(v):
+			return v
+		case recipientKeyPattern.MatchString(key):
+			return s.Recipient(v)
+		case sensitiveKeyPattern.MatchString(key):
+			return anonymizedSecret
+		}
+		return s.Text(v)
+	}
+	return value
+}
+
+func scrubConfig(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if _, isString := child.(string); isString && sensitiveKeyPattern.MatchString(k) {
+				v[k] = anonymizedSecret
+				continue
+			}
+			v[k] = scrubConfig(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = scrubConfig(child)
+		}
+	}
+	return value
+}
+
+func isTimestamp(value string) bool {
+	_, err := time.Parse(time.RFC3339Nano, value)
+	return err == nil
+}
+
+// hash returns the first 12 hex characters of the keyed hash of a value
+func (s *Scrambler) hash(value string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// stream returns the keyed pseudo-random stream of a value
+func (s *Scrambler) stream(value string) *keyStream {
+	return &keyStream{key: s.key, value: value}
+}
+
+// keyStream yields pseudo-random numbers derived from a value, blocks of HMAC-SHA256 of the value and a counter
+type keyStream struct {
+	key     []byte
+	value   string
+	block   []byte
+	counter uint64
+}
+
+// next returns a number from 0 to n-1
+func (k *keyStream) next(n int) int {
+	if len(k.block) < 2 {
+		mac := hmac.New(sha256.New, k.key)
+		mac.Write([]byte(k.value))
+		_ = binary.Write(mac, binary.BigEndian, k.counter)
+		k.counter++
+		k.block = mac.Sum(nil)
+	}
+	number := binary.BigEndian.Uint16(k.block)
+	k.block = k.block[2:]
+	return int(number) % n
+}
+
+func pick(letters string, stream *keyStream) rune {
+	runes := []rune(letters)
+	return runes[stream.next(len(runes))]
+}
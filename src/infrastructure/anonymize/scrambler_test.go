@@ -0,0 +1,103 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestText_KeepsShapeAndIsDeterministic(t *testing.T) {
+	s := NewScrambler("salt")
+
+	message := "Hi Anna, your code is 4711. Grüße!"
+	scrambled := s.Text(message)
+	assert.NotEqual(t, message, scrambled)
+	assert.Equal(t, utf8.RuneCountInString(message), utf8.RuneCountInString(scrambled))
+	assert.Equal(t, scrambled, s.Text(message))
+	assert.NotEqual(t, scrambled, NewScrambler("other").Text(message))
+
+	// Whitespace and punctuation stay, letters stay letters of their case and digits stay digits
+	original, replaced := []rune(message), []rune(scrambled)
+	for i := range original {
+		switch {
+		case original[i] >= '0' && original[i] <= '9':
+			assert.True(t, replaced[i] >= '0' && replaced[i] <= '9')
+		case original[i] >= 'A' && original[i] <= 'Z':
+			assert.True(t, replaced[i] >= 'A' && replaced[i] <= 'Z')
+		case strings.ContainsRune(gsmLetters, original[i]):
+			assert.True(t, strings.ContainsRune(gsmLetters, replaced[i]))
+		case !(original[i] >= 'a' && original[i] <= 'z'):
+			assert.Equal(t, original[i], replaced[i])
+		}
+	}
+}
+
+func TestRecipient_KeepsTheKindOfRecipient(t *testing.T) {
+	s := NewScrambler("salt")
+
+	phone := s.Recipient("+4915112345678")
+	assert.True(t, strings.HasPrefix(phone, "+999"))
+	assert.Len(t, phone, len("+4915112345678"))
+	assert.Regexp(t, `^user-[0-9a-f]{12}@example\.invalid$`, s.Recipient("anna@example.com"))
+	assert.Regexp(t, `^@user-[0-9a-f]{12}:example\.invalid$`, s.Recipient("@anna:matrix.org"))
+	assert.True(t, strings.HasPrefix(s.Recipient("group.abc123=="), "group."))
+	assert.Equal(t, s.Recipient("anna@example.com"), s.Recipient("anna@example.com"))
+}
+
+func TestRecipients(t *testing.T) {
+	s := NewScrambler("salt")
+
+	var recipients []string
+	require.NoError(t, json.Unmarshal([]byte(s.Recipients(`["+4915112345678","anna@example.com"]`)), &recipients))
+	assert.Equal(t, []string{s.Recipient("+4915112345678"), s.Recipient("anna@example.com")}, recipients)
+	assert.Equal(t, s.Recipient("+4915112345678"), s.Recipients("+4915112345678"))
+}
+
+func TestJSON_KeepsStatusesAndTimestamps(t *testing.T) {
+	s := NewScrambler("salt")
+
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(s.JSON(`{"status":"delivered","timestamp":"2024-05-01T10:00:00Z",`+
+		`"recipient":"+4915112345678","message":"hello","count":3,"api_key":"abc"}`)), &document))
+	assert.Equal(t, "delivered", document["status"])
+	assert.Equal(t, "2024-05-01T10:00:00Z", document["timestamp"])
+	assert.Equal(t, s.Recipient("+4915112345678"), document["recipient"])
+	assert.NotEqual(t, "hello", document["message"])
+	assert.Equal(t, float64(3), document["count"])
+	assert.Equal(t, anonymizedSecret, document["api_key"])
+}
+
+func TestConfig_ReplacesOnlyCredentials(t *testing.T) {
+	s := NewScrambler("salt")
+
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(s.Config(`{"host":"smtp.example.com","port":587,"password":"hunter2",`+
+		`"auth":{"account_sid":"AC1","region":"eu"}}`)), &config))
+	assert.Equal(t, "smtp.example.com", config["host"])
+	assert.Equal(t, float64(587), config["port"])
+	assert.Equal(t, anonymizedSecret, config["password"])
+	assert.Equal(t, anonymizedSecret, config["auth"].(map[string]interface{})["account_sid"])
+	assert.Equal(t, "eu", config["auth"].(map[string]interface{})["region"])
+	assert.Equal(t, anonymizedSecret, s.Config("not json"))
+}
+
+func TestIdentifiers_ScrubsNumbersAndEmails(t *testing.T) {
+	s := NewScrambler("salt")
+
+	scrubbed := s.Identifiers("Failed to send to +4915112345678 (anna@example.com): rate limit 429")
+	assert.NotContains(t, scrubbed, "4915112345678")
+	assert.NotContains(t, scrubbed, "anna@example.com")
+	assert.Contains(t, scrubbed, "rate limit 429")
+}
+
+func TestURLAndIP(t *testing.T) {
+	s := NewScrambler("salt")
+
+	assert.Regexp(t, `^https://[0-9a-f]{12}\.example\.invalid/`, s.URL("https://hooks.acme.com/delivery?token=abc"))
+	assert.Regexp(t, `^203\.0\.113\.\d+$`, s.IP("192.168.1.20"))
+	assert.Len(t, s.Secret("0123456789abcdef0123456789abcdef"), 32)
+}
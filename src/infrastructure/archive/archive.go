@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/payload"
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Targets the message history is archived to
+const (
+	// TargetS3 uploads every file with a PUT request, as accepted by S3 compatible object stores
+	TargetS3 = "s3"
+	// TargetDir writes every file below a directory, e.g. a mounted bucket
+	TargetDir = "dir"
+)
+
+// ParquetContentType is the media type the files are uploaded with
+const ParquetContentType = "application/vnd.apache.parquet"
+
+// defaultOrg names the org partition of deployments without a JWT_ORG
+const defaultOrg = "default"
+
+// Config controls the archival of the message history to cold storage, it is disabled without a target
+type Config struct {
+	Target string
+	// AfterDays is the age in days from which history entries are archived
+	AfterDays int
+	// Delete removes the archived entries from the database once their file is written
+	Delete bool
+	// Interval is the time between two scheduled archivals
+	Interval time.Duration
+	// BatchSize is the largest number of entries written to one file
+	BatchSize int
+	// Prefix starts the keys of the objects and files the entries are written to
+	Prefix string
+	// Org partitions the files of the deployment, from JWT_ORG
+	Org     string
+	S3URL   string
+	S3Token string
+	Dir     string
+}
+
+// LoadConfig loads the archive settings from environment variables
+func LoadConfig() (Config, error) {
+	afterDays, err := utils.GetIntEnv("ARCHIVE_AFTER_DAYS", 90)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_AFTER_DAYS: %w", err)
+	}
+	if afterDays <= 0 {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_AFTER_DAYS: must be positive")
+	}
+	interval, err := utils.GetIntEnv("ARCHIVE_INTERVAL_HOURS", 24)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_INTERVAL_HOURS: %w", err)
+	}
+	if interval <= 0 {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_INTERVAL_HOURS: must be positive")
+	}
+	batchSize, err := utils.GetIntEnv("ARCHIVE_BATCH_SIZE", 10000)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_BATCH_SIZE: %w", err)
+	}
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_BATCH_SIZE: must be positive")
+	}
+	deleteArchived, err := strconv.ParseBool(utils.GetEnv("ARCHIVE_DELETE", "false"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_DELETE: %w", err)
+	}
+
+	config := Config{
+		Target:    utils.GetEnv("ARCHIVE_TARGET", ""),
+		AfterDays: afterDays,
+		Delete:    deleteArchived,
+		Interval:  time.Duration(interval) * time.Hour,
+		BatchSize: batchSize,
+		Prefix:    strings.Trim(utils.GetEnv("ARCHIVE_PREFIX", "archive"), "/"),
+		Org:       utils.GetEnv("JWT_ORG", defaultOrg),
+		S3URL:     strings.TrimSuffix(utils.GetEnv("ARCHIVE_S3_URL", ""), "/"),
+		S3Token:   utils.GetEnv("ARCHIVE_S3_TOKEN", ""),
+		Dir:       utils.GetEnv("ARCHIVE_DIR", ""),
+	}
+	if config.Org == "" {
+		config.Org = defaultOrg
+	}
+	switch config.Target {
+	case "":
+	case TargetS3:
+		if config.S3URL == "" {
+			return Config{}, fmt.Errorf("ARCHIVE_S3_URL is required for ARCHIVE_TARGET=s3")
+		}
+	case TargetDir:
+		if config.Dir == "" {
+			return Config{}, fmt.Errorf("ARCHIVE_DIR is required for ARCHIVE_TARGET=dir")
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported ARCHIVE_TARGET: %s", config.Target)
+	}
+	return config, nil
+}
+
+// Enabled reports whether the message history is archived
+func (c Config) Enabled() bool {
+	return c.Target != ""
+}
+
+// Cutoff returns the start of the UTC day before which the history is archived at now
+func (c Config) Cutoff(now time.Time) time.Time {
+	day := now.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -c.AfterDays)
+}
+
+// NewTarget creates the target of a configuration, nil when the archive is disabled
+func NewTarget(config Config) payload.Store {
+	switch config.Target {
+	case TargetS3:
+		return payload.NewHTTPStore(config.S3URL, config.S3Token).WithContentType(ParquetContentType)
+	case TargetDir:
+		return payload.NewDirStore(config.Dir)
+	}
+	return nil
+}
+
+// Key returns the key of the file holding history entries of a day, partitioned Hive style by org and date so
+// query engines reading the bucket prune the files of a range
+func Key(prefix string, org string, day time.Time, firstID int, lastID int) string {
+	return fmt.Sprintf("%s/message_history/org=%s/date=%s/%010d-%010d.parquet", prefix, org, day.UTC().Format(time.DateOnly), firstID, lastID)
+}
+
+// HistoryColumns are the columns of the archived history entries, named like the columns of the database table
+var HistoryColumns = []Column{
+	{Name: "id", Type: ColumnInt64},
+	{Name: "message_id", Type: ColumnInt64},
+	{Name: "user_id", Type: ColumnInt64},
+	{Name: "provider_id", Type: ColumnInt64},
+	{Name: "recipients", Type: ColumnString},
+	{Name: "message", Type: ColumnString},
+	{Name: "tags", Type: ColumnString},
+	{Name: "request_data", Type: ColumnString},
+	{Name: "response_data", Type: ColumnString},
+	{Name: "status", Type: ColumnString},
+	{Name: "error_message", Type: ColumnString},
+	{Name: "error_code", Type: ColumnString},
+	{Name: "retry_count", Type: ColumnInt32},
+	{Name: "segments", Type: ColumnInt32},
+	{Name: "processed_at", Type: ColumnTimestamp, Optional: true},
+	{Name: "created_at", Type: ColumnTimestamp},
+	{Name: "updated_at", Type: ColumnTimestamp},
+}
+
+// HistoryRow returns the values of a history entry in the order of HistoryColumns
+func HistoryRow(history provider.MessageTransactionHistory) []interface{} {
+	var processedAt interface{}
+	if !history.ProcessedAt.IsZero() {
+		processedAt = history.ProcessedAt
+	}
+	return []interface{}{
+		int64(history.ID),
+		int64(history.MessageID),
+		int64(history.UserID),
+		int64(history.ProviderID),
+		history.Recipients,
+		history.Message,
+		history.Tags,
+		history.RequestData,
+		history.ResponseData,
+		history.Status,
+		history.ErrorMessage,
+		history.ErrorCode,
+		int32(history.RetryCount),
+		int32(history.Segments),
+		processedAt,
+		history.CreatedAt,
+		history.UpdatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package archive
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("ARCHIVE_TARGET", "")
+	t.Setenv("JWT_ORG", "")
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.False(t, config.Enabled())
+	assert.Equal(t, 90, config.AfterDays)
+	assert.Equal(t, "default", config.Org)
+	assert.False(t, config.Delete)
+
+	t.Setenv("ARCHIVE_TARGET", TargetS3)
+	_, err = LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("ARCHIVE_S3_URL", "https://bucket.example.com/")
+	t.Setenv("ARCHIVE_DELETE", "true")
+	t.Setenv("JWT_ORG", "acme")
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://bucket.example.com", config.S3URL)
+	assert.True(t, config.Delete)
+	assert.Equal(t, "acme", config.Org)
+
+	t.Setenv("ARCHIVE_AFTER_DAYS", "0")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestConfig_Cutoff(t *testing.T) {
+	config := Config{AfterDays: 30}
+	now := time.Date(2026, 10, 16, 13, 45, 0, 0, time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC), config.Cutoff(now))
+}
+
+func TestKey(t *testing.T) {
+	day := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "archive/message_history/org=acme/date=2026-07-01/0000000012-0000000345.parquet", Key("archive", "acme", day, 12, 345))
+}
+
+func TestHistoryRow(t *testing.T) {
+	created := time.UnixMilli(1760608800000)
+	row := HistoryRow(provider.MessageTransactionHistory{ID: 3, Status: "success", RetryCount: 2, CreatedAt: created, UpdatedAt: created})
+	require.Len(t, row, len(HistoryColumns))
+	assert.Equal(t, int64(3), row[0])
+	assert.Equal(t, "success", row[9])
+	assert.Equal(t, int32(2), row[12])
+	// A message that was never processed has no processing time
+	assert.Nil(t, row[14])
+
+	_, err := EncodeParquet(HistoryColumns, [][]interface{}{row})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,398 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// The archived rows are written as Apache Parquet files with a single row group and one gzip compressed, PLAIN
+// encoded data page per column. That is all the archive needs and every Parquet reader understands it, so the
+// format is written here rather than pulling in a Parquet library.
+
+// parquetMagic starts and ends every Parquet file
+const parquetMagic = "PAR1"
+
+// ColumnType is the type of the values of a Parquet column
+type ColumnType int
+
+const (
+	// ColumnInt32 holds int32 values
+	ColumnInt32 ColumnType = iota
+	// ColumnInt64 holds int64 values
+	ColumnInt64
+	// ColumnString holds string values, stored as UTF-8 byte arrays
+	ColumnString
+	// ColumnTimestamp holds time.Time values, stored as milliseconds since the epoch
+	ColumnTimestamp
+)
+
+// Column describes a column of a Parquet file. The values of an optional column may be nil.
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Optional bool
+}
+
+// Parquet physical types, converted types, encodings and codecs, as numbered by the format's Thrift definitions
+const (
+	parquetTypeInt32     = 1
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecGzip = 2
+
+	parquetPageData = 0
+)
+
+// parquetCreatedBy is recorded as the writer of the files
+const parquetCreatedBy = "go-multi-chat-api"
+
+// columnChunk is a column as written, remembered for the footer
+type columnChunk struct {
+	offset           int64
+	values           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// EncodeParquet writes rows as a Parquet file. Every row has one value per column, in the order of the columns.
+func EncodeParquet(columns []Column, rows [][]interface{}) ([]byte, error) {
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", i, len(row), len(columns))
+		}
+	}
+
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+	chunks := make([]columnChunk, len(columns))
+	for i, column := range columns {
+		page, err := encodePage(column, i, rows)
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := gzipBytes(page)
+		if err != nil {
+			return nil, err
+		}
+
+		var header thriftWriter
+		header.i32(1, parquetPageData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(compressed)))
+		header.beginStruct(5)
+		header.i32(1, int32(len(rows)))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunks[i] = columnChunk{
+			offset:           int64(file.Len()),
+			values:           int64(len(rows)),
+			uncompressedSize: int64(header.buffer.Len() + len(page)),
+			compressedSize:   int64(header.buffer.Len() + len(compressed)),
+		}
+		file.Write(header.buffer.Bytes())
+		file.Write(compressed)
+	}
+
+	footer := encodeFooter(columns, chunks, int64(len(rows)))
+	file.Write(footer)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	file.Write(length[:])
+	file.WriteString(parquetMagic)
+	return file.Bytes(), nil
+}
+
+// encodePage encodes the values of a column as the content of a data page: the definition levels of an optional
+// column followed by its non-null values
+func encodePage(column Column, index int, rows [][]interface{}) ([]byte, error) {
+	var page bytes.Buffer
+	if column.Optional {
+		levels := make([]bool, len(rows))
+		for i, row := range rows {
+			levels[i] = row[index] != nil
+		}
+		page.Write(encodeDefinitionLevels(levels))
+	}
+
+	for i, row := range rows {
+		value := row[index]
+		if value == nil {
+			if !column.Optional {
+				return nil, fmt.Errorf("row %d: column %s is required", i, column.Name)
+			}
+			continue
+		}
+		if err := writePlainValue(&page, column, value); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+func writePlainValue(page *bytes.Buffer, column Column, value interface{}) error {
+	switch column.Type {
+	case ColumnInt32:
+		typed, ok := value.(int32)
+		if !ok {
+			return fmt.Errorf("column %s takes int32 values, not %T", column.Name, value)
+		}
+		return binary.Write(page, binary.LittleEndian, typed)
+	case ColumnInt64:
+		typed, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("column %s takes int64 values, not %T", column.Name, value)
+		}
+		return binary.Write(page, binary.LittleEndian, typed)
+	case ColumnTimestamp:
+		typed, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("column %s takes time.Time values, not %T", column.Name, value)
+		}
+		return binary.Write(page, binary.LittleEndian, typed.UnixMilli())
+	case ColumnString:
+		typed, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s takes string values, not %T", column.Name, value)
+		}
+		if err := binary.Write(page, binary.LittleEndian, uint32(len(typed))); err != nil {
+			return err
+		}
+		page.WriteString(typed)
+		return nil
+	}
+	return fmt.Errorf("column %s has an unknown type", column.Name)
+}
+
+// encodeDefinitionLevels encodes whether each value of an optional column is set with the RLE hybrid encoding
+// at bit width 1, as runs of equal levels behind the length of the encoded runs
+func encodeDefinitionLevels(levels []bool) []byte {
+	var runs bytes.Buffer
+	for start := 0; start < len(levels); {
+		end := start
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		writeUvarint(&runs, uint64(end-start)<<1)
+		if levels[start] {
+			runs.WriteByte(1)
+		} else {
+			runs.WriteByte(0)
+		}
+		start = end
+	}
+	encoded := make([]byte, 4, 4+runs.Len())
+	binary.LittleEndian.PutUint32(encoded, uint32(runs.Len()))
+	return append(encoded, runs.Bytes()...)
+}
+
+// encodeFooter encodes the FileMetaData describing the schema and the column chunks of the file
+func encodeFooter(columns []Column, chunks []columnChunk, rows int64) []byte {
+	var footer thriftWriter
+	footer.i32(1, 1)
+
+	footer.beginStructList(2, len(columns)+1)
+	footer.string(4, "schema")
+	footer.i32(5, int32(len(columns)))
+	footer.endElement()
+	for _, column := range columns {
+		physicalType, convertedType := parquetTypes(column.Type)
+		footer.i32(1, physicalType)
+		repetition := int32(parquetRequired)
+		if column.Optional {
+			repetition = parquetOptional
+		}
+		footer.i32(3, repetition)
+		footer.string(4, column.Name)
+		if convertedType >= 0 {
+			footer.i32(6, convertedType)
+		}
+		footer.endElement()
+	}
+	footer.endStructList()
+
+	footer.i64(3, rows)
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.uncompressedSize
+	}
+	footer.beginStructList(4, 1)
+	footer.beginStructList(1, len(columns))
+	for i, column := range columns {
+		physicalType, _ := parquetTypes(column.Type)
+		chunk := chunks[i]
+		footer.i64(2, chunk.offset)
+		footer.beginStruct(3)
+		footer.i32(1, physicalType)
+		footer.beginList(2, thriftI32, 2)
+		footer.listI32(parquetEncodingPlain)
+		footer.listI32(parquetEncodingRLE)
+		footer.beginList(3, thriftBinary, 1)
+		footer.listString(column.Name)
+		footer.i32(4, parquetCodecGzip)
+		footer.i64(5, chunk.values)
+		footer.i64(6, chunk.uncompressedSize)
+		footer.i64(7, chunk.compressedSize)
+		footer.i64(9, chunk.offset)
+		footer.endStruct()
+		footer.endElement()
+	}
+	footer.endStructList()
+	footer.i64(2, totalSize)
+	footer.i64(3, rows)
+	footer.endElement()
+	footer.endStructList()
+
+	footer.string(6, parquetCreatedBy)
+	footer.stop()
+	return footer.buffer.Bytes()
+}
+
+// parquetTypes returns the physical and converted type of a column type, the converted type is -1 when the
+// physical type says it all
+func parquetTypes(columnType ColumnType) (int32, int32) {
+	switch columnType {
+	case ColumnInt32:
+		return parquetTypeInt32, -1
+	case ColumnString:
+		return parquetTypeByteArray, parquetConvertedUTF8
+	case ColumnTimestamp:
+		return parquetTypeInt64, parquetConvertedTimestampMillis
+	}
+	return parquetTypeInt64, -1
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// Types of the Thrift compact protocol the Parquet metadata is written with
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter writes structs with the Thrift compact protocol. Field IDs are written as deltas to the previous
+// field of the same struct, so the writer keeps the last field ID of every open struct.
+type thriftWriter struct {
+	buffer  bytes.Buffer
+	lastIDs []int16
+	lastID  int16
+}
+
+func (w *thriftWriter) field(id int16, fieldType byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buffer.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buffer.WriteByte(fieldType)
+		writeUvarint(&w.buffer, zigzag(int64(id)))
+	}
+	w.lastID = id
+}
+
+func (w *thriftWriter) i32(id int16, value int32) {
+	w.field(id, thriftI32)
+	writeUvarint(&w.buffer, zigzag(int64(value)))
+}
+
+func (w *thriftWriter) i64(id int16, value int64) {
+	w.field(id, thriftI64)
+	writeUvarint(&w.buffer, zigzag(value))
+}
+
+func (w *thriftWriter) string(id int16, value string) {
+	w.field(id, thriftBinary)
+	w.listString(value)
+}
+
+// beginStruct starts a struct field, ended with endStruct
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.lastIDs = append(w.lastIDs, w.lastID)
+	w.lastID = 0
+}
+
+func (w *thriftWriter) endStruct() {
+	w.stop()
+	w.lastID = w.lastIDs[len(w.lastIDs)-1]
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+// beginList starts a list field of size elements of a type other than struct, written with listI32 or listString
+func (w *thriftWriter) beginList(id int16, elementType byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buffer.WriteByte(byte(size)<<4 | elementType)
+	} else {
+		w.buffer.WriteByte(0xf0 | elementType)
+		writeUvarint(&w.buffer, uint64(size))
+	}
+}
+
+// beginStructList starts a list field of size structs. The fields of every struct are followed by endElement,
+// the list by endStructList.
+func (w *thriftWriter) beginStructList(id int16, size int) {
+	w.beginList(id, thriftStruct, size)
+	w.lastIDs = append(w.lastIDs, w.lastID)
+	w.lastID = 0
+}
+
+func (w *thriftWriter) endElement() {
+	w.stop()
+	w.lastID = 0
+}
+
+func (w *thriftWriter) endStructList() {
+	w.lastID = w.lastIDs[len(w.lastIDs)-1]
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+func (w *thriftWriter) listI32(value int32) {
+	writeUvarint(&w.buffer, zigzag(int64(value)))
+}
+
+func (w *thriftWriter) listString(value string) {
+	writeUvarint(&w.buffer, uint64(len(value)))
+	w.buffer.WriteString(value)
+}
+
+func (w *thriftWriter) stop() {
+	w.buffer.WriteByte(0)
+}
+
+func zigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
+
+func writeUvarint(buffer *bytes.Buffer, value uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	buffer.Write(encoded[:binary.PutUvarint(encoded[:], value)])
+}
@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps of field IDs to values, enough to check the metadata
+// written by EncodeParquet
+type thriftReader struct {
+	reader *bytes.Reader
+}
+
+func (r *thriftReader) uvarint(t *testing.T) uint64 {
+	value, err := binary.ReadUvarint(r.reader)
+	require.NoError(t, err)
+	return value
+}
+
+func (r *thriftReader) varint(t *testing.T) int64 {
+	value := r.uvarint(t)
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (r *thriftReader) value(t *testing.T, valueType byte) interface{} {
+	switch valueType {
+	case thriftI32, thriftI64:
+		return r.varint(t)
+	case thriftBinary:
+		data := make([]byte, r.uvarint(t))
+		_, err := io.ReadFull(r.reader, data)
+		require.NoError(t, err)
+		return string(data)
+	case thriftList:
+		header, err := r.reader.ReadByte()
+		require.NoError(t, err)
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint(t))
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(t, header&0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure(t)
+	}
+	t.Fatalf("unexpected thrift type %d", valueType)
+	return nil
+}
+
+func (r *thriftReader) structure(t *testing.T) map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var lastID int16
+	for {
+		header, err := r.reader.ReadByte()
+		require.NoError(t, err)
+		if header == 0 {
+			return fields
+		}
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint(t))
+		}
+		fields[id] = r.value(t, header&0x0f)
+		lastID = id
+	}
+}
+
+func TestEncodeParquet(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: ColumnInt64},
+		{Name: "status", Type: ColumnString},
+		{Name: "retries", Type: ColumnInt32},
+		{Name: "processed_at", Type: ColumnTimestamp, Optional: true},
+	}
+	processedAt := time.UnixMilli(1760608800000)
+	rows := [][]interface{}{
+		{int64(7), "success", int32(0), processedAt},
+		{int64(8), "failed", int32(2), nil},
+		{int64(9), "", int32(1), nil},
+	}
+	content, err := EncodeParquet(columns, rows)
+	require.NoError(t, err)
+
+	require.Equal(t, parquetMagic, string(content[:4]))
+	require.Equal(t, parquetMagic, string(content[len(content)-4:]))
+	footerLength := int(binary.LittleEndian.Uint32(content[len(content)-8:]))
+	footer := (&thriftReader{reader: bytes.NewReader(content[len(content)-8-footerLength : len(content)-8])}).structure(t)
+	assert.Equal(t, int64(3), footer[3])
+
+	schema := footer[2].([]interface{})
+	require.Len(t, schema, 5)
+	assert.Equal(t, int64(4), schema[0].(map[int16]interface{})[5])
+	timestamp := schema[4].(map[int16]interface{})
+	assert.Equal(t, "processed_at", timestamp[4])
+	assert.Equal(t, int64(parquetOptional), timestamp[3])
+	assert.Equal(t, int64(parquetConvertedTimestampMillis), timestamp[6])
+
+	chunks := footer[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	require.Len(t, chunks, 4)
+	pages := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		metadata := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+		assert.Equal(t, []interface{}{columns[i].Name}, metadata[3])
+		assert.Equal(t, int64(3), metadata[5])
+
+		reader := &thriftReader{reader: bytes.NewReader(content[metadata[9].(int64):])}
+		header := reader.structure(t)
+		compressed := make([]byte, header[3].(int64))
+		_, err := io.ReadFull(reader.reader, compressed)
+		require.NoError(t, err)
+		decompressor, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		pages[i], err = io.ReadAll(decompressor)
+		require.NoError(t, err)
+		assert.Len(t, pages[i], int(header[2].(int64)))
+	}
+
+	assert.Equal(t, []byte{7, 0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0}, pages[0])
+	assert.Equal(t, "\x07\x00\x00\x00success\x06\x00\x00\x00failed\x00\x00\x00\x00", string(pages[1]))
+	// One value set followed by two nulls as RLE runs, then the only value
+	levels := []byte{4, 0, 0, 0, 1 << 1, 1, 2 << 1, 0}
+	assert.Equal(t, levels, pages[3][:len(levels)])
+	assert.Equal(t, uint64(processedAt.UnixMilli()), binary.LittleEndian.Uint64(pages[3][len(levels):]))
+}
+
+func TestEncodeParquet_RejectsInvalidValues(t *testing.T) {
+	columns := []Column{{Name: "id", Type: ColumnInt64}}
+
+	_, err := EncodeParquet(columns, [][]interface{}{{nil}})
+	assert.ErrorContains(t, err, "required")
+	_, err = EncodeParquet(columns, [][]interface{}{{7}})
+	assert.ErrorContains(t, err, "int64")
+	_, err = EncodeParquet(columns, [][]interface{}{{int64(7), "x"}})
+	assert.Error(t, err)
+}
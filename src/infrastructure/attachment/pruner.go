@@ -0,0 +1,100 @@
+package attachment
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// pruneInterval is how often the expired attachments are removed
+const pruneInterval = time.Hour
+
+// pruneBatchSize is the number of expired attachments removed at once
+const pruneBatchSize = 100
+
+// Pruner periodically removes the uploads that weren't completed in time and the attachments past their
+// retention, on the leader instance only
+type Pruner struct {
+	repository providerRepo.AttachmentRepositoryInterface
+	store      *DirStore
+	elector    leader.Elector
+	Logger     *logger.Logger
+	shutdown   chan struct{}
+	done       chan struct{}
+}
+
+// NewPruner creates a new attachment pruner and starts it
+func NewPruner(repository providerRepo.AttachmentRepositoryInterface, store *DirStore, elector leader.Elector, loggerInstance *logger.Logger) *Pruner {
+	pruner := &Pruner{
+		repository: repository,
+		store:      store,
+		elector:    elector,
+		Logger:     loggerInstance,
+		shutdown:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go pruner.run()
+
+	return pruner
+}
+
+func (p *Pruner) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	p.Logger.Info("Starting attachment pruner")
+
+	p.prune()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.prune()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+func (p *Pruner) prune() {
+	if !p.elector.IsLeader() {
+		return
+	}
+	removed := 0
+	for {
+		attachments, err := p.repository.GetExpired(time.Now(), pruneBatchSize)
+		if err != nil {
+			p.Logger.Error("Error getting expired attachments", zap.Error(err))
+			break
+		}
+		for _, attachment := range attachments {
+			if err := p.store.Remove(attachment.ID); err != nil {
+				p.Logger.Error("Error removing attachment files", zap.Error(err), zap.Int("attachmentID", attachment.ID))
+				return
+			}
+			if err := p.repository.Delete(attachment.ID); err != nil {
+				return
+			}
+			removed++
+		}
+		if len(attachments) < pruneBatchSize {
+			break
+		}
+	}
+	if removed > 0 {
+		p.Logger.Info("Pruned expired attachments", zap.Int("removed", removed))
+	}
+}
+
+// Shutdown stops the pruner
+func (p *Pruner) Shutdown() {
+	close(p.shutdown)
+	<-p.done
+}
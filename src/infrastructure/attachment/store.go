@@ -0,0 +1,183 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// partPrefix starts the names of the part files, followed by the part number
+const partPrefix = "part-"
+
+// dataFile is the name of the file an upload is assembled into once complete
+const dataFile = "data"
+
+// ErrPartSize is returned for a part that doesn't have the size of its position in the file
+var ErrPartSize = errors.New("the part doesn't have the expected size")
+
+// Config controls the attachments uploaded in parts
+type Config struct {
+	// Dir keeps the parts and assembled files, it must be shared by the instances behind the load balancer
+	Dir string
+	// MaxBytes is the largest attachment accepted
+	MaxBytes int64
+	// ChunkBytes is the size of every part but the last
+	ChunkBytes int64
+	// UploadExpiry is how long an upload may take before it is deleted
+	UploadExpiry time.Duration
+	// Retention is how long a completed attachment can be sent before it is deleted
+	Retention time.Duration
+}
+
+// LoadConfig loads the attachment upload settings from environment variables
+func LoadConfig() (Config, error) {
+	maxMB, err := utils.GetIntEnv("ATTACHMENT_MAX_MB", 100)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ATTACHMENT_MAX_MB: %w", err)
+	}
+	chunkMB, err := utils.GetIntEnv("ATTACHMENT_CHUNK_MB", 8)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ATTACHMENT_CHUNK_MB: %w", err)
+	}
+	uploadExpiry, err := utils.GetIntEnv("ATTACHMENT_UPLOAD_EXPIRY_HOURS", 24)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ATTACHMENT_UPLOAD_EXPIRY_HOURS: %w", err)
+	}
+	retention, err := utils.GetIntEnv("ATTACHMENT_RETENTION_HOURS", 72)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ATTACHMENT_RETENTION_HOURS: %w", err)
+	}
+	if maxMB < 1 || chunkMB < 1 || uploadExpiry < 1 || retention < 1 {
+		return Config{}, errors.New("ATTACHMENT_MAX_MB, ATTACHMENT_CHUNK_MB, ATTACHMENT_UPLOAD_EXPIRY_HOURS and ATTACHMENT_RETENTION_HOURS must be at least 1")
+	}
+	return Config{
+		Dir:          utils.GetEnv("ATTACHMENT_UPLOAD_DIR", filepath.Join(os.TempDir(), "go-multi-chat-api-attachments")),
+		MaxBytes:     int64(maxMB) << 20,
+		ChunkBytes:   int64(chunkMB) << 20,
+		UploadExpiry: time.Duration(uploadExpiry) * time.Hour,
+		Retention:    time.Duration(retention) * time.Hour,
+	}, nil
+}
+
+// DirStore keeps the parts of each attachment in a directory of its own below dir, and the assembled file once the
+// upload is complete
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a store writing below dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+func (s *DirStore) attachmentDir(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id))
+}
+
+func (s *DirStore) partPath(id int, number int) string {
+	return filepath.Join(s.attachmentDir(id), fmt.Sprintf("%s%05d", partPrefix, number))
+}
+
+// WritePart stores a part read from body, it fails with ErrPartSize unless body holds exactly size bytes. A part
+// written again replaces the earlier one, so a failed part upload is retried as is.
+func (s *DirStore) WritePart(id int, number int, body io.Reader, size int64) error {
+	if err := os.MkdirAll(s.attachmentDir(id), 0o750); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(s.attachmentDir(id), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, io.LimitReader(body, size+1))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return ErrPartSize
+	}
+	return os.Rename(temp.Name(), s.partPath(id, number))
+}
+
+// Parts returns the numbers of the parts stored for an attachment
+func (s *DirStore) Parts(id int) (map[int]bool, error) {
+	entries, err := os.ReadDir(s.attachmentDir(id))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	parts := make(map[int]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, partPrefix) {
+			continue
+		}
+		if number, err := strconv.Atoi(strings.TrimPrefix(name, partPrefix)); err == nil {
+			parts[number] = true
+		}
+	}
+	return parts, nil
+}
+
+// Assemble joins the parts 1 to count into the file of the attachment and returns its hex SHA-256, the parts are
+// removed once the file is written
+func (s *DirStore) Assemble(id int, count int) (string, error) {
+	temp, err := os.CreateTemp(s.attachmentDir(id), ".assemble-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+
+	hash := sha256.New()
+	writer := io.MultiWriter(temp, hash)
+	for number := 1; number <= count; number++ {
+		if err = appendFile(writer, s.partPath(id, number)); err != nil {
+			break
+		}
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(s.attachmentDir(id), dataFile)); err != nil {
+		return "", err
+	}
+	for number := 1; number <= count; number++ {
+		_ = os.Remove(s.partPath(id, number))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func appendFile(writer io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+// Read returns the assembled file of an attachment
+func (s *DirStore) Read(id int) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.attachmentDir(id), dataFile))
+}
+
+// Remove deletes the parts and the file of an attachment
+func (s *DirStore) Remove(id int) error {
+	return os.RemoveAll(s.attachmentDir(id))
+}
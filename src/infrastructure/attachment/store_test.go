@@ -0,0 +1,50 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirStore_AssemblesParts(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+
+	// Parts are uploaded in any order, a part of the wrong size is refused
+	require.NoError(t, store.WritePart(1, 2, strings.NewReader("world"), 5))
+	assert.ErrorIs(t, store.WritePart(1, 1, strings.NewReader("hello!"), 5), ErrPartSize)
+	assert.ErrorIs(t, store.WritePart(1, 1, strings.NewReader("hell"), 5), ErrPartSize)
+	require.NoError(t, store.WritePart(1, 1, strings.NewReader("hello"), 5))
+
+	parts, err := store.Parts(1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 2: true}, parts)
+
+	digest, err := store.Assemble(1, 2)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("helloworld"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), digest)
+
+	data, err := store.Read(1)
+	require.NoError(t, err)
+	assert.Equal(t, "helloworld", string(data))
+	// The parts are removed once assembled
+	parts, err = store.Parts(1)
+	require.NoError(t, err)
+	assert.Empty(t, parts)
+
+	require.NoError(t, store.Remove(1))
+	_, err = os.Stat(filepath.Join(store.dir, "1"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDirStore_PartsOfUnknownAttachment(t *testing.T) {
+	parts, err := NewDirStore(t.TempDir()).Parts(9)
+	require.NoError(t, err)
+	assert.Empty(t, parts)
+}
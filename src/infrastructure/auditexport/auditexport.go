@@ -0,0 +1,207 @@
+package auditexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Formats of the exported audit records
+const (
+	// FormatJSONL writes one JSON object per line
+	FormatJSONL = "jsonl"
+	// FormatCEF writes one ArcSight Common Event Format line per record
+	FormatCEF = "cef"
+)
+
+// Targets the audit records are exported to
+const (
+	// TargetS3 uploads every batch as an object with a PUT request, as accepted by S3 compatible object stores
+	TargetS3 = "s3"
+	// TargetDir writes every batch as a file below a directory, e.g. a mounted bucket
+	TargetDir = "dir"
+	// TargetSyslog sends every record as a syslog message
+	TargetSyslog = "syslog"
+)
+
+// cefVendor and cefProduct name the device in the header of CEF lines
+const (
+	cefVendor  = "go-multi-chat-api"
+	cefProduct = "go-multi-chat-api"
+	cefVersion = "1.0"
+)
+
+// Config controls the export of the audit logs to a SIEM, it is disabled without a target
+type Config struct {
+	Target string
+	Format string
+	// Interval is the time between two scheduled exports
+	Interval time.Duration
+	// BatchSize is the number of records of a source exported in one batch
+	BatchSize int
+	// Prefix starts the keys of the objects and files the batches are written to
+	Prefix        string
+	S3URL         string
+	S3Token       string
+	Dir           string
+	SyslogNetwork string
+	SyslogAddress string
+}
+
+// LoadConfig loads the audit export settings from environment variables
+func LoadConfig() (Config, error) {
+	interval, err := utils.GetIntEnv("AUDIT_EXPORT_INTERVAL_MINUTES", 60)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid AUDIT_EXPORT_INTERVAL_MINUTES: %w", err)
+	}
+	if interval <= 0 {
+		return Config{}, fmt.Errorf("invalid AUDIT_EXPORT_INTERVAL_MINUTES: must be positive")
+	}
+	batchSize, err := utils.GetIntEnv("AUDIT_EXPORT_BATCH_SIZE", 1000)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid AUDIT_EXPORT_BATCH_SIZE: %w", err)
+	}
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("invalid AUDIT_EXPORT_BATCH_SIZE: must be positive")
+	}
+
+	config := Config{
+		Target:        utils.GetEnv("AUDIT_EXPORT_TARGET", ""),
+		Format:        utils.GetEnv("AUDIT_EXPORT_FORMAT", FormatJSONL),
+		Interval:      time.Duration(interval) * time.Minute,
+		BatchSize:     batchSize,
+		Prefix:        strings.Trim(utils.GetEnv("AUDIT_EXPORT_PREFIX", "audit"), "/"),
+		S3URL:         strings.TrimSuffix(utils.GetEnv("AUDIT_EXPORT_S3_URL", ""), "/"),
+		S3Token:       utils.GetEnv("AUDIT_EXPORT_S3_TOKEN", ""),
+		Dir:           utils.GetEnv("AUDIT_EXPORT_DIR", ""),
+		SyslogNetwork: utils.GetEnv("AUDIT_EXPORT_SYSLOG_NETWORK", "tcp"),
+		SyslogAddress: utils.GetEnv("AUDIT_EXPORT_SYSLOG_ADDRESS", ""),
+	}
+	switch config.Format {
+	case FormatJSONL, FormatCEF:
+	default:
+		return Config{}, fmt.Errorf("unsupported AUDIT_EXPORT_FORMAT: %s", config.Format)
+	}
+	switch config.Target {
+	case "":
+	case TargetS3:
+		if config.S3URL == "" {
+			return Config{}, fmt.Errorf("AUDIT_EXPORT_S3_URL is required for AUDIT_EXPORT_TARGET=s3")
+		}
+	case TargetDir:
+		if config.Dir == "" {
+			return Config{}, fmt.Errorf("AUDIT_EXPORT_DIR is required for AUDIT_EXPORT_TARGET=dir")
+		}
+	case TargetSyslog:
+		if config.SyslogAddress == "" {
+			return Config{}, fmt.Errorf("AUDIT_EXPORT_SYSLOG_ADDRESS is required for AUDIT_EXPORT_TARGET=syslog")
+		}
+		if config.SyslogNetwork != "tcp" && config.SyslogNetwork != "udp" {
+			return Config{}, fmt.Errorf("unsupported AUDIT_EXPORT_SYSLOG_NETWORK: %s", config.SyslogNetwork)
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported AUDIT_EXPORT_TARGET: %s", config.Target)
+	}
+	return config, nil
+}
+
+// Enabled reports whether the audit logs are exported
+func (c Config) Enabled() bool {
+	return c.Target != ""
+}
+
+// Record is an entry of an audit log as it is exported
+type Record struct {
+	Source    string    `json:"source"`
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`    // login or control_command
+	Outcome   string    `json:"outcome"`  // success or failure
+	Severity  int       `json:"severity"` // 0 to 10 like in CEF
+	UserID    int       `json:"user_id,omitempty"`
+	Actor     string    `json:"actor,omitempty"` // number of the operator sending a control command
+	ActorUUID string    `json:"actor_uuid,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Action    string    `json:"action,omitempty"` // login method or control command
+	Text      string    `json:"text,omitempty"`   // control message as received
+	Reason    string    `json:"reason,omitempty"` // why a login failed, or the reply to a control command
+	// NewLocation reports a login from an IP address or device not seen in earlier logins
+	NewLocation bool `json:"new_location,omitempty"`
+}
+
+// Encode writes the records in a format, one line per record
+func Encode(format string, records []Record) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, record := range records {
+		switch format {
+		case FormatJSONL:
+			line, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(line)
+		case FormatCEF:
+			buffer.WriteString(cefLine(record))
+		default:
+			return nil, fmt.Errorf("unsupported audit export format: %s", format)
+		}
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
+
+// cefLine formats a record as a CEF line, with the standard extension keys where one fits and custom strings
+// for the rest
+func cefLine(record Record) string {
+	name := "Login"
+	if record.Event == "control_command" {
+		name = "Control command"
+	}
+	extension := []string{
+		"rt=" + strconv.FormatInt(record.Time.UnixMilli(), 10),
+		"externalId=" + strconv.Itoa(record.ID),
+		"outcome=" + cefValue(record.Outcome),
+		"cs1Label=source",
+		"cs1=" + cefValue(record.Source),
+	}
+	add := func(key string, value string) {
+		if value != "" {
+			extension = append(extension, key+"="+cefValue(value))
+		}
+	}
+	if record.UserID != 0 {
+		add("suid", strconv.Itoa(record.UserID))
+	}
+	add("suser", record.Actor)
+	if record.ActorUUID != "" {
+		add("cs2Label", "actorUuid")
+		add("cs2", record.ActorUUID)
+	}
+	add("src", record.IPAddress)
+	add("requestClientApplication", record.UserAgent)
+	add("act", record.Action)
+	add("msg", record.Text)
+	add("reason", record.Reason)
+	if record.NewLocation {
+		add("cs3Label", "newLocation")
+		add("cs3", "true")
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s", cefHeader(cefVendor), cefHeader(cefProduct), cefVersion,
+		cefHeader(record.Event), cefHeader(name), record.Severity, strings.Join(extension, " "))
+}
+
+// cefHeader escapes a header field, backslashes and pipes are escaped
+func cefHeader(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ").Replace(value)
+}
+
+// cefValue escapes an extension value, backslashes, equal signs and line breaks are escaped
+func cefValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
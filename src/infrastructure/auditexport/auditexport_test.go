@@ -0,0 +1,95 @@
+package auditexport
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecords = []Record{
+	{Source: "login_events", ID: 7, Time: time.UnixMilli(1760608800000).UTC(), Event: "login", Outcome: "failure", Severity: 5,
+		UserID: 3, IPAddress: "203.0.113.9", UserAgent: "curl/8.5", Action: "password", Reason: "invalid password"},
+	{Source: "control_commands", ID: 2, Time: time.UnixMilli(1760608860000).UTC(), Event: "control_command", Outcome: "success", Severity: 5,
+		Actor: "+4912345678", Action: "pause", Text: "!pause provider=2|sms\nnow", Reason: "paused"},
+}
+
+func TestEncode_JSONL(t *testing.T) {
+	content, err := Encode(FormatJSONL, testRecords)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"source":"login_events","id":7,"time":"2025-10-16T10:00:00Z","event":"login","outcome":"failure","severity":5,`+
+		`"user_id":3,"ip_address":"203.0.113.9","user_agent":"curl/8.5","action":"password","reason":"invalid password"}`, lines[0])
+}
+
+func TestEncode_CEF(t *testing.T) {
+	content, err := Encode(FormatCEF, testRecords)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `CEF:0|go-multi-chat-api|go-multi-chat-api|1.0|login|Login|5|rt=1760608800000 externalId=7 outcome=failure `+
+		`cs1Label=source cs1=login_events suid=3 src=203.0.113.9 requestClientApplication=curl/8.5 act=password reason=invalid password`, lines[0])
+	// Equal signs and line breaks of values are escaped, so a record stays on one line
+	assert.Equal(t, `CEF:0|go-multi-chat-api|go-multi-chat-api|1.0|control_command|Control command|5|rt=1760608860000 externalId=2 `+
+		`outcome=success cs1Label=source cs1=control_commands suser=+4912345678 act=pause msg=!pause provider\=2|sms\nnow reason=paused`, lines[1])
+
+	_, err = Encode("xml", testRecords)
+	assert.Error(t, err)
+}
+
+func TestBatchHash_ChainsBatches(t *testing.T) {
+	first := BatchHash("", []byte("a\n"))
+	assert.Len(t, first, 64)
+	assert.NotEqual(t, BatchHash("", []byte("b\n")), BatchHash(first, []byte("b\n")))
+	assert.Equal(t, BatchHash(first, []byte("b\n")), BatchHash(first, []byte("b\n")))
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("AUDIT_EXPORT_TARGET", "")
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.False(t, config.Enabled())
+	assert.Equal(t, FormatJSONL, config.Format)
+
+	t.Setenv("AUDIT_EXPORT_TARGET", TargetS3)
+	_, err = LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("AUDIT_EXPORT_TARGET", TargetSyslog)
+	t.Setenv("AUDIT_EXPORT_SYSLOG_ADDRESS", "siem.example.com:6514")
+	t.Setenv("AUDIT_EXPORT_FORMAT", FormatCEF)
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.True(t, config.Enabled())
+	assert.Equal(t, "tcp", config.SyslogNetwork)
+
+	t.Setenv("AUDIT_EXPORT_FORMAT", "leef")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestSyslogTarget_SendsEveryLine(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	location, err := NewSyslogTarget("udp", conn.LocalAddr().String()).Put("audit/x.cef", []byte("first\nsecond\n"))
+	require.NoError(t, err)
+	assert.Empty(t, location)
+
+	buffer := make([]byte, 1024)
+	for _, want := range []string{"first", "second"} {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		n, _, err := conn.ReadFrom(buffer)
+		require.NoError(t, err)
+		message := string(buffer[:n])
+		// Priority 38 is the info level of the auth facility
+		assert.True(t, strings.HasPrefix(message, "<38>"), message)
+		assert.Contains(t, message, " "+syslogTag+"[")
+		assert.True(t, strings.HasSuffix(strings.TrimSpace(message), "]: "+want), message)
+	}
+}
@@ -0,0 +1,79 @@
+package auditexport
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Starter queues an audit export job
+type Starter interface {
+	Start(createdBy int) (*provider.Job, error)
+}
+
+// Scheduler periodically queues an audit export job, on the leader instance only
+type Scheduler struct {
+	starter  Starter
+	elector  leader.Elector
+	Logger   *logger.Logger
+	interval time.Duration
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewScheduler creates a new audit export scheduler and starts it
+func NewScheduler(starter Starter, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Hour // Default to exporting once an hour if not specified
+	}
+
+	scheduler := &Scheduler{
+		starter:  starter,
+		elector:  elector,
+		Logger:   loggerInstance,
+		interval: interval,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting audit export scheduler", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			s.queueExport()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) queueExport() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if _, err := s.starter.Start(0); err != nil {
+		s.Logger.Error("Error queueing audit export", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
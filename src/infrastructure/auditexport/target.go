@@ -0,0 +1,68 @@
+package auditexport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/syslog"
+
+	"go-multi-chat-api/src/infrastructure/payload"
+)
+
+// syslogTag is the tag of the syslog messages the records are sent as
+const syslogTag = "go-multi-chat-api"
+
+// Target receives the exported batches. Put writes a batch under a key and returns the reference of the written
+// object, empty when the target keeps no object per batch.
+type Target interface {
+	Put(key string, data []byte) (string, error)
+}
+
+// NewTarget creates the target of a configuration, nil when the export is disabled
+func NewTarget(config Config) Target {
+	switch config.Target {
+	case TargetS3:
+		return payload.NewHTTPStore(config.S3URL, config.S3Token)
+	case TargetDir:
+		return payload.NewDirStore(config.Dir)
+	case TargetSyslog:
+		return NewSyslogTarget(config.SyslogNetwork, config.SyslogAddress)
+	}
+	return nil
+}
+
+// SyslogTarget sends every line of a batch as a syslog message of the auth facility
+type SyslogTarget struct {
+	network string
+	address string
+}
+
+// NewSyslogTarget creates a target sending to the syslog server at address over tcp or udp
+func NewSyslogTarget(network string, address string) *SyslogTarget {
+	return &SyslogTarget{network: network, address: address}
+}
+
+// Put sends the lines of a batch over a new connection, the key isn't sent
+func (t *SyslogTarget) Put(key string, data []byte) (string, error) {
+	writer, err := syslog.Dial(t.network, t.address, syslog.LOG_INFO|syslog.LOG_AUTH, syslogTag)
+	if err != nil {
+		return "", err
+	}
+	defer writer.Close()
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		if err := writer.Info(string(line)); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// BatchHash chains the content of a batch to the batch exported before it: it is the hex SHA-256 of the hash of
+// the previous batch followed by the content. Recomputing the hashes from the first batch on detects a batch that
+// was altered, removed or reordered.
+func BatchHash(previousHash string, content []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(previousHash))
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,439 @@
+package configcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	"go-multi-chat-api/src/infrastructure/repository/mysql"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/rest/server"
+	"go-multi-chat-api/src/infrastructure/security"
+	"go-multi-chat-api/src/infrastructure/seed"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"gorm.io/gorm"
+)
+
+// dialTimeout bounds every connectivity check
+const dialTimeout = 5 * time.Second
+
+// Run validates the configuration the application would boot with, without migrating the database,
+// starting workers or sending anything, and reports every problem found
+func Run(loggerInstance *logger.Logger) *Report {
+	report := newReport()
+
+	config := checkSettings(report)
+	checkConfig(report, config)
+
+	db, err := mysql.ConnectMySQLDB(loggerInstance)
+	if err != nil {
+		report.fail("database", "couldn't connect: %v", err)
+	} else if err := pingDatabase(db); err != nil {
+		report.fail("database", "couldn't ping: %v", err)
+	} else {
+		report.ok("database", "connected")
+		checkStoredProviderConfigs(report, db, loggerInstance)
+	}
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+
+	checkSignalCli(report)
+	checkJWT(report)
+	checkLDAP(report)
+	checkAzureAD(report)
+	checkCredentialEncryption(report)
+
+	return report
+}
+
+// checkSettings loads the settings the application context is built with, through the loader of the DI
+// container, and reports every problem that would abort the boot
+func checkSettings(report *Report) *di.Config {
+	config, err := di.LoadConfig()
+	if err != nil {
+		report.fail("settings", "%s", strings.ReplaceAll(err.Error(), "\n", "; "))
+	} else {
+		report.ok("settings", "valid")
+	}
+	return config
+}
+
+// checkConfig checks the values of the loaded settings the dependencies reject only once they are created, and
+// runs the loaders of the settings read outside the DI container
+func checkConfig(report *Report, config *di.Config) {
+	if config.Leader.Type != "" && config.Leader.Type != "mysql" {
+		report.fail("leader_election", "unsupported leader election type: %s", config.Leader.Type)
+	} else {
+		report.ok("leader_election", "type %q", config.Leader.Type)
+	}
+
+	report.ok("watcher", "checking every %s, undelivered after %s", config.Watcher.Interval, config.Watcher.UndeliveredAfter)
+
+	if config.Directory.URL == "" {
+		report.ok("recipient_directory", "disabled")
+	} else if _, err := url.ParseRequestURI(config.Directory.URL); err != nil {
+		report.fail("recipient_directory", "invalid RECIPIENT_DIRECTORY_URL: %v", err)
+	} else {
+		report.ok("recipient_directory", "resolving %s", strings.Join(config.Directory.Schemes, ", "))
+	}
+
+	switch config.Publisher.Type {
+	case "":
+		report.ok("event_publisher", "disabled")
+	case "kafka", "nats":
+		report.ok("event_publisher", "%s publishing to %s", config.Publisher.Type, config.Publisher.Topic)
+	default:
+		report.fail("event_publisher", "unsupported event publisher type: %s", config.Publisher.Type)
+	}
+
+	if config.Payload.Store != "" {
+		report.ok("payload_policy", "storing full payloads in %s store", config.Payload.Store)
+	} else {
+		report.ok("payload_policy", "valid")
+	}
+
+	if os.Getenv("ALERT_EMAIL_HOST") == "" {
+		report.ok("alerting", "disabled")
+	} else {
+		report.ok("alerting", "email alerts through %s", os.Getenv("ALERT_EMAIL_HOST"))
+	}
+
+	if !config.ShortLink.Enabled() {
+		report.ok("link_tracking", "disabled")
+	} else if _, err := url.ParseRequestURI(config.ShortLink.BaseURL); err != nil {
+		report.fail("link_tracking", "invalid SHORT_LINK_BASE_URL: %v", err)
+	} else {
+		report.ok("link_tracking", "short links at %s%s", config.ShortLink.BaseURL, shortlink.Path)
+	}
+
+	if !config.Control.Enabled() {
+		report.ok("control_commands", "disabled")
+	} else {
+		report.ok("control_commands", "%d operators", len(config.Control.Operators))
+	}
+
+	report.ok("webhook_events", "kept for %d days", int(config.WebhookEventRetention.Hours()/24))
+
+	if len(config.QueueMonitor.AlertRecipients) > 0 && os.Getenv("ALERT_EMAIL_HOST") == "" {
+		report.warn("queue_monitor", "QUEUE_LAG_ALERT_RECIPIENTS is set but ALERT_EMAIL_HOST is not, queue lag alerts are only logged")
+	} else {
+		report.ok("queue_monitor", "valid")
+	}
+
+	report.ok("jobs", "%d workers, %d attempts", config.Jobs.Workers, config.Jobs.MaxAttempts)
+
+	if config, err := server.LoadConfig(); err != nil {
+		report.fail("http_middlewares", "%v", err)
+	} else if config.RateLimitPerMinute > 0 {
+		report.ok("http_middlewares", "rate limit %d requests per minute, body log %t, tracing %t", config.RateLimitPerMinute, config.BodyLog, config.Tracing)
+	} else {
+		report.ok("http_middlewares", "body log %t, tracing %t", config.BodyLog, config.Tracing)
+	}
+
+	if config, err := seed.LoadConfig(); err != nil {
+		report.fail("seed", "%v", err)
+	} else if _, err := config.Load(); err != nil {
+		report.fail("seed", "%v", err)
+	} else if config.File == "" && !config.Demo {
+		report.ok("seed", "disabled")
+	} else {
+		report.ok("seed", "file %q, demo %t", config.File, config.Demo)
+	}
+
+	// The connectivity checks run after the loaders, so they go through the configured outbound transport
+	if err := httpclient.Configure(config.Outbound); err != nil {
+		report.fail("outbound_http", "%v", err)
+	} else if config.Outbound.CABundle != "" {
+		report.ok("outbound_http", "timeout %s, trusting %s", config.Outbound.Timeout, config.Outbound.CABundle)
+	} else {
+		report.ok("outbound_http", "timeout %s", config.Outbound.Timeout)
+	}
+}
+
+func pingDatabase(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// checkStoredProviderConfigs validates the stored provider and user provider configs against the schemas of their types
+func checkStoredProviderConfigs(report *Report, db *gorm.DB, loggerInstance *logger.Logger) {
+	providers, err := providerRepo.NewProviderRepository(db, loggerInstance).GetAll()
+	if err != nil {
+		report.warn("provider_configs", "couldn't read providers, is the database migrated? %v", err)
+		return
+	}
+	var userProviders []providerRepo.UserProvider
+	if err := db.Find(&userProviders).Error; err != nil {
+		report.warn("provider_configs", "couldn't read user providers, is the database migrated? %v", err)
+		return
+	}
+
+	types := make(map[int]string, len(*providers))
+	var problems []string
+	for _, p := range *providers {
+		types[p.ID] = p.Type
+		providerType, ok := providerconfig.Lookup(p.Type)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("provider %d has the unknown type %q", p.ID, p.Type))
+			continue
+		}
+		if err := providerType.ProviderSchema.Validate(p.Config); err != nil {
+			problems = append(problems, fmt.Sprintf("provider %d: %v", p.ID, err))
+		}
+	}
+	for _, up := range userProviders {
+		providerType, ok := providerconfig.Lookup(types[up.ProviderID])
+		if !ok {
+			providerType = providerconfig.Generic()
+		}
+		if err := providerType.UserProviderSchema.Validate(up.Config); err != nil {
+			problems = append(problems, fmt.Sprintf("user provider %d: %v", up.ID, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		report.fail("provider_configs", "%s", strings.Join(problems, "; "))
+		return
+	}
+	report.ok("provider_configs", "%d providers and %d user providers are valid", len(*providers), len(userProviders))
+}
+
+// checkSignalCli checks that signal-cli is reachable in the configured mode, or the remote REST API with
+// the remote backend
+func checkSignalCli(report *Report) {
+	switch backend := utils.GetEnv("SIGNAL_BACKEND", "cli"); backend {
+	case "cli":
+	case "remote":
+		checkSignalNumber(report)
+		checkSignalRestApi(report)
+		return
+	default:
+		report.fail("signal_backend", "unknown SIGNAL_BACKEND %q, expected cli or remote", backend)
+		return
+	}
+
+	configDir := utils.GetEnv("SIGNAL_CLI_CONFIG_DIR", "/home/.local/share/signal-cli/")
+	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
+		report.fail("signal_cli_config", "config directory %s doesn't exist", configDir)
+	} else {
+		report.ok("signal_cli_config", "config directory %s", configDir)
+	}
+
+	checkSignalNumber(report)
+
+	mode := utils.GetEnv("SIGNAL_MODE", "normal")
+	switch mode {
+	case "normal":
+		checkSignalCliBinary(report, "signal-cli")
+	case "native":
+		if _, err := exec.LookPath("signal-cli-native"); err != nil {
+			report.warn("signal_cli", "signal-cli-native not found, falling back to signal-cli")
+			checkSignalCliBinary(report, "signal-cli")
+			return
+		}
+		report.ok("signal_cli", "signal-cli-native found")
+	case "json-rpc":
+		checkJsonRpc(report, strings.TrimSuffix(configDir, "/")+"/jsonrpc2.yml")
+	default:
+		report.fail("signal_cli", "unknown SIGNAL_MODE %q, expected normal, native or json-rpc", mode)
+	}
+}
+
+func checkSignalNumber(report *Report) {
+	if utils.GetEnv("SIGNAL_FROM_NUMBER", "") == "" {
+		report.warn("signal_number", "SIGNAL_FROM_NUMBER is not set, messages can't be sent or received through signal")
+	} else {
+		report.ok("signal_number", "set")
+	}
+}
+
+// checkSignalRestApi checks that the signal-cli-rest-api of the remote backend answers
+func checkSignalRestApi(report *Report) {
+	baseURL := utils.GetEnv("SIGNAL_REST_API_URL", "")
+	if baseURL == "" {
+		report.fail("signal_rest_api", "SIGNAL_REST_API_URL is required with SIGNAL_BACKEND remote")
+		return
+	}
+
+	client := httpclient.New(dialTimeout)
+	response, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/v1/about")
+	if err != nil {
+		report.fail("signal_rest_api", "unreachable: %v", err)
+		return
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		report.fail("signal_rest_api", "%s/v1/about answered %d", baseURL, response.StatusCode)
+		return
+	}
+	report.ok("signal_rest_api", "reachable at %s", baseURL)
+}
+
+func checkSignalCliBinary(report *Report, binary string) {
+	if _, err := exec.LookPath(binary); err != nil {
+		report.fail("signal_cli", "%s not found in PATH", binary)
+		return
+	}
+	report.ok("signal_cli", "%s found", binary)
+}
+
+// checkJsonRpc checks that the signal-cli daemon of every configured number accepts connections
+func checkJsonRpc(report *Report, configPath string) {
+	config := utils.NewJsonRpc2ClientConfig()
+	if err := config.Load(configPath); err != nil {
+		report.fail("signal_cli", "couldn't load %s: %v", configPath, err)
+		return
+	}
+
+	ports := config.GetTcpPortsForNumbers()
+	var unreachable []string
+	for number, port := range ports {
+		conn, err := net.DialTimeout("tcp", "127.0.0.1:"+strconv.FormatInt(port, 10), dialTimeout)
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s (port %d)", number, port))
+			continue
+		}
+		_ = conn.Close()
+	}
+	if len(unreachable) > 0 {
+		report.fail("signal_cli", "json-rpc daemon unreachable for %s", strings.Join(unreachable, ", "))
+		return
+	}
+	report.ok("signal_cli", "json-rpc daemon reachable for %d numbers", len(ports))
+}
+
+// checkJWT rejects missing or default secrets, tokens signed with them can be forged
+func checkJWT(report *Report) {
+	var problems []string
+	for _, secret := range []struct{ key, defaultValue string }{
+		{"JWT_ACCESS_SECRET_KEY", "default_access_secret"},
+		{"JWT_REFRESH_SECRET_KEY", "default_refresh_secret"},
+	} {
+		if value := os.Getenv(secret.key); value == "" || value == secret.defaultValue {
+			problems = append(problems, secret.key+" is not set")
+		}
+	}
+	for _, key := range []string{"JWT_ACCESS_TIME_MINUTE", "JWT_REFRESH_TIME_HOUR"} {
+		if value, exists := os.LookupEnv(key); exists {
+			if i, err := strconv.Atoi(value); err != nil || i <= 0 {
+				problems = append(problems, key+" must be a positive integer")
+			}
+		}
+	}
+	if os.Getenv("JWT_ACCESS_SECRET_KEY") != "" && os.Getenv("JWT_ACCESS_SECRET_KEY") == os.Getenv("JWT_REFRESH_SECRET_KEY") {
+		problems = append(problems, "JWT_ACCESS_SECRET_KEY and JWT_REFRESH_SECRET_KEY must differ")
+	}
+	if len(problems) > 0 {
+		report.fail("jwt", "%s", strings.Join(problems, "; "))
+		return
+	}
+	report.ok("jwt", "secrets set")
+}
+
+// checkLDAP checks the LDAP settings and that the server accepts connections, if LDAP is enabled
+func checkLDAP(report *Report) {
+	if utils.GetEnv("LDAP_ENABLED", "false") != "true" {
+		report.ok("ldap", "disabled")
+		return
+	}
+
+	missing := missingEnvVariables("LDAP_URL", "LDAP_BASE_DN")
+	if len(missing) > 0 {
+		report.fail("ldap", "missing %s", strings.Join(missing, ", "))
+		return
+	}
+
+	address, err := ldapAddress(os.Getenv("LDAP_URL"), utils.GetEnv("LDAP_TLS_ENABLED", "false") == "true")
+	if err != nil {
+		report.fail("ldap", "%v", err)
+		return
+	}
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		report.fail("ldap", "server unreachable: %v", err)
+		return
+	}
+	_ = conn.Close()
+	report.ok("ldap", "server reachable")
+}
+
+// ldapAddress returns the host and port of an LDAP_URL given as ldap://host:port, ldaps://host:port or host:port
+func ldapAddress(rawURL string, tls bool) (string, error) {
+	scheme := "ldap"
+	if tls {
+		scheme = "ldaps"
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = scheme + "://" + rawURL
+	}
+
+	ldapURL, err := url.Parse(rawURL)
+	if err != nil || (ldapURL.Scheme != "ldap" && ldapURL.Scheme != "ldaps") || ldapURL.Hostname() == "" {
+		return "", fmt.Errorf("LDAP_URL must be host:port or an ldap:// or ldaps:// URL")
+	}
+	port := ldapURL.Port()
+	if port == "" {
+		port = map[string]string{"ldap": "389", "ldaps": "636"}[ldapURL.Scheme]
+	}
+	return net.JoinHostPort(ldapURL.Hostname(), port), nil
+}
+
+// checkAzureAD checks the Azure AD settings, if Azure AD is enabled
+func checkAzureAD(report *Report) {
+	if utils.GetEnv("AZURE_AD_ENABLED", "false") != "true" {
+		report.ok("azure_ad", "disabled")
+		return
+	}
+
+	missing := missingEnvVariables("AZURE_AD_TENANT_ID", "AZURE_AD_CLIENT_ID", "AZURE_AD_CLIENT_SECRET", "AZURE_AD_REDIRECT_URI")
+	if len(missing) > 0 {
+		report.fail("azure_ad", "missing %s", strings.Join(missing, ", "))
+		return
+	}
+	redirectURI, err := url.Parse(os.Getenv("AZURE_AD_REDIRECT_URI"))
+	if err != nil || (redirectURI.Scheme != "http" && redirectURI.Scheme != "https") || redirectURI.Host == "" {
+		report.fail("azure_ad", "AZURE_AD_REDIRECT_URI must be an http or https URL")
+		return
+	}
+	report.ok("azure_ad", "settings complete")
+}
+
+// checkCredentialEncryption warns when stored credentials can't be encrypted
+func checkCredentialEncryption(report *Report) {
+	if _, err := security.NewCredentialCipherFromEnv(); err != nil {
+		report.warn("credential_encryption", "CREDENTIAL_ENCRYPTION_KEY is not set, credentials can't be stored")
+		return
+	}
+	report.ok("credential_encryption", "enabled")
+}
+
+func missingEnvVariables(keys ...string) []string {
+	var missing []string
+	for _, key := range keys {
+		if os.Getenv(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
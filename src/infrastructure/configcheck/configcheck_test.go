@@ -0,0 +1,159 @@
+package configcheck
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func onlyCheck(t *testing.T, report *Report) Check {
+	require.Len(t, report.Checks, 1)
+	return report.Checks[0]
+}
+
+func TestReport_WarningsKeepItValid(t *testing.T) {
+	report := newReport()
+	report.ok("a", "fine")
+	report.warn("b", "careful")
+	assert.True(t, report.Valid)
+
+	report.fail("c", "broken: %d", 42)
+	assert.False(t, report.Valid)
+	assert.Equal(t, Check{Name: "c", Status: StatusError, Message: "broken: 42"}, report.Checks[2])
+}
+
+func TestCheckSettings(t *testing.T) {
+	t.Setenv("SEND_BACKLOG_THRESHOLD", "many")
+	t.Setenv("ACK_CHECK_INTERVAL_SECONDS", "30")
+	t.Setenv("RECIPIENT_CAP_ACTION", "drop")
+
+	report := newReport()
+	checkSettings(report)
+	check := onlyCheck(t, report)
+	assert.Equal(t, StatusError, check.Status)
+	assert.Contains(t, check.Message, "invalid SEND_BACKLOG_THRESHOLD")
+	assert.Contains(t, check.Message, "; invalid RECIPIENT_CAP_ACTION")
+	assert.NotContains(t, check.Message, "ACK_CHECK_INTERVAL_SECONDS")
+
+	t.Setenv("SEND_BACKLOG_THRESHOLD", "100")
+	t.Setenv("RECIPIENT_CAP_ACTION", "hold")
+	report = newReport()
+	checkSettings(report)
+	assert.Equal(t, Check{Name: "settings", Status: StatusOK, Message: "valid"}, onlyCheck(t, report))
+}
+
+func TestCheckJWT(t *testing.T) {
+	t.Setenv("JWT_ACCESS_SECRET_KEY", "default_access_secret")
+	t.Setenv("JWT_REFRESH_SECRET_KEY", "refresh")
+	t.Setenv("JWT_ACCESS_TIME_MINUTE", "0")
+
+	report := newReport()
+	checkJWT(report)
+	check := onlyCheck(t, report)
+	assert.Equal(t, StatusError, check.Status)
+	assert.Equal(t, "JWT_ACCESS_SECRET_KEY is not set; JWT_ACCESS_TIME_MINUTE must be a positive integer", check.Message)
+
+	t.Setenv("JWT_ACCESS_SECRET_KEY", "access")
+	t.Setenv("JWT_ACCESS_TIME_MINUTE", "15")
+	report = newReport()
+	checkJWT(report)
+	assert.Equal(t, StatusOK, onlyCheck(t, report).Status)
+}
+
+func TestCheckLDAP(t *testing.T) {
+	t.Setenv("LDAP_ENABLED", "true")
+	t.Setenv("LDAP_URL", "http://ldap.example.com")
+	t.Setenv("LDAP_BASE_DN", "dc=example,dc=com")
+
+	report := newReport()
+	checkLDAP(report)
+	assert.Equal(t, "LDAP_URL must be host:port or an ldap:// or ldaps:// URL", onlyCheck(t, report).Message)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	t.Setenv("LDAP_URL", "ldap://127.0.0.1:"+strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	report = newReport()
+	checkLDAP(report)
+	assert.Equal(t, Check{Name: "ldap", Status: StatusOK, Message: "server reachable"}, onlyCheck(t, report))
+}
+
+func TestLdapAddress(t *testing.T) {
+	address, err := ldapAddress("ldap.example.com:389", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ldap.example.com:389", address)
+
+	address, err = ldapAddress("ldap.example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "ldap.example.com:636", address)
+
+	address, err = ldapAddress("ldaps://ldap.example.com", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ldap.example.com:636", address)
+}
+
+func TestCheckAzureAD(t *testing.T) {
+	t.Setenv("AZURE_AD_ENABLED", "true")
+	t.Setenv("AZURE_AD_TENANT_ID", "tenant")
+	t.Setenv("AZURE_AD_CLIENT_ID", "")
+
+	report := newReport()
+	checkAzureAD(report)
+	assert.Equal(t, "missing AZURE_AD_CLIENT_ID, AZURE_AD_CLIENT_SECRET, AZURE_AD_REDIRECT_URI", onlyCheck(t, report).Message)
+
+	t.Setenv("AZURE_AD_ENABLED", "false")
+	report = newReport()
+	checkAzureAD(report)
+	assert.Equal(t, Check{Name: "azure_ad", Status: StatusOK, Message: "disabled"}, onlyCheck(t, report))
+}
+
+func TestCheckSignalCli_UnknownMode(t *testing.T) {
+	t.Setenv("SIGNAL_CLI_CONFIG_DIR", t.TempDir())
+	t.Setenv("SIGNAL_FROM_NUMBER", "+491234567")
+	t.Setenv("SIGNAL_MODE", "rest")
+
+	report := newReport()
+	checkSignalCli(report)
+	assert.False(t, report.Valid)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.Equal(t, `unknown SIGNAL_MODE "rest", expected normal, native or json-rpc`, report.Checks[2].Message)
+}
+
+func TestCheckSignalCli_RemoteBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/about", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	t.Setenv("SIGNAL_BACKEND", "remote")
+	t.Setenv("SIGNAL_FROM_NUMBER", "+491234567")
+	t.Setenv("SIGNAL_REST_API_URL", server.URL)
+
+	report := newReport()
+	checkSignalCli(report)
+	assert.True(t, report.Valid)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "signal_rest_api", report.Checks[1].Name)
+
+	t.Setenv("SIGNAL_REST_API_URL", "")
+	report = newReport()
+	checkSignalCli(report)
+	assert.Equal(t, Check{Name: "signal_rest_api", Status: StatusError, Message: "SIGNAL_REST_API_URL is required with SIGNAL_BACKEND remote"}, report.Checks[1])
+}
+
+func TestCheckConfig_UnsupportedTypes(t *testing.T) {
+	t.Setenv("LEADER_ELECTION", "zookeeper")
+	t.Setenv("EVENT_PUBLISHER", "rabbitmq")
+
+	report := newReport()
+	checkConfig(report, checkSettings(newReport()))
+	assert.False(t, report.Valid)
+	assert.Equal(t, StatusError, report.Checks[0].Status)
+	assert.Equal(t, Check{Name: "event_publisher", Status: StatusError, Message: "unsupported event publisher type: rabbitmq"}, report.Checks[3])
+}
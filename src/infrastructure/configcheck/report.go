@@ -0,0 +1,46 @@
+package configcheck
+
+import "fmt"
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning"
+	StatusError   Status = "error"
+)
+
+// Check is the outcome of validating one part of the configuration
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report lists the outcome of every check. Valid is false if any check failed, warnings don't invalidate it.
+type Report struct {
+	Valid  bool    `json:"valid"`
+	Checks []Check `json:"checks"`
+}
+
+func newReport() *Report {
+	return &Report{Valid: true, Checks: []Check{}}
+}
+
+func (r *Report) ok(name string, format string, args ...interface{}) {
+	r.add(name, StatusOK, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) warn(name string, format string, args ...interface{}) {
+	r.add(name, StatusWarning, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) fail(name string, format string, args ...interface{}) {
+	r.Valid = false
+	r.add(name, StatusError, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) add(name string, status Status, message string) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Message: message})
+}
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// uuidPattern matches the UUID of a Signal account
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// numberPattern matches an E.164 phone number
+var numberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ErrSenderNotVerified is returned for operator numbers sending from another Signal account than the one they're pinned to
+var ErrSenderNotVerified = errors.New("sender verification failed")
+
+// Config controls the control commands operators send to the Signal number, they're disabled without operators
+type Config struct {
+	// Number is the registered number commands are received on and answered from
+	Number string
+	// Operators maps the allow-listed numbers to the UUID of the Signal account they're pinned to, empty when
+	// a number isn't pinned
+	Operators map[string]string
+	// Prefix starts every control message, other messages of operators are routed as usual
+	Prefix string
+}
+
+// LoadConfig loads the control command settings from environment variables. CONTROL_OPERATORS is a comma
+// separated list of numbers, each optionally pinned to a Signal account as number=uuid.
+func LoadConfig() (Config, error) {
+	operators, err := parseOperators(utils.GetEnv("CONTROL_OPERATORS", ""))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid CONTROL_OPERATORS: %w", err)
+	}
+	config := Config{
+		Number:    utils.GetEnv("SIGNAL_FROM_NUMBER", ""),
+		Operators: operators,
+		Prefix:    utils.GetEnv("CONTROL_COMMAND_PREFIX", "!"),
+	}
+	if config.Enabled() && strings.TrimSpace(config.Prefix) == "" {
+		return Config{}, errors.New("invalid CONTROL_COMMAND_PREFIX: must not be empty when CONTROL_OPERATORS is set")
+	}
+	return config, nil
+}
+
+func parseOperators(value string) (map[string]string, error) {
+	operators := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		number, uuid, _ := strings.Cut(entry, "=")
+		number, uuid = strings.TrimSpace(number), strings.TrimSpace(uuid)
+		if !numberPattern.MatchString(number) {
+			return nil, fmt.Errorf("%q is not an E.164 number", number)
+		}
+		if uuid != "" && !uuidPattern.MatchString(uuid) {
+			return nil, fmt.Errorf("%q is not a Signal account UUID", uuid)
+		}
+		operators[number] = strings.ToLower(uuid)
+	}
+	return operators, nil
+}
+
+// Enabled reports whether operators can send control commands
+func (c Config) Enabled() bool {
+	return len(c.Operators) > 0
+}
+
+// IsOperator reports whether a number is allow-listed
+func (c Config) IsOperator(number string) bool {
+	_, ok := c.Operators[number]
+	return ok
+}
+
+// Verify checks that the sender of a message is an operator on the Signal account its number is pinned to
+func (c Config) Verify(number string, uuid string) error {
+	pinned, ok := c.Operators[number]
+	if !ok {
+		return ErrSenderNotVerified
+	}
+	if pinned != "" && !strings.EqualFold(pinned, uuid) {
+		return ErrSenderNotVerified
+	}
+	return nil
+}
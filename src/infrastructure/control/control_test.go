@@ -0,0 +1,58 @@
+package control
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("CONTROL_OPERATORS", " +491701234567=1C8B0F2E-5D4A-4C3B-9A8E-7F6D5C4B3A21, +491709876543 ")
+	t.Setenv("CONTROL_COMMAND_PREFIX", "/")
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.True(t, config.Enabled())
+	assert.Equal(t, "/", config.Prefix)
+	assert.Equal(t, map[string]string{
+		"+491701234567": "1c8b0f2e-5d4a-4c3b-9a8e-7f6d5c4b3a21",
+		"+491709876543": "",
+	}, config.Operators)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	t.Setenv("CONTROL_OPERATORS", "0170 1234567")
+	_, err := LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("CONTROL_OPERATORS", "+491701234567=not-a-uuid")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("CONTROL_OPERATORS", "+491701234567")
+	t.Setenv("CONTROL_COMMAND_PREFIX", " ")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_DisabledByDefault(t *testing.T) {
+	t.Setenv("CONTROL_OPERATORS", "")
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.False(t, config.Enabled())
+}
+
+func TestVerify(t *testing.T) {
+	config := Config{Operators: map[string]string{
+		"+491701234567": "1c8b0f2e-5d4a-4c3b-9a8e-7f6d5c4b3a21",
+		"+491709876543": "",
+	}}
+
+	assert.NoError(t, config.Verify("+491701234567", "1C8B0F2E-5D4A-4C3B-9A8E-7F6D5C4B3A21"))
+	assert.ErrorIs(t, config.Verify("+491701234567", ""), ErrSenderNotVerified)
+	assert.ErrorIs(t, config.Verify("+491701234567", "00000000-0000-0000-0000-000000000000"), ErrSenderNotVerified)
+	assert.NoError(t, config.Verify("+491709876543", "00000000-0000-0000-0000-000000000000"))
+	assert.ErrorIs(t, config.Verify("+15550100", ""), ErrSenderNotVerified)
+}
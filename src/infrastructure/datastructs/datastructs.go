@@ -1,7 +1,7 @@
 package datastructs
 
 import (
-	"fmt"
+	domainSignal "go-multi-chat-api/src/domain/signal"
 )
 
 type RecpType int
@@ -12,27 +12,14 @@ const (
 	Group
 )
 
-type MessageMention struct {
-	Start  int64  `json:"start"`
-	Length int64  `json:"length"`
-	Author string `json:"author"`
-}
-
-func (s *MessageMention) ToString() string {
-	return fmt.Sprintf("%d:%d:%s", s.Start, s.Length, s.Author)
-}
+type MessageMention = domainSignal.MessageMention
 
 type SendMessageRecipient struct {
 	Identifier string `json:"identifier"`
 	Type       string `json:"type"`
 }
 
-type LinkPreviewType struct {
-	Url             string `json:"url"`
-	Title           string `json:"title"`
-	Description     string `json:"description"`
-	Base64Thumbnail string `json:"base64_thumbnail"`
-}
+type LinkPreviewType = domainSignal.LinkPreview
 
 type SignalCliSendRequest struct {
 	Number            string
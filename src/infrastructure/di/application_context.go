@@ -1,34 +1,135 @@
 package di
 
 import (
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"go-multi-chat-api/src/domain/common"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/acknowledgement"
+	"go-multi-chat-api/src/infrastructure/adminui"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	"go-multi-chat-api/src/infrastructure/anonymize"
+	"go-multi-chat-api/src/infrastructure/archive"
+	"go-multi-chat-api/src/infrastructure/attachment"
+	"go-multi-chat-api/src/infrastructure/auditexport"
+	"go-multi-chat-api/src/infrastructure/directory"
+	"go-multi-chat-api/src/infrastructure/discord"
+	"go-multi-chat-api/src/infrastructure/distributionlist"
+	"go-multi-chat-api/src/infrastructure/escalation"
+	"go-multi-chat-api/src/infrastructure/events"
 	"go-multi-chat-api/src/infrastructure/helper"
+	"go-multi-chat-api/src/infrastructure/leader"
+	"go-multi-chat-api/src/infrastructure/line"
 	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/queuesnapshot"
+	"go-multi-chat-api/src/infrastructure/receivestream"
+	"go-multi-chat-api/src/infrastructure/twilio"
 	"go-multi-chat-api/src/infrastructure/utils"
+	"go-multi-chat-api/src/infrastructure/verification"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
+	acknowledgementUseCase "go-multi-chat-api/src/application/usecases/acknowledgement"
+	actionUseCase "go-multi-chat-api/src/application/usecases/action"
+	analyticsUseCase "go-multi-chat-api/src/application/usecases/analytics"
+	anonymizeUseCase "go-multi-chat-api/src/application/usecases/anonymize"
+	archiveUseCase "go-multi-chat-api/src/application/usecases/archive"
+	attachmentUseCase "go-multi-chat-api/src/application/usecases/attachment"
+	auditExportUseCase "go-multi-chat-api/src/application/usecases/auditexport"
 	authUseCase "go-multi-chat-api/src/application/usecases/auth"
+	bootstrapUseCase "go-multi-chat-api/src/application/usecases/bootstrap"
+	bulkOperationUseCase "go-multi-chat-api/src/application/usecases/bulkoperation"
+	contentTransformUseCase "go-multi-chat-api/src/application/usecases/contenttransform"
+	controlUseCase "go-multi-chat-api/src/application/usecases/control"
+	conversationUseCase "go-multi-chat-api/src/application/usecases/conversation"
+	customDomainUseCase "go-multi-chat-api/src/application/usecases/customdomain"
+	deactivationUseCase "go-multi-chat-api/src/application/usecases/deactivation"
+	deliveryUseCase "go-multi-chat-api/src/application/usecases/delivery"
+	deviceLinkUseCase "go-multi-chat-api/src/application/usecases/devicelink"
+	digestUseCase "go-multi-chat-api/src/application/usecases/digest"
+	distributionListUseCase "go-multi-chat-api/src/application/usecases/distributionlist"
+	emailTemplateUseCase "go-multi-chat-api/src/application/usecases/emailtemplate"
+	engagementUseCase "go-multi-chat-api/src/application/usecases/engagement"
+	escalationUseCase "go-multi-chat-api/src/application/usecases/escalation"
+	hookUseCase "go-multi-chat-api/src/application/usecases/hook"
+	inboundNumberUseCase "go-multi-chat-api/src/application/usecases/inboundnumber"
+	jobUseCase "go-multi-chat-api/src/application/usecases/job"
+	loginAuditUseCase "go-multi-chat-api/src/application/usecases/loginaudit"
+	maintenanceUseCase "go-multi-chat-api/src/application/usecases/maintenance"
 	messageUseCase "go-multi-chat-api/src/application/usecases/message"
+	numberHealthUseCase "go-multi-chat-api/src/application/usecases/numberhealth"
+	providerUseCase "go-multi-chat-api/src/application/usecases/provider"
+	queueSnapshotUseCase "go-multi-chat-api/src/application/usecases/queuesnapshot"
+	receivedEnvelopeUseCase "go-multi-chat-api/src/application/usecases/receivedenvelope"
+	retentionUseCase "go-multi-chat-api/src/application/usecases/retention"
+	shortLinkUseCase "go-multi-chat-api/src/application/usecases/shortlink"
+	statusUseCase "go-multi-chat-api/src/application/usecases/status"
+	syncUseCase "go-multi-chat-api/src/application/usecases/syncfeed"
 	userUseCase "go-multi-chat-api/src/application/usecases/user"
+	verificationUseCase "go-multi-chat-api/src/application/usecases/verification"
+	"go-multi-chat-api/src/infrastructure/emailtemplate"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	"go-multi-chat-api/src/infrastructure/i18n"
+	"go-multi-chat-api/src/infrastructure/jobs"
 	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/matrix"
+	"go-multi-chat-api/src/infrastructure/payload"
+	"go-multi-chat-api/src/infrastructure/reporting"
 	"go-multi-chat-api/src/infrastructure/repository/mysql"
+	bootstrapRepo "go-multi-chat-api/src/infrastructure/repository/mysql/bootstrap"
 	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
 	"go-multi-chat-api/src/infrastructure/repository/mysql/user"
 	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+	telegramClient "go-multi-chat-api/src/infrastructure/repository/telegram-client"
+	actionController "go-multi-chat-api/src/infrastructure/rest/controllers/action"
+	analyticsController "go-multi-chat-api/src/infrastructure/rest/controllers/analytics"
+	anonymizeController "go-multi-chat-api/src/infrastructure/rest/controllers/anonymize"
+	archiveController "go-multi-chat-api/src/infrastructure/rest/controllers/archive"
+	attachmentController "go-multi-chat-api/src/infrastructure/rest/controllers/attachment"
+	auditExportController "go-multi-chat-api/src/infrastructure/rest/controllers/auditexport"
 	authController "go-multi-chat-api/src/infrastructure/rest/controllers/auth"
+	bootstrapController "go-multi-chat-api/src/infrastructure/rest/controllers/bootstrap"
+	bulkOperationController "go-multi-chat-api/src/infrastructure/rest/controllers/bulkoperation"
+	contentTransformController "go-multi-chat-api/src/infrastructure/rest/controllers/contenttransform"
+	controlController "go-multi-chat-api/src/infrastructure/rest/controllers/control"
+	conversationController "go-multi-chat-api/src/infrastructure/rest/controllers/conversation"
+	customDomainController "go-multi-chat-api/src/infrastructure/rest/controllers/customdomain"
+	deactivationController "go-multi-chat-api/src/infrastructure/rest/controllers/deactivation"
+	deliveryController "go-multi-chat-api/src/infrastructure/rest/controllers/delivery"
+	digestController "go-multi-chat-api/src/infrastructure/rest/controllers/digest"
+	distributionListController "go-multi-chat-api/src/infrastructure/rest/controllers/distributionlist"
+	emailTemplateController "go-multi-chat-api/src/infrastructure/rest/controllers/emailtemplate"
+	escalationController "go-multi-chat-api/src/infrastructure/rest/controllers/escalation"
+	hookController "go-multi-chat-api/src/infrastructure/rest/controllers/hook"
+	inboundNumberController "go-multi-chat-api/src/infrastructure/rest/controllers/inboundnumber"
+	jobController "go-multi-chat-api/src/infrastructure/rest/controllers/job"
+	loginAuditController "go-multi-chat-api/src/infrastructure/rest/controllers/loginaudit"
+	maintenanceController "go-multi-chat-api/src/infrastructure/rest/controllers/maintenance"
+	providerController "go-multi-chat-api/src/infrastructure/rest/controllers/provider"
+	queueSnapshotController "go-multi-chat-api/src/infrastructure/rest/controllers/queuesnapshot"
+	retentionController "go-multi-chat-api/src/infrastructure/rest/controllers/retention"
 	sendController "go-multi-chat-api/src/infrastructure/rest/controllers/send"
+	shortLinkController "go-multi-chat-api/src/infrastructure/rest/controllers/shortlink"
 	signalController "go-multi-chat-api/src/infrastructure/rest/controllers/signal"
+	statusController "go-multi-chat-api/src/infrastructure/rest/controllers/status"
+	syncController "go-multi-chat-api/src/infrastructure/rest/controllers/syncfeed"
 	userController "go-multi-chat-api/src/infrastructure/rest/controllers/user"
+	"go-multi-chat-api/src/infrastructure/retention"
+	"go-multi-chat-api/src/infrastructure/retry"
 	"go-multi-chat-api/src/infrastructure/security"
+	"go-multi-chat-api/src/infrastructure/sendgrid"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+	"go-multi-chat-api/src/infrastructure/statuspage"
+	"go-multi-chat-api/src/infrastructure/transform"
 
 	"gorm.io/gorm"
 )
@@ -40,19 +141,104 @@ type ApplicationContext struct {
 	AuthController                      authController.IAuthController
 	UserController                      userController.IUserController
 	SignalController                    signalController.ISignalController
+	RegistrationLockController          signalController.IRegistrationLockController
+	RateLimitChallengeController        signalController.IRateLimitChallengeController
+	DeviceLinkController                signalController.IDeviceLinkController
+	NumberHealthController              signalController.INumberHealthController
+	VerificationController              signalController.IVerificationController
+	ReceiveStreamController             signalController.IReceiveStreamController
+	ReceivedEnvelopeController          signalController.IReceivedEnvelopeController
+	GroupLinkController                 signalController.IGroupLinkController
+	RecipientController                 signalController.IRecipientController
+	RemoteDeleteController              signalController.IRemoteDeleteController
 	SendController                      sendController.ISendController
+	DigestController                    digestController.IDigestController
+	AnalyticsController                 analyticsController.IAnalyticsController
+	HookController                      hookController.IHookController
+	EscalationController                escalationController.IEscalationController
+	ProviderController                  providerController.IProviderController
+	LoginAuditController                loginAuditController.ILoginAuditController
+	DistributionListController          distributionListController.IDistributionListController
+	EmailTemplateController             emailTemplateController.IEmailTemplateController
+	ContentTransformController          contentTransformController.IContentTransformController
+	InboundNumberController             inboundNumberController.IInboundNumberController
+	ShortLinkController                 shortLinkController.IShortLinkController
+	ConversationController              conversationController.IConversationController
+	BulkOperationController             bulkOperationController.IBulkOperationController
+	QueueSnapshotController             queueSnapshotController.IQueueSnapshotController
+	MaintenanceController               maintenanceController.IMaintenanceController
+	RetentionController                 retentionController.IRetentionController
+	JobController                       jobController.IJobController
+	DeliveryController                  deliveryController.IDeliveryController
+	ActionController                    actionController.IActionController
+	ControlController                   controlController.IControlController
+	CustomDomainController              customDomainController.ICustomDomainController
+	DeactivationController              deactivationController.IDeactivationController
+	AuditExportController               auditExportController.IAuditExportController
+	ArchiveController                   archiveController.IArchiveController
+	AnonymizeController                 anonymizeController.IAnonymizeController
+	AttachmentController                attachmentController.IAttachmentController
+	StatusController                    statusController.IStatusController
+	SyncController                      syncController.ISyncController
+	BootstrapController                 bootstrapController.IBootstrapController
 	JWTService                          security.IJWTService
 	LDAPService                         security.ILDAPService
 	AzureADService                      security.IAzureADService
+	ExternalTokenValidator              security.IExternalTokenValidator
 	CommonService                       common.CommonService
 	UserRepository                      user.UserRepositoryInterface
+	UserLocales                         *i18n.UserLocales
+	AdminUIConfig                       adminui.Config
+	StatusPageConfig                    statuspage.Config
 	AuthUseCase                         authUseCase.IAuthUseCase
 	UserUseCase                         userUseCase.IUserUseCase
+	DeactivationUseCase                 deactivationUseCase.IDeactivationUseCase
 	MessageProcessor                    *messaging.MessageProcessor
 	ProviderRepository                  providerRepo.ProviderRepositoryInterface
 	UserProviderRepository              providerRepo.UserProviderRepositoryInterface
 	MessageTransactionRepository        providerRepo.MessageTransactionRepositoryInterface
 	MessageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	OutboxEventRepository               providerRepo.OutboxEventRepositoryInterface
+	OutboxRelay                         *events.OutboxRelay
+	RegistrationLockRepository          signalRepo.RegistrationLockRepositoryInterface
+	RateLimitChallengeRepository        signalRepo.RateLimitChallengeRepositoryInterface
+	DeviceLinkRepository                signalRepo.DeviceLinkRepositoryInterface
+	ReceivedMessageRepository           signalRepo.ReceivedMessageRepositoryInterface
+	ReceivedEnvelopeRepository          signalRepo.ReceivedEnvelopeRepositoryInterface
+	DigestRepository                    providerRepo.DigestRepositoryInterface
+	DigestScheduler                     *reporting.DigestScheduler
+	HookSubscriptionRepository          providerRepo.HookSubscriptionRepositoryInterface
+	HookDispatcher                      *messaging.HookDispatcher
+	WebhookEventRepository              providerRepo.WebhookEventRepositoryInterface
+	ControlCommandRepository            providerRepo.ControlCommandRepositoryInterface
+	HookEventPruner                     *messaging.HookEventPruner
+	EscalationRepository                providerRepo.EscalationRepositoryInterface
+	DistributionListRepository          providerRepo.DistributionListRepositoryInterface
+	EmailTemplateRepository             providerRepo.EmailTemplateRepositoryInterface
+	ContentTransformRepository          providerRepo.ContentTransformRepositoryInterface
+	ShortLinkRepository                 providerRepo.ShortLinkRepositoryInterface
+	ConversationRepository              providerRepo.ConversationRepositoryInterface
+	JobRepository                       providerRepo.JobRepositoryInterface
+	JobRunner                           *jobs.Runner
+	MessageDeliveryRepository           providerRepo.MessageDeliveryRepositoryInterface
+	MessageEngagementRepository         providerRepo.MessageEngagementRepositoryInterface
+	EventBus                            *events.Bus
+	ProviderDrillRepository             providerRepo.ProviderDrillRepositoryInterface
+	LoginActivityRepository             user.LoginActivityRepositoryInterface
+	EscalationScheduler                 *escalation.Scheduler
+	DistributionListScheduler           *distributionlist.Scheduler
+	VerificationScheduler               *verification.Scheduler
+	MessagePurgeScheduler               *retention.Scheduler
+	AuditExportScheduler                *auditexport.Scheduler
+	ArchiveScheduler                    *archive.Scheduler
+	AttachmentPruner                    *attachment.Pruner
+	AcknowledgementScheduler            *acknowledgement.Scheduler
+	RetryScheduler                      *retry.Scheduler
+	QueueMonitor                        *messaging.QueueMonitor
+	ReceivePoller                       *signalClient.ReceivePoller
+	ReceiveDeduplicator                 *signalClient.ReceiveDeduplicator
+	MatrixSyncPoller                    *matrix.SyncPoller
+	LeaderElector                       leader.Elector
 }
 
 var (
@@ -69,12 +255,25 @@ func GetLogger() *logger.Logger {
 
 // SetupDependencies creates a new application context with all dependencies
 func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, error) {
+	// Read every setting before anything is created, so that an invalid one aborts the boot before the database
+	// is migrated
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize database with logger
 	db, err := mysql.InitMySQLDB(loggerInstance)
 	if err != nil {
 		return nil, err
 	}
 
+	// Configure the proxies, trusted CAs and connection pooling of every outbound HTTP client before the
+	// integrations create theirs
+	if err := httpclient.Configure(config.Outbound); err != nil {
+		return nil, err
+	}
+
 	// Initialize signal-cli configuration
 	signalCliConfigDir := "/home/.local/share/signal-cli/"
 	signalCliConfigDirEnv := utils.GetEnv("SIGNAL_CLI_CONFIG_DIR", "")
@@ -139,21 +338,47 @@ func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, erro
 		if signalCliCommandTimeoutEnvVariableSet {
 			loggerInstance.Fatal("Env variable SIGNAL_CLI_CMD_TIMEOUT can't be used with mode json-rpc")
 		}
+
+		// signal-cli pushes received messages in json-rpc mode, there is nothing to poll
+		_, receivePollIntervalEnvVariableSet := os.LookupEnv("RECEIVE_POLL_INTERVAL_SECONDS")
+		if receivePollIntervalEnvVariableSet {
+			loggerInstance.Fatal("Env variable RECEIVE_POLL_INTERVAL_SECONDS can't be used with mode json-rpc")
+		}
 	}
 
+	// Received messages are posted to the webhook in every mode, pushed by signal-cli in json-rpc mode
+	// and polled on a schedule in the normal and native modes
 	webhookUrl := utils.GetEnv("RECEIVE_WEBHOOK_URL", "")
-	if webhookUrl != "" && signalCliMode != signalClient.JsonRpc {
-		log.Fatal("Env variable RECEIVE_WEBHOOK_URL can't be used with mode json-rpc!")
-	}
 
 	jsonRpc2ClientConfigPathPath := *signalCliConfig + "/jsonrpc2.yml"
 	signalCliApiConfigPath := *signalCliConfig + "/api-config.yml"
 
-	// Create the signal client directly for backward compatibility
-	signalClientInstance := signalClient.NewSignalClient(*signalCliConfig, *attachmentTmpDir, *avatarTmpDir, signalCliMode, jsonRpc2ClientConfigPathPath, signalCliApiConfigPath, webhookUrl, loggerInstance)
-	err = signalClientInstance.Init()
-	if err != nil {
-		log.Fatal("Couldn't init Signal Client: ", err.Error())
+	// Every consumer sends and receives through the Signal service, backed by signal-cli on this host or by
+	// a remote signal-cli-rest-api
+	var signalService domainSignal.ISignalService
+	var signalClientInstance *signalClient.SignalClient
+	switch config.SignalBackend {
+	case "cli":
+		signalClientInstance = signalClient.NewSignalClient(*signalCliConfig, *attachmentTmpDir, *avatarTmpDir, signalCliMode, jsonRpc2ClientConfigPathPath, signalCliApiConfigPath, webhookUrl, loggerInstance)
+		err = signalClientInstance.Init()
+		if err != nil {
+			log.Fatal("Couldn't init Signal Client: ", err.Error())
+		}
+		signalService = signalClient.NewSignalRepositoryWithClient(signalClientInstance, loggerInstance)
+	case "remote":
+		signalRestApiUrl := config.SignalRestApiURL
+		remoteSignalRepository := signalClient.NewRemoteSignalRepository(signalRestApiUrl, utils.GetEnv("SIGNAL_REST_API_TOKEN", ""),
+			time.Duration(config.SignalRestApiTimeout)*time.Second, loggerInstance)
+		// The remote API may come up after this instance, so it only has to be reachable once messages are sent
+		if about, err := remoteSignalRepository.About(); err != nil {
+			loggerInstance.Warn("Couldn't reach the signal-cli-rest-api", zap.String("url", signalRestApiUrl), zap.Error(err))
+		} else {
+			loggerInstance.Info("Using the signal-cli-rest-api", zap.String("url", signalRestApiUrl), zap.String("version", about.Version), zap.String("mode", about.Mode))
+			if about.Mode == "json-rpc" {
+				loggerInstance.Warn("The signal-cli-rest-api runs in json-rpc mode, received messages can't be polled from it")
+			}
+		}
+		signalService = remoteSignalRepository
 	}
 
 	// Initialize JWT service (manages its own configuration)
@@ -187,54 +412,490 @@ func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, erro
 	azureADService := security.NewAzureADService(azureADConfig, loggerInstance)
 	loggerInstance.Info("Azure AD authentication " + map[bool]string{true: "enabled", false: "disabled"}[azureADEnabled])
 
+	// Accept the access tokens Azure AD issues for the API in place of tokens of the API
+	var externalTokenValidator security.IExternalTokenValidator
+	if utils.GetEnv("AZURE_AD_API_TOKENS_ENABLED", "false") == "true" {
+		if azureADConfig.TenantID == "" {
+			return nil, fmt.Errorf("AZURE_AD_API_TOKENS_ENABLED needs AZURE_AD_TENANT_ID")
+		}
+		tokenConfig := security.AzureADTokenConfig(azureADConfig, utils.GetEnv("AZURE_AD_API_AUDIENCE", ""))
+		externalTokenValidator, err = security.NewExternalTokenValidator(tokenConfig, loggerInstance)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure AD API token configuration: %w", err)
+		}
+		loggerInstance.Info("Azure AD API tokens accepted", zap.String("audience", tokenConfig.Audience))
+	}
+
+	// Initialize the cipher used to encrypt stored credentials, secrets can't be stored without it
+	credentialCipher, err := security.NewCredentialCipherFromEnv()
+	if err != nil {
+		loggerInstance.Warn("Credential encryption disabled, CREDENTIAL_ENCRYPTION_KEY is not set")
+	}
+	// Secrets of users, like the secrets of their hook subscriptions, are encrypted with a key of each user
+	userSecretCipher, err := security.NewUserSecretCipherFromEnv()
+	if err != nil {
+		loggerInstance.Warn("Hook secrets are stored in plaintext, CREDENTIAL_ENCRYPTION_KEY is not set")
+	}
+
 	validator := helper.NewValidator(loggerInstance)
 	commonService := common.NewCommonService(validator)
 
 	// Initialize repositories with logger
 	userRepo := user.NewUserRepository(db, loggerInstance)
+	userLocales := i18n.NewUserLocales(userRepo)
+	loginActivityRepository := user.NewLoginActivityRepository(db, loggerInstance)
 	providerRepository := providerRepo.NewProviderRepository(db, loggerInstance)
 	userProviderRepository := providerRepo.NewUserProviderRepository(db, loggerInstance)
-	messageTransactionRepository := providerRepo.NewMessageTransactionRepository(db, loggerInstance)
+	messageTransactionRepository := providerRepo.NewMessageTransactionRepository(db, loggerInstance, config.Publisher.Enabled())
 	messageTransactionHistoryRepository := providerRepo.NewMessageTransactionHistoryRepository(db, loggerInstance)
+	outboxEventRepository := providerRepo.NewOutboxEventRepository(db, loggerInstance)
+	receivedMessageRepository := signalRepo.NewReceivedMessageRepository(db, loggerInstance)
+	receivedEnvelopeRepository := signalRepo.NewReceivedEnvelopeRepository(db, loggerInstance)
+	registrationLockRepository := signalRepo.NewRegistrationLockRepository(db, loggerInstance)
+	rateLimitChallengeRepository := signalRepo.NewRateLimitChallengeRepository(db, loggerInstance)
+	deviceLinkRepository := signalRepo.NewDeviceLinkRepository(db, loggerInstance)
+	verificationAttemptRepository := signalRepo.NewVerificationAttemptRepository(db, loggerInstance)
+	numberHealthRepository := signalRepo.NewNumberHealthRepository(db, loggerInstance)
+	digestRepository := providerRepo.NewDigestRepository(db, loggerInstance)
+	hookSubscriptionRepository := providerRepo.NewHookSubscriptionRepository(db, loggerInstance)
+	webhookEventRepository := providerRepo.NewWebhookEventRepository(db, loggerInstance)
+	controlCommandRepository := providerRepo.NewControlCommandRepository(db, loggerInstance)
+	escalationRepository := providerRepo.NewEscalationRepository(db, loggerInstance)
+	distributionListRepository := providerRepo.NewDistributionListRepository(db, loggerInstance)
+	providerDrillRepository := providerRepo.NewProviderDrillRepository(db, loggerInstance)
+	emailTemplateRepository := providerRepo.NewEmailTemplateRepository(db, loggerInstance)
+	shortLinkRepository := providerRepo.NewShortLinkRepository(db, loggerInstance)
+	customDomainRepository := providerRepo.NewCustomDomainRepository(db, loggerInstance)
+	conversationRepository := providerRepo.NewConversationRepository(db, loggerInstance)
+	syncRepository := providerRepo.NewSyncRepository(db, loggerInstance)
+	bootstrapRepository := bootstrapRepo.NewBootstrapRepository(db, userSecretCipher, loggerInstance)
+	jobRepository := providerRepo.NewJobRepository(db, loggerInstance)
+	messageDeliveryRepository := providerRepo.NewMessageDeliveryRepository(db, loggerInstance)
+	messageEditRepository := providerRepo.NewMessageEditRepository(db, loggerInstance)
+	partitionRepository := providerRepo.NewPartitionRepository(db, loggerInstance)
+	auditExportBatchRepository := providerRepo.NewAuditExportBatchRepository(db, loggerInstance)
+	messageArchiveBatchRepository := providerRepo.NewMessageArchiveBatchRepository(db, loggerInstance)
+	attachmentRepository := providerRepo.NewAttachmentRepository(db, loggerInstance)
+	statusBannerRepository := providerRepo.NewStatusBannerRepository(db, loggerInstance)
+	messageEngagementRepository := providerRepo.NewMessageEngagementRepository(db, loggerInstance)
+	recipientCapViolationRepository := providerRepo.NewRecipientCapViolationRepository(db, loggerInstance)
+	contentTransformRepository := providerRepo.NewContentTransformRepository(db, loggerInstance)
+
+	// Elect the instance running the periodic background jobs, all instances keep processing their queues
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get database handle for leader election: %w", err)
+	}
+	leaderElector, err := leader.NewElector(config.Leader, sqlDB, loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+
+	// Publish message lifecycle events written to the outbox, if an event publisher is configured
+	var outboxRelay *events.OutboxRelay
+	if config.Publisher.Enabled() {
+		publisher, err := events.NewPublisher(config.Publisher, loggerInstance)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create event publisher: %w", err)
+		}
+		outboxRelay = events.NewOutboxRelay(outboxEventRepository, publisher, leaderElector, loggerInstance, time.Duration(config.EventRelayInterval)*time.Second, 100)
+		loggerInstance.Info("Event publishing enabled", zap.String("publisher", config.Publisher.Type), zap.String("topic", config.Publisher.Topic))
+	}
 
 	// Initialize use cases with logger
-	authUC := authUseCase.NewAuthUseCase(userRepo, jwtService, ldapService, azureADService, loggerInstance)
+	authUC := authUseCase.NewAuthUseCase(userRepo, jwtService, ldapService, azureADService, externalTokenValidator, loggerInstance)
 	userUC := userUseCase.NewUserUseCase(userRepo, loggerInstance)
 
+	// Deliver events to the REST hook subscriptions of users
+	hookDispatcher := messaging.NewHookDispatcher(hookSubscriptionRepository, webhookEventRepository, userSecretCipher, loggerInstance)
+
+	// Deliver the messages sent and received by this instance to the read models, like the conversations
+	eventBus := events.NewBus(config.EventBusBufferSize, loggerInstance)
+
+	// Replace the URLs of messages tracking links with signed short links, if a short link base URL is configured
+	// Users with a verified custom domain get their short links on it
+	customDomainUC := customDomainUseCase.NewCustomDomainUseCase(customDomainRepository, nil, loggerInstance)
+	linkTracker := shortlink.NewTracker(config.ShortLink, shortLinkRepository, customDomainUC, loggerInstance)
+
+	// Content transformations of users are applied to their messages before they are stored
+	contentTransformer := transform.NewPipeline(contentTransformRepository, linkTracker, time.Duration(config.ContentTransformTimeout)*time.Second, loggerInstance)
+
+	// Matrix clients are shared by sending and sync, so resolved room aliases are looked up once per account
+	matrixClients := matrix.NewClients(time.Duration(config.MatrixTimeout) * time.Second)
+
+	discordClient := discord.NewClient(utils.GetEnv("DISCORD_API_URL", discord.DefaultAPIURL), time.Duration(config.DiscordTimeout)*time.Second)
+	telegramBotClient := telegramClient.NewClient(utils.GetEnv("TELEGRAM_API_URL", telegramClient.DefaultAPIURL), time.Duration(config.TelegramTimeout)*time.Second)
+
+	lineClient := line.NewClient(utils.GetEnv("LINE_API_URL", line.DefaultAPIURL), time.Duration(config.LineTimeout)*time.Second)
+
+	twilioClient := twilio.NewClient(utils.GetEnv("TWILIO_API_URL", twilio.DefaultAPIURL), time.Duration(config.TwilioTimeout)*time.Second)
+
+	// Attachments uploaded in parts are kept below a directory the instances share until they expire
+	attachmentStore := attachment.NewDirStore(config.Attachment.Dir)
+	attachmentUC := attachmentUseCase.NewAttachmentUseCase(attachmentRepository, attachmentStore, config.Attachment, loggerInstance)
+	attachmentPruner := attachment.NewPruner(attachmentRepository, attachmentStore, leaderElector, loggerInstance)
+
+	// The status page reports the health of the provider types and the banners of the admins
+	statusUC := statusUseCase.NewStatusUseCase(providerRepository, messageTransactionHistoryRepository, statusBannerRepository, config.StatusPage, loggerInstance)
+
+	// Numbers linked as devices of an account, a link waits for its QR code to be scanned until the timeout
+	deviceLinkUC := deviceLinkUseCase.NewDeviceLinkUseCase(signalService, deviceLinkRepository, deviceLinkUseCase.Config{
+		Timeout:      time.Duration(config.SignalLinkTimeout) * time.Second,
+		PollInterval: 3 * time.Second,
+	}, loggerInstance)
+
+	// Track the health of the Signal numbers from their send and receive errors, and alert operators when one needs them
+	var numberHealthAlertRecipients []string
+	for _, recipient := range strings.Split(utils.GetEnv("NUMBER_HEALTH_ALERT_RECIPIENTS", ""), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			numberHealthAlertRecipients = append(numberHealthAlertRecipients, recipient)
+		}
+	}
+	numberHealthUC := numberHealthUseCase.NewNumberHealthUseCase(numberHealthRepository, config.Alerting.GetAlertingProviderByAlertType(alert.TypeEmail),
+		numberHealthUseCase.Config{FailureThreshold: config.NumberHealthFailureThreshold, AlertRecipients: numberHealthAlertRecipients}, loggerInstance)
+
+	// Senders by provider type, the processor sends every message through the sender of its provider's type
+	// Vendors post the delivery status of the messages of a provider below the public base URL of the API
+	webhookBaseURL := os.Getenv("INBOUND_WEBHOOK_BASE_URL")
+	deliveryCallbackURL := func(vendor string) func(providerID int) string {
+		return func(providerID int) string {
+			return deliveryUseCase.CallbackURL(webhookBaseURL, vendor, providerID)
+		}
+	}
+	senders := map[string]messaging.ProviderSender{
+		string(alert.TypeSignal):   messaging.NewSignalSender(signalService, attachmentUC, deviceLinkUC, numberHealthUC),
+		string(alert.TypeMatrix):   messaging.NewMatrixSender(matrixClients, userProviderRepository),
+		string(alert.TypeDiscord):  messaging.NewDiscordSender(discordClient, userProviderRepository),
+		string(alert.TypeTelegram): messaging.NewTelegramSender(telegramBotClient, userProviderRepository),
+		string(alert.TypeLine):     messaging.NewLineSender(lineClient),
+		string(alert.TypeEmail):    messaging.NewEmailSender(userProviderRepository, attachmentUC),
+		string(alert.TypeSMS):      messaging.NewSMSSender(twilioClient, deliveryCallbackURL("twilio")),
+	}
+
+	// The sandbox provider type sends nowhere, for load tests against a deployment without vendors
+	if config.Sandbox != nil {
+		senders[string(alert.TypeSandbox)] = messaging.NewSandboxSender(*config.Sandbox)
+	}
+
 	// Create message processor with 100 worker goroutines
 	messageProcessor := messaging.NewMessageProcessor(
-		signalClientInstance,
+		senders,
 		providerRepository,
 		userProviderRepository,
 		messageTransactionRepository,
 		messageTransactionHistoryRepository,
+		providerDrillRepository,
+		messageDeliveryRepository,
+		rateLimitChallengeRepository,
+		payload.NewPolicy(config.Payload, loggerInstance),
 		loggerInstance,
 		100, // 100 worker goroutines
+		config.MaxInFlightPerUser,
+		config.Recovery,
+		config.Watcher,
+		leaderElector,
+		hookDispatcher,
+		linkTracker,
+		userLocales,
+		eventBus,
 	)
 
+	// Refresh the queue gauges and alert when the oldest pending message waits too long
+	queueMonitor := messaging.NewQueueMonitor(messageTransactionRepository, config.Alerting.GetAlertingProviderByAlertType(alert.TypeEmail),
+		leaderElector, loggerInstance, config.QueueMonitor)
+
+	// Resolve directory identifiers such as employee:1234 at send time, if a recipient directory is configured
+	recipientResolver := directory.NewResolver(config.Directory, loggerInstance)
+
 	// Initialize message use case
 	messageUC := messageUseCase.NewMessageUseCase(
 		providerRepository,
 		userProviderRepository,
 		messageTransactionRepository,
+		messageTransactionHistoryRepository,
 		messageProcessor,
+		queueMonitor,
 		userRepo,
+		messageUseCase.BacklogConfig{
+			Threshold:  config.BacklogThreshold,
+			RetryAfter: time.Duration(config.BacklogRetryAfter) * time.Second,
+		},
+		messageUseCase.RecipientCapConfig{PerHour: config.RecipientCapPerHour, PerDay: config.RecipientCapPerDay, Action: config.RecipientCapAction},
+		recipientResolver,
+		linkTracker,
+		messageDeliveryRepository,
+		messageEditRepository,
+		attachmentRepository,
+		recipientCapViolationRepository,
+		contentTransformer,
 		loggerInstance,
 	)
 
+	// Retry the failed messages due for a retry on every check of the watcher, the retry policy of their error
+	// code decides between the same and the next provider
+	retryScheduler := retry.NewScheduler(messageUC, leaderElector, loggerInstance, config.Watcher.Interval)
+
+	// Initialize digest use case and the scheduler generating due digests
+	digestUC := digestUseCase.NewDigestUseCase(digestRepository, messageTransactionHistoryRepository, shortLinkRepository, messageUC, loggerInstance)
+	digestScheduler := reporting.NewDigestScheduler(digestUC, leaderElector, loggerInstance, time.Duration(config.DigestCheckInterval)*time.Minute)
+
+	analyticsUC := analyticsUseCase.NewAnalyticsUseCase(messageTransactionHistoryRepository, messageEngagementRepository,
+		recipientCapViolationRepository, loggerInstance)
+	// Optionally only let users subscribe hook targets on their verified custom domain
+	var hookDomains hookUseCase.HookDomainResolver
+	if utils.GetEnv("HOOK_REQUIRE_VERIFIED_DOMAIN", "false") == "true" {
+		hookDomains = customDomainUC
+	}
+	hookUC := hookUseCase.NewHookUseCase(hookSubscriptionRepository, webhookEventRepository, hookDispatcher, hookDomains, userSecretCipher, loggerInstance)
+	// Encrypt the hook secrets stored in plaintext before the encryption key was set
+	if encrypted, err := hookUC.EncryptStoredSecrets(); err != nil {
+		loggerInstance.Error("Error encrypting stored hook secrets", zap.Error(err))
+	} else if encrypted > 0 {
+		loggerInstance.Info("Encrypted stored hook secrets", zap.Int("count", encrypted))
+	}
+
+	// Remove the webhook events older than the retention period, they can't be replayed anymore
+	hookEventPruner := messaging.NewHookEventPruner(webhookEventRepository, leaderElector, loggerInstance, config.WebhookEventRetention)
+	providerUC := providerUseCase.NewProviderUseCase(providerRepository, userProviderRepository, providerDrillRepository, userRepo, messageProcessor, userSecretCipher, loggerInstance)
+
+	// Initialize escalation use case and the scheduler notifying the due steps of active escalations
+	escalationUC := escalationUseCase.NewEscalationUseCase(escalationRepository, messageUC, loggerInstance)
+	escalationScheduler := escalation.NewScheduler(escalationUC, leaderElector, loggerInstance, time.Duration(config.EscalationCheckInterval)*time.Second)
+
+	// Initialize acknowledgement use case and the scheduler expiring unacknowledged messages
+	acknowledgementUC := acknowledgementUseCase.NewAcknowledgementUseCase(messageTransactionRepository, messageProcessor, escalationUC, loggerInstance)
+	acknowledgementScheduler := acknowledgement.NewScheduler(acknowledgementUC, leaderElector, loggerInstance, time.Duration(config.AckCheckInterval)*time.Second)
+
+	// Initialize action use case, reporting the actions recipients choose by the buttons vendors post clicks on
+	// below /v1/interactions and by numbered replies
+	actionVendors := map[string]actionUseCase.Vendor{
+		"discord": {ProviderType: string(alert.TypeDiscord), Parser: discord.NewInteractions()},
+		"line":    {ProviderType: string(alert.TypeLine), Parser: line.NewPostbacks()},
+	}
+	actionChosen := func(userID int, choice *domainProvider.ActionChoice) {
+		hookDispatcher.DispatchToUser(userID, messaging.HookEventMessageAction, messaging.NewActionHookPayload(choice))
+	}
+	actionUC := actionUseCase.NewActionUseCase(providerRepository, messageTransactionRepository, actionVendors, actionChosen, loggerInstance)
+
+	// Initialize distribution list use case and the scheduler syncing the membership of their Signal groups
+	distributionListUC := distributionListUseCase.NewDistributionListUseCase(distributionListRepository, messageUC, signalService, distributionListUseCase.Config{
+		Number:    os.Getenv("SIGNAL_FROM_NUMBER"),
+		Reconcile: utils.GetEnv("DISTRIBUTION_LIST_RECONCILE", "true") == "true",
+	}, loggerInstance)
+	distributionListScheduler := distributionlist.NewScheduler(distributionListUC, leaderElector, loggerInstance, time.Duration(config.DistributionListSyncInterval)*time.Minute)
+
+	// Verify the numbers to be registered by SMS, calling them instead when the code isn't verified in time, and
+	// space the codes requested for a number so Signal doesn't ban it
+	verificationConfig := verificationUseCase.Config{
+		SMSTimeout:  time.Duration(config.VerificationSMSTimeout) * time.Second,
+		Cooldown:    time.Duration(config.VerificationCooldown) * time.Second,
+		MaxAttempts: config.VerificationMaxAttempts,
+		Window:      time.Duration(config.VerificationWindow) * time.Hour,
+	}
+	verificationUC := verificationUseCase.NewVerificationUseCase(signalService, verificationAttemptRepository, verificationConfig, loggerInstance)
+	verificationScheduler := verification.NewScheduler(verificationUC, leaderElector, loggerInstance, 15*time.Second)
+
+	// Initialize email template use case, images embedded in templates are read from EMAIL_ATTACHMENT_DIR
+	var emailAttachmentStore emailtemplate.AttachmentStore
+	if dir := os.Getenv("EMAIL_ATTACHMENT_DIR"); dir != "" {
+		emailAttachmentStore = emailtemplate.NewDirAttachmentStore(dir)
+	}
+	emailRenderer := emailtemplate.NewRenderer(emailAttachmentStore)
+	emailTemplateUC := emailTemplateUseCase.NewEmailTemplateUseCase(emailTemplateRepository, emailRenderer, loggerInstance)
+	contentTransformUC := contentTransformUseCase.NewContentTransformUseCase(contentTransformRepository, contentTransformer, contentTransformer, loggerInstance)
+	// Documents are checked against JWT_ORG, so one meant for another deployment isn't applied by mistake
+	bootstrapUC := bootstrapUseCase.NewBootstrapUseCase(bootstrapRepository, providerRepository, hookDispatcher, emailRenderer, security.LoadJWTConfig().Org, loggerInstance)
+
+	// Initialize inbound number use case, numbers are provisioned through the vendor of the SMS provider and
+	// the vendor posts the SMS they receive below INBOUND_WEBHOOK_BASE_URL
+	numberProvisioners := map[string]inboundNumberUseCase.NumberProvisioner{
+		"twilio": twilio.NewProvisioner(twilioClient),
+	}
+	routeSMSReceived := func(userProvider *domainProvider.UserProvider, sms *domainProvider.InboundSMS) {
+		routeInboundSMS(userProvider, sms, hookDispatcher, eventBus, escalationUC, acknowledgementUC, actionUC, loggerInstance)
+	}
+	inboundNumberUC := inboundNumberUseCase.NewInboundNumberUseCase(providerRepository, userProviderRepository, numberProvisioners, routeSMSReceived,
+		inboundNumberUseCase.Config{WebhookBaseURL: webhookBaseURL}, loggerInstance)
+	// Record the engagement of the recipients of the users tracking it, from the sends, the deliveries and the
+	// clicks on short links
+	engagementUC := engagementUseCase.NewEngagementUseCase(messageEngagementRepository, userRepo, loggerInstance)
+	eventBus.Subscribe(events.TopicMessage, func(event events.Event) {
+		if messageEvent, ok := event.Payload.(*domainProvider.MessageEvent); ok {
+			engagementUC.RecordSent(messageEvent)
+		}
+	})
+	// Initialize delivery use case, updating the delivery of messages from the callbacks of their vendor and the
+	// receipts of the Signal number
+	deliveryVendors := map[string]deliveryUseCase.Vendor{
+		"twilio":   {ProviderType: string(alert.TypeSMS), Parser: twilio.NewDeliveryCallbacks()},
+		"sendgrid": {ProviderType: string(alert.TypeEmail), Parser: sendgrid.NewDeliveryCallbacks()},
+	}
+	deliveryChanged := func(userID int, delivery *domainProvider.MessageDelivery) {
+		hookDispatcher.DispatchToUser(userID, messaging.HookEventMessageDelivery, messaging.NewDeliveryHookPayload(delivery))
+		engagementUC.RecordDelivery(userID, delivery)
+	}
+	deliveryUC := deliveryUseCase.NewDeliveryUseCase(providerRepository, messageTransactionRepository, messageDeliveryRepository, deliveryVendors,
+		deliveryChanged, deliveryUseCase.Config{CallbackBaseURL: webhookBaseURL}, loggerInstance)
+	shortLinkUC := shortLinkUseCase.NewShortLinkUseCase(linkTracker, shortLinkRepository, engagementUC.RecordClick, loggerInstance)
+
+	// Run the control commands operators send to the Signal number
+	controlUC := controlUseCase.NewControlUseCase(controlCommandRepository, providerRepository, messageTransactionRepository, signalService, config.Control, loggerInstance)
+
+	// Project the message events into the conversations read model
+	conversationUC := conversationUseCase.NewConversationUseCase(conversationRepository, messageTransactionRepository, providerRepository, loggerInstance)
+	syncUC := syncUseCase.NewSyncUseCase(syncRepository, loggerInstance)
+	eventBus.Subscribe(events.TopicMessage, func(event events.Event) {
+		if messageEvent, ok := event.Payload.(*domainProvider.MessageEvent); ok {
+			conversationUC.Project(messageEvent)
+		}
+	})
+
+	// Run the long-running tasks as background jobs, the workers of every instance share the job queue
+	jobRunner := jobs.NewRunner(jobRepository, config.Jobs, loggerInstance)
+	deactivationUC := deactivationUseCase.NewDeactivationUseCase(userRepo, userProviderRepository, messageTransactionRepository, messageTransactionHistoryRepository, loggerInstance)
+	bulkOperationUC := bulkOperationUseCase.NewBulkOperationUseCase(jobRunner, messageTransactionRepository, messageTransactionHistoryRepository, loggerInstance)
+	jobRunner.Register(bulkOperationUseCase.JobType, bulkOperationUC.Run)
+
+	// Snapshot the messages waiting to be sent and replay snapshots after an incident corrupted the queue
+	queueSnapshotUC := queueSnapshotUseCase.NewQueueSnapshotUseCase(jobRunner,
+		queuesnapshot.New(messageTransactionRepository, messageTransactionHistoryRepository, loggerInstance),
+		utils.GetEnv("QUEUE_SNAPSHOT_DIR", "queue-snapshots"), loggerInstance)
+	jobRunner.Register(queueSnapshotUseCase.SnapshotJobType, queueSnapshotUC.RunSnapshot)
+	jobRunner.Register(queueSnapshotUseCase.ReplayJobType, queueSnapshotUC.RunReplay)
+
+	// Stream the received messages to the admins debugging inbound routing, until maintenance mode is enabled
+	receiveStream := receivestream.New(loggerInstance)
+	maintenanceUC := maintenanceUseCase.NewMaintenanceUseCase(providerRepo.NewMaintenanceModeRepository(db, loggerInstance), receiveStream, loggerInstance)
+
+	// Purge the messages older than the retention and keep the partitions of the coming months ready, once a day.
+	// The purge also enforces the retention policy of the org, which the admins may set at any time.
+	retentionOrg := utils.GetEnv("JWT_ORG", "default")
+	if retentionOrg == "" {
+		retentionOrg = "default"
+	}
+	retentionUC := retentionUseCase.NewRetentionUseCase(jobRunner, partitionRepository,
+		providerRepo.NewRetentionPolicyRepository(db, loggerInstance), providerRepo.NewDataRetentionRepository(db, loggerInstance),
+		attachmentRepository, retentionUseCase.Config{
+			MonthsAhead:     config.Partition.MonthsAhead,
+			RetentionMonths: config.Partition.RetentionMonths,
+			Org:             retentionOrg,
+		}, loggerInstance)
+	jobRunner.Register(retentionUseCase.JobType, retentionUC.Run)
+	messagePurgeScheduler := retention.NewScheduler(retentionUC, leaderElector, loggerInstance, 24*time.Hour)
+
+	// Export the login events and control commands to the SIEM target, incrementally from the last checkpoint
+	auditExportUC := auditExportUseCase.NewAuditExportUseCase(jobRunner, loginActivityRepository, controlCommandRepository, auditExportBatchRepository,
+		auditexport.NewTarget(config.AuditExport), config.AuditExport, loggerInstance)
+	jobRunner.Register(auditExportUseCase.JobType, auditExportUC.Run)
+	var auditExportScheduler *auditexport.Scheduler
+	if config.AuditExport.Enabled() {
+		auditExportScheduler = auditexport.NewScheduler(auditExportUC, leaderElector, loggerInstance, config.AuditExport.Interval)
+	}
+
+	// Archive the message history older than ARCHIVE_AFTER_DAYS to Parquet files in cold storage
+	archiveUC := archiveUseCase.NewArchiveUseCase(jobRunner, messageTransactionHistoryRepository, messageArchiveBatchRepository,
+		archive.NewTarget(config.Archive), config.Archive, loggerInstance)
+	jobRunner.Register(archiveUseCase.JobType, archiveUC.Run)
+	var archiveScheduler *archive.Scheduler
+	if config.Archive.Enabled() {
+		archiveScheduler = archive.NewScheduler(archiveUC, leaderElector, loggerInstance, config.Archive.Interval)
+	}
+
+	// Anonymize a clone of the production database in place for staging, only where ANONYMIZE_ENABLED allows it
+	anonymizer, err := anonymize.New(db, config.Anonymize, loggerInstance)
+	if err != nil {
+		return nil, err
+	}
+	anonymizeUC := anonymizeUseCase.NewAnonymizeUseCase(jobRunner, anonymizer, config.Anonymize.Enabled, loggerInstance)
+	jobRunner.Register(anonymizeUseCase.JobType, anonymizeUC.Run)
+	jobRunner.Start()
+	jobUC := jobUseCase.NewJobUseCase(jobRepository, loggerInstance)
+
+	// Initialize login audit use case, notifying users about logins from new locations and repeated failures
+	loginAuditUC := loginAuditUseCase.NewLoginAuditUseCase(loginActivityRepository, userRepo, messageUC, loginAuditUseCase.Config{
+		FailureThreshold: config.LoginFailureThreshold,
+		FailureWindow:    time.Duration(config.LoginFailureWindow) * time.Minute,
+	}, loggerInstance)
+
 	// Initialize controllers with logger
-	authController := authController.NewAuthController(authUC, loggerInstance)
+	authController := authController.NewAuthController(authUC, loginAuditUC, loggerInstance)
 	userController := userController.NewUserController(userUC, loggerInstance)
-	signalClientController := signalController.NewSignalController(signalClientInstance, commonService, loggerInstance)
+	digestController := digestController.NewDigestController(digestUC, loggerInstance)
+	analyticsController := analyticsController.NewAnalyticsController(analyticsUC, loggerInstance)
+	hookController := hookController.NewHookController(hookUC, loggerInstance)
+	escalationController := escalationController.NewEscalationController(escalationUC, loggerInstance)
+	providerController := providerController.NewProviderController(providerUC, loggerInstance)
+	loginAuditController := loginAuditController.NewLoginAuditController(loginAuditUC, loggerInstance)
+	distributionListController := distributionListController.NewDistributionListController(distributionListUC, loggerInstance)
+	emailTemplateController := emailTemplateController.NewEmailTemplateController(emailTemplateUC, loggerInstance)
+	contentTransformController := contentTransformController.NewContentTransformController(contentTransformUC, loggerInstance)
+	inboundNumberController := inboundNumberController.NewInboundNumberController(inboundNumberUC, loggerInstance)
+	shortLinkController := shortLinkController.NewShortLinkController(shortLinkUC, loggerInstance)
+	conversationController := conversationController.NewConversationController(conversationUC, loggerInstance)
+	bulkOperationController := bulkOperationController.NewBulkOperationController(bulkOperationUC, loggerInstance)
+	queueSnapshotController := queueSnapshotController.NewQueueSnapshotController(queueSnapshotUC, loggerInstance)
+	maintenanceController := maintenanceController.NewMaintenanceController(maintenanceUC, loggerInstance)
+	retentionController := retentionController.NewRetentionController(retentionUC, loggerInstance)
+	jobController := jobController.NewJobController(jobUC, loggerInstance)
+	deliveryController := deliveryController.NewDeliveryController(deliveryUC, loggerInstance)
+	actionController := actionController.NewActionController(actionUC, loggerInstance)
+	controlController := controlController.NewControlController(controlUC, loggerInstance)
+	customDomainController := customDomainController.NewCustomDomainController(customDomainUC, loggerInstance)
+	deactivationController := deactivationController.NewDeactivationController(deactivationUC, loggerInstance)
+	auditExportController := auditExportController.NewAuditExportController(auditExportUC, loggerInstance)
+	archiveController := archiveController.NewArchiveController(archiveUC, loggerInstance)
+	anonymizeController := anonymizeController.NewAnonymizeController(anonymizeUC, loggerInstance)
+	attachmentController := attachmentController.NewAttachmentController(attachmentUC, loggerInstance)
+	statusController := statusController.NewStatusController(statusUC, loggerInstance)
+	syncController := syncController.NewSyncController(syncUC, loggerInstance)
+	bootstrapController := bootstrapController.NewBootstrapController(bootstrapUC, loggerInstance)
+	signalClientController := signalController.NewSignalController(signalService, commonService, loggerInstance)
+	registrationLockController := signalController.NewRegistrationLockController(signalService, registrationLockRepository, credentialCipher, loggerInstance)
+	rateLimitChallengeController := signalController.NewRateLimitChallengeController(signalService, rateLimitChallengeRepository, messageTransactionRepository, loggerInstance)
+	deviceLinkController := signalController.NewDeviceLinkController(deviceLinkUC, loggerInstance)
+	numberHealthController := signalController.NewNumberHealthController(numberHealthUC, loggerInstance)
+	verificationController := signalController.NewVerificationController(verificationUC, loggerInstance)
+	receiveStreamController := signalController.NewReceiveStreamController(receiveStream, maintenanceUC, loggerInstance)
+	groupLinkController := signalController.NewGroupLinkController(signalService, loggerInstance)
+	recipientController := signalController.NewRecipientController(signalService, loggerInstance)
+	remoteDeleteController := signalController.NewRemoteDeleteController(signalService, messageTransactionRepository, messageProcessor, loggerInstance)
 	sendController := sendController.NewSendController(
 		commonService,
 		messageUC,
 		loggerInstance,
 	)
 
-	var wsMutex sync.Mutex
-	var stopSignalReceive = make(chan struct{})
-	go handleSignalReceive(signalClientInstance, os.Getenv("SIGNAL_FROM_NUMBER"), stopSignalReceive, &wsMutex, loggerInstance)
+	// Route every received message once, whichever receive path or instance gets it
+	receiveDeduplicator := signalClient.NewReceiveDeduplicator(receivedMessageRepository, time.Duration(config.ReceiveDedupeRetention)*time.Hour, loggerInstance)
+
+	// Keep the normalized contact cards, payment notifications and reactions received
+	receivedEnvelopeUC := receivedEnvelopeUseCase.NewReceivedEnvelopeUseCase(receivedEnvelopeRepository, time.Duration(config.ReceivedEnvelopeRetention)*24*time.Hour, loggerInstance)
+	receivedEnvelopeController := signalController.NewReceivedEnvelopeController(receivedEnvelopeUC, loggerInstance)
+
+	receiveNumber := os.Getenv("SIGNAL_FROM_NUMBER")
+	routeReceived := receiveStream.Handler(func(receivedMessage *domainSignal.ReceivedMessage) {
+		routeReceivedMessage(receivedMessage, receiveNumber, hookDispatcher, eventBus, receivedEnvelopeUC, escalationUC, acknowledgementUC, actionUC, controlUC, deliveryUC, loggerInstance)
+	})
+	var receivePoller *signalClient.ReceivePoller
+	if signalClientInstance != nil && signalCliMode == signalClient.JsonRpc {
+		var wsMutex sync.Mutex
+		var stopSignalReceive = make(chan struct{})
+		go handleSignalReceive(signalClientInstance, stopSignalReceive, &wsMutex, receiveDeduplicator.Handler(routeReceived), receiveNumber, numberHealthUC, loggerInstance)
+	} else {
+		// Polling consumes the messages of the number from signal-cli, so it only runs when asked for
+		_, receivePollIntervalEnvVariableSet := os.LookupEnv("RECEIVE_POLL_INTERVAL_SECONDS")
+		if webhookUrl != "" || receivePollIntervalEnvVariableSet {
+			receivePoller = signalClient.NewReceivePoller(signalService, receiveNumber, webhookUrl, routeReceived, receiveDeduplicator, numberHealthUC,
+				leaderElector, loggerInstance, time.Duration(config.ReceivePollInterval)*time.Second, int64(config.ReceivePollTimeout))
+		}
+	}
+
+	// Bridge the messages received by the Matrix accounts of users into their hook subscriptions
+	routeMatrixReceived := func(userProvider *domainProvider.UserProvider, receivedMessage *matrix.ReceivedMessage) {
+		routeMatrixMessage(userProvider, receivedMessage, hookDispatcher, eventBus, escalationUC, acknowledgementUC, actionUC, loggerInstance)
+	}
+	matrixSyncPoller := matrix.NewSyncPoller(userProviderRepository, matrixClients, routeMatrixReceived, leaderElector, loggerInstance,
+		time.Duration(config.MatrixSyncInterval)*time.Second, time.Duration(config.MatrixSyncTimeout)*time.Second)
 
 	return &ApplicationContext{
 		DB:                                  db,
@@ -242,24 +903,110 @@ func SetupDependencies(loggerInstance *logger.Logger) (*ApplicationContext, erro
 		AuthController:                      authController,
 		UserController:                      userController,
 		SignalController:                    signalClientController,
+		RegistrationLockController:          registrationLockController,
+		RateLimitChallengeController:        rateLimitChallengeController,
+		DeviceLinkController:                deviceLinkController,
+		NumberHealthController:              numberHealthController,
+		VerificationController:              verificationController,
+		ReceiveStreamController:             receiveStreamController,
+		ReceivedEnvelopeController:          receivedEnvelopeController,
+		GroupLinkController:                 groupLinkController,
+		RecipientController:                 recipientController,
+		RemoteDeleteController:              remoteDeleteController,
 		SendController:                      sendController,
+		DigestController:                    digestController,
+		AnalyticsController:                 analyticsController,
+		HookController:                      hookController,
+		EscalationController:                escalationController,
+		ProviderController:                  providerController,
+		LoginAuditController:                loginAuditController,
+		DistributionListController:          distributionListController,
+		EmailTemplateController:             emailTemplateController,
+		ContentTransformController:          contentTransformController,
+		InboundNumberController:             inboundNumberController,
+		ShortLinkController:                 shortLinkController,
+		ConversationController:              conversationController,
+		BulkOperationController:             bulkOperationController,
+		QueueSnapshotController:             queueSnapshotController,
+		MaintenanceController:               maintenanceController,
+		RetentionController:                 retentionController,
+		JobController:                       jobController,
+		DeliveryController:                  deliveryController,
+		ActionController:                    actionController,
+		ControlController:                   controlController,
+		CustomDomainController:              customDomainController,
+		DeactivationController:              deactivationController,
+		AuditExportController:               auditExportController,
+		ArchiveController:                   archiveController,
+		AnonymizeController:                 anonymizeController,
+		AttachmentController:                attachmentController,
+		StatusController:                    statusController,
+		SyncController:                      syncController,
+		BootstrapController:                 bootstrapController,
 		JWTService:                          jwtService,
 		LDAPService:                         ldapService,
 		AzureADService:                      azureADService,
+		ExternalTokenValidator:              externalTokenValidator,
 		CommonService:                       commonService,
 		UserRepository:                      userRepo,
+		UserLocales:                         userLocales,
+		AdminUIConfig:                       adminui.LoadConfig(),
+		StatusPageConfig:                    config.StatusPage,
 		AuthUseCase:                         authUC,
 		UserUseCase:                         userUC,
+		DeactivationUseCase:                 deactivationUC,
 		MessageProcessor:                    messageProcessor,
 		ProviderRepository:                  providerRepository,
 		UserProviderRepository:              userProviderRepository,
 		MessageTransactionRepository:        messageTransactionRepository,
 		MessageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		OutboxEventRepository:               outboxEventRepository,
+		OutboxRelay:                         outboxRelay,
+		RegistrationLockRepository:          registrationLockRepository,
+		RateLimitChallengeRepository:        rateLimitChallengeRepository,
+		DeviceLinkRepository:                deviceLinkRepository,
+		ReceivedMessageRepository:           receivedMessageRepository,
+		ReceivedEnvelopeRepository:          receivedEnvelopeRepository,
+		DigestRepository:                    digestRepository,
+		DigestScheduler:                     digestScheduler,
+		HookSubscriptionRepository:          hookSubscriptionRepository,
+		HookDispatcher:                      hookDispatcher,
+		WebhookEventRepository:              webhookEventRepository,
+		ControlCommandRepository:            controlCommandRepository,
+		HookEventPruner:                     hookEventPruner,
+		EscalationRepository:                escalationRepository,
+		DistributionListRepository:          distributionListRepository,
+		EmailTemplateRepository:             emailTemplateRepository,
+		ContentTransformRepository:          contentTransformRepository,
+		ShortLinkRepository:                 shortLinkRepository,
+		ConversationRepository:              conversationRepository,
+		JobRepository:                       jobRepository,
+		JobRunner:                           jobRunner,
+		MessageDeliveryRepository:           messageDeliveryRepository,
+		MessageEngagementRepository:         messageEngagementRepository,
+		EventBus:                            eventBus,
+		ProviderDrillRepository:             providerDrillRepository,
+		LoginActivityRepository:             loginActivityRepository,
+		EscalationScheduler:                 escalationScheduler,
+		DistributionListScheduler:           distributionListScheduler,
+		VerificationScheduler:               verificationScheduler,
+		MessagePurgeScheduler:               messagePurgeScheduler,
+		AuditExportScheduler:                auditExportScheduler,
+		ArchiveScheduler:                    archiveScheduler,
+		AttachmentPruner:                    attachmentPruner,
+		AcknowledgementScheduler:            acknowledgementScheduler,
+		RetryScheduler:                      retryScheduler,
+		QueueMonitor:                        queueMonitor,
+		ReceivePoller:                       receivePoller,
+		ReceiveDeduplicator:                 receiveDeduplicator,
+		MatrixSyncPoller:                    matrixSyncPoller,
+		LeaderElector:                       leaderElector,
 	}, nil
 }
 
-func handleSignalReceive(signalClient *signalClient.SignalClient, number string, stop chan struct{}, wsMutex *sync.Mutex, loggerInstance *logger.Logger) {
-	receiveChannel, channelUuid, err := signalClient.GetReceiveChannel()
+func handleSignalReceive(signalClientInstance *signalClient.SignalClient, stop chan struct{}, wsMutex *sync.Mutex, handler signalClient.ReceiveHandler,
+	number string, receiveErrors signalClient.ReceiveErrorRecorder, loggerInstance *logger.Logger) {
+	receiveChannel, channelUuid, err := signalClientInstance.GetReceiveChannel()
 	if err != nil {
 		loggerInstance.Error("Couldn't get receive channel: ", zap.Error(err))
 		return
@@ -268,38 +1015,194 @@ func handleSignalReceive(signalClient *signalClient.SignalClient, number string,
 	for {
 		select {
 		case <-stop:
-			signalClient.RemoveReceiveChannel(channelUuid)
+			signalClientInstance.RemoveReceiveChannel(channelUuid)
 			return
 		case msg := <-receiveChannel:
-			var data string = string(msg.Params)
-			var err error = nil
 			if msg.Err.Code != 0 {
-				err = errors.New(msg.Err.Message)
+				wsMutex.Lock()
+				loggerInstance.Error(fmt.Sprintf("Received error message: %s", string(msg.Params)), zap.Error(errors.New(msg.Err.Message)))
+				wsMutex.Unlock()
+				receiveErrors.RecordReceiveError(number, errors.New(msg.Err.Message))
+				continue
 			}
 
-			if err == nil {
-				if data != "" {
-					type Response struct {
-						Account string `json:"account"`
-					}
-					var response Response
-					err = json.Unmarshal([]byte(data), &response)
-					if err != nil {
-						loggerInstance.Error(fmt.Sprintf("Couldn't parse message %s", data), zap.Error(err))
-						continue
-					}
-
-					if response.Account == number {
-						wsMutex.Lock()
-						loggerInstance.Debug("Received message from self: " + data)
-						wsMutex.Unlock()
-					}
+			if len(msg.Params) == 0 {
+				continue
+			}
+
+			receivedMessage, err := signalClient.ParseReceivedMessage(msg.Params)
+			if err != nil {
+				loggerInstance.Error(fmt.Sprintf("Couldn't parse message %s", string(msg.Params)), zap.Error(err))
+				continue
+			}
+
+			wsMutex.Lock()
+			handler(receivedMessage)
+			wsMutex.Unlock()
+		}
+	}
+}
+
+// routeReceivedMessage dispatches a received message by the type of its envelope. Data messages, contact cards,
+// payment notifications, reactions and group updates are delivered to the message.received hook subscriptions of
+// the users sending through signal that accept their envelope type, contact cards, payment notifications and
+// reactions are stored normalized. Text messages also go to the event bus, and a reply carrying an acknowledgement
+// keyword acknowledges the escalation or message it refers to. Any other direct reply of a number chooses an action
+// of the latest message with actions. Receipts update the deliveries of the messages they report on.
+func routeReceivedMessage(receivedMessage *domainSignal.ReceivedMessage, number string, hookDispatcher *messaging.HookDispatcher, eventBus *events.Bus, receivedEnvelopeUC receivedEnvelopeUseCase.IReceivedEnvelopeUseCase, escalationUC escalationUseCase.IEscalationUseCase, acknowledgementUC acknowledgementUseCase.IAcknowledgementUseCase, actionUC actionUseCase.IActionUseCase, controlUC controlUseCase.IControlUseCase, deliveryUC deliveryUseCase.IDeliveryUseCase, loggerInstance *logger.Logger) {
+	envelope := receivedMessage.Envelope
+	fields := []zap.Field{
+		zap.String("account", receivedMessage.Account),
+		zap.String("source", envelope.Source),
+		zap.Int64("timestamp", envelope.Timestamp),
+	}
+
+	if receivedMessage.Account == number && envelope.Source == number {
+		loggerInstance.Debug("Received message from self", fields...)
+		return
+	}
+
+	envelopeType := envelope.Type()
+	switch envelopeType {
+	case domainSignal.EnvelopeTypeDataMessage:
+		// Control messages of operators are only accepted in direct messages and go no further, their text
+		// shouldn't reach the hooks or the conversations
+		if envelope.DataMessage.Message != nil && envelope.DataMessage.GroupInfo == nil &&
+			controlUC.Handle(envelope.Source, envelope.SourceUuid, *envelope.DataMessage.Message) {
+			loggerInstance.Info("Received control message", fields...)
+			return
+		}
+		loggerInstance.Info("Received data message", append(fields, zap.Int("attachments", len(envelope.DataMessage.Attachments)))...)
+		hookDispatcher.DispatchReceivedEnvelope("signal", envelopeType, signalClient.NewReceiveWebhookPayload(receivedMessage))
+		if envelope.DataMessage.Message != nil {
+			// The number is shared by the users, the message joins the conversations they have with the sender
+			eventBus.Publish(events.TopicMessage, &domainProvider.MessageEvent{
+				Channel:      "signal",
+				Participants: []string{envelope.Source},
+				Direction:    domainProvider.DirectionInbound,
+				ExternalID:   strconv.FormatInt(envelope.Timestamp, 10),
+				Body:         *envelope.DataMessage.Message,
+				OccurredAt:   time.UnixMilli(envelope.Timestamp),
+			})
+			acknowledged, err := escalationUC.AcknowledgeByKeyword(*envelope.DataMessage.Message, envelope.Source)
+			if err != nil {
+				loggerInstance.Error("Error acknowledging escalation", append(fields, zap.Error(err))...)
+			}
+			if !acknowledged {
+				if acknowledged, err = acknowledgementUC.AcknowledgeByReply(*envelope.DataMessage.Message, envelope.Source); err != nil {
+					loggerInstance.Error("Error acknowledging message", append(fields, zap.Error(err))...)
 				}
-			} else {
-				wsMutex.Lock()
-				loggerInstance.Error(fmt.Sprintf("Received error message: %s", data), zap.Error(err))
-				wsMutex.Unlock()
 			}
+			if !acknowledged && envelope.DataMessage.GroupInfo == nil {
+				if _, err := actionUC.ChooseByReply("signal", *envelope.DataMessage.Message, envelope.Source, envelope.Source); err != nil {
+					loggerInstance.Error("Error choosing message action", append(fields, zap.Error(err))...)
+				}
+			}
+		}
+	case domainSignal.EnvelopeTypeReaction, domainSignal.EnvelopeTypeContact, domainSignal.EnvelopeTypePayment:
+		switch envelopeType {
+		case domainSignal.EnvelopeTypeReaction:
+			reaction := envelope.DataMessage.Reaction
+			loggerInstance.Info("Received reaction", append(fields, zap.String("emoji", reaction.Emoji), zap.Int64("targetSentTimestamp", reaction.TargetSentTimestamp), zap.Bool("isRemove", reaction.IsRemove))...)
+		case domainSignal.EnvelopeTypeContact:
+			loggerInstance.Info("Received contact card", append(fields, zap.Int("contacts", len(envelope.DataMessage.Contacts)))...)
+		default:
+			loggerInstance.Info("Received payment notification", fields...)
+		}
+		if err := receivedEnvelopeUC.Record(receivedMessage); err != nil {
+			loggerInstance.Error("Error storing received envelope", append(fields, zap.Error(err))...)
+		}
+		hookDispatcher.DispatchReceivedEnvelope("signal", envelopeType, signalClient.NewReceiveWebhookPayload(receivedMessage))
+	case domainSignal.EnvelopeTypeGroupUpdate:
+		loggerInstance.Info("Received group update", append(fields, zap.String("groupId", envelope.DataMessage.GroupInfo.GroupId))...)
+		hookDispatcher.DispatchReceivedEnvelope("signal", envelopeType, signalClient.NewReceiveWebhookPayload(receivedMessage))
+	case domainSignal.EnvelopeTypeReceipt:
+		receipt := envelope.ReceiptMessage
+		loggerInstance.Debug("Received receipt", append(fields, zap.Bool("isDelivery", receipt.IsDelivery), zap.Bool("isRead", receipt.IsRead), zap.Int64s("timestamps", receipt.Timestamps))...)
+		if err := deliveryUC.ReceiveSignalReceipt(envelope.Source, *receipt); err != nil {
+			loggerInstance.Error("Error applying receipt", append(fields, zap.Error(err))...)
+		}
+	case domainSignal.EnvelopeTypeTyping:
+		loggerInstance.Debug("Received typing indicator", append(fields, zap.String("action", envelope.TypingMessage.Action))...)
+	default:
+		loggerInstance.Debug("Received envelope", append(fields, zap.String("type", string(envelope.Type())))...)
+	}
+}
+
+// routeMatrixMessage delivers a message received by the Matrix account of a user to their message.received hook
+// subscriptions and to the event bus. A reply carrying an acknowledgement keyword acknowledges the escalation or the message sent to
+// the room it was posted in, a reply of a number chooses an action of the latest message with actions sent there.
+func routeMatrixMessage(userProvider *domainProvider.UserProvider, receivedMessage *matrix.ReceivedMessage, hookDispatcher *messaging.HookDispatcher, eventBus *events.Bus, escalationUC escalationUseCase.IEscalationUseCase, acknowledgementUC acknowledgementUseCase.IAcknowledgementUseCase, actionUC actionUseCase.IActionUseCase, loggerInstance *logger.Logger) {
+	fields := []zap.Field{
+		zap.Int("userID", userProvider.UserID),
+		zap.String("roomID", receivedMessage.RoomID),
+		zap.String("sender", receivedMessage.Sender),
+		zap.String("eventID", receivedMessage.EventID),
+	}
+	loggerInstance.Info("Received matrix message", fields...)
+
+	hookDispatcher.DispatchToUser(userProvider.UserID, messaging.HookEventMessageReceived, receivedMessage)
+	eventBus.Publish(events.TopicMessage, &domainProvider.MessageEvent{
+		UserID:       userProvider.UserID,
+		Channel:      "matrix",
+		Participants: []string{receivedMessage.RoomID},
+		Direction:    domainProvider.DirectionInbound,
+		ExternalID:   receivedMessage.EventID,
+		Body:         receivedMessage.Body,
+		OccurredAt:   time.UnixMilli(receivedMessage.Timestamp),
+	})
+
+	acknowledged, err := escalationUC.AcknowledgeByKeyword(receivedMessage.Body, receivedMessage.Sender)
+	if err != nil {
+		loggerInstance.Error("Error acknowledging escalation", append(fields, zap.Error(err))...)
+	}
+	if !acknowledged {
+		if acknowledged, err = acknowledgementUC.AcknowledgeByReply(receivedMessage.Body, receivedMessage.RoomID); err != nil {
+			loggerInstance.Error("Error acknowledging message", append(fields, zap.Error(err))...)
+		}
+	}
+	if !acknowledged {
+		if _, err := actionUC.ChooseByReply("matrix", receivedMessage.Body, receivedMessage.RoomID, receivedMessage.Sender); err != nil {
+			loggerInstance.Error("Error choosing message action", append(fields, zap.Error(err))...)
+		}
+	}
+}
+
+// routeInboundSMS delivers an SMS sent to a number provisioned for a user to their message.received hook
+// subscriptions and to the event bus. A reply carrying an acknowledgement keyword acknowledges the escalation or the message sent to
+// the number it came from, a reply of a number chooses an action of the latest message with actions sent there.
+func routeInboundSMS(userProvider *domainProvider.UserProvider, sms *domainProvider.InboundSMS, hookDispatcher *messaging.HookDispatcher, eventBus *events.Bus, escalationUC escalationUseCase.IEscalationUseCase, acknowledgementUC acknowledgementUseCase.IAcknowledgementUseCase, actionUC actionUseCase.IActionUseCase, loggerInstance *logger.Logger) {
+	fields := []zap.Field{
+		zap.Int("userID", userProvider.UserID),
+		zap.String("from", sms.From),
+		zap.String("to", sms.To),
+		zap.String("messageID", sms.ID),
+	}
+	loggerInstance.Info("Received sms", fields...)
+
+	hookDispatcher.DispatchToUser(userProvider.UserID, messaging.HookEventMessageReceived, sms)
+	eventBus.Publish(events.TopicMessage, &domainProvider.MessageEvent{
+		UserID:       userProvider.UserID,
+		Channel:      "sms",
+		Participants: []string{sms.From},
+		Direction:    domainProvider.DirectionInbound,
+		ExternalID:   sms.ID,
+		Body:         sms.Body,
+		OccurredAt:   sms.ReceivedAt,
+	})
+
+	acknowledged, err := escalationUC.AcknowledgeByKeyword(sms.Body, sms.From)
+	if err != nil {
+		loggerInstance.Error("Error acknowledging escalation", append(fields, zap.Error(err))...)
+	}
+	if !acknowledged {
+		if acknowledged, err = acknowledgementUC.AcknowledgeByReply(sms.Body, sms.From); err != nil {
+			loggerInstance.Error("Error acknowledging message", append(fields, zap.Error(err))...)
+		}
+	}
+	if !acknowledged {
+		if _, err := actionUC.ChooseByReply("sms", sms.Body, sms.From, sms.From); err != nil {
+			loggerInstance.Error("Error choosing message action", append(fields, zap.Error(err))...)
 		}
 	}
 }
@@ -313,11 +1216,11 @@ func NewTestApplicationContext(
 	loggerInstance *logger.Logger,
 ) *ApplicationContext {
 	// Initialize use cases with mocked repositories and logger
-	authUC := authUseCase.NewAuthUseCase(mockUserRepo, mockJWTService, mockLDAPService, mockAzureADService, loggerInstance)
+	authUC := authUseCase.NewAuthUseCase(mockUserRepo, mockJWTService, mockLDAPService, mockAzureADService, nil, loggerInstance)
 	userUC := userUseCase.NewUserUseCase(mockUserRepo, loggerInstance)
 
 	// Initialize controllers with logger
-	authController := authController.NewAuthController(authUC, loggerInstance)
+	authController := authController.NewAuthController(authUC, nil, loggerInstance)
 	userController := userController.NewUserController(userUC, loggerInstance)
 
 	return &ApplicationContext{
@@ -0,0 +1,221 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting"
+	"go-multi-chat-api/src/infrastructure/anonymize"
+	"go-multi-chat-api/src/infrastructure/archive"
+	"go-multi-chat-api/src/infrastructure/attachment"
+	"go-multi-chat-api/src/infrastructure/auditexport"
+	"go-multi-chat-api/src/infrastructure/control"
+	"go-multi-chat-api/src/infrastructure/directory"
+	"go-multi-chat-api/src/infrastructure/events"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	"go-multi-chat-api/src/infrastructure/jobs"
+	"go-multi-chat-api/src/infrastructure/leader"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/payload"
+	"go-multi-chat-api/src/infrastructure/repository/mysql"
+	"go-multi-chat-api/src/infrastructure/shortlink"
+	"go-multi-chat-api/src/infrastructure/statuspage"
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Config is the configuration the application context is built with, read from the environment before any
+// dependency is created. The configuration dry run loads it the same way, so it checks every setting the
+// application boots with.
+type Config struct {
+	Outbound     httpclient.Config
+	Leader       leader.Config
+	Publisher    events.PublisherConfig
+	Recovery     messaging.RecoveryConfig
+	Watcher      messaging.WatcherConfig
+	Payload      payload.Config
+	ShortLink    shortlink.Config
+	Attachment   attachment.Config
+	StatusPage   statuspage.Config
+	Alerting     *alerting.Config
+	Sandbox      *messaging.SandboxConfig // nil when the sandbox provider type is disabled
+	QueueMonitor messaging.QueueMonitorConfig
+	Directory    directory.Config
+	Control      control.Config
+	Jobs         jobs.Config
+	Partition    mysql.PartitionConfig
+	AuditExport  auditexport.Config
+	Archive      archive.Config
+	Anonymize    anonymize.Config
+
+	WebhookEventRetention time.Duration
+
+	// SignalBackend is cli or remote, the remote backend is the signal-cli-rest-api at SignalRestApiURL
+	SignalBackend        string
+	SignalRestApiURL     string
+	SignalRestApiTimeout int // seconds
+
+	// Timeouts of the calls to the vendors in seconds
+	ContentTransformTimeout int
+	MatrixTimeout           int
+	DiscordTimeout          int
+	TelegramTimeout         int
+	LineTimeout             int
+	TwilioTimeout           int
+	SignalLinkTimeout       int
+
+	EventRelayInterval           int // seconds
+	EventBusBufferSize           int
+	NumberHealthFailureThreshold int
+	// MaxInFlightPerUser keeps a single user from taking every worker, the workers take turns between the users
+	// either way
+	MaxInFlightPerUser int
+	BacklogThreshold   int
+	BacklogRetryAfter  int // seconds
+
+	// The caps of the messages a user sends to the same recipient, the caps of a user override them
+	RecipientCapPerHour int
+	RecipientCapPerDay  int
+	RecipientCapAction  string
+
+	// Check intervals of the schedulers
+	DigestCheckInterval          int // minutes
+	EscalationCheckInterval      int // seconds
+	AckCheckInterval             int // seconds
+	DistributionListSyncInterval int // minutes
+	VerificationSMSTimeout       int // seconds
+	VerificationCooldown         int // seconds
+	VerificationMaxAttempts      int
+	VerificationWindow           int // hours
+	LoginFailureThreshold        int
+	LoginFailureWindow           int // minutes
+	ReceiveDedupeRetention       int // hours
+	ReceivedEnvelopeRetention    int // days
+	ReceivePollInterval          int // seconds
+	ReceivePollTimeout           int // seconds
+	MatrixSyncInterval           int // seconds
+	MatrixSyncTimeout            int // seconds
+}
+
+// LoadConfig reads the configuration of the application context. It reads every setting even after an invalid
+// one, the error joins the problems of all of them.
+func LoadConfig() (*Config, error) {
+	config := &Config{}
+	s := &settings{}
+
+	config.Outbound = load(s, httpclient.LoadConfig)
+	config.Leader = load(s, leader.LoadConfig)
+	config.Publisher = events.LoadPublisherConfig()
+	config.Recovery = load(s, messaging.LoadRecoveryConfig)
+	config.Watcher = load(s, messaging.LoadWatcherConfig)
+	config.Payload = load(s, payload.LoadConfig)
+	config.ShortLink = load(s, shortlink.LoadConfig)
+	config.Attachment = load(s, attachment.LoadConfig)
+	config.StatusPage = load(s, statuspage.LoadConfig)
+	config.Alerting = load(s, alerting.LoadConfig)
+	config.Sandbox = load(s, messaging.LoadSandboxConfig)
+	config.QueueMonitor = load(s, messaging.LoadQueueMonitorConfig)
+	config.Directory = load(s, directory.LoadConfig)
+	config.Control = load(s, control.LoadConfig)
+	config.Jobs = load(s, jobs.LoadConfig)
+	config.Partition = load(s, mysql.LoadPartitionConfig)
+	config.AuditExport = load(s, auditexport.LoadConfig)
+	config.Archive = load(s, archive.LoadConfig)
+	config.Anonymize = load(s, anonymize.LoadConfig)
+	config.WebhookEventRetention = load(s, messaging.LoadHookEventRetention)
+
+	config.SignalBackend = utils.GetEnv("SIGNAL_BACKEND", "cli")
+	switch config.SignalBackend {
+	case "cli":
+	case "remote":
+		config.SignalRestApiURL = utils.GetEnv("SIGNAL_REST_API_URL", "")
+		if config.SignalRestApiURL == "" {
+			s.fail(errors.New("SIGNAL_REST_API_URL is required with SIGNAL_BACKEND remote"))
+		}
+		config.SignalRestApiTimeout = s.int("SIGNAL_REST_API_TIMEOUT_SECONDS", 30)
+	default:
+		s.fail(fmt.Errorf("unknown SIGNAL_BACKEND %q, expected cli or remote", config.SignalBackend))
+	}
+
+	config.ContentTransformTimeout = s.atLeast("CONTENT_TRANSFORM_TIMEOUT_SECONDS", 5, 1)
+	config.MatrixTimeout = s.int("MATRIX_TIMEOUT_SECONDS", 30)
+	config.DiscordTimeout = s.int("DISCORD_TIMEOUT_SECONDS", 30)
+	config.TelegramTimeout = s.int("TELEGRAM_TIMEOUT_SECONDS", 30)
+	config.LineTimeout = s.int("LINE_TIMEOUT_SECONDS", 30)
+	config.TwilioTimeout = s.int("TWILIO_TIMEOUT_SECONDS", 30)
+	config.SignalLinkTimeout = s.int("SIGNAL_LINK_TIMEOUT_SECONDS", 180)
+
+	if config.Publisher.Enabled() {
+		config.EventRelayInterval = s.int("EVENT_RELAY_INTERVAL_SECONDS", 5)
+	}
+	config.EventBusBufferSize = s.int("EVENT_BUS_BUFFER_SIZE", 1000)
+	config.NumberHealthFailureThreshold = s.atLeast("NUMBER_HEALTH_FAILURE_THRESHOLD", 3, 1)
+	config.MaxInFlightPerUser = s.int("PROCESSOR_MAX_IN_FLIGHT_PER_USER", 0)
+	config.BacklogThreshold = s.int("SEND_BACKLOG_THRESHOLD", 0)
+	config.BacklogRetryAfter = s.int("SEND_BACKLOG_RETRY_AFTER_SECONDS", 30)
+
+	config.RecipientCapPerHour = s.atLeast("RECIPIENT_CAP_PER_HOUR", 0, 0)
+	config.RecipientCapPerDay = s.atLeast("RECIPIENT_CAP_PER_DAY", 0, 0)
+	config.RecipientCapAction = utils.GetEnv("RECIPIENT_CAP_ACTION", domainProvider.RecipientCapActionReject)
+	if config.RecipientCapAction != domainProvider.RecipientCapActionReject && config.RecipientCapAction != domainProvider.RecipientCapActionHold {
+		s.fail(fmt.Errorf("invalid RECIPIENT_CAP_ACTION: must be %s or %s", domainProvider.RecipientCapActionReject, domainProvider.RecipientCapActionHold))
+	}
+
+	config.DigestCheckInterval = s.int("DIGEST_CHECK_INTERVAL_MINUTES", 60)
+	config.EscalationCheckInterval = s.int("ESCALATION_CHECK_INTERVAL_SECONDS", 30)
+	config.AckCheckInterval = s.int("ACK_CHECK_INTERVAL_SECONDS", 30)
+	config.DistributionListSyncInterval = s.int("DISTRIBUTION_LIST_SYNC_INTERVAL_MINUTES", 60)
+	config.VerificationSMSTimeout = s.atLeast("VERIFICATION_SMS_TIMEOUT_SECONDS", 120, 1)
+	config.VerificationCooldown = s.atLeast("VERIFICATION_COOLDOWN_SECONDS", 60, 1)
+	config.VerificationMaxAttempts = s.atLeast("VERIFICATION_MAX_ATTEMPTS", 5, 1)
+	config.VerificationWindow = s.atLeast("VERIFICATION_WINDOW_HOURS", 24, 1)
+	config.LoginFailureThreshold = s.int("LOGIN_FAILURE_THRESHOLD", 5)
+	config.LoginFailureWindow = s.int("LOGIN_FAILURE_WINDOW_MINUTES", 15)
+	config.ReceiveDedupeRetention = s.int("RECEIVE_DEDUPE_RETENTION_HOURS", 72)
+	config.ReceivedEnvelopeRetention = s.int("RECEIVED_ENVELOPE_RETENTION_DAYS", 30)
+	config.ReceivePollInterval = s.int("RECEIVE_POLL_INTERVAL_SECONDS", 10)
+	config.ReceivePollTimeout = s.int("RECEIVE_POLL_TIMEOUT_SECONDS", 1)
+	config.MatrixSyncInterval = s.int("MATRIX_SYNC_INTERVAL_SECONDS", 10)
+	config.MatrixSyncTimeout = s.int("MATRIX_SYNC_TIMEOUT_SECONDS", 0)
+
+	return config, errors.Join(s.errs...)
+}
+
+// settings collects the problems of the settings read by LoadConfig
+type settings struct {
+	errs []error
+}
+
+func (s *settings) fail(err error) {
+	s.errs = append(s.errs, err)
+}
+
+// int reads an integer setting, the default when it isn't set
+func (s *settings) int(key string, defaultValue int) int {
+	value, err := utils.GetIntEnv(key, defaultValue)
+	if err != nil {
+		s.fail(fmt.Errorf("invalid %s: %w", key, err))
+		return defaultValue
+	}
+	return value
+}
+
+// atLeast reads an integer setting that can't be lower than min
+func (s *settings) atLeast(key string, defaultValue int, min int) int {
+	value, err := utils.GetIntEnv(key, defaultValue)
+	if err != nil || value < min {
+		s.fail(fmt.Errorf("invalid %s: must be a number of at least %d", key, min))
+		return defaultValue
+	}
+	return value
+}
+
+// load runs the loader of a package, the zero config is returned with its problem recorded
+func load[T any](s *settings, loader func() (T, error)) T {
+	config, err := loader()
+	if err != nil {
+		s.fail(err)
+	}
+	return config
+}
@@ -0,0 +1,262 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// resolveConcurrency limits the lookups running at the same time while resolving the recipients of a message
+	resolveConcurrency = 8
+	// cachePruneSize is the number of cached entries from which expired entries are removed
+	cachePruneSize = 10000
+)
+
+// ErrNotFound is returned when the directory has no address of a recipient for the provider type
+var ErrNotFound = errors.New("recipient not found in directory")
+
+// Resolver resolves directory identifiers such as employee:1234 to the address of the person on a provider
+// type, e.g. their phone number for signal or their email address for email
+type Resolver interface {
+	// Handles reports whether the recipient is a directory identifier looked up by the resolver
+	Handles(recipient string) bool
+	Resolve(recipient string, providerType string) (string, error)
+}
+
+// Failure reports a recipient that couldn't be resolved
+type Failure struct {
+	Recipient string
+	Error     string
+}
+
+// Config holds the recipient directory configuration
+type Config struct {
+	// URL of the HTTP resolver, resolution is disabled when empty
+	URL   string
+	Token string
+	// Schemes are the identifier prefixes resolved through the directory, e.g. employee for employee:1234
+	Schemes  []string
+	CacheTTL time.Duration
+	Timeout  time.Duration
+}
+
+// LoadConfig loads the recipient directory configuration from environment variables
+func LoadConfig() (Config, error) {
+	cacheSeconds, err := utils.GetIntEnv("RECIPIENT_DIRECTORY_CACHE_SECONDS", 300)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RECIPIENT_DIRECTORY_CACHE_SECONDS: %w", err)
+	}
+	timeoutSeconds, err := utils.GetIntEnv("RECIPIENT_DIRECTORY_TIMEOUT_SECONDS", 5)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RECIPIENT_DIRECTORY_TIMEOUT_SECONDS: %w", err)
+	}
+
+	var schemes []string
+	for _, scheme := range strings.Split(utils.GetEnv("RECIPIENT_DIRECTORY_SCHEMES", "employee"), ",") {
+		if scheme = strings.TrimSpace(scheme); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return Config{
+		URL:      utils.GetEnv("RECIPIENT_DIRECTORY_URL", ""),
+		Token:    utils.GetEnv("RECIPIENT_DIRECTORY_TOKEN", ""),
+		Schemes:  schemes,
+		CacheTTL: time.Duration(cacheSeconds) * time.Second,
+		Timeout:  time.Duration(timeoutSeconds) * time.Second,
+	}, nil
+}
+
+// NewResolver creates the resolver for the configuration
+func NewResolver(config Config, loggerInstance *logger.Logger) Resolver {
+	if config.URL == "" {
+		return Disabled{}
+	}
+	resolver := NewHTTPResolver(config, loggerInstance)
+	if config.CacheTTL <= 0 {
+		return resolver
+	}
+	return NewCachingResolver(resolver, config.CacheTTL)
+}
+
+// Disabled is used when no directory is configured, recipients are sent to as given
+type Disabled struct{}
+
+func (Disabled) Handles(recipient string) bool { return false }
+
+func (Disabled) Resolve(recipient string, providerType string) (string, error) {
+	return "", errors.New("no recipient directory configured")
+}
+
+// ResolveAll resolves the directory identifiers among the recipients for the provider type. Recipients the
+// resolver doesn't handle are kept as given, recipients that couldn't be resolved are reported as failures.
+func ResolveAll(resolver Resolver, recipients []string, providerType string) ([]string, []Failure) {
+	addresses := make([]string, len(recipients))
+	errs := make([]error, len(recipients))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, resolveConcurrency)
+	for i, recipient := range recipients {
+		if !resolver.Handles(recipient) {
+			addresses[i] = recipient
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, recipient string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			addresses[i], errs[i] = resolver.Resolve(recipient, providerType)
+		}(i, recipient)
+	}
+	wg.Wait()
+
+	resolved := make([]string, 0, len(recipients))
+	var failures []Failure
+	for i, recipient := range recipients {
+		if errs[i] != nil {
+			failures = append(failures, Failure{Recipient: recipient, Error: errs[i].Error()})
+			continue
+		}
+		resolved = append(resolved, addresses[i])
+	}
+	return resolved, failures
+}
+
+// HTTPResolver looks recipients up with an HTTP resolver plugin. The plugin is called with
+// GET <url>?id=<recipient>&provider=<provider type> and answers with {"address": "..."}, or 404 Not Found
+// if it doesn't know the recipient.
+type HTTPResolver struct {
+	url     string
+	token   string
+	schemes []string
+	client  *http.Client
+	Logger  *logger.Logger
+}
+
+// NewHTTPResolver creates a new HTTP resolver
+func NewHTTPResolver(config Config, loggerInstance *logger.Logger) *HTTPResolver {
+	return &HTTPResolver{
+		url:     config.URL,
+		token:   config.Token,
+		schemes: config.Schemes,
+		client:  httpclient.New(config.Timeout),
+		Logger:  loggerInstance,
+	}
+}
+
+func (r *HTTPResolver) Handles(recipient string) bool {
+	for _, scheme := range r.schemes {
+		if strings.HasPrefix(recipient, scheme+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPResolver) Resolve(recipient string, providerType string) (string, error) {
+	query := url.Values{}
+	query.Set("id", recipient)
+	query.Set("provider", providerType)
+
+	req, err := http.NewRequest("GET", r.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		r.Logger.Error("Error calling recipient directory", zap.Error(err), zap.String("recipient", recipient))
+		return "", errors.New("recipient directory is unavailable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		r.Logger.Error("Recipient directory returned an error", zap.Int("statusCode", resp.StatusCode), zap.String("recipient", recipient))
+		return "", fmt.Errorf("recipient directory answered with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.New("recipient directory returned an invalid response")
+	}
+	if body.Address == "" {
+		return "", ErrNotFound
+	}
+	return body.Address, nil
+}
+
+// CachingResolver caches the lookups of another resolver. Unknown recipients are cached as well, so a
+// missing directory entry doesn't cause a lookup for every message, lookup errors are not cached.
+type CachingResolver struct {
+	resolver Resolver
+	ttl      time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	address   string
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingResolver creates a resolver caching the lookups of resolver for ttl
+func NewCachingResolver(resolver Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{resolver: resolver, ttl: ttl, now: time.Now, entries: map[string]cacheEntry{}}
+}
+
+func (c *CachingResolver) Handles(recipient string) bool {
+	return c.resolver.Handles(recipient)
+}
+
+func (c *CachingResolver) Resolve(recipient string, providerType string) (string, error) {
+	key := providerType + "|" + recipient
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.address, entry.err
+	}
+
+	address, err := c.resolver.Resolve(recipient, providerType)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= cachePruneSize {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry{address: address, err: err, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return address, err
+}
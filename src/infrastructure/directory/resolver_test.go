@@ -0,0 +1,136 @@
+package directory
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResolver struct {
+	addresses map[string]string
+	err       error
+	calls     atomic.Int32
+}
+
+func (m *mockResolver) Handles(recipient string) bool {
+	return len(recipient) > 9 && recipient[:9] == "employee:"
+}
+
+func (m *mockResolver) Resolve(recipient string, providerType string) (string, error) {
+	m.calls.Add(1)
+	if m.err != nil {
+		return "", m.err
+	}
+	address, ok := m.addresses[recipient]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return address, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+func TestResolveAll(t *testing.T) {
+	resolver := &mockResolver{addresses: map[string]string{"employee:1": "+491111"}}
+
+	resolved, failures := ResolveAll(resolver, []string{"+492222", "employee:1", "employee:2"}, "signal")
+	assert.Equal(t, []string{"+492222", "+491111"}, resolved)
+	assert.Equal(t, []Failure{{Recipient: "employee:2", Error: ErrNotFound.Error()}}, failures)
+}
+
+func TestResolveAll_Disabled(t *testing.T) {
+	resolved, failures := ResolveAll(Disabled{}, []string{"employee:1"}, "signal")
+	assert.Equal(t, []string{"employee:1"}, resolved)
+	assert.Empty(t, failures)
+}
+
+func TestHTTPResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Query().Get("id") == "employee:1" && r.URL.Query().Get("provider") == "email":
+			_, _ = w.Write([]byte(`{"address":"jane@example.com"}`))
+		case r.URL.Query().Get("id") == "employee:500":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolver := NewHTTPResolver(Config{URL: server.URL, Token: "secret", Schemes: []string{"employee"}, Timeout: time.Second}, setupLogger(t))
+	assert.True(t, resolver.Handles("employee:1"))
+	assert.False(t, resolver.Handles("+491111"))
+	assert.False(t, resolver.Handles("u:username.01"))
+
+	address, err := resolver.Resolve("employee:1", "email")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", address)
+
+	_, err = resolver.Resolve("employee:1", "signal")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = resolver.Resolve("employee:500", "signal")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
+
+func TestCachingResolver(t *testing.T) {
+	inner := &mockResolver{addresses: map[string]string{"employee:1": "+491111"}}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	resolver := NewCachingResolver(inner, time.Minute)
+	resolver.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		address, err := resolver.Resolve("employee:1", "signal")
+		assert.NoError(t, err)
+		assert.Equal(t, "+491111", address)
+		_, err = resolver.Resolve("employee:2", "signal")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, int32(2), inner.calls.Load())
+
+	now = now.Add(2 * time.Minute)
+	_, _ = resolver.Resolve("employee:1", "signal")
+	assert.Equal(t, int32(3), inner.calls.Load())
+}
+
+func TestCachingResolver_DoesNotCacheErrors(t *testing.T) {
+	inner := &mockResolver{err: errors.New("recipient directory is unavailable")}
+	resolver := NewCachingResolver(inner, time.Minute)
+
+	_, err := resolver.Resolve("employee:1", "signal")
+	assert.Error(t, err)
+	_, err = resolver.Resolve("employee:1", "signal")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("RECIPIENT_DIRECTORY_URL", "https://directory.example.com/resolve")
+	t.Setenv("RECIPIENT_DIRECTORY_SCHEMES", "employee, badge")
+	t.Setenv("RECIPIENT_DIRECTORY_CACHE_SECONDS", "60")
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"employee", "badge"}, config.Schemes)
+	assert.Equal(t, time.Minute, config.CacheTTL)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+
+	t.Setenv("RECIPIENT_DIRECTORY_CACHE_SECONDS", "soon")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,406 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Discord API the bot calls go to
+	DefaultAPIURL = "https://discord.com/api/v10"
+
+	// WebhookRecipient targets the webhook of the user instead of a channel
+	WebhookRecipient = "webhook"
+
+	// maxContentLength is the longest text Discord accepts as message content, longer messages are sent as an embed
+	maxContentLength = 2000
+	// maxEmbedDescriptionLength is the longest description of an embed, longer messages are uploaded as a file
+	maxEmbedDescriptionLength = 4096
+	// longMessageFilename is the name of the file a message too long for an embed is uploaded as
+	longMessageFilename = "message.txt"
+	// maxButtonsPerRow is the most buttons an action row holds
+	maxButtonsPerRow = 5
+)
+
+var channelIDPattern = regexp.MustCompile(`^[0-9]{15,21}$`)
+
+// Config is the Discord setup of a user, stored in the config of their discord user provider. The bot token
+// sends to channels the bot was added to, the webhook URL posts to the channel of the webhook.
+type Config struct {
+	BotToken   string `json:"bot_token"`
+	WebhookURL string `json:"discord_webhook_url"`
+}
+
+// ParseConfig reads the Discord setup from the config of a user provider
+func ParseConfig(userProviderConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(userProviderConfig) != "" {
+		if err := json.Unmarshal([]byte(userProviderConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid discord config: %w", err)
+		}
+	}
+	if config.BotToken == "" && config.WebhookURL == "" {
+		return Config{}, errors.New("discord config needs a bot_token or a discord_webhook_url")
+	}
+	return config, nil
+}
+
+// Attachment is a file uploaded with a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Button is a button sent with a message, Discord posts a click on it to the interactions endpoint of the
+// application with its custom id
+type Button struct {
+	CustomID string
+	Label    string
+}
+
+// Error is an error answered by Discord
+type Error struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	// RetryAfter is the number of seconds to wait when Discord rate limited the request
+	RetryAfter float64 `json:"retry_after"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("discord answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("discord answered %d: %s", e.StatusCode, e.Message)
+}
+
+// ErrorCode classifies an error of a send by the status and the JSON error code Discord answered with. It returns
+// an empty code for other errors.
+func ErrorCode(err error) string {
+	var discordErr *Error
+	if !errors.As(err, &discordErr) {
+		return ""
+	}
+	switch {
+	case discordErr.Code == 10003 || discordErr.Code == 50001 || discordErr.StatusCode == http.StatusNotFound:
+		// unknown channel or a channel the bot can't see
+		return domainProvider.ErrorCodeInvalidRecipient
+	case discordErr.StatusCode == http.StatusUnauthorized || discordErr.StatusCode == http.StatusForbidden:
+		return domainProvider.ErrorCodeAuthFailed
+	case discordErr.StatusCode == http.StatusTooManyRequests:
+		return domainProvider.ErrorCodeRateLimited
+	case discordErr.StatusCode >= 500:
+		return domainProvider.ErrorCodeNetwork
+	}
+	return domainProvider.ErrorCodeUnknown
+}
+
+// SendResult is the message a text was sent as to one recipient
+type SendResult struct {
+	Recipient string `json:"recipient"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+// Client calls the Discord API with the bot token or webhook of a user
+type Client struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewClient creates a new client calling the Discord API at apiURL
+func NewClient(apiURL string, timeout time.Duration) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		client: httpclient.New(timeout),
+	}
+}
+
+// Send sends a text with its attachments to every recipient, a channel id or webhook for the webhook of the
+// user. It stops at the first recipient the message couldn't be sent to and returns the messages sent until then.
+func (c *Client) Send(config Config, recipients []string, text string, attachments []Attachment) ([]SendResult, error) {
+	body, files := formatMessage(text, attachments)
+	return c.send(config, recipients, body, files)
+}
+
+// SendWithButtons sends a text with buttons below it to every channel recipient. Only the bot sends buttons,
+// channel webhooks not created by an application can't. It stops like Send.
+func (c *Client) SendWithButtons(config Config, recipients []string, text string, buttons []Button) ([]SendResult, error) {
+	for _, recipient := range recipients {
+		if recipient == WebhookRecipient {
+			return nil, &domainProvider.RecipientError{Description: "the webhook recipient can't be sent buttons"}
+		}
+	}
+	body, files := formatMessage(text, nil)
+	body.Components = actionRows(buttons)
+	return c.send(config, recipients, body, files)
+}
+
+// send posts a message body to every recipient
+func (c *Client) send(config Config, recipients []string, body messageBody, files []Attachment) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		var target string
+		var authorization string
+		switch {
+		case recipient == WebhookRecipient:
+			if config.WebhookURL == "" {
+				return results, errors.New("discord config has no discord_webhook_url to send to")
+			}
+			// wait=true makes Discord answer with the created message
+			target = config.WebhookURL + "?wait=true"
+		case channelIDPattern.MatchString(recipient):
+			if config.BotToken == "" {
+				return results, errors.New("discord config has no bot_token to send to channels")
+			}
+			target = c.apiURL + "/channels/" + recipient + "/messages"
+			authorization = "Bot " + config.BotToken
+		default:
+			return results, &domainProvider.RecipientError{Description: fmt.Sprintf("recipient %q is not a discord channel id or %s", recipient, WebhookRecipient)}
+		}
+
+		var response struct {
+			ID        string `json:"id"`
+			ChannelID string `json:"channel_id"`
+		}
+		if err := c.post(target, authorization, body, files, &response); err != nil {
+			return results, fmt.Errorf("couldn't send to %s: %w", recipient, err)
+		}
+		results = append(results, SendResult{Recipient: recipient, ChannelID: response.ChannelID, MessageID: response.ID})
+	}
+	return results, nil
+}
+
+// Edit replaces the text of the messages a text was sent as, through the bot or the webhook that sent them. It
+// stops at the first message that couldn't be edited and returns the messages edited until then.
+func (c *Client) Edit(config Config, sent []SendResult, text string) ([]SendResult, error) {
+	body, files := formatMessage(text, nil)
+
+	results := make([]SendResult, 0, len(sent))
+	for _, message := range sent {
+		var target string
+		var authorization string
+		if message.Recipient == WebhookRecipient {
+			if config.WebhookURL == "" {
+				return results, errors.New("discord config has no discord_webhook_url to edit with")
+			}
+			target = config.WebhookURL + "/messages/" + message.MessageID
+		} else {
+			if config.BotToken == "" {
+				return results, errors.New("discord config has no bot_token to edit with")
+			}
+			target = c.apiURL + "/channels/" + message.ChannelID + "/messages/" + message.MessageID
+			authorization = "Bot " + config.BotToken
+		}
+
+		if err := c.request(http.MethodPatch, target, authorization, body, files, nil); err != nil {
+			return results, fmt.Errorf("couldn't edit the message to %s: %w", message.Recipient, err)
+		}
+		results = append(results, message)
+	}
+	return results, nil
+}
+
+// messageBody is the JSON part of a message, see https://discord.com/developers/docs/resources/message#create-message
+type messageBody struct {
+	Content     string              `json:"content,omitempty"`
+	Embeds      []embed             `json:"embeds,omitempty"`
+	Attachments []attachmentPayload `json:"attachments,omitempty"`
+	Components  []component         `json:"components,omitempty"`
+}
+
+// component is an action row or a button of it, see https://discord.com/developers/docs/interactions/message-components
+type component struct {
+	Type       int         `json:"type"`
+	Style      int         `json:"style,omitempty"`
+	Label      string      `json:"label,omitempty"`
+	CustomID   string      `json:"custom_id,omitempty"`
+	Components []component `json:"components,omitempty"`
+}
+
+// Component types and the button style
+const (
+	componentTypeActionRow = 1
+	componentTypeButton    = 2
+	buttonStylePrimary     = 1
+)
+
+type embed struct {
+	Description string `json:"description"`
+}
+
+type attachmentPayload struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+// formatMessage lays out a text for Discord: as content when it fits, as an embed description when it's
+// too long for content, and uploaded as a file with the start of it in the embed when it's too long for both
+func formatMessage(text string, attachments []Attachment) (messageBody, []Attachment) {
+	var body messageBody
+	files := attachments
+
+	runes := []rune(text)
+	switch {
+	case len(runes) <= maxContentLength:
+		body.Content = text
+	case len(runes) <= maxEmbedDescriptionLength:
+		body.Embeds = []embed{{Description: text}}
+	default:
+		preview := string(runes[:maxEmbedDescriptionLength-1]) + "…"
+		body.Embeds = []embed{{Description: preview}}
+		files = append([]Attachment{{Filename: longMessageFilename, ContentType: "text/plain; charset=utf-8", Data: []byte(text)}}, attachments...)
+	}
+
+	for i, file := range files {
+		body.Attachments = append(body.Attachments, attachmentPayload{ID: i, Filename: file.Filename})
+	}
+	return body, files
+}
+
+// actionRows lays buttons out in action rows of up to five buttons
+func actionRows(buttons []Button) []component {
+	var rows []component
+	for i, button := range buttons {
+		if i%maxButtonsPerRow == 0 {
+			rows = append(rows, component{Type: componentTypeActionRow})
+		}
+		row := &rows[len(rows)-1]
+		row.Components = append(row.Components, component{Type: componentTypeButton, Style: buttonStylePrimary, Label: button.Label, CustomID: button.CustomID})
+	}
+	return rows
+}
+
+// VerifyCredentials fetches the bot user with the bot token and the webhook with its URL, whichever the config
+// has, without posting anything
+func (c *Client) VerifyCredentials(config Config) error {
+	if config.BotToken != "" {
+		if err := c.get(c.apiURL+"/users/@me", "Bot "+config.BotToken); err != nil {
+			return fmt.Errorf("couldn't authenticate the bot: %w", err)
+		}
+	}
+	if config.WebhookURL != "" {
+		if err := c.get(config.WebhookURL, ""); err != nil {
+			return fmt.Errorf("couldn't reach the webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+// get fetches a resource, only to check that it can be fetched
+func (c *Client) get(target string, authorization string) error {
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		discordErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, discordErr)
+		return discordErr
+	}
+	return nil
+}
+
+// post creates a message, as JSON or as multipart form with payload_json when files are uploaded
+func (c *Client) post(target string, authorization string, body messageBody, files []Attachment, result interface{}) error {
+	return c.request(http.MethodPost, target, authorization, body, files, result)
+}
+
+// request creates or edits a message
+func (c *Client) request(method string, target string, authorization string, body messageBody, files []Attachment, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	requestBody := bytes.NewBuffer(payload)
+	contentType := "application/json"
+	if len(files) > 0 {
+		requestBody = &bytes.Buffer{}
+		writer := multipart.NewWriter(requestBody)
+		if err := writer.WriteField("payload_json", string(payload)); err != nil {
+			return err
+		}
+		for i, file := range files {
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename=%q`, i, file.Filename))
+			if file.ContentType != "" {
+				header.Set("Content-Type", file.ContentType)
+			} else {
+				header.Set("Content-Type", "application/octet-stream")
+			}
+			part, err := writer.CreatePart(header)
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write(file.Data); err != nil {
+				return err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		contentType = writer.FormDataContentType()
+	}
+
+	request, err := http.NewRequest(method, target, requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		discordErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, discordErr)
+		return discordErr
+	}
+	if result == nil || len(responseBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(responseBody, result)
+}
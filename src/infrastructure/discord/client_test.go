@@ -0,0 +1,229 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{"bot_token":"secret","webhook_enabled":true}`)
+	require.NoError(t, err)
+	assert.Equal(t, Config{BotToken: "secret"}, config)
+
+	_, err = ParseConfig(`{"webhook_enabled":true}`)
+	assert.Error(t, err)
+}
+
+func TestFormatMessage(t *testing.T) {
+	body, files := formatMessage("hello", nil)
+	assert.Equal(t, messageBody{Content: "hello"}, body)
+	assert.Empty(t, files)
+
+	long := strings.Repeat("a", maxContentLength+1)
+	body, files = formatMessage(long, nil)
+	assert.Empty(t, body.Content)
+	assert.Equal(t, []embed{{Description: long}}, body.Embeds)
+	assert.Empty(t, files)
+
+	tooLong := strings.Repeat("b", maxEmbedDescriptionLength+1)
+	attachment := Attachment{Filename: "graph.png", ContentType: "image/png", Data: []byte{1, 2}}
+	body, files = formatMessage(tooLong, []Attachment{attachment})
+	require.Len(t, body.Embeds, 1)
+	assert.Len(t, []rune(body.Embeds[0].Description), maxEmbedDescriptionLength)
+	require.Len(t, files, 2)
+	assert.Equal(t, longMessageFilename, files[0].Filename)
+	assert.Equal(t, tooLong, string(files[0].Data))
+	assert.Equal(t, attachment, files[1])
+	assert.Equal(t, []attachmentPayload{{ID: 0, Filename: longMessageFilename}, {ID: 1, Filename: "graph.png"}}, body.Attachments)
+}
+
+func TestClient_SendToChannelAndWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body messageBody
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "hello", body.Content)
+
+		switch r.URL.Path {
+		case "/api/channels/123456789012345678/messages":
+			assert.Equal(t, "Bot secret", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"id":"1","channel_id":"123456789012345678"}`))
+		case "/api/webhooks/42/token":
+			assert.Empty(t, r.Header.Get("Authorization"))
+			assert.Equal(t, "true", r.URL.Query().Get("wait"))
+			_, _ = w.Write([]byte(`{"id":"2","channel_id":"876543210987654321"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api", time.Second)
+	config := Config{BotToken: "secret", WebhookURL: server.URL + "/api/webhooks/42/token"}
+	results, err := client.Send(config, []string{"123456789012345678", WebhookRecipient}, "hello", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []SendResult{
+		{Recipient: "123456789012345678", ChannelID: "123456789012345678", MessageID: "1"},
+		{Recipient: WebhookRecipient, ChannelID: "876543210987654321", MessageID: "2"},
+	}, results)
+}
+
+func TestClient_SendWithButtons(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body messageBody
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Deploy?", body.Content)
+		require.Len(t, body.Components, 2)
+		assert.Len(t, body.Components[0].Components, maxButtonsPerRow)
+		assert.Equal(t, component{Type: componentTypeButton, Style: buttonStylePrimary, Label: "6", CustomID: "action:1:6"}, body.Components[1].Components[0])
+		_, _ = w.Write([]byte(`{"id":"1","channel_id":"123456789012345678"}`))
+	}))
+	defer server.Close()
+
+	var buttons []Button
+	for i := 1; i <= 6; i++ {
+		label := string(rune('0' + i))
+		buttons = append(buttons, Button{CustomID: "action:1:" + label, Label: label})
+	}
+	client := NewClient(server.URL, time.Second)
+	results, err := client.SendWithButtons(Config{BotToken: "secret"}, []string{"123456789012345678"}, "Deploy?", buttons)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = client.SendWithButtons(Config{WebhookURL: server.URL}, []string{WebhookRecipient}, "Deploy?", buttons)
+	var recipientErr *domainProvider.RecipientError
+	assert.True(t, errors.As(err, &recipientErr))
+}
+
+func TestClient_Edit(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		paths = append(paths, r.URL.Path)
+		var body messageBody
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "fixed", body.Content)
+		if strings.HasPrefix(r.URL.Path, "/api/channels/") {
+			assert.Equal(t, "Bot secret", r.Header.Get("Authorization"))
+		} else {
+			assert.Empty(t, r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api", time.Second)
+	sent := []SendResult{
+		{Recipient: "123456789012345678", ChannelID: "123456789012345678", MessageID: "1"},
+		{Recipient: WebhookRecipient, ChannelID: "876543210987654321", MessageID: "2"},
+	}
+	results, err := client.Edit(Config{BotToken: "secret", WebhookURL: server.URL + "/api/webhooks/42/token"}, sent, "fixed")
+	require.NoError(t, err)
+	assert.Equal(t, sent, results)
+	assert.Equal(t, []string{"/api/channels/123456789012345678/messages/1", "/api/webhooks/42/token/messages/2"}, paths)
+
+	// Messages sent through the webhook can't be edited without it
+	results, err = client.Edit(Config{BotToken: "secret"}, sent, "fixed")
+	assert.Error(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestClient_SendUploadsAttachments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		var body messageBody
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("payload_json")), &body))
+		assert.Equal(t, []attachmentPayload{{ID: 0, Filename: "report.csv"}}, body.Attachments)
+
+		file, header, err := r.FormFile("files[0]")
+		require.NoError(t, err)
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "report.csv", header.Filename)
+		assert.Equal(t, "a,b\n", string(data))
+		_, _ = w.Write([]byte(`{"id":"1","channel_id":"123456789012345678"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	_, err := client.Send(Config{BotToken: "secret"}, []string{"123456789012345678"}, "report",
+		[]Attachment{{Filename: "report.csv", ContentType: "text/csv", Data: []byte("a,b\n")}})
+	require.NoError(t, err)
+}
+
+func TestClient_SendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"You are being rate limited.","retry_after":1.5,"global":false}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	_, err := client.Send(Config{BotToken: "secret"}, []string{"123456789012345678"}, "hello", nil)
+	var discordErr *Error
+	require.True(t, errors.As(err, &discordErr))
+	assert.Equal(t, http.StatusTooManyRequests, discordErr.StatusCode)
+	assert.Equal(t, 1.5, discordErr.RetryAfter)
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(err))
+
+	_, err = client.Send(Config{BotToken: "secret"}, []string{WebhookRecipient}, "hello", nil)
+	assert.EqualError(t, err, "discord config has no discord_webhook_url to send to")
+	_, err = client.Send(Config{WebhookURL: server.URL}, []string{"123456789012345678"}, "hello", nil)
+	assert.EqualError(t, err, "discord config has no bot_token to send to channels")
+	_, err = client.Send(Config{BotToken: "secret"}, []string{"#general"}, "hello", nil)
+	var recipientErr *domainProvider.RecipientError
+	assert.True(t, errors.As(err, &recipientErr))
+}
+
+func TestClient_VerifyCredentials(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/users/@me":
+			if r.Header.Get("Authorization") != "Bot secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"message":"401: Unauthorized","code":0}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"1","username":"bot"}`))
+		case "/webhooks/1/token":
+			_, _ = w.Write([]byte(`{"id":"1","channel_id":"2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Unknown Webhook","code":10015}`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, time.Second)
+
+	require.NoError(t, client.VerifyCredentials(Config{BotToken: "secret", WebhookURL: server.URL + "/webhooks/1/token"}))
+	assert.Equal(t, []string{"/users/@me", "/webhooks/1/token"}, requested)
+
+	err := client.VerifyCredentials(Config{BotToken: "wrong"})
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(err))
+	err = client.VerifyCredentials(Config{WebhookURL: server.URL + "/webhooks/1/deleted"})
+	var discordErr *Error
+	require.True(t, errors.As(err, &discordErr))
+	assert.Equal(t, http.StatusNotFound, discordErr.StatusCode)
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(&Error{StatusCode: http.StatusNotFound, Code: 10003}))
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(&Error{StatusCode: http.StatusForbidden, Code: 50001}))
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(&Error{StatusCode: http.StatusUnauthorized}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&Error{StatusCode: http.StatusBadGateway}))
+	assert.Equal(t, domainProvider.ErrorCodeUnknown, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Code: 50035}))
+	assert.Equal(t, "", ErrorCode(errors.New("connection refused")))
+}
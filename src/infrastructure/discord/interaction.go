@@ -0,0 +1,142 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+)
+
+const (
+	// SignatureHeader carries the signature of the interactions Discord posts
+	SignatureHeader = "X-Signature-Ed25519"
+	// TimestampHeader carries the time the interaction was signed, it is part of the signed data
+	TimestampHeader = "X-Signature-Timestamp"
+)
+
+// Interaction types and the responses to them, see
+// https://discord.com/developers/docs/interactions/receiving-and-responding
+const (
+	interactionTypePing      = 1
+	interactionTypeComponent = 3
+	responseTypePong         = 1
+	// responseTypeDeferredUpdate acknowledges a click without changing the message
+	responseTypeDeferredUpdate = 6
+)
+
+// ErrInvalidSignature is returned for interactions that weren't signed with the key of the application
+var ErrInvalidSignature = errors.New("invalid discord signature")
+
+// InteractionConfig is the Discord application whose interactions endpoint receives the button clicks of a
+// provider, stored in the provider config
+type InteractionConfig struct {
+	PublicKey string `json:"public_key"`
+}
+
+// ParseInteractionConfig reads the application from the config of a provider
+func ParseInteractionConfig(providerConfig string) (InteractionConfig, error) {
+	var config InteractionConfig
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &config); err != nil {
+			return InteractionConfig{}, fmt.Errorf("invalid discord config: %w", err)
+		}
+	}
+	if config.PublicKey == "" {
+		return InteractionConfig{}, errors.New("discord config needs a public_key to verify interactions")
+	}
+	return config, nil
+}
+
+// ValidSignature reports whether an interaction was signed by Discord, an Ed25519 signature of the timestamp
+// followed by the raw body verified with the public key of the application
+func ValidSignature(publicKey string, timestamp string, body []byte, signature string) bool {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	signatureData, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, append([]byte(timestamp), body...), signatureData)
+}
+
+// interaction is the part of an interaction read for button clicks
+type interaction struct {
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+	// Member is set for clicks in guild channels, User for clicks in direct messages
+	Member *struct {
+		User interactionUser `json:"user"`
+	} `json:"member"`
+	User *interactionUser `json:"user"`
+}
+
+type interactionUser struct {
+	ID string `json:"id"`
+}
+
+// Interactions reads the interactions Discord posts for the buttons of messages sent by the bot
+type Interactions struct{}
+
+// NewInteractions creates a new reader of Discord interactions
+func NewInteractions() *Interactions {
+	return &Interactions{}
+}
+
+// ParseInteraction verifies an interaction with the public key of the provider and returns the action chosen by a
+// button click with the response Discord expects. Pings answer with a pong and choose nothing, like clicks on
+// buttons of other messages.
+func (i *Interactions) ParseInteraction(providerConfig string, header http.Header, body []byte) ([]domainProvider.ActionChoice, []byte, error) {
+	config, err := ParseInteractionConfig(providerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ValidSignature(config.PublicKey, header.Get(TimestampHeader), body, header.Get(SignatureHeader)) {
+		return nil, nil, domainErrors.NewAppError(ErrInvalidSignature, domainErrors.NotAuthenticated)
+	}
+
+	var received interaction
+	if err := json.Unmarshal(body, &received); err != nil {
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("invalid discord interaction: %w", err), domainErrors.ValidationError)
+	}
+	switch received.Type {
+	case interactionTypePing:
+		return nil, interactionResponse(responseTypePong), nil
+	case interactionTypeComponent:
+	default:
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("unsupported discord interaction type %d", received.Type), domainErrors.ValidationError)
+	}
+
+	response := interactionResponse(responseTypeDeferredUpdate)
+	messageID, actionID, ok := domainProvider.ParseActionData(received.Data.CustomID)
+	if !ok {
+		return nil, response, nil
+	}
+	choice := domainProvider.ActionChoice{
+		MessageTransactionID: messageID,
+		ActionID:             actionID,
+		Recipient:            received.ChannelID,
+	}
+	switch {
+	case received.Member != nil:
+		choice.ChosenBy = received.Member.User.ID
+	case received.User != nil:
+		choice.ChosenBy = received.User.ID
+	}
+	return []domainProvider.ActionChoice{choice}, response, nil
+}
+
+func interactionResponse(responseType int) []byte {
+	response, _ := json.Marshal(map[string]int{"type": responseType})
+	return response
+}
@@ -0,0 +1,68 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedInteraction signs an interaction like Discord does and returns the config with the public key
+func signedInteraction(t *testing.T, body string) (string, http.Header) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set(TimestampHeader, "1700000000")
+	header.Set(SignatureHeader, hex.EncodeToString(ed25519.Sign(privateKey, []byte("1700000000"+body))))
+	return `{"public_key":"` + hex.EncodeToString(publicKey) + `"}`, header
+}
+
+func TestInteractions_AnswersPing(t *testing.T) {
+	config, header := signedInteraction(t, `{"type":1}`)
+
+	choices, response, err := NewInteractions().ParseInteraction(config, header, []byte(`{"type":1}`))
+	require.NoError(t, err)
+	assert.Empty(t, choices)
+	assert.JSONEq(t, `{"type":1}`, string(response))
+}
+
+func TestInteractions_ReadsButtonClicks(t *testing.T) {
+	body := `{"type":3,"channel_id":"123456789012345678","data":{"custom_id":"action:42:approve","component_type":2},"member":{"user":{"id":"80351110224678912"}}}`
+	config, header := signedInteraction(t, body)
+
+	choices, response, err := NewInteractions().ParseInteraction(config, header, []byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":6}`, string(response))
+	assert.Equal(t, []domainProvider.ActionChoice{{
+		MessageTransactionID: 42,
+		ActionID:             "approve",
+		Recipient:            "123456789012345678",
+		ChosenBy:             "80351110224678912",
+	}}, choices)
+
+	// Buttons of other messages are acknowledged and choose nothing
+	body = `{"type":3,"channel_id":"123456789012345678","data":{"custom_id":"menu"},"user":{"id":"80351110224678912"}}`
+	config, header = signedInteraction(t, body)
+	choices, _, err = NewInteractions().ParseInteraction(config, header, []byte(body))
+	require.NoError(t, err)
+	assert.Empty(t, choices)
+}
+
+func TestInteractions_RejectsInvalidSignatures(t *testing.T) {
+	config, header := signedInteraction(t, `{"type":1}`)
+
+	_, _, err := NewInteractions().ParseInteraction(config, header, []byte(`{"type":2}`))
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+
+	_, _, err = NewInteractions().ParseInteraction(`{}`, header, []byte(`{"type":1}`))
+	assert.Error(t, err)
+}
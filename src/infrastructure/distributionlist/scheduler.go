@@ -0,0 +1,74 @@
+package distributionlist
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/distributionlist"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically syncs the Signal group membership of distribution lists, on the leader instance only
+type Scheduler struct {
+	distributionListUseCase distributionlist.IDistributionListUseCase
+	elector                 leader.Elector
+	Logger                  *logger.Logger
+	interval                time.Duration
+	shutdown                chan struct{}
+	done                    chan struct{}
+}
+
+// NewScheduler creates a new distribution list sync scheduler and starts it
+func NewScheduler(distributionListUseCase distributionlist.IDistributionListUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Hour // Default to syncing every hour if not specified
+	}
+
+	scheduler := &Scheduler{
+		distributionListUseCase: distributionListUseCase,
+		elector:                 elector,
+		Logger:                  loggerInstance,
+		interval:                interval,
+		shutdown:                make(chan struct{}),
+		done:                    make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting distribution list sync scheduler", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			s.syncAll()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) syncAll() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.distributionListUseCase.SyncAll(); err != nil {
+		s.Logger.Error("Error syncing distribution lists", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
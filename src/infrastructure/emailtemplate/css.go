@@ -0,0 +1,219 @@
+package emailtemplate
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// selector is a compound selector of a tag, an id and classes, like p, .note, #footer or td.cell.
+// Email clients drop most <style> elements, so the rules with such selectors are inlined; other rules, like
+// @media queries or descendant selectors, stay in a <style> element for the clients that support them.
+type selector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+var (
+	selectorPattern     = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?((?:[.#][a-zA-Z_-][a-zA-Z0-9_-]*)*)$`)
+	selectorPartPattern = regexp.MustCompile(`[.#][^.#]+`)
+)
+
+func parseSelector(text string) (selector, bool) {
+	match := selectorPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil || (match[1] == "" && match[2] == "") {
+		return selector{}, false
+	}
+	s := selector{tag: strings.ToLower(match[1])}
+	for _, part := range selectorPartPattern.FindAllString(match[2], -1) {
+		if part[0] == '#' {
+			if s.id != "" {
+				return selector{}, false
+			}
+			s.id = part[1:]
+		} else {
+			s.classes = append(s.classes, part[1:])
+		}
+	}
+	return s, true
+}
+
+// specificity orders the selectors like CSS does, ids before classes before tags
+func (s selector) specificity() int {
+	specificity := len(s.classes) * 100
+	if s.id != "" {
+		specificity += 10000
+	}
+	if s.tag != "" {
+		specificity++
+	}
+	return specificity
+}
+
+func (s selector) matches(node *html.Node) bool {
+	if s.tag != "" && s.tag != node.Data {
+		return false
+	}
+	if s.id != "" && attribute(node, "id") != s.id {
+		return false
+	}
+	classes := strings.Fields(attribute(node, "class"))
+	for _, class := range s.classes {
+		found := false
+		for _, c := range classes {
+			if c == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type cssRule struct {
+	selector     selector
+	declarations string
+	order        int
+}
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseCSS splits a stylesheet into the rules that can be inlined and the CSS that has to stay in a
+// <style> element
+func parseCSS(css string, order int) ([]cssRule, string) {
+	css = cssComment.ReplaceAllString(css, "")
+	var rules []cssRule
+	var remaining strings.Builder
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+
+		if strings.HasPrefix(prelude, "@") {
+			// at-rules nest blocks, keep them up to the matching brace
+			end, depth := len(css), 0
+			for i := open; i < len(css); i++ {
+				if css[i] == '{' {
+					depth++
+				} else if css[i] == '}' {
+					depth--
+					if depth == 0 {
+						end = i + 1
+						break
+					}
+				}
+			}
+			remaining.WriteString(strings.TrimSpace(css[:end]) + "\n")
+			css = css[end:]
+			continue
+		}
+
+		closing := strings.IndexByte(css[open:], '}')
+		if closing < 0 {
+			break
+		}
+		declarations := strings.Trim(strings.TrimSpace(css[open+1:open+closing]), ";")
+		css = css[open+closing+1:]
+
+		for _, text := range strings.Split(prelude, ",") {
+			if s, ok := parseSelector(text); ok {
+				rules = append(rules, cssRule{selector: s, declarations: strings.TrimSpace(declarations), order: order + len(rules)})
+			} else {
+				remaining.WriteString(strings.TrimSpace(text) + " { " + declarations + " }\n")
+			}
+		}
+	}
+	return rules, remaining.String()
+}
+
+// inlineCSS moves the rules of the <style> elements of a document into the style attributes of the elements
+// they apply to. Declarations of the style attribute win over the inlined ones.
+func inlineCSS(document *html.Node) {
+	var rules []cssRule
+	var styles []*html.Node
+	walk(document, func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Style {
+			styles = append(styles, node)
+		}
+	})
+	for _, style := range styles {
+		var css strings.Builder
+		for child := style.FirstChild; child != nil; child = child.NextSibling {
+			css.WriteString(child.Data)
+		}
+		styleRules, remaining := parseCSS(css.String(), len(rules))
+		rules = append(rules, styleRules...)
+
+		for style.FirstChild != nil {
+			style.RemoveChild(style.FirstChild)
+		}
+		if strings.TrimSpace(remaining) == "" {
+			style.Parent.RemoveChild(style)
+		} else {
+			style.AppendChild(&html.Node{Type: html.TextNode, Data: remaining})
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].selector.specificity() != rules[j].selector.specificity() {
+			return rules[i].selector.specificity() < rules[j].selector.specificity()
+		}
+		return rules[i].order < rules[j].order
+	})
+
+	walk(document, func(node *html.Node) {
+		if node.Type != html.ElementNode {
+			return
+		}
+		var declarations []string
+		for _, rule := range rules {
+			if rule.declarations != "" && rule.selector.matches(node) {
+				declarations = append(declarations, rule.declarations)
+			}
+		}
+		if len(declarations) == 0 {
+			return
+		}
+		if existing := strings.Trim(strings.TrimSpace(attribute(node, "style")), ";"); existing != "" {
+			declarations = append(declarations, existing)
+		}
+		setAttribute(node, "style", strings.Join(declarations, "; "))
+	})
+}
+
+func walk(node *html.Node, visit func(*html.Node)) {
+	visit(node)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, visit)
+	}
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttribute(node *html.Node, key string, value string) {
+	for i, a := range node.Attr {
+		if a.Namespace == "" && a.Key == key {
+			node.Attr[i].Val = value
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: value})
+}
@@ -0,0 +1,146 @@
+package emailtemplate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"regexp"
+	"strings"
+	textTemplate "text/template"
+
+	"golang.org/x/net/html"
+)
+
+// Template is an email template. The subject is a text/template and the HTML body an html/template, both
+// rendered with the same variables. CSS in <style> elements of the body is inlined into the elements it
+// applies to, and {{ image "key" }} embeds an image of the attachment store.
+type Template struct {
+	Subject string
+	HTML    string
+}
+
+// Image is an image embedded in a rendered email, referenced from the HTML as cid:ContentID
+type Image struct {
+	ContentID   string
+	ContentType string
+	Data        []byte
+}
+
+// Rendered is an email rendered from a template
+type Rendered struct {
+	Subject string
+	// HTML is the body with the CSS inlined and the images referenced by content id
+	HTML string
+	// Text is the plain-text alternative of the body
+	Text   string
+	Images []Image
+}
+
+// Renderer renders email templates, loading embedded images from the attachment store
+type Renderer struct {
+	store AttachmentStore
+}
+
+// NewRenderer creates a new renderer, store may be nil when no images are embedded
+func NewRenderer(store AttachmentStore) *Renderer {
+	return &Renderer{store: store}
+}
+
+// Validate parses the subject and body of a template, reporting syntax errors without rendering it
+func (r *Renderer) Validate(t Template) error {
+	if _, err := textTemplate.New("subject").Option("missingkey=zero").Parse(t.Subject); err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+	if _, err := htmlTemplate.New("html").Funcs(htmlTemplate.FuncMap{"image": func(string) (htmlTemplate.URL, error) { return "", nil }}).Parse(t.HTML); err != nil {
+		return fmt.Errorf("invalid html template: %w", err)
+	}
+	return nil
+}
+
+// Render renders a template with the variables
+func (r *Renderer) Render(t Template, variables map[string]interface{}) (*Rendered, error) {
+	subjectTemplate, err := textTemplate.New("subject").Option("missingkey=zero").Parse(t.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	var subject bytes.Buffer
+	if err := subjectTemplate.Execute(&subject, variables); err != nil {
+		return nil, fmt.Errorf("couldn't render subject: %w", err)
+	}
+
+	var images []Image
+	embedded := make(map[string]bool)
+	funcs := htmlTemplate.FuncMap{
+		"image": func(key string) (htmlTemplate.URL, error) {
+			contentID := contentIDFor(key)
+			if !embedded[contentID] {
+				image, err := r.loadImage(key, contentID)
+				if err != nil {
+					return "", err
+				}
+				images = append(images, *image)
+				embedded[contentID] = true
+			}
+			return htmlTemplate.URL("cid:" + contentID), nil
+		},
+	}
+	bodyTemplate, err := htmlTemplate.New("html").Option("missingkey=zero").Funcs(funcs).Parse(t.HTML)
+	if err != nil {
+		return nil, fmt.Errorf("invalid html template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := bodyTemplate.Execute(&body, variables); err != nil {
+		return nil, fmt.Errorf("couldn't render html: %w", err)
+	}
+
+	document, err := html.Parse(&body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse rendered html: %w", err)
+	}
+	inlineCSS(document)
+
+	var rendered bytes.Buffer
+	if err := html.Render(&rendered, document); err != nil {
+		return nil, err
+	}
+
+	return &Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    rendered.String(),
+		Text:    plainText(document),
+		Images:  images,
+	}, nil
+}
+
+// PreviewHTML returns the HTML with the embedded images as data URIs, so a browser shows it as sent
+func (r *Rendered) PreviewHTML() string {
+	preview := r.HTML
+	for _, image := range r.Images {
+		dataURI := "data:" + image.ContentType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)
+		preview = strings.ReplaceAll(preview, `"cid:`+image.ContentID+`"`, `"`+dataURI+`"`)
+	}
+	return preview
+}
+
+func (r *Renderer) loadImage(key string, contentID string) (*Image, error) {
+	if r.store == nil {
+		return nil, errors.New("images can't be embedded, no attachment store is configured")
+	}
+	data, contentType, err := r.store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load image %s: %w", key, err)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("attachment %s is not an image but %s", key, contentType)
+	}
+	return &Image{ContentID: contentID, ContentType: contentType, Data: data}, nil
+}
+
+var contentIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// contentIDFor derives the content id of an image from its key in the attachment store
+func contentIDFor(key string) string {
+	return contentIDUnsafe.ReplaceAllString(key, "_")
+}
@@ -0,0 +1,88 @@
+package emailtemplate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStore map[string][]byte
+
+func (m mockStore) Get(key string) ([]byte, string, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+	return data, "image/png", nil
+}
+
+const welcomeTemplate = `<html><head><style>
+p { color: #333; margin: 0 }
+.note { font-size: 12px }
+#footer { color: gray }
+a:hover { color: red }
+@media (max-width: 600px) { p { margin: 4px } }
+</style></head><body>
+<h1>Welcome {{ .name }}</h1>
+<p class="note" style="font-weight: bold">Your code is <b>{{ .code }}</b>.</p>
+<img src="{{ image "logos/logo.png" }}" alt="Logo">
+<ul><li>First</li><li>Second</li></ul>
+<p id="footer">Read the <a href="https://example.com/docs">docs</a> or mail <a href="mailto:help@example.com">help@example.com</a></p>
+</body></html>`
+
+func TestRenderer_Render(t *testing.T) {
+	renderer := NewRenderer(mockStore{"logos/logo.png": []byte("png")})
+
+	rendered, err := renderer.Render(Template{Subject: "Hello {{ .name }}", HTML: welcomeTemplate}, map[string]interface{}{"name": "Ada <3", "code": 42})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello Ada <3", rendered.Subject)
+	assert.Contains(t, rendered.HTML, "Welcome Ada &lt;3")
+	assert.Contains(t, rendered.HTML, `<p class="note" style="color: #333; margin: 0; font-size: 12px; font-weight: bold">`)
+	assert.Contains(t, rendered.HTML, `<p id="footer" style="color: #333; margin: 0; color: gray">`)
+	assert.Contains(t, rendered.HTML, `<img src="cid:logos_logo.png" alt="Logo"/>`)
+	assert.Contains(t, rendered.HTML, "a:hover { color: red }")
+	assert.Contains(t, rendered.HTML, "@media (max-width: 600px) { p { margin: 4px } }")
+	assert.NotContains(t, rendered.HTML, ".note")
+
+	assert.Equal(t, "Welcome Ada <3\n\nYour code is 42.\n\nLogo\n\n- First\n- Second\n\nRead the docs (https://example.com/docs) or mail help@example.com", rendered.Text)
+	assert.Equal(t, []Image{{ContentID: "logos_logo.png", ContentType: "image/png", Data: []byte("png")}}, rendered.Images)
+
+	assert.Contains(t, rendered.PreviewHTML(), `<img src="data:image/png;base64,cG5n" alt="Logo"/>`)
+}
+
+func TestRenderer_RenderErrors(t *testing.T) {
+	renderer := NewRenderer(nil)
+
+	_, err := renderer.Render(Template{HTML: `<img src="{{ image "logo.png" }}">`}, nil)
+	assert.ErrorContains(t, err, "no attachment store is configured")
+
+	_, err = NewRenderer(mockStore{}).Render(Template{HTML: `<img src="{{ image "logo.png" }}">`}, nil)
+	assert.ErrorContains(t, err, "couldn't load image logo.png")
+
+	assert.ErrorContains(t, renderer.Validate(Template{Subject: "{{ .name", HTML: "<p></p>"}), "invalid subject template")
+	assert.ErrorContains(t, renderer.Validate(Template{HTML: "{{ if .name }}"}), "invalid html template")
+	assert.NoError(t, renderer.Validate(Template{Subject: "Hi", HTML: `<img src="{{ image "logo.png" }}">`}))
+}
+
+func TestDirAttachmentStore_Get(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logos"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logos", "logo.png"), []byte("\x89PNG\r\n\x1a\n"), 0o644))
+
+	store := NewDirAttachmentStore(dir)
+	data, contentType, err := store.Get("logos/logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.True(t, strings.HasPrefix(string(data), "\x89PNG"))
+
+	_, _, err = store.Get("../secret.png")
+	assert.EqualError(t, err, `invalid attachment key "../secret.png"`)
+	_, _, err = store.Get("missing.png")
+	assert.EqualError(t, err, "attachment missing.png not found")
+}
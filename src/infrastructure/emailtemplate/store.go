@@ -0,0 +1,51 @@
+package emailtemplate
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// AttachmentStore provides the attachments emails embed, by key
+type AttachmentStore interface {
+	// Get returns the data and content type of an attachment
+	Get(key string) ([]byte, string, error)
+}
+
+// DirAttachmentStore reads attachments from the files of a directory, the key is the path in the directory
+type DirAttachmentStore struct {
+	dir string
+}
+
+// NewDirAttachmentStore creates a new attachment store reading from dir
+func NewDirAttachmentStore(dir string) *DirAttachmentStore {
+	return &DirAttachmentStore{dir: dir}
+}
+
+func (s *DirAttachmentStore) Get(key string) ([]byte, string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if key == "" || cleaned != key {
+		return nil, "", fmt.Errorf("invalid attachment key %q", key)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("attachment %s not found", key)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	return data, contentType, nil
+}
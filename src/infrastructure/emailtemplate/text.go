@@ -0,0 +1,83 @@
+package emailtemplate
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockElements start on a new line in the plain-text alternative
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Div: true, atom.Footer: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+// skippedElements have no text shown to the reader
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Title: true,
+}
+
+var (
+	whitespace      = regexp.MustCompile(`[ \t\r\n\f]+`)
+	extraBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// plainText generates the plain-text alternative of a document: block elements become paragraphs, list items
+// dashes, links their text followed by the URL and images their alt text
+func plainText(document *html.Node) string {
+	var text strings.Builder
+	writeText(&text, document)
+
+	lines := strings.Split(extraBlankLines.ReplaceAllString(text.String(), "\n\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(extraBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func writeText(text *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		text.WriteString(whitespace.ReplaceAllString(node.Data, " "))
+		return
+	case html.ElementNode:
+		if skippedElements[node.DataAtom] {
+			return
+		}
+		switch node.DataAtom {
+		case atom.Br:
+			text.WriteString("\n")
+			return
+		case atom.Img:
+			text.WriteString(attribute(node, "alt"))
+			return
+		case atom.Li:
+			text.WriteString("\n- ")
+		case atom.Td, atom.Th:
+			text.WriteString(" ")
+		}
+	}
+
+	block := node.Type == html.ElementNode && blockElements[node.DataAtom]
+	if block {
+		text.WriteString("\n\n")
+	}
+	start := text.Len()
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeText(text, child)
+	}
+	if node.Type == html.ElementNode && node.DataAtom == atom.A {
+		href := attribute(node, "href")
+		linkText := strings.TrimSpace(text.String()[start:])
+		if href != "" && !strings.HasPrefix(href, "#") && href != linkText && href != "mailto:"+linkText {
+			text.WriteString(" (" + href + ")")
+		}
+	}
+	if block {
+		text.WriteString("\n\n")
+	}
+}
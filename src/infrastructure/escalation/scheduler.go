@@ -0,0 +1,77 @@
+package escalation
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/escalation"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically notifies the due steps of running escalations, on the leader instance only
+type Scheduler struct {
+	escalationUseCase escalation.IEscalationUseCase
+	elector           leader.Elector
+	Logger            *logger.Logger
+	interval          time.Duration
+	shutdown          chan struct{}
+	done              chan struct{}
+}
+
+// NewScheduler creates a new escalation scheduler and starts it
+func NewScheduler(escalationUseCase escalation.IEscalationUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second // Default to checking every 30 seconds if not specified
+	}
+
+	scheduler := &Scheduler{
+		escalationUseCase: escalationUseCase,
+		elector:           elector,
+		Logger:            loggerInstance,
+		interval:          interval,
+		shutdown:          make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting escalation scheduler", zap.Duration("interval", s.interval))
+
+	// Notify steps that became due while the service was down
+	s.processDueEscalations()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.processDueEscalations()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) processDueEscalations() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.escalationUseCase.ProcessDueEscalations(time.Now()); err != nil {
+		s.Logger.Error("Error processing due escalations", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// TopicMessage carries a *provider.MessageEvent for every message sent or received
+const TopicMessage = "message"
+
+// Event is published on the bus to the handlers of its topic
+type Event struct {
+	Topic   string
+	Payload interface{}
+}
+
+// Handler handles the events of a topic
+type Handler func(event Event)
+
+// Bus delivers events to the handlers of this instance, in the order they were published and off the path of
+// the publisher. Publishing never blocks: when the buffer is full the event is dropped and counted, so the
+// consumers of the bus have to tolerate missed events, e.g. by being rebuildable.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	queue    chan Event
+	dropped  atomic.Int64
+	Logger   *logger.Logger
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewBus creates a new event bus buffering up to bufferSize events and starts it
+func NewBus(bufferSize int, loggerInstance *logger.Logger) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = 1000 // Default to 1000 events if not specified
+	}
+	bus := &Bus{
+		handlers: map[string][]Handler{},
+		queue:    make(chan Event, bufferSize),
+		Logger:   loggerInstance,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go bus.run()
+	return bus
+}
+
+// Subscribe registers a handler for the events of a topic
+func (b *Bus) Subscribe(topic string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish queues an event for the handlers of its topic. A nil bus drops every event.
+func (b *Bus) Publish(topic string, payload interface{}) {
+	if b == nil {
+		return
+	}
+	select {
+	case b.queue <- Event{Topic: topic, Payload: payload}:
+	default:
+		b.dropped.Add(1)
+		b.Logger.Warn("Event bus is full, dropping event", zap.String("topic", topic), zap.Int64("dropped", b.dropped.Load()))
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+	for {
+		select {
+		case event := <-b.queue:
+			b.deliver(event)
+		case <-b.shutdown:
+			// Deliver what was published before the shutdown
+			for {
+				select {
+				case event := <-b.queue:
+					b.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Bus) deliver(event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Topic]
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		b.safeHandle(handler, event)
+	}
+}
+
+// safeHandle keeps the bus running when a handler panics
+func (b *Bus) safeHandle(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.Logger.Error("Event handler panicked", zap.String("topic", event.Topic), zap.Any("panic", r))
+		}
+	}()
+	handler(event)
+}
+
+// Shutdown stops the bus after delivering the queued events
+func (b *Bus) Shutdown() {
+	close(b.shutdown)
+	<-b.done
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBus(t *testing.T, bufferSize int) *Bus {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return NewBus(bufferSize, loggerInstance)
+}
+
+func TestBus_DeliversInOrderToTopicHandlers(t *testing.T) {
+	bus := newTestBus(t, 10)
+	var messages []interface{}
+	var others int
+	bus.Subscribe(TopicMessage, func(event Event) {
+		messages = append(messages, event.Payload)
+	})
+	bus.Subscribe("other", func(event Event) {
+		others++
+	})
+
+	bus.Publish(TopicMessage, 1)
+	bus.Publish(TopicMessage, 2)
+	bus.Publish("other", 3)
+	bus.Shutdown()
+
+	assert.Equal(t, []interface{}{1, 2}, messages)
+	assert.Equal(t, 1, others)
+	assert.Equal(t, int64(0), bus.Dropped())
+}
+
+func TestBus_HandlerPanicKeepsDelivering(t *testing.T) {
+	bus := newTestBus(t, 10)
+	var delivered int
+	bus.Subscribe(TopicMessage, func(event Event) {
+		if event.Payload == "panic" {
+			panic("handler failed")
+		}
+		delivered++
+	})
+
+	bus.Publish(TopicMessage, "panic")
+	bus.Publish(TopicMessage, "ok")
+	bus.Shutdown()
+
+	assert.Equal(t, 1, delivered)
+}
+
+func TestBus_DropsWhenFull(t *testing.T) {
+	bus := newTestBus(t, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	bus.Subscribe(TopicMessage, func(event Event) {
+		if event.Payload == 1 {
+			close(started)
+			<-release
+		}
+	})
+
+	bus.Publish(TopicMessage, 1)
+	<-started
+	bus.Publish(TopicMessage, 2)
+	bus.Publish(TopicMessage, 3)
+	close(release)
+	bus.Shutdown()
+
+	assert.Equal(t, int64(1), bus.Dropped())
+}
+
+func TestBus_NilPublishIsNoop(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() { bus.Publish(TopicMessage, 1) })
+}
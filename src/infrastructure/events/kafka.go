@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes events to a Kafka topic
+type KafkaPublisher struct {
+	writer *kafka.Writer
+	Logger *logger.Logger
+}
+
+func NewKafkaPublisher(brokers []string, topic string, loggerInstance *logger.Logger) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+		Logger: loggerInstance,
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: payload})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NatsPublisher publishes events to a NATS subject
+type NatsPublisher struct {
+	conn    *nats.Conn
+	subject string
+	Logger  *logger.Logger
+}
+
+func NewNatsPublisher(url string, subject string, loggerInstance *logger.Logger) (*NatsPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("go-multi-chat-api"))
+	if err != nil {
+		return nil, err
+	}
+	return &NatsPublisher{conn: conn, subject: subject, Logger: loggerInstance}, nil
+}
+
+func (p *NatsPublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	msg := nats.NewMsg(p.subject)
+	msg.Header.Set("Message-Key", key)
+	msg.Data = payload
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	// Make sure the server received the event before it is marked as published in the outbox
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *NatsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Publisher publishes message lifecycle events to an external event stream
+type Publisher interface {
+	// Publish sends the payload to the configured topic/subject. The key is used for partitioning.
+	Publish(ctx context.Context, key string, payload []byte) error
+	Close() error
+}
+
+// PublisherConfig holds the event publisher configuration
+type PublisherConfig struct {
+	Type         string // kafka or nats, empty disables publishing
+	Topic        string
+	KafkaBrokers []string
+	NatsURL      string
+}
+
+// LoadPublisherConfig loads the event publisher configuration from environment variables
+func LoadPublisherConfig() PublisherConfig {
+	return PublisherConfig{
+		Type:         strings.ToLower(utils.GetEnv("EVENT_PUBLISHER", "")),
+		Topic:        utils.GetEnv("EVENT_TOPIC", "message-lifecycle"),
+		KafkaBrokers: strings.Split(utils.GetEnv("KAFKA_BROKERS", "localhost:9092"), ","),
+		NatsURL:      utils.GetEnv("NATS_URL", "nats://localhost:4222"),
+	}
+}
+
+// Enabled returns whether an event publisher is configured
+func (c PublisherConfig) Enabled() bool {
+	return c.Type != ""
+}
+
+// NewPublisher creates the publisher for the configured type
+func NewPublisher(config PublisherConfig, loggerInstance *logger.Logger) (Publisher, error) {
+	switch config.Type {
+	case "kafka":
+		return NewKafkaPublisher(config.KafkaBrokers, config.Topic, loggerInstance), nil
+	case "nats":
+		return NewNatsPublisher(config.NatsURL, config.Topic, loggerInstance)
+	default:
+		return nil, fmt.Errorf("unsupported event publisher type: %s", config.Type)
+	}
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// OutboxRelay periodically reads unpublished events from the outbox table and publishes them. Only the leader
+// instance publishes, so events are never published twice or out of order by concurrent relays.
+type OutboxRelay struct {
+	outboxEventRepository providerRepo.OutboxEventRepositoryInterface
+	publisher             Publisher
+	elector               leader.Elector
+	Logger                *logger.Logger
+	interval              time.Duration
+	batchSize             int
+	shutdown              chan struct{}
+	done                  chan struct{}
+}
+
+// NewOutboxRelay creates a new outbox relay and starts it
+func NewOutboxRelay(
+	outboxEventRepository providerRepo.OutboxEventRepositoryInterface,
+	publisher Publisher,
+	elector leader.Elector,
+	loggerInstance *logger.Logger,
+	interval time.Duration,
+	batchSize int,
+) *OutboxRelay {
+	if interval <= 0 {
+		interval = 5 * time.Second // Default to 5 seconds if not specified
+	}
+	if batchSize <= 0 {
+		batchSize = 100 // Default to 100 events per run if not specified
+	}
+
+	relay := &OutboxRelay{
+		outboxEventRepository: outboxEventRepository,
+		publisher:             publisher,
+		elector:               elector,
+		Logger:                loggerInstance,
+		interval:              interval,
+		batchSize:             batchSize,
+		shutdown:              make(chan struct{}),
+		done:                  make(chan struct{}),
+	}
+
+	go relay.run()
+
+	return relay
+}
+
+func (r *OutboxRelay) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.Logger.Info("Starting outbox relay", zap.Duration("interval", r.interval), zap.Int("batchSize", r.batchSize))
+
+	for {
+		select {
+		case <-ticker.C:
+			if r.elector.IsLeader() {
+				r.publishPendingEvents()
+			}
+		case <-r.shutdown:
+			return
+		}
+	}
+}
+
+// publishPendingEvents publishes one batch of events in insertion order. It stops at the first failure so the
+// ordering of events of the same message is preserved.
+func (r *OutboxRelay) publishPendingEvents() {
+	pendingEvents, err := r.outboxEventRepository.GetUnpublished(r.batchSize)
+	if err != nil {
+		r.Logger.Error("Error getting unpublished outbox events", zap.Error(err))
+		return
+	}
+
+	for _, event := range *pendingEvents {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := r.publisher.Publish(ctx, strconv.Itoa(event.MessageID), []byte(event.Payload))
+		cancel()
+
+		if err != nil {
+			r.Logger.Error("Error publishing outbox event", zap.Error(err), zap.Int("eventID", event.ID), zap.String("eventType", event.EventType))
+			if markErr := r.outboxEventRepository.MarkFailed(event.ID, err.Error()); markErr != nil {
+				r.Logger.Error("Error recording outbox publish failure", zap.Error(markErr), zap.Int("eventID", event.ID))
+			}
+			return
+		}
+
+		if err := r.outboxEventRepository.MarkPublished(event.ID); err != nil {
+			r.Logger.Error("Error marking outbox event as published", zap.Error(err), zap.Int("eventID", event.ID))
+			return
+		}
+	}
+
+	if len(*pendingEvents) > 0 {
+		r.Logger.Info("Published outbox events", zap.Int("count", len(*pendingEvents)))
+	}
+}
+
+// Shutdown stops the relay and closes the publisher
+func (r *OutboxRelay) Shutdown() {
+	close(r.shutdown)
+	<-r.done
+	if err := r.publisher.Close(); err != nil {
+		r.Logger.Error("Error closing event publisher", zap.Error(err))
+	}
+}
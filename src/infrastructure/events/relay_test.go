@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOutboxEventRepository struct {
+	events    []domainProvider.OutboxEvent
+	published []int
+	failed    []int
+}
+
+func (m *mockOutboxEventRepository) GetUnpublished(limit int) (*[]domainProvider.OutboxEvent, error) {
+	return &m.events, nil
+}
+
+func (m *mockOutboxEventRepository) MarkPublished(id int) error {
+	m.published = append(m.published, id)
+	return nil
+}
+
+func (m *mockOutboxEventRepository) MarkFailed(id int, errorMessage string) error {
+	m.failed = append(m.failed, id)
+	return nil
+}
+
+type mockPublisher struct {
+	failOnKey string
+	keys      []string
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	if key == m.failOnKey {
+		return errors.New("broker unavailable")
+	}
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *mockPublisher) Close() error {
+	return nil
+}
+
+func newTestRelay(t *testing.T, repo *mockOutboxEventRepository, publisher *mockPublisher) *OutboxRelay {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return &OutboxRelay{
+		outboxEventRepository: repo,
+		publisher:             publisher,
+		Logger:                loggerInstance,
+		batchSize:             10,
+	}
+}
+
+func TestOutboxRelay_PublishPendingEvents(t *testing.T) {
+	repo := &mockOutboxEventRepository{events: []domainProvider.OutboxEvent{
+		{ID: 1, MessageID: 10, Payload: "{}"},
+		{ID: 2, MessageID: 11, Payload: "{}"},
+	}}
+	publisher := &mockPublisher{}
+	relay := newTestRelay(t, repo, publisher)
+
+	relay.publishPendingEvents()
+
+	assert.Equal(t, []string{"10", "11"}, publisher.keys)
+	assert.Equal(t, []int{1, 2}, repo.published)
+	assert.Empty(t, repo.failed)
+}
+
+func TestOutboxRelay_StopsAtFirstFailure(t *testing.T) {
+	repo := &mockOutboxEventRepository{events: []domainProvider.OutboxEvent{
+		{ID: 1, MessageID: 10, Payload: "{}"},
+		{ID: 2, MessageID: 11, Payload: "{}"},
+		{ID: 3, MessageID: 12, Payload: "{}"},
+	}}
+	publisher := &mockPublisher{failOnKey: "11"}
+	relay := newTestRelay(t, repo, publisher)
+
+	relay.publishPendingEvents()
+
+	assert.Equal(t, []int{1}, repo.published)
+	assert.Equal(t, []int{2}, repo.failed)
+}
+
+func TestLoadPublisherConfig_DisabledByDefault(t *testing.T) {
+	t.Setenv("EVENT_PUBLISHER", "")
+
+	config := LoadPublisherConfig()
+
+	assert.False(t, config.Enabled())
+	assert.Equal(t, "message-lifecycle", config.Topic)
+}
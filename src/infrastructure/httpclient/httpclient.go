@@ -0,0 +1,185 @@
+// Package httpclient builds the HTTP clients of every outbound integration, so proxies, trusted CAs and connection
+// pooling are configured once for Azure AD, hook deliveries, the provider APIs and the payload stores.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Config controls the transport shared by the outbound HTTP clients. Proxies are taken from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables.
+type Config struct {
+	// CABundle is a PEM file of CAs trusted besides the system roots, e.g. of a TLS intercepting proxy
+	CABundle string
+	// Timeout is the timeout of clients created without one of their own
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+// LoadConfig loads the outbound HTTP settings from environment variables
+func LoadConfig() (Config, error) {
+	config := Config{CABundle: utils.GetEnv("OUTBOUND_CA_BUNDLE", "")}
+	seconds := []struct {
+		key          string
+		defaultValue int
+		target       *time.Duration
+	}{
+		{"OUTBOUND_TIMEOUT_SECONDS", 30, &config.Timeout},
+		{"OUTBOUND_DIAL_TIMEOUT_SECONDS", 10, &config.DialTimeout},
+		{"OUTBOUND_IDLE_CONN_TIMEOUT_SECONDS", 90, &config.IdleConnTimeout},
+	}
+	for _, setting := range seconds {
+		value, err := utils.GetIntEnv(setting.key, setting.defaultValue)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		if value <= 0 {
+			return Config{}, fmt.Errorf("invalid %s: must be positive", setting.key)
+		}
+		*setting.target = time.Duration(value) * time.Second
+	}
+
+	counts := []struct {
+		key          string
+		defaultValue int
+		target       *int
+	}{
+		{"OUTBOUND_MAX_IDLE_CONNS", 100, &config.MaxIdleConns},
+		{"OUTBOUND_MAX_IDLE_CONNS_PER_HOST", 10, &config.MaxIdleConnsPerHost},
+		{"OUTBOUND_MAX_CONNS_PER_HOST", 0, &config.MaxConnsPerHost},
+	}
+	for _, setting := range counts {
+		value, err := utils.GetIntEnv(setting.key, setting.defaultValue)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		if value < 0 {
+			return Config{}, fmt.Errorf("invalid %s: must not be negative", setting.key)
+		}
+		*setting.target = value
+	}
+	return config, nil
+}
+
+// NewTransport creates a transport using the proxies of the environment and trusting the CA bundle of the config
+// besides the system roots
+func NewTransport(config Config) (*http.Transport, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.CABundle != "" {
+		pem, err := os.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read OUTBOUND_CA_BUNDLE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("OUTBOUND_CA_BUNDLE %s contains no PEM certificate", config.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{Timeout: config.DialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
+
+var (
+	mu              sync.Mutex
+	sharedConfig    = Config{Timeout: 30 * time.Second, DialTimeout: 10 * time.Second, MaxIdleConns: 100, MaxIdleConnsPerHost: 10, IdleConnTimeout: 90 * time.Second}
+	sharedTransport http.RoundTripper
+)
+
+// Configure replaces the transport of the clients created afterwards, it is called on startup before the
+// integrations are set up. Until then the clients use a transport with the default settings.
+func Configure(config Config) error {
+	transport, err := NewTransport(config)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sharedConfig = config
+	sharedTransport = transport
+	return nil
+}
+
+// New creates a client with the shared transport, a timeout of 0 uses the configured OUTBOUND_TIMEOUT_SECONDS
+func New(timeout time.Duration) *http.Client {
+	mu.Lock()
+	defer mu.Unlock()
+	if sharedTransport == nil {
+		// The defaults read no file and can't fail
+		sharedTransport, _ = NewTransport(sharedConfig)
+	}
+	if timeout <= 0 {
+		timeout = sharedConfig.Timeout
+	}
+	return &http.Client{Timeout: timeout, Transport: sharedTransport}
+}
+
+// TLSOptions are the TLS settings of a client talking to a single target, e.g. one requiring mTLS
+type TLSOptions struct {
+	// Certificates are presented to targets asking for a client certificate
+	Certificates []tls.Certificate
+	// RootCAs are PEM CAs trusted besides the system roots and OUTBOUND_CA_BUNDLE
+	RootCAs []byte
+	// InsecureSkipVerify turns off the verification of the certificate of the target
+	InsecureSkipVerify bool
+}
+
+// NewWithTLS creates a client with a transport of its own, configured like the shared transport plus the TLS
+// options. Its connections aren't pooled with the other clients, so it should be reused for the same target.
+func NewWithTLS(timeout time.Duration, options TLSOptions) (*http.Client, error) {
+	mu.Lock()
+	config := sharedConfig
+	mu.Unlock()
+	transport, err := NewTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := transport.TLSClientConfig
+	tlsConfig.Certificates = options.Certificates
+	tlsConfig.InsecureSkipVerify = options.InsecureSkipVerify
+	if len(options.RootCAs) > 0 {
+		pool := tlsConfig.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(options.RootCAs) {
+			return nil, fmt.Errorf("the CA certificate contains no PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if timeout <= 0 {
+		timeout = config.Timeout
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
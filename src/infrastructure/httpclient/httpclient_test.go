@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, config.Timeout)
+	assert.Equal(t, 100, config.MaxIdleConns)
+	assert.Equal(t, 10, config.MaxIdleConnsPerHost)
+
+	t.Setenv("OUTBOUND_TIMEOUT_SECONDS", "5")
+	t.Setenv("OUTBOUND_MAX_CONNS_PER_HOST", "20")
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 20, config.MaxConnsPerHost)
+
+	t.Setenv("OUTBOUND_TIMEOUT_SECONDS", "0")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("OUTBOUND_TIMEOUT_SECONDS", "5")
+	t.Setenv("OUTBOUND_MAX_IDLE_CONNS", "-1")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestNewTransport_UsesProxyOfEnvironment(t *testing.T) {
+	transport, err := NewTransport(Config{})
+	require.NoError(t, err)
+
+	t.Setenv("HTTPS_PROXY", "http://proxy.internal:3128")
+	t.Setenv("NO_PROXY", "graph.internal")
+	request := &http.Request{URL: &url.URL{Scheme: "https", Host: "login.microsoftonline.com"}}
+	// ProxyFromEnvironment caches the environment of its first call, this test runs before any request is sent
+	proxy, err := transport.Proxy(request)
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+
+	proxy, err = transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "graph.internal"}})
+	require.NoError(t, err)
+	assert.Nil(t, proxy)
+}
+
+func TestNewTransport_TrustsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	// The self-signed certificate of the server is rejected without the bundle
+	transport, err := NewTransport(Config{DialTimeout: time.Second})
+	require.NoError(t, err)
+	_, err = (&http.Client{Transport: transport, Timeout: 5 * time.Second}).Get(server.URL)
+	assert.Error(t, err)
+
+	transport, err = NewTransport(Config{CABundle: bundle, DialTimeout: time.Second})
+	require.NoError(t, err)
+	response, err := (&http.Client{Transport: transport, Timeout: 5 * time.Second}).Get(server.URL)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+}
+
+func TestNewTransport_RejectsInvalidCABundle(t *testing.T) {
+	_, err := NewTransport(Config{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0o600))
+	_, err = NewTransport(Config{CABundle: bundle})
+	assert.Error(t, err)
+}
+
+func TestNew_SharesTransport(t *testing.T) {
+	config := Config{Timeout: 7 * time.Second, DialTimeout: time.Second, MaxIdleConnsPerHost: 4}
+	require.NoError(t, Configure(config))
+
+	first := New(0)
+	second := New(time.Second)
+	assert.Equal(t, 7*time.Second, first.Timeout)
+	assert.Equal(t, time.Second, second.Timeout)
+	assert.Same(t, first.Transport, second.Transport)
+	assert.Equal(t, 4, first.Transport.(*http.Transport).MaxIdleConnsPerHost)
+}
+
+func TestNewWithTLS_PresentsClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "client", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "client"}, NotAfter: time.Now().Add(time.Hour)}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	clientCertificate := tls.Certificate{Certificate: [][]byte{certificate}, PrivateKey: key}
+
+	// The server rejects clients without a certificate
+	client, err := NewWithTLS(5*time.Second, TLSOptions{RootCAs: serverCA})
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = NewWithTLS(5*time.Second, TLSOptions{Certificates: []tls.Certificate{clientCertificate}, RootCAs: serverCA})
+	require.NoError(t, err)
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	// Without verification the CA isn't needed
+	client, err = NewWithTLS(5*time.Second, TLSOptions{Certificates: []tls.Certificate{clientCertificate}, InsecureSkipVerify: true})
+	require.NoError(t, err)
+	response, err = client.Get(server.URL)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	_, err = NewWithTLS(time.Second, TLSOptions{RootCAs: []byte("not a certificate")})
+	assert.Error(t, err)
+}
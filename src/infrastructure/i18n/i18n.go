@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the locale messages are written in, and the one used when no other matches
+const DefaultLocale = "en"
+
+// catalogFiles are the message catalogs, one JSON object per locale mapping English messages to their translation
+//
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+// catalogs holds the translations of each locale but the default one
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := catalogFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Errorf("couldn't read message catalogs: %w", err))
+	}
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := catalogFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Errorf("couldn't read message catalog %s: %w", entry.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Errorf("invalid message catalog %s: %w", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Supported returns the supported locales, sorted
+func Supported() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether messages can be translated to a locale
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok || locale == DefaultLocale
+}
+
+// Negotiate picks the supported locale the Accept-Language header prefers most, matching regional variants
+// like de-CH by their language. It returns "" when the header names no supported locale.
+func Negotiate(acceptLanguage string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if quality > bestQuality && IsSupported(language) {
+			best, bestQuality = language, quality
+		}
+	}
+	return best
+}
+
+// Translate returns the translation of a message to a locale, or the message itself when it has none
+func Translate(locale string, message string) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf translates a format and formats it. String arguments are translated too, so reasons composed of
+// several messages are translated as a whole. Without arguments the message is only translated, not formatted.
+func Sprintf(locale string, format string, args ...interface{}) string {
+	if len(args) == 0 {
+		return Translate(locale, format)
+	}
+	translated := make([]interface{}, len(args))
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			arg = Translate(locale, s)
+		}
+		translated[i] = arg
+	}
+	return fmt.Sprintf(Translate(locale, format), translated...)
+}
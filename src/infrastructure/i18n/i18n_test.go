@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"de", "de"},
+		{"de-CH,de;q=0.9", "de"},
+		{"ja, fr;q=0.5, es;q=0.7", "es"},
+		{"EN-us", "en"},
+		{"fr;q=bad, es;q=0.1", "es"},
+		{"ja", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Negotiate(tt.header), tt.header)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "Nicht berechtigt", Translate("de", "not authorized"))
+	assert.Equal(t, "not authorized", Translate("en", "not authorized"))
+	assert.Equal(t, "name of the list is taken", Translate("de", "name of the list is taken"))
+	assert.Equal(t, "not authorized", Translate("ja", "not authorized"))
+}
+
+func TestSprintf(t *testing.T) {
+	assert.Equal(t,
+		"Der Sendeplan des Providers ist geschlossen, Sperrzeit, Nachricht zurückgehalten bis 2026-10-17T00:00:00Z",
+		Sprintf("de", "provider schedule is closed, %s, message held until %s", "blackout", "2026-10-17T00:00:00Z"))
+	assert.Equal(t, "100% sent", Sprintf("fr", "100% sent"))
+}
+
+func TestSupported(t *testing.T) {
+	assert.Equal(t, []string{"de", "en", "es", "fr"}, Supported())
+	assert.True(t, IsSupported("fr"))
+	assert.False(t, IsSupported(""))
+}
+
+// TestCatalogs_Complete keeps the catalogs in step: every locale translates the same messages, with the same verbs
+func TestCatalogs_Complete(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	reference := catalogs["de"]
+	for locale, messages := range catalogs {
+		assert.Len(t, messages, len(reference), locale)
+		for message := range reference {
+			translated, ok := messages[message]
+			if assert.True(t, ok, "%s misses %q", locale, message) {
+				assert.Equal(t, verbs.FindAllString(message, -1), verbs.FindAllString(translated, -1), "%s: %q", locale, message)
+			}
+		}
+	}
+}
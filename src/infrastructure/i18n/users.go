@@ -0,0 +1,24 @@
+package i18n
+
+import (
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+)
+
+// UserLocales looks up the locale users chose, the fallback when a request or a webhook names none
+type UserLocales struct {
+	userRepository userRepo.UserRepositoryInterface
+}
+
+// NewUserLocales creates the lookup of the locales of users
+func NewUserLocales(userRepository userRepo.UserRepositoryInterface) *UserLocales {
+	return &UserLocales{userRepository: userRepository}
+}
+
+// UserLocale returns the locale of a user, the default locale when the user chose none or can't be found
+func (u *UserLocales) UserLocale(userID int) string {
+	user, err := u.userRepository.GetByID(userID)
+	if err != nil || !IsSupported(user.Locale) {
+		return DefaultLocale
+	}
+	return user.Locale
+}
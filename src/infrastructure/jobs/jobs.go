@@ -0,0 +1,342 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// Config controls the job workers of an instance
+type Config struct {
+	Workers      int
+	PollInterval time.Duration // how often idle workers look for queued jobs and running jobs send heartbeats
+	StaleAfter   time.Duration // a running job without heartbeat for this long is queued again
+	MaxAttempts  int
+	RetryDelay   time.Duration // delay before the first retry, doubled for every further attempt
+}
+
+// LoadConfig loads the job worker settings from environment variables
+func LoadConfig() (Config, error) {
+	workers, err := utils.GetIntEnv("JOB_WORKER_COUNT", 2)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid JOB_WORKER_COUNT: %w", err)
+	}
+	pollInterval, err := utils.GetIntEnv("JOB_POLL_INTERVAL_SECONDS", 5)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid JOB_POLL_INTERVAL_SECONDS: %w", err)
+	}
+	staleAfter, err := utils.GetIntEnv("JOB_STALE_AFTER_SECONDS", 60)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid JOB_STALE_AFTER_SECONDS: %w", err)
+	}
+	maxAttempts, err := utils.GetIntEnv("JOB_MAX_ATTEMPTS", 3)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid JOB_MAX_ATTEMPTS: %w", err)
+	}
+	retryDelay, err := utils.GetIntEnv("JOB_RETRY_DELAY_SECONDS", 30)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid JOB_RETRY_DELAY_SECONDS: %w", err)
+	}
+	if pollInterval <= 0 || staleAfter < 3*pollInterval {
+		return Config{}, fmt.Errorf("invalid JOB_STALE_AFTER_SECONDS: must be at least 3 times JOB_POLL_INTERVAL_SECONDS")
+	}
+	if maxAttempts < 1 {
+		return Config{}, fmt.Errorf("invalid JOB_MAX_ATTEMPTS: must be at least 1")
+	}
+	return Config{
+		Workers:      workers,
+		PollInterval: time.Duration(pollInterval) * time.Second,
+		StaleAfter:   time.Duration(staleAfter) * time.Second,
+		MaxAttempts:  maxAttempts,
+		RetryDelay:   time.Duration(retryDelay) * time.Second,
+	}, nil
+}
+
+// Handler runs a job of a type and returns its result, which is stored as JSON. A handler should return once ctx
+// is done, the job was then cancelled or the instance is shutting down.
+type Handler func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error)
+
+// Queue queues jobs for the workers
+type Queue interface {
+	Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error)
+}
+
+// permanentError marks an error retrying doesn't help with
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps an error so the job fails right away instead of being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Progress reports the progress of a running job
+type Progress struct {
+	runner          *Runner
+	job             *provider.Job
+	workerID        string
+	cancel          context.CancelFunc
+	cancelRequested atomic.Bool
+}
+
+// Report records how far the job is, in percent, with details stored as its result while it runs. A job that
+// was asked to stop is cancelled by the next report. A nil Progress drops the reports.
+func (p *Progress) Report(percent int, details interface{}) {
+	if p == nil {
+		return
+	}
+	percent = max(0, min(percent, 100))
+	result, err := json.Marshal(details)
+	if err != nil {
+		p.runner.Logger.Warn("Error encoding job progress", zap.Error(err), zap.Int("jobID", p.job.ID))
+		result = nil
+	}
+	p.heartbeat(percent, string(result))
+}
+
+func (p *Progress) heartbeat(percent int, result string) {
+	cancelRequested, err := p.runner.repository.Heartbeat(p.job.ID, p.workerID, percent, result)
+	if err != nil {
+		return
+	}
+	if cancelRequested && !p.cancelRequested.Swap(true) {
+		p.runner.Logger.Info("Cancelling job", zap.Int("jobID", p.job.ID))
+		p.cancel()
+	}
+}
+
+// Runner runs the queued jobs on a pool of workers. Jobs are claimed from the database, so the workers of every
+// instance share the queue and a job runs on one worker at a time.
+type Runner struct {
+	repository providerRepo.JobRepositoryInterface
+	config     Config
+	Logger     *logger.Logger
+	instanceID string
+
+	handlersMu sync.RWMutex
+	handlers   map[string]Handler
+
+	ctx      context.Context
+	stop     context.CancelFunc
+	wake     chan struct{}
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewRunner creates a new job runner, handlers are registered before it is started
+func NewRunner(repository providerRepo.JobRepositoryInterface, config Config, loggerInstance *logger.Logger) *Runner {
+	if config.Workers <= 0 {
+		config.Workers = 2 // Default to 2 workers if not specified
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = 12 * config.PollInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	hostname, _ := os.Hostname()
+	ctx, stop := context.WithCancel(context.Background())
+	return &Runner{
+		repository: repository,
+		config:     config,
+		Logger:     loggerInstance,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		handlers:   map[string]Handler{},
+		ctx:        ctx,
+		stop:       stop,
+		wake:       make(chan struct{}, 1),
+		shutdown:   make(chan struct{}),
+	}
+}
+
+// Register sets the handler running the jobs of a type
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.handlersMu.Lock()
+	defer r.handlersMu.Unlock()
+	r.handlers[jobType] = handler
+}
+
+func (r *Runner) handler(jobType string) Handler {
+	r.handlersMu.RLock()
+	defer r.handlersMu.RUnlock()
+	return r.handlers[jobType]
+}
+
+// Enqueue stores a job with its payload as JSON and wakes a worker of this instance
+func (r *Runner) Enqueue(jobType string, payload interface{}, createdBy int) (*provider.Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job, err := r.repository.Create(&provider.Job{
+		Type:        jobType,
+		Payload:     string(payloadJSON),
+		Status:      providerRepo.JobStatusQueued,
+		MaxAttempts: r.config.MaxAttempts,
+		RunAfter:    time.Now(),
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start starts the workers
+func (r *Runner) Start() {
+	r.Logger.Info("Starting job workers", zap.Int("workers", r.config.Workers), zap.Duration("pollInterval", r.config.PollInterval))
+	for i := 0; i < r.config.Workers; i++ {
+		r.wg.Add(1)
+		go r.worker(fmt.Sprintf("%s-%d", r.instanceID, i))
+	}
+}
+
+func (r *Runner) worker(workerID string) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.runQueuedJobs(workerID)
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-r.shutdown:
+			return
+		}
+	}
+}
+
+// runQueuedJobs runs queued jobs until there is none left, after queuing the jobs of stopped workers again
+func (r *Runner) runQueuedJobs(workerID string) {
+	if _, err := r.repository.RequeueStale(time.Now().Add(-r.config.StaleAfter)); err != nil {
+		r.Logger.Error("Error recovering stale jobs", zap.Error(err))
+	}
+	for r.ctx.Err() == nil {
+		job, err := r.repository.Claim(workerID, time.Now())
+		if err != nil || job == nil {
+			return
+		}
+		r.run(workerID, job)
+	}
+}
+
+// run runs a claimed job and records its outcome. Failed attempts are retried with an exponential backoff until
+// the job used up its attempts, a job interrupted by a shutdown is queued again right away.
+func (r *Runner) run(workerID string, job *provider.Job) {
+	r.Logger.Info("Running job", zap.Int("jobID", job.ID), zap.String("type", job.Type), zap.Int("attempt", job.Attempts))
+	handler := r.handler(job.Type)
+	if handler == nil {
+		r.finish(job, workerID, providerRepo.JobStatusFailed, nil, fmt.Errorf("no handler for job type %s", job.Type))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+	progress := &Progress{runner: r, job: job, workerID: workerID, cancel: cancel}
+
+	// Send heartbeats while the job runs, they also pick up cancellation requests
+	heartbeatDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress.heartbeat(-1, "")
+			case <-heartbeatDone:
+				return
+			}
+		}
+	}()
+	result, err := r.safeRun(ctx, handler, job, progress)
+	close(heartbeatDone)
+
+	var permanent *permanentError
+	switch {
+	case progress.cancelRequested.Load():
+		r.finish(job, workerID, providerRepo.JobStatusCancelled, result, nil)
+	case err == nil:
+		r.finish(job, workerID, providerRepo.JobStatusCompleted, result, nil)
+	case r.ctx.Err() != nil:
+		r.reschedule(job, workerID, errors.New("interrupted by a shutdown"), time.Now())
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		r.finish(job, workerID, providerRepo.JobStatusFailed, result, err)
+	default:
+		r.reschedule(job, workerID, err, time.Now().Add(r.config.RetryDelay<<(job.Attempts-1)))
+	}
+}
+
+// safeRun turns a panicking handler into a failed attempt
+func (r *Runner) safeRun(ctx context.Context, handler Handler, job *provider.Job, progress *Progress) (result interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.Logger.Error("Job handler panicked", zap.Int("jobID", job.ID), zap.Any("panic", p))
+			err = fmt.Errorf("job handler panicked: %v", p)
+		}
+	}()
+	return handler(ctx, job, progress)
+}
+
+func (r *Runner) finish(job *provider.Job, workerID string, status string, result interface{}, runErr error) {
+	var resultJSON []byte
+	if result != nil {
+		var err error
+		if resultJSON, err = json.Marshal(result); err != nil {
+			r.Logger.Warn("Error encoding job result", zap.Error(err), zap.Int("jobID", job.ID))
+		}
+	}
+	errorMessage := ""
+	if runErr != nil {
+		errorMessage = runErr.Error()
+		r.Logger.Error("Job failed", zap.Error(runErr), zap.Int("jobID", job.ID), zap.String("type", job.Type))
+	} else {
+		r.Logger.Info("Job finished", zap.Int("jobID", job.ID), zap.String("type", job.Type), zap.String("status", status))
+	}
+	if err := r.repository.Finish(job.ID, workerID, status, string(resultJSON), errorMessage); err != nil {
+		r.Logger.Error("Error recording job outcome", zap.Error(err), zap.Int("jobID", job.ID))
+	}
+}
+
+func (r *Runner) reschedule(job *provider.Job, workerID string, runErr error, runAfter time.Time) {
+	r.Logger.Warn("Job attempt failed, retrying", zap.Error(runErr), zap.Int("jobID", job.ID),
+		zap.Int("attempt", job.Attempts), zap.Time("runAfter", runAfter))
+	if err := r.repository.Reschedule(job.ID, workerID, runErr.Error(), runAfter); err != nil {
+		r.Logger.Error("Error rescheduling job", zap.Error(err), zap.Int("jobID", job.ID))
+	}
+}
+
+// Shutdown stops the workers, running jobs are interrupted and queued again
+func (r *Runner) Shutdown() {
+	close(r.shutdown)
+	r.stop()
+	r.wg.Wait()
+}
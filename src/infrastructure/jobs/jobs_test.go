@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJobRepository struct {
+	providerRepo.JobRepositoryInterface
+	cancelRequested bool
+	heartbeats      []int
+	status          string
+	result          string
+	errorMessage    string
+	runAfter        time.Time
+}
+
+func (m *mockJobRepository) Heartbeat(id int, workerID string, progress int, result string) (bool, error) {
+	m.heartbeats = append(m.heartbeats, progress)
+	return m.cancelRequested, nil
+}
+
+func (m *mockJobRepository) Finish(id int, workerID string, status string, result string, errorMessage string) error {
+	m.status = status
+	m.result = result
+	m.errorMessage = errorMessage
+	return nil
+}
+
+func (m *mockJobRepository) Reschedule(id int, workerID string, errorMessage string, runAfter time.Time) error {
+	m.status = providerRepo.JobStatusQueued
+	m.errorMessage = errorMessage
+	m.runAfter = runAfter
+	return nil
+}
+
+func setupRunner(t *testing.T, repository *mockJobRepository) *Runner {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return NewRunner(repository, Config{Workers: 1, PollInterval: time.Hour, MaxAttempts: 3, RetryDelay: time.Minute}, loggerInstance)
+}
+
+func claimedJob(attempts int) *provider.Job {
+	return &provider.Job{ID: 1, Type: "test", Status: providerRepo.JobStatusRunning, Attempts: attempts, MaxAttempts: 3}
+}
+
+func TestRun_Completes(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		progress.Report(50, map[string]int{"done": 1})
+		return map[string]int{"done": 2}, nil
+	})
+
+	runner.run("worker", claimedJob(1))
+	assert.Equal(t, providerRepo.JobStatusCompleted, repository.status)
+	assert.JSONEq(t, `{"done":2}`, repository.result)
+	assert.Equal(t, []int{50}, repository.heartbeats)
+}
+
+func TestRun_RetriesWithBackoff(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		return nil, errors.New("provider unavailable")
+	})
+
+	before := time.Now()
+	runner.run("worker", claimedJob(2))
+	assert.Equal(t, providerRepo.JobStatusQueued, repository.status)
+	assert.Equal(t, "provider unavailable", repository.errorMessage)
+	assert.WithinDuration(t, before.Add(2*time.Minute), repository.runAfter, time.Second)
+}
+
+func TestRun_FailsAfterLastAttempt(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		return nil, errors.New("provider unavailable")
+	})
+
+	runner.run("worker", claimedJob(3))
+	assert.Equal(t, providerRepo.JobStatusFailed, repository.status)
+	assert.Equal(t, "provider unavailable", repository.errorMessage)
+}
+
+func TestRun_PermanentErrorFailsAtOnce(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		return nil, Permanent(errors.New("invalid payload"))
+	})
+
+	runner.run("worker", claimedJob(1))
+	assert.Equal(t, providerRepo.JobStatusFailed, repository.status)
+	assert.Equal(t, "invalid payload", repository.errorMessage)
+}
+
+func TestRun_UnknownTypeFails(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+
+	runner.run("worker", claimedJob(1))
+	assert.Equal(t, providerRepo.JobStatusFailed, repository.status)
+	assert.Equal(t, "no handler for job type test", repository.errorMessage)
+}
+
+func TestRun_CancelledThroughHeartbeat(t *testing.T) {
+	repository := &mockJobRepository{cancelRequested: true}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		progress.Report(10, nil)
+		<-ctx.Done()
+		return map[string]int{"done": 1}, ctx.Err()
+	})
+
+	runner.run("worker", claimedJob(1))
+	assert.Equal(t, providerRepo.JobStatusCancelled, repository.status)
+	assert.JSONEq(t, `{"done":1}`, repository.result)
+	assert.Empty(t, repository.errorMessage)
+}
+
+func TestRun_PanicIsFailedAttempt(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		panic("boom")
+	})
+
+	runner.run("worker", claimedJob(1))
+	assert.Equal(t, providerRepo.JobStatusQueued, repository.status)
+	assert.Equal(t, "job handler panicked: boom", repository.errorMessage)
+}
+
+func TestRun_ShutdownQueuesAgain(t *testing.T) {
+	repository := &mockJobRepository{}
+	runner := setupRunner(t, repository)
+	runner.Register("test", func(ctx context.Context, job *provider.Job, progress *Progress) (interface{}, error) {
+		runner.stop()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	before := time.Now()
+	runner.run("worker", claimedJob(3))
+	assert.Equal(t, providerRepo.JobStatusQueued, repository.status)
+	assert.WithinDuration(t, before, repository.runAfter, time.Second)
+}
@@ -0,0 +1,180 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// lockName is the MySQL advisory lock held by the instance running the background jobs
+const lockName = "go-multi-chat-api:background-jobs"
+
+// Elector decides whether this instance runs the periodic background jobs. Queue consumption is not
+// affected, every instance processes the messages queued to it.
+type Elector interface {
+	IsLeader() bool
+	Shutdown()
+}
+
+// Config holds the leader election configuration
+type Config struct {
+	// Type is the election backend, mysql or empty to let every instance run the jobs
+	Type     string
+	Interval time.Duration
+}
+
+// LoadConfig loads the leader election configuration from environment variables
+func LoadConfig() (Config, error) {
+	interval, err := utils.GetIntEnv("LEADER_ELECTION_INTERVAL_SECONDS", 10)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid LEADER_ELECTION_INTERVAL_SECONDS: %w", err)
+	}
+	return Config{
+		Type:     strings.ToLower(utils.GetEnv("LEADER_ELECTION", "")),
+		Interval: time.Duration(interval) * time.Second,
+	}, nil
+}
+
+// NewElector creates the elector for the configured type
+func NewElector(config Config, db *sql.DB, loggerInstance *logger.Logger) (Elector, error) {
+	switch config.Type {
+	case "":
+		return AlwaysLeader{}, nil
+	case "mysql":
+		return NewMySQLElector(db, config.Interval, loggerInstance), nil
+	default:
+		return nil, fmt.Errorf("unsupported leader election type: %s", config.Type)
+	}
+}
+
+// AlwaysLeader is used when leader election is disabled, e.g. for a single instance deployment
+type AlwaysLeader struct{}
+
+func (AlwaysLeader) IsLeader() bool { return true }
+
+func (AlwaysLeader) Shutdown() {}
+
+// MySQLElector elects the leader with a MySQL advisory lock. Advisory locks belong to a session, so the lock
+// is held on a dedicated connection and released by the server as soon as that connection is gone, which
+// lets another instance take over on its next attempt.
+type MySQLElector struct {
+	db       *sql.DB
+	Logger   *logger.Logger
+	interval time.Duration
+	conn     *sql.Conn
+	leader   atomic.Bool
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewMySQLElector creates a new MySQL elector and starts campaigning. The first attempt is made before
+// returning, so a single instance is leader right away.
+func NewMySQLElector(db *sql.DB, interval time.Duration, loggerInstance *logger.Logger) *MySQLElector {
+	if interval <= 0 {
+		interval = 10 * time.Second // Default to 10 seconds if not specified
+	}
+
+	elector := &MySQLElector{
+		db:       db,
+		Logger:   loggerInstance,
+		interval: interval,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	elector.campaign()
+	go elector.run()
+
+	return elector
+}
+
+func (e *MySQLElector) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign()
+		case <-e.shutdown:
+			return
+		}
+	}
+}
+
+// campaign tries to acquire the lock, or checks that it is still held when this instance is the leader
+func (e *MySQLElector) campaign() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if e.conn == nil {
+		conn, err := e.db.Conn(ctx)
+		if err != nil {
+			e.Logger.Error("Error opening leader election connection", zap.Error(err))
+			e.setLeader(false)
+			return
+		}
+		e.conn = conn
+	}
+
+	query := "SELECT GET_LOCK(?, 0)"
+	if e.leader.Load() {
+		query = "SELECT IS_USED_LOCK(?) = CONNECTION_ID()"
+	}
+
+	var held sql.NullInt64
+	if err := e.conn.QueryRowContext(ctx, query, lockName).Scan(&held); err != nil {
+		// The lock is gone with a broken connection, start over with a new one
+		e.Logger.Error("Error campaigning for leadership", zap.Error(err))
+		e.conn.Close()
+		e.conn = nil
+		e.setLeader(false)
+		return
+	}
+	e.setLeader(held.Valid && held.Int64 == 1)
+}
+
+func (e *MySQLElector) setLeader(leader bool) {
+	if e.leader.Swap(leader) != leader {
+		if leader {
+			e.Logger.Info("Acquired leadership, running background jobs on this instance")
+		} else {
+			e.Logger.Warn("Lost leadership, background jobs stop on this instance")
+		}
+	}
+}
+
+// IsLeader returns whether this instance currently holds the lock
+func (e *MySQLElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Shutdown stops campaigning and releases the lock so another instance takes over immediately
+func (e *MySQLElector) Shutdown() {
+	close(e.shutdown)
+	<-e.done
+
+	if e.conn == nil {
+		return
+	}
+	if e.leader.Load() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := e.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			e.Logger.Error("Error releasing leadership", zap.Error(err))
+		}
+	}
+	e.leader.Store(false)
+	e.conn.Close()
+	e.conn = nil
+}
@@ -0,0 +1,68 @@
+package leader
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestElector(t *testing.T) (*MySQLElector, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return &MySQLElector{db: db, Logger: loggerInstance}, mock
+}
+
+func TestMySQLElector_Campaign(t *testing.T) {
+	elector, mock := newTestElector(t)
+
+	// Another instance holds the lock
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(lockName).WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(0))
+	elector.campaign()
+	assert.False(t, elector.IsLeader())
+
+	// The lock was released and is acquired on the next attempt
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(lockName).WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+	elector.campaign()
+	assert.True(t, elector.IsLeader())
+
+	// The leader only verifies it still holds the lock
+	mock.ExpectQuery("SELECT IS_USED_LOCK").WithArgs(lockName).WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+	elector.campaign()
+	assert.True(t, elector.IsLeader())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLElector_LosesLeadershipOnConnectionError(t *testing.T) {
+	elector, mock := newTestElector(t)
+
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(lockName).WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+	elector.campaign()
+	assert.True(t, elector.IsLeader())
+
+	mock.ExpectQuery("SELECT IS_USED_LOCK").WithArgs(lockName).WillReturnError(driver.ErrBadConn)
+	elector.campaign()
+	assert.False(t, elector.IsLeader())
+	assert.Nil(t, elector.conn)
+}
+
+func TestNewElector(t *testing.T) {
+	elector, err := NewElector(Config{}, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, elector.IsLeader())
+
+	_, err = NewElector(Config{Type: "redis"}, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,282 @@
+package line
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the LINE Messaging API
+	DefaultAPIURL = "https://api.line.me"
+
+	// maxTextLength is the longest text of a LINE text message, longer messages are split
+	maxTextLength = 5000
+	// maxMessagesPerPush is the most messages a single push request carries
+	maxMessagesPerPush = 5
+	// maxQuickReplies is the most quick reply buttons of a message
+	maxQuickReplies = 13
+)
+
+// recipientPattern matches the ids of LINE users (U), groups (C) and multi-person chats (R)
+var recipientPattern = regexp.MustCompile(`^[UCR][0-9a-f]{32}$`)
+
+// Config is the LINE official account of a provider, stored in the provider config
+type Config struct {
+	ChannelAccessToken string `json:"channel_access_token"`
+	// ChannelSecret verifies the webhook events of the channel, only needed to receive quick reply postbacks
+	ChannelSecret string `json:"channel_secret"`
+}
+
+// ParseConfig reads the LINE official account from the config of a provider
+func ParseConfig(providerConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid line config: %w", err)
+		}
+	}
+	if config.ChannelAccessToken == "" {
+		return Config{}, errors.New("line config needs a channel_access_token")
+	}
+	return config, nil
+}
+
+// Error is an error answered by the Messaging API
+type Error struct {
+	StatusCode int
+	Message    string `json:"message"`
+	Details    []struct {
+		Message  string `json:"message"`
+		Property string `json:"property"`
+	} `json:"details"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("line answered %d", e.StatusCode)
+	}
+	message := fmt.Sprintf("line answered %d: %s", e.StatusCode, e.Message)
+	for _, detail := range e.Details {
+		message += fmt.Sprintf("; %s: %s", detail.Property, detail.Message)
+	}
+	return message
+}
+
+// ErrorCode classifies an error of a push by the status the Messaging API answered with, it answers 400 to pushes
+// to users who don't exist or never added the channel. It returns an empty code for other errors.
+func ErrorCode(err error) string {
+	var lineErr *Error
+	if !errors.As(err, &lineErr) {
+		return ""
+	}
+	switch {
+	case lineErr.StatusCode == http.StatusBadRequest || lineErr.StatusCode == http.StatusNotFound:
+		return domainProvider.ErrorCodeInvalidRecipient
+	case lineErr.StatusCode == http.StatusUnauthorized || lineErr.StatusCode == http.StatusForbidden:
+		return domainProvider.ErrorCodeAuthFailed
+	case lineErr.StatusCode == http.StatusTooManyRequests:
+		return domainProvider.ErrorCodeRateLimited
+	case lineErr.StatusCode >= 500:
+		return domainProvider.ErrorCodeNetwork
+	}
+	return domainProvider.ErrorCodeUnknown
+}
+
+// SendResult is the messages a text was pushed as to one recipient
+type SendResult struct {
+	Recipient  string   `json:"recipient"`
+	MessageIDs []string `json:"message_ids"`
+}
+
+// QuickReply is a quick reply button shown below a message, LINE posts a tap on it to the webhook of the channel as
+// a postback event with its data
+type QuickReply struct {
+	Label string
+	Data  string
+}
+
+// Client pushes messages through the Messaging API of LINE official accounts
+type Client struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewClient creates a new client calling the Messaging API at apiURL
+func NewClient(apiURL string, timeout time.Duration) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		client: httpclient.New(timeout),
+	}
+}
+
+// Send pushes a text to every recipient, a user, group or chat id. It stops at the first recipient the text
+// couldn't be pushed to and returns the messages pushed until then.
+func (c *Client) Send(config Config, recipients []string, text string) ([]SendResult, error) {
+	messages, err := textMessages(text)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(config, recipients, messages)
+}
+
+// SendWithQuickReplies pushes a text like Send, with quick reply buttons below its last message
+func (c *Client) SendWithQuickReplies(config Config, recipients []string, text string, replies []QuickReply) ([]SendResult, error) {
+	if len(replies) > maxQuickReplies {
+		return nil, fmt.Errorf("line shows at most %d quick replies", maxQuickReplies)
+	}
+	messages, err := textMessages(text)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]quickReplyItem, len(replies))
+	for i, reply := range replies {
+		items[i] = quickReplyItem{Type: "action", Action: postbackAction{Type: "postback", Label: reply.Label, Data: reply.Data, DisplayText: reply.Label}}
+	}
+	messages[len(messages)-1].QuickReply = &quickReply{Items: items}
+	return c.send(config, recipients, messages)
+}
+
+// send pushes the messages to every recipient
+func (c *Client) send(config Config, recipients []string, messages []textMessage) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		if !recipientPattern.MatchString(recipient) {
+			return results, &domainProvider.RecipientError{Description: fmt.Sprintf("recipient %q is not a line user, group or chat id", recipient)}
+		}
+		messageIDs, err := c.push(config, recipient, messages)
+		if err != nil {
+			return results, fmt.Errorf("couldn't send to %s: %w", recipient, err)
+		}
+		results = append(results, SendResult{Recipient: recipient, MessageIDs: messageIDs})
+	}
+	return results, nil
+}
+
+type textMessage struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text"`
+	QuickReply *quickReply `json:"quickReply,omitempty"`
+}
+
+// quickReply holds the quick reply buttons of a message, see
+// https://developers.line.biz/en/reference/messaging-api/#quick-reply
+type quickReply struct {
+	Items []quickReplyItem `json:"items"`
+}
+
+type quickReplyItem struct {
+	Type   string         `json:"type"`
+	Action postbackAction `json:"action"`
+}
+
+// postbackAction posts its data to the webhook on a tap, and shows the display text as the reply of the user
+type postbackAction struct {
+	Type        string `json:"type"`
+	Label       string `json:"label"`
+	Data        string `json:"data"`
+	DisplayText string `json:"displayText,omitempty"`
+}
+
+// textMessages splits a text into the text messages of one push request
+func textMessages(text string) ([]textMessage, error) {
+	runes := []rune(text)
+	var messages []textMessage
+	for len(runes) > 0 || len(messages) == 0 {
+		n := len(runes)
+		if n > maxTextLength {
+			n = maxTextLength
+		}
+		messages = append(messages, textMessage{Type: "text", Text: string(runes[:n])})
+		runes = runes[n:]
+	}
+	if len(messages) > maxMessagesPerPush {
+		return nil, fmt.Errorf("message is longer than the %d characters line takes in one push", maxTextLength*maxMessagesPerPush)
+	}
+	return messages, nil
+}
+
+// VerifyCredentials fetches the bot info of the official account, which fails for a wrong or expired channel
+// access token
+func (c *Client) VerifyCredentials(config Config) error {
+	request, err := http.NewRequest(http.MethodGet, c.apiURL+"/v2/bot/info", nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+config.ChannelAccessToken)
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		lineErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, lineErr)
+		return lineErr
+	}
+	return nil
+}
+
+func (c *Client) push(config Config, recipient string, messages []textMessage) ([]string, error) {
+	payload, err := json.Marshal(map[string]interface{}{"to": recipient, "messages": messages})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, c.apiURL+"/v2/bot/message/push", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+config.ChannelAccessToken)
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		lineErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, lineErr)
+		return nil, lineErr
+	}
+
+	var result struct {
+		SentMessages []struct {
+			ID string `json:"id"`
+		} `json:"sentMessages"`
+	}
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return nil, err
+	}
+	messageIDs := make([]string, len(result.SentMessages))
+	for i, sentMessage := range result.SentMessages {
+		messageIDs[i] = sentMessage.ID
+	}
+	return messageIDs, nil
+}
@@ -0,0 +1,128 @@
+package line
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecipient = "U4af4980629a0ed6a4d2d8f2a8e5a1f1b"
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{"channel_access_token":"secret","warmup":{"enabled":false}}`)
+	require.NoError(t, err)
+	assert.Equal(t, Config{ChannelAccessToken: "secret"}, config)
+
+	_, err = ParseConfig("")
+	assert.Error(t, err)
+}
+
+func TestTextMessages(t *testing.T) {
+	messages, err := textMessages("hello")
+	require.NoError(t, err)
+	assert.Equal(t, []textMessage{{Type: "text", Text: "hello"}}, messages)
+
+	messages, err = textMessages(strings.Repeat("a", maxTextLength+1))
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Len(t, messages[0].Text, maxTextLength)
+	assert.Equal(t, "a", messages[1].Text)
+
+	_, err = textMessages(strings.Repeat("a", maxTextLength*maxMessagesPerPush+1))
+	assert.Error(t, err)
+}
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/bot/message/push", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var body struct {
+			To       string        `json:"to"`
+			Messages []textMessage `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, testRecipient, body.To)
+		assert.Equal(t, []textMessage{{Type: "text", Text: "hello"}}, body.Messages)
+		_, _ = w.Write([]byte(`{"sentMessages":[{"id":"461230966842064897","quoteToken":"token"}]}`))
+	}))
+	defer server.Close()
+
+	results, err := NewClient(server.URL, time.Second).Send(Config{ChannelAccessToken: "secret"}, []string{testRecipient}, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []SendResult{{Recipient: testRecipient, MessageIDs: []string{"461230966842064897"}}}, results)
+}
+
+func TestClient_SendWithQuickReplies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []textMessage `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Messages, 1)
+		require.NotNil(t, body.Messages[0].QuickReply)
+		assert.Equal(t, []quickReplyItem{
+			{Type: "action", Action: postbackAction{Type: "postback", Label: "Approve", Data: "action:42:approve", DisplayText: "Approve"}},
+		}, body.Messages[0].QuickReply.Items)
+		_, _ = w.Write([]byte(`{"sentMessages":[{"id":"461230966842064897"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	_, err := client.SendWithQuickReplies(Config{ChannelAccessToken: "secret"}, []string{testRecipient}, "Deploy?",
+		[]QuickReply{{Label: "Approve", Data: "action:42:approve"}})
+	require.NoError(t, err)
+
+	_, err = client.SendWithQuickReplies(Config{ChannelAccessToken: "secret"}, []string{testRecipient}, "Deploy?", make([]QuickReply, maxQuickReplies+1))
+	assert.Error(t, err)
+}
+
+func TestClient_SendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message":"The request body has 1 error(s)","details":[{"message":"May not be empty","property":"messages[0].text"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	_, err := client.Send(Config{ChannelAccessToken: "secret"}, []string{testRecipient}, "hello")
+	var lineErr *Error
+	require.True(t, errors.As(err, &lineErr))
+	assert.Equal(t, http.StatusBadRequest, lineErr.StatusCode)
+	assert.Contains(t, err.Error(), "messages[0].text: May not be empty")
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(err))
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(&Error{StatusCode: http.StatusUnauthorized}))
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(&Error{StatusCode: http.StatusTooManyRequests}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&Error{StatusCode: http.StatusInternalServerError}))
+
+	_, err = client.Send(Config{ChannelAccessToken: "secret"}, []string{"+4912345"}, "hello")
+	assert.EqualError(t, err, `recipient "+4912345" is not a line user, group or chat id`)
+}
+
+func TestClient_VerifyCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/v2/bot/info", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Authentication failed. Confirm that the access token in the authorization header is valid."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"userId":"U4af4980629","basicId":"@216ru0sd","displayName":"Bot"}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, time.Second)
+
+	assert.NoError(t, client.VerifyCredentials(Config{ChannelAccessToken: "secret"}))
+	err := client.VerifyCredentials(Config{ChannelAccessToken: "expired"})
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(err))
+}
@@ -0,0 +1,105 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+)
+
+// SignatureHeader carries the signature of the webhook events LINE posts
+const SignatureHeader = "X-Line-Signature"
+
+// ErrInvalidSignature is returned for webhook events that weren't signed with the secret of the channel
+var ErrInvalidSignature = errors.New("invalid line signature")
+
+// ValidSignature reports whether webhook events were posted by LINE, the signature is the base64 encoded
+// HMAC-SHA256 of the raw body keyed with the channel secret
+func ValidSignature(channelSecret string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// webhookEvent is the part of a webhook event read for postbacks, see
+// https://developers.line.biz/en/reference/messaging-api/#postback-event
+type webhookEvent struct {
+	Type     string `json:"type"`
+	Postback struct {
+		Data string `json:"data"`
+	} `json:"postback"`
+	Source struct {
+		Type    string `json:"type"`
+		UserID  string `json:"userId"`
+		GroupID string `json:"groupId"`
+		RoomID  string `json:"roomId"`
+	} `json:"source"`
+}
+
+// recipient is the user, group or chat the message with the tapped quick reply was pushed to
+func (e webhookEvent) recipient() string {
+	switch e.Source.Type {
+	case "group":
+		return e.Source.GroupID
+	case "room":
+		return e.Source.RoomID
+	}
+	return e.Source.UserID
+}
+
+// Postbacks reads the webhook events LINE posts for the quick replies of messages pushed by a provider
+type Postbacks struct{}
+
+// NewPostbacks creates a new reader of LINE webhook events
+func NewPostbacks() *Postbacks {
+	return &Postbacks{}
+}
+
+// ParseInteraction verifies webhook events with the channel secret of the provider and returns the actions chosen
+// by their postbacks. Other events, like the messages users send, are ignored.
+func (p *Postbacks) ParseInteraction(providerConfig string, header http.Header, body []byte) ([]domainProvider.ActionChoice, []byte, error) {
+	var config Config
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &config); err != nil {
+			return nil, nil, fmt.Errorf("invalid line config: %w", err)
+		}
+	}
+	if config.ChannelSecret == "" {
+		return nil, nil, errors.New("line config needs a channel_secret to verify webhook events")
+	}
+	if !ValidSignature(config.ChannelSecret, body, header.Get(SignatureHeader)) {
+		return nil, nil, domainErrors.NewAppError(ErrInvalidSignature, domainErrors.NotAuthenticated)
+	}
+
+	var payload struct {
+		Events []webhookEvent `json:"events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, nil, domainErrors.NewAppError(fmt.Errorf("invalid line webhook events: %w", err), domainErrors.ValidationError)
+	}
+	var choices []domainProvider.ActionChoice
+	for _, event := range payload.Events {
+		if event.Type != "postback" {
+			continue
+		}
+		messageID, actionID, ok := domainProvider.ParseActionData(event.Postback.Data)
+		if !ok {
+			continue
+		}
+		choices = append(choices, domainProvider.ActionChoice{
+			MessageTransactionID: messageID,
+			ActionID:             actionID,
+			Recipient:            event.recipient(),
+			ChosenBy:             event.Source.UserID,
+		})
+	}
+	return choices, []byte("{}"), nil
+}
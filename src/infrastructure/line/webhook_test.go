@@ -0,0 +1,56 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `{"channel_access_token":"token","channel_secret":"secret"}`
+
+// signedEvents signs webhook events like LINE does with the channel secret of testConfig
+func signedEvents(body string) http.Header {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	header := http.Header{}
+	header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestPostbacks_ReadsQuickReplyPostbacks(t *testing.T) {
+	body := `{"destination":"U0","events":[
+		{"type":"message","message":{"type":"text","text":"hi"},"source":{"type":"user","userId":"` + testRecipient + `"}},
+		{"type":"postback","postback":{"data":"action:42:approve"},"source":{"type":"user","userId":"` + testRecipient + `"}},
+		{"type":"postback","postback":{"data":"action:43:reject"},"source":{"type":"group","groupId":"C4af4980629a0ed6a4d2d8f2a8e5a1f1b","userId":"` + testRecipient + `"}},
+		{"type":"postback","postback":{"data":"menu=1"},"source":{"type":"user","userId":"` + testRecipient + `"}}
+	]}`
+
+	choices, response, err := NewPostbacks().ParseInteraction(testConfig, signedEvents(body), []byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(response))
+	assert.Equal(t, []domainProvider.ActionChoice{
+		{MessageTransactionID: 42, ActionID: "approve", Recipient: testRecipient, ChosenBy: testRecipient},
+		{MessageTransactionID: 43, ActionID: "reject", Recipient: "C4af4980629a0ed6a4d2d8f2a8e5a1f1b", ChosenBy: testRecipient},
+	}, choices)
+}
+
+func TestPostbacks_RejectsInvalidSignatures(t *testing.T) {
+	body := `{"events":[]}`
+
+	_, _, err := NewPostbacks().ParseInteraction(testConfig, signedEvents(`{"events":[{}]}`), []byte(body))
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+
+	_, _, err = NewPostbacks().ParseInteraction(`{"channel_access_token":"token"}`, signedEvents(body), []byte(body))
+	assert.Error(t, err)
+}
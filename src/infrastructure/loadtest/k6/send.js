@@ -0,0 +1,33 @@
+// k6 run -e TOKEN=... -e BASE_URL=http://localhost:8080 src/infrastructure/loadtest/k6/send.js
+// Sends messages through the sandbox provider type at a constant rate, see docs/messaging.md
+import http from 'k6/http';
+import { check } from 'k6';
+
+export const options = {
+  scenarios: {
+    send: {
+      executor: 'constant-arrival-rate',
+      rate: Number(__ENV.RATE || 100),
+      timeUnit: '1s',
+      duration: __ENV.DURATION || '1m',
+      preAllocatedVUs: Number(__ENV.VUS || 50),
+    },
+  },
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(99)<500'],
+  },
+};
+
+const body = JSON.stringify({
+  type: __ENV.PROVIDER_TYPE || 'sandbox',
+  message: 'Load test message',
+  recipients: ['+10000000000'],
+});
+
+export default function () {
+  const response = http.post(`${__ENV.BASE_URL || 'http://localhost:8080'}/v1/send/message`, body, {
+    headers: { 'Content-Type': 'application/json', Authorization: `Bearer ${__ENV.TOKEN}` },
+  });
+  check(response, { accepted: (r) => r.status === 202 });
+}
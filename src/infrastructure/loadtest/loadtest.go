@@ -0,0 +1,218 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Config describes a load test of an endpoint: the same request is sent for the duration, at the rate or as
+// fast as the workers go
+type Config struct {
+	// URL is the endpoint the requests are posted to, e.g. http://localhost:8080/v1/send/message
+	URL string
+	// Token is sent as the bearer token of every request
+	Token string
+	// Body is the JSON body of every request
+	Body []byte
+	// Rate is the number of requests started per second, 0 sends as fast as the workers go
+	Rate int
+	// Workers is the number of requests in flight at most, 0 uses 10
+	Workers int
+	// Duration is how long requests are started for
+	Duration time.Duration
+	// Timeout caps each request, 0 uses 30 seconds
+	Timeout time.Duration
+}
+
+// Latencies are the latencies of the requests answered
+type Latencies struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Result summarizes a load test
+type Result struct {
+	Requests int `json:"requests"`
+	// Succeeded counts the requests answered with a 2xx status
+	Succeeded int `json:"succeeded"`
+	// StatusCodes counts the requests by the status of their answer
+	StatusCodes map[int]int `json:"status_codes"`
+	// Errors counts the requests that got no answer by their error
+	Errors   map[string]int `json:"errors,omitempty"`
+	Duration time.Duration  `json:"duration"`
+	// Throughput is the number of requests answered with a 2xx status per second
+	Throughput float64   `json:"throughput"`
+	Latencies  Latencies `json:"latencies"`
+}
+
+type sample struct {
+	status  int
+	err     error
+	latency time.Duration
+}
+
+// Run sends the requests of the config until its duration passes or the context is done, then waits for the
+// requests in flight and returns their summary
+func Run(ctx context.Context, client *http.Client, config Config) (*Result, error) {
+	if config.URL == "" {
+		return nil, errors.New("the URL to load is required")
+	}
+	if config.Duration <= 0 {
+		return nil, errors.New("the duration must be positive")
+	}
+	if config.Rate < 0 {
+		return nil, errors.New("the rate must not be negative")
+	}
+	if config.Workers <= 0 {
+		config.Workers = 10
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+
+	// The ticks start the requests at the rate, without a rate the workers take the next request right away
+	var ticks <-chan time.Time
+	if config.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(config.Rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	samples := make(chan sample, config.Workers)
+	var wg sync.WaitGroup
+	started := time.Now()
+	for i := 0; i < config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if ticks != nil {
+					select {
+					case <-ticks:
+					case <-ctx.Done():
+						return
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+				samples <- send(client, config)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	result := &Result{StatusCodes: map[int]int{}, Errors: map[string]int{}}
+	var latencies []time.Duration
+	for s := range samples {
+		result.Requests++
+		if s.err != nil {
+			result.Errors[s.err.Error()]++
+			continue
+		}
+		result.StatusCodes[s.status]++
+		if s.status >= 200 && s.status < 300 {
+			result.Succeeded++
+		}
+		latencies = append(latencies, s.latency)
+	}
+	result.Duration = time.Since(started)
+	result.Throughput = float64(result.Succeeded) / result.Duration.Seconds()
+	result.Latencies = summarize(latencies)
+	return result, nil
+}
+
+// send posts the request of the config once. Requests run out on their own timeout, not on the end of the
+// test, so the requests in flight at the end are still measured.
+func send(client *http.Client, config Config) sample {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(config.Body))
+	if err != nil {
+		return sample{err: err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return sample{err: err}
+	}
+	// Read the whole answer so the connection is reused
+	_, _ = io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+	return sample{status: response.StatusCode, latency: time.Since(start)}
+}
+
+// summarize returns the min, mean, percentiles and max of the latencies
+func summarize(latencies []time.Duration) Latencies {
+	if len(latencies) == 0 {
+		return Latencies{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	return Latencies{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(latencies, 50),
+		P90:  percentile(latencies, 90),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// percentile returns the latency below which the given percent of the sorted latencies fall
+func percentile(sorted []time.Duration, percent int) time.Duration {
+	index := (len(sorted)*percent+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// Write writes the result as a text report
+func (r *Result) Write(w io.Writer) error {
+	codes := make([]int, 0, len(r.StatusCodes))
+	for code := range r.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var report bytes.Buffer
+	fmt.Fprintf(&report, "Requests      %d in %s\n", r.Requests, r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&report, "Succeeded     %d\n", r.Succeeded)
+	fmt.Fprintf(&report, "Throughput    %.2f/s\n", r.Throughput)
+	fmt.Fprintf(&report, "Latencies     min %s, mean %s, p50 %s, p90 %s, p99 %s, max %s\n",
+		r.Latencies.Min, r.Latencies.Mean, r.Latencies.P50, r.Latencies.P90, r.Latencies.P99, r.Latencies.Max)
+	for _, code := range codes {
+		fmt.Fprintf(&report, "Status %d    %d\n", code, r.StatusCodes[code])
+	}
+	for message, count := range r.Errors {
+		fmt.Fprintf(&report, "Error         %d x %s\n", count, message)
+	}
+	_, err := w.Write(report.Bytes())
+	return err
+}
@@ -0,0 +1,73 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_CollectsStatusesAndLatencies(t *testing.T) {
+	var count atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		// Every fourth request is refused like the backpressure of the send endpoint
+		if count.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	result, err := Run(context.Background(), server.Client(), Config{
+		URL:      server.URL,
+		Token:    "secret",
+		Body:     []byte(`{"type":"sandbox","message":"hello","recipients":["a"]}`),
+		Rate:     200,
+		Workers:  4,
+		Duration: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Greater(t, result.Requests, 10)
+	assert.Equal(t, result.Requests, result.StatusCodes[http.StatusAccepted]+result.StatusCodes[http.StatusTooManyRequests])
+	assert.Equal(t, result.StatusCodes[http.StatusAccepted], result.Succeeded)
+	assert.Empty(t, result.Errors)
+	assert.Greater(t, result.Throughput, 0.0)
+	assert.LessOrEqual(t, result.Latencies.Min, result.Latencies.P50)
+	assert.LessOrEqual(t, result.Latencies.P99, result.Latencies.Max)
+
+	var report bytes.Buffer
+	require.NoError(t, result.Write(&report))
+	assert.Contains(t, report.String(), "Status 202")
+	assert.Contains(t, report.String(), "Status 429")
+}
+
+func TestRun_Validation(t *testing.T) {
+	_, err := Run(context.Background(), http.DefaultClient, Config{Duration: time.Second})
+	assert.Error(t, err)
+	_, err = Run(context.Background(), http.DefaultClient, Config{URL: "http://localhost"})
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	summary := summarize(latencies)
+	assert.Equal(t, Latencies{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, summary)
+}
@@ -0,0 +1,58 @@
+package infrastructure
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Capture is a logger recording its entries in memory instead of writing them, so tests can assert on the log
+// output. It records the entries of its child loggers as well and is safe for concurrent use, e.g. by the workers
+// of the message processor.
+type Capture struct {
+	*Logger
+	logs *observer.ObservedLogs
+}
+
+// Entry is a recorded log entry, its fields include those of the child logger that logged it
+type Entry struct {
+	Level   zapcore.Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// NewCapture creates a logger recording the entries of every level
+func NewCapture() *Capture {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &Capture{Logger: &Logger{Log: zap.New(core)}, logs: logs}
+}
+
+// Entries returns the recorded entries with the message in the order they were logged, every entry for an empty
+// message
+func (c *Capture) Entries(message string) []Entry {
+	logged := c.logs.All()
+	if message != "" {
+		logged = c.logs.FilterMessage(message).All()
+	}
+	entries := make([]Entry, len(logged))
+	for i, entry := range logged {
+		entries[i] = Entry{Level: entry.Level, Message: entry.Message, Fields: entry.ContextMap()}
+	}
+	return entries
+}
+
+// Messages returns the messages of the recorded entries of a level in the order they were logged
+func (c *Capture) Messages(level zapcore.Level) []string {
+	var messages []string
+	for _, entry := range c.logs.All() {
+		if entry.Level == level {
+			messages = append(messages, entry.Message)
+		}
+	}
+	return messages
+}
+
+// Reset drops the recorded entries
+func (c *Capture) Reset() {
+	c.logs.TakeAll()
+}
@@ -0,0 +1,41 @@
+package infrastructure
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCapture_RecordsEntriesOfChildLoggers(t *testing.T) {
+	capture := NewCapture()
+	child := capture.With(zap.Int("userID", 7)).With(zap.Int("messageID", 4))
+
+	capture.Info("Starting")
+	child.Warn("Message held", zap.String("reason", "schedule"))
+
+	assert.Equal(t, []string{"Starting"}, capture.Messages(zapcore.InfoLevel))
+	entries := capture.Entries("Message held")
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"userID": int64(7), "messageID": int64(4), "reason": "schedule"}, entries[0].Fields)
+
+	capture.Reset()
+	assert.Empty(t, capture.Entries(""))
+}
+
+func TestCapture_IsSafeForConcurrentUse(t *testing.T) {
+	capture := NewCapture()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			capture.With(zap.Int("worker", i)).Debug("Processing message")
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, capture.Entries("Processing message"), 20)
+}
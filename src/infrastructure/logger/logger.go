@@ -12,6 +12,18 @@ import (
 	gormlogger "gorm.io/gorm/logger"
 )
 
+// ILogger logs structured entries. *Logger implements it with zap, NewCapture returns a logger recording its
+// entries in memory for tests.
+type ILogger interface {
+	Debug(msg string, fields ...zap.Field)
+	Info(msg string, fields ...zap.Field)
+	Warn(msg string, fields ...zap.Field)
+	Error(msg string, fields ...zap.Field)
+	// With returns a child logger adding the fields to each of its entries, e.g. the user and message an entry is
+	// about. Child loggers are safe for concurrent use like their parent.
+	With(fields ...zap.Field) ILogger
+}
+
 type Logger struct {
 	Log *zap.Logger
 }
@@ -93,6 +105,10 @@ func (l *Logger) Debug(msg string, fields ...zap.Field) {
 	l.Log.Debug(msg, fields...)
 }
 
+func (l *Logger) With(fields ...zap.Field) ILogger {
+	return &Logger{Log: l.Log.With(fields...)}
+}
+
 // SetupGinWithZapLogger configures Gin to use the Zap logger
 func (l *Logger) SetupGinWithZapLogger() {
 	// Configure Gin to use release mode by default
@@ -148,7 +164,12 @@ func (l *Logger) GinZapLogger() gin.HandlerFunc {
 		start := time.Now()
 		c.Next()
 		latency := time.Since(start)
-		l.Log.Info("HTTP request", zap.String("method", c.Request.Method), zap.String("path", c.Request.URL.Path), zap.Int("status", c.Writer.Status()), zap.Duration("latency", latency), zap.String("client_ip", c.ClientIP()))
+		fields := []zap.Field{zap.String("method", c.Request.Method), zap.String("path", c.Request.URL.Path), zap.Int("status", c.Writer.Status()), zap.Duration("latency", latency), zap.String("client_ip", c.ClientIP())}
+		// Set by the tracing middleware when it is enabled
+		if traceID := c.GetString("traceID"); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+		l.Log.Info("HTTP request", fields...)
 	}
 }
 
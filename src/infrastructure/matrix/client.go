@@ -0,0 +1,328 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+// Config is the Matrix account of a user, stored in the config of their matrix user provider
+type Config struct {
+	HomeserverURL string `json:"homeserver_url"`
+	AccessToken   string `json:"access_token"`
+}
+
+// ParseConfig reads the Matrix account from the config of a user provider
+func ParseConfig(userProviderConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(userProviderConfig) != "" {
+		if err := json.Unmarshal([]byte(userProviderConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid matrix config: %w", err)
+		}
+	}
+	if config.HomeserverURL == "" || config.AccessToken == "" {
+		return Config{}, errors.New("matrix config needs homeserver_url and access_token")
+	}
+	config.HomeserverURL = strings.TrimSuffix(config.HomeserverURL, "/")
+	return config, nil
+}
+
+// Error is an error answered by the homeserver
+type Error struct {
+	StatusCode int
+	ErrCode    string `json:"errcode"`
+	Message    string `json:"error"`
+	// RetryAfterMs is set when the homeserver rate limited the request
+	RetryAfterMs int64 `json:"retry_after_ms"`
+}
+
+func (e *Error) Error() string {
+	if e.ErrCode == "" {
+		return fmt.Sprintf("matrix homeserver answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("matrix homeserver answered %d: %s %s", e.StatusCode, e.ErrCode, e.Message)
+}
+
+// ErrorCode classifies an error of a send by the Matrix error code of the homeserver. It returns an empty code
+// for other errors.
+func ErrorCode(err error) string {
+	var matrixErr *Error
+	if !errors.As(err, &matrixErr) {
+		return ""
+	}
+	switch {
+	case matrixErr.ErrCode == "M_NOT_FOUND" || matrixErr.ErrCode == "M_FORBIDDEN":
+		// an unknown room alias or a room the account isn't joined to
+		return domainProvider.ErrorCodeInvalidRecipient
+	case matrixErr.ErrCode == "M_UNKNOWN_TOKEN" || matrixErr.ErrCode == "M_MISSING_TOKEN" || matrixErr.StatusCode == http.StatusUnauthorized:
+		return domainProvider.ErrorCodeAuthFailed
+	case matrixErr.ErrCode == "M_LIMIT_EXCEEDED" || matrixErr.StatusCode == http.StatusTooManyRequests:
+		return domainProvider.ErrorCodeRateLimited
+	case matrixErr.StatusCode >= 500:
+		return domainProvider.ErrorCodeNetwork
+	}
+	return domainProvider.ErrorCodeUnknown
+}
+
+// SendResult is the event a message was sent as to one recipient
+type SendResult struct {
+	Recipient string `json:"recipient"`
+	RoomID    string `json:"room_id"`
+	EventID   string `json:"event_id"`
+}
+
+// Client calls the client-server API of a homeserver with the access token of one account
+type Client struct {
+	config Config
+	client *http.Client
+	// txnPrefix and txnCounter make the transaction ids of sent events unique, so the homeserver only
+	// deduplicates retries of the same request
+	txnPrefix  string
+	txnCounter atomic.Int64
+	mu         sync.Mutex
+	rooms      map[string]string // room alias -> room id
+}
+
+// NewClient creates a new client for the account
+func NewClient(config Config, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		config:    config,
+		client:    httpclient.New(timeout),
+		txnPrefix: strconv.FormatInt(time.Now().UnixNano(), 36),
+		rooms:     make(map[string]string),
+	}
+}
+
+// ResolveRoom returns the room id of a recipient, a room id (!room:server) as is or a room alias
+// (#alias:server) looked up in the room directory. Resolved aliases are cached.
+func (c *Client) ResolveRoom(recipient string) (string, error) {
+	switch {
+	case strings.HasPrefix(recipient, "!"):
+		return recipient, nil
+	case strings.HasPrefix(recipient, "#"):
+	default:
+		return "", &domainProvider.RecipientError{Description: fmt.Sprintf("recipient %q is not a matrix room id or room alias", recipient)}
+	}
+
+	c.mu.Lock()
+	roomID, ok := c.rooms[recipient]
+	c.mu.Unlock()
+	if ok {
+		return roomID, nil
+	}
+
+	var response struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/directory/room/"+url.PathEscape(recipient), nil, &response); err != nil {
+		return "", fmt.Errorf("couldn't resolve room alias %s: %w", recipient, err)
+	}
+
+	c.mu.Lock()
+	c.rooms[recipient] = response.RoomID
+	c.mu.Unlock()
+	return response.RoomID, nil
+}
+
+// SendText sends a text message to a room and returns the id of the event
+func (c *Client) SendText(roomID string, text string) (string, error) {
+	txnID := c.txnPrefix + "-" + strconv.FormatInt(c.txnCounter.Add(1), 10)
+	body := map[string]string{"msgtype": "m.text", "body": text}
+
+	var response struct {
+		EventID string `json:"event_id"`
+	}
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + url.PathEscape(txnID)
+	if err := c.do(http.MethodPut, path, body, &response); err != nil {
+		return "", err
+	}
+	return response.EventID, nil
+}
+
+// Send sends a text message to every recipient. It stops at the first recipient the message couldn't be
+// sent to and returns the messages sent until then.
+func (c *Client) Send(recipients []string, text string) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		roomID, err := c.ResolveRoom(recipient)
+		if err != nil {
+			return results, err
+		}
+		eventID, err := c.SendText(roomID, text)
+		if err != nil {
+			return results, fmt.Errorf("couldn't send to %s: %w", recipient, err)
+		}
+		results = append(results, SendResult{Recipient: recipient, RoomID: roomID, EventID: eventID})
+	}
+	return results, nil
+}
+
+// EditText replaces the text of a sent event with a replacement event and returns the id of the replacement.
+// Clients show the new text in place of the original, see
+// https://spec.matrix.org/latest/client-server-api/#event-replacements
+func (c *Client) EditText(roomID string, eventID string, text string) (string, error) {
+	txnID := c.txnPrefix + "-" + strconv.FormatInt(c.txnCounter.Add(1), 10)
+	body := map[string]interface{}{
+		"msgtype":       "m.text",
+		"body":          "* " + text,
+		"m.new_content": map[string]string{"msgtype": "m.text", "body": text},
+		"m.relates_to":  map[string]string{"rel_type": "m.replace", "event_id": eventID},
+	}
+
+	var response struct {
+		EventID string `json:"event_id"`
+	}
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + url.PathEscape(txnID)
+	if err := c.do(http.MethodPut, path, body, &response); err != nil {
+		return "", err
+	}
+	return response.EventID, nil
+}
+
+// Edit replaces the text of the events a message was sent as. It stops at the first event that couldn't be
+// replaced and returns the replacements sent until then.
+func (c *Client) Edit(sent []SendResult, text string) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(sent))
+	for _, result := range sent {
+		eventID, err := c.EditText(result.RoomID, result.EventID, text)
+		if err != nil {
+			return results, fmt.Errorf("couldn't edit the message to %s: %w", result.Recipient, err)
+		}
+		results = append(results, SendResult{Recipient: result.Recipient, RoomID: result.RoomID, EventID: eventID})
+	}
+	return results, nil
+}
+
+// WhoAmI returns the user id of the account
+func (c *Client) WhoAmI() (string, error) {
+	var response struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &response); err != nil {
+		return "", err
+	}
+	return response.UserID, nil
+}
+
+// Event is a room event returned by sync
+type Event struct {
+	Type           string `json:"type"`
+	EventID        string `json:"event_id"`
+	Sender         string `json:"sender"`
+	OriginServerTS int64  `json:"origin_server_ts"`
+	Content        struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+// SyncResponse is the part of a sync response holding the timelines of the joined rooms
+type SyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []Event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// syncFilter limits sync to the messages of the joined rooms
+const syncFilter = `{"presence":{"types":[]},"account_data":{"types":[]},"room":{"state":{"types":[]},"ephemeral":{"types":[]},"account_data":{"types":[]},"timeline":{"types":["m.room.message"]}}}`
+
+// Sync returns the events since the batch token of the previous sync, waiting up to timeout for new events.
+// Without a token it returns the latest events of every room.
+func (c *Client) Sync(since string, timeout time.Duration) (*SyncResponse, error) {
+	query := url.Values{}
+	query.Set("filter", syncFilter)
+	query.Set("timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+	if since != "" {
+		query.Set("since", since)
+	}
+
+	var response SyncResponse
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/sync?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var requestBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, c.config.HomeserverURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		matrixErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, matrixErr)
+		return matrixErr
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(responseBody, result)
+}
+
+// Clients keeps one client per account, so the resolved room aliases are shared between messages
+type Clients struct {
+	timeout time.Duration
+	mu      sync.Mutex
+	clients map[Config]*Client
+}
+
+// NewClients creates a new set of clients calling the homeservers with the timeout
+func NewClients(timeout time.Duration) *Clients {
+	return &Clients{timeout: timeout, clients: make(map[Config]*Client)}
+}
+
+// Get returns the client of an account, creating it on first use
+func (c *Clients) Get(config Config) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	client, ok := c.clients[config]
+	if !ok {
+		client = NewClient(config, c.timeout)
+		c.clients[config] = client
+	}
+	return client
+}
@@ -0,0 +1,139 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(Config{HomeserverURL: server.URL, AccessToken: "secret"}, time.Second)
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{"homeserver_url":"https://matrix.example.org/","access_token":"secret","webhook_enabled":true}`)
+	require.NoError(t, err)
+	assert.Equal(t, Config{HomeserverURL: "https://matrix.example.org", AccessToken: "secret"}, config)
+
+	_, err = ParseConfig(`{"homeserver_url":"https://matrix.example.org"}`)
+	assert.Error(t, err)
+	_, err = ParseConfig("")
+	assert.Error(t, err)
+}
+
+func TestClient_ResolveRoom(t *testing.T) {
+	lookups := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		assert.Equal(t, "/_matrix/client/v3/directory/room/#ops:example.org", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"room_id":"!abc:example.org","servers":["example.org"]}`))
+	})
+
+	roomID, err := client.ResolveRoom("!direct:example.org")
+	require.NoError(t, err)
+	assert.Equal(t, "!direct:example.org", roomID)
+
+	for i := 0; i < 2; i++ {
+		roomID, err = client.ResolveRoom("#ops:example.org")
+		require.NoError(t, err)
+		assert.Equal(t, "!abc:example.org", roomID)
+	}
+	assert.Equal(t, 1, lookups, "resolved aliases are cached")
+
+	_, err = client.ResolveRoom("+4912345")
+	assert.Error(t, err)
+}
+
+func TestClient_Send(t *testing.T) {
+	var txnIDs []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		prefix := "/_matrix/client/v3/rooms/!abc:example.org/send/m.room.message/"
+		require.True(t, strings.HasPrefix(r.URL.Path, prefix), r.URL.Path)
+		txnIDs = append(txnIDs, strings.TrimPrefix(r.URL.Path, prefix))
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"msgtype": "m.text", "body": "hello"}, body)
+		_, _ = w.Write([]byte(`{"event_id":"$event` + string(rune('0'+len(txnIDs))) + `"}`))
+	})
+
+	results, err := client.Send([]string{"!abc:example.org", "!abc:example.org"}, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []SendResult{
+		{Recipient: "!abc:example.org", RoomID: "!abc:example.org", EventID: "$event1"},
+		{Recipient: "!abc:example.org", RoomID: "!abc:example.org", EventID: "$event2"},
+	}, results)
+	require.Len(t, txnIDs, 2)
+	assert.NotEqual(t, txnIDs[0], txnIDs[1])
+}
+
+func TestClient_Edit(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!abc:example.org/send/m.room.message/"), r.URL.Path)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "* fixed", body["body"])
+		assert.Equal(t, map[string]interface{}{"msgtype": "m.text", "body": "fixed"}, body["m.new_content"])
+		assert.Equal(t, map[string]interface{}{"rel_type": "m.replace", "event_id": "$event1"}, body["m.relates_to"])
+		_, _ = w.Write([]byte(`{"event_id":"$edit1"}`))
+	})
+
+	results, err := client.Edit([]SendResult{{Recipient: "#ops:example.org", RoomID: "!abc:example.org", EventID: "$event1"}}, "fixed")
+	require.NoError(t, err)
+	assert.Equal(t, []SendResult{{Recipient: "#ops:example.org", RoomID: "!abc:example.org", EventID: "$edit1"}}, results)
+}
+
+func TestClient_SendError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"You are not in this room."}`))
+	})
+
+	results, err := client.Send([]string{"!abc:example.org"}, "hello")
+	assert.Empty(t, results)
+	var matrixErr *Error
+	require.True(t, errors.As(err, &matrixErr))
+	assert.Equal(t, http.StatusForbidden, matrixErr.StatusCode)
+	assert.Equal(t, "M_FORBIDDEN", matrixErr.ErrCode)
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(err))
+
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(&Error{StatusCode: http.StatusUnauthorized, ErrCode: "M_UNKNOWN_TOKEN"}))
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(&Error{StatusCode: http.StatusTooManyRequests, ErrCode: "M_LIMIT_EXCEEDED"}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&Error{StatusCode: http.StatusBadGateway}))
+	assert.Equal(t, "", ErrorCode(errors.New("connection refused")))
+}
+
+func TestClient_Sync(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_matrix/client/v3/sync", r.URL.Path)
+		assert.Equal(t, "s1", r.URL.Query().Get("since"))
+		assert.Equal(t, "5000", r.URL.Query().Get("timeout"))
+		assert.NotEmpty(t, r.URL.Query().Get("filter"))
+		_, _ = w.Write([]byte(`{"next_batch":"s2","rooms":{"join":{"!abc:example.org":{"timeline":{"events":[
+			{"type":"m.room.message","event_id":"$1","sender":"@alice:example.org","origin_server_ts":1700000000000,"content":{"msgtype":"m.text","body":"ACK"}}
+		]}}}}}`))
+	})
+
+	response, err := client.Sync("s1", 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "s2", response.NextBatch)
+	events := response.Rooms.Join["!abc:example.org"].Timeline.Events
+	require.Len(t, events, 1)
+	assert.Equal(t, "@alice:example.org", events[0].Sender)
+	assert.Equal(t, "ACK", events[0].Content.Body)
+}
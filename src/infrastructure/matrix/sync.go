@@ -0,0 +1,173 @@
+package matrix
+
+import (
+	"sync"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// ReceivedMessage is a message received in a room of a user's Matrix account, it is the payload of the
+// message.received hook event for matrix
+type ReceivedMessage struct {
+	Channel   string `json:"channel"`
+	RoomID    string `json:"room_id"`
+	Sender    string `json:"sender"`
+	EventID   string `json:"event_id"`
+	MsgType   string `json:"msgtype"`
+	Body      string `json:"body"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ReceiveHandler is called for every message received by the account of a user provider
+type ReceiveHandler func(userProvider *domainProvider.UserProvider, receivedMessage *ReceivedMessage)
+
+// SyncPoller syncs the Matrix accounts of all users on a schedule and passes the messages other room
+// members sent to the handler. The first sync of an account only sets the position to sync from, so
+// the room history isn't replayed on startup. It syncs on the leader instance only.
+type SyncPoller struct {
+	repository providerRepo.UserProviderRepositoryInterface
+	clients    *Clients
+	handler    ReceiveHandler
+	elector    leader.Elector
+	Logger     *logger.Logger
+	interval   time.Duration
+	timeout    time.Duration
+	mu         sync.Mutex
+	since      map[int]string // user provider id -> next batch token
+	userIDs    map[int]string // user provider id -> matrix user id of the account
+	shutdown   chan struct{}
+	done       chan struct{}
+}
+
+// NewSyncPoller creates a new sync poller and starts it. The timeout is how long the homeserver waits
+// for new events on each sync, zero returns right away.
+func NewSyncPoller(repository providerRepo.UserProviderRepositoryInterface, clients *Clients, handler ReceiveHandler, elector leader.Elector,
+	loggerInstance *logger.Logger, interval time.Duration, timeout time.Duration) *SyncPoller {
+	if interval <= 0 {
+		interval = 10 * time.Second // Default to syncing every 10 seconds if not specified
+	}
+	if timeout < 0 {
+		timeout = 0
+	}
+
+	poller := &SyncPoller{
+		repository: repository,
+		clients:    clients,
+		handler:    handler,
+		elector:    elector,
+		Logger:     loggerInstance,
+		interval:   interval,
+		timeout:    timeout,
+		since:      make(map[int]string),
+		userIDs:    make(map[int]string),
+		shutdown:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go poller.run()
+
+	return poller
+}
+
+func (p *SyncPoller) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.Logger.Info("Starting matrix sync poller", zap.Duration("interval", p.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			p.poll()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+func (p *SyncPoller) poll() {
+	if !p.elector.IsLeader() {
+		return
+	}
+
+	userProviders, err := p.repository.GetActiveByProviderType(string(alert.TypeMatrix))
+	if err != nil {
+		p.Logger.Error("Couldn't get matrix user providers", zap.Error(err))
+		return
+	}
+
+	for i := range *userProviders {
+		p.syncUserProvider(&(*userProviders)[i])
+	}
+}
+
+func (p *SyncPoller) syncUserProvider(userProvider *domainProvider.UserProvider) {
+	fields := []zap.Field{zap.Int("userProviderID", userProvider.ID), zap.Int("userID", userProvider.UserID)}
+
+	config, err := ParseConfig(userProvider.Config)
+	if err != nil {
+		p.Logger.Warn("Skipping matrix sync of user provider", append(fields, zap.Error(err))...)
+		return
+	}
+	client := p.clients.Get(config)
+
+	p.mu.Lock()
+	since := p.since[userProvider.ID]
+	self, knowSelf := p.userIDs[userProvider.ID]
+	p.mu.Unlock()
+
+	if !knowSelf {
+		self, err = client.WhoAmI()
+		if err != nil {
+			p.Logger.Error("Couldn't get the matrix user of user provider", append(fields, zap.Error(err))...)
+			return
+		}
+	}
+
+	response, err := client.Sync(since, p.timeout)
+	if err != nil {
+		p.Logger.Error("Couldn't sync matrix account", append(fields, zap.Error(err))...)
+		return
+	}
+
+	p.mu.Lock()
+	p.since[userProvider.ID] = response.NextBatch
+	p.userIDs[userProvider.ID] = self
+	p.mu.Unlock()
+
+	if since == "" || p.handler == nil {
+		return
+	}
+
+	for roomID, room := range response.Rooms.Join {
+		for _, event := range room.Timeline.Events {
+			if event.Type != "m.room.message" || event.Sender == self {
+				continue
+			}
+			p.handler(userProvider, &ReceivedMessage{
+				Channel:   string(alert.TypeMatrix),
+				RoomID:    roomID,
+				Sender:    event.Sender,
+				EventID:   event.EventID,
+				MsgType:   event.Content.MsgType,
+				Body:      event.Content.Body,
+				Timestamp: event.OriginServerTS,
+			})
+		}
+	}
+}
+
+// Shutdown stops the poller
+func (p *SyncPoller) Shutdown() {
+	close(p.shutdown)
+	<-p.done
+}
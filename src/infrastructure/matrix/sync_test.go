@@ -0,0 +1,103 @@
+package matrix
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUserProviderRepository implements GetActiveByProviderType, the embedded interface panics on any other call
+type mockUserProviderRepository struct {
+	providerRepo.UserProviderRepositoryInterface
+	userProviders []domainProvider.UserProvider
+}
+
+func (m *mockUserProviderRepository) GetActiveByProviderType(providerType string) (*[]domainProvider.UserProvider, error) {
+	return &m.userProviders, nil
+}
+
+type notLeader struct{}
+
+func (notLeader) IsLeader() bool { return false }
+
+func (notLeader) Shutdown() {}
+
+func newTestSyncPoller(t *testing.T, homeserverURL string, elector leader.Elector, handler ReceiveHandler) *SyncPoller {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	repository := &mockUserProviderRepository{userProviders: []domainProvider.UserProvider{
+		{ID: 3, UserID: 7, Config: `{"homeserver_url":"` + homeserverURL + `","access_token":"secret"}`},
+		{ID: 4, UserID: 8, Config: `{"webhook_enabled":true}`},
+	}}
+	return &SyncPoller{
+		repository: repository,
+		clients:    NewClients(time.Second),
+		handler:    handler,
+		elector:    elector,
+		Logger:     loggerInstance,
+		since:      make(map[int]string),
+		userIDs:    make(map[int]string),
+	}
+}
+
+func TestSyncPoller_PassesMessagesOfOthersAfterInitialSync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_matrix/client/v3/account/whoami":
+			_, _ = w.Write([]byte(`{"user_id":"@bot:example.org"}`))
+		case "/_matrix/client/v3/sync":
+			next := "s1"
+			if r.URL.Query().Get("since") == "s1" {
+				next = "s2"
+			}
+			_, _ = w.Write([]byte(`{"next_batch":"` + next + `","rooms":{"join":{"!abc:example.org":{"timeline":{"events":[
+				{"type":"m.room.message","event_id":"$1","sender":"@bot:example.org","origin_server_ts":1,"content":{"msgtype":"m.text","body":"Alert"}},
+				{"type":"m.room.message","event_id":"$2","sender":"@alice:example.org","origin_server_ts":2,"content":{"msgtype":"m.text","body":"ACK"}}
+			]}}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var received []*ReceivedMessage
+	var userIDs []int
+	poller := newTestSyncPoller(t, server.URL, leader.AlwaysLeader{}, func(userProvider *domainProvider.UserProvider, receivedMessage *ReceivedMessage) {
+		userIDs = append(userIDs, userProvider.UserID)
+		received = append(received, receivedMessage)
+	})
+
+	// The initial sync only sets the position, the history isn't replayed
+	poller.poll()
+	assert.Empty(t, received)
+	assert.Equal(t, "s1", poller.since[3])
+
+	poller.poll()
+	require.Len(t, received, 1)
+	assert.Equal(t, []int{7}, userIDs)
+	assert.Equal(t, &ReceivedMessage{
+		Channel:   "matrix",
+		RoomID:    "!abc:example.org",
+		Sender:    "@alice:example.org",
+		EventID:   "$2",
+		MsgType:   "m.text",
+		Body:      "ACK",
+		Timestamp: 2,
+	}, received[0])
+	assert.Equal(t, "s2", poller.since[3])
+}
+
+func TestSyncPoller_OnlyLeaderSyncs(t *testing.T) {
+	// The poller has no repository, syncing on a follower would panic
+	poller := &SyncPoller{elector: notLeader{}}
+	assert.NotPanics(t, poller.poll)
+}
@@ -0,0 +1,92 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// SMS segment sizes in characters. A longer message is split into segments that each lose room to the header
+// joining them again, and a message with a character outside the GSM 7-bit alphabet is sent in UCS-2.
+const (
+	gsmSegmentLength          = 160
+	gsmConcatenatedLength     = 153
+	unicodeSegmentLength      = 70
+	unicodeConcatenatedLength = 67
+)
+
+// gsmCharacters is the GSM 7-bit default alphabet, gsmExtendedCharacters take two characters of a segment
+const (
+	gsmCharacters = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtendedCharacters = "^{}\\[~]|€\f"
+)
+
+type providerCostConfig struct {
+	CostPerMessage *float64 `json:"cost_per_message"`
+}
+
+// ParseCostPerMessage extracts the price of one message stored under the "cost_per_message" key of a provider
+// config, nil when the provider has no price
+func ParseCostPerMessage(config string) (*float64, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var parsed providerCostConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.CostPerMessage != nil && *parsed.CostPerMessage < 0 {
+		return nil, errors.New("cost_per_message must not be negative")
+	}
+	return parsed.CostPerMessage, nil
+}
+
+// MessageSegments returns how many messages a provider of the type bills for sending the message to one
+// recipient. SMS are billed per segment, every other type per message.
+func MessageSegments(providerType string, message string) int {
+	if providerType != "sms" {
+		return 1
+	}
+	length, unicode := smsLength(message)
+	if unicode {
+		return segments(length, unicodeSegmentLength, unicodeConcatenatedLength)
+	}
+	return segments(length, gsmSegmentLength, gsmConcatenatedLength)
+}
+
+// smsLength returns the length of a message in characters of its SMS encoding and whether it is sent in UCS-2
+func smsLength(message string) (int, bool) {
+	length := 0
+	unicode := false
+	for _, r := range message {
+		switch {
+		case strings.ContainsRune(gsmCharacters, r):
+			length++
+		case strings.ContainsRune(gsmExtendedCharacters, r):
+			length += 2
+		default:
+			unicode = true
+		}
+	}
+	if unicode {
+		// UCS-2 takes characters outside the basic multilingual plane as two
+		length = 0
+		for _, r := range message {
+			if utf8.RuneLen(r) == 4 {
+				length += 2
+			} else {
+				length++
+			}
+		}
+	}
+	return length, unicode
+}
+
+func segments(length int, single int, concatenated int) int {
+	if length <= single {
+		return 1
+	}
+	return (length + concatenated - 1) / concatenated
+}
@@ -0,0 +1,45 @@
+package messaging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCostPerMessage(t *testing.T) {
+	cost, err := ParseCostPerMessage(`{"cost_per_message":0.0079}`)
+	require.NoError(t, err)
+	require.NotNil(t, cost)
+	assert.Equal(t, 0.0079, *cost)
+
+	cost, err = ParseCostPerMessage(`{"from":"+4912345"}`)
+	require.NoError(t, err)
+	assert.Nil(t, cost)
+
+	_, err = ParseCostPerMessage(`{"cost_per_message":-1}`)
+	assert.Error(t, err)
+}
+
+func TestMessageSegments(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerType string
+		message      string
+		expected     int
+	}{
+		{"one GSM segment", "sms", strings.Repeat("a", 160), 1},
+		{"two GSM segments", "sms", strings.Repeat("a", 161), 2},
+		{"extended characters count twice", "sms", strings.Repeat("€", 81), 2},
+		{"a character outside GSM switches to unicode", "sms", strings.Repeat("ä", 100) + "ł", 2},
+		{"emoji count twice", "sms", strings.Repeat("😀", 35), 1},
+		{"three unicode segments", "sms", strings.Repeat("ł", 135), 3},
+		{"other types aren't split", "signal", strings.Repeat("a", 1000), 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, MessageSegments(test.providerType, test.message))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// recordingDeliveryRepository keeps the deliveries recorded
+type recordingDeliveryRepository struct {
+	providerRepo.MessageDeliveryRepositoryInterface
+	recorded []provider.MessageDelivery
+	err      error
+}
+
+func (m *recordingDeliveryRepository) RecordSent(deliveries []provider.MessageDelivery) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.recorded = append(m.recorded, deliveries...)
+	return nil
+}
+
+func TestRecordDeliveries_HonorsSignalReceiptOptions(t *testing.T) {
+	repository := &recordingDeliveryRepository{}
+	processor := &MessageProcessor{
+		senders:                   map[string]ProviderSender{"signal": NewSignalSender(nil, nil, nil, nil)},
+		messageDeliveryRepository: repository,
+		Logger:                    &logger.Logger{Log: zap.NewNop()},
+	}
+	signalProvider := &provider.Provider{ID: 3, Type: "signal"}
+	responseData := []byte(`[{"timestamp":1700000000000}]`)
+
+	// Deliveries are tracked with read receipts unless the extension turns them off
+	processor.recordDeliveries(&provider.MessageTransaction{ID: 1, Recipients: `["+491111"]`}, signalProvider, responseData)
+	require.Len(t, repository.recorded, 1)
+	assert.Equal(t, "1700000000000:+491111", repository.recorded[0].ProviderMessageID)
+	assert.True(t, repository.recorded[0].ReadReceipts)
+
+	repository.recorded = nil
+	processor.recordDeliveries(&provider.MessageTransaction{ID: 2, Recipients: `["+491111"]`, Extensions: `{"signal":{"read_receipts":false}}`}, signalProvider, responseData)
+	require.Len(t, repository.recorded, 1)
+	assert.False(t, repository.recorded[0].ReadReceipts)
+
+	repository.recorded = nil
+	processor.recordDeliveries(&provider.MessageTransaction{ID: 3, Recipients: `["+491111"]`, Extensions: `{"signal":{"delivery_confirmation":false}}`}, signalProvider, responseData)
+	assert.Empty(t, repository.recorded)
+}
+
+func TestRecordDeliveries_LogsErrorsWithTheMessage(t *testing.T) {
+	capture := logger.NewCapture()
+	processor := &MessageProcessor{
+		senders:                   map[string]ProviderSender{"signal": NewSignalSender(nil, nil, nil, nil)},
+		messageDeliveryRepository: &recordingDeliveryRepository{err: errors.New("connection refused")},
+		Logger:                    capture.Logger,
+	}
+
+	processor.recordDeliveries(&provider.MessageTransaction{ID: 4, UserID: 7, Recipients: `["+491111"]`},
+		&provider.Provider{ID: 3, Type: "signal"}, []byte(`[{"timestamp":1700000000000}]`))
+
+	entries := capture.Entries("Error recording message deliveries")
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, int64(7), entries[0].Fields["userID"])
+	assert.Equal(t, int64(4), entries[0].Fields["messageID"])
+	assert.Equal(t, "connection refused", entries[0].Fields["error"])
+}
@@ -0,0 +1,140 @@
+package messaging
+
+import (
+	"sort"
+	"sync"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+// UserLoad is the share of the processor a user currently takes
+type UserLoad struct {
+	UserID   int
+	InFlight int // messages of the user being processed by a worker
+	Queued   int // messages of the user waiting for a worker
+}
+
+// fairQueue hands the queued messages to the workers round-robin across users, so a user queueing a large batch
+// doesn't hold up the messages of the others. A user with maxInFlight messages being processed gets no further
+// worker until one of them is done, leaving the remaining workers to the other users.
+type fairQueue struct {
+	mu          sync.Mutex
+	ready       *sync.Cond
+	capacity    int
+	maxInFlight int // 0 doesn't limit the workers a user can take
+	queued      map[int][]*provider.MessageTransaction
+	inFlight    map[int]int
+	order       []int // users with queued messages, in the order they are served
+	size        int
+	closed      bool
+}
+
+func newFairQueue(capacity int, maxInFlight int) *fairQueue {
+	q := &fairQueue{
+		capacity:    capacity,
+		maxInFlight: maxInFlight,
+		queued:      make(map[int][]*provider.MessageTransaction),
+		inFlight:    make(map[int]int),
+	}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a message, it returns false when the queue is full
+func (q *fairQueue) push(msg *provider.MessageTransaction) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.size >= q.capacity {
+		return false
+	}
+	if len(q.queued[msg.UserID]) == 0 {
+		q.order = append(q.order, msg.UserID)
+	}
+	q.queued[msg.UserID] = append(q.queued[msg.UserID], msg)
+	q.size++
+	q.ready.Signal()
+	return true
+}
+
+// next waits for a message of a user below the in-flight limit and counts it in flight. The user moves to the
+// back of the order, so the next message goes to another user if one is waiting. It returns false once the
+// queue is closed.
+func (q *fairQueue) next() (*provider.MessageTransaction, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.closed {
+			return nil, false
+		}
+		for i, userID := range q.order {
+			if q.maxInFlight > 0 && q.inFlight[userID] >= q.maxInFlight {
+				continue
+			}
+			messages := q.queued[userID]
+			msg := messages[0]
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			if len(messages) > 1 {
+				q.queued[userID] = messages[1:]
+				q.order = append(q.order, userID)
+			} else {
+				delete(q.queued, userID)
+			}
+			q.size--
+			q.inFlight[userID]++
+			return msg, true
+		}
+		q.ready.Wait()
+	}
+}
+
+// done marks a message returned by next as processed, freeing a worker for its user
+func (q *fairQueue) done(msg *provider.MessageTransaction) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inFlight[msg.UserID] <= 1 {
+		delete(q.inFlight, msg.UserID)
+	} else {
+		q.inFlight[msg.UserID]--
+	}
+	// Waiting workers may only have been blocked by the limit of this user
+	q.ready.Broadcast()
+}
+
+// close wakes the waiting workers and makes next return false. The messages still queued are left locked for
+// processing, they are picked up again by the recovery on the next start.
+func (q *fairQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.ready.Broadcast()
+}
+
+func (q *fairQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// loads returns the users with messages in flight or queued, by user ID
+func (q *fairQueue) loads() []UserLoad {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	byUser := make(map[int]*UserLoad)
+	for userID, count := range q.inFlight {
+		byUser[userID] = &UserLoad{UserID: userID, InFlight: count}
+	}
+	for userID, messages := range q.queued {
+		load, ok := byUser[userID]
+		if !ok {
+			load = &UserLoad{UserID: userID}
+			byUser[userID] = load
+		}
+		load.Queued = len(messages)
+	}
+	loads := make([]UserLoad, 0, len(byUser))
+	for _, load := range byUser {
+		loads = append(loads, *load)
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].UserID < loads[j].UserID })
+	return loads
+}
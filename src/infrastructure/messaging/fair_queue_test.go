@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextUserIDs(t *testing.T, q *fairQueue, count int) []int {
+	var userIDs []int
+	for i := 0; i < count; i++ {
+		msg, ok := q.next()
+		require.True(t, ok)
+		userIDs = append(userIDs, msg.UserID)
+	}
+	return userIDs
+}
+
+func TestFairQueueTakesTurnsBetweenUsers(t *testing.T) {
+	q := newFairQueue(10, 0)
+	for i := 1; i <= 4; i++ {
+		assert.True(t, q.push(&provider.MessageTransaction{ID: i, UserID: 1}))
+	}
+	assert.True(t, q.push(&provider.MessageTransaction{ID: 5, UserID: 2}))
+	assert.True(t, q.push(&provider.MessageTransaction{ID: 6, UserID: 3}))
+
+	assert.Equal(t, []int{1, 2, 3, 1, 1, 1}, nextUserIDs(t, q, 6))
+	assert.Equal(t, 0, q.depth())
+}
+
+func TestFairQueueLimitsInFlightPerUser(t *testing.T) {
+	q := newFairQueue(10, 2)
+	for i := 1; i <= 4; i++ {
+		q.push(&provider.MessageTransaction{ID: i, UserID: 1})
+	}
+	first, _ := q.next()
+	q.next()
+
+	// User 1 has 2 messages in flight, a worker waits until one is done or another user queues a message
+	received := make(chan *provider.MessageTransaction)
+	go func() {
+		msg, _ := q.next()
+		received <- msg
+	}()
+	select {
+	case <-received:
+		t.Fatal("a third message of the user was handed out")
+	case <-time.After(20 * time.Millisecond):
+	}
+	q.push(&provider.MessageTransaction{ID: 5, UserID: 2})
+	assert.Equal(t, 5, (<-received).ID)
+
+	assert.Equal(t, []UserLoad{{UserID: 1, InFlight: 2, Queued: 2}, {UserID: 2, InFlight: 1}}, q.loads())
+
+	q.done(first)
+	msg, ok := q.next()
+	require.True(t, ok)
+	assert.Equal(t, 3, msg.ID)
+}
+
+func TestFairQueueCapacityAndClose(t *testing.T) {
+	q := newFairQueue(1, 0)
+	assert.True(t, q.push(&provider.MessageTransaction{ID: 1, UserID: 1}))
+	assert.False(t, q.push(&provider.MessageTransaction{ID: 2, UserID: 2}))
+
+	q.close()
+	_, ok := q.next()
+	assert.False(t, ok)
+	assert.False(t, q.push(&provider.MessageTransaction{ID: 3, UserID: 2}))
+}
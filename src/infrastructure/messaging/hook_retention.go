@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// hookEventPruneInterval is how often the events older than the retention period are removed
+const hookEventPruneInterval = time.Hour
+
+// LoadHookEventRetention loads how long the emitted webhook events are kept for replays
+func LoadHookEventRetention() (time.Duration, error) {
+	days, err := utils.GetIntEnv("WEBHOOK_EVENT_RETENTION_DAYS", 30)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WEBHOOK_EVENT_RETENTION_DAYS: %w", err)
+	}
+	if days < 1 {
+		return 0, fmt.Errorf("WEBHOOK_EVENT_RETENTION_DAYS must be at least 1")
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// HookEventPruner periodically removes the webhook events older than the retention period, on the leader
+// instance only
+type HookEventPruner struct {
+	repository providerRepo.WebhookEventRepositoryInterface
+	elector    leader.Elector
+	Logger     *logger.Logger
+	retention  time.Duration
+	shutdown   chan struct{}
+	done       chan struct{}
+}
+
+// NewHookEventPruner creates a new webhook event pruner and starts it
+func NewHookEventPruner(repository providerRepo.WebhookEventRepositoryInterface, elector leader.Elector, loggerInstance *logger.Logger, retention time.Duration) *HookEventPruner {
+	pruner := &HookEventPruner{
+		repository: repository,
+		elector:    elector,
+		Logger:     loggerInstance,
+		retention:  retention,
+		shutdown:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go pruner.run()
+
+	return pruner
+}
+
+func (p *HookEventPruner) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(hookEventPruneInterval)
+	defer ticker.Stop()
+
+	p.Logger.Info("Starting webhook event pruner", zap.Duration("retention", p.retention))
+
+	p.prune()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.prune()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+func (p *HookEventPruner) prune() {
+	if !p.elector.IsLeader() {
+		return
+	}
+	deleted, err := p.repository.DeleteBefore(time.Now().Add(-p.retention))
+	if err != nil {
+		p.Logger.Error("Error pruning webhook events", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		p.Logger.Info("Pruned webhook events", zap.Int64("deleted", deleted))
+	}
+}
+
+// Shutdown stops the pruner
+func (p *HookEventPruner) Shutdown() {
+	close(p.shutdown)
+	<-p.done
+}
@@ -0,0 +1,523 @@
+package messaging
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// HookSecretHeader carries the secret of a subscription in the verification handshake, the target
+	// confirms the subscription by echoing it back
+	HookSecretHeader = "X-Hook-Secret"
+	// HookSignatureHeader carries the hex encoded HMAC-SHA256 of a delivery body, keyed with the subscription secret
+	HookSignatureHeader = "X-Hook-Signature"
+	// HookEventHeader names the event a delivery reports
+	HookEventHeader = "X-Hook-Event"
+	// HookEventIDHeader carries the ID of the stored event a delivery reports, the same for the deliveries of
+	// an event to several targets and its replays, so targets can drop duplicates
+	HookEventIDHeader = "X-Hook-Event-ID"
+	// HookReplayHeader is set to true on the deliveries of a replayed event
+	HookReplayHeader = "X-Hook-Replay"
+
+	HookEventMessageSuccess      = "message.success"
+	HookEventMessageFailed       = "message.failed"
+	HookEventMessageHeld         = "message.held"
+	HookEventMessageHeldSchedule = "message.held_schedule"
+	HookEventMessageRateLimited  = "message.rate_limited"
+	HookEventMessageUnconfirmed  = "message.unconfirmed"
+	HookEventMessageReceived     = "message.received"
+	// HookEventMessageAcknowledged and HookEventMessageUnacknowledged report messages that demanded an
+	// acknowledgement, when a recipient acknowledged or the deadline passed
+	HookEventMessageAcknowledged   = "message.acknowledged"
+	HookEventMessageUnacknowledged = "message.unacknowledged"
+	// HookEventMessageDelivery reports a change of the delivery status of a message to a recipient, as reported
+	// by the delivery callbacks of the provider
+	HookEventMessageDelivery = "message.delivery"
+	// HookEventMessageRevoked reports a sent message that was deleted for its recipients
+	HookEventMessageRevoked = "message.revoked"
+	// HookEventMessageAction reports an action of a message chosen by a recipient, by a button or a numbered reply
+	HookEventMessageAction = "message.action"
+
+	// hookEventVerify is the event of the verification handshake request
+	hookEventVerify = "hook.verify"
+)
+
+// HookEvents lists the event types a target URL can subscribe to
+var HookEvents = []string{
+	HookEventMessageSuccess,
+	HookEventMessageFailed,
+	HookEventMessageHeld,
+	HookEventMessageHeldSchedule,
+	HookEventMessageRateLimited,
+	HookEventMessageUnconfirmed,
+	HookEventMessageReceived,
+	HookEventMessageAcknowledged,
+	HookEventMessageUnacknowledged,
+	HookEventMessageDelivery,
+	HookEventMessageRevoked,
+	HookEventMessageAction,
+}
+
+// HookEnvelopeTypes lists the types of received envelopes message.received subscriptions can filter on
+var HookEnvelopeTypes = []domainSignal.EnvelopeType{
+	domainSignal.EnvelopeTypeDataMessage,
+	domainSignal.EnvelopeTypeContact,
+	domainSignal.EnvelopeTypePayment,
+	domainSignal.EnvelopeTypeReaction,
+	domainSignal.EnvelopeTypeGroupUpdate,
+}
+
+// defaultHookEnvelopeTypes are delivered to message.received subscriptions without a filter, the envelopes
+// carrying a message. Reactions and group updates have to be asked for.
+var defaultHookEnvelopeTypes = []domainSignal.EnvelopeType{
+	domainSignal.EnvelopeTypeDataMessage,
+	domainSignal.EnvelopeTypeContact,
+	domainSignal.EnvelopeTypePayment,
+}
+
+// IsHookEnvelopeType reports whether message.received subscriptions can filter on the envelope type
+func IsHookEnvelopeType(envelopeType string) bool {
+	for _, t := range HookEnvelopeTypes {
+		if string(t) == envelopeType {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsEnvelopeType reports whether a message.received subscription is delivered envelopes of a type
+func AcceptsEnvelopeType(subscription provider.HookSubscription, envelopeType domainSignal.EnvelopeType) bool {
+	if len(subscription.EnvelopeTypes) == 0 {
+		for _, t := range defaultHookEnvelopeTypes {
+			if t == envelopeType {
+				return true
+			}
+		}
+		return false
+	}
+	for _, t := range subscription.EnvelopeTypes {
+		if t == string(envelopeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryHookPayload is the payload of the message.delivery event
+type DeliveryHookPayload struct {
+	MessageID    int       `json:"message_id"`
+	Recipient    string    `json:"recipient"`
+	Status       string    `json:"status"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	ReportedAt   time.Time `json:"reported_at"`
+}
+
+// NewDeliveryHookPayload builds the message.delivery payload of a delivery whose status changed
+func NewDeliveryHookPayload(delivery *provider.MessageDelivery) DeliveryHookPayload {
+	return DeliveryHookPayload{
+		MessageID:    delivery.MessageTransactionID,
+		Recipient:    delivery.Recipient,
+		Status:       delivery.Status,
+		ErrorCode:    delivery.ErrorCode,
+		ErrorMessage: delivery.ErrorMessage,
+		ReportedAt:   time.Now().UTC(),
+	}
+}
+
+// ActionHookPayload is the payload of the message.action event
+type ActionHookPayload struct {
+	MessageID int       `json:"message_id"`
+	ActionID  string    `json:"action_id"`
+	Label     string    `json:"label"`
+	Recipient string    `json:"recipient"`
+	ChosenBy  string    `json:"chosen_by"`
+	Channel   string    `json:"channel"`
+	Via       string    `json:"via"`
+	ChosenAt  time.Time `json:"chosen_at"`
+}
+
+// NewActionHookPayload builds the message.action payload of a chosen action
+func NewActionHookPayload(choice *provider.ActionChoice) ActionHookPayload {
+	return ActionHookPayload{
+		MessageID: choice.MessageTransactionID,
+		ActionID:  choice.ActionID,
+		Label:     choice.Label,
+		Recipient: choice.Recipient,
+		ChosenBy:  choice.ChosenBy,
+		Channel:   choice.Channel,
+		Via:       choice.Via,
+		ChosenAt:  choice.ChosenAt.UTC(),
+	}
+}
+
+// IsHookEvent reports whether the event can be subscribed to
+func IsHookEvent(event string) bool {
+	for _, e := range HookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// hookEventForStatus returns the event reporting a message status, matching the event of the v2 webhook payload
+func hookEventForStatus(status string) string {
+	return "message." + status
+}
+
+// HookSecretDecrypter decrypts the stored secrets of subscriptions with the key of their user
+type HookSecretDecrypter interface {
+	Decrypt(userID int, ciphertext string) (string, error)
+}
+
+// HookDispatcher verifies REST hook subscriptions and delivers events to their target URLs. Every delivered
+// event is stored once per user, so it can be fetched or replayed later.
+type HookDispatcher struct {
+	repository      providerRepo.HookSubscriptionRepositoryInterface
+	eventRepository providerRepo.WebhookEventRepositoryInterface
+	secrets         HookSecretDecrypter
+	Logger          *logger.Logger
+	client          *http.Client
+	// tlsClients are the clients of the subscriptions with TLS settings of their own, by subscription ID
+	tlsClients sync.Map
+}
+
+// NewHookDispatcher creates a new REST hook dispatcher. secrets decrypts the stored secrets deliveries are signed
+// with, it may be nil when CREDENTIAL_ENCRYPTION_KEY isn't set and secrets are stored in plaintext.
+func NewHookDispatcher(repository providerRepo.HookSubscriptionRepositoryInterface, eventRepository providerRepo.WebhookEventRepositoryInterface, secrets HookSecretDecrypter, loggerInstance *logger.Logger) *HookDispatcher {
+	return &HookDispatcher{
+		repository:      repository,
+		eventRepository: eventRepository,
+		secrets:         secrets,
+		Logger:          loggerInstance,
+		client:          httpclient.New(10 * time.Second),
+	}
+}
+
+// Verify performs the verification handshake with a target URL. The target must answer with a 2xx
+// status and echo the secret in the X-Hook-Secret header to confirm it accepts the subscription.
+func (d *HookDispatcher) Verify(targetURL string, secret string) error {
+	return d.VerifySubscription(provider.HookSubscription{TargetURL: targetURL}, secret)
+}
+
+// VerifySubscription performs the verification handshake with the target URL of a subscription, with the stored
+// delivery settings of the subscription
+func (d *HookDispatcher) VerifySubscription(subscription provider.HookSubscription, secret string) error {
+	body, err := json.Marshal(map[string]string{"event": hookEventVerify})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", subscription.TargetURL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("invalid target url: %w", err)
+	}
+	if err := d.setDeliveryHeaders(req, subscription.UserID, subscription.Delivery); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-multi-chat-api-Webhook")
+	req.Header.Set(HookEventHeader, hookEventVerify)
+	req.Header.Set(HookSecretHeader, secret)
+
+	client, err := d.clientFor(subscription)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("target url is unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("target url answered the verification request with status %d", resp.StatusCode)
+	}
+	if !hmac.Equal([]byte(resp.Header.Get(HookSecretHeader)), []byte(secret)) {
+		return fmt.Errorf("target url didn't echo the %s header", HookSecretHeader)
+	}
+	return nil
+}
+
+// DispatchToUser delivers an event to the subscriptions of a user
+func (d *HookDispatcher) DispatchToUser(userID int, event string, payload interface{}) {
+	subscriptions, err := d.repository.GetUserSubscriptionsForEvent(userID, event)
+	if err != nil {
+		return
+	}
+	d.dispatch(*subscriptions, event, payload)
+}
+
+// DispatchToProviderType delivers an event to the subscriptions of every user with an active provider of the given type
+func (d *HookDispatcher) DispatchToProviderType(providerType string, event string, payload interface{}) {
+	subscriptions, err := d.repository.GetSubscriptionsForProviderType(event, providerType)
+	if err != nil {
+		return
+	}
+	d.dispatch(*subscriptions, event, payload)
+}
+
+// DispatchReceivedEnvelope delivers a received envelope as message.received event to the subscriptions of every
+// user with an active provider of the given type that accept its envelope type
+func (d *HookDispatcher) DispatchReceivedEnvelope(providerType string, envelopeType domainSignal.EnvelopeType, payload interface{}) {
+	subscriptions, err := d.repository.GetSubscriptionsForProviderType(HookEventMessageReceived, providerType)
+	if err != nil {
+		return
+	}
+	d.dispatch(acceptingEnvelopeType(*subscriptions, envelopeType), HookEventMessageReceived, payload)
+}
+
+// acceptingEnvelopeType returns the subscriptions delivered envelopes of a type
+func acceptingEnvelopeType(subscriptions []provider.HookSubscription, envelopeType domainSignal.EnvelopeType) []provider.HookSubscription {
+	var accepting []provider.HookSubscription
+	for _, subscription := range subscriptions {
+		if AcceptsEnvelopeType(subscription, envelopeType) {
+			accepting = append(accepting, subscription)
+		}
+	}
+	return accepting
+}
+
+func (d *HookDispatcher) dispatch(subscriptions []provider.HookSubscription, event string, payload interface{}) {
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		d.Logger.Error("Error marshaling hook payload", zap.Error(err), zap.String("event", event))
+		return
+	}
+
+	// Subscriptions of the same user share the stored event
+	eventIDs := make(map[int]int)
+	for _, subscription := range subscriptions {
+		eventID, stored := eventIDs[subscription.UserID]
+		if !stored {
+			eventID = d.store(subscription.UserID, event, body)
+			eventIDs[subscription.UserID] = eventID
+		}
+		go d.deliver(subscription, eventID, event, body, false)
+	}
+}
+
+// store keeps an event of a user for replays and returns its ID, 0 if it couldn't be stored. The event is
+// delivered either way.
+func (d *HookDispatcher) store(userID int, event string, body []byte) int {
+	stored, err := d.eventRepository.Create(&provider.WebhookEvent{UserID: userID, Event: event, Payload: string(body)})
+	if err != nil {
+		return 0
+	}
+	return stored.ID
+}
+
+// Replay re-delivers a stored event to the current subscriptions of its user to the event and waits for the
+// answers of the targets. A received envelope is only re-delivered to the subscriptions accepting its type.
+func (d *HookDispatcher) Replay(event *provider.WebhookEvent) ([]provider.WebhookReplayResult, error) {
+	subscriptions, err := d.repository.GetUserSubscriptionsForEvent(event.UserID, event.Event)
+	if err != nil {
+		return nil, err
+	}
+	if envelopeType := receivedEnvelopeType(event); envelopeType != "" {
+		accepting := acceptingEnvelopeType(*subscriptions, envelopeType)
+		subscriptions = &accepting
+	}
+
+	results := make([]provider.WebhookReplayResult, len(*subscriptions))
+	var wg sync.WaitGroup
+	for i, subscription := range *subscriptions {
+		wg.Add(1)
+		go func(i int, subscription provider.HookSubscription) {
+			defer wg.Done()
+			statusCode, err := d.deliver(subscription, event.ID, event.Event, []byte(event.Payload), true)
+			results[i] = provider.WebhookReplayResult{
+				SubscriptionID: subscription.ID,
+				TargetURL:      subscription.TargetURL,
+				StatusCode:     statusCode,
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, subscription)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// receivedEnvelopeType returns the envelope type of a stored message.received event, empty for events without one
+// such as the messages received by other providers
+func receivedEnvelopeType(event *provider.WebhookEvent) domainSignal.EnvelopeType {
+	if event.Event != HookEventMessageReceived {
+		return ""
+	}
+	var payload struct {
+		Type     domainSignal.EnvelopeType `json:"type"`
+		Envelope json.RawMessage           `json:"envelope"`
+	}
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil || payload.Envelope == nil {
+		return ""
+	}
+	return payload.Type
+}
+
+// deliver posts an event to the target URL of a subscription and returns the status code of the answer. A
+// 410 Gone answer unsubscribes the target.
+func (d *HookDispatcher) deliver(subscription provider.HookSubscription, eventID int, event string, body []byte, replay bool) (int, error) {
+	secret, err := d.secret(subscription)
+	if err != nil {
+		d.Logger.Error("Error decrypting hook secret", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", subscription.TargetURL, bytes.NewBuffer(body))
+	if err != nil {
+		d.Logger.Error("Error creating hook request", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+		return 0, err
+	}
+	if err := d.setDeliveryHeaders(req, subscription.UserID, subscription.Delivery); err != nil {
+		d.Logger.Error("Error decrypting hook headers", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-multi-chat-api-Webhook")
+	req.Header.Set(HookEventHeader, event)
+	req.Header.Set(HookSignatureHeader, signHookBody(secret, body))
+	if eventID > 0 {
+		req.Header.Set(HookEventIDHeader, strconv.Itoa(eventID))
+	}
+	if replay {
+		req.Header.Set(HookReplayHeader, "true")
+	}
+
+	client, err := d.clientFor(subscription)
+	if err != nil {
+		d.Logger.Error("Error creating hook client", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		d.Logger.Error("Error sending hook request", zap.Error(err), zap.Int("subscriptionID", subscription.ID))
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone {
+		d.Logger.Info("Hook target is gone, removing subscription",
+			zap.Int("subscriptionID", subscription.ID),
+			zap.Int("userID", subscription.UserID))
+		_ = d.repository.DeleteByID(subscription.ID)
+		d.ForgetClient(subscription.ID)
+		return resp.StatusCode, nil
+	}
+
+	d.Logger.Info("Hook event delivered",
+		zap.Int("subscriptionID", subscription.ID),
+		zap.String("event", event),
+		zap.Int("eventID", eventID),
+		zap.Bool("replay", replay),
+		zap.Int("statusCode", resp.StatusCode))
+	return resp.StatusCode, nil
+}
+
+// secret returns the plaintext secret of a subscription. Secrets stored before they were encrypted are used as
+// they are until they are encrypted at the next start.
+func (d *HookDispatcher) secret(subscription provider.HookSubscription) (string, error) {
+	return d.decrypt(subscription.UserID, subscription.Secret)
+}
+
+// decrypt returns the plaintext of a value of a subscription stored encrypted, values stored in plaintext are
+// returned as they are
+func (d *HookDispatcher) decrypt(userID int, value string) (string, error) {
+	if !security.IsEncryptedUserSecret(value) {
+		return value, nil
+	}
+	if d.secrets == nil {
+		return "", errors.New("the hook secret is encrypted but CREDENTIAL_ENCRYPTION_KEY is not set")
+	}
+	return d.secrets.Decrypt(userID, value)
+}
+
+// setDeliveryHeaders sets the custom headers of delivery settings on a request, before the headers of the hook
+// or webhook protocol so those can't be replaced
+func (d *HookDispatcher) setDeliveryHeaders(req *http.Request, userID int, delivery provider.HookDelivery) error {
+	for name, value := range delivery.Headers {
+		plaintext, err := d.decrypt(userID, value)
+		if err != nil {
+			return fmt.Errorf("couldn't decrypt the %s header: %w", name, err)
+		}
+		req.Header.Set(name, plaintext)
+	}
+	return nil
+}
+
+// clientFor returns the client delivering to a subscription: the shared client, or a client of its own for
+// subscriptions with TLS settings, kept for the later deliveries until ForgetClient drops it.
+func (d *HookDispatcher) clientFor(subscription provider.HookSubscription) (*http.Client, error) {
+	if !subscription.Delivery.HasTLS() {
+		return d.client, nil
+	}
+	if client, ok := d.tlsClients.Load(subscription.ID); ok {
+		return client.(*http.Client), nil
+	}
+	client, err := d.newTLSClient(subscription.UserID, subscription.Delivery)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.ID > 0 {
+		d.tlsClients.Store(subscription.ID, client)
+	}
+	return client, nil
+}
+
+// newTLSClient creates a client with the TLS settings of delivery settings, decrypting the client key with the
+// key of the user
+func (d *HookDispatcher) newTLSClient(userID int, delivery provider.HookDelivery) (*http.Client, error) {
+	options := httpclient.TLSOptions{
+		RootCAs:            []byte(delivery.CACertificate),
+		InsecureSkipVerify: delivery.InsecureSkipVerify,
+	}
+	if delivery.ClientCertificate != "" {
+		key, err := d.decrypt(userID, delivery.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decrypt the client key: %w", err)
+		}
+		certificate, err := tls.X509KeyPair([]byte(delivery.ClientCertificate), []byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		options.Certificates = []tls.Certificate{certificate}
+	}
+	return httpclient.NewWithTLS(10*time.Second, options)
+}
+
+// ForgetClient drops the client kept for a subscription, the next delivery creates one with its current settings.
+// It is called when the subscription is gone or its delivery settings were updated.
+func (d *HookDispatcher) ForgetClient(subscriptionID int) {
+	d.tlsClients.Delete(subscriptionID)
+}
+
+// signHookBody returns the hex encoded HMAC-SHA256 of a delivery body
+func signHookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
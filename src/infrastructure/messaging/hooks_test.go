@@ -0,0 +1,341 @@
+package messaging
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockHookSubscriptionRepository struct {
+	mu            sync.Mutex
+	subscriptions []provider.HookSubscription
+	deleted       []int
+}
+
+func (m *mockHookSubscriptionRepository) Create(subscription *provider.HookSubscription) (*provider.HookSubscription, error) {
+	return subscription, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscriptions(userID int) (*[]provider.HookSubscription, error) {
+	return &m.subscriptions, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscriptionsForEvent(userID int, event string) (*[]provider.HookSubscription, error) {
+	return &m.subscriptions, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetSubscriptionsForProviderType(event string, providerType string) (*[]provider.HookSubscription, error) {
+	return &m.subscriptions, nil
+}
+
+func (m *mockHookSubscriptionRepository) GetUserSubscription(userID int, id int) (*provider.HookSubscription, error) {
+	return &m.subscriptions[id-1], nil
+}
+
+func (m *mockHookSubscriptionRepository) GetAll() (*[]provider.HookSubscription, error) {
+	return &m.subscriptions, nil
+}
+
+func (m *mockHookSubscriptionRepository) UpdateSecret(id int, secret string) error {
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) UpdateDelivery(id int, delivery provider.HookDelivery) error {
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) Delete(userID int, id int) error {
+	return m.DeleteByID(id)
+}
+
+func (m *mockHookSubscriptionRepository) DeleteByID(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *mockHookSubscriptionRepository) deletedIDs() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.deleted...)
+}
+
+type mockWebhookEventRepository struct {
+	mu     sync.Mutex
+	events []provider.WebhookEvent
+}
+
+func (m *mockWebhookEventRepository) Create(event *provider.WebhookEvent) (*provider.WebhookEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event.ID = len(m.events) + 1
+	m.events = append(m.events, *event)
+	return event, nil
+}
+
+func (m *mockWebhookEventRepository) GetUserEvents(userID int, event string, page domain.PageRequest) (*domain.Page[provider.WebhookEvent], error) {
+	return &domain.Page[provider.WebhookEvent]{Items: m.events}, nil
+}
+
+func (m *mockWebhookEventRepository) GetUserEvent(userID int, id int) (*provider.WebhookEvent, error) {
+	return &m.events[id-1], nil
+}
+
+func (m *mockWebhookEventRepository) DeleteBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestHookDispatcher(t *testing.T, repo *mockHookSubscriptionRepository) *HookDispatcher {
+	return newTestHookDispatcherWithEvents(t, repo, &mockWebhookEventRepository{})
+}
+
+func newTestHookDispatcherWithEvents(t *testing.T, repo *mockHookSubscriptionRepository, events *mockWebhookEventRepository) *HookDispatcher {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return NewHookDispatcher(repo, events, nil, loggerInstance)
+}
+
+func TestHookDispatcherVerify(t *testing.T) {
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, hookEventVerify, r.Header.Get(HookEventHeader))
+		w.Header().Set(HookSecretHeader, r.Header.Get(HookSecretHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer echo.Close()
+
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer silent.Close()
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HookSecretHeader, r.Header.Get(HookSecretHeader))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer rejecting.Close()
+
+	dispatcher := newTestHookDispatcher(t, &mockHookSubscriptionRepository{})
+	assert.NoError(t, dispatcher.Verify(echo.URL, "secret"))
+	assert.Error(t, dispatcher.Verify(silent.URL, "secret"))
+	assert.Error(t, dispatcher.Verify(rejecting.URL, "secret"))
+}
+
+func TestHookDispatcherDeliversSignedEvents(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 1, UserID: 7, Event: HookEventMessageFailed, TargetURL: server.URL, Secret: "secret"}}}
+	events := &mockWebhookEventRepository{}
+	newTestHookDispatcherWithEvents(t, repo, events).DispatchToUser(7, hookEventForStatus("failed"), map[string]string{"status": "failed"})
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		assert.Equal(t, HookEventMessageFailed, r.Header.Get(HookEventHeader))
+		assert.Equal(t, "1", r.Header.Get(HookEventIDHeader))
+		assert.Empty(t, r.Header.Get(HookReplayHeader))
+		assert.Len(t, events.events, 1)
+		assert.Equal(t, 7, events.events[0].UserID)
+		assert.JSONEq(t, string(body), events.events[0].Payload)
+		assert.Equal(t, signHookBody("secret", body), r.Header.Get(HookSignatureHeader))
+		assert.JSONEq(t, `{"status":"failed"}`, string(body))
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook event was not delivered")
+	}
+}
+
+func TestHookDispatcherUnsubscribesGoneTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 3, UserID: 7, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret"}}}
+	newTestHookDispatcher(t, repo).DispatchToProviderType("signal", HookEventMessageReceived, map[string]string{})
+
+	assert.Eventually(t, func() bool { return len(repo.deletedIDs()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{3}, repo.deletedIDs())
+}
+
+func TestHookDispatcherStoresEventsOncePerUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{
+		{ID: 1, UserID: 7, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret"},
+		{ID: 2, UserID: 7, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret"},
+		{ID: 3, UserID: 8, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret"},
+	}}
+	events := &mockWebhookEventRepository{}
+	newTestHookDispatcherWithEvents(t, repo, events).DispatchToProviderType("signal", HookEventMessageReceived, map[string]string{})
+
+	assert.Len(t, events.events, 2)
+	assert.Equal(t, 7, events.events[0].UserID)
+	assert.Equal(t, 8, events.events[1].UserID)
+}
+
+func TestHookDispatcherReplaysEvents(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 1, UserID: 7, Event: HookEventMessageFailed, TargetURL: server.URL, Secret: "secret"}}}
+	event := &provider.WebhookEvent{ID: 42, UserID: 7, Event: HookEventMessageFailed, Payload: `{"status":"failed"}`}
+
+	results, err := newTestHookDispatcher(t, repo).Replay(event)
+	assert.NoError(t, err)
+	assert.Equal(t, []provider.WebhookReplayResult{{SubscriptionID: 1, TargetURL: server.URL, StatusCode: http.StatusAccepted}}, results)
+
+	r := <-received
+	assert.Equal(t, "42", r.Header.Get(HookEventIDHeader))
+	assert.Equal(t, "true", r.Header.Get(HookReplayHeader))
+	assert.Equal(t, signHookBody("secret", []byte(event.Payload)), r.Header.Get(HookSignatureHeader))
+}
+
+func TestHookDispatcherSignsWithDecryptedSecret(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	encrypted, err := secrets.Encrypt(7, "secret")
+	require.NoError(t, err)
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 1, UserID: 7, Event: HookEventMessageFailed, TargetURL: server.URL, Secret: encrypted}}}
+	event := &provider.WebhookEvent{ID: 42, UserID: 7, Event: HookEventMessageFailed, Payload: `{"status":"failed"}`}
+
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	_, err = NewHookDispatcher(repo, &mockWebhookEventRepository{}, secrets, loggerInstance).Replay(event)
+	require.NoError(t, err)
+	r := <-received
+	assert.Equal(t, signHookBody("secret", []byte(event.Payload)), r.Header.Get(HookSignatureHeader))
+
+	// Without the key an encrypted secret can't sign, the delivery fails rather than being signed with the ciphertext
+	results, err := newTestHookDispatcher(t, repo).Replay(event)
+	require.NoError(t, err)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+func TestHookDispatcherDeliversWithDeliverySettings(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	authorization, err := secrets.Encrypt(7, "Bearer static")
+	require.NoError(t, err)
+	delivery := provider.HookDelivery{
+		Headers:       map[string]string{"Authorization": authorization, HookEventHeader: "spoofed"},
+		CACertificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+	}
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 1, UserID: 7, Event: HookEventMessageFailed, TargetURL: server.URL, Secret: "secret", Delivery: delivery}}}
+	event := &provider.WebhookEvent{ID: 42, UserID: 7, Event: HookEventMessageFailed, Payload: `{"status":"failed"}`}
+
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	dispatcher := NewHookDispatcher(repo, &mockWebhookEventRepository{}, secrets, loggerInstance)
+	results, err := dispatcher.Replay(event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	r := <-received
+	assert.Equal(t, "Bearer static", r.Header.Get("Authorization"))
+	// The headers of the hook protocol win over custom headers of the same name
+	assert.Equal(t, HookEventMessageFailed, r.Header.Get(HookEventHeader))
+
+	// The shared client doesn't trust the self-signed certificate of the target
+	repo.subscriptions[0].Delivery.CACertificate = ""
+	repo.subscriptions[0].ID = 2
+	results, err = dispatcher.Replay(event)
+	require.NoError(t, err)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+func TestHookDispatcherForgetsClients(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	delivery := provider.HookDelivery{CACertificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))}
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{{ID: 1, UserID: 7, Event: HookEventMessageFailed, TargetURL: server.URL, Secret: "secret", Delivery: delivery}}}
+	event := &provider.WebhookEvent{ID: 42, UserID: 7, Event: HookEventMessageFailed, Payload: `{"status":"failed"}`}
+	dispatcher := newTestHookDispatcher(t, repo)
+	results, err := dispatcher.Replay(event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+
+	// The kept client is used until it is dropped, then the updated settings apply
+	repo.subscriptions[0].Delivery.CACertificate = "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"
+	results, err = dispatcher.Replay(event)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	dispatcher.ForgetClient(1)
+	results, err = dispatcher.Replay(event)
+	require.NoError(t, err)
+	assert.NotEmpty(t, results[0].Error)
+}
+
+func TestHookDispatcherFiltersReceivedEnvelopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := &mockHookSubscriptionRepository{subscriptions: []provider.HookSubscription{
+		{ID: 1, UserID: 7, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret"},
+		{ID: 2, UserID: 8, Event: HookEventMessageReceived, TargetURL: server.URL, Secret: "secret", EnvelopeTypes: []string{"reaction"}},
+	}}
+	events := &mockWebhookEventRepository{}
+	dispatcher := newTestHookDispatcherWithEvents(t, repo, events)
+
+	// Reactions are only delivered to the subscriptions asking for them
+	dispatcher.DispatchReceivedEnvelope("signal", domainSignal.EnvelopeTypeReaction, map[string]string{"type": "reaction"})
+	require.Len(t, events.events, 1)
+	assert.Equal(t, 8, events.events[0].UserID)
+
+	// Payments are a default type
+	dispatcher.DispatchReceivedEnvelope("signal", domainSignal.EnvelopeTypePayment, map[string]string{"type": "payment"})
+	require.Len(t, events.events, 2)
+	assert.Equal(t, 7, events.events[1].UserID)
+
+	results, err := dispatcher.Replay(&provider.WebhookEvent{ID: 1, UserID: 8, Event: HookEventMessageReceived, Payload: `{"type":"payment","envelope":{}}`})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 1, results[0].SubscriptionID)
+}
@@ -0,0 +1,25 @@
+package messaging
+
+import (
+	"encoding/json"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+// NewOutboundMessageEvent builds the event of a message transaction in the given status. Its time is the creation
+// time of the message, so the status events of a message keep its place among the messages of a conversation.
+func NewOutboundMessageEvent(msg *provider.MessageTransaction, status string) *provider.MessageEvent {
+	var recipients []string
+	_ = json.Unmarshal([]byte(msg.Recipients), &recipients)
+	return &provider.MessageEvent{
+		UserID:       msg.UserID,
+		ProviderID:   msg.ProviderID,
+		Participants: recipients,
+		Direction:    provider.DirectionOutbound,
+		MessageID:    msg.ID,
+		Body:         msg.Message,
+		Status:       status,
+		Tags:         msg.Tags,
+		OccurredAt:   msg.CreatedAt,
+	}
+}
@@ -2,67 +2,158 @@ package messaging
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go-multi-chat-api/src/domain/provider"
-	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/events"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	"go-multi-chat-api/src/infrastructure/leader"
 	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/payload"
 	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
-	domainSignal "go-multi-chat-api/src/infrastructure/repository/signal-client"
-	"go-multi-chat-api/src/infrastructure/rest/controllers/signal"
-	"go-multi-chat-api/src/infrastructure/utils"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
 
 	"go.uber.org/zap"
 )
 
+// errProviderDrill fails the messages routed to a provider in a failover drill
+var errProviderDrill = errors.New("provider outage simulated by a failover drill")
+
+// LinkPersonalizer replaces the tracked URLs of a message with the short links of each recipient
+type LinkPersonalizer interface {
+	// Personalize returns the text of the message for each recipient, in the order of the recipients
+	Personalize(messageID int, message string, recipients []string) ([]string, error)
+}
+
 // MessageProcessor handles the processing of messages using a worker pool
 type MessageProcessor struct {
-	signalService                       *domainSignal.SignalClient
+	senders                             map[string]ProviderSender
 	providerRepository                  providerRepo.ProviderRepositoryInterface
 	userProviderRepository              providerRepo.UserProviderRepositoryInterface
 	messageTransactionRepository        providerRepo.MessageTransactionRepositoryInterface
 	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	providerDrillRepository             providerRepo.ProviderDrillRepositoryInterface
+	messageDeliveryRepository           providerRepo.MessageDeliveryRepositoryInterface
+	rateLimitChallengeRepository        signalRepo.RateLimitChallengeRepositoryInterface
+	payloadPolicy                       *payload.Policy
 	Logger                              *logger.Logger
 	workerCount                         int
-	messageQueue                        chan *provider.MessageTransaction
+	maxInFlightPerUser                  int
+	recovery                            RecoveryConfig
+	watcher                             WatcherConfig
+	elector                             leader.Elector
+	hookDispatcher                      *HookDispatcher
+	linkPersonalizer                    LinkPersonalizer
+	locales                             LocaleResolver
+	eventBus                            *events.Bus
+	messageQueue                        *fairQueue
 	wg                                  sync.WaitGroup
 	shutdown                            chan struct{}
+	deferredCount                       atomic.Int64
+	rejectedCount                       atomic.Int64
+}
+
+// QueueStats reports the saturation of the processing queue
+type QueueStats struct {
+	Depth    int
+	Capacity int
+	// Deferred counts messages that found the queue full and were left pending for the watcher instead of being dropped
+	Deferred int64
+	// Rejected counts send requests refused because the pending backlog exceeded its threshold
+	Rejected int64
+	// MaxInFlightPerUser is the limit of workers one user can take, 0 when it isn't limited
+	MaxInFlightPerUser int
+	// Users are the users with messages being processed or queued
+	Users []UserLoad
 }
 
 // WebhookConfig represents the webhook configuration in the user provider config
 type WebhookConfig struct {
 	WebhookURL string `json:"webhook_url"`
 	Enabled    bool   `json:"webhook_enabled"`
+	Version    string `json:"webhook_version"` // payload schema version, defaults to v1
+	// Headers are sent with every webhook request, e.g. an Authorization header. Their values are stored
+	// encrypted when CREDENTIAL_ENCRYPTION_KEY is set.
+	Headers map[string]string `json:"webhook_headers"`
+	// ClientCertificate and ClientKey are the PEM certificate and key presented to a webhook URL asking for one,
+	// the key is stored encrypted
+	ClientCertificate string `json:"webhook_client_certificate"`
+	ClientKey         string `json:"webhook_client_key"`
+	// CACertificate holds PEM CAs trusted for the webhook URL besides the system roots
+	CACertificate string `json:"webhook_ca_certificate"`
+	// InsecureSkipVerify turns off the verification of the certificate of the webhook URL
+	InsecureSkipVerify bool `json:"webhook_insecure_skip_verify"`
+}
+
+// Delivery returns the delivery settings of the webhook, the same settings as those of hook subscriptions
+func (c WebhookConfig) Delivery() provider.HookDelivery {
+	return provider.HookDelivery{
+		Headers:            c.Headers,
+		ClientCertificate:  c.ClientCertificate,
+		ClientKey:          c.ClientKey,
+		CACertificate:      c.CACertificate,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
 }
 
-// NewMessageProcessor creates a new message processor with the specified number of workers
+// NewMessageProcessor creates a new message processor with the specified number of workers, of which a single user
+// takes at most maxInFlightPerUser at a time unless it is 0
 func NewMessageProcessor(
-	signalService *domainSignal.SignalClient,
+	senders map[string]ProviderSender,
 	providerRepository providerRepo.ProviderRepositoryInterface,
 	userProviderRepository providerRepo.UserProviderRepositoryInterface,
 	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
 	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	providerDrillRepository providerRepo.ProviderDrillRepositoryInterface,
+	messageDeliveryRepository providerRepo.MessageDeliveryRepositoryInterface,
+	rateLimitChallengeRepository signalRepo.RateLimitChallengeRepositoryInterface,
+	payloadPolicy *payload.Policy,
 	loggerInstance *logger.Logger,
 	workerCount int,
+	maxInFlightPerUser int,
+	recovery RecoveryConfig,
+	watcher WatcherConfig,
+	elector leader.Elector,
+	hookDispatcher *HookDispatcher,
+	linkPersonalizer LinkPersonalizer,
+	locales LocaleResolver,
+	eventBus *events.Bus,
 ) *MessageProcessor {
 	if workerCount <= 0 {
 		workerCount = 10 // Default to 10 workers if not specified
 	}
 
 	processor := &MessageProcessor{
-		signalService:                       signalService,
+		senders:                             senders,
 		providerRepository:                  providerRepository,
 		userProviderRepository:              userProviderRepository,
 		messageTransactionRepository:        messageTransactionRepository,
 		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		providerDrillRepository:             providerDrillRepository,
+		messageDeliveryRepository:           messageDeliveryRepository,
+		rateLimitChallengeRepository:        rateLimitChallengeRepository,
+		payloadPolicy:                       payloadPolicy,
 		Logger:                              loggerInstance,
 		workerCount:                         workerCount,
-		messageQueue:                        make(chan *provider.MessageTransaction, 1000), // Buffer size of 1000
+		maxInFlightPerUser:                  maxInFlightPerUser,
+		recovery:                            recovery,
+		watcher:                             watcher.withDefaults(),
+		elector:                             elector,
+		hookDispatcher:                      hookDispatcher,
+		linkPersonalizer:                    linkPersonalizer,
+		locales:                             locales,
+		eventBus:                            eventBus,
+		messageQueue:                        newFairQueue(1000, maxInFlightPerUser), // Buffer size of 1000
 		shutdown:                            make(chan struct{}),
 	}
 
@@ -77,7 +168,7 @@ func NewMessageProcessor(
 
 // startWorkers starts the worker pool
 func (p *MessageProcessor) startWorkers() {
-	p.Logger.Info("Starting message processor workers", zap.Int("workerCount", p.workerCount))
+	p.Logger.Info("Starting message processor workers", zap.Int("workerCount", p.workerCount), zap.Int("maxInFlightPerUser", p.maxInFlightPerUser))
 
 	for i := 0; i < p.workerCount; i++ {
 		p.wg.Add(1)
@@ -85,36 +176,60 @@ func (p *MessageProcessor) startWorkers() {
 	}
 }
 
-// worker processes messages from the queue
+// worker processes messages from the queue, taking turns between the users with queued messages
 func (p *MessageProcessor) worker(id int) {
 	defer p.wg.Done()
 
 	p.Logger.Info("Starting message processor worker", zap.Int("workerID", id))
 
 	for {
-		select {
-		case msg := <-p.messageQueue:
-			p.processMessage(msg)
-		case <-p.shutdown:
+		msg, ok := p.messageQueue.next()
+		if !ok {
 			p.Logger.Info("Shutting down message processor worker", zap.Int("workerID", id))
 			return
 		}
+		p.processMessage(msg)
+		p.messageQueue.done(msg)
 	}
 }
 
-// watchPendingMessages periodically checks for pending messages and undelivered messages and adds them to the queue
+// watchPendingMessages periodically checks for pending messages and undelivered messages and adds them to the queue.
+// The checks only run on the leader instance, the other instances process the messages queued to them directly.
+// Every check is delayed by a random jitter, so instances started together don't all query at once.
 func (p *MessageProcessor) watchPendingMessages() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	timer := time.NewTimer(p.watcher.jitter())
+	defer timer.Stop()
 
-	// Process pending messages immediately on startup
-	p.checkPendingMessages()
+	// Recover messages stranded by a previous crash, then process pending messages right after startup
+	select {
+	case <-timer.C:
+	case <-p.shutdown:
+		return
+	}
+	recovered := false
+	if p.elector.IsLeader() {
+		p.RecoverStaleMessages()
+		p.checkPendingMessages()
+		recovered = true
+	}
+	timer.Reset(p.watcher.nextCheck())
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			// The next check is scheduled once this one is done, a slow check doesn't make checks pile up
+			if !p.elector.IsLeader() {
+				timer.Reset(p.watcher.nextCheck())
+				continue
+			}
+			// An instance that takes over leadership recovers what the previous leader left behind
+			if !recovered {
+				p.RecoverStaleMessages()
+				recovered = true
+			}
 			p.checkPendingMessages()
 			p.checkUndeliveredMessages()
+			timer.Reset(p.watcher.nextCheck())
 		case <-p.shutdown:
 			return
 		}
@@ -123,6 +238,11 @@ func (p *MessageProcessor) watchPendingMessages() {
 
 // checkPendingMessages queries the database for pending messages and adds them to the queue
 func (p *MessageProcessor) checkPendingMessages() {
+	// Release messages held back by warm-up limits or sending schedules once their hold has expired
+	if _, err := p.messageTransactionRepository.ReleaseHeldMessages(); err != nil {
+		p.Logger.Error("Error releasing held messages", zap.Error(err))
+	}
+
 	// Get pending messages
 	pendingMessages, err := p.messageTransactionRepository.GetPendingMessages()
 	if err != nil {
@@ -137,22 +257,28 @@ func (p *MessageProcessor) checkPendingMessages() {
 	p.Logger.Info("Found pending messages to process", zap.Int("count", len(*pendingMessages)))
 
 	// Add messages to the queue
+	var skipped []int
 	for _, msg := range *pendingMessages {
-		select {
-		case p.messageQueue <- &msg:
-			// Message added to queue
-		default:
-			// Queue is full, log and continue
-			p.Logger.Warn("Message queue is full, skipping message", zap.Int("messageID", msg.ID))
+		if !p.messageQueue.push(&msg) {
+			skipped = append(skipped, msg.ID)
+		}
+	}
+
+	// Unlock the messages that didn't fit so the next check picks them up instead of leaving them stranded
+	if len(skipped) > 0 {
+		p.deferredCount.Add(int64(len(skipped)))
+		p.Logger.Warn("Message queue is full, deferring pending messages to the next check", zap.Int("count", len(skipped)))
+		if err := p.messageTransactionRepository.ReleaseProcessing(skipped); err != nil {
+			p.Logger.Error("Error releasing deferred messages", zap.Error(err))
 		}
 	}
 }
 
-// checkUndeliveredMessages queries the database for messages that were sent successfully but not delivered within 5 minutes
-// and sends them via an alternative provider
+// checkUndeliveredMessages queries the database for messages that were sent successfully but not delivered within
+// the UndeliveredAfter of the watcher and sends them via an alternative provider
 func (p *MessageProcessor) checkUndeliveredMessages() {
 	// Get undelivered messages
-	undeliveredMessages, err := p.messageTransactionRepository.GetUndeliveredMessages()
+	undeliveredMessages, err := p.messageTransactionRepository.GetUndeliveredMessages(time.Now().Add(-p.watcher.UndeliveredAfter))
 	if err != nil {
 		p.Logger.Error("Error getting undelivered messages", zap.Error(err))
 		return
@@ -164,259 +290,596 @@ func (p *MessageProcessor) checkUndeliveredMessages() {
 
 	p.Logger.Info("Found undelivered messages to process", zap.Int("count", len(*undeliveredMessages)))
 
-	// Process each undelivered message
+	// Pick the fallback provider of each message, the writes are batched below
+	var fallbacks []provider.MessageTransaction
+	var originalIDs []int
+	var deliveredIDs []int
 	for _, msg := range *undeliveredMessages {
+		log := p.messageLogger(&msg)
 		// Get user providers sorted by priority
 		userProviders, err := p.userProviderRepository.GetUserProvidersByPriority(msg.UserID)
 		if err != nil {
-			p.Logger.Error("Error getting user providers for fallback", zap.Error(err), zap.Int("userID", msg.UserID))
+			log.Error("Error getting user providers for fallback", zap.Error(err))
 			continue
 		}
 
 		// Find the next provider to try (skip the current provider)
 		var nextProvider *provider.UserProvider
 		for _, up := range *userProviders {
-			if up.ProviderID != msg.ProviderID {
+			if up.ProviderID != msg.ProviderID && !p.InDrill(msg.UserID, up.ProviderID) {
 				nextProvider = &up
 				break
 			}
 		}
 
 		if nextProvider == nil {
-			p.Logger.Warn("No alternative provider found for fallback", zap.Int("userID", msg.UserID), zap.Int("messageID", msg.ID))
-			p.updateMessageStatus(msg.ID, "delivered", "", "")
+			log.Warn("No alternative provider found for fallback")
+			deliveredIDs = append(deliveredIDs, msg.ID)
 			continue
 		}
 
-		p.Logger.Info("Found alternative provider for fallback",
-			zap.Int("userID", msg.UserID),
-			zap.Int("messageID", msg.ID),
+		log.Info("Found alternative provider for fallback",
 			zap.Int("originalProviderID", msg.ProviderID),
 			zap.Int("newProviderID", nextProvider.ProviderID))
 
 		// Create a new message transaction with the new provider
-		newMsg := &provider.MessageTransaction{
+		fallbacks = append(fallbacks, provider.MessageTransaction{
 			UserID:     msg.UserID,
 			ProviderID: nextProvider.ProviderID,
 			Recipients: msg.Recipients,
 			Message:    msg.Message,
-			Status:     "pending",
+			Extensions: msg.Extensions,
+			Actions:    msg.Actions,
+			Status:     provider.MessageStatusPending,
 			Processing: false,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
-		}
+		})
+		originalIDs = append(originalIDs, msg.ID)
+	}
 
-		// Save the new message transaction
-		newMsg, err = p.messageTransactionRepository.Create(newMsg)
-		if err != nil {
-			p.Logger.Error("Error creating fallback message transaction", zap.Error(err), zap.Int("userID", msg.UserID))
-			continue
-		}
+	if err := p.messageTransactionRepository.UpdateBatch(deliveredIDs, map[string]interface{}{
+		"status":     provider.MessageStatusDelivered,
+		"processing": false,
+	}); err != nil {
+		p.Logger.Error("Error updating message status", zap.Error(err), zap.Ints("messageIDs", deliveredIDs))
+	}
 
-		// Update the original message status to indicate it was not delivered and a fallback was triggered
-		updateData := map[string]interface{}{
-			"status":       "fallback_triggered",
-			"errorMessage": "Message not delivered within 5 minutes, fallback to alternative provider triggered",
-			"processing":   false,
-		}
+	if len(fallbacks) == 0 {
+		return
+	}
 
-		_, err = p.messageTransactionRepository.Update(msg.ID, updateData)
-		if err != nil {
-			p.Logger.Error("Error updating original message status", zap.Error(err), zap.Int("messageID", msg.ID))
-		}
+	// Save the new message transactions
+	created, err := p.messageTransactionRepository.CreateBatch(fallbacks)
+	if err != nil {
+		p.Logger.Error("Error creating fallback message transactions", zap.Error(err), zap.Int("count", len(fallbacks)))
+		return
+	}
 
-		// Move the original transaction to history
-		err = p.messageTransactionRepository.MoveToHistory(msg.ID, p.messageTransactionHistoryRepository)
-		if err != nil {
-			p.Logger.Error("Error moving original message to history", zap.Error(err), zap.Int("messageID", msg.ID))
-		}
+	// Update the original messages status to indicate they were not delivered and a fallback was triggered
+	updateData := map[string]interface{}{
+		"status":       provider.MessageStatusFallbackTriggered,
+		"errorMessage": fmt.Sprintf("Message not delivered within %s, fallback to alternative provider triggered", p.watcher.UndeliveredAfter),
+		"processing":   false,
+	}
+	if err := p.messageTransactionRepository.UpdateBatch(originalIDs, updateData); err != nil {
+		p.Logger.Error("Error updating original message status", zap.Error(err), zap.Ints("messageIDs", originalIDs))
+	}
 
-		// Add the new message to the queue
-		select {
-		case p.messageQueue <- newMsg:
-			p.Logger.Info("Fallback message added to queue", zap.Int("newMessageID", newMsg.ID), zap.Int("originalMessageID", msg.ID))
-		default:
+	// Move the original transactions to history
+	if err := p.messageTransactionRepository.MoveToHistoryBatch(originalIDs, p.messageTransactionHistoryRepository); err != nil {
+		p.Logger.Error("Error moving original messages to history", zap.Error(err), zap.Ints("messageIDs", originalIDs))
+	}
+
+	// Add the new messages to the queue
+	for i := range *created {
+		newMsg := &(*created)[i]
+		if p.messageQueue.push(newMsg) {
+			p.Logger.Info("Fallback message added to queue", zap.Int("newMessageID", newMsg.ID), zap.Int("originalMessageID", originalIDs[i]))
+		} else {
 			p.Logger.Warn("Message queue is full, fallback message not queued", zap.Int("newMessageID", newMsg.ID))
 		}
 	}
 }
 
-// EnqueueMessage adds a message to the processing queue
-func (p *MessageProcessor) EnqueueMessage(msg *provider.MessageTransaction) {
-	select {
-	case p.messageQueue <- msg:
-		p.Logger.Info("Message added to processing queue", zap.Int("messageID", msg.ID))
-	default:
-		p.Logger.Warn("Message queue is full, message not queued", zap.Int("messageID", msg.ID))
+// EnqueueMessage adds a message to the processing queue. It returns false when the queue is full, the
+// message then stays pending and is picked up by the pending message watcher.
+func (p *MessageProcessor) EnqueueMessage(msg *provider.MessageTransaction) bool {
+	log := p.messageLogger(msg)
+	if !p.messageQueue.push(msg) {
+		p.deferredCount.Add(1)
+		log.Warn("Message queue is full, message left pending for the watcher")
+		return false
 	}
+	log.Info("Message added to processing queue")
+	return true
+}
+
+// RecordRejected counts a send request refused because of backpressure
+func (p *MessageProcessor) RecordRejected() {
+	p.rejectedCount.Add(1)
+}
+
+// Stats returns the current saturation of the processing queue
+func (p *MessageProcessor) Stats() QueueStats {
+	return QueueStats{
+		Depth:              p.messageQueue.depth(),
+		Capacity:           p.messageQueue.capacity,
+		Deferred:           p.deferredCount.Load(),
+		Rejected:           p.rejectedCount.Load(),
+		MaxInFlightPerUser: p.maxInFlightPerUser,
+		Users:              p.messageQueue.loads(),
+	}
+}
+
+// messageLogger returns a child logger adding the user and the message to the entries about a message
+func (p *MessageProcessor) messageLogger(msg *provider.MessageTransaction) logger.ILogger {
+	return p.Logger.With(zap.Int("userID", msg.UserID), zap.Int("messageID", msg.ID))
 }
 
 // processMessage processes a single message
 func (p *MessageProcessor) processMessage(msg *provider.MessageTransaction) {
-	p.Logger.Info("Processing message", zap.Int("messageID", msg.ID), zap.Int("userID", msg.UserID), zap.Int("providerID", msg.ProviderID))
+	log := p.messageLogger(msg)
+	log.Info("Processing message", zap.Int("providerID", msg.ProviderID))
 
 	// Get provider details
 	providerDetails, err := p.providerRepository.GetByID(msg.ProviderID)
 	if err != nil {
-		p.Logger.Error("Error getting provider details", zap.Error(err), zap.Int("providerID", msg.ProviderID))
-		p.updateMessageStatus(msg.ID, "failed", err.Error(), "")
+		log.Error("Error getting provider details", zap.Error(err), zap.Int("providerID", msg.ProviderID))
+		p.failMessage(msg, err)
 		return
 	}
 
 	// Skip inactive providers
 	if !providerDetails.Status {
 		err := errors.New("provider is inactive")
-		p.Logger.Warn("Provider is inactive", zap.Int("providerID", msg.ProviderID))
-		p.updateMessageStatus(msg.ID, "failed", err.Error(), "")
+		log.Warn("Provider is inactive", zap.Int("providerID", msg.ProviderID))
+		p.failMessage(msg, err)
 		return
 	}
 
-	// Prepare request data based on provider type
-	var requestData []byte
-	var responseData []byte
-	var sendErr error
+	// Hold the message until the provider's sending schedule opens
+	if p.holdForSchedule(msg, providerDetails) {
+		return
+	}
+
+	// Hold the message if the provider's number is still warming up and has reached today's limit
+	if p.holdForWarmup(msg, providerDetails) {
+		return
+	}
+
+	// Claim the send so a message queued twice, or recovered after a crash, is never sent twice
+	claimed, err := p.messageTransactionRepository.MarkSendStarted(msg.ID)
+	if err != nil {
+		// Leave the message in processing, it is requeued by the stale message recovery
+		log.Error("Error claiming message send", zap.Error(err))
+		return
+	}
+	if !claimed {
+		log.Warn("Message send already started, skipping duplicate")
+		return
+	}
 
 	// Parse recipients from JSON
 	var recipients []string
 	json.Unmarshal([]byte(msg.Recipients), &recipients)
 
-	switch providerDetails.Type {
-	case string(alert.TypeSignal):
-		// Send via Signal
-		var signalRequest = signal.SendMessage{
-			Number:     os.Getenv("SIGNAL_FROM_NUMBER"),
-			Message:    msg.Message,
-			Recipients: recipients,
-		}
-
-		textMode := signalRequest.TextMode
-		if textMode == nil {
-			defaultSignalTextMode := utils.GetEnv("DEFAULT_SIGNAL_TEXT_MODE", "normal")
-			if defaultSignalTextMode == "styled" {
-				styledStr := "styled"
-				textMode = &styledStr
-			}
-		}
-
-		requestData, _ = json.Marshal(signalRequest)
-
-		data, sendErr := p.signalService.SendV2(
-			signalRequest.Number, signalRequest.Message, signalRequest.Recipients, signalRequest.Base64Attachments, signalRequest.Sticker,
-			signalRequest.Mentions, signalRequest.QuoteTimestamp, signalRequest.QuoteAuthor, signalRequest.QuoteMessage, signalRequest.QuoteMentions,
-			textMode, signalRequest.EditTimestamp, signalRequest.NotifySelf, signalRequest.LinkPreview, signalRequest.ViewOnce)
+	var requestData, responseData []byte
+	var sendErr error
+	if p.InDrill(msg.UserID, msg.ProviderID) {
+		// Fail like a provider outage would, so retries, fallback and the failed webhook run as in a real one
+		sendErr = errProviderDrill
+		log.Warn("Provider is in a failover drill, failing message", zap.Int("providerID", msg.ProviderID))
+	} else if msg.TrackLinks && p.linkPersonalizer != nil {
+		requestData, responseData, sendErr = p.sendWithTrackedLinks(msg, providerDetails, recipients)
+	} else {
+		requestData, responseData, sendErr = p.send(msg, providerDetails, msg.Message, recipients)
+	}
 
-		if sendErr == nil && data != nil {
-			responseData, _ = json.Marshal(data)
-		}
-	case string(alert.TypeEmail):
-		// Email implementation would go here
-		sendErr = errors.New("email provider not implemented yet")
-	default:
-		sendErr = errors.New("unsupported provider type: " + providerDetails.Type)
+	// Hold the message until the rate limit challenge of the account is solved, Signal refused it so it can be sent again
+	var rateLimitErr *domainSignal.RateLimitError
+	if errors.As(sendErr, &rateLimitErr) {
+		p.holdForRateLimit(msg, requestData, rateLimitErr)
+		return
 	}
 
-	// Update transaction with request/response data
+	// Record the message each recipient was sent, delivery callbacks of the provider report on it
+	p.recordDeliveries(msg, providerDetails, responseData)
+
+	// Update transaction with request/response data, and the messages billed by the provider that sent it, which
+	// differ from the provider the message was queued for after a fallback
 	updateData := map[string]interface{}{
-		"requestData": string(requestData),
+		"requestData": p.payloadPolicy.Apply(payloadKey(msg.ID, "request"), requestData),
 		"processing":  false, // Mark as not being processed anymore
+		"segments":    MessageSegments(providerDetails.Type, msg.Message),
 	}
 
 	if sendErr != nil {
-		updateData["status"] = "failed"
+		msg.ErrorCode = p.errorCode(providerDetails.Type, sendErr)
+		action, backoff := p.RetryPolicies(providerDetails).Decide(msg.ErrorCode, msg.RetryCount)
 		updateData["errorMessage"] = sendErr.Error()
+		updateData["errorCode"] = msg.ErrorCode
 		updateData["responseData"] = ""
-		// Set next retry time to 3 minutes from now
-		nextRetry := time.Now().Add(3 * time.Minute)
+		// Set the next retry time by the retry policy of the error, suppressed messages aren't retried
+		var nextRetry *time.Time
+		if action != provider.FailureSuppress {
+			retryAt := time.Now().Add(backoff)
+			nextRetry = &retryAt
+		}
 		updateData["nextRetryAt"] = nextRetry
 
-		p.Logger.Error("Error sending message",
+		log.Error("Error sending message",
 			zap.Error(sendErr),
-			zap.Int("userID", msg.UserID),
 			zap.Int("providerID", msg.ProviderID),
-			zap.Time("nextRetryAt", nextRetry))
+			zap.String("errorCode", msg.ErrorCode),
+			zap.String("action", action))
 
-		// Update transaction with error
-		_, err = p.messageTransactionRepository.Update(msg.ID, updateData)
-		if err != nil {
-			p.Logger.Error("Error updating message transaction", zap.Error(err))
-		}
-
-		// Move the transaction to history
-		err = p.messageTransactionRepository.MoveToHistory(msg.ID, p.messageTransactionHistoryRepository)
-		if err != nil {
-			p.Logger.Error("Error moving message transaction to history", zap.Error(err), zap.Int("messageID", msg.ID))
-		}
-
-		// Send webhook notification for failed message
-		p.sendWebhookNotification(msg.UserID, msg.ID, "failed", sendErr.Error())
+		p.transition(msg, provider.MessageStatusFailed, updateData, newReason(sendErr.Error()))
 	} else {
 		// Message sent successfully
-		updateData["status"] = "success"
-		updateData["responseData"] = string(responseData)
+		updateData["responseData"] = p.payloadPolicy.Apply(payloadKey(msg.ID, "response"), responseData)
 		updateData["errorMessage"] = ""
 
-		_, err = p.messageTransactionRepository.Update(msg.ID, updateData)
-		if err != nil {
-			p.Logger.Error("Error updating message transaction", zap.Error(err))
+		if p.transition(msg, provider.MessageStatusSuccess, updateData, reason{}) {
+			log.Info("Message sent successfully", zap.Int("providerID", msg.ProviderID))
 		}
+	}
+}
 
-		// Move the transaction to history
-		err = p.messageTransactionRepository.MoveToHistory(msg.ID, p.messageTransactionHistoryRepository)
-		if err != nil {
-			p.Logger.Error("Error moving message transaction to history", zap.Error(err), zap.Int("messageID", msg.ID))
+// RetryPolicies returns the retry policies of a provider, a provider with an invalid policy falls back to the
+// defaults
+func (p *MessageProcessor) RetryPolicies(providerDetails *provider.Provider) RetryPolicies {
+	policies, err := ParseRetryPolicies(providerDetails.Config)
+	if err != nil {
+		p.Logger.Error("Invalid retry policy in provider config, using the defaults", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		policies, _ = ParseRetryPolicies("")
+	}
+	return policies
+}
+
+// errorCode classifies an error of a send through a provider of the type, by the sender of the type first and
+// then by the errors every provider may fail with
+func (p *MessageProcessor) errorCode(providerType string, err error) string {
+	if classifier, ok := p.senders[providerType].(ErrorClassifier); ok {
+		if code := classifier.ErrorCode(err); code != "" {
+			return code
 		}
+	}
+	var recipientErr *provider.RecipientError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &recipientErr):
+		return provider.ErrorCodeInvalidRecipient
+	case errors.Is(err, errProviderDrill), errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return provider.ErrorCodeNetwork
+	}
+	return provider.ErrorCodeUnknown
+}
 
-		p.Logger.Info("Message sent successfully",
-			zap.Int("userID", msg.UserID),
-			zap.Int("providerID", msg.ProviderID),
-			zap.Int("transactionID", msg.ID))
+// recordDeliveries records the messages the provider created for the recipients, for providers reporting the
+// delivery to each recipient through callbacks. Signal messages can turn the delivery confirmation and the read
+// receipts off in their extension.
+func (p *MessageProcessor) recordDeliveries(msg *provider.MessageTransaction, providerDetails *provider.Provider, responseData []byte) {
+	tracker, ok := p.senders[providerDetails.Type].(DeliveryTracker)
+	if !ok || p.messageDeliveryRepository == nil || len(responseData) == 0 {
+		return
+	}
+	var extensions provider.MessageExtensions
+	if msg.Extensions != "" {
+		_ = json.Unmarshal([]byte(msg.Extensions), &extensions)
+	}
+	if !extensions.Signal.WantsDeliveryConfirmation() {
+		return
+	}
+	var recipients []string
+	_ = json.Unmarshal([]byte(msg.Recipients), &recipients)
+	messageIDs := tracker.SentMessageIDs(recipients, responseData)
+	deliveries := make([]provider.MessageDelivery, 0, len(messageIDs))
+	for recipient, messageID := range messageIDs {
+		deliveries = append(deliveries, provider.MessageDelivery{
+			MessageTransactionID: msg.ID,
+			ProviderID:           providerDetails.ID,
+			Recipient:            recipient,
+			ProviderMessageID:    messageID,
+			ReadReceipts:         extensions.Signal.WantsReadReceipts(),
+		})
+	}
+	if err := p.messageDeliveryRepository.RecordSent(deliveries); err != nil {
+		// The message was sent, only its delivery can't be followed
+		p.messageLogger(msg).Error("Error recording message deliveries", zap.Error(err))
+	}
+}
 
-		// Send webhook notification for successful message
-		p.sendWebhookNotification(msg.UserID, msg.ID, "success", "")
+// InDrill reports whether a provider of a user is in a failover drill. A drill that can't be looked up
+// is treated as not running, so a database hiccup never blocks real messages.
+func (p *MessageProcessor) InDrill(userID int, providerID int) bool {
+	providerIDs, err := p.providerDrillRepository.GetActiveProviderIDs(userID, time.Now())
+	if err != nil {
+		return false
 	}
+	return providerIDs[providerID]
 }
 
-// updateMessageStatus updates the status of a message
-func (p *MessageProcessor) updateMessageStatus(id int, status string, errorMessage string, responseData string) {
-	updateData := map[string]interface{}{
-		"status":     status,
-		"processing": false, // Mark as not being processed anymore
+// SendThroughProvider sends a message of a user to the recipients through the given provider and returns the
+// raw request and response of the provider call
+func (p *MessageProcessor) SendThroughProvider(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	sender, ok := p.senders[providerDetails.Type]
+	if !ok {
+		return nil, nil, errors.New("unsupported provider type: " + providerDetails.Type)
 	}
+	return sender.Send(userID, providerDetails, message, recipients)
+}
 
-	if errorMessage != "" {
-		updateData["errorMessage"] = errorMessage
+// CheckCredentials checks the credentials a provider sends with for a user holding the given user provider config.
+// It reports false when the sender of the provider type can't check credentials without sending.
+func (p *MessageProcessor) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) (bool, error) {
+	checker, ok := p.senders[providerDetails.Type].(CredentialChecker)
+	if !ok {
+		return false, nil
 	}
+	return true, checker.CheckCredentials(providerDetails, userProviderConfig)
+}
 
-	if responseData != "" {
-		updateData["responseData"] = responseData
+// CanEdit reports whether the sender of a provider type can change the text of sent messages
+func (p *MessageProcessor) CanEdit(providerType string) bool {
+	_, ok := p.senders[providerType].(MessageEditor)
+	return ok
+}
+
+// EditSentMessage changes the text of a sent message through the provider that sent it and returns the raw
+// response of the provider call. The messages to change are read from the response data stored with the message.
+func (p *MessageProcessor) EditSentMessage(msg *provider.MessageTransaction, providerDetails *provider.Provider, message string) ([]byte, error) {
+	editor, ok := p.senders[providerDetails.Type].(MessageEditor)
+	if !ok {
+		return nil, errors.New("provider type can't edit messages: " + providerDetails.Type)
+	}
+	responseData := payload.Unwrap(msg.ResponseData)
+	if len(responseData) == 0 {
+		return nil, errNothingToEdit
 	}
+	var recipients []string
+	_ = json.Unmarshal([]byte(msg.Recipients), &recipients)
 
-	if status == "failed" {
-		// Set next retry time to 3 minutes from now
-		nextRetry := time.Now().Add(3 * time.Minute)
-		updateData["nextRetryAt"] = nextRetry
+	_, editData, err := editor.Edit(msg.UserID, providerDetails, message, recipients, responseData)
+	return editData, err
+}
+
+// send sends the text of a message, with its extensions when the sender of the provider type supports them. A
+// message falling back to a provider of another type is sent without them. The actions of the message are sent
+// as buttons by the senders supporting them and appended as numbered options by the others.
+func (p *MessageProcessor) send(msg *provider.MessageTransaction, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	if msg.Actions != "" {
+		var actions []provider.MessageAction
+		if err := json.Unmarshal([]byte(msg.Actions), &actions); err != nil {
+			return nil, nil, fmt.Errorf("invalid message actions: %w", err)
+		}
+		if sender, ok := p.senders[providerDetails.Type].(ActionSender); ok {
+			return sender.SendWithActions(msg.UserID, providerDetails, msg.ID, message, recipients, actions)
+		}
+		// The recipients choose by replying the number of an action
+		message += provider.ActionOptions(actions)
+	}
+	sender, ok := p.senders[providerDetails.Type].(ExtensionSender)
+	if !ok || msg.Extensions == "" {
+		return p.SendThroughProvider(msg.UserID, providerDetails, message, recipients)
+	}
+	var extensions provider.MessageExtensions
+	if err := json.Unmarshal([]byte(msg.Extensions), &extensions); err != nil {
+		return nil, nil, fmt.Errorf("invalid message extensions: %w", err)
 	}
+	return sender.SendWithExtensions(msg.UserID, providerDetails, message, recipients, &extensions)
+}
 
-	_, err := p.messageTransactionRepository.Update(id, updateData)
+// sendWithTrackedLinks sends a message with tracked links to each recipient on its own, with the short links of
+// the recipient. The requests and responses of the recipients are stored as JSON arrays; like a provider sending to
+// each recipient, an error stops the send and keeps the responses of the recipients sent to before.
+func (p *MessageProcessor) sendWithTrackedLinks(msg *provider.MessageTransaction, providerDetails *provider.Provider, recipients []string) ([]byte, []byte, error) {
+	texts, err := p.linkPersonalizer.Personalize(msg.ID, msg.Message, recipients)
 	if err != nil {
-		p.Logger.Error("Error updating message status", zap.Error(err), zap.Int("messageID", id))
+		// Losing the click tracking is preferred over not sending the message
+		p.messageLogger(msg).Error("Error personalizing tracked links, sending the original links", zap.Error(err))
+		return p.send(msg, providerDetails, msg.Message, recipients)
 	}
 
-	// Move the transaction to history if it's completed (success or failed)
-	if status == "success" || status == "failed" {
-		err = p.messageTransactionRepository.MoveToHistory(id, p.messageTransactionHistoryRepository)
+	requests := make([]json.RawMessage, 0, len(recipients))
+	responses := make([]json.RawMessage, 0, len(recipients))
+	var sendErr error
+	for i, recipient := range recipients {
+		requestData, responseData, err := p.send(msg, providerDetails, texts[i], []string{recipient})
+		if len(requestData) > 0 {
+			requests = append(requests, rawJSON(requestData))
+		}
+		if len(responseData) > 0 {
+			responses = append(responses, rawJSON(responseData))
+		}
 		if err != nil {
-			p.Logger.Error("Error moving message transaction to history", zap.Error(err), zap.Int("messageID", id))
+			sendErr = err
+			break
+		}
+	}
+	requestData, _ := json.Marshal(requests)
+	responseData, _ := json.Marshal(responses)
+	return requestData, responseData, sendErr
+}
+
+// rawJSON embeds a payload in a JSON document, as a string when it isn't JSON itself
+func rawJSON(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+// holdForWarmup checks the provider's warm-up policy and holds the message until the next day when
+// the daily limit for the current warm-up day has been reached. It returns true if the message was held.
+func (p *MessageProcessor) holdForWarmup(msg *provider.MessageTransaction, providerDetails *provider.Provider) bool {
+	log := p.messageLogger(msg)
+	warmup, err := parseWarmupConfig(providerDetails.Config)
+	if err != nil {
+		log.Warn("Error parsing provider warm-up config, ignoring warm-up policy", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		return false
+	}
+	if warmup == nil {
+		return false
+	}
+
+	now := time.Now()
+	limit, active := warmup.DailyLimit(now, providerDetails.CreatedAt)
+	if !active {
+		return false
+	}
+
+	sentToday, err := p.messageTransactionRepository.CountProviderMessagesSentToday(providerDetails.ID)
+	if err != nil {
+		log.Error("Error counting provider messages for warm-up", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		return false
+	}
+	if sentToday < limit {
+		return false
+	}
+
+	releaseAt := nextUTCDay(now)
+	heldReason := newReason("warm-up limit of %d messages per day reached for provider, message held until %s", limit, releaseAt.Format(time.RFC3339))
+
+	log.Warn("Message held due to warm-up limit",
+		zap.Int("providerID", providerDetails.ID),
+		zap.Int("dailyLimit", limit),
+		zap.Int("sentToday", sentToday),
+		zap.Time("releaseAt", releaseAt))
+
+	// The webhook notification lets the user know the message was delayed
+	p.transition(msg, provider.MessageStatusHeld, map[string]interface{}{
+		"errorMessage": heldReason.String(),
+		"nextRetryAt":  releaseAt,
+		"processing":   false,
+	}, heldReason)
+	return true
+}
+
+// holdForSchedule checks the provider's sending schedule and holds the message as held_schedule until the
+// schedule opens when it is outside its windows or in a blackout. It returns true if the message was held.
+func (p *MessageProcessor) holdForSchedule(msg *provider.MessageTransaction, providerDetails *provider.Provider) bool {
+	log := p.messageLogger(msg)
+	schedule, err := parseScheduleConfig(providerDetails.Config)
+	if err != nil {
+		log.Warn("Error parsing provider schedule config, ignoring sending schedule", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		return false
+	}
+	if schedule == nil {
+		return false
+	}
+
+	now := time.Now()
+	releaseAt, closedReason, err := schedule.NextOpening(now)
+	if err != nil {
+		log.Warn("Provider schedule never opens, ignoring sending schedule", zap.Error(err), zap.Int("providerID", providerDetails.ID))
+		return false
+	}
+	if !releaseAt.After(now) {
+		return false
+	}
+
+	heldReason := newReason("provider schedule is closed, %s, message held until %s", closedReason, releaseAt.Format(time.RFC3339))
+	log.Info("Message held due to provider schedule",
+		zap.Int("providerID", providerDetails.ID),
+		zap.String("reason", closedReason),
+		zap.Time("releaseAt", releaseAt))
+
+	p.transition(msg, provider.MessageStatusHeldSchedule, map[string]interface{}{
+		"errorMessage": heldReason.String(),
+		"nextRetryAt":  releaseAt,
+		"processing":   false,
+	}, heldReason)
+	return true
+}
+
+// holdForRateLimit stores the challenge tokens of a rate limited send and holds the message as rate_limited
+// until a captcha is submitted for the account, see the signal rate limit challenge endpoint
+func (p *MessageProcessor) holdForRateLimit(msg *provider.MessageTransaction, requestData []byte, rateLimitErr *domainSignal.RateLimitError) {
+	log := p.messageLogger(msg)
+	account := os.Getenv("SIGNAL_FROM_NUMBER")
+	if err := p.rateLimitChallengeRepository.Save(account, rateLimitErr.ChallengeTokens); err != nil {
+		log.Error("Error storing rate limit challenge tokens", zap.Error(err), zap.String("account", account))
+	}
+
+	heldReason := newReason("signal rate limited account %s, message held until a rate limit challenge is solved: %s", account, rateLimitErr.Error())
+	log.Warn("Message held due to signal rate limit",
+		zap.String("account", account),
+		zap.Int("challengeTokens", len(rateLimitErr.ChallengeTokens)))
+
+	p.transition(msg, provider.MessageStatusRateLimited, map[string]interface{}{
+		"errorMessage": heldReason.String(),
+		"requestData":  p.payloadPolicy.Apply(payloadKey(msg.ID, "request"), requestData),
+		"processing":   false,
+		// Signal refused the message, so the send can be started again once the challenge is lifted
+		"sendStartedAt": nil,
+	}, heldReason)
+}
+
+// payloadKey names the full request or response payload of a message in the payload store
+func payloadKey(messageID int, kind string) string {
+	return fmt.Sprintf("messages/%d/%s.json", messageID, kind)
+}
+
+// transition moves a message to a status of the message status state machine together with the other changes
+// of updateData, and reports the new status through sendWebhookNotification. The repository rejects a status
+// the message can't move to, e.g. because it was cancelled meanwhile, nothing is reported then. Sent and failed
+// messages are copied to history. It returns whether the message moved.
+func (p *MessageProcessor) transition(msg *provider.MessageTransaction, status string, updateData map[string]interface{}, r reason) bool {
+	log := p.messageLogger(msg)
+	updateData["status"] = status
+	if _, err := p.messageTransactionRepository.Update(msg.ID, updateData); err != nil {
+		log.Error("Error updating message status", zap.Error(err), zap.String("from", msg.Status), zap.String("to", status))
+		return false
+	}
+	msg.Status = status
+
+	if status == provider.MessageStatusSuccess || status == provider.MessageStatusFailed {
+		if err := p.messageTransactionRepository.MoveToHistory(msg.ID, p.messageTransactionHistoryRepository); err != nil {
+			log.Error("Error moving message transaction to history", zap.Error(err))
 		}
 	}
+
+	p.sendWebhookNotification(msg, status, r)
+	return true
+}
+
+// failMessage fails a message that couldn't be handed to its provider, it is retried in 3 minutes
+func (p *MessageProcessor) failMessage(msg *provider.MessageTransaction, err error) {
+	msg.ErrorCode = provider.ErrorCodeUnknown
+	p.transition(msg, provider.MessageStatusFailed, map[string]interface{}{
+		"processing":   false, // Mark as not being processed anymore
+		"errorMessage": err.Error(),
+		"errorCode":    msg.ErrorCode,
+		"nextRetryAt":  time.Now().Add(3 * time.Minute),
+	}, newReason(err.Error()))
 }
 
-// sendWebhookNotification sends a webhook notification for a message status update
-func (p *MessageProcessor) sendWebhookNotification(userID int, messageID int, status string, errorMessage string) {
+// sendWebhookNotification sends a webhook notification for a message status update to every webhook
+// configured by the user, in the payload version selected by the webhook, and to the user's REST hook
+// subscriptions of the status event, which always receive the v2 payload
+func (p *MessageProcessor) sendWebhookNotification(msg *provider.MessageTransaction, status string, errorMessage reason) {
+	log := p.messageLogger(msg)
+	event := webhookEvent{
+		MessageID:  msg.ID,
+		UserID:     msg.UserID,
+		ProviderID: msg.ProviderID,
+		Recipients: msg.Recipients,
+		Tags:       msg.Tags,
+		Status:     status,
+		Error:      p.localizeReason(msg.UserID, errorMessage),
+		ErrorCode:  msg.ErrorCode,
+		OccurredAt: time.Now(),
+	}
+
+	p.hookDispatcher.DispatchToUser(msg.UserID, hookEventForStatus(status), buildWebhookPayloadV2(event))
+	p.eventBus.Publish(events.TopicMessage, NewOutboundMessageEvent(msg, status))
+
 	// Get user providers
-	userProviders, err := p.userProviderRepository.GetUserProviders(userID)
+	userProviders, err := p.userProviderRepository.GetUserProviders(msg.UserID)
 	if err != nil {
-		p.Logger.Error("Error getting user providers for webhook notification", zap.Error(err), zap.Int("userID", userID))
+		log.Error("Error getting user providers for webhook notification", zap.Error(err))
 		return
 	}
 
@@ -427,64 +890,95 @@ func (p *MessageProcessor) sendWebhookNotification(userID int, messageID int, st
 		if up.Config != "" {
 			err := json.Unmarshal([]byte(up.Config), &config)
 			if err != nil {
-				p.Logger.Error("Error parsing user provider config", zap.Error(err), zap.Int("userProviderID", up.ID))
+				log.Error("Error parsing user provider config", zap.Error(err), zap.Int("userProviderID", up.ID))
 				continue
 			}
 
 			// If webhook is enabled and URL is set, send notification
 			if config.Enabled && config.WebhookURL != "" {
-				// Prepare webhook payload
-				payload := map[string]interface{}{
-					"message_id": messageID,
-					"user_id":    userID,
-					"status":     status,
-					"timestamp":  time.Now().Unix(),
-				}
-
-				if errorMessage != "" {
-					payload["error"] = errorMessage
+				version := resolveWebhookVersion(config.Version)
+				if version != config.Version && config.Version != "" {
+					log.Warn("Unsupported webhook version, falling back to the default",
+						zap.String("webhookVersion", config.Version), zap.Int("userProviderID", up.ID))
 				}
 
 				// Send webhook request
-				go p.sendWebhookRequest(config.WebhookURL, payload)
+				go p.sendWebhookRequest(log, msg.UserID, config, version, buildWebhookPayload(version, event))
 			}
 		}
 	}
 }
 
-// sendWebhookRequest sends an HTTP request to the webhook URL
-func (p *MessageProcessor) sendWebhookRequest(webhookURL string, payload map[string]interface{}) {
+// NotifyAcknowledgement reports the outcome of an acknowledgement demanded by the sender through the same
+// webhooks and REST hook subscriptions as status updates, as the acknowledged or unacknowledged event
+func (p *MessageProcessor) NotifyAcknowledgement(msg *provider.MessageTransaction, acknowledged bool) {
+	if acknowledged {
+		p.sendWebhookNotification(msg, "acknowledged", reason{})
+		return
+	}
+	p.sendWebhookNotification(msg, "unacknowledged", newReason("acknowledgement deadline passed"))
+}
+
+// NotifyRevoked reports a sent message that was deleted for its recipients through the same webhooks and REST
+// hook subscriptions as status updates, as the revoked event
+func (p *MessageProcessor) NotifyRevoked(msg *provider.MessageTransaction) {
+	p.sendWebhookNotification(msg, provider.MessageStatusRevoked, reason{})
+}
+
+// localizeReason translates a webhook reason to the locale of the user
+func (p *MessageProcessor) localizeReason(userID int, r reason) string {
+	if r.format == "" || p.locales == nil {
+		return r.String()
+	}
+	return r.Localize(p.locales.UserLocale(userID))
+}
+
+// sendWebhookRequest sends an HTTP request to the webhook URL of a user with its delivery settings, logging the
+// outcome to the logger of the message
+func (p *MessageProcessor) sendWebhookRequest(log logger.ILogger, userID int, config WebhookConfig, version string, payload interface{}) {
+	webhookURL := config.WebhookURL
+	delivery := config.Delivery()
+
 	// Convert payload to JSON
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		p.Logger.Error("Error marshaling webhook payload", zap.Error(err))
+		log.Error("Error marshaling webhook payload", zap.Error(err))
 		return
 	}
 
 	// Create HTTP request
 	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		p.Logger.Error("Error creating webhook request", zap.Error(err), zap.String("webhookURL", webhookURL))
+		log.Error("Error creating webhook request", zap.Error(err), zap.String("webhookURL", webhookURL))
 		return
 	}
 
-	// Set headers
+	// Set headers, the custom headers first so the headers of the webhook protocol can't be replaced
+	if err := p.hookDispatcher.setDeliveryHeaders(req, userID, delivery); err != nil {
+		log.Error("Error decrypting webhook headers", zap.Error(err), zap.String("webhookURL", webhookURL))
+		return
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "go-multi-chat-api-Webhook")
+	req.Header.Set(WebhookVersionHeader, version)
 
 	// Send request with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	client := httpclient.New(10 * time.Second)
+	if delivery.HasTLS() {
+		if client, err = p.hookDispatcher.newTLSClient(userID, delivery); err != nil {
+			log.Error("Error creating webhook client", zap.Error(err), zap.String("webhookURL", webhookURL))
+			return
+		}
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		p.Logger.Error("Error sending webhook request", zap.Error(err), zap.String("webhookURL", webhookURL))
+		log.Error("Error sending webhook request", zap.Error(err), zap.String("webhookURL", webhookURL))
 		return
 	}
 	defer resp.Body.Close()
 
 	// Log response
-	p.Logger.Info("Webhook notification sent",
+	log.Info("Webhook notification sent",
 		zap.String("webhookURL", webhookURL),
 		zap.Int("statusCode", resp.StatusCode))
 }
@@ -495,6 +989,7 @@ func (p *MessageProcessor) Shutdown() {
 
 	// Signal all workers to shut down
 	close(p.shutdown)
+	p.messageQueue.close()
 
 	// Wait for all workers to finish
 	p.wg.Wait()
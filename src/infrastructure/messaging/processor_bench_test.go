@@ -0,0 +1,97 @@
+package messaging
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/payload"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+// benchRoundTrip is the simulated latency of every repository call of the processor
+const benchRoundTrip = 200 * time.Microsecond
+
+type benchProviderRepository struct {
+	providerRepo.ProviderRepositoryInterface
+}
+
+func (m *benchProviderRepository) GetByID(id int) (*provider.Provider, error) {
+	time.Sleep(benchRoundTrip)
+	return &provider.Provider{ID: id, Type: string(alert.TypeSandbox), Status: true}, nil
+}
+
+type benchProviderDrillRepository struct {
+	providerRepo.ProviderDrillRepositoryInterface
+}
+
+func (m *benchProviderDrillRepository) GetActiveProviderIDs(userID int, at time.Time) (map[int]bool, error) {
+	return nil, nil
+}
+
+// benchMessageTransactionRepository marks a message done once it is moved to history
+type benchMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	done *sync.WaitGroup
+}
+
+func (m *benchMessageTransactionRepository) MarkSendStarted(id int) (bool, error) {
+	time.Sleep(benchRoundTrip)
+	return true, nil
+}
+
+func (m *benchMessageTransactionRepository) Update(id int, messageTransactionMap map[string]interface{}) (*provider.MessageTransaction, error) {
+	time.Sleep(benchRoundTrip)
+	return &provider.MessageTransaction{ID: id}, nil
+}
+
+func (m *benchMessageTransactionRepository) MoveToHistory(id int, historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface) error {
+	time.Sleep(benchRoundTrip)
+	m.done.Done()
+	return nil
+}
+
+// BenchmarkMessageProcessor sends messages of many users through the workers and the sandbox sender, reporting the
+// messages processed per second by worker count
+func BenchmarkMessageProcessor(b *testing.B) {
+	for _, workers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			var done sync.WaitGroup
+			loggerInstance := &logger.Logger{Log: zap.NewNop()}
+			processor := &MessageProcessor{
+				senders:                      map[string]ProviderSender{string(alert.TypeSandbox): NewSandboxSender(SandboxConfig{Latency: time.Millisecond})},
+				providerRepository:           &benchProviderRepository{},
+				userProviderRepository:       &mockUserProviderRepository{},
+				messageTransactionRepository: &benchMessageTransactionRepository{done: &done},
+				providerDrillRepository:      &benchProviderDrillRepository{},
+				payloadPolicy:                payload.NewPolicy(payload.Config{}, loggerInstance),
+				hookDispatcher:               NewHookDispatcher(&mockHookSubscriptionRepository{}, &mockWebhookEventRepository{}, nil, loggerInstance),
+				Logger:                       loggerInstance,
+				workerCount:                  workers,
+				messageQueue:                 newFairQueue(1000, 0),
+				shutdown:                     make(chan struct{}),
+			}
+			processor.startWorkers()
+			b.Cleanup(processor.Shutdown)
+
+			b.ResetTimer()
+			start := time.Now()
+			done.Add(b.N)
+			for i := 0; i < b.N; i++ {
+				msg := &provider.MessageTransaction{ID: i + 1, UserID: i%20 + 1, ProviderID: 1, Recipients: `["+4912345"]`, Message: "Server down"}
+				// A full queue is retried like the watcher would pick the message up later
+				for !processor.messageQueue.push(msg) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			done.Wait()
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "msgs/s")
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package messaging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	alertingProvider "go-multi-chat-api/src/infrastructure/alerting/provider"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// LagLevel grades the age of the oldest pending message against the alert thresholds
+type LagLevel string
+
+const (
+	LagOK       LagLevel = "ok"
+	LagWarning  LagLevel = "warning"
+	LagCritical LagLevel = "critical"
+)
+
+// QueueMonitorConfig controls how often the queue metrics are refreshed and when queue lag alerts fire
+type QueueMonitorConfig struct {
+	// Interval is how often the queue metrics are refreshed
+	Interval time.Duration
+	// LagWarning and LagCritical are the ages of the oldest pending message that raise the lag level, 0 disables a level
+	LagWarning  time.Duration
+	LagCritical time.Duration
+	// AlertRecipients receive the queue lag alerts, without recipients the alerts are only logged
+	AlertRecipients []string
+}
+
+// LoadQueueMonitorConfig loads the queue monitor configuration from environment variables
+func LoadQueueMonitorConfig() (QueueMonitorConfig, error) {
+	interval, err := utils.GetIntEnv("QUEUE_METRICS_INTERVAL_SECONDS", 15)
+	if err != nil {
+		return QueueMonitorConfig{}, fmt.Errorf("invalid QUEUE_METRICS_INTERVAL_SECONDS: %w", err)
+	}
+	lagWarning, err := utils.GetIntEnv("QUEUE_LAG_WARNING_SECONDS", 300)
+	if err != nil {
+		return QueueMonitorConfig{}, fmt.Errorf("invalid QUEUE_LAG_WARNING_SECONDS: %w", err)
+	}
+	lagCritical, err := utils.GetIntEnv("QUEUE_LAG_CRITICAL_SECONDS", 900)
+	if err != nil {
+		return QueueMonitorConfig{}, fmt.Errorf("invalid QUEUE_LAG_CRITICAL_SECONDS: %w", err)
+	}
+	if lagWarning > 0 && lagCritical > 0 && lagCritical < lagWarning {
+		return QueueMonitorConfig{}, fmt.Errorf("QUEUE_LAG_CRITICAL_SECONDS must not be lower than QUEUE_LAG_WARNING_SECONDS")
+	}
+
+	var recipients []string
+	for _, recipient := range strings.Split(utils.GetEnv("QUEUE_LAG_ALERT_RECIPIENTS", ""), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	return QueueMonitorConfig{
+		Interval:        time.Duration(interval) * time.Second,
+		LagWarning:      time.Duration(lagWarning) * time.Second,
+		LagCritical:     time.Duration(lagCritical) * time.Second,
+		AlertRecipients: recipients,
+	}, nil
+}
+
+// QueueMetricsSource counts the active messages per queue state
+type QueueMetricsSource interface {
+	GetQueueMetrics() (*provider.QueueMetrics, error)
+}
+
+// QueueGauges is the latest snapshot of the queue metrics
+type QueueGauges struct {
+	provider.QueueMetrics
+	// Lag is the age of the oldest pending message
+	Lag         time.Duration
+	LagLevel    LagLevel
+	RefreshedAt time.Time
+}
+
+// QueueMonitor refreshes the queue gauges periodically on every instance and alerts when the queue lag
+// changes its level, on the leader instance only
+type QueueMonitor struct {
+	source        QueueMetricsSource
+	alertProvider alertingProvider.AlertProvider
+	elector       leader.Elector
+	config        QueueMonitorConfig
+	Logger        *logger.Logger
+	mu            sync.RWMutex
+	gauges        QueueGauges
+	alertedLevel  LagLevel
+	shutdown      chan struct{}
+	done          chan struct{}
+}
+
+// NewQueueMonitor creates a new queue monitor and starts it. alertProvider may be nil, the alerts are
+// then only logged.
+func NewQueueMonitor(source QueueMetricsSource, alertProvider alertingProvider.AlertProvider, elector leader.Elector, loggerInstance *logger.Logger, config QueueMonitorConfig) *QueueMonitor {
+	monitor := newQueueMonitor(source, alertProvider, elector, loggerInstance, config)
+	go monitor.run()
+	return monitor
+}
+
+func newQueueMonitor(source QueueMetricsSource, alertProvider alertingProvider.AlertProvider, elector leader.Elector, loggerInstance *logger.Logger, config QueueMonitorConfig) *QueueMonitor {
+	if config.Interval <= 0 {
+		config.Interval = 15 * time.Second // Default to refreshing every 15 seconds if not specified
+	}
+	return &QueueMonitor{
+		source:        source,
+		alertProvider: alertProvider,
+		elector:       elector,
+		config:        config,
+		Logger:        loggerInstance,
+		gauges:        QueueGauges{LagLevel: LagOK},
+		alertedLevel:  LagOK,
+		shutdown:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func (m *QueueMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	m.Logger.Info("Starting queue monitor", zap.Duration("interval", m.config.Interval))
+	m.refresh(time.Now())
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.refresh(now)
+		case <-m.shutdown:
+			return
+		}
+	}
+}
+
+// Gauges returns the latest snapshot of the queue metrics
+func (m *QueueMonitor) Gauges() QueueGauges {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.gauges
+}
+
+// refresh reads the queue metrics and alerts on a change of the lag level. The previous snapshot is kept
+// when the metrics can't be read.
+func (m *QueueMonitor) refresh(now time.Time) {
+	metrics, err := m.source.GetQueueMetrics()
+	if err != nil {
+		m.Logger.Error("Error refreshing queue metrics", zap.Error(err))
+		return
+	}
+
+	gauges := QueueGauges{QueueMetrics: *metrics, RefreshedAt: now}
+	if metrics.OldestPendingAt != nil && now.After(*metrics.OldestPendingAt) {
+		gauges.Lag = now.Sub(*metrics.OldestPendingAt)
+	}
+	gauges.LagLevel = m.lagLevel(gauges.Lag)
+
+	m.mu.Lock()
+	m.gauges = gauges
+	m.mu.Unlock()
+
+	m.alertOnLevelChange(gauges)
+}
+
+func (m *QueueMonitor) lagLevel(lag time.Duration) LagLevel {
+	switch {
+	case m.config.LagCritical > 0 && lag >= m.config.LagCritical:
+		return LagCritical
+	case m.config.LagWarning > 0 && lag >= m.config.LagWarning:
+		return LagWarning
+	default:
+		return LagOK
+	}
+}
+
+// alertOnLevelChange sends one alert per change of the lag level, including the recovery to ok. An alert
+// that couldn't be sent is tried again on the next refresh.
+func (m *QueueMonitor) alertOnLevelChange(gauges QueueGauges) {
+	if !m.elector.IsLeader() || gauges.LagLevel == m.alertedLevel {
+		return
+	}
+
+	subject := fmt.Sprintf("Message queue lag %s", gauges.LagLevel)
+	if gauges.LagLevel == LagOK {
+		subject = "Message queue lag resolved"
+	}
+	description := fmt.Sprintf("The oldest pending message has waited %s. Pending: %d, processing: %d, failed awaiting retry: %d, held: %d.",
+		gauges.Lag.Truncate(time.Second), gauges.Pending, gauges.Processing, gauges.FailedAwaitingRetry, gauges.Held)
+
+	m.Logger.Warn(subject,
+		zap.Duration("lag", gauges.Lag),
+		zap.String("previousLevel", string(m.alertedLevel)),
+		zap.Int("pending", gauges.Pending),
+		zap.Int("processing", gauges.Processing))
+
+	if m.alertProvider != nil && len(m.config.AlertRecipients) > 0 {
+		err := m.alertProvider.Send(&alert.Alert{
+			Type:        alert.TypeEmail,
+			Subject:     &subject,
+			Description: &description,
+			Recipients:  m.config.AlertRecipients,
+		})
+		if err != nil {
+			m.Logger.Error("Error sending queue lag alert", zap.Error(err))
+			return
+		}
+	}
+	m.alertedLevel = gauges.LagLevel
+}
+
+// Shutdown stops the monitor
+func (m *QueueMonitor) Shutdown() {
+	close(m.shutdown)
+	<-m.done
+}
@@ -0,0 +1,114 @@
+package messaging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/alerting/alert"
+	alertingProvider "go-multi-chat-api/src/infrastructure/alerting/provider"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockQueueMetricsSource struct {
+	metrics provider.QueueMetrics
+	err     error
+}
+
+func (m *mockQueueMetricsSource) GetQueueMetrics() (*provider.QueueMetrics, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	metrics := m.metrics
+	return &metrics, nil
+}
+
+type mockAlertProvider struct {
+	alertingProvider.AlertProvider
+	sent []*alert.Alert
+	err  error
+}
+
+func (m *mockAlertProvider) Send(a *alert.Alert) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, a)
+	return nil
+}
+
+func newTestQueueMonitor(t *testing.T, source *mockQueueMetricsSource, alertProvider *mockAlertProvider) *QueueMonitor {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return newQueueMonitor(source, alertProvider, leader.AlwaysLeader{}, loggerInstance, QueueMonitorConfig{
+		LagWarning:      5 * time.Minute,
+		LagCritical:     15 * time.Minute,
+		AlertRecipients: []string{"ops@example.com"},
+	})
+}
+
+func TestQueueMonitor_RefreshesGaugesAndLag(t *testing.T) {
+	now := time.Now()
+	oldest := now.Add(-2 * time.Minute)
+	source := &mockQueueMetricsSource{metrics: provider.QueueMetrics{Pending: 4, Processing: 2, FailedAwaitingRetry: 1, Held: 3, OldestPendingAt: &oldest}}
+	monitor := newTestQueueMonitor(t, source, &mockAlertProvider{})
+
+	monitor.refresh(now)
+	gauges := monitor.Gauges()
+	assert.Equal(t, 4, gauges.Pending)
+	assert.Equal(t, 2, gauges.Processing)
+	assert.Equal(t, 1, gauges.FailedAwaitingRetry)
+	assert.Equal(t, 3, gauges.Held)
+	assert.Equal(t, 2*time.Minute, gauges.Lag)
+	assert.Equal(t, LagOK, gauges.LagLevel)
+
+	// A failed refresh keeps the previous snapshot
+	source.err = errors.New("database unavailable")
+	monitor.refresh(now.Add(time.Minute))
+	assert.Equal(t, now, monitor.Gauges().RefreshedAt)
+}
+
+func TestQueueMonitor_AlertsOncePerLevelChange(t *testing.T) {
+	now := time.Now()
+	source := &mockQueueMetricsSource{}
+	alertProvider := &mockAlertProvider{}
+	monitor := newTestQueueMonitor(t, source, alertProvider)
+
+	refreshWithLag := func(lag time.Duration) {
+		oldest := now.Add(-lag)
+		source.metrics.OldestPendingAt = &oldest
+		monitor.refresh(now)
+	}
+
+	refreshWithLag(time.Minute)
+	refreshWithLag(6 * time.Minute)
+	refreshWithLag(7 * time.Minute)
+	refreshWithLag(20 * time.Minute)
+	source.metrics.OldestPendingAt = nil
+	monitor.refresh(now)
+
+	require.Len(t, alertProvider.sent, 3)
+	assert.Equal(t, "Message queue lag warning", *alertProvider.sent[0].Subject)
+	assert.Equal(t, "Message queue lag critical", *alertProvider.sent[1].Subject)
+	assert.Equal(t, "Message queue lag resolved", *alertProvider.sent[2].Subject)
+	assert.Equal(t, []string{"ops@example.com"}, alertProvider.sent[0].Recipients)
+}
+
+func TestQueueMonitor_RetriesAlertThatFailed(t *testing.T) {
+	now := time.Now()
+	oldest := now.Add(-10 * time.Minute)
+	alertProvider := &mockAlertProvider{err: errors.New("smtp unavailable")}
+	monitor := newTestQueueMonitor(t, &mockQueueMetricsSource{metrics: provider.QueueMetrics{Pending: 1, OldestPendingAt: &oldest}}, alertProvider)
+
+	monitor.refresh(now)
+	assert.Empty(t, alertProvider.sent)
+
+	alertProvider.err = nil
+	monitor.refresh(now)
+	assert.Len(t, alertProvider.sent, 1)
+}
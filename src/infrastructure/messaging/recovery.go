@@ -0,0 +1,143 @@
+package messaging
+
+import (
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// RecoveryConfig controls how messages stranded in processing by a crash are recovered on startup
+type RecoveryConfig struct {
+	// StaleAfter is how long a message has to be in processing before it is considered stranded
+	StaleAfter time.Duration
+	// RequeueUnconfirmed sends messages again whose provider call was started but whose outcome is unknown.
+	// When disabled they are marked unconfirmed instead, so a recipient never gets a message twice.
+	RequeueUnconfirmed bool
+}
+
+// LoadRecoveryConfig loads the recovery configuration from environment variables
+func LoadRecoveryConfig() (RecoveryConfig, error) {
+	staleAfter, err := utils.GetIntEnv("PROCESSING_STALE_AFTER_MINUTES", 10)
+	if err != nil {
+		return RecoveryConfig{}, fmt.Errorf("invalid PROCESSING_STALE_AFTER_MINUTES: %w", err)
+	}
+	return RecoveryConfig{
+		StaleAfter:         time.Duration(staleAfter) * time.Minute,
+		RequeueUnconfirmed: utils.GetEnv("RECOVERY_REQUEUE_UNCONFIRMED", "false") == "true",
+	}, nil
+}
+
+type recoveryAction int
+
+const (
+	// recoveryRequeue releases a message whose provider call never started
+	recoveryRequeue recoveryAction = iota
+	// recoveryResend releases a message whose provider call started but didn't reach the provider, or
+	// whose outcome is unknown when resending is allowed
+	recoveryResend
+	// recoveryMarkSent completes a message the provider confirmed as sent
+	recoveryMarkSent
+	// recoveryMarkUnconfirmed completes a message whose outcome is unknown without sending it again
+	recoveryMarkUnconfirmed
+)
+
+// decideRecovery picks how a stranded message is recovered. sent and known are the result of the
+// provider-side lookup, which is only consulted when the provider call was started.
+func decideRecovery(sendStarted bool, sent bool, known bool, requeueUnconfirmed bool) recoveryAction {
+	switch {
+	case !sendStarted:
+		return recoveryRequeue
+	case known && sent:
+		return recoveryMarkSent
+	case known || requeueUnconfirmed:
+		return recoveryResend
+	default:
+		return recoveryMarkUnconfirmed
+	}
+}
+
+// lookupSend asks the provider whether a message whose send was interrupted reached it. known is false
+// when the provider can't tell, which is currently the case for every provider type: signal-cli offers
+// no lookup of sent messages and SMTP servers don't report the emails they accepted.
+func (p *MessageProcessor) lookupSend(msg *provider.MessageTransaction) (sent bool, known bool) {
+	return false, false
+}
+
+// RecoverStaleMessages recovers messages that were picked up for processing but never finished, e.g. because
+// the process crashed mid-send. Messages whose provider call never started are requeued, the others are
+// looked up on the provider side where possible and otherwise resent or marked unconfirmed, as configured.
+func (p *MessageProcessor) RecoverStaleMessages() {
+	staleBefore := time.Now().Add(-p.recovery.StaleAfter)
+	staleMessages, err := p.messageTransactionRepository.GetStaleProcessingMessages(staleBefore)
+	if err != nil {
+		p.Logger.Error("Error getting stale processing messages", zap.Error(err))
+		return
+	}
+
+	if len(*staleMessages) == 0 {
+		return
+	}
+
+	p.Logger.Warn("Found messages stranded in processing", zap.Int("count", len(*staleMessages)))
+
+	for _, msg := range *staleMessages {
+		sendStarted := msg.SendStartedAt != nil
+		var sent, known bool
+		if sendStarted {
+			sent, known = p.lookupSend(&msg)
+		}
+
+		var updateData map[string]interface{}
+		var webhookStatus string
+		var unconfirmedReason reason
+		action := decideRecovery(sendStarted, sent, known, p.recovery.RequeueUnconfirmed)
+		switch action {
+		case recoveryRequeue:
+			updateData = map[string]interface{}{"processing": false}
+		case recoveryResend:
+			updateData = map[string]interface{}{
+				"processing":    false,
+				"sendStartedAt": nil,
+				"retryCount":    msg.RetryCount + 1,
+			}
+		case recoveryMarkSent:
+			updateData = map[string]interface{}{
+				"status":       provider.MessageStatusSuccess,
+				"errorMessage": "",
+				"processing":   false,
+			}
+			webhookStatus = provider.MessageStatusSuccess
+		case recoveryMarkUnconfirmed:
+			unconfirmedReason = newReason("processing was interrupted after the message was handed to the provider, delivery is unconfirmed")
+			updateData = map[string]interface{}{
+				"status":       provider.MessageStatusUnconfirmed,
+				"errorMessage": unconfirmedReason.String(),
+				"processing":   false,
+			}
+			webhookStatus = provider.MessageStatusUnconfirmed
+		}
+
+		recovered, err := p.messageTransactionRepository.RecoverStaleMessage(msg.ID, staleBefore, updateData)
+		if err != nil || !recovered {
+			// Either failed or another instance recovered the message first
+			continue
+		}
+
+		p.Logger.Info("Recovered stranded message",
+			zap.Int("messageID", msg.ID),
+			zap.Bool("sendStarted", sendStarted),
+			zap.Int("action", int(action)))
+
+		// Completed messages are archived and reported like any other processed message
+		if webhookStatus != "" {
+			if err := p.messageTransactionRepository.MoveToHistory(msg.ID, p.messageTransactionHistoryRepository); err != nil {
+				p.Logger.Error("Error moving message transaction to history", zap.Error(err), zap.Int("messageID", msg.ID))
+			}
+			p.sendWebhookNotification(&msg, webhookStatus, unconfirmedReason)
+		}
+	}
+}
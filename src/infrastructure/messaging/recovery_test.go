@@ -0,0 +1,44 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecideRecovery(t *testing.T) {
+	tests := []struct {
+		name               string
+		sendStarted        bool
+		sent               bool
+		known              bool
+		requeueUnconfirmed bool
+		expected           recoveryAction
+	}{
+		{"send never started", false, false, false, false, recoveryRequeue},
+		{"provider confirmed the send", true, true, true, false, recoveryMarkSent},
+		{"provider confirmed nothing was sent", true, false, true, false, recoveryResend},
+		{"unknown outcome", true, false, false, false, recoveryMarkUnconfirmed},
+		{"unknown outcome with resend allowed", true, false, false, true, recoveryResend},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decideRecovery(tt.sendStarted, tt.sent, tt.known, tt.requeueUnconfirmed))
+		})
+	}
+}
+
+func TestLoadRecoveryConfig(t *testing.T) {
+	t.Setenv("PROCESSING_STALE_AFTER_MINUTES", "15")
+	t.Setenv("RECOVERY_REQUEUE_UNCONFIRMED", "true")
+
+	config, err := LoadRecoveryConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, config.StaleAfter)
+	assert.True(t, config.RequeueUnconfirmed)
+
+	t.Setenv("PROCESSING_STALE_AFTER_MINUTES", "soon")
+	_, err = LoadRecoveryConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+// maxRetryBackoff bounds the delay before a retry however often a message failed
+const maxRetryBackoff = 24 * time.Hour
+
+// RetryPolicy is how the failures of one error class are handled. A retry sends the message again through the
+// same provider after the backoff, doubled for every retry the message already had, and falls back to the next
+// provider once the message was retried MaxRetries times. A fallback hands the message to the next provider
+// after the backoff, and a suppressed message isn't sent again.
+type RetryPolicy struct {
+	Action         string `json:"action"`          // retry, fallback or suppress
+	MaxRetries     int    `json:"max_retries"`     // retries through the same provider before falling back
+	BackoffSeconds int    `json:"backoff_seconds"` // delay before the first retry or the fallback
+}
+
+// RetryPolicies maps the error codes of failures to their policy
+type RetryPolicies map[string]RetryPolicy
+
+// defaultRetryPolicies apply to the error classes a provider config doesn't set a policy for. An invalid
+// recipient is invalid on every provider, while a recipient without an account or a provider with broken
+// credentials may be reached through another provider right away.
+var defaultRetryPolicies = RetryPolicies{
+	provider.ErrorCodeInvalidRecipient: {Action: provider.FailureSuppress},
+	provider.ErrorCodeUnregistered:     {Action: provider.FailureFallback},
+	provider.ErrorCodeRateLimited:      {Action: provider.FailureRetry, MaxRetries: 5, BackoffSeconds: 900},
+	provider.ErrorCodeAuthFailed:       {Action: provider.FailureFallback},
+	provider.ErrorCodeNetwork:          {Action: provider.FailureRetry, MaxRetries: 3, BackoffSeconds: 60},
+	provider.ErrorCodeDeviceUnlinked:   {Action: provider.FailureFallback},
+	provider.ErrorCodeUnknown:          {Action: provider.FailureFallback, BackoffSeconds: 180},
+}
+
+type providerRetryConfig struct {
+	RetryPolicy map[string]json.RawMessage `json:"retry_policy"`
+}
+
+// ParseRetryPolicies extracts the retry policies stored under the "retry_policy" key of a provider config,
+// keyed by error code. The fields a policy leaves out, and the error classes without a policy, keep their
+// defaults.
+func ParseRetryPolicies(config string) (RetryPolicies, error) {
+	policies := make(RetryPolicies, len(defaultRetryPolicies))
+	for code, policy := range defaultRetryPolicies {
+		policies[code] = policy
+	}
+	if config == "" {
+		return policies, nil
+	}
+	var parsed providerRetryConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+	for code, raw := range parsed.RetryPolicy {
+		policy, ok := policies[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown error code %q in retry policy", code)
+		}
+		if err := json.Unmarshal(raw, &policy); err != nil {
+			return nil, fmt.Errorf("retry policy of %s: %w", code, err)
+		}
+		switch policy.Action {
+		case provider.FailureRetry, provider.FailureFallback, provider.FailureSuppress:
+		default:
+			return nil, fmt.Errorf("retry policy of %s has unknown action %q", code, policy.Action)
+		}
+		if policy.MaxRetries < 0 || policy.BackoffSeconds < 0 {
+			return nil, fmt.Errorf("retry policy of %s must not be negative", code)
+		}
+		policies[code] = policy
+	}
+	return policies, nil
+}
+
+// Decide returns how a message that failed with the error code after retryCount retries is handled, and how long
+// to wait before it is sent again
+func (p RetryPolicies) Decide(errorCode string, retryCount int) (string, time.Duration) {
+	policy, ok := p[errorCode]
+	if !ok {
+		policy = p[provider.ErrorCodeUnknown]
+	}
+	backoff := time.Duration(policy.BackoffSeconds) * time.Second
+	switch policy.Action {
+	case provider.FailureSuppress:
+		return provider.FailureSuppress, 0
+	case provider.FailureRetry:
+		if retryCount >= policy.MaxRetries {
+			return provider.FailureFallback, backoff
+		}
+		for i := 0; i < retryCount && backoff < maxRetryBackoff; i++ {
+			backoff *= 2
+		}
+		return provider.FailureRetry, min(backoff, maxRetryBackoff)
+	}
+	return provider.FailureFallback, backoff
+}
@@ -0,0 +1,61 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryPolicies(t *testing.T) {
+	policies, err := ParseRetryPolicies(`{"from":"+4912345","retry_policy":{"rate_limited":{"backoff_seconds":3600},"auth_failed":{"action":"suppress"}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, RetryPolicy{Action: provider.FailureRetry, MaxRetries: 5, BackoffSeconds: 3600}, policies[provider.ErrorCodeRateLimited])
+	assert.Equal(t, provider.FailureSuppress, policies[provider.ErrorCodeAuthFailed].Action)
+	assert.Equal(t, defaultRetryPolicies[provider.ErrorCodeNetwork], policies[provider.ErrorCodeNetwork])
+
+	policies, err = ParseRetryPolicies("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultRetryPolicies, policies)
+
+	for _, config := range []string{
+		`{invalid`,
+		`{"retry_policy":{"timeout":{"action":"retry"}}}`,
+		`{"retry_policy":{"network":{"action":"wait"}}}`,
+		`{"retry_policy":{"network":{"max_retries":-1}}}`,
+	} {
+		_, err = ParseRetryPolicies(config)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestRetryPolicies_Decide(t *testing.T) {
+	policies, err := ParseRetryPolicies(`{"retry_policy":{"rate_limited":{"max_retries":8,"backoff_seconds":3600}}}`)
+	require.NoError(t, err)
+
+	action, backoff := policies.Decide(provider.ErrorCodeNetwork, 0)
+	assert.Equal(t, provider.FailureRetry, action)
+	assert.Equal(t, time.Minute, backoff)
+
+	action, backoff = policies.Decide(provider.ErrorCodeNetwork, 2)
+	assert.Equal(t, provider.FailureRetry, action)
+	assert.Equal(t, 4*time.Minute, backoff)
+
+	action, backoff = policies.Decide(provider.ErrorCodeNetwork, 3)
+	assert.Equal(t, provider.FailureFallback, action)
+	assert.Equal(t, time.Minute, backoff)
+
+	// Backoffs double up to a day
+	_, backoff = policies.Decide(provider.ErrorCodeRateLimited, 6)
+	assert.Equal(t, maxRetryBackoff, backoff)
+
+	action, _ = policies.Decide(provider.ErrorCodeInvalidRecipient, 0)
+	assert.Equal(t, provider.FailureSuppress, action)
+
+	action, backoff = policies.Decide("", 0)
+	assert.Equal(t, provider.FailureFallback, action)
+	assert.Equal(t, 3*time.Minute, backoff)
+}
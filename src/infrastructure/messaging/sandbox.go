@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// errSandboxFailure fails the share of the sandbox sends set by the failure percent
+var errSandboxFailure = errors.New("sandbox provider failure")
+
+// SandboxConfig configures the sandbox sender, which sends nowhere and only takes the time of a real provider
+type SandboxConfig struct {
+	// Latency is how long every send takes
+	Latency time.Duration
+	// FailurePercent is the share of sends failing, from 0 to 100
+	FailurePercent int
+}
+
+// LoadSandboxConfig loads the config of the sandbox sender, nil when the sandbox provider type isn't enabled
+func LoadSandboxConfig() (*SandboxConfig, error) {
+	enabled, _ := strconv.ParseBool(os.Getenv("SANDBOX_PROVIDER_ENABLED"))
+	if !enabled {
+		return nil, nil
+	}
+	latency, err := utils.GetIntEnv("SANDBOX_LATENCY_MS", 50)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SANDBOX_LATENCY_MS: %w", err)
+	}
+	if latency < 0 {
+		return nil, fmt.Errorf("SANDBOX_LATENCY_MS must not be negative")
+	}
+	failurePercent, err := utils.GetIntEnv("SANDBOX_FAILURE_PERCENT", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SANDBOX_FAILURE_PERCENT: %w", err)
+	}
+	if failurePercent < 0 || failurePercent > 100 {
+		return nil, fmt.Errorf("SANDBOX_FAILURE_PERCENT must be between 0 and 100")
+	}
+	return &SandboxConfig{Latency: time.Duration(latency) * time.Millisecond, FailurePercent: failurePercent}, nil
+}
+
+// SandboxSender pretends to send to the recipients, for load tests of the API and the processor without a
+// vendor. Every send waits for the configured latency and the configured share of the sends fails.
+type SandboxSender struct {
+	config SandboxConfig
+	sent   atomic.Int64
+}
+
+// NewSandboxSender creates a new sandbox sender
+func NewSandboxSender(config SandboxConfig) *SandboxSender {
+	return &SandboxSender{config: config}
+}
+
+func (s *SandboxSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+	if s.config.Latency > 0 {
+		time.Sleep(s.config.Latency)
+	}
+	if s.config.FailurePercent > 0 && rand.Intn(100) < s.config.FailurePercent {
+		return requestData, nil, errSandboxFailure
+	}
+
+	type sandboxResult struct {
+		Recipient string `json:"recipient"`
+		MessageID string `json:"message_id"`
+	}
+	results := make([]sandboxResult, len(recipients))
+	for i, recipient := range recipients {
+		results[i] = sandboxResult{Recipient: recipient, MessageID: "sandbox-" + strconv.FormatInt(s.sent.Add(1), 10)}
+	}
+	return requestData, resultsData(results), nil
+}
+
+// Sent returns how many recipients the sandbox sender was sent to
+func (s *SandboxSender) Sent() int64 {
+	return s.sent.Load()
+}
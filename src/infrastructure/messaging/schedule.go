@@ -0,0 +1,232 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleLookahead bounds the search for the next opening of a schedule, a schedule that stays closed
+// for longer is treated as never opening
+const maxScheduleLookahead = 366 * 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ScheduleConfig represents the sending schedule stored under the "schedule" key of a provider config.
+// Messages are only sent inside one of the windows, read in the timezone of the schedule, and never during
+// a blackout. A schedule without windows is open whenever there is no blackout, so
+// {"windows": [{"days": ["mon","tue","wed","thu","fri"], "start": "00:00", "end": "24:00"}]} sends on
+// weekdays only and {"windows": [{"start": "08:00", "end": "21:00"}]} between 8am and 9pm every day.
+type ScheduleConfig struct {
+	Timezone  string          `json:"timezone"`
+	Windows   []SendingWindow `json:"windows"`
+	Blackouts []Blackout      `json:"blackouts"`
+
+	location *time.Location
+}
+
+// SendingWindow is a daily time range messages are sent in. An end before the start spans midnight, the
+// window then belongs to the day it starts on.
+type SendingWindow struct {
+	Days  []string `json:"days"`  // mon to sun, every day when empty
+	Start string   `json:"start"` // HH:MM
+	End   string   `json:"end"`   // HH:MM, 24:00 for the end of the day
+
+	start, end time.Duration
+}
+
+// Blackout is a period no messages are sent in, either a whole calendar day in the timezone of the schedule
+// or a range of time
+type Blackout struct {
+	Date   string     `json:"date"` // YYYY-MM-DD
+	Start  *time.Time `json:"start"`
+	End    *time.Time `json:"end"`
+	Reason string     `json:"reason"`
+}
+
+type providerScheduleConfig struct {
+	Schedule *ScheduleConfig `json:"schedule"`
+}
+
+// parseScheduleConfig extracts the sending schedule from a provider config, returning nil when none is configured
+func parseScheduleConfig(config string) (*ScheduleConfig, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var parsed providerScheduleConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+	schedule := parsed.Schedule
+	if schedule == nil || (len(schedule.Windows) == 0 && len(schedule.Blackouts) == 0) {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown schedule timezone %q", schedule.Timezone)
+	}
+	schedule.location = location
+	for i := range schedule.Windows {
+		window := &schedule.Windows[i]
+		if window.start, err = parseClock(window.Start); err != nil {
+			return nil, err
+		}
+		if window.end, err = parseClock(window.End); err != nil {
+			return nil, err
+		}
+		if window.start == window.end {
+			return nil, fmt.Errorf("sending window %d is empty", i+1)
+		}
+		for _, day := range window.Days {
+			if _, ok := weekdays[day]; !ok {
+				return nil, fmt.Errorf("unknown day %q in sending window %d", day, i+1)
+			}
+		}
+	}
+	for i, blackout := range schedule.Blackouts {
+		if _, _, err := blackout.period(location); err != nil {
+			return nil, fmt.Errorf("blackout %d: %w", i+1, err)
+		}
+	}
+	return schedule, nil
+}
+
+// parseClock parses a time of day as HH:MM into the duration since midnight
+func parseClock(clock string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(clock, ":")
+	h, hErr := strconv.Atoi(hours)
+	m, mErr := strconv.Atoi(minutes)
+	if !ok || len(hours) != 2 || len(minutes) != 2 || hErr != nil || mErr != nil || h > 24 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, must be HH:MM", clock)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// NextOpening returns now when the schedule allows sending at now, otherwise the next time it does and why
+// it is closed until then
+func (s *ScheduleConfig) NextOpening(now time.Time) (time.Time, string, error) {
+	t := now.In(s.location)
+	reason := ""
+	for t.Sub(now) <= maxScheduleLookahead {
+		if blackout, end := s.blackoutAt(t); blackout != nil {
+			if reason == "" {
+				reason = "blackout"
+				if blackout.Reason != "" {
+					reason += " (" + blackout.Reason + ")"
+				}
+			}
+			t = end
+			continue
+		}
+		if len(s.Windows) == 0 || s.inWindow(t) {
+			return t, reason, nil
+		}
+		if reason == "" {
+			reason = "outside the sending window"
+		}
+		t = s.nextWindowStart(t)
+	}
+	return time.Time{}, "", errors.New("schedule doesn't open within a year")
+}
+
+// blackoutAt returns the blackout t is in and when it ends, nil when there is none
+func (s *ScheduleConfig) blackoutAt(t time.Time) (*Blackout, time.Time) {
+	for i := range s.Blackouts {
+		start, end, _ := s.Blackouts[i].period(s.location)
+		if !t.Before(start) && t.Before(end) {
+			return &s.Blackouts[i], end
+		}
+	}
+	return nil, time.Time{}
+}
+
+// inWindow reports whether t is in a window starting on its day or, spanning midnight, on the day before
+func (s *ScheduleConfig) inWindow(t time.Time) bool {
+	for _, window := range s.Windows {
+		for offset := 0; offset >= -1; offset-- {
+			day := midnight(t).AddDate(0, 0, offset)
+			if !window.onDay(day.Weekday()) {
+				continue
+			}
+			start, end := window.on(day)
+			if !t.Before(start) && t.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextWindowStart returns the first start of a window after t
+func (s *ScheduleConfig) nextWindowStart(t time.Time) time.Time {
+	var next time.Time
+	for offset := 0; offset <= 7; offset++ {
+		day := midnight(t).AddDate(0, 0, offset)
+		for _, window := range s.Windows {
+			if !window.onDay(day.Weekday()) {
+				continue
+			}
+			if start, _ := window.on(day); start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	// Unreachable with at least one valid window, but never loop on the same time
+	return t.Add(24 * time.Hour)
+}
+
+func (w SendingWindow) onDay(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if weekdays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// on returns the start and end of the window on a day, given as its midnight
+func (w SendingWindow) on(day time.Time) (time.Time, time.Time) {
+	start := atClock(day, w.start)
+	end := atClock(day, w.end)
+	if w.end < w.start {
+		end = atClock(day.AddDate(0, 0, 1), w.end)
+	}
+	return start, end
+}
+
+// period returns the time range of a blackout
+func (b Blackout) period(location *time.Location) (time.Time, time.Time, error) {
+	if b.Date != "" {
+		day, err := time.ParseInLocation("2006-01-02", b.Date, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, must be YYYY-MM-DD", b.Date)
+		}
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if b.Start == nil || b.End == nil || !b.End.After(*b.Start) {
+		return time.Time{}, time.Time{}, errors.New("needs a date, or a start before its end")
+	}
+	return *b.Start, *b.End, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// atClock returns the time of day on a day, built from the wall clock so days with a DST change keep their hours
+func atClock(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, day.Location())
+}
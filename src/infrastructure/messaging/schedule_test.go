@@ -0,0 +1,84 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleConfig(t *testing.T) {
+	schedule, err := parseScheduleConfig(`{"schedule": {"timezone": "Europe/Berlin", "windows": [{"start": "08:00", "end": "21:00"}]}}`)
+	require.NoError(t, err)
+	require.NotNil(t, schedule)
+	assert.Equal(t, 8*time.Hour, schedule.Windows[0].start)
+
+	// Missing and empty schedules are ignored
+	for _, raw := range []string{"", `{}`, `{"schedule": {"timezone": "Europe/Berlin"}}`} {
+		schedule, err = parseScheduleConfig(raw)
+		assert.NoError(t, err)
+		assert.Nil(t, schedule)
+	}
+
+	for raw, message := range map[string]string{
+		`{"schedule": {"timezone": "Mars/Olympus", "windows": [{"start": "08:00", "end": "21:00"}]}}`: `unknown schedule timezone "Mars/Olympus"`,
+		`{"schedule": {"windows": [{"start": "8am", "end": "21:00"}]}}`:                               `invalid time of day "8am", must be HH:MM`,
+		`{"schedule": {"windows": [{"start": "08:00", "end": "08:00"}]}}`:                             "sending window 1 is empty",
+		`{"schedule": {"windows": [{"days": ["monday"], "start": "08:00", "end": "21:00"}]}}`:         `unknown day "monday" in sending window 1`,
+		`{"schedule": {"blackouts": [{"reason": "holiday"}]}}`:                                        "blackout 1: needs a date, or a start before its end",
+	} {
+		_, err = parseScheduleConfig(raw)
+		assert.EqualError(t, err, message, raw)
+	}
+}
+
+func TestScheduleConfig_NextOpening(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	schedule, err := parseScheduleConfig(`{"schedule": {
+		"timezone": "Europe/Berlin",
+		"windows": [{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "08:00", "end": "21:00"}],
+		"blackouts": [{"date": "2026-12-24", "reason": "Christmas Eve"}]
+	}}`)
+	require.NoError(t, err)
+
+	// Inside the window the schedule is open right away
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, berlin)
+	opening, reason, err := schedule.NextOpening(now)
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(now))
+	assert.Empty(t, reason)
+
+	// Late on a weekday it opens the next morning
+	opening, reason, err = schedule.NextOpening(time.Date(2026, 10, 14, 22, 30, 0, 0, berlin))
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(time.Date(2026, 10, 15, 8, 0, 0, 0, berlin)), opening)
+	assert.Equal(t, "outside the sending window", reason)
+
+	// On Saturday it opens on Monday
+	opening, _, err = schedule.NextOpening(time.Date(2026, 10, 17, 10, 0, 0, 0, berlin))
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, berlin)), opening)
+
+	// During a blackout it opens in the first window after it
+	opening, reason, err = schedule.NextOpening(time.Date(2026, 12, 24, 9, 0, 0, 0, berlin))
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(time.Date(2026, 12, 25, 8, 0, 0, 0, berlin)), opening)
+	assert.Equal(t, "blackout (Christmas Eve)", reason)
+}
+
+func TestScheduleConfig_NextOpeningAcrossMidnight(t *testing.T) {
+	schedule, err := parseScheduleConfig(`{"schedule": {"windows": [{"days": ["fri"], "start": "22:00", "end": "02:00"}]}}`)
+	require.NoError(t, err)
+
+	// The window of Friday night still covers early Saturday
+	now := time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC)
+	opening, _, err := schedule.NextOpening(now)
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(now))
+
+	opening, _, err = schedule.NextOpening(time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, opening.Equal(time.Date(2026, 10, 23, 22, 0, 0, 0, time.UTC)), opening)
+}
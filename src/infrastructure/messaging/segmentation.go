@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Length policies of providers, how a message longer than the provider sends is handled
+const (
+	// LengthPolicyReject refuses the message, the default
+	LengthPolicyReject = "reject"
+	// LengthPolicyTruncate cuts the message to the longest text the provider sends
+	LengthPolicyTruncate = "truncate"
+)
+
+// SMS encodings, a message with a character outside the GSM 7-bit alphabet is sent in UCS-2
+const (
+	EncodingGSM7 = "gsm7"
+	EncodingUCS2 = "ucs2"
+)
+
+// LengthLimits limit the length of the messages sent through a provider
+type LengthLimits struct {
+	// MaxCharacters is the longest message the provider type sends, 0 when it has no limit
+	MaxCharacters int
+	// MaxSegments is the most SMS segments a message is sent in, 0 when only MaxCharacters limits it
+	MaxSegments int
+	// Policy is LengthPolicyReject or LengthPolicyTruncate
+	Policy string
+}
+
+// Segmentation describes how a message is sent through a provider
+type Segmentation struct {
+	// Segments is the number of messages billed per recipient, SMS are billed per segment
+	Segments int
+	// Encoding is the SMS encoding, empty for the other types
+	Encoding string
+	// Characters is the length of the message
+	Characters    int
+	MaxCharacters int
+	MaxSegments   int
+	// Truncated reports whether the message was cut to the limits of the provider
+	Truncated bool
+}
+
+type lengthConfig struct {
+	LengthPolicy string `json:"length_policy"`
+	MaxSegments  int    `json:"max_segments"`
+}
+
+// ParseLengthLimits reads the length policy and the segment limit stored under the "length_policy" and
+// "max_segments" keys of a provider config. maxCharacters is the limit of the provider type. An invalid config
+// returns the error with the limits of the type and the reject policy.
+func ParseLengthLimits(config string, maxCharacters int) (LengthLimits, error) {
+	limits := LengthLimits{MaxCharacters: maxCharacters, Policy: LengthPolicyReject}
+	if config == "" {
+		return limits, nil
+	}
+	var parsed lengthConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return limits, err
+	}
+	switch parsed.LengthPolicy {
+	case "":
+	case LengthPolicyReject, LengthPolicyTruncate:
+		limits.Policy = parsed.LengthPolicy
+	default:
+		return limits, fmt.Errorf("unsupported length_policy: %s", parsed.LengthPolicy)
+	}
+	if parsed.MaxSegments < 0 {
+		return limits, fmt.Errorf("max_segments must not be negative")
+	}
+	limits.MaxSegments = parsed.MaxSegments
+	return limits, nil
+}
+
+// Segment fits a message to the length limits of a provider of the type. The suffix, e.g. acknowledgement
+// instructions, is appended to the message and kept whole when the message is truncated. It returns the text to
+// send with its segmentation, and false when the text is too long for the provider and the policy doesn't truncate
+// it, or it can't be truncated to fit.
+func Segment(providerType string, limits LengthLimits, message string, suffix string) (string, Segmentation, bool) {
+	text := message + suffix
+	segmentation := segment(providerType, limits, text)
+	if fits(segmentation) {
+		return text, segmentation, true
+	}
+	if limits.Policy != LengthPolicyTruncate {
+		return text, segmentation, false
+	}
+
+	// Keep the longest start of the message that fits together with the suffix
+	runes := []rune(message)
+	low, high := 0, len(runes)
+	for low < high {
+		middle := (low + high + 1) / 2
+		if fits(segment(providerType, limits, string(runes[:middle])+suffix)) {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+	truncated := string(runes[:low]) + suffix
+	truncatedSegmentation := segment(providerType, limits, truncated)
+	if !fits(truncatedSegmentation) {
+		return text, segmentation, false
+	}
+	truncatedSegmentation.Truncated = true
+	return truncated, truncatedSegmentation, true
+}
+
+// segment computes the segmentation of a text sent through a provider of the type
+func segment(providerType string, limits LengthLimits, text string) Segmentation {
+	segmentation := Segmentation{
+		Segments:      MessageSegments(providerType, text),
+		Characters:    utf8.RuneCountInString(text),
+		MaxCharacters: limits.MaxCharacters,
+		MaxSegments:   limits.MaxSegments,
+	}
+	if providerType == "sms" {
+		segmentation.Encoding = EncodingGSM7
+		if _, unicode := smsLength(text); unicode {
+			segmentation.Encoding = EncodingUCS2
+		}
+	}
+	return segmentation
+}
+
+func fits(segmentation Segmentation) bool {
+	return (segmentation.MaxCharacters == 0 || segmentation.Characters <= segmentation.MaxCharacters) &&
+		(segmentation.MaxSegments == 0 || segmentation.Segments <= segmentation.MaxSegments)
+}
@@ -0,0 +1,68 @@
+package messaging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLengthLimits(t *testing.T) {
+	limits, err := ParseLengthLimits("", 1600)
+	require.NoError(t, err)
+	assert.Equal(t, LengthLimits{MaxCharacters: 1600, Policy: LengthPolicyReject}, limits)
+
+	limits, err = ParseLengthLimits(`{"length_policy":"truncate","max_segments":3}`, 1600)
+	require.NoError(t, err)
+	assert.Equal(t, LengthLimits{MaxCharacters: 1600, MaxSegments: 3, Policy: LengthPolicyTruncate}, limits)
+
+	// An invalid config keeps the limit of the type and rejects longer messages
+	limits, err = ParseLengthLimits(`{"length_policy":"split"}`, 2000)
+	assert.Error(t, err)
+	assert.Equal(t, LengthLimits{MaxCharacters: 2000, Policy: LengthPolicyReject}, limits)
+
+	_, err = ParseLengthLimits(`{"max_segments":-1}`, 0)
+	assert.Error(t, err)
+}
+
+func TestSegment(t *testing.T) {
+	text, segmentation, ok := Segment("sms", LengthLimits{MaxCharacters: 1600}, strings.Repeat("a", 200), "")
+	require.True(t, ok)
+	assert.Equal(t, strings.Repeat("a", 200), text)
+	assert.Equal(t, Segmentation{Segments: 2, Encoding: EncodingGSM7, Characters: 200, MaxCharacters: 1600}, segmentation)
+
+	_, segmentation, ok = Segment("signal", LengthLimits{MaxCharacters: 2000}, "Grüße 😀", "")
+	require.True(t, ok)
+	assert.Equal(t, Segmentation{Segments: 1, Characters: 7, MaxCharacters: 2000}, segmentation)
+
+	// Rejected messages report their length
+	_, segmentation, ok = Segment("signal", LengthLimits{MaxCharacters: 2000, Policy: LengthPolicyReject}, strings.Repeat("a", 2001), "")
+	assert.False(t, ok)
+	assert.Equal(t, 2001, segmentation.Characters)
+
+	_, segmentation, ok = Segment("sms", LengthLimits{MaxCharacters: 1600, MaxSegments: 2}, strings.Repeat("ł", 150), "")
+	assert.False(t, ok)
+	assert.Equal(t, Segmentation{Segments: 3, Encoding: EncodingUCS2, Characters: 150, MaxCharacters: 1600, MaxSegments: 2}, segmentation)
+}
+
+func TestSegment_Truncates(t *testing.T) {
+	limits := LengthLimits{MaxCharacters: 1600, MaxSegments: 2, Policy: LengthPolicyTruncate}
+
+	// The suffix is kept whole, the message is cut to what fits in the segments
+	suffix := "\n\nReply ACK K7QX2M to acknowledge."
+	text, segmentation, ok := Segment("sms", limits, strings.Repeat("a", 400), suffix)
+	require.True(t, ok)
+	assert.True(t, strings.HasSuffix(text, suffix))
+	assert.Equal(t, 306, len(text))
+	assert.Equal(t, Segmentation{Segments: 2, Encoding: EncodingGSM7, Characters: 306, MaxCharacters: 1600, MaxSegments: 2, Truncated: true}, segmentation)
+
+	text, segmentation, ok = Segment("signal", LengthLimits{MaxCharacters: 10, Policy: LengthPolicyTruncate}, "Grüße aus Berlin", "")
+	require.True(t, ok)
+	assert.Equal(t, "Grüße aus ", text)
+	assert.True(t, segmentation.Truncated)
+
+	// A suffix longer than the limit can't be fit
+	_, _, ok = Segment("signal", LengthLimits{MaxCharacters: 10, Policy: LengthPolicyTruncate}, "Hello", strings.Repeat("x", 11))
+	assert.False(t, ok)
+}
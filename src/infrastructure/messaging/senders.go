@@ -0,0 +1,897 @@
+package messaging
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/alerting/provider/email"
+	"go-multi-chat-api/src/infrastructure/discord"
+	"go-multi-chat-api/src/infrastructure/line"
+	"go-multi-chat-api/src/infrastructure/matrix"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+	telegramClient "go-multi-chat-api/src/infrastructure/repository/telegram-client"
+	"go-multi-chat-api/src/infrastructure/twilio"
+)
+
+// ProviderSender sends messages through the providers of one type. The processor looks up the sender of a
+// provider by its type, so a new provider type only needs a sender registered in the application context
+// and a schema in providerconfig.
+type ProviderSender interface {
+	// Send sends a message of a user to the recipients and returns the raw request and response of the
+	// provider call, the response of the recipients sent to before an error included
+	Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error)
+}
+
+// DeliveryTracker is implemented by the senders of providers reporting the delivery to each recipient through
+// callbacks. The processor records the message each recipient was sent, so the callbacks can be matched to it.
+type DeliveryTracker interface {
+	// SentMessageIDs reads the ID the provider gave the message of each recipient from the response data of a send
+	// to the recipients
+	SentMessageIDs(recipients []string, responseData []byte) map[string]string
+}
+
+// ExtensionSender is implemented by the senders of provider types supporting message extensions. The processor
+// sends messages carrying extensions through it, other senders send their text alone.
+type ExtensionSender interface {
+	// SendWithExtensions sends like Send, with the options of the extensions of the provider type
+	SendWithExtensions(userID int, providerDetails *provider.Provider, message string, recipients []string, extensions *provider.MessageExtensions) ([]byte, []byte, error)
+}
+
+// ActionSender is implemented by the senders of provider types showing the actions of a message as buttons. The
+// processor appends the actions of messages sent through other senders to their text as numbered options.
+type ActionSender interface {
+	// SendWithActions sends like Send, with a button for each action. The buttons carry provider.ActionData of
+	// the message, which the provider posts back on a click.
+	SendWithActions(userID int, providerDetails *provider.Provider, messageID int, message string, recipients []string, actions []provider.MessageAction) ([]byte, []byte, error)
+}
+
+// MessageEditor is implemented by the senders of provider types that can change the text of a sent message. The
+// messages to change are read from the response data of the send.
+type MessageEditor interface {
+	// Edit changes the text of the message sent to the recipients and returns the raw request and response of the
+	// provider call, the response of the messages changed before an error included
+	Edit(userID int, providerDetails *provider.Provider, message string, recipients []string, responseData []byte) ([]byte, []byte, error)
+}
+
+// ErrorClassifier is implemented by the senders knowing the errors of their provider. The processor stores the
+// code of a failed send with the message and decides with it whether the message is retried, falls back to another
+// provider or is given up.
+type ErrorClassifier interface {
+	// ErrorCode returns the provider error code of an error of a send, empty for errors the provider didn't answer
+	ErrorCode(err error) string
+}
+
+// CredentialChecker is implemented by the senders of provider types whose credentials can be checked without
+// sending a message. The credentials are checked when users save their config of a provider.
+type CredentialChecker interface {
+	// CheckCredentials makes a lightweight authenticated call with the credentials the provider sends with for a
+	// user holding the given user provider config, it returns why they were refused
+	CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error
+}
+
+// errNoUserProviderConfig is returned by userProviderConfig when the user has no config of the provider
+var errNoUserProviderConfig = errors.New("user has no config for the provider")
+
+// maxEmailSubjectRunes is the length a subject taken from the message is shortened to
+const maxEmailSubjectRunes = 78
+
+// contentIDReference matches the cid: URLs of an HTML email, the images it shows from its inline attachments
+var contentIDReference = regexp.MustCompile(`(?i)cid:([^"'\s)>]+)`)
+
+// errNothingToEdit is returned by the editors when the response data of a send names no message to edit
+var errNothingToEdit = errors.New("the response of the send names no message to edit")
+
+// AttachmentLoader returns the attachments users uploaded in parts with their content
+type AttachmentLoader interface {
+	Load(userID int, id int) (*provider.Attachment, []byte, error)
+}
+
+// DeviceLinks tracks the numbers operated as linked devices of an account
+type DeviceLinks interface {
+	// LinkStatus returns the link status of a number, empty for numbers that aren't linked devices
+	LinkStatus(number string) string
+	// MarkUnlinked records that the primary device unlinked a number
+	MarkUnlinked(number string, reason string) error
+}
+
+// NumberHealth tracks the health of the numbers sent from by the outcome of their sends
+type NumberHealth interface {
+	RecordSendSuccess(number string)
+	RecordSendFailure(number string, err error)
+}
+
+// SignalSender sends through the Signal backend from SIGNAL_FROM_NUMBER
+type SignalSender struct {
+	service     domainSignal.ISignalService
+	attachments AttachmentLoader
+	deviceLinks DeviceLinks
+	health      NumberHealth
+}
+
+// NewSignalSender creates a new Signal sender, attachments is nil when attachments can't be uploaded in parts,
+// deviceLinks is nil when numbers aren't linked as devices and health is nil when the health of numbers isn't
+// tracked
+func NewSignalSender(service domainSignal.ISignalService, attachments AttachmentLoader, deviceLinks DeviceLinks, health NumberHealth) *SignalSender {
+	return &SignalSender{service: service, attachments: attachments, deviceLinks: deviceLinks, health: health}
+}
+
+func (s *SignalSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	return s.SendWithExtensions(userID, providerDetails, message, recipients, nil)
+}
+
+// SendWithExtensions sends with the attachments, view-once and text mode of the Signal extension
+func (s *SignalSender) SendWithExtensions(userID int, providerDetails *provider.Provider, message string, recipients []string, extensions *provider.MessageExtensions) ([]byte, []byte, error) {
+	signalRequest := domainSignal.SendRequest{
+		Number:     os.Getenv("SIGNAL_FROM_NUMBER"),
+		Message:    message,
+		Recipients: recipients,
+	}
+	if extensions != nil && extensions.Signal != nil {
+		signalRequest.Base64Attachments = extensions.Signal.Base64Attachments
+		if len(extensions.Signal.AttachmentIDs) > 0 {
+			attachments, err := s.loadAttachments(userID, extensions.Signal.AttachmentIDs)
+			if err != nil {
+				requestData, _ := json.Marshal(signalRequest)
+				return requestData, nil, err
+			}
+			signalRequest.Base64Attachments = append(append([]string{}, signalRequest.Base64Attachments...), attachments...)
+		}
+		if extensions.Signal.ViewOnce {
+			viewOnce := true
+			signalRequest.ViewOnce = &viewOnce
+		}
+		if extensions.Signal.TextMode != "" {
+			textMode := extensions.Signal.TextMode
+			signalRequest.TextMode = &textMode
+		}
+		if extensions.Signal.ResolveMentions {
+			mentions, err := s.resolveMentions(signalRequest.Number, message, recipients)
+			if err != nil {
+				requestData, _ := json.Marshal(signalRequest)
+				return requestData, nil, err
+			}
+			signalRequest.Mentions = mentions
+		}
+	}
+	requestData, _ := json.Marshal(signalRequest)
+
+	data, err := s.send(signalRequest)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	var responseData []byte
+	if data != nil {
+		responseData, _ = json.Marshal(data)
+	}
+	return requestData, responseData, nil
+}
+
+// send sends a request and records its outcome in the health of the number it is sent from
+func (s *SignalSender) send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	data, err := s.sendFromDevice(request)
+	if s.health != nil {
+		if err != nil {
+			s.health.RecordSendFailure(request.Number, err)
+		} else {
+			s.health.RecordSendSuccess(request.Number)
+		}
+	}
+	return data, err
+}
+
+// sendFromDevice fails a request at once when it is sent from a linked device its primary unlinked. A linked
+// device the backend refuses to authorize was unlinked meanwhile, it is recorded as unlinked.
+func (s *SignalSender) sendFromDevice(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	if s.deviceLinks == nil {
+		return s.service.Send(request)
+	}
+	status := s.deviceLinks.LinkStatus(request.Number)
+	if status == domainSignal.DeviceLinkStatusUnlinked {
+		return nil, &domainSignal.DeviceUnlinkedError{Number: request.Number}
+	}
+	data, err := s.service.Send(request)
+	if err != nil && status == domainSignal.DeviceLinkStatusLinked && signalClient.ErrorCode(err) == provider.ErrorCodeAuthFailed {
+		_ = s.deviceLinks.MarkUnlinked(request.Number, err.Error())
+		return data, &domainSignal.DeviceUnlinkedError{Number: request.Number, Err: err}
+	}
+	return data, err
+}
+
+// loadAttachments reads the uploaded attachments of a user as data URIs naming their type and file
+func (s *SignalSender) loadAttachments(userID int, ids []int) ([]string, error) {
+	if s.attachments == nil {
+		return nil, errors.New("attachment uploads are not enabled")
+	}
+	dataURIs := make([]string, len(ids))
+	for i, id := range ids {
+		attachment, data, err := s.attachments.Load(userID, id)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load attachment %d: %w", id, err)
+		}
+		dataURIs[i] = fmt.Sprintf("data:%s;filename=%s;base64,%s", attachment.MimeType, attachment.Filename, base64.StdEncoding.EncodeToString(data))
+	}
+	return dataURIs, nil
+}
+
+// resolveMentions mentions the members of the group recipients named in the message. Names several members share
+// are left as text, the message is sent without them rather than failing for good.
+func (s *SignalSender) resolveMentions(number string, message string, recipients []string) ([]domainSignal.MessageMention, error) {
+	var members []domainSignal.GroupMember
+	for _, recipient := range recipients {
+		if !domainSignal.IsGroupRecipient(recipient) {
+			continue
+		}
+		groupMembers, err := s.service.GetGroupMembers(number, recipient)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get the members of %s to resolve mentions: %w", recipient, err)
+		}
+		members = append(members, groupMembers...)
+	}
+	mentions, _ := domainSignal.ResolveMentions(message, members)
+	return mentions, nil
+}
+
+// SentMessageIDs identifies the message of each phone number by the timestamp of its send, read and delivery
+// receipts come from the number and name the timestamp. Groups and usernames are left out, their receipts can't be
+// matched.
+func (s *SignalSender) SentMessageIDs(recipients []string, responseData []byte) map[string]string {
+	timestamps := sentTimestamps(recipients, responseData)
+	if timestamps == nil {
+		return nil
+	}
+	messageIDs := make(map[string]string, len(recipients))
+	for i, recipient := range recipients {
+		if timestamps[i] != 0 && strings.HasPrefix(recipient, "+") {
+			messageIDs[recipient] = domainSignal.DeliveryID(timestamps[i], recipient)
+		}
+	}
+	return messageIDs
+}
+
+// Edit sends the new text as an edit of the message to each recipient, which names the timestamp of the send.
+// Recipients sent the message with the same timestamp get one edit.
+func (s *SignalSender) Edit(userID int, providerDetails *provider.Provider, message string, recipients []string, responseData []byte) ([]byte, []byte, error) {
+	timestamps := sentTimestamps(recipients, responseData)
+	if timestamps == nil {
+		return nil, nil, errNothingToEdit
+	}
+	var order []int64
+	byTimestamp := make(map[int64][]string)
+	for i, recipient := range recipients {
+		if timestamps[i] == 0 {
+			continue
+		}
+		if _, ok := byTimestamp[timestamps[i]]; !ok {
+			order = append(order, timestamps[i])
+		}
+		byTimestamp[timestamps[i]] = append(byTimestamp[timestamps[i]], recipient)
+	}
+	if len(order) == 0 {
+		return nil, nil, errNothingToEdit
+	}
+
+	requests := make([]json.RawMessage, 0, len(order))
+	responses := make([]json.RawMessage, 0, len(order))
+	var editErr error
+	for _, timestamp := range order {
+		editTimestamp := timestamp
+		signalRequest := domainSignal.SendRequest{
+			Number:        os.Getenv("SIGNAL_FROM_NUMBER"),
+			Message:       message,
+			Recipients:    byTimestamp[timestamp],
+			EditTimestamp: &editTimestamp,
+		}
+		requestData, _ := json.Marshal(signalRequest)
+		requests = append(requests, requestData)
+
+		data, err := s.send(signalRequest)
+		if err != nil {
+			editErr = err
+			break
+		}
+		edited, _ := json.Marshal(data)
+		responses = append(responses, edited)
+	}
+	requestData, _ := json.Marshal(requests)
+	return requestData, resultsData(responses), editErr
+}
+
+// sentTimestamps reads the timestamp of the message to each recipient from the response data of a send, 0 for the
+// recipients it has none for. Messages with tracked links were sent to each recipient on its own, in the order of
+// the recipients. It returns nil when the response data can't be read.
+func sentTimestamps(recipients []string, responseData []byte) []int64 {
+	timestamps := make([]int64, len(recipients))
+	var responses []domainSignal.SendResponse
+	if err := json.Unmarshal(responseData, &responses); err == nil {
+		if len(responses) != 1 {
+			return nil
+		}
+		for i := range timestamps {
+			timestamps[i] = responses[0].Timestamp
+		}
+		return timestamps
+	}
+
+	var recipientResponses [][]domainSignal.SendResponse
+	if json.Unmarshal(responseData, &recipientResponses) != nil {
+		return nil
+	}
+	for i := 0; i < len(recipientResponses) && i < len(timestamps); i++ {
+		if len(recipientResponses[i]) == 1 {
+			timestamps[i] = recipientResponses[i][0].Timestamp
+		}
+	}
+	return timestamps
+}
+
+func (s *SignalSender) ErrorCode(err error) string {
+	return signalClient.ErrorCode(err)
+}
+
+// MatrixSender sends with the Matrix account of the user, the recipients are room ids or room aliases
+type MatrixSender struct {
+	clients                *matrix.Clients
+	userProviderRepository providerRepo.UserProviderRepositoryInterface
+}
+
+// NewMatrixSender creates a new Matrix sender
+func NewMatrixSender(clients *matrix.Clients, userProviderRepository providerRepo.UserProviderRepositoryInterface) *MatrixSender {
+	return &MatrixSender{clients: clients, userProviderRepository: userProviderRepository}
+}
+
+func (s *MatrixSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := matrix.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.clients.Get(config).Send(recipients, message)
+	return requestData, resultsData(results), err
+}
+
+// Edit replaces the events the message was sent as
+func (s *MatrixSender) Edit(userID int, providerDetails *provider.Provider, message string, recipients []string, responseData []byte) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	sent := sentResults[matrix.SendResult](responseData)
+	if len(sent) == 0 {
+		return requestData, nil, errNothingToEdit
+	}
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := matrix.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.clients.Get(config).Edit(sent, message)
+	return requestData, resultsData(results), err
+}
+
+// CheckCredentials asks the homeserver who the access token belongs to
+func (s *MatrixSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := matrix.ParseConfig(userProviderConfig)
+	if err != nil {
+		return err
+	}
+	_, err = s.clients.Get(config).WhoAmI()
+	return err
+}
+
+func (s *MatrixSender) ErrorCode(err error) string {
+	return matrix.ErrorCode(err)
+}
+
+// DiscordSender sends with the bot or webhook of the user, the recipients are channel ids or "webhook"
+type DiscordSender struct {
+	client                 *discord.Client
+	userProviderRepository providerRepo.UserProviderRepositoryInterface
+}
+
+// NewDiscordSender creates a new Discord sender
+func NewDiscordSender(client *discord.Client, userProviderRepository providerRepo.UserProviderRepositoryInterface) *DiscordSender {
+	return &DiscordSender{client: client, userProviderRepository: userProviderRepository}
+}
+
+func (s *DiscordSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := discord.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.Send(config, recipients, message, nil)
+	return requestData, resultsData(results), err
+}
+
+// SendWithActions sends the text with buttons to the channels. The webhook can't send buttons, it is sent the
+// actions as numbered options after the channels.
+func (s *DiscordSender) SendWithActions(userID int, providerDetails *provider.Provider, messageID int, message string, recipients []string, actions []provider.MessageAction) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := discord.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	buttons := make([]discord.Button, len(actions))
+	for i, action := range actions {
+		buttons[i] = discord.Button{CustomID: provider.ActionData(messageID, action.ID), Label: action.Label}
+	}
+	channels := make([]string, 0, len(recipients))
+	webhook := false
+	for _, recipient := range recipients {
+		if recipient == discord.WebhookRecipient {
+			webhook = true
+			continue
+		}
+		channels = append(channels, recipient)
+	}
+
+	var results []discord.SendResult
+	if len(channels) > 0 {
+		results, err = s.client.SendWithButtons(config, channels, message, buttons)
+		if err != nil {
+			return requestData, resultsData(results), err
+		}
+	}
+	if webhook {
+		webhookResults, err := s.client.Send(config, []string{discord.WebhookRecipient}, message+provider.ActionOptions(actions), nil)
+		results = append(results, webhookResults...)
+		if err != nil {
+			return requestData, resultsData(results), err
+		}
+	}
+	return requestData, resultsData(results), nil
+}
+
+// Edit edits the messages the text was sent as
+func (s *DiscordSender) Edit(userID int, providerDetails *provider.Provider, message string, recipients []string, responseData []byte) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	sent := sentResults[discord.SendResult](responseData)
+	if len(sent) == 0 {
+		return requestData, nil, errNothingToEdit
+	}
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := discord.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.Edit(config, sent, message)
+	return requestData, resultsData(results), err
+}
+
+// CheckCredentials fetches the bot user and the webhook of the user
+func (s *DiscordSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := discord.ParseConfig(userProviderConfig)
+	if err != nil {
+		return err
+	}
+	return s.client.VerifyCredentials(config)
+}
+
+func (s *DiscordSender) ErrorCode(err error) string {
+	return discord.ErrorCode(err)
+}
+
+// TelegramSender sends with the bot of the user, the recipients are chat ids or @usernames of public channels
+type TelegramSender struct {
+	client                 *telegramClient.Client
+	userProviderRepository providerRepo.UserProviderRepositoryInterface
+}
+
+// NewTelegramSender creates a new Telegram sender
+func NewTelegramSender(client *telegramClient.Client, userProviderRepository providerRepo.UserProviderRepositoryInterface) *TelegramSender {
+	return &TelegramSender{client: client, userProviderRepository: userProviderRepository}
+}
+
+func (s *TelegramSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := telegramClient.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.Send(config, recipients, message)
+	return requestData, resultsData(results), err
+}
+
+// Edit edits the messages the text was sent as
+func (s *TelegramSender) Edit(userID int, providerDetails *provider.Provider, message string, recipients []string, responseData []byte) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	sent := sentResults[telegramClient.SendResult](responseData)
+	if len(sent) == 0 {
+		return requestData, nil, errNothingToEdit
+	}
+	userProviderConfig, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil {
+		return requestData, nil, err
+	}
+	config, err := telegramClient.ParseConfig(userProviderConfig)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.Edit(config, sent, message)
+	return requestData, resultsData(results), err
+}
+
+// CheckCredentials fetches the bot of the user
+func (s *TelegramSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := telegramClient.ParseConfig(userProviderConfig)
+	if err != nil {
+		return err
+	}
+	return s.client.VerifyCredentials(config)
+}
+
+func (s *TelegramSender) ErrorCode(err error) string {
+	return telegramClient.ErrorCode(err)
+}
+
+// LineSender pushes with the LINE official account of the provider, the recipients are user, group or chat ids
+type LineSender struct {
+	client *line.Client
+}
+
+// NewLineSender creates a new LINE sender
+func NewLineSender(client *line.Client) *LineSender {
+	return &LineSender{client: client}
+}
+
+func (s *LineSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	config, err := line.ParseConfig(providerDetails.Config)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.Send(config, recipients, message)
+	return requestData, resultsData(results), err
+}
+
+// SendWithActions pushes the text with a quick reply for each action
+func (s *LineSender) SendWithActions(userID int, providerDetails *provider.Provider, messageID int, message string, recipients []string, actions []provider.MessageAction) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	config, err := line.ParseConfig(providerDetails.Config)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	replies := make([]line.QuickReply, len(actions))
+	for i, action := range actions {
+		replies[i] = line.QuickReply{Label: action.Label, Data: provider.ActionData(messageID, action.ID)}
+	}
+	results, err := s.client.SendWithQuickReplies(config, recipients, message, replies)
+	return requestData, resultsData(results), err
+}
+
+// CheckCredentials checks the channel access token of the provider, users have no LINE credentials of their own
+func (s *LineSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := line.ParseConfig(providerDetails.Config)
+	if err != nil {
+		return err
+	}
+	return s.client.VerifyCredentials(config)
+}
+
+func (s *LineSender) ErrorCode(err error) string {
+	return line.ErrorCode(err)
+}
+
+// SMSSender sends SMS through the Twilio account of the provider, the recipients are phone numbers
+type SMSSender struct {
+	client      *twilio.Client
+	callbackURL func(providerID int) string
+}
+
+// NewSMSSender creates a new SMS sender, Twilio posts the delivery status of the messages to the URL callbackURL
+// returns for the provider, no status is posted when it returns an empty URL
+func NewSMSSender(client *twilio.Client, callbackURL func(providerID int) string) *SMSSender {
+	return &SMSSender{client: client, callbackURL: callbackURL}
+}
+
+func (s *SMSSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	requestData := textRequestData(message, recipients)
+
+	config, err := twilio.ParseConfig(providerDetails.Config)
+	if err != nil {
+		return requestData, nil, err
+	}
+
+	results, err := s.client.SendSMS(config, recipients, message, s.callbackURL(providerDetails.ID))
+	return requestData, resultsData(results), err
+}
+
+// SentMessageIDs reads the message SIDs of the recipients, from the results of a send or, for messages with
+// tracked links sent to each recipient on its own, from the results of each send
+func (s *SMSSender) SentMessageIDs(recipients []string, responseData []byte) map[string]string {
+	var results []twilio.SendResult
+	if err := json.Unmarshal(responseData, &results); err != nil {
+		var responses []json.RawMessage
+		if json.Unmarshal(responseData, &responses) != nil {
+			return nil
+		}
+		results = results[:0]
+		for _, response := range responses {
+			var recipientResults []twilio.SendResult
+			if json.Unmarshal(response, &recipientResults) == nil {
+				results = append(results, recipientResults...)
+			}
+		}
+	}
+	messageIDs := make(map[string]string, len(results))
+	for _, result := range results {
+		if result.MessageSID != "" {
+			messageIDs[result.Recipient] = result.MessageSID
+		}
+	}
+	return messageIDs
+}
+
+// CheckCredentials checks the Twilio account of the provider, users have no Twilio credentials of their own
+func (s *SMSSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := twilio.ParseConfig(providerDetails.Config)
+	if err != nil {
+		return err
+	}
+	return s.client.VerifyCredentials(config)
+}
+
+func (s *SMSSender) ErrorCode(err error) string {
+	return twilio.ErrorCode(err)
+}
+
+// EmailSender sends emails through the SMTP server of the provider, with the sender address and credentials of the
+// user provider config overriding those of the provider. Every recipient gets an email of their own.
+type EmailSender struct {
+	userProviderRepository providerRepo.UserProviderRepositoryInterface
+	attachments            AttachmentLoader
+}
+
+// NewEmailSender creates a new email sender, attachments is nil when attachments can't be uploaded in parts
+func NewEmailSender(userProviderRepository providerRepo.UserProviderRepositoryInterface, attachments AttachmentLoader) *EmailSender {
+	return &EmailSender{userProviderRepository: userProviderRepository, attachments: attachments}
+}
+
+// EmailResult is the email sent to one recipient
+type EmailResult struct {
+	Recipient string `json:"recipient"`
+	MessageID string `json:"message_id"`
+}
+
+func (s *EmailSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	return s.SendWithExtensions(userID, providerDetails, message, recipients, nil)
+}
+
+// SendWithExtensions sends with the subject, the HTML alternative and the attachments of the email extension.
+// Without a subject the first line of the message is the subject.
+func (s *EmailSender) SendWithExtensions(userID int, providerDetails *provider.Provider, message string, recipients []string, extensions *provider.MessageExtensions) ([]byte, []byte, error) {
+	var extension provider.EmailExtension
+	if extensions != nil && extensions.Email != nil {
+		extension = *extensions.Email
+	}
+	if extension.Subject == "" {
+		extension.Subject = emailSubject(message)
+	}
+	request := map[string]interface{}{
+		"recipients": recipients,
+		"subject":    extension.Subject,
+		"message":    message,
+		"html":       extension.HTML,
+	}
+	if len(extension.AttachmentIDs) > 0 {
+		request["attachment_ids"] = extension.AttachmentIDs
+	}
+	requestData, _ := json.Marshal(request)
+
+	config, err := s.config(userID, providerDetails)
+	if err != nil {
+		return requestData, nil, err
+	}
+	attachments, err := s.loadAttachments(userID, extension.AttachmentIDs, extension.HTML)
+	if err != nil {
+		return requestData, nil, err
+	}
+	messages := make([]email.Message, len(recipients))
+	results := make([]EmailResult, len(recipients))
+	for i, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return requestData, nil, &provider.RecipientError{Description: fmt.Sprintf("recipient %q is not an email address", recipient)}
+		}
+		messageID, err := emailMessageID(config.From)
+		if err != nil {
+			return requestData, nil, err
+		}
+		messages[i] = email.Message{To: []string{recipient}, Subject: extension.Subject, Text: message, HTML: extension.HTML, Headers: map[string]string{"Message-ID": messageID}, Attachments: attachments}
+		results[i] = EmailResult{Recipient: recipient, MessageID: messageID}
+	}
+
+	sent, err := config.SendMessages(messages)
+	return requestData, resultsData(results[:sent]), err
+}
+
+// loadAttachments reads the uploaded attachments of a user for an email. The images the HTML references as
+// cid:<filename> are inline attachments.
+func (s *EmailSender) loadAttachments(userID int, ids []int, html string) ([]email.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if s.attachments == nil {
+		return nil, errors.New("attachment uploads are not enabled")
+	}
+	referenced := make(map[string]bool)
+	for _, match := range contentIDReference.FindAllStringSubmatch(html, -1) {
+		if contentID, err := url.PathUnescape(match[1]); err == nil {
+			referenced[contentID] = true
+		}
+	}
+	attachments := make([]email.Attachment, len(ids))
+	for i, id := range ids {
+		attachment, data, err := s.attachments.Load(userID, id)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load attachment %d: %w", id, err)
+		}
+		inline := referenced[attachment.Filename] && strings.HasPrefix(attachment.MimeType, "image/")
+		attachments[i] = email.Attachment{Filename: attachment.Filename, ContentType: attachment.MimeType, Data: data, Inline: inline}
+	}
+	return attachments, nil
+}
+
+// CheckCredentials authenticates to the SMTP server with the credentials the user sends with
+func (s *EmailSender) CheckCredentials(providerDetails *provider.Provider, userProviderConfig string) error {
+	config, err := email.ParseConfig(providerDetails.Config, userProviderConfig)
+	if err != nil {
+		return err
+	}
+	return config.VerifyCredentials()
+}
+
+// ErrorCode classifies the errors of an SMTP server by their reply code
+func (s *EmailSender) ErrorCode(err error) string {
+	var smtpErr *textproto.Error
+	if !errors.As(err, &smtpErr) {
+		return ""
+	}
+	switch {
+	case smtpErr.Code == 530 || smtpErr.Code == 534 || smtpErr.Code == 535:
+		return provider.ErrorCodeAuthFailed
+	case smtpErr.Code == 550 || smtpErr.Code == 551 || smtpErr.Code == 553:
+		return provider.ErrorCodeInvalidRecipient
+	case smtpErr.Code == 421 || smtpErr.Code == 450 || smtpErr.Code == 451 || smtpErr.Code == 452:
+		// The server is busy or throttles the sender, the message can be sent later
+		return provider.ErrorCodeRateLimited
+	}
+	return provider.ErrorCodeUnknown
+}
+
+// config returns the SMTP server of the provider with the overrides of the user, users without a config of the
+// provider send with the credentials of the provider
+func (s *EmailSender) config(userID int, providerDetails *provider.Provider) (*email.Config, error) {
+	config, err := userProviderConfig(s.userProviderRepository, userID, providerDetails.ID)
+	if err != nil && !errors.Is(err, errNoUserProviderConfig) {
+		return nil, err
+	}
+	return email.ParseConfig(providerDetails.Config, config)
+}
+
+// emailSubject returns the first line of a message as the subject of its email, shortened to a line mail clients
+// show in full
+func emailSubject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSpace(subject)
+	if runes := []rune(subject); len(runes) > maxEmailSubjectRunes {
+		subject = strings.TrimSpace(string(runes[:maxEmailSubjectRunes-1])) + "…"
+	}
+	return subject
+}
+
+// emailMessageID generates the Message-ID of an email, on the domain of the sender address
+func emailMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain), nil
+}
+
+// textRequestData is the request data stored for providers sending a plain text to each recipient
+func textRequestData(message string, recipients []string) []byte {
+	requestData, _ := json.Marshal(map[string]interface{}{
+		"recipients": recipients,
+		"message":    message,
+	})
+	return requestData
+}
+
+// resultsData is the response data stored for the per recipient results of a send, nil when nothing was sent
+func resultsData[T any](results []T) []byte {
+	if len(results) == 0 {
+		return nil
+	}
+	responseData, _ := json.Marshal(results)
+	return responseData
+}
+
+// sentResults reads the per recipient results of a send from its response data, or of the sends to each
+// recipient on its own for messages with tracked links
+func sentResults[T any](responseData []byte) []T {
+	var results []T
+	if json.Unmarshal(responseData, &results) == nil {
+		return results
+	}
+	var responses []json.RawMessage
+	if json.Unmarshal(responseData, &responses) != nil {
+		return nil
+	}
+	for _, response := range responses {
+		var recipientResults []T
+		if json.Unmarshal(response, &recipientResults) == nil {
+			results = append(results, recipientResults...)
+		}
+	}
+	return results
+}
+
+// userProviderConfig returns the config of a user for a provider, which holds the account of the user on
+// providers sending with the credentials of each user
+func userProviderConfig(repository providerRepo.UserProviderRepositoryInterface, userID int, providerID int) (string, error) {
+	userProviders, err := repository.GetUserProviders(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, up := range *userProviders {
+		if up.ProviderID == providerID {
+			return up.Config, nil
+		}
+	}
+	return "", errNoUserProviderConfig
+}
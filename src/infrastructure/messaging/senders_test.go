@@ -0,0 +1,604 @@
+package messaging
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/alerting/provider/email"
+	"go-multi-chat-api/src/infrastructure/line"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	telegramClient "go-multi-chat-api/src/infrastructure/repository/telegram-client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUserProviderRepository implements GetUserProviders, the embedded interface panics on any other call
+type mockUserProviderRepository struct {
+	providerRepo.UserProviderRepositoryInterface
+	userProviders []provider.UserProvider
+	err           error
+}
+
+func (m *mockUserProviderRepository) GetUserProviders(userID int) (*[]provider.UserProvider, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.userProviders, nil
+}
+
+type mockSender struct {
+	userID int
+}
+
+func (m *mockSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	m.userID = userID
+	return []byte(`{}`), []byte(`{"sent":true}`), nil
+}
+
+func TestSendThroughProvider_UsesSenderOfType(t *testing.T) {
+	sender := &mockSender{}
+	processor := &MessageProcessor{senders: map[string]ProviderSender{"viber": sender}}
+
+	_, responseData, err := processor.SendThroughProvider(7, &provider.Provider{Type: "viber"}, "hello", []string{"a"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sent":true}`, string(responseData))
+	assert.Equal(t, 7, sender.userID)
+
+	_, _, err = processor.SendThroughProvider(7, &provider.Provider{Type: "pager"}, "hello", []string{"a"})
+	assert.EqualError(t, err, "unsupported provider type: pager")
+}
+
+type mockExtensionSender struct {
+	mockSender
+	extensions *provider.MessageExtensions
+}
+
+func (m *mockExtensionSender) SendWithExtensions(userID int, providerDetails *provider.Provider, message string, recipients []string, extensions *provider.MessageExtensions) ([]byte, []byte, error) {
+	m.extensions = extensions
+	return m.Send(userID, providerDetails, message, recipients)
+}
+
+func TestSend_PassesExtensionsToSendersSupportingThem(t *testing.T) {
+	extensionSender := &mockExtensionSender{}
+	textSender := &mockSender{}
+	processor := &MessageProcessor{senders: map[string]ProviderSender{"signal": extensionSender, "line": textSender}}
+	msg := &provider.MessageTransaction{UserID: 7, Extensions: `{"signal":{"base64_attachments":["aGk="],"view_once":true}}`}
+
+	_, _, err := processor.send(msg, &provider.Provider{Type: "signal"}, "hello", []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, &provider.MessageExtensions{Signal: &provider.SignalExtension{Base64Attachments: []string{"aGk="}, ViewOnce: true}}, extensionSender.extensions)
+
+	_, _, err = processor.send(msg, &provider.Provider{Type: "line"}, "hello", []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, 7, textSender.userID)
+
+	msg.Extensions = `{"signal":`
+	_, _, err = processor.send(msg, &provider.Provider{Type: "signal"}, "hello", []string{"a"})
+	assert.Error(t, err)
+}
+
+type mockActionSender struct {
+	mockSender
+	messageID int
+	actions   []provider.MessageAction
+}
+
+func (m *mockActionSender) SendWithActions(userID int, providerDetails *provider.Provider, messageID int, message string, recipients []string, actions []provider.MessageAction) ([]byte, []byte, error) {
+	m.messageID = messageID
+	m.actions = actions
+	return m.Send(userID, providerDetails, message, recipients)
+}
+
+func TestSend_SendsActionsAsButtonsOrNumberedOptions(t *testing.T) {
+	actionSender := &mockActionSender{}
+	textSender := &recordingSender{messages: map[string]string{}}
+	processor := &MessageProcessor{senders: map[string]ProviderSender{"discord": actionSender, "sms": textSender}}
+	msg := &provider.MessageTransaction{ID: 42, UserID: 7, Actions: `[{"id":"approve","label":"Approve"},{"id":"reject","label":"Reject"}]`}
+
+	_, _, err := processor.send(msg, &provider.Provider{Type: "discord"}, "Deploy?", []string{"123456789012345678"})
+	require.NoError(t, err)
+	assert.Equal(t, 42, actionSender.messageID)
+	assert.Equal(t, []provider.MessageAction{{ID: "approve", Label: "Approve"}, {ID: "reject", Label: "Reject"}}, actionSender.actions)
+
+	_, _, err = processor.send(msg, &provider.Provider{Type: "sms"}, "Deploy?", []string{"+491111"})
+	require.NoError(t, err)
+	assert.Equal(t, "Deploy?\n\nReply with the number of your choice:\n1. Approve\n2. Reject", textSender.messages["+491111"])
+}
+
+type mockSignalService struct {
+	domainSignal.ISignalService
+	request domainSignal.SendRequest
+	err     error
+}
+
+func (m *mockSignalService) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	m.request = request
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &[]domainSignal.SendResponse{}, nil
+}
+
+func TestSignalSender_SendsSignalExtension(t *testing.T) {
+	service := &mockSignalService{}
+	sender := NewSignalSender(service, nil, nil, nil)
+
+	_, _, err := sender.SendWithExtensions(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"}, &provider.MessageExtensions{
+		Signal: &provider.SignalExtension{Base64Attachments: []string{"data:image/png;base64,aGk="}, ViewOnce: true, TextMode: "styled"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data:image/png;base64,aGk="}, service.request.Base64Attachments)
+	require.NotNil(t, service.request.ViewOnce)
+	assert.True(t, *service.request.ViewOnce)
+	require.NotNil(t, service.request.TextMode)
+	assert.Equal(t, "styled", *service.request.TextMode)
+
+	_, _, err = sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	require.NoError(t, err)
+	assert.Nil(t, service.request.Base64Attachments)
+	assert.Nil(t, service.request.ViewOnce)
+}
+
+type mockAttachmentLoader struct{}
+
+func (m *mockAttachmentLoader) Load(userID int, id int) (*provider.Attachment, []byte, error) {
+	if userID != 7 {
+		return nil, nil, errors.New("not found")
+	}
+	return &provider.Attachment{ID: id, UserID: userID, Filename: "report.pdf", MimeType: "application/pdf"}, []byte("hi"), nil
+}
+
+func TestSignalSender_SendsUploadedAttachments(t *testing.T) {
+	service := &mockSignalService{}
+	sender := NewSignalSender(service, &mockAttachmentLoader{}, nil, nil)
+	inline := []string{"data:image/png;base64,aGk="}
+
+	_, _, err := sender.SendWithExtensions(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"}, &provider.MessageExtensions{
+		Signal: &provider.SignalExtension{Base64Attachments: inline, AttachmentIDs: []int{3}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data:image/png;base64,aGk=", "data:application/pdf;filename=report.pdf;base64,aGk="}, service.request.Base64Attachments)
+	assert.Len(t, inline, 1)
+
+	_, _, err = sender.SendWithExtensions(8, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"}, &provider.MessageExtensions{
+		Signal: &provider.SignalExtension{AttachmentIDs: []int{3}},
+	})
+	assert.EqualError(t, err, "couldn't load attachment 3: not found")
+}
+
+func (m *mockSignalService) GetGroupMembers(number string, groupId string) ([]domainSignal.GroupMember, error) {
+	return []domainSignal.GroupMember{{Number: "+4912345", Name: "Alice"}, {UUID: "7c9e2b4a", Name: "Bob"}}, nil
+}
+
+func TestSignalSender_ResolvesMentionsOfGroupMembers(t *testing.T) {
+	service := &mockSignalService{}
+	sender := NewSignalSender(service, nil, nil, nil)
+
+	_, _, err := sender.SendWithExtensions(7, &provider.Provider{Type: "signal"}, "@Bob please check", []string{"group.abc"}, &provider.MessageExtensions{
+		Signal: &provider.SignalExtension{ResolveMentions: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []domainSignal.MessageMention{{Start: 0, Length: 4, Author: "7c9e2b4a"}}, service.request.Mentions)
+}
+
+type mockDeviceLinks struct {
+	statuses map[string]string
+}
+
+func (m *mockDeviceLinks) LinkStatus(number string) string {
+	return m.statuses[number]
+}
+
+func (m *mockDeviceLinks) MarkUnlinked(number string, reason string) error {
+	m.statuses[number] = domainSignal.DeviceLinkStatusUnlinked
+	return nil
+}
+
+func TestSignalSender_FailsSendsOfUnlinkedDevices(t *testing.T) {
+	t.Setenv("SIGNAL_FROM_NUMBER", "+4911111")
+	service := &mockSignalService{err: errors.New("Authorization failed!")}
+	deviceLinks := &mockDeviceLinks{statuses: map[string]string{"+4911111": domainSignal.DeviceLinkStatusLinked}}
+	sender := NewSignalSender(service, nil, deviceLinks, nil)
+
+	// The linked device the backend refuses is recorded as unlinked, later sends don't reach the backend
+	_, _, err := sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	var unlinkedErr *domainSignal.DeviceUnlinkedError
+	require.ErrorAs(t, err, &unlinkedErr)
+	assert.Equal(t, provider.ErrorCodeDeviceUnlinked, sender.ErrorCode(err))
+	assert.Equal(t, domainSignal.DeviceLinkStatusUnlinked, deviceLinks.statuses["+4911111"])
+
+	service.request, service.err = domainSignal.SendRequest{}, nil
+	_, _, err = sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	require.ErrorAs(t, err, &unlinkedErr)
+	assert.Empty(t, service.request.Number)
+
+	// A primary number refused by the backend isn't a device to link again
+	deviceLinks.statuses = map[string]string{}
+	service.err = errors.New("Authorization failed!")
+	_, _, err = sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	assert.Equal(t, provider.ErrorCodeAuthFailed, sender.ErrorCode(err))
+}
+
+type mockNumberHealth struct {
+	successes []string
+	failures  []error
+}
+
+func (m *mockNumberHealth) RecordSendSuccess(number string) {
+	m.successes = append(m.successes, number)
+}
+
+func (m *mockNumberHealth) RecordSendFailure(number string, err error) {
+	m.failures = append(m.failures, err)
+}
+
+func TestSignalSender_RecordsNumberHealth(t *testing.T) {
+	t.Setenv("SIGNAL_FROM_NUMBER", "+4911111")
+	service := &mockSignalService{}
+	health := &mockNumberHealth{}
+	sender := NewSignalSender(service, nil, nil, health)
+
+	_, _, err := sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+4911111"}, health.successes)
+
+	service.err = errors.New("Authorization failed!")
+	_, _, err = sender.Send(7, &provider.Provider{Type: "signal"}, "hello", []string{"+4912345"})
+	require.Error(t, err)
+	assert.Equal(t, []error{err}, health.failures)
+}
+
+func TestLineSender_UsesProviderConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"sentMessages":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+
+	sender := NewLineSender(line.NewClient(server.URL, time.Second))
+	recipient := "C4af4980629a0ed6a4d2d8f2a8e5a1f1b"
+	requestData, responseData, err := sender.Send(7, &provider.Provider{Type: "line", Config: `{"channel_access_token":"secret"}`}, "hello", []string{recipient})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"recipients":["`+recipient+`"],"message":"hello"}`, string(requestData))
+	assert.JSONEq(t, `[{"recipient":"`+recipient+`","message_ids":["1"]}]`, string(responseData))
+
+	_, responseData, err = sender.Send(7, &provider.Provider{Type: "line"}, "hello", []string{recipient})
+	assert.Error(t, err)
+	assert.Nil(t, responseData)
+}
+
+func TestTelegramSender_SendsAndEditsWithBotOfUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bot123:secret/sendMessage":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":7,"chat":{"id":42}}}`))
+		case "/bot123:secret/editMessageText":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":7,"chat":{"id":42}}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	repository := &mockUserProviderRepository{userProviders: []provider.UserProvider{{ProviderID: 3, Config: `{"bot_token":"123:secret"}`}}}
+	sender := NewTelegramSender(telegramClient.NewClient(server.URL, time.Second), repository)
+	providerDetails := &provider.Provider{ID: 3, Type: "telegram"}
+	_, responseData, err := sender.Send(7, providerDetails, "hello", []string{"42"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"recipient":"42","chat_id":42,"message_id":7}]`, string(responseData))
+
+	_, editData, err := sender.Edit(7, providerDetails, "changed", []string{"42"}, responseData)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(responseData), string(editData))
+
+	_, _, err = sender.Send(7, &provider.Provider{ID: 4, Type: "telegram"}, "hello", []string{"42"})
+	assert.Error(t, err)
+}
+
+// fakeSMTPServer accepts emails on a local port with any credentials, it refuses recipients at blocked.example.com
+type fakeSMTPServer struct {
+	listener net.Listener
+	users    chan string
+	messages chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener, users: make(chan string, 10), messages: make(chan string, 10)}
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(connection)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(connection net.Conn) {
+	defer connection.Close()
+	reader := textproto.NewReader(bufio.NewReader(connection))
+	reply := func(line string) { _, _ = fmt.Fprintf(connection, "%s\r\n", line) }
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			s.users <- strings.TrimSpace(line[len("AUTH PLAIN"):])
+			reply("235 authenticated")
+		case strings.HasPrefix(command, "RCPT TO:") && strings.Contains(command, "@BLOCKED.EXAMPLE.COM"):
+			reply("550 mailbox unavailable")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 go ahead")
+			data, err := reader.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.messages <- strings.Join(data, "\n")
+			reply("250 queued")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailSender_SendsWithCredentialsOfUser(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	providerDetails := &provider.Provider{ID: 3, Type: "email", Config: fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"from":"noreply@example.com"}`, server.port())}
+	repository := &mockUserProviderRepository{userProviders: []provider.UserProvider{{ProviderID: 3, Config: `{"from":"alice@example.org","username":"alice","password":"secret"}`}}}
+	sender := NewEmailSender(repository, nil)
+
+	extensions := &provider.MessageExtensions{Email: &provider.EmailExtension{Subject: "Invoice", HTML: "<p>Paid</p>"}}
+	requestData, responseData, err := sender.SendWithExtensions(7, providerDetails, "Paid", []string{"bob@example.com"}, extensions)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"recipients":["bob@example.com"],"subject":"Invoice","message":"Paid","html":"<p>Paid</p>"}`, string(requestData))
+	results := sentResults[EmailResult](responseData)
+	require.Len(t, results, 1)
+	assert.Equal(t, "bob@example.com", results[0].Recipient)
+	assert.True(t, strings.HasSuffix(results[0].MessageID, "@example.org>"))
+
+	// AUTH PLAIN sends the base64 of "\x00alice\x00secret"
+	assert.Equal(t, "AGFsaWNlAHNlY3JldA==", <-server.users)
+	message := <-server.messages
+	assert.Contains(t, message, "From: alice@example.org")
+	assert.Contains(t, message, "Subject: Invoice")
+	assert.Contains(t, message, "Message-ID: "+results[0].MessageID)
+	assert.Contains(t, message, "text/html")
+
+	// A refused recipient stops the send, the emails sent before it are returned
+	_, responseData, err = sender.Send(7, providerDetails, "Paid\nThanks", []string{"bob@example.com", "eve@blocked.example.com"})
+	require.Error(t, err)
+	assert.Equal(t, provider.ErrorCodeInvalidRecipient, sender.ErrorCode(err))
+	assert.Len(t, sentResults[EmailResult](responseData), 1)
+	assert.Contains(t, <-server.messages, "Subject: Paid")
+
+	_, _, err = sender.Send(7, providerDetails, "Paid", []string{"not an address"})
+	var recipientErr *provider.RecipientError
+	assert.ErrorAs(t, err, &recipientErr)
+}
+
+// fileAttachmentLoader loads the attachments of user 7 from a map by ID
+type fileAttachmentLoader map[int]provider.Attachment
+
+func (l fileAttachmentLoader) Load(userID int, id int) (*provider.Attachment, []byte, error) {
+	attachment, ok := l[id]
+	if !ok || userID != 7 {
+		return nil, nil, errors.New("not found")
+	}
+	return &attachment, make([]byte, attachment.Size), nil
+}
+
+func TestEmailSender_SendsAttachmentsAndInlineImages(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	providerDetails := &provider.Provider{ID: 3, Type: "email", Config: fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"from":"noreply@example.com","max_message_size":4096}`, server.port())}
+	sender := NewEmailSender(&mockUserProviderRepository{}, fileAttachmentLoader{
+		1: {Filename: "logo.png", MimeType: "image/png", Size: 16},
+		2: {Filename: "report.pdf", MimeType: "application/pdf", Size: 16},
+		3: {Filename: "scan.pdf", MimeType: "application/pdf", Size: 4096},
+	})
+
+	extensions := &provider.MessageExtensions{Email: &provider.EmailExtension{Subject: "Report", HTML: `<img src="cid:logo.png">`, AttachmentIDs: []int{1, 2}}}
+	requestData, _, err := sender.SendWithExtensions(7, providerDetails, "Report", []string{"bob@example.com"}, extensions)
+	require.NoError(t, err)
+	assert.Contains(t, string(requestData), `"attachment_ids":[1,2]`)
+	message := <-server.messages
+	assert.Contains(t, message, "multipart/mixed")
+	assert.Contains(t, message, "multipart/related")
+	assert.Contains(t, message, "Content-ID: <logo.png>")
+	assert.Contains(t, message, "Content-Disposition: inline; filename=logo.png")
+	assert.Contains(t, message, "Content-Disposition: attachment; filename=report.pdf")
+
+	// An email over the size limit of the server isn't sent, base64 grows the 4096 bytes of the attachment beyond it
+	extensions.Email.AttachmentIDs = []int{3}
+	_, responseData, err := sender.SendWithExtensions(7, providerDetails, "Report", []string{"bob@example.com"}, extensions)
+	assert.ErrorIs(t, err, email.ErrMessageTooLarge)
+	assert.Empty(t, sentResults[EmailResult](responseData))
+	assert.Empty(t, server.messages)
+
+	extensions.Email.AttachmentIDs = []int{1}
+	_, _, err = sender.SendWithExtensions(8, providerDetails, "Report", []string{"bob@example.com"}, extensions)
+	assert.ErrorContains(t, err, "couldn't load attachment 1")
+}
+
+func TestEmailSender_ErrorCode(t *testing.T) {
+	sender := NewEmailSender(nil, nil)
+	assert.Equal(t, provider.ErrorCodeAuthFailed, sender.ErrorCode(&textproto.Error{Code: 535, Msg: "authentication failed"}))
+	assert.Equal(t, provider.ErrorCodeRateLimited, sender.ErrorCode(fmt.Errorf("couldn't send: %w", &textproto.Error{Code: 421, Msg: "try later"})))
+	assert.Equal(t, provider.ErrorCodeUnknown, sender.ErrorCode(&textproto.Error{Code: 554, Msg: "rejected"}))
+	assert.Equal(t, "", sender.ErrorCode(errors.New("connection refused")))
+}
+
+func TestCheckCredentials_UsesCheckerOfType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/bot/info", r.URL.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Authentication failed"}`))
+	}))
+	defer server.Close()
+	processor := &MessageProcessor{senders: map[string]ProviderSender{
+		"line":  NewLineSender(line.NewClient(server.URL, time.Second)),
+		"viber": &mockSender{},
+	}}
+
+	checked, err := processor.CheckCredentials(&provider.Provider{Type: "line", Config: `{"channel_access_token":"expired"}`}, "")
+	assert.True(t, checked)
+	assert.EqualError(t, err, "line answered 401: Authentication failed")
+
+	// A sender that can't check credentials leaves them unchecked
+	checked, err = processor.CheckCredentials(&provider.Provider{Type: "viber"}, "")
+	assert.False(t, checked)
+	assert.NoError(t, err)
+}
+
+func TestUserProviderConfig(t *testing.T) {
+	repository := &mockUserProviderRepository{userProviders: []provider.UserProvider{{ProviderID: 3, Config: `{"bot_token":"secret"}`}}}
+
+	config, err := userProviderConfig(repository, 7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, `{"bot_token":"secret"}`, config)
+
+	_, err = userProviderConfig(repository, 7, 4)
+	assert.Error(t, err)
+
+	repository.err = errors.New("database is down")
+	_, err = userProviderConfig(repository, 7, 3)
+	assert.EqualError(t, err, "database is down")
+}
+
+type recordingSender struct {
+	messages map[string]string
+}
+
+func (m *recordingSender) Send(userID int, providerDetails *provider.Provider, message string, recipients []string) ([]byte, []byte, error) {
+	m.messages[recipients[0]] = message
+	return []byte(`{"to":"` + recipients[0] + `"}`), []byte("ok"), nil
+}
+
+type mockLinkPersonalizer struct{}
+
+func (m *mockLinkPersonalizer) Personalize(messageID int, message string, recipients []string) ([]string, error) {
+	texts := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		texts[i] = message + " " + recipient
+	}
+	return texts, nil
+}
+
+func TestSendWithTrackedLinks_SendsEachRecipientTheirLinks(t *testing.T) {
+	sender := &recordingSender{messages: map[string]string{}}
+	processor := &MessageProcessor{senders: map[string]ProviderSender{"sms": sender}, linkPersonalizer: &mockLinkPersonalizer{}}
+
+	requestData, responseData, err := processor.sendWithTrackedLinks(&provider.MessageTransaction{ID: 1, Message: "hi"},
+		&provider.Provider{Type: "sms"}, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "hi a", "b": "hi b"}, sender.messages)
+	assert.JSONEq(t, `[{"to":"a"},{"to":"b"}]`, string(requestData))
+	assert.JSONEq(t, `["ok","ok"]`, string(responseData))
+}
+
+func TestSMSSender_SentMessageIDs(t *testing.T) {
+	sender := NewSMSSender(nil, func(providerID int) string { return "" })
+
+	// A send to every recipient at once
+	messageIDs := sender.SentMessageIDs(nil, []byte(`[{"recipient":"+1","message_sid":"SM1"},{"recipient":"+2","message_sid":"SM2"}]`))
+	assert.Equal(t, map[string]string{"+1": "SM1", "+2": "SM2"}, messageIDs)
+
+	// A message with tracked links sent to each recipient on its own
+	messageIDs = sender.SentMessageIDs(nil, []byte(`[[{"recipient":"+1","message_sid":"SM1"}],[{"recipient":"+2","message_sid":"SM2"}]]`))
+	assert.Equal(t, map[string]string{"+1": "SM1", "+2": "SM2"}, messageIDs)
+
+	assert.Empty(t, sender.SentMessageIDs(nil, []byte(`{"sent":true}`)))
+}
+
+func TestSignalSender_SentMessageIDs(t *testing.T) {
+	sender := NewSignalSender(nil, nil, nil, nil)
+	recipients := []string{"+491111", "group.abc", "+492222"}
+
+	// A send to every recipient at once shares its timestamp, groups can't be tracked
+	messageIDs := sender.SentMessageIDs(recipients, []byte(`[{"timestamp":1700000000000}]`))
+	assert.Equal(t, map[string]string{"+491111": "1700000000000:+491111", "+492222": "1700000000000:+492222"}, messageIDs)
+
+	// A message with tracked links sent to each recipient on its own
+	messageIDs = sender.SentMessageIDs(recipients, []byte(`[[{"timestamp":1}],[{"timestamp":2}],[{"timestamp":3}]]`))
+	assert.Equal(t, map[string]string{"+491111": "1:+491111", "+492222": "3:+492222"}, messageIDs)
+
+	assert.Empty(t, sender.SentMessageIDs(recipients, []byte(`{"sent":true}`)))
+}
+
+// recordingSignalService keeps every request sent
+type recordingSignalService struct {
+	domainSignal.ISignalService
+	requests []domainSignal.SendRequest
+}
+
+func (m *recordingSignalService) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	m.requests = append(m.requests, request)
+	return &[]domainSignal.SendResponse{{Timestamp: 1700000009999}}, nil
+}
+
+func TestSignalSender_EditsEachSentMessage(t *testing.T) {
+	service := &recordingSignalService{}
+	sender := NewSignalSender(service, nil, nil, nil)
+	recipients := []string{"+491111", "group.abc", "+492222"}
+
+	// A send to every recipient at once is edited with one request
+	_, _, err := sender.Edit(7, &provider.Provider{Type: "signal"}, "fixed", recipients, []byte(`[{"timestamp":1700000000000}]`))
+	require.NoError(t, err)
+	require.Len(t, service.requests, 1)
+	assert.Equal(t, recipients, service.requests[0].Recipients)
+	require.NotNil(t, service.requests[0].EditTimestamp)
+	assert.Equal(t, int64(1700000000000), *service.requests[0].EditTimestamp)
+	assert.Equal(t, "fixed", service.requests[0].Message)
+
+	// A message with tracked links is edited for each recipient on its own
+	service.requests = nil
+	_, _, err = sender.Edit(7, &provider.Provider{Type: "signal"}, "fixed", recipients, []byte(`[[{"timestamp":1}],[{"timestamp":2}],[{"timestamp":3}]]`))
+	require.NoError(t, err)
+	require.Len(t, service.requests, 3)
+	assert.Equal(t, []string{"group.abc"}, service.requests[1].Recipients)
+	assert.Equal(t, int64(2), *service.requests[1].EditTimestamp)
+
+	_, _, err = sender.Edit(7, &provider.Provider{Type: "signal"}, "fixed", recipients, []byte(`{"sent":true}`))
+	assert.ErrorIs(t, err, errNothingToEdit)
+}
+
+func TestErrorCode_ClassifiesBySenderThenByError(t *testing.T) {
+	processor := &MessageProcessor{senders: map[string]ProviderSender{
+		"line":  NewLineSender(line.NewClient("http://localhost", time.Second)),
+		"viber": &mockSender{},
+	}}
+
+	assert.Equal(t, provider.ErrorCodeRateLimited, processor.errorCode("line", &line.Error{StatusCode: http.StatusTooManyRequests}))
+	assert.Equal(t, provider.ErrorCodeInvalidRecipient, processor.errorCode("line", &provider.RecipientError{Description: "not a line id"}))
+	assert.Equal(t, provider.ErrorCodeNetwork, processor.errorCode("viber", errProviderDrill))
+	assert.Equal(t, provider.ErrorCodeUnknown, processor.errorCode("viber", errors.New("viber refused")))
+}
@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WarmupConfig represents the warm-up policy stored under the "warmup" key of a provider config.
+// Ramp holds the maximum number of messages per day for each day of the warm-up period, so a
+// ramp of [20, 50, 100] limits a new number to 20 messages on its first day, 50 on the second
+// and 100 on the third, after which the number is no longer throttled.
+type WarmupConfig struct {
+	Enabled   bool       `json:"enabled"`
+	StartDate *time.Time `json:"start_date"`
+	Ramp      []int      `json:"ramp"`
+}
+
+type providerWarmupConfig struct {
+	Warmup *WarmupConfig `json:"warmup"`
+}
+
+// parseWarmupConfig extracts the warm-up policy from a provider config, returning nil when none is configured
+func parseWarmupConfig(config string) (*WarmupConfig, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var parsed providerWarmupConfig
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.Warmup == nil || !parsed.Warmup.Enabled || len(parsed.Warmup.Ramp) == 0 {
+		return nil, nil
+	}
+	return parsed.Warmup, nil
+}
+
+// DailyLimit returns the message limit for the given day and whether the number is still warming up.
+// registeredAt is used as the start of the warm-up period when no explicit start date is configured.
+func (w *WarmupConfig) DailyLimit(now time.Time, registeredAt time.Time) (int, bool) {
+	start := registeredAt
+	if w.StartDate != nil {
+		start = *w.StartDate
+	}
+
+	startDay := truncateToDay(start)
+	today := truncateToDay(now)
+	if today.Before(startDay) {
+		return w.Ramp[0], true
+	}
+
+	day := int(today.Sub(startDay).Hours() / 24)
+	if day >= len(w.Ramp) {
+		return 0, false
+	}
+	return w.Ramp[day], true
+}
+
+// nextUTCDay returns the start of the UTC day following t
+func nextUTCDay(t time.Time) time.Time {
+	return truncateToDay(t).Add(24 * time.Hour)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
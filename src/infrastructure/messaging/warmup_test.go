@@ -0,0 +1,54 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWarmupConfig(t *testing.T) {
+	config, err := parseWarmupConfig(`{"warmup": {"enabled": true, "ramp": [20, 50, 100]}}`)
+	assert.NoError(t, err)
+	assert.NotNil(t, config)
+	assert.Equal(t, []int{20, 50, 100}, config.Ramp)
+
+	// Disabled, missing and empty policies are ignored
+	for _, raw := range []string{"", `{}`, `{"warmup": {"enabled": false, "ramp": [1]}}`, `{"warmup": {"enabled": true}}`} {
+		config, err = parseWarmupConfig(raw)
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	}
+
+	_, err = parseWarmupConfig(`{invalid`)
+	assert.Error(t, err)
+}
+
+func TestWarmupConfig_DailyLimit(t *testing.T) {
+	registeredAt := time.Date(2026, 10, 1, 15, 30, 0, 0, time.UTC)
+	config := &WarmupConfig{Enabled: true, Ramp: []int{20, 50, 100}}
+
+	limit, active := config.DailyLimit(registeredAt.Add(time.Hour), registeredAt)
+	assert.True(t, active)
+	assert.Equal(t, 20, limit)
+
+	limit, active = config.DailyLimit(time.Date(2026, 10, 3, 0, 5, 0, 0, time.UTC), registeredAt)
+	assert.True(t, active)
+	assert.Equal(t, 100, limit)
+
+	_, active = config.DailyLimit(time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC), registeredAt)
+	assert.False(t, active)
+}
+
+func TestWarmupConfig_DailyLimitUsesStartDate(t *testing.T) {
+	startDate := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	config := &WarmupConfig{Enabled: true, StartDate: &startDate, Ramp: []int{20, 50}}
+
+	limit, active := config.DailyLimit(time.Date(2026, 10, 11, 12, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, active)
+	assert.Equal(t, 50, limit)
+}
+
+func TestNextUTCDay(t *testing.T) {
+	assert.Equal(t, time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), nextUTCDay(time.Date(2026, 10, 1, 23, 59, 0, 0, time.UTC)))
+}
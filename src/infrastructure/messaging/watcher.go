@@ -0,0 +1,75 @@
+package messaging
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// WatcherConfig controls how often the pending message watcher runs and when a sent message counts as undelivered
+type WatcherConfig struct {
+	// Interval is the time between two checks for pending and undelivered messages
+	Interval time.Duration
+	// Jitter is the upper bound of a random delay added to every check, so instances started together don't
+	// query the database at the same moment
+	Jitter time.Duration
+	// UndeliveredAfter is how long a sent message may go without a delivery before it falls back to the next
+	// provider
+	UndeliveredAfter time.Duration
+}
+
+// LoadWatcherConfig loads the watcher settings from environment variables
+func LoadWatcherConfig() (WatcherConfig, error) {
+	interval, err := utils.GetIntEnv("WATCHER_INTERVAL_SECONDS", 60)
+	if err != nil {
+		return WatcherConfig{}, fmt.Errorf("invalid WATCHER_INTERVAL_SECONDS: %w", err)
+	}
+	if interval < 1 {
+		return WatcherConfig{}, fmt.Errorf("WATCHER_INTERVAL_SECONDS must be at least 1")
+	}
+	jitter, err := utils.GetIntEnv("WATCHER_JITTER_SECONDS", 5)
+	if err != nil {
+		return WatcherConfig{}, fmt.Errorf("invalid WATCHER_JITTER_SECONDS: %w", err)
+	}
+	if jitter < 0 {
+		return WatcherConfig{}, fmt.Errorf("WATCHER_JITTER_SECONDS must not be negative")
+	}
+	undeliveredAfter, err := utils.GetIntEnv("UNDELIVERED_FALLBACK_AFTER_SECONDS", 300)
+	if err != nil {
+		return WatcherConfig{}, fmt.Errorf("invalid UNDELIVERED_FALLBACK_AFTER_SECONDS: %w", err)
+	}
+	if undeliveredAfter < 1 {
+		return WatcherConfig{}, fmt.Errorf("UNDELIVERED_FALLBACK_AFTER_SECONDS must be at least 1")
+	}
+	return WatcherConfig{
+		Interval:         time.Duration(interval) * time.Second,
+		Jitter:           time.Duration(jitter) * time.Second,
+		UndeliveredAfter: time.Duration(undeliveredAfter) * time.Second,
+	}, nil
+}
+
+// withDefaults fills in the settings of a config that weren't set with the defaults of LoadWatcherConfig
+func (c WatcherConfig) withDefaults() WatcherConfig {
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	if c.UndeliveredAfter <= 0 {
+		c.UndeliveredAfter = 5 * time.Minute
+	}
+	return c
+}
+
+// jitter returns a random delay of up to Jitter
+func (c WatcherConfig) jitter() time.Duration {
+	if c.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(c.Jitter + 1)
+}
+
+// nextCheck returns the delay until the next check
+func (c WatcherConfig) nextCheck() time.Duration {
+	return c.Interval + c.jitter()
+}
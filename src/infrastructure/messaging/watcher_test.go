@@ -0,0 +1,42 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWatcherConfig(t *testing.T) {
+	config, err := LoadWatcherConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, WatcherConfig{Interval: time.Minute, Jitter: 5 * time.Second, UndeliveredAfter: 5 * time.Minute}, config)
+
+	t.Setenv("WATCHER_INTERVAL_SECONDS", "30")
+	t.Setenv("WATCHER_JITTER_SECONDS", "0")
+	t.Setenv("UNDELIVERED_FALLBACK_AFTER_SECONDS", "900")
+	config, err = LoadWatcherConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, WatcherConfig{Interval: 30 * time.Second, UndeliveredAfter: 15 * time.Minute}, config)
+
+	t.Setenv("WATCHER_INTERVAL_SECONDS", "0")
+	_, err = LoadWatcherConfig()
+	assert.Error(t, err)
+
+	t.Setenv("WATCHER_INTERVAL_SECONDS", "30")
+	t.Setenv("WATCHER_JITTER_SECONDS", "-1")
+	_, err = LoadWatcherConfig()
+	assert.Error(t, err)
+}
+
+func TestWatcherNextCheck(t *testing.T) {
+	config := WatcherConfig{Interval: time.Minute, Jitter: 10 * time.Second}
+	for i := 0; i < 100; i++ {
+		delay := config.nextCheck()
+		assert.GreaterOrEqual(t, delay, time.Minute)
+		assert.LessOrEqual(t, delay, time.Minute+10*time.Second)
+	}
+
+	assert.Equal(t, time.Minute, WatcherConfig{Interval: time.Minute}.nextCheck())
+	assert.Equal(t, WatcherConfig{Interval: time.Minute, UndeliveredAfter: 5 * time.Minute}, WatcherConfig{}.withDefaults())
+}
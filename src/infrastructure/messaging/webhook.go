@@ -0,0 +1,155 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/i18n"
+)
+
+const (
+	// WebhookVersionHeader carries the schema version of a webhook payload
+	WebhookVersionHeader = "X-Webhook-Version"
+
+	// WebhookVersionV1 is the original flat payload, kept as the default so existing consumers keep working
+	WebhookVersionV1 = "v1"
+	// WebhookVersionV2 nests the message details and adds the outcome per recipient
+	WebhookVersionV2 = "v2"
+)
+
+// LocaleResolver looks up the locale webhook reasons are translated to for a user
+type LocaleResolver interface {
+	UserLocale(userID int) string
+}
+
+// reason explains the status of a message. It is kept as format and arguments, so it is stored in English
+// and translated to the locale of the user in webhooks.
+type reason struct {
+	format string
+	args   []interface{}
+}
+
+func newReason(format string, args ...interface{}) reason {
+	return reason{format: format, args: args}
+}
+
+// String returns the reason in English, as it is stored with the message
+func (r reason) String() string {
+	if len(r.args) == 0 {
+		return r.format
+	}
+	return fmt.Sprintf(r.format, r.args...)
+}
+
+// Localize returns the reason in a locale, parts without a translation stay in English
+func (r reason) Localize(locale string) string {
+	return i18n.Sprintf(locale, r.format, r.args...)
+}
+
+// webhookEvent holds everything a webhook payload of any version may report
+type webhookEvent struct {
+	MessageID  int
+	UserID     int
+	ProviderID int
+	Recipients string // JSON array of recipients
+	Tags       string // JSON object of tags
+	Status     string
+	Error      string
+	ErrorCode  string // error code of a failed message, see provider.ErrorCode*
+	OccurredAt time.Time
+}
+
+// WebhookPayloadV2 is the v2 webhook payload
+type WebhookPayloadV2 struct {
+	Version    string                      `json:"version"`
+	Event      string                      `json:"event"`
+	Message    WebhookMessageV2            `json:"message"`
+	Recipients []WebhookRecipientOutcomeV2 `json:"recipients"`
+	OccurredAt string                      `json:"occurred_at"`
+}
+
+// WebhookMessageV2 describes the message a v2 webhook reports on
+type WebhookMessageV2 struct {
+	ID         int             `json:"id"`
+	UserID     int             `json:"user_id"`
+	ProviderID int             `json:"provider_id"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	ErrorCode  string          `json:"error_code,omitempty"`
+	Tags       json.RawMessage `json:"tags,omitempty"`
+}
+
+// WebhookRecipientOutcomeV2 is the outcome of a message for a single recipient
+type WebhookRecipientOutcomeV2 struct {
+	Recipient string `json:"recipient"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// resolveWebhookVersion returns the payload version to send for a configured version. Missing and unknown
+// versions fall back to v1, so a consumer never receives a payload it doesn't understand.
+func resolveWebhookVersion(configured string) string {
+	switch configured {
+	case WebhookVersionV2:
+		return WebhookVersionV2
+	default:
+		return WebhookVersionV1
+	}
+}
+
+// buildWebhookPayload renders the event in the given payload version
+func buildWebhookPayload(version string, event webhookEvent) interface{} {
+	if version == WebhookVersionV2 {
+		return buildWebhookPayloadV2(event)
+	}
+	return buildWebhookPayloadV1(event)
+}
+
+func buildWebhookPayloadV1(event webhookEvent) map[string]interface{} {
+	payload := map[string]interface{}{
+		"message_id": event.MessageID,
+		"user_id":    event.UserID,
+		"status":     event.Status,
+		"timestamp":  event.OccurredAt.Unix(),
+	}
+
+	if event.Error != "" {
+		payload["error"] = event.Error
+	}
+
+	if event.Tags != "" {
+		payload["tags"] = json.RawMessage(event.Tags)
+	}
+	return payload
+}
+
+func buildWebhookPayloadV2(event webhookEvent) *WebhookPayloadV2 {
+	var recipients []string
+	_ = json.Unmarshal([]byte(event.Recipients), &recipients)
+
+	// A message is sent to all of its recipients in a single provider call, so they share its outcome
+	outcomes := make([]WebhookRecipientOutcomeV2, len(recipients))
+	for i, recipient := range recipients {
+		outcomes[i] = WebhookRecipientOutcomeV2{Recipient: recipient, Status: event.Status, Error: event.Error}
+	}
+
+	payload := &WebhookPayloadV2{
+		Version: WebhookVersionV2,
+		Event:   "message." + event.Status,
+		Message: WebhookMessageV2{
+			ID:         event.MessageID,
+			UserID:     event.UserID,
+			ProviderID: event.ProviderID,
+			Status:     event.Status,
+			Error:      event.Error,
+			ErrorCode:  event.ErrorCode,
+		},
+		Recipients: outcomes,
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339),
+	}
+	if event.Tags != "" {
+		payload.Message.Tags = json.RawMessage(event.Tags)
+	}
+	return payload
+}
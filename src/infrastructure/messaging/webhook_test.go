@@ -0,0 +1,115 @@
+package messaging
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testWebhookEvent = webhookEvent{
+	MessageID:  42,
+	UserID:     7,
+	ProviderID: 3,
+	Recipients: `["+491111","+492222"]`,
+	Tags:       `{"order":"A-1"}`,
+	Status:     "failed",
+	Error:      "provider is inactive",
+	ErrorCode:  "unknown",
+	OccurredAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestResolveWebhookVersion(t *testing.T) {
+	assert.Equal(t, WebhookVersionV1, resolveWebhookVersion(""))
+	assert.Equal(t, WebhookVersionV1, resolveWebhookVersion("v1"))
+	assert.Equal(t, WebhookVersionV2, resolveWebhookVersion("v2"))
+	assert.Equal(t, WebhookVersionV1, resolveWebhookVersion("v9"))
+}
+
+func TestBuildWebhookPayloadV1(t *testing.T) {
+	raw, err := json.Marshal(buildWebhookPayload(WebhookVersionV1, testWebhookEvent))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"message_id": 42,
+		"user_id": 7,
+		"status": "failed",
+		"error": "provider is inactive",
+		"tags": {"order": "A-1"},
+		"timestamp": 1790856000
+	}`, string(raw))
+}
+
+func TestBuildWebhookPayloadV2(t *testing.T) {
+	raw, err := json.Marshal(buildWebhookPayload(WebhookVersionV2, testWebhookEvent))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "v2",
+		"event": "message.failed",
+		"message": {
+			"id": 42,
+			"user_id": 7,
+			"provider_id": 3,
+			"status": "failed",
+			"error": "provider is inactive",
+			"error_code": "unknown",
+			"tags": {"order": "A-1"}
+		},
+		"recipients": [
+			{"recipient": "+491111", "status": "failed", "error": "provider is inactive"},
+			{"recipient": "+492222", "status": "failed", "error": "provider is inactive"}
+		],
+		"occurred_at": "2026-10-01T12:00:00Z"
+	}`, string(raw))
+}
+
+func TestReason(t *testing.T) {
+	held := newReason("warm-up limit of %d messages per day reached for provider, message held until %s", 50, "2026-10-17T00:00:00Z")
+	assert.Equal(t, "warm-up limit of 50 messages per day reached for provider, message held until 2026-10-17T00:00:00Z", held.String())
+	assert.Equal(t, "Aufwärmlimit von 50 Nachrichten pro Tag für den Provider erreicht, Nachricht zurückgehalten bis 2026-10-17T00:00:00Z", held.Localize("de"))
+
+	failed := newReason("provider is inactive: 100% down")
+	assert.Equal(t, "provider is inactive: 100% down", failed.String())
+	assert.Equal(t, "provider is inactive: 100% down", failed.Localize("fr"))
+}
+
+func TestSendWebhookRequestWithDeliverySettings(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secrets, err := security.NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	authorization, err := secrets.Encrypt(7, "Bearer static")
+	require.NoError(t, err)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	p := &MessageProcessor{hookDispatcher: NewHookDispatcher(&mockHookSubscriptionRepository{}, &mockWebhookEventRepository{}, secrets, loggerInstance)}
+
+	config := WebhookConfig{
+		WebhookURL:    server.URL,
+		Enabled:       true,
+		Headers:       map[string]string{"Authorization": authorization, WebhookVersionHeader: "v9"},
+		CACertificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+	}
+	p.sendWebhookRequest(loggerInstance, 7, config, WebhookVersionV2, buildWebhookPayload(WebhookVersionV2, testWebhookEvent))
+	r := <-received
+	assert.Equal(t, "Bearer static", r.Header.Get("Authorization"))
+	// The headers of the webhook protocol win over custom headers of the same name
+	assert.Equal(t, WebhookVersionV2, r.Header.Get(WebhookVersionHeader))
+
+	// Without the CA the certificate of the webhook URL isn't trusted
+	config.CACertificate = ""
+	p.sendWebhookRequest(loggerInstance, 7, config, WebhookVersionV2, buildWebhookPayload(WebhookVersionV2, testWebhookEvent))
+	assert.Empty(t, received)
+}
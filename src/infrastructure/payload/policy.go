@@ -0,0 +1,230 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// attachmentKeys are the JSON fields whose values are attachment bodies
+var attachmentKeys = map[string]bool{
+	"attachment":         true,
+	"attachments":        true,
+	"base64_attachments": true,
+	"base64Attachments":  true,
+}
+
+// Config controls how the provider request and response payloads of messages are stored
+type Config struct {
+	// StripAttachments replaces attachment bodies with a placeholder naming their size
+	StripAttachments bool
+	// MaxBytes truncates payloads that are still larger after stripping, 0 keeps them whole
+	MaxBytes int
+	// Store keeps the full payloads outside of the database: "dir", "http" or empty for none
+	Store      string
+	StoreDir   string
+	StoreURL   string
+	StoreToken string
+}
+
+// LoadConfig loads the payload policy from environment variables
+func LoadConfig() (Config, error) {
+	maxBytes, err := utils.GetIntEnv("PAYLOAD_MAX_BYTES", 16384)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid PAYLOAD_MAX_BYTES: %w", err)
+	}
+	if maxBytes < 0 {
+		return Config{}, fmt.Errorf("invalid PAYLOAD_MAX_BYTES: must not be negative")
+	}
+
+	config := Config{
+		StripAttachments: utils.GetEnv("PAYLOAD_STRIP_ATTACHMENTS", "true") == "true",
+		MaxBytes:         maxBytes,
+		Store:            utils.GetEnv("PAYLOAD_STORE", ""),
+		StoreDir:         utils.GetEnv("PAYLOAD_STORE_DIR", ""),
+		StoreURL:         strings.TrimSuffix(utils.GetEnv("PAYLOAD_STORE_URL", ""), "/"),
+		StoreToken:       utils.GetEnv("PAYLOAD_STORE_TOKEN", ""),
+	}
+	switch config.Store {
+	case "":
+	case "dir":
+		if config.StoreDir == "" {
+			return Config{}, fmt.Errorf("PAYLOAD_STORE_DIR is required for PAYLOAD_STORE=dir")
+		}
+	case "http":
+		if config.StoreURL == "" {
+			return Config{}, fmt.Errorf("PAYLOAD_STORE_URL is required for PAYLOAD_STORE=http")
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported PAYLOAD_STORE: %s", config.Store)
+	}
+	return config, nil
+}
+
+// envelope replaces a payload that was truncated or stored in full elsewhere
+type envelope struct {
+	Truncated     bool            `json:"truncated"`
+	OriginalBytes int             `json:"original_bytes"`
+	PayloadRef    string          `json:"payload_ref,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Prefix        string          `json:"prefix,omitempty"`
+}
+
+// Policy strips, truncates and offloads payloads before they are stored with a message
+type Policy struct {
+	config Config
+	store  Store
+	Logger *logger.Logger
+}
+
+// NewPolicy creates the payload policy of a configuration
+func NewPolicy(config Config, loggerInstance *logger.Logger) *Policy {
+	var store Store
+	switch config.Store {
+	case "dir":
+		store = NewDirStore(config.StoreDir)
+	case "http":
+		store = NewHTTPStore(config.StoreURL, config.StoreToken)
+	}
+	return &Policy{config: config, store: store, Logger: loggerInstance}
+}
+
+// Apply returns the payload to store in the database under the given key. Attachment bodies are stripped,
+// the full payload is put into the store when one is configured, and payloads over the size limit are
+// truncated. A truncated or offloaded payload is replaced by an envelope reporting its original size, the
+// reference of the full payload and the payload itself, or only its beginning when truncated.
+func (p *Policy) Apply(key string, data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	ref := ""
+	if p.store != nil {
+		storedRef, err := p.store.Put(key, data)
+		if err != nil {
+			p.Logger.Warn("Error storing full payload, keeping it only in the database", zap.Error(err), zap.String("key", key))
+		} else {
+			ref = storedRef
+		}
+	}
+
+	stored := data
+	if p.config.StripAttachments {
+		stored = StripAttachments(stored)
+	}
+
+	truncated := p.config.MaxBytes > 0 && len(stored) > p.config.MaxBytes
+	if !truncated && ref == "" {
+		return string(stored)
+	}
+
+	result := envelope{Truncated: truncated, OriginalBytes: len(data), PayloadRef: ref}
+	if truncated {
+		result.Prefix = utf8Prefix(stored, p.config.MaxBytes)
+	} else if json.Valid(stored) {
+		result.Payload = stored
+	} else {
+		result.Prefix = string(stored)
+	}
+	encoded, err := marshal(result)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// Unwrap returns a payload stored by Apply as the provider sent it, the payload of an envelope when the payload
+// was offloaded. It returns nil for a truncated payload whose beginning is all that was kept.
+func Unwrap(stored string) []byte {
+	var wrapped envelope
+	if err := json.Unmarshal([]byte(stored), &wrapped); err != nil || wrapped.OriginalBytes == 0 {
+		return []byte(stored)
+	}
+	if wrapped.Truncated || len(wrapped.Payload) == 0 {
+		return nil
+	}
+	return wrapped.Payload
+}
+
+// StripAttachments replaces the attachment bodies of a JSON payload with a placeholder naming their size.
+// Payloads that aren't JSON or have no attachments are returned unchanged.
+func StripAttachments(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+
+	stripped, changed := strip(value, false)
+	if !changed {
+		return data
+	}
+	encoded, err := marshal(stripped)
+	if err != nil {
+		return data
+	}
+	return encoded
+}
+
+// strip walks a decoded JSON value, attachment tells whether the value is held by an attachment field
+func strip(value interface{}, attachment bool) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, field := range v {
+			stripped, fieldChanged := strip(field, attachmentKeys[key])
+			if fieldChanged {
+				v[key] = stripped
+				changed = true
+			}
+		}
+		return v, changed
+	case []interface{}:
+		changed := false
+		for i, element := range v {
+			stripped, elementChanged := strip(element, attachment)
+			if elementChanged {
+				v[i] = stripped
+				changed = true
+			}
+		}
+		return v, changed
+	case string:
+		if (attachment && v != "") || isDataURI(v) {
+			return fmt.Sprintf("[stripped %d bytes]", len(v)), true
+		}
+	}
+	return value, false
+}
+
+func isDataURI(value string) bool {
+	return strings.HasPrefix(value, "data:") && strings.Contains(value, ";base64,")
+}
+
+// utf8Prefix cuts data to at most max bytes without splitting a character
+func utf8Prefix(data []byte, max int) string {
+	prefix := data[:max]
+	for len(prefix) > 0 && !utf8.Valid(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return string(prefix)
+}
+
+// marshal encodes JSON without escaping HTML characters, payloads are stored, not embedded in HTML
+func marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,96 @@
+package payload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPolicy(t *testing.T, config Config) *Policy {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewPolicy(config, loggerInstance)
+}
+
+func TestStripAttachments(t *testing.T) {
+	data := []byte(`{"number":"+4911111","message":"<b>hi</b>","base64_attachments":["aGVsbG8=","data:image/png;base64,iVBORw0KGgo="],"quote":{"body":"data:text/plain;base64,aGk="},"count":12345678901234567890}`)
+
+	var stripped map[string]interface{}
+	require.NoError(t, json.Unmarshal(StripAttachments(data), &stripped))
+	assert.Equal(t, []interface{}{"[stripped 8 bytes]", "[stripped 34 bytes]"}, stripped["base64_attachments"])
+	assert.Equal(t, "[stripped 27 bytes]", stripped["quote"].(map[string]interface{})["body"])
+	assert.Equal(t, "<b>hi</b>", stripped["message"])
+	// Large numbers survive the round trip
+	assert.Contains(t, string(StripAttachments(data)), "12345678901234567890")
+}
+
+func TestStripAttachments_LeavesOtherPayloadsUnchanged(t *testing.T) {
+	for _, data := range []string{`{"timestamp":1790856000}`, `not json`, `{"base64_attachments":[]}`} {
+		assert.Equal(t, data, string(StripAttachments([]byte(data))))
+	}
+}
+
+func TestApply_TruncatesLargePayloads(t *testing.T) {
+	policy := newTestPolicy(t, Config{StripAttachments: true, MaxBytes: 20})
+
+	assert.Equal(t, `{"timestamp":1}`, policy.Apply("key", []byte(`{"timestamp":1}`)))
+
+	data := []byte(`{"message":"` + strings.Repeat("ü", 20) + `"}`)
+	var result envelope
+	require.NoError(t, json.Unmarshal([]byte(policy.Apply("key", data)), &result))
+	assert.True(t, result.Truncated)
+	assert.Equal(t, len(data), result.OriginalBytes)
+	assert.LessOrEqual(t, len(result.Prefix), 20)
+	assert.True(t, strings.HasPrefix(string(data), result.Prefix))
+}
+
+func TestApply_StoresFullPayload(t *testing.T) {
+	dir := t.TempDir()
+	policy := newTestPolicy(t, Config{StripAttachments: true, Store: "dir", StoreDir: dir})
+
+	data := []byte(`{"base64_attachments":["aGVsbG8="]}`)
+	var result envelope
+	require.NoError(t, json.Unmarshal([]byte(policy.Apply("messages/7/request.json", data)), &result))
+	assert.False(t, result.Truncated)
+	assert.Equal(t, filepath.Join(dir, "messages", "7", "request.json"), result.PayloadRef)
+	assert.JSONEq(t, `{"base64_attachments":["[stripped 8 bytes]"]}`, string(result.Payload))
+
+	stored, err := os.ReadFile(result.PayloadRef)
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+}
+
+func TestUnwrap(t *testing.T) {
+	data := []byte(`[{"timestamp":1790856000}]`)
+	assert.Equal(t, data, Unwrap(string(data)))
+
+	offloaded := newTestPolicy(t, Config{Store: "dir", StoreDir: t.TempDir()})
+	assert.JSONEq(t, string(data), string(Unwrap(offloaded.Apply("messages/7/response.json", data))))
+
+	truncated := newTestPolicy(t, Config{MaxBytes: 10})
+	assert.Nil(t, Unwrap(truncated.Apply("messages/7/response.json", data)))
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("PAYLOAD_STORE", "http")
+	_, err := LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("PAYLOAD_STORE_URL", "https://objects.example.com/payloads/")
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://objects.example.com/payloads", config.StoreURL)
+	assert.True(t, config.StripAttachments)
+	assert.Equal(t, 16384, config.MaxBytes)
+
+	t.Setenv("PAYLOAD_STORE", "s3")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
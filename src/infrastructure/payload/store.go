@@ -0,0 +1,84 @@
+package payload
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+// Store keeps full payloads outside of the database and returns a reference to find them again
+type Store interface {
+	Put(key string, data []byte) (string, error)
+}
+
+// DirStore writes payloads to files below a directory, e.g. a mounted bucket
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a store writing below dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// Put writes a payload to the file named by its key and returns the file path
+func (s *DirStore) Put(key string, data []byte) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// HTTPStore uploads payloads with PUT requests, as accepted by S3 compatible object stores and most blob stores
+type HTTPStore struct {
+	baseURL     string
+	token       string
+	contentType string
+	client      *http.Client
+}
+
+// NewHTTPStore creates a store uploading below baseURL, token is sent as bearer token when set
+func NewHTTPStore(baseURL string, token string) *HTTPStore {
+	return &HTTPStore{baseURL: baseURL, token: token, contentType: "application/json", client: httpclient.New(10 * time.Second)}
+}
+
+// WithContentType returns a copy of the store uploading its objects with another content type than JSON
+func (s *HTTPStore) WithContentType(contentType string) *HTTPStore {
+	store := *s
+	store.contentType = contentType
+	return &store
+}
+
+// Put uploads a payload to the URL named by its key and returns that URL
+func (s *HTTPStore) Put(key string, data []byte) (string, error) {
+	objectURL := s.baseURL + "/" + key
+	request, err := http.NewRequest(http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", s.contentType)
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("object store answered %d", response.StatusCode)
+	}
+	return objectURL, nil
+}
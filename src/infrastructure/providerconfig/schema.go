@@ -0,0 +1,232 @@
+package providerconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used to describe provider configs. It is served as is to UIs
+// rendering config forms, and checked by Validate.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	// Format is one of uri, email, date, date-time or timezone, an IANA time zone name like Europe/Berlin
+	Format string `json:"format,omitempty"`
+	// WriteOnly marks secrets that UIs shouldn't display
+	WriteOnly bool `json:"writeOnly,omitempty"`
+}
+
+// FieldError is a violation of a schema by a single field. Field is the dotted path of the field, e.g.
+// warmup.ramp[2], and empty for the config itself.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every violation of a config against its schema
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		if fieldError.Field == "" {
+			messages[i] = fieldError.Message
+		} else {
+			messages[i] = fieldError.Field + ": " + fieldError.Message
+		}
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+// Validate checks a JSON config against the schema. An empty config means the provider isn't configured
+// and is always valid. It returns a *ValidationError listing every violation.
+func (s *Schema) Validate(config string) error {
+	if strings.TrimSpace(config) == "" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewBufferString(config))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Errors: []FieldError{{Message: "config is not valid JSON: " + err.Error()}}}
+	}
+	if decoder.More() {
+		return &ValidationError{Errors: []FieldError{{Message: "config must be a single JSON value"}}}
+	}
+
+	var errors []FieldError
+	s.validate("", value, &errors)
+	if len(errors) > 0 {
+		return &ValidationError{Errors: errors}
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value interface{}, errors *[]FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*errors = append(*errors, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				*errors = append(*errors, FieldError{Field: joinPath(path, name), Message: "is required"})
+			}
+		}
+		// Sorted so the errors come in a stable order
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errors = append(*errors, FieldError{Field: joinPath(path, name), Message: "is not a known field"})
+				}
+				continue
+			}
+			property.validate(joinPath(path, name), object[name], errors)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(array) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(array) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errors)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && len(str) > *s.MaxLength {
+			fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			fail("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+			fail("must match %s", s.Pattern)
+		}
+		if message := checkFormat(s.Format, str); message != "" {
+			fail("%s", message)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			fail("must be a %s", s.Type)
+			return
+		}
+		f, err := number.Float64()
+		if err != nil {
+			fail("must be a %s", s.Type)
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+// checkFormat returns why a string doesn't match a format, or an empty string when it does
+func checkFormat(format string, value string) string {
+	switch format {
+	case "uri":
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return "must be an http or https URL"
+		}
+	case "email":
+		if _, err := mail.ParseAddress(value); err != nil {
+			return "must be an email address"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date like 2006-01-02"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "timezone":
+		if _, err := time.LoadLocation(value); err != nil || value == "" || value == "Local" {
+			return "must be an IANA time zone like Europe/Berlin"
+		}
+	}
+	return ""
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package providerconfig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validationErrors(t *testing.T, err error) []FieldError {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	return validationErr.Errors
+}
+
+func TestValidate_EmptyConfigIsValid(t *testing.T) {
+	signal, _ := Lookup("signal")
+	assert.NoError(t, signal.ProviderSchema.Validate(""))
+	assert.NoError(t, signal.UserProviderSchema.Validate("  "))
+}
+
+func TestValidate_InvalidJSON(t *testing.T) {
+	signal, _ := Lookup("signal")
+	errs := validationErrors(t, signal.ProviderSchema.Validate(`{"warmup":`))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "", errs[0].Field)
+
+	errs = validationErrors(t, signal.ProviderSchema.Validate(`{} {}`))
+	assert.Equal(t, "config must be a single JSON value", errs[0].Message)
+
+	errs = validationErrors(t, signal.ProviderSchema.Validate(`[]`))
+	assert.Equal(t, []FieldError{{Field: "", Message: "must be an object"}}, errs)
+}
+
+func TestValidate_NestedFieldPaths(t *testing.T) {
+	signal, _ := Lookup("signal")
+	errs := validationErrors(t, signal.ProviderSchema.Validate(
+		`{"warmup":{"enabled":"yes","start_date":"tomorrow","ramp":[10,0,2.5]},"region":"eu"}`))
+	assert.Equal(t, []FieldError{
+		{Field: "region", Message: "is not a known field"},
+		{Field: "warmup.enabled", Message: "must be a boolean"},
+		{Field: "warmup.ramp[1]", Message: "must be at least 1"},
+		{Field: "warmup.ramp[2]", Message: "must be an integer"},
+		{Field: "warmup.start_date", Message: "must be an RFC 3339 date-time"},
+	}, errs)
+}
+
+func TestValidate_ValidConfigs(t *testing.T) {
+	email, _ := Lookup("email")
+	assert.NoError(t, email.ProviderSchema.Validate(
+		`{"from":"ops@example.com","host":"smtp.example.com","port":587,"username":"ops","password":"secret","warmup":{"enabled":false,"ramp":[100],"start_date":"2026-10-01T00:00:00Z"}}`))
+	assert.NoError(t, email.UserProviderSchema.Validate(
+		`{"webhook_url":"https://example.com/hook","webhook_enabled":true,"webhook_version":"v2"}`))
+}
+
+func TestValidate_ScheduleConfig(t *testing.T) {
+	sms, _ := Lookup("sms")
+	assert.NoError(t, sms.ProviderSchema.Validate(
+		`{"schedule":{"timezone":"Europe/Berlin","windows":[{"days":["mon","fri"],"start":"08:00","end":"24:00"}],"blackouts":[{"date":"2026-12-24","reason":"Christmas Eve"}]}}`))
+
+	errs := validationErrors(t, sms.ProviderSchema.Validate(
+		`{"schedule":{"timezone":"Berlin","windows":[{"days":["monday"],"start":"8:00"}],"blackouts":[{"date":"24.12.2026"}]}}`))
+	assert.Equal(t, []FieldError{
+		{Field: "schedule.blackouts[0].date", Message: "must be a date like 2006-01-02"},
+		{Field: "schedule.timezone", Message: "must be an IANA time zone like Europe/Berlin"},
+		{Field: "schedule.windows[0].end", Message: "is required"},
+		{Field: "schedule.windows[0].days[0]", Message: "must be one of mon, tue, wed, thu, fri, sat, sun"},
+		{Field: "schedule.windows[0].start", Message: "must match " + clockPattern},
+	}, errs)
+}
+
+func TestValidate_RetryPolicyConfig(t *testing.T) {
+	signal, _ := Lookup("signal")
+	assert.NoError(t, signal.ProviderSchema.Validate(
+		`{"retry_policy":{"rate_limited":{"action":"retry","max_retries":2,"backoff_seconds":3600},"auth_failed":{"action":"fallback"}}}`))
+
+	errs := validationErrors(t, signal.ProviderSchema.Validate(`{"retry_policy":{"network":{"action":"wait","backoff_seconds":-1},"timeout":{}}}`))
+	assert.Equal(t, []FieldError{
+		{Field: "retry_policy.network.action", Message: "must be one of retry, fallback, suppress"},
+		{Field: "retry_policy.network.backoff_seconds", Message: "must be at least 0"},
+		{Field: "retry_policy.timeout", Message: "is not a known field"},
+	}, errs)
+}
+
+func TestValidate_MatrixUserProviderConfig(t *testing.T) {
+	matrix, _ := Lookup("matrix")
+	assert.NoError(t, matrix.UserProviderSchema.Validate(
+		`{"homeserver_url":"https://matrix.example.org","access_token":"syt_secret","webhook_enabled":false}`))
+
+	errs := validationErrors(t, matrix.UserProviderSchema.Validate(`{"homeserver_url":"matrix.example.org"}`))
+	assert.Equal(t, []FieldError{
+		{Field: "access_token", Message: "is required"},
+		{Field: "homeserver_url", Message: "must be an http or https URL"},
+	}, errs)
+}
+
+func TestTypes_HaveCapabilities(t *testing.T) {
+	for _, providerType := range Types() {
+		assert.NotEmpty(t, providerType.Capabilities.Recipients, providerType.Type)
+		assert.Contains(t, []string{"provider", "user"}, providerType.Capabilities.Credentials, providerType.Type)
+	}
+}
+
+func TestValidate_ArrayBounds(t *testing.T) {
+	signal, _ := Lookup("signal")
+	errs := validationErrors(t, signal.ProviderSchema.Validate(`{"warmup":{"ramp":[]}}`))
+	assert.Equal(t, []FieldError{{Field: "warmup.ramp", Message: "must have at least 1 items"}}, errs)
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{{Field: "port", Message: "is required"}, {Message: "must be an object"}}}
+	assert.Equal(t, "invalid config: port: is required; must be an object", err.Error())
+}
+
+func TestTypes_SortedAndLookup(t *testing.T) {
+	types := Types()
+	names := make([]string, len(types))
+	for i, providerType := range types {
+		names[i] = providerType.Type
+	}
+	assert.Equal(t, []string{"discord", "email", "line", "matrix", "sandbox", "signal", "sms", "teams", "telegram"}, names)
+
+	_, ok := Lookup("pager")
+	assert.False(t, ok)
+}
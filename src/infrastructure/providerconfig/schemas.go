@@ -0,0 +1,360 @@
+package providerconfig
+
+import "sort"
+
+const schemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// ProviderType describes a provider type and the schemas of its configs
+type ProviderType struct {
+	Type string `json:"type"`
+	// ProviderSchema describes Provider.Config, set up once by an admin
+	ProviderSchema *Schema `json:"provider_schema"`
+	// UserProviderSchema describes UserProvider.Config, the settings of a user for the provider
+	UserProviderSchema *Schema      `json:"user_provider_schema"`
+	Capabilities       Capabilities `json:"capabilities"`
+}
+
+// Capabilities describes how the providers of a type send, so clients can tell which recipients to pass
+type Capabilities struct {
+	// Recipients describes the recipients the type sends to
+	Recipients string `json:"recipients"`
+	// MaxMessageLength is the longest message in characters, 0 when longer messages are split or uploaded. Longer
+	// messages are refused or truncated by the length_policy of the provider.
+	MaxMessageLength int `json:"max_message_length"`
+	// Receive reports whether messages received by the provider are delivered to message.received hooks
+	Receive bool `json:"receive"`
+	// ResolveRecipients reports whether the type sends to usernames and looks recipients up before sending, see
+	// POST /signal/accounts/:number/resolve
+	ResolveRecipients bool `json:"resolve_recipients"`
+	// DeliveryCallbacks reports whether the vendor of the type reports the delivery to each recipient
+	DeliveryCallbacks bool `json:"delivery_callbacks"`
+	// Credentials is provider when the type sends with the credentials in the provider config, and user
+	// when it sends with the credentials of each user in their user provider config
+	Credentials string `json:"credentials"`
+	// Extensions lists the message extensions of /v1/send the type sends, each named after its provider type
+	Extensions []string `json:"extensions,omitempty"`
+	// Actions reports whether the type shows the actions of a message as buttons, the other types append them to
+	// the text as numbered options
+	Actions bool `json:"actions"`
+}
+
+// SupportsExtension reports whether the providers of the type send the message extension of the given name
+func (c Capabilities) SupportsExtension(name string) bool {
+	for _, extension := range c.Extensions {
+		if extension == name {
+			return true
+		}
+	}
+	return false
+}
+
+var providerTypes = map[string]*ProviderType{
+	"signal": {
+		Type:               "signal",
+		ProviderSchema:     providerSchema("Signal provider", nil),
+		UserProviderSchema: userProviderSchema(nil),
+		Capabilities:       Capabilities{Recipients: "Phone numbers, usernames (u:name), phone number identities (PNI:uuid) or group ids", Receive: true, ResolveRecipients: true, MaxMessageLength: 2000, Credentials: "provider", Extensions: []string{"signal"}},
+	},
+	"sms": {
+		Type: "sms",
+		ProviderSchema: providerSchema("SMS provider", &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"vendor":       {Type: "string", Enum: []string{"twilio"}, Description: "SMS vendor inbound numbers are provisioned through, defaults to twilio"},
+				"account_sid":  {Type: "string", MinLength: intPtr(1), Description: "Twilio account SID"},
+				"auth_token":   {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Twilio auth token, also verifies inbound SMS webhooks and delivery callbacks"},
+				"from":         {Type: "string", MinLength: intPtr(1), Description: "Number SMS are sent from"},
+				"max_segments": {Type: "integer", Minimum: floatPtr(1), Description: "Most segments a message is sent in, longer messages are handled by the length_policy"},
+			},
+		}),
+		UserProviderSchema: userProviderSchema(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"inbound_numbers": {
+					Type:        "array",
+					Description: "Numbers provisioned for the user, managed through /v1/providers/{id}/numbers",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"id":             {Type: "string"},
+							"phone_number":   {Type: "string"},
+							"provisioned_at": {Type: "string", Format: "date-time"},
+						},
+						AdditionalProperties: boolPtr(false),
+					},
+				},
+			},
+		}),
+		Capabilities: Capabilities{Recipients: "Phone numbers", MaxMessageLength: 1600, Receive: true, DeliveryCallbacks: true, Credentials: "provider"},
+	},
+	"teams": {
+		Type:               "teams",
+		ProviderSchema:     providerSchema("Teams provider", nil),
+		UserProviderSchema: userProviderSchema(nil),
+		Capabilities:       Capabilities{Recipients: "Teams channel or chat ids", MaxMessageLength: 28000, Credentials: "provider"},
+	},
+	"email": {
+		Type: "email",
+		ProviderSchema: providerSchema("Email provider", &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"from":                     {Type: "string", Format: "email", Description: "Sender address"},
+				"host":                     {Type: "string", MinLength: intPtr(1), Description: "SMTP host"},
+				"port":                     {Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(65535), Description: "SMTP port"},
+				"username":                 {Type: "string", Description: "SMTP user"},
+				"password":                 {Type: "string", WriteOnly: true, Description: "SMTP password"},
+				"event_webhook_public_key": {Type: "string", MinLength: intPtr(1), Description: "Public key of the SendGrid event webhook, verifies delivery callbacks"},
+				"max_message_size":         {Type: "integer", Minimum: floatPtr(1), Description: "Largest email in bytes the SMTP server accepts, attachments included, 25 MiB when not set"},
+			},
+			Required: []string{"from", "host", "port"},
+		}),
+		UserProviderSchema: userProviderSchema(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"from":     {Type: "string", Format: "email", Description: "Sender address of the user, overrides the from of the provider"},
+				"username": {Type: "string", Description: "SMTP user of the user, overrides the username of the provider"},
+				"password": {Type: "string", WriteOnly: true, Description: "SMTP password of the user, overrides the password of the provider"},
+			},
+		}),
+		Capabilities: Capabilities{Recipients: "Email addresses", DeliveryCallbacks: true, Credentials: "provider", Extensions: []string{"email"}},
+	},
+	"matrix": {
+		Type:           "matrix",
+		ProviderSchema: providerSchema("Matrix provider", nil),
+		UserProviderSchema: userProviderSchema(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"homeserver_url": {Type: "string", Format: "uri", Description: "Client-server API base URL of the homeserver, e.g. https://matrix.example.org"},
+				"access_token":   {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Access token of the user's Matrix account"},
+			},
+			Required: []string{"homeserver_url", "access_token"},
+		}),
+		Capabilities: Capabilities{Recipients: "Room ids (!room:server) or room aliases (#alias:server)", Receive: true, Credentials: "user"},
+	},
+	"discord": {
+		Type: "discord",
+		ProviderSchema: providerSchema("Discord provider", &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"public_key": {Type: "string", Pattern: "^[0-9a-fA-F]{64}$", Description: "Public key of the Discord application, verifies the button clicks posted to its interactions endpoint"},
+			},
+		}),
+		UserProviderSchema: userProviderSchema(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"bot_token":           {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Token of the bot sending to channel id recipients"},
+				"discord_webhook_url": {Type: "string", Format: "uri", WriteOnly: true, Description: "Channel webhook the webhook recipient posts to"},
+			},
+		}),
+		Capabilities: Capabilities{Recipients: "Channel ids, or webhook for the channel webhook of the user", Credentials: "user", Actions: true},
+	},
+	"telegram": {
+		Type:           "telegram",
+		ProviderSchema: providerSchema("Telegram provider", nil),
+		UserProviderSchema: userProviderSchema(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"bot_token": {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Token of the user's bot from @BotFather"},
+			},
+			Required: []string{"bot_token"},
+		}),
+		Capabilities: Capabilities{Recipients: "Chat ids, or @usernames of public channels the bot was added to", MaxMessageLength: 4096, Credentials: "user"},
+	},
+	"line": {
+		Type: "line",
+		ProviderSchema: providerSchema("LINE official account", &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"channel_access_token": {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Long-lived channel access token of the Messaging API channel"},
+				"channel_secret":       {Type: "string", MinLength: intPtr(1), WriteOnly: true, Description: "Channel secret of the Messaging API channel, verifies the postbacks of quick replies posted to its webhook"},
+			},
+			Required: []string{"channel_access_token"},
+		}),
+		UserProviderSchema: userProviderSchema(nil),
+		Capabilities:       Capabilities{Recipients: "LINE user, group or chat ids", MaxMessageLength: 25000, Credentials: "provider", Actions: true},
+	},
+	"sandbox": {
+		Type:               "sandbox",
+		ProviderSchema:     providerSchema("Sandbox provider, sending nowhere, for load tests with SANDBOX_PROVIDER_ENABLED", nil),
+		UserProviderSchema: userProviderSchema(nil),
+		Capabilities:       Capabilities{Recipients: "Any recipients, nothing is sent", Credentials: "provider"},
+	},
+}
+
+// Types returns the known provider types, sorted by type
+func Types() []ProviderType {
+	types := make([]ProviderType, 0, len(providerTypes))
+	for _, providerType := range providerTypes {
+		types = append(types, *providerType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+	return types
+}
+
+// Lookup returns a provider type, or false if the type is unknown
+func Lookup(providerType string) (*ProviderType, bool) {
+	t, ok := providerTypes[providerType]
+	return t, ok
+}
+
+// Generic returns the schemas every provider type shares, for providers of types without their own schema
+func Generic() *ProviderType {
+	return &ProviderType{
+		ProviderSchema:     providerSchema("Provider", nil),
+		UserProviderSchema: userProviderSchema(nil),
+	}
+}
+
+// clockPattern matches a time of day as HH:MM, up to 24:00
+const clockPattern = `^(([01][0-9]|2[0-3]):[0-5][0-9]|24:00)$`
+
+// providerSchema builds the schema of a provider config, adding the fields of a type to the settings
+// every provider supports
+func providerSchema(title string, typeSpecific *Schema) *Schema {
+	schema := &Schema{
+		SchemaURI: schemaURI,
+		Title:     title,
+		Type:      "object",
+		Properties: map[string]*Schema{
+			"warmup": {
+				Type:        "object",
+				Description: "Ramps up the daily message limit of a new number",
+				Properties: map[string]*Schema{
+					"enabled":    {Type: "boolean"},
+					"start_date": {Type: "string", Format: "date-time", Description: "Start of the warm-up, defaults to the creation of the provider"},
+					"ramp": {
+						Type:        "array",
+						Description: "Maximum number of messages per day for each day of the warm-up",
+						Items:       &Schema{Type: "integer", Minimum: floatPtr(1)},
+						MinItems:    intPtr(1),
+					},
+				},
+				AdditionalProperties: boolPtr(false),
+			},
+			"schedule": {
+				Type:        "object",
+				Description: "Limits sending to windows and keeps it quiet during blackouts, held messages are sent once the schedule opens",
+				Properties: map[string]*Schema{
+					"timezone": {Type: "string", Format: "timezone", Description: "Time zone the windows and blackout dates are read in, defaults to UTC"},
+					"windows": {
+						Type:        "array",
+						Description: "Daily time ranges messages are sent in, any time when empty",
+						Items: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"days": {
+									Type:        "array",
+									Description: "Days the window applies on, every day when empty",
+									Items:       &Schema{Type: "string", Enum: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}},
+								},
+								"start": {Type: "string", Pattern: clockPattern, Description: "HH:MM"},
+								"end":   {Type: "string", Pattern: clockPattern, Description: "HH:MM, 24:00 for the end of the day, before start to span midnight"},
+							},
+							Required:             []string{"start", "end"},
+							AdditionalProperties: boolPtr(false),
+						},
+					},
+					"blackouts": {
+						Type:        "array",
+						Description: "Periods no messages are sent in, a whole date or a start and end",
+						Items: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"date":   {Type: "string", Format: "date"},
+								"start":  {Type: "string", Format: "date-time"},
+								"end":    {Type: "string", Format: "date-time"},
+								"reason": {Type: "string", MaxLength: intPtr(255)},
+							},
+							AdditionalProperties: boolPtr(false),
+						},
+					},
+				},
+				AdditionalProperties: boolPtr(false),
+			},
+			"retry_policy": {
+				Type:        "object",
+				Description: "How failed messages are retried per error code, error codes without a policy keep their defaults",
+				Properties: map[string]*Schema{
+					"invalid_recipient": retryPolicySchema(),
+					"unregistered":      retryPolicySchema(),
+					"rate_limited":      retryPolicySchema(),
+					"auth_failed":       retryPolicySchema(),
+					"network":           retryPolicySchema(),
+					"unknown":           retryPolicySchema(),
+				},
+				AdditionalProperties: boolPtr(false),
+			},
+			"cost_per_message": {
+				Type:        "number",
+				Minimum:     floatPtr(0),
+				Description: "Price of one message, or of one SMS segment, to one recipient, used by the cost estimate of send previews",
+			},
+			"length_policy": {
+				Type:        "string",
+				Enum:        []string{"reject", "truncate"},
+				Description: "Whether messages longer than the provider sends are refused or truncated, defaults to reject",
+			},
+		},
+		AdditionalProperties: boolPtr(false),
+	}
+	if typeSpecific != nil {
+		for name, property := range typeSpecific.Properties {
+			schema.Properties[name] = property
+		}
+		schema.Required = typeSpecific.Required
+	}
+	return schema
+}
+
+// retryPolicySchema describes the retry policy of one error code
+func retryPolicySchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"action":          {Type: "string", Enum: []string{"retry", "fallback", "suppress"}, Description: "Retry through the same provider, fall back to the next provider or give the message up"},
+			"max_retries":     {Type: "integer", Minimum: floatPtr(0), Description: "Retries through the same provider before falling back"},
+			"backoff_seconds": {Type: "integer", Minimum: floatPtr(0), Description: "Delay before the first retry or the fallback, doubled for each further retry"},
+		},
+		AdditionalProperties: boolPtr(false),
+	}
+}
+
+// userProviderSchema builds the schema of a user provider config, adding the fields of a type to the
+// settings every provider supports
+func userProviderSchema(typeSpecific *Schema) *Schema {
+	schema := &Schema{
+		SchemaURI: schemaURI,
+		Title:     "User provider settings",
+		Type:      "object",
+		Properties: map[string]*Schema{
+			"webhook_url":                  {Type: "string", Format: "uri", Description: "Receives status updates of the user's messages"},
+			"webhook_enabled":              {Type: "boolean"},
+			"webhook_version":              {Type: "string", Enum: []string{"v1", "v2"}, Description: "Payload schema version, defaults to v1"},
+			"webhook_headers":              {Type: "object", WriteOnly: true, Description: "Headers sent with every webhook request, e.g. an Authorization header"},
+			"webhook_client_certificate":   {Type: "string", Description: "PEM client certificate presented to a webhook_url asking for one"},
+			"webhook_client_key":           {Type: "string", WriteOnly: true, Description: "PEM key of the webhook_client_certificate, stored encrypted"},
+			"webhook_ca_certificate":       {Type: "string", Description: "PEM CAs trusted for the webhook_url besides the system roots"},
+			"webhook_insecure_skip_verify": {Type: "boolean", Description: "Turns off the verification of the certificate of the webhook_url"},
+		},
+		AdditionalProperties: boolPtr(false),
+	}
+	if typeSpecific != nil {
+		for name, property := range typeSpecific.Properties {
+			schema.Properties[name] = property
+		}
+		schema.Required = typeSpecific.Required
+	}
+	return schema
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
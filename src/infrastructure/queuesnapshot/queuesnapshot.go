@@ -0,0 +1,360 @@
+package queuesnapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize is the number of messages read at once while taking a snapshot
+	batchSize = 500
+	// maxLineSize bounds a line of a snapshot, messages with large extensions make long lines
+	maxLineSize = 16 << 20
+)
+
+// Statuses are the statuses of the messages a snapshot holds, the messages still waiting to be sent
+var Statuses = []string{
+	domainProvider.MessageStatusPending,
+	domainProvider.MessageStatusFailed,
+	domainProvider.MessageStatusHeld,
+	domainProvider.MessageStatusHeldSchedule,
+	domainProvider.MessageStatusRateLimited,
+	domainProvider.MessageStatusSuspended,
+	domainProvider.MessageStatusUnconfirmed,
+}
+
+// Reasons a message of a snapshot isn't replayed
+const (
+	SkipProcessing = "processing" // a worker holds the message
+	SkipQueued     = "queued"     // the message is pending already
+	SkipStarted    = "started"    // the send was started after the snapshot, it may have reached the provider
+	SkipSent       = "sent"       // the message was sent since the snapshot
+	SkipCancelled  = "cancelled"  // an admin cancelled the message since the snapshot
+	SkipChanged    = "changed"    // the message changed while it was replayed
+)
+
+// Record is a message of a snapshot, one JSON object per line
+type Record struct {
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	ProviderID  int    `json:"provider_id"`
+	Recipients  string `json:"recipients"`
+	Message     string `json:"message"`
+	Tags        string `json:"tags,omitempty"`
+	Extensions  string `json:"extensions,omitempty"`
+	RequestData string `json:"request_data,omitempty"`
+	Actions     string `json:"actions,omitempty"`
+	TrackLinks  bool   `json:"track_links,omitempty"`
+	// Acknowledgement demanded by the sender, AckStatus is empty when none was demanded
+	AckStatus            string     `json:"ack_status,omitempty"`
+	AckToken             string     `json:"ack_token,omitempty"`
+	AckKeyword           string     `json:"ack_keyword,omitempty"`
+	AckDeadline          *time.Time `json:"ack_deadline,omitempty"`
+	AckEscalationChainID int        `json:"ack_escalation_chain_id,omitempty"`
+	Status               string     `json:"status"`
+	ErrorMessage         string     `json:"error_message,omitempty"`
+	ErrorCode            string     `json:"error_code,omitempty"`
+	RetryCount           int        `json:"retry_count"`
+	SendStartedAt        *time.Time `json:"send_started_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// Filter selects the messages of a snapshot to replay, zero fields match every message
+type Filter struct {
+	Statuses   []string   `json:"statuses,omitempty"`
+	UserID     int        `json:"user_id,omitempty"`
+	ProviderID int        `json:"provider_id,omitempty"`
+	ErrorCode  string     `json:"error_code,omitempty"`
+	From       *time.Time `json:"from,omitempty"` // created at or after, inclusive
+	To         *time.Time `json:"to,omitempty"`   // created before, exclusive
+}
+
+// Validate checks the statuses and the time range of a filter
+func (f *Filter) Validate() error {
+	for _, status := range f.Statuses {
+		if !contains(Statuses, status) {
+			return domainErrors.NewAppError(fmt.Errorf("statuses must be of %s", strings.Join(Statuses, ", ")), domainErrors.ValidationError)
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return domainErrors.NewAppError(errors.New("from must be before to"), domainErrors.ValidationError)
+	}
+	return nil
+}
+
+// Matches reports whether a message of a snapshot is selected by the filter
+func (f *Filter) Matches(record *Record) bool {
+	switch {
+	case len(f.Statuses) > 0 && !contains(f.Statuses, record.Status),
+		f.UserID != 0 && record.UserID != f.UserID,
+		f.ProviderID != 0 && record.ProviderID != f.ProviderID,
+		f.ErrorCode != "" && record.ErrorCode != f.ErrorCode,
+		f.From != nil && record.CreatedAt.Before(*f.From),
+		f.To != nil && !record.CreatedAt.Before(*f.To):
+		return false
+	}
+	return true
+}
+
+// Options control a replay
+type Options struct {
+	// DryRun only counts what a replay would do
+	DryRun bool
+	// Resend replays messages whose send was started after the snapshot, the recipients may get them twice
+	Resend bool
+}
+
+// SnapshotResult counts the messages of a snapshot per status
+type SnapshotResult struct {
+	Records  int            `json:"records"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// ReplayResult counts what a replay did with the messages of a snapshot
+type ReplayResult struct {
+	DryRun    bool           `json:"dry_run"`
+	Read      int            `json:"read"`      // messages read from the snapshot
+	Matched   int            `json:"matched"`   // messages selected by the filter
+	Requeued  int            `json:"requeued"`  // messages put back in the queue with their snapshot content
+	Recreated int            `json:"recreated"` // messages gone from the queue, queued again as new messages
+	Skipped   map[string]int `json:"skipped"`   // messages left alone, per reason
+}
+
+// Snapshotter writes the messages waiting to be sent to snapshots and replays them into the queue
+type Snapshotter struct {
+	messageTransactionRepository        providerRepo.MessageTransactionRepositoryInterface
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface
+	Logger                              *logger.Logger
+}
+
+// New creates a new Snapshotter
+func New(
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface,
+	messageTransactionHistoryRepository providerRepo.MessageTransactionHistoryRepositoryInterface,
+	loggerInstance *logger.Logger,
+) *Snapshotter {
+	return &Snapshotter{
+		messageTransactionRepository:        messageTransactionRepository,
+		messageTransactionHistoryRepository: messageTransactionHistoryRepository,
+		Logger:                              loggerInstance,
+	}
+}
+
+// Snapshot writes the messages waiting to be sent as JSON lines in ID order. The messages are read in batches,
+// so a message changing status meanwhile is written as it was when its batch was read.
+func (s *Snapshotter) Snapshot(ctx context.Context, w io.Writer) (*SnapshotResult, error) {
+	result := &SnapshotResult{Statuses: map[string]int{}}
+	encoder := json.NewEncoder(w)
+	afterID := 0
+	for ctx.Err() == nil {
+		messages, err := s.messageTransactionRepository.GetAfterID(afterID, 0, batchSize)
+		if err != nil {
+			return result, err
+		}
+		for _, message := range *messages {
+			afterID = message.ID
+			if !contains(Statuses, message.Status) {
+				continue
+			}
+			if err := encoder.Encode(toRecord(&message)); err != nil {
+				return result, err
+			}
+			result.Records++
+			result.Statuses[message.Status]++
+		}
+		if len(*messages) < batchSize {
+			return result, nil
+		}
+	}
+	return result, ctx.Err()
+}
+
+// Replay puts the messages of a snapshot selected by the filter back in the queue, restoring the content they had
+// when the snapshot was taken. A message still in the queue is requeued unless it is pending or held by a worker,
+// a message gone from the queue is queued again as a new message unless it was sent or cancelled meanwhile. A
+// message whose send was started after the snapshot is only replayed with Resend, it may have reached its
+// recipients. Replaying a snapshot again leaves the messages it requeued alone while they are pending.
+func (s *Snapshotter) Replay(ctx context.Context, r io.Reader, filter Filter, options Options, report func(result *ReplayResult)) (*ReplayResult, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	result := &ReplayResult{DryRun: options.DryRun, Skipped: map[string]int{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		result.Read++
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return result, domainErrors.NewAppError(fmt.Errorf("line %d of the snapshot: %w", result.Read, err), domainErrors.ValidationError)
+		}
+		if !filter.Matches(&record) {
+			continue
+		}
+		result.Matched++
+		if err := s.replay(&record, options, result); err != nil {
+			return result, err
+		}
+		if report != nil && result.Matched%batchSize == 0 {
+			report(result)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	s.Logger.Info("Replayed queue snapshot", zap.Bool("dryRun", options.DryRun), zap.Int("matched", result.Matched),
+		zap.Int("requeued", result.Requeued), zap.Int("recreated", result.Recreated), zap.Any("skipped", result.Skipped))
+	return result, nil
+}
+
+func (s *Snapshotter) replay(record *Record, options Options, result *ReplayResult) error {
+	current, err := s.messageTransactionRepository.GetByID(record.ID)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		return s.recreate(record, options, result)
+	}
+
+	switch {
+	case current.Processing:
+		result.Skipped[SkipProcessing]++
+		return nil
+	case current.Status == domainProvider.MessageStatusPending:
+		result.Skipped[SkipQueued]++
+		return nil
+	case current.SendStartedAt != nil && record.SendStartedAt == nil && !options.Resend:
+		result.Skipped[SkipStarted]++
+		return nil
+	case !domainProvider.CanTransition(current.Status, domainProvider.MessageStatusPending):
+		result.Skipped[SkipSent]++
+		return nil
+	}
+	if options.DryRun {
+		result.Requeued++
+		return nil
+	}
+	restored, err := s.messageTransactionRepository.Restore(record.ID, current.Status, map[string]interface{}{
+		"recipients":  record.Recipients,
+		"message":     record.Message,
+		"tags":        record.Tags,
+		"extensions":  record.Extensions,
+		"requestData": record.RequestData,
+		"actions":     record.Actions,
+		"retryCount":  record.RetryCount,
+	})
+	if err != nil {
+		return err
+	}
+	if !restored {
+		result.Skipped[SkipChanged]++
+		return nil
+	}
+	result.Requeued++
+	return nil
+}
+
+// recreate queues a message of a snapshot that is gone from the queue again, unless its history shows it was
+// sent or cancelled since
+func (s *Snapshotter) recreate(record *Record, options Options, result *ReplayResult) error {
+	histories, err := s.messageTransactionHistoryRepository.GetByMessageID(record.ID)
+	if err != nil {
+		return err
+	}
+	for _, history := range *histories {
+		switch {
+		case history.Status == domainProvider.MessageStatusCancelled:
+			result.Skipped[SkipCancelled]++
+			return nil
+		case history.Status != domainProvider.MessageStatusFailed && !options.Resend:
+			result.Skipped[SkipSent]++
+			return nil
+		}
+	}
+	if options.DryRun {
+		result.Recreated++
+		return nil
+	}
+	created, err := s.messageTransactionRepository.Create(&domainProvider.MessageTransaction{
+		UserID:               record.UserID,
+		ProviderID:           record.ProviderID,
+		Recipients:           record.Recipients,
+		Message:              record.Message,
+		Tags:                 record.Tags,
+		Extensions:           record.Extensions,
+		RequestData:          record.RequestData,
+		Actions:              record.Actions,
+		TrackLinks:           record.TrackLinks,
+		AckStatus:            record.AckStatus,
+		AckToken:             record.AckToken,
+		AckKeyword:           record.AckKeyword,
+		AckDeadline:          record.AckDeadline,
+		AckEscalationChainID: record.AckEscalationChainID,
+		Status:               domainProvider.MessageStatusPending,
+	})
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("Recreated message from queue snapshot", zap.Int("snapshotID", record.ID), zap.Int("id", created.ID))
+	result.Recreated++
+	return nil
+}
+
+func toRecord(message *domainProvider.MessageTransaction) *Record {
+	return &Record{
+		ID:                   message.ID,
+		UserID:               message.UserID,
+		ProviderID:           message.ProviderID,
+		Recipients:           message.Recipients,
+		Message:              message.Message,
+		Tags:                 message.Tags,
+		Extensions:           message.Extensions,
+		RequestData:          message.RequestData,
+		Actions:              message.Actions,
+		TrackLinks:           message.TrackLinks,
+		AckStatus:            message.AckStatus,
+		AckToken:             message.AckToken,
+		AckKeyword:           message.AckKeyword,
+		AckDeadline:          message.AckDeadline,
+		AckEscalationChainID: message.AckEscalationChainID,
+		Status:               message.Status,
+		ErrorMessage:         message.ErrorMessage,
+		ErrorCode:            message.ErrorCode,
+		RetryCount:           message.RetryCount,
+		SendStartedAt:        message.SendStartedAt,
+		CreatedAt:            message.CreatedAt,
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	var appErr *domainErrors.AppError
+	return errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound
+}
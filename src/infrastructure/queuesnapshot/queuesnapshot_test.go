@@ -0,0 +1,175 @@
+package queuesnapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// mockMessageTransactionRepository keeps the messages of the queue by ID
+type mockMessageTransactionRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	messages map[int]*domainProvider.MessageTransaction
+	restored map[int]map[string]interface{}
+	created  []domainProvider.MessageTransaction
+}
+
+func (m *mockMessageTransactionRepository) GetAfterID(afterID int, userID int, limit int) (*[]domainProvider.MessageTransaction, error) {
+	messages := []domainProvider.MessageTransaction{}
+	for id := afterID + 1; id <= afterID+limit && id <= 100; id++ {
+		if message, ok := m.messages[id]; ok {
+			messages = append(messages, *message)
+		}
+	}
+	return &messages, nil
+}
+
+func (m *mockMessageTransactionRepository) GetByID(id int) (*domainProvider.MessageTransaction, error) {
+	if message, ok := m.messages[id]; ok {
+		return message, nil
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockMessageTransactionRepository) Restore(id int, status string, messageTransactionMap map[string]interface{}) (bool, error) {
+	if m.messages[id].Status != status {
+		return false, nil
+	}
+	m.restored[id] = messageTransactionMap
+	return true, nil
+}
+
+func (m *mockMessageTransactionRepository) Create(messageTransaction *domainProvider.MessageTransaction) (*domainProvider.MessageTransaction, error) {
+	created := *messageTransaction
+	created.ID = 1000 + len(m.created)
+	m.created = append(m.created, created)
+	return &created, nil
+}
+
+type mockHistoryRepository struct {
+	providerRepo.MessageTransactionHistoryRepositoryInterface
+	histories map[int][]domainProvider.MessageTransactionHistory
+}
+
+func (m *mockHistoryRepository) GetByMessageID(messageID int) (*[]domainProvider.MessageTransactionHistory, error) {
+	histories := m.histories[messageID]
+	return &histories, nil
+}
+
+func setup() (*Snapshotter, *mockMessageTransactionRepository, *mockHistoryRepository) {
+	started := time.Now()
+	messages := &mockMessageTransactionRepository{
+		messages: map[int]*domainProvider.MessageTransaction{
+			1: {ID: 1, UserID: 1, Status: domainProvider.MessageStatusFailed, Message: "corrupted"},
+			2: {ID: 2, UserID: 1, Status: domainProvider.MessageStatusPending},
+			3: {ID: 3, UserID: 1, Status: domainProvider.MessageStatusFailed, Processing: true},
+			4: {ID: 4, UserID: 2, Status: domainProvider.MessageStatusUnconfirmed, SendStartedAt: &started},
+			5: {ID: 5, UserID: 2, Status: domainProvider.MessageStatusSuccess},
+		},
+		restored: map[int]map[string]interface{}{},
+	}
+	histories := &mockHistoryRepository{histories: map[int][]domainProvider.MessageTransactionHistory{
+		6: {{MessageID: 6, Status: domainProvider.MessageStatusFailed}},
+		7: {{MessageID: 7, Status: domainProvider.MessageStatusSuccess}},
+		8: {{MessageID: 8, Status: domainProvider.MessageStatusCancelled}},
+	}}
+	return New(messages, histories, &logger.Logger{Log: zap.NewNop()}), messages, histories
+}
+
+func snapshot(records ...Record) *bytes.Buffer {
+	var buffer bytes.Buffer
+	for _, record := range records {
+		line, _ := json.Marshal(record)
+		buffer.Write(append(line, '\n'))
+	}
+	return &buffer
+}
+
+func TestSnapshot_WritesMessagesWaitingToBeSent(t *testing.T) {
+	snapshotter, _, _ := setup()
+
+	var buffer bytes.Buffer
+	result, err := snapshotter.Snapshot(context.Background(), &buffer)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Records)
+	assert.Equal(t, map[string]int{"failed": 2, "pending": 1, "unconfirmed": 1}, result.Statuses)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 4)
+	var record Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "corrupted", record.Message)
+}
+
+func TestReplay(t *testing.T) {
+	snapshotter, messages, _ := setup()
+	records := snapshot(
+		Record{ID: 1, UserID: 1, Status: "failed", Message: "original", RetryCount: 2},
+		Record{ID: 2, UserID: 1, Status: "pending"},
+		Record{ID: 3, UserID: 1, Status: "failed"},
+		Record{ID: 4, UserID: 2, Status: "pending"},
+		Record{ID: 5, UserID: 2, Status: "pending"},
+		Record{ID: 6, UserID: 2, Status: "failed", Message: "lost"},
+		Record{ID: 7, UserID: 2, Status: "pending"},
+		Record{ID: 8, UserID: 2, Status: "pending"},
+		Record{ID: 9, UserID: 2, Status: "pending", Message: "gone"},
+	)
+
+	result, err := snapshotter.Replay(context.Background(), records, Filter{}, Options{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 9, result.Matched)
+	assert.Equal(t, 1, result.Requeued)
+	assert.Equal(t, 2, result.Recreated)
+	assert.Equal(t, map[string]int{SkipQueued: 1, SkipProcessing: 1, SkipStarted: 1, SkipSent: 2, SkipCancelled: 1}, result.Skipped)
+
+	// The content of the snapshot replaces the corrupted one
+	assert.Equal(t, "original", messages.restored[1]["message"])
+	assert.Equal(t, 2, messages.restored[1]["retryCount"])
+	require.Len(t, messages.created, 2)
+	assert.Equal(t, "lost", messages.created[0].Message)
+	assert.Equal(t, domainProvider.MessageStatusPending, messages.created[1].Status)
+}
+
+func TestReplay_ResendAndDryRun(t *testing.T) {
+	snapshotter, messages, _ := setup()
+	records := snapshot(Record{ID: 4, UserID: 2, Status: "pending"}, Record{ID: 7, UserID: 2, Status: "pending"})
+
+	result, err := snapshotter.Replay(context.Background(), records, Filter{}, Options{Resend: true, DryRun: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Requeued)
+	assert.Equal(t, 1, result.Recreated)
+	assert.Empty(t, messages.restored)
+	assert.Empty(t, messages.created)
+}
+
+func TestReplay_Filter(t *testing.T) {
+	snapshotter, _, _ := setup()
+	from := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := snapshot(
+		Record{ID: 1, UserID: 1, Status: "failed", ErrorCode: "rate_limited", CreatedAt: from},
+		Record{ID: 2, UserID: 1, Status: "failed", ErrorCode: "rate_limited", CreatedAt: from.Add(-time.Minute)},
+		Record{ID: 3, UserID: 2, Status: "failed", ErrorCode: "rate_limited", CreatedAt: from},
+		Record{ID: 4, UserID: 1, Status: "pending", CreatedAt: from},
+	)
+
+	result, err := snapshotter.Replay(context.Background(), records,
+		Filter{Statuses: []string{"failed"}, UserID: 1, ErrorCode: "rate_limited", From: &from}, Options{DryRun: true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Read)
+	assert.Equal(t, 1, result.Matched)
+
+	_, err = snapshotter.Replay(context.Background(), records, Filter{Statuses: []string{"success"}}, Options{}, nil)
+	assert.ErrorContains(t, err, "statuses must be of")
+}
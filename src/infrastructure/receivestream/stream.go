@@ -0,0 +1,247 @@
+// Package receivestream fans the messages received by the Signal numbers out to the admins watching them live,
+// to debug inbound routing.
+package receivestream
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+
+	"go.uber.org/zap"
+)
+
+// subscriptionBuffer is the number of events a subscription holds for a slow reader before it drops events
+const subscriptionBuffer = 64
+
+// RedactedValue replaces the redacted values of an event
+const RedactedValue = "[redacted]"
+
+// redactedKeys are the keys of the message contents and the names of people and groups in a received message.
+// Numbers, UUIDs and timestamps are kept, they are what inbound routing matches on.
+var redactedKeys = map[string]bool{
+	"message":     true,
+	"text":        true,
+	"body":        true,
+	"caption":     true,
+	"filename":    true,
+	"name":        true,
+	"sourceName":  true,
+	"groupName":   true,
+	"title":       true,
+	"description": true,
+}
+
+// Event is a received message as streamed to a subscriber
+type Event struct {
+	Type       string          `json:"type"`
+	Account    string          `json:"account"`
+	Source     string          `json:"source"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Redacted   bool            `json:"redacted"`
+	Message    json.RawMessage `json:"message"`
+}
+
+// Filter selects the received messages a subscriber is sent
+type Filter struct {
+	// Numbers are matched against the receiving account and the sender, empty for every message
+	Numbers []string
+}
+
+// Matches reports whether a received message passes the filter
+func (f Filter) Matches(receivedMessage *domainSignal.ReceivedMessage) bool {
+	if len(f.Numbers) == 0 {
+		return true
+	}
+	envelope := &receivedMessage.Envelope
+	return slices.ContainsFunc(f.Numbers, func(number string) bool {
+		return number == receivedMessage.Account || number == envelope.Source || number == envelope.SourceNumber || number == envelope.SourceUuid
+	})
+}
+
+// Subscription receives the events of the messages passing its filter until it is closed
+type Subscription struct {
+	filter    Filter
+	redact    bool
+	events    chan *Event
+	done      chan struct{}
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	reason    string
+	dropped   int
+}
+
+// Events returns the events of the subscription
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Done is closed when the stream closes the subscription, Reason tells why
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Reason returns why the stream closed the subscription
+func (s *Subscription) Reason() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.reason
+}
+
+// TakeDropped returns the number of events dropped since the last call, because the subscriber read too slowly
+func (s *Subscription) TakeDropped() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	dropped := s.dropped
+	s.dropped = 0
+	return dropped
+}
+
+func (s *Subscription) close(reason string) {
+	s.closeOnce.Do(func() {
+		s.mutex.Lock()
+		s.reason = reason
+		s.mutex.Unlock()
+		close(s.done)
+	})
+}
+
+// Stream publishes the received messages to its subscriptions. Publishing never blocks the receive path, a
+// subscription that can't keep up loses events.
+type Stream struct {
+	mutex         sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	Logger        *logger.Logger
+	now           func() time.Time
+}
+
+// New creates a new stream without subscriptions
+func New(loggerInstance *logger.Logger) *Stream {
+	return &Stream{subscriptions: map[*Subscription]struct{}{}, Logger: loggerInstance, now: time.Now}
+}
+
+// Subscribe adds a subscription to the messages passing the filter, with their contents redacted if asked for
+func (s *Stream) Subscribe(filter Filter, redact bool) *Subscription {
+	subscription := &Subscription{
+		filter: filter,
+		redact: redact,
+		events: make(chan *Event, subscriptionBuffer),
+		done:   make(chan struct{}),
+	}
+	s.mutex.Lock()
+	s.subscriptions[subscription] = struct{}{}
+	s.mutex.Unlock()
+	return subscription
+}
+
+// Unsubscribe removes a subscription, it isn't sent events anymore
+func (s *Stream) Unsubscribe(subscription *Subscription) {
+	s.mutex.Lock()
+	delete(s.subscriptions, subscription)
+	s.mutex.Unlock()
+	subscription.close("unsubscribed")
+}
+
+// DisconnectAll closes every subscription with the reason, e.g. when maintenance mode is enabled
+func (s *Stream) DisconnectAll(reason string) {
+	s.mutex.Lock()
+	subscriptions := s.subscriptions
+	s.subscriptions = map[*Subscription]struct{}{}
+	s.mutex.Unlock()
+	for subscription := range subscriptions {
+		subscription.close(reason)
+	}
+	if len(subscriptions) > 0 {
+		s.Logger.Info("Disconnected receive stream subscribers", zap.Int("subscribers", len(subscriptions)), zap.String("reason", reason))
+	}
+}
+
+// Publish sends a received message to the subscriptions it passes the filter of. The message is only encoded
+// when somebody listens.
+func (s *Stream) Publish(receivedMessage *domainSignal.ReceivedMessage) {
+	s.mutex.Lock()
+	var matching []*Subscription
+	for subscription := range s.subscriptions {
+		if subscription.filter.Matches(receivedMessage) {
+			matching = append(matching, subscription)
+		}
+	}
+	s.mutex.Unlock()
+	if len(matching) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(receivedMessage)
+	if err != nil {
+		s.Logger.Error("Couldn't encode received message for the receive stream", zap.Error(err))
+		return
+	}
+	var redacted json.RawMessage
+	base := Event{
+		Type:       string(receivedMessage.Envelope.Type()),
+		Account:    receivedMessage.Account,
+		Source:     receivedMessage.Envelope.SenderID(),
+		ReceivedAt: s.now(),
+	}
+	for _, subscription := range matching {
+		event := base
+		event.Message = raw
+		if subscription.redact {
+			if redacted == nil {
+				redacted = Redact(raw)
+			}
+			event.Message, event.Redacted = redacted, true
+		}
+		select {
+		case subscription.events <- &event:
+		default:
+			subscription.mutex.Lock()
+			subscription.dropped++
+			subscription.mutex.Unlock()
+		}
+	}
+}
+
+// Handler returns a receive handler publishing every message before passing it to the next handler
+func (s *Stream) Handler(next signalClient.ReceiveHandler) signalClient.ReceiveHandler {
+	return func(receivedMessage *domainSignal.ReceivedMessage) {
+		s.Publish(receivedMessage)
+		next(receivedMessage)
+	}
+}
+
+// Redact replaces the message contents and the names in an encoded received message, whatever part of the
+// envelope they are in
+func Redact(raw json.RawMessage) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return json.RawMessage(`"` + RedactedValue + `"`)
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return json.RawMessage(`"` + RedactedValue + `"`)
+	}
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			if _, ok := child.(string); ok && redactedKeys[key] {
+				typed[key] = RedactedValue
+				continue
+			}
+			typed[key] = redactValue(child)
+		}
+	case []any:
+		for i, child := range typed {
+			typed[i] = redactValue(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,90 @@
+package receivestream
+
+import (
+	"encoding/json"
+	"testing"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func receivedMessage(account string, source string, text string) *domainSignal.ReceivedMessage {
+	return &domainSignal.ReceivedMessage{
+		Account: account,
+		Envelope: domainSignal.Envelope{
+			Source:     source,
+			SourceName: "Anna",
+			Timestamp:  1700000000000,
+			DataMessage: &domainSignal.DataMessage{
+				Message:   &text,
+				GroupInfo: &domainSignal.GroupInfo{GroupId: "abc", GroupName: "Ops"},
+			},
+		},
+	}
+}
+
+func TestStream_FiltersByNumber(t *testing.T) {
+	stream := New(&logger.Logger{Log: zap.NewNop()})
+	all := stream.Subscribe(Filter{}, false)
+	filtered := stream.Subscribe(Filter{Numbers: []string{"+4922"}}, false)
+
+	stream.Publish(receivedMessage("+4911", "+4933", "hello"))
+	stream.Publish(receivedMessage("+4911", "+4922", "hi"))
+
+	assert.Len(t, all.Events(), 2)
+	require.Len(t, filtered.Events(), 1)
+	event := <-filtered.Events()
+	assert.Equal(t, "data_message", event.Type)
+	assert.Equal(t, "+4922", event.Source)
+	assert.False(t, event.Redacted)
+	assert.Contains(t, string(event.Message), `"message":"hi"`)
+}
+
+func TestStream_RedactsContents(t *testing.T) {
+	stream := New(&logger.Logger{Log: zap.NewNop()})
+	subscription := stream.Subscribe(Filter{}, true)
+
+	stream.Publish(receivedMessage("+4911", "+4922", "secret"))
+
+	event := <-subscription.Events()
+	assert.True(t, event.Redacted)
+	var message domainSignal.ReceivedMessage
+	require.NoError(t, json.Unmarshal(event.Message, &message))
+	assert.Equal(t, RedactedValue, *message.Envelope.DataMessage.Message)
+	assert.Equal(t, RedactedValue, message.Envelope.SourceName)
+	assert.Equal(t, RedactedValue, message.Envelope.DataMessage.GroupInfo.GroupName)
+	// What inbound routing matches on is kept
+	assert.Equal(t, "+4922", message.Envelope.Source)
+	assert.Equal(t, "abc", message.Envelope.DataMessage.GroupInfo.GroupId)
+}
+
+func TestStream_DropsEventsOfSlowSubscribers(t *testing.T) {
+	stream := New(&logger.Logger{Log: zap.NewNop()})
+	subscription := stream.Subscribe(Filter{}, false)
+
+	for i := 0; i < subscriptionBuffer+3; i++ {
+		stream.Publish(receivedMessage("+4911", "+4922", "hello"))
+	}
+	assert.Len(t, subscription.Events(), subscriptionBuffer)
+	assert.Equal(t, 3, subscription.TakeDropped())
+	assert.Equal(t, 0, subscription.TakeDropped())
+}
+
+func TestStream_DisconnectAll(t *testing.T) {
+	stream := New(&logger.Logger{Log: zap.NewNop()})
+	subscription := stream.Subscribe(Filter{}, false)
+
+	stream.DisconnectAll("maintenance mode enabled")
+	<-subscription.Done()
+	assert.Equal(t, "maintenance mode enabled", subscription.Reason())
+
+	// A disconnected subscription isn't sent events anymore
+	stream.Publish(receivedMessage("+4911", "+4922", "hello"))
+	assert.Empty(t, subscription.Events())
+	stream.Unsubscribe(subscription)
+	assert.Equal(t, "maintenance mode enabled", subscription.Reason())
+}
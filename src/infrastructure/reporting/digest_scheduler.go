@@ -0,0 +1,77 @@
+package reporting
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/digest"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// DigestScheduler periodically generates the delivery digests that are due, on the leader instance only
+type DigestScheduler struct {
+	digestUseCase digest.IDigestUseCase
+	elector       leader.Elector
+	Logger        *logger.Logger
+	interval      time.Duration
+	shutdown      chan struct{}
+	done          chan struct{}
+}
+
+// NewDigestScheduler creates a new digest scheduler and starts it
+func NewDigestScheduler(digestUseCase digest.IDigestUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *DigestScheduler {
+	if interval <= 0 {
+		interval = time.Hour // Default to hourly checks if not specified
+	}
+
+	scheduler := &DigestScheduler{
+		digestUseCase: digestUseCase,
+		elector:       elector,
+		Logger:        loggerInstance,
+		interval:      interval,
+		shutdown:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *DigestScheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting digest scheduler", zap.Duration("interval", s.interval))
+
+	// Generate digests that became due while the service was down
+	s.generateDueDigests()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.generateDueDigests()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *DigestScheduler) generateDueDigests() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.digestUseCase.GenerateDueDigests(time.Now()); err != nil {
+		s.Logger.Error("Error generating delivery digests", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *DigestScheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
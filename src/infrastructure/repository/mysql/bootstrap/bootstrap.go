@@ -0,0 +1,449 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	domainBootstrap "go-multi-chat-api/src/domain/bootstrap"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// SecretEncrypter encrypts the secrets of webhooks with the key of their user before they are stored
+type SecretEncrypter interface {
+	Encrypt(userID int, plaintext string) (string, error)
+}
+
+// BootstrapRepositoryInterface defines the interface for applying bootstrap documents
+type BootstrapRepositoryInterface interface {
+	// Apply brings the stored resources to the state of a document in one transaction and returns the changes,
+	// in the order of the document. Nothing is changed when it fails or on a dry run.
+	Apply(document *domainBootstrap.Document, dryRun bool) ([]domainBootstrap.Change, error)
+}
+
+type BootstrapRepository struct {
+	DB      *gorm.DB
+	secrets SecretEncrypter
+	Logger  *logger.Logger
+}
+
+// NewBootstrapRepository creates a new BootstrapRepository. When secrets is nil, the secrets of webhooks are
+// stored in plaintext.
+func NewBootstrapRepository(db *gorm.DB, secrets SecretEncrypter, loggerInstance *logger.Logger) BootstrapRepositoryInterface {
+	return &BootstrapRepository{DB: db, secrets: secrets, Logger: loggerInstance}
+}
+
+func (r *BootstrapRepository) Apply(document *domainBootstrap.Document, dryRun bool) ([]domainBootstrap.Change, error) {
+	var changes []domainBootstrap.Change
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		a := &applier{tx: tx, dryRun: dryRun, secrets: r.secrets, userIDs: map[string]int{}, providerIDs: map[string]int{}, changes: []domainBootstrap.Change{}}
+		if err := a.apply(document); err != nil {
+			return err
+		}
+		changes = a.changes
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return changes, nil
+	}
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		r.Logger.Error("Error applying bootstrap document", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return changes, nil
+}
+
+// applier applies one document within its transaction. userIDs and providerIDs hold the IDs of the users and
+// providers looked up or created so far, 0 for those a dry run would create.
+type applier struct {
+	tx          *gorm.DB
+	dryRun      bool
+	secrets     SecretEncrypter
+	userIDs     map[string]int
+	providerIDs map[string]int
+	changes     []domainBootstrap.Change
+}
+
+func (a *applier) apply(document *domainBootstrap.Document) error {
+	for i, u := range document.Users {
+		if err := a.applyUser(fmt.Sprintf("users[%d]", i), u); err != nil {
+			return err
+		}
+	}
+	emails := make([]string, 0, len(document.Roles))
+	for email := range document.Roles {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+	for _, email := range emails {
+		if err := a.applyRole(email, document.Roles[email]); err != nil {
+			return err
+		}
+	}
+	for i, p := range document.Providers {
+		if err := a.applyProvider(fmt.Sprintf("providers[%d]", i), p); err != nil {
+			return err
+		}
+	}
+	for i, up := range document.UserProviders {
+		if err := a.applyUserProvider(fmt.Sprintf("user_providers[%d]", i), up); err != nil {
+			return err
+		}
+	}
+	for i, w := range document.Webhooks {
+		if err := a.applyWebhook(fmt.Sprintf("webhooks[%d]", i), w); err != nil {
+			return err
+		}
+	}
+	for i, t := range document.Templates {
+		if err := a.applyTemplate(fmt.Sprintf("templates[%d]", i), t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyUser(path string, u domainBootstrap.User) error {
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindUser, Name: u.Email}
+	var model userRepo.User
+	exists, err := found(a.tx.Where("email = ?", u.Email).Take(&model))
+	if err != nil {
+		return err
+	}
+	taken, err := found(a.tx.Where("user_name = ? AND email <> ?", u.UserName, u.Email).Take(&userRepo.User{}))
+	if err != nil {
+		return err
+	}
+	if taken {
+		return domainErrors.NewAppError(fmt.Errorf("%s: user_name %s is taken by another user", path, u.UserName), domainErrors.Conflict)
+	}
+
+	if !exists {
+		if u.PasswordHash == "" {
+			return domainErrors.NewAppError(fmt.Errorf("%s: password is required to create user %s", path, u.Email), domainErrors.ValidationError)
+		}
+		change.Action = domainBootstrap.ActionCreated
+		if !a.dryRun {
+			model = userRepo.User{
+				Email:            u.Email,
+				UserName:         u.UserName,
+				FirstName:        u.FirstName,
+				LastName:         u.LastName,
+				HashPassword:     u.PasswordHash,
+				Role:             u.Role,
+				Locale:           u.Locale,
+				Status:           u.Status,
+				MessageRateLimit: u.MessageRateLimit,
+			}
+			if err := a.tx.Create(&model).Error; err != nil {
+				return fmt.Errorf("couldn't create user %s: %w", u.Email, err)
+			}
+			change.ID = model.ID
+		}
+		a.userIDs[u.Email] = change.ID
+		a.changes = append(a.changes, change)
+		return nil
+	}
+
+	change.ID = model.ID
+	a.userIDs[u.Email] = model.ID
+	updates := map[string]interface{}{}
+	if model.UserName != u.UserName {
+		updates["user_name"] = u.UserName
+	}
+	if model.FirstName != u.FirstName {
+		updates["first_name"] = u.FirstName
+	}
+	if model.LastName != u.LastName {
+		updates["last_name"] = u.LastName
+	}
+	if model.Role != u.Role {
+		updates["role"] = u.Role
+	}
+	if model.Locale != u.Locale {
+		updates["locale"] = u.Locale
+	}
+	if model.Status != u.Status {
+		updates["status"] = u.Status
+	}
+	if model.MessageRateLimit != u.MessageRateLimit {
+		updates["message_rate_limit"] = u.MessageRateLimit
+	}
+	return a.update(change, &userRepo.User{}, updates)
+}
+
+// applyRole sets the role of a user the document doesn't list
+func (a *applier) applyRole(email string, role string) error {
+	var model userRepo.User
+	exists, err := found(a.tx.Where("email = ?", email).Take(&model))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domainErrors.NewAppError(fmt.Errorf("roles: unknown user %s", email), domainErrors.ValidationError)
+	}
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindUser, Name: email, ID: model.ID}
+	updates := map[string]interface{}{}
+	if model.Role != role {
+		updates["role"] = role
+	}
+	return a.update(change, &userRepo.User{}, updates)
+}
+
+func (a *applier) applyProvider(path string, p domainBootstrap.Provider) error {
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindProvider, Name: p.Name}
+	var model providerRepo.Provider
+	exists, err := found(a.tx.Where("name = ?", p.Name).Take(&model))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		change.Action = domainBootstrap.ActionCreated
+		if !a.dryRun {
+			model = providerRepo.Provider{Name: p.Name, Type: p.Type, Description: p.Description, Config: p.Config, Status: p.Status}
+			if err := a.tx.Create(&model).Error; err != nil {
+				return fmt.Errorf("couldn't create provider %s: %w", p.Name, err)
+			}
+			change.ID = model.ID
+		}
+		a.providerIDs[p.Name] = change.ID
+		a.changes = append(a.changes, change)
+		return nil
+	}
+	if model.Type != p.Type {
+		return domainErrors.NewAppError(fmt.Errorf("%s: the type of provider %q can't be changed from %s", path, p.Name, model.Type), domainErrors.ValidationError)
+	}
+
+	change.ID = model.ID
+	a.providerIDs[p.Name] = model.ID
+	updates := map[string]interface{}{}
+	if model.Description != p.Description {
+		updates["description"] = p.Description
+	}
+	if !sameConfig(model.Config, p.Config) {
+		updates["config"] = p.Config
+	}
+	if model.Status != p.Status {
+		updates["status"] = p.Status
+	}
+	if len(updates) > 0 {
+		// Concurrent updates through the API check the version they read
+		updates["version"] = gorm.Expr("version + 1")
+	}
+	return a.update(change, &providerRepo.Provider{}, updates)
+}
+
+func (a *applier) applyUserProvider(path string, up domainBootstrap.UserProvider) error {
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindUserProvider, Name: up.Provider, User: up.User}
+	userID, err := a.userID(path, up.User)
+	if err != nil {
+		return err
+	}
+	providerID, ok := a.providerIDs[up.Provider]
+	if !ok {
+		var p providerRepo.Provider
+		exists, err := found(a.tx.Where("name = ?", up.Provider).Take(&p))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domainErrors.NewAppError(fmt.Errorf("%s: unknown provider %s", path, up.Provider), domainErrors.ValidationError)
+		}
+		providerID = p.ID
+		a.providerIDs[up.Provider] = providerID
+	}
+
+	var model providerRepo.UserProvider
+	exists := false
+	if userID != 0 && providerID != 0 {
+		exists, err = found(a.tx.Where("user_id = ? AND provider_id = ?", userID, providerID).Take(&model))
+		if err != nil {
+			return err
+		}
+	}
+	if !exists {
+		change.Action = domainBootstrap.ActionCreated
+		if !a.dryRun {
+			model = providerRepo.UserProvider{UserID: userID, ProviderID: providerID, Priority: up.Priority, Config: up.Config, Status: up.Status}
+			if err := a.tx.Create(&model).Error; err != nil {
+				return fmt.Errorf("couldn't create user provider %s of %s: %w", up.Provider, up.User, err)
+			}
+			change.ID = model.ID
+		}
+		a.changes = append(a.changes, change)
+		return nil
+	}
+
+	change.ID = model.ID
+	updates := map[string]interface{}{}
+	if model.Priority != up.Priority {
+		updates["priority"] = up.Priority
+	}
+	if !sameConfig(model.Config, up.Config) {
+		updates["config"] = up.Config
+	}
+	if model.Status != up.Status {
+		updates["status"] = up.Status
+	}
+	if len(updates) > 0 {
+		updates["version"] = gorm.Expr("version + 1")
+	}
+	return a.update(change, &providerRepo.UserProvider{}, updates)
+}
+
+// applyWebhook creates a subscription that doesn't exist yet, subscriptions have nothing to update
+func (a *applier) applyWebhook(path string, w domainBootstrap.Webhook) error {
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindWebhook, Name: w.Event + " " + w.TargetURL, User: w.User}
+	userID, err := a.userID(path, w.User)
+	if err != nil {
+		return err
+	}
+
+	var model providerRepo.HookSubscription
+	exists := false
+	if userID != 0 {
+		exists, err = found(a.tx.Where("user_id = ? AND event = ? AND target_url = ?", userID, w.Event, w.TargetURL).Take(&model))
+		if err != nil {
+			return err
+		}
+	}
+	if exists {
+		change.Action, change.ID = domainBootstrap.ActionUnchanged, model.ID
+		a.changes = append(a.changes, change)
+		return nil
+	}
+
+	change.Action = domainBootstrap.ActionCreated
+	if !a.dryRun {
+		if w.Secret == "" {
+			// The subscription was removed since its target was verified
+			return domainErrors.NewAppError(fmt.Errorf("%s: the target wasn't verified, apply the document again", path), domainErrors.Conflict)
+		}
+		secret := w.Secret
+		if a.secrets != nil {
+			if secret, err = a.secrets.Encrypt(userID, w.Secret); err != nil {
+				return fmt.Errorf("couldn't encrypt the secret of webhook %s of %s: %w", change.Name, w.User, err)
+			}
+		}
+		model = providerRepo.HookSubscription{UserID: userID, Event: w.Event, TargetURL: w.TargetURL, Secret: secret}
+		if err := a.tx.Create(&model).Error; err != nil {
+			return fmt.Errorf("couldn't create webhook %s of %s: %w", change.Name, w.User, err)
+		}
+		change.ID, change.Secret = model.ID, w.Secret
+	}
+	a.changes = append(a.changes, change)
+	return nil
+}
+
+func (a *applier) applyTemplate(path string, t domainBootstrap.Template) error {
+	change := domainBootstrap.Change{Kind: domainBootstrap.KindTemplate, Name: t.Name, User: t.User}
+	userID, err := a.userID(path, t.User)
+	if err != nil {
+		return err
+	}
+
+	var model providerRepo.EmailTemplate
+	exists := false
+	if userID != 0 {
+		exists, err = found(a.tx.Where("user_id = ? AND name = ?", userID, t.Name).Take(&model))
+		if err != nil {
+			return err
+		}
+	}
+	if !exists {
+		change.Action = domainBootstrap.ActionCreated
+		if !a.dryRun {
+			model = providerRepo.EmailTemplate{UserID: userID, Name: t.Name, Subject: t.Subject, HTML: t.HTML}
+			if err := a.tx.Create(&model).Error; err != nil {
+				return fmt.Errorf("couldn't create template %s of %s: %w", t.Name, t.User, err)
+			}
+			change.ID = model.ID
+		}
+		a.changes = append(a.changes, change)
+		return nil
+	}
+
+	change.ID = model.ID
+	updates := map[string]interface{}{}
+	if model.Subject != t.Subject {
+		updates["subject"] = t.Subject
+	}
+	if model.HTML != t.HTML {
+		updates["html"] = t.HTML
+	}
+	return a.update(change, &providerRepo.EmailTemplate{}, updates)
+}
+
+// userID returns the ID of the user a resource belongs to, 0 for a user a dry run would create
+func (a *applier) userID(path string, email string) (int, error) {
+	if id, ok := a.userIDs[email]; ok {
+		return id, nil
+	}
+	var model userRepo.User
+	exists, err := found(a.tx.Where("email = ?", email).Take(&model))
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, domainErrors.NewAppError(fmt.Errorf("%s: unknown user %s", path, email), domainErrors.ValidationError)
+	}
+	a.userIDs[email] = model.ID
+	return model.ID, nil
+}
+
+// update records the change of a stored resource, updating its row with the changed columns unless it is a
+// dry run
+func (a *applier) update(change domainBootstrap.Change, model interface{}, updates map[string]interface{}) error {
+	change.Action = domainBootstrap.ActionUnchanged
+	if len(updates) > 0 {
+		change.Action = domainBootstrap.ActionUpdated
+		if !a.dryRun {
+			if err := a.tx.Model(model).Where("id = ?", change.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("couldn't update %s %s: %w", change.Kind, change.Name, err)
+			}
+		}
+	}
+	a.changes = append(a.changes, change)
+	return nil
+}
+
+// found reports whether a lookup found a row, turning only unexpected errors into an error
+func found(result *gorm.DB) (bool, error) {
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return true, nil
+}
+
+// sameConfig reports whether two configs hold the same JSON, whatever the order of their keys
+func sameConfig(a string, b string) bool {
+	if a == b {
+		return true
+	}
+	var valueA, valueB interface{}
+	if json.Unmarshal([]byte(a), &valueA) != nil || json.Unmarshal([]byte(b), &valueB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
@@ -7,10 +7,11 @@ import (
 
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	"go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/signal"
 	"go-multi-chat-api/src/infrastructure/repository/mysql/user"
+	"go-multi-chat-api/src/infrastructure/seed"
 
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
@@ -111,27 +112,20 @@ func (c DatabaseConfig) GetDSN() string {
 }
 
 func (r *MySQLRepository) InitDatabase() error {
-	cfg, err := loadDatabaseConfig()
+	err := r.Connect()
 	if err != nil {
-		r.Logger.Error("Failed to load database configuration", zap.Error(err))
-		return fmt.Errorf("failed to load database configuration: %w", err)
+		return err
 	}
 
-	// Create a GORM logger with zap
-	gormZap := logger.NewGormLogger(r.Logger.Log).
-		LogMode(gormlogger.Warn) // Silent / Error / Warn / Info
-
-	r.DB, err = gorm.Open(mysql.Open(cfg.GetDSN()), &gorm.Config{
-		Logger: gormZap,
-	})
+	err = r.MigrateEntitiesGORM()
 	if err != nil {
-		r.Logger.Error("Error connecting to the database", zap.Error(err))
+		r.Logger.Error("Error migrating the database", zap.Error(err))
 		return err
 	}
 
-	err = r.MigrateEntitiesGORM()
+	err = r.MigratePartitions()
 	if err != nil {
-		r.Logger.Error("Error migrating the database", zap.Error(err))
+		r.Logger.Error("Error partitioning the message tables", zap.Error(err))
 		return err
 	}
 
@@ -141,27 +135,133 @@ func (r *MySQLRepository) InitDatabase() error {
 		return err
 	}
 
+	err = r.SeedFixtures()
+	if err != nil {
+		r.Logger.Error("Error seeding fixtures", zap.Error(err))
+		return err
+	}
+
 	r.Logger.Info("Database connection and migrations successful")
 	return nil
 }
 
+// Connect opens the database connection without migrating or seeding it
+func (r *MySQLRepository) Connect() error {
+	cfg, err := loadDatabaseConfig()
+	if err != nil {
+		r.Logger.Error("Failed to load database configuration", zap.Error(err))
+		return fmt.Errorf("failed to load database configuration: %w", err)
+	}
+
+	// Create a GORM logger with zap
+	gormZap := logger.NewGormLogger(r.Logger.Log).
+		LogMode(gormlogger.Warn) // Silent / Error / Warn / Info
+
+	r.DB, err = gorm.Open(mysql.Open(cfg.GetDSN()), &gorm.Config{
+		Logger: gormZap,
+	})
+	if err != nil {
+		r.Logger.Error("Error connecting to the database", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 func (r *MySQLRepository) MigrateEntitiesGORM() error {
 	// Import the models to register them with GORM
 	userModel := &user.User{}
+	loginEventModel := &user.LoginEvent{}
+	loginNotificationSettingsModel := &user.LoginNotificationSettings{}
 
 	// Import provider models
 	providerModel := &provider.Provider{}
 	userProviderModel := &provider.UserProvider{}
 	messageTransactionModel := &provider.MessageTransaction{}
 	messageTransactionHistoryModel := &provider.MessageTransactionHistory{}
+	outboxEventModel := &provider.OutboxEvent{}
+	digestSubscriptionModel := &provider.DigestSubscription{}
+	deliveryDigestModel := &provider.DeliveryDigest{}
+	hookSubscriptionModel := &provider.HookSubscription{}
+	webhookEventModel := &provider.WebhookEvent{}
+	controlCommandModel := &provider.ControlCommand{}
+	messageEngagementModel := &provider.MessageEngagement{}
+	escalationChainModel := &provider.EscalationChain{}
+	escalationModel := &provider.Escalation{}
+	distributionListModel := &provider.DistributionList{}
+	providerDrillModel := &provider.ProviderDrill{}
+	emailTemplateModel := &provider.EmailTemplate{}
+	shortLinkModel := &provider.ShortLink{}
+	linkClickModel := &provider.LinkClick{}
+	conversationModel := &provider.Conversation{}
+	conversationMessageModel := &provider.ConversationMessage{}
+	jobModel := &provider.Job{}
+	messageDeliveryModel := &provider.MessageDelivery{}
+	messageEditModel := &provider.MessageEdit{}
+	customDomainModel := &provider.CustomDomain{}
+	auditExportBatchModel := &provider.AuditExportBatch{}
+	attachmentModel := &provider.Attachment{}
+	statusBannerModel := &provider.StatusBanner{}
+	recipientCapViolationModel := &provider.RecipientCapViolation{}
+	contentTransformModel := &provider.ContentTransform{}
+	maintenanceModeModel := &provider.MaintenanceMode{}
+	retentionPolicyModel := &provider.RetentionPolicy{}
+	messageArchiveBatchModel := &provider.MessageArchiveBatch{}
+
+	// Import signal models
+	registrationLockModel := &signal.RegistrationLock{}
+	receivedMessageModel := &signal.ReceivedMessage{}
+	receiveWatermarkModel := &signal.ReceiveWatermark{}
+	receivedEnvelopeModel := &signal.ReceivedEnvelope{}
+	verificationAttemptModel := &signal.VerificationAttempt{}
+	rateLimitChallengeModel := &signal.RateLimitChallenge{}
+	deviceLinkModel := &signal.DeviceLink{}
+	numberHealthModel := &signal.NumberHealth{}
 
 	// Auto migrate the models to create/update tables
 	err := r.DB.AutoMigrate(
 		userModel,
+		loginEventModel,
+		loginNotificationSettingsModel,
 		providerModel,
 		userProviderModel,
 		messageTransactionModel,
 		messageTransactionHistoryModel,
+		outboxEventModel,
+		digestSubscriptionModel,
+		deliveryDigestModel,
+		hookSubscriptionModel,
+		webhookEventModel,
+		controlCommandModel,
+		messageEngagementModel,
+		escalationChainModel,
+		escalationModel,
+		distributionListModel,
+		providerDrillModel,
+		emailTemplateModel,
+		shortLinkModel,
+		linkClickModel,
+		conversationModel,
+		conversationMessageModel,
+		jobModel,
+		messageDeliveryModel,
+		messageEditModel,
+		customDomainModel,
+		auditExportBatchModel,
+		attachmentModel,
+		statusBannerModel,
+		recipientCapViolationModel,
+		contentTransformModel,
+		maintenanceModeModel,
+		retentionPolicyModel,
+		messageArchiveBatchModel,
+		registrationLockModel,
+		receivedMessageModel,
+		receiveWatermarkModel,
+		receivedEnvelopeModel,
+		verificationAttemptModel,
+		rateLimitChallengeModel,
+		deviceLinkModel,
+		numberHealthModel,
 	)
 	if err != nil {
 		r.Logger.Error("Error migrating database entities", zap.Error(err))
@@ -172,6 +272,7 @@ func (r *MySQLRepository) MigrateEntitiesGORM() error {
 	return nil
 }
 
+// SeedInitialUser creates the admin of START_USER_EMAIL with the default providers, unless the admin exists
 func (r *MySQLRepository) SeedInitialUser() error {
 	email := os.Getenv("START_USER_EMAIL")
 	pw := os.Getenv("START_USER_PW")
@@ -188,72 +289,49 @@ func (r *MySQLRepository) SeedInitialUser() error {
 		return nil
 	}
 
-	// Create an initial user
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
-	if err != nil {
-		r.Logger.Error("Error hashing password for initial user", zap.Error(err))
-		return err
-	}
-
-	newUser := user.User{
-		Email:            email,
-		HashPassword:     string(hashedPassword),
-		Role:             "admin",
-		UserName:         "admin",
-		Status:           true,
-		MessageRateLimit: 1000, // Example rate limit
+	fixtures := &seed.Fixtures{
+		Users: []seed.User{
+			{Email: email, UserName: "admin", Password: pw, Role: "admin", MessageRateLimit: 1000},
+		},
+		// Default providers, existing ones are kept
+		Providers: []seed.Provider{
+			{Name: "Signal", Type: "signal", Description: "Signal is a free and open-source messaging app for Android and iOS."},
+			{Name: "Teams", Type: "teams", Description: "Microsoft Teams is a collaboration app that helps your team stay organized and has conversations all in one place."},
+			{Name: "Sms", Type: "sms", Description: "SMS is a text messaging service component of most telephone, internet, and mobile device systems."},
+			{Name: "Email", Type: "email", Description: "Email is a method of exchanging digital messages between people using electronic devices."},
+		},
+		UserProviders: []seed.UserProvider{
+			{User: email, Provider: "Signal", Priority: 1},
+		},
 	}
-
-	err = r.DB.Create(&newUser).Error
-	if err != nil {
+	if err := seed.Apply(r.DB, fixtures, r.Logger); err != nil {
 		r.Logger.Error("Error creating initial user", zap.Error(err))
 		return err
 	}
 
 	r.Logger.Info("Initial user created successfully", zap.String("email", email))
+	return nil
+}
 
-	// Create default providers for the initial user
-	defaultProviders := []provider.Provider{
-		{Name: "Signal", Type: "signal", Status: true, Description: "Signal is a free and open-source messaging app for Android and iOS."},
-		{Name: "Teams", Type: "teams", Status: true, Description: "Microsoft Teams is a collaboration app that helps your team stay organized and has conversations all in one place."},
-		{Name: "Sms", Type: "sms", Status: true, Description: "SMS is a text messaging service component of most telephone, internet, and mobile device systems."},
-		{Name: "Email", Type: "email", Status: true, Description: "Email is a method of exchanging digital messages between people using electronic devices."},
-	}
-
-	for _, providerData := range defaultProviders {
-		var (
-			// Check if the provider already exists
-			existingProvider provider.Provider
-		)
-		err = r.DB.Where("name = ?", providerData.Name).First(&existingProvider).Error
-		err = r.DB.Create(&providerData).Error
-		if err != nil {
-			r.Logger.Error("Error creating default provider", zap.Error(err), zap.String("provider", providerData.Name))
-			return err
-		}
-		r.Logger.Info("Default provider created successfully", zap.String("provider", providerData.Name))
-	}
-
-	var signalProvider provider.Provider
-	// Check if Signal provider exists
-	err = r.DB.Where("name = ?", "Signal").First(&signalProvider).Error
+// SeedFixtures applies the demo dataset and the seed file of SEED_DEMO and SEED_FILE, creating the users,
+// providers and user providers that don't exist yet
+func (r *MySQLRepository) SeedFixtures() error {
+	config, err := seed.LoadConfig()
 	if err != nil {
-		r.Logger.Error("Signal provider not found", zap.Error(err))
 		return err
 	}
-
-	// Create user-provider associations
-	userProvider := provider.UserProvider{
-		UserID:     newUser.ID,
-		ProviderID: signalProvider.ID,
-		Status:     true,
-		Priority:   1, // Default priority
-	}
-	err = r.DB.Create(&userProvider).Error
+	all, err := config.Load()
 	if err != nil {
-		r.Logger.Error("Error creating user-provider association", zap.Error(err), zap.String("provider", signalProvider.Name))
 		return err
 	}
+	for _, fixtures := range all {
+		if err := seed.Apply(r.DB, fixtures, r.Logger); err != nil {
+			return err
+		}
+	}
+	if len(all) > 0 {
+		r.Logger.Info("Seed fixtures applied", zap.String("file", config.File), zap.Bool("demo", config.Demo))
+	}
 	return nil
 }
 
@@ -270,3 +348,17 @@ func InitMySQLDB(loggerInstance *logger.Logger) (*gorm.DB, error) {
 
 	return repo.DB, nil
 }
+
+// ConnectMySQLDB opens the database connection without migrating or seeding it, e.g. to validate the configuration
+func ConnectMySQLDB(loggerInstance *logger.Logger) (*gorm.DB, error) {
+	repo := &MySQLRepository{
+		Logger: loggerInstance,
+	}
+
+	err := repo.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.DB, nil
+}
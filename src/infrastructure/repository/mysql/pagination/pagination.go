@@ -0,0 +1,30 @@
+package pagination
+
+import (
+	"go-multi-chat-api/src/domain"
+
+	"gorm.io/gorm"
+)
+
+// Apply orders a query newest first by timeColumn and id and restricts it to the page after the cursor of the
+// request. It fetches one row more than the limit, Cut uses it to tell whether another page follows. The
+// columns are compared as a pair, so rows sharing a timestamp are neither skipped nor repeated; an index
+// starting with the filter columns and ending with timeColumn keeps deep pages as cheap as the first one.
+func Apply(query *gorm.DB, timeColumn string, request domain.PageRequest) *gorm.DB {
+	if request.After != nil {
+		query = query.Where(timeColumn+" < ? OR ("+timeColumn+" = ? AND id < ?)",
+			request.After.Time, request.After.Time, request.After.ID)
+	}
+	return query.Order(timeColumn + " DESC, id DESC").Limit(request.Limit + 1)
+}
+
+// Cut drops the extra row fetched by Apply and returns the cursor of the next page, nil when the rows are the
+// last page
+func Cut[T any](rows []T, limit int, cursorOf func(row *T) domain.Cursor) ([]T, *domain.Cursor) {
+	if len(rows) <= limit {
+		return rows, nil
+	}
+	rows = rows[:limit]
+	next := cursorOf(&rows[limit-1])
+	return rows, &next
+}
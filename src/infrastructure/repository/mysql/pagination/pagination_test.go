@@ -0,0 +1,55 @@
+package pagination
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type row struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+func cursorOf(r *row) domain.Cursor {
+	return domain.Cursor{Time: r.CreatedAt, ID: r.ID}
+}
+
+func TestCut(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rows := []row{{ID: 3, CreatedAt: now}, {ID: 2, CreatedAt: now}, {ID: 1, CreatedAt: now.Add(-time.Minute)}}
+
+	page, next := Cut(rows, 2, cursorOf)
+	assert.Equal(t, rows[:2], page)
+	assert.Equal(t, &domain.Cursor{Time: now, ID: 2}, next)
+
+	page, next = Cut(rows, 3, cursorOf)
+	assert.Len(t, page, 3)
+	assert.Nil(t, next)
+}
+
+func TestApply(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	require.NoError(t, err)
+
+	after := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `rows` WHERE user_id = ? AND (created_at < ? OR (created_at = ? AND id < ?)) ORDER BY created_at DESC, id DESC LIMIT ?")).
+		WithArgs(7, after, after, 42, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	var rows []row
+	query := Apply(db.Table("rows").Where("user_id = ?", 7), "created_at", domain.PageRequest{Limit: 2, After: &domain.Cursor{Time: after, ID: 42}})
+	require.NoError(t, query.Find(&rows).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+// PartitionConfig controls the monthly partitioning of the message tables
+type PartitionConfig struct {
+	Enabled         bool
+	MonthsAhead     int // partitions kept ready after the current month
+	RetentionMonths int // months of messages kept by the purge job besides the current one, 0 keeps every message
+}
+
+// LoadPartitionConfig loads the partitioning settings from environment variables
+func LoadPartitionConfig() (PartitionConfig, error) {
+	monthsAhead, err := utils.GetIntEnv("DB_PARTITIONS_AHEAD_MONTHS", 3)
+	if err != nil || monthsAhead < 1 {
+		return PartitionConfig{}, fmt.Errorf("invalid DB_PARTITIONS_AHEAD_MONTHS: must be at least 1")
+	}
+	retentionMonths, err := utils.GetIntEnv("MESSAGE_RETENTION_MONTHS", 0)
+	if err != nil || retentionMonths < 0 {
+		return PartitionConfig{}, fmt.Errorf("invalid MESSAGE_RETENTION_MONTHS: must not be negative")
+	}
+	return PartitionConfig{
+		Enabled:         utils.GetEnv("DB_PARTITIONING_ENABLED", "false") == "true",
+		MonthsAhead:     monthsAhead,
+		RetentionMonths: retentionMonths,
+	}, nil
+}
+
+// MigratePartitions partitions the message tables by month when partitioning is enabled. Tables that are
+// partitioned already are left alone, the purge job adds the partitions of the coming months.
+func (r *MySQLRepository) MigratePartitions() error {
+	config, err := LoadPartitionConfig()
+	if err != nil || !config.Enabled {
+		return err
+	}
+
+	repository := provider.NewPartitionRepository(r.DB, r.Logger)
+	now := time.Now()
+	for _, table := range provider.PartitionedTables {
+		if err := repository.Partition(table, now, now.AddDate(0, config.MonthsAhead, 0)); err != nil {
+			r.Logger.Error("Error partitioning message table", zap.Error(err), zap.String("table", table))
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Statuses of attachments
+const (
+	AttachmentStatusUploading = "uploading"
+	AttachmentStatusComplete  = "complete"
+)
+
+// Attachment is the database model for the attachments uploaded in parts, their content is kept in the
+// attachment store
+type Attachment struct {
+	ID          int        `gorm:"primaryKey"`
+	UserID      int        `gorm:"column:user_id;index"`
+	Filename    string     `gorm:"column:filename;type:varchar(255)"`
+	MimeType    string     `gorm:"column:mime_type;type:varchar(127)"`
+	Size        int64      `gorm:"column:size"`
+	ChunkSize   int64      `gorm:"column:chunk_size"`
+	SHA256      string     `gorm:"column:sha256;type:varchar(64)"`
+	Status      string     `gorm:"column:status;type:varchar(16)"`
+	ExpiresAt   time.Time  `gorm:"column:expires_at;index"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// AttachmentRepositoryInterface defines the interface for attachment operations
+type AttachmentRepositoryInterface interface {
+	Create(attachment *domainProvider.Attachment) (*domainProvider.Attachment, error)
+	GetByID(id int) (*domainProvider.Attachment, error)
+	// Complete marks an uploading attachment complete with its digest and new expiry, it returns false when the
+	// attachment isn't uploading anymore
+	Complete(id int, sha256 string, expiresAt time.Time) (bool, error)
+	Delete(id int) error
+	// GetExpired returns up to limit attachments that expired before a time
+	GetExpired(before time.Time, limit int) ([]domainProvider.Attachment, error)
+	// ExpireCreatedBefore lets the attachments created before a time expire at expiresAt unless they expire
+	// earlier, the pruner removes them then
+	ExpireCreatedBefore(before time.Time, expiresAt time.Time) (int64, error)
+}
+
+type AttachmentRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewAttachmentRepository(db *gorm.DB, loggerInstance *logger.Logger) AttachmentRepositoryInterface {
+	return &AttachmentRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *AttachmentRepository) Create(attachmentDomain *domainProvider.Attachment) (*domainProvider.Attachment, error) {
+	attachment := attachmentFromDomainMapper(attachmentDomain)
+	if err := r.DB.Create(attachment).Error; err != nil {
+		r.Logger.Error("Error creating attachment", zap.Error(err), zap.Int("userID", attachmentDomain.UserID))
+		return &domainProvider.Attachment{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return attachment.toDomainMapper(), nil
+}
+
+func (r *AttachmentRepository) GetByID(id int) (*domainProvider.Attachment, error) {
+	var attachment Attachment
+	err := r.DB.Where("id = ?", id).First(&attachment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting attachment", zap.Error(err), zap.Int("id", id))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.Attachment{}, err
+	}
+	return attachment.toDomainMapper(), nil
+}
+
+func (r *AttachmentRepository) Complete(id int, sha256 string, expiresAt time.Time) (bool, error) {
+	result := r.DB.Model(&Attachment{}).
+		Where("id = ? AND status = ?", id, AttachmentStatusUploading).
+		Updates(map[string]interface{}{
+			"status":       AttachmentStatusComplete,
+			"sha256":       sha256,
+			"expires_at":   expiresAt,
+			"completed_at": time.Now(),
+		})
+	if result.Error != nil {
+		r.Logger.Error("Error completing attachment", zap.Error(result.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *AttachmentRepository) Delete(id int) error {
+	if err := r.DB.Delete(&Attachment{}, id).Error; err != nil {
+		r.Logger.Error("Error deleting attachment", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *AttachmentRepository) ExpireCreatedBefore(before time.Time, expiresAt time.Time) (int64, error) {
+	result := r.DB.Model(&Attachment{}).
+		Where("created_at < ? AND expires_at > ?", before, expiresAt).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		r.Logger.Error("Error expiring attachments", zap.Error(result.Error), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *AttachmentRepository) GetExpired(before time.Time, limit int) ([]domainProvider.Attachment, error) {
+	var attachments []Attachment
+	if err := r.DB.Where("expires_at < ?", before).Order("expires_at ASC").Limit(limit).Find(&attachments).Error; err != nil {
+		r.Logger.Error("Error getting expired attachments", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		result[i] = *attachment.toDomainMapper()
+	}
+	return result, nil
+}
+
+// Mappers
+func (a *Attachment) toDomainMapper() *domainProvider.Attachment {
+	return &domainProvider.Attachment{
+		ID:          a.ID,
+		UserID:      a.UserID,
+		Filename:    a.Filename,
+		MimeType:    a.MimeType,
+		Size:        a.Size,
+		ChunkSize:   a.ChunkSize,
+		SHA256:      a.SHA256,
+		Status:      a.Status,
+		ExpiresAt:   a.ExpiresAt,
+		CompletedAt: a.CompletedAt,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
+
+func attachmentFromDomainMapper(a *domainProvider.Attachment) *Attachment {
+	return &Attachment{
+		ID:          a.ID,
+		UserID:      a.UserID,
+		Filename:    a.Filename,
+		MimeType:    a.MimeType,
+		Size:        a.Size,
+		ChunkSize:   a.ChunkSize,
+		SHA256:      a.SHA256,
+		Status:      a.Status,
+		ExpiresAt:   a.ExpiresAt,
+		CompletedAt: a.CompletedAt,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
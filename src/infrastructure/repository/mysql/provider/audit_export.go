@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AuditExportBatch is the database model for the batches of audit records exported to the SIEM target
+type AuditExportBatch struct {
+	ID           int       `gorm:"primaryKey"`
+	Source       string    `gorm:"column:source;type:varchar(32);uniqueIndex:idx_audit_export_source_last"`
+	Format       string    `gorm:"column:format;type:varchar(8)"`
+	Target       string    `gorm:"column:target;type:varchar(8)"`
+	FirstID      int       `gorm:"column:first_id"`
+	LastID       int       `gorm:"column:last_id;uniqueIndex:idx_audit_export_source_last"`
+	Records      int       `gorm:"column:records"`
+	Location     string    `gorm:"column:location;type:text"`
+	Hash         string    `gorm:"column:hash;type:char(64)"`
+	PreviousHash string    `gorm:"column:previous_hash;type:varchar(64)"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:mili;index"`
+}
+
+func (AuditExportBatch) TableName() string {
+	return "audit_export_batches"
+}
+
+// AuditExportBatchRepositoryInterface defines the interface for the exported batches of audit records
+type AuditExportBatchRepositoryInterface interface {
+	Create(batch *domainProvider.AuditExportBatch) (*domainProvider.AuditExportBatch, error)
+	// GetCheckpoint returns the last exported batch of a source, nil when the source wasn't exported yet
+	GetCheckpoint(source string) (*domainProvider.AuditExportBatch, error)
+	// GetRecent returns the most recent batches of every source, newest first
+	GetRecent(limit int) ([]domainProvider.AuditExportBatch, error)
+}
+
+type AuditExportBatchRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewAuditExportBatchRepository(db *gorm.DB, loggerInstance *logger.Logger) AuditExportBatchRepositoryInterface {
+	return &AuditExportBatchRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *AuditExportBatchRepository) Create(batchDomain *domainProvider.AuditExportBatch) (*domainProvider.AuditExportBatch, error) {
+	batch := auditExportBatchFromDomainMapper(batchDomain)
+	if err := r.DB.Create(batch).Error; err != nil {
+		r.Logger.Error("Error creating audit export batch", zap.Error(err), zap.String("source", batchDomain.Source))
+		return &domainProvider.AuditExportBatch{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return batch.toDomainMapper(), nil
+}
+
+func (r *AuditExportBatchRepository) GetCheckpoint(source string) (*domainProvider.AuditExportBatch, error) {
+	var batch AuditExportBatch
+	err := r.DB.Where("source = ?", source).Order("last_id DESC").First(&batch).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error getting audit export checkpoint", zap.Error(err), zap.String("source", source))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return batch.toDomainMapper(), nil
+}
+
+func (r *AuditExportBatchRepository) GetRecent(limit int) ([]domainProvider.AuditExportBatch, error) {
+	var batches []AuditExportBatch
+	if err := r.DB.Order("id DESC").Limit(limit).Find(&batches).Error; err != nil {
+		r.Logger.Error("Error getting audit export batches", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.AuditExportBatch, len(batches))
+	for i, batch := range batches {
+		result[i] = *batch.toDomainMapper()
+	}
+	return result, nil
+}
+
+// Mappers
+func (b *AuditExportBatch) toDomainMapper() *domainProvider.AuditExportBatch {
+	return &domainProvider.AuditExportBatch{
+		ID:           b.ID,
+		Source:       b.Source,
+		Format:       b.Format,
+		Target:       b.Target,
+		FirstID:      b.FirstID,
+		LastID:       b.LastID,
+		Records:      b.Records,
+		Location:     b.Location,
+		Hash:         b.Hash,
+		PreviousHash: b.PreviousHash,
+		CreatedAt:    b.CreatedAt,
+	}
+}
+
+func auditExportBatchFromDomainMapper(b *domainProvider.AuditExportBatch) *AuditExportBatch {
+	return &AuditExportBatch{
+		ID:           b.ID,
+		Source:       b.Source,
+		Format:       b.Format,
+		Target:       b.Target,
+		FirstID:      b.FirstID,
+		LastID:       b.LastID,
+		Records:      b.Records,
+		Location:     b.Location,
+		Hash:         b.Hash,
+		PreviousHash: b.PreviousHash,
+		CreatedAt:    b.CreatedAt,
+	}
+}
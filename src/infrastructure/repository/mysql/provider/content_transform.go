@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ContentTransform is the database model for the content transformations of users
+type ContentTransform struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;index:idx_content_transforms_user_position"`
+	Position  int       `gorm:"column:position;index:idx_content_transforms_user_position"`
+	Type      string    `gorm:"column:type;size:32"`
+	Config    string    `gorm:"column:config;type:text"`
+	Disabled  bool      `gorm:"column:disabled;default:false"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (ContentTransform) TableName() string {
+	return "content_transforms"
+}
+
+// ContentTransformRepositoryInterface defines the interface for content transformation operations
+type ContentTransformRepositoryInterface interface {
+	// GetByUserID returns the content transformations of a user in the order they are applied
+	GetByUserID(userID int) (*[]domainProvider.ContentTransform, error)
+	// ReplaceForUser replaces the content transformations of a user with the given ones, positioned in their order
+	ReplaceForUser(userID int, transforms []domainProvider.ContentTransform) (*[]domainProvider.ContentTransform, error)
+}
+
+type ContentTransformRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewContentTransformRepository(db *gorm.DB, loggerInstance *logger.Logger) ContentTransformRepositoryInterface {
+	return &ContentTransformRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ContentTransformRepository) GetByUserID(userID int) (*[]domainProvider.ContentTransform, error) {
+	var transforms []ContentTransform
+	if err := r.DB.Where("user_id = ?", userID).Order("position").Find(&transforms).Error; err != nil {
+		r.Logger.Error("Error getting content transforms", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.ContentTransform, len(transforms))
+	for i := range transforms {
+		result[i] = *transforms[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *ContentTransformRepository) ReplaceForUser(userID int, transformsDomain []domainProvider.ContentTransform) (*[]domainProvider.ContentTransform, error) {
+	transforms := make([]ContentTransform, len(transformsDomain))
+	for i := range transformsDomain {
+		transforms[i] = *contentTransformFromDomainMapper(&transformsDomain[i])
+		transforms[i].ID, transforms[i].UserID, transforms[i].Position = 0, userID, i+1
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&ContentTransform{}).Error; err != nil {
+			return err
+		}
+		if len(transforms) == 0 {
+			return nil
+		}
+		return tx.Create(&transforms).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error replacing content transforms", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.ContentTransform, len(transforms))
+	for i := range transforms {
+		result[i] = *transforms[i].toDomainMapper()
+	}
+	r.Logger.Info("Successfully replaced content transforms", zap.Int("userID", userID), zap.Int("count", len(transforms)))
+	return &result, nil
+}
+
+// Mappers
+func (t *ContentTransform) toDomainMapper() *domainProvider.ContentTransform {
+	return &domainProvider.ContentTransform{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Position:  t.Position,
+		Type:      t.Type,
+		Config:    t.Config,
+		Disabled:  t.Disabled,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func contentTransformFromDomainMapper(t *domainProvider.ContentTransform) *ContentTransform {
+	return &ContentTransform{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Position:  t.Position,
+		Type:      t.Type,
+		Config:    t.Config,
+		Disabled:  t.Disabled,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
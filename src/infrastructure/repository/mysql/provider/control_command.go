@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ControlCommand is the database model for the audit log of control commands
+type ControlCommand struct {
+	ID         int       `gorm:"primaryKey"`
+	Sender     string    `gorm:"column:sender;type:varchar(32);index"`
+	SenderUUID string    `gorm:"column:sender_uuid;type:varchar(36)"`
+	Text       string    `gorm:"column:text;type:text"`
+	Command    string    `gorm:"column:command;type:varchar(32)"`
+	Status     string    `gorm:"column:status;type:varchar(16)"`
+	Result     string    `gorm:"column:result;type:text"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:mili;index"`
+}
+
+func (ControlCommand) TableName() string {
+	return "control_commands"
+}
+
+// ControlCommandRepositoryInterface defines the interface for the control command audit log
+type ControlCommandRepositoryInterface interface {
+	Create(command *domainProvider.ControlCommand) (*domainProvider.ControlCommand, error)
+	// GetAll retrieves a page of the control commands, newest first
+	GetAll(page domain.PageRequest) (*domain.Page[domainProvider.ControlCommand], error)
+	// GetAfter returns up to limit control commands with an ID above afterID, oldest first
+	GetAfter(afterID int, limit int) ([]domainProvider.ControlCommand, error)
+}
+
+type ControlCommandRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewControlCommandRepository(db *gorm.DB, loggerInstance *logger.Logger) ControlCommandRepositoryInterface {
+	return &ControlCommandRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ControlCommandRepository) Create(commandDomain *domainProvider.ControlCommand) (*domainProvider.ControlCommand, error) {
+	command := controlCommandFromDomainMapper(commandDomain)
+	if err := r.DB.Create(command).Error; err != nil {
+		r.Logger.Error("Error creating control command", zap.Error(err), zap.String("sender", commandDomain.Sender))
+		return &domainProvider.ControlCommand{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return command.toDomainMapper(), nil
+}
+
+func (r *ControlCommandRepository) GetAll(page domain.PageRequest) (*domain.Page[domainProvider.ControlCommand], error) {
+	var commands []ControlCommand
+	if err := pagination.Apply(r.DB, "created_at", page).Find(&commands).Error; err != nil {
+		r.Logger.Error("Error getting control commands", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	commands, next := pagination.Cut(commands, page.Limit, func(command *ControlCommand) domain.Cursor {
+		return domain.Cursor{Time: command.CreatedAt, ID: command.ID}
+	})
+	result := make([]domainProvider.ControlCommand, len(commands))
+	for i, command := range commands {
+		result[i] = *command.toDomainMapper()
+	}
+	return &domain.Page[domainProvider.ControlCommand]{Items: result, Next: next}, nil
+}
+
+func (r *ControlCommandRepository) GetAfter(afterID int, limit int) ([]domainProvider.ControlCommand, error) {
+	var commands []ControlCommand
+	if err := r.DB.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&commands).Error; err != nil {
+		r.Logger.Error("Error getting control commands", zap.Error(err), zap.Int("afterID", afterID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.ControlCommand, len(commands))
+	for i, command := range commands {
+		result[i] = *command.toDomainMapper()
+	}
+	return result, nil
+}
+
+// Mappers
+func (c *ControlCommand) toDomainMapper() *domainProvider.ControlCommand {
+	return &domainProvider.ControlCommand{
+		ID:         c.ID,
+		Sender:     c.Sender,
+		SenderUUID: c.SenderUUID,
+		Text:       c.Text,
+		Command:    c.Command,
+		Status:     c.Status,
+		Result:     c.Result,
+		CreatedAt:  c.CreatedAt,
+	}
+}
+
+func controlCommandFromDomainMapper(c *domainProvider.ControlCommand) *ControlCommand {
+	return &ControlCommand{
+		ID:         c.ID,
+		Sender:     c.Sender,
+		SenderUUID: c.SenderUUID,
+		Text:       c.Text,
+		Command:    c.Command,
+		Status:     c.Status,
+		Result:     c.Result,
+		CreatedAt:  c.CreatedAt,
+	}
+}
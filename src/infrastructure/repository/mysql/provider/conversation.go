@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lastMessagePreviewLength is the number of characters of the latest message kept with a conversation
+const lastMessagePreviewLength = 200
+
+// Conversation is the database model for the conversation read model
+type Conversation struct {
+	ID             int       `gorm:"primaryKey"`
+	UserID         int       `gorm:"column:user_id;uniqueIndex:idx_conversation_key,priority:1;index:idx_conversation_user_activity,priority:1"`
+	Channel        string    `gorm:"column:channel;type:varchar(32);uniqueIndex:idx_conversation_key,priority:2;index:idx_conversation_participant,priority:1"`
+	Participant    string    `gorm:"column:participant;type:varchar(191);uniqueIndex:idx_conversation_key,priority:3;index:idx_conversation_participant,priority:2"`
+	LastMessage    string    `gorm:"column:last_message;type:text"`
+	LastDirection  string    `gorm:"column:last_direction;type:varchar(16)"`
+	LastStatus     string    `gorm:"column:last_status;type:varchar(32)"`
+	MessageCount   int       `gorm:"column:message_count;default:0"`
+	InboundCount   int       `gorm:"column:inbound_count;default:0"`
+	LastActivityAt time.Time `gorm:"column:last_activity_at;index:idx_conversation_user_activity,priority:2"`
+	CreatedAt      time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// ConversationMessage is the database model for the messages of the conversation read model. A message is stored
+// once per conversation, keyed by its message transaction when sent and by its vendor id when received.
+type ConversationMessage struct {
+	ID             int       `gorm:"primaryKey"`
+	ConversationID int       `gorm:"column:conversation_id;uniqueIndex:idx_conversation_message_key,priority:1;index:idx_conversation_message_time,priority:1"`
+	Direction      string    `gorm:"column:direction;type:varchar(16);uniqueIndex:idx_conversation_message_key,priority:2"`
+	MessageID      int       `gorm:"column:message_id;uniqueIndex:idx_conversation_message_key,priority:3"`
+	ExternalID     string    `gorm:"column:external_id;type:varchar(191);uniqueIndex:idx_conversation_message_key,priority:4"`
+	Body           string    `gorm:"column:body;type:text"`
+	Status         string    `gorm:"column:status;type:varchar(32)"`
+	OccurredAt     time.Time `gorm:"column:occurred_at;index:idx_conversation_message_time,priority:2"`
+}
+
+func (ConversationMessage) TableName() string {
+	return "conversation_messages"
+}
+
+// ConversationRepositoryInterface defines the interface for the conversation read model
+type ConversationRepositoryInterface interface {
+	// Apply projects a message event into the conversations of its participants. Applying an event again only
+	// updates the status of the message, so events can be replayed.
+	Apply(event *domainProvider.MessageEvent) error
+	GetUserConversations(userID int, channel string, limit int) (*[]domainProvider.Conversation, error)
+	GetByID(id int) (*domainProvider.Conversation, error)
+	// GetMessages returns a page of the messages of a conversation, newest first
+	GetMessages(conversationID int, page domain.PageRequest) (*domain.Page[domainProvider.ConversationMessage], error)
+}
+
+type ConversationRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewConversationRepository(db *gorm.DB, loggerInstance *logger.Logger) ConversationRepositoryInterface {
+	return &ConversationRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ConversationRepository) Apply(event *domainProvider.MessageEvent) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		conversations, err := conversationsOfEvent(tx, event)
+		if err != nil {
+			return err
+		}
+		for _, conversation := range conversations {
+			if err := applyToConversation(tx, conversation.ID, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Logger.Error("Error projecting message event", zap.Error(err), zap.Int("userID", event.UserID),
+			zap.String("direction", event.Direction), zap.Int("messageID", event.MessageID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// conversationsOfEvent returns the conversations a message belongs to. Messages of a user open the conversations
+// that don't exist yet, messages received on a shared account only join the conversations users already have with
+// the sender.
+func conversationsOfEvent(tx *gorm.DB, event *domainProvider.MessageEvent) ([]Conversation, error) {
+	var conversations []Conversation
+	if len(event.Participants) == 0 {
+		return conversations, nil
+	}
+	if event.UserID == 0 {
+		err := tx.Where("channel = ? AND participant IN ?", event.Channel, event.Participants).Find(&conversations).Error
+		return conversations, err
+	}
+
+	for _, participant := range event.Participants {
+		conversation := Conversation{UserID: event.UserID, Channel: event.Channel, Participant: participant, LastActivityAt: event.OccurredAt}
+		// Another instance may open the same conversation concurrently, the unique key keeps one
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&conversation).Error; err != nil {
+			return nil, err
+		}
+		err := tx.Where("user_id = ? AND channel = ? AND participant = ?", event.UserID, event.Channel, participant).
+			First(&conversation).Error
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations, nil
+}
+
+// applyToConversation adds a message to a conversation, or updates its status when it was added before, and makes
+// it the latest message of the conversation unless a newer one is there
+func applyToConversation(tx *gorm.DB, conversationID int, event *domainProvider.MessageEvent) error {
+	message := ConversationMessage{
+		ConversationID: conversationID,
+		Direction:      event.Direction,
+		MessageID:      event.MessageID,
+		ExternalID:     event.ExternalID,
+		Body:           event.Body,
+		Status:         event.Status,
+		OccurredAt:     event.OccurredAt,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&message)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		counts := map[string]interface{}{"message_count": gorm.Expr("message_count + 1")}
+		if event.Direction == domainProvider.DirectionInbound {
+			counts["inbound_count"] = gorm.Expr("inbound_count + 1")
+		}
+		if err := tx.Model(&Conversation{}).Where("id = ?", conversationID).Updates(counts).Error; err != nil {
+			return err
+		}
+	} else if event.Direction == domainProvider.DirectionOutbound {
+		err := tx.Model(&ConversationMessage{}).
+			Where("conversation_id = ? AND direction = ? AND message_id = ?", conversationID, event.Direction, event.MessageID).
+			Update("status", event.Status).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&Conversation{}).Where("id = ? AND last_activity_at <= ?", conversationID, event.OccurredAt).
+		Updates(map[string]interface{}{
+			"last_message":     preview(event.Body),
+			"last_direction":   event.Direction,
+			"last_status":      event.Status,
+			"last_activity_at": event.OccurredAt,
+		}).Error
+}
+
+func preview(body string) string {
+	runes := []rune(body)
+	if len(runes) > lastMessagePreviewLength {
+		return string(runes[:lastMessagePreviewLength])
+	}
+	return body
+}
+
+// GetUserConversations returns the conversations of a user with the most recent activity first, of one channel
+// when channel is set
+func (r *ConversationRepository) GetUserConversations(userID int, channel string, limit int) (*[]domainProvider.Conversation, error) {
+	query := r.DB.Where("user_id = ?", userID)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	var conversations []Conversation
+	if err := query.Order("last_activity_at DESC, id DESC").Limit(limit).Find(&conversations).Error; err != nil {
+		r.Logger.Error("Error getting user conversations", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.Conversation, len(conversations))
+	for i := range conversations {
+		result[i] = *conversations[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *ConversationRepository) GetByID(id int) (*domainProvider.Conversation, error) {
+	var conversation Conversation
+	if err := r.DB.Where("id = ?", id).First(&conversation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &domainProvider.Conversation{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting conversation", zap.Error(err), zap.Int("id", id))
+		return &domainProvider.Conversation{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return conversation.toDomainMapper(), nil
+}
+
+func (r *ConversationRepository) GetMessages(conversationID int, page domain.PageRequest) (*domain.Page[domainProvider.ConversationMessage], error) {
+	var messages []ConversationMessage
+	err := pagination.Apply(r.DB.Where("conversation_id = ?", conversationID), "occurred_at", page).Find(&messages).Error
+	if err != nil {
+		r.Logger.Error("Error getting conversation messages", zap.Error(err), zap.Int("conversationID", conversationID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	messages, next := pagination.Cut(messages, page.Limit, func(message *ConversationMessage) domain.Cursor {
+		return domain.Cursor{Time: message.OccurredAt, ID: message.ID}
+	})
+	result := make([]domainProvider.ConversationMessage, len(messages))
+	for i, message := range messages {
+		result[i] = domainProvider.ConversationMessage{
+			ID:             message.ID,
+			ConversationID: message.ConversationID,
+			Direction:      message.Direction,
+			MessageID:      message.MessageID,
+			ExternalID:     message.ExternalID,
+			Body:           message.Body,
+			Status:         message.Status,
+			OccurredAt:     message.OccurredAt,
+		}
+	}
+	return &domain.Page[domainProvider.ConversationMessage]{Items: result, Next: next}, nil
+}
+
+// Mappers
+func (c *Conversation) toDomainMapper() *domainProvider.Conversation {
+	return &domainProvider.Conversation{
+		ID:             c.ID,
+		UserID:         c.UserID,
+		Channel:        c.Channel,
+		Participant:    c.Participant,
+		LastMessage:    c.LastMessage,
+		LastDirection:  c.LastDirection,
+		LastStatus:     c.LastStatus,
+		MessageCount:   c.MessageCount,
+		InboundCount:   c.InboundCount,
+		LastActivityAt: c.LastActivityAt,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+}
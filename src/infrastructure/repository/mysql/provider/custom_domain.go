@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"errors"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CustomDomain is the database model for the custom domain of a user, each user has at most one
+type CustomDomain struct {
+	ID                int        `gorm:"primaryKey"`
+	UserID            int        `gorm:"column:user_id;uniqueIndex"`
+	Domain            string     `gorm:"column:domain;size:253;uniqueIndex"`
+	VerificationToken string     `gorm:"column:verification_token;size:64"`
+	VerifiedAt        *time.Time `gorm:"column:verified_at"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (CustomDomain) TableName() string {
+	return "custom_domains"
+}
+
+// CustomDomainRepositoryInterface defines the interface for the custom domains of users
+type CustomDomainRepositoryInterface interface {
+	// GetByUserID returns the custom domain of a user, NotFound if the user has none
+	GetByUserID(userID int) (*domainProvider.CustomDomain, error)
+	// GetByDomain returns the custom domain of any user by its name, NotFound if no user has it
+	GetByDomain(domain string) (*domainProvider.CustomDomain, error)
+	// Save sets the custom domain of a user, replacing the previous domain and its verification
+	Save(customDomain *domainProvider.CustomDomain) (*domainProvider.CustomDomain, error)
+	// MarkVerified records that the domain of a user was verified
+	MarkVerified(userID int, verifiedAt time.Time) error
+	// Delete removes the custom domain of a user, NotFound if the user has none
+	Delete(userID int) error
+}
+
+type CustomDomainRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewCustomDomainRepository(db *gorm.DB, loggerInstance *logger.Logger) CustomDomainRepositoryInterface {
+	return &CustomDomainRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *CustomDomainRepository) GetByUserID(userID int) (*domainProvider.CustomDomain, error) {
+	var customDomain CustomDomain
+	err := r.DB.Where("user_id = ?", userID).First(&customDomain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting custom domain", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return customDomain.toDomainMapper(), nil
+}
+
+func (r *CustomDomainRepository) GetByDomain(domain string) (*domainProvider.CustomDomain, error) {
+	var customDomain CustomDomain
+	err := r.DB.Where("domain = ?", domain).First(&customDomain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting custom domain by name", zap.Error(err), zap.String("domain", domain))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return customDomain.toDomainMapper(), nil
+}
+
+func (r *CustomDomainRepository) Save(customDomainDomain *domainProvider.CustomDomain) (*domainProvider.CustomDomain, error) {
+	customDomain := customDomainFromDomainMapper(customDomainDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"domain", "verification_token", "verified_at", "updated_at"}),
+	}).Create(customDomain).Error
+	if err != nil {
+		r.Logger.Error("Error saving custom domain", zap.Error(err), zap.Int("userID", customDomainDomain.UserID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully saved custom domain", zap.Int("userID", customDomainDomain.UserID), zap.String("domain", customDomainDomain.Domain))
+	return r.GetByUserID(customDomainDomain.UserID)
+}
+
+func (r *CustomDomainRepository) MarkVerified(userID int, verifiedAt time.Time) error {
+	tx := r.DB.Model(&CustomDomain{}).Where("user_id = ?", userID).Update("verified_at", verifiedAt)
+	if tx.Error != nil {
+		r.Logger.Error("Error marking custom domain verified", zap.Error(tx.Error), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return nil
+}
+
+func (r *CustomDomainRepository) Delete(userID int) error {
+	tx := r.DB.Where("user_id = ?", userID).Delete(&CustomDomain{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting custom domain", zap.Error(tx.Error), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted custom domain", zap.Int("userID", userID))
+	return nil
+}
+
+// Mappers
+func (d *CustomDomain) toDomainMapper() *domainProvider.CustomDomain {
+	return &domainProvider.CustomDomain{
+		ID:                d.ID,
+		UserID:            d.UserID,
+		Domain:            d.Domain,
+		VerificationToken: d.VerificationToken,
+		VerifiedAt:        d.VerifiedAt,
+		CreatedAt:         d.CreatedAt,
+		UpdatedAt:         d.UpdatedAt,
+	}
+}
+
+func customDomainFromDomainMapper(d *domainProvider.CustomDomain) *CustomDomain {
+	return &CustomDomain{
+		ID:                d.ID,
+		UserID:            d.UserID,
+		Domain:            d.Domain,
+		VerificationToken: d.VerificationToken,
+		VerifiedAt:        d.VerifiedAt,
+	}
+}
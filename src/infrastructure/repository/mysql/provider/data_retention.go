@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// retentionTable describes a table the retention policies expire rows of
+type retentionTable struct {
+	timeColumn  string
+	bodyColumns []string // columns cleared when bodies are redacted, none for audit logs
+	keep        string   // condition of the rows kept regardless of their age
+}
+
+var retentionTables = map[string]retentionTable{
+	"message_transactions":        {timeColumn: "created_at", bodyColumns: []string{"message", "request_data", "response_data"}, keep: activeMessageCondition},
+	"message_transaction_history": {timeColumn: "created_at", bodyColumns: []string{"message", "request_data", "response_data"}},
+	"message_edits":               {timeColumn: "created_at", bodyColumns: []string{"previous_message", "message", "response_data"}},
+	"conversation_messages":       {timeColumn: "occurred_at", bodyColumns: []string{"body"}},
+	"login_events":                {timeColumn: "created_at"},
+	"control_commands":            {timeColumn: "created_at"},
+}
+
+// BodyTables are the tables holding message bodies, expired by the body retention
+var BodyTables = []string{"message_transactions", "message_transaction_history", "message_edits", "conversation_messages"}
+
+// AuditLogTables are the audit logs, expired by the audit log retention
+var AuditLogTables = []string{"login_events", "control_commands"}
+
+// DataRetentionRepositoryInterface defines the interface for expiring the rows of the body and audit log tables.
+// Messages still to be sent or acknowledged are never expired.
+type DataRetentionRepositoryInterface interface {
+	// RedactBodies clears the body columns of up to limit rows of a body table created before a time, keeping the
+	// rows. Rows cleared before aren't counted again.
+	RedactBodies(table string, before time.Time, limit int) (int64, error)
+	// DeleteBefore deletes up to limit rows of a body or audit log table created before a time
+	DeleteBefore(table string, before time.Time, limit int) (int64, error)
+}
+
+type DataRetentionRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewDataRetentionRepository(db *gorm.DB, loggerInstance *logger.Logger) DataRetentionRepositoryInterface {
+	return &DataRetentionRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *DataRetentionRepository) RedactBodies(table string, before time.Time, limit int) (int64, error) {
+	definition, ok := retentionTables[table]
+	if !ok || len(definition.bodyColumns) == 0 {
+		return 0, domainErrors.NewAppError(fmt.Errorf("table %s holds no message bodies", table), domainErrors.ValidationError)
+	}
+	assignments := make([]string, len(definition.bodyColumns))
+	filled := make([]string, len(definition.bodyColumns))
+	for i, column := range definition.bodyColumns {
+		assignments[i] = "`" + column + "` = ''"
+		filled[i] = "`" + column + "` <> ''"
+	}
+	condition := definition.condition() + " AND (" + strings.Join(filled, " OR ") + ")"
+	tx := r.DB.Exec("UPDATE `"+table+"` SET "+strings.Join(assignments, ", ")+" WHERE "+condition+" LIMIT ?", before, limit)
+	if tx.Error != nil {
+		r.Logger.Error("Error redacting message bodies", zap.Error(tx.Error), zap.String("table", table), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected, nil
+}
+
+func (r *DataRetentionRepository) DeleteBefore(table string, before time.Time, limit int) (int64, error) {
+	definition, ok := retentionTables[table]
+	if !ok {
+		return 0, domainErrors.NewAppError(fmt.Errorf("table %s has no retention", table), domainErrors.ValidationError)
+	}
+	tx := r.DB.Exec("DELETE FROM `"+table+"` WHERE "+definition.condition()+" LIMIT ?", before, limit)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting expired rows", zap.Error(tx.Error), zap.String("table", table), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected, nil
+}
+
+// condition selects the expired rows, created before the time bound to it
+func (t retentionTable) condition() string {
+	condition := "`" + t.timeColumn + "` < ?"
+	if t.keep != "" {
+		condition += " AND NOT " + t.keep
+	}
+	return condition
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupDataRetentionRepository(t *testing.T) (DataRetentionRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return NewDataRetentionRepository(gormDB, &logger.Logger{Log: zap.NewNop()}), mock
+}
+
+func TestDataRetention_RedactBodies(t *testing.T) {
+	repository, mock := setupDataRetentionRepository(t)
+	before := time.Date(2026, time.July, 18, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_transactions` SET `message` = '', `request_data` = '', `response_data` = '' "+
+		"WHERE `created_at` < ? AND NOT "+activeMessageCondition+" AND (`message` <> '' OR `request_data` <> '' OR `response_data` <> '') LIMIT ?")).
+		WithArgs(before, 1000).WillReturnResult(sqlmock.NewResult(0, 12))
+	redacted, err := repository.RedactBodies("message_transactions", before, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), redacted)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `conversation_messages` SET `body` = '' WHERE `occurred_at` < ? AND (`body` <> '') LIMIT ?")).
+		WithArgs(before, 1000).WillReturnResult(sqlmock.NewResult(0, 3))
+	redacted, err = repository.RedactBodies("conversation_messages", before, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), redacted)
+
+	// Audit logs have no bodies
+	_, err = repository.RedactBodies("login_events", before, 1000)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDataRetention_DeleteBefore(t *testing.T) {
+	repository, mock := setupDataRetentionRepository(t)
+	before := time.Date(2026, time.July, 18, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `login_events` WHERE `created_at` < ? LIMIT ?")).
+		WithArgs(before, 1000).WillReturnResult(sqlmock.NewResult(0, 7))
+	deleted, err := repository.DeleteBefore("login_events", before, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+
+	_, err = repository.DeleteBefore("users", before, 1000)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DigestSubscription is the database model for delivery digest subscriptions
+type DigestSubscription struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex"`
+	Frequency string    `gorm:"column:frequency;type:varchar(16)"`
+	Channel   string    `gorm:"column:channel;type:varchar(32)"`
+	Recipient string    `gorm:"column:recipient"`
+	Enabled   bool      `gorm:"column:enabled;default:false;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (DigestSubscription) TableName() string {
+	return "digest_subscriptions"
+}
+
+// DeliveryDigest is the database model for compiled delivery digest reports
+type DeliveryDigest struct {
+	ID           int       `gorm:"primaryKey"`
+	UserID       int       `gorm:"column:user_id;index:idx_delivery_digest_user_period"`
+	Frequency    string    `gorm:"column:frequency;type:varchar(16)"`
+	PeriodStart  time.Time `gorm:"column:period_start"`
+	PeriodEnd    time.Time `gorm:"column:period_end;index:idx_delivery_digest_user_period"`
+	Total        int       `gorm:"column:total"`
+	Sent         int       `gorm:"column:sent"`
+	Failed       int       `gorm:"column:failed"`
+	Fallbacks    int       `gorm:"column:fallbacks"`
+	FallbackRate float64   `gorm:"column:fallback_rate"`
+	TopErrors    string    `gorm:"column:top_errors;type:text"`
+	LinkClicks   int       `gorm:"column:link_clicks;default:0"`
+	LinkClickers int       `gorm:"column:link_clickers;default:0"`
+	Channel      string    `gorm:"column:channel;type:varchar(32)"`
+	MessageID    int       `gorm:"column:message_id"`
+	Status       string    `gorm:"column:status"`
+	ErrorMessage string    `gorm:"column:error_message;type:text"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (DeliveryDigest) TableName() string {
+	return "delivery_digests"
+}
+
+// DigestRepositoryInterface defines the interface for digest subscription and report operations
+type DigestRepositoryInterface interface {
+	GetSubscription(userID int) (*domainProvider.DigestSubscription, error)
+	SaveSubscription(subscription *domainProvider.DigestSubscription) (*domainProvider.DigestSubscription, error)
+	GetEnabledSubscriptions() (*[]domainProvider.DigestSubscription, error)
+	CreateDigest(digest *domainProvider.DeliveryDigest) (*domainProvider.DeliveryDigest, error)
+	GetUserDigests(userID int, limit int) (*[]domainProvider.DeliveryDigest, error)
+	DigestExists(userID int, frequency string, periodEnd time.Time) (bool, error)
+}
+
+type DigestRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewDigestRepository(db *gorm.DB, loggerInstance *logger.Logger) DigestRepositoryInterface {
+	return &DigestRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *DigestRepository) GetSubscription(userID int) (*domainProvider.DigestSubscription, error) {
+	var subscription DigestSubscription
+	err := r.DB.Where("user_id = ?", userID).First(&subscription).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting digest subscription", zap.Error(err), zap.Int("userID", userID))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.DigestSubscription{}, err
+	}
+	return subscription.toDomainMapper(), nil
+}
+
+// SaveSubscription creates or replaces the digest subscription of a user
+func (r *DigestRepository) SaveSubscription(subscriptionDomain *domainProvider.DigestSubscription) (*domainProvider.DigestSubscription, error) {
+	subscription := digestSubscriptionFromDomainMapper(subscriptionDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "channel", "recipient", "enabled", "updated_at"}),
+	}).Create(subscription).Error
+	if err != nil {
+		r.Logger.Error("Error saving digest subscription", zap.Error(err), zap.Int("userID", subscriptionDomain.UserID))
+		return &domainProvider.DigestSubscription{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully saved digest subscription", zap.Int("userID", subscriptionDomain.UserID), zap.Bool("enabled", subscriptionDomain.Enabled))
+	return r.GetSubscription(subscriptionDomain.UserID)
+}
+
+func (r *DigestRepository) GetEnabledSubscriptions() (*[]domainProvider.DigestSubscription, error) {
+	var subscriptions []DigestSubscription
+	if err := r.DB.Where("enabled = ?", true).Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting enabled digest subscriptions", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.DigestSubscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		result[i] = *subscription.toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *DigestRepository) CreateDigest(digestDomain *domainProvider.DeliveryDigest) (*domainProvider.DeliveryDigest, error) {
+	digest := deliveryDigestFromDomainMapper(digestDomain)
+	if err := r.DB.Create(digest).Error; err != nil {
+		r.Logger.Error("Error creating delivery digest", zap.Error(err), zap.Int("userID", digestDomain.UserID))
+		return &domainProvider.DeliveryDigest{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return digest.toDomainMapper(), nil
+}
+
+// GetUserDigests retrieves the most recent digests of a user, newest first
+func (r *DigestRepository) GetUserDigests(userID int, limit int) (*[]domainProvider.DeliveryDigest, error) {
+	var digests []DeliveryDigest
+	if err := r.DB.Where("user_id = ?", userID).Order("period_end DESC").Limit(limit).Find(&digests).Error; err != nil {
+		r.Logger.Error("Error getting user delivery digests", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.DeliveryDigest, len(digests))
+	for i, digest := range digests {
+		result[i] = *digest.toDomainMapper()
+	}
+	return &result, nil
+}
+
+// DigestExists reports whether a digest was already compiled for the given period
+func (r *DigestRepository) DigestExists(userID int, frequency string, periodEnd time.Time) (bool, error) {
+	var count int64
+	err := r.DB.Model(&DeliveryDigest{}).
+		Where("user_id = ? AND frequency = ? AND period_end = ?", userID, frequency, periodEnd).
+		Count(&count).Error
+	if err != nil {
+		r.Logger.Error("Error checking delivery digest", zap.Error(err), zap.Int("userID", userID))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return count > 0, nil
+}
+
+// Mappers
+func (s *DigestSubscription) toDomainMapper() *domainProvider.DigestSubscription {
+	return &domainProvider.DigestSubscription{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Frequency: s.Frequency,
+		Channel:   s.Channel,
+		Recipient: s.Recipient,
+		Enabled:   s.Enabled,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func digestSubscriptionFromDomainMapper(s *domainProvider.DigestSubscription) *DigestSubscription {
+	return &DigestSubscription{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Frequency: s.Frequency,
+		Channel:   s.Channel,
+		Recipient: s.Recipient,
+		Enabled:   s.Enabled,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func (d *DeliveryDigest) toDomainMapper() *domainProvider.DeliveryDigest {
+	topErrors := []domainProvider.ErrorReasonCount{}
+	if d.TopErrors != "" {
+		_ = json.Unmarshal([]byte(d.TopErrors), &topErrors)
+	}
+	return &domainProvider.DeliveryDigest{
+		ID:           d.ID,
+		UserID:       d.UserID,
+		Frequency:    d.Frequency,
+		PeriodStart:  d.PeriodStart,
+		PeriodEnd:    d.PeriodEnd,
+		Total:        d.Total,
+		Sent:         d.Sent,
+		Failed:       d.Failed,
+		Fallbacks:    d.Fallbacks,
+		FallbackRate: d.FallbackRate,
+		TopErrors:    topErrors,
+		LinkClicks:   d.LinkClicks,
+		LinkClickers: d.LinkClickers,
+		Channel:      d.Channel,
+		MessageID:    d.MessageID,
+		Status:       d.Status,
+		ErrorMessage: d.ErrorMessage,
+		CreatedAt:    d.CreatedAt,
+	}
+}
+
+func deliveryDigestFromDomainMapper(d *domainProvider.DeliveryDigest) *DeliveryDigest {
+	topErrors, _ := json.Marshal(d.TopErrors)
+	return &DeliveryDigest{
+		ID:           d.ID,
+		UserID:       d.UserID,
+		Frequency:    d.Frequency,
+		PeriodStart:  d.PeriodStart,
+		PeriodEnd:    d.PeriodEnd,
+		Total:        d.Total,
+		Sent:         d.Sent,
+		Failed:       d.Failed,
+		Fallbacks:    d.Fallbacks,
+		FallbackRate: d.FallbackRate,
+		TopErrors:    string(topErrors),
+		LinkClicks:   d.LinkClicks,
+		LinkClickers: d.LinkClickers,
+		Channel:      d.Channel,
+		MessageID:    d.MessageID,
+		Status:       d.Status,
+		ErrorMessage: d.ErrorMessage,
+		CreatedAt:    d.CreatedAt,
+	}
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DistributionList is the database model for distribution lists
+type DistributionList struct {
+	ID            int        `gorm:"primaryKey"`
+	UserID        int        `gorm:"column:user_id;index"`
+	Name          string     `gorm:"column:name"`
+	SignalGroupID string     `gorm:"column:signal_group_id"`
+	SignalMembers string     `gorm:"column:signal_members;type:text"`
+	ExtraMembers  string     `gorm:"column:extra_members;type:text"`
+	Drift         string     `gorm:"column:drift;type:text"`
+	SyncError     string     `gorm:"column:sync_error;type:text"`
+	LastSyncedAt  *time.Time `gorm:"column:last_synced_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (DistributionList) TableName() string {
+	return "distribution_lists"
+}
+
+// DistributionListRepositoryInterface defines the interface for distribution list operations
+type DistributionListRepositoryInterface interface {
+	Create(list *domainProvider.DistributionList) (*domainProvider.DistributionList, error)
+	GetByID(id int) (*domainProvider.DistributionList, error)
+	GetUserList(userID int, id int) (*domainProvider.DistributionList, error)
+	GetUserLists(userID int) (*[]domainProvider.DistributionList, error)
+	// GetSignalLists retrieves the lists sending to a Signal group, whose membership is synced
+	GetSignalLists() (*[]domainProvider.DistributionList, error)
+	// GetDrifted retrieves the lists whose last sync found drift or failed
+	GetDrifted() (*[]domainProvider.DistributionList, error)
+	Update(list *domainProvider.DistributionList) (*domainProvider.DistributionList, error)
+	UpdateSyncResult(id int, drift *domainProvider.MembershipDrift, syncError string, syncedAt time.Time) error
+	Delete(userID int, id int) error
+}
+
+type DistributionListRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewDistributionListRepository(db *gorm.DB, loggerInstance *logger.Logger) DistributionListRepositoryInterface {
+	return &DistributionListRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *DistributionListRepository) Create(listDomain *domainProvider.DistributionList) (*domainProvider.DistributionList, error) {
+	list := distributionListFromDomainMapper(listDomain)
+	if err := r.DB.Create(list).Error; err != nil {
+		r.Logger.Error("Error creating distribution list", zap.Error(err), zap.Int("userID", listDomain.UserID))
+		return &domainProvider.DistributionList{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created distribution list", zap.Int("id", list.ID), zap.Int("userID", list.UserID))
+	return list.toDomainMapper(), nil
+}
+
+func (r *DistributionListRepository) GetByID(id int) (*domainProvider.DistributionList, error) {
+	return r.first(r.DB.Where("id = ?", id), id)
+}
+
+func (r *DistributionListRepository) GetUserList(userID int, id int) (*domainProvider.DistributionList, error) {
+	return r.first(r.DB.Where("id = ? AND user_id = ?", id, userID), id)
+}
+
+func (r *DistributionListRepository) first(query *gorm.DB, id int) (*domainProvider.DistributionList, error) {
+	var list DistributionList
+	err := query.First(&list).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting distribution list", zap.Error(err), zap.Int("id", id))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.DistributionList{}, err
+	}
+	return list.toDomainMapper(), nil
+}
+
+func (r *DistributionListRepository) GetUserLists(userID int) (*[]domainProvider.DistributionList, error) {
+	return r.find(r.DB.Where("user_id = ?", userID), "Error getting distribution lists")
+}
+
+func (r *DistributionListRepository) GetSignalLists() (*[]domainProvider.DistributionList, error) {
+	return r.find(r.DB.Where("signal_group_id <> ''"), "Error getting distribution lists to sync")
+}
+
+func (r *DistributionListRepository) GetDrifted() (*[]domainProvider.DistributionList, error) {
+	return r.find(r.DB.Where("drift <> '' OR sync_error <> ''"), "Error getting drifted distribution lists")
+}
+
+func (r *DistributionListRepository) find(query *gorm.DB, errorMessage string) (*[]domainProvider.DistributionList, error) {
+	var lists []DistributionList
+	if err := query.Order("id").Find(&lists).Error; err != nil {
+		r.Logger.Error(errorMessage, zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.DistributionList, len(lists))
+	for i, list := range lists {
+		result[i] = *list.toDomainMapper()
+	}
+	return &result, nil
+}
+
+// Update replaces the name, group and members of a list of a user, returning NotFound if the user has no such list
+func (r *DistributionListRepository) Update(listDomain *domainProvider.DistributionList) (*domainProvider.DistributionList, error) {
+	list := distributionListFromDomainMapper(listDomain)
+	tx := r.DB.Model(&DistributionList{}).Where("id = ? AND user_id = ?", list.ID, list.UserID).Updates(map[string]interface{}{
+		"name":            list.Name,
+		"signal_group_id": list.SignalGroupID,
+		"signal_members":  list.SignalMembers,
+		"extra_members":   list.ExtraMembers,
+	})
+	if tx.Error != nil {
+		r.Logger.Error("Error updating distribution list", zap.Error(tx.Error), zap.Int("id", list.ID))
+		return &domainProvider.DistributionList{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	// MySQL reports unchanged rows as unaffected, so a missing list is detected by reading it back
+	r.Logger.Info("Successfully updated distribution list", zap.Int("id", list.ID))
+	return r.GetUserList(list.UserID, list.ID)
+}
+
+// UpdateSyncResult stores the outcome of a membership sync of a list
+func (r *DistributionListRepository) UpdateSyncResult(id int, drift *domainProvider.MembershipDrift, syncError string, syncedAt time.Time) error {
+	err := r.DB.Model(&DistributionList{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"drift":          encodeMembershipDrift(drift),
+		"sync_error":     syncError,
+		"last_synced_at": syncedAt,
+	}).Error
+	if err != nil {
+		r.Logger.Error("Error updating distribution list sync result", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// Delete removes a list of a user, returning NotFound if the user has no such list
+func (r *DistributionListRepository) Delete(userID int, id int) error {
+	tx := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&DistributionList{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting distribution list", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted distribution list", zap.Int("id", id), zap.Int("userID", userID))
+	return nil
+}
+
+// Mappers
+func decodeSignalMembers(data string) []string {
+	members := []string{}
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &members)
+	}
+	return members
+}
+
+func encodeSignalMembers(members []string) string {
+	if members == nil {
+		members = []string{}
+	}
+	data, _ := json.Marshal(members)
+	return string(data)
+}
+
+func decodeExtraMembers(data string) []domainProvider.DistributionListMember {
+	members := []domainProvider.DistributionListMember{}
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &members)
+	}
+	return members
+}
+
+func encodeExtraMembers(members []domainProvider.DistributionListMember) string {
+	if members == nil {
+		members = []domainProvider.DistributionListMember{}
+	}
+	data, _ := json.Marshal(members)
+	return string(data)
+}
+
+func decodeMembershipDrift(data string) *domainProvider.MembershipDrift {
+	if data == "" {
+		return nil
+	}
+	var drift domainProvider.MembershipDrift
+	if err := json.Unmarshal([]byte(data), &drift); err != nil {
+		return nil
+	}
+	return &drift
+}
+
+func encodeMembershipDrift(drift *domainProvider.MembershipDrift) string {
+	if drift == nil {
+		return ""
+	}
+	data, _ := json.Marshal(drift)
+	return string(data)
+}
+
+func (l *DistributionList) toDomainMapper() *domainProvider.DistributionList {
+	return &domainProvider.DistributionList{
+		ID:            l.ID,
+		UserID:        l.UserID,
+		Name:          l.Name,
+		SignalGroupID: l.SignalGroupID,
+		SignalMembers: decodeSignalMembers(l.SignalMembers),
+		ExtraMembers:  decodeExtraMembers(l.ExtraMembers),
+		Drift:         decodeMembershipDrift(l.Drift),
+		SyncError:     l.SyncError,
+		LastSyncedAt:  l.LastSyncedAt,
+		CreatedAt:     l.CreatedAt,
+		UpdatedAt:     l.UpdatedAt,
+	}
+}
+
+func distributionListFromDomainMapper(l *domainProvider.DistributionList) *DistributionList {
+	return &DistributionList{
+		ID:            l.ID,
+		UserID:        l.UserID,
+		Name:          l.Name,
+		SignalGroupID: l.SignalGroupID,
+		SignalMembers: encodeSignalMembers(l.SignalMembers),
+		ExtraMembers:  encodeExtraMembers(l.ExtraMembers),
+		Drift:         encodeMembershipDrift(l.Drift),
+		SyncError:     l.SyncError,
+		LastSyncedAt:  l.LastSyncedAt,
+		CreatedAt:     l.CreatedAt,
+		UpdatedAt:     l.UpdatedAt,
+	}
+}
@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EmailTemplate is the database model for email templates
+type EmailTemplate struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex:idx_email_templates_user_name"`
+	Name      string    `gorm:"column:name;size:191;uniqueIndex:idx_email_templates_user_name"`
+	Subject   string    `gorm:"column:subject;type:text"`
+	HTML      string    `gorm:"column:html;type:mediumtext"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+// EmailTemplateRepositoryInterface defines the interface for email template operations
+type EmailTemplateRepositoryInterface interface {
+	Create(template *domainProvider.EmailTemplate) (*domainProvider.EmailTemplate, error)
+	GetUserTemplate(userID int, id int) (*domainProvider.EmailTemplate, error)
+	GetUserTemplateByName(userID int, name string) (*domainProvider.EmailTemplate, error)
+	GetUserTemplates(userID int) (*[]domainProvider.EmailTemplate, error)
+	Update(template *domainProvider.EmailTemplate) (*domainProvider.EmailTemplate, error)
+	Delete(userID int, id int) error
+}
+
+type EmailTemplateRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewEmailTemplateRepository(db *gorm.DB, loggerInstance *logger.Logger) EmailTemplateRepositoryInterface {
+	return &EmailTemplateRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *EmailTemplateRepository) Create(templateDomain *domainProvider.EmailTemplate) (*domainProvider.EmailTemplate, error) {
+	template := emailTemplateFromDomainMapper(templateDomain)
+	if err := r.DB.Create(template).Error; err != nil {
+		r.Logger.Error("Error creating email template", zap.Error(err), zap.Int("userID", templateDomain.UserID))
+		return &domainProvider.EmailTemplate{}, emailTemplateWriteError(err)
+	}
+	r.Logger.Info("Successfully created email template", zap.Int("id", template.ID), zap.Int("userID", template.UserID))
+	return template.toDomainMapper(), nil
+}
+
+func (r *EmailTemplateRepository) GetUserTemplate(userID int, id int) (*domainProvider.EmailTemplate, error) {
+	return r.first(r.DB.Where("id = ? AND user_id = ?", id, userID))
+}
+
+func (r *EmailTemplateRepository) GetUserTemplateByName(userID int, name string) (*domainProvider.EmailTemplate, error) {
+	return r.first(r.DB.Where("name = ? AND user_id = ?", name, userID))
+}
+
+func (r *EmailTemplateRepository) first(query *gorm.DB) (*domainProvider.EmailTemplate, error) {
+	var template EmailTemplate
+	err := query.First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting email template", zap.Error(err))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.EmailTemplate{}, err
+	}
+	return template.toDomainMapper(), nil
+}
+
+func (r *EmailTemplateRepository) GetUserTemplates(userID int) (*[]domainProvider.EmailTemplate, error) {
+	var templates []EmailTemplate
+	if err := r.DB.Where("user_id = ?", userID).Order("id").Find(&templates).Error; err != nil {
+		r.Logger.Error("Error getting email templates", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.EmailTemplate, len(templates))
+	for i, template := range templates {
+		result[i] = *template.toDomainMapper()
+	}
+	return &result, nil
+}
+
+// Update replaces the name, subject and body of a template of a user, returning NotFound if the user has no such template
+func (r *EmailTemplateRepository) Update(templateDomain *domainProvider.EmailTemplate) (*domainProvider.EmailTemplate, error) {
+	template := emailTemplateFromDomainMapper(templateDomain)
+	tx := r.DB.Model(&EmailTemplate{}).Where("id = ? AND user_id = ?", template.ID, template.UserID).Updates(map[string]interface{}{
+		"name":    template.Name,
+		"subject": template.Subject,
+		"html":    template.HTML,
+	})
+	if tx.Error != nil {
+		r.Logger.Error("Error updating email template", zap.Error(tx.Error), zap.Int("id", template.ID))
+		return &domainProvider.EmailTemplate{}, emailTemplateWriteError(tx.Error)
+	}
+	r.Logger.Info("Successfully updated email template", zap.Int("id", template.ID))
+	return r.GetUserTemplate(template.UserID, template.ID)
+}
+
+// Delete removes a template of a user, returning NotFound if the user has no such template
+func (r *EmailTemplateRepository) Delete(userID int, id int) error {
+	tx := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&EmailTemplate{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting email template", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted email template", zap.Int("id", id), zap.Int("userID", userID))
+	return nil
+}
+
+// emailTemplateWriteError reports a template name the user already has as ResourceAlreadyExists
+func emailTemplateWriteError(err error) error {
+	byteErr, _ := json.Marshal(err)
+	var gormErr domainErrors.GormErr
+	if json.Unmarshal(byteErr, &gormErr) == nil && gormErr.Number == 1062 {
+		return domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+}
+
+// Mappers
+func (t *EmailTemplate) toDomainMapper() *domainProvider.EmailTemplate {
+	return &domainProvider.EmailTemplate{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Subject:   t.Subject,
+		HTML:      t.HTML,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func emailTemplateFromDomainMapper(t *domainProvider.EmailTemplate) *EmailTemplate {
+	return &EmailTemplate{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Subject:   t.Subject,
+		HTML:      t.HTML,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
@@ -0,0 +1,300 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EscalationChain is the database model for escalation chains
+type EscalationChain struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;index"`
+	Name      string    `gorm:"column:name"`
+	Steps     string    `gorm:"column:steps;type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (EscalationChain) TableName() string {
+	return "escalation_chains"
+}
+
+// Escalation is the database model for running escalations
+type Escalation struct {
+	ID             int        `gorm:"primaryKey"`
+	UserID         int        `gorm:"column:user_id;index"`
+	ChainID        int        `gorm:"column:chain_id;index"`
+	Steps          string     `gorm:"column:steps;type:text"`
+	Message        string     `gorm:"column:message;type:text"`
+	Status         string     `gorm:"column:status;type:varchar(16);index:idx_escalation_status_next_step"`
+	CurrentStep    int        `gorm:"column:current_step"`
+	NextStepAt     *time.Time `gorm:"column:next_step_at;index:idx_escalation_status_next_step"`
+	AckCode        string     `gorm:"column:ack_code;type:varchar(16);index"`
+	AcknowledgedBy string     `gorm:"column:acknowledged_by"`
+	AcknowledgedAt *time.Time `gorm:"column:acknowledged_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (Escalation) TableName() string {
+	return "escalations"
+}
+
+// EscalationRepositoryInterface defines the interface for escalation chain and escalation operations
+type EscalationRepositoryInterface interface {
+	CreateChain(chain *domainProvider.EscalationChain) (*domainProvider.EscalationChain, error)
+	GetChain(userID int, id int) (*domainProvider.EscalationChain, error)
+	GetUserChains(userID int) (*[]domainProvider.EscalationChain, error)
+	DeleteChain(userID int, id int) error
+	CreateEscalation(escalation *domainProvider.Escalation) (*domainProvider.Escalation, error)
+	GetEscalation(userID int, id int) (*domainProvider.Escalation, error)
+	GetUserEscalations(userID int, limit int) (*[]domainProvider.Escalation, error)
+	GetOpenEscalationByAckCode(ackCode string) (*domainProvider.Escalation, error)
+	GetDueEscalations(now time.Time) (*[]domainProvider.Escalation, error)
+	AdvanceEscalation(id int, fromStep int, nextStep int, status string, nextStepAt *time.Time) (bool, error)
+	AcknowledgeEscalation(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error)
+}
+
+type EscalationRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewEscalationRepository(db *gorm.DB, loggerInstance *logger.Logger) EscalationRepositoryInterface {
+	return &EscalationRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *EscalationRepository) CreateChain(chainDomain *domainProvider.EscalationChain) (*domainProvider.EscalationChain, error) {
+	chain := escalationChainFromDomainMapper(chainDomain)
+	if err := r.DB.Create(chain).Error; err != nil {
+		r.Logger.Error("Error creating escalation chain", zap.Error(err), zap.Int("userID", chainDomain.UserID))
+		return &domainProvider.EscalationChain{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created escalation chain", zap.Int("id", chain.ID), zap.Int("userID", chain.UserID))
+	return chain.toDomainMapper(), nil
+}
+
+func (r *EscalationRepository) GetChain(userID int, id int) (*domainProvider.EscalationChain, error) {
+	var chain EscalationChain
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&chain).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting escalation chain", zap.Error(err), zap.Int("id", id))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.EscalationChain{}, err
+	}
+	return chain.toDomainMapper(), nil
+}
+
+func (r *EscalationRepository) GetUserChains(userID int) (*[]domainProvider.EscalationChain, error) {
+	var chains []EscalationChain
+	if err := r.DB.Where("user_id = ?", userID).Order("id").Find(&chains).Error; err != nil {
+		r.Logger.Error("Error getting escalation chains", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.EscalationChain, len(chains))
+	for i, chain := range chains {
+		result[i] = *chain.toDomainMapper()
+	}
+	return &result, nil
+}
+
+// DeleteChain removes a chain of a user, returning NotFound if the user has no such chain. Running
+// escalations keep working on the steps they were started with.
+func (r *EscalationRepository) DeleteChain(userID int, id int) error {
+	tx := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&EscalationChain{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting escalation chain", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return nil
+}
+
+func (r *EscalationRepository) CreateEscalation(escalationDomain *domainProvider.Escalation) (*domainProvider.Escalation, error) {
+	escalation := escalationFromDomainMapper(escalationDomain)
+	if err := r.DB.Create(escalation).Error; err != nil {
+		r.Logger.Error("Error creating escalation", zap.Error(err), zap.Int("chainID", escalationDomain.ChainID))
+		return &domainProvider.Escalation{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created escalation", zap.Int("id", escalation.ID), zap.Int("chainID", escalation.ChainID))
+	return escalation.toDomainMapper(), nil
+}
+
+func (r *EscalationRepository) GetEscalation(userID int, id int) (*domainProvider.Escalation, error) {
+	var escalation Escalation
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&escalation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting escalation", zap.Error(err), zap.Int("id", id))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.Escalation{}, err
+	}
+	return escalation.toDomainMapper(), nil
+}
+
+// GetUserEscalations retrieves the most recent escalations of a user, newest first
+func (r *EscalationRepository) GetUserEscalations(userID int, limit int) (*[]domainProvider.Escalation, error) {
+	var escalations []Escalation
+	if err := r.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&escalations).Error; err != nil {
+		r.Logger.Error("Error getting escalations", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return escalationsToDomain(escalations), nil
+}
+
+// GetOpenEscalationByAckCode retrieves the most recent escalation with the acknowledgement code that
+// can still be acknowledged
+func (r *EscalationRepository) GetOpenEscalationByAckCode(ackCode string) (*domainProvider.Escalation, error) {
+	var escalation Escalation
+	err := r.DB.Where("ack_code = ? AND status IN ?", ackCode, []string{"active", "exhausted"}).
+		Order("id DESC").First(&escalation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting escalation by acknowledgement code", zap.Error(err))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.Escalation{}, err
+	}
+	return escalation.toDomainMapper(), nil
+}
+
+// GetDueEscalations retrieves the active escalations whose next step is due
+func (r *EscalationRepository) GetDueEscalations(now time.Time) (*[]domainProvider.Escalation, error) {
+	var escalations []Escalation
+	err := r.DB.Where("status = ? AND next_step_at <= ?", "active", now).Order("next_step_at").Find(&escalations).Error
+	if err != nil {
+		r.Logger.Error("Error getting due escalations", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return escalationsToDomain(escalations), nil
+}
+
+// AdvanceEscalation moves an active escalation from one step to the next. The update is conditional on the
+// current step, so a step is only taken once even if several instances process the same escalation, and
+// an escalation acknowledged in the meantime is not advanced. It reports whether the escalation was advanced.
+func (r *EscalationRepository) AdvanceEscalation(id int, fromStep int, nextStep int, status string, nextStepAt *time.Time) (bool, error) {
+	tx := r.DB.Model(&Escalation{}).
+		Where("id = ? AND status = ? AND current_step = ?", id, "active", fromStep).
+		Updates(map[string]interface{}{"current_step": nextStep, "status": status, "next_step_at": nextStepAt})
+	if tx.Error != nil {
+		r.Logger.Error("Error advancing escalation", zap.Error(tx.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// AcknowledgeEscalation acknowledges an escalation that is still open, stopping further steps. It reports
+// whether the escalation was acknowledged, false means it was already acknowledged.
+func (r *EscalationRepository) AcknowledgeEscalation(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error) {
+	tx := r.DB.Model(&Escalation{}).
+		Where("id = ? AND status IN ?", id, []string{"active", "exhausted"}).
+		Updates(map[string]interface{}{
+			"status":          "acknowledged",
+			"next_step_at":    nil,
+			"acknowledged_by": acknowledgedBy,
+			"acknowledged_at": acknowledgedAt,
+		})
+	if tx.Error != nil {
+		r.Logger.Error("Error acknowledging escalation", zap.Error(tx.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// Mappers
+func decodeEscalationSteps(data string) []domainProvider.EscalationStep {
+	steps := []domainProvider.EscalationStep{}
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &steps)
+	}
+	return steps
+}
+
+func encodeEscalationSteps(steps []domainProvider.EscalationStep) string {
+	data, _ := json.Marshal(steps)
+	return string(data)
+}
+
+func (c *EscalationChain) toDomainMapper() *domainProvider.EscalationChain {
+	return &domainProvider.EscalationChain{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Name:      c.Name,
+		Steps:     decodeEscalationSteps(c.Steps),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func escalationChainFromDomainMapper(c *domainProvider.EscalationChain) *EscalationChain {
+	return &EscalationChain{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		Name:      c.Name,
+		Steps:     encodeEscalationSteps(c.Steps),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func (e *Escalation) toDomainMapper() *domainProvider.Escalation {
+	return &domainProvider.Escalation{
+		ID:             e.ID,
+		UserID:         e.UserID,
+		ChainID:        e.ChainID,
+		Steps:          decodeEscalationSteps(e.Steps),
+		Message:        e.Message,
+		Status:         e.Status,
+		CurrentStep:    e.CurrentStep,
+		NextStepAt:     e.NextStepAt,
+		AckCode:        e.AckCode,
+		AcknowledgedBy: e.AcknowledgedBy,
+		AcknowledgedAt: e.AcknowledgedAt,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+	}
+}
+
+func escalationFromDomainMapper(e *domainProvider.Escalation) *Escalation {
+	return &Escalation{
+		ID:             e.ID,
+		UserID:         e.UserID,
+		ChainID:        e.ChainID,
+		Steps:          encodeEscalationSteps(e.Steps),
+		Message:        e.Message,
+		Status:         e.Status,
+		CurrentStep:    e.CurrentStep,
+		NextStepAt:     e.NextStepAt,
+		AckCode:        e.AckCode,
+		AcknowledgedBy: e.AcknowledgedBy,
+		AcknowledgedAt: e.AcknowledgedAt,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+	}
+}
+
+func escalationsToDomain(escalations []Escalation) *[]domainProvider.Escalation {
+	result := make([]domainProvider.Escalation, len(escalations))
+	for i, escalation := range escalations {
+		result[i] = *escalation.toDomainMapper()
+	}
+	return &result
+}
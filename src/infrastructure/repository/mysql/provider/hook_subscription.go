@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// HookSubscription is the database model for REST hook subscriptions
+type HookSubscription struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"column:user_id;index:idx_hook_subscription_user_event"`
+	Event     string `gorm:"column:event;type:varchar(64);index:idx_hook_subscription_user_event"`
+	TargetURL string `gorm:"column:target_url;type:varchar(2048)"`
+	Secret    string `gorm:"column:secret;type:varchar(255)"` // encrypted with the key of the user, see security.UserSecretCipher
+	// EnvelopeTypes is the comma separated envelope type filter of message.received subscriptions
+	EnvelopeTypes string `gorm:"column:envelope_types;type:varchar(255)"`
+	// Headers is the JSON object of the delivery headers, their values are encrypted like the secret
+	Headers            string    `gorm:"column:headers;type:text"`
+	ClientCertificate  string    `gorm:"column:client_certificate;type:text"`
+	ClientKey          string    `gorm:"column:client_key;type:text"` // encrypted like the secret
+	CACertificate      string    `gorm:"column:ca_certificate;type:text"`
+	InsecureSkipVerify bool      `gorm:"column:insecure_skip_verify;default:false"`
+	CreatedAt          time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (HookSubscription) TableName() string {
+	return "hook_subscriptions"
+}
+
+// HookSubscriptionRepositoryInterface defines the interface for REST hook subscription operations
+type HookSubscriptionRepositoryInterface interface {
+	Create(subscription *domainProvider.HookSubscription) (*domainProvider.HookSubscription, error)
+	GetUserSubscriptions(userID int) (*[]domainProvider.HookSubscription, error)
+	GetUserSubscriptionsForEvent(userID int, event string) (*[]domainProvider.HookSubscription, error)
+	GetSubscriptionsForProviderType(event string, providerType string) (*[]domainProvider.HookSubscription, error)
+	GetUserSubscription(userID int, id int) (*domainProvider.HookSubscription, error)
+	GetAll() (*[]domainProvider.HookSubscription, error)
+	UpdateSecret(id int, secret string) error
+	UpdateDelivery(id int, delivery domainProvider.HookDelivery) error
+	Delete(userID int, id int) error
+	DeleteByID(id int) error
+}
+
+type HookSubscriptionRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewHookSubscriptionRepository(db *gorm.DB, loggerInstance *logger.Logger) HookSubscriptionRepositoryInterface {
+	return &HookSubscriptionRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *HookSubscriptionRepository) Create(subscriptionDomain *domainProvider.HookSubscription) (*domainProvider.HookSubscription, error) {
+	subscription := hookSubscriptionFromDomainMapper(subscriptionDomain)
+	if err := r.DB.Create(subscription).Error; err != nil {
+		r.Logger.Error("Error creating hook subscription", zap.Error(err), zap.Int("userID", subscriptionDomain.UserID))
+		return &domainProvider.HookSubscription{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created hook subscription", zap.Int("id", subscription.ID), zap.Int("userID", subscription.UserID), zap.String("event", subscription.Event))
+	return subscription.toDomainMapper(), nil
+}
+
+func (r *HookSubscriptionRepository) GetUserSubscriptions(userID int) (*[]domainProvider.HookSubscription, error) {
+	var subscriptions []HookSubscription
+	if err := r.DB.Where("user_id = ?", userID).Order("id").Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting hook subscriptions", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return hookSubscriptionsToDomain(subscriptions), nil
+}
+
+func (r *HookSubscriptionRepository) GetUserSubscriptionsForEvent(userID int, event string) (*[]domainProvider.HookSubscription, error) {
+	var subscriptions []HookSubscription
+	if err := r.DB.Where("user_id = ? AND event = ?", userID, event).Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting hook subscriptions for event", zap.Error(err), zap.Int("userID", userID), zap.String("event", event))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return hookSubscriptionsToDomain(subscriptions), nil
+}
+
+// GetSubscriptionsForProviderType retrieves the subscriptions to an event of all users with an active
+// provider of the given type, used to fan out events that aren't tied to a single user such as inbound messages
+func (r *HookSubscriptionRepository) GetSubscriptionsForProviderType(event string, providerType string) (*[]domainProvider.HookSubscription, error) {
+	var subscriptions []HookSubscription
+	err := r.DB.Where("event = ?", event).
+		Where("user_id IN (?)", r.DB.Table("user_providers").
+			Select("user_providers.user_id").
+			Joins("JOIN providers ON providers.id = user_providers.provider_id").
+			Where("providers.type = ? AND providers.status = ? AND user_providers.status = ?", providerType, true, true)).
+		Find(&subscriptions).Error
+	if err != nil {
+		r.Logger.Error("Error getting hook subscriptions for provider type", zap.Error(err), zap.String("event", event), zap.String("providerType", providerType))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return hookSubscriptionsToDomain(subscriptions), nil
+}
+
+// GetUserSubscription retrieves a subscription of a user, returning NotFound if the user has no such subscription
+func (r *HookSubscriptionRepository) GetUserSubscription(userID int, id int) (*domainProvider.HookSubscription, error) {
+	var subscription HookSubscription
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting hook subscription", zap.Error(err), zap.Int("id", id), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return subscription.toDomainMapper(), nil
+}
+
+// GetAll retrieves the subscriptions of every user, used to encrypt the secrets stored before they were encrypted
+func (r *HookSubscriptionRepository) GetAll() (*[]domainProvider.HookSubscription, error) {
+	var subscriptions []HookSubscription
+	if err := r.DB.Order("id").Find(&subscriptions).Error; err != nil {
+		r.Logger.Error("Error getting all hook subscriptions", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return hookSubscriptionsToDomain(subscriptions), nil
+}
+
+// UpdateSecret replaces the stored secret of a subscription
+func (r *HookSubscriptionRepository) UpdateSecret(id int, secret string) error {
+	tx := r.DB.Model(&HookSubscription{}).Where("id = ?", id).Update("secret", secret)
+	if tx.Error != nil {
+		r.Logger.Error("Error updating hook subscription secret", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return nil
+}
+
+// UpdateDelivery replaces the stored delivery settings of a subscription
+func (r *HookSubscriptionRepository) UpdateDelivery(id int, delivery domainProvider.HookDelivery) error {
+	columns := hookDeliveryColumns(delivery)
+	tx := r.DB.Model(&HookSubscription{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"headers":              columns.Headers,
+		"client_certificate":   columns.ClientCertificate,
+		"client_key":           columns.ClientKey,
+		"ca_certificate":       columns.CACertificate,
+		"insecure_skip_verify": columns.InsecureSkipVerify,
+	})
+	if tx.Error != nil {
+		r.Logger.Error("Error updating hook subscription delivery", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return nil
+}
+
+// Delete removes a subscription of a user, returning NotFound if the user has no such subscription
+func (r *HookSubscriptionRepository) Delete(userID int, id int) error {
+	tx := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&HookSubscription{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting hook subscription", zap.Error(tx.Error), zap.Int("id", id), zap.Int("userID", userID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Info("Successfully deleted hook subscription", zap.Int("id", id), zap.Int("userID", userID))
+	return nil
+}
+
+// DeleteByID removes a subscription regardless of its owner, used when a target asks to be unsubscribed
+func (r *HookSubscriptionRepository) DeleteByID(id int) error {
+	if err := r.DB.Where("id = ?", id).Delete(&HookSubscription{}).Error; err != nil {
+		r.Logger.Error("Error deleting hook subscription", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// Mappers
+func (s *HookSubscription) toDomainMapper() *domainProvider.HookSubscription {
+	subscription := &domainProvider.HookSubscription{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Event:     s.Event,
+		TargetURL: s.TargetURL,
+		Secret:    s.Secret,
+		Delivery: domainProvider.HookDelivery{
+			ClientCertificate:  s.ClientCertificate,
+			ClientKey:          s.ClientKey,
+			CACertificate:      s.CACertificate,
+			InsecureSkipVerify: s.InsecureSkipVerify,
+		},
+		CreatedAt: s.CreatedAt,
+	}
+	if s.EnvelopeTypes != "" {
+		subscription.EnvelopeTypes = strings.Split(s.EnvelopeTypes, ",")
+	}
+	if s.Headers != "" {
+		_ = json.Unmarshal([]byte(s.Headers), &subscription.Delivery.Headers)
+	}
+	return subscription
+}
+
+func hookSubscriptionFromDomainMapper(s *domainProvider.HookSubscription) *HookSubscription {
+	subscription := hookDeliveryColumns(s.Delivery)
+	subscription.ID = s.ID
+	subscription.UserID = s.UserID
+	subscription.Event = s.Event
+	subscription.TargetURL = s.TargetURL
+	subscription.Secret = s.Secret
+	subscription.EnvelopeTypes = strings.Join(s.EnvelopeTypes, ",")
+	subscription.CreatedAt = s.CreatedAt
+	return subscription
+}
+
+// hookDeliveryColumns returns a model with the columns of the delivery settings set
+func hookDeliveryColumns(delivery domainProvider.HookDelivery) *HookSubscription {
+	subscription := &HookSubscription{
+		ClientCertificate:  delivery.ClientCertificate,
+		ClientKey:          delivery.ClientKey,
+		CACertificate:      delivery.CACertificate,
+		InsecureSkipVerify: delivery.InsecureSkipVerify,
+	}
+	if len(delivery.Headers) > 0 {
+		headers, _ := json.Marshal(delivery.Headers)
+		subscription.Headers = string(headers)
+	}
+	return subscription
+}
+
+func hookSubscriptionsToDomain(subscriptions []HookSubscription) *[]domainProvider.HookSubscription {
+	result := make([]domainProvider.HookSubscription, len(subscriptions))
+	for i, subscription := range subscriptions {
+		result[i] = *subscription.toDomainMapper()
+	}
+	return &result
+}
@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Statuses of jobs
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// claimCandidates is the number of due jobs a worker tries to claim before giving up, other workers may claim
+// the same jobs at the same time
+const claimCandidates = 5
+
+// Job is the database model for background jobs
+type Job struct {
+	ID              int        `gorm:"primaryKey"`
+	Type            string     `gorm:"column:type;type:varchar(64);index"`
+	Payload         string     `gorm:"column:payload;type:text"`
+	Status          string     `gorm:"column:status;type:varchar(16);index:idx_job_status_run_after"`
+	Progress        int        `gorm:"column:progress;default:0"`
+	Result          string     `gorm:"column:result;type:text"`
+	Error           string     `gorm:"column:error;type:text"`
+	Attempts        int        `gorm:"column:attempts;default:0"`
+	MaxAttempts     int        `gorm:"column:max_attempts;default:1"`
+	RunAfter        time.Time  `gorm:"column:run_after;index:idx_job_status_run_after"`
+	CancelRequested bool       `gorm:"column:cancel_requested;default:false"`
+	WorkerID        string     `gorm:"column:worker_id;type:varchar(191)"`
+	HeartbeatAt     *time.Time `gorm:"column:heartbeat_at"`
+	CreatedBy       int        `gorm:"column:created_by;index"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime:mili"`
+	StartedAt       *time.Time `gorm:"column:started_at"`
+	FinishedAt      *time.Time `gorm:"column:finished_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// JobRepositoryInterface defines the interface for job operations. The updates of a running job are conditional
+// on the worker running it, so a worker that lost its job to the stale job recovery can't change it anymore.
+type JobRepositoryInterface interface {
+	Create(job *domainProvider.Job) (*domainProvider.Job, error)
+	GetByID(id int) (*domainProvider.Job, error)
+	// GetUserJobs returns the jobs created by a user, the most recent first, of one type when jobType is set
+	GetUserJobs(userID int, jobType string, limit int) (*[]domainProvider.Job, error)
+	// Claim starts the oldest due queued job on a worker, it returns nil when there is none
+	Claim(workerID string, now time.Time) (*domainProvider.Job, error)
+	// Heartbeat records that the worker still runs the job, with its progress unless progress is negative, and
+	// returns whether its cancellation was requested
+	Heartbeat(id int, workerID string, progress int, result string) (bool, error)
+	Finish(id int, workerID string, status string, result string, errorMessage string) error
+	// Reschedule queues a failed attempt again to run after runAfter
+	Reschedule(id int, workerID string, errorMessage string, runAfter time.Time) error
+	// RequeueStale queues the running jobs whose worker stopped sending heartbeats before staleBefore again,
+	// or fails them when they used up their attempts
+	RequeueStale(staleBefore time.Time) (int, error)
+	// RequestCancel cancels a queued job right away and asks the worker of a running job to stop. It returns
+	// false when the job already finished.
+	RequestCancel(id int) (bool, error)
+	// Retry queues a failed or cancelled job again with fresh attempts. It returns false for other jobs.
+	Retry(id int) (bool, error)
+}
+
+type JobRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewJobRepository(db *gorm.DB, loggerInstance *logger.Logger) JobRepositoryInterface {
+	return &JobRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *JobRepository) Create(jobDomain *domainProvider.Job) (*domainProvider.Job, error) {
+	job := jobFromDomainMapper(jobDomain)
+	if err := r.DB.Create(job).Error; err != nil {
+		r.Logger.Error("Error creating job", zap.Error(err), zap.String("type", jobDomain.Type))
+		return &domainProvider.Job{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created job", zap.Int("id", job.ID), zap.String("type", job.Type))
+	return job.toDomainMapper(), nil
+}
+
+func (r *JobRepository) GetByID(id int) (*domainProvider.Job, error) {
+	var job Job
+	if err := r.DB.Where("id = ?", id).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &domainProvider.Job{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting job", zap.Error(err), zap.Int("id", id))
+		return &domainProvider.Job{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return job.toDomainMapper(), nil
+}
+
+func (r *JobRepository) GetUserJobs(userID int, jobType string, limit int) (*[]domainProvider.Job, error) {
+	query := r.DB.Where("created_by = ?", userID)
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	var jobs []Job
+	if err := query.Order("id DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		r.Logger.Error("Error getting user jobs", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.Job, len(jobs))
+	for i := range jobs {
+		result[i] = *jobs[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *JobRepository) Claim(workerID string, now time.Time) (*domainProvider.Job, error) {
+	var candidates []Job
+	if err := r.DB.Where("status = ? AND run_after <= ?", JobStatusQueued, now).
+		Order("run_after ASC, id ASC").Limit(claimCandidates).Find(&candidates).Error; err != nil {
+		r.Logger.Error("Error getting queued jobs", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	for _, candidate := range candidates {
+		result := r.DB.Model(&Job{}).
+			Where("id = ? AND status = ?", candidate.ID, JobStatusQueued).
+			Updates(map[string]interface{}{
+				"status":       JobStatusRunning,
+				"worker_id":    workerID,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"heartbeat_at": now,
+				"started_at":   now,
+				"error":        "",
+			})
+		if result.Error != nil {
+			r.Logger.Error("Error claiming job", zap.Error(result.Error), zap.Int("id", candidate.ID))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		if result.RowsAffected == 1 {
+			return r.GetByID(candidate.ID)
+		}
+	}
+	return nil, nil
+}
+
+func (r *JobRepository) Heartbeat(id int, workerID string, progress int, result string) (bool, error) {
+	updateData := map[string]interface{}{"heartbeat_at": time.Now()}
+	if progress >= 0 {
+		updateData["progress"] = progress
+		updateData["result"] = result
+	}
+	if err := r.runningJob(id, workerID).Updates(updateData).Error; err != nil {
+		r.Logger.Error("Error recording job heartbeat", zap.Error(err), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	var job Job
+	if err := r.DB.Select("cancel_requested").Where("id = ?", id).First(&job).Error; err != nil {
+		r.Logger.Error("Error getting job cancellation", zap.Error(err), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return job.CancelRequested, nil
+}
+
+func (r *JobRepository) Finish(id int, workerID string, status string, result string, errorMessage string) error {
+	updateData := map[string]interface{}{
+		"status":      status,
+		"error":       errorMessage,
+		"finished_at": time.Now(),
+	}
+	if result != "" {
+		updateData["result"] = result
+	}
+	if status == JobStatusCompleted {
+		updateData["progress"] = 100
+	}
+	if err := r.runningJob(id, workerID).Updates(updateData).Error; err != nil {
+		r.Logger.Error("Error finishing job", zap.Error(err), zap.Int("id", id), zap.String("status", status))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *JobRepository) Reschedule(id int, workerID string, errorMessage string, runAfter time.Time) error {
+	err := r.runningJob(id, workerID).Updates(map[string]interface{}{
+		"status":    JobStatusQueued,
+		"error":     errorMessage,
+		"run_after": runAfter,
+		"worker_id": "",
+	}).Error
+	if err != nil {
+		r.Logger.Error("Error rescheduling job", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// runningJob selects a job while the worker still runs it
+func (r *JobRepository) runningJob(id int, workerID string) *gorm.DB {
+	return r.DB.Model(&Job{}).Where("id = ? AND status = ? AND worker_id = ?", id, JobStatusRunning, workerID)
+}
+
+func (r *JobRepository) RequeueStale(staleBefore time.Time) (int, error) {
+	const staleError = "the worker running the job stopped"
+	now := time.Now()
+	var count int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		failed := tx.Model(&Job{}).
+			Where("status = ? AND heartbeat_at < ? AND attempts >= max_attempts", JobStatusRunning, staleBefore).
+			Updates(map[string]interface{}{"status": JobStatusFailed, "error": staleError, "worker_id": "", "finished_at": now})
+		if failed.Error != nil {
+			return failed.Error
+		}
+		requeued := tx.Model(&Job{}).
+			Where("status = ? AND heartbeat_at < ?", JobStatusRunning, staleBefore).
+			Updates(map[string]interface{}{"status": JobStatusQueued, "error": staleError, "worker_id": "", "run_after": now})
+		count = failed.RowsAffected + requeued.RowsAffected
+		return requeued.Error
+	})
+	if err != nil {
+		r.Logger.Error("Error requeuing stale jobs", zap.Error(err))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if count > 0 {
+		r.Logger.Warn("Recovered stale jobs", zap.Int64("count", count))
+	}
+	return int(count), nil
+}
+
+func (r *JobRepository) RequestCancel(id int) (bool, error) {
+	var changed int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		queued := tx.Model(&Job{}).Where("id = ? AND status = ?", id, JobStatusQueued).
+			Updates(map[string]interface{}{"status": JobStatusCancelled, "cancel_requested": true, "finished_at": time.Now()})
+		if queued.Error != nil {
+			return queued.Error
+		}
+		running := tx.Model(&Job{}).Where("id = ? AND status = ?", id, JobStatusRunning).Update("cancel_requested", true)
+		changed = queued.RowsAffected + running.RowsAffected
+		return running.Error
+	})
+	if err != nil {
+		r.Logger.Error("Error cancelling job", zap.Error(err), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return changed > 0, nil
+}
+
+func (r *JobRepository) Retry(id int) (bool, error) {
+	result := r.DB.Model(&Job{}).
+		Where("id = ? AND status IN ?", id, []string{JobStatusFailed, JobStatusCancelled}).
+		Updates(map[string]interface{}{
+			"status":           JobStatusQueued,
+			"attempts":         0,
+			"progress":         0,
+			"error":            "",
+			"cancel_requested": false,
+			"run_after":        time.Now(),
+			"finished_at":      nil,
+		})
+	if result.Error != nil {
+		r.Logger.Error("Error retrying job", zap.Error(result.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Mappers
+func (j *Job) toDomainMapper() *domainProvider.Job {
+	return &domainProvider.Job{
+		ID:              j.ID,
+		Type:            j.Type,
+		Payload:         j.Payload,
+		Status:          j.Status,
+		Progress:        j.Progress,
+		Result:          j.Result,
+		Error:           j.Error,
+		Attempts:        j.Attempts,
+		MaxAttempts:     j.MaxAttempts,
+		RunAfter:        j.RunAfter,
+		CancelRequested: j.CancelRequested,
+		WorkerID:        j.WorkerID,
+		HeartbeatAt:     j.HeartbeatAt,
+		CreatedBy:       j.CreatedBy,
+		CreatedAt:       j.CreatedAt,
+		UpdatedAt:       j.UpdatedAt,
+		StartedAt:       j.StartedAt,
+		FinishedAt:      j.FinishedAt,
+	}
+}
+
+func jobFromDomainMapper(j *domainProvider.Job) *Job {
+	return &Job{
+		ID:              j.ID,
+		Type:            j.Type,
+		Payload:         j.Payload,
+		Status:          j.Status,
+		Progress:        j.Progress,
+		Result:          j.Result,
+		Error:           j.Error,
+		Attempts:        j.Attempts,
+		MaxAttempts:     j.MaxAttempts,
+		RunAfter:        j.RunAfter,
+		CancelRequested: j.CancelRequested,
+		WorkerID:        j.WorkerID,
+		HeartbeatAt:     j.HeartbeatAt,
+		CreatedBy:       j.CreatedBy,
+		CreatedAt:       j.CreatedAt,
+		UpdatedAt:       j.UpdatedAt,
+		StartedAt:       j.StartedAt,
+		FinishedAt:      j.FinishedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maintenanceModeID is the ID of the single row holding the maintenance mode
+const maintenanceModeID = 1
+
+// MaintenanceMode is the database model of the maintenance mode, shared by the instances
+type MaintenanceMode struct {
+	ID        int       `gorm:"primaryKey;autoIncrement:false"`
+	Enabled   bool      `gorm:"column:enabled"`
+	Reason    string    `gorm:"column:reason;size:255"`
+	UpdatedBy int       `gorm:"column:updated_by"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (MaintenanceMode) TableName() string {
+	return "maintenance_mode"
+}
+
+// MaintenanceModeRepositoryInterface defines the interface for reading and changing the maintenance mode
+type MaintenanceModeRepositoryInterface interface {
+	// Get returns the maintenance mode, disabled while it was never changed
+	Get() (*domainProvider.MaintenanceMode, error)
+	Save(mode *domainProvider.MaintenanceMode) (*domainProvider.MaintenanceMode, error)
+}
+
+type MaintenanceModeRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewMaintenanceModeRepository(db *gorm.DB, loggerInstance *logger.Logger) MaintenanceModeRepositoryInterface {
+	return &MaintenanceModeRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *MaintenanceModeRepository) Get() (*domainProvider.MaintenanceMode, error) {
+	var mode MaintenanceMode
+	err := r.DB.Where("id = ?", maintenanceModeID).First(&mode).Error
+	if err == gorm.ErrRecordNotFound {
+		return &domainProvider.MaintenanceMode{}, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error getting maintenance mode", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return mode.toDomainMapper(), nil
+}
+
+func (r *MaintenanceModeRepository) Save(modeDomain *domainProvider.MaintenanceMode) (*domainProvider.MaintenanceMode, error) {
+	mode := &MaintenanceMode{ID: maintenanceModeID, Enabled: modeDomain.Enabled, Reason: modeDomain.Reason, UpdatedBy: modeDomain.UpdatedBy}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "reason", "updated_by", "updated_at"}),
+	}).Create(mode).Error
+	if err != nil {
+		r.Logger.Error("Error saving maintenance mode", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return mode.toDomainMapper(), nil
+}
+
+// Mappers
+func (m *MaintenanceMode) toDomainMapper() *domainProvider.MaintenanceMode {
+	updatedAt := m.UpdatedAt
+	return &domainProvider.MaintenanceMode{
+		Enabled:   m.Enabled,
+		Reason:    m.Reason,
+		UpdatedBy: m.UpdatedBy,
+		UpdatedAt: &updatedAt,
+	}
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MessageArchiveBatch is the database model for the files of message history archived to cold storage. The day
+// is stored as its ISO date, so it doesn't move with the time zone of the connection.
+type MessageArchiveBatch struct {
+	ID        int       `gorm:"primaryKey"`
+	Org       string    `gorm:"column:org;type:varchar(64)"`
+	Day       string    `gorm:"column:day;type:char(10);index"`
+	Target    string    `gorm:"column:target;type:varchar(8)"`
+	FirstID   int       `gorm:"column:first_id"`
+	LastID    int       `gorm:"column:last_id;uniqueIndex"`
+	Records   int       `gorm:"column:records"`
+	Location  string    `gorm:"column:location;type:text"`
+	Deleted   bool      `gorm:"column:deleted;default:false;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (MessageArchiveBatch) TableName() string {
+	return "message_archive_batches"
+}
+
+// MessageArchiveBatchRepositoryInterface defines the interface for the archived files of message history
+type MessageArchiveBatchRepositoryInterface interface {
+	Create(batch *domainProvider.MessageArchiveBatch) (*domainProvider.MessageArchiveBatch, error)
+	// GetCheckpoint returns the last archived batch, nil when nothing was archived yet
+	GetCheckpoint() (*domainProvider.MessageArchiveBatch, error)
+	// GetFirst returns the first archived batch, nil when nothing was archived yet
+	GetFirst() (*domainProvider.MessageArchiveBatch, error)
+	// GetBetween returns the batches of the days from through to, newest first. Zero times leave the range open.
+	GetBetween(from time.Time, to time.Time, limit int) ([]domainProvider.MessageArchiveBatch, error)
+	CountBetween(from time.Time, to time.Time) (int64, error)
+	// GetUndeleted returns the oldest batches whose entries are still in the database
+	GetUndeleted(limit int) ([]domainProvider.MessageArchiveBatch, error)
+	MarkDeleted(id int) error
+}
+
+type MessageArchiveBatchRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewMessageArchiveBatchRepository(db *gorm.DB, loggerInstance *logger.Logger) MessageArchiveBatchRepositoryInterface {
+	return &MessageArchiveBatchRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *MessageArchiveBatchRepository) Create(batchDomain *domainProvider.MessageArchiveBatch) (*domainProvider.MessageArchiveBatch, error) {
+	batch := messageArchiveBatchFromDomainMapper(batchDomain)
+	if err := r.DB.Create(batch).Error; err != nil {
+		r.Logger.Error("Error creating message archive batch", zap.Error(err), zap.Int("firstID", batchDomain.FirstID))
+		return &domainProvider.MessageArchiveBatch{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return batch.toDomainMapper(), nil
+}
+
+func (r *MessageArchiveBatchRepository) GetCheckpoint() (*domainProvider.MessageArchiveBatch, error) {
+	return r.getEdge("last_id DESC")
+}
+
+func (r *MessageArchiveBatchRepository) GetFirst() (*domainProvider.MessageArchiveBatch, error) {
+	return r.getEdge("last_id ASC")
+}
+
+func (r *MessageArchiveBatchRepository) getEdge(order string) (*domainProvider.MessageArchiveBatch, error) {
+	var batch MessageArchiveBatch
+	err := r.DB.Order(order).First(&batch).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error getting message archive batch", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return batch.toDomainMapper(), nil
+}
+
+func (r *MessageArchiveBatchRepository) GetBetween(from time.Time, to time.Time, limit int) ([]domainProvider.MessageArchiveBatch, error) {
+	var batches []MessageArchiveBatch
+	if err := r.between(from, to).Order("day DESC, last_id DESC").Limit(limit).Find(&batches).Error; err != nil {
+		r.Logger.Error("Error getting message archive batches", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageArchiveBatchArrayToDomainMapper(batches), nil
+}
+
+func (r *MessageArchiveBatchRepository) CountBetween(from time.Time, to time.Time) (int64, error) {
+	var count int64
+	if err := r.between(from, to).Model(&MessageArchiveBatch{}).Count(&count).Error; err != nil {
+		r.Logger.Error("Error counting message archive batches", zap.Error(err))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return count, nil
+}
+
+func (r *MessageArchiveBatchRepository) between(from time.Time, to time.Time) *gorm.DB {
+	query := r.DB
+	if !from.IsZero() {
+		query = query.Where("day >= ?", from.UTC().Format(time.DateOnly))
+	}
+	if !to.IsZero() {
+		query = query.Where("day <= ?", to.UTC().Format(time.DateOnly))
+	}
+	return query
+}
+
+func (r *MessageArchiveBatchRepository) GetUndeleted(limit int) ([]domainProvider.MessageArchiveBatch, error) {
+	var batches []MessageArchiveBatch
+	if err := r.DB.Where("deleted = ?", false).Order("last_id ASC").Limit(limit).Find(&batches).Error; err != nil {
+		r.Logger.Error("Error getting undeleted message archive batches", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageArchiveBatchArrayToDomainMapper(batches), nil
+}
+
+func (r *MessageArchiveBatchRepository) MarkDeleted(id int) error {
+	if err := r.DB.Model(&MessageArchiveBatch{}).Where("id = ?", id).Update("deleted", true).Error; err != nil {
+		r.Logger.Error("Error marking message archive batch deleted", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// Mappers
+func (b *MessageArchiveBatch) toDomainMapper() *domainProvider.MessageArchiveBatch {
+	day, _ := time.Parse(time.DateOnly, b.Day)
+	return &domainProvider.MessageArchiveBatch{
+		ID:        b.ID,
+		Org:       b.Org,
+		Day:       day,
+		Target:    b.Target,
+		FirstID:   b.FirstID,
+		LastID:    b.LastID,
+		Records:   b.Records,
+		Location:  b.Location,
+		Deleted:   b.Deleted,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func messageArchiveBatchFromDomainMapper(b *domainProvider.MessageArchiveBatch) *MessageArchiveBatch {
+	return &MessageArchiveBatch{
+		ID:        b.ID,
+		Org:       b.Org,
+		Day:       b.Day.UTC().Format(time.DateOnly),
+		Target:    b.Target,
+		FirstID:   b.FirstID,
+		LastID:    b.LastID,
+		Records:   b.Records,
+		Location:  b.Location,
+		Deleted:   b.Deleted,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func messageArchiveBatchArrayToDomainMapper(batches []MessageArchiveBatch) []domainProvider.MessageArchiveBatch {
+	result := make([]domainProvider.MessageArchiveBatch, len(batches))
+	for i := range batches {
+		result[i] = *batches[i].toDomainMapper()
+	}
+	return result
+}
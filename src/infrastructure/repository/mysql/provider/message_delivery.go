@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery statuses of a message to a recipient
+const (
+	DeliveryStatusSent      = "sent"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusRead      = "read"
+	DeliveryStatusFailed    = "failed"
+)
+
+// deliveryStatusRank orders the delivery statuses, callbacks arriving out of order never move a delivery back.
+// Delivered and failed are both final, whichever is reported first is kept.
+var deliveryStatusRank = map[string]int{
+	DeliveryStatusSent:      1,
+	DeliveryStatusDelivered: 2,
+	DeliveryStatusFailed:    2,
+	DeliveryStatusRead:      3,
+}
+
+// MessageDelivery is the database model for the delivery of a message to a recipient
+type MessageDelivery struct {
+	ID                   int        `gorm:"primaryKey"`
+	MessageTransactionID int        `gorm:"column:message_transaction_id;uniqueIndex:idx_message_delivery_recipient"`
+	ProviderID           int        `gorm:"column:provider_id;index:idx_message_delivery_provider_message"`
+	Recipient            string     `gorm:"column:recipient;size:191;uniqueIndex:idx_message_delivery_recipient"`
+	ProviderMessageID    string     `gorm:"column:provider_message_id;size:191;index:idx_message_delivery_provider_message"`
+	Status               string     `gorm:"column:status;size:20"`
+	ErrorCode            string     `gorm:"column:error_code;size:64"`
+	ErrorMessage         string     `gorm:"column:error_message;type:text"`
+	ReadReceipts         *bool      `gorm:"column:read_receipts;not null;default:true"` // a pointer, false isn't replaced by the default
+	DeliveredAt          *time.Time `gorm:"column:delivered_at"`
+	ReadAt               *time.Time `gorm:"column:read_at"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (MessageDelivery) TableName() string {
+	return "message_deliveries"
+}
+
+// MessageDeliveryRepositoryInterface defines the interface for the deliveries of messages to their recipients
+type MessageDeliveryRepositoryInterface interface {
+	// RecordSent records the messages handed to the provider for each recipient, a message sent again replaces
+	// the delivery of its earlier send
+	RecordSent(deliveries []domainProvider.MessageDelivery) error
+	// Apply updates the delivery a callback of a provider reports on. It returns the delivery and whether its
+	// status changed, updates older than the current status are ignored. Read updates of deliveries without read
+	// receipts confirm the delivery only.
+	Apply(providerID int, update domainProvider.DeliveryUpdate) (*domainProvider.MessageDelivery, bool, error)
+	GetByMessageTransactionID(messageTransactionID int) (*[]domainProvider.MessageDelivery, error)
+}
+
+type MessageDeliveryRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewMessageDeliveryRepository(db *gorm.DB, loggerInstance *logger.Logger) MessageDeliveryRepositoryInterface {
+	return &MessageDeliveryRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *MessageDeliveryRepository) RecordSent(deliveriesDomain []domainProvider.MessageDelivery) error {
+	if len(deliveriesDomain) == 0 {
+		return nil
+	}
+	deliveries := make([]MessageDelivery, len(deliveriesDomain))
+	for i := range deliveriesDomain {
+		deliveries[i] = *messageDeliveryFromDomainMapper(&deliveriesDomain[i])
+		deliveries[i].Status = DeliveryStatusSent
+	}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_transaction_id"}, {Name: "recipient"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider_id", "provider_message_id", "status", "error_code", "error_message", "read_receipts", "delivered_at", "read_at", "updated_at"}),
+	}).Create(&deliveries).Error
+	if err != nil {
+		r.Logger.Error("Error recording message deliveries", zap.Error(err), zap.Int("messageID", deliveriesDomain[0].MessageTransactionID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *MessageDeliveryRepository) Apply(providerID int, update domainProvider.DeliveryUpdate) (*domainProvider.MessageDelivery, bool, error) {
+	if _, ok := deliveryStatusRank[update.Status]; !ok {
+		return nil, false, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+	delivery, err := r.find(providerID, update)
+	if err != nil {
+		return nil, false, err
+	}
+	if update.Status == DeliveryStatusRead && delivery.ReadReceipts != nil && !*delivery.ReadReceipts {
+		update.Status = DeliveryStatusDelivered
+	}
+	rank := deliveryStatusRank[update.Status]
+
+	var lowerStatuses []string
+	for status, statusRank := range deliveryStatusRank {
+		if statusRank < rank {
+			lowerStatuses = append(lowerStatuses, status)
+		}
+	}
+	if len(lowerStatuses) == 0 {
+		return delivery.toDomainMapper(), false, nil
+	}
+	updates := map[string]interface{}{
+		"status":        update.Status,
+		"error_code":    update.ErrorCode,
+		"error_message": update.ErrorMessage,
+	}
+	// A read message was delivered too, even when its delivery receipt never came
+	now := time.Now()
+	if (update.Status == DeliveryStatusDelivered || update.Status == DeliveryStatusRead) && delivery.DeliveredAt == nil {
+		updates["delivered_at"] = now
+	}
+	if update.Status == DeliveryStatusRead {
+		updates["read_at"] = now
+	}
+	result := r.DB.Model(&MessageDelivery{}).
+		Where("id = ? AND status IN ?", delivery.ID, lowerStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		r.Logger.Error("Error updating message delivery", zap.Error(result.Error), zap.Int("id", delivery.ID))
+		return nil, false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if result.RowsAffected == 0 {
+		return delivery.toDomainMapper(), false, nil
+	}
+	delivery.Status = update.Status
+	delivery.ErrorCode = update.ErrorCode
+	delivery.ErrorMessage = update.ErrorMessage
+	if _, ok := updates["delivered_at"]; ok {
+		delivery.DeliveredAt = &now
+	}
+	if _, ok := updates["read_at"]; ok {
+		delivery.ReadAt = &now
+	}
+	return delivery.toDomainMapper(), true, nil
+}
+
+// find looks a delivery up by the ID the provider gave the message, then by message transaction and recipient
+func (r *MessageDeliveryRepository) find(providerID int, update domainProvider.DeliveryUpdate) (*MessageDelivery, error) {
+	var delivery MessageDelivery
+	err := gorm.ErrRecordNotFound
+	if update.ProviderMessageID != "" {
+		err = r.DB.Where("provider_id = ? AND provider_message_id = ?", providerID, update.ProviderMessageID).First(&delivery).Error
+	}
+	if err == gorm.ErrRecordNotFound && update.MessageTransactionID != 0 {
+		err = r.DB.Where("message_transaction_id = ? AND recipient = ? AND provider_id = ?",
+			update.MessageTransactionID, update.Recipient, providerID).First(&delivery).Error
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting message delivery", zap.Error(err), zap.Int("providerID", providerID),
+			zap.String("providerMessageID", update.ProviderMessageID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return &delivery, nil
+}
+
+// GetByMessageTransactionID returns the deliveries of a message in the order of its recipients
+func (r *MessageDeliveryRepository) GetByMessageTransactionID(messageTransactionID int) (*[]domainProvider.MessageDelivery, error) {
+	var deliveries []MessageDelivery
+	if err := r.DB.Where("message_transaction_id = ?", messageTransactionID).Order("id").Find(&deliveries).Error; err != nil {
+		r.Logger.Error("Error getting message deliveries", zap.Error(err), zap.Int("messageID", messageTransactionID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.MessageDelivery, len(deliveries))
+	for i := range deliveries {
+		result[i] = *deliveries[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (d *MessageDelivery) toDomainMapper() *domainProvider.MessageDelivery {
+	return &domainProvider.MessageDelivery{
+		ID:                   d.ID,
+		MessageTransactionID: d.MessageTransactionID,
+		ProviderID:           d.ProviderID,
+		Recipient:            d.Recipient,
+		ProviderMessageID:    d.ProviderMessageID,
+		Status:               d.Status,
+		ErrorCode:            d.ErrorCode,
+		ErrorMessage:         d.ErrorMessage,
+		ReadReceipts:         d.ReadReceipts == nil || *d.ReadReceipts,
+		DeliveredAt:          d.DeliveredAt,
+		ReadAt:               d.ReadAt,
+		CreatedAt:            d.CreatedAt,
+		UpdatedAt:            d.UpdatedAt,
+	}
+}
+
+func messageDeliveryFromDomainMapper(d *domainProvider.MessageDelivery) *MessageDelivery {
+	readReceipts := d.ReadReceipts
+	return &MessageDelivery{
+		ID:                   d.ID,
+		MessageTransactionID: d.MessageTransactionID,
+		ProviderID:           d.ProviderID,
+		Recipient:            d.Recipient,
+		ProviderMessageID:    d.ProviderMessageID,
+		Status:               d.Status,
+		ErrorCode:            d.ErrorCode,
+		ErrorMessage:         d.ErrorMessage,
+		ReadReceipts:         &readReceipts,
+		DeliveredAt:          d.DeliveredAt,
+		ReadAt:               d.ReadAt,
+	}
+}
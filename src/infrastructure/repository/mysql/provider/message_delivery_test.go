@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMessageDeliveryRepository(t *testing.T) (MessageDeliveryRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return NewMessageDeliveryRepository(gormDB, &logger.Logger{Log: zap.NewNop()}), mock
+}
+
+func TestApply_ReadWithoutReadReceiptsConfirmsDelivery(t *testing.T) {
+	repository, mock := setupMessageDeliveryRepository(t)
+	columns := []string{"id", "message_transaction_id", "provider_id", "recipient", "provider_message_id", "status", "read_receipts"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `message_deliveries` WHERE provider_id = ? AND provider_message_id = ?")).
+		WithArgs(3, "1700000000000:+491111", 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(5, 10, 3, "+491111", "1700000000000:+491111", DeliveryStatusSent, false))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_deliveries` SET `delivered_at`=?,`error_code`=?,`error_message`=?,`status`=?")).
+		WithArgs(sqlmock.AnyArg(), "", "", DeliveryStatusDelivered, sqlmock.AnyArg(), 5, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	delivery, changed, err := repository.Apply(3, domainProvider.DeliveryUpdate{ProviderMessageID: "1700000000000:+491111", Status: DeliveryStatusRead})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, DeliveryStatusDelivered, delivery.Status)
+	assert.False(t, delivery.ReadReceipts)
+	assert.NotNil(t, delivery.DeliveredAt)
+	assert.Nil(t, delivery.ReadAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
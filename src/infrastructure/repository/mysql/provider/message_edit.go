@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MessageEdit is the database model for the edits of sent messages
+type MessageEdit struct {
+	ID                   int       `gorm:"primaryKey"`
+	MessageTransactionID int       `gorm:"column:message_transaction_id;index"`
+	PreviousMessage      string    `gorm:"column:previous_message;type:text"`
+	Message              string    `gorm:"column:message;type:text"`
+	ResponseData         string    `gorm:"column:response_data;type:text"`
+	CreatedAt            time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (MessageEdit) TableName() string {
+	return "message_edits"
+}
+
+// MessageEditRepositoryInterface defines the interface for the edit history of sent messages
+type MessageEditRepositoryInterface interface {
+	Create(edit *domainProvider.MessageEdit) (*domainProvider.MessageEdit, error)
+	// GetByMessageTransactionID returns the edits of a message, the oldest first
+	GetByMessageTransactionID(messageTransactionID int) (*[]domainProvider.MessageEdit, error)
+}
+
+type MessageEditRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewMessageEditRepository(db *gorm.DB, loggerInstance *logger.Logger) MessageEditRepositoryInterface {
+	return &MessageEditRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *MessageEditRepository) Create(edit *domainProvider.MessageEdit) (*domainProvider.MessageEdit, error) {
+	model := messageEditFromDomainMapper(edit)
+	if err := r.DB.Create(model).Error; err != nil {
+		r.Logger.Error("Error creating message edit", zap.Error(err), zap.Int("messageID", edit.MessageTransactionID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return model.toDomainMapper(), nil
+}
+
+func (r *MessageEditRepository) GetByMessageTransactionID(messageTransactionID int) (*[]domainProvider.MessageEdit, error) {
+	var edits []MessageEdit
+	if err := r.DB.Where("message_transaction_id = ?", messageTransactionID).Order("id").Find(&edits).Error; err != nil {
+		r.Logger.Error("Error getting message edits", zap.Error(err), zap.Int("messageID", messageTransactionID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.MessageEdit, len(edits))
+	for i := range edits {
+		result[i] = *edits[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (e *MessageEdit) toDomainMapper() *domainProvider.MessageEdit {
+	return &domainProvider.MessageEdit{
+		ID:                   e.ID,
+		MessageTransactionID: e.MessageTransactionID,
+		PreviousMessage:      e.PreviousMessage,
+		Message:              e.Message,
+		ResponseData:         e.ResponseData,
+		CreatedAt:            e.CreatedAt,
+	}
+}
+
+func messageEditFromDomainMapper(e *domainProvider.MessageEdit) *MessageEdit {
+	return &MessageEdit{
+		ID:                   e.ID,
+		MessageTransactionID: e.MessageTransactionID,
+		PreviousMessage:      e.PreviousMessage,
+		Message:              e.Message,
+		ResponseData:         e.ResponseData,
+	}
+}
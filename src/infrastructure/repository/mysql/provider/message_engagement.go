@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Engagement events of a recipient with a message
+const (
+	EngagementSent      = "sent"
+	EngagementDelivered = "delivered"
+	EngagementRead      = "read"
+	EngagementClicked   = "clicked"
+)
+
+// Groups of an engagement rollup
+const (
+	// EngagementGroupProvider groups the engagement by the ID of the provider that sent the messages
+	EngagementGroupProvider = "provider"
+	// EngagementGroupTag groups the engagement by the value of a tag key
+	EngagementGroupTag = "tag"
+)
+
+// engagementColumns are the columns recording the time of each engagement event, with the columns of the events
+// it implies: a recipient who read a message or clicked one of its links received it
+var engagementColumns = map[string][]string{
+	EngagementSent:      {"sent_at"},
+	EngagementDelivered: {"delivered_at"},
+	EngagementRead:      {"read_at", "delivered_at"},
+	EngagementClicked:   {"clicked_at", "delivered_at"},
+}
+
+// MessageEngagement is the database model for the engagement of a recipient with a message
+type MessageEngagement struct {
+	ID                   int        `gorm:"primaryKey"`
+	MessageTransactionID int        `gorm:"column:message_transaction_id;uniqueIndex:idx_message_engagement_recipient"`
+	UserID               int        `gorm:"column:user_id;index:idx_message_engagement_user_created"`
+	ProviderID           int        `gorm:"column:provider_id"`
+	Recipient            string     `gorm:"column:recipient;size:191;uniqueIndex:idx_message_engagement_recipient"`
+	Tags                 string     `gorm:"column:tags;type:text"`
+	SentAt               *time.Time `gorm:"column:sent_at"`
+	DeliveredAt          *time.Time `gorm:"column:delivered_at"`
+	ReadAt               *time.Time `gorm:"column:read_at"`
+	ClickedAt            *time.Time `gorm:"column:clicked_at"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime:mili;index:idx_message_engagement_user_created"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (MessageEngagement) TableName() string {
+	return "message_engagements"
+}
+
+// MessageEngagementRepositoryInterface defines the interface for the engagement of recipients with messages
+type MessageEngagementRepositoryInterface interface {
+	// Record records an engagement event of a recipient. Events arrive in any order, the first time of each
+	// event is kept.
+	Record(event domainProvider.EngagementEvent) error
+	// GetUserRollup counts the engagement of the recipients of a user's messages first engaged with between from
+	// (inclusive) and to (exclusive), grouped by provider or by the value of a tag key. Messages without the tag
+	// are skipped.
+	GetUserRollup(userID int, groupBy string, tagKey string, from time.Time, to time.Time) (*[]domainProvider.EngagementRollup, error)
+}
+
+type MessageEngagementRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewMessageEngagementRepository(db *gorm.DB, loggerInstance *logger.Logger) MessageEngagementRepositoryInterface {
+	return &MessageEngagementRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *MessageEngagementRepository) Record(event domainProvider.EngagementEvent) error {
+	columns, ok := engagementColumns[event.Event]
+	if !ok {
+		return domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+	at := event.OccurredAt
+	engagement := MessageEngagement{
+		MessageTransactionID: event.MessageTransactionID,
+		UserID:               event.UserID,
+		ProviderID:           event.ProviderID,
+		Recipient:            event.Recipient,
+		Tags:                 event.Tags,
+	}
+	updates := map[string]interface{}{}
+	for _, column := range columns {
+		switch column {
+		case "sent_at":
+			engagement.SentAt = &at
+		case "delivered_at":
+			engagement.DeliveredAt = &at
+		case "read_at":
+			engagement.ReadAt = &at
+		case "clicked_at":
+			engagement.ClickedAt = &at
+		}
+		updates[column] = gorm.Expr("COALESCE(" + column + ", VALUES(" + column + "))")
+	}
+	if event.Event == EngagementSent {
+		// Only the send knows the tags and the provider of every event, the other events may have been recorded
+		// before it
+		updates["tags"] = gorm.Expr("VALUES(tags)")
+		updates["provider_id"] = gorm.Expr("VALUES(provider_id)")
+	}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_transaction_id"}, {Name: "recipient"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&engagement).Error
+	if err != nil {
+		r.Logger.Error("Error recording message engagement", zap.Error(err), zap.Int("messageID", event.MessageTransactionID),
+			zap.String("event", event.Event))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *MessageEngagementRepository) GetUserRollup(userID int, groupBy string, tagKey string, from time.Time, to time.Time) (*[]domainProvider.EngagementRollup, error) {
+	query := r.DB.Model(&MessageEngagement{}).Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to)
+	groupExpr := "CAST(provider_id AS CHAR)"
+	var groupArgs []interface{}
+	if groupBy == EngagementGroupTag {
+		groupExpr = "JSON_UNQUOTE(JSON_EXTRACT(" + tagsJSONExpr + ", ?))"
+		groupArgs = append(groupArgs, tagJSONPath(tagKey))
+		query = query.Where("JSON_EXTRACT("+tagsJSONExpr+", ?) IS NOT NULL", tagJSONPath(tagKey))
+	}
+
+	type engagementCount struct {
+		GroupValue string
+		Recipients int
+		Delivered  int
+		ReadCount  int
+		Clicked    int
+	}
+	var counts []engagementCount
+	err := query.
+		Select(groupExpr+" AS group_value, COUNT(*) AS recipients, COUNT(delivered_at) AS delivered, "+
+			"COUNT(read_at) AS read_count, COUNT(clicked_at) AS clicked", groupArgs...).
+		Group("group_value").
+		Order("group_value").
+		Scan(&counts).Error
+	if err != nil {
+		r.Logger.Error("Error getting user engagement rollup", zap.Error(err), zap.Int("userID", userID), zap.String("groupBy", groupBy))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	rollups := make([]domainProvider.EngagementRollup, len(counts))
+	for i, c := range counts {
+		rollups[i] = domainProvider.EngagementRollup{
+			Group:      c.GroupValue,
+			Recipients: c.Recipients,
+			Delivered:  c.Delivered,
+			Read:       c.ReadCount,
+			Clicked:    c.Clicked,
+		}
+	}
+	return &rollups, nil
+}
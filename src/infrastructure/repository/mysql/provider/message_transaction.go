@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	domainErrors "go-multi-chat-api/src/domain/errors"
@@ -9,25 +12,40 @@ import (
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MessageTransaction is the database model for message transactions
 type MessageTransaction struct {
-	ID           int        `gorm:"primaryKey"`
-	UserID       int        `gorm:"column:user_id;index"`
-	ProviderID   int        `gorm:"column:provider_id;index"`
-	Recipients   string     `gorm:"column:recipients;type:text"`
-	Message      string     `gorm:"column:message;type:text"`
-	RequestData  string     `gorm:"column:request_data;type:text"`
-	ResponseData string     `gorm:"column:response_data;type:text"`
-	Status       string     `gorm:"column:status;index"`
-	ErrorMessage string     `gorm:"column:error_message;type:text"`
-	RetryCount   int        `gorm:"column:retry_count;default:0"`
-	NextRetryAt  *time.Time `gorm:"column:next_retry_at;index"`
-	Processing   bool       `gorm:"column:processing;default:false;index"`
-	ProcessedAt  *time.Time `gorm:"column:processed_at"`
-	CreatedAt    time.Time  `gorm:"autoCreateTime:mili"`
-	UpdatedAt    time.Time  `gorm:"autoUpdateTime:mili"`
+	ID                   int        `gorm:"primaryKey"`
+	UserID               int        `gorm:"column:user_id;index"`
+	ProviderID           int        `gorm:"column:provider_id;index"`
+	Recipients           string     `gorm:"column:recipients;type:text"`
+	Message              string     `gorm:"column:message;type:text"`
+	Tags                 string     `gorm:"column:tags;type:text"`
+	Extensions           string     `gorm:"column:extensions;type:mediumtext"`
+	RequestData          string     `gorm:"column:request_data;type:text"`
+	ResponseData         string     `gorm:"column:response_data;type:text"`
+	Status               string     `gorm:"column:status;index"`
+	ErrorMessage         string     `gorm:"column:error_message;type:text"`
+	ErrorCode            string     `gorm:"column:error_code;size:32;index"`
+	RetryCount           int        `gorm:"column:retry_count;default:0"`
+	NextRetryAt          *time.Time `gorm:"column:next_retry_at;index"`
+	Processing           bool       `gorm:"column:processing;default:false;index"`
+	ProcessedAt          *time.Time `gorm:"column:processed_at"`
+	SendStartedAt        *time.Time `gorm:"column:send_started_at"`
+	AckStatus            string     `gorm:"column:ack_status;size:16;index"`
+	AckToken             string     `gorm:"column:ack_token;size:16;index"`
+	AckKeyword           string     `gorm:"column:ack_keyword;size:32"`
+	AckDeadline          *time.Time `gorm:"column:ack_deadline;index"`
+	AckEscalationChainID int        `gorm:"column:ack_escalation_chain_id;default:0"`
+	AcknowledgedBy       string     `gorm:"column:acknowledged_by"`
+	AcknowledgedAt       *time.Time `gorm:"column:acknowledged_at"`
+	TrackLinks           bool       `gorm:"column:track_links;default:false"`
+	Segments             int        `gorm:"column:segments;default:0"`
+	Actions              string     `gorm:"column:actions;type:text"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime:mili;not null"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime:mili"`
 }
 
 func (MessageTransaction) TableName() string {
@@ -35,21 +53,35 @@ func (MessageTransaction) TableName() string {
 }
 
 var ColumnsMessageTransactionMapping = map[string]string{
-	"id":           "id",
-	"userID":       "user_id",
-	"providerID":   "provider_id",
-	"recipients":   "recipients",
-	"message":      "message",
-	"requestData":  "request_data",
-	"responseData": "response_data",
-	"status":       "status",
-	"errorMessage": "error_message",
-	"retryCount":   "retry_count",
-	"nextRetryAt":  "next_retry_at",
-	"processing":   "processing",
-	"processedAt":  "processed_at",
-	"createdAt":    "created_at",
-	"updatedAt":    "updated_at",
+	"id":                   "id",
+	"userID":               "user_id",
+	"providerID":           "provider_id",
+	"recipients":           "recipients",
+	"message":              "message",
+	"tags":                 "tags",
+	"extensions":           "extensions",
+	"requestData":          "request_data",
+	"responseData":         "response_data",
+	"status":               "status",
+	"errorMessage":         "error_message",
+	"errorCode":            "error_code",
+	"retryCount":           "retry_count",
+	"nextRetryAt":          "next_retry_at",
+	"processing":           "processing",
+	"processedAt":          "processed_at",
+	"sendStartedAt":        "send_started_at",
+	"ackStatus":            "ack_status",
+	"ackToken":             "ack_token",
+	"ackKeyword":           "ack_keyword",
+	"ackDeadline":          "ack_deadline",
+	"ackEscalationChainID": "ack_escalation_chain_id",
+	"acknowledgedBy":       "acknowledged_by",
+	"acknowledgedAt":       "acknowledged_at",
+	"trackLinks":           "track_links",
+	"segments":             "segments",
+	"actions":              "actions",
+	"createdAt":            "created_at",
+	"updatedAt":            "updated_at",
 }
 
 // MessageTransactionRepositoryInterface defines the interface for message transaction repository operations
@@ -57,28 +89,84 @@ type MessageTransactionRepositoryInterface interface {
 	Create(messageTransactionDomain *domainProvider.MessageTransaction) (*domainProvider.MessageTransaction, error)
 	GetByID(id int) (*domainProvider.MessageTransaction, error)
 	GetUserMessageTransactions(userID int) (*[]domainProvider.MessageTransaction, error)
+	// GetAfterID returns up to limit message transactions with an ID above afterID in ID order, of one user when
+	// userID is set, for walking through all messages
+	GetAfterID(afterID int, userID int, limit int) (*[]domainProvider.MessageTransaction, error)
 	Update(id int, messageTransactionMap map[string]interface{}) (*domainProvider.MessageTransaction, error)
 	GetFailedMessagesForRetry() (*[]domainProvider.MessageTransaction, error)
 	GetPendingMessages() (*[]domainProvider.MessageTransaction, error)
-	GetUndeliveredMessages() (*[]domainProvider.MessageTransaction, error)
+	GetUndeliveredMessages(sentBefore time.Time) (*[]domainProvider.MessageTransaction, error)
 	MoveToHistory(id int, historyRepository MessageTransactionHistoryRepositoryInterface) error
+	// CreateBatch, UpdateBatch and MoveToHistoryBatch write many messages with a few statements instead of one per message
+	CreateBatch(messageTransactions []domainProvider.MessageTransaction) (*[]domainProvider.MessageTransaction, error)
+	UpdateBatch(ids []int, messageTransactionMap map[string]interface{}) error
+	MoveToHistoryBatch(ids []int, historyRepository MessageTransactionHistoryRepositoryInterface) error
 	CountUserMessagesForToday(userID int) (int, error)
+	// GetRecipientSendTimes returns when the messages of a user created since the given time were sent to each of
+	// the recipients, the oldest first. Retries and cancelled messages aren't counted.
+	GetRecipientSendTimes(userID int, recipients []string, since time.Time) (map[string][]time.Time, error)
+	CountProviderMessagesSentToday(providerID int) (int, error)
+	ReleaseHeldMessages() (int, error)
+	ReleaseRateLimitedMessages() (int, error)
+	MarkSendStarted(id int) (bool, error)
+	ReleaseProcessing(ids []int) error
+	CountPendingMessages() (int, error)
+	// GetQueueMetrics counts the active messages per queue state with one grouped query
+	GetQueueMetrics() (*domainProvider.QueueMetrics, error)
+	GetStaleProcessingMessages(staleBefore time.Time) (*[]domainProvider.MessageTransaction, error)
+	RecoverStaleMessage(id int, staleBefore time.Time, messageTransactionMap map[string]interface{}) (bool, error)
+	GetPendingAckByToken(ackToken string) (*domainProvider.MessageTransaction, error)
+	GetLatestPendingAckForRecipient(recipient string, ackKeyword string) (*domainProvider.MessageTransaction, error)
+	AcknowledgeMessage(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error)
+	// GetLatestWithActionsForRecipient finds the most recent message with actions sent to a recipient since the
+	// given time, the message a numbered reply of the recipient chooses from
+	GetLatestWithActionsForRecipient(recipient string, since time.Time) (*domainProvider.MessageTransaction, error)
+	// GetBySignalTimestamp finds the message of a user Signal sent with the timestamp
+	GetBySignalTimestamp(userID int, timestamp int64) (*domainProvider.MessageTransaction, error)
+	GetExpiredAcks(now time.Time) (*[]domainProvider.MessageTransaction, error)
+	ExpireAck(id int) (bool, error)
+	// CountForBulk and GetIDsForBulk select the messages of a bulk operation, GetIDsForBulk walks through them in
+	// ID order starting after afterID
+	CountForBulk(filter domainProvider.BulkOperationFilter) (int, error)
+	GetIDsForBulk(filter domainProvider.BulkOperationFilter, afterID int, limit int) ([]int, error)
+	// RequeueBatch and CancelBatch change the messages of ids that still have the given status and return the IDs
+	// they changed
+	RequeueBatch(ids []int, status string) ([]int, error)
+	CancelBatch(ids []int, status string) ([]int, error)
+	// SuspendBatch holds the messages of ids that still have the given status as suspended until they are
+	// requeued, and returns the IDs it changed
+	SuspendBatch(ids []int, status string) ([]int, error)
+	// Restore requeues a message with the content of messageTransactionMap, e.g. from a queue snapshot, unless it
+	// no longer has the given status or a worker holds it. It reports whether the message was restored.
+	Restore(id int, status string, messageTransactionMap map[string]interface{}) (bool, error)
 }
 
+// createBatchSize is the number of rows inserted by one multi-row INSERT
+const createBatchSize = 500
+
 type MessageTransactionRepository struct {
 	DB     *gorm.DB
 	Logger *logger.Logger
+	// OutboxEnabled writes a lifecycle event to the outbox table in the same DB transaction as every status change
+	OutboxEnabled bool
 }
 
-func NewMessageTransactionRepository(db *gorm.DB, loggerInstance *logger.Logger) MessageTransactionRepositoryInterface {
-	return &MessageTransactionRepository{DB: db, Logger: loggerInstance}
+func NewMessageTransactionRepository(db *gorm.DB, loggerInstance *logger.Logger, outboxEnabled bool) MessageTransactionRepositoryInterface {
+	return &MessageTransactionRepository{DB: db, Logger: loggerInstance, OutboxEnabled: outboxEnabled}
 }
 
 func (r *MessageTransactionRepository) Create(messageTransactionDomain *domainProvider.MessageTransaction) (*domainProvider.MessageTransaction, error) {
 	r.Logger.Info("Creating new message transaction", zap.Int("userID", messageTransactionDomain.UserID), zap.Int("providerID", messageTransactionDomain.ProviderID))
 	messageTransactionRepository := messageTransactionFromDomainMapper(messageTransactionDomain)
-	txDb := r.DB.Create(messageTransactionRepository)
-	err := txDb.Error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(messageTransactionRepository).Error; err != nil {
+			return err
+		}
+		if r.OutboxEnabled {
+			return createOutboxEvent(tx, messageTransactionRepository)
+		}
+		return nil
+	})
 	if err != nil {
 		r.Logger.Error("Error creating message transaction", zap.Error(err), zap.Int("userID", messageTransactionDomain.UserID))
 		return &domainProvider.MessageTransaction{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
@@ -87,6 +175,64 @@ func (r *MessageTransactionRepository) Create(messageTransactionDomain *domainPr
 	return messageTransactionRepository.toDomainMapper(), err
 }
 
+// CreateBatch creates many message transactions in one DB transaction, with their lifecycle events when the
+// outbox is enabled, and returns them with their IDs
+func (r *MessageTransactionRepository) CreateBatch(messageTransactions []domainProvider.MessageTransaction) (*[]domainProvider.MessageTransaction, error) {
+	models := make([]MessageTransaction, len(messageTransactions))
+	for i := range messageTransactions {
+		models[i] = *messageTransactionFromDomainMapper(&messageTransactions[i])
+	}
+	if len(models) > 0 {
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.CreateInBatches(models, createBatchSize).Error; err != nil {
+				return err
+			}
+			if r.OutboxEnabled {
+				return createOutboxEvents(tx, models)
+			}
+			return nil
+		})
+		if err != nil {
+			r.Logger.Error("Error creating message transaction batch", zap.Error(err), zap.Int("count", len(models)))
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		r.Logger.Info("Successfully created message transaction batch", zap.Int("count", len(models)))
+	}
+
+	result := make([]domainProvider.MessageTransaction, len(models))
+	for i := range models {
+		result[i] = *models[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+// UpdateBatch applies the same update to many message transactions with one UPDATE. A status change only
+// changes the messages whose status can move to the new one, e.g. not those that changed in the meantime, and
+// writes their lifecycle events in the same DB transaction when the outbox is enabled.
+func (r *MessageTransactionRepository) UpdateBatch(ids []int, messageTransactionMap map[string]interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	updateData := mapMessageTransactionColumns(messageTransactionMap)
+	if status, statusChanged := updateData["status"]; statusChanged {
+		to, _ := status.(string)
+		changed, err := r.changeBatch(ids, "status IN (?)", []interface{}{domainProvider.PreviousStatuses(to)}, updateData)
+		if err != nil {
+			return err
+		}
+		r.Logger.Info("Successfully updated message transaction batch", zap.Int("count", len(changed)), zap.Int("requested", len(ids)))
+		return nil
+	}
+
+	result := r.DB.Model(&MessageTransaction{}).Where("id IN (?)", ids).Updates(updateData)
+	if result.Error != nil {
+		r.Logger.Error("Error updating message transaction batch", zap.Error(result.Error), zap.Int("count", len(ids)))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully updated message transaction batch", zap.Int64("count", result.RowsAffected), zap.Int("requested", len(ids)))
+	return nil
+}
+
 func (r *MessageTransactionRepository) GetByID(id int) (*domainProvider.MessageTransaction, error) {
 	var messageTransaction MessageTransaction
 	err := r.DB.Where("id = ?", id).First(&messageTransaction).Error
@@ -114,11 +260,77 @@ func (r *MessageTransactionRepository) GetUserMessageTransactions(userID int) (*
 	return messageTransactionArrayToDomainMapper(&messageTransactions), nil
 }
 
+func (r *MessageTransactionRepository) GetAfterID(afterID int, userID int, limit int) (*[]domainProvider.MessageTransaction, error) {
+	query := r.DB.Where("id > ?", afterID)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var messageTransactions []MessageTransaction
+	if err := query.Order("id ASC").Limit(limit).Find(&messageTransactions).Error; err != nil {
+		r.Logger.Error("Error getting message transactions after ID", zap.Error(err), zap.Int("afterID", afterID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransactionArrayToDomainMapper(&messageTransactions), nil
+}
+
 func (r *MessageTransactionRepository) Update(id int, messageTransactionMap map[string]interface{}) (*domainProvider.MessageTransaction, error) {
 	var messageTransactionObj MessageTransaction
 	messageTransactionObj.ID = id
 
-	// Map JSON field names to DB column names
+	updateData := mapMessageTransactionColumns(messageTransactionMap)
+	status, statusChanged := updateData["status"]
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if statusChanged {
+			if err := r.lockTransition(tx, id, status); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&messageTransactionObj).Updates(updateData).Error; err != nil {
+			r.Logger.Error("Error updating message transaction", zap.Error(err), zap.Int("id", id))
+			return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		if err := tx.Where("id = ?", id).First(&messageTransactionObj).Error; err != nil {
+			r.Logger.Error("Error retrieving updated message transaction", zap.Error(err), zap.Int("id", id))
+			return err
+		}
+		if r.OutboxEnabled && statusChanged {
+			if err := createOutboxEvent(tx, &messageTransactionObj); err != nil {
+				r.Logger.Error("Error writing outbox event", zap.Error(err), zap.Int("id", id))
+				return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &domainProvider.MessageTransaction{}, err
+	}
+	r.Logger.Info("Successfully updated message transaction", zap.Int("id", id))
+	return messageTransactionObj.toDomainMapper(), nil
+}
+
+// lockTransition locks a message transaction and rejects a change of its status that isn't a transition of
+// the message status state machine, e.g. cancelling a message that was sent in the meantime
+func (r *MessageTransactionRepository) lockTransition(tx *gorm.DB, id int, status interface{}) error {
+	var current MessageTransaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").Where("id = ?", id).First(&current).Error
+	if err == gorm.ErrRecordNotFound {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error locking message transaction", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	to, _ := status.(string)
+	if err := domainProvider.ValidateTransition(current.Status, to); err != nil {
+		r.Logger.Warn("Rejected message status change", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppError(err, domainErrors.Conflict)
+	}
+	return nil
+}
+
+// mapMessageTransactionColumns maps JSON field names to DB column names
+func mapMessageTransactionColumns(messageTransactionMap map[string]interface{}) map[string]interface{} {
 	updateData := make(map[string]interface{})
 	for k, v := range messageTransactionMap {
 		if column, ok := ColumnsMessageTransactionMapping[k]; ok {
@@ -127,19 +339,7 @@ func (r *MessageTransactionRepository) Update(id int, messageTransactionMap map[
 			updateData[k] = v
 		}
 	}
-
-	err := r.DB.Model(&messageTransactionObj).
-		Updates(updateData).Error
-	if err != nil {
-		r.Logger.Error("Error updating message transaction", zap.Error(err), zap.Int("id", id))
-		return &domainProvider.MessageTransaction{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
-	}
-	if err := r.DB.Where("id = ?", id).First(&messageTransactionObj).Error; err != nil {
-		r.Logger.Error("Error retrieving updated message transaction", zap.Error(err), zap.Int("id", id))
-		return &domainProvider.MessageTransaction{}, err
-	}
-	r.Logger.Info("Successfully updated message transaction", zap.Int("id", id))
-	return messageTransactionObj.toDomainMapper(), nil
+	return updateData
 }
 
 // GetFailedMessagesForRetry retrieves failed message transactions that are ready for retry
@@ -148,7 +348,7 @@ func (r *MessageTransactionRepository) GetFailedMessagesForRetry() (*[]domainPro
 
 	// Get failed messages where next_retry_at is in the past
 	now := time.Now()
-	if err := r.DB.Where("status = ? AND next_retry_at <= ?", "failed", now).
+	if err := r.DB.Where("status = ? AND next_retry_at <= ?", domainProvider.MessageStatusFailed, now).
 		Find(&messageTransactions).Error; err != nil {
 		r.Logger.Error("Error getting failed messages for retry", zap.Error(err))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
@@ -171,7 +371,7 @@ func (r *MessageTransactionRepository) GetPendingMessages() (*[]domainProvider.M
 	}
 
 	// Get messages with status "pending" that are not being processed, limited to 1000
-	if err := tx.Where("status = ? AND processing = ?", "pending", false).
+	if err := tx.Where("status = ? AND processing = ?", domainProvider.MessageStatusPending, false).
 		Limit(1000).
 		Find(&messageTransactions).Error; err != nil {
 		tx.Rollback()
@@ -222,16 +422,30 @@ func (mt *MessageTransaction) toDomainMapper() *domainProvider.MessageTransactio
 		ProviderID:   mt.ProviderID,
 		Recipients:   mt.Recipients,
 		Message:      mt.Message,
+		Tags:         mt.Tags,
+		Extensions:   mt.Extensions,
 		RequestData:  mt.RequestData,
 		ResponseData: mt.ResponseData,
 		Status:       mt.Status,
 		ErrorMessage: mt.ErrorMessage,
+		ErrorCode:    mt.ErrorCode,
 		RetryCount:   mt.RetryCount,
 		//NextRetryAt:  mt.NextRetryAt,
 		Processing: mt.Processing,
 		//ProcessedAt:  mt.ProcessedAt,
-		CreatedAt: mt.CreatedAt,
-		UpdatedAt: mt.UpdatedAt,
+		SendStartedAt:        mt.SendStartedAt,
+		AckStatus:            mt.AckStatus,
+		AckToken:             mt.AckToken,
+		AckKeyword:           mt.AckKeyword,
+		AckDeadline:          mt.AckDeadline,
+		AckEscalationChainID: mt.AckEscalationChainID,
+		AcknowledgedBy:       mt.AcknowledgedBy,
+		AcknowledgedAt:       mt.AcknowledgedAt,
+		TrackLinks:           mt.TrackLinks,
+		Segments:             mt.Segments,
+		Actions:              mt.Actions,
+		CreatedAt:            mt.CreatedAt,
+		UpdatedAt:            mt.UpdatedAt,
 	}
 }
 
@@ -242,16 +456,30 @@ func messageTransactionFromDomainMapper(mt *domainProvider.MessageTransaction) *
 		ProviderID:   mt.ProviderID,
 		Recipients:   mt.Recipients,
 		Message:      mt.Message,
+		Tags:         mt.Tags,
+		Extensions:   mt.Extensions,
 		RequestData:  mt.RequestData,
 		ResponseData: mt.ResponseData,
 		Status:       mt.Status,
 		ErrorMessage: mt.ErrorMessage,
+		ErrorCode:    mt.ErrorCode,
 		RetryCount:   mt.RetryCount,
 		//NextRetryAt:  mt.NextRetryAt,
 		Processing: mt.Processing,
 		//ProcessedAt:  mt.ProcessedAt,
-		CreatedAt: mt.CreatedAt,
-		UpdatedAt: mt.UpdatedAt,
+		SendStartedAt:        mt.SendStartedAt,
+		AckStatus:            mt.AckStatus,
+		AckToken:             mt.AckToken,
+		AckKeyword:           mt.AckKeyword,
+		AckDeadline:          mt.AckDeadline,
+		AckEscalationChainID: mt.AckEscalationChainID,
+		AcknowledgedBy:       mt.AcknowledgedBy,
+		AcknowledgedAt:       mt.AcknowledgedAt,
+		TrackLinks:           mt.TrackLinks,
+		Segments:             mt.Segments,
+		Actions:              mt.Actions,
+		CreatedAt:            mt.CreatedAt,
+		UpdatedAt:            mt.UpdatedAt,
 	}
 }
 
@@ -263,14 +491,12 @@ func messageTransactionArrayToDomainMapper(messageTransactions *[]MessageTransac
 	return &messageTransactionsDomain
 }
 
-// GetUndeliveredMessages retrieves messages that were sent successfully but not delivered within 5 minutes
-func (r *MessageTransactionRepository) GetUndeliveredMessages() (*[]domainProvider.MessageTransaction, error) {
+// GetUndeliveredMessages retrieves messages that were sent successfully before sentBefore but not delivered since
+func (r *MessageTransactionRepository) GetUndeliveredMessages(sentBefore time.Time) (*[]domainProvider.MessageTransaction, error) {
 	var messageTransactions []MessageTransaction
 
-	// Get messages that were sent successfully more than 5 minutes ago
-	fiveMinutesAgo := time.Now().Add(-5 * time.Minute)
-
-	if err := r.DB.Where("status = ? AND processing = ? AND updated_at <= ?", "success", false, fiveMinutesAgo).
+	// A message is created before it is sent, the bound on created_at skips the partitions of newer messages
+	if err := r.DB.Where("status = ? AND processing = ? AND updated_at <= ? AND created_at <= ?", domainProvider.MessageStatusSuccess, false, sentBefore, sentBefore).
 		Find(&messageTransactions).Error; err != nil {
 		r.Logger.Error("Error getting undelivered messages", zap.Error(err))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
@@ -296,11 +522,14 @@ func (r *MessageTransactionRepository) MoveToHistory(id int, historyRepository M
 		ProviderID:   messageTransaction.ProviderID,
 		Recipients:   messageTransaction.Recipients,
 		Message:      messageTransaction.Message,
+		Tags:         messageTransaction.Tags,
 		RequestData:  messageTransaction.RequestData,
 		ResponseData: messageTransaction.ResponseData,
 		Status:       messageTransaction.Status,
 		ErrorMessage: messageTransaction.ErrorMessage,
+		ErrorCode:    messageTransaction.ErrorCode,
 		RetryCount:   messageTransaction.RetryCount,
+		Segments:     messageTransaction.Segments,
 		ProcessedAt:  messageTransaction.UpdatedAt,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -317,6 +546,42 @@ func (r *MessageTransactionRepository) MoveToHistory(id int, historyRepository M
 	return nil
 }
 
+// MoveToHistoryBatch copies many message transactions to the history with multi-row INSERTs
+func (r *MessageTransactionRepository) MoveToHistoryBatch(ids []int, historyRepository MessageTransactionHistoryRepositoryInterface) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var messageTransactions []MessageTransaction
+	if err := r.DB.Where("id IN (?)", ids).Find(&messageTransactions).Error; err != nil {
+		r.Logger.Error("Error getting message transactions for history", zap.Error(err), zap.Int("count", len(ids)))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	now := time.Now()
+	histories := make([]domainProvider.MessageTransactionHistory, len(messageTransactions))
+	for i, mt := range messageTransactions {
+		histories[i] = domainProvider.MessageTransactionHistory{
+			MessageID:    mt.ID,
+			UserID:       mt.UserID,
+			ProviderID:   mt.ProviderID,
+			Recipients:   mt.Recipients,
+			Message:      mt.Message,
+			Tags:         mt.Tags,
+			RequestData:  mt.RequestData,
+			ResponseData: mt.ResponseData,
+			Status:       mt.Status,
+			ErrorMessage: mt.ErrorMessage,
+			ErrorCode:    mt.ErrorCode,
+			RetryCount:   mt.RetryCount,
+			Segments:     mt.Segments,
+			ProcessedAt:  mt.UpdatedAt,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+	}
+	return historyRepository.CreateBatch(histories)
+}
+
 // CountUserMessagesForToday counts the number of messages sent by a user on the current day
 func (r *MessageTransactionRepository) CountUserMessagesForToday(userID int) (int, error) {
 	r.Logger.Info("Counting messages sent by user today", zap.Int("userID", userID))
@@ -342,3 +607,492 @@ func (r *MessageTransactionRepository) CountUserMessagesForToday(userID int) (in
 
 	return int(count), nil
 }
+
+func (r *MessageTransactionRepository) GetRecipientSendTimes(userID int, recipients []string, since time.Time) (map[string][]time.Time, error) {
+	sendTimes := make(map[string][]time.Time)
+	if len(recipients) == 0 {
+		return sendTimes, nil
+	}
+	wanted := make(map[string]bool, len(recipients))
+	conditions := make([]string, len(recipients))
+	patterns := make([]interface{}, len(recipients))
+	for i, recipient := range recipients {
+		wanted[recipient] = true
+		recipientJSON, _ := json.Marshal(recipient)
+		conditions[i] = "recipients LIKE ?"
+		patterns[i] = "%" + string(recipientJSON) + "%"
+	}
+
+	var rows []MessageTransaction
+	if err := r.DB.Select("recipients", "created_at").
+		Where("user_id = ? AND created_at >= ? AND retry_count = ? AND status <> ?", userID, since, 0, domainProvider.MessageStatusCancelled).
+		Where(strings.Join(conditions, " OR "), patterns...).
+		Order("created_at ASC").
+		Find(&rows).Error; err != nil {
+		r.Logger.Error("Error getting recipient send times", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	// LIKE also matches addresses containing a recipient, only the exact recipients are counted
+	for _, row := range rows {
+		var sentTo []string
+		if err := json.Unmarshal([]byte(row.Recipients), &sentTo); err != nil {
+			continue
+		}
+		for _, recipient := range sentTo {
+			if wanted[recipient] {
+				sendTimes[recipient] = append(sendTimes[recipient], row.CreatedAt)
+			}
+		}
+	}
+	return sendTimes, nil
+}
+
+// CountProviderMessagesSentToday counts the messages successfully sent through a provider on the current UTC day
+func (r *MessageTransactionRepository) CountProviderMessagesSentToday(providerID int) (int, error) {
+	now := time.Now().UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	endOfDay := startOfDay.Add(24 * time.Hour)
+
+	var count int64
+	err := r.DB.Model(&MessageTransaction{}).
+		Where("provider_id = ? AND status IN (?) AND updated_at >= ? AND updated_at < ? AND created_at < ?", providerID, []string{domainProvider.MessageStatusSuccess, domainProvider.MessageStatusDelivered}, startOfDay, endOfDay, endOfDay).
+		Count(&count).Error
+
+	if err != nil {
+		r.Logger.Error("Error counting provider messages sent today", zap.Error(err), zap.Int("providerID", providerID))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	return int(count), nil
+}
+
+// ReleaseHeldMessages moves messages held by a warm-up limit or a sending schedule whose hold has expired back
+// to pending so they are picked up again
+func (r *MessageTransactionRepository) ReleaseHeldMessages() (int, error) {
+	released, err := r.releaseToPending("status IN ? AND next_retry_at <= ?",
+		[]string{domainProvider.MessageStatusHeld, domainProvider.MessageStatusHeldSchedule}, time.Now())
+	if err != nil {
+		r.Logger.Error("Error releasing held messages", zap.Error(err))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	if len(released) > 0 {
+		r.Logger.Info("Released held messages", zap.Int("count", len(released)))
+	}
+	return len(released), nil
+}
+
+// ReleaseRateLimitedMessages moves the messages blocked by a Signal rate limit back to pending so they are sent again
+func (r *MessageTransactionRepository) ReleaseRateLimitedMessages() (int, error) {
+	released, err := r.releaseToPending("status = ?", domainProvider.MessageStatusRateLimited)
+	if err != nil {
+		r.Logger.Error("Error releasing rate limited messages", zap.Error(err))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	if len(released) > 0 {
+		r.Logger.Info("Released rate limited messages", zap.Int("count", len(released)))
+	}
+	return len(released), nil
+}
+
+// releaseToPending moves the messages matching the condition that may become pending again back to pending and
+// writes their outbox events, it returns the IDs of the released messages
+func (r *MessageTransactionRepository) releaseToPending(condition string, args ...interface{}) ([]int, error) {
+	return r.changeWhere(func(query *gorm.DB) *gorm.DB {
+		return query.Where(condition, args...).
+			Where("status IN ?", domainProvider.PreviousStatuses(domainProvider.MessageStatusPending))
+	}, map[string]interface{}{
+		"status":     domainProvider.MessageStatusPending,
+		"processing": false,
+	})
+}
+
+// MarkSendStarted records that the provider call for a message is about to start. It returns false when the
+// send was already started, e.g. because the message was queued twice, so the caller must not send it again.
+func (r *MessageTransactionRepository) MarkSendStarted(id int) (bool, error) {
+	result := r.DB.Model(&MessageTransaction{}).
+		Where("id = ? AND send_started_at IS NULL", id).
+		Update("send_started_at", time.Now())
+	if result.Error != nil {
+		r.Logger.Error("Error marking message send as started", zap.Error(result.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetStaleProcessingMessages retrieves messages that were picked up for processing before staleBefore and
+// never finished, e.g. because the process crashed mid-send
+func (r *MessageTransactionRepository) GetStaleProcessingMessages(staleBefore time.Time) (*[]domainProvider.MessageTransaction, error) {
+	var messageTransactions []MessageTransaction
+	if err := r.DB.Where("processing = ? AND processed_at <= ? AND created_at <= ?", true, staleBefore, staleBefore).
+		Find(&messageTransactions).Error; err != nil {
+		r.Logger.Error("Error getting stale processing messages", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully retrieved stale processing messages", zap.Int("count", len(messageTransactions)))
+	return messageTransactionArrayToDomainMapper(&messageTransactions), nil
+}
+
+// RecoverStaleMessage applies the update only if the message is still stuck in processing since before staleBefore,
+// so a message is recovered by a single instance only. It returns whether the message was recovered.
+func (r *MessageTransactionRepository) RecoverStaleMessage(id int, staleBefore time.Time, messageTransactionMap map[string]interface{}) (bool, error) {
+	updateData := mapMessageTransactionColumns(messageTransactionMap)
+	status, statusChanged := updateData["status"]
+
+	recovered := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&MessageTransaction{}).Where("id = ? AND processing = ? AND processed_at <= ?", id, true, staleBefore)
+		if statusChanged {
+			to, _ := status.(string)
+			query = query.Where("status IN (?)", domainProvider.PreviousStatuses(to))
+		}
+		result := query.Updates(updateData)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		recovered = true
+
+		if r.OutboxEnabled && statusChanged {
+			var messageTransactionObj MessageTransaction
+			if err := tx.Where("id = ?", id).First(&messageTransactionObj).Error; err != nil {
+				return err
+			}
+			return createOutboxEvent(tx, &messageTransactionObj)
+		}
+		return nil
+	})
+	if err != nil {
+		r.Logger.Error("Error recovering stale message transaction", zap.Error(err), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return recovered, nil
+}
+
+// ReleaseProcessing clears the processing flag of messages that were locked but couldn't be queued,
+// so the pending message watcher picks them up again
+func (r *MessageTransactionRepository) ReleaseProcessing(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.DB.Model(&MessageTransaction{}).
+		Where("id IN (?) AND send_started_at IS NULL", ids).
+		Update("processing", false).Error; err != nil {
+		r.Logger.Error("Error releasing message processing locks", zap.Error(err), zap.Int("count", len(ids)))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// queueStateCount is one row of the grouped queue metrics query
+type queueStateCount struct {
+	Status       string
+	Processing   bool
+	Count        int
+	OldestCreate *time.Time
+}
+
+func (r *MessageTransactionRepository) GetQueueMetrics() (*domainProvider.QueueMetrics, error) {
+	var rows []queueStateCount
+	if err := r.DB.Model(&MessageTransaction{}).
+		Select("status, processing, COUNT(*) AS count, MIN(created_at) AS oldest_create").
+		Where("status IN ?", []string{domainProvider.MessageStatusPending, domainProvider.MessageStatusFailed,
+			domainProvider.MessageStatusHeld, domainProvider.MessageStatusHeldSchedule, domainProvider.MessageStatusRateLimited}).
+		Group("status, processing").
+		Scan(&rows).Error; err != nil {
+		r.Logger.Error("Error getting queue metrics", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	metrics := &domainProvider.QueueMetrics{}
+	for _, row := range rows {
+		switch {
+		case row.Processing:
+			metrics.Processing += row.Count
+		case row.Status == domainProvider.MessageStatusPending:
+			metrics.Pending += row.Count
+			metrics.OldestPendingAt = row.OldestCreate
+		case row.Status == domainProvider.MessageStatusFailed:
+			metrics.FailedAwaitingRetry += row.Count
+		default:
+			metrics.Held += row.Count
+		}
+	}
+	return metrics, nil
+}
+
+// CountPendingMessages counts the messages waiting to be sent across all users
+func (r *MessageTransactionRepository) CountPendingMessages() (int, error) {
+	var count int64
+	if err := r.DB.Model(&MessageTransaction{}).
+		Where("status = ?", domainProvider.MessageStatusPending).
+		Count(&count).Error; err != nil {
+		r.Logger.Error("Error counting pending messages", zap.Error(err))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return int(count), nil
+}
+
+// GetPendingAckByToken retrieves the message awaiting an acknowledgement with the given token
+func (r *MessageTransactionRepository) GetPendingAckByToken(ackToken string) (*domainProvider.MessageTransaction, error) {
+	var messageTransaction MessageTransaction
+	err := r.DB.Where("ack_status = ? AND ack_token = ?", "pending", ackToken).First(&messageTransaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting message awaiting acknowledgement", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransaction.toDomainMapper(), nil
+}
+
+// GetLatestPendingAckForRecipient retrieves the most recent message sent to a recipient that awaits an
+// acknowledgement by the given keyword
+func (r *MessageTransactionRepository) GetLatestPendingAckForRecipient(recipient string, ackKeyword string) (*domainProvider.MessageTransaction, error) {
+	// Recipients are stored as a JSON array, quoting the recipient avoids matching a prefix of another number
+	recipientJSON, _ := json.Marshal(recipient)
+
+	var messageTransaction MessageTransaction
+	err := r.DB.Where("ack_status = ? AND ack_keyword = ? AND recipients LIKE ?", "pending", ackKeyword, "%"+string(recipientJSON)+"%").
+		Order("id DESC").
+		First(&messageTransaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting message awaiting acknowledgement", zap.Error(err), zap.String("recipient", recipient))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransaction.toDomainMapper(), nil
+}
+
+// GetLatestWithActionsForRecipient retrieves the most recent message sent successfully to a recipient since the
+// given time that offers actions
+func (r *MessageTransactionRepository) GetLatestWithActionsForRecipient(recipient string, since time.Time) (*domainProvider.MessageTransaction, error) {
+	recipientJSON, _ := json.Marshal(recipient)
+
+	var messageTransaction MessageTransaction
+	err := r.DB.Where("status = ? AND actions <> '' AND created_at >= ? AND recipients LIKE ?", domainProvider.MessageStatusSuccess, since, "%"+string(recipientJSON)+"%").
+		Order("id DESC").
+		First(&messageTransaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting message with actions", zap.Error(err), zap.String("recipient", recipient))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransaction.toDomainMapper(), nil
+}
+
+// GetBySignalTimestamp retrieves the message of a user that Signal sent with the timestamp, the timestamp the
+// Signal provider stored in the response data of the send
+func (r *MessageTransactionRepository) GetBySignalTimestamp(userID int, timestamp int64) (*domainProvider.MessageTransaction, error) {
+	// The closing brace avoids matching a timestamp that starts with the searched one
+	timestampJSON := fmt.Sprintf(`"timestamp":%d}`, timestamp)
+
+	var messageTransaction MessageTransaction
+	err := r.DB.Where("user_id = ? AND response_data LIKE ?", userID, "%"+timestampJSON+"%").
+		Order("id DESC").
+		First(&messageTransaction).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting message by Signal timestamp", zap.Error(err), zap.Int64("timestamp", timestamp))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransaction.toDomainMapper(), nil
+}
+
+// AcknowledgeMessage marks a message awaiting an acknowledgement as acknowledged. It returns false when the
+// message was already acknowledged or expired.
+func (r *MessageTransactionRepository) AcknowledgeMessage(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error) {
+	result := r.DB.Model(&MessageTransaction{}).
+		Where("id = ? AND ack_status = ?", id, "pending").
+		Updates(map[string]interface{}{
+			"ack_status":      "acknowledged",
+			"acknowledged_by": acknowledgedBy,
+			"acknowledged_at": acknowledgedAt,
+		})
+	if result.Error != nil {
+		r.Logger.Error("Error acknowledging message", zap.Error(result.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetExpiredAcks retrieves up to 1000 messages whose acknowledgement deadline passed unacknowledged
+func (r *MessageTransactionRepository) GetExpiredAcks(now time.Time) (*[]domainProvider.MessageTransaction, error) {
+	var messageTransactions []MessageTransaction
+	if err := r.DB.Where("ack_status = ? AND ack_deadline <= ?", "pending", now).
+		Limit(1000).
+		Find(&messageTransactions).Error; err != nil {
+		r.Logger.Error("Error getting expired acknowledgements", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messageTransactionArrayToDomainMapper(&messageTransactions), nil
+}
+
+// ExpireAck marks the acknowledgement of a message as expired, so the expiry is handled by a single
+// instance only. It returns false when the message was acknowledged or expired in the meantime.
+func (r *MessageTransactionRepository) ExpireAck(id int) (bool, error) {
+	result := r.DB.Model(&MessageTransaction{}).
+		Where("id = ? AND ack_status = ?", id, "pending").
+		Update("ack_status", "expired")
+	if result.Error != nil {
+		r.Logger.Error("Error expiring message acknowledgement", zap.Error(result.Error), zap.Int("id", id))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// bulkFilterQuery restricts a query to the messages matching a bulk operation filter
+func bulkFilterQuery(db *gorm.DB, filter domainProvider.BulkOperationFilter) *gorm.DB {
+	query := db.Model(&MessageTransaction{}).Where("status = ?", filter.Status)
+	if filter.ProviderID != 0 {
+		query = query.Where("provider_id = ?", filter.ProviderID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+func (r *MessageTransactionRepository) CountForBulk(filter domainProvider.BulkOperationFilter) (int, error) {
+	var count int64
+	if err := bulkFilterQuery(r.DB, filter).Count(&count).Error; err != nil {
+		r.Logger.Error("Error counting messages of bulk operation", zap.Error(err), zap.String("status", filter.Status))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return int(count), nil
+}
+
+func (r *MessageTransactionRepository) GetIDsForBulk(filter domainProvider.BulkOperationFilter, afterID int, limit int) ([]int, error) {
+	var ids []int
+	err := bulkFilterQuery(r.DB, filter).Where("id > ?", afterID).Order("id ASC").Limit(limit).Pluck("id", &ids).Error
+	if err != nil {
+		r.Logger.Error("Error getting messages of bulk operation", zap.Error(err), zap.String("status", filter.Status), zap.Int("afterID", afterID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return ids, nil
+}
+
+// RequeueBatch moves messages back to pending so the pending message watcher sends them again. Messages being
+// processed are left alone, the send of a requeued message can be started again.
+func (r *MessageTransactionRepository) RequeueBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusPending); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return r.changeBatch(ids, "status = ? AND processing = ?", []interface{}{status, false}, map[string]interface{}{
+		"status":          domainProvider.MessageStatusPending,
+		"error_message":   "",
+		"error_code":      "",
+		"next_retry_at":   nil,
+		"send_started_at": nil,
+	})
+}
+
+// CancelBatch cancels messages unless their send was started. Cancelling claims the send like MarkSendStarted,
+// so a cancelled message still sitting in the queue of a worker is never sent.
+func (r *MessageTransactionRepository) CancelBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusCancelled); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	now := time.Now()
+	return r.changeBatch(ids, "status = ? AND (send_started_at IS NULL OR status = ?)", []interface{}{status, domainProvider.MessageStatusFailed}, map[string]interface{}{
+		"status":          domainProvider.MessageStatusCancelled,
+		"error_message":   "cancelled by an admin",
+		"processing":      false,
+		"next_retry_at":   nil,
+		"send_started_at": gorm.Expr("COALESCE(send_started_at, ?)", now),
+	})
+}
+
+// SuspendBatch suspends messages unless their send was started. Like cancelling, suspending claims the send,
+// requeueing the suspended messages releases the claim.
+func (r *MessageTransactionRepository) SuspendBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusSuspended); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	now := time.Now()
+	return r.changeBatch(ids, "status = ? AND (send_started_at IS NULL OR status = ?)", []interface{}{status, domainProvider.MessageStatusFailed}, map[string]interface{}{
+		"status":          domainProvider.MessageStatusSuspended,
+		"error_message":   "the user was deactivated",
+		"processing":      false,
+		"next_retry_at":   nil,
+		"send_started_at": gorm.Expr("COALESCE(send_started_at, ?)", now),
+	})
+}
+
+// Restore requeues one message with the content of its snapshot if it still has the expected status and no worker holds it
+func (r *MessageTransactionRepository) Restore(id int, status string, messageTransactionMap map[string]interface{}) (bool, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusPending); err != nil {
+		return false, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	updateData := mapMessageTransactionColumns(messageTransactionMap)
+	updateData["status"] = domainProvider.MessageStatusPending
+	updateData["error_message"] = ""
+	updateData["error_code"] = ""
+	updateData["next_retry_at"] = nil
+	updateData["send_started_at"] = nil
+	changed, err := r.changeBatch([]int{id}, "status = ? AND processing = ?", []interface{}{status, false}, updateData)
+	if err != nil {
+		return false, err
+	}
+	return len(changed) == 1, nil
+}
+
+// changeBatch changes the messages of ids still matching the condition and returns the IDs it changed
+func (r *MessageTransactionRepository) changeBatch(ids []int, condition string, args []interface{}, updateData map[string]interface{}) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	changed, err := r.changeWhere(func(query *gorm.DB) *gorm.DB {
+		return query.Where("id IN (?)", ids).Where(condition, args...)
+	}, updateData)
+	if err != nil {
+		r.Logger.Error("Error changing message transaction batch", zap.Error(err), zap.Int("count", len(ids)))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return changed, nil
+}
+
+// changeWhere locks the messages selected by scope, updates them and writes their outbox events in one DB
+// transaction, and returns the IDs it changed
+func (r *MessageTransactionRepository) changeWhere(scope func(query *gorm.DB) *gorm.DB, updateData map[string]interface{}) ([]int, error) {
+	var changed []int
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Model(&MessageTransaction{}).Clauses(clause.Locking{Strength: "UPDATE"})).
+			Pluck("id", &changed).Error; err != nil {
+			return err
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		if err := tx.Model(&MessageTransaction{}).Where("id IN (?)", changed).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if !r.OutboxEnabled {
+			return nil
+		}
+		var updated []MessageTransaction
+		if err := tx.Where("id IN (?)", changed).Find(&updated).Error; err != nil {
+			return err
+		}
+		return createOutboxEvents(tx, updated)
+	})
+	return changed, err
+}
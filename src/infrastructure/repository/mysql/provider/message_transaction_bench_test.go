@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+const (
+	benchMessages = 1000
+	// benchRoundTrip is the simulated latency of every statement sent to the database
+	benchRoundTrip = 50 * time.Microsecond
+)
+
+func setupBenchRepository(b *testing.B) (*MessageTransactionRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		b.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		b.Fatalf("Failed to open gorm: %v", err)
+	}
+	return &MessageTransactionRepository{DB: gormDB, Logger: &logger.Logger{Log: zap.NewNop()}}, mock
+}
+
+func benchMessageTransactions() []domainProvider.MessageTransaction {
+	messageTransactions := make([]domainProvider.MessageTransaction, benchMessages)
+	for i := range messageTransactions {
+		messageTransactions[i] = domainProvider.MessageTransaction{
+			UserID:     1,
+			ProviderID: 1,
+			Recipients: `["+4912345"]`,
+			Message:    "Server down",
+			Status:     "pending",
+		}
+	}
+	return messageTransactions
+}
+
+func BenchmarkMessageTransactionCreate(b *testing.B) {
+	messageTransactions := benchMessageTransactions()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		repository, mock := setupBenchRepository(b)
+		b.StartTimer()
+
+		for i := range messageTransactions {
+			mock.ExpectBegin()
+			mock.ExpectExec("INSERT INTO `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+			if _, err := repository.Create(&messageTransactions[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMessageTransactionCreateBatch(b *testing.B) {
+	messageTransactions := benchMessageTransactions()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		repository, mock := setupBenchRepository(b)
+		mock.ExpectBegin()
+		for i := 0; i < benchMessages; i += createBatchSize {
+			rows := min(createBatchSize, benchMessages-i)
+			mock.ExpectExec("INSERT INTO `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnResult(sqlmock.NewResult(1, int64(rows)))
+		}
+		mock.ExpectCommit()
+		b.StartTimer()
+
+		if _, err := repository.CreateBatch(messageTransactions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMessageTransactionUpdate(b *testing.B) {
+	updateData := map[string]interface{}{"status": "delivered", "processing": false}
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		repository, mock := setupBenchRepository(b)
+		b.StartTimer()
+
+		for id := 1; id <= benchMessages; id++ {
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT").WillDelayFor(benchRoundTrip).WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(id, "success"))
+			mock.ExpectExec("UPDATE `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery("SELECT").WillDelayFor(benchRoundTrip).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+			mock.ExpectCommit()
+			if _, err := repository.Update(id, updateData); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMessageTransactionUpdateBatch(b *testing.B) {
+	updateData := map[string]interface{}{"status": "delivered", "processing": false}
+	ids := make([]int, benchMessages)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		repository, mock := setupBenchRepository(b)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnResult(sqlmock.NewResult(0, benchMessages))
+		mock.ExpectCommit()
+		b.StartTimer()
+
+		if err := repository.UpdateBatch(ids, updateData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchClaimBatch is the number of pending messages each GetPendingMessages call claims
+const benchClaimBatch = 100
+
+// BenchmarkGetPendingMessagesConcurrent claims pending messages from concurrent workers. The workers compete for the
+// same pending rows, whose locks taken by the UPDATE serialize the claiming transactions in MySQL; the benchmark
+// models them with a single connection, so the claims per second show how much the workers wait on each other.
+func BenchmarkGetPendingMessagesConcurrent(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			repository, mock := setupBenchRepository(b)
+			sqlDB, err := repository.DB.DB()
+			if err != nil {
+				b.Fatal(err)
+			}
+			sqlDB.SetMaxOpenConns(1)
+
+			for n := 0; n < b.N; n++ {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "provider_id", "status"})
+				for id := 1; id <= benchClaimBatch; id++ {
+					rows.AddRow(n*benchClaimBatch+id, 1, 1, "pending")
+				}
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnRows(rows)
+				mock.ExpectExec("UPDATE `message_transactions`").WillDelayFor(benchRoundTrip).WillReturnResult(sqlmock.NewResult(0, benchClaimBatch))
+				mock.ExpectCommit()
+			}
+
+			claims := make(chan struct{}, b.N)
+			for n := 0; n < b.N; n++ {
+				claims <- struct{}{}
+			}
+			close(claims)
+
+			b.ResetTimer()
+			start := time.Now()
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range claims {
+						messages, err := repository.GetPendingMessages()
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						if len(*messages) != benchClaimBatch {
+							b.Errorf("claimed %d messages, want %d", len(*messages), benchClaimBatch)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			b.ReportMetric(float64(b.N*benchClaimBatch)/time.Since(start).Seconds(), "msgs/s")
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package provider
 
 import (
+	"encoding/json"
 	"time"
 
+	"go-multi-chat-api/src/domain"
 	domainErrors "go-multi-chat-api/src/domain/errors"
 	domainProvider "go-multi-chat-api/src/domain/provider"
 	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -15,17 +18,20 @@ import (
 type MessageTransactionHistory struct {
 	ID           int       `gorm:"primaryKey"`
 	MessageID    int       `gorm:"column:message_id;index"`
-	UserID       int       `gorm:"column:user_id;index"`
+	UserID       int       `gorm:"column:user_id;index;index:idx_history_user_created,priority:1"`
 	ProviderID   int       `gorm:"column:provider_id;index"`
 	Recipients   string    `gorm:"column:recipients;type:text"`
 	Message      string    `gorm:"column:message;type:text"`
+	Tags         string    `gorm:"column:tags;type:text"`
 	RequestData  string    `gorm:"column:request_data;type:text"`
 	ResponseData string    `gorm:"column:response_data;type:text"`
 	Status       string    `gorm:"column:status;index"`
 	ErrorMessage string    `gorm:"column:error_message;type:text"`
+	ErrorCode    string    `gorm:"column:error_code;size:32"`
 	RetryCount   int       `gorm:"column:retry_count;default:0"`
+	Segments     int       `gorm:"column:segments;default:0"`
 	ProcessedAt  time.Time `gorm:"column:processed_at"`
-	CreatedAt    time.Time `gorm:"autoCreateTime:mili"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:mili;not null;index:idx_history_user_created,priority:2"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime:mili"`
 }
 
@@ -40,11 +46,14 @@ var ColumnsMessageTransactionHistoryMapping = map[string]string{
 	"providerID":   "provider_id",
 	"recipients":   "recipients",
 	"message":      "message",
+	"tags":         "tags",
 	"requestData":  "request_data",
 	"responseData": "response_data",
 	"status":       "status",
 	"errorMessage": "error_message",
+	"errorCode":    "error_code",
 	"retryCount":   "retry_count",
+	"segments":     "segments",
 	"processedAt":  "processed_at",
 	"createdAt":    "created_at",
 	"updatedAt":    "updated_at",
@@ -53,11 +62,36 @@ var ColumnsMessageTransactionHistoryMapping = map[string]string{
 // MessageTransactionHistoryRepositoryInterface defines the interface for message transaction history repository operations
 type MessageTransactionHistoryRepositoryInterface interface {
 	Create(historyDomain *domainProvider.MessageTransactionHistory) (*domainProvider.MessageTransactionHistory, error)
+	// CreateBatch stores many history entries with multi-row INSERTs
+	CreateBatch(histories []domainProvider.MessageTransactionHistory) error
 	GetByID(id int) (*domainProvider.MessageTransactionHistory, error)
 	GetByMessageID(messageID int) (*[]domainProvider.MessageTransactionHistory, error)
 	GetUserMessageTransactionHistory(userID int) (*[]domainProvider.MessageTransactionHistory, error)
+	GetUserDeliveryStats(userID int, from time.Time, to time.Time, topErrorLimit int) (*domainProvider.DeliveryStats, error)
+	SearchUserHistory(userID int, status string, tags map[string]string, page domain.PageRequest) (*domain.Page[domainProvider.MessageTransactionHistory], error)
+	GetUserTagRollup(userID int, tagKey string, from time.Time, to time.Time, granularity string) (*[]domainProvider.TagDeliveryRollup, error)
+	// GetProviderTypeOutcomes counts the messages of all users processed since from per provider type
+	GetProviderTypeOutcomes(from time.Time) ([]domainProvider.ProviderTypeOutcomes, error)
+	// GetAfter returns the entries with an ID after afterID, oldest first
+	GetAfter(afterID int, limit int) ([]domainProvider.MessageTransactionHistory, error)
+	// GetOldestCreatedAt returns when the oldest entry was created, nil without entries
+	GetOldestCreatedAt() (*time.Time, error)
+	// DeleteRange deletes the entries with an ID from firstID through lastID and returns how many it deleted
+	DeleteRange(firstID int, lastID int) (int64, error)
 }
 
+const (
+	// RollupGranularityDay groups a tag rollup by UTC day
+	RollupGranularityDay = "day"
+	// RollupGranularityWeek groups a tag rollup by week starting on Monday
+	RollupGranularityWeek = "week"
+	// RollupGranularityTotal reports a single bucket for the whole range
+	RollupGranularityTotal = "total"
+)
+
+// tagsJSONExpr replaces the empty string stored for untagged rows by an empty object so JSON functions don't fail
+const tagsJSONExpr = "IF(tags IS NULL OR tags = '', '{}', tags)"
+
 type MessageTransactionHistoryRepository struct {
 	DB     *gorm.DB
 	Logger *logger.Logger
@@ -80,6 +114,22 @@ func (r *MessageTransactionHistoryRepository) Create(historyDomain *domainProvid
 	return historyRepository.toDomainMapper(), err
 }
 
+func (r *MessageTransactionHistoryRepository) CreateBatch(histories []domainProvider.MessageTransactionHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	models := make([]MessageTransactionHistory, len(histories))
+	for i := range histories {
+		models[i] = *messageTransactionHistoryFromDomainMapper(&histories[i])
+	}
+	if err := r.DB.CreateInBatches(models, createBatchSize).Error; err != nil {
+		r.Logger.Error("Error creating message transaction history batch", zap.Error(err), zap.Int("count", len(histories)))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully created message transaction history batch", zap.Int("count", len(histories)))
+	return nil
+}
+
 func (r *MessageTransactionHistoryRepository) GetByID(id int) (*domainProvider.MessageTransactionHistory, error) {
 	var history MessageTransactionHistory
 	err := r.DB.Where("id = ?", id).First(&history).Error
@@ -117,7 +167,225 @@ func (r *MessageTransactionHistoryRepository) GetUserMessageTransactionHistory(u
 	return messageTransactionHistoryArrayToDomainMapper(&histories), nil
 }
 
+// SearchUserHistory retrieves a page of the processed messages of a user, newest first, optionally filtered by
+// status and by tags. Every given tag has to match for a message to be returned.
+func (r *MessageTransactionHistoryRepository) SearchUserHistory(userID int, status string, tags map[string]string, page domain.PageRequest) (*domain.Page[domainProvider.MessageTransactionHistory], error) {
+	query := r.DB.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query = applyTagFilters(query, tags)
+
+	var histories []MessageTransactionHistory
+	if err := pagination.Apply(query, "created_at", page).Find(&histories).Error; err != nil {
+		r.Logger.Error("Error searching user message transaction history", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	histories, next := pagination.Cut(histories, page.Limit, func(history *MessageTransactionHistory) domain.Cursor {
+		return domain.Cursor{Time: history.CreatedAt, ID: history.ID}
+	})
+	r.Logger.Info("Successfully searched user message transaction history", zap.Int("userID", userID), zap.Int("count", len(histories)))
+	return &domain.Page[domainProvider.MessageTransactionHistory]{Items: *messageTransactionHistoryArrayToDomainMapper(&histories), Next: next}, nil
+}
+
+// applyTagFilters restricts the query to rows whose tags JSON contains every given key with the given value
+func applyTagFilters(query *gorm.DB, tags map[string]string) *gorm.DB {
+	for key, value := range tags {
+		query = query.Where("JSON_UNQUOTE(JSON_EXTRACT("+tagsJSONExpr+", ?)) = ?", tagJSONPath(key), value)
+	}
+	return query
+}
+
+// tagJSONPath returns the JSON path of a tag key, quoting it so keys with dots or spaces are matched literally
+func tagJSONPath(key string) string {
+	quoted, _ := json.Marshal(key)
+	return "$." + string(quoted)
+}
+
+// GetUserTagRollup aggregates the delivery outcomes of a user's processed messages between from (inclusive)
+// and to (exclusive), grouped by the value of the given tag key and by period. Messages without the tag are skipped.
+func (r *MessageTransactionHistoryRepository) GetUserTagRollup(userID int, tagKey string, from time.Time, to time.Time, granularity string) (*[]domainProvider.TagDeliveryRollup, error) {
+	var periodExpr string
+	switch granularity {
+	case RollupGranularityDay:
+		periodExpr = "DATE_FORMAT(processed_at, '%Y-%m-%d')"
+	case RollupGranularityWeek:
+		periodExpr = "DATE_FORMAT(DATE_SUB(processed_at, INTERVAL WEEKDAY(processed_at) DAY), '%Y-%m-%d')"
+	default:
+		periodExpr = "''"
+	}
+
+	type tagStatusCount struct {
+		TagValue string
+		Period   string
+		Status   string
+		Count    int
+	}
+	var counts []tagStatusCount
+	tagValueExpr := "JSON_UNQUOTE(JSON_EXTRACT(" + tagsJSONExpr + ", ?))"
+	// History rows are created once the message was processed, the bound on created_at skips the partitions of
+	// older months
+	err := r.DB.Model(&MessageTransactionHistory{}).
+		Select(tagValueExpr+" AS tag_value, "+periodExpr+" AS period, status, COUNT(*) AS count", tagJSONPath(tagKey)).
+		Where("user_id = ? AND processed_at >= ? AND processed_at < ? AND created_at >= ?", userID, from, to, from).
+		Where("JSON_EXTRACT("+tagsJSONExpr+", ?) IS NOT NULL", tagJSONPath(tagKey)).
+		Group("tag_value, period, status").
+		Order("period, tag_value").
+		Scan(&counts).Error
+	if err != nil {
+		r.Logger.Error("Error getting user tag rollup", zap.Error(err), zap.Int("userID", userID), zap.String("tagKey", tagKey))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	rollups := []domainProvider.TagDeliveryRollup{}
+	index := map[string]int{}
+	for _, c := range counts {
+		bucket := c.Period + "|" + c.TagValue
+		i, ok := index[bucket]
+		if !ok {
+			periodStart := from
+			if c.Period != "" {
+				if parsed, err := time.Parse("2006-01-02", c.Period); err == nil {
+					periodStart = parsed
+				}
+			}
+			rollups = append(rollups, domainProvider.TagDeliveryRollup{TagValue: c.TagValue, PeriodStart: periodStart})
+			i = len(rollups) - 1
+			index[bucket] = i
+		}
+		rollups[i].Total += c.Count
+		switch c.Status {
+		case "success", "delivered":
+			rollups[i].Sent += c.Count
+		case "failed":
+			rollups[i].Failed += c.Count
+		case "fallback_triggered":
+			rollups[i].Fallbacks += c.Count
+		}
+	}
+
+	r.Logger.Info("Successfully retrieved user tag rollup", zap.Int("userID", userID), zap.String("tagKey", tagKey), zap.Int("buckets", len(rollups)))
+	return &rollups, nil
+}
+
+// GetUserDeliveryStats aggregates the processed messages of a user between from (inclusive) and to (exclusive)
+func (r *MessageTransactionHistoryRepository) GetUserDeliveryStats(userID int, from time.Time, to time.Time, topErrorLimit int) (*domainProvider.DeliveryStats, error) {
+	type statusCount struct {
+		Status string
+		Count  int
+	}
+	var statusCounts []statusCount
+	err := r.DB.Model(&MessageTransactionHistory{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ? AND processed_at >= ? AND processed_at < ? AND created_at >= ?", userID, from, to, from).
+		Group("status").
+		Scan(&statusCounts).Error
+	if err != nil {
+		r.Logger.Error("Error getting user delivery stats", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	stats := &domainProvider.DeliveryStats{TopErrors: []domainProvider.ErrorReasonCount{}}
+	for _, sc := range statusCounts {
+		stats.Total += sc.Count
+		switch sc.Status {
+		case "success", "delivered":
+			stats.Sent += sc.Count
+		case "failed":
+			stats.Failed += sc.Count
+		case "fallback_triggered":
+			stats.Fallbacks += sc.Count
+		}
+	}
+
+	var topErrors []domainProvider.ErrorReasonCount
+	err = r.DB.Model(&MessageTransactionHistory{}).
+		Select("error_code AS code, error_message AS reason, COUNT(*) AS count").
+		Where("user_id = ? AND processed_at >= ? AND processed_at < ? AND created_at >= ? AND error_message <> ''", userID, from, to, from).
+		Group("error_code, error_message").
+		Order("count DESC").
+		Limit(topErrorLimit).
+		Scan(&topErrors).Error
+	if err != nil {
+		r.Logger.Error("Error getting user top error reasons", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	stats.TopErrors = append(stats.TopErrors, topErrors...)
+
+	return stats, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetProviderTypeOutcomes(from time.Time) ([]domainProvider.ProviderTypeOutcomes, error) {
+	type typeStatusCount struct {
+		Type   string
+		Status string
+		Count  int
+	}
+	var counts []typeStatusCount
+	err := r.DB.Model(&MessageTransactionHistory{}).
+		Select("providers.type AS type, message_transaction_history.status AS status, COUNT(*) AS count").
+		Joins("JOIN providers ON providers.id = message_transaction_history.provider_id").
+		Where("message_transaction_history.processed_at >= ? AND message_transaction_history.created_at >= ?", from, from).
+		Group("providers.type, message_transaction_history.status").
+		Scan(&counts).Error
+	if err != nil {
+		r.Logger.Error("Error getting provider type outcomes", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	var outcomes []domainProvider.ProviderTypeOutcomes
+	index := make(map[string]int)
+	for _, count := range counts {
+		i, ok := index[count.Type]
+		if !ok {
+			i = len(outcomes)
+			index[count.Type] = i
+			outcomes = append(outcomes, domainProvider.ProviderTypeOutcomes{Type: count.Type})
+		}
+		switch count.Status {
+		case "success", "delivered":
+			outcomes[i].Sent += count.Count
+		case "failed", "fallback_triggered":
+			// A fallback was triggered by a failure of the provider
+			outcomes[i].Failed += count.Count
+		}
+	}
+	return outcomes, nil
+}
+
 // Mappers
+func (r *MessageTransactionHistoryRepository) GetAfter(afterID int, limit int) ([]domainProvider.MessageTransactionHistory, error) {
+	var histories []MessageTransactionHistory
+	if err := r.DB.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&histories).Error; err != nil {
+		r.Logger.Error("Error getting message transaction history after ID", zap.Error(err), zap.Int("afterID", afterID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return *messageTransactionHistoryArrayToDomainMapper(&histories), nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetOldestCreatedAt() (*time.Time, error) {
+	var history MessageTransactionHistory
+	err := r.DB.Select("created_at").Order("created_at ASC").First(&history).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error getting oldest message transaction history", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return &history.CreatedAt, nil
+}
+
+func (r *MessageTransactionHistoryRepository) DeleteRange(firstID int, lastID int) (int64, error) {
+	result := r.DB.Where("id BETWEEN ? AND ?", firstID, lastID).Delete(&MessageTransactionHistory{})
+	if result.Error != nil {
+		r.Logger.Error("Error deleting message transaction history range", zap.Error(result.Error), zap.Int("firstID", firstID), zap.Int("lastID", lastID))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Deleted message transaction history range", zap.Int("firstID", firstID), zap.Int("lastID", lastID), zap.Int64("count", result.RowsAffected))
+	return result.RowsAffected, nil
+}
+
 func (mth *MessageTransactionHistory) toDomainMapper() *domainProvider.MessageTransactionHistory {
 	return &domainProvider.MessageTransactionHistory{
 		ID:           mth.ID,
@@ -126,11 +394,14 @@ func (mth *MessageTransactionHistory) toDomainMapper() *domainProvider.MessageTr
 		ProviderID:   mth.ProviderID,
 		Recipients:   mth.Recipients,
 		Message:      mth.Message,
+		Tags:         mth.Tags,
 		RequestData:  mth.RequestData,
 		ResponseData: mth.ResponseData,
 		Status:       mth.Status,
 		ErrorMessage: mth.ErrorMessage,
+		ErrorCode:    mth.ErrorCode,
 		RetryCount:   mth.RetryCount,
+		Segments:     mth.Segments,
 		ProcessedAt:  mth.ProcessedAt,
 		CreatedAt:    mth.CreatedAt,
 		UpdatedAt:    mth.UpdatedAt,
@@ -145,11 +416,14 @@ func messageTransactionHistoryFromDomainMapper(mth *domainProvider.MessageTransa
 		ProviderID:   mth.ProviderID,
 		Recipients:   mth.Recipients,
 		Message:      mth.Message,
+		Tags:         mth.Tags,
 		RequestData:  mth.RequestData,
 		ResponseData: mth.ResponseData,
 		Status:       mth.Status,
 		ErrorMessage: mth.ErrorMessage,
+		ErrorCode:    mth.ErrorCode,
 		RetryCount:   mth.RetryCount,
+		Segments:     mth.Segments,
 		ProcessedAt:  mth.ProcessedAt,
 		CreatedAt:    mth.CreatedAt,
 		UpdatedAt:    mth.UpdatedAt,
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMessageTransactionRepository(t *testing.T) (MessageTransactionRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return NewMessageTransactionRepository(gormDB, &logger.Logger{Log: zap.NewNop()}, false), mock
+}
+
+func TestUpdate_RejectsIllegalStatusTransition(t *testing.T) {
+	repository, mock := setupMessageTransactionRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`status` FROM `message_transactions` WHERE id = ?")).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(7, domainProvider.MessageStatusSuccess))
+	mock.ExpectRollback()
+
+	_, err := repository.Update(7, map[string]interface{}{"status": domainProvider.MessageStatusCancelled})
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, domainErrors.Conflict, appErr.Type)
+	var transitionErr *domainProvider.TransitionError
+	assert.True(t, errors.As(appErr.Err, &transitionErr))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdate_AppliesLegalStatusTransition(t *testing.T) {
+	repository, mock := setupMessageTransactionRepository(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`status` FROM `message_transactions` WHERE id = ?")).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(7, domainProvider.MessageStatusSuccess))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_transactions` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `message_transactions` WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(7, domainProvider.MessageStatusRevoked))
+	mock.ExpectCommit()
+
+	updated, err := repository.Update(7, map[string]interface{}{"status": domainProvider.MessageStatusRevoked})
+	require.NoError(t, err)
+	assert.Equal(t, domainProvider.MessageStatusRevoked, updated.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequeueBatch_RejectsStatusThatCantBeRequeued(t *testing.T) {
+	repository, mock := setupMessageTransactionRepository(t)
+
+	_, err := repository.RequeueBatch([]int{1, 2}, domainProvider.MessageStatusSuccess)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseHeldMessages_WritesOutboxEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repository := NewMessageTransactionRepository(gormDB, &logger.Logger{Log: zap.NewNop()}, true)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `message_transactions` WHERE (status IN (?,?) AND next_retry_at <= ?) AND status IN (")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(9))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_transactions` SET")).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `message_transactions` WHERE id IN (?,?)")).
+		WithArgs(4, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+			AddRow(4, domainProvider.MessageStatusPending).
+			AddRow(9, domainProvider.MessageStatusPending))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `outbox_events`")).WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	released, err := repository.ReleaseHeldMessages()
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateBatch_WritesOutboxEventsOfChangedMessagesOnly(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repository := NewMessageTransactionRepository(gormDB, &logger.Logger{Log: zap.NewNop()}, true)
+
+	// Message 9 can't move to delivered anymore, so only message 4 is changed and reported
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `message_transactions` WHERE id IN (?,?) AND status IN (")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_transactions` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `message_transactions` WHERE id IN (?)")).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(4, domainProvider.MessageStatusDelivered))
+	mock.ExpectExec("^INSERT INTO `outbox_events` \\(.*\\) VALUES \\([^()]*\\)$").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = repository.UpdateBatch([]int{4, 9}, map[string]interface{}{"status": domainProvider.MessageStatusDelivered})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateBatch_LogsTheRowsItChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	core, logs := observer.New(zap.InfoLevel)
+	repository := NewMessageTransactionRepository(gormDB, &logger.Logger{Log: zap.New(core)}, true)
+
+	// An update without a status change isn't re-read and writes no events
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `message_transactions` SET `processing`=?,`updated_at`=? WHERE id IN (?,?)")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repository.UpdateBatch([]int{4, 9}, map[string]interface{}{"processing": false})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	entries := logs.FilterMessage("Successfully updated message transaction batch").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(1), entries[0].ContextMap()["count"])
+	assert.Equal(t, int64(2), entries[0].ContextMap()["requested"])
+}
+
+func TestGetRecipientSendTimes_CountsExactRecipients(t *testing.T) {
+	repository, mock := setupMessageTransactionRepository(t)
+	since := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	first, second := since.Add(time.Minute), since.Add(2*time.Minute)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `recipients`,`created_at` FROM `message_transactions` WHERE (user_id = ? AND created_at >= ? AND retry_count = ? AND status <> ?) AND (recipients LIKE ? OR recipients LIKE ?) ORDER BY created_at ASC")).
+		WithArgs(3, since, 0, domainProvider.MessageStatusCancelled, `%"+4911"%`, `%"+4922"%`).
+		WillReturnRows(sqlmock.NewRows([]string{"recipients", "created_at"}).
+			AddRow(`["+4911","+4933"]`, first).
+			AddRow(`["+4922","+4911"]`, second))
+
+	sendTimes, err := repository.GetRecipientSendTimes(3, []string{"+4911", "+4922"}, since)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]time.Time{"+4911": {first, second}, "+4922": {second}}, sendTimes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is the database model for the transactional outbox of message lifecycle events
+type OutboxEvent struct {
+	ID          int        `gorm:"primaryKey"`
+	EventType   string     `gorm:"column:event_type;index"`
+	MessageID   int        `gorm:"column:message_id;index"`
+	UserID      int        `gorm:"column:user_id;index"`
+	ProviderID  int        `gorm:"column:provider_id"`
+	Payload     string     `gorm:"column:payload;type:text"`
+	Published   bool       `gorm:"column:published;default:false;index"`
+	PublishedAt *time.Time `gorm:"column:published_at"`
+	Attempts    int        `gorm:"column:attempts;default:0"`
+	LastError   string     `gorm:"column:last_error;type:text"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxEventRepositoryInterface defines the interface for outbox event repository operations
+type OutboxEventRepositoryInterface interface {
+	GetUnpublished(limit int) (*[]domainProvider.OutboxEvent, error)
+	MarkPublished(id int) error
+	MarkFailed(id int, errorMessage string) error
+}
+
+type OutboxEventRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewOutboxEventRepository(db *gorm.DB, loggerInstance *logger.Logger) OutboxEventRepositoryInterface {
+	return &OutboxEventRepository{DB: db, Logger: loggerInstance}
+}
+
+// GetUnpublished retrieves the oldest events that were not published yet
+func (r *OutboxEventRepository) GetUnpublished(limit int) (*[]domainProvider.OutboxEvent, error) {
+	var events []OutboxEvent
+	if err := r.DB.Where("published = ?", false).Order("id ASC").Limit(limit).Find(&events).Error; err != nil {
+		r.Logger.Error("Error getting unpublished outbox events", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return outboxEventArrayToDomainMapper(&events), nil
+}
+
+// MarkPublished flags an event as published
+func (r *OutboxEventRepository) MarkPublished(id int) error {
+	now := time.Now()
+	err := r.DB.Model(&OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published":    true,
+		"published_at": now,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	}).Error
+	if err != nil {
+		r.Logger.Error("Error marking outbox event as published", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish attempt so it is retried on the next relay run
+func (r *OutboxEventRepository) MarkFailed(id int, errorMessage string) error {
+	err := r.DB.Model(&OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errorMessage,
+	}).Error
+	if err != nil {
+		r.Logger.Error("Error marking outbox event as failed", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// outboxEventTypeForStatus maps a message transaction status to its lifecycle event type
+func outboxEventTypeForStatus(status string) string {
+	switch status {
+	case "pending":
+		return "message.queued"
+	case "success":
+		return "message.sent"
+	default:
+		return "message." + status
+	}
+}
+
+// createOutboxEvents stores the lifecycle events of many message transactions with multi-row INSERTs
+func createOutboxEvents(tx *gorm.DB, mts []MessageTransaction) error {
+	events := make([]OutboxEvent, len(mts))
+	for i := range mts {
+		event, err := newOutboxEvent(&mts[i])
+		if err != nil {
+			return err
+		}
+		events[i] = *event
+	}
+	return tx.CreateInBatches(events, createBatchSize).Error
+}
+
+// createOutboxEvent stores a lifecycle event for the message transaction using the given (transactional) DB handle
+func createOutboxEvent(tx *gorm.DB, mt *MessageTransaction) error {
+	event, err := newOutboxEvent(mt)
+	if err != nil {
+		return err
+	}
+	return tx.Create(event).Error
+}
+
+// newOutboxEvent builds the lifecycle event reporting the current status of a message transaction
+func newOutboxEvent(mt *MessageTransaction) (*OutboxEvent, error) {
+	eventType := outboxEventTypeForStatus(mt.Status)
+	payload, err := json.Marshal(map[string]interface{}{
+		"event_type":    eventType,
+		"message_id":    mt.ID,
+		"user_id":       mt.UserID,
+		"provider_id":   mt.ProviderID,
+		"status":        mt.Status,
+		"error_message": mt.ErrorMessage,
+		"error_code":    mt.ErrorCode,
+		"retry_count":   mt.RetryCount,
+		"occurred_at":   time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		EventType:  eventType,
+		MessageID:  mt.ID,
+		UserID:     mt.UserID,
+		ProviderID: mt.ProviderID,
+		Payload:    string(payload),
+	}, nil
+}
+
+// Mappers
+func (e *OutboxEvent) toDomainMapper() *domainProvider.OutboxEvent {
+	return &domainProvider.OutboxEvent{
+		ID:          e.ID,
+		EventType:   e.EventType,
+		MessageID:   e.MessageID,
+		UserID:      e.UserID,
+		ProviderID:  e.ProviderID,
+		Payload:     e.Payload,
+		Published:   e.Published,
+		PublishedAt: e.PublishedAt,
+		Attempts:    e.Attempts,
+		LastError:   e.LastError,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+func outboxEventArrayToDomainMapper(events *[]OutboxEvent) *[]domainProvider.OutboxEvent {
+	eventsDomain := make([]domainProvider.OutboxEvent, len(*events))
+	for i, event := range *events {
+		eventsDomain[i] = *event.toDomainMapper()
+	}
+	return &eventsDomain
+}
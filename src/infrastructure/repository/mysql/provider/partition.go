@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PartitionedTables are the message tables that can be partitioned by the month of created_at
+var PartitionedTables = []string{"message_transactions", "message_transaction_history"}
+
+// maxPartition takes the messages created after the last monthly partition
+const maxPartition = "pmax"
+
+// activeMessageCondition selects the message transactions still to be sent or acknowledged
+const activeMessageCondition = "(status IN ('pending', 'failed', 'held', 'held_schedule', 'rate_limited', 'suspended') OR processing = TRUE OR ack_status = 'pending')"
+
+var partitionNamePattern = regexp.MustCompile(`^p(\d{6})$`)
+
+// PartitionRepositoryInterface defines the interface for the monthly partitions of the message tables
+type PartitionRepositoryInterface interface {
+	// Partition converts a table to monthly partitions from the month of from through the month of through, the
+	// first partition holding every older message. Partitioned tables are left alone.
+	Partition(table string, from time.Time, through time.Time) error
+	// GetPartitions lists the monthly partitions of a table, oldest first, and nothing when it isn't partitioned
+	GetPartitions(table string) ([]domainProvider.MessagePartition, error)
+	// AddPartitions adds the monthly partitions missing through the month of through and returns how many it added
+	AddPartitions(table string, through time.Time) (int, error)
+	DropPartition(table string, name string) error
+	// CountActive counts the messages of a partition of message_transactions still to be sent or acknowledged
+	CountActive(name string) (int64, error)
+	// DeleteBefore deletes up to limit messages created before the given time from a table that isn't
+	// partitioned. Message transactions still to be sent or acknowledged are kept.
+	DeleteBefore(table string, before time.Time, limit int) (int64, error)
+}
+
+type PartitionRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewPartitionRepository(db *gorm.DB, loggerInstance *logger.Logger) PartitionRepositoryInterface {
+	return &PartitionRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *PartitionRepository) Partition(table string, from time.Time, through time.Time) error {
+	if err := checkPartitionedTable(table); err != nil {
+		return err
+	}
+	partitions, err := r.GetPartitions(table)
+	if err != nil || len(partitions) > 0 {
+		return err
+	}
+
+	// Every unique key of a partitioned table has to include the partitioning column
+	statements := []string{
+		"ALTER TABLE `" + table + "` DROP PRIMARY KEY, ADD PRIMARY KEY (id, created_at)",
+		"ALTER TABLE `" + table + "` PARTITION BY RANGE COLUMNS(created_at) (" + partitionDefinitions(monthsBetween(from, through)) + ")",
+	}
+	for _, statement := range statements {
+		if err := r.DB.Exec(statement).Error; err != nil {
+			r.Logger.Error("Error partitioning table", zap.Error(err), zap.String("table", table))
+			return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	}
+	r.Logger.Info("Partitioned table by month", zap.String("table", table), zap.Time("from", from), zap.Time("through", through))
+	return nil
+}
+
+func (r *PartitionRepository) GetPartitions(table string) ([]domainProvider.MessagePartition, error) {
+	if err := checkPartitionedTable(table); err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Name      string
+		TableRows int64
+	}
+	err := r.DB.Raw("SELECT PARTITION_NAME AS name, TABLE_ROWS AS table_rows FROM information_schema.PARTITIONS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL ORDER BY PARTITION_ORDINAL_POSITION", table).
+		Scan(&rows).Error
+	if err != nil {
+		r.Logger.Error("Error getting partitions", zap.Error(err), zap.String("table", table))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	partitions := make([]domainProvider.MessagePartition, 0, len(rows))
+	for _, row := range rows {
+		month, ok := partitionMonth(row.Name)
+		if !ok {
+			continue
+		}
+		partitions = append(partitions, domainProvider.MessagePartition{Table: table, Name: row.Name, Month: month, Rows: row.TableRows})
+	}
+	return partitions, nil
+}
+
+func (r *PartitionRepository) AddPartitions(table string, through time.Time) (int, error) {
+	partitions, err := r.GetPartitions(table)
+	if err != nil {
+		return 0, err
+	}
+	if len(partitions) == 0 {
+		return 0, domainErrors.NewAppError(fmt.Errorf("table %s isn't partitioned", table), domainErrors.ValidationError)
+	}
+	months := monthsBetween(partitions[len(partitions)-1].Month.AddDate(0, 1, 0), through)
+	if len(months) == 0 {
+		return 0, nil
+	}
+
+	// The messages of the new months are in the empty max partition, so reorganizing it moves no rows
+	statement := "ALTER TABLE `" + table + "` REORGANIZE PARTITION " + maxPartition + " INTO (" + partitionDefinitions(months) + ")"
+	if err := r.DB.Exec(statement).Error; err != nil {
+		r.Logger.Error("Error adding partitions", zap.Error(err), zap.String("table", table))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return len(months), nil
+}
+
+func (r *PartitionRepository) DropPartition(table string, name string) error {
+	if err := checkPartitionedTable(table); err != nil {
+		return err
+	}
+	if _, ok := partitionMonth(name); !ok {
+		return domainErrors.NewAppError(fmt.Errorf("%s isn't a monthly partition", name), domainErrors.ValidationError)
+	}
+	if err := r.DB.Exec("ALTER TABLE `" + table + "` DROP PARTITION " + name).Error; err != nil {
+		r.Logger.Error("Error dropping partition", zap.Error(err), zap.String("table", table), zap.String("partition", name))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *PartitionRepository) CountActive(name string) (int64, error) {
+	if _, ok := partitionMonth(name); !ok {
+		return 0, domainErrors.NewAppError(fmt.Errorf("%s isn't a monthly partition", name), domainErrors.ValidationError)
+	}
+	var count int64
+	if err := r.DB.Raw("SELECT COUNT(*) FROM `message_transactions` PARTITION (" + name + ") WHERE " + activeMessageCondition).
+		Scan(&count).Error; err != nil {
+		r.Logger.Error("Error counting active messages of partition", zap.Error(err), zap.String("partition", name))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return count, nil
+}
+
+func (r *PartitionRepository) DeleteBefore(table string, before time.Time, limit int) (int64, error) {
+	if err := checkPartitionedTable(table); err != nil {
+		return 0, err
+	}
+	condition := "created_at < ?"
+	if table == "message_transactions" {
+		condition += " AND NOT " + activeMessageCondition
+	}
+	tx := r.DB.Exec("DELETE FROM `"+table+"` WHERE "+condition+" LIMIT ?", before, limit)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting old messages", zap.Error(tx.Error), zap.String("table", table), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected, nil
+}
+
+func checkPartitionedTable(table string) error {
+	for _, partitioned := range PartitionedTables {
+		if table == partitioned {
+			return nil
+		}
+	}
+	return domainErrors.NewAppError(fmt.Errorf("table %s can't be partitioned", table), domainErrors.ValidationError)
+}
+
+// MonthStart returns the first instant of the month of t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// monthsBetween returns the first day of every month from the month of from through the month of through
+func monthsBetween(from time.Time, through time.Time) []time.Time {
+	var months []time.Time
+	for month := MonthStart(from); !month.After(MonthStart(through)); month = month.AddDate(0, 1, 0) {
+		months = append(months, month)
+	}
+	return months
+}
+
+// partitionDefinitions defines a partition for each month and the max partition after them
+func partitionDefinitions(months []time.Time) string {
+	definitions := make([]string, 0, len(months)+1)
+	for _, month := range months {
+		definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN ('%s')",
+			partitionName(month), month.AddDate(0, 1, 0).Format("2006-01-02")))
+	}
+	definitions = append(definitions, "PARTITION "+maxPartition+" VALUES LESS THAN (MAXVALUE)")
+	return strings.Join(definitions, ", ")
+}
+
+func partitionName(month time.Time) string {
+	return "p" + month.Format("200601")
+}
+
+// partitionMonth returns the month of a monthly partition
+func partitionMonth(name string) (time.Time, bool) {
+	match := partitionNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	month, err := time.ParseInLocation("200601", match[1], time.Local)
+	return month, err == nil
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupPartitionRepository(t *testing.T) (PartitionRepositoryInterface, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	return NewPartitionRepository(gormDB, &logger.Logger{Log: zap.NewNop()}), mock
+}
+
+func TestPartitionDefinitions(t *testing.T) {
+	months := monthsBetween(time.Date(2026, time.November, 16, 0, 0, 0, 0, time.Local), time.Date(2027, time.January, 2, 0, 0, 0, 0, time.Local))
+	assert.Equal(t, "PARTITION p202611 VALUES LESS THAN ('2026-12-01'), "+
+		"PARTITION p202612 VALUES LESS THAN ('2027-01-01'), "+
+		"PARTITION p202701 VALUES LESS THAN ('2027-02-01'), "+
+		"PARTITION pmax VALUES LESS THAN (MAXVALUE)", partitionDefinitions(months))
+
+	month, ok := partitionMonth("p202611")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.Local), month)
+	_, ok = partitionMonth("pmax")
+	assert.False(t, ok)
+	_, ok = partitionMonth("p202611; DROP TABLE users")
+	assert.False(t, ok)
+}
+
+func TestPartition(t *testing.T) {
+	repository, mock := setupPartitionRepository(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.PARTITIONS")).WithArgs("message_transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "table_rows"}))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `message_transactions` DROP PRIMARY KEY, ADD PRIMARY KEY (id, created_at)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `message_transactions` PARTITION BY RANGE COLUMNS(created_at) (PARTITION p202610 VALUES LESS THAN ('2026-11-01'), PARTITION p202611")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	now := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.Local)
+	require.NoError(t, repository.Partition("message_transactions", now, now.AddDate(0, 1, 0)))
+
+	// Partitioned tables are left alone
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.PARTITIONS")).WithArgs("message_transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "table_rows"}).AddRow("p202610", 5).AddRow("pmax", 0))
+	require.NoError(t, repository.Partition("message_transactions", now, now.AddDate(0, 1, 0)))
+
+	assert.Error(t, repository.Partition("users", now, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddPartitions(t *testing.T) {
+	repository, mock := setupPartitionRepository(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.PARTITIONS")).WithArgs("message_transaction_history").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "table_rows"}).AddRow("p202610", 5).AddRow("p202611", 0).AddRow("pmax", 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `message_transaction_history` REORGANIZE PARTITION pmax INTO (" +
+		"PARTITION p202612 VALUES LESS THAN ('2027-01-01'), PARTITION p202701 VALUES LESS THAN ('2027-02-01'), PARTITION pmax VALUES LESS THAN (MAXVALUE))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	added, err := repository.AddPartitions("message_transaction_history", time.Date(2027, time.January, 16, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
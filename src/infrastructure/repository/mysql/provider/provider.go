@@ -20,6 +20,7 @@ type Provider struct {
 	Description string    `gorm:"column:description"`
 	Config      string    `gorm:"column:config;type:text"`
 	Status      bool      `gorm:"column:status"`
+	Version     int       `gorm:"column:version;not null;default:1"`
 	CreatedAt   time.Time `gorm:"autoCreateTime:mili"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime:mili"`
 }
@@ -35,6 +36,7 @@ var ColumnsProviderMapping = map[string]string{
 	"description": "description",
 	"config":      "config",
 	"status":      "status",
+	"version":     "version",
 	"createdAt":   "created_at",
 	"updatedAt":   "updated_at",
 }
@@ -44,6 +46,8 @@ type ProviderRepositoryInterface interface {
 	GetAll() (*[]domainProvider.Provider, error)
 	Create(providerDomain *domainProvider.Provider) (*domainProvider.Provider, error)
 	GetByID(id int) (*domainProvider.Provider, error)
+	// GetByName returns the provider of a name, names are unique
+	GetByName(name string) (*domainProvider.Provider, error)
 	Update(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error)
 	Delete(id int) error
 }
@@ -109,6 +113,23 @@ func (r *Repository) GetByID(id int) (*domainProvider.Provider, error) {
 	return provider.toDomainMapper(), nil
 }
 
+func (r *Repository) GetByName(name string) (*domainProvider.Provider, error) {
+	var provider Provider
+	err := r.DB.Where("name = ?", name).First(&provider).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Provider not found", zap.String("name", name))
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting provider by name", zap.Error(err), zap.String("name", name))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainProvider.Provider{}, err
+	}
+	r.Logger.Info("Successfully retrieved provider by name", zap.String("name", name))
+	return provider.toDomainMapper(), nil
+}
+
 func (r *Repository) Update(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error) {
 	var providerObj Provider
 	providerObj.ID = id
@@ -123,9 +144,17 @@ func (r *Repository) Update(id int, providerMap map[string]interface{}) (*domain
 		}
 	}
 
-	err := r.DB.Model(&providerObj).
-		Select("name", "type", "description", "config", "status").
-		Updates(updateData).Error
+	// Optimistic locking: when the caller sends the version it read, only update if nobody changed it since
+	expectedVersion, checkVersion := updateData["version"]
+	updateData["version"] = gorm.Expr("version + 1")
+
+	query := r.DB.Model(&providerObj).
+		Select("name", "type", "description", "config", "status", "version")
+	if checkVersion {
+		query = query.Where("version = ?", expectedVersion)
+	}
+	tx := query.Updates(updateData)
+	err := tx.Error
 	if err != nil {
 		r.Logger.Error("Error updating provider", zap.Error(err), zap.Int("id", id))
 		byteErr, _ := json.Marshal(err)
@@ -141,6 +170,9 @@ func (r *Repository) Update(id int, providerMap map[string]interface{}) (*domain
 			return &domainProvider.Provider{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 		}
 	}
+	if tx.RowsAffected == 0 {
+		return &domainProvider.Provider{}, r.versionMismatchError(id, checkVersion)
+	}
 	if err := r.DB.Where("id = ?", id).First(&providerObj).Error; err != nil {
 		r.Logger.Error("Error retrieving updated provider", zap.Error(err), zap.Int("id", id))
 		return &domainProvider.Provider{}, err
@@ -149,6 +181,21 @@ func (r *Repository) Update(id int, providerMap map[string]interface{}) (*domain
 	return providerObj.toDomainMapper(), nil
 }
 
+// versionMismatchError tells apart a missing provider from a concurrent modification after an update touched no rows
+func (r *Repository) versionMismatchError(id int, checkVersion bool) error {
+	var count int64
+	if err := r.DB.Model(&Provider{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		r.Logger.Error("Error checking provider existence", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if count == 0 || !checkVersion {
+		r.Logger.Warn("Provider not found for update", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Warn("Provider was modified concurrently", zap.Int("id", id))
+	return domainErrors.NewAppErrorWithType(domainErrors.Conflict)
+}
+
 func (r *Repository) Delete(id int) error {
 	tx := r.DB.Delete(&Provider{}, id)
 	if tx.Error != nil {
@@ -172,6 +219,7 @@ func (p *Provider) toDomainMapper() *domainProvider.Provider {
 		Description: p.Description,
 		Config:      p.Config,
 		Status:      p.Status,
+		Version:     p.Version,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 	}
@@ -185,6 +233,7 @@ func fromDomainMapper(p *domainProvider.Provider) *Provider {
 		Description: p.Description,
 		Config:      p.Config,
 		Status:      p.Status,
+		Version:     p.Version,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
 	}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ProviderDrill is the database model for provider failover drills
+type ProviderDrill struct {
+	ID         int        `gorm:"primaryKey"`
+	UserID     int        `gorm:"column:user_id;index:idx_provider_drill_user_ends"`
+	ProviderID int        `gorm:"column:provider_id"`
+	StartedBy  int        `gorm:"column:started_by"`
+	Reason     string     `gorm:"column:reason"`
+	StartsAt   time.Time  `gorm:"column:starts_at"`
+	EndsAt     time.Time  `gorm:"column:ends_at;index:idx_provider_drill_user_ends"`
+	EndedAt    *time.Time `gorm:"column:ended_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime:mili"`
+}
+
+func (ProviderDrill) TableName() string {
+	return "provider_drills"
+}
+
+// ProviderDrillRepositoryInterface defines the interface for provider failover drill operations
+type ProviderDrillRepositoryInterface interface {
+	Create(drill *domainProvider.ProviderDrill) (*domainProvider.ProviderDrill, error)
+	// GetActive retrieves the drills running at the given time, of all users
+	GetActive(now time.Time) (*[]domainProvider.ProviderDrill, error)
+	// GetActiveProviderIDs retrieves the providers of a user in a drill at the given time
+	GetActiveProviderIDs(userID int, now time.Time) (map[int]bool, error)
+	// End ends a running drill, returning NotFound if there is no such running drill
+	End(id int, now time.Time) (*domainProvider.ProviderDrill, error)
+}
+
+type ProviderDrillRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewProviderDrillRepository(db *gorm.DB, loggerInstance *logger.Logger) ProviderDrillRepositoryInterface {
+	return &ProviderDrillRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ProviderDrillRepository) Create(drillDomain *domainProvider.ProviderDrill) (*domainProvider.ProviderDrill, error) {
+	drill := providerDrillFromDomainMapper(drillDomain)
+	if err := r.DB.Create(drill).Error; err != nil {
+		r.Logger.Error("Error creating provider drill", zap.Error(err),
+			zap.Int("userID", drillDomain.UserID), zap.Int("providerID", drillDomain.ProviderID))
+		return &domainProvider.ProviderDrill{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return drill.toDomainMapper(), nil
+}
+
+func (r *ProviderDrillRepository) GetActive(now time.Time) (*[]domainProvider.ProviderDrill, error) {
+	var drills []ProviderDrill
+	err := r.active(r.DB, now).Order("ends_at").Find(&drills).Error
+	if err != nil {
+		r.Logger.Error("Error getting active provider drills", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.ProviderDrill, len(drills))
+	for i, drill := range drills {
+		result[i] = *drill.toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *ProviderDrillRepository) GetActiveProviderIDs(userID int, now time.Time) (map[int]bool, error) {
+	var providerIDs []int
+	err := r.active(r.DB.Model(&ProviderDrill{}).Where("user_id = ?", userID), now).Pluck("provider_id", &providerIDs).Error
+	if err != nil {
+		r.Logger.Error("Error getting providers in a drill", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make(map[int]bool, len(providerIDs))
+	for _, providerID := range providerIDs {
+		result[providerID] = true
+	}
+	return result, nil
+}
+
+func (r *ProviderDrillRepository) End(id int, now time.Time) (*domainProvider.ProviderDrill, error) {
+	tx := r.active(r.DB.Model(&ProviderDrill{}).Where("id = ?", id), now).Update("ended_at", now)
+	if tx.Error != nil {
+		r.Logger.Error("Error ending provider drill", zap.Error(tx.Error), zap.Int("id", id))
+		return &domainProvider.ProviderDrill{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return &domainProvider.ProviderDrill{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+
+	var drill ProviderDrill
+	if err := r.DB.Where("id = ?", id).First(&drill).Error; err != nil {
+		r.Logger.Error("Error getting provider drill", zap.Error(err), zap.Int("id", id))
+		return &domainProvider.ProviderDrill{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return drill.toDomainMapper(), nil
+}
+
+// active restricts a query to the drills running at the given time
+func (r *ProviderDrillRepository) active(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("starts_at <= ? AND ends_at > ? AND ended_at IS NULL", now, now)
+}
+
+// Mappers
+func (d *ProviderDrill) toDomainMapper() *domainProvider.ProviderDrill {
+	return &domainProvider.ProviderDrill{
+		ID:         d.ID,
+		UserID:     d.UserID,
+		ProviderID: d.ProviderID,
+		StartedBy:  d.StartedBy,
+		Reason:     d.Reason,
+		StartsAt:   d.StartsAt,
+		EndsAt:     d.EndsAt,
+		EndedAt:    d.EndedAt,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func providerDrillFromDomainMapper(d *domainProvider.ProviderDrill) *ProviderDrill {
+	return &ProviderDrill{
+		ID:         d.ID,
+		UserID:     d.UserID,
+		ProviderID: d.ProviderID,
+		StartedBy:  d.StartedBy,
+		Reason:     d.Reason,
+		StartsAt:   d.StartsAt,
+		EndsAt:     d.EndsAt,
+		EndedAt:    d.EndedAt,
+		CreatedAt:  d.CreatedAt,
+	}
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RecipientCapViolation is the database model for the sends held or rejected by a per-recipient frequency cap
+type RecipientCapViolation struct {
+	ID                   int       `gorm:"primaryKey"`
+	UserID               int       `gorm:"column:user_id;index:idx_recipient_cap_violations_user_created,priority:1"`
+	Recipient            string    `gorm:"column:recipient;size:255"`
+	Window               string    `gorm:"column:cap_window;size:8"`
+	Cap                  int       `gorm:"column:cap"`
+	Action               string    `gorm:"column:action;size:8"`
+	MessageTransactionID int       `gorm:"column:message_transaction_id"`
+	CreatedAt            time.Time `gorm:"autoCreateTime:mili;index:idx_recipient_cap_violations_user_created,priority:2"`
+}
+
+func (RecipientCapViolation) TableName() string {
+	return "recipient_cap_violations"
+}
+
+// RecipientCapViolationRepositoryInterface defines the interface for recipient cap violation operations
+type RecipientCapViolationRepositoryInterface interface {
+	CreateBatch(violations []domainProvider.RecipientCapViolation) error
+	// GetUserRollup counts the held and rejected sends of a user per recipient within [from, to), the recipients
+	// capped most often first
+	GetUserRollup(userID int, from time.Time, to time.Time) (*[]domainProvider.RecipientCapRollup, error)
+}
+
+type RecipientCapViolationRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewRecipientCapViolationRepository(db *gorm.DB, loggerInstance *logger.Logger) RecipientCapViolationRepositoryInterface {
+	return &RecipientCapViolationRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *RecipientCapViolationRepository) CreateBatch(violations []domainProvider.RecipientCapViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	models := make([]RecipientCapViolation, len(violations))
+	for i := range violations {
+		models[i] = *recipientCapViolationFromDomainMapper(&violations[i])
+	}
+	if err := r.DB.Create(&models).Error; err != nil {
+		r.Logger.Error("Error creating recipient cap violations", zap.Error(err), zap.Int("count", len(models)))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *RecipientCapViolationRepository) GetUserRollup(userID int, from time.Time, to time.Time) (*[]domainProvider.RecipientCapRollup, error) {
+	var rows []struct {
+		Recipient       string
+		Held            int
+		Rejected        int
+		LastViolationAt time.Time
+	}
+	err := r.DB.Model(&RecipientCapViolation{}).
+		Select("recipient, SUM(CASE WHEN action = ? THEN 1 ELSE 0 END) AS held, SUM(CASE WHEN action = ? THEN 1 ELSE 0 END) AS rejected, MAX(created_at) AS last_violation_at",
+			domainProvider.RecipientCapActionHold, domainProvider.RecipientCapActionReject).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Group("recipient").
+		Order("COUNT(*) DESC, recipient").
+		Scan(&rows).Error
+	if err != nil {
+		r.Logger.Error("Error getting recipient cap rollup", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.RecipientCapRollup, len(rows))
+	for i, row := range rows {
+		result[i] = domainProvider.RecipientCapRollup{
+			Recipient:       row.Recipient,
+			Held:            row.Held,
+			Rejected:        row.Rejected,
+			LastViolationAt: row.LastViolationAt,
+		}
+	}
+	return &result, nil
+}
+
+// Mappers
+func recipientCapViolationFromDomainMapper(v *domainProvider.RecipientCapViolation) *RecipientCapViolation {
+	return &RecipientCapViolation{
+		ID:                   v.ID,
+		UserID:               v.UserID,
+		Recipient:            v.Recipient,
+		Window:               v.Window,
+		Cap:                  v.Cap,
+		Action:               v.Action,
+		MessageTransactionID: v.MessageTransactionID,
+		CreatedAt:            v.CreatedAt,
+	}
+}
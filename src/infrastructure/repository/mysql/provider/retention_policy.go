@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RetentionPolicy is the database model of the data retention of an org
+type RetentionPolicy struct {
+	ID             int       `gorm:"primaryKey"`
+	Org            string    `gorm:"column:org;type:varchar(64);uniqueIndex"`
+	BodyDays       int       `gorm:"column:body_days"`
+	AttachmentDays int       `gorm:"column:attachment_days"`
+	AuditLogDays   int       `gorm:"column:audit_log_days"`
+	RedactBodies   bool      `gorm:"column:redact_bodies"`
+	UpdatedBy      int       `gorm:"column:updated_by"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (RetentionPolicy) TableName() string {
+	return "retention_policies"
+}
+
+// RetentionPolicyRepositoryInterface defines the interface for reading and changing the retention of the orgs
+type RetentionPolicyRepositoryInterface interface {
+	// Get returns the policy of an org, keeping everything while it was never changed
+	Get(org string) (*domainProvider.RetentionPolicy, error)
+	Save(policy *domainProvider.RetentionPolicy) (*domainProvider.RetentionPolicy, error)
+}
+
+type RetentionPolicyRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewRetentionPolicyRepository(db *gorm.DB, loggerInstance *logger.Logger) RetentionPolicyRepositoryInterface {
+	return &RetentionPolicyRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *RetentionPolicyRepository) Get(org string) (*domainProvider.RetentionPolicy, error) {
+	var policy RetentionPolicy
+	err := r.DB.Where("org = ?", org).First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return &domainProvider.RetentionPolicy{Org: org}, nil
+	}
+	if err != nil {
+		r.Logger.Error("Error getting retention policy", zap.Error(err), zap.String("org", org))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return policy.toDomainMapper(), nil
+}
+
+func (r *RetentionPolicyRepository) Save(policyDomain *domainProvider.RetentionPolicy) (*domainProvider.RetentionPolicy, error) {
+	policy := &RetentionPolicy{
+		Org:            policyDomain.Org,
+		BodyDays:       policyDomain.BodyDays,
+		AttachmentDays: policyDomain.AttachmentDays,
+		AuditLogDays:   policyDomain.AuditLogDays,
+		RedactBodies:   policyDomain.RedactBodies,
+		UpdatedBy:      policyDomain.UpdatedBy,
+	}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org"}},
+		DoUpdates: clause.AssignmentColumns([]string{"body_days", "attachment_days", "audit_log_days", "redact_bodies", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+	if err != nil {
+		r.Logger.Error("Error saving retention policy", zap.Error(err), zap.String("org", policyDomain.Org))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return policy.toDomainMapper(), nil
+}
+
+// Mappers
+func (p *RetentionPolicy) toDomainMapper() *domainProvider.RetentionPolicy {
+	updatedAt := p.UpdatedAt
+	return &domainProvider.RetentionPolicy{
+		Org:            p.Org,
+		BodyDays:       p.BodyDays,
+		AttachmentDays: p.AttachmentDays,
+		AuditLogDays:   p.AuditLogDays,
+		RedactBodies:   p.RedactBodies,
+		UpdatedBy:      p.UpdatedBy,
+		UpdatedAt:      &updatedAt,
+	}
+}
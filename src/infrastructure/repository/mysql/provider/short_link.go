@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"sort"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ShortLink is the database model for the tracked URLs of messages
+type ShortLink struct {
+	ID         int       `gorm:"primaryKey"`
+	MessageID  int       `gorm:"column:message_id;index"`
+	UserID     int       `gorm:"column:user_id"`
+	Index      int       `gorm:"column:link_index"`
+	URL        string    `gorm:"column:url;type:text"`
+	Recipients string    `gorm:"column:recipients;type:text"`
+	CreatedAt  time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (ShortLink) TableName() string {
+	return "short_links"
+}
+
+// LinkClick is the database model for the clicks on short links
+type LinkClick struct {
+	ID        int       `gorm:"primaryKey"`
+	LinkID    int       `gorm:"column:link_id"`
+	MessageID int       `gorm:"column:message_id;index"`
+	UserID    int       `gorm:"column:user_id;index:idx_link_clicks_user_clicked"`
+	Recipient string    `gorm:"column:recipient"`
+	UserAgent string    `gorm:"column:user_agent;size:512"`
+	ClickedAt time.Time `gorm:"column:clicked_at;index:idx_link_clicks_user_clicked"`
+}
+
+func (LinkClick) TableName() string {
+	return "link_clicks"
+}
+
+// ShortLinkRepositoryInterface defines the interface for short link and click operations
+type ShortLinkRepositoryInterface interface {
+	CreateBatch(links []domainProvider.ShortLink) (*[]domainProvider.ShortLink, error)
+	GetByID(id int) (*domainProvider.ShortLink, error)
+	GetByMessageID(messageID int) (*[]domainProvider.ShortLink, error)
+	RecordClick(click *domainProvider.LinkClick) error
+	GetMessageClickStats(messageID int) (*domainProvider.LinkClickStats, error)
+	// CountUserClicks counts the clicks on the links of a user between from (inclusive) and to (exclusive) and
+	// the distinct recipients who clicked
+	CountUserClicks(userID int, from time.Time, to time.Time) (int, int, error)
+}
+
+type ShortLinkRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewShortLinkRepository(db *gorm.DB, loggerInstance *logger.Logger) ShortLinkRepositoryInterface {
+	return &ShortLinkRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ShortLinkRepository) CreateBatch(linksDomain []domainProvider.ShortLink) (*[]domainProvider.ShortLink, error) {
+	result := make([]domainProvider.ShortLink, 0, len(linksDomain))
+	if len(linksDomain) == 0 {
+		return &result, nil
+	}
+	links := make([]ShortLink, len(linksDomain))
+	for i := range linksDomain {
+		links[i] = *shortLinkFromDomainMapper(&linksDomain[i])
+	}
+	if err := r.DB.Create(&links).Error; err != nil {
+		r.Logger.Error("Error creating short links", zap.Error(err), zap.Int("messageID", linksDomain[0].MessageID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	for i := range links {
+		result = append(result, *links[i].toDomainMapper())
+	}
+	r.Logger.Info("Successfully created short links", zap.Int("messageID", linksDomain[0].MessageID), zap.Int("count", len(links)))
+	return &result, nil
+}
+
+func (r *ShortLinkRepository) GetByID(id int) (*domainProvider.ShortLink, error) {
+	var link ShortLink
+	if err := r.DB.Where("id = ?", id).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &domainProvider.ShortLink{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		}
+		r.Logger.Error("Error getting short link", zap.Error(err), zap.Int("id", id))
+		return &domainProvider.ShortLink{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return link.toDomainMapper(), nil
+}
+
+// GetByMessageID returns the tracked URLs of a message in the order they appear in it
+func (r *ShortLinkRepository) GetByMessageID(messageID int) (*[]domainProvider.ShortLink, error) {
+	var links []ShortLink
+	if err := r.DB.Where("message_id = ?", messageID).Order("link_index").Find(&links).Error; err != nil {
+		r.Logger.Error("Error getting short links of message", zap.Error(err), zap.Int("messageID", messageID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.ShortLink, len(links))
+	for i := range links {
+		result[i] = *links[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *ShortLinkRepository) RecordClick(clickDomain *domainProvider.LinkClick) error {
+	click := &LinkClick{
+		LinkID:    clickDomain.LinkID,
+		MessageID: clickDomain.MessageID,
+		UserID:    clickDomain.UserID,
+		Recipient: clickDomain.Recipient,
+		UserAgent: clickDomain.UserAgent,
+		ClickedAt: clickDomain.ClickedAt,
+	}
+	if err := r.DB.Create(click).Error; err != nil {
+		r.Logger.Error("Error recording link click", zap.Error(err), zap.Int("linkID", clickDomain.LinkID))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+// GetMessageClickStats summarizes the clicks on the links of a message, per URL and per recipient
+func (r *ShortLinkRepository) GetMessageClickStats(messageID int) (*domainProvider.LinkClickStats, error) {
+	links, err := r.GetByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	var clicks []LinkClick
+	if err := r.DB.Where("message_id = ?", messageID).Order("clicked_at").Find(&clicks).Error; err != nil {
+		r.Logger.Error("Error getting link clicks of message", zap.Error(err), zap.Int("messageID", messageID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return summarizeClicks(*links, clicks), nil
+}
+
+// summarizeClicks counts clicks, ordered by click time, per link and per recipient
+func summarizeClicks(links []domainProvider.ShortLink, clicks []LinkClick) *domainProvider.LinkClickStats {
+	stats := &domainProvider.LinkClickStats{
+		Links:      make([]domainProvider.LinkStats, len(links)),
+		Recipients: []domainProvider.RecipientClicks{},
+	}
+	linkIndex := map[int]int{}
+	linkClickers := make([]map[string]bool, len(links))
+	for i, link := range links {
+		stats.Links[i].URL = link.URL
+		linkIndex[link.ID] = i
+		linkClickers[i] = map[string]bool{}
+	}
+
+	recipientIndex := map[string]int{}
+	for _, click := range clicks {
+		stats.Clicks++
+		if i, ok := linkIndex[click.LinkID]; ok {
+			stats.Links[i].Clicks++
+			linkClickers[i][click.Recipient] = true
+		}
+		i, ok := recipientIndex[click.Recipient]
+		if !ok {
+			stats.Recipients = append(stats.Recipients, domainProvider.RecipientClicks{Recipient: click.Recipient, FirstClickedAt: click.ClickedAt})
+			i = len(stats.Recipients) - 1
+			recipientIndex[click.Recipient] = i
+		}
+		stats.Recipients[i].Clicks++
+		stats.Recipients[i].LastClickedAt = click.ClickedAt
+	}
+	for i := range stats.Links {
+		stats.Links[i].UniqueRecipients = len(linkClickers[i])
+	}
+	stats.UniqueRecipients = len(stats.Recipients)
+	sort.SliceStable(stats.Recipients, func(a, b int) bool {
+		return stats.Recipients[a].Clicks > stats.Recipients[b].Clicks
+	})
+	return stats
+}
+
+func (r *ShortLinkRepository) CountUserClicks(userID int, from time.Time, to time.Time) (int, int, error) {
+	var counts struct {
+		Clicks           int
+		UniqueRecipients int
+	}
+	err := r.DB.Model(&LinkClick{}).
+		Select("COUNT(*) AS clicks, COUNT(DISTINCT recipient) AS unique_recipients").
+		Where("user_id = ? AND clicked_at >= ? AND clicked_at < ?", userID, from, to).
+		Scan(&counts).Error
+	if err != nil {
+		r.Logger.Error("Error counting link clicks", zap.Error(err), zap.Int("userID", userID))
+		return 0, 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return counts.Clicks, counts.UniqueRecipients, nil
+}
+
+// Mappers
+func (l *ShortLink) toDomainMapper() *domainProvider.ShortLink {
+	return &domainProvider.ShortLink{
+		ID:         l.ID,
+		MessageID:  l.MessageID,
+		UserID:     l.UserID,
+		Index:      l.Index,
+		URL:        l.URL,
+		Recipients: l.Recipients,
+		CreatedAt:  l.CreatedAt,
+	}
+}
+
+func shortLinkFromDomainMapper(l *domainProvider.ShortLink) *ShortLink {
+	return &ShortLink{
+		ID:         l.ID,
+		MessageID:  l.MessageID,
+		UserID:     l.UserID,
+		Index:      l.Index,
+		URL:        l.URL,
+		Recipients: l.Recipients,
+		CreatedAt:  l.CreatedAt,
+	}
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// StatusBanner is the database model for the banners of the status page
+type StatusBanner struct {
+	ID           int        `gorm:"primaryKey"`
+	Title        string     `gorm:"column:title"`
+	Message      string     `gorm:"column:message;type:text"`
+	Severity     string     `gorm:"column:severity;size:16"`
+	ProviderType string     `gorm:"column:provider_type;size:32"`
+	StartsAt     time.Time  `gorm:"column:starts_at;index"`
+	EndsAt       *time.Time `gorm:"column:ends_at"`
+	CreatedBy    int        `gorm:"column:created_by"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (StatusBanner) TableName() string {
+	return "status_banners"
+}
+
+var ColumnsStatusBannerMapping = map[string]string{
+	"title":        "title",
+	"message":      "message",
+	"severity":     "severity",
+	"providerType": "provider_type",
+	"startsAt":     "starts_at",
+	"endsAt":       "ends_at",
+}
+
+// StatusBannerRepositoryInterface defines the interface for status banner operations
+type StatusBannerRepositoryInterface interface {
+	Create(banner *domainProvider.StatusBanner) (*domainProvider.StatusBanner, error)
+	GetByID(id int) (*domainProvider.StatusBanner, error)
+	// GetAll retrieves every banner, the most recently started first
+	GetAll() (*[]domainProvider.StatusBanner, error)
+	// GetActive retrieves the banners shown at the given time, the most recently started first
+	GetActive(now time.Time) (*[]domainProvider.StatusBanner, error)
+	Update(id int, bannerMap map[string]interface{}) (*domainProvider.StatusBanner, error)
+	Delete(id int) error
+}
+
+type StatusBannerRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewStatusBannerRepository(db *gorm.DB, loggerInstance *logger.Logger) StatusBannerRepositoryInterface {
+	return &StatusBannerRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *StatusBannerRepository) Create(bannerDomain *domainProvider.StatusBanner) (*domainProvider.StatusBanner, error) {
+	banner := statusBannerFromDomainMapper(bannerDomain)
+	if err := r.DB.Create(banner).Error; err != nil {
+		r.Logger.Error("Error creating status banner", zap.Error(err))
+		return &domainProvider.StatusBanner{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return banner.toDomainMapper(), nil
+}
+
+func (r *StatusBannerRepository) GetByID(id int) (*domainProvider.StatusBanner, error) {
+	var banner StatusBanner
+	err := r.DB.Where("id = ?", id).First(&banner).Error
+	if err == gorm.ErrRecordNotFound {
+		return &domainProvider.StatusBanner{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting status banner", zap.Error(err), zap.Int("id", id))
+		return &domainProvider.StatusBanner{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return banner.toDomainMapper(), nil
+}
+
+func (r *StatusBannerRepository) GetAll() (*[]domainProvider.StatusBanner, error) {
+	return r.find(r.DB, "Error getting status banners")
+}
+
+func (r *StatusBannerRepository) GetActive(now time.Time) (*[]domainProvider.StatusBanner, error) {
+	query := r.DB.Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now)
+	return r.find(query, "Error getting active status banners")
+}
+
+func (r *StatusBannerRepository) Update(id int, bannerMap map[string]interface{}) (*domainProvider.StatusBanner, error) {
+	updates := make(map[string]interface{}, len(bannerMap))
+	for key, value := range bannerMap {
+		if column, ok := ColumnsStatusBannerMapping[key]; ok {
+			updates[column] = value
+		}
+	}
+	tx := r.DB.Model(&StatusBanner{}).Where("id = ?", id).Updates(updates)
+	if tx.Error != nil {
+		r.Logger.Error("Error updating status banner", zap.Error(tx.Error), zap.Int("id", id))
+		return &domainProvider.StatusBanner{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return r.GetByID(id)
+}
+
+func (r *StatusBannerRepository) Delete(id int) error {
+	tx := r.DB.Delete(&StatusBanner{}, id)
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting status banner", zap.Error(tx.Error), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if tx.RowsAffected == 0 {
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return nil
+}
+
+func (r *StatusBannerRepository) find(query *gorm.DB, errorMessage string) (*[]domainProvider.StatusBanner, error) {
+	var banners []StatusBanner
+	if err := query.Order("starts_at DESC, id DESC").Find(&banners).Error; err != nil {
+		r.Logger.Error(errorMessage, zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainProvider.StatusBanner, len(banners))
+	for i, banner := range banners {
+		result[i] = *banner.toDomainMapper()
+	}
+	return &result, nil
+}
+
+// Mappers
+func (b *StatusBanner) toDomainMapper() *domainProvider.StatusBanner {
+	return &domainProvider.StatusBanner{
+		ID:           b.ID,
+		Title:        b.Title,
+		Message:      b.Message,
+		Severity:     b.Severity,
+		ProviderType: b.ProviderType,
+		StartsAt:     b.StartsAt,
+		EndsAt:       b.EndsAt,
+		CreatedBy:    b.CreatedBy,
+		CreatedAt:    b.CreatedAt,
+		UpdatedAt:    b.UpdatedAt,
+	}
+}
+
+func statusBannerFromDomainMapper(b *domainProvider.StatusBanner) *StatusBanner {
+	return &StatusBanner{
+		ID:           b.ID,
+		Title:        b.Title,
+		Message:      b.Message,
+		Severity:     b.Severity,
+		ProviderType: b.ProviderType,
+		StartsAt:     b.StartsAt,
+		EndsAt:       b.EndsAt,
+		CreatedBy:    b.CreatedBy,
+		CreatedAt:    b.CreatedAt,
+		UpdatedAt:    b.UpdatedAt,
+	}
+}
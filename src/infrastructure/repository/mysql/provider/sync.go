@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SyncRepositoryInterface reads the changes of a user after a sync cursor. Every method returns the changes in the
+// order of the cursor, at most limit of them.
+type SyncRepositoryInterface interface {
+	// GetStatusChanges reads the lifecycle events of the messages of the user after an event
+	GetStatusChanges(userID int, afterEventID int, limit int) ([]domainProvider.MessageStatusChange, error)
+	// GetInboundMessages reads the messages received in the conversations of the user after a message
+	GetInboundMessages(userID int, afterMessageID int, limit int) ([]domainProvider.InboundMessage, error)
+	// GetChangedUserProviders reads the provider configs of the user changed after the cursor, oldest change first
+	GetChangedUserProviders(userID int, afterUpdatedAt time.Time, afterID int, limit int) (*[]domainProvider.UserProvider, error)
+	// GetUserProviderIDs returns the IDs of the provider configs the user has, deleted configs are missing
+	GetUserProviderIDs(userID int) ([]int, error)
+}
+
+type SyncRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewSyncRepository(db *gorm.DB, loggerInstance *logger.Logger) SyncRepositoryInterface {
+	return &SyncRepository{DB: db, Logger: loggerInstance}
+}
+
+// statusEventPayload is the part of the payload of a lifecycle event reported by a sync
+type statusEventPayload struct {
+	Status       string `json:"status"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+	RetryCount   int    `json:"retry_count"`
+	OccurredAt   string `json:"occurred_at"`
+}
+
+func (r *SyncRepository) GetStatusChanges(userID int, afterEventID int, limit int) ([]domainProvider.MessageStatusChange, error) {
+	var events []OutboxEvent
+	err := r.DB.Where("user_id = ? AND id > ?", userID, afterEventID).Order("id ASC").Limit(limit).Find(&events).Error
+	if err != nil {
+		r.Logger.Error("Error getting message status changes", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	changes := make([]domainProvider.MessageStatusChange, len(events))
+	for i, event := range events {
+		var payload statusEventPayload
+		_ = json.Unmarshal([]byte(event.Payload), &payload)
+		occurredAt, err := time.Parse(time.RFC3339Nano, payload.OccurredAt)
+		if err != nil {
+			occurredAt = event.CreatedAt
+		}
+		changes[i] = domainProvider.MessageStatusChange{
+			EventID:      event.ID,
+			MessageID:    event.MessageID,
+			ProviderID:   event.ProviderID,
+			EventType:    event.EventType,
+			Status:       payload.Status,
+			ErrorCode:    payload.ErrorCode,
+			ErrorMessage: payload.ErrorMessage,
+			RetryCount:   payload.RetryCount,
+			OccurredAt:   occurredAt,
+		}
+	}
+	return changes, nil
+}
+
+func (r *SyncRepository) GetInboundMessages(userID int, afterMessageID int, limit int) ([]domainProvider.InboundMessage, error) {
+	var messages []domainProvider.InboundMessage
+	err := r.DB.Table("conversation_messages").
+		Select("conversation_messages.id, conversation_messages.conversation_id, conversations.channel, conversations.participant, "+
+			"conversation_messages.external_id, conversation_messages.body, conversation_messages.occurred_at").
+		Joins("JOIN conversations ON conversations.id = conversation_messages.conversation_id").
+		Where("conversations.user_id = ? AND conversation_messages.direction = ? AND conversation_messages.id > ?",
+			userID, domainProvider.DirectionInbound, afterMessageID).
+		Order("conversation_messages.id ASC").
+		Limit(limit).
+		Scan(&messages).Error
+	if err != nil {
+		r.Logger.Error("Error getting inbound messages", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return messages, nil
+}
+
+func (r *SyncRepository) GetChangedUserProviders(userID int, afterUpdatedAt time.Time, afterID int, limit int) (*[]domainProvider.UserProvider, error) {
+	var userProviders []UserProvider
+	err := r.DB.Where("user_id = ? AND (updated_at > ? OR (updated_at = ? AND id > ?))", userID, afterUpdatedAt, afterUpdatedAt, afterID).
+		Order("updated_at ASC, id ASC").
+		Limit(limit).
+		Find(&userProviders).Error
+	if err != nil {
+		r.Logger.Error("Error getting changed user providers", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return userProviderArrayToDomainMapper(&userProviders), nil
+}
+
+func (r *SyncRepository) GetUserProviderIDs(userID int) ([]int, error) {
+	var ids []int
+	if err := r.DB.Model(&UserProvider{}).Where("user_id = ?", userID).Order("id ASC").Pluck("id", &ids).Error; err != nil {
+		r.Logger.Error("Error getting user provider ids", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return ids, nil
+}
@@ -14,14 +14,20 @@ import (
 
 // UserProvider is the database model for user providers
 type UserProvider struct {
-	ID         int       `gorm:"primaryKey"`
-	UserID     int       `gorm:"column:user_id;index"`
-	ProviderID int       `gorm:"column:provider_id;index"`
-	Priority   int       `gorm:"column:priority"`
-	Config     string    `gorm:"column:config;type:text"`
-	Status     bool      `gorm:"column:status"`
-	CreatedAt  time.Time `gorm:"autoCreateTime:mili"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime:mili"`
+	ID                   int        `gorm:"primaryKey"`
+	UserID               int        `gorm:"column:user_id;index"`
+	ProviderID           int        `gorm:"column:provider_id;index"`
+	Priority             int        `gorm:"column:priority"`
+	Config               string     `gorm:"column:config;type:text"`
+	Status               bool       `gorm:"column:status"`
+	Suspended            bool       `gorm:"column:suspended;default:false"`
+	Version              int        `gorm:"column:version;not null;default:1"`
+	CredentialStatus     string     `gorm:"column:credential_status;size:16"`
+	CredentialError      string     `gorm:"column:credential_error;type:text"`
+	CredentialsCheckedAt *time.Time `gorm:"column:credentials_checked_at"`
+	CredentialsForced    bool       `gorm:"column:credentials_forced;default:false"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime:mili"`
 }
 
 func (UserProvider) TableName() string {
@@ -29,14 +35,20 @@ func (UserProvider) TableName() string {
 }
 
 var ColumnsUserProviderMapping = map[string]string{
-	"id":         "id",
-	"userID":     "user_id",
-	"providerID": "provider_id",
-	"priority":   "priority",
-	"config":     "config",
-	"status":     "status",
-	"createdAt":  "created_at",
-	"updatedAt":  "updated_at",
+	"id":                   "id",
+	"userID":               "user_id",
+	"providerID":           "provider_id",
+	"priority":             "priority",
+	"config":               "config",
+	"status":               "status",
+	"suspended":            "suspended",
+	"version":              "version",
+	"createdAt":            "created_at",
+	"updatedAt":            "updated_at",
+	"credentialStatus":     "credential_status",
+	"credentialError":      "credential_error",
+	"credentialsCheckedAt": "credentials_checked_at",
+	"credentialsForced":    "credentials_forced",
 }
 
 // UserProviderRepositoryInterface defines the interface for user provider repository operations
@@ -46,7 +58,10 @@ type UserProviderRepositoryInterface interface {
 	GetByID(id int) (*domainProvider.UserProvider, error)
 	Update(id int, userProviderMap map[string]interface{}) (*domainProvider.UserProvider, error)
 	Delete(id int) error
+	// GetUserProvidersByPriority retrieves the user providers messages of the user are routed to, skipping the
+	// inactive ones and those whose credentials failed the check unless they were forced
 	GetUserProvidersByPriority(userID int) (*[]domainProvider.UserProvider, error)
+	GetActiveByProviderType(providerType string) (*[]domainProvider.UserProvider, error)
 }
 
 type UserProviderRepository struct {
@@ -124,9 +139,18 @@ func (r *UserProviderRepository) Update(id int, userProviderMap map[string]inter
 		}
 	}
 
-	err := r.DB.Model(&userProviderObj).
-		Select("user_id", "provider_id", "priority", "config", "status").
-		Updates(updateData).Error
+	// Optimistic locking: when the caller sends the version it read, only update if nobody changed it since
+	expectedVersion, checkVersion := updateData["version"]
+	updateData["version"] = gorm.Expr("version + 1")
+
+	query := r.DB.Model(&userProviderObj).
+		Select("user_id", "provider_id", "priority", "config", "status", "suspended", "version",
+			"credential_status", "credential_error", "credentials_checked_at", "credentials_forced")
+	if checkVersion {
+		query = query.Where("version = ?", expectedVersion)
+	}
+	tx := query.Updates(updateData)
+	err := tx.Error
 	if err != nil {
 		r.Logger.Error("Error updating user provider", zap.Error(err), zap.Int("id", id))
 		byteErr, _ := json.Marshal(err)
@@ -142,6 +166,9 @@ func (r *UserProviderRepository) Update(id int, userProviderMap map[string]inter
 			return &domainProvider.UserProvider{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 		}
 	}
+	if tx.RowsAffected == 0 {
+		return &domainProvider.UserProvider{}, r.versionMismatchError(id, checkVersion)
+	}
 	if err := r.DB.Where("id = ?", id).First(&userProviderObj).Error; err != nil {
 		r.Logger.Error("Error retrieving updated user provider", zap.Error(err), zap.Int("id", id))
 		return &domainProvider.UserProvider{}, err
@@ -150,6 +177,21 @@ func (r *UserProviderRepository) Update(id int, userProviderMap map[string]inter
 	return userProviderObj.toDomainMapper(), nil
 }
 
+// versionMismatchError tells apart a missing user provider from a concurrent modification after an update touched no rows
+func (r *UserProviderRepository) versionMismatchError(id int, checkVersion bool) error {
+	var count int64
+	if err := r.DB.Model(&UserProvider{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		r.Logger.Error("Error checking user provider existence", zap.Error(err), zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	if count == 0 || !checkVersion {
+		r.Logger.Warn("User provider not found for update", zap.Int("id", id))
+		return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	r.Logger.Warn("User provider was modified concurrently", zap.Int("id", id))
+	return domainErrors.NewAppErrorWithType(domainErrors.Conflict)
+}
+
 func (r *UserProviderRepository) Delete(id int) error {
 	tx := r.DB.Delete(&UserProvider{}, id)
 	if tx.Error != nil {
@@ -166,7 +208,9 @@ func (r *UserProviderRepository) Delete(id int) error {
 
 func (r *UserProviderRepository) GetUserProvidersByPriority(userID int) (*[]domainProvider.UserProvider, error) {
 	var userProviders []UserProvider
-	if err := r.DB.Where("user_id = ? AND status = ?", userID, true).Order("priority ASC").Find(&userProviders).Error; err != nil {
+	if err := r.DB.Where("user_id = ? AND status = ?", userID, true).
+		Where("(credential_status IS NULL OR credential_status <> ? OR credentials_forced = ?)", domainProvider.CredentialStatusInvalid, true).
+		Order("priority ASC").Find(&userProviders).Error; err != nil {
 		r.Logger.Error("Error getting user providers by priority", zap.Error(err), zap.Int("userID", userID))
 		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
 	}
@@ -174,30 +218,56 @@ func (r *UserProviderRepository) GetUserProvidersByPriority(userID int) (*[]doma
 	return userProviderArrayToDomainMapper(&userProviders), nil
 }
 
+// GetActiveByProviderType retrieves the active user providers of all users for active providers of the given
+// type, used by background jobs working with the accounts of every user on a provider such as Matrix sync
+func (r *UserProviderRepository) GetActiveByProviderType(providerType string) (*[]domainProvider.UserProvider, error) {
+	var userProviders []UserProvider
+	err := r.DB.Joins("JOIN providers ON providers.id = user_providers.provider_id").
+		Where("providers.type = ? AND providers.status = ? AND user_providers.status = ?", providerType, true, true).
+		Find(&userProviders).Error
+	if err != nil {
+		r.Logger.Error("Error getting user providers by provider type", zap.Error(err), zap.String("providerType", providerType))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return userProviderArrayToDomainMapper(&userProviders), nil
+}
+
 // Mappers
 func (up *UserProvider) toDomainMapper() *domainProvider.UserProvider {
 	return &domainProvider.UserProvider{
-		ID:         up.ID,
-		UserID:     up.UserID,
-		ProviderID: up.ProviderID,
-		Priority:   up.Priority,
-		Config:     up.Config,
-		Status:     up.Status,
-		CreatedAt:  up.CreatedAt,
-		UpdatedAt:  up.UpdatedAt,
+		ID:                   up.ID,
+		UserID:               up.UserID,
+		ProviderID:           up.ProviderID,
+		Priority:             up.Priority,
+		Config:               up.Config,
+		Status:               up.Status,
+		Suspended:            up.Suspended,
+		Version:              up.Version,
+		CredentialStatus:     up.CredentialStatus,
+		CredentialError:      up.CredentialError,
+		CredentialsCheckedAt: up.CredentialsCheckedAt,
+		CredentialsForced:    up.CredentialsForced,
+		CreatedAt:            up.CreatedAt,
+		UpdatedAt:            up.UpdatedAt,
 	}
 }
 
 func userProviderFromDomainMapper(up *domainProvider.UserProvider) *UserProvider {
 	return &UserProvider{
-		ID:         up.ID,
-		UserID:     up.UserID,
-		ProviderID: up.ProviderID,
-		Priority:   up.Priority,
-		Config:     up.Config,
-		Status:     up.Status,
-		CreatedAt:  up.CreatedAt,
-		UpdatedAt:  up.UpdatedAt,
+		ID:                   up.ID,
+		UserID:               up.UserID,
+		ProviderID:           up.ProviderID,
+		Priority:             up.Priority,
+		Config:               up.Config,
+		Status:               up.Status,
+		Suspended:            up.Suspended,
+		Version:              up.Version,
+		CredentialStatus:     up.CredentialStatus,
+		CredentialError:      up.CredentialError,
+		CredentialsCheckedAt: up.CredentialsCheckedAt,
+		CredentialsForced:    up.CredentialsForced,
+		CreatedAt:            up.CreatedAt,
+		UpdatedAt:            up.UpdatedAt,
 	}
 }
 
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is the database model for the events emitted to REST hook subscriptions
+type WebhookEvent struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;index:idx_webhook_event_user_created"`
+	Event     string    `gorm:"column:event;type:varchar(64)"`
+	Payload   string    `gorm:"column:payload;type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili;index:idx_webhook_event_user_created;index"`
+}
+
+func (WebhookEvent) TableName() string {
+	return "webhook_events"
+}
+
+// WebhookEventRepositoryInterface defines the interface for webhook event operations
+type WebhookEventRepositoryInterface interface {
+	Create(event *domainProvider.WebhookEvent) (*domainProvider.WebhookEvent, error)
+	// GetUserEvents retrieves a page of the events of a user, newest first, optionally of one event type
+	GetUserEvents(userID int, event string, page domain.PageRequest) (*domain.Page[domainProvider.WebhookEvent], error)
+	GetUserEvent(userID int, id int) (*domainProvider.WebhookEvent, error)
+	// DeleteBefore removes the events created before the given time
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type WebhookEventRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewWebhookEventRepository(db *gorm.DB, loggerInstance *logger.Logger) WebhookEventRepositoryInterface {
+	return &WebhookEventRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *WebhookEventRepository) Create(eventDomain *domainProvider.WebhookEvent) (*domainProvider.WebhookEvent, error) {
+	event := webhookEventFromDomainMapper(eventDomain)
+	if err := r.DB.Create(event).Error; err != nil {
+		r.Logger.Error("Error creating webhook event", zap.Error(err), zap.Int("userID", eventDomain.UserID), zap.String("event", eventDomain.Event))
+		return &domainProvider.WebhookEvent{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return event.toDomainMapper(), nil
+}
+
+func (r *WebhookEventRepository) GetUserEvents(userID int, event string, page domain.PageRequest) (*domain.Page[domainProvider.WebhookEvent], error) {
+	query := r.DB.Where("user_id = ?", userID)
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var events []WebhookEvent
+	if err := pagination.Apply(query, "created_at", page).Find(&events).Error; err != nil {
+		r.Logger.Error("Error getting webhook events", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	events, next := pagination.Cut(events, page.Limit, func(event *WebhookEvent) domain.Cursor {
+		return domain.Cursor{Time: event.CreatedAt, ID: event.ID}
+	})
+	result := make([]domainProvider.WebhookEvent, len(events))
+	for i, event := range events {
+		result[i] = *event.toDomainMapper()
+	}
+	return &domain.Page[domainProvider.WebhookEvent]{Items: result, Next: next}, nil
+}
+
+// GetUserEvent retrieves an event of a user, returning NotFound if the user has no such event
+func (r *WebhookEventRepository) GetUserEvent(userID int, id int) (*domainProvider.WebhookEvent, error) {
+	var event WebhookEvent
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&event).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	if err != nil {
+		r.Logger.Error("Error getting webhook event", zap.Error(err), zap.Int("id", id), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return event.toDomainMapper(), nil
+}
+
+func (r *WebhookEventRepository) DeleteBefore(before time.Time) (int64, error) {
+	tx := r.DB.Where("created_at < ?", before).Delete(&WebhookEvent{})
+	if tx.Error != nil {
+		r.Logger.Error("Error deleting webhook events", zap.Error(tx.Error), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return tx.RowsAffected, nil
+}
+
+// Mappers
+func (e *WebhookEvent) toDomainMapper() *domainProvider.WebhookEvent {
+	return &domainProvider.WebhookEvent{
+		ID:        e.ID,
+		UserID:    e.UserID,
+		Event:     e.Event,
+		Payload:   e.Payload,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func webhookEventFromDomainMapper(e *domainProvider.WebhookEvent) *WebhookEvent {
+	return &WebhookEvent{
+		ID:        e.ID,
+		UserID:    e.UserID,
+		Event:     e.Event,
+		Payload:   e.Payload,
+		CreatedAt: e.CreatedAt,
+	}
+}
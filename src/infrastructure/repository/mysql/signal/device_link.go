@@ -0,0 +1,119 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeviceLink is the database model for a number operated as a linked device
+type DeviceLink struct {
+	ID         int        `gorm:"primaryKey"`
+	Number     string     `gorm:"column:number;type:varchar(64);uniqueIndex"`
+	DeviceName string     `gorm:"column:device_name;type:varchar(255)"`
+	Status     string     `gorm:"column:status;type:varchar(20);index"`
+	LinkedAt   time.Time  `gorm:"column:linked_at"`
+	UnlinkedAt *time.Time `gorm:"column:unlinked_at"`
+	LastError  string     `gorm:"column:last_error;type:text"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (DeviceLink) TableName() string {
+	return "signal_device_links"
+}
+
+// DeviceLinkRepositoryInterface defines the interface for device link repository operations
+type DeviceLinkRepositoryInterface interface {
+	GetByNumber(number string) (*domainSignal.DeviceLink, error)
+	GetAll() (*[]domainSignal.DeviceLink, error)
+	Save(link *domainSignal.DeviceLink) (*domainSignal.DeviceLink, error)
+}
+
+type DeviceLinkRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewDeviceLinkRepository(db *gorm.DB, loggerInstance *logger.Logger) DeviceLinkRepositoryInterface {
+	return &DeviceLinkRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *DeviceLinkRepository) GetByNumber(number string) (*domainSignal.DeviceLink, error) {
+	var link DeviceLink
+	err := r.DB.Where("number = ?", number).First(&link).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting device link", zap.Error(err), zap.String("number", number))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainSignal.DeviceLink{}, err
+	}
+	return link.toDomainMapper(), nil
+}
+
+func (r *DeviceLinkRepository) GetAll() (*[]domainSignal.DeviceLink, error) {
+	var links []DeviceLink
+	if err := r.DB.Order("number").Find(&links).Error; err != nil {
+		r.Logger.Error("Error getting device links", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.DeviceLink, len(links))
+	for i := range links {
+		result[i] = *links[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+// Save creates or replaces the link state of a number
+func (r *DeviceLinkRepository) Save(linkDomain *domainSignal.DeviceLink) (*domainSignal.DeviceLink, error) {
+	link := deviceLinkFromDomainMapper(linkDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"device_name", "status", "linked_at", "unlinked_at", "last_error", "updated_at"}),
+	}).Create(link).Error
+	if err != nil {
+		r.Logger.Error("Error saving device link", zap.Error(err), zap.String("number", linkDomain.Number))
+		return &domainSignal.DeviceLink{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully saved device link", zap.String("number", linkDomain.Number), zap.String("status", linkDomain.Status))
+	return r.GetByNumber(linkDomain.Number)
+}
+
+// Mappers
+func (l *DeviceLink) toDomainMapper() *domainSignal.DeviceLink {
+	return &domainSignal.DeviceLink{
+		ID:         l.ID,
+		Number:     l.Number,
+		DeviceName: l.DeviceName,
+		Status:     l.Status,
+		LinkedAt:   l.LinkedAt,
+		UnlinkedAt: l.UnlinkedAt,
+		LastError:  l.LastError,
+		CreatedAt:  l.CreatedAt,
+		UpdatedAt:  l.UpdatedAt,
+	}
+}
+
+func deviceLinkFromDomainMapper(l *domainSignal.DeviceLink) *DeviceLink {
+	return &DeviceLink{
+		ID:         l.ID,
+		Number:     l.Number,
+		DeviceName: l.DeviceName,
+		Status:     l.Status,
+		LinkedAt:   l.LinkedAt,
+		UnlinkedAt: l.UnlinkedAt,
+		LastError:  l.LastError,
+		CreatedAt:  l.CreatedAt,
+		UpdatedAt:  l.UpdatedAt,
+	}
+}
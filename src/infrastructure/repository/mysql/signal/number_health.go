@@ -0,0 +1,128 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NumberHealth is the database model for the health of a Signal number
+type NumberHealth struct {
+	ID                  int        `gorm:"primaryKey"`
+	Number              string     `gorm:"column:number;type:varchar(64);uniqueIndex"`
+	Status              string     `gorm:"column:status;type:varchar(20);index"`
+	ActionCode          string     `gorm:"column:action_code;type:varchar(32)"`
+	SendFailures        int        `gorm:"column:send_failures;default:0"`
+	UnregisteredErrors  int        `gorm:"column:unregistered_errors;default:0"`
+	ReceiveErrors       int        `gorm:"column:receive_errors;default:0"`
+	ConsecutiveFailures int        `gorm:"column:consecutive_failures;default:0"`
+	LastError           string     `gorm:"column:last_error;type:text"`
+	LastErrorAt         *time.Time `gorm:"column:last_error_at"`
+	LastSuccessAt       *time.Time `gorm:"column:last_success_at"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime:mili"`
+}
+
+func (NumberHealth) TableName() string {
+	return "number_health"
+}
+
+// NumberHealthRepositoryInterface defines the interface for number health repository operations
+type NumberHealthRepositoryInterface interface {
+	GetByNumber(number string) (*domainSignal.NumberHealth, error)
+	GetAll() (*[]domainSignal.NumberHealth, error)
+	Save(health *domainSignal.NumberHealth) (*domainSignal.NumberHealth, error)
+}
+
+type NumberHealthRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewNumberHealthRepository(db *gorm.DB, loggerInstance *logger.Logger) NumberHealthRepositoryInterface {
+	return &NumberHealthRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *NumberHealthRepository) GetByNumber(number string) (*domainSignal.NumberHealth, error) {
+	var health NumberHealth
+	err := r.DB.Where("number = ?", number).First(&health).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting number health", zap.Error(err), zap.String("number", number))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainSignal.NumberHealth{}, err
+	}
+	return health.toDomainMapper(), nil
+}
+
+func (r *NumberHealthRepository) GetAll() (*[]domainSignal.NumberHealth, error) {
+	var healths []NumberHealth
+	if err := r.DB.Order("number").Find(&healths).Error; err != nil {
+		r.Logger.Error("Error getting number health", zap.Error(err))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.NumberHealth, len(healths))
+	for i := range healths {
+		result[i] = *healths[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+// Save creates or replaces the health of a number
+func (r *NumberHealthRepository) Save(healthDomain *domainSignal.NumberHealth) (*domainSignal.NumberHealth, error) {
+	health := numberHealthFromDomainMapper(healthDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "action_code", "send_failures", "unregistered_errors", "receive_errors",
+			"consecutive_failures", "last_error", "last_error_at", "last_success_at", "updated_at"}),
+	}).Create(health).Error
+	if err != nil {
+		r.Logger.Error("Error saving number health", zap.Error(err), zap.String("number", healthDomain.Number))
+		return &domainSignal.NumberHealth{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return r.GetByNumber(healthDomain.Number)
+}
+
+// Mappers
+func (h *NumberHealth) toDomainMapper() *domainSignal.NumberHealth {
+	return &domainSignal.NumberHealth{
+		Number:              h.Number,
+		Status:              h.Status,
+		ActionCode:          h.ActionCode,
+		SendFailures:        h.SendFailures,
+		UnregisteredErrors:  h.UnregisteredErrors,
+		ReceiveErrors:       h.ReceiveErrors,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		LastError:           h.LastError,
+		LastErrorAt:         h.LastErrorAt,
+		LastSuccessAt:       h.LastSuccessAt,
+		CreatedAt:           h.CreatedAt,
+		UpdatedAt:           h.UpdatedAt,
+	}
+}
+
+func numberHealthFromDomainMapper(h *domainSignal.NumberHealth) *NumberHealth {
+	return &NumberHealth{
+		Number:              h.Number,
+		Status:              h.Status,
+		ActionCode:          h.ActionCode,
+		SendFailures:        h.SendFailures,
+		UnregisteredErrors:  h.UnregisteredErrors,
+		ReceiveErrors:       h.ReceiveErrors,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		LastError:           h.LastError,
+		LastErrorAt:         h.LastErrorAt,
+		LastSuccessAt:       h.LastSuccessAt,
+		CreatedAt:           h.CreatedAt,
+		UpdatedAt:           h.UpdatedAt,
+	}
+}
@@ -0,0 +1,95 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitChallenge is the database model for the rate limit challenge tokens of Signal accounts
+type RateLimitChallenge struct {
+	ID        int        `gorm:"primaryKey"`
+	Account   string     `gorm:"column:account;type:varchar(64);index"`
+	Token     string     `gorm:"column:token;type:varchar(255);uniqueIndex"`
+	LiftedAt  *time.Time `gorm:"column:lifted_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime:mili"`
+}
+
+func (RateLimitChallenge) TableName() string {
+	return "signal_rate_limit_challenges"
+}
+
+// RateLimitChallengeRepositoryInterface defines the interface for rate limit challenge repository operations
+type RateLimitChallengeRepositoryInterface interface {
+	// Save stores the challenge tokens of an account, tokens already stored are left as they are
+	Save(account string, tokens []string) error
+	// GetPending retrieves the challenges of an account that weren't lifted yet, newest first
+	GetPending(account string) (*[]domainSignal.RateLimitChallenge, error)
+	// MarkLifted marks every pending challenge of an account as lifted and returns how many there were
+	MarkLifted(account string, liftedAt time.Time) (int, error)
+}
+
+type RateLimitChallengeRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewRateLimitChallengeRepository(db *gorm.DB, loggerInstance *logger.Logger) RateLimitChallengeRepositoryInterface {
+	return &RateLimitChallengeRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *RateLimitChallengeRepository) Save(account string, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	challenges := make([]RateLimitChallenge, len(tokens))
+	for i, token := range tokens {
+		challenges[i] = RateLimitChallenge{Account: account, Token: token}
+	}
+	err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&challenges).Error
+	if err != nil {
+		r.Logger.Error("Error saving rate limit challenges", zap.Error(err), zap.String("account", account))
+		return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return nil
+}
+
+func (r *RateLimitChallengeRepository) GetPending(account string) (*[]domainSignal.RateLimitChallenge, error) {
+	var challenges []RateLimitChallenge
+	err := r.DB.Where("account = ? AND lifted_at IS NULL", account).Order("id DESC").Find(&challenges).Error
+	if err != nil {
+		r.Logger.Error("Error getting pending rate limit challenges", zap.Error(err), zap.String("account", account))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.RateLimitChallenge, len(challenges))
+	for i, challenge := range challenges {
+		result[i] = *challenge.toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *RateLimitChallengeRepository) MarkLifted(account string, liftedAt time.Time) (int, error) {
+	tx := r.DB.Model(&RateLimitChallenge{}).Where("account = ? AND lifted_at IS NULL", account).Update("lifted_at", liftedAt)
+	if tx.Error != nil {
+		r.Logger.Error("Error marking rate limit challenges lifted", zap.Error(tx.Error), zap.String("account", account))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return int(tx.RowsAffected), nil
+}
+
+// Mappers
+func (c *RateLimitChallenge) toDomainMapper() *domainSignal.RateLimitChallenge {
+	return &domainSignal.RateLimitChallenge{
+		ID:        c.ID,
+		Account:   c.Account,
+		Token:     c.Token,
+		LiftedAt:  c.LiftedAt,
+		CreatedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,111 @@
+package signal
+
+import (
+	"encoding/json"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReceivedEnvelope is the database model of the normalized content of a received envelope that isn't a text
+// message
+type ReceivedEnvelope struct {
+	ID        int       `gorm:"primaryKey"`
+	Account   string    `gorm:"column:account;type:varchar(64);index:idx_received_envelope_account_type,priority:1"`
+	Source    string    `gorm:"column:source;type:varchar(128)"`
+	Timestamp int64     `gorm:"column:timestamp"`
+	Type      string    `gorm:"column:type;type:varchar(32);index:idx_received_envelope_account_type,priority:2"`
+	Content   string    `gorm:"column:content;type:text"` // JSON of the domain EnvelopeContent
+	CreatedAt time.Time `gorm:"autoCreateTime:mili;index"`
+}
+
+func (ReceivedEnvelope) TableName() string {
+	return "signal_received_envelopes"
+}
+
+// ReceivedEnvelopeRepositoryInterface defines the interface for the stored content of received envelopes
+type ReceivedEnvelopeRepositoryInterface interface {
+	Create(envelope *domainSignal.ReceivedEnvelope) (*domainSignal.ReceivedEnvelope, error)
+	// GetRecent returns the latest envelopes received by an account, optionally of one type, newest first. An
+	// empty account returns the envelopes of every account.
+	GetRecent(account string, envelopeType string, limit int) ([]domainSignal.ReceivedEnvelope, error)
+	// DeleteBefore removes the envelopes stored before the given time
+	DeleteBefore(before time.Time) (int64, error)
+}
+
+type ReceivedEnvelopeRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewReceivedEnvelopeRepository(db *gorm.DB, loggerInstance *logger.Logger) ReceivedEnvelopeRepositoryInterface {
+	return &ReceivedEnvelopeRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ReceivedEnvelopeRepository) Create(envelopeDomain *domainSignal.ReceivedEnvelope) (*domainSignal.ReceivedEnvelope, error) {
+	content, err := json.Marshal(envelopeDomain.Content)
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.UnknownError)
+	}
+	envelope := &ReceivedEnvelope{
+		Account:   envelopeDomain.Account,
+		Source:    envelopeDomain.Source,
+		Timestamp: envelopeDomain.Timestamp,
+		Type:      string(envelopeDomain.Type),
+		Content:   string(content),
+	}
+	if err := r.DB.Create(envelope).Error; err != nil {
+		r.Logger.Error("Error storing received envelope", zap.Error(err), zap.String("account", envelope.Account), zap.Int64("timestamp", envelope.Timestamp))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return envelope.toDomainMapper(), nil
+}
+
+func (r *ReceivedEnvelopeRepository) GetRecent(account string, envelopeType string, limit int) ([]domainSignal.ReceivedEnvelope, error) {
+	query := r.DB.Order("id DESC").Limit(limit)
+	if account != "" {
+		query = query.Where("account = ?", account)
+	}
+	if envelopeType != "" {
+		query = query.Where("type = ?", envelopeType)
+	}
+	var envelopes []ReceivedEnvelope
+	if err := query.Find(&envelopes).Error; err != nil {
+		r.Logger.Error("Error getting received envelopes", zap.Error(err), zap.String("account", account), zap.String("type", envelopeType))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.ReceivedEnvelope, len(envelopes))
+	for i := range envelopes {
+		result[i] = *envelopes[i].toDomainMapper()
+	}
+	return result, nil
+}
+
+func (r *ReceivedEnvelopeRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("created_at < ?", before).Delete(&ReceivedEnvelope{})
+	if result.Error != nil {
+		r.Logger.Error("Error deleting received envelopes", zap.Error(result.Error), zap.Time("before", before))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected, nil
+}
+
+// Mappers
+func (e *ReceivedEnvelope) toDomainMapper() *domainSignal.ReceivedEnvelope {
+	envelope := &domainSignal.ReceivedEnvelope{
+		ID:        e.ID,
+		Account:   e.Account,
+		Source:    e.Source,
+		Timestamp: e.Timestamp,
+		Type:      domainSignal.EnvelopeType(e.Type),
+		CreatedAt: e.CreatedAt,
+	}
+	// Content was written by Create, a row that doesn't decode keeps the type only
+	_ = json.Unmarshal([]byte(e.Content), &envelope.Content)
+	return envelope
+}
@@ -0,0 +1,121 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReceivedMessage is the database model of the dedupe key of a routed inbound message
+type ReceivedMessage struct {
+	ID        int       `gorm:"primaryKey"`
+	Account   string    `gorm:"column:account;type:varchar(64);uniqueIndex:idx_received_message_key,priority:1"`
+	Timestamp int64     `gorm:"column:timestamp;uniqueIndex:idx_received_message_key,priority:2"`
+	Source    string    `gorm:"column:source;type:varchar(128);uniqueIndex:idx_received_message_key,priority:3"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+}
+
+func (ReceivedMessage) TableName() string {
+	return "signal_received_messages"
+}
+
+// ReceiveWatermark is the database model of the highest envelope timestamp routed for an account
+type ReceiveWatermark struct {
+	ID        int       `gorm:"primaryKey"`
+	Account   string    `gorm:"column:account;type:varchar(64);uniqueIndex"`
+	Timestamp int64     `gorm:"column:timestamp"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (ReceiveWatermark) TableName() string {
+	return "signal_receive_watermarks"
+}
+
+// ReceivedMessageRepositoryInterface defines the interface for inbound message deduplication
+type ReceivedMessageRepositoryInterface interface {
+	// Claim stores the dedupe key of a message and advances the watermark of the account. It reports false
+	// when the key was stored before, then the message was already routed.
+	Claim(account string, timestamp int64, source string) (bool, error)
+	GetWatermark(account string) (*domainSignal.ReceiveWatermark, error)
+	// DeleteBefore removes the dedupe keys of an account with a timestamp before the given one
+	DeleteBefore(account string, timestamp int64) (int64, error)
+}
+
+type ReceivedMessageRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewReceivedMessageRepository(db *gorm.DB, loggerInstance *logger.Logger) ReceivedMessageRepositoryInterface {
+	return &ReceivedMessageRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *ReceivedMessageRepository) Claim(account string, timestamp int64, source string) (bool, error) {
+	claimed := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ReceivedMessage{
+			Account:   account,
+			Timestamp: timestamp,
+			Source:    source,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "account"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"timestamp":  gorm.Expr("GREATEST(timestamp, VALUES(timestamp))"),
+				"updated_at": gorm.Expr("VALUES(updated_at)"),
+			}),
+		}).Create(&ReceiveWatermark{Account: account, Timestamp: timestamp}).Error
+	})
+	if err != nil {
+		r.Logger.Error("Error claiming received message", zap.Error(err),
+			zap.String("account", account), zap.Int64("timestamp", timestamp), zap.String("source", source))
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return claimed, nil
+}
+
+// GetWatermark returns the watermark of an account, accounts without routed messages have a zero watermark
+func (r *ReceivedMessageRepository) GetWatermark(account string) (*domainSignal.ReceiveWatermark, error) {
+	var watermark ReceiveWatermark
+	err := r.DB.Where("account = ?", account).First(&watermark).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &domainSignal.ReceiveWatermark{Account: account}, nil
+		}
+		r.Logger.Error("Error getting receive watermark", zap.Error(err), zap.String("account", account))
+		return &domainSignal.ReceiveWatermark{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return watermark.toDomainMapper(), nil
+}
+
+func (r *ReceivedMessageRepository) DeleteBefore(account string, timestamp int64) (int64, error) {
+	result := r.DB.Where("account = ? AND timestamp < ?", account, timestamp).Delete(&ReceivedMessage{})
+	if result.Error != nil {
+		r.Logger.Error("Error deleting received message keys", zap.Error(result.Error), zap.String("account", account))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return result.RowsAffected, nil
+}
+
+// Mappers
+func (w *ReceiveWatermark) toDomainMapper() *domainSignal.ReceiveWatermark {
+	return &domainSignal.ReceiveWatermark{
+		Account:   w.Account,
+		Timestamp: w.Timestamp,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
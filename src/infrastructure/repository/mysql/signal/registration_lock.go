@@ -0,0 +1,97 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegistrationLock is the database model for the registration lock state of a Signal number
+type RegistrationLock struct {
+	ID           int       `gorm:"primaryKey"`
+	Number       string    `gorm:"column:number;type:varchar(64);uniqueIndex"`
+	Enabled      bool      `gorm:"column:enabled;default:false"`
+	EncryptedPin string    `gorm:"column:encrypted_pin;type:text"`
+	CreatedAt    time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (RegistrationLock) TableName() string {
+	return "signal_registration_locks"
+}
+
+// RegistrationLockRepositoryInterface defines the interface for registration lock repository operations
+type RegistrationLockRepositoryInterface interface {
+	GetByNumber(number string) (*domainSignal.RegistrationLock, error)
+	Save(lock *domainSignal.RegistrationLock) (*domainSignal.RegistrationLock, error)
+}
+
+type RegistrationLockRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewRegistrationLockRepository(db *gorm.DB, loggerInstance *logger.Logger) RegistrationLockRepositoryInterface {
+	return &RegistrationLockRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *RegistrationLockRepository) GetByNumber(number string) (*domainSignal.RegistrationLock, error) {
+	var lock RegistrationLock
+	err := r.DB.Where("number = ?", number).First(&lock).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.Logger.Warn("Registration lock not found", zap.String("number", number))
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting registration lock", zap.Error(err), zap.String("number", number))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainSignal.RegistrationLock{}, err
+	}
+	return lock.toDomainMapper(), nil
+}
+
+// Save creates or replaces the registration lock state of a number
+func (r *RegistrationLockRepository) Save(lockDomain *domainSignal.RegistrationLock) (*domainSignal.RegistrationLock, error) {
+	lock := registrationLockFromDomainMapper(lockDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "encrypted_pin", "updated_at"}),
+	}).Create(lock).Error
+	if err != nil {
+		r.Logger.Error("Error saving registration lock", zap.Error(err), zap.String("number", lockDomain.Number))
+		return &domainSignal.RegistrationLock{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+
+	r.Logger.Info("Successfully saved registration lock", zap.String("number", lockDomain.Number), zap.Bool("enabled", lockDomain.Enabled))
+	return r.GetByNumber(lockDomain.Number)
+}
+
+// Mappers
+func (l *RegistrationLock) toDomainMapper() *domainSignal.RegistrationLock {
+	return &domainSignal.RegistrationLock{
+		ID:           l.ID,
+		Number:       l.Number,
+		Enabled:      l.Enabled,
+		EncryptedPin: l.EncryptedPin,
+		CreatedAt:    l.CreatedAt,
+		UpdatedAt:    l.UpdatedAt,
+	}
+}
+
+func registrationLockFromDomainMapper(l *domainSignal.RegistrationLock) *RegistrationLock {
+	return &RegistrationLock{
+		ID:           l.ID,
+		Number:       l.Number,
+		Enabled:      l.Enabled,
+		EncryptedPin: l.EncryptedPin,
+		CreatedAt:    l.CreatedAt,
+		UpdatedAt:    l.UpdatedAt,
+	}
+}
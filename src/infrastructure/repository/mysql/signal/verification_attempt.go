@@ -0,0 +1,119 @@
+package signal
+
+import (
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// VerificationAttempt is the database model for the verification codes requested for Signal numbers
+type VerificationAttempt struct {
+	ID        int       `gorm:"primaryKey"`
+	Number    string    `gorm:"column:number;type:varchar(64);index:idx_verification_number_created"`
+	Channel   string    `gorm:"column:channel;type:varchar(16)"`
+	Status    string    `gorm:"column:status;type:varchar(16);index"`
+	Error     string    `gorm:"column:error;type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili;index:idx_verification_number_created"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (VerificationAttempt) TableName() string {
+	return "signal_verification_attempts"
+}
+
+// VerificationAttemptRepositoryInterface defines the interface for verification attempt repository operations
+type VerificationAttemptRepositoryInterface interface {
+	Create(attempt *domainSignal.VerificationAttempt) (*domainSignal.VerificationAttempt, error)
+	// GetSince retrieves the attempts of a number created since a time, newest first
+	GetSince(number string, since time.Time) (*[]domainSignal.VerificationAttempt, error)
+	// GetPending retrieves the sent attempts over a channel created before a time, oldest first
+	GetPending(channel string, before time.Time, limit int) (*[]domainSignal.VerificationAttempt, error)
+	// SetStatus changes the status of the sent attempts of a number and returns how many there were
+	SetStatus(number string, status string) (int, error)
+}
+
+type VerificationAttemptRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewVerificationAttemptRepository(db *gorm.DB, loggerInstance *logger.Logger) VerificationAttemptRepositoryInterface {
+	return &VerificationAttemptRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *VerificationAttemptRepository) Create(attemptDomain *domainSignal.VerificationAttempt) (*domainSignal.VerificationAttempt, error) {
+	attempt := verificationAttemptFromDomainMapper(attemptDomain)
+	if err := r.DB.Create(attempt).Error; err != nil {
+		r.Logger.Error("Error creating verification attempt", zap.Error(err), zap.String("number", attempt.Number))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return attempt.toDomainMapper(), nil
+}
+
+func (r *VerificationAttemptRepository) GetSince(number string, since time.Time) (*[]domainSignal.VerificationAttempt, error) {
+	var attempts []VerificationAttempt
+	err := r.DB.Where("number = ? AND created_at >= ?", number, since).Order("created_at DESC, id DESC").Find(&attempts).Error
+	if err != nil {
+		r.Logger.Error("Error getting verification attempts", zap.Error(err), zap.String("number", number))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.VerificationAttempt, len(attempts))
+	for i := range attempts {
+		result[i] = *attempts[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *VerificationAttemptRepository) GetPending(channel string, before time.Time, limit int) (*[]domainSignal.VerificationAttempt, error) {
+	var attempts []VerificationAttempt
+	err := r.DB.Where("channel = ? AND status = ? AND created_at < ?", channel, domainSignal.VerificationAttemptSent, before).
+		Order("id").Limit(limit).Find(&attempts).Error
+	if err != nil {
+		r.Logger.Error("Error getting pending verification attempts", zap.Error(err), zap.String("channel", channel))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainSignal.VerificationAttempt, len(attempts))
+	for i := range attempts {
+		result[i] = *attempts[i].toDomainMapper()
+	}
+	return &result, nil
+}
+
+func (r *VerificationAttemptRepository) SetStatus(number string, status string) (int, error) {
+	tx := r.DB.Model(&VerificationAttempt{}).Where("number = ? AND status = ?", number, domainSignal.VerificationAttemptSent).
+		Update("status", status)
+	if tx.Error != nil {
+		r.Logger.Error("Error updating verification attempts", zap.Error(tx.Error), zap.String("number", number))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return int(tx.RowsAffected), nil
+}
+
+// Mappers
+func (a *VerificationAttempt) toDomainMapper() *domainSignal.VerificationAttempt {
+	return &domainSignal.VerificationAttempt{
+		ID:        a.ID,
+		Number:    a.Number,
+		Channel:   a.Channel,
+		Status:    a.Status,
+		Error:     a.Error,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+func verificationAttemptFromDomainMapper(a *domainSignal.VerificationAttempt) *VerificationAttempt {
+	return &VerificationAttempt{
+		ID:        a.ID,
+		Number:    a.Number,
+		Channel:   a.Channel,
+		Status:    a.Status,
+		Error:     a.Error,
+		CreatedAt: a.CreatedAt,
+	}
+}
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginEvent struct {
+	ID            int       `gorm:"primaryKey"`
+	UserID        int       `gorm:"column:user_id;index:idx_login_event_user_created"`
+	Method        string    `gorm:"column:method;type:varchar(16)"`
+	IPAddress     string    `gorm:"column:ip_address;type:varchar(64)"`
+	UserAgent     string    `gorm:"column:user_agent;type:varchar(255)"`
+	Success       bool      `gorm:"column:success"`
+	FailureReason string    `gorm:"column:failure_reason"`
+	NewLocation   bool      `gorm:"column:new_location"`
+	CreatedAt     time.Time `gorm:"autoCreateTime:mili;index:idx_login_event_user_created"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+type LoginNotificationSettings struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"column:user_id;uniqueIndex"`
+	Channel   string    `gorm:"column:channel;type:varchar(32)"`
+	Recipient string    `gorm:"column:recipient"`
+	Enabled   bool      `gorm:"column:enabled;default:false"`
+	CreatedAt time.Time `gorm:"autoCreateTime:mili"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:mili"`
+}
+
+func (LoginNotificationSettings) TableName() string {
+	return "login_notification_settings"
+}
+
+// LoginActivityRepositoryInterface defines the interface for login audit operations
+type LoginActivityRepositoryInterface interface {
+	CreateEvent(event *domainUser.LoginEvent) (*domainUser.LoginEvent, error)
+	GetUserEvents(userID int, page domain.PageRequest) (*domain.Page[domainUser.LoginEvent], error)
+	// GetKnownLocation reports whether earlier successful logins of a user came from the IP address and the
+	// device, and whether the user logged in successfully before at all
+	GetKnownLocation(userID int, ipAddress string, userAgent string) (knownIP bool, knownDevice bool, hasLogins bool, err error)
+	CountFailedLoginsSince(userID int, since time.Time) (int64, error)
+	// GetEventsAfter returns up to limit login events of every user with an ID above afterID, oldest first
+	GetEventsAfter(afterID int, limit int) ([]domainUser.LoginEvent, error)
+	GetSettings(userID int) (*domainUser.LoginNotificationSettings, error)
+	SaveSettings(settings *domainUser.LoginNotificationSettings) (*domainUser.LoginNotificationSettings, error)
+}
+
+type LoginActivityRepository struct {
+	DB     *gorm.DB
+	Logger *logger.Logger
+}
+
+func NewLoginActivityRepository(db *gorm.DB, loggerInstance *logger.Logger) LoginActivityRepositoryInterface {
+	return &LoginActivityRepository{DB: db, Logger: loggerInstance}
+}
+
+func (r *LoginActivityRepository) CreateEvent(eventDomain *domainUser.LoginEvent) (*domainUser.LoginEvent, error) {
+	event := loginEventFromDomainMapper(eventDomain)
+	if err := r.DB.Create(event).Error; err != nil {
+		r.Logger.Error("Error creating login event", zap.Error(err), zap.Int("userID", eventDomain.UserID))
+		return &domainUser.LoginEvent{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return event.toDomainMapper(), nil
+}
+
+// GetUserEvents retrieves a page of the login events of a user, newest first
+func (r *LoginActivityRepository) GetUserEvents(userID int, page domain.PageRequest) (*domain.Page[domainUser.LoginEvent], error) {
+	var events []LoginEvent
+	if err := pagination.Apply(r.DB.Where("user_id = ?", userID), "created_at", page).Find(&events).Error; err != nil {
+		r.Logger.Error("Error getting login events", zap.Error(err), zap.Int("userID", userID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	events, next := pagination.Cut(events, page.Limit, func(event *LoginEvent) domain.Cursor {
+		return domain.Cursor{Time: event.CreatedAt, ID: event.ID}
+	})
+	result := make([]domainUser.LoginEvent, len(events))
+	for i, event := range events {
+		result[i] = *event.toDomainMapper()
+	}
+	return &domain.Page[domainUser.LoginEvent]{Items: result, Next: next}, nil
+}
+
+func (r *LoginActivityRepository) GetKnownLocation(userID int, ipAddress string, userAgent string) (bool, bool, bool, error) {
+	var counts struct {
+		Logins      int64
+		KnownIP     int64
+		KnownDevice int64
+	}
+	err := r.DB.Model(&LoginEvent{}).
+		Select("COUNT(*) AS logins, "+
+			"COALESCE(SUM(CASE WHEN ip_address = ? THEN 1 ELSE 0 END), 0) AS known_ip, "+
+			"COALESCE(SUM(CASE WHEN user_agent = ? THEN 1 ELSE 0 END), 0) AS known_device", ipAddress, userAgent).
+		Where("user_id = ? AND success = ?", userID, true).
+		Scan(&counts).Error
+	if err != nil {
+		r.Logger.Error("Error checking known login location", zap.Error(err), zap.Int("userID", userID))
+		return false, false, false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return counts.KnownIP > 0, counts.KnownDevice > 0, counts.Logins > 0, nil
+}
+
+func (r *LoginActivityRepository) CountFailedLoginsSince(userID int, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Model(&LoginEvent{}).
+		Where("user_id = ? AND success = ? AND created_at >= ?", userID, false, since).
+		Count(&count).Error
+	if err != nil {
+		r.Logger.Error("Error counting failed logins", zap.Error(err), zap.Int("userID", userID))
+		return 0, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return count, nil
+}
+
+func (r *LoginActivityRepository) GetEventsAfter(afterID int, limit int) ([]domainUser.LoginEvent, error) {
+	var events []LoginEvent
+	if err := r.DB.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&events).Error; err != nil {
+		r.Logger.Error("Error getting login events", zap.Error(err), zap.Int("afterID", afterID))
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	result := make([]domainUser.LoginEvent, len(events))
+	for i, event := range events {
+		result[i] = *event.toDomainMapper()
+	}
+	return result, nil
+}
+
+func (r *LoginActivityRepository) GetSettings(userID int) (*domainUser.LoginNotificationSettings, error) {
+	var settings LoginNotificationSettings
+	err := r.DB.Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+		} else {
+			r.Logger.Error("Error getting login notification settings", zap.Error(err), zap.Int("userID", userID))
+			err = domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+		return &domainUser.LoginNotificationSettings{}, err
+	}
+	return settings.toDomainMapper(), nil
+}
+
+// SaveSettings creates or replaces the login notification settings of a user
+func (r *LoginActivityRepository) SaveSettings(settingsDomain *domainUser.LoginNotificationSettings) (*domainUser.LoginNotificationSettings, error) {
+	settings := loginNotificationSettingsFromDomainMapper(settingsDomain)
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channel", "recipient", "enabled", "updated_at"}),
+	}).Create(settings).Error
+	if err != nil {
+		r.Logger.Error("Error saving login notification settings", zap.Error(err), zap.Int("userID", settingsDomain.UserID))
+		return &domainUser.LoginNotificationSettings{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	r.Logger.Info("Successfully saved login notification settings", zap.Int("userID", settingsDomain.UserID), zap.Bool("enabled", settingsDomain.Enabled))
+	return r.GetSettings(settingsDomain.UserID)
+}
+
+// Mappers
+func (e *LoginEvent) toDomainMapper() *domainUser.LoginEvent {
+	return &domainUser.LoginEvent{
+		ID:            e.ID,
+		UserID:        e.UserID,
+		Method:        e.Method,
+		IPAddress:     e.IPAddress,
+		UserAgent:     e.UserAgent,
+		Success:       e.Success,
+		FailureReason: e.FailureReason,
+		NewLocation:   e.NewLocation,
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
+func loginEventFromDomainMapper(e *domainUser.LoginEvent) *LoginEvent {
+	return &LoginEvent{
+		ID:            e.ID,
+		UserID:        e.UserID,
+		Method:        e.Method,
+		IPAddress:     e.IPAddress,
+		UserAgent:     e.UserAgent,
+		Success:       e.Success,
+		FailureReason: e.FailureReason,
+		NewLocation:   e.NewLocation,
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
+func (s *LoginNotificationSettings) toDomainMapper() *domainUser.LoginNotificationSettings {
+	return &domainUser.LoginNotificationSettings{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Channel:   s.Channel,
+		Recipient: s.Recipient,
+		Enabled:   s.Enabled,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func loginNotificationSettingsFromDomainMapper(s *domainUser.LoginNotificationSettings) *LoginNotificationSettings {
+	return &LoginNotificationSettings{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Channel:   s.Channel,
+		Recipient: s.Recipient,
+		Enabled:   s.Enabled,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -14,17 +14,22 @@ import (
 )
 
 type User struct {
-	ID               int       `gorm:"primaryKey"`
-	UserName         string    `gorm:"column:user_name;unique"`
-	Email            string    `gorm:"unique"`
-	FirstName        string    `gorm:"column:first_name"`
-	LastName         string    `gorm:"column:last_name"`
-	Status           bool      `gorm:"column:status"`
-	HashPassword     string    `gorm:"column:hash_password"`
-	MessageRateLimit int       `gorm:"column:message_rate_limit;default:1000"` // Default to 1000 messages per day
-	Role             string    `gorm:"column:role;default:'member'"`           // Default role is member
-	CreatedAt        time.Time `gorm:"autoCreateTime:mili"`
-	UpdatedAt        time.Time `gorm:"autoUpdateTime:mili"`
+	ID                 int        `gorm:"primaryKey"`
+	UserName           string     `gorm:"column:user_name;unique"`
+	Email              string     `gorm:"unique"`
+	FirstName          string     `gorm:"column:first_name"`
+	LastName           string     `gorm:"column:last_name"`
+	Status             bool       `gorm:"column:status"`
+	HashPassword       string     `gorm:"column:hash_password"`
+	MessageRateLimit   int        `gorm:"column:message_rate_limit;default:1000"` // Default to 1000 messages per day
+	Role               string     `gorm:"column:role;default:'member'"`           // Default role is member
+	Locale             string     `gorm:"column:locale;size:16"`
+	RecipientHourlyCap int        `gorm:"column:recipient_hourly_cap;default:0"`
+	RecipientDailyCap  int        `gorm:"column:recipient_daily_cap;default:0"`
+	EngagementTracking bool       `gorm:"column:engagement_tracking;default:false"`
+	DeactivatedAt      *time.Time `gorm:"column:deactivated_at"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime:mili"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime:mili"`
 }
 
 func (User) TableName() string {
@@ -32,17 +37,22 @@ func (User) TableName() string {
 }
 
 var ColumnsUserMapping = map[string]string{
-	"id":               "id",
-	"userName":         "user_name",
-	"email":            "email",
-	"firstName":        "first_name",
-	"lastName":         "last_name",
-	"status":           "status",
-	"hashPassword":     "hash_password",
-	"messageRateLimit": "message_rate_limit",
-	"role":             "role",
-	"createdAt":        "created_at",
-	"updatedAt":        "updated_at",
+	"id":                 "id",
+	"userName":           "user_name",
+	"email":              "email",
+	"firstName":          "first_name",
+	"lastName":           "last_name",
+	"status":             "status",
+	"hashPassword":       "hash_password",
+	"messageRateLimit":   "message_rate_limit",
+	"role":               "role",
+	"locale":             "locale",
+	"recipientHourlyCap": "recipient_hourly_cap",
+	"recipientDailyCap":  "recipient_daily_cap",
+	"engagementTracking": "engagement_tracking",
+	"deactivatedAt":      "deactivated_at",
+	"createdAt":          "created_at",
+	"updatedAt":          "updated_at",
 }
 
 // UserRepositoryInterface defines the interface for user repository operations
@@ -150,7 +160,7 @@ func (r *Repository) Update(id int, userMap map[string]interface{}) (*domainUser
 	}
 
 	err := r.DB.Model(&userObj).
-		Select("user_name", "email", "first_name", "last_name", "status", "role").
+		Select("user_name", "email", "first_name", "last_name", "status", "role", "locale", "engagement_tracking", "deactivated_at").
 		Updates(updateData).Error
 	if err != nil {
 		r.Logger.Error("Error updating user", zap.Error(err), zap.Int("id", id))
@@ -304,33 +314,43 @@ func (r *Repository) SearchByProperty(property string, searchText string) (*[]st
 // Mappers
 func (u *User) toDomainMapper() *domainUser.User {
 	return &domainUser.User{
-		ID:               u.ID,
-		UserName:         u.UserName,
-		Email:            u.Email,
-		FirstName:        u.FirstName,
-		LastName:         u.LastName,
-		Status:           u.Status,
-		HashPassword:     u.HashPassword,
-		MessageRateLimit: u.MessageRateLimit,
-		Role:             u.Role,
-		CreatedAt:        u.CreatedAt,
-		UpdatedAt:        u.UpdatedAt,
+		ID:                 u.ID,
+		UserName:           u.UserName,
+		Email:              u.Email,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		Status:             u.Status,
+		HashPassword:       u.HashPassword,
+		MessageRateLimit:   u.MessageRateLimit,
+		Role:               u.Role,
+		Locale:             u.Locale,
+		RecipientHourlyCap: u.RecipientHourlyCap,
+		RecipientDailyCap:  u.RecipientDailyCap,
+		EngagementTracking: u.EngagementTracking,
+		DeactivatedAt:      u.DeactivatedAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
 	}
 }
 
 func fromDomainMapper(u *domainUser.User) *User {
 	return &User{
-		ID:               u.ID,
-		UserName:         u.UserName,
-		Email:            u.Email,
-		FirstName:        u.FirstName,
-		LastName:         u.LastName,
-		Status:           u.Status,
-		HashPassword:     u.HashPassword,
-		MessageRateLimit: u.MessageRateLimit,
-		Role:             u.Role,
-		CreatedAt:        u.CreatedAt,
-		UpdatedAt:        u.UpdatedAt,
+		ID:                 u.ID,
+		UserName:           u.UserName,
+		Email:              u.Email,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		Status:             u.Status,
+		HashPassword:       u.HashPassword,
+		MessageRateLimit:   u.MessageRateLimit,
+		Role:               u.Role,
+		Locale:             u.Locale,
+		RecipientHourlyCap: u.RecipientHourlyCap,
+		RecipientDailyCap:  u.RecipientDailyCap,
+		EngagementTracking: u.EngagementTracking,
+		DeactivatedAt:      u.DeactivatedAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
 	}
 }
 
@@ -3,10 +3,13 @@ package signal_client
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	utils2 "go-multi-chat-api/src/infrastructure/utils"
+	"io"
 	"os/exec"
 	"strings"
 	"time"
@@ -52,22 +55,24 @@ func stripInfoAndWarnMessages(input string) (string, string, string) {
 	return output, infoMessages, warnMessages
 }
 
-func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, error) {
-	containerId, err := getContainerId()
-	s.Logger.Debug("If you want to run this command manually, run the following steps on your host system:")
-	if err == nil {
-		s.Logger.Debug(fmt.Sprintf("*) docker exec -it %s /bin/bash", containerId))
-	} else {
-		s.Logger.Debug("*) docker exec -it <container id> /bin/bash")
-	}
+// OutputLimitExceededError is returned when a signal-cli command produces more output than allowed
+type OutputLimitExceededError struct {
+	Limit int64
+}
 
+func (e *OutputLimitExceededError) Error() string {
+	return fmt.Sprintf("signal-cli output exceeded the limit of %d bytes", e.Limit)
+}
+
+// buildCommand resolves the signal-cli binary and prepends the trust mode configured for the account (if any)
+func (s *CliClient) buildCommand(args []string) (string, []string, error) {
 	signalCliBinary := ""
 	if s.signalCliMode == Normal {
 		signalCliBinary = "signal-cli"
 	} else if s.signalCliMode == Native {
 		signalCliBinary = "signal-cli-native"
 	} else {
-		return "", errors.New("Invalid signal-cli mode")
+		return "", nil, errors.New("Invalid signal-cli mode")
 	}
 
 	//check if args contain number
@@ -91,6 +96,18 @@ func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, err
 		args = append([]string{"--trust-new-identities", trustModeStr}, args...)
 	}
 
+	return signalCliBinary, args, nil
+}
+
+func (s *CliClient) logManualCommand(signalCliBinary string, args []string, stdin string) {
+	containerId, err := getContainerId()
+	s.Logger.Debug("If you want to run this command manually, run the following steps on your host system:")
+	if err == nil {
+		s.Logger.Debug(fmt.Sprintf("*) docker exec -it %s /bin/bash", containerId))
+	} else {
+		s.Logger.Debug("*) docker exec -it <container id> /bin/bash")
+	}
+
 	fullCmd := ""
 	if stdin != "" {
 		fullCmd += "echo '" + stdin + "' | "
@@ -99,51 +116,64 @@ func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, err
 
 	s.Logger.Debug("*) su signal-api")
 	s.Logger.Debug(fmt.Sprintf("*) %s", fullCmd))
+}
 
+func (s *CliClient) getCommandTimeout() time.Duration {
 	cmdTimeout, err := utils2.GetIntEnv("SIGNAL_CLI_CMD_TIMEOUT", 120)
 	if err != nil {
 		s.Logger.Error("Env variable 'SIGNAL_CLI_CMD_TIMEOUT' contains an invalid timeout...falling back to default timeout (120 seconds)")
 		cmdTimeout = 120
 	}
+	return time.Duration(cmdTimeout) * time.Second
+}
+
+func (s *CliClient) getMaxOutputBytes() int64 {
+	maxOutputBytes, err := utils2.GetIntEnv("SIGNAL_CLI_MAX_OUTPUT_BYTES", 50*1024*1024)
+	if err != nil || maxOutputBytes <= 0 {
+		s.Logger.Error("Env variable 'SIGNAL_CLI_MAX_OUTPUT_BYTES' contains an invalid size...falling back to default size (50 MiB)")
+		maxOutputBytes = 50 * 1024 * 1024
+	}
+	return int64(maxOutputBytes)
+}
 
-	cmd := exec.Command(signalCliBinary, args...)
-	if stdin != "" {
-		cmd.Stdin = strings.NewReader(stdin)
+func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, error) {
+	signalCliBinary, args, err := s.buildCommand(args)
+	if err != nil {
+		return "", err
 	}
+
+	s.logManualCommand(signalCliBinary, args, stdin)
+
 	if wait {
-		var stdoutBuffer bytes.Buffer
-		var stderrBuffer bytes.Buffer
-		cmd.Stdout = &stdoutBuffer
-		cmd.Stderr = &stderrBuffer
+		ctx, cancel := context.WithTimeout(context.Background(), s.getCommandTimeout())
+		defer cancel()
 
-		err := cmd.Start()
-		if err != nil {
-			return "", err
+		cmd := exec.CommandContext(ctx, signalCliBinary, args...)
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
 		}
 
-		done := make(chan error, 1)
-		go func() {
-			done <- cmd.Wait()
-		}()
-		select {
-		case <-time.After(time.Duration(cmdTimeout) * time.Second):
-			err := cmd.Process.Kill()
-			if err != nil {
-				return "", err
-			}
+		// Both outputs are kept in memory, each is limited to SIGNAL_CLI_MAX_OUTPUT_BYTES
+		maxOutputBytes := s.getMaxOutputBytes()
+		stdoutBuffer := limitedBuffer{limit: int(maxOutputBytes)}
+		stderrBuffer := limitedBuffer{limit: int(maxOutputBytes)}
+		cmd.Stdout = &stdoutBuffer
+		cmd.Stderr = &stderrBuffer
+
+		err := cmd.Run()
+		if ctx.Err() == context.DeadlineExceeded {
 			return "", errors.New("process killed as timeout reached")
-		case err := <-done:
-			if err != nil {
-				combinedOutput := stdoutBuffer.String() + stderrBuffer.String()
-				s.Logger.Debug(fmt.Sprintf("signal-cli output (stdout): %s", stdoutBuffer.String()))
-				s.Logger.Debug(fmt.Sprintf("signal-cli output (stderr): %s", stderrBuffer.String()))
-				return "", errors.New(combinedOutput)
-			}
 		}
-
-		combinedOutput := stdoutBuffer.String() + stderrBuffer.String()
+		if stdoutBuffer.exceeded || stderrBuffer.exceeded {
+			return "", &OutputLimitExceededError{Limit: maxOutputBytes}
+		}
 		s.Logger.Debug(fmt.Sprintf("signal-cli output (stdout): %s", stdoutBuffer.String()))
 		s.Logger.Debug(fmt.Sprintf("signal-cli output (stderr): %s", stderrBuffer.String()))
+		combinedOutput := stdoutBuffer.String() + stderrBuffer.String()
+		if err != nil {
+			return "", errors.New(combinedOutput)
+		}
+
 		strippedOutput, infoMessages, warnMessages := stripInfoAndWarnMessages(combinedOutput)
 		for _, line := range strings.Split(infoMessages, "\n") {
 			if line != "" {
@@ -159,6 +189,10 @@ func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, err
 
 		return strippedOutput, nil
 	} else {
+		cmd := exec.Command(signalCliBinary, args...)
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
+		}
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
 			return "", err
@@ -169,3 +203,119 @@ func (s *CliClient) Execute(wait bool, args []string, stdin string) (string, err
 		return string(line), nil
 	}
 }
+
+// ExecuteStream runs a signal-cli command and hands every line-delimited JSON object on stdout to the handler
+// as soon as it is read, instead of buffering the whole output in memory. The command is cancelled when ctx is
+// done, when SIGNAL_CLI_CMD_TIMEOUT is reached, when the handler returns an error or when the output grows
+// beyond SIGNAL_CLI_MAX_OUTPUT_BYTES.
+func (s *CliClient) ExecuteStream(ctx context.Context, args []string, stdin string, handler func(line json.RawMessage) error) error {
+	signalCliBinary, args, err := s.buildCommand(args)
+	if err != nil {
+		return err
+	}
+
+	s.logManualCommand(signalCliBinary, args, stdin)
+
+	ctx, cancel := context.WithTimeout(ctx, s.getCommandTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, signalCliBinary, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	var stderrBuffer limitedBuffer
+	stderrBuffer.limit = 64 * 1024
+	cmd.Stderr = &stderrBuffer
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanErr := s.scanJsonLines(stdout, s.getMaxOutputBytes(), handler)
+	if scanErr != nil {
+		// stop signal-cli right away, there is no point in reading the remaining output
+		cancel()
+	}
+
+	waitErr := cmd.Wait()
+	s.Logger.Debug(fmt.Sprintf("signal-cli output (stderr): %s", stderrBuffer.String()))
+
+	if scanErr != nil {
+		return scanErr
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.New("process killed as timeout reached")
+	}
+	if waitErr != nil {
+		if stderrBuffer.Len() > 0 {
+			return errors.New(stderrBuffer.String())
+		}
+		return waitErr
+	}
+	return nil
+}
+
+// scanJsonLines reads line-delimited JSON from r and passes each object to the handler. INFO/WARN lines
+// emitted by signal-cli are logged and skipped.
+func (s *CliClient) scanJsonLines(r io.Reader, maxOutputBytes int64, handler func(line json.RawMessage) error) error {
+	// A line isn't counted before its end was read, the limit of the reader keeps a line without a newline from
+	// growing past it
+	reader := bufio.NewReader(io.LimitReader(r, maxOutputBytes+1))
+	var total int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		total += int64(len(line))
+		if total > maxOutputBytes {
+			return &OutputLimitExceededError{Limit: maxOutputBytes}
+		}
+
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 {
+			if bytes.HasPrefix(trimmed, []byte("INFO")) {
+				s.Logger.Info(string(trimmed))
+			} else if bytes.HasPrefix(trimmed, []byte("WARN")) {
+				s.Logger.Warn(string(trimmed))
+			} else if !json.Valid(trimmed) {
+				return fmt.Errorf("couldn't parse signal-cli output line: %s", string(trimmed))
+			} else if handlerErr := handler(json.RawMessage(trimmed)); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// limitedBuffer is an io.Writer that keeps at most limit bytes and silently discards the rest, exceeded tells
+// whether anything was discarded. Writes never fail so the command isn't blocked on a full pipe.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.Len()
+	if len(p) > remaining {
+		b.exceeded = true
+	}
+	if remaining > 0 {
+		if len(p) > remaining {
+			b.Buffer.Write(p[:remaining])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
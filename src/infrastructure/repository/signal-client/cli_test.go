@@ -0,0 +1,103 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCliClient(t *testing.T) *CliClient {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return NewCliClient(Normal, nil, loggerInstance)
+}
+
+func TestScanJsonLines_SkipsInfoAndWarnMessages(t *testing.T) {
+	client := setupCliClient(t)
+	input := "INFO some info\n{\"a\":1}\nWARN some warning\n\n{\"b\":2}"
+
+	var lines []string
+	err := client.scanJsonLines(strings.NewReader(input), 1024, func(line json.RawMessage) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"a":1}`, `{"b":2}`}, lines)
+}
+
+func TestScanJsonLines_OutputLimitExceeded(t *testing.T) {
+	client := setupCliClient(t)
+	input := strings.Repeat("{\"a\":1}\n", 10)
+
+	count := 0
+	err := client.scanJsonLines(strings.NewReader(input), 20, func(line json.RawMessage) error {
+		count++
+		return nil
+	})
+
+	var limitErr *OutputLimitExceededError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, int64(20), limitErr.Limit)
+	assert.Equal(t, 2, count)
+}
+
+func TestScanJsonLines_OutputLimitExceededWithinALine(t *testing.T) {
+	client := setupCliClient(t)
+	input := "{\"a\":\"" + strings.Repeat("x", 1024)
+
+	err := client.scanJsonLines(strings.NewReader(input), 20, func(line json.RawMessage) error {
+		t.Fatal("the line exceeding the limit must not be handled")
+		return nil
+	})
+
+	var limitErr *OutputLimitExceededError
+	assert.True(t, errors.As(err, &limitErr))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buffer := limitedBuffer{limit: 4}
+
+	n, err := buffer.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, buffer.exceeded)
+
+	n, err = buffer.Write([]byte("def"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.True(t, buffer.exceeded)
+	assert.Equal(t, "abcd", buffer.String())
+}
+
+func TestScanJsonLines_InvalidJson(t *testing.T) {
+	client := setupCliClient(t)
+
+	err := client.scanJsonLines(strings.NewReader("not json\n"), 1024, func(line json.RawMessage) error {
+		return nil
+	})
+
+	assert.Error(t, err)
+}
+
+func TestScanJsonLines_HandlerErrorStopsScan(t *testing.T) {
+	client := setupCliClient(t)
+	input := "{\"a\":1}\n{\"b\":2}\n"
+	handlerErr := errors.New("stop")
+
+	count := 0
+	err := client.scanJsonLines(strings.NewReader(input), 1024, func(line json.RawMessage) error {
+		count++
+		return handlerErr
+	})
+
+	assert.Equal(t, handlerErr, err)
+	assert.Equal(t, 1, count)
+}
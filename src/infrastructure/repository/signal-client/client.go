@@ -1,6 +1,7 @@
 package signal_client
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -20,12 +21,19 @@ import (
 	uuid "github.com/gofrs/uuid"
 	qrcode "github.com/skip2/go-qrcode"
 
+	domainSignal "go-multi-chat-api/src/domain/signal"
 	ds "go-multi-chat-api/src/infrastructure/datastructs"
 	utils "go-multi-chat-api/src/infrastructure/utils"
 )
 
 const groupPrefix = "group."
 
+// usernamePrefix marks a recipient as Signal username, e.g. u:alice.42
+const usernamePrefix = "u:"
+
+// pniPrefix marks a recipient as phone number identity, the UUID Signal knows a number by when it is hidden
+const pniPrefix = "PNI:"
+
 const signalCliV2GroupError = "Cannot create a V2 group as self does not have a versioned profile"
 
 const endpointNotSupportedInJsonRpcMode = "This endpoint is not supported in JSON-RPC mode."
@@ -118,6 +126,9 @@ type GroupEntry struct {
 	Admins          []string `json:"admins"`
 }
 
+// JoinGroupResponse is the result of joining a group from an invite link
+type JoinGroupResponse = domainSignal.JoinGroupResponse
+
 type IdentityEntry struct {
 	Number       string `json:"number"`
 	Status       string `json:"status"`
@@ -161,9 +172,7 @@ type SignalCliIdentityEntry struct {
 	AddedTimestamp        int64  `json:"addedTimestamp"`
 }
 
-type SendResponse struct {
-	Timestamp int64 `json:"timestamp"`
-}
+type SendResponse = domainSignal.SendResponse
 
 type About struct {
 	SupportedApiVersions []string            `json:"versions"`
@@ -173,6 +182,8 @@ type About struct {
 	Capabilities         map[string][]string `json:"capabilities"`
 }
 
+type ResolvedRecipient = domainSignal.ResolvedRecipient
+
 type SearchResultEntry struct {
 	Number     string `json:"number"`
 	Registered bool   `json:"registered"`
@@ -325,6 +336,20 @@ func getSignalCliModeString(signalCliMode SignalCliMode) string {
 }
 
 func getRecipientType(s string) (ds.RecpType, error) {
+	// usernames and phone number identities may be given with the prefixes signal-cli uses for them
+	if strings.HasPrefix(s, usernamePrefix) {
+		if len(s) == len(usernamePrefix) {
+			return ds.Username, errors.New("Invalid username " + s)
+		}
+		return ds.Username, nil
+	}
+	if strings.HasPrefix(s, pniPrefix) {
+		if _, err := uuid.FromString(strings.TrimPrefix(s, pniPrefix)); err != nil {
+			return ds.Number, errors.New("Invalid phone number identity " + s)
+		}
+		return ds.Number, nil
+	}
+
 	// check if the provided recipient is of type 'group'
 	if strings.HasPrefix(s, groupPrefix) { // if the recipient starts with 'group.' it is either a group or a username that starts with 'group.'
 		// in order to find out whether it is a Signal group or a username that starts with 'group.',
@@ -839,22 +864,31 @@ func (s *SignalClient) getJsonRpc2Clients() []*JsonRpc2Client {
 	return jsonRpc2Clients
 }
 
+// SendV2 sends a message with positional options.
+//
+// Deprecated: use Send with a SendRequest.
 func (s *SignalClient) SendV2(number string, message string, recps []string, base64Attachments []string, sticker string, mentions []ds.MessageMention,
 	quoteTimestamp *int64, quoteAuthor *string, quoteMessage *string, quoteMentions []ds.MessageMention, textMode *string, editTimestamp *int64, notifySelf *bool,
 	linkPreview *ds.LinkPreviewType, viewOnce *bool) (*[]SendResponse, error) {
-	if len(recps) == 0 {
-		return nil, errors.New("Please provide at least one recipient")
-	}
+	return s.Send(SendRequest{Number: number, Message: message, Recipients: recps, Base64Attachments: base64Attachments,
+		Sticker: sticker, Mentions: mentions, QuoteTimestamp: quoteTimestamp, QuoteAuthor: quoteAuthor, QuoteMessage: quoteMessage,
+		QuoteMentions: quoteMentions, TextMode: textMode, EditTimestamp: editTimestamp, NotifySelf: notifySelf, LinkPreview: linkPreview,
+		ViewOnce: viewOnce})
+}
 
-	if number == "" {
-		return nil, errors.New("Please provide a valid number")
+// Send sends a message to its recipients, once per group and once for all numbers or usernames. The
+// configured defaults are applied to the options the request doesn't set.
+func (s *SignalClient) Send(request SendRequest) (*[]SendResponse, error) {
+	request = withSendDefaults(request)
+	if err := request.Validate(); err != nil {
+		return nil, err
 	}
 
 	groups := []string{}
 	numbers := []string{}
 	usernames := []string{}
 
-	for _, recipient := range recps {
+	for _, recipient := range request.Recipients {
 		recipientType, err := getRecipientType(recipient)
 		if err != nil {
 			return nil, err
@@ -865,7 +899,7 @@ func (s *SignalClient) SendV2(number string, message string, recps []string, bas
 		} else if recipientType == ds.Number {
 			numbers = append(numbers, recipient)
 		} else if recipientType == ds.Username {
-			usernames = append(usernames, recipient)
+			usernames = append(usernames, strings.TrimPrefix(recipient, usernamePrefix))
 		} else {
 			return nil, errors.New("Invalid recipient type")
 		}
@@ -889,11 +923,7 @@ func (s *SignalClient) SendV2(number string, message string, recps []string, bas
 
 	timestamps := []SendResponse{}
 	for _, group := range groups {
-		signalCliSendRequest := ds.SignalCliSendRequest{Number: number, Message: message, Recipients: []string{group}, Base64Attachments: base64Attachments,
-			RecipientType: ds.Group, Sticker: sticker, Mentions: mentions, QuoteTimestamp: quoteTimestamp,
-			QuoteAuthor: quoteAuthor, QuoteMessage: quoteMessage, QuoteMentions: quoteMentions,
-			TextMode: textMode, EditTimestamp: editTimestamp, NotifySelf: notifySelf, LinkPreview: linkPreview, ViewOnce: viewOnce}
-		timestamp, err := s.send(signalCliSendRequest)
+		timestamp, err := s.send(cliSendRequest(&request, []string{group}, ds.Group))
 		if err != nil {
 			return nil, err
 		}
@@ -901,11 +931,7 @@ func (s *SignalClient) SendV2(number string, message string, recps []string, bas
 	}
 
 	if len(numbers) > 0 {
-		signalCliSendRequest := ds.SignalCliSendRequest{Number: number, Message: message, Recipients: numbers, Base64Attachments: base64Attachments,
-			RecipientType: ds.Number, Sticker: sticker, Mentions: mentions, QuoteTimestamp: quoteTimestamp,
-			QuoteAuthor: quoteAuthor, QuoteMessage: quoteMessage, QuoteMentions: quoteMentions,
-			TextMode: textMode, EditTimestamp: editTimestamp, NotifySelf: notifySelf, LinkPreview: linkPreview, ViewOnce: viewOnce}
-		timestamp, err := s.send(signalCliSendRequest)
+		timestamp, err := s.send(cliSendRequest(&request, numbers, ds.Number))
 		if err != nil {
 			return nil, err
 		}
@@ -913,11 +939,7 @@ func (s *SignalClient) SendV2(number string, message string, recps []string, bas
 	}
 
 	if len(usernames) > 0 {
-		signalCliSendRequest := ds.SignalCliSendRequest{Number: number, Message: message, Recipients: usernames, Base64Attachments: base64Attachments,
-			RecipientType: ds.Username, Sticker: sticker, Mentions: mentions, QuoteTimestamp: quoteTimestamp,
-			QuoteAuthor: quoteAuthor, QuoteMessage: quoteMessage, QuoteMentions: quoteMentions,
-			TextMode: textMode, EditTimestamp: editTimestamp, NotifySelf: notifySelf, LinkPreview: linkPreview, ViewOnce: viewOnce}
-		timestamp, err := s.send(signalCliSendRequest)
+		timestamp, err := s.send(cliSendRequest(&request, usernames, ds.Username))
 		if err != nil {
 			return nil, err
 		}
@@ -927,9 +949,9 @@ func (s *SignalClient) SendV2(number string, message string, recps []string, bas
 	return &timestamps, nil
 }
 
-func (s *SignalClient) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) (string, error) {
+func (s *SignalClient) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]domainSignal.ReceivedMessage, error) {
 	if s.signalCliMode == JsonRpc {
-		return "", errors.New("Not implemented")
+		return nil, errors.New("Not implemented")
 	} else {
 		command := []string{"--config", s.signalCliConfig, "--output", "json", "-a", number, "receive", "-t", strconv.FormatInt(timeout, 10)}
 
@@ -950,24 +972,21 @@ func (s *SignalClient) Receive(number string, timeout int64, ignoreAttachments b
 			command = append(command, "--send-read-receipts")
 		}
 
-		out, err := s.cliClient.Execute(true, command, "")
-		if err != nil {
-			return "", err
-		}
-
-		out = strings.Trim(out, "\n")
-		lines := strings.Split(out, "\n")
-
-		jsonStr := "["
-		for i, line := range lines {
-			jsonStr += line
-			if i != (len(lines) - 1) {
-				jsonStr += ","
+		receivedMessages := []domainSignal.ReceivedMessage{}
+		err := s.cliClient.ExecuteStream(context.Background(), command, "", func(line json.RawMessage) error {
+			receivedMessage, err := ParseReceivedMessage(line)
+			if err != nil {
+				s.Logger.Warn("Skipping unparsable received message", zap.Error(err), zap.String("data", string(line)))
+				return nil
 			}
+			receivedMessages = append(receivedMessages, *receivedMessage)
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		jsonStr += "]"
 
-		return jsonStr, nil
+		return receivedMessages, nil
 	}
 }
 
@@ -1082,8 +1101,8 @@ func prefixUsernameMembers(members []string) []string {
 	res := []string{}
 	for _, member := range members {
 		recipientType, err := getRecipientType(member)
-		if err == nil && recipientType == ds.Username {
-			res = append(res, "u:"+member)
+		if err == nil && recipientType == ds.Username && !strings.HasPrefix(member, usernamePrefix) {
+			res = append(res, usernamePrefix+member)
 		} else {
 			res = append(res, member)
 		}
@@ -1414,15 +1433,9 @@ func (s *SignalClient) GetQrCodeLink(deviceName string, qrCodeVersion int) ([]by
 			return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
 		}
 
-		q, err := qrcode.NewWithForcedVersion(string(resp.DeviceLinkUri), qrCodeVersion, qrcode.Highest)
-		if err != nil {
-			return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
-		}
-
-		var png []byte
-		png, err = q.PNG(256)
+		png, err := createQrCodePng(resp.DeviceLinkUri, qrCodeVersion)
 		if err != nil {
-			return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
+			return []byte{}, err
 		}
 
 		go (func() {
@@ -1454,20 +1467,121 @@ func (s *SignalClient) GetQrCodeLink(deviceName string, qrCodeVersion int) ([]by
 		return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
 	}
 
-	q, err := qrcode.NewWithForcedVersion(string(tsdeviceLink), qrCodeVersion, qrcode.Highest)
+	return createQrCodePng(tsdeviceLink, qrCodeVersion)
+}
+
+// createQrCodePng renders content as a 256x256 PNG QR code
+func createQrCodePng(content string, qrCodeVersion int) ([]byte, error) {
+	q, err := qrcode.NewWithForcedVersion(content, qrCodeVersion, qrcode.Highest)
 	if err != nil {
 		return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
 	}
 
 	q.DisableBorder = false
-	var png []byte
-	png, err = q.PNG(256)
+	png, err := q.PNG(256)
 	if err != nil {
 		return []byte{}, errors.New("Couldn't create QR code: " + err.Error())
 	}
 	return png, nil
 }
 
+// GetGroupInviteLink returns the invite link of a group, the link is empty while it is disabled for the group
+func (s *SignalClient) GetGroupInviteLink(number string, groupId string) (string, error) {
+	group, err := s.GetGroup(number, groupId)
+	if err != nil {
+		return "", err
+	}
+	if group == nil {
+		return "", &NotFoundError{Description: "No group with that group id (" + groupId + ") found"}
+	}
+	if group.InviteLink == "" {
+		return "", &NotFoundError{Description: "The invite link of the group is disabled"}
+	}
+	return group.InviteLink, nil
+}
+
+// ResetGroupInviteLink rotates the invite link of a group, invalidating the previous link, and returns the new one
+func (s *SignalClient) ResetGroupInviteLink(number string, groupId string) (string, error) {
+	internalGroupId, err := ConvertGroupIdToInternalGroupId(groupId)
+	if err != nil {
+		return "", err
+	}
+
+	if s.signalCliMode == JsonRpc {
+		type Request struct {
+			GroupId   string `json:"groupId"`
+			ResetLink bool   `json:"resetLink"`
+		}
+		request := Request{GroupId: internalGroupId, ResetLink: true}
+
+		jsonRpc2Client, err := s.getJsonRpc2Client()
+		if err != nil {
+			return "", err
+		}
+		_, err = jsonRpc2Client.getRaw("updateGroup", &number, request)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		_, err = s.cliClient.Execute(true, []string{"--config", s.signalCliConfig, "-a", number, "updateGroup", "-g", internalGroupId, "--reset-link"}, "")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return s.GetGroupInviteLink(number, groupId)
+}
+
+// GetGroupInviteLinkQrCode renders the invite link of a group as a PNG QR code
+func (s *SignalClient) GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error) {
+	inviteLink, err := s.GetGroupInviteLink(number, groupId)
+	if err != nil {
+		return []byte{}, err
+	}
+	return createQrCodePng(inviteLink, qrCodeVersion)
+}
+
+// JoinGroupByLink joins the group of an invite link. Groups requiring admin approval only register a join
+// request, which is reported by OnlyRequested.
+func (s *SignalClient) JoinGroupByLink(number string, uri string) (*JoinGroupResponse, error) {
+	var rawData string
+	var err error
+	if s.signalCliMode == JsonRpc {
+		type Request struct {
+			Uri string `json:"uri"`
+		}
+		request := Request{Uri: uri}
+
+		jsonRpc2Client, err := s.getJsonRpc2Client()
+		if err != nil {
+			return nil, err
+		}
+		rawData, err = jsonRpc2Client.getRaw("joinGroup", &number, request)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rawData, err = s.cliClient.Execute(true, []string{"--config", s.signalCliConfig, "-o", "json", "-a", number, "joinGroup", "--uri", uri}, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type SignalCliResponse struct {
+		GroupId       string `json:"groupId"`
+		OnlyRequested bool   `json:"onlyRequested"`
+	}
+	var signalCliResponse SignalCliResponse
+	if err := json.Unmarshal([]byte(rawData), &signalCliResponse); err != nil {
+		return nil, errors.New("Couldn't unmarshal data: " + err.Error())
+	}
+
+	return &JoinGroupResponse{
+		Id:            convertInternalGroupIdToGroupId(signalCliResponse.GroupId),
+		OnlyRequested: signalCliResponse.OnlyRequested,
+	}, nil
+}
+
 func (s *SignalClient) GetAccounts() ([]string, error) {
 	accounts := make([]string, 0)
 	var rawData string
@@ -1938,6 +2052,53 @@ func (s *SignalClient) SendReaction(number string, recipient string, emoji strin
 	return err
 }
 
+// RemoteDelete deletes a sent message for its recipients and groups, the message is identified by the
+// timestamp Signal returned when it was sent
+func (s *SignalClient) RemoteDelete(number string, recipients []string, timestamp int64) error {
+	// see https://github.com/AsamK/signal-cli/blob/master/man/signal-cli.1.adoc#remotedelete
+	var numbers []string
+	var groupIds []string
+	for _, recipient := range recipients {
+		if !strings.HasPrefix(recipient, groupPrefix) {
+			numbers = append(numbers, recipient)
+			continue
+		}
+		groupId, err := ConvertGroupIdToInternalGroupId(recipient)
+		if err != nil {
+			return errors.New("Invalid group id")
+		}
+		groupIds = append(groupIds, groupId)
+	}
+
+	if s.signalCliMode == JsonRpc {
+		type Request struct {
+			Recipients []string `json:"recipient,omitempty"`
+			GroupIds   []string `json:"group-id,omitempty"`
+			Timestamp  int64    `json:"target-timestamp"`
+		}
+		request := Request{Recipients: numbers, GroupIds: groupIds, Timestamp: timestamp}
+		jsonRpc2Client, err := s.getJsonRpc2Client()
+		if err != nil {
+			return err
+		}
+		_, err = jsonRpc2Client.getRaw("remoteDelete", &number, request)
+		return err
+	}
+
+	cmd := []string{
+		"--config", s.signalCliConfig,
+		"-a", number,
+		"remoteDelete",
+		"-t", strconv.FormatInt(timestamp, 10),
+	}
+	cmd = append(cmd, numbers...)
+	for _, groupId := range groupIds {
+		cmd = append(cmd, "-g", groupId)
+	}
+	_, err := s.cliClient.Execute(true, cmd, "")
+	return err
+}
+
 func (s *SignalClient) SendReceipt(number string, recipient string, receipt_type string, timestamp int64) error {
 	// see https://github.com/AsamK/signal-cli/blob/master/man/signal-cli.1.adoc#sendreceipt
 	var err error
@@ -2118,6 +2279,104 @@ func (s *SignalClient) SearchForNumbers(number string, numbers []string) ([]Sear
 	return searchResultEntries, err
 }
 
+// ResolveRecipients looks up the accounts of recipients with getUserStatus. Usernames are passed without their
+// prefix, every other recipient as it is, and the results name the recipients as given.
+func (s *SignalClient) ResolveRecipients(number string, recipients []string) ([]ResolvedRecipient, error) {
+	resolved := []ResolvedRecipient{}
+	numbers := []string{}
+	usernames := []string{}
+	givenAs := map[string]string{}
+	for _, recipient := range recipients {
+		recipientType, err := getRecipientType(recipient)
+		if err != nil {
+			return resolved, err
+		}
+		switch recipientType {
+		case ds.Number:
+			numbers = append(numbers, recipient)
+			givenAs[recipient] = recipient
+		case ds.Username:
+			username := strings.TrimPrefix(recipient, usernamePrefix)
+			usernames = append(usernames, username)
+			givenAs[username] = recipient
+		default:
+			return resolved, errors.New("Groups can't be resolved: " + recipient)
+		}
+	}
+	if len(numbers) == 0 && len(usernames) == 0 {
+		return resolved, nil
+	}
+
+	var err error
+	var rawData string
+	if s.signalCliMode == JsonRpc {
+		type Request struct {
+			Recipients []string `json:"recipient,omitempty"`
+			Usernames  []string `json:"username,omitempty"`
+		}
+		request := Request{Recipients: numbers, Usernames: usernames}
+
+		jsonRpc2Clients := s.getJsonRpc2Clients()
+		if len(jsonRpc2Clients) == 0 {
+			return resolved, errors.New("No JsonRpc2Client registered!")
+		}
+		for _, jsonRpc2Client := range jsonRpc2Clients {
+			rawData, err = jsonRpc2Client.getRaw("getUserStatus", &number, request)
+			if err == nil {
+				break
+			}
+		}
+	} else {
+		cmd := []string{"--config", s.signalCliConfig, "--output", "json"}
+		if number != "" {
+			cmd = append(cmd, []string{"-a", number}...)
+		}
+		cmd = append(cmd, "getUserStatus")
+		cmd = append(cmd, numbers...)
+		if len(usernames) > 0 {
+			cmd = append(cmd, "--username")
+			cmd = append(cmd, usernames...)
+		}
+		rawData, err = s.cliClient.Execute(true, cmd, "")
+	}
+	if err != nil {
+		return resolved, err
+	}
+	return parseUserStatus(rawData, givenAs)
+}
+
+// parseUserStatus converts the getUserStatus output to the resolved recipients, givenAs maps the recipients
+// signal-cli names to the recipients as they were given
+func parseUserStatus(rawData string, givenAs map[string]string) ([]ResolvedRecipient, error) {
+	type SignalCliResponse struct {
+		Recipient    string  `json:"recipient"`
+		Number       *string `json:"number"`
+		Uuid         *string `json:"uuid"`
+		IsRegistered bool    `json:"isRegistered"`
+	}
+
+	var resp []SignalCliResponse
+	if err := json.Unmarshal([]byte(rawData), &resp); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]ResolvedRecipient, 0, len(resp))
+	for _, val := range resp {
+		entry := ResolvedRecipient{Recipient: val.Recipient, Registered: val.IsRegistered}
+		if recipient, ok := givenAs[val.Recipient]; ok {
+			entry.Recipient = recipient
+		}
+		if val.Number != nil {
+			entry.Number = *val.Number
+		}
+		if val.Uuid != nil {
+			entry.UUID = *val.Uuid
+		}
+		resolved = append(resolved, entry)
+	}
+	return resolved, nil
+}
+
 func (s *SignalClient) SendContacts(number string) error {
 	var err error
 	if s.signalCliMode == JsonRpc {
@@ -1,5 +1,13 @@
 package signal_client
 
+import (
+	"errors"
+	"strings"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+)
+
 type InvalidNameError struct {
 	Description string
 }
@@ -23,3 +31,38 @@ type InternalError struct {
 func (e *InternalError) Error() string {
 	return e.Description
 }
+
+// ErrorCode classifies an error of a send, signal-cli reports most failures by their message only
+func ErrorCode(err error) string {
+	var rateLimitErr *domainSignal.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return domainProvider.ErrorCodeRateLimited
+	}
+	var unlinkedErr *domainSignal.DeviceUnlinkedError
+	if errors.As(err, &unlinkedErr) {
+		return domainProvider.ErrorCodeDeviceUnlinked
+	}
+	var internalErr *InternalError
+	if errors.As(err, &internalErr) {
+		return domainProvider.ErrorCodeNetwork
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "unregistered"):
+		// a recipient without a Signal account
+		return domainProvider.ErrorCodeUnregistered
+	case strings.Contains(message, "invalid number"), strings.Contains(message, "invalid phone number"),
+		strings.Contains(message, "invalid username"), strings.Contains(message, "invalid group id"),
+		strings.Contains(message, "username not found"):
+		// a username or phone number identity nobody holds is invalid on every backend
+		return domainProvider.ErrorCodeInvalidRecipient
+	case strings.Contains(message, "not registered"), strings.Contains(message, "authorization failed"),
+		strings.Contains(message, "account does not exist"):
+		// the number sent from isn't registered on this backend any more
+		return domainProvider.ErrorCodeAuthFailed
+	case strings.Contains(message, "rate limit"):
+		return domainProvider.ErrorCodeRateLimited
+	}
+	return ""
+}
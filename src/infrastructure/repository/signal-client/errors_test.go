@@ -0,0 +1,23 @@
+package signal_client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(&domainSignal.RateLimitError{Err: errors.New("too many messages")}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&InternalError{Description: "signal-cli exited"}))
+	assert.Equal(t, domainProvider.ErrorCodeUnregistered, ErrorCode(errors.New("Failed to send message: +4912345: Unregistered user")))
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(errors.New("Invalid phone number: 12345")))
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(errors.New("Invalid phone number identity PNI:abc")))
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(errors.New("User +4999999 is not registered.")))
+	assert.Equal(t, domainProvider.ErrorCodeDeviceUnlinked, ErrorCode(fmt.Errorf("sending: %w", &domainSignal.DeviceUnlinkedError{Number: "+4911111"})))
+	assert.Equal(t, "", ErrorCode(errors.New("connection refused")))
+}
@@ -0,0 +1,63 @@
+package signal_client
+
+import (
+	"strings"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+)
+
+// GetGroupMembers lists the members of a group with the names of their contacts
+func (s *SignalClient) GetGroupMembers(number string, groupId string) ([]domainSignal.GroupMember, error) {
+	group, err := s.GetGroup(number, groupId)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, &NotFoundError{Description: "No group with id " + groupId + " found"}
+	}
+	contacts, err := s.ListContacts(number)
+	if err != nil {
+		return nil, err
+	}
+	return groupMembers(group.Members, contacts), nil
+}
+
+// groupMembers names the members of a group, given by number or by UUID, with their contact names. Members that
+// aren't contacts have no name and can't be mentioned by it.
+func groupMembers(members []string, contacts []ListContactsResponse) []domainSignal.GroupMember {
+	byIdentifier := make(map[string]ListContactsResponse, 2*len(contacts))
+	for _, contact := range contacts {
+		if contact.Number != "" {
+			byIdentifier[contact.Number] = contact
+		}
+		if contact.Uuid != "" {
+			byIdentifier[contact.Uuid] = contact
+		}
+	}
+
+	groupMembers := make([]domainSignal.GroupMember, 0, len(members))
+	for _, member := range members {
+		groupMember := domainSignal.GroupMember{Number: member}
+		if !strings.HasPrefix(member, "+") {
+			groupMember = domainSignal.GroupMember{UUID: member}
+		}
+		if contact, ok := byIdentifier[member]; ok {
+			groupMember.Number = contact.Number
+			groupMember.UUID = contact.Uuid
+			groupMember.Name = contactName(contact)
+		}
+		groupMembers = append(groupMembers, groupMember)
+	}
+	return groupMembers
+}
+
+// contactName is the name the account gave a contact, or its profile name
+func contactName(contact ListContactsResponse) string {
+	if contact.Name != "" {
+		return contact.Name
+	}
+	if contact.ProfileName != "" {
+		return contact.ProfileName
+	}
+	return strings.TrimSpace(contact.Profile.GivenName + " " + contact.Profile.FamilyName)
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/httpclient"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	"net"
 	"net/http"
@@ -52,14 +54,7 @@ type RateLimitResult struct {
 	Token string `json:"token"`
 }
 
-type RateLimitErrorType struct {
-	ChallengeTokens []string
-	Err             error
-}
-
-func (r *RateLimitErrorType) Error() string {
-	return r.Err.Error()
-}
+type RateLimitErrorType = domainSignal.RateLimitError
 
 type JsonRpc2Client struct {
 	conn                     net.Conn
@@ -194,7 +189,7 @@ func postMessageToWebhook(webhookUrl string, data []byte) error {
 
 	r.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client := httpclient.New(0)
 	res, err := client.Do(r)
 	if err != nil {
 		return err
@@ -223,16 +218,13 @@ func (r *JsonRpc2Client) ReceiveData(number string, receiveWebhookUrl string) {
 		}
 		r.Logger.Debug(fmt.Sprintf("json-rpc received data: %s", str))
 
-		if receiveWebhookUrl != "" {
-			err = postMessageToWebhook(receiveWebhookUrl, []byte(str))
-			if err != nil {
-				r.Logger.Error("Couldn't post data to webhook:", zap.Error(err))
-			}
-		}
-
 		var resp1 JsonRpc2ReceivedMessage
 		json.Unmarshal([]byte(str), &resp1)
 		if resp1.Method == "receive" {
+			if receiveWebhookUrl != "" {
+				r.postReceivedMessageToWebhook(receiveWebhookUrl, resp1.Params)
+			}
+
 			r.receivedMessagesMutex.Lock()
 			for _, c := range r.receivedMessagesChannels {
 				select {
@@ -260,6 +252,25 @@ func (r *JsonRpc2Client) ReceiveData(number string, receiveWebhookUrl string) {
 	}
 }
 
+// postReceivedMessageToWebhook posts a received message to the webhook as a typed payload,
+// falling back to the raw data if it can't be parsed
+func (r *JsonRpc2Client) postReceivedMessageToWebhook(receiveWebhookUrl string, params json.RawMessage) {
+	data := []byte(params)
+	receivedMessage, err := ParseReceivedMessage(params)
+	if err == nil {
+		data, err = json.Marshal(NewReceiveWebhookPayload(receivedMessage))
+	}
+	if err != nil {
+		r.Logger.Warn("Couldn't parse received message, posting raw data to webhook", zap.Error(err))
+		data = []byte(params)
+	}
+
+	err = postMessageToWebhook(receiveWebhookUrl, data)
+	if err != nil {
+		r.Logger.Error("Couldn't post data to webhook:", zap.Error(err))
+	}
+}
+
 func (r *JsonRpc2Client) GetReceiveChannel() (chan JsonRpc2ReceivedMessage, string, error) {
 	c := make(chan JsonRpc2ReceivedMessage)
 
@@ -0,0 +1,103 @@
+package signal_client
+
+import (
+	"sync"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+
+	"go.uber.org/zap"
+)
+
+// pruneInterval is how often the dedupe keys of an account that fell out of the retention window are deleted
+const pruneInterval = time.Hour
+
+// ReceiveDeduplicator makes sure every inbound message is routed once, however often signal-cli returns it
+// and whichever receive path or instance gets it. Messages are keyed by account, envelope timestamp and
+// source, and the highest routed timestamp is kept per account as a watermark. Keys older than the
+// retention before the watermark are pruned, messages that old are treated as already routed.
+type ReceiveDeduplicator struct {
+	repository signalRepo.ReceivedMessageRepositoryInterface
+	retention  time.Duration
+	Logger     *logger.Logger
+	mutex      sync.Mutex
+	lastPrune  map[string]time.Time
+}
+
+// NewReceiveDeduplicator creates a new receive deduplicator
+func NewReceiveDeduplicator(repository signalRepo.ReceivedMessageRepositoryInterface, retention time.Duration, loggerInstance *logger.Logger) *ReceiveDeduplicator {
+	if retention <= 0 {
+		retention = 72 * time.Hour // Default to keeping the keys of three days if not specified
+	}
+	return &ReceiveDeduplicator{
+		repository: repository,
+		retention:  retention,
+		Logger:     loggerInstance,
+		lastPrune:  make(map[string]time.Time),
+	}
+}
+
+// IsNew claims a received message and reports whether it wasn't routed before. When the dedupe keys
+// can't be stored the message counts as new, a duplicate is preferred over a lost message.
+func (d *ReceiveDeduplicator) IsNew(receivedMessage *domainSignal.ReceivedMessage) bool {
+	account := receivedMessage.Account
+	timestamp := receivedMessage.Envelope.Timestamp
+	source := receivedMessage.Envelope.SenderID()
+	if timestamp == 0 {
+		// Without a timestamp the message can't be keyed
+		return true
+	}
+
+	watermark, err := d.repository.GetWatermark(account)
+	if err != nil {
+		return true
+	}
+	if timestamp < watermark.Timestamp-d.retention.Milliseconds() {
+		d.Logger.Warn("Skipping received message older than the dedupe retention",
+			zap.String("account", account), zap.Int64("timestamp", timestamp), zap.Int64("watermark", watermark.Timestamp))
+		return false
+	}
+
+	claimed, err := d.repository.Claim(account, timestamp, source)
+	if err != nil {
+		return true
+	}
+	if !claimed {
+		d.Logger.Info("Skipping duplicate received message",
+			zap.String("account", account), zap.Int64("timestamp", timestamp), zap.String("source", source))
+		return false
+	}
+
+	d.prune(account, max(watermark.Timestamp, timestamp))
+	return true
+}
+
+// Handler returns a receive handler passing only messages that weren't routed before to the next handler
+func (d *ReceiveDeduplicator) Handler(next ReceiveHandler) ReceiveHandler {
+	return func(receivedMessage *domainSignal.ReceivedMessage) {
+		if d.IsNew(receivedMessage) {
+			next(receivedMessage)
+		}
+	}
+}
+
+// prune deletes the dedupe keys of an account that fell out of the retention window, at most once per interval
+func (d *ReceiveDeduplicator) prune(account string, watermark int64) {
+	d.mutex.Lock()
+	if time.Since(d.lastPrune[account]) < pruneInterval {
+		d.mutex.Unlock()
+		return
+	}
+	d.lastPrune[account] = time.Now()
+	d.mutex.Unlock()
+
+	deleted, err := d.repository.DeleteBefore(account, watermark-d.retention.Milliseconds())
+	if err != nil {
+		return
+	}
+	if deleted > 0 {
+		d.Logger.Info("Pruned received message keys", zap.String("account", account), zap.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,97 @@
+package signal_client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockReceivedMessageRepository struct {
+	keys       map[string]bool
+	watermarks map[string]int64
+	deleted    []int64
+	err        error
+}
+
+func newMockReceivedMessageRepository() *mockReceivedMessageRepository {
+	return &mockReceivedMessageRepository{keys: make(map[string]bool), watermarks: make(map[string]int64)}
+}
+
+func (m *mockReceivedMessageRepository) Claim(account string, timestamp int64, source string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	key := fmt.Sprintf("%s/%d/%s", account, timestamp, source)
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	m.watermarks[account] = max(m.watermarks[account], timestamp)
+	return true, nil
+}
+
+func (m *mockReceivedMessageRepository) GetWatermark(account string) (*domainSignal.ReceiveWatermark, error) {
+	return &domainSignal.ReceiveWatermark{Account: account, Timestamp: m.watermarks[account]}, nil
+}
+
+func (m *mockReceivedMessageRepository) DeleteBefore(account string, timestamp int64) (int64, error) {
+	m.deleted = append(m.deleted, timestamp)
+	return 0, nil
+}
+
+func newTestDeduplicator(t *testing.T, repository *mockReceivedMessageRepository) *ReceiveDeduplicator {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewReceiveDeduplicator(repository, time.Hour, loggerInstance)
+}
+
+func receivedMessageAt(source string, timestamp int64) *domainSignal.ReceivedMessage {
+	return &domainSignal.ReceivedMessage{Account: "+4999999", Envelope: domainSignal.Envelope{Source: source, Timestamp: timestamp}}
+}
+
+func TestReceiveDeduplicator_RoutesEachMessageOnce(t *testing.T) {
+	deduplicator := newTestDeduplicator(t, newMockReceivedMessageRepository())
+
+	var routed []int64
+	handler := deduplicator.Handler(func(receivedMessage *domainSignal.ReceivedMessage) {
+		routed = append(routed, receivedMessage.Envelope.Timestamp)
+	})
+
+	handler(receivedMessageAt("+4912345", 1000))
+	handler(receivedMessageAt("+4912345", 1000))
+	// The same timestamp from another source is another message
+	handler(receivedMessageAt("+4954321", 1000))
+	handler(receivedMessageAt("+4912345", 2000))
+
+	assert.Equal(t, []int64{1000, 1000, 2000}, routed)
+}
+
+func TestReceiveDeduplicator_SkipsMessagesOlderThanRetention(t *testing.T) {
+	repository := newMockReceivedMessageRepository()
+	deduplicator := newTestDeduplicator(t, repository)
+	now := time.Now().UnixMilli()
+
+	assert.True(t, deduplicator.IsNew(receivedMessageAt("+4912345", now)))
+	// Out of order within the retention is still routed
+	assert.True(t, deduplicator.IsNew(receivedMessageAt("+4912345", now-time.Minute.Milliseconds())))
+	assert.False(t, deduplicator.IsNew(receivedMessageAt("+4912345", now-2*time.Hour.Milliseconds())))
+
+	// Keys are pruned once per interval
+	assert.Equal(t, []int64{now - time.Hour.Milliseconds()}, repository.deleted)
+}
+
+func TestReceiveDeduplicator_PassesMessagesWhenKeysCantBeStored(t *testing.T) {
+	repository := newMockReceivedMessageRepository()
+	repository.err = errors.New("database unavailable")
+	deduplicator := newTestDeduplicator(t, repository)
+
+	assert.True(t, deduplicator.IsNew(receivedMessageAt("+4912345", 1000)))
+	assert.True(t, deduplicator.IsNew(receivedMessageAt("+4912345", 1000)))
+}
@@ -0,0 +1,38 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"errors"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+)
+
+// ReceiveWebhookPayload is the body posted to the receive webhook for every received message. Content holds the
+// normalized content of data messages, reactions, contact cards and payment notifications.
+type ReceiveWebhookPayload struct {
+	Type    domainSignal.EnvelopeType     `json:"type"`
+	Content *domainSignal.EnvelopeContent `json:"content,omitempty"`
+	domainSignal.ReceivedMessage
+}
+
+// ParseReceivedMessage decodes a single received message as emitted by signal-cli, either as a line of
+// the receive command output or as the params of a json-rpc receive notification
+func ParseReceivedMessage(data []byte) (*domainSignal.ReceivedMessage, error) {
+	var receivedMessage domainSignal.ReceivedMessage
+	if err := json.Unmarshal(data, &receivedMessage); err != nil {
+		return nil, err
+	}
+	if receivedMessage.Envelope.Source == "" && receivedMessage.Envelope.SourceUuid == "" && receivedMessage.Envelope.Timestamp == 0 {
+		return nil, errors.New("received message has no envelope")
+	}
+	return &receivedMessage, nil
+}
+
+// NewReceiveWebhookPayload wraps a received message with its envelope type and content for webhook delivery
+func NewReceiveWebhookPayload(receivedMessage *domainSignal.ReceivedMessage) ReceiveWebhookPayload {
+	return ReceiveWebhookPayload{
+		Type:            receivedMessage.Envelope.Type(),
+		Content:         receivedMessage.Envelope.Content(),
+		ReceivedMessage: *receivedMessage,
+	}
+}
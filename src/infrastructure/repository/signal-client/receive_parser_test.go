@@ -0,0 +1,102 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"testing"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReceivedMessage_DataMessage(t *testing.T) {
+	data := `{"envelope":{"source":"+4912345","sourceNumber":"+4912345","sourceUuid":"a-b-c","sourceName":"Alice","sourceDevice":1,"timestamp":1700000000000,
+		"dataMessage":{"timestamp":1700000000000,"message":"hello","expiresInSeconds":0,"viewOnce":false,
+		"attachments":[{"contentType":"image/png","filename":"a.png","id":"att1","size":42}],
+		"groupInfo":{"groupId":"group1","type":"DELIVER"}}},"account":"+4999999"}`
+
+	receivedMessage, err := ParseReceivedMessage([]byte(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "+4999999", receivedMessage.Account)
+	assert.Equal(t, "Alice", receivedMessage.Envelope.SourceName)
+	assert.Equal(t, domainSignal.EnvelopeTypeDataMessage, receivedMessage.Envelope.Type())
+	require.NotNil(t, receivedMessage.Envelope.DataMessage.Message)
+	assert.Equal(t, "hello", *receivedMessage.Envelope.DataMessage.Message)
+	assert.Len(t, receivedMessage.Envelope.DataMessage.Attachments, 1)
+	assert.Equal(t, "group1", receivedMessage.Envelope.DataMessage.GroupInfo.GroupId)
+}
+
+func TestParseReceivedMessage_EnvelopeTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope string
+		expected domainSignal.EnvelopeType
+	}{
+		{"reaction", `"dataMessage":{"timestamp":1,"reaction":{"emoji":"👍","targetAuthor":"+49","targetSentTimestamp":5,"isRemove":false}}`, domainSignal.EnvelopeTypeReaction},
+		{"group update", `"dataMessage":{"timestamp":1,"groupInfo":{"groupId":"group1","type":"UPDATE"}}`, domainSignal.EnvelopeTypeGroupUpdate},
+		{"contact", `"dataMessage":{"timestamp":1,"contacts":[{"name":{"display":"Bob"},"phone":[{"value":"+4955","type":"MOBILE"}]}]}`, domainSignal.EnvelopeTypeContact},
+		{"payment", `"dataMessage":{"timestamp":1,"payment":{"note":"lunch","receipt":"cmVjZWlwdA=="}}`, domainSignal.EnvelopeTypePayment},
+		{"receipt", `"receiptMessage":{"when":1,"isDelivery":true,"isRead":false,"isViewed":false,"timestamps":[5]}`, domainSignal.EnvelopeTypeReceipt},
+		{"typing", `"typingMessage":{"action":"STARTED","timestamp":1}`, domainSignal.EnvelopeTypeTyping},
+		{"sync", `"syncMessage":{}`, domainSignal.EnvelopeTypeSync},
+		{"unknown", `"callMessage":{}`, domainSignal.EnvelopeTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"envelope":{"source":"+4912345","sourceDevice":1,"timestamp":1,` + tt.envelope + `},"account":"+4999999"}`
+			receivedMessage, err := ParseReceivedMessage([]byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, receivedMessage.Envelope.Type())
+		})
+	}
+}
+
+func TestParseReceivedMessage_Invalid(t *testing.T) {
+	_, err := ParseReceivedMessage([]byte(`{invalid`))
+	assert.Error(t, err)
+
+	_, err = ParseReceivedMessage([]byte(`{"account":"+4999999"}`))
+	assert.Error(t, err)
+}
+
+func TestNewReceiveWebhookPayload(t *testing.T) {
+	receivedMessage, err := ParseReceivedMessage([]byte(`{"envelope":{"source":"+4912345","sourceDevice":1,"timestamp":1,"typingMessage":{"action":"STOPPED","timestamp":1}},"account":"+4999999"}`))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(NewReceiveWebhookPayload(receivedMessage))
+	require.NoError(t, err)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &payload))
+	assert.Equal(t, "typing", payload["type"])
+	assert.Equal(t, "+4999999", payload["account"])
+	assert.NotNil(t, payload["envelope"])
+}
+
+func TestNewReceiveWebhookPayload_NormalizesContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope string
+		expected *domainSignal.EnvelopeContent
+	}{
+		{"reaction", `"dataMessage":{"timestamp":1,"reaction":{"emoji":"👍","targetAuthor":"+49","targetSentTimestamp":5,"isRemove":true}}`,
+			&domainSignal.EnvelopeContent{Type: domainSignal.EnvelopeTypeReaction, Reaction: &domainSignal.ReactionContent{Emoji: "👍", TargetAuthor: "+49", TargetSentTimestamp: 5, Removed: true}}},
+		{"contact", `"dataMessage":{"timestamp":1,"contacts":[{"name":{"given":"Bob","family":"Builder"},"phone":[{"value":"+4955","type":"MOBILE"}],"email":[{"value":"bob@example.com","type":"WORK"}],"organization":"Acme"}]}`,
+			&domainSignal.EnvelopeContent{Type: domainSignal.EnvelopeTypeContact, Contacts: []domainSignal.ContactCard{{Name: "Bob Builder", Phones: []string{"+4955"}, Emails: []string{"bob@example.com"}, Organization: "Acme"}}}},
+		{"payment", `"dataMessage":{"timestamp":1,"payment":{"note":"lunch","receipt":"cmVjZWlwdA=="}}`,
+			&domainSignal.EnvelopeContent{Type: domainSignal.EnvelopeTypePayment, Payment: &domainSignal.PaymentContent{Note: "lunch", Receipt: "cmVjZWlwdA=="}}},
+		{"receipt", `"receiptMessage":{"when":1,"isDelivery":true,"timestamps":[5]}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"envelope":{"source":"+4912345","sourceDevice":1,"timestamp":1,` + tt.envelope + `},"account":"+4999999"}`
+			receivedMessage, err := ParseReceivedMessage([]byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, NewReceiveWebhookPayload(receivedMessage).Content)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// ReceiveHandler is called for every received message, in the order signal-cli returned them
+type ReceiveHandler func(receivedMessage *domainSignal.ReceivedMessage)
+
+// ReceiveErrorRecorder counts the errors of the receive stream of a number in its health
+type ReceiveErrorRecorder interface {
+	RecordReceiveError(number string, err error)
+}
+
+// ReceivePoller receives the messages of a number on a schedule in the normal and native modes, where
+// signal-cli doesn't push received messages like in json-rpc mode. Every message the deduplicator didn't
+// see before is posted to the receive webhook, if one is set, and passed to the handler. It polls on the
+// leader instance only, so messages aren't split between instances.
+type ReceivePoller struct {
+	client     domainSignal.ISignalService
+	number     string
+	webhookUrl string
+	handler    ReceiveHandler
+	dedupe     *ReceiveDeduplicator
+	errors     ReceiveErrorRecorder
+	elector    leader.Elector
+	Logger     *logger.Logger
+	interval   time.Duration
+	timeout    int64
+	shutdown   chan struct{}
+	done       chan struct{}
+}
+
+// NewReceivePoller creates a new receive poller and starts it. The timeout is the number of seconds
+// signal-cli waits for new messages on each poll. errors may be nil, failed polls are only logged then.
+func NewReceivePoller(client domainSignal.ISignalService, number string, webhookUrl string, handler ReceiveHandler, dedupe *ReceiveDeduplicator, errors ReceiveErrorRecorder, elector leader.Elector,
+	loggerInstance *logger.Logger, interval time.Duration, timeout int64) *ReceivePoller {
+	if interval <= 0 {
+		interval = 10 * time.Second // Default to polling every 10 seconds if not specified
+	}
+	if timeout <= 0 {
+		timeout = 1
+	}
+
+	poller := &ReceivePoller{
+		client:     client,
+		number:     number,
+		webhookUrl: webhookUrl,
+		handler:    handler,
+		dedupe:     dedupe,
+		errors:     errors,
+		elector:    elector,
+		Logger:     loggerInstance,
+		interval:   interval,
+		timeout:    timeout,
+		shutdown:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go poller.run()
+
+	return poller
+}
+
+func (p *ReceivePoller) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.Logger.Info("Starting receive poller", zap.String("number", p.number), zap.Duration("interval", p.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			p.poll()
+		case <-p.shutdown:
+			return
+		}
+	}
+}
+
+func (p *ReceivePoller) poll() {
+	if !p.elector.IsLeader() {
+		return
+	}
+
+	receivedMessages, err := p.client.Receive(p.number, p.timeout, false, true, 0, false)
+	if err != nil {
+		p.Logger.Error("Couldn't receive messages", zap.Error(err), zap.String("number", p.number))
+		if p.errors != nil {
+			p.errors.RecordReceiveError(p.number, err)
+		}
+		return
+	}
+
+	for i := range receivedMessages {
+		receivedMessage := &receivedMessages[i]
+		if p.dedupe != nil && !p.dedupe.IsNew(receivedMessage) {
+			continue
+		}
+		if p.webhookUrl != "" {
+			p.postToWebhook(receivedMessage)
+		}
+		if p.handler != nil {
+			p.handler(receivedMessage)
+		}
+	}
+}
+
+// postToWebhook posts a received message to the webhook with the same payload as in json-rpc mode
+func (p *ReceivePoller) postToWebhook(receivedMessage *domainSignal.ReceivedMessage) {
+	data, err := json.Marshal(NewReceiveWebhookPayload(receivedMessage))
+	if err != nil {
+		p.Logger.Error("Couldn't marshal received message", zap.Error(err))
+		return
+	}
+	if err := postMessageToWebhook(p.webhookUrl, data); err != nil {
+		p.Logger.Error("Couldn't post data to webhook:", zap.Error(err))
+	}
+}
+
+// Shutdown stops the poller
+func (p *ReceivePoller) Shutdown() {
+	close(p.shutdown)
+	<-p.done
+}
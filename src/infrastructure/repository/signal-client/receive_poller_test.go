@@ -0,0 +1,51 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notLeader struct{}
+
+func (notLeader) IsLeader() bool { return false }
+
+func (notLeader) Shutdown() {}
+
+func newTestPoller(t *testing.T, webhookUrl string) *ReceivePoller {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return &ReceivePoller{number: "+4999999", webhookUrl: webhookUrl, elector: notLeader{}, Logger: loggerInstance}
+}
+
+func TestReceivePoller_PostsTypedPayloadToWebhook(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	receivedMessage, err := ParseReceivedMessage([]byte(`{"envelope":{"source":"+4912345","sourceDevice":1,"timestamp":1,"dataMessage":{"timestamp":1,"message":"ACK 1234"}},"account":"+4999999"}`))
+	require.NoError(t, err)
+
+	newTestPoller(t, server.URL).postToWebhook(receivedMessage)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "data_message", payload["type"])
+	assert.Equal(t, "+4999999", payload["account"])
+}
+
+func TestReceivePoller_OnlyLeaderPolls(t *testing.T) {
+	// The poller has no client, receiving on a follower would panic
+	poller := newTestPoller(t, "")
+	assert.NotPanics(t, poller.poll)
+}
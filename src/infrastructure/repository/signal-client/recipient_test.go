@@ -0,0 +1,50 @@
+package signal_client
+
+import (
+	"testing"
+
+	ds "go-multi-chat-api/src/infrastructure/datastructs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRecipientType(t *testing.T) {
+	tests := []struct {
+		recipient string
+		expected  ds.RecpType
+	}{
+		{"+4912345678", ds.Number},
+		{"PNI:0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11", ds.Number},
+		{"0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11", ds.Number},
+		{"u:alice.42", ds.Username},
+		{"alice.42", ds.Username},
+	}
+	for _, test := range tests {
+		recipientType, err := getRecipientType(test.recipient)
+		require.NoError(t, err, test.recipient)
+		assert.Equal(t, test.expected, recipientType, test.recipient)
+	}
+
+	_, err := getRecipientType("u:")
+	assert.Error(t, err)
+	_, err = getRecipientType("PNI:not-a-uuid")
+	assert.Error(t, err)
+}
+
+func TestPrefixUsernameMembers(t *testing.T) {
+	assert.Equal(t, []string{"+4912345678", "u:alice.42", "u:bob.07"},
+		prefixUsernameMembers([]string{"+4912345678", "alice.42", "u:bob.07"}))
+}
+
+func TestParseUserStatus(t *testing.T) {
+	rawData := `[{"recipient":"alice.42","number":null,"uuid":"0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11","isRegistered":true},` +
+		`{"recipient":"+4912345678","number":"+4912345678","uuid":null,"isRegistered":false}]`
+
+	resolved, err := parseUserStatus(rawData, map[string]string{"alice.42": "u:alice.42", "+4912345678": "+4912345678"})
+	require.NoError(t, err)
+	assert.Equal(t, []ResolvedRecipient{
+		{Recipient: "u:alice.42", UUID: "0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11", Registered: true},
+		{Recipient: "+4912345678", Number: "+4912345678"},
+	}, resolved)
+}
@@ -0,0 +1,499 @@
+package signal_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// errNotSupportedByRemote is returned for the operations the signal-cli-rest-api has no endpoint for
+var errNotSupportedByRemote = errors.New("operation is not supported by the remote signal backend")
+
+// RemoteRepository implements the domainSignal.ISignalService interface against a remote
+// signal-cli-rest-api instead of a local signal-cli
+type RemoteRepository struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	Logger  *logger.Logger
+}
+
+// NewRemoteSignalRepository creates a new RemoteRepository calling the REST API at baseURL, token is sent as
+// bearer token when set
+func NewRemoteSignalRepository(baseURL string, token string, timeout time.Duration, loggerInstance *logger.Logger) *RemoteRepository {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &RemoteRepository{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  httpclient.New(timeout),
+		Logger:  loggerInstance,
+	}
+}
+
+// remoteError is the error body of the REST API, challenge tokens are set when an account was rate limited
+type remoteError struct {
+	Error           string   `json:"error"`
+	ChallengeTokens []string `json:"challenge_tokens"`
+}
+
+// do sends a request to the REST API and decodes the JSON response into result, when result is set
+func (r *RemoteRepository) do(method string, path string, body interface{}, result interface{}) error {
+	responseBody, err := r.doRaw(method, path, body)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(responseBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(responseBody, result); err != nil {
+		return fmt.Errorf("couldn't parse response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func (r *RemoteRepository) doRaw(method string, path string, body interface{}) ([]byte, error) {
+	var requestBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		requestBody = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, r.baseURL+path, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if r.token != "" {
+		request.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return responseBody, nil
+	}
+
+	var errorBody remoteError
+	_ = json.Unmarshal(responseBody, &errorBody)
+	if errorBody.Error == "" {
+		errorBody.Error = fmt.Sprintf("signal rest api answered %d", response.StatusCode)
+	}
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		return nil, &domainSignal.RateLimitError{ChallengeTokens: errorBody.ChallengeTokens, Err: errors.New(errorBody.Error)}
+	case response.StatusCode == http.StatusNotFound:
+		return nil, &NotFoundError{Description: errorBody.Error}
+	case response.StatusCode >= 500:
+		return nil, &InternalError{Description: errorBody.Error}
+	default:
+		return nil, errors.New(errorBody.Error)
+	}
+}
+
+// About returns the version and mode of the remote REST API
+func (r *RemoteRepository) About() (*About, error) {
+	var about About
+	if err := r.do(http.MethodGet, "/v1/about", nil, &about); err != nil {
+		return nil, err
+	}
+	return &about, nil
+}
+
+// RegisterNumber registers a new Signal number
+func (r *RemoteRepository) RegisterNumber(number string, useVoice bool, captcha string) error {
+	r.Logger.Info("RemoteRepository: Registering number", zap.String("number", number))
+	body := map[string]interface{}{"use_voice": useVoice}
+	if captcha != "" {
+		body["captcha"] = captcha
+	}
+	return r.do(http.MethodPost, "/v1/register/"+url.PathEscape(number), body, nil)
+}
+
+// VerifyRegisteredNumber verifies a registered Signal number
+func (r *RemoteRepository) VerifyRegisteredNumber(number string, token string, pin string) error {
+	r.Logger.Info("RemoteRepository: Verifying registered number", zap.String("number", number))
+	var body interface{}
+	if pin != "" {
+		body = map[string]string{"pin": pin}
+	}
+	return r.do(http.MethodPost, "/v1/register/"+url.PathEscape(number)+"/verify/"+url.PathEscape(token), body, nil)
+}
+
+// UnregisterNumber unregisters a Signal number
+func (r *RemoteRepository) UnregisterNumber(number string, deleteAccount bool, deleteLocalData bool) error {
+	r.Logger.Info("RemoteRepository: Unregistering number", zap.String("number", number))
+	body := map[string]bool{"delete_account": deleteAccount, "delete_local_data": deleteLocalData}
+	return r.do(http.MethodPost, "/v1/unregister/"+url.PathEscape(number), body, nil)
+}
+
+// GetAccounts gets all registered Signal accounts
+func (r *RemoteRepository) GetAccounts() ([]string, error) {
+	r.Logger.Info("RemoteRepository: Getting all accounts")
+	accounts := []string{}
+	if err := r.do(http.MethodGet, "/v1/accounts", nil, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SetPin sets the registration lock PIN of a Signal number
+func (r *RemoteRepository) SetPin(number string, registrationLockPin string) error {
+	r.Logger.Info("RemoteRepository: Setting registration lock pin", zap.String("number", number))
+	return r.do(http.MethodPost, "/v1/accounts/"+url.PathEscape(number)+"/pin", map[string]string{"pin": registrationLockPin}, nil)
+}
+
+// RemovePin removes the registration lock PIN of a Signal number
+func (r *RemoteRepository) RemovePin(number string) error {
+	r.Logger.Info("RemoteRepository: Removing registration lock pin", zap.String("number", number))
+	return r.do(http.MethodDelete, "/v1/accounts/"+url.PathEscape(number)+"/pin", nil, nil)
+}
+
+// SubmitRateLimitChallenge submits the solved captcha of a rate limit challenge
+func (r *RemoteRepository) SubmitRateLimitChallenge(number string, challengeToken string, captcha string) error {
+	r.Logger.Info("RemoteRepository: Submitting rate limit challenge", zap.String("number", number))
+	body := map[string]string{"challenge_token": challengeToken, "captcha": captcha}
+	return r.do(http.MethodPost, "/v1/accounts/"+url.PathEscape(number)+"/rate-limit-challenge", body, nil)
+}
+
+// Send sends a message via Signal. The REST API splits the recipients by type itself and answers with the
+// timestamp of the last message it sent.
+func (r *RemoteRepository) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	r.Logger.Info("RemoteRepository: Sending message",
+		zap.String("from", request.Number),
+		zap.Int("recipientsCount", len(request.Recipients)),
+		zap.Int("attachmentsCount", len(request.Base64Attachments)))
+
+	request = withSendDefaults(request)
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The REST API returns the timestamp as string
+	var response struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := r.do(http.MethodPost, "/v2/send", request, &response); err != nil {
+		return nil, err
+	}
+	timestamp, err := strconv.ParseInt(response.Timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q in send response: %w", response.Timestamp, err)
+	}
+	return &[]domainSignal.SendResponse{{Timestamp: timestamp}}, nil
+}
+
+// RemoteDelete deletes a sent message for its recipients. The REST API deletes it for one recipient or
+// group per request.
+func (r *RemoteRepository) RemoteDelete(number string, recipients []string, timestamp int64) error {
+	r.Logger.Info("RemoteRepository: Deleting sent message",
+		zap.Int64("timestamp", timestamp),
+		zap.Int("recipientsCount", len(recipients)))
+
+	for _, recipient := range recipients {
+		body := map[string]interface{}{"recipient": recipient, "timestamp": timestamp}
+		if err := r.do(http.MethodDelete, "/v1/remote-delete/"+url.PathEscape(number), body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive receives messages via Signal. The REST API has to run in normal or native mode for it, in
+// json-rpc mode it only pushes received messages over a websocket.
+func (r *RemoteRepository) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]domainSignal.ReceivedMessage, error) {
+	r.Logger.Info("RemoteRepository: Receiving messages", zap.String("number", number))
+
+	query := url.Values{}
+	query.Set("timeout", strconv.FormatInt(timeout, 10))
+	query.Set("ignore_attachments", strconv.FormatBool(ignoreAttachments))
+	query.Set("ignore_stories", strconv.FormatBool(ignoreStories))
+	if maxMessages > 0 {
+		query.Set("max_messages", strconv.FormatInt(maxMessages, 10))
+	}
+	query.Set("send_read_receipts", strconv.FormatBool(sendReadReceipts))
+
+	var lines []json.RawMessage
+	if err := r.do(http.MethodGet, "/v1/receive/"+url.PathEscape(number)+"?"+query.Encode(), nil, &lines); err != nil {
+		return nil, err
+	}
+
+	receivedMessages := []domainSignal.ReceivedMessage{}
+	for _, line := range lines {
+		receivedMessage, err := ParseReceivedMessage(line)
+		if err != nil {
+			r.Logger.Warn("Skipping unparsable received message", zap.Error(err), zap.String("data", string(line)))
+			continue
+		}
+		receivedMessages = append(receivedMessages, *receivedMessage)
+	}
+	return receivedMessages, nil
+}
+
+// ResolveRecipients looks up the registration of phone numbers through the search endpoint of the REST API, it
+// has no endpoint to resolve usernames or phone number identities
+func (r *RemoteRepository) ResolveRecipients(number string, recipients []string) ([]domainSignal.ResolvedRecipient, error) {
+	r.Logger.Info("RemoteRepository: Resolving recipients", zap.String("number", number), zap.Int("recipientsCount", len(recipients)))
+
+	query := url.Values{}
+	for _, recipient := range recipients {
+		if strings.HasPrefix(recipient, usernamePrefix) || strings.HasPrefix(recipient, pniPrefix) {
+			return nil, errNotSupportedByRemote
+		}
+		query.Add("numbers", recipient)
+	}
+
+	results := []SearchResultEntry{}
+	if err := r.do(http.MethodGet, "/v1/search/"+url.PathEscape(number)+"?"+query.Encode(), nil, &results); err != nil {
+		return nil, err
+	}
+
+	resolved := make([]domainSignal.ResolvedRecipient, len(results))
+	for i, result := range results {
+		resolved[i] = domainSignal.ResolvedRecipient{Recipient: result.Number, Number: result.Number, Registered: result.Registered}
+	}
+	return resolved, nil
+}
+
+// CreateGroup creates a new Signal group
+func (r *RemoteRepository) CreateGroup(number string, name string, members []string, description string, editGroupPermission domainSignal.GroupPermission, addMembersPermission domainSignal.GroupPermission, groupLinkState domainSignal.GroupLinkState, expirationTime *int) (string, error) {
+	r.Logger.Info("RemoteRepository: Creating group",
+		zap.String("name", name),
+		zap.String("creator", number),
+		zap.Int("membersCount", len(members)))
+
+	body := map[string]interface{}{
+		"name":        name,
+		"members":     members,
+		"description": description,
+		"permissions": map[string]string{
+			"add_members": GroupPermission(addMembersPermission).String(),
+			"edit_group":  GroupPermission(editGroupPermission).String(),
+		},
+		"group_link": GroupLinkState(groupLinkState).String(),
+	}
+	if expirationTime != nil {
+		body["expiration_time"] = *expirationTime
+	}
+
+	var response struct {
+		Id string `json:"id"`
+	}
+	if err := r.do(http.MethodPost, "/v1/groups/"+url.PathEscape(number), body, &response); err != nil {
+		return "", err
+	}
+	return response.Id, nil
+}
+
+// GetGroups gets all Signal groups
+func (r *RemoteRepository) GetGroups(number string) ([]domainSignal.GroupEntry, error) {
+	r.Logger.Info("RemoteRepository: Getting all groups", zap.String("number", number))
+
+	groups := []GroupEntry{}
+	if err := r.do(http.MethodGet, "/v1/groups/"+url.PathEscape(number), nil, &groups); err != nil {
+		return nil, err
+	}
+
+	domainGroups := make([]domainSignal.GroupEntry, len(groups))
+	for i, group := range groups {
+		domainGroups[i] = toDomainGroupEntry(group)
+	}
+	return domainGroups, nil
+}
+
+// GetGroup gets a specific Signal group
+func (r *RemoteRepository) GetGroup(number string, groupId string) (*domainSignal.GroupEntry, error) {
+	r.Logger.Info("RemoteRepository: Getting group", zap.String("groupId", groupId))
+
+	var group GroupEntry
+	if err := r.do(http.MethodGet, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId), nil, &group); err != nil {
+		return nil, err
+	}
+	domainGroup := toDomainGroupEntry(group)
+	return &domainGroup, nil
+}
+
+// GetGroupMembers lists the members of a Signal group with the names of the contacts of the account
+func (r *RemoteRepository) GetGroupMembers(number string, groupId string) ([]domainSignal.GroupMember, error) {
+	r.Logger.Info("RemoteRepository: Getting group members", zap.String("groupId", groupId))
+
+	var group GroupEntry
+	if err := r.do(http.MethodGet, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId), nil, &group); err != nil {
+		return nil, err
+	}
+	contacts := []ListContactsResponse{}
+	if err := r.do(http.MethodGet, "/v1/contacts/"+url.PathEscape(number), nil, &contacts); err != nil {
+		return nil, err
+	}
+	return groupMembers(group.Members, contacts), nil
+}
+
+// UpdateGroup updates a Signal group
+func (r *RemoteRepository) UpdateGroup(number string, groupId string, avatar *string, description *string, name *string, expirationTime *int, groupLinkState *domainSignal.GroupLinkState) error {
+	r.Logger.Info("RemoteRepository: Updating group", zap.String("groupId", groupId))
+
+	body := map[string]interface{}{}
+	if avatar != nil {
+		body["base64_avatar"] = *avatar
+	}
+	if description != nil {
+		body["description"] = *description
+	}
+	if name != nil {
+		body["name"] = *name
+	}
+	if expirationTime != nil {
+		body["expiration_time"] = *expirationTime
+	}
+	if groupLinkState != nil {
+		body["group_link"] = GroupLinkState(*groupLinkState).String()
+	}
+	return r.do(http.MethodPut, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId), body, nil)
+}
+
+// DeleteGroup deletes a Signal group
+func (r *RemoteRepository) DeleteGroup(number string, groupId string) error {
+	r.Logger.Info("RemoteRepository: Deleting group", zap.String("groupId", groupId))
+	return r.do(http.MethodDelete, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId), nil, nil)
+}
+
+// AddMembersToGroup adds members to a Signal group
+func (r *RemoteRepository) AddMembersToGroup(number string, groupId string, members []string) error {
+	r.Logger.Info("RemoteRepository: Adding members to group",
+		zap.String("groupId", groupId),
+		zap.Int("membersCount", len(members)))
+	return r.do(http.MethodPost, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId)+"/members", map[string][]string{"members": members}, nil)
+}
+
+// RemoveMembersFromGroup removes members from a Signal group
+func (r *RemoteRepository) RemoveMembersFromGroup(number string, groupId string, members []string) error {
+	r.Logger.Info("RemoteRepository: Removing members from group",
+		zap.String("groupId", groupId),
+		zap.Int("membersCount", len(members)))
+	return r.do(http.MethodDelete, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId)+"/members", map[string][]string{"members": members}, nil)
+}
+
+// AddAdminsToGroup adds admins to a Signal group
+func (r *RemoteRepository) AddAdminsToGroup(number string, groupId string, admins []string) error {
+	r.Logger.Info("RemoteRepository: Adding admins to group",
+		zap.String("groupId", groupId),
+		zap.Int("adminsCount", len(admins)))
+	return r.do(http.MethodPost, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId)+"/admins", map[string][]string{"admins": admins}, nil)
+}
+
+// RemoveAdminsFromGroup removes admins from a Signal group
+func (r *RemoteRepository) RemoveAdminsFromGroup(number string, groupId string, admins []string) error {
+	r.Logger.Info("RemoteRepository: Removing admins from group",
+		zap.String("groupId", groupId),
+		zap.Int("adminsCount", len(admins)))
+	return r.do(http.MethodDelete, "/v1/groups/"+url.PathEscape(number)+"/"+url.PathEscape(groupId)+"/admins", map[string][]string{"admins": admins}, nil)
+}
+
+// GetGroupInviteLink isn't supported by the REST API
+func (r *RemoteRepository) GetGroupInviteLink(number string, groupId string) (string, error) {
+	return "", errNotSupportedByRemote
+}
+
+// ResetGroupInviteLink isn't supported by the REST API
+func (r *RemoteRepository) ResetGroupInviteLink(number string, groupId string) (string, error) {
+	return "", errNotSupportedByRemote
+}
+
+// GetGroupInviteLinkQrCode isn't supported by the REST API
+func (r *RemoteRepository) GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error) {
+	return nil, errNotSupportedByRemote
+}
+
+// JoinGroupByLink isn't supported by the REST API
+func (r *RemoteRepository) JoinGroupByLink(number string, uri string) (*domainSignal.JoinGroupResponse, error) {
+	return nil, errNotSupportedByRemote
+}
+
+// ListIdentities lists all Signal identities
+func (r *RemoteRepository) ListIdentities(number string) (*[]domainSignal.IdentityEntry, error) {
+	r.Logger.Info("RemoteRepository: Listing identities", zap.String("number", number))
+
+	identities := []IdentityEntry{}
+	if err := r.do(http.MethodGet, "/v1/identities/"+url.PathEscape(number), nil, &identities); err != nil {
+		return nil, err
+	}
+
+	domainIdentities := make([]domainSignal.IdentityEntry, len(identities))
+	for i, identity := range identities {
+		domainIdentities[i] = domainSignal.IdentityEntry{
+			Number:       identity.Number,
+			TrustLevel:   identity.Status,
+			SafetyNumber: identity.SafetyNumber,
+		}
+	}
+	return &domainIdentities, nil
+}
+
+// TrustIdentity trusts a Signal identity
+func (r *RemoteRepository) TrustIdentity(number string, numberToTrust string, verifiedSafetyNumber *string, trustAllKnownKeys *bool) error {
+	r.Logger.Info("RemoteRepository: Trusting identity",
+		zap.String("number", number),
+		zap.String("numberToTrust", numberToTrust))
+
+	body := map[string]interface{}{}
+	if verifiedSafetyNumber != nil {
+		body["verified_safety_number"] = *verifiedSafetyNumber
+	}
+	if trustAllKnownKeys != nil {
+		body["trust_all_known_keys"] = *trustAllKnownKeys
+	}
+	return r.do(http.MethodPut, "/v1/identities/"+url.PathEscape(number)+"/trust/"+url.PathEscape(numberToTrust), body, nil)
+}
+
+// GetQrCodeLink gets a QR code link for Signal as PNG
+func (r *RemoteRepository) GetQrCodeLink(deviceName string, qrCodeVersion int) ([]byte, error) {
+	r.Logger.Info("RemoteRepository: Getting QR code link",
+		zap.String("deviceName", deviceName),
+		zap.Int("qrCodeVersion", qrCodeVersion))
+
+	query := url.Values{}
+	query.Set("device_name", deviceName)
+	query.Set("qrcode_version", strconv.Itoa(qrCodeVersion))
+	return r.doRaw(http.MethodGet, "/v1/qrcodelink?"+query.Encode(), nil)
+}
+
+func toDomainGroupEntry(group GroupEntry) domainSignal.GroupEntry {
+	return domainSignal.GroupEntry{
+		ID:                group.Id,
+		Name:              group.Name,
+		Description:       group.Description,
+		Members:           group.Members,
+		Admins:            group.Admins,
+		BlockedMembers:    []string{}, // Not available in the REST API
+		PendingMembers:    group.PendingInvites,
+		RequestingMembers: group.PendingRequests,
+		GroupLinkState:    domainSignal.DefaultGroupLinkState, // Not available in the REST API
+	}
+}
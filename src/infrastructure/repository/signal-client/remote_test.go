@@ -0,0 +1,182 @@
+package signal_client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRemoteRepository(t *testing.T, handler http.HandlerFunc) *RemoteRepository {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	return NewRemoteSignalRepository(server.URL+"/", "secret", time.Second, loggerInstance)
+}
+
+func TestRemoteRepository_Send(t *testing.T) {
+	var sent domainSignal.SendRequest
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v2/send", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"timestamp":"1700000000000"}`))
+	})
+
+	request := domainSignal.SendRequest{
+		Number:     "+4999999",
+		Recipients: []string{"+4912345"},
+		Message:    "hello",
+		Mentions:   []domainSignal.MessageMention{{Start: 0, Length: 5, Author: "+4912345"}},
+	}
+	response, err := repository.Send(request)
+	require.NoError(t, err)
+	assert.Equal(t, []domainSignal.SendResponse{{Timestamp: 1700000000000}}, *response)
+	assert.Equal(t, request.Recipients, sent.Recipients)
+	assert.Equal(t, request.Mentions, sent.Mentions)
+}
+
+func TestRemoteRepository_SendRateLimited(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"rate limited","challenge_tokens":["token-1"]}`))
+	})
+
+	_, err := repository.Send(domainSignal.SendRequest{Number: "+4999999", Recipients: []string{"+4912345"}})
+	var rateLimitErr *domainSignal.RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Equal(t, []string{"token-1"}, rateLimitErr.ChallengeTokens)
+	assert.EqualError(t, err, "rate limited")
+}
+
+func TestRemoteRepository_RemoteDelete(t *testing.T) {
+	var deleted []string
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/v1/remote-delete/+4999999", r.URL.Path)
+		var body struct {
+			Recipient string `json:"recipient"`
+			Timestamp int64  `json:"timestamp"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, int64(1700000000000), body.Timestamp)
+		deleted = append(deleted, body.Recipient)
+		_, _ = w.Write([]byte(`{"timestamp":"1700000000001"}`))
+	})
+
+	require.NoError(t, repository.RemoteDelete("+4999999", []string{"+4912345", "group.abc"}, 1700000000000))
+	assert.Equal(t, []string{"+4912345", "group.abc"}, deleted)
+}
+
+func TestRemoteRepository_GetGroup(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/groups/+4999999/group.abc" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"no such group"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"group.abc","name":"On call","members":["+4912345"],"pending_invites":["+4954321"]}`))
+	})
+
+	group, err := repository.GetGroup("+4999999", "group.abc")
+	require.NoError(t, err)
+	assert.Equal(t, "group.abc", group.ID)
+	assert.Equal(t, []string{"+4912345"}, group.Members)
+	assert.Equal(t, []string{"+4954321"}, group.PendingMembers)
+
+	_, err = repository.GetGroup("+4999999", "group.other")
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestRemoteRepository_GetGroupMembers(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/groups/+4999999/group.abc":
+			_, _ = w.Write([]byte(`{"id":"group.abc","members":["+4912345","7c9e2b4a","+4954321"]}`))
+		case "/v1/contacts/+4999999":
+			_, _ = w.Write([]byte(`[{"number":"+4912345","uuid":"0d2f6a1e","name":"Alice"},` +
+				`{"number":"","uuid":"7c9e2b4a","profile":{"given_name":"Bob","lastname":"Jones"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	members, err := repository.GetGroupMembers("+4999999", "group.abc")
+	require.NoError(t, err)
+	assert.Equal(t, []domainSignal.GroupMember{
+		{Number: "+4912345", UUID: "0d2f6a1e", Name: "Alice"},
+		{UUID: "7c9e2b4a", Name: "Bob Jones"},
+		{Number: "+4954321"},
+	}, members)
+}
+
+func TestRemoteRepository_UnsupportedOperations(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	_, err := repository.JoinGroupByLink("+4999999", "https://signal.group/#abc")
+	assert.ErrorIs(t, err, errNotSupportedByRemote)
+	_, err = repository.GetGroupInviteLink("+4999999", "group.abc")
+	assert.ErrorIs(t, err, errNotSupportedByRemote)
+}
+
+func TestRemoteRepository_Receive(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/receive/+4999999", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("timeout"))
+		assert.Equal(t, "true", r.URL.Query().Get("ignore_stories"))
+		_, _ = w.Write([]byte(`[
+			{"account":"+4999999","envelope":{"source":"+4912345","timestamp":1700000000000,"dataMessage":{"timestamp":1700000000000,"message":"ack"}}},
+			"not a message"
+		]`))
+	})
+
+	receivedMessages, err := repository.Receive("+4999999", 1, false, true, 0, false)
+	require.NoError(t, err)
+	require.Len(t, receivedMessages, 1)
+	assert.Equal(t, "+4912345", receivedMessages[0].Envelope.Source)
+	assert.Equal(t, domainSignal.EnvelopeTypeDataMessage, receivedMessages[0].Envelope.Type())
+}
+
+func TestRemoteRepository_About(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/about", r.URL.Path)
+		_, _ = w.Write([]byte(`{"versions":["v1","v2"],"build":2,"mode":"normal","version":"0.80"}`))
+	})
+
+	about, err := repository.About()
+	require.NoError(t, err)
+	assert.Equal(t, "normal", about.Mode)
+	assert.Equal(t, "0.80", about.Version)
+}
+
+func TestRemoteRepository_ResolveRecipients(t *testing.T) {
+	repository := newTestRemoteRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/search/+4999999", r.URL.Path)
+		assert.Equal(t, []string{"+4912345", "+4954321"}, r.URL.Query()["numbers"])
+		_, _ = w.Write([]byte(`[{"number":"+4912345","registered":true},{"number":"+4954321","registered":false}]`))
+	})
+
+	resolved, err := repository.ResolveRecipients("+4999999", []string{"+4912345", "+4954321"})
+	require.NoError(t, err)
+	assert.Equal(t, []domainSignal.ResolvedRecipient{
+		{Recipient: "+4912345", Number: "+4912345", Registered: true},
+		{Recipient: "+4954321", Number: "+4954321"},
+	}, resolved)
+
+	// The REST API can't look up usernames
+	_, err = repository.ResolveRecipients("+4999999", []string{"u:alice.42"})
+	assert.ErrorIs(t, err, errNotSupportedByRemote)
+}
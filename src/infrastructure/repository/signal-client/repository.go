@@ -22,6 +22,11 @@ func (r *Repository) GetClient() *SignalClient {
 // NewSignalRepository creates a new Repository
 func NewSignalRepository(signalCliConfig string, attachmentTmpDir string, avatarTmpDir string, signalCliMode SignalCliMode, jsonRpc2ClientConfigPath string, signalCliApiConfigPath string, receiveWebhookUrl string, loggerInstance *logger.Logger) domainSignal.ISignalService {
 	client := NewSignalClient(signalCliConfig, attachmentTmpDir, avatarTmpDir, signalCliMode, jsonRpc2ClientConfigPath, signalCliApiConfigPath, receiveWebhookUrl, loggerInstance)
+	return NewSignalRepositoryWithClient(client, loggerInstance)
+}
+
+// NewSignalRepositoryWithClient creates a new Repository for a SignalClient that is already set up
+func NewSignalRepositoryWithClient(client *SignalClient, loggerInstance *logger.Logger) *Repository {
 	return &Repository{
 		client: client,
 		Logger: loggerInstance,
@@ -52,31 +57,45 @@ func (r *Repository) GetAccounts() ([]string, error) {
 	return r.client.GetAccounts()
 }
 
-// Send sends a message via Signal
-func (r *Repository) Send(number string, message string, recipients []string, attachments []string, isGroup bool) (*domainSignal.SendResponse, error) {
-	r.Logger.Info("Repository: Sending message",
-		zap.String("from", number),
-		zap.Int("recipientsCount", len(recipients)),
-		zap.Int("attachmentsCount", len(attachments)),
-		zap.Bool("isGroup", isGroup))
+// SetPin sets the registration lock PIN of a Signal number
+func (r *Repository) SetPin(number string, registrationLockPin string) error {
+	r.Logger.Info("Repository: Setting registration lock pin", zap.String("number", number))
+	return r.client.SetPin(number, registrationLockPin)
+}
 
-	response, err := r.client.SendV1(number, message, recipients, attachments, isGroup)
-	if err != nil {
-		return nil, err
-	}
+// RemovePin removes the registration lock PIN of a Signal number
+func (r *Repository) RemovePin(number string) error {
+	r.Logger.Info("Repository: Removing registration lock pin", zap.String("number", number))
+	return r.client.RemovePin(number)
+}
 
-	// Convert from internal SendResponse to domain SendResponse
-	return &domainSignal.SendResponse{
-		Timestamp: response.Timestamp,
-	}, nil
+// SubmitRateLimitChallenge submits the solved captcha of a rate limit challenge
+func (r *Repository) SubmitRateLimitChallenge(number string, challengeToken string, captcha string) error {
+	r.Logger.Info("Repository: Submitting rate limit challenge", zap.String("number", number))
+	return r.client.SubmitRateLimitChallenge(number, challengeToken, captcha)
+}
+
+// Send sends a message via Signal
+func (r *Repository) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	r.Logger.Info("Repository: Sending message",
+		zap.String("from", request.Number),
+		zap.Int("recipientsCount", len(request.Recipients)),
+		zap.Int("attachmentsCount", len(request.Base64Attachments)))
+	return r.client.Send(request)
 }
 
 // Receive receives messages via Signal
-func (r *Repository) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) (string, error) {
+func (r *Repository) Receive(number string, timeout int64, ignoreAttachments bool, ignoreStories bool, maxMessages int64, sendReadReceipts bool) ([]domainSignal.ReceivedMessage, error) {
 	r.Logger.Info("Repository: Receiving messages", zap.String("number", number))
 	return r.client.Receive(number, timeout, ignoreAttachments, ignoreStories, maxMessages, sendReadReceipts)
 }
 
+// ResolveRecipients looks up the Signal accounts of recipients
+func (r *Repository) ResolveRecipients(number string, recipients []string) ([]domainSignal.ResolvedRecipient, error) {
+	r.Logger.Info("Repository: Resolving recipients", zap.String("number", number), zap.Int("recipientsCount", len(recipients)))
+	return r.client.ResolveRecipients(number, recipients)
+}
+
 // CreateGroup creates a new Signal group
 func (r *Repository) CreateGroup(number string, name string, members []string, description string, editGroupPermission domainSignal.GroupPermission, addMembersPermission domainSignal.GroupPermission, groupLinkState domainSignal.GroupLinkState, expirationTime *int) (string, error) {
 	r.Logger.Info("Repository: Creating group",
@@ -145,6 +164,12 @@ func (r *Repository) GetGroup(number string, groupId string) (*domainSignal.Grou
 	return domainGroup, nil
 }
 
+// GetGroupMembers lists the members of a Signal group with their contact names
+func (r *Repository) GetGroupMembers(number string, groupId string) ([]domainSignal.GroupMember, error) {
+	r.Logger.Info("Repository: Getting group members", zap.String("groupId", groupId))
+	return r.client.GetGroupMembers(number, groupId)
+}
+
 // UpdateGroup updates a Signal group
 func (r *Repository) UpdateGroup(number string, groupId string, avatar *string, description *string, name *string, expirationTime *int, groupLinkState *domainSignal.GroupLinkState) error {
 	r.Logger.Info("Repository: Updating group", zap.String("groupId", groupId))
@@ -197,6 +222,38 @@ func (r *Repository) RemoveAdminsFromGroup(number string, groupId string, admins
 	return r.client.RemoveAdminsFromGroup(number, groupId, admins)
 }
 
+// RemoteDelete deletes a sent message for its recipients
+func (r *Repository) RemoteDelete(number string, recipients []string, timestamp int64) error {
+	r.Logger.Info("Repository: Deleting sent message",
+		zap.Int64("timestamp", timestamp),
+		zap.Int("recipientsCount", len(recipients)))
+	return r.client.RemoteDelete(number, recipients, timestamp)
+}
+
+// GetGroupInviteLink gets the invite link of a Signal group
+func (r *Repository) GetGroupInviteLink(number string, groupId string) (string, error) {
+	r.Logger.Info("Repository: Getting group invite link", zap.String("groupId", groupId))
+	return r.client.GetGroupInviteLink(number, groupId)
+}
+
+// ResetGroupInviteLink replaces the invite link of a Signal group
+func (r *Repository) ResetGroupInviteLink(number string, groupId string) (string, error) {
+	r.Logger.Info("Repository: Resetting group invite link", zap.String("groupId", groupId))
+	return r.client.ResetGroupInviteLink(number, groupId)
+}
+
+// GetGroupInviteLinkQrCode gets the invite link of a Signal group as a QR code
+func (r *Repository) GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error) {
+	r.Logger.Info("Repository: Getting group invite link QR code", zap.String("groupId", groupId))
+	return r.client.GetGroupInviteLinkQrCode(number, groupId, qrCodeVersion)
+}
+
+// JoinGroupByLink joins a Signal group from an invite link
+func (r *Repository) JoinGroupByLink(number string, uri string) (*domainSignal.JoinGroupResponse, error) {
+	r.Logger.Info("Repository: Joining group by link", zap.String("number", number))
+	return r.client.JoinGroupByLink(number, uri)
+}
+
 // ListIdentities lists all Signal identities
 func (r *Repository) ListIdentities(number string) (*[]domainSignal.IdentityEntry, error) {
 	r.Logger.Info("Repository: Listing identities", zap.String("number", number))
@@ -0,0 +1,41 @@
+package signal_client
+
+import (
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	ds "go-multi-chat-api/src/infrastructure/datastructs"
+	utils "go-multi-chat-api/src/infrastructure/utils"
+)
+
+// SendRequest is a message sent through Signal, see domainSignal.SendRequest
+type SendRequest = domainSignal.SendRequest
+
+// withSendDefaults returns the request with the configured defaults applied to the options that aren't set
+func withSendDefaults(request SendRequest) SendRequest {
+	if request.TextMode == nil && utils.GetEnv("DEFAULT_SIGNAL_TEXT_MODE", "normal") == "styled" {
+		styled := "styled"
+		request.TextMode = &styled
+	}
+	return request
+}
+
+// cliSendRequest builds the signal-cli request sending the message to recipients of one type
+func cliSendRequest(request *SendRequest, recipients []string, recipientType ds.RecpType) ds.SignalCliSendRequest {
+	return ds.SignalCliSendRequest{
+		Number:            request.Number,
+		Message:           request.Message,
+		Recipients:        recipients,
+		Base64Attachments: request.Base64Attachments,
+		RecipientType:     recipientType,
+		Sticker:           request.Sticker,
+		Mentions:          request.Mentions,
+		QuoteTimestamp:    request.QuoteTimestamp,
+		QuoteAuthor:       request.QuoteAuthor,
+		QuoteMessage:      request.QuoteMessage,
+		QuoteMentions:     request.QuoteMentions,
+		TextMode:          request.TextMode,
+		EditTimestamp:     request.EditTimestamp,
+		NotifySelf:        request.NotifySelf,
+		LinkPreview:       request.LinkPreview,
+		ViewOnce:          request.ViewOnce,
+	}
+}
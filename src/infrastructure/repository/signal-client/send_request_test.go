@@ -0,0 +1,47 @@
+package signal_client
+
+import (
+	"testing"
+
+	ds "go-multi-chat-api/src/infrastructure/datastructs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSendDefaults(t *testing.T) {
+	request := SendRequest{Number: "+4999999", Recipients: []string{"+4912345"}, Message: "hello"}
+
+	t.Setenv("DEFAULT_SIGNAL_TEXT_MODE", "normal")
+	assert.Nil(t, withSendDefaults(request).TextMode)
+
+	t.Setenv("DEFAULT_SIGNAL_TEXT_MODE", "styled")
+	withDefaults := withSendDefaults(request)
+	if assert.NotNil(t, withDefaults.TextMode) {
+		assert.Equal(t, "styled", *withDefaults.TextMode)
+	}
+	// The request itself is left unchanged
+	assert.Nil(t, request.TextMode)
+
+	// An explicit text mode wins over the default
+	normal := "normal"
+	request.TextMode = &normal
+	assert.Equal(t, "normal", *withSendDefaults(request).TextMode)
+}
+
+func TestCliSendRequest(t *testing.T) {
+	quoteTimestamp := int64(1000)
+	request := SendRequest{
+		Number:         "+4999999",
+		Message:        "hello",
+		Recipients:     []string{"+4912345", "+4954321"},
+		Mentions:       []ds.MessageMention{{Start: 0, Length: 5, Author: "+4912345"}},
+		QuoteTimestamp: &quoteTimestamp,
+	}
+
+	cliRequest := cliSendRequest(&request, []string{"+4954321"}, ds.Number)
+	assert.Equal(t, "+4999999", cliRequest.Number)
+	assert.Equal(t, []string{"+4954321"}, cliRequest.Recipients)
+	assert.Equal(t, ds.Number, cliRequest.RecipientType)
+	assert.Equal(t, request.Mentions, cliRequest.Mentions)
+	assert.Equal(t, &quoteTimestamp, cliRequest.QuoteTimestamp)
+}
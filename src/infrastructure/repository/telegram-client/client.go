@@ -0,0 +1,208 @@
+package telegram_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+// DefaultAPIURL is the base URL of the Telegram Bot API
+const DefaultAPIURL = "https://api.telegram.org"
+
+// chatPattern matches the recipients a bot sends to: numeric chat ids, negative for groups and channels, and the
+// usernames of public channels and supergroups
+var chatPattern = regexp.MustCompile(`^(-?[0-9]{1,20}|@[A-Za-z][A-Za-z0-9_]{3,31})$`)
+
+// Config is the Telegram setup of a user, stored in the config of their telegram user provider
+type Config struct {
+	BotToken string `json:"bot_token"`
+}
+
+// ParseConfig reads the Telegram setup from the config of a user provider
+func ParseConfig(userProviderConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(userProviderConfig) != "" {
+		if err := json.Unmarshal([]byte(userProviderConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid telegram config: %w", err)
+		}
+	}
+	if config.BotToken == "" {
+		return Config{}, errors.New("telegram config needs a bot_token")
+	}
+	return config, nil
+}
+
+// Error is an error answered by the Bot API
+type Error struct {
+	StatusCode  int
+	Description string
+	// RetryAfter is the number of seconds to wait when Telegram rate limited the bot
+	RetryAfter int
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("telegram answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("telegram answered %d: %s", e.StatusCode, e.Description)
+}
+
+// ErrorCode classifies an error of a send by the status Telegram answered with. It returns an empty code for
+// other errors.
+func ErrorCode(err error) string {
+	var telegramErr *Error
+	if !errors.As(err, &telegramErr) {
+		return ""
+	}
+	switch {
+	case telegramErr.StatusCode == http.StatusUnauthorized:
+		return domainProvider.ErrorCodeAuthFailed
+	case telegramErr.StatusCode == http.StatusForbidden:
+		// the recipient blocked the bot, deleted their account or removed the bot from the chat
+		return domainProvider.ErrorCodeInvalidRecipient
+	case telegramErr.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(telegramErr.Description), "chat not found"):
+		return domainProvider.ErrorCodeInvalidRecipient
+	case telegramErr.StatusCode == http.StatusTooManyRequests:
+		return domainProvider.ErrorCodeRateLimited
+	case telegramErr.StatusCode >= 500:
+		return domainProvider.ErrorCodeNetwork
+	}
+	return domainProvider.ErrorCodeUnknown
+}
+
+// SendResult is the message a text was sent as to one recipient
+type SendResult struct {
+	Recipient string `json:"recipient"`
+	ChatID    int64  `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+}
+
+// Client calls the Telegram Bot API with the bot token of a user
+type Client struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewClient creates a new client calling the Bot API at apiURL
+func NewClient(apiURL string, timeout time.Duration) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		client: httpclient.New(timeout),
+	}
+}
+
+// message is the part of a sent message the client reads, see https://core.telegram.org/bots/api#message
+type message struct {
+	MessageID int64 `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// Send sends a text to every recipient, a chat id or the @username of a public channel. It stops at the first
+// recipient the message couldn't be sent to and returns the messages sent until then.
+func (c *Client) Send(config Config, recipients []string, text string) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		if !chatPattern.MatchString(recipient) {
+			return results, &domainProvider.RecipientError{Description: fmt.Sprintf("recipient %q is not a telegram chat id or @channel", recipient)}
+		}
+		var sent message
+		if err := c.call(config, "sendMessage", map[string]interface{}{"chat_id": recipient, "text": text}, &sent); err != nil {
+			return results, fmt.Errorf("couldn't send to %s: %w", recipient, err)
+		}
+		results = append(results, SendResult{Recipient: recipient, ChatID: sent.Chat.ID, MessageID: sent.MessageID})
+	}
+	return results, nil
+}
+
+// Edit replaces the text of the messages a text was sent as. It stops at the first message that couldn't be
+// edited and returns the messages edited until then.
+func (c *Client) Edit(config Config, sent []SendResult, text string) ([]SendResult, error) {
+	results := make([]SendResult, 0, len(sent))
+	for _, result := range sent {
+		request := map[string]interface{}{"chat_id": result.ChatID, "message_id": result.MessageID, "text": text}
+		if err := c.call(config, "editMessageText", request, nil); err != nil {
+			return results, fmt.Errorf("couldn't edit the message to %s: %w", result.Recipient, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// VerifyCredentials fetches the bot with its token, without sending anything
+func (c *Client) VerifyCredentials(config Config) error {
+	if err := c.call(config, "getMe", nil, nil); err != nil {
+		return fmt.Errorf("couldn't authenticate the bot: %w", err)
+	}
+	return nil
+}
+
+// call calls a method of the Bot API and reads its result into result, see https://core.telegram.org/bots/api#making-requests
+func (c *Client) call(config Config, method string, body interface{}, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(http.MethodPost, c.apiURL+"/bot"+config.BotToken+"/"+method, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		// The URL holds the bot token, it isn't passed on in the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("couldn't call %s: %w", method, urlErr.Err)
+		}
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	var answer struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	_ = json.Unmarshal(responseBody, &answer)
+	if response.StatusCode < 200 || response.StatusCode >= 300 || !answer.OK {
+		return &Error{StatusCode: response.StatusCode, Description: answer.Description, RetryAfter: answer.Parameters.RetryAfter}
+	}
+	if result != nil {
+		if err := json.Unmarshal(answer.Result, result); err != nil {
+			return fmt.Errorf("couldn't read the %s result: %w", method, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package telegram_client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{"bot_token":"123:secret","webhook_enabled":true}`)
+	require.NoError(t, err)
+	assert.Equal(t, Config{BotToken: "123:secret"}, config)
+
+	_, err = ParseConfig(`{"webhook_enabled":true}`)
+	assert.Error(t, err)
+}
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:secret/sendMessage", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "hello", body["text"])
+
+		switch body["chat_id"] {
+		case "-1001234567890":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":7,"chat":{"id":-1001234567890}}}`))
+		case "@alerts":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":8,"chat":{"id":-1009876543210}}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	config := Config{BotToken: "123:secret"}
+	results, err := client.Send(config, []string{"-1001234567890", "@alerts"}, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []SendResult{
+		{Recipient: "-1001234567890", ChatID: -1001234567890, MessageID: 7},
+		{Recipient: "@alerts", ChatID: -1009876543210, MessageID: 8},
+	}, results)
+
+	results, err = client.Send(config, []string{"@alerts", "42"}, "hello")
+	require.Error(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(err))
+	assert.Contains(t, err.Error(), "bot was blocked by the user")
+
+	_, err = client.Send(config, []string{"+491701234567"}, "hello")
+	var recipientErr *domainProvider.RecipientError
+	assert.True(t, errors.As(err, &recipientErr))
+}
+
+func TestClient_EditAndVerifyCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bot123:secret/editMessageText":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, float64(7), body["message_id"])
+			assert.Equal(t, "changed", body["text"])
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":7,"chat":{"id":42}}}`))
+		case "/bot123:secret/getMe":
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":123,"is_bot":true}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, time.Second)
+	sent := []SendResult{{Recipient: "42", ChatID: 42, MessageID: 7}}
+	results, err := client.Edit(Config{BotToken: "123:secret"}, sent, "changed")
+	require.NoError(t, err)
+	assert.Equal(t, sent, results)
+
+	assert.NoError(t, client.VerifyCredentials(Config{BotToken: "123:secret"}))
+	err = client.VerifyCredentials(Config{BotToken: "123:wrong"})
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(err))
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(&Error{StatusCode: http.StatusTooManyRequests, RetryAfter: 5}))
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Description: "Bad Request: chat not found"}))
+	assert.Equal(t, domainProvider.ErrorCodeUnknown, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Description: "Bad Request: message is too long"}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&Error{StatusCode: http.StatusBadGateway}))
+	assert.Empty(t, ErrorCode(errors.New("connection refused")))
+}
@@ -0,0 +1,613 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// pendingBatchSize is the number of messages GetPendingMessages and GetExpiredAcks return at most, like the MySQL
+// repository
+const pendingBatchSize = 1000
+
+// MessageTransactionRepository keeps message transactions in memory, it implements
+// provider.MessageTransactionRepositoryInterface. Status changes follow the message status state machine like the
+// MySQL repository: Update rejects an invalid transition with a Conflict, the batch updates leave messages that
+// can't make it alone.
+type MessageTransactionRepository struct {
+	// Now is the time of created and updated rows and of the due retries, holds and acknowledgements, time.Now
+	// when nil
+	Now func() time.Time
+
+	mu       sync.Mutex
+	messages map[int]*domainProvider.MessageTransaction
+	lastID   int
+}
+
+var _ providerRepo.MessageTransactionRepositoryInterface = (*MessageTransactionRepository)(nil)
+
+// NewMessageTransactionRepository creates a MessageTransactionRepository holding the given messages
+func NewMessageTransactionRepository(messages ...domainProvider.MessageTransaction) *MessageTransactionRepository {
+	r := &MessageTransactionRepository{messages: make(map[int]*domainProvider.MessageTransaction)}
+	for i := range messages {
+		if _, err := r.Create(&messages[i]); err != nil {
+			panic(fmt.Sprintf("testsupport: couldn't create message transaction of user %d: %v", messages[i].UserID, err))
+		}
+	}
+	return r
+}
+
+func (r *MessageTransactionRepository) Create(messageTransactionDomain *domainProvider.MessageTransaction) (*domainProvider.MessageTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(*messageTransactionDomain)
+}
+
+func (r *MessageTransactionRepository) CreateBatch(messageTransactions []domainProvider.MessageTransaction) (*[]domainProvider.MessageTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The batch is created in one transaction, a duplicate ID creates none of the messages
+	for _, message := range messageTransactions {
+		if _, exists := r.messages[message.ID]; message.ID != 0 && exists {
+			return nil, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	}
+	created := make([]domainProvider.MessageTransaction, len(messageTransactions))
+	for i, message := range messageTransactions {
+		stored, err := r.create(message)
+		if err != nil {
+			return nil, err
+		}
+		created[i] = *stored
+	}
+	return &created, nil
+}
+
+func (r *MessageTransactionRepository) create(message domainProvider.MessageTransaction) (*domainProvider.MessageTransaction, error) {
+	id, exists := assignID(r.messages, &r.lastID, message.ID)
+	if exists {
+		return &domainProvider.MessageTransaction{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	message.ID = id
+	now := clock(r.Now)
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = now
+	}
+	if message.UpdatedAt.IsZero() {
+		message.UpdatedAt = now
+	}
+	r.messages[message.ID] = &message
+	stored := message
+	return &stored, nil
+}
+
+func (r *MessageTransactionRepository) GetByID(id int) (*domainProvider.MessageTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[id]
+	if !ok {
+		return &domainProvider.MessageTransaction{}, notFound()
+	}
+	found := *message
+	return &found, nil
+}
+
+func (r *MessageTransactionRepository) GetUserMessageTransactions(userID int) (*[]domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.UserID == userID
+	})
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+	return &messages, nil
+}
+
+func (r *MessageTransactionRepository) GetAfterID(afterID int, userID int, limit int) (*[]domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.ID > afterID && (userID == 0 || message.UserID == userID)
+	})
+	messages = messages[:min(limit, len(messages))]
+	return &messages, nil
+}
+
+func (r *MessageTransactionRepository) Update(id int, messageTransactionMap map[string]interface{}) (*domainProvider.MessageTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[id]
+	if !ok {
+		return &domainProvider.MessageTransaction{}, notFound()
+	}
+	updateData := columnsOf(messageTransactionMap, providerRepo.ColumnsMessageTransactionMapping)
+	if status, statusChanged := updateData["status"]; statusChanged {
+		to, _ := status.(string)
+		if err := domainProvider.ValidateTransition(message.Status, to); err != nil {
+			return &domainProvider.MessageTransaction{}, domainErrors.NewAppError(err, domainErrors.Conflict)
+		}
+	}
+	updated, err := r.update(message, updateData)
+	if err != nil {
+		return &domainProvider.MessageTransaction{}, err
+	}
+	return updated, nil
+}
+
+func (r *MessageTransactionRepository) UpdateBatch(ids []int, messageTransactionMap map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	updateData := columnsOf(messageTransactionMap, providerRepo.ColumnsMessageTransactionMapping)
+	status, statusChanged := updateData["status"]
+	to, _ := status.(string)
+	for _, id := range ids {
+		message, ok := r.messages[id]
+		if !ok || (statusChanged && !domainProvider.CanTransition(message.Status, to)) {
+			continue
+		}
+		if _, err := r.update(message, updateData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update applies an update map to a stored message and returns a copy of the result
+func (r *MessageTransactionRepository) update(message *domainProvider.MessageTransaction, updateData map[string]interface{}) (*domainProvider.MessageTransaction, error) {
+	updated := *message
+	if err := applyUpdate(&updated, updateData); err != nil {
+		return nil, err
+	}
+	if _, ok := updateData["updated_at"]; !ok {
+		updated.UpdatedAt = clock(r.Now)
+	}
+	*message = updated
+	return &updated, nil
+}
+
+func (r *MessageTransactionRepository) GetFailedMessagesForRetry() (*[]domainProvider.MessageTransaction, error) {
+	now := clock(r.Now)
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == domainProvider.MessageStatusFailed && message.NextRetryAt != nil && !message.NextRetryAt.After(now)
+	})
+	return &messages, nil
+}
+
+// GetPendingMessages marks up to 1000 pending messages as processing. Like the MySQL repository it returns them as
+// they were read, before they were marked.
+func (r *MessageTransactionRepository) GetPendingMessages() (*[]domainProvider.MessageTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := clock(r.Now)
+	messages := []domainProvider.MessageTransaction{}
+	for _, id := range sortedIDs(r.messages) {
+		message := r.messages[id]
+		if message.Status != domainProvider.MessageStatusPending || message.Processing {
+			continue
+		}
+		messages = append(messages, *message)
+		processedAt := now
+		message.Processing, message.ProcessedAt, message.UpdatedAt = true, &processedAt, now
+		if len(messages) == pendingBatchSize {
+			break
+		}
+	}
+	return &messages, nil
+}
+
+func (r *MessageTransactionRepository) GetUndeliveredMessages(sentBefore time.Time) (*[]domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == domainProvider.MessageStatusSuccess && !message.Processing &&
+			!message.UpdatedAt.After(sentBefore) && !message.CreatedAt.After(sentBefore)
+	})
+	return &messages, nil
+}
+
+// MoveToHistory copies a message transaction to the history, the message transaction itself is kept
+func (r *MessageTransactionRepository) MoveToHistory(id int, historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface) error {
+	message, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	history := historyOf(message, clock(r.Now))
+	_, err = historyRepository.Create(&history)
+	return err
+}
+
+func (r *MessageTransactionRepository) MoveToHistoryBatch(ids []int, historyRepository providerRepo.MessageTransactionHistoryRepositoryInterface) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := clock(r.Now)
+	var histories []domainProvider.MessageTransactionHistory
+	for _, message := range r.find(func(message *domainProvider.MessageTransaction) bool { return containsID(ids, message.ID) }) {
+		histories = append(histories, historyOf(&message, now))
+	}
+	return historyRepository.CreateBatch(histories)
+}
+
+// historyOf returns the history entry of a processed message transaction
+func historyOf(message *domainProvider.MessageTransaction, now time.Time) domainProvider.MessageTransactionHistory {
+	return domainProvider.MessageTransactionHistory{
+		MessageID:    message.ID,
+		UserID:       message.UserID,
+		ProviderID:   message.ProviderID,
+		Recipients:   message.Recipients,
+		Message:      message.Message,
+		Tags:         message.Tags,
+		RequestData:  message.RequestData,
+		ResponseData: message.ResponseData,
+		Status:       message.Status,
+		ErrorMessage: message.ErrorMessage,
+		ErrorCode:    message.ErrorCode,
+		RetryCount:   message.RetryCount,
+		Segments:     message.Segments,
+		ProcessedAt:  message.UpdatedAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func (r *MessageTransactionRepository) CountUserMessagesForToday(userID int) (int, error) {
+	startOfDay, endOfDay := today(clock(r.Now))
+	return len(r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.UserID == userID && !message.CreatedAt.Before(startOfDay) && message.CreatedAt.Before(endOfDay)
+	})), nil
+}
+
+func (r *MessageTransactionRepository) GetRecipientSendTimes(userID int, recipients []string, since time.Time) (map[string][]time.Time, error) {
+	sendTimes := make(map[string][]time.Time)
+	if len(recipients) == 0 {
+		return sendTimes, nil
+	}
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.UserID == userID && !message.CreatedAt.Before(since) && message.RetryCount == 0 &&
+			message.Status != domainProvider.MessageStatusCancelled
+	})
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	for _, message := range messages {
+		var sentTo []string
+		if err := json.Unmarshal([]byte(message.Recipients), &sentTo); err != nil {
+			continue
+		}
+		for _, recipient := range sentTo {
+			if contains(recipients, recipient) {
+				sendTimes[recipient] = append(sendTimes[recipient], message.CreatedAt)
+			}
+		}
+	}
+	return sendTimes, nil
+}
+
+func (r *MessageTransactionRepository) CountProviderMessagesSentToday(providerID int) (int, error) {
+	startOfDay, endOfDay := today(clock(r.Now))
+	return len(r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.ProviderID == providerID &&
+			(message.Status == domainProvider.MessageStatusSuccess || message.Status == domainProvider.MessageStatusDelivered) &&
+			!message.UpdatedAt.Before(startOfDay) && message.UpdatedAt.Before(endOfDay) && message.CreatedAt.Before(endOfDay)
+	})), nil
+}
+
+func (r *MessageTransactionRepository) ReleaseHeldMessages() (int, error) {
+	now := clock(r.Now)
+	return r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return (message.Status == domainProvider.MessageStatusHeld || message.Status == domainProvider.MessageStatusHeldSchedule) &&
+			message.NextRetryAt != nil && !message.NextRetryAt.After(now)
+	}, func(message *domainProvider.MessageTransaction) {
+		message.Status, message.Processing = domainProvider.MessageStatusPending, false
+	}), nil
+}
+
+func (r *MessageTransactionRepository) ReleaseRateLimitedMessages() (int, error) {
+	return r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == domainProvider.MessageStatusRateLimited
+	}, func(message *domainProvider.MessageTransaction) {
+		message.Status, message.Processing = domainProvider.MessageStatusPending, false
+	}), nil
+}
+
+func (r *MessageTransactionRepository) MarkSendStarted(id int) (bool, error) {
+	now := clock(r.Now)
+	changed := r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return message.ID == id && message.SendStartedAt == nil
+	}, func(message *domainProvider.MessageTransaction) {
+		message.SendStartedAt = &now
+	})
+	return changed == 1, nil
+}
+
+func (r *MessageTransactionRepository) ReleaseProcessing(ids []int) error {
+	r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return containsID(ids, message.ID) && message.SendStartedAt == nil
+	}, func(message *domainProvider.MessageTransaction) {
+		message.Processing = false
+	})
+	return nil
+}
+
+func (r *MessageTransactionRepository) CountPendingMessages() (int, error) {
+	return len(r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == domainProvider.MessageStatusPending
+	})), nil
+}
+
+func (r *MessageTransactionRepository) GetQueueMetrics() (*domainProvider.QueueMetrics, error) {
+	metrics := &domainProvider.QueueMetrics{}
+	for _, message := range r.find(func(*domainProvider.MessageTransaction) bool { return true }) {
+		switch {
+		case message.Status != domainProvider.MessageStatusPending && message.Status != domainProvider.MessageStatusFailed &&
+			message.Status != domainProvider.MessageStatusHeld && message.Status != domainProvider.MessageStatusHeldSchedule &&
+			message.Status != domainProvider.MessageStatusRateLimited:
+			continue
+		case message.Processing:
+			metrics.Processing++
+		case message.Status == domainProvider.MessageStatusPending:
+			metrics.Pending++
+			if metrics.OldestPendingAt == nil || message.CreatedAt.Before(*metrics.OldestPendingAt) {
+				createdAt := message.CreatedAt
+				metrics.OldestPendingAt = &createdAt
+			}
+		case message.Status == domainProvider.MessageStatusFailed:
+			metrics.FailedAwaitingRetry++
+		default:
+			metrics.Held++
+		}
+	}
+	return metrics, nil
+}
+
+func (r *MessageTransactionRepository) GetStaleProcessingMessages(staleBefore time.Time) (*[]domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return stale(message, staleBefore) && !message.CreatedAt.After(staleBefore)
+	})
+	return &messages, nil
+}
+
+func (r *MessageTransactionRepository) RecoverStaleMessage(id int, staleBefore time.Time, messageTransactionMap map[string]interface{}) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[id]
+	if !ok || !stale(message, staleBefore) {
+		return false, nil
+	}
+	updateData := columnsOf(messageTransactionMap, providerRepo.ColumnsMessageTransactionMapping)
+	if status, statusChanged := updateData["status"]; statusChanged {
+		if to, _ := status.(string); !domainProvider.CanTransition(message.Status, to) {
+			return false, nil
+		}
+	}
+	if _, err := r.update(message, updateData); err != nil {
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	return true, nil
+}
+
+// stale reports whether a message is stuck in processing since before staleBefore
+func stale(message *domainProvider.MessageTransaction, staleBefore time.Time) bool {
+	return message.Processing && message.ProcessedAt != nil && !message.ProcessedAt.After(staleBefore)
+}
+
+func (r *MessageTransactionRepository) GetPendingAckByToken(ackToken string) (*domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.AckStatus == "pending" && message.AckToken == ackToken
+	})
+	if len(messages) == 0 {
+		return nil, notFound()
+	}
+	return &messages[0], nil
+}
+
+func (r *MessageTransactionRepository) GetLatestPendingAckForRecipient(recipient string, ackKeyword string) (*domainProvider.MessageTransaction, error) {
+	return r.latest(func(message *domainProvider.MessageTransaction) bool {
+		return message.AckStatus == "pending" && message.AckKeyword == ackKeyword && sentTo(message, recipient)
+	})
+}
+
+func (r *MessageTransactionRepository) GetLatestWithActionsForRecipient(recipient string, since time.Time) (*domainProvider.MessageTransaction, error) {
+	return r.latest(func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == domainProvider.MessageStatusSuccess && message.Actions != "" &&
+			!message.CreatedAt.Before(since) && sentTo(message, recipient)
+	})
+}
+
+func (r *MessageTransactionRepository) GetBySignalTimestamp(userID int, timestamp int64) (*domainProvider.MessageTransaction, error) {
+	timestampJSON := fmt.Sprintf(`"timestamp":%d}`, timestamp)
+	return r.latest(func(message *domainProvider.MessageTransaction) bool {
+		return message.UserID == userID && strings.Contains(message.ResponseData, timestampJSON)
+	})
+}
+
+// sentTo reports whether a recipient is in the JSON array of the recipients of a message, quoted like the LIKE
+// of the MySQL repository so a number doesn't match a longer one
+func sentTo(message *domainProvider.MessageTransaction, recipient string) bool {
+	recipientJSON, _ := json.Marshal(recipient)
+	return strings.Contains(message.Recipients, string(recipientJSON))
+}
+
+func (r *MessageTransactionRepository) AcknowledgeMessage(id int, acknowledgedBy string, acknowledgedAt time.Time) (bool, error) {
+	changed := r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return message.ID == id && message.AckStatus == "pending"
+	}, func(message *domainProvider.MessageTransaction) {
+		message.AckStatus, message.AcknowledgedBy, message.AcknowledgedAt = "acknowledged", acknowledgedBy, &acknowledgedAt
+	})
+	return changed == 1, nil
+}
+
+func (r *MessageTransactionRepository) GetExpiredAcks(now time.Time) (*[]domainProvider.MessageTransaction, error) {
+	messages := r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.AckStatus == "pending" && message.AckDeadline != nil && !message.AckDeadline.After(now)
+	})
+	messages = messages[:min(pendingBatchSize, len(messages))]
+	return &messages, nil
+}
+
+func (r *MessageTransactionRepository) ExpireAck(id int) (bool, error) {
+	changed := r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		return message.ID == id && message.AckStatus == "pending"
+	}, func(message *domainProvider.MessageTransaction) {
+		message.AckStatus = "expired"
+	})
+	return changed == 1, nil
+}
+
+func (r *MessageTransactionRepository) CountForBulk(filter domainProvider.BulkOperationFilter) (int, error) {
+	return len(r.find(func(message *domainProvider.MessageTransaction) bool { return matchesBulk(message, filter) })), nil
+}
+
+func (r *MessageTransactionRepository) GetIDsForBulk(filter domainProvider.BulkOperationFilter, afterID int, limit int) ([]int, error) {
+	var ids []int
+	for _, message := range r.find(func(message *domainProvider.MessageTransaction) bool {
+		return message.ID > afterID && matchesBulk(message, filter)
+	}) {
+		if len(ids) == limit {
+			break
+		}
+		ids = append(ids, message.ID)
+	}
+	return ids, nil
+}
+
+// matchesBulk reports whether a message is selected by the filter of a bulk operation
+func matchesBulk(message *domainProvider.MessageTransaction, filter domainProvider.BulkOperationFilter) bool {
+	return message.Status == filter.Status &&
+		(filter.ProviderID == 0 || message.ProviderID == filter.ProviderID) &&
+		(filter.UserID == 0 || message.UserID == filter.UserID) &&
+		(filter.From == nil || !message.CreatedAt.Before(*filter.From)) &&
+		(filter.To == nil || message.CreatedAt.Before(*filter.To))
+}
+
+func (r *MessageTransactionRepository) RequeueBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusPending); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return r.changeBatch(ids, func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == status && !message.Processing
+	}, requeue), nil
+}
+
+func (r *MessageTransactionRepository) CancelBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusCancelled); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return r.claimBatch(ids, status, domainProvider.MessageStatusCancelled, "cancelled by an admin"), nil
+}
+
+func (r *MessageTransactionRepository) SuspendBatch(ids []int, status string) ([]int, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusSuspended); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return r.claimBatch(ids, status, domainProvider.MessageStatusSuspended, "the user was deactivated"), nil
+}
+
+// claimBatch moves the messages of ids that still have the status and whose send wasn't started, or that failed,
+// to a status holding them, and claims their send like MarkSendStarted
+func (r *MessageTransactionRepository) claimBatch(ids []int, status string, to string, errorMessage string) []int {
+	now := clock(r.Now)
+	return r.changeBatch(ids, func(message *domainProvider.MessageTransaction) bool {
+		return message.Status == status && (message.SendStartedAt == nil || message.Status == domainProvider.MessageStatusFailed)
+	}, func(message *domainProvider.MessageTransaction) {
+		message.Status, message.ErrorMessage, message.Processing, message.NextRetryAt = to, errorMessage, false, nil
+		if message.SendStartedAt == nil {
+			message.SendStartedAt = &now
+		}
+	})
+}
+
+func (r *MessageTransactionRepository) Restore(id int, status string, messageTransactionMap map[string]interface{}) (bool, error) {
+	if err := domainProvider.ValidateTransition(status, domainProvider.MessageStatusPending); err != nil {
+		return false, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[id]
+	if !ok || message.Status != status || message.Processing {
+		return false, nil
+	}
+	updated := *message
+	if err := applyUpdate(&updated, columnsOf(messageTransactionMap, providerRepo.ColumnsMessageTransactionMapping)); err != nil {
+		return false, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	requeue(&updated)
+	updated.UpdatedAt = clock(r.Now)
+	*message = updated
+	return true, nil
+}
+
+// requeue moves a message back to pending and releases the claim of its send
+func requeue(message *domainProvider.MessageTransaction) {
+	message.Status, message.ErrorMessage, message.ErrorCode = domainProvider.MessageStatusPending, "", ""
+	message.NextRetryAt, message.SendStartedAt = nil, nil
+}
+
+// changeBatch changes the messages of ids still matching the condition and returns the IDs it changed
+func (r *MessageTransactionRepository) changeBatch(ids []int, matches func(message *domainProvider.MessageTransaction) bool, change func(message *domainProvider.MessageTransaction)) []int {
+	changed := []int{}
+	r.updateWhere(func(message *domainProvider.MessageTransaction) bool {
+		if containsID(ids, message.ID) && matches(message) {
+			changed = append(changed, message.ID)
+			return true
+		}
+		return false
+	}, change)
+	return changed
+}
+
+// updateWhere changes the messages matching a condition in ID order and returns how many it changed
+func (r *MessageTransactionRepository) updateWhere(matches func(message *domainProvider.MessageTransaction) bool, change func(message *domainProvider.MessageTransaction)) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := clock(r.Now)
+	changed := 0
+	for _, id := range sortedIDs(r.messages) {
+		if message := r.messages[id]; matches(message) {
+			change(message)
+			message.UpdatedAt = now
+			changed++
+		}
+	}
+	return changed
+}
+
+// find returns copies of the messages matching a condition in ID order
+func (r *MessageTransactionRepository) find(matches func(message *domainProvider.MessageTransaction) bool) []domainProvider.MessageTransaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := []domainProvider.MessageTransaction{}
+	for _, id := range sortedIDs(r.messages) {
+		if matches(r.messages[id]) {
+			messages = append(messages, *r.messages[id])
+		}
+	}
+	return messages
+}
+
+// latest returns the message with the highest ID matching a condition
+func (r *MessageTransactionRepository) latest(matches func(message *domainProvider.MessageTransaction) bool) (*domainProvider.MessageTransaction, error) {
+	messages := r.find(matches)
+	if len(messages) == 0 {
+		return nil, notFound()
+	}
+	return &messages[len(messages)-1], nil
+}
+
+// today returns the bounds of the UTC day of now
+func today(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return startOfDay, startOfDay.Add(24 * time.Hour)
+}
+
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,347 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/repository/mysql/pagination"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// MessageTransactionHistoryRepository keeps the history of processed messages in memory, it implements
+// provider.MessageTransactionHistoryRepositoryInterface. The statistics count the entries processed within their
+// range like the MySQL repository.
+type MessageTransactionHistoryRepository struct {
+	// Now is the time of created and updated rows, time.Now when nil
+	Now func() time.Time
+
+	// providers are joined by GetProviderTypeOutcomes, nil when no test needs it
+	providers providerRepo.ProviderRepositoryInterface
+
+	mu        sync.Mutex
+	histories map[int]*domainProvider.MessageTransactionHistory
+	lastID    int
+}
+
+var _ providerRepo.MessageTransactionHistoryRepositoryInterface = (*MessageTransactionHistoryRepository)(nil)
+
+// NewMessageTransactionHistoryRepository creates a MessageTransactionHistoryRepository holding the given entries,
+// GetProviderTypeOutcomes reads the types of their providers from providers
+func NewMessageTransactionHistoryRepository(providers providerRepo.ProviderRepositoryInterface, histories ...domainProvider.MessageTransactionHistory) *MessageTransactionHistoryRepository {
+	r := &MessageTransactionHistoryRepository{providers: providers, histories: make(map[int]*domainProvider.MessageTransactionHistory)}
+	if err := r.CreateBatch(histories); err != nil {
+		panic(fmt.Sprintf("testsupport: couldn't create message transaction history: %v", err))
+	}
+	return r
+}
+
+func (r *MessageTransactionHistoryRepository) Create(historyDomain *domainProvider.MessageTransactionHistory) (*domainProvider.MessageTransactionHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(*historyDomain)
+}
+
+func (r *MessageTransactionHistoryRepository) CreateBatch(histories []domainProvider.MessageTransactionHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, history := range histories {
+		if _, exists := r.histories[history.ID]; history.ID != 0 && exists {
+			return domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+		}
+	}
+	for _, history := range histories {
+		if _, err := r.create(history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MessageTransactionHistoryRepository) create(history domainProvider.MessageTransactionHistory) (*domainProvider.MessageTransactionHistory, error) {
+	id, exists := assignID(r.histories, &r.lastID, history.ID)
+	if exists {
+		return &domainProvider.MessageTransactionHistory{}, domainErrors.NewAppErrorWithType(domainErrors.UnknownError)
+	}
+	history.ID = id
+	now := clock(r.Now)
+	if history.CreatedAt.IsZero() {
+		history.CreatedAt = now
+	}
+	if history.UpdatedAt.IsZero() {
+		history.UpdatedAt = now
+	}
+	r.histories[history.ID] = &history
+	stored := history
+	return &stored, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetByID(id int) (*domainProvider.MessageTransactionHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history, ok := r.histories[id]
+	if !ok {
+		return &domainProvider.MessageTransactionHistory{}, notFound()
+	}
+	found := *history
+	return &found, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetByMessageID(messageID int) (*[]domainProvider.MessageTransactionHistory, error) {
+	histories := r.newestFirst(func(history *domainProvider.MessageTransactionHistory) bool {
+		return history.MessageID == messageID
+	})
+	return &histories, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetUserMessageTransactionHistory(userID int) (*[]domainProvider.MessageTransactionHistory, error) {
+	histories := r.newestFirst(func(history *domainProvider.MessageTransactionHistory) bool {
+		return history.UserID == userID
+	})
+	return &histories, nil
+}
+
+// SearchUserHistory pages through the entries of a user like pagination.Apply: newest first by created_at and ID,
+// after the cursor of the request
+func (r *MessageTransactionHistoryRepository) SearchUserHistory(userID int, status string, tags map[string]string, page domain.PageRequest) (*domain.Page[domainProvider.MessageTransactionHistory], error) {
+	histories := r.find(func(history *domainProvider.MessageTransactionHistory) bool {
+		if history.UserID != userID || (status != "" && history.Status != status) {
+			return false
+		}
+		if after := page.After; after != nil &&
+			!(history.CreatedAt.Before(after.Time) || (history.CreatedAt.Equal(after.Time) && history.ID < after.ID)) {
+			return false
+		}
+		historyTags := tagsOf(history)
+		for key, value := range tags {
+			if tagValue, ok := historyTags[key]; !ok || tagValue != value {
+				return false
+			}
+		}
+		return true
+	})
+	sort.SliceStable(histories, func(i, j int) bool {
+		if !histories[i].CreatedAt.Equal(histories[j].CreatedAt) {
+			return histories[i].CreatedAt.After(histories[j].CreatedAt)
+		}
+		return histories[i].ID > histories[j].ID
+	})
+	histories = histories[:min(page.Limit+1, len(histories))]
+	histories, next := pagination.Cut(histories, page.Limit, func(history *domainProvider.MessageTransactionHistory) domain.Cursor {
+		return domain.Cursor{Time: history.CreatedAt, ID: history.ID}
+	})
+	return &domain.Page[domainProvider.MessageTransactionHistory]{Items: histories, Next: next}, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetUserTagRollup(userID int, tagKey string, from time.Time, to time.Time, granularity string) (*[]domainProvider.TagDeliveryRollup, error) {
+	type bucket struct {
+		period   string
+		tagValue string
+	}
+	rollups := map[bucket]*domainProvider.TagDeliveryRollup{}
+	for _, history := range r.find(func(history *domainProvider.MessageTransactionHistory) bool {
+		return history.UserID == userID && processedWithin(history, from, to)
+	}) {
+		tagValue, ok := tagsOf(&history)[tagKey]
+		if !ok {
+			continue
+		}
+		key, periodStart := bucket{tagValue: tagValue}, from
+		switch granularity {
+		case providerRepo.RollupGranularityDay:
+			periodStart = startOfDay(history.ProcessedAt)
+			key.period = periodStart.Format("2006-01-02")
+		case providerRepo.RollupGranularityWeek:
+			day := startOfDay(history.ProcessedAt)
+			// Weeks start on Monday like WEEKDAY of MySQL counts them
+			periodStart = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+			key.period = periodStart.Format("2006-01-02")
+		}
+		rollup, ok := rollups[key]
+		if !ok {
+			rollup = &domainProvider.TagDeliveryRollup{TagValue: tagValue, PeriodStart: periodStart}
+			rollups[key] = rollup
+		}
+		rollup.Total++
+		countOutcome(history.Status, &rollup.Sent, &rollup.Failed, &rollup.Fallbacks)
+	}
+
+	keys := make([]bucket, 0, len(rollups))
+	for key := range rollups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].period != keys[j].period {
+			return keys[i].period < keys[j].period
+		}
+		return keys[i].tagValue < keys[j].tagValue
+	})
+	result := make([]domainProvider.TagDeliveryRollup, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *rollups[key])
+	}
+	return &result, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetUserDeliveryStats(userID int, from time.Time, to time.Time, topErrorLimit int) (*domainProvider.DeliveryStats, error) {
+	stats := &domainProvider.DeliveryStats{TopErrors: []domainProvider.ErrorReasonCount{}}
+	type errorReason struct {
+		code    string
+		message string
+	}
+	counts := map[errorReason]int{}
+	for _, history := range r.find(func(history *domainProvider.MessageTransactionHistory) bool {
+		return history.UserID == userID && processedWithin(history, from, to)
+	}) {
+		stats.Total++
+		countOutcome(history.Status, &stats.Sent, &stats.Failed, &stats.Fallbacks)
+		if history.ErrorMessage != "" {
+			counts[errorReason{code: history.ErrorCode, message: history.ErrorMessage}]++
+		}
+	}
+
+	for reason, count := range counts {
+		stats.TopErrors = append(stats.TopErrors, domainProvider.ErrorReasonCount{Code: reason.code, Reason: reason.message, Count: count})
+	}
+	// The order of reasons occurring as often isn't defined by the query, they are sorted to keep tests stable
+	sort.Slice(stats.TopErrors, func(i, j int) bool {
+		a, b := stats.TopErrors[i], stats.TopErrors[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Reason < b.Reason
+	})
+	stats.TopErrors = stats.TopErrors[:min(max(topErrorLimit, 0), len(stats.TopErrors))]
+	return stats, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetProviderTypeOutcomes(from time.Time) ([]domainProvider.ProviderTypeOutcomes, error) {
+	if r.providers == nil {
+		return nil, domainErrors.NewAppError(fmt.Errorf("testsupport: message transaction history repository has no providers"), domainErrors.UnknownError)
+	}
+	providers, err := r.providers.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[int]string)
+	for _, provider := range *providers {
+		types[provider.ID] = provider.Type
+	}
+
+	var outcomes []domainProvider.ProviderTypeOutcomes
+	index := make(map[string]int)
+	for _, history := range r.find(func(history *domainProvider.MessageTransactionHistory) bool {
+		return !history.ProcessedAt.Before(from) && !history.CreatedAt.Before(from)
+	}) {
+		providerType, ok := types[history.ProviderID]
+		if !ok {
+			continue
+		}
+		i, ok := index[providerType]
+		if !ok {
+			i = len(outcomes)
+			index[providerType] = i
+			outcomes = append(outcomes, domainProvider.ProviderTypeOutcomes{Type: providerType})
+		}
+		switch history.Status {
+		case "success", "delivered":
+			outcomes[i].Sent++
+		case "failed", "fallback_triggered":
+			outcomes[i].Failed++
+		}
+	}
+	return outcomes, nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetAfter(afterID int, limit int) ([]domainProvider.MessageTransactionHistory, error) {
+	histories := r.find(func(history *domainProvider.MessageTransactionHistory) bool {
+		return history.ID > afterID
+	})
+	return histories[:min(limit, len(histories))], nil
+}
+
+func (r *MessageTransactionHistoryRepository) GetOldestCreatedAt() (*time.Time, error) {
+	var oldest *time.Time
+	for _, history := range r.find(func(*domainProvider.MessageTransactionHistory) bool { return true }) {
+		if oldest == nil || history.CreatedAt.Before(*oldest) {
+			createdAt := history.CreatedAt
+			oldest = &createdAt
+		}
+	}
+	return oldest, nil
+}
+
+func (r *MessageTransactionHistoryRepository) DeleteRange(firstID int, lastID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id := range r.histories {
+		if id >= firstID && id <= lastID {
+			delete(r.histories, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// find returns copies of the entries matching a condition in ID order
+func (r *MessageTransactionHistoryRepository) find(matches func(history *domainProvider.MessageTransactionHistory) bool) []domainProvider.MessageTransactionHistory {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	histories := []domainProvider.MessageTransactionHistory{}
+	for _, id := range sortedIDs(r.histories) {
+		if matches(r.histories[id]) {
+			histories = append(histories, *r.histories[id])
+		}
+	}
+	return histories
+}
+
+// newestFirst returns the entries matching a condition ordered by created_at descending
+func (r *MessageTransactionHistoryRepository) newestFirst(matches func(history *domainProvider.MessageTransactionHistory) bool) []domainProvider.MessageTransactionHistory {
+	histories := r.find(matches)
+	sort.SliceStable(histories, func(i, j int) bool {
+		return histories[i].CreatedAt.After(histories[j].CreatedAt)
+	})
+	return histories
+}
+
+// processedWithin reports whether an entry was processed from (inclusive) to (exclusive), the created_at bound of
+// the MySQL queries skipping older partitions is kept
+func processedWithin(history *domainProvider.MessageTransactionHistory, from time.Time, to time.Time) bool {
+	return !history.ProcessedAt.Before(from) && history.ProcessedAt.Before(to) && !history.CreatedAt.Before(from)
+}
+
+// tagsOf decodes the tags of an entry, untagged entries have none
+func tagsOf(history *domainProvider.MessageTransactionHistory) map[string]string {
+	tags := map[string]string{}
+	if history.Tags != "" {
+		_ = json.Unmarshal([]byte(history.Tags), &tags)
+	}
+	return tags
+}
+
+// countOutcome counts a status as sent, failed or fallback like the statistics queries
+func countOutcome(status string, sent *int, failed *int, fallbacks *int) {
+	switch status {
+	case "success", "delivered":
+		*sent++
+	case "failed":
+		*failed++
+	case "fallback_triggered":
+		*fallbacks++
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
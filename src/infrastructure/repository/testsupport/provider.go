@@ -0,0 +1,157 @@
+package testsupport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// providerUpdateColumns are the columns Update changes
+var providerUpdateColumns = []string{"name", "type", "description", "config", "status", "version"}
+
+// ProviderRepository keeps providers in memory, it implements provider.ProviderRepositoryInterface. Names are
+// unique and updates sending a version are rejected with a Conflict when the provider changed since.
+type ProviderRepository struct {
+	// Now is the time of created and updated rows, time.Now when nil
+	Now func() time.Time
+
+	mu        sync.Mutex
+	providers map[int]*domainProvider.Provider
+	lastID    int
+}
+
+var _ providerRepo.ProviderRepositoryInterface = (*ProviderRepository)(nil)
+
+// NewProviderRepository creates a ProviderRepository holding the given providers
+func NewProviderRepository(providers ...domainProvider.Provider) *ProviderRepository {
+	r := &ProviderRepository{providers: make(map[int]*domainProvider.Provider)}
+	for i := range providers {
+		if _, err := r.Create(&providers[i]); err != nil {
+			panic(fmt.Sprintf("testsupport: couldn't create provider %q: %v", providers[i].Name, err))
+		}
+	}
+	return r
+}
+
+func (r *ProviderRepository) GetAll() (*[]domainProvider.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	providers := make([]domainProvider.Provider, 0, len(r.providers))
+	for _, id := range sortedIDs(r.providers) {
+		providers = append(providers, *r.providers[id])
+	}
+	return &providers, nil
+}
+
+func (r *ProviderRepository) Create(providerDomain *domainProvider.Provider) (*domainProvider.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider := *providerDomain
+	if r.duplicate(0, provider.Name) {
+		return &domainProvider.Provider{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	id, exists := assignID(r.providers, &r.lastID, provider.ID)
+	if exists {
+		return &domainProvider.Provider{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	provider.ID = id
+	if provider.Version == 0 {
+		provider.Version = 1
+	}
+	now := clock(r.Now)
+	if provider.CreatedAt.IsZero() {
+		provider.CreatedAt = now
+	}
+	if provider.UpdatedAt.IsZero() {
+		provider.UpdatedAt = now
+	}
+	r.providers[provider.ID] = &provider
+	stored := provider
+	return &stored, nil
+}
+
+func (r *ProviderRepository) GetByID(id int) (*domainProvider.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider, ok := r.providers[id]
+	if !ok {
+		return &domainProvider.Provider{}, notFound()
+	}
+	found := *provider
+	return &found, nil
+}
+
+func (r *ProviderRepository) GetByName(name string) (*domainProvider.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range sortedIDs(r.providers) {
+		if r.providers[id].Name == name {
+			found := *r.providers[id]
+			return &found, nil
+		}
+	}
+	return &domainProvider.Provider{}, notFound()
+}
+
+func (r *ProviderRepository) Update(id int, providerMap map[string]interface{}) (*domainProvider.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider, ok := r.providers[id]
+	if !ok {
+		return &domainProvider.Provider{}, notFound()
+	}
+	updateData := columnsOf(providerMap, providerRepo.ColumnsProviderMapping)
+	if err := checkVersion(updateData, provider.Version); err != nil {
+		return &domainProvider.Provider{}, err
+	}
+	updated := *provider
+	if err := applyUpdate(&updated, updateData, providerUpdateColumns...); err != nil {
+		return &domainProvider.Provider{}, err
+	}
+	if r.duplicate(id, updated.Name) {
+		return &domainProvider.Provider{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	updated.Version = provider.Version + 1
+	updated.UpdatedAt = clock(r.Now)
+	r.providers[id] = &updated
+	stored := updated
+	return &stored, nil
+}
+
+func (r *ProviderRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.providers[id]; !ok {
+		return notFound()
+	}
+	delete(r.providers, id)
+	return nil
+}
+
+// duplicate reports whether a provider other than id has the name
+func (r *ProviderRepository) duplicate(id int, name string) bool {
+	for _, provider := range r.providers {
+		if provider.ID != id && provider.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVersion rejects an update sending a version other than the current one of the row, the optimistic lock of
+// the provider and user provider updates. The version is removed from the update, it is incremented instead.
+func checkVersion(updateData map[string]interface{}, current int) error {
+	expected, ok := updateData["version"]
+	if !ok {
+		return nil
+	}
+	delete(updateData, "version")
+	if version, ok := toInt(expected); !ok || version != current {
+		return domainErrors.NewAppErrorWithType(domainErrors.Conflict)
+	}
+	return nil
+}
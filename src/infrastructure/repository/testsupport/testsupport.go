@@ -0,0 +1,227 @@
+// Package testsupport holds in-memory implementations of the repositories of users, providers, user providers,
+// message transactions and their history, so use cases and the message processor can be tested without a
+// database. They follow the semantics of the MySQL repositories: IDs are assigned in creation order, missing rows
+// are NotFound app errors, updates take the JSON field or column names of the Columns...Mapping of the repository
+// and guarded updates only change the rows still matching their condition. The outbox isn't written.
+package testsupport
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+
+	"gorm.io/gorm/schema"
+)
+
+// naming names the columns of the fields of domain structs like the MySQL models do
+var naming = schema.NamingStrategy{}
+
+// clock returns the current time of a repository, time.Now unless a test set its own
+func clock(now func() time.Time) time.Time {
+	if now == nil {
+		return time.Now()
+	}
+	return now()
+}
+
+// columnsOf maps the JSON field names of an update map to column names, keys that are column names already are
+// kept
+func columnsOf(updateMap map[string]interface{}, mapping map[string]string) map[string]interface{} {
+	updateData := make(map[string]interface{}, len(updateMap))
+	for k, v := range updateMap {
+		if column, ok := mapping[k]; ok {
+			updateData[column] = v
+		} else {
+			updateData[k] = v
+		}
+	}
+	return updateData
+}
+
+// applyUpdate sets the fields of the struct target points to from an update map of column names. Columns missing
+// from selected are skipped like the columns a MySQL repository doesn't select, a nil selected allows all columns.
+func applyUpdate(target interface{}, updateData map[string]interface{}, selected ...string) error {
+	value := reflect.ValueOf(target).Elem()
+	for column, update := range updateData {
+		if len(selected) > 0 && !contains(selected, column) {
+			continue
+		}
+		field, ok := fieldOf(value, column)
+		if !ok {
+			return domainErrors.NewAppError(fmt.Errorf("unknown column %q", column), domainErrors.UnknownError)
+		}
+		if err := setField(field, update); err != nil {
+			return domainErrors.NewAppError(fmt.Errorf("column %q: %w", column, err), domainErrors.UnknownError)
+		}
+	}
+	return nil
+}
+
+// fieldOf returns the field of a struct stored in a column
+func fieldOf(value reflect.Value, column string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		if naming.ColumnName("", value.Type().Field(i).Name) == column {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// columnValue returns the value of a column of a struct, nil for unknown columns and unset pointers
+func columnValue(target interface{}, column string) interface{} {
+	value := reflect.ValueOf(target)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	field, ok := fieldOf(value, column)
+	if !ok || (field.Kind() == reflect.Ptr && field.IsNil()) {
+		return nil
+	}
+	return reflect.Indirect(field).Interface()
+}
+
+// setField assigns an update value to a field, converting between numeric types and to pointers like the driver
+// does when it writes and reads back a row
+func setField(field reflect.Value, update interface{}) error {
+	if update == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	value := reflect.ValueOf(update)
+	if value.Type().AssignableTo(field.Type()) {
+		field.Set(value)
+		return nil
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		value = value.Elem()
+	}
+	target := field.Type()
+	if target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+	converted, ok := convert(value, target)
+	if !ok {
+		return fmt.Errorf("can't store a %s in a %s", value.Type(), field.Type())
+	}
+	if field.Kind() == reflect.Ptr {
+		pointer := reflect.New(target)
+		pointer.Elem().Set(converted)
+		field.Set(pointer)
+		return nil
+	}
+	field.Set(converted)
+	return nil
+}
+
+// convert converts a value to a type of the same kind, or between numeric types
+func convert(value reflect.Value, target reflect.Type) (reflect.Value, bool) {
+	if value.Type().AssignableTo(target) {
+		return value, true
+	}
+	if (isNumeric(value.Kind()) && isNumeric(target.Kind())) || value.Kind() == target.Kind() {
+		if value.Type().ConvertibleTo(target) {
+			return value.Convert(target), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// toInt reads an int from an update value, e.g. the version of an optimistic lock decoded from JSON as a float64
+func toInt(value interface{}) (int, bool) {
+	converted, ok := convert(reflect.ValueOf(value), reflect.TypeOf(0))
+	if !ok {
+		return 0, false
+	}
+	return int(converted.Int()), true
+}
+
+// compareValues orders two column values, nil first
+func compareValues(a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if a {
+			return 1
+		}
+		return -1
+	}
+	x, _ := convert(reflect.ValueOf(a), reflect.TypeOf(float64(0)))
+	y, _ := convert(reflect.ValueOf(b), reflect.TypeOf(float64(0)))
+	switch {
+	case x.Float() < y.Float():
+		return -1
+	case x.Float() > y.Float():
+		return 1
+	}
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedIDs returns the keys of rows in ascending order, the order MySQL returns rows without an ORDER BY in
+func sortedIDs[T any](rows map[int]*T) []int {
+	ids := make([]int, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// assignID returns the ID of a created row, the given one or the next of the auto increment, and reports whether
+// a row with the given ID exists already. Like MySQL the IDs of deleted rows aren't used again.
+func assignID[T any](rows map[int]*T, lastID *int, id int) (int, bool) {
+	if id == 0 {
+		*lastID++
+		return *lastID, false
+	}
+	if _, exists := rows[id]; exists {
+		return id, true
+	}
+	if id > *lastID {
+		*lastID = id
+	}
+	return id, false
+}
+
+func notFound() error {
+	return domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
@@ -0,0 +1,203 @@
+package testsupport
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireAppErrorType(t *testing.T, err error, errType domainErrors.ErrorType) {
+	t.Helper()
+	var appErr *domainErrors.AppError
+	require.True(t, errors.As(err, &appErr), "expected an app error, got %v", err)
+	assert.Equal(t, errType, appErr.Type)
+}
+
+func TestUserRepository_AssignsIDsAndDefaults(t *testing.T) {
+	repository := NewUserRepository(domainUser.User{UserName: "alice", Email: "alice@example.com"})
+
+	user, err := repository.Create(&domainUser.User{UserName: "bob", Email: "bob@example.com", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, user.ID)
+	assert.Equal(t, 1000, user.MessageRateLimit)
+	assert.Equal(t, "member", user.Role)
+	assert.Empty(t, user.Password)
+
+	_, err = repository.Create(&domainUser.User{UserName: "carol", Email: "alice@example.com"})
+	requireAppErrorType(t, err, domainErrors.ResourceAlreadyExists)
+
+	require.NoError(t, repository.Delete(2))
+	user, err = repository.Create(&domainUser.User{UserName: "dave", Email: "dave@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, user.ID, "IDs of deleted users aren't used again")
+
+	_, err = repository.GetByID(2)
+	requireAppErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestUserRepository_UpdateOnlyChangesUpdatableColumns(t *testing.T) {
+	repository := NewUserRepository(domainUser.User{UserName: "alice", Email: "alice@example.com", HashPassword: "hash"})
+
+	user, err := repository.Update(1, map[string]interface{}{"firstName": "Alice", "hash_password": "changed"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.FirstName)
+	assert.Equal(t, "hash", user.HashPassword)
+}
+
+func TestProviderRepository_UpdateChecksVersion(t *testing.T) {
+	repository := NewProviderRepository(domainProvider.Provider{Name: "twilio", Type: "sms"})
+
+	provider, err := repository.Update(1, map[string]interface{}{"description": "SMS", "version": float64(1)})
+	require.NoError(t, err)
+	assert.Equal(t, "SMS", provider.Description)
+	assert.Equal(t, 2, provider.Version)
+
+	_, err = repository.Update(1, map[string]interface{}{"description": "stale", "version": 1})
+	requireAppErrorType(t, err, domainErrors.Conflict)
+	provider, err = repository.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "SMS", provider.Description)
+}
+
+func TestUserProviderRepository_GetActiveByProviderType(t *testing.T) {
+	providers := NewProviderRepository(
+		domainProvider.Provider{Name: "twilio", Type: "sms", Status: true},
+		domainProvider.Provider{Name: "smtp", Type: "email", Status: true},
+	)
+	repository := NewUserProviderRepository(providers,
+		domainProvider.UserProvider{UserID: 1, ProviderID: 1, Status: true},
+		domainProvider.UserProvider{UserID: 2, ProviderID: 1},
+		domainProvider.UserProvider{UserID: 3, ProviderID: 2, Status: true},
+	)
+
+	userProviders, err := repository.GetActiveByProviderType("sms")
+	require.NoError(t, err)
+	require.Len(t, *userProviders, 1)
+	assert.Equal(t, 1, (*userProviders)[0].UserID)
+}
+
+func TestMessageTransactionRepository_UpdateRejectsIllegalTransition(t *testing.T) {
+	repository := NewMessageTransactionRepository(domainProvider.MessageTransaction{UserID: 1, Status: domainProvider.MessageStatusSuccess})
+
+	_, err := repository.Update(1, map[string]interface{}{"status": domainProvider.MessageStatusCancelled})
+	requireAppErrorType(t, err, domainErrors.Conflict)
+
+	_, err = repository.Update(2, map[string]interface{}{"status": domainProvider.MessageStatusFailed})
+	requireAppErrorType(t, err, domainErrors.NotFound)
+}
+
+func TestMessageTransactionRepository_GetPendingMessagesMarksThemProcessing(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	repository := NewMessageTransactionRepository(
+		domainProvider.MessageTransaction{UserID: 1, Status: domainProvider.MessageStatusPending},
+		domainProvider.MessageTransaction{UserID: 1, Status: domainProvider.MessageStatusFailed},
+	)
+	repository.Now = func() time.Time { return now }
+
+	messages, err := repository.GetPendingMessages()
+	require.NoError(t, err)
+	require.Len(t, *messages, 1)
+	assert.False(t, (*messages)[0].Processing, "the messages are returned as they were read")
+
+	message, err := repository.GetByID(1)
+	require.NoError(t, err)
+	assert.True(t, message.Processing)
+	require.NotNil(t, message.ProcessedAt)
+	assert.Equal(t, now, *message.ProcessedAt)
+
+	messages, err = repository.GetPendingMessages()
+	require.NoError(t, err)
+	assert.Empty(t, *messages)
+}
+
+func TestMessageTransactionRepository_CancelBatchSkipsStartedSends(t *testing.T) {
+	started := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	repository := NewMessageTransactionRepository(
+		domainProvider.MessageTransaction{UserID: 1, Status: domainProvider.MessageStatusPending},
+		domainProvider.MessageTransaction{UserID: 1, Status: domainProvider.MessageStatusPending, SendStartedAt: &started},
+	)
+
+	cancelled, err := repository.CancelBatch([]int{1, 2}, domainProvider.MessageStatusPending)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, cancelled)
+	message, err := repository.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, domainProvider.MessageStatusCancelled, message.Status)
+	assert.Equal(t, "cancelled by an admin", message.ErrorMessage)
+	assert.NotNil(t, message.SendStartedAt)
+
+	_, err = repository.CancelBatch([]int{1}, domainProvider.MessageStatusCancelled)
+	requireAppErrorType(t, err, domainErrors.ValidationError)
+}
+
+func TestMessageTransactionRepository_MoveToHistory(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	repository := NewMessageTransactionRepository(domainProvider.MessageTransaction{UserID: 1, ProviderID: 2, Status: domainProvider.MessageStatusSuccess, UpdatedAt: now})
+	repository.Now = func() time.Time { return now }
+	history := NewMessageTransactionHistoryRepository(nil)
+
+	require.NoError(t, repository.MoveToHistory(1, history))
+
+	entries, err := history.GetByMessageID(1)
+	require.NoError(t, err)
+	require.Len(t, *entries, 1)
+	assert.Equal(t, domainProvider.MessageStatusSuccess, (*entries)[0].Status)
+	assert.Equal(t, now, (*entries)[0].ProcessedAt)
+	_, err = repository.GetByID(1)
+	assert.NoError(t, err, "the message transaction is kept")
+}
+
+func TestMessageTransactionHistoryRepository_SearchUserHistoryPages(t *testing.T) {
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	history := NewMessageTransactionHistoryRepository(nil,
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", Tags: `{"team":"ops"}`, CreatedAt: createdAt},
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", Tags: `{"team":"ops"}`, CreatedAt: createdAt},
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", Tags: `{"team":"sales"}`, CreatedAt: createdAt},
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", Tags: `{"team":"ops"}`, CreatedAt: createdAt.Add(-time.Hour)},
+		domainProvider.MessageTransactionHistory{UserID: 2, Status: "success", Tags: `{"team":"ops"}`, CreatedAt: createdAt},
+	)
+
+	var ids []int
+	page := domain.PageRequest{Limit: 2}
+	for {
+		result, err := history.SearchUserHistory(1, "", map[string]string{"team": "ops"}, page)
+		require.NoError(t, err)
+		for _, entry := range result.Items {
+			ids = append(ids, entry.ID)
+		}
+		if result.Next == nil {
+			break
+		}
+		page.After = result.Next
+	}
+	assert.Equal(t, []int{2, 1, 4}, ids)
+}
+
+func TestMessageTransactionHistoryRepository_GetUserTagRollupByWeek(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	history := NewMessageTransactionHistoryRepository(nil,
+		// Wednesday and Friday of the week starting on Monday, March 2nd
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", Tags: `{"team":"ops"}`, ProcessedAt: from.AddDate(0, 0, 3), CreatedAt: from.AddDate(0, 0, 3)},
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "failed", Tags: `{"team":"ops"}`, ProcessedAt: from.AddDate(0, 0, 5), CreatedAt: from.AddDate(0, 0, 5)},
+		domainProvider.MessageTransactionHistory{UserID: 1, Status: "success", ProcessedAt: from.AddDate(0, 0, 5), CreatedAt: from.AddDate(0, 0, 5)},
+	)
+
+	rollups, err := history.GetUserTagRollup(1, "team", from, from.AddDate(0, 1, 0), "week")
+	require.NoError(t, err)
+	require.Len(t, *rollups, 1)
+	assert.Equal(t, domainProvider.TagDeliveryRollup{
+		TagValue:    "ops",
+		PeriodStart: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Total:       2,
+		Sent:        1,
+		Failed:      1,
+	}, (*rollups)[0])
+}
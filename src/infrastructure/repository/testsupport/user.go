@@ -0,0 +1,256 @@
+package testsupport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainUser "go-multi-chat-api/src/domain/user"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+)
+
+// userUpdateColumns are the columns Update changes, the others like the password hash are left alone
+var userUpdateColumns = []string{"user_name", "email", "first_name", "last_name", "status", "role", "locale", "engagement_tracking", "deactivated_at"}
+
+// UserRepository keeps users in memory, it implements user.UserRepositoryInterface. User names and emails are
+// unique.
+type UserRepository struct {
+	// Now is the time of created and updated rows, time.Now when nil
+	Now func() time.Time
+
+	mu     sync.Mutex
+	users  map[int]*domainUser.User
+	lastID int
+}
+
+var _ userRepo.UserRepositoryInterface = (*UserRepository)(nil)
+
+// NewUserRepository creates a UserRepository holding the given users
+func NewUserRepository(users ...domainUser.User) *UserRepository {
+	r := &UserRepository{users: make(map[int]*domainUser.User)}
+	for i := range users {
+		if _, err := r.Create(&users[i]); err != nil {
+			panic(fmt.Sprintf("testsupport: couldn't create user %q: %v", users[i].Email, err))
+		}
+	}
+	return r
+}
+
+func (r *UserRepository) GetAll() (*[]domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]domainUser.User, 0, len(r.users))
+	for _, id := range sortedIDs(r.users) {
+		users = append(users, *r.users[id])
+	}
+	return &users, nil
+}
+
+func (r *UserRepository) Create(userDomain *domainUser.User) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := *userDomain
+	if r.duplicate(0, user.UserName, user.Email) {
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	id, exists := assignID(r.users, &r.lastID, user.ID)
+	if exists {
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	user.ID = id
+	// The column defaults of the users table
+	if user.MessageRateLimit == 0 {
+		user.MessageRateLimit = 1000
+	}
+	if user.Role == "" {
+		user.Role = "member"
+	}
+	// The password is hashed by the use case, only the hash is stored
+	user.Password = ""
+	now := clock(r.Now)
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	r.users[user.ID] = &user
+	stored := user
+	return &stored, nil
+}
+
+func (r *UserRepository) GetByID(id int) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return &domainUser.User{}, notFound()
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *UserRepository) GetByEmail(email string) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range sortedIDs(r.users) {
+		if r.users[id].Email == email {
+			found := *r.users[id]
+			return &found, nil
+		}
+	}
+	return &domainUser.User{}, notFound()
+}
+
+func (r *UserRepository) Update(id int, userMap map[string]interface{}) (*domainUser.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return &domainUser.User{}, notFound()
+	}
+	updated := *user
+	if err := applyUpdate(&updated, columnsOf(userMap, userRepo.ColumnsUserMapping), userUpdateColumns...); err != nil {
+		return &domainUser.User{}, err
+	}
+	if r.duplicate(id, updated.UserName, updated.Email) {
+		return &domainUser.User{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	updated.UpdatedAt = clock(r.Now)
+	r.users[id] = &updated
+	stored := updated
+	return &stored, nil
+}
+
+func (r *UserRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return notFound()
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// SearchPaginated filters like the MySQL repository: like filters match case-insensitively anywhere in the value,
+// matches compare the value as text and filters of unknown fields are ignored
+func (r *UserRepository) SearchPaginated(filters domain.DataFilters) (*domainUser.SearchResultUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []domainUser.User
+	for _, id := range sortedIDs(r.users) {
+		if matchesFilters(r.users[id], filters, userRepo.ColumnsUserMapping) {
+			users = append(users, *r.users[id])
+		}
+	}
+	sortByFilters(users, filters, userRepo.ColumnsUserMapping)
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.PageSize < 1 {
+		filters.PageSize = 10
+	}
+	total := int64(len(users))
+	page := []domainUser.User{}
+	if offset := (filters.Page - 1) * filters.PageSize; offset < len(users) {
+		page = users[offset:min(offset+filters.PageSize, len(users))]
+	}
+	return &domainUser.SearchResultUser{
+		Data:       &page,
+		Total:      total,
+		Page:       filters.Page,
+		PageSize:   filters.PageSize,
+		TotalPages: int((total + int64(filters.PageSize) - 1) / int64(filters.PageSize)),
+	}, nil
+}
+
+func (r *UserRepository) SearchByProperty(property string, searchText string) (*[]string, error) {
+	column := userRepo.ColumnsUserMapping[property]
+	if column == "" {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.ValidationError)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	coincidences := []string{}
+	for _, id := range sortedIDs(r.users) {
+		value := fmt.Sprint(columnValue(r.users[id], column))
+		if strings.Contains(strings.ToLower(value), strings.ToLower(searchText)) && !contains(coincidences, value) {
+			coincidences = append(coincidences, value)
+			if len(coincidences) == 20 {
+				break
+			}
+		}
+	}
+	return &coincidences, nil
+}
+
+// duplicate reports whether a user other than id has the user name or email
+func (r *UserRepository) duplicate(id int, userName string, email string) bool {
+	for _, user := range r.users {
+		if user.ID != id && ((userName != "" && user.UserName == userName) || (email != "" && user.Email == email)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters reports whether a row passes the like, match and date range filters of a search
+func matchesFilters(row interface{}, filters domain.DataFilters, mapping map[string]string) bool {
+	for field, values := range filters.LikeFilters {
+		column := mapping[field]
+		if column == "" {
+			continue
+		}
+		value := strings.ToLower(fmt.Sprint(columnValue(row, column)))
+		for _, like := range values {
+			if like != "" && !strings.Contains(value, strings.ToLower(like)) {
+				return false
+			}
+		}
+	}
+	for field, values := range filters.Matches {
+		column := mapping[field]
+		if column != "" && len(values) > 0 && !contains(values, fmt.Sprint(columnValue(row, column))) {
+			return false
+		}
+	}
+	for _, dateFilter := range filters.DateRangeFilters {
+		column := mapping[dateFilter.Field]
+		if column == "" {
+			continue
+		}
+		value, ok := columnValue(row, column).(time.Time)
+		if !ok {
+			return false
+		}
+		if (dateFilter.Start != nil && value.Before(*dateFilter.Start)) || (dateFilter.End != nil && value.After(*dateFilter.End)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortByFilters sorts rows by the sort fields of a search, keeping the ID order of equal rows
+func sortByFilters[T any](rows []T, filters domain.DataFilters, mapping map[string]string) {
+	if len(filters.SortBy) == 0 || !filters.SortDirection.IsValid() {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, field := range filters.SortBy {
+			column := mapping[field]
+			if column == "" {
+				continue
+			}
+			order := compareValues(columnValue(&rows[i], column), columnValue(&rows[j], column))
+			if order != 0 {
+				return (order < 0) == (filters.SortDirection == domain.SortAsc)
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,160 @@
+package testsupport
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// userProviderUpdateColumns are the columns Update changes
+var userProviderUpdateColumns = []string{"user_id", "provider_id", "priority", "config", "status", "suspended", "version",
+	"credential_status", "credential_error", "credentials_checked_at", "credentials_forced"}
+
+// UserProviderRepository keeps user providers in memory, it implements provider.UserProviderRepositoryInterface.
+// Updates sending a version are rejected with a Conflict when the user provider changed since.
+type UserProviderRepository struct {
+	// Now is the time of created and updated rows, time.Now when nil
+	Now func() time.Time
+
+	// providers are joined by GetActiveByProviderType, nil when no test needs it
+	providers providerRepo.ProviderRepositoryInterface
+
+	mu            sync.Mutex
+	userProviders map[int]*domainProvider.UserProvider
+	lastID        int
+}
+
+var _ providerRepo.UserProviderRepositoryInterface = (*UserProviderRepository)(nil)
+
+// NewUserProviderRepository creates a UserProviderRepository holding the given user providers, GetActiveByProviderType
+// reads the types of their providers from providers
+func NewUserProviderRepository(providers providerRepo.ProviderRepositoryInterface, userProviders ...domainProvider.UserProvider) *UserProviderRepository {
+	r := &UserProviderRepository{providers: providers, userProviders: make(map[int]*domainProvider.UserProvider)}
+	for i := range userProviders {
+		if _, err := r.Create(&userProviders[i]); err != nil {
+			panic(fmt.Sprintf("testsupport: couldn't create user provider of user %d: %v", userProviders[i].UserID, err))
+		}
+	}
+	return r
+}
+
+func (r *UserProviderRepository) GetUserProviders(userID int) (*[]domainProvider.UserProvider, error) {
+	return r.find(func(userProvider *domainProvider.UserProvider) bool {
+		return userProvider.UserID == userID
+	}), nil
+}
+
+func (r *UserProviderRepository) Create(userProviderDomain *domainProvider.UserProvider) (*domainProvider.UserProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userProvider := *userProviderDomain
+	id, exists := assignID(r.userProviders, &r.lastID, userProvider.ID)
+	if exists {
+		return &domainProvider.UserProvider{}, domainErrors.NewAppErrorWithType(domainErrors.ResourceAlreadyExists)
+	}
+	userProvider.ID = id
+	if userProvider.Version == 0 {
+		userProvider.Version = 1
+	}
+	now := clock(r.Now)
+	if userProvider.CreatedAt.IsZero() {
+		userProvider.CreatedAt = now
+	}
+	if userProvider.UpdatedAt.IsZero() {
+		userProvider.UpdatedAt = now
+	}
+	r.userProviders[userProvider.ID] = &userProvider
+	stored := userProvider
+	return &stored, nil
+}
+
+func (r *UserProviderRepository) GetByID(id int) (*domainProvider.UserProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userProvider, ok := r.userProviders[id]
+	if !ok {
+		return &domainProvider.UserProvider{}, notFound()
+	}
+	found := *userProvider
+	return &found, nil
+}
+
+func (r *UserProviderRepository) Update(id int, userProviderMap map[string]interface{}) (*domainProvider.UserProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userProvider, ok := r.userProviders[id]
+	if !ok {
+		return &domainProvider.UserProvider{}, notFound()
+	}
+	updateData := columnsOf(userProviderMap, providerRepo.ColumnsUserProviderMapping)
+	if err := checkVersion(updateData, userProvider.Version); err != nil {
+		return &domainProvider.UserProvider{}, err
+	}
+	updated := *userProvider
+	if err := applyUpdate(&updated, updateData, userProviderUpdateColumns...); err != nil {
+		return &domainProvider.UserProvider{}, err
+	}
+	updated.Version = userProvider.Version + 1
+	updated.UpdatedAt = clock(r.Now)
+	r.userProviders[id] = &updated
+	stored := updated
+	return &stored, nil
+}
+
+func (r *UserProviderRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.userProviders[id]; !ok {
+		return notFound()
+	}
+	delete(r.userProviders, id)
+	return nil
+}
+
+func (r *UserProviderRepository) GetUserProvidersByPriority(userID int) (*[]domainProvider.UserProvider, error) {
+	userProviders := r.find(func(userProvider *domainProvider.UserProvider) bool {
+		return userProvider.UserID == userID && userProvider.Status &&
+			(userProvider.CredentialStatus != domainProvider.CredentialStatusInvalid || userProvider.CredentialsForced)
+	})
+	sort.SliceStable(*userProviders, func(i, j int) bool {
+		return (*userProviders)[i].Priority < (*userProviders)[j].Priority
+	})
+	return userProviders, nil
+}
+
+func (r *UserProviderRepository) GetActiveByProviderType(providerType string) (*[]domainProvider.UserProvider, error) {
+	if r.providers == nil {
+		return nil, domainErrors.NewAppError(fmt.Errorf("testsupport: user provider repository has no providers"), domainErrors.UnknownError)
+	}
+	providers, err := r.providers.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[int]bool)
+	for _, provider := range *providers {
+		if provider.Type == providerType && provider.Status {
+			active[provider.ID] = true
+		}
+	}
+	return r.find(func(userProvider *domainProvider.UserProvider) bool {
+		return userProvider.Status && active[userProvider.ProviderID]
+	}), nil
+}
+
+// find returns copies of the user providers matching a condition in ID order
+func (r *UserProviderRepository) find(matches func(userProvider *domainProvider.UserProvider) bool) *[]domainProvider.UserProvider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	userProviders := []domainProvider.UserProvider{}
+	for _, id := range sortedIDs(r.userProviders) {
+		if matches(r.userProviders[id]) {
+			userProviders = append(userProviders, *r.userProviders[id])
+		}
+	}
+	return &userProviders
+}
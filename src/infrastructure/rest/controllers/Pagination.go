@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+)
+
+// errInvalidCursor answers cursors not issued by the API
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageQuery holds the query parameters of cursor paginated lists. Embed it in the query DTO of a list.
+type PageQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// PageRequest decodes the cursor of the query, the limit defaults to domain.DefaultPageLimit
+func (q PageQuery) PageRequest() (domain.PageRequest, error) {
+	request := domain.PageRequest{Limit: q.Limit}.WithDefaults()
+	if q.Cursor == "" {
+		return request, nil
+	}
+	cursor, err := DecodeCursor(q.Cursor)
+	if err != nil {
+		return domain.PageRequest{}, err
+	}
+	request.After = cursor
+	return request, nil
+}
+
+// PageResponse is the envelope of every cursor paginated list. NextCursor is left out on the last page.
+type PageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPageResponse wraps the items of a page, converted to their response DTOs, with the cursor of the next page
+func NewPageResponse[T any](items []T, next *domain.Cursor) PageResponse[T] {
+	response := PageResponse[T]{Data: items}
+	if next != nil {
+		response.NextCursor = EncodeCursor(*next)
+	}
+	return response
+}
+
+// EncodeCursor turns a cursor into an opaque token. Clients pass it back as is, its format may change.
+func EncodeCursor(cursor domain.Cursor) string {
+	raw := strconv.FormatInt(cursor.Time.UnixNano(), 10) + ":" + strconv.Itoa(cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reads a token of EncodeCursor
+func DecodeCursor(token string) (*domain.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	cursorID, err := strconv.Atoi(id)
+	if err != nil || cursorID <= 0 {
+		return nil, errInvalidCursor
+	}
+	return &domain.Cursor{Time: time.Unix(0, unixNano).UTC(), ID: cursorID}, nil
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := domain.Cursor{Time: time.Date(2024, 3, 1, 8, 30, 0, 123000000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm8tY29sb24", "YWJjOjE", "MTIzOmFiYw", "MTIzOjA"} {
+		_, err := DecodeCursor(token)
+		assert.ErrorIs(t, err, errInvalidCursor, token)
+	}
+}
+
+func TestPageQuery_PageRequest(t *testing.T) {
+	request, err := PageQuery{}.PageRequest()
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageRequest{Limit: domain.DefaultPageLimit}, request)
+
+	cursor := domain.Cursor{Time: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), ID: 7}
+	request, err = PageQuery{Cursor: EncodeCursor(cursor), Limit: 10}.PageRequest()
+	require.NoError(t, err)
+	assert.Equal(t, domain.PageRequest{Limit: 10, After: &cursor}, request)
+
+	_, err = PageQuery{Cursor: "garbage"}.PageRequest()
+	assert.Error(t, err)
+}
+
+func TestNewPageResponse(t *testing.T) {
+	response := NewPageResponse([]int{1, 2}, nil)
+	assert.Equal(t, PageResponse[int]{Data: []int{1, 2}}, response)
+
+	next := domain.Cursor{Time: time.Unix(0, 0).UTC(), ID: 2}
+	response = NewPageResponse([]int{1, 2}, &next)
+	assert.Equal(t, EncodeCursor(next), response.NextCursor)
+}
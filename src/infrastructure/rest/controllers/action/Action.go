@@ -0,0 +1,52 @@
+package action
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	actionUseCase "go-multi-chat-api/src/application/usecases/action"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxInteractionSize limits the body of an interaction, LINE batches the webhook events of a channel
+const maxInteractionSize = 1 << 20
+
+type IActionController interface {
+	ReceiveInteraction(ctx *gin.Context)
+}
+
+type ActionController struct {
+	actionUseCase actionUseCase.IActionUseCase
+	Logger        *logger.Logger
+}
+
+func NewActionController(actionUseCase actionUseCase.IActionUseCase, loggerInstance *logger.Logger) IActionController {
+	return &ActionController{actionUseCase: actionUseCase, Logger: loggerInstance}
+}
+
+// ReceiveInteraction is the webhook vendors post the button clicks on the messages of a provider to. It is
+// authenticated by the signature of the vendor instead of a JWT, which covers the raw body.
+func (c *ActionController) ReceiveInteraction(ctx *gin.Context) {
+	providerID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || providerID <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxInteractionSize))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	response, err := c.actionUseCase.ReceiveInteraction(ctx.Param("vendor"), providerID, ctx.Request.Header, body)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json", response)
+}
@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"net/http"
+
+	analyticsUseCase "go-multi-chat-api/src/application/usecases/analytics"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IAnalyticsController interface {
+	GetTagRollup(ctx *gin.Context)
+	GetEngagement(ctx *gin.Context)
+	GetRecipientCaps(ctx *gin.Context)
+}
+
+type AnalyticsController struct {
+	analyticsUseCase analyticsUseCase.IAnalyticsUseCase
+	Logger           *logger.Logger
+}
+
+func NewAnalyticsController(analyticsUseCase analyticsUseCase.IAnalyticsUseCase, loggerInstance *logger.Logger) IAnalyticsController {
+	return &AnalyticsController{analyticsUseCase: analyticsUseCase, Logger: loggerInstance}
+}
+
+// GetTagRollup returns the delivery outcomes of the authenticated user grouped by the values of a tag key
+func (c *AnalyticsController) GetTagRollup(ctx *gin.Context) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return
+	}
+
+	var request TagRollupRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	rollup, err := c.analyticsUseCase.GetTagRollup(&analyticsUseCase.TagRollupRequest{
+		UserID:      int(userID),
+		TagKey:      request.Key,
+		From:        request.From,
+		To:          request.To,
+		Granularity: request.Granularity,
+	})
+	if err != nil {
+		c.Logger.Error("Error getting tag rollup", zap.Error(err), zap.Float64("userID", userID), zap.String("tagKey", request.Key))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := TagRollupResponse{
+		Key:         rollup.TagKey,
+		From:        rollup.From,
+		To:          rollup.To,
+		Granularity: rollup.Granularity,
+		Buckets:     make([]TagRollupBucket, len(rollup.Buckets)),
+	}
+	for i, bucket := range rollup.Buckets {
+		response.Buckets[i] = TagRollupBucket{
+			TagValue:    bucket.TagValue,
+			PeriodStart: bucket.PeriodStart,
+			Total:       bucket.Total,
+			Sent:        bucket.Sent,
+			Failed:      bucket.Failed,
+			Fallbacks:   bucket.Fallbacks,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetEngagement returns the engagement of the recipients of the authenticated user's messages and its rates,
+// grouped by provider, tag value or campaign
+func (c *AnalyticsController) GetEngagement(ctx *gin.Context) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return
+	}
+
+	var request EngagementRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	engagement, err := c.analyticsUseCase.GetEngagement(&analyticsUseCase.EngagementRequest{
+		UserID:  int(userID),
+		GroupBy: request.GroupBy,
+		TagKey:  request.Key,
+		From:    request.From,
+		To:      request.To,
+	})
+	if err != nil {
+		c.Logger.Error("Error getting engagement", zap.Error(err), zap.Float64("userID", userID), zap.String("groupBy", request.GroupBy))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := EngagementResponse{
+		GroupBy: engagement.GroupBy,
+		Key:     engagement.TagKey,
+		From:    engagement.From,
+		To:      engagement.To,
+		Groups:  make([]EngagementGroup, len(engagement.Groups)),
+	}
+	for i, group := range engagement.Groups {
+		response.Groups[i] = EngagementGroup{
+			Group:        group.Group,
+			Recipients:   group.Recipients,
+			Delivered:    group.Delivered,
+			Read:         group.Read,
+			Clicked:      group.Clicked,
+			DeliveryRate: group.DeliveryRate,
+			ReadRate:     group.ReadRate,
+			ClickRate:    group.ClickRate,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetRecipientCaps returns the recipients whose frequency caps held or rejected sends of the authenticated user
+func (c *AnalyticsController) GetRecipientCaps(ctx *gin.Context) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return
+	}
+
+	var request RecipientCapsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	caps, err := c.analyticsUseCase.GetRecipientCaps(&analyticsUseCase.RecipientCapsRequest{
+		UserID: int(userID),
+		From:   request.From,
+		To:     request.To,
+	})
+	if err != nil {
+		c.Logger.Error("Error getting recipient caps", zap.Error(err), zap.Float64("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := RecipientCapsResponse{
+		From:       caps.From,
+		To:         caps.To,
+		Recipients: make([]RecipientCapRollup, len(caps.Recipients)),
+	}
+	for i, rollup := range caps.Recipients {
+		response.Recipients[i] = RecipientCapRollup{
+			Recipient:       rollup.Recipient,
+			Held:            rollup.Held,
+			Rejected:        rollup.Rejected,
+			LastViolationAt: rollup.LastViolationAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
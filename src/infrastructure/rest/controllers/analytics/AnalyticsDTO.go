@@ -0,0 +1,71 @@
+package analytics
+
+import "time"
+
+type TagRollupRequest struct {
+	Key         string    `form:"key" binding:"required"`
+	From        time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Granularity string    `form:"granularity" binding:"omitempty,oneof=day week total"`
+}
+
+type TagRollupBucket struct {
+	TagValue    string    `json:"tag_value"`
+	PeriodStart time.Time `json:"period_start"`
+	Total       int       `json:"total"`
+	Sent        int       `json:"sent"`
+	Failed      int       `json:"failed"`
+	Fallbacks   int       `json:"fallbacks"`
+}
+
+type TagRollupResponse struct {
+	Key         string            `json:"key"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Granularity string            `json:"granularity"`
+	Buckets     []TagRollupBucket `json:"buckets"`
+}
+
+type EngagementRequest struct {
+	GroupBy string    `form:"group_by" binding:"omitempty,oneof=provider tag campaign"`
+	Key     string    `form:"key"`
+	From    time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type EngagementGroup struct {
+	Group        string  `json:"group"`
+	Recipients   int     `json:"recipients"`
+	Delivered    int     `json:"delivered"`
+	Read         int     `json:"read"`
+	Clicked      int     `json:"clicked"`
+	DeliveryRate float64 `json:"delivery_rate"`
+	ReadRate     float64 `json:"read_rate"`
+	ClickRate    float64 `json:"click_rate"`
+}
+
+type EngagementResponse struct {
+	GroupBy string            `json:"group_by"`
+	Key     string            `json:"key,omitempty"`
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Groups  []EngagementGroup `json:"groups"`
+}
+
+type RecipientCapsRequest struct {
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type RecipientCapRollup struct {
+	Recipient       string    `json:"recipient"`
+	Held            int       `json:"held"`
+	Rejected        int       `json:"rejected"`
+	LastViolationAt time.Time `json:"last_violation_at"`
+}
+
+type RecipientCapsResponse struct {
+	From       time.Time            `json:"from"`
+	To         time.Time            `json:"to"`
+	Recipients []RecipientCapRollup `json:"recipients"`
+}
@@ -0,0 +1,58 @@
+package anonymize
+
+import (
+	"net/http"
+
+	anonymizeUseCase "go-multi-chat-api/src/application/usecases/anonymize"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IAnonymizeController interface {
+	Start(ctx *gin.Context)
+}
+
+type AnonymizeController struct {
+	anonymizeUseCase anonymizeUseCase.IAnonymizeUseCase
+	Logger           *logger.Logger
+}
+
+func NewAnonymizeController(anonymizeUseCase anonymizeUseCase.IAnonymizeUseCase, loggerInstance *logger.Logger) IAnonymizeController {
+	return &AnonymizeController{anonymizeUseCase: anonymizeUseCase, Logger: loggerInstance}
+}
+
+// Start queues a job anonymizing the database in place, its progress is followed through the jobs endpoints
+func (c *AnonymizeController) Start(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request StartRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	job, err := c.anonymizeUseCase.Start(userID, request.Confirm)
+	if err != nil {
+		c.Logger.Error("Error starting anonymization", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{JobID: job.ID, JobStatus: job.Status})
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *AnonymizeController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
@@ -0,0 +1,11 @@
+package anonymize
+
+type StartRequest struct {
+	// Confirm is the name of the database, so a production database isn't anonymized by mistake
+	Confirm string `json:"confirm" binding:"required"`
+}
+
+type StartResponse struct {
+	JobID     int    `json:"job_id"`
+	JobStatus string `json:"job_status"`
+}
@@ -0,0 +1,121 @@
+package archive
+
+import (
+	"net/http"
+	"time"
+
+	archiveUseCase "go-multi-chat-api/src/application/usecases/archive"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultBatchLimit is the number of files listed when the request doesn't set a limit
+const defaultBatchLimit = 50
+
+type IArchiveController interface {
+	Start(ctx *gin.Context)
+	GetBatches(ctx *gin.Context)
+	Locate(ctx *gin.Context)
+}
+
+type ArchiveController struct {
+	archiveUseCase archiveUseCase.IArchiveUseCase
+	Logger         *logger.Logger
+}
+
+func NewArchiveController(archiveUseCase archiveUseCase.IArchiveUseCase, loggerInstance *logger.Logger) IArchiveController {
+	return &ArchiveController{archiveUseCase: archiveUseCase, Logger: loggerInstance}
+}
+
+// Start queues a job archiving the message history older than the configured age, its progress is followed
+// through the jobs endpoints
+func (c *ArchiveController) Start(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.archiveUseCase.Start(userID)
+	if err != nil {
+		c.Logger.Error("Error starting message archive", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{JobID: job.ID, JobStatus: job.Status})
+}
+
+// GetBatches lists the archived files, optionally of the days from through to, newest first
+func (c *ArchiveController) GetBatches(ctx *gin.Context) {
+	var request BatchesRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultBatchLimit
+	}
+
+	batches, err := c.archiveUseCase.GetBatches(request.From, request.To, request.Limit)
+	if err != nil {
+		c.Logger.Error("Error getting message archive batches", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]BatchResponse, len(batches))
+	for i, batch := range batches {
+		response[i] = BatchResponse{
+			ID:        batch.ID,
+			Org:       batch.Org,
+			Day:       batch.Day.Format(time.DateOnly),
+			Target:    batch.Target,
+			FirstID:   batch.FirstID,
+			LastID:    batch.LastID,
+			Records:   batch.Records,
+			Location:  batch.Location,
+			Deleted:   batch.Deleted,
+			CreatedAt: batch.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Locate tells clients whether the message history of a range is queried from the database or has to be read
+// from the archived files
+func (c *ArchiveController) Locate(ctx *gin.Context) {
+	var request LocationRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	location, err := c.archiveUseCase.Locate(request.From, request.To)
+	if err != nil {
+		c.Logger.Error("Error locating message history", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, LocationResponse{
+		From:      request.From,
+		To:        request.To,
+		Location:  location.Location,
+		WarmFrom:  location.WarmFrom,
+		ColdFrom:  location.ColdFrom,
+		ColdUntil: location.ColdUntil,
+		Files:     location.Files,
+	})
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *ArchiveController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
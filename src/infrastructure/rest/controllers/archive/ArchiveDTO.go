@@ -0,0 +1,42 @@
+package archive
+
+import "time"
+
+type BatchesRequest struct {
+	From  time.Time `form:"from" time_format:"2006-01-02"`
+	To    time.Time `form:"to" time_format:"2006-01-02"`
+	Limit int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type LocationRequest struct {
+	From time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+	To   time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+}
+
+type StartResponse struct {
+	JobID     int    `json:"job_id"`
+	JobStatus string `json:"job_status"`
+}
+
+type BatchResponse struct {
+	ID        int       `json:"id"`
+	Org       string    `json:"org"`
+	Day       string    `json:"day"`
+	Target    string    `json:"target"`
+	FirstID   int       `json:"first_id"`
+	LastID    int       `json:"last_id"`
+	Records   int       `json:"records"`
+	Location  string    `json:"location"`
+	Deleted   bool      `json:"deleted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LocationResponse struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Location  string     `json:"location"`
+	WarmFrom  *time.Time `json:"warm_from"`
+	ColdFrom  *time.Time `json:"cold_from"`
+	ColdUntil *time.Time `json:"cold_until"`
+	Files     int64      `json:"files"`
+}
@@ -0,0 +1,159 @@
+package attachment
+
+import (
+	"net/http"
+	"strconv"
+
+	attachmentUseCase "go-multi-chat-api/src/application/usecases/attachment"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IAttachmentController interface {
+	Start(ctx *gin.Context)
+	UploadPart(ctx *gin.Context)
+	GetAttachment(ctx *gin.Context)
+	Complete(ctx *gin.Context)
+	Abort(ctx *gin.Context)
+}
+
+type AttachmentController struct {
+	attachmentUseCase attachmentUseCase.IAttachmentUseCase
+	Logger            *logger.Logger
+}
+
+func NewAttachmentController(attachmentUseCase attachmentUseCase.IAttachmentUseCase, loggerInstance *logger.Logger) IAttachmentController {
+	return &AttachmentController{attachmentUseCase: attachmentUseCase, Logger: loggerInstance}
+}
+
+// Start declares an attachment and returns the size and number of the parts to upload
+func (c *AttachmentController) Start(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request StartRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	attachment, err := c.attachmentUseCase.Start(userID, attachmentUseCase.StartRequest{
+		Filename: request.Filename,
+		MimeType: request.MimeType,
+		Size:     request.Size,
+		SHA256:   request.SHA256,
+	})
+	if err != nil {
+		c.Logger.Error("Error starting attachment upload", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toResponse(attachment, nil))
+}
+
+// UploadPart stores the raw request body as a part of the attachment, numbered from 1
+func (c *AttachmentController) UploadPart(ctx *gin.Context) {
+	userID, id, ok := c.attachmentID(ctx)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(ctx.Param("part"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	if err := c.attachmentUseCase.UploadPart(userID, id, number, ctx.Request.Body); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetAttachment reports an attachment with the parts received so far, so an interrupted upload is resumed with the
+// missing parts
+func (c *AttachmentController) GetAttachment(ctx *gin.Context) {
+	userID, id, ok := c.attachmentID(ctx)
+	if !ok {
+		return
+	}
+	attachment, received, err := c.attachmentUseCase.Get(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(attachment, received))
+}
+
+// Complete assembles the uploaded parts, the attachment is sent by its ID from then on
+func (c *AttachmentController) Complete(ctx *gin.Context) {
+	userID, id, ok := c.attachmentID(ctx)
+	if !ok {
+		return
+	}
+	attachment, err := c.attachmentUseCase.Complete(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(attachment, nil))
+}
+
+// Abort deletes an attachment and the parts uploaded so far
+func (c *AttachmentController) Abort(ctx *gin.Context) {
+	userID, id, ok := c.attachmentID(ctx)
+	if !ok {
+		return
+	}
+	if err := c.attachmentUseCase.Abort(userID, id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// attachmentID reads the user ID set by the JWT middleware and the attachment ID of the path
+func (c *AttachmentController) attachmentID(ctx *gin.Context) (int, int, bool) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *AttachmentController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func toResponse(attachment *provider.Attachment, received []int) AttachmentResponse {
+	return AttachmentResponse{
+		ID:            attachment.ID,
+		Filename:      attachment.Filename,
+		MimeType:      attachment.MimeType,
+		Size:          attachment.Size,
+		SHA256:        attachment.SHA256,
+		Status:        attachment.Status,
+		ChunkSize:     attachment.ChunkSize,
+		Parts:         attachment.Parts(),
+		ReceivedParts: received,
+		ExpiresAt:     attachment.ExpiresAt,
+		CompletedAt:   attachment.CompletedAt,
+		CreatedAt:     attachment.CreatedAt,
+	}
+}
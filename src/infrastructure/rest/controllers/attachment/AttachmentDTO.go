@@ -0,0 +1,25 @@
+package attachment
+
+import "time"
+
+type StartRequest struct {
+	Filename string `json:"filename" binding:"required,max=255"`
+	MimeType string `json:"mime_type" binding:"required,max=127"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+	SHA256   string `json:"sha256" binding:"omitempty,len=64,hexadecimal"`
+}
+
+type AttachmentResponse struct {
+	ID            int        `json:"id"`
+	Filename      string     `json:"filename"`
+	MimeType      string     `json:"mime_type"`
+	Size          int64      `json:"size"`
+	SHA256        string     `json:"sha256,omitempty"`
+	Status        string     `json:"status"`
+	ChunkSize     int64      `json:"chunk_size"`
+	Parts         int        `json:"parts"`
+	ReceivedParts []int      `json:"received_parts,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
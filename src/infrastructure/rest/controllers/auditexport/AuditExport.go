@@ -0,0 +1,94 @@
+package auditexport
+
+import (
+	"net/http"
+
+	auditExportUseCase "go-multi-chat-api/src/application/usecases/auditexport"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultBatchLimit is the number of batches listed when the request doesn't set a limit
+const defaultBatchLimit = 50
+
+type IAuditExportController interface {
+	Start(ctx *gin.Context)
+	GetBatches(ctx *gin.Context)
+}
+
+type AuditExportController struct {
+	auditExportUseCase auditExportUseCase.IAuditExportUseCase
+	Logger             *logger.Logger
+}
+
+func NewAuditExportController(auditExportUseCase auditExportUseCase.IAuditExportUseCase, loggerInstance *logger.Logger) IAuditExportController {
+	return &AuditExportController{auditExportUseCase: auditExportUseCase, Logger: loggerInstance}
+}
+
+// Start queues a job exporting the audit records added since the last export, its progress is followed through
+// the jobs endpoints
+func (c *AuditExportController) Start(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	job, err := c.auditExportUseCase.Start(userID)
+	if err != nil {
+		c.Logger.Error("Error starting audit export", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{JobID: job.ID, JobStatus: job.Status})
+}
+
+// GetBatches lists the most recently exported batches with their checkpoints and integrity hashes, newest first
+func (c *AuditExportController) GetBatches(ctx *gin.Context) {
+	var request BatchesRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultBatchLimit
+	}
+
+	batches, err := c.auditExportUseCase.GetBatches(request.Limit)
+	if err != nil {
+		c.Logger.Error("Error getting audit export batches", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]BatchResponse, len(batches))
+	for i, batch := range batches {
+		response[i] = BatchResponse{
+			ID:           batch.ID,
+			Source:       batch.Source,
+			Format:       batch.Format,
+			Target:       batch.Target,
+			FirstID:      batch.FirstID,
+			LastID:       batch.LastID,
+			Records:      batch.Records,
+			Location:     batch.Location,
+			Hash:         batch.Hash,
+			PreviousHash: batch.PreviousHash,
+			CreatedAt:    batch.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *AuditExportController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
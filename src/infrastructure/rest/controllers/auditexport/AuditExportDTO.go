@@ -0,0 +1,26 @@
+package auditexport
+
+import "time"
+
+type BatchesRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type StartResponse struct {
+	JobID     int    `json:"job_id"`
+	JobStatus string `json:"job_status"`
+}
+
+type BatchResponse struct {
+	ID           int       `json:"id"`
+	Source       string    `json:"source"`
+	Format       string    `json:"format"`
+	Target       string    `json:"target"`
+	FirstID      int       `json:"first_id"`
+	LastID       int       `json:"last_id"`
+	Records      int       `json:"records"`
+	Location     string    `json:"location,omitempty"`
+	Hash         string    `json:"hash"`
+	PreviousHash string    `json:"previous_hash,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
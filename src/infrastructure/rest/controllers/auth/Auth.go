@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 
 	useCaseAuth "go-multi-chat-api/src/application/usecases/auth"
+	"go-multi-chat-api/src/application/usecases/loginaudit"
 	domainErrors "go-multi-chat-api/src/domain/errors"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	"go-multi-chat-api/src/infrastructure/rest/controllers"
@@ -20,14 +22,17 @@ type IAuthController interface {
 }
 
 type AuthController struct {
-	authUseCase useCaseAuth.IAuthUseCase
-	Logger      *logger.Logger
+	authUseCase       useCaseAuth.IAuthUseCase
+	loginAuditUseCase loginaudit.ILoginAuditUseCase
+	Logger            *logger.Logger
 }
 
-func NewAuthController(authUsecase useCaseAuth.IAuthUseCase, loggerInstance *logger.Logger) IAuthController {
+// NewAuthController creates the auth controller. Login attempts are audited unless loginAuditUseCase is nil.
+func NewAuthController(authUsecase useCaseAuth.IAuthUseCase, loginAuditUseCase loginaudit.ILoginAuditUseCase, loggerInstance *logger.Logger) IAuthController {
 	return &AuthController{
-		authUseCase: authUsecase,
-		Logger:      loggerInstance,
+		authUseCase:       authUsecase,
+		loginAuditUseCase: loginAuditUseCase,
+		Logger:            loggerInstance,
 	}
 }
 
@@ -44,9 +49,15 @@ func (c *AuthController) Login(ctx *gin.Context) {
 	domainUser, authTokens, err := c.authUseCase.Login(request.Email, request.Password)
 	if err != nil {
 		c.Logger.Error("Login failed", zap.Error(err), zap.String("email", request.Email))
+		// Only rejected credentials count as failed logins, not e.g. database errors
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotAuthenticated {
+			c.recordLogin(ctx, loginaudit.LoginAttempt{Email: request.Email, Method: loginaudit.MethodPassword, FailureReason: err.Error()})
+		}
 		_ = ctx.Error(err)
 		return
 	}
+	c.recordLogin(ctx, loginaudit.LoginAttempt{UserID: domainUser.ID, Email: domainUser.Email, Method: loginaudit.MethodPassword, Success: true})
 
 	response := LoginResponse{
 		Data: UserData{
@@ -151,6 +162,7 @@ func (c *AuthController) CompleteAzureADAuth(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
+	c.recordLogin(ctx, loginaudit.LoginAttempt{UserID: domainUser.ID, Email: domainUser.Email, Method: loginaudit.MethodAzureAD, Success: true})
 
 	response := LoginResponse{
 		Data: UserData{
@@ -172,3 +184,13 @@ func (c *AuthController) CompleteAzureADAuth(ctx *gin.Context) {
 	c.Logger.Info("Azure AD auth completion successful", zap.Int("userID", domainUser.ID))
 	ctx.JSON(http.StatusOK, response)
 }
+
+// recordLogin audits a login attempt with the client IP address and user agent of the request
+func (c *AuthController) recordLogin(ctx *gin.Context, attempt loginaudit.LoginAttempt) {
+	if c.loginAuditUseCase == nil {
+		return
+	}
+	attempt.IPAddress = ctx.ClientIP()
+	attempt.UserAgent = ctx.Request.UserAgent()
+	c.loginAuditUseCase.RecordLogin(attempt)
+}
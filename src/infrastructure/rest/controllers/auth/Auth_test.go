@@ -15,6 +15,7 @@ import (
 	logger "go-multi-chat-api/src/infrastructure/logger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // MockAuthUseCase implements IAuthUseCase for testing
@@ -53,6 +54,10 @@ func (m *MockAuthUseCase) CompleteAzureADAuth(code, state string) (*userDomain.U
 	return nil, nil, nil
 }
 
+func (m *MockAuthUseCase) ExternalTokenClaims(tokenString string) (jwt.MapClaims, error) {
+	return nil, errors.New("external tokens are not accepted")
+}
+
 func setupLogger(t *testing.T) *logger.Logger {
 	loggerInstance, err := logger.NewLogger()
 	if err != nil {
@@ -64,7 +69,7 @@ func setupLogger(t *testing.T) *logger.Logger {
 func TestNewAuthController(t *testing.T) {
 	mockUseCase := &MockAuthUseCase{}
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	if controller == nil {
 		t.Error("Expected NewAuthController to return a non-nil controller")
@@ -98,7 +103,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	loginRequest := LoginRequest{
@@ -135,7 +140,7 @@ func TestAuthController_Login_InvalidRequest(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create invalid request (missing required fields)
 	requestBody := []byte(`{"email": "test@example.com"}`) // Missing password
@@ -185,7 +190,7 @@ func TestAuthController_GetAccessTokenByRefreshToken_Success(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	accessTokenRequest := AccessTokenRequest{
@@ -221,7 +226,7 @@ func TestAuthController_GetAccessTokenByRefreshToken_InvalidRequest(t *testing.T
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create invalid request (missing required fields)
 	requestBody := []byte(`{}`) // Missing refreshToken
@@ -289,7 +294,7 @@ func TestAuthController_InitiateAzureADAuth_Success(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	authRequest := AzureADAuthRequest{
@@ -344,7 +349,7 @@ func TestAuthController_InitiateAzureADAuth_Error(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	authRequest := AzureADAuthRequest{
@@ -398,7 +403,7 @@ func TestAuthController_CompleteAzureADAuth_Success(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	callbackRequest := AzureADCallbackRequest{
@@ -454,7 +459,7 @@ func TestAuthController_CompleteAzureADAuth_Error(t *testing.T) {
 
 	// Create controller
 	logger := setupLogger(t)
-	controller := NewAuthController(mockUseCase, logger)
+	controller := NewAuthController(mockUseCase, nil, logger)
 
 	// Create test request
 	callbackRequest := AzureADCallbackRequest{
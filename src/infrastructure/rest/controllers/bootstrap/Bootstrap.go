@@ -0,0 +1,152 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	bootstrapUseCase "go-multi-chat-api/src/application/usecases/bootstrap"
+	domainBootstrap "go-multi-chat-api/src/domain/bootstrap"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IBootstrapController interface {
+	Apply(ctx *gin.Context)
+}
+
+type BootstrapController struct {
+	bootstrapUseCase bootstrapUseCase.IBootstrapUseCase
+	Logger           *logger.Logger
+}
+
+func NewBootstrapController(bootstrapUseCase bootstrapUseCase.IBootstrapUseCase, loggerInstance *logger.Logger) IBootstrapController {
+	return &BootstrapController{bootstrapUseCase: bootstrapUseCase, Logger: loggerInstance}
+}
+
+// Apply validates a bootstrap document and applies it atomically, listing what was created and updated. With
+// dry_run the changes are only listed.
+func (c *BootstrapController) Apply(ctx *gin.Context) {
+	var query BootstrapQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	var request BootstrapRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	document, err := toDocument(&request)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	changes, err := c.bootstrapUseCase.Apply(document, query.DryRun)
+	if err != nil {
+		var validationErr *providerconfig.ValidationError
+		if errors.As(err, &validationErr) {
+			ctx.JSON(http.StatusBadRequest, InvalidConfigResponse{Error: "invalid config", Fields: validationErr.Errors})
+			return
+		}
+		c.Logger.Error("Error applying bootstrap document", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	response := BootstrapResponse{DryRun: query.DryRun, Changes: make([]ChangeResponse, len(changes))}
+	for i, change := range changes {
+		response.Changes[i] = ChangeResponse{
+			Kind:   change.Kind,
+			Name:   change.Name,
+			User:   change.User,
+			Action: change.Action,
+			ID:     change.ID,
+			Secret: change.Secret,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// toDocument fills in the defaults of a request and compacts its configs the way they are stored
+func toDocument(request *BootstrapRequest) (*bootstrapUseCase.Document, error) {
+	document := &bootstrapUseCase.Document{
+		Org:           request.Org,
+		Users:         make([]bootstrapUseCase.User, len(request.Users)),
+		Roles:         request.Roles,
+		Providers:     make([]domainBootstrap.Provider, len(request.Providers)),
+		UserProviders: make([]domainBootstrap.UserProvider, len(request.UserProviders)),
+		Webhooks:      make([]domainBootstrap.Webhook, len(request.Webhooks)),
+		Templates:     make([]domainBootstrap.Template, len(request.Templates)),
+	}
+	for i, u := range request.Users {
+		document.Users[i] = bootstrapUseCase.User{
+			User: domainBootstrap.User{
+				Email:            u.Email,
+				UserName:         u.UserName,
+				FirstName:        u.FirstName,
+				LastName:         u.LastName,
+				PasswordHash:     u.PasswordHash,
+				Role:             u.Role,
+				Locale:           u.Locale,
+				Status:           u.Status == nil || *u.Status,
+				MessageRateLimit: u.MessageRateLimit,
+			},
+			Password: u.Password,
+		}
+	}
+	for i, p := range request.Providers {
+		config, err := configString(p.Config)
+		if err != nil {
+			return nil, err
+		}
+		document.Providers[i] = domainBootstrap.Provider{
+			Name:        p.Name,
+			Type:        p.Type,
+			Description: p.Description,
+			Config:      config,
+			Status:      p.Status == nil || *p.Status,
+		}
+	}
+	for i, up := range request.UserProviders {
+		config, err := configString(up.Config)
+		if err != nil {
+			return nil, err
+		}
+		priority := up.Priority
+		if priority == 0 {
+			priority = 1
+		}
+		document.UserProviders[i] = domainBootstrap.UserProvider{
+			User:     up.User,
+			Provider: up.Provider,
+			Priority: priority,
+			Config:   config,
+			Status:   up.Status == nil || *up.Status,
+		}
+	}
+	for i, w := range request.Webhooks {
+		document.Webhooks[i] = domainBootstrap.Webhook{User: w.User, Event: w.Event, TargetURL: w.TargetURL}
+	}
+	for i, t := range request.Templates {
+		document.Templates[i] = domainBootstrap.Template{User: t.User, Name: t.Name, Subject: t.Subject, HTML: t.HTML}
+	}
+	return document, nil
+}
+
+// configString compacts a config sent as a JSON object into the text it is stored as, empty when none is given
+func configString(config json.RawMessage) (string, error) {
+	if len(config) == 0 || string(config) == "null" {
+		return "", nil
+	}
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, config); err != nil {
+		return "", domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return buffer.String(), nil
+}
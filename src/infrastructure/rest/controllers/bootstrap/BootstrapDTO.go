@@ -0,0 +1,91 @@
+package bootstrap
+
+import (
+	"encoding/json"
+
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+)
+
+type BootstrapQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// BootstrapRequest is the declarative setup of a deployment. Resources are matched by email, provider name,
+// user and provider, user, event and target URL, and user and template name.
+type BootstrapRequest struct {
+	// Org must be the JWT_ORG of the deployment when one is set
+	Org   string        `json:"org"`
+	Users []UserRequest `json:"users" binding:"dive"`
+	// Roles lists the users of each role by email
+	Roles         map[string][]string   `json:"roles"`
+	Providers     []ProviderRequest     `json:"providers" binding:"dive"`
+	UserProviders []UserProviderRequest `json:"user_providers" binding:"dive"`
+	Webhooks      []WebhookRequest      `json:"webhooks" binding:"dive"`
+	Templates     []TemplateRequest     `json:"templates" binding:"dive"`
+}
+
+// UserRequest is a user of a document. The password, or its bcrypt hash, is only needed to create the user.
+// Role defaults to member, status to active and message_rate_limit to 1000.
+type UserRequest struct {
+	Email            string `json:"email" binding:"required,email"`
+	UserName         string `json:"user_name" binding:"required"`
+	FirstName        string `json:"first_name"`
+	LastName         string `json:"last_name"`
+	Password         string `json:"password"`
+	PasswordHash     string `json:"password_hash"`
+	Role             string `json:"role"`
+	Locale           string `json:"locale"`
+	Status           *bool  `json:"status"`
+	MessageRateLimit int    `json:"message_rate_limit"`
+}
+
+type ProviderRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Type        string          `json:"type" binding:"required"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	Status      *bool           `json:"status"`
+}
+
+// UserProviderRequest links a user, by email, to a provider, by name. Priority defaults to 1 and status to
+// active.
+type UserProviderRequest struct {
+	User     string          `json:"user" binding:"required"`
+	Provider string          `json:"provider" binding:"required"`
+	Priority int             `json:"priority"`
+	Config   json.RawMessage `json:"config"`
+	Status   *bool           `json:"status"`
+}
+
+type WebhookRequest struct {
+	User      string `json:"user" binding:"required"`
+	Event     string `json:"event" binding:"required"`
+	TargetURL string `json:"target_url" binding:"required"`
+}
+
+type TemplateRequest struct {
+	User    string `json:"user" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html" binding:"required"`
+}
+
+type BootstrapResponse struct {
+	DryRun  bool             `json:"dry_run"`
+	Changes []ChangeResponse `json:"changes"`
+}
+
+type ChangeResponse struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	User   string `json:"user,omitempty"`
+	Action string `json:"action"`
+	ID     int    `json:"id,omitempty"`
+	// Secret is the secret of a created webhook, it can't be read again
+	Secret string `json:"secret,omitempty"`
+}
+
+type InvalidConfigResponse struct {
+	Error  string                      `json:"error"`
+	Fields []providerconfig.FieldError `json:"fields"`
+}
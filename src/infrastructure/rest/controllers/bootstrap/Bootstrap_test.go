@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToDocument_FillsInDefaults(t *testing.T) {
+	inactive := false
+	request := &BootstrapRequest{
+		Users:         []UserRequest{{Email: "ops@example.com", UserName: "ops", Password: "secret123"}},
+		Providers:     []ProviderRequest{{Name: "alerts", Type: "signal", Config: json.RawMessage(`{ "warmup": { "enabled": true } }`), Status: &inactive}},
+		UserProviders: []UserProviderRequest{{User: "ops@example.com", Provider: "alerts"}},
+	}
+
+	document, err := toDocument(request)
+	require.NoError(t, err)
+	assert.True(t, document.Users[0].Status)
+	assert.Equal(t, "secret123", document.Users[0].Password)
+	assert.False(t, document.Providers[0].Status)
+	assert.Equal(t, `{"warmup":{"enabled":true}}`, document.Providers[0].Config)
+	assert.Equal(t, 1, document.UserProviders[0].Priority)
+	assert.True(t, document.UserProviders[0].Status)
+	assert.Empty(t, document.UserProviders[0].Config)
+}
+
+func TestToDocument_RejectsInvalidConfig(t *testing.T) {
+	_, err := toDocument(&BootstrapRequest{Providers: []ProviderRequest{{Name: "alerts", Type: "signal", Config: json.RawMessage(`{`)}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package bulkoperation
+
+import (
+	"net/http"
+
+	bulkOperationUseCase "go-multi-chat-api/src/application/usecases/bulkoperation"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IBulkOperationController interface {
+	Start(ctx *gin.Context)
+}
+
+type BulkOperationController struct {
+	bulkOperationUseCase bulkOperationUseCase.IBulkOperationUseCase
+	Logger               *logger.Logger
+}
+
+func NewBulkOperationController(bulkOperationUseCase bulkOperationUseCase.IBulkOperationUseCase, loggerInstance *logger.Logger) IBulkOperationController {
+	return &BulkOperationController{bulkOperationUseCase: bulkOperationUseCase, Logger: loggerInstance}
+}
+
+// Start queues a job requeuing or cancelling the messages matching a filter, its progress is followed through
+// the jobs endpoints
+func (c *BulkOperationController) Start(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request StartRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	filter := provider.BulkOperationFilter{
+		Status:     request.Status,
+		ProviderID: request.ProviderID,
+		UserID:     request.UserID,
+		From:       request.From,
+		To:         request.To,
+	}
+	job, payload, err := c.bulkOperationUseCase.Start(request.Action, filter, userID)
+	if err != nil {
+		c.Logger.Error("Error starting bulk operation", zap.Error(err), zap.String("action", request.Action))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{
+		JobID:     job.ID,
+		JobStatus: job.Status,
+		Action:    payload.Action,
+		Filter: FilterResponse{
+			Status:     payload.Filter.Status,
+			ProviderID: payload.Filter.ProviderID,
+			UserID:     payload.Filter.UserID,
+			From:       payload.Filter.From,
+			To:         payload.Filter.To,
+		},
+		Matched: payload.Matched,
+	})
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *BulkOperationController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
@@ -0,0 +1,28 @@
+package bulkoperation
+
+import "time"
+
+type StartRequest struct {
+	Action     string     `json:"action" binding:"required,oneof=requeue cancel"`
+	Status     string     `json:"status" binding:"required"`
+	ProviderID int        `json:"provider_id" binding:"omitempty,min=1"`
+	UserID     int        `json:"user_id" binding:"omitempty,min=1"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+}
+
+type FilterResponse struct {
+	Status     string     `json:"status"`
+	ProviderID int        `json:"provider_id,omitempty"`
+	UserID     int        `json:"user_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+}
+
+type StartResponse struct {
+	JobID     int            `json:"job_id"`
+	JobStatus string         `json:"job_status"`
+	Action    string         `json:"action"`
+	Filter    FilterResponse `json:"filter"`
+	Matched   int            `json:"matched"`
+}
@@ -0,0 +1,143 @@
+package contenttransform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	contentTransformUseCase "go-multi-chat-api/src/application/usecases/contenttransform"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/transform"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IContentTransformController interface {
+	GetTransforms(ctx *gin.Context)
+	SetTransforms(ctx *gin.Context)
+	TestTransforms(ctx *gin.Context)
+}
+
+type ContentTransformController struct {
+	contentTransformUseCase contentTransformUseCase.IContentTransformUseCase
+	Logger                  *logger.Logger
+}
+
+func NewContentTransformController(contentTransformUseCase contentTransformUseCase.IContentTransformUseCase, loggerInstance *logger.Logger) IContentTransformController {
+	return &ContentTransformController{contentTransformUseCase: contentTransformUseCase, Logger: loggerInstance}
+}
+
+// GetTransforms returns the content transformations of the authenticated user in the order they are applied
+func (c *ContentTransformController) GetTransforms(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	transforms, err := c.contentTransformUseCase.GetTransforms(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, transformsToResponse(transforms))
+}
+
+// SetTransforms replaces the content transformations of the authenticated user, an empty list removes them
+func (c *ContentTransformController) SetTransforms(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request SetTransformsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	transforms := make([]provider.ContentTransform, len(request.Transforms))
+	for i, t := range request.Transforms {
+		config, err := configString(t.Config)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		transforms[i] = provider.ContentTransform{Type: t.Type, Config: config, Disabled: t.Disabled}
+	}
+
+	saved, err := c.contentTransformUseCase.SetTransforms(userID, transforms)
+	if err != nil {
+		c.Logger.Info("Error setting content transforms", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, transformsToResponse(saved))
+}
+
+// TestTransforms applies the content transformations of the authenticated user to a message without sending it
+func (c *ContentTransformController) TestTransforms(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request TestTransformsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	message, err := c.contentTransformUseCase.Test(userID, request.Type, request.Message)
+	if err != nil {
+		var transformErr *transform.Error
+		if errors.As(err, &transformErr) {
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "transform_failed", "position": transformErr.Position})
+			return
+		}
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, TestTransformsResponse{Message: message})
+}
+
+func currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+// configString compacts a config sent as a JSON object into the text it is stored as, empty when none is given
+func configString(config json.RawMessage) (string, error) {
+	if len(config) == 0 || string(config) == "null" {
+		return "", nil
+	}
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, config); err != nil {
+		return "", domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return buffer.String(), nil
+}
+
+func transformsToResponse(transforms *[]provider.ContentTransform) []ContentTransformResponse {
+	response := make([]ContentTransformResponse, len(*transforms))
+	for i, t := range *transforms {
+		response[i] = ContentTransformResponse{
+			ID:        t.ID,
+			Position:  t.Position,
+			Type:      t.Type,
+			Disabled:  t.Disabled,
+			UpdatedAt: t.UpdatedAt,
+		}
+		if t.Config != "" {
+			response[i].Config = json.RawMessage(t.Config)
+		}
+	}
+	return response
+}
@@ -0,0 +1,36 @@
+package contenttransform
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ContentTransformRequest is a transformation in the ordered list of a user, config is a JSON object with the
+// settings of its type
+type ContentTransformRequest struct {
+	Type     string          `json:"type" binding:"required"`
+	Config   json.RawMessage `json:"config,omitempty"`
+	Disabled bool            `json:"disabled"`
+}
+
+type SetTransformsRequest struct {
+	Transforms []ContentTransformRequest `json:"transforms"`
+}
+
+type TestTransformsRequest struct {
+	Type    string `json:"type"`
+	Message string `json:"message" binding:"required"`
+}
+
+type TestTransformsResponse struct {
+	Message string `json:"message"`
+}
+
+type ContentTransformResponse struct {
+	ID        int             `json:"id"`
+	Position  int             `json:"position"`
+	Type      string          `json:"type"`
+	Config    json.RawMessage `json:"config,omitempty"`
+	Disabled  bool            `json:"disabled"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
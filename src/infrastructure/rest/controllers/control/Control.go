@@ -0,0 +1,62 @@
+package control
+
+import (
+	"net/http"
+
+	controlUseCase "go-multi-chat-api/src/application/usecases/control"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IControlController interface {
+	GetCommands(ctx *gin.Context)
+}
+
+type ControlController struct {
+	controlUseCase controlUseCase.IControlUseCase
+	Logger         *logger.Logger
+}
+
+func NewControlController(controlUseCase controlUseCase.IControlUseCase, loggerInstance *logger.Logger) IControlController {
+	return &ControlController{controlUseCase: controlUseCase, Logger: loggerInstance}
+}
+
+// GetCommands returns a page of the audit log of the control commands operators sent through Signal, newest first
+func (c *ControlController) GetCommands(ctx *gin.Context) {
+	var request CommandsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	page, err := request.PageRequest()
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	commands, err := c.controlUseCase.GetCommands(page)
+	if err != nil {
+		c.Logger.Error("Error getting control commands", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]CommandResponse, len(commands.Items))
+	for i, command := range commands.Items {
+		response[i] = CommandResponse{
+			ID:         command.ID,
+			Sender:     command.Sender,
+			SenderUUID: command.SenderUUID,
+			Text:       command.Text,
+			Command:    command.Command,
+			Status:     command.Status,
+			Result:     command.Result,
+			CreatedAt:  command.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, controllers.NewPageResponse(response, commands.Next))
+}
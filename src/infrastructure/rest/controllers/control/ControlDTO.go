@@ -0,0 +1,22 @@
+package control
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+)
+
+type CommandsRequest struct {
+	controllers.PageQuery
+}
+
+type CommandResponse struct {
+	ID         int       `json:"id"`
+	Sender     string    `json:"sender"`
+	SenderUUID string    `json:"sender_uuid,omitempty"`
+	Text       string    `json:"text"`
+	Command    string    `json:"command,omitempty"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,156 @@
+package conversation
+
+import (
+	"net/http"
+	"strconv"
+
+	conversationUseCase "go-multi-chat-api/src/application/usecases/conversation"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultLimit = 50
+
+type IConversationController interface {
+	GetConversations(ctx *gin.Context)
+	GetMessages(ctx *gin.Context)
+	Rebuild(ctx *gin.Context)
+	GetRebuildStatus(ctx *gin.Context)
+}
+
+type ConversationController struct {
+	conversationUseCase conversationUseCase.IConversationUseCase
+	Logger              *logger.Logger
+}
+
+func NewConversationController(conversationUseCase conversationUseCase.IConversationUseCase, loggerInstance *logger.Logger) IConversationController {
+	return &ConversationController{conversationUseCase: conversationUseCase, Logger: loggerInstance}
+}
+
+// GetConversations lists the conversations of the authenticated user, the most recently active first
+func (c *ConversationController) GetConversations(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request ConversationsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+
+	conversations, err := c.conversationUseCase.GetConversations(userID, request.Channel, request.Limit)
+	if err != nil {
+		c.Logger.Error("Error getting conversations", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]ConversationResponse, len(*conversations))
+	for i, conversation := range *conversations {
+		response[i] = ConversationResponse{
+			ID:             conversation.ID,
+			Channel:        conversation.Channel,
+			Participant:    conversation.Participant,
+			LastMessage:    conversation.LastMessage,
+			LastDirection:  conversation.LastDirection,
+			LastStatus:     conversation.LastStatus,
+			MessageCount:   conversation.MessageCount,
+			InboundCount:   conversation.InboundCount,
+			LastActivityAt: conversation.LastActivityAt,
+			CreatedAt:      conversation.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetMessages returns a page of the messages of a conversation of the authenticated user, newest first
+func (c *ConversationController) GetMessages(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	conversationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	var request MessagesRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	page, err := request.PageRequest()
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	messages, err := c.conversationUseCase.GetMessages(userID, conversationID, page)
+	if err != nil {
+		c.Logger.Error("Error getting conversation messages", zap.Error(err), zap.Int("userID", userID), zap.Int("conversationID", conversationID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]MessageResponse, len(messages.Items))
+	for i, message := range messages.Items {
+		response[i] = MessageResponse{
+			ID:         message.ID,
+			Direction:  message.Direction,
+			MessageID:  message.MessageID,
+			ExternalID: message.ExternalID,
+			Body:       message.Body,
+			Status:     message.Status,
+			OccurredAt: message.OccurredAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, controllers.NewPageResponse(response, messages.Next))
+}
+
+// Rebuild starts replaying the messages of one or every user into the conversations
+func (c *ConversationController) Rebuild(ctx *gin.Context) {
+	var request RebuildRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	status, err := c.conversationUseCase.Rebuild(request.UserID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, rebuildStatusToResponse(status))
+}
+
+// GetRebuildStatus reports the last rebuild started on the instance answering the request
+func (c *ConversationController) GetRebuildStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, rebuildStatusToResponse(c.conversationUseCase.GetRebuildStatus()))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *ConversationController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func rebuildStatusToResponse(status *conversationUseCase.RebuildStatus) RebuildStatusResponse {
+	return RebuildStatusResponse{
+		Running:    status.Running,
+		UserID:     status.UserID,
+		StartedAt:  status.StartedAt,
+		FinishedAt: status.FinishedAt,
+		Messages:   status.Messages,
+		Error:      status.Error,
+	}
+}
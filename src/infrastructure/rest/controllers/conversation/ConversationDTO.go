@@ -0,0 +1,52 @@
+package conversation
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+)
+
+type ConversationsRequest struct {
+	Channel string `form:"channel"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type MessagesRequest struct {
+	controllers.PageQuery
+}
+
+type RebuildRequest struct {
+	UserID int `form:"user_id" binding:"omitempty,min=1"`
+}
+
+type ConversationResponse struct {
+	ID             int       `json:"id"`
+	Channel        string    `json:"channel"`
+	Participant    string    `json:"participant"`
+	LastMessage    string    `json:"last_message"`
+	LastDirection  string    `json:"last_direction"`
+	LastStatus     string    `json:"last_status,omitempty"`
+	MessageCount   int       `json:"message_count"`
+	InboundCount   int       `json:"inbound_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type MessageResponse struct {
+	ID         int       `json:"id"`
+	Direction  string    `json:"direction"`
+	MessageID  int       `json:"message_id,omitempty"`
+	ExternalID string    `json:"external_id,omitempty"`
+	Body       string    `json:"body"`
+	Status     string    `json:"status,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type RebuildStatusResponse struct {
+	Running    bool       `json:"running"`
+	UserID     int        `json:"user_id,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Messages   int        `json:"messages"`
+	Error      string     `json:"error,omitempty"`
+}
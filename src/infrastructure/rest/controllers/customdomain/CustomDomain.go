@@ -0,0 +1,118 @@
+package customdomain
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	customDomainUseCase "go-multi-chat-api/src/application/usecases/customdomain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ICustomDomainController interface {
+	GetDomain(ctx *gin.Context)
+	SetDomain(ctx *gin.Context)
+	Verify(ctx *gin.Context)
+	RemoveDomain(ctx *gin.Context)
+}
+
+type CustomDomainController struct {
+	customDomainUseCase customDomainUseCase.ICustomDomainUseCase
+	Logger              *logger.Logger
+}
+
+func NewCustomDomainController(customDomainUseCase customDomainUseCase.ICustomDomainUseCase, loggerInstance *logger.Logger) ICustomDomainController {
+	return &CustomDomainController{customDomainUseCase: customDomainUseCase, Logger: loggerInstance}
+}
+
+// GetDomain returns the custom domain of a user with its verification state
+func (c *CustomDomainController) GetDomain(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	customDomain, err := c.customDomainUseCase.GetDomain(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponse(customDomain))
+}
+
+// SetDomain sets the custom domain of a user and returns the DNS TXT record that verifies it
+func (c *CustomDomainController) SetDomain(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request SetDomainRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	customDomain, err := c.customDomainUseCase.SetDomain(userID, request.Domain)
+	if err != nil {
+		c.Logger.Error("Error setting custom domain", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponse(customDomain))
+}
+
+// Verify checks the DNS TXT record of the custom domain of a user
+func (c *CustomDomainController) Verify(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	customDomain, err := c.customDomainUseCase.Verify(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, domainToResponse(customDomain))
+}
+
+// RemoveDomain removes the custom domain of a user
+func (c *CustomDomainController) RemoveDomain(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.customDomainUseCase.RemoveDomain(userID); err != nil {
+		c.Logger.Error("Error removing custom domain", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "custom domain removed"})
+}
+
+func userIDParam(ctx *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("user id is invalid"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return userID, true
+}
+
+func domainToResponse(customDomain *provider.CustomDomain) CustomDomainResponse {
+	challenge := customDomainUseCase.ChallengeOf(customDomain)
+	return CustomDomainResponse{
+		UserID:     customDomain.UserID,
+		Domain:     customDomain.Domain,
+		Verified:   customDomain.VerifiedAt != nil,
+		VerifiedAt: customDomain.VerifiedAt,
+		Challenge:  ChallengeResponse{Name: challenge.Name, Value: challenge.Value},
+	}
+}
@@ -0,0 +1,21 @@
+package customdomain
+
+import "time"
+
+type SetDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// ChallengeResponse is the DNS TXT record to publish to verify a custom domain
+type ChallengeResponse struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CustomDomainResponse struct {
+	UserID     int               `json:"user_id"`
+	Domain     string            `json:"domain"`
+	Verified   bool              `json:"verified"`
+	VerifiedAt *time.Time        `json:"verified_at,omitempty"`
+	Challenge  ChallengeResponse `json:"challenge"`
+}
@@ -0,0 +1,88 @@
+package deactivation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	deactivationUseCase "go-multi-chat-api/src/application/usecases/deactivation"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IDeactivationController interface {
+	Deactivate(ctx *gin.Context)
+	Reactivate(ctx *gin.Context)
+}
+
+type DeactivationController struct {
+	deactivationUseCase deactivationUseCase.IDeactivationUseCase
+	Logger              *logger.Logger
+}
+
+func NewDeactivationController(deactivationUseCase deactivationUseCase.IDeactivationUseCase, loggerInstance *logger.Logger) IDeactivationController {
+	return &DeactivationController{deactivationUseCase: deactivationUseCase, Logger: loggerInstance}
+}
+
+// Deactivate deactivates a user, revoking the user's tokens and suspending or cancelling the messages still
+// waiting to be sent
+func (c *DeactivationController) Deactivate(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var request DeactivateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	result, err := c.deactivationUseCase.Deactivate(userID, request.PendingMessages)
+	if err != nil {
+		c.Logger.Error("Error deactivating user", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(result))
+}
+
+// Reactivate activates a user again and requeues the messages suspended by the deactivation
+func (c *DeactivationController) Reactivate(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := c.deactivationUseCase.Reactivate(userID)
+	if err != nil {
+		c.Logger.Error("Error reactivating user", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(result))
+}
+
+func userIDParam(ctx *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("user id is invalid"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return userID, true
+}
+
+func toResponse(result *deactivationUseCase.Result) DeactivationResponse {
+	return DeactivationResponse{
+		UserID:        result.User.ID,
+		Status:        result.User.Status,
+		DeactivatedAt: result.User.DeactivatedAt,
+		Messages:      result.Messages,
+		Providers:     result.Providers,
+	}
+}
@@ -0,0 +1,16 @@
+package deactivation
+
+import "time"
+
+type DeactivateRequest struct {
+	// PendingMessages is hold or cancel, hold is the default
+	PendingMessages string `json:"pending_messages"`
+}
+
+type DeactivationResponse struct {
+	UserID        int        `json:"user_id"`
+	Status        bool       `json:"status"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	Messages      int        `json:"messages"`
+	Providers     int        `json:"providers"`
+}
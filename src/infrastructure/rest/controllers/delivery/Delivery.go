@@ -0,0 +1,52 @@
+package delivery
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	deliveryUseCase "go-multi-chat-api/src/application/usecases/delivery"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCallbackSize limits the body of a delivery callback, SendGrid batches up to a few thousand events
+const maxCallbackSize = 5 << 20
+
+type IDeliveryController interface {
+	ReceiveCallback(ctx *gin.Context)
+}
+
+type DeliveryController struct {
+	deliveryUseCase deliveryUseCase.IDeliveryUseCase
+	Logger          *logger.Logger
+}
+
+func NewDeliveryController(deliveryUseCase deliveryUseCase.IDeliveryUseCase, loggerInstance *logger.Logger) IDeliveryController {
+	return &DeliveryController{deliveryUseCase: deliveryUseCase, Logger: loggerInstance}
+}
+
+// ReceiveCallback is the webhook vendors post the delivery status of the messages of a provider to. It is
+// authenticated by the signature of the vendor instead of a JWT, which covers the raw body.
+func (c *DeliveryController) ReceiveCallback(ctx *gin.Context) {
+	providerID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || providerID <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxCallbackSize))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	err = c.deliveryUseCase.ReceiveCallback(ctx.Param("vendor"), providerID, ctx.Request.URL.RequestURI(), ctx.Request.Header, body)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
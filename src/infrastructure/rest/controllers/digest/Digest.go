@@ -0,0 +1,148 @@
+package digest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	digestUseCase "go-multi-chat-api/src/application/usecases/digest"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultDigestLimit = 30
+
+type IDigestController interface {
+	GetDigests(ctx *gin.Context)
+	GetSubscription(ctx *gin.Context)
+	UpdateSubscription(ctx *gin.Context)
+}
+
+type DigestController struct {
+	digestUseCase digestUseCase.IDigestUseCase
+	Logger        *logger.Logger
+}
+
+func NewDigestController(digestUseCase digestUseCase.IDigestUseCase, loggerInstance *logger.Logger) IDigestController {
+	return &DigestController{digestUseCase: digestUseCase, Logger: loggerInstance}
+}
+
+// GetDigests returns the past digests of the authenticated user
+func (c *DigestController) GetDigests(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	limit := defaultDigestLimit
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			_ = ctx.Error(domainErrors.NewAppError(errors.New("limit must be a positive integer"), domainErrors.ValidationError))
+			return
+		}
+		limit = parsed
+	}
+
+	digests, err := c.digestUseCase.GetDigests(userID, limit)
+	if err != nil {
+		c.Logger.Error("Error getting digests", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]DigestResponse, len(*digests))
+	for i, digest := range *digests {
+		response[i] = digestToResponse(&digest)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSubscription returns the digest subscription of the authenticated user
+func (c *DigestController) GetSubscription(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	subscription, err := c.digestUseCase.GetSubscription(userID)
+	if err != nil {
+		c.Logger.Error("Error getting digest subscription", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptionToResponse(subscription))
+}
+
+// UpdateSubscription opts the authenticated user in or out of digests
+func (c *DigestController) UpdateSubscription(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request UpdateSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	subscription, err := c.digestUseCase.UpdateSubscription(&provider.DigestSubscription{
+		UserID:    userID,
+		Frequency: request.Frequency,
+		Channel:   request.Channel,
+		Recipient: request.Recipient,
+		Enabled:   *request.Enabled,
+	})
+	if err != nil {
+		c.Logger.Error("Error updating digest subscription", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptionToResponse(subscription))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *DigestController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func subscriptionToResponse(subscription *provider.DigestSubscription) SubscriptionResponse {
+	return SubscriptionResponse{
+		Frequency: subscription.Frequency,
+		Channel:   subscription.Channel,
+		Recipient: subscription.Recipient,
+		Enabled:   subscription.Enabled,
+	}
+}
+
+func digestToResponse(digest *provider.DeliveryDigest) DigestResponse {
+	return DigestResponse{
+		ID:           digest.ID,
+		Frequency:    digest.Frequency,
+		PeriodStart:  digest.PeriodStart,
+		PeriodEnd:    digest.PeriodEnd,
+		Total:        digest.Total,
+		Sent:         digest.Sent,
+		Failed:       digest.Failed,
+		Fallbacks:    digest.Fallbacks,
+		FallbackRate: digest.FallbackRate,
+		TopErrors:    digest.TopErrors,
+		LinkClicks:   digest.LinkClicks,
+		LinkClickers: digest.LinkClickers,
+		Channel:      digest.Channel,
+		Status:       digest.Status,
+		ErrorMessage: digest.ErrorMessage,
+		CreatedAt:    digest.CreatedAt,
+	}
+}
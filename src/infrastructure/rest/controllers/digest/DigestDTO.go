@@ -0,0 +1,40 @@
+package digest
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+type UpdateSubscriptionRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekly"`
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Enabled   *bool  `json:"enabled" binding:"required"`
+}
+
+type SubscriptionResponse struct {
+	Frequency string `json:"frequency"`
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type DigestResponse struct {
+	ID           int                         `json:"id"`
+	Frequency    string                      `json:"frequency"`
+	PeriodStart  time.Time                   `json:"period_start"`
+	PeriodEnd    time.Time                   `json:"period_end"`
+	Total        int                         `json:"total"`
+	Sent         int                         `json:"sent"`
+	Failed       int                         `json:"failed"`
+	Fallbacks    int                         `json:"fallbacks"`
+	FallbackRate float64                     `json:"fallback_rate"`
+	TopErrors    []provider.ErrorReasonCount `json:"top_errors"`
+	LinkClicks   int                         `json:"link_clicks"`
+	LinkClickers int                         `json:"link_clickers"`
+	Channel      string                      `json:"channel"`
+	Status       string                      `json:"status"`
+	ErrorMessage string                      `json:"error_message,omitempty"`
+	CreatedAt    time.Time                   `json:"created_at"`
+}
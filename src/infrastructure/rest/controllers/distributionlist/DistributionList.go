@@ -0,0 +1,250 @@
+package distributionlist
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	distributionListUseCase "go-multi-chat-api/src/application/usecases/distributionlist"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IDistributionListController interface {
+	CreateList(ctx *gin.Context)
+	GetLists(ctx *gin.Context)
+	GetList(ctx *gin.Context)
+	UpdateList(ctx *gin.Context)
+	DeleteList(ctx *gin.Context)
+	Send(ctx *gin.Context)
+	GetDrifted(ctx *gin.Context)
+	SyncList(ctx *gin.Context)
+}
+
+type DistributionListController struct {
+	distributionListUseCase distributionListUseCase.IDistributionListUseCase
+	Logger                  *logger.Logger
+}
+
+func NewDistributionListController(distributionListUseCase distributionListUseCase.IDistributionListUseCase, loggerInstance *logger.Logger) IDistributionListController {
+	return &DistributionListController{distributionListUseCase: distributionListUseCase, Logger: loggerInstance}
+}
+
+// CreateList stores a distribution list of the authenticated user
+func (c *DistributionListController) CreateList(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request DistributionListRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	list, err := c.distributionListUseCase.CreateList(requestToList(&request, userID, 0))
+	if err != nil {
+		c.Logger.Info("Error creating distribution list", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, listToResponse(list))
+}
+
+// GetLists returns the distribution lists of the authenticated user
+func (c *DistributionListController) GetLists(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	lists, err := c.distributionListUseCase.GetLists(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listsToResponse(lists))
+}
+
+// GetList returns a distribution list of the authenticated user
+func (c *DistributionListController) GetList(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	list, err := c.distributionListUseCase.GetList(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listToResponse(list))
+}
+
+// UpdateList replaces the name, group and members of a distribution list of the authenticated user
+func (c *DistributionListController) UpdateList(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request DistributionListRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	list, err := c.distributionListUseCase.UpdateList(requestToList(&request, userID, id))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listToResponse(list))
+}
+
+// DeleteList removes a distribution list of the authenticated user
+func (c *DistributionListController) DeleteList(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.distributionListUseCase.DeleteList(userID, id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Send sends a message to a distribution list of the authenticated user
+func (c *DistributionListController) Send(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request SendRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	results, err := c.distributionListUseCase.Send(userID, id, request.Message, request.Tags)
+	if err != nil {
+		c.Logger.Error("Error sending to distribution list", zap.Error(err), zap.Int("userID", userID), zap.Int("listID", id))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]SendResultResponse, len(results))
+	for i, result := range results {
+		response[i] = SendResultResponse{
+			Channel:    result.Channel,
+			Recipients: result.Recipients,
+			MessageID:  result.MessageID,
+			Status:     result.Status,
+			Error:      result.Error,
+		}
+	}
+	ctx.JSON(http.StatusAccepted, response)
+}
+
+// GetDrifted returns the distribution lists of all users whose last sync found drift or failed
+func (c *DistributionListController) GetDrifted(ctx *gin.Context) {
+	lists, err := c.distributionListUseCase.GetDrifted()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listsToResponse(lists))
+}
+
+// SyncList syncs the Signal group membership of a distribution list of any user right away
+func (c *DistributionListController) SyncList(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	list, err := c.distributionListUseCase.SyncList(id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listToResponse(list))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *DistributionListController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
+
+func requestToList(request *DistributionListRequest, userID int, id int) *provider.DistributionList {
+	return &provider.DistributionList{
+		ID:            id,
+		UserID:        userID,
+		Name:          request.Name,
+		SignalGroupID: request.SignalGroupID,
+		SignalMembers: request.SignalMembers,
+		ExtraMembers:  request.ExtraMembers,
+	}
+}
+
+func listToResponse(list *provider.DistributionList) DistributionListResponse {
+	return DistributionListResponse{
+		ID:            list.ID,
+		UserID:        list.UserID,
+		Name:          list.Name,
+		SignalGroupID: list.SignalGroupID,
+		SignalMembers: list.SignalMembers,
+		ExtraMembers:  list.ExtraMembers,
+		Drift:         list.Drift,
+		SyncError:     list.SyncError,
+		LastSyncedAt:  list.LastSyncedAt,
+		CreatedAt:     list.CreatedAt,
+	}
+}
+
+func listsToResponse(lists *[]provider.DistributionList) []DistributionListResponse {
+	response := make([]DistributionListResponse, len(*lists))
+	for i, list := range *lists {
+		response[i] = listToResponse(&list)
+	}
+	return response
+}
@@ -0,0 +1,40 @@
+package distributionlist
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+type DistributionListRequest struct {
+	Name          string                            `json:"name" binding:"required,max=255"`
+	SignalGroupID string                            `json:"signal_group_id"`
+	SignalMembers []string                          `json:"signal_members"`
+	ExtraMembers  []provider.DistributionListMember `json:"extra_members"`
+}
+
+type DistributionListResponse struct {
+	ID            int                               `json:"id"`
+	UserID        int                               `json:"user_id"`
+	Name          string                            `json:"name"`
+	SignalGroupID string                            `json:"signal_group_id,omitempty"`
+	SignalMembers []string                          `json:"signal_members"`
+	ExtraMembers  []provider.DistributionListMember `json:"extra_members"`
+	Drift         *provider.MembershipDrift         `json:"drift,omitempty"`
+	SyncError     string                            `json:"sync_error,omitempty"`
+	LastSyncedAt  *time.Time                        `json:"last_synced_at,omitempty"`
+	CreatedAt     time.Time                         `json:"created_at"`
+}
+
+type SendRequest struct {
+	Message string            `json:"message" binding:"required"`
+	Tags    map[string]string `json:"tags"`
+}
+
+type SendResultResponse struct {
+	Channel    string   `json:"channel"`
+	Recipients []string `json:"recipients"`
+	MessageID  int      `json:"message_id,omitempty"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+}
@@ -0,0 +1,245 @@
+package emailtemplate
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	emailTemplateUseCase "go-multi-chat-api/src/application/usecases/emailtemplate"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	emailRenderer "go-multi-chat-api/src/infrastructure/emailtemplate"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IEmailTemplateController interface {
+	CreateTemplate(ctx *gin.Context)
+	GetTemplates(ctx *gin.Context)
+	GetTemplate(ctx *gin.Context)
+	UpdateTemplate(ctx *gin.Context)
+	DeleteTemplate(ctx *gin.Context)
+	Preview(ctx *gin.Context)
+	PreviewDraft(ctx *gin.Context)
+}
+
+type EmailTemplateController struct {
+	emailTemplateUseCase emailTemplateUseCase.IEmailTemplateUseCase
+	Logger               *logger.Logger
+}
+
+func NewEmailTemplateController(emailTemplateUseCase emailTemplateUseCase.IEmailTemplateUseCase, loggerInstance *logger.Logger) IEmailTemplateController {
+	return &EmailTemplateController{emailTemplateUseCase: emailTemplateUseCase, Logger: loggerInstance}
+}
+
+// CreateTemplate stores an email template of the authenticated user
+func (c *EmailTemplateController) CreateTemplate(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request EmailTemplateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	template, err := c.emailTemplateUseCase.CreateTemplate(requestToTemplate(&request, userID, 0))
+	if err != nil {
+		c.Logger.Info("Error creating email template", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, templateToResponse(template))
+}
+
+// GetTemplates returns the email templates of the authenticated user
+func (c *EmailTemplateController) GetTemplates(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	templates, err := c.emailTemplateUseCase.GetTemplates(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]EmailTemplateResponse, len(*templates))
+	for i, template := range *templates {
+		response[i] = templateToResponse(&template)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTemplate returns an email template of the authenticated user
+func (c *EmailTemplateController) GetTemplate(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.emailTemplateUseCase.GetTemplate(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, templateToResponse(template))
+}
+
+// UpdateTemplate replaces the name, subject and body of an email template of the authenticated user
+func (c *EmailTemplateController) UpdateTemplate(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request EmailTemplateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	template, err := c.emailTemplateUseCase.UpdateTemplate(requestToTemplate(&request, userID, id))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, templateToResponse(template))
+}
+
+// DeleteTemplate removes an email template of the authenticated user
+func (c *EmailTemplateController) DeleteTemplate(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.emailTemplateUseCase.DeleteTemplate(userID, id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Preview renders an email template of the authenticated user with the variables of the request
+func (c *EmailTemplateController) Preview(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request PreviewRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	rendered, err := c.emailTemplateUseCase.Render(userID, id, request.Variables)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	writePreview(ctx, rendered)
+}
+
+// PreviewDraft renders the template of the request, which doesn't have to be stored
+func (c *EmailTemplateController) PreviewDraft(ctx *gin.Context) {
+	if _, ok := c.currentUserID(ctx); !ok {
+		return
+	}
+
+	var request DraftPreviewRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	rendered, err := c.emailTemplateUseCase.RenderDraft(request.Subject, request.HTML, request.Variables)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	writePreview(ctx, rendered)
+}
+
+// writePreview answers the rendered email with its images as data URIs, as JSON or with ?format=html as the
+// HTML page alone to open in a browser
+func writePreview(ctx *gin.Context, rendered *emailRenderer.Rendered) {
+	if ctx.Query("format") == "html" {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.PreviewHTML()))
+		return
+	}
+
+	images := make([]PreviewImageResponse, len(rendered.Images))
+	for i, image := range rendered.Images {
+		images[i] = PreviewImageResponse{ContentID: image.ContentID, ContentType: image.ContentType, Size: len(image.Data)}
+	}
+	ctx.JSON(http.StatusOK, PreviewResponse{
+		Subject: rendered.Subject,
+		HTML:    rendered.PreviewHTML(),
+		Text:    rendered.Text,
+		Images:  images,
+	})
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *EmailTemplateController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
+
+func requestToTemplate(request *EmailTemplateRequest, userID int, id int) *provider.EmailTemplate {
+	return &provider.EmailTemplate{
+		ID:      id,
+		UserID:  userID,
+		Name:    request.Name,
+		Subject: request.Subject,
+		HTML:    request.HTML,
+	}
+}
+
+func templateToResponse(template *provider.EmailTemplate) EmailTemplateResponse {
+	return EmailTemplateResponse{
+		ID:        template.ID,
+		UserID:    template.UserID,
+		Name:      template.Name,
+		Subject:   template.Subject,
+		HTML:      template.HTML,
+		CreatedAt: template.CreatedAt,
+		UpdatedAt: template.UpdatedAt,
+	}
+}
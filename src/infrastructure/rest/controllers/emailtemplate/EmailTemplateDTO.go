@@ -0,0 +1,42 @@
+package emailtemplate
+
+import "time"
+
+type EmailTemplateRequest struct {
+	Name    string `json:"name" binding:"required,max=191"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html" binding:"required"`
+}
+
+type EmailTemplateResponse struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	HTML      string    `json:"html"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PreviewRequest struct {
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type DraftPreviewRequest struct {
+	Subject   string                 `json:"subject"`
+	HTML      string                 `json:"html" binding:"required"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type PreviewImageResponse struct {
+	ContentID   string `json:"content_id"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+type PreviewResponse struct {
+	Subject string                 `json:"subject"`
+	HTML    string                 `json:"html"`
+	Text    string                 `json:"text"`
+	Images  []PreviewImageResponse `json:"images"`
+}
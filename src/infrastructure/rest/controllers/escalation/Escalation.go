@@ -0,0 +1,242 @@
+package escalation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	escalationUseCase "go-multi-chat-api/src/application/usecases/escalation"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultEscalationLimit = 50
+
+type IEscalationController interface {
+	CreateChain(ctx *gin.Context)
+	GetChains(ctx *gin.Context)
+	DeleteChain(ctx *gin.Context)
+	Trigger(ctx *gin.Context)
+	GetEscalations(ctx *gin.Context)
+	GetEscalation(ctx *gin.Context)
+	Acknowledge(ctx *gin.Context)
+}
+
+type EscalationController struct {
+	escalationUseCase escalationUseCase.IEscalationUseCase
+	Logger            *logger.Logger
+}
+
+func NewEscalationController(escalationUseCase escalationUseCase.IEscalationUseCase, loggerInstance *logger.Logger) IEscalationController {
+	return &EscalationController{escalationUseCase: escalationUseCase, Logger: loggerInstance}
+}
+
+// CreateChain stores an escalation chain of the authenticated user
+func (c *EscalationController) CreateChain(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request CreateChainRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	chain, err := c.escalationUseCase.CreateChain(&provider.EscalationChain{UserID: userID, Name: request.Name, Steps: request.Steps})
+	if err != nil {
+		c.Logger.Info("Error creating escalation chain", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, chainToResponse(chain))
+}
+
+// GetChains returns the escalation chains of the authenticated user
+func (c *EscalationController) GetChains(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	chains, err := c.escalationUseCase.GetChains(userID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]ChainResponse, len(*chains))
+	for i, chain := range *chains {
+		response[i] = chainToResponse(&chain)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// DeleteChain removes an escalation chain of the authenticated user
+func (c *EscalationController) DeleteChain(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.escalationUseCase.DeleteChain(userID, id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// Trigger starts an escalation of a chain of the authenticated user
+func (c *EscalationController) Trigger(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request TriggerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	escalation, err := c.escalationUseCase.Trigger(userID, request.ChainID, request.Message)
+	if err != nil {
+		c.Logger.Error("Error triggering escalation", zap.Error(err), zap.Int("userID", userID), zap.Int("chainID", request.ChainID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, escalationToResponse(escalation))
+}
+
+// GetEscalations returns the most recent escalations of the authenticated user
+func (c *EscalationController) GetEscalations(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	limit := defaultEscalationLimit
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 {
+			_ = ctx.Error(domainErrors.NewAppError(errors.New("limit must be a positive integer"), domainErrors.ValidationError))
+			return
+		}
+		limit = parsed
+	}
+
+	escalations, err := c.escalationUseCase.GetEscalations(userID, limit)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]EscalationResponse, len(*escalations))
+	for i, escalation := range *escalations {
+		response[i] = escalationToResponse(&escalation)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetEscalation returns an escalation of the authenticated user
+func (c *EscalationController) GetEscalation(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	escalation, err := c.escalationUseCase.GetEscalation(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, escalationToResponse(escalation))
+}
+
+// Acknowledge acknowledges an escalation of the authenticated user, no further steps are notified
+func (c *EscalationController) Acknowledge(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request AcknowledgeRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+			return
+		}
+	}
+	if request.AcknowledgedBy == "" {
+		request.AcknowledgedBy = "user:" + strconv.Itoa(userID)
+	}
+
+	escalation, err := c.escalationUseCase.Acknowledge(userID, id, request.AcknowledgedBy)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, escalationToResponse(escalation))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *EscalationController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
+
+func chainToResponse(chain *provider.EscalationChain) ChainResponse {
+	return ChainResponse{
+		ID:        chain.ID,
+		Name:      chain.Name,
+		Steps:     chain.Steps,
+		CreatedAt: chain.CreatedAt,
+	}
+}
+
+func escalationToResponse(escalation *provider.Escalation) EscalationResponse {
+	return EscalationResponse{
+		ID:             escalation.ID,
+		ChainID:        escalation.ChainID,
+		Message:        escalation.Message,
+		Status:         escalation.Status,
+		CurrentStep:    escalation.CurrentStep,
+		Steps:          escalation.Steps,
+		NextStepAt:     escalation.NextStepAt,
+		AckCode:        escalation.AckCode,
+		AcknowledgedBy: escalation.AcknowledgedBy,
+		AcknowledgedAt: escalation.AcknowledgedAt,
+		CreatedAt:      escalation.CreatedAt,
+	}
+}
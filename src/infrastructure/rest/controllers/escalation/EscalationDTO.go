@@ -0,0 +1,42 @@
+package escalation
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+)
+
+type CreateChainRequest struct {
+	Name  string                    `json:"name" binding:"required,max=255"`
+	Steps []provider.EscalationStep `json:"steps" binding:"required,min=1,max=10,dive"`
+}
+
+type ChainResponse struct {
+	ID        int                       `json:"id"`
+	Name      string                    `json:"name"`
+	Steps     []provider.EscalationStep `json:"steps"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+type TriggerRequest struct {
+	ChainID int    `json:"chain_id" binding:"required"`
+	Message string `json:"message" binding:"required"`
+}
+
+type AcknowledgeRequest struct {
+	AcknowledgedBy string `json:"acknowledged_by"`
+}
+
+type EscalationResponse struct {
+	ID             int                       `json:"id"`
+	ChainID        int                       `json:"chain_id"`
+	Message        string                    `json:"message"`
+	Status         string                    `json:"status"`
+	CurrentStep    int                       `json:"current_step"`
+	Steps          []provider.EscalationStep `json:"steps"`
+	NextStepAt     *time.Time                `json:"next_step_at,omitempty"`
+	AckCode        string                    `json:"ack_code"`
+	AcknowledgedBy string                    `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time                `json:"acknowledged_at,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at"`
+}
@@ -0,0 +1,231 @@
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	hookUseCase "go-multi-chat-api/src/application/usecases/hook"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IHookController interface {
+	Subscribe(ctx *gin.Context)
+	Unsubscribe(ctx *gin.Context)
+	RotateSecret(ctx *gin.Context)
+	GetSubscriptions(ctx *gin.Context)
+	GetEvents(ctx *gin.Context)
+	ReplayEvent(ctx *gin.Context)
+}
+
+type HookController struct {
+	hookUseCase hookUseCase.IHookUseCase
+	Logger      *logger.Logger
+}
+
+func NewHookController(hookUseCase hookUseCase.IHookUseCase, loggerInstance *logger.Logger) IHookController {
+	return &HookController{hookUseCase: hookUseCase, Logger: loggerInstance}
+}
+
+// Subscribe subscribes a target URL to an event of the authenticated user after verifying it
+func (c *HookController) Subscribe(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request SubscribeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	delivery := provider.HookDelivery{
+		Headers:            request.Headers,
+		ClientCertificate:  request.ClientCertificate,
+		ClientKey:          request.ClientKey,
+		CACertificate:      request.CACertificate,
+		InsecureSkipVerify: request.InsecureSkipVerify,
+	}
+	subscription, err := c.hookUseCase.Subscribe(userID, request.Event, request.TargetURL, request.EnvelopeTypes, delivery)
+	if err != nil {
+		c.Logger.Info("Error subscribing hook", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := subscriptionToResponse(subscription)
+	response.Secret = subscription.Secret
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// Unsubscribe removes a subscription of the authenticated user
+func (c *HookController) Unsubscribe(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+
+	if err := c.hookUseCase.Unsubscribe(userID, id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RotateSecret replaces the secret of a subscription of the authenticated user once the target accepted the new
+// one, the response is the only time the new secret is shown
+func (c *HookController) RotateSecret(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+
+	subscription, err := c.hookUseCase.RotateSecret(userID, id)
+	if err != nil {
+		c.Logger.Info("Error rotating hook secret", zap.Error(err), zap.Int("userID", userID), zap.Int("subscriptionID", id))
+		_ = ctx.Error(err)
+		return
+	}
+	response := subscriptionToResponse(subscription)
+	response.Secret = subscription.Secret
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetSubscriptions returns the subscriptions of the authenticated user
+func (c *HookController) GetSubscriptions(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := c.hookUseCase.GetSubscriptions(userID)
+	if err != nil {
+		c.Logger.Error("Error getting hook subscriptions", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]SubscriptionResponse, len(*subscriptions))
+	for i, subscription := range *subscriptions {
+		response[i] = subscriptionToResponse(&subscription)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetEvents returns a page of the events emitted to the subscriptions of the authenticated user, newest first
+func (c *HookController) GetEvents(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request EventsRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	page, err := request.PageRequest()
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	events, err := c.hookUseCase.GetEvents(userID, request.Event, page)
+	if err != nil {
+		c.Logger.Info("Error getting hook events", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]EventResponse, len(events.Items))
+	for i, event := range events.Items {
+		response[i] = EventResponse{
+			ID:        event.ID,
+			Event:     event.Event,
+			Payload:   json.RawMessage(event.Payload),
+			CreatedAt: event.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, controllers.NewPageResponse(response, events.Next))
+}
+
+// ReplayEvent re-delivers a stored event of the authenticated user to its current subscriptions to the event
+func (c *HookController) ReplayEvent(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+
+	results, err := c.hookUseCase.ReplayEvent(userID, id)
+	if err != nil {
+		c.Logger.Info("Error replaying hook event", zap.Error(err), zap.Int("userID", userID), zap.Int("eventID", id))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := ReplayResponse{EventID: id, Deliveries: make([]ReplayDeliveryResult, len(results))}
+	for i, result := range results {
+		response.Deliveries[i] = ReplayDeliveryResult{
+			SubscriptionID: result.SubscriptionID,
+			TargetURL:      result.TargetURL,
+			StatusCode:     result.StatusCode,
+			Error:          result.Error,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *HookController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func subscriptionToResponse(subscription *provider.HookSubscription) SubscriptionResponse {
+	response := SubscriptionResponse{
+		ID:                 subscription.ID,
+		Event:              subscription.Event,
+		TargetURL:          subscription.TargetURL,
+		EnvelopeTypes:      subscription.EnvelopeTypes,
+		ClientCertificate:  subscription.Delivery.ClientCertificate,
+		CACertificate:      subscription.Delivery.CACertificate,
+		InsecureSkipVerify: subscription.Delivery.InsecureSkipVerify,
+		CreatedAt:          subscription.CreatedAt,
+	}
+	for name := range subscription.Delivery.Headers {
+		response.HeaderNames = append(response.HeaderNames, name)
+	}
+	sort.Strings(response.HeaderNames)
+	return response
+}
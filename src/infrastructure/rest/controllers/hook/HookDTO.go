@@ -0,0 +1,62 @@
+package hook
+
+import (
+	"encoding/json"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+)
+
+type SubscribeRequest struct {
+	Event     string `json:"event" binding:"required"`
+	TargetURL string `json:"target_url" binding:"required,url,max=2048"`
+	// EnvelopeTypes limits a message.received subscription to some types of received envelopes
+	EnvelopeTypes []string `json:"envelope_types"`
+	// Headers are sent with every delivery, e.g. a static Authorization header, their values are stored encrypted
+	Headers map[string]string `json:"headers" binding:"max=20"`
+	// ClientCertificate and ClientKey are the PEM certificate and key presented to targets requiring mTLS
+	ClientCertificate string `json:"client_certificate" binding:"max=16384"`
+	ClientKey         string `json:"client_key" binding:"max=16384"`
+	// CACertificate holds PEM CAs trusted for the target besides the system roots
+	CACertificate      string `json:"ca_certificate" binding:"max=65536"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+type SubscriptionResponse struct {
+	ID            int      `json:"id"`
+	Event         string   `json:"event"`
+	TargetURL     string   `json:"target_url"`
+	EnvelopeTypes []string `json:"envelope_types,omitempty"`
+	// HeaderNames are the names of the custom headers, their values and the client key aren't returned
+	HeaderNames        []string `json:"header_names,omitempty"`
+	ClientCertificate  string   `json:"client_certificate,omitempty"`
+	CACertificate      string   `json:"ca_certificate,omitempty"`
+	InsecureSkipVerify bool     `json:"insecure_skip_verify,omitempty"`
+	// Secret signs the deliveries, it is only returned when the subscription is created or its secret rotated
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EventsRequest struct {
+	Event string `form:"event"`
+	controllers.PageQuery
+}
+
+type EventResponse struct {
+	ID        int             `json:"id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ReplayResponse struct {
+	EventID    int                    `json:"event_id"`
+	Deliveries []ReplayDeliveryResult `json:"deliveries"`
+}
+
+type ReplayDeliveryResult struct {
+	SubscriptionID int    `json:"subscription_id"`
+	TargetURL      string `json:"target_url"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
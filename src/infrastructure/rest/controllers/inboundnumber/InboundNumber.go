@@ -0,0 +1,176 @@
+package inboundnumber
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	inboundNumberUseCase "go-multi-chat-api/src/application/usecases/inboundnumber"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// emptyTwiML acknowledges an inbound SMS without replying to it
+const emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+
+type IInboundNumberController interface {
+	SearchNumbers(ctx *gin.Context)
+	GetNumbers(ctx *gin.Context)
+	ProvisionNumber(ctx *gin.Context)
+	ReleaseNumber(ctx *gin.Context)
+	ReceiveSMS(ctx *gin.Context)
+}
+
+type InboundNumberController struct {
+	inboundNumberUseCase inboundNumberUseCase.IInboundNumberUseCase
+	Logger               *logger.Logger
+}
+
+func NewInboundNumberController(inboundNumberUseCase inboundNumberUseCase.IInboundNumberUseCase, loggerInstance *logger.Logger) IInboundNumberController {
+	return &InboundNumberController{inboundNumberUseCase: inboundNumberUseCase, Logger: loggerInstance}
+}
+
+// SearchNumbers lists the numbers the SMS provider offers to the authenticated user
+func (c *InboundNumberController) SearchNumbers(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	providerID, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request SearchNumbersRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	numbers, err := c.inboundNumberUseCase.SearchNumbers(userID, providerID, provider.NumberSearch{
+		Country:  request.Country,
+		AreaCode: request.AreaCode,
+		Contains: request.Contains,
+		Limit:    request.Limit,
+	})
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]AvailableNumberResponse, len(numbers))
+	for i, number := range numbers {
+		response[i] = AvailableNumberResponse(number)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetNumbers returns the numbers provisioned for the authenticated user on the SMS provider
+func (c *InboundNumberController) GetNumbers(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	providerID, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	numbers, err := c.inboundNumberUseCase.GetNumbers(userID, providerID)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]InboundNumberResponse, len(numbers))
+	for i, number := range numbers {
+		response[i] = InboundNumberResponse(number)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ProvisionNumber buys a number for the authenticated user, the SMS sent to it are routed to the user
+func (c *InboundNumberController) ProvisionNumber(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	providerID, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request ProvisionNumberRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	number, err := c.inboundNumberUseCase.ProvisionNumber(userID, providerID, request.PhoneNumber)
+	if err != nil {
+		c.Logger.Info("Error provisioning inbound number", zap.Error(err), zap.Int("userID", userID), zap.Int("providerID", providerID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, InboundNumberResponse(*number))
+}
+
+// ReleaseNumber gives a number of the authenticated user back to the SMS provider
+func (c *InboundNumberController) ReleaseNumber(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	providerID, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.inboundNumberUseCase.ReleaseNumber(userID, providerID, ctx.Param("number")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ReceiveSMS is the webhook SMS vendors post the SMS sent to provisioned numbers to. It is authenticated by
+// the signature of the vendor instead of a JWT.
+func (c *InboundNumberController) ReceiveSMS(ctx *gin.Context) {
+	providerID, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+	if err := ctx.Request.ParseForm(); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	err := c.inboundNumberUseCase.ReceiveSMS(providerID, ctx.Request.URL.RequestURI(), ctx.Request.Header, ctx.Request.PostForm)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Data(http.StatusOK, "text/xml; charset=utf-8", []byte(emptyTwiML))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *InboundNumberController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
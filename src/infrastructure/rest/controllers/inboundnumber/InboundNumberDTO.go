@@ -0,0 +1,28 @@
+package inboundnumber
+
+import "time"
+
+type SearchNumbersRequest struct {
+	Country  string `form:"country" binding:"required,len=2"`
+	AreaCode string `form:"area_code"`
+	Contains string `form:"contains"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type AvailableNumberResponse struct {
+	PhoneNumber string `json:"phone_number"`
+	Locality    string `json:"locality"`
+	Region      string `json:"region"`
+	Country     string `json:"country"`
+	MMS         bool   `json:"mms"`
+}
+
+type ProvisionNumberRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+type InboundNumberResponse struct {
+	ID            string    `json:"id"`
+	PhoneNumber   string    `json:"phone_number"`
+	ProvisionedAt time.Time `json:"provisioned_at"`
+}
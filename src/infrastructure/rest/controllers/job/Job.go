@@ -0,0 +1,134 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	jobUseCase "go-multi-chat-api/src/application/usecases/job"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultLimit = 50
+
+type IJobController interface {
+	GetJobs(ctx *gin.Context)
+	GetJob(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+	Retry(ctx *gin.Context)
+}
+
+type JobController struct {
+	jobUseCase jobUseCase.IJobUseCase
+	Logger     *logger.Logger
+}
+
+func NewJobController(jobUseCase jobUseCase.IJobUseCase, loggerInstance *logger.Logger) IJobController {
+	return &JobController{jobUseCase: jobUseCase, Logger: loggerInstance}
+}
+
+// GetJobs lists the jobs of the authenticated user, the most recent first
+func (c *JobController) GetJobs(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request ListRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+
+	jobs, err := c.jobUseCase.GetJobs(userID, request.Type, request.Limit)
+	if err != nil {
+		c.Logger.Error("Error getting jobs", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]JobResponse, len(*jobs))
+	for i := range *jobs {
+		response[i] = toResponse(&(*jobs)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetJob reports the status and progress of a job of the authenticated user
+func (c *JobController) GetJob(ctx *gin.Context) {
+	c.withJob(ctx, c.jobUseCase.GetJob)
+}
+
+// Cancel cancels a queued job, or asks the worker running it to stop
+func (c *JobController) Cancel(ctx *gin.Context) {
+	c.withJob(ctx, c.jobUseCase.Cancel)
+}
+
+// Retry queues a failed or cancelled job again
+func (c *JobController) Retry(ctx *gin.Context) {
+	c.withJob(ctx, c.jobUseCase.Retry)
+}
+
+// withJob answers with the job returned by an operation on the job of the path
+func (c *JobController) withJob(ctx *gin.Context, operation func(userID int, id int) (*provider.Job, error)) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	job, err := operation(userID, id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(job))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *JobController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func toResponse(job *provider.Job) JobResponse {
+	return JobResponse{
+		ID:              job.ID,
+		Type:            job.Type,
+		Payload:         rawJSON(job.Payload),
+		Status:          job.Status,
+		Progress:        job.Progress,
+		Result:          rawJSON(job.Result),
+		Error:           job.Error,
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		RunAfter:        job.RunAfter,
+		CancelRequested: job.CancelRequested,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+// rawJSON embeds stored JSON as is, leaving out what is empty or isn't valid JSON
+func rawJSON(value string) json.RawMessage {
+	if value == "" || !json.Valid([]byte(value)) {
+		return nil
+	}
+	return json.RawMessage(value)
+}
@@ -0,0 +1,29 @@
+package job
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ListRequest struct {
+	Type  string `form:"type"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type JobResponse struct {
+	ID              int             `json:"id"`
+	Type            string          `json:"type"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+	Status          string          `json:"status"`
+	Progress        int             `json:"progress"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	RunAfter        time.Time       `json:"run_after"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,132 @@
+package loginaudit
+
+import (
+	"net/http"
+
+	loginAuditUseCase "go-multi-chat-api/src/application/usecases/loginaudit"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainUser "go-multi-chat-api/src/domain/user"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ILoginAuditController interface {
+	GetActivity(ctx *gin.Context)
+	GetSettings(ctx *gin.Context)
+	UpdateSettings(ctx *gin.Context)
+}
+
+type LoginAuditController struct {
+	loginAuditUseCase loginAuditUseCase.ILoginAuditUseCase
+	Logger            *logger.Logger
+}
+
+func NewLoginAuditController(loginAuditUseCase loginAuditUseCase.ILoginAuditUseCase, loggerInstance *logger.Logger) ILoginAuditController {
+	return &LoginAuditController{loginAuditUseCase: loginAuditUseCase, Logger: loggerInstance}
+}
+
+// GetActivity returns a page of the login attempts of the authenticated user, newest first
+func (c *LoginAuditController) GetActivity(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request ActivityRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	page, err := request.PageRequest()
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	events, err := c.loginAuditUseCase.GetActivity(userID, page)
+	if err != nil {
+		c.Logger.Error("Error getting login activity", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := make([]LoginEventResponse, len(events.Items))
+	for i, event := range events.Items {
+		response[i] = LoginEventResponse{
+			ID:            event.ID,
+			Method:        event.Method,
+			IPAddress:     event.IPAddress,
+			UserAgent:     event.UserAgent,
+			Success:       event.Success,
+			FailureReason: event.FailureReason,
+			NewLocation:   event.NewLocation,
+			CreatedAt:     event.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, controllers.NewPageResponse(response, events.Next))
+}
+
+// GetSettings returns the login notification settings of the authenticated user
+func (c *LoginAuditController) GetSettings(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	settings, err := c.loginAuditUseCase.GetSettings(userID)
+	if err != nil {
+		c.Logger.Error("Error getting login notification settings", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, settingsToResponse(settings))
+}
+
+// UpdateSettings sets where the authenticated user is notified about suspicious logins, or disables the notifications
+func (c *LoginAuditController) UpdateSettings(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request UpdateSettingsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	settings, err := c.loginAuditUseCase.UpdateSettings(&domainUser.LoginNotificationSettings{
+		UserID:    userID,
+		Channel:   request.Channel,
+		Recipient: request.Recipient,
+		Enabled:   *request.Enabled,
+	})
+	if err != nil {
+		c.Logger.Error("Error updating login notification settings", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, settingsToResponse(settings))
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *LoginAuditController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func settingsToResponse(settings *domainUser.LoginNotificationSettings) SettingsResponse {
+	return SettingsResponse{
+		Channel:   settings.Channel,
+		Recipient: settings.Recipient,
+		Enabled:   settings.Enabled,
+	}
+}
@@ -0,0 +1,34 @@
+package loginaudit
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+)
+
+type ActivityRequest struct {
+	controllers.PageQuery
+}
+
+type UpdateSettingsRequest struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Enabled   *bool  `json:"enabled" binding:"required"`
+}
+
+type SettingsResponse struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type LoginEventResponse struct {
+	ID            int       `json:"id"`
+	Method        string    `json:"method"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	NewLocation   bool      `json:"new_location"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package maintenance
+
+import (
+	"net/http"
+
+	maintenanceUseCase "go-multi-chat-api/src/application/usecases/maintenance"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IMaintenanceController interface {
+	Get(ctx *gin.Context)
+	Set(ctx *gin.Context)
+}
+
+type MaintenanceController struct {
+	maintenanceUseCase maintenanceUseCase.IMaintenanceUseCase
+	Logger             *logger.Logger
+}
+
+func NewMaintenanceController(maintenanceUseCase maintenanceUseCase.IMaintenanceUseCase, loggerInstance *logger.Logger) IMaintenanceController {
+	return &MaintenanceController{maintenanceUseCase: maintenanceUseCase, Logger: loggerInstance}
+}
+
+// Get returns whether maintenance mode is enabled
+func (c *MaintenanceController) Get(ctx *gin.Context) {
+	mode, err := c.maintenanceUseCase.Get()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(mode))
+}
+
+// Set enables or disables maintenance mode
+func (c *MaintenanceController) Set(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request SetRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	mode, err := c.maintenanceUseCase.Set(*request.Enabled, request.Reason, userID)
+	if err != nil {
+		c.Logger.Error("Error setting maintenance mode", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(mode))
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *MaintenanceController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
+
+func toResponse(mode *domainProvider.MaintenanceMode) MaintenanceResponse {
+	return MaintenanceResponse{Enabled: mode.Enabled, Reason: mode.Reason, UpdatedBy: mode.UpdatedBy, UpdatedAt: mode.UpdatedAt}
+}
@@ -0,0 +1,15 @@
+package maintenance
+
+import "time"
+
+type SetRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Reason  string `json:"reason,omitempty" binding:"max=255"`
+}
+
+type MaintenanceResponse struct {
+	Enabled   bool       `json:"enabled"`
+	Reason    string     `json:"reason,omitempty"`
+	UpdatedBy int        `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
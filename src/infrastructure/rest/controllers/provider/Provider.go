@@ -0,0 +1,489 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	providerUseCase "go-multi-chat-api/src/application/usecases/provider"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IProviderController interface {
+	TestSend(ctx *gin.Context)
+	GetProviderTypes(ctx *gin.Context)
+	GetProviders(ctx *gin.Context)
+	CreateProvider(ctx *gin.Context)
+	UpdateProvider(ctx *gin.Context)
+	UpdateUserProviderConfig(ctx *gin.Context)
+	ValidateUserProviderCredentials(ctx *gin.Context)
+	StartDrill(ctx *gin.Context)
+	GetActiveDrills(ctx *gin.Context)
+	EndDrill(ctx *gin.Context)
+	UpsertProvider(ctx *gin.Context)
+	ApplyDesiredState(ctx *gin.Context)
+}
+
+type ProviderController struct {
+	providerUseCase providerUseCase.IProviderUseCase
+	Logger          *logger.Logger
+}
+
+func NewProviderController(providerUseCase providerUseCase.IProviderUseCase, loggerInstance *logger.Logger) IProviderController {
+	return &ProviderController{providerUseCase: providerUseCase, Logger: loggerInstance}
+}
+
+// TestSend sends a test message through one provider of the authenticated user and returns the raw provider
+// response. A failed send is answered with 502 Bad Gateway and the provider error.
+func (c *ProviderController) TestSend(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request TestSendRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	result, err := c.providerUseCase.TestSend(userID, id, request.Recipient)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusBadGateway
+	}
+	ctx.JSON(status, TestSendResponse{
+		ProviderID:   result.ProviderID,
+		ProviderType: result.ProviderType,
+		Active:       result.Active,
+		Recipient:    result.Recipient,
+		Success:      result.Success,
+		Error:        result.Error,
+		Request:      result.Request,
+		Response:     result.Response,
+	})
+}
+
+// GetProviderTypes lists the provider types with the JSON schemas of their configs, so UIs can render config
+// forms, and their capabilities
+func (c *ProviderController) GetProviderTypes(ctx *gin.Context) {
+	types := c.providerUseCase.GetProviderTypes()
+	response := make([]ProviderTypeResponse, len(types))
+	for i, t := range types {
+		response[i] = ProviderTypeResponse{Type: t.Type, ProviderSchema: t.ProviderSchema, UserProviderSchema: t.UserProviderSchema, Capabilities: t.Capabilities}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetProviders lists every provider, without their configs as these hold credentials
+func (c *ProviderController) GetProviders(ctx *gin.Context) {
+	providers, err := c.providerUseCase.GetProviders()
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	response := make([]ProviderResponse, len(*providers))
+	for i := range *providers {
+		response[i] = providerToResponse(&(*providers)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CreateProvider creates a provider. An invalid config is answered with 400 and the offending fields.
+func (c *ProviderController) CreateProvider(ctx *gin.Context) {
+	var request CreateProviderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	config, err := configString(request.Config)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	status := true
+	if request.Status != nil {
+		status = *request.Status
+	}
+	created, err := c.providerUseCase.CreateProvider(&domainProvider.Provider{
+		Name:        request.Name,
+		Type:        request.Type,
+		Description: request.Description,
+		Config:      config,
+		Status:      status,
+	})
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	c.Logger.Info("Provider created", zap.Int("providerID", created.ID), zap.String("type", created.Type))
+	ctx.JSON(http.StatusCreated, providerToResponse(created))
+}
+
+// UpdateProvider updates the given fields of a provider. An invalid config is answered with 400 and the offending fields.
+func (c *ProviderController) UpdateProvider(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request UpdateProviderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if request.Name != nil {
+		updates["name"] = *request.Name
+	}
+	if request.Type != nil {
+		updates["type"] = *request.Type
+	}
+	if request.Description != nil {
+		updates["description"] = *request.Description
+	}
+	if request.Config != nil {
+		config, err := configString(request.Config)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		updates["config"] = config
+	}
+	if request.Status != nil {
+		updates["status"] = *request.Status
+	}
+	if request.Version != nil {
+		updates["version"] = *request.Version
+	}
+	if len(updates) == 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("no fields to update"), domainErrors.ValidationError))
+		return
+	}
+
+	updated, err := c.providerUseCase.UpdateProvider(id, updates)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, providerToResponse(updated))
+}
+
+// UpdateUserProviderConfig replaces the authenticated user's config for one of the user's providers
+func (c *ProviderController) UpdateUserProviderConfig(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request UpdateUserProviderConfigRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	config, err := configString(request.Config)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	updated, err := c.providerUseCase.UpdateUserProviderConfig(userID, id, config, request.Version, request.Force)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, userProviderToResponse(updated))
+}
+
+// ValidateUserProviderCredentials checks the credentials of the authenticated user's config for one of the
+// user's providers again
+func (c *ProviderController) ValidateUserProviderCredentials(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	validated, err := c.providerUseCase.ValidateUserProviderCredentials(userID, id)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, userProviderToResponse(validated))
+}
+
+// StartDrill starts a failover drill simulating an outage of a provider for a user
+func (c *ProviderController) StartDrill(ctx *gin.Context) {
+	adminID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request StartDrillRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	drill, err := c.providerUseCase.StartDrill(request.UserID, id, adminID, request.DurationMinutes, request.Reason)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, drillToResponse(drill))
+}
+
+// GetActiveDrills returns the failover drills running now
+func (c *ProviderController) GetActiveDrills(ctx *gin.Context) {
+	drills, err := c.providerUseCase.GetActiveDrills()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]DrillResponse, len(*drills))
+	for i, drill := range *drills {
+		response[i] = drillToResponse(&drill)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// EndDrill ends a running failover drill early
+func (c *ProviderController) EndDrill(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := c.providerUseCase.EndDrill(id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// UpsertProvider creates or updates the provider of the name in the path, answering 201 when it was created.
+// Sending the same provider again changes nothing.
+func (c *ProviderController) UpsertProvider(ctx *gin.Context) {
+	var request UpsertProviderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	config, err := configString(request.Config)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	provider, created, err := c.providerUseCase.UpsertProvider(&providerUseCase.DesiredProvider{
+		Name:        ctx.Param("name"),
+		Type:        request.Type,
+		Description: request.Description,
+		Config:      config,
+		Status:      request.Status == nil || *request.Status,
+	})
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, providerToResponse(provider))
+}
+
+// ApplyDesiredState reconciles the providers and user providers with a desired state document and lists the
+// changes. With dry_run the changes are only listed.
+func (c *ProviderController) ApplyDesiredState(ctx *gin.Context) {
+	var query ApplyDesiredStateQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	var request DesiredStateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	state := &providerUseCase.DesiredState{
+		Providers:     make([]providerUseCase.DesiredProvider, len(request.Providers)),
+		UserProviders: make([]providerUseCase.DesiredUserProvider, len(request.UserProviders)),
+		Prune:         request.Prune,
+	}
+	for i, p := range request.Providers {
+		config, err := configString(p.Config)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		state.Providers[i] = providerUseCase.DesiredProvider{
+			Name:        p.Name,
+			Type:        p.Type,
+			Description: p.Description,
+			Config:      config,
+			Status:      p.Status == nil || *p.Status,
+		}
+	}
+	for i, up := range request.UserProviders {
+		config, err := configString(up.Config)
+		if err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		priority := up.Priority
+		if priority == 0 {
+			priority = 1
+		}
+		state.UserProviders[i] = providerUseCase.DesiredUserProvider{
+			User:     up.User,
+			Provider: up.Provider,
+			Priority: priority,
+			Config:   config,
+			Status:   up.Status == nil || *up.Status,
+		}
+	}
+
+	changes, err := c.providerUseCase.ApplyDesiredState(state, query.DryRun)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	response := ApplyDesiredStateResponse{DryRun: query.DryRun, Changes: make([]ChangeResponse, len(changes))}
+	for i, change := range changes {
+		response.Changes[i] = ChangeResponse{
+			Kind:     change.Kind,
+			Provider: change.Provider,
+			User:     change.User,
+			Action:   change.Action,
+			ID:       change.ID,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// handleError answers config validation errors with 400 and the offending fields, other errors go to the error handler
+func (c *ProviderController) handleError(ctx *gin.Context, err error) {
+	var validationErr *providerconfig.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, InvalidConfigResponse{Error: "invalid config", Fields: validationErr.Errors})
+		return
+	}
+	_ = ctx.Error(err)
+}
+
+// idParam reads the provider or drill ID from the path
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
+
+// configString compacts a config sent as a JSON object into the text stored on the provider. A missing or null
+// config clears it.
+func configString(config json.RawMessage) (string, error) {
+	if len(config) == 0 || string(config) == "null" {
+		return "", nil
+	}
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, config); err != nil {
+		return "", domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return buffer.String(), nil
+}
+
+// rawConfig passes a stored config through as JSON
+func rawConfig(config string) json.RawMessage {
+	if config == "" || !json.Valid([]byte(config)) {
+		return nil
+	}
+	return json.RawMessage(config)
+}
+
+func providerToResponse(p *domainProvider.Provider) ProviderResponse {
+	return ProviderResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Type:        p.Type,
+		Description: p.Description,
+		Status:      p.Status,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func userProviderToResponse(up *domainProvider.UserProvider) UserProviderResponse {
+	return UserProviderResponse{
+		ID:                   up.ID,
+		ProviderID:           up.ProviderID,
+		Priority:             up.Priority,
+		Config:               rawConfig(up.Config),
+		Status:               up.Status,
+		Version:              up.Version,
+		CredentialStatus:     up.CredentialStatus,
+		CredentialError:      up.CredentialError,
+		CredentialsCheckedAt: up.CredentialsCheckedAt,
+		CredentialsForced:    up.CredentialsForced,
+		UpdatedAt:            up.UpdatedAt,
+	}
+}
+
+func drillToResponse(d *domainProvider.ProviderDrill) DrillResponse {
+	return DrillResponse{
+		ID:         d.ID,
+		UserID:     d.UserID,
+		ProviderID: d.ProviderID,
+		StartedBy:  d.StartedBy,
+		Reason:     d.Reason,
+		StartsAt:   d.StartsAt,
+		EndsAt:     d.EndsAt,
+		EndedAt:    d.EndedAt,
+	}
+}
+
+// currentUserID reads the user ID set by the JWT middleware
+func (c *ProviderController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"encoding/json"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+)
+
+type TestSendRequest struct {
+	Recipient string `json:"recipient" binding:"required"`
+}
+
+type TestSendResponse struct {
+	ProviderID   int             `json:"provider_id"`
+	ProviderType string          `json:"provider_type"`
+	Active       bool            `json:"active"`
+	Recipient    string          `json:"recipient"`
+	Success      bool            `json:"success"`
+	Error        string          `json:"error,omitempty"`
+	Request      json.RawMessage `json:"request,omitempty"`
+	Response     json.RawMessage `json:"response,omitempty"`
+}
+
+type ProviderTypeResponse struct {
+	Type               string                      `json:"type"`
+	ProviderSchema     *providerconfig.Schema      `json:"provider_schema"`
+	UserProviderSchema *providerconfig.Schema      `json:"user_provider_schema"`
+	Capabilities       providerconfig.Capabilities `json:"capabilities"`
+}
+
+// Configs are sent as JSON objects and stored as text
+
+type CreateProviderRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Type        string          `json:"type" binding:"required"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	Status      *bool           `json:"status"`
+}
+
+type UpdateProviderRequest struct {
+	Name        *string         `json:"name"`
+	Type        *string         `json:"type"`
+	Description *string         `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	Status      *bool           `json:"status"`
+	Version     *int            `json:"version"`
+}
+
+// UpsertProviderRequest is the provider of the name in the path, its status defaults to active
+type UpsertProviderRequest struct {
+	Type        string          `json:"type" binding:"required"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	Status      *bool           `json:"status"`
+}
+
+// DesiredStateRequest is the full provider setup applied by ApplyDesiredState
+type DesiredStateRequest struct {
+	Providers     []DesiredProviderRequest     `json:"providers" binding:"dive"`
+	UserProviders []DesiredUserProviderRequest `json:"user_providers" binding:"dive"`
+	// Prune removes the user providers of the listed users that aren't listed
+	Prune bool `json:"prune"`
+}
+
+type DesiredProviderRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Type        string          `json:"type" binding:"required"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	Status      *bool           `json:"status"`
+}
+
+// DesiredUserProviderRequest links a user, by email, to a provider, by name. Priority defaults to 1 and status
+// to active.
+type DesiredUserProviderRequest struct {
+	User     string          `json:"user" binding:"required"`
+	Provider string          `json:"provider" binding:"required"`
+	Priority int             `json:"priority"`
+	Config   json.RawMessage `json:"config"`
+	Status   *bool           `json:"status"`
+}
+
+type ApplyDesiredStateQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type ApplyDesiredStateResponse struct {
+	DryRun  bool             `json:"dry_run"`
+	Changes []ChangeResponse `json:"changes"`
+}
+
+type ChangeResponse struct {
+	Kind     string `json:"kind"`
+	Provider string `json:"provider"`
+	User     string `json:"user,omitempty"`
+	Action   string `json:"action"`
+	ID       int    `json:"id,omitempty"`
+}
+
+type UpdateUserProviderConfigRequest struct {
+	Config  json.RawMessage `json:"config" binding:"required"`
+	Version *int            `json:"version"`
+	// Force routes messages to the provider even when its credentials are refused
+	Force bool `json:"force"`
+}
+
+// ProviderResponse leaves out the config, it may hold credentials
+type ProviderResponse struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Status      bool      `json:"status"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UserProviderResponse struct {
+	ID                   int             `json:"id"`
+	ProviderID           int             `json:"provider_id"`
+	Priority             int             `json:"priority"`
+	Config               json.RawMessage `json:"config,omitempty"`
+	Status               bool            `json:"status"`
+	Version              int             `json:"version"`
+	CredentialStatus     string          `json:"credential_status,omitempty"`
+	CredentialError      string          `json:"credential_error,omitempty"`
+	CredentialsCheckedAt *time.Time      `json:"credentials_checked_at,omitempty"`
+	CredentialsForced    bool            `json:"credentials_forced"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+}
+
+type StartDrillRequest struct {
+	UserID          int    `json:"user_id" binding:"required"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required"`
+	Reason          string `json:"reason"`
+}
+
+type DrillResponse struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	ProviderID int        `json:"provider_id"`
+	StartedBy  int        `json:"started_by"`
+	Reason     string     `json:"reason,omitempty"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+}
+
+type InvalidConfigResponse struct {
+	Error  string                      `json:"error"`
+	Fields []providerconfig.FieldError `json:"fields"`
+}
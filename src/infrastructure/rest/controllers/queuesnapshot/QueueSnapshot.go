@@ -0,0 +1,115 @@
+package queuesnapshot
+
+import (
+	"fmt"
+	"net/http"
+
+	snapshotUseCase "go-multi-chat-api/src/application/usecases/queuesnapshot"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	queueSnapshot "go-multi-chat-api/src/infrastructure/queuesnapshot"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IQueueSnapshotController interface {
+	StartSnapshot(ctx *gin.Context)
+	GetSnapshots(ctx *gin.Context)
+	DownloadSnapshot(ctx *gin.Context)
+	StartReplay(ctx *gin.Context)
+}
+
+type QueueSnapshotController struct {
+	snapshotUseCase snapshotUseCase.IQueueSnapshotUseCase
+	Logger          *logger.Logger
+}
+
+func NewQueueSnapshotController(snapshotUseCase snapshotUseCase.IQueueSnapshotUseCase, loggerInstance *logger.Logger) IQueueSnapshotController {
+	return &QueueSnapshotController{snapshotUseCase: snapshotUseCase, Logger: loggerInstance}
+}
+
+// StartSnapshot queues a job writing the messages waiting to be sent to a new snapshot, its progress is followed
+// through the jobs endpoints
+func (c *QueueSnapshotController) StartSnapshot(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	job, name, err := c.snapshotUseCase.StartSnapshot(userID)
+	if err != nil {
+		c.Logger.Error("Error starting queue snapshot", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{Name: name, JobID: job.ID, JobStatus: job.Status})
+}
+
+// GetSnapshots lists the snapshots, newest first
+func (c *QueueSnapshotController) GetSnapshots(ctx *gin.Context) {
+	snapshots, err := c.snapshotUseCase.GetSnapshots()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]SnapshotResponse, len(snapshots))
+	for i, snapshot := range snapshots {
+		response[i] = SnapshotResponse{Name: snapshot.Name, Size: snapshot.Size, CreatedAt: snapshot.CreatedAt}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// DownloadSnapshot streams a snapshot as JSON lines, e.g. to keep it or to replay it with the CLI elsewhere
+func (c *QueueSnapshotController) DownloadSnapshot(ctx *gin.Context) {
+	file, snapshot, err := c.snapshotUseCase.OpenSnapshot(ctx.Param("name"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	defer file.Close()
+	ctx.DataFromReader(http.StatusOK, snapshot.Size, "application/x-ndjson", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename=%q`, snapshot.Name),
+	})
+}
+
+// StartReplay queues a job putting the messages of a snapshot selected by the request back in the queue
+func (c *QueueSnapshotController) StartReplay(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request ReplayRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	filter := queueSnapshot.Filter{
+		Statuses:   request.Statuses,
+		UserID:     request.UserID,
+		ProviderID: request.ProviderID,
+		ErrorCode:  request.ErrorCode,
+		From:       request.From,
+		To:         request.To,
+	}
+	options := queueSnapshot.Options{DryRun: request.DryRun, Resend: request.Resend}
+	job, err := c.snapshotUseCase.StartReplay(ctx.Param("name"), filter, options, userID)
+	if err != nil {
+		c.Logger.Error("Error starting queue snapshot replay", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, StartResponse{JobID: job.ID, JobStatus: job.Status})
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *QueueSnapshotController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
@@ -0,0 +1,27 @@
+package queuesnapshot
+
+import "time"
+
+type ReplayRequest struct {
+	// Statuses select the messages by the status they had in the snapshot, all of them when empty
+	Statuses   []string   `json:"statuses"`
+	UserID     int        `json:"user_id" binding:"omitempty,min=1"`
+	ProviderID int        `json:"provider_id" binding:"omitempty,min=1"`
+	ErrorCode  string     `json:"error_code"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	DryRun     bool       `json:"dry_run"`
+	Resend     bool       `json:"resend"`
+}
+
+type StartResponse struct {
+	Name      string `json:"name,omitempty"`
+	JobID     int    `json:"job_id"`
+	JobStatus string `json:"job_status"`
+}
+
+type SnapshotResponse struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
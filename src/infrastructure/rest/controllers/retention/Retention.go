@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"net/http"
+
+	retentionUseCase "go-multi-chat-api/src/application/usecases/retention"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IRetentionController interface {
+	GetPolicy(ctx *gin.Context)
+	SetPolicy(ctx *gin.Context)
+}
+
+type RetentionController struct {
+	retentionUseCase retentionUseCase.IRetentionUseCase
+	Logger           *logger.Logger
+}
+
+func NewRetentionController(retentionUseCase retentionUseCase.IRetentionUseCase, loggerInstance *logger.Logger) IRetentionController {
+	return &RetentionController{retentionUseCase: retentionUseCase, Logger: loggerInstance}
+}
+
+// GetPolicy returns the retention policy of the org
+func (c *RetentionController) GetPolicy(ctx *gin.Context) {
+	policy, err := c.retentionUseCase.GetPolicy()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(policy))
+}
+
+// SetPolicy replaces the retention policy of the org
+func (c *RetentionController) SetPolicy(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request SetPolicyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	policy, err := c.retentionUseCase.SetPolicy(domainProvider.RetentionPolicy{
+		BodyDays:       *request.BodyDays,
+		AttachmentDays: *request.AttachmentDays,
+		AuditLogDays:   *request.AuditLogDays,
+		RedactBodies:   request.RedactBodies,
+	}, userID)
+	if err != nil {
+		c.Logger.Info("Error setting retention policy", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toResponse(policy))
+}
+
+// currentUserID reads the user ID set by the auth middlewares, the role middleware stores it as an int
+func (c *RetentionController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, _ := ctx.Get("userID")
+	switch userID := userIdentity.(type) {
+	case int:
+		return userID, true
+	case float64:
+		return int(userID), true
+	}
+	_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+	return 0, false
+}
+
+func toResponse(policy *domainProvider.RetentionPolicy) PolicyResponse {
+	return PolicyResponse{
+		Org:            policy.Org,
+		BodyDays:       policy.BodyDays,
+		AttachmentDays: policy.AttachmentDays,
+		AuditLogDays:   policy.AuditLogDays,
+		RedactBodies:   policy.RedactBodies,
+		UpdatedBy:      policy.UpdatedBy,
+		UpdatedAt:      policy.UpdatedAt,
+	}
+}
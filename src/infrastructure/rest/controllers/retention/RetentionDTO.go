@@ -0,0 +1,20 @@
+package retention
+
+import "time"
+
+type SetPolicyRequest struct {
+	BodyDays       *int `json:"body_days" binding:"required"`
+	AttachmentDays *int `json:"attachment_days" binding:"required"`
+	AuditLogDays   *int `json:"audit_log_days" binding:"required"`
+	RedactBodies   bool `json:"redact_bodies"`
+}
+
+type PolicyResponse struct {
+	Org            string     `json:"org"`
+	BodyDays       int        `json:"body_days"`
+	AttachmentDays int        `json:"attachment_days"`
+	AuditLogDays   int        `json:"audit_log_days"`
+	RedactBodies   bool       `json:"redact_bodies"`
+	UpdatedBy      int        `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
@@ -4,8 +4,18 @@ import (
 	"errors"
 	"go-multi-chat-api/src/application/usecases/message"
 	"go-multi-chat-api/src/domain/common"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	domainUser "go-multi-chat-api/src/domain/user"
+	"go-multi-chat-api/src/infrastructure/directory"
 	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/messaging"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+	"go-multi-chat-api/src/infrastructure/transform"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +27,12 @@ type ISendController interface {
 	Message(c *gin.Context)
 	RetryFailedMessages()
 	GetMessageStatus(c *gin.Context)
+	EditMessage(c *gin.Context)
+	GetMessageHistory(c *gin.Context)
+	GetUserMessageHistory(c *gin.Context)
+	GetQueueStats(c *gin.Context)
+	GetUserLoads(c *gin.Context)
+	Preview(c *gin.Context)
 }
 
 type SendController struct {
@@ -75,15 +91,60 @@ func (c *SendController) Message(ctx *gin.Context) {
 	}
 
 	// Convert controller request to use case request
-	useCaseRequest := &message.MessageRequest{
-		Type:       request.Type,
-		Message:    request.Message,
-		Recipients: request.Recipients,
-		UserID:     int(userID),
-	}
+	useCaseRequest := toMessageRequest(request, int(userID))
 
 	// Call the use case
 	useCaseResponse, err := c.messageUseCase.SendMessage(useCaseRequest)
+	var backlogErr *message.BacklogExceededError
+	if errors.As(err, &backlogErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(backlogErr.RetryAfter.Seconds())))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many pending messages, retry later"})
+		return
+	}
+	var capErr *message.RecipientCapExceededError
+	if errors.As(err, &capErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(capErr.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error":             err.Error(),
+			"code":              "recipient_cap_exceeded",
+			"capped_recipients": toCappedRecipients(capErr.Recipients),
+		})
+		return
+	}
+	var deactivatedErr *message.UserDeactivatedError
+	if errors.As(err, &deactivatedErr) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "The account is deactivated", "code": domainUser.ErrorCodeDeactivated})
+		return
+	}
+	var resolutionErr *message.RecipientResolutionError
+	if errors.As(err, &resolutionErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":                 "None of the recipients could be resolved",
+			"unresolved_recipients": toUnresolvedRecipients(resolutionErr.Failures),
+			"recipients":            toRecipientResults(resolutionErr.Recipients),
+		})
+		return
+	}
+	var tooLongErr *message.MessageTooLongError
+	if errors.As(err, &tooLongErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         err.Error(),
+			"code":          "message_too_long",
+			"provider_type": tooLongErr.ProviderType,
+			"segmentation":  toSegmentation(tooLongErr.Segmentation),
+		})
+		return
+	}
+	var transformErr *transform.Error
+	if errors.As(err, &transformErr) {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "transform_failed", "position": transformErr.Position})
+		return
+	}
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.ValidationError {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": appErr.Error()})
+		return
+	}
 	if err != nil {
 		c.Logger.Error("Error sending message", zap.Error(err), zap.Float64("userID", userID))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending message"})
@@ -92,9 +153,15 @@ func (c *SendController) Message(ctx *gin.Context) {
 
 	// Convert use case response to controller response
 	response := &MessageResponse{
-		ID:      useCaseResponse.ID,
-		Status:  useCaseResponse.Status,
-		Message: useCaseResponse.Message,
+		ID:                   useCaseResponse.ID,
+		Status:               useCaseResponse.Status,
+		Message:              useCaseResponse.Message,
+		UnresolvedRecipients: toUnresolvedRecipients(useCaseResponse.UnresolvedRecipients),
+		AckToken:             useCaseResponse.AckToken,
+		AckDeadline:          formatOptionalTime(useCaseResponse.AckDeadline),
+		Segmentation:         toSegmentation(useCaseResponse.Segmentation),
+		CappedRecipients:     toCappedRecipients(useCaseResponse.CappedRecipients),
+		Recipients:           toRecipientResults(useCaseResponse.Recipients),
 	}
 
 	c.Logger.Info("Message queued for processing",
@@ -105,6 +172,48 @@ func (c *SendController) Message(ctx *gin.Context) {
 	ctx.JSON(http.StatusAccepted, response)
 }
 
+// Preview handles requests to plan a message without sending it: the message as sent, the resolved recipients,
+// the provider it is routed to and its fallbacks, and the estimated cost
+func (c *SendController) Preview(ctx *gin.Context) {
+	var request MessageRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		c.Logger.Error("Couldn't process request - invalid request", zap.Error(err))
+		var ve validator.ValidationErrors
+		if errors.As(err, &ve) {
+			c.commonService.AppendValidationErrors(ctx, ve, request)
+			return
+		}
+		ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	userIdentity, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusOK, gin.H{"error": "User not found"})
+		return
+	}
+
+	userID, ok := userIdentity.(float64)
+	if !ok {
+		c.Logger.Error("Invalid user ID type", zap.Any("userID", userIdentity))
+		ctx.JSON(http.StatusOK, gin.H{"error": "User not found"})
+		return
+	}
+
+	preview, err := c.messageUseCase.PreviewMessage(toMessageRequest(request, int(userID)))
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) && appErr.Type == domainErrors.ValidationError {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": appErr.Error()})
+		return
+	}
+	if err != nil {
+		c.Logger.Error("Error previewing message", zap.Error(err), zap.Float64("userID", userID))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error previewing message"})
+		return
+	}
+	ctx.JSON(http.StatusOK, toPreviewResponse(preview))
+}
+
 // GetMessageStatus handles requests to check the status of a message
 func (c *SendController) GetMessageStatus(ctx *gin.Context) {
 	var request MessageStatusRequest
@@ -129,16 +238,420 @@ func (c *SendController) GetMessageStatus(ctx *gin.Context) {
 
 	// Convert use case response to controller response
 	response := &MessageStatusResponse{
-		ID:           useCaseResponse.ID,
-		Status:       useCaseResponse.Status,
-		Message:      useCaseResponse.Message,
-		Recipients:   useCaseResponse.Recipients,
-		ErrorMessage: useCaseResponse.ErrorMessage,
-		RetryCount:   useCaseResponse.RetryCount,
-		CreatedAt:    useCaseResponse.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    useCaseResponse.UpdatedAt.Format(time.RFC3339),
+		ID:             useCaseResponse.ID,
+		Status:         useCaseResponse.Status,
+		Message:        useCaseResponse.Message,
+		Recipients:     useCaseResponse.Recipients,
+		ErrorMessage:   useCaseResponse.ErrorMessage,
+		ErrorCode:      useCaseResponse.ErrorCode,
+		RetryCount:     useCaseResponse.RetryCount,
+		Tags:           useCaseResponse.Tags,
+		AckStatus:      useCaseResponse.AckStatus,
+		AckDeadline:    formatOptionalTime(useCaseResponse.AckDeadline),
+		AcknowledgedBy: useCaseResponse.AcknowledgedBy,
+		AcknowledgedAt: formatOptionalTime(useCaseResponse.AcknowledgedAt),
+		LinkClicks:     toLinkClicks(useCaseResponse.LinkClicks),
+		Deliveries:     toDeliveries(useCaseResponse.Deliveries),
+		Edits:          toMessageEdits(useCaseResponse.Edits),
+		Segments:       useCaseResponse.Segments,
+		Actions:        toActions(useCaseResponse.Actions),
+		CreatedAt:      useCaseResponse.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      useCaseResponse.UpdatedAt.Format(time.RFC3339),
 	}
 
 	c.Logger.Info("Retrieved message status", zap.Int("messageID", request.ID), zap.String("status", useCaseResponse.Status))
 	ctx.JSON(http.StatusOK, response)
 }
+
+// EditMessage handles requests to change the text of a sent message of the user
+func (c *SendController) EditMessage(ctx *gin.Context) {
+	var uri MessageStatusRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+	var request EditMessageRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Couldn't process request - please provide the new message"})
+		return
+	}
+
+	userIdentity, _ := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !ok {
+		ctx.JSON(http.StatusOK, gin.H{"error": "User not found"})
+		return
+	}
+
+	edited, err := c.messageUseCase.EditMessage(&message.EditMessageRequest{ID: uri.ID, UserID: int(userID), Message: request.Message})
+	var notSupportedErr *message.EditNotSupportedError
+	if errors.As(err, &notSupportedErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "edit_not_supported", "provider_type": notSupportedErr.ProviderType})
+		return
+	}
+	var failedErr *message.EditFailedError
+	if errors.As(err, &failedErr) {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "edit_failed", "provider_type": failedErr.ProviderType})
+		return
+	}
+	var appErr *domainErrors.AppError
+	if errors.As(err, &appErr) {
+		_ = ctx.Error(appErr)
+		return
+	}
+	if err != nil {
+		c.Logger.Error("Error editing message", zap.Error(err), zap.Int("messageID", uri.ID))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error editing message"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &EditMessageResponse{
+		ID:           edited.ID,
+		ProviderType: edited.ProviderType,
+		Message:      edited.Message,
+		Edits:        toMessageEdits(edited.Edits),
+	})
+}
+
+// GetMessageHistory handles requests to search the processed messages of the user by status and tags
+func (c *SendController) GetMessageHistory(ctx *gin.Context) {
+	userIdentity, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusOK, gin.H{"error": "User not found"})
+		return
+	}
+
+	userID, ok := userIdentity.(float64)
+	if !ok {
+		c.Logger.Error("Invalid user ID type", zap.Any("userID", userIdentity))
+		ctx.JSON(http.StatusOK, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.searchMessageHistory(ctx, int(userID))
+}
+
+// GetUserMessageHistory handles requests of admins to search the processed messages of any user by status and tags
+func (c *SendController) GetUserMessageHistory(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || userID <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return
+	}
+
+	c.searchMessageHistory(ctx, userID)
+}
+
+// searchMessageHistory answers a history search of the messages of a user
+func (c *SendController) searchMessageHistory(ctx *gin.Context, userID int) {
+	var request MessageHistoryRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		c.Logger.Error("Invalid message history request", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message history request"})
+		return
+	}
+
+	tags, err := parseTagFilters(request.Tags)
+	if err != nil {
+		c.Logger.Error("Invalid tag filter", zap.Error(err))
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := request.PageRequest()
+	if err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+
+	// Convert controller request to use case request
+	useCaseRequest := &message.MessageHistoryRequest{
+		UserID: userID,
+		Status: request.Status,
+		Tags:   tags,
+		Page:   page,
+	}
+
+	// Call the use case
+	history, err := c.messageUseCase.GetMessageHistory(useCaseRequest)
+	if err != nil {
+		c.Logger.Error("Error getting message history", zap.Error(err), zap.Int("userID", userID))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting message history"})
+		return
+	}
+
+	// Convert use case response to controller response
+	response := make([]MessageHistoryResponse, len(history.Items))
+	for i, item := range history.Items {
+		response[i] = MessageHistoryResponse{
+			ID:           item.ID,
+			MessageID:    item.MessageID,
+			ProviderID:   item.ProviderID,
+			Status:       item.Status,
+			Message:      item.Message,
+			Recipients:   item.Recipients,
+			ErrorMessage: item.ErrorMessage,
+			ErrorCode:    item.ErrorCode,
+			RetryCount:   item.RetryCount,
+			Tags:         item.Tags,
+			ProcessedAt:  item.ProcessedAt.Format(time.RFC3339),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, controllers.NewPageResponse(response, history.Next))
+}
+
+// GetQueueStats handles requests for the saturation of the message pipeline
+func (c *SendController) GetQueueStats(ctx *gin.Context) {
+	stats, err := c.messageUseCase.GetQueueStats()
+	if err != nil {
+		c.Logger.Error("Error getting queue stats", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting queue stats"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &QueueStatsResponse{
+		QueueDepth:          stats.QueueDepth,
+		QueueCapacity:       stats.QueueCapacity,
+		Backlog:             stats.Backlog,
+		Threshold:           stats.Threshold,
+		Deferred:            stats.Deferred,
+		Rejected:            stats.Rejected,
+		MaxInFlightPerUser:  stats.MaxInFlightPerUser,
+		Processing:          stats.Processing,
+		FailedAwaitingRetry: stats.FailedAwaitingRetry,
+		Held:                stats.Held,
+		LagSeconds:          int(stats.Lag.Seconds()),
+		LagLevel:            stats.LagLevel,
+		RefreshedAt:         stats.RefreshedAt,
+	})
+}
+
+// GetUserLoads handles requests for the messages of each user being processed or queued on this instance
+func (c *SendController) GetUserLoads(ctx *gin.Context) {
+	stats, err := c.messageUseCase.GetQueueStats()
+	if err != nil {
+		c.Logger.Error("Error getting queue stats", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting queue stats"})
+		return
+	}
+
+	users := make([]UserLoadResponse, len(stats.Users))
+	for i, load := range stats.Users {
+		users[i] = UserLoadResponse{UserID: load.UserID, InFlight: load.InFlight, Queued: load.Queued}
+	}
+	ctx.JSON(http.StatusOK, &UserLoadsResponse{MaxInFlightPerUser: stats.MaxInFlightPerUser, Users: users})
+}
+
+// toMessageRequest converts a send request of a user to the request of the message use case
+func toMessageRequest(request MessageRequest, userID int) *message.MessageRequest {
+	useCaseRequest := &message.MessageRequest{
+		Type:       request.Type,
+		Message:    request.Message,
+		Recipients: request.Recipients,
+		Tags:       request.Tags,
+		TrackLinks: request.TrackLinks,
+		UserID:     userID,
+	}
+	if request.Ack != nil {
+		useCaseRequest.Ack = &message.AckRequest{
+			Keyword:           request.Ack.Keyword,
+			Timeout:           time.Duration(request.Ack.TimeoutSeconds) * time.Second,
+			EscalationChainID: request.Ack.EscalationChainID,
+		}
+	}
+	if request.Extensions != nil && request.Extensions.Signal != nil {
+		useCaseRequest.Extensions = &provider.MessageExtensions{Signal: &provider.SignalExtension{
+			Base64Attachments:    request.Extensions.Signal.Base64Attachments,
+			ViewOnce:             request.Extensions.Signal.ViewOnce,
+			TextMode:             request.Extensions.Signal.TextMode,
+			ResolveMentions:      request.Extensions.Signal.ResolveMentions,
+			AttachmentIDs:        request.Extensions.Signal.AttachmentIDs,
+			ReadReceipts:         request.Extensions.Signal.ReadReceipts,
+			DeliveryConfirmation: request.Extensions.Signal.DeliveryConfirmation,
+		}}
+	}
+	if request.Extensions != nil && request.Extensions.Email != nil {
+		if useCaseRequest.Extensions == nil {
+			useCaseRequest.Extensions = &provider.MessageExtensions{}
+		}
+		useCaseRequest.Extensions.Email = &provider.EmailExtension{
+			Subject:       request.Extensions.Email.Subject,
+			HTML:          request.Extensions.Email.HTML,
+			AttachmentIDs: request.Extensions.Email.AttachmentIDs,
+		}
+	}
+	for _, action := range request.Actions {
+		useCaseRequest.Actions = append(useCaseRequest.Actions, provider.MessageAction{ID: action.ID, Label: action.Label})
+	}
+	return useCaseRequest
+}
+
+// parseTagFilters converts key:value query parameters into a tag filter
+func parseTagFilters(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, found := strings.Cut(filter, ":")
+		if !found || key == "" {
+			return nil, errors.New("tag filters must have the form key:value")
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// toUnresolvedRecipients converts the recipients the directory couldn't resolve for the response
+func toUnresolvedRecipients(failures []directory.Failure) []UnresolvedRecipient {
+	if len(failures) == 0 {
+		return nil
+	}
+	unresolved := make([]UnresolvedRecipient, len(failures))
+	for i, failure := range failures {
+		unresolved[i] = UnresolvedRecipient{Recipient: failure.Recipient, Error: failure.Error}
+	}
+	return unresolved
+}
+
+func toCappedRecipients(capped []message.CappedRecipient) []CappedRecipient {
+	if len(capped) == 0 {
+		return nil
+	}
+	result := make([]CappedRecipient, len(capped))
+	for i, recipient := range capped {
+		result[i] = CappedRecipient{Recipient: recipient.Recipient, Window: recipient.Window, Cap: recipient.Cap, ReleaseAt: recipient.ReleaseAt}
+	}
+	return result
+}
+
+// toRecipientResults converts the validation results of the recipients for the response, the address is only
+// given when the recipient resolved to another one
+func toRecipientResults(results []message.RecipientResult) []RecipientResult {
+	converted := make([]RecipientResult, len(results))
+	for i, result := range results {
+		converted[i] = RecipientResult{Recipient: result.Recipient, Result: result.Result, Reason: result.Reason, Error: result.Error}
+		if result.Address != result.Recipient {
+			converted[i].Address = result.Address
+		}
+	}
+	return converted
+}
+
+// toDeliveries converts the deliveries of a message for the response
+func toDeliveries(deliveries []provider.MessageDelivery) []Delivery {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	result := make([]Delivery, len(deliveries))
+	for i, delivery := range deliveries {
+		result[i] = Delivery{
+			Recipient:    delivery.Recipient,
+			Status:       delivery.Status,
+			ErrorCode:    delivery.ErrorCode,
+			ErrorMessage: delivery.ErrorMessage,
+			ReadReceipts: delivery.ReadReceipts,
+			DeliveredAt:  formatOptionalTime(delivery.DeliveredAt),
+			ReadAt:       formatOptionalTime(delivery.ReadAt),
+			UpdatedAt:    delivery.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+	return result
+}
+
+// toActions converts the actions offered with a message for the response
+func toActions(actions []provider.MessageAction) []ActionRequest {
+	if len(actions) == 0 {
+		return nil
+	}
+	result := make([]ActionRequest, len(actions))
+	for i, action := range actions {
+		result[i] = ActionRequest{ID: action.ID, Label: action.Label}
+	}
+	return result
+}
+
+// toMessageEdits converts the edit history of a message for the response
+func toMessageEdits(edits []provider.MessageEdit) []MessageEdit {
+	if len(edits) == 0 {
+		return nil
+	}
+	result := make([]MessageEdit, len(edits))
+	for i, edit := range edits {
+		result[i] = MessageEdit{
+			PreviousMessage: edit.PreviousMessage,
+			Message:         edit.Message,
+			EditedAt:        edit.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return result
+}
+
+// toLinkClicks converts the click statistics of a message for the response
+func toLinkClicks(stats *provider.LinkClickStats) *LinkClicks {
+	if stats == nil {
+		return nil
+	}
+	linkClicks := &LinkClicks{
+		Clicks:           stats.Clicks,
+		UniqueRecipients: stats.UniqueRecipients,
+		Links:            make([]LinkStats, len(stats.Links)),
+		Recipients:       make([]RecipientClicks, len(stats.Recipients)),
+	}
+	for i, link := range stats.Links {
+		linkClicks.Links[i] = LinkStats{URL: link.URL, Clicks: link.Clicks, UniqueRecipients: link.UniqueRecipients}
+	}
+	for i, recipient := range stats.Recipients {
+		linkClicks.Recipients[i] = RecipientClicks{
+			Recipient:      recipient.Recipient,
+			Clicks:         recipient.Clicks,
+			FirstClickedAt: recipient.FirstClickedAt.Format(time.RFC3339),
+			LastClickedAt:  recipient.LastClickedAt.Format(time.RFC3339),
+		}
+	}
+	return linkClicks
+}
+
+// formatOptionalTime formats a time as RFC 3339, an unset time as an empty string
+func toPreviewResponse(preview *message.PreviewResponse) *PreviewResponse {
+	response := &PreviewResponse{
+		Message:              preview.Message,
+		Recipients:           preview.Recipients,
+		UnresolvedRecipients: toUnresolvedRecipients(preview.UnresolvedRecipients),
+		Provider:             PreviewProvider(preview.Provider),
+		Route:                preview.Route,
+		Fallbacks:            toPreviewProviders(preview.Fallbacks),
+		Drilled:              toPreviewProviders(preview.Drilled),
+		TrackedLinks:         preview.TrackedLinks,
+		Estimate:             CostEstimate(preview.Estimate),
+		Warnings:             preview.Warnings,
+	}
+	if response.Recipients == nil {
+		response.Recipients = []string{}
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+	return response
+}
+
+func toSegmentation(segmentation messaging.Segmentation) *Segmentation {
+	converted := Segmentation(segmentation)
+	return &converted
+}
+
+func toPreviewProviders(providers []message.PreviewProvider) []PreviewProvider {
+	result := make([]PreviewProvider, len(providers))
+	for i, p := range providers {
+		result[i] = PreviewProvider(p)
+	}
+	return result
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
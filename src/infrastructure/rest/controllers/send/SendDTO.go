@@ -1,16 +1,139 @@
 package send
 
+import (
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
+)
+
 type MessageRequest struct {
-	Type       string   `json:"type" binding:"required"`
-	Message    string   `json:"message" binding:"required"`
-	Recipients []string `json:"recipients" binding:"required"`
+	Type       string             `json:"type" binding:"required"`
+	Message    string             `json:"message" binding:"required"`
+	Recipients []string           `json:"recipients" binding:"required"`
+	Tags       map[string]string  `json:"tags,omitempty" binding:"omitempty,max=20,dive,keys,required,max=64,endkeys,max=256"`
+	Ack        *AckRequest        `json:"ack,omitempty"`
+	TrackLinks bool               `json:"track_links,omitempty"`
+	Extensions *ExtensionsRequest `json:"extensions,omitempty"`
+	Actions    []ActionRequest    `json:"actions,omitempty" binding:"omitempty,max=10,dive"`
+}
+
+// ActionRequest is a choice offered with a message, a button on providers with buttons and a numbered option the
+// recipients reply with on the others
+type ActionRequest struct {
+	ID    string `json:"id" binding:"required,max=64"`
+	Label string `json:"label" binding:"required"`
+}
+
+// ExtensionsRequest holds the provider specific options of a message, sent only when the selected provider type supports them
+type ExtensionsRequest struct {
+	Signal *SignalExtensionRequest `json:"signal,omitempty"`
+	Email  *EmailExtensionRequest  `json:"email,omitempty"`
+}
+
+// EmailExtensionRequest sets the subject of emails and sends an HTML alternative of the message, which is the
+// plain-text body, and uploaded attachments
+type EmailExtensionRequest struct {
+	Subject string `json:"subject,omitempty" binding:"omitempty,max=998"`
+	HTML    string `json:"html,omitempty"`
+	// AttachmentIDs are attachments uploaded in parts through /attachments
+	AttachmentIDs []int `json:"attachment_ids,omitempty" binding:"omitempty,max=10,dive,min=1"`
+}
+
+// SignalExtensionRequest sends attachments, view-once images, styled text and mentions through Signal providers,
+// and turns the tracking of its receipts on or off
+type SignalExtensionRequest struct {
+	Base64Attachments []string `json:"base64_attachments,omitempty" binding:"omitempty,max=10,dive,required"`
+	ViewOnce          bool     `json:"view_once,omitempty"`
+	TextMode          string   `json:"text_mode,omitempty" binding:"omitempty,oneof=normal styled"`
+	ResolveMentions   bool     `json:"resolve_mentions,omitempty"`
+	// AttachmentIDs are attachments uploaded in parts through /attachments
+	AttachmentIDs []int `json:"attachment_ids,omitempty" binding:"omitempty,max=10,dive,min=1"`
+	// ReadReceipts and DeliveryConfirmation default to true, false stops the deliveries at delivered or leaves
+	// them untracked
+	ReadReceipts         *bool `json:"read_receipts,omitempty"`
+	DeliveryConfirmation *bool `json:"delivery_confirmation,omitempty"`
+}
+
+// AckRequest demands that a recipient acknowledges the message before the timeout passes
+type AckRequest struct {
+	Keyword           string `json:"keyword,omitempty" binding:"omitempty,max=32,alphanum"`
+	TimeoutSeconds    int    `json:"timeout_seconds" binding:"required,min=1"`
+	EscalationChainID int    `json:"escalation_chain_id,omitempty"`
 }
 
 type MessageResponse struct {
-	ID        int    `json:"id"`
-	Status    string `json:"status"`
-	Timestamp string `json:"timestamp,omitempty"`
-	Message   string `json:"message,omitempty"`
+	ID                   int                   `json:"id"`
+	Status               string                `json:"status"`
+	Timestamp            string                `json:"timestamp,omitempty"`
+	Message              string                `json:"message,omitempty"`
+	UnresolvedRecipients []UnresolvedRecipient `json:"unresolved_recipients,omitempty"`
+	AckToken             string                `json:"ack_token,omitempty"`
+	AckDeadline          string                `json:"ack_deadline,omitempty"`
+	Segmentation         *Segmentation         `json:"segmentation,omitempty"`
+	// CappedRecipients are the recipients whose frequency caps held the message
+	CappedRecipients []CappedRecipient `json:"capped_recipients,omitempty"`
+	// Recipients are the initial validation results of the requested recipients, only accepted ones are attempted
+	Recipients []RecipientResult `json:"recipients"`
+}
+
+// RecipientResult is the initial validation result of a recipient: accepted, suppressed or invalid
+type RecipientResult struct {
+	Recipient string `json:"recipient"`
+	Address   string `json:"address,omitempty"`
+	Result    string `json:"result"`
+	Reason    string `json:"reason,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CappedRecipient is a recipient whose frequency cap a send exceeded
+type CappedRecipient struct {
+	Recipient string    `json:"recipient"`
+	Window    string    `json:"window"`
+	Cap       int       `json:"cap"`
+	ReleaseAt time.Time `json:"release_at"`
+}
+
+// Segmentation is how a message is sent through its provider
+type Segmentation struct {
+	// Segments is the number of messages billed per recipient, SMS are billed per segment
+	Segments      int    `json:"segments"`
+	Encoding      string `json:"encoding,omitempty"`
+	Characters    int    `json:"characters"`
+	MaxCharacters int    `json:"max_characters,omitempty"`
+	MaxSegments   int    `json:"max_segments,omitempty"`
+	Truncated     bool   `json:"truncated"`
+}
+
+// PreviewResponse is the plan of a message, nothing was stored or sent
+type PreviewResponse struct {
+	Message              string                `json:"message"`
+	Recipients           []string              `json:"recipients"`
+	UnresolvedRecipients []UnresolvedRecipient `json:"unresolved_recipients,omitempty"`
+	Provider             PreviewProvider       `json:"provider"`
+	Route                string                `json:"route"`
+	Fallbacks            []PreviewProvider     `json:"fallbacks"`
+	Drilled              []PreviewProvider     `json:"drilled,omitempty"`
+	TrackedLinks         int                   `json:"tracked_links"`
+	Estimate             CostEstimate          `json:"estimate"`
+	Warnings             []string              `json:"warnings"`
+}
+
+type PreviewProvider struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type CostEstimate struct {
+	Segments       int      `json:"segments"`
+	Messages       int      `json:"messages"`
+	CostPerMessage *float64 `json:"cost_per_message,omitempty"`
+	Cost           *float64 `json:"cost,omitempty"`
+}
+
+type UnresolvedRecipient struct {
+	Recipient string `json:"recipient"`
+	Error     string `json:"error"`
 }
 
 type MessageStatusRequest struct {
@@ -18,12 +141,127 @@ type MessageStatusRequest struct {
 }
 
 type MessageStatusResponse struct {
-	ID           int    `json:"id"`
+	ID             int               `json:"id"`
+	Status         string            `json:"status"`
+	Message        string            `json:"message"`
+	Recipients     string            `json:"recipients"`
+	ErrorMessage   string            `json:"error_message,omitempty"`
+	ErrorCode      string            `json:"error_code,omitempty"`
+	RetryCount     int               `json:"retry_count"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	AckStatus      string            `json:"ack_status,omitempty"`
+	AckDeadline    string            `json:"ack_deadline,omitempty"`
+	AcknowledgedBy string            `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt string            `json:"acknowledged_at,omitempty"`
+	LinkClicks     *LinkClicks       `json:"link_clicks,omitempty"`
+	Deliveries     []Delivery        `json:"deliveries,omitempty"`
+	Edits          []MessageEdit     `json:"edits,omitempty"`
+	Segments       int               `json:"segments,omitempty"`
+	Actions        []ActionRequest   `json:"actions,omitempty"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+}
+
+// EditMessageRequest is the new text of a sent message
+type EditMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// EditMessageResponse is a message after an edit, with its edit history
+type EditMessageResponse struct {
+	ID           int           `json:"id"`
+	ProviderType string        `json:"provider_type"`
+	Message      string        `json:"message"`
+	Edits        []MessageEdit `json:"edits"`
+}
+
+// MessageEdit is an edit of a sent message
+type MessageEdit struct {
+	PreviousMessage string `json:"previous_message"`
+	Message         string `json:"message"`
+	EditedAt        string `json:"edited_at"`
+}
+
+// Delivery is the delivery of a message to one recipient as reported by the provider
+type Delivery struct {
+	Recipient    string `json:"recipient"`
 	Status       string `json:"status"`
-	Message      string `json:"message"`
-	Recipients   string `json:"recipients"`
+	ErrorCode    string `json:"error_code,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
-	RetryCount   int    `json:"retry_count"`
-	CreatedAt    string `json:"created_at"`
+	// ReadReceipts reports whether read receipts count, without them the delivery stops at delivered
+	ReadReceipts bool   `json:"read_receipts"`
+	DeliveredAt  string `json:"delivered_at,omitempty"`
+	ReadAt       string `json:"read_at,omitempty"`
 	UpdatedAt    string `json:"updated_at"`
 }
+
+// LinkClicks summarizes the clicks on the tracked links of a message
+type LinkClicks struct {
+	Clicks           int               `json:"clicks"`
+	UniqueRecipients int               `json:"unique_recipients"`
+	Links            []LinkStats       `json:"links"`
+	Recipients       []RecipientClicks `json:"recipients"`
+}
+
+type LinkStats struct {
+	URL              string `json:"url"`
+	Clicks           int    `json:"clicks"`
+	UniqueRecipients int    `json:"unique_recipients"`
+}
+
+type RecipientClicks struct {
+	Recipient      string `json:"recipient"`
+	Clicks         int    `json:"clicks"`
+	FirstClickedAt string `json:"first_clicked_at"`
+	LastClickedAt  string `json:"last_clicked_at"`
+}
+
+type MessageHistoryRequest struct {
+	controllers.PageQuery
+	Status string   `form:"status"`
+	Tags   []string `form:"tag"` // key:value pairs, all of them have to match
+}
+
+type MessageHistoryResponse struct {
+	ID           int               `json:"id"`
+	MessageID    int               `json:"message_id"`
+	ProviderID   int               `json:"provider_id"`
+	Status       string            `json:"status"`
+	Message      string            `json:"message"`
+	Recipients   string            `json:"recipients"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	ErrorCode    string            `json:"error_code,omitempty"`
+	RetryCount   int               `json:"retry_count"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	ProcessedAt  string            `json:"processed_at"`
+}
+
+type QueueStatsResponse struct {
+	QueueDepth    int   `json:"queue_depth"`
+	QueueCapacity int   `json:"queue_capacity"`
+	Backlog       int   `json:"backlog"`
+	Threshold     int   `json:"backlog_threshold"`
+	Deferred      int64 `json:"deferred"`
+	Rejected      int64 `json:"rejected"`
+	// MaxInFlightPerUser is the limit of workers one user can take, 0 when it isn't limited
+	MaxInFlightPerUser int `json:"max_in_flight_per_user"`
+	// Gauges refreshed periodically by the queue monitor
+	Processing          int       `json:"processing"`
+	FailedAwaitingRetry int       `json:"failed_awaiting_retry"`
+	Held                int       `json:"held"`
+	LagSeconds          int       `json:"lag_seconds"`
+	LagLevel            string    `json:"lag_level"`
+	RefreshedAt         time.Time `json:"metrics_refreshed_at"`
+}
+
+// UserLoadResponse is the share of the processor one user takes
+type UserLoadResponse struct {
+	UserID   int `json:"user_id"`
+	InFlight int `json:"in_flight"`
+	Queued   int `json:"queued"`
+}
+
+type UserLoadsResponse struct {
+	MaxInFlightPerUser int                `json:"max_in_flight_per_user"`
+	Users              []UserLoadResponse `json:"users"`
+}
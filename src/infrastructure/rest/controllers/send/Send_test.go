@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/domain"
 	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/controllers"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -22,6 +24,10 @@ type MockMessageUseCase struct {
 	sendMessageFunc         func(*message.MessageRequest) (*message.MessageResponse, error)
 	retryFailedMessagesFunc func() error
 	getMessageStatusFunc    func(*message.MessageStatusRequest) (*message.MessageStatusResponse, error)
+	getMessageHistoryFunc   func(*message.MessageHistoryRequest) (*domain.Page[message.MessageHistoryItem], error)
+	getQueueStatsFunc       func() (*message.QueueStatsResponse, error)
+	previewMessageFunc      func(*message.MessageRequest) (*message.PreviewResponse, error)
+	editMessageFunc         func(*message.EditMessageRequest) (*message.EditMessageResponse, error)
 }
 
 func (m *MockMessageUseCase) SendMessage(req *message.MessageRequest) (*message.MessageResponse, error) {
@@ -45,6 +51,34 @@ func (m *MockMessageUseCase) GetMessageStatus(req *message.MessageStatusRequest)
 	return nil, nil
 }
 
+func (m *MockMessageUseCase) GetMessageHistory(req *message.MessageHistoryRequest) (*domain.Page[message.MessageHistoryItem], error) {
+	if m.getMessageHistoryFunc != nil {
+		return m.getMessageHistoryFunc(req)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageUseCase) GetQueueStats() (*message.QueueStatsResponse, error) {
+	if m.getQueueStatsFunc != nil {
+		return m.getQueueStatsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockMessageUseCase) PreviewMessage(req *message.MessageRequest) (*message.PreviewResponse, error) {
+	if m.previewMessageFunc != nil {
+		return m.previewMessageFunc(req)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageUseCase) EditMessage(req *message.EditMessageRequest) (*message.EditMessageResponse, error) {
+	if m.editMessageFunc != nil {
+		return m.editMessageFunc(req)
+	}
+	return nil, nil
+}
+
 // MockCommonService mocks the common service for testing
 type MockCommonService struct {
 	appendValidationErrorsFunc func(*gin.Context, validator.ValidationErrors, interface{})
@@ -367,3 +401,103 @@ func TestSendController_RetryFailedMessages_Error(t *testing.T) {
 	// Since this method doesn't return anything and we can't easily check the log output,
 	// we're just testing that it doesn't panic
 }
+
+func TestSendController_GetMessageHistory_FiltersByTags(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	var received *message.MessageHistoryRequest
+	mockMessageUseCase := &MockMessageUseCase{
+		getMessageHistoryFunc: func(req *message.MessageHistoryRequest) (*domain.Page[message.MessageHistoryItem], error) {
+			received = req
+			return &domain.Page[message.MessageHistoryItem]{
+				Items: []message.MessageHistoryItem{
+					{
+						ID:          7,
+						MessageID:   123,
+						Status:      "success",
+						Message:     "Test message",
+						Tags:        map[string]string{"order": "A-1"},
+						ProcessedAt: time.Now(),
+					},
+				},
+			}, nil
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewSendController(&MockCommonService{}, mockMessageUseCase, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/send/messages?status=success&tag=order:A-1&tag=campaign:spring", nil)
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("userID", float64(1))
+
+	controller.GetMessageHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, received.UserID)
+	assert.Equal(t, "success", received.Status)
+	assert.Equal(t, map[string]string{"order": "A-1", "campaign": "spring"}, received.Tags)
+
+	var response controllers.PageResponse[MessageHistoryResponse]
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "A-1", response.Data[0].Tags["order"])
+	assert.Empty(t, response.NextCursor)
+}
+
+func TestSendController_GetMessageHistory_InvalidTagFilter(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	logger := setupLogger(t)
+	controller := NewSendController(&MockCommonService{}, &MockMessageUseCase{}, logger)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/send/messages?tag=order", nil)
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("userID", float64(1))
+
+	controller.GetMessageHistory(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSendController_Message_BacklogExceeded(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	mockMessageUseCase := &MockMessageUseCase{
+		sendMessageFunc: func(req *message.MessageRequest) (*message.MessageResponse, error) {
+			return nil, &message.BacklogExceededError{Backlog: 5000, RetryAfter: 30 * time.Second}
+		},
+	}
+
+	logger := setupLogger(t)
+	controller := NewSendController(&MockCommonService{}, mockMessageUseCase, logger)
+
+	requestBody, _ := json.Marshal(MessageRequest{
+		Type:       "signal",
+		Message:    "Test message",
+		Recipients: []string{"+1234567890"},
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/send", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("userID", float64(1))
+
+	controller.Message(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+}
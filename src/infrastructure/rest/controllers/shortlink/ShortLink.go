@@ -0,0 +1,35 @@
+package shortlink
+
+import (
+	"net/http"
+
+	shortLinkUseCase "go-multi-chat-api/src/application/usecases/shortlink"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IShortLinkController interface {
+	Follow(ctx *gin.Context)
+}
+
+type ShortLinkController struct {
+	shortLinkUseCase shortLinkUseCase.IShortLinkUseCase
+	Logger           *logger.Logger
+}
+
+func NewShortLinkController(shortLinkUseCase shortLinkUseCase.IShortLinkUseCase, loggerInstance *logger.Logger) IShortLinkController {
+	return &ShortLinkController{shortLinkUseCase: shortLinkUseCase, Logger: loggerInstance}
+}
+
+// Follow redirects a recipient from a short link to the tracked URL
+func (c *ShortLinkController) Follow(ctx *gin.Context) {
+	url, err := c.shortLinkUseCase.Follow(ctx.Param("token"), ctx.Request.UserAgent())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	// Every click has to reach the API to be counted
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"go-multi-chat-api/src/application/usecases/devicelink"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultQrCodeVersion is the QR code version of link codes requested without one
+const defaultQrCodeVersion = 10
+
+type IDeviceLinkController interface {
+	StartDeviceLink(ctx *gin.Context)
+	GetDeviceLinkAttempt(ctx *gin.Context)
+	GetDevices(ctx *gin.Context)
+	RelinkDevice(ctx *gin.Context)
+	GetAccountMode(ctx *gin.Context)
+}
+
+type DeviceLinkController struct {
+	deviceLinkUseCase devicelink.IDeviceLinkUseCase
+	Logger            *logger.Logger
+}
+
+// NewDeviceLinkController creates a new DeviceLinkController
+func NewDeviceLinkController(deviceLinkUseCase devicelink.IDeviceLinkUseCase, loggerInstance *logger.Logger) IDeviceLinkController {
+	return &DeviceLinkController{deviceLinkUseCase: deviceLinkUseCase, Logger: loggerInstance}
+}
+
+// StartDeviceLink returns the QR code to scan with the primary device of an account to link this backend to it
+func (c *DeviceLinkController) StartDeviceLink(ctx *gin.Context) {
+	var req StartDeviceLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide a device_name"})
+		return
+	}
+	if req.QrCodeVersion == 0 {
+		req.QrCodeVersion = defaultQrCodeVersion
+	}
+
+	png, err := c.deviceLinkUseCase.StartLink(req.DeviceName, req.QrCodeVersion)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+// GetDeviceLinkAttempt returns whether the latest QR code was scanned
+func (c *DeviceLinkController) GetDeviceLinkAttempt(ctx *gin.Context) {
+	attempt := c.deviceLinkUseCase.GetLinkAttempt()
+	if attempt == nil {
+		ctx.JSON(http.StatusNotFound, Error{Msg: "No device link was started"})
+		return
+	}
+	ctx.JSON(http.StatusOK, DeviceLinkAttemptResponse{
+		DeviceName:   attempt.DeviceName,
+		Number:       attempt.Number,
+		Status:       attempt.Status,
+		StartedAt:    attempt.StartedAt,
+		ExpiresAt:    attempt.ExpiresAt,
+		LinkedNumber: attempt.LinkedNumber,
+	})
+}
+
+// GetDevices returns the numbers linked as devices and whether they are still linked
+func (c *DeviceLinkController) GetDevices(ctx *gin.Context) {
+	links, err := c.deviceLinkUseCase.GetDevices()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get linked devices"})
+		return
+	}
+	devices := make([]AccountModeResponse, len(*links))
+	for i, link := range *links {
+		devices[i] = linkedAccountResponse(link)
+	}
+	ctx.JSON(http.StatusOK, devices)
+}
+
+// RelinkDevice returns the QR code to link a number its primary device unlinked again
+func (c *DeviceLinkController) RelinkDevice(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+	var req RelinkDeviceRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - invalid request"})
+			return
+		}
+	}
+	if req.QrCodeVersion == 0 {
+		req.QrCodeVersion = defaultQrCodeVersion
+	}
+
+	png, err := c.deviceLinkUseCase.Relink(number, req.QrCodeVersion)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+// GetAccountMode returns whether a number is the primary device of its account or a linked device
+func (c *DeviceLinkController) GetAccountMode(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	mode, err := c.deviceLinkUseCase.GetMode(number)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, AccountModeResponse{
+		Number:     mode.Number,
+		Mode:       mode.Mode,
+		Status:     mode.Status,
+		DeviceName: mode.DeviceName,
+		LinkedAt:   mode.LinkedAt,
+		UnlinkedAt: mode.UnlinkedAt,
+		LastError:  mode.LastError,
+	})
+}
+
+func linkedAccountResponse(link domainSignal.DeviceLink) AccountModeResponse {
+	linkedAt := link.LinkedAt
+	return AccountModeResponse{
+		Number:     link.Number,
+		Mode:       domainSignal.AccountModeLinked,
+		Status:     link.Status,
+		DeviceName: link.DeviceName,
+		LinkedAt:   &linkedAt,
+		UnlinkedAt: link.UnlinkedAt,
+		LastError:  link.LastError,
+	}
+}
+
+// deviceLinkErrorStatus returns the HTTP status of an error of the device link use case
+func deviceLinkErrorStatus(err error) int {
+	var appErr *domainErrors.AppError
+	if !errors.As(err, &appErr) {
+		return http.StatusBadRequest
+	}
+	switch appErr.Type {
+	case domainErrors.NotFound:
+		return http.StatusNotFound
+	case domainErrors.Conflict:
+		return http.StatusConflict
+	case domainErrors.ValidationError:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,103 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-multi-chat-api/src/application/usecases/devicelink"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignalEntities "go-multi-chat-api/src/domain/signal"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockDeviceLinkUseCase implements devicelink.IDeviceLinkUseCase for testing
+type MockDeviceLinkUseCase struct {
+	devicelink.IDeviceLinkUseCase
+	modes     map[string]devicelink.AccountMode
+	relinkErr error
+}
+
+func (m *MockDeviceLinkUseCase) StartLink(deviceName string, qrCodeVersion int) ([]byte, error) {
+	return []byte("png"), nil
+}
+
+func (m *MockDeviceLinkUseCase) Relink(number string, qrCodeVersion int) ([]byte, error) {
+	if m.relinkErr != nil {
+		return nil, m.relinkErr
+	}
+	return []byte("png"), nil
+}
+
+func (m *MockDeviceLinkUseCase) GetMode(number string) (*devicelink.AccountMode, error) {
+	mode, ok := m.modes[number]
+	if !ok {
+		return nil, domainErrors.NewAppError(errors.New("Signal number isn't an account of this backend"), domainErrors.NotFound)
+	}
+	return &mode, nil
+}
+
+func newDeviceLinkTestContext(method string, path string, body []byte, number string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	if number != "" {
+		c.Params = []gin.Param{{Key: "number", Value: number}}
+	}
+	return c, w
+}
+
+func TestDeviceLinkController_StartDeviceLink(t *testing.T) {
+	controller := NewDeviceLinkController(&MockDeviceLinkUseCase{}, setupLogger(t))
+
+	c, w := newDeviceLinkTestContext(http.MethodPost, "/signal/devices/link", []byte(`{}`), "")
+	controller.StartDeviceLink(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	c, w = newDeviceLinkTestContext(http.MethodPost, "/signal/devices/link", []byte(`{"device_name":"api"}`), "")
+	controller.StartDeviceLink(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+}
+
+func TestDeviceLinkController_RelinkDevice(t *testing.T) {
+	useCase := &MockDeviceLinkUseCase{relinkErr: domainErrors.NewAppError(errors.New("Signal number +1234567890 is still linked"), domainErrors.Conflict)}
+	controller := NewDeviceLinkController(useCase, setupLogger(t))
+
+	c, w := newDeviceLinkTestContext(http.MethodPost, "/signal/accounts/+1234567890/relink", nil, "+1234567890")
+	controller.RelinkDevice(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	useCase.relinkErr = nil
+	c, w = newDeviceLinkTestContext(http.MethodPost, "/signal/accounts/+1234567890/relink", []byte(`{"qrcode_version":12}`), "+1234567890")
+	controller.RelinkDevice(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDeviceLinkController_GetAccountMode(t *testing.T) {
+	useCase := &MockDeviceLinkUseCase{modes: map[string]devicelink.AccountMode{
+		"+1234567890": {Number: "+1234567890", Mode: domainSignalEntities.AccountModeLinked, Status: domainSignalEntities.DeviceLinkStatusUnlinked, LastError: "Authorization failed"},
+	}}
+	controller := NewDeviceLinkController(useCase, setupLogger(t))
+
+	c, w := newDeviceLinkTestContext(http.MethodGet, "/signal/accounts/+1234567890/mode", nil, "+1234567890")
+	controller.GetAccountMode(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response AccountModeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "linked", response.Mode)
+	assert.Equal(t, "unlinked", response.Status)
+	assert.Equal(t, "Authorization failed", response.LastError)
+
+	c, w = newDeviceLinkTestContext(http.MethodGet, "/signal/accounts/+1999/mode", nil, "+1999")
+	controller.GetAccountMode(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
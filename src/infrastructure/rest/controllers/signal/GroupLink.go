@@ -0,0 +1,150 @@
+package signal
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	domainSignal "go-multi-chat-api/src/infrastructure/repository/signal-client"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GroupLinkClient is the subset of the signal client used to manage group invite links
+type GroupLinkClient interface {
+	GetGroupInviteLink(number string, groupId string) (string, error)
+	ResetGroupInviteLink(number string, groupId string) (string, error)
+	GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error)
+	JoinGroupByLink(number string, uri string) (*domainSignal.JoinGroupResponse, error)
+}
+
+type IGroupLinkController interface {
+	GetInviteLink(ctx *gin.Context)
+	ResetInviteLink(ctx *gin.Context)
+	GetInviteLinkQrCode(ctx *gin.Context)
+	JoinGroup(ctx *gin.Context)
+}
+
+type GroupLinkController struct {
+	signalClient GroupLinkClient
+	Logger       *logger.Logger
+}
+
+func NewGroupLinkController(signalClient GroupLinkClient, loggerInstance *logger.Logger) IGroupLinkController {
+	return &GroupLinkController{signalClient: signalClient, Logger: loggerInstance}
+}
+
+// GetInviteLink returns the invite link of a group
+func (c *GroupLinkController) GetInviteLink(ctx *gin.Context) {
+	number, groupId, ok := groupParams(ctx)
+	if !ok {
+		return
+	}
+
+	inviteLink, err := c.signalClient.GetGroupInviteLink(number, groupId)
+	if err != nil {
+		c.respondWithError(ctx, err, "Error getting group invite link", number)
+		return
+	}
+	ctx.JSON(http.StatusOK, GroupInviteLinkResponse{InviteLink: inviteLink})
+}
+
+// ResetInviteLink rotates the invite link of a group, the previous link stops working
+func (c *GroupLinkController) ResetInviteLink(ctx *gin.Context) {
+	number, groupId, ok := groupParams(ctx)
+	if !ok {
+		return
+	}
+
+	inviteLink, err := c.signalClient.ResetGroupInviteLink(number, groupId)
+	if err != nil {
+		c.respondWithError(ctx, err, "Error resetting group invite link", number)
+		return
+	}
+
+	c.Logger.Info("Group invite link reset", zap.String("number", number), zap.String("groupId", groupId))
+	ctx.JSON(http.StatusOK, GroupInviteLinkResponse{InviteLink: inviteLink})
+}
+
+// GetInviteLinkQrCode returns the invite link of a group as a PNG QR code
+func (c *GroupLinkController) GetInviteLinkQrCode(ctx *gin.Context) {
+	number, groupId, ok := groupParams(ctx)
+	if !ok {
+		return
+	}
+
+	qrCodeVersion := 10
+	if qrCodeVersionParam := ctx.Query("qrcode_version"); qrCodeVersionParam != "" {
+		var err error
+		qrCodeVersion, err = strconv.Atoi(qrCodeVersionParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, Error{Msg: "The qrcode_version parameter needs to be an integer!"})
+			return
+		}
+	}
+
+	png, err := c.signalClient.GetGroupInviteLinkQrCode(number, groupId, qrCodeVersion)
+	if err != nil {
+		c.respondWithError(ctx, err, "Error creating group invite link QR code", number)
+		return
+	}
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+// JoinGroup joins a group from an invite link
+func (c *GroupLinkController) JoinGroup(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req JoinGroupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide the invite link as uri"})
+		return
+	}
+
+	joined, err := c.signalClient.JoinGroupByLink(number, req.Uri)
+	if err != nil {
+		c.respondWithError(ctx, err, "Error joining group from invite link", number)
+		return
+	}
+
+	c.Logger.Info("Joined group from invite link", zap.String("number", number), zap.String("groupId", joined.Id), zap.Bool("onlyRequested", joined.OnlyRequested))
+	ctx.JSON(http.StatusOK, joined)
+}
+
+func (c *GroupLinkController) respondWithError(ctx *gin.Context, err error, message string, number string) {
+	var notFoundErr *domainSignal.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		ctx.JSON(http.StatusNotFound, Error{Msg: err.Error()})
+		return
+	}
+	c.Logger.Error(message, zap.Error(err), zap.String("number", number))
+	ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+}
+
+// groupParams reads the number and group id path parameters. Group ids are base64 encoded and may contain
+// a slash, so the URL-safe alphabet is accepted in the path as well.
+func groupParams(ctx *gin.Context) (string, string, bool) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return "", "", false
+	}
+	groupId, err := url.PathUnescape(ctx.Param("groupid"))
+	if err != nil || groupId == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed group id"})
+		return "", "", false
+	}
+	return number, groupIdFromPath(groupId), true
+}
+
+func groupIdFromPath(groupId string) string {
+	return strings.NewReplacer("-", "+", "_", "/").Replace(groupId)
+}
@@ -0,0 +1,140 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	domainSignal "go-multi-chat-api/src/infrastructure/repository/signal-client"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockGroupLinkClient implements GroupLinkClient for testing
+type MockGroupLinkClient struct {
+	inviteLink string
+	err        error
+	resets     int
+	joinedUri  string
+}
+
+func (m *MockGroupLinkClient) GetGroupInviteLink(number string, groupId string) (string, error) {
+	return m.inviteLink, m.err
+}
+
+func (m *MockGroupLinkClient) ResetGroupInviteLink(number string, groupId string) (string, error) {
+	m.resets++
+	m.inviteLink = "https://signal.group/#rotated"
+	return m.inviteLink, m.err
+}
+
+func (m *MockGroupLinkClient) GetGroupInviteLinkQrCode(number string, groupId string, qrCodeVersion int) ([]byte, error) {
+	return []byte("png"), m.err
+}
+
+func (m *MockGroupLinkClient) JoinGroupByLink(number string, uri string) (*domainSignal.JoinGroupResponse, error) {
+	m.joinedUri = uri
+	return &domainSignal.JoinGroupResponse{Id: "group.abc", OnlyRequested: true}, m.err
+}
+
+func newGroupLinkTestRouter(t *testing.T, client *MockGroupLinkClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewGroupLinkController(client, setupLogger(t))
+	router := gin.New()
+	router.GET("/signal/groups/:number/:groupid/link", controller.GetInviteLink)
+	router.POST("/signal/groups/:number/:groupid/link/reset", controller.ResetInviteLink)
+	router.GET("/signal/groups/:number/:groupid/link/qrcode", controller.GetInviteLinkQrCode)
+	router.POST("/signal/groups/:number/join", controller.JoinGroup)
+	return router
+}
+
+func groupLinkPath(suffix string) string {
+	return "/signal/groups/" + url.PathEscape("+1234567890") + "/" + url.PathEscape("group.abc") + suffix
+}
+
+func TestGroupLinkController_GetInviteLink(t *testing.T) {
+	router := newGroupLinkTestRouter(t, &MockGroupLinkClient{inviteLink: "https://signal.group/#abc"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, groupLinkPath("/link"), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"invite_link":"https://signal.group/#abc"}`, w.Body.String())
+}
+
+func TestGroupLinkController_GetInviteLink_Disabled(t *testing.T) {
+	router := newGroupLinkTestRouter(t, &MockGroupLinkClient{err: &domainSignal.NotFoundError{Description: "The invite link of the group is disabled"}})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, groupLinkPath("/link"), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGroupLinkController_ResetInviteLink(t *testing.T) {
+	client := &MockGroupLinkClient{inviteLink: "https://signal.group/#abc"}
+	router := newGroupLinkTestRouter(t, client)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, groupLinkPath("/link/reset"), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, client.resets)
+	assert.JSONEq(t, `{"invite_link":"https://signal.group/#rotated"}`, w.Body.String())
+}
+
+func TestGroupLinkController_GetInviteLinkQrCode(t *testing.T) {
+	router := newGroupLinkTestRouter(t, &MockGroupLinkClient{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, groupLinkPath("/link/qrcode"), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, groupLinkPath("/link/qrcode?qrcode_version=high"), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGroupLinkController_JoinGroup(t *testing.T) {
+	client := &MockGroupLinkClient{}
+	router := newGroupLinkTestRouter(t, client)
+
+	body, _ := json.Marshal(JoinGroupRequest{Uri: "https://signal.group/#abc"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/signal/groups/"+url.PathEscape("+1234567890")+"/join", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://signal.group/#abc", client.joinedUri)
+	assert.JSONEq(t, `{"id":"group.abc","only_requested":true}`, w.Body.String())
+}
+
+func TestGroupLinkController_JoinGroup_RejectsOtherLinks(t *testing.T) {
+	client := &MockGroupLinkClient{}
+	router := newGroupLinkTestRouter(t, client)
+
+	body, _ := json.Marshal(JoinGroupRequest{Uri: "https://example.com/#abc"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/signal/groups/"+url.PathEscape("+1234567890")+"/join", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, client.joinedUri)
+}
+
+func TestGroupIdFromPath(t *testing.T) {
+	assert.Equal(t, "group.ab+c/d==", groupIdFromPath("group.ab-c_d=="))
+	assert.Equal(t, "group.ab+c/d==", groupIdFromPath("group.ab+c/d=="))
+}
@@ -0,0 +1,93 @@
+package signal
+
+import (
+	"net/http"
+	"net/url"
+
+	"go-multi-chat-api/src/application/usecases/numberhealth"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type INumberHealthController interface {
+	GetNumberHealth(ctx *gin.Context)
+	GetNumberHealthOfNumber(ctx *gin.Context)
+	ResolveNumberHealth(ctx *gin.Context)
+}
+
+type NumberHealthController struct {
+	numberHealthUseCase numberhealth.INumberHealthUseCase
+	Logger              *logger.Logger
+}
+
+// NewNumberHealthController creates a new NumberHealthController
+func NewNumberHealthController(numberHealthUseCase numberhealth.INumberHealthUseCase, loggerInstance *logger.Logger) INumberHealthController {
+	return &NumberHealthController{numberHealthUseCase: numberHealthUseCase, Logger: loggerInstance}
+}
+
+// GetNumberHealth returns the health of every number that sent or received since the table was created
+func (c *NumberHealthController) GetNumberHealth(ctx *gin.Context) {
+	healths, err := c.numberHealthUseCase.GetAll()
+	if err != nil {
+		c.Logger.Error("Error getting number health", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get number health"})
+		return
+	}
+	response := make([]NumberHealthResponse, len(*healths))
+	for i := range *healths {
+		response[i] = numberHealthResponse(&(*healths)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetNumberHealthOfNumber returns the health of a number and what an operator has to do about it
+func (c *NumberHealthController) GetNumberHealthOfNumber(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	health, err := c.numberHealthUseCase.Get(number)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, numberHealthResponse(health))
+}
+
+// ResolveNumberHealth marks a number healthy again once an operator took the action it needed
+func (c *NumberHealthController) ResolveNumberHealth(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	health, err := c.numberHealthUseCase.Resolve(number)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, numberHealthResponse(health))
+}
+
+func numberHealthResponse(health *domainSignal.NumberHealth) NumberHealthResponse {
+	return NumberHealthResponse{
+		Number:              health.Number,
+		Status:              health.Status,
+		ActionCode:          health.ActionCode,
+		Guidance:            numberhealth.Guidance(health.ActionCode, health.Number),
+		SendFailures:        health.SendFailures,
+		UnregisteredErrors:  health.UnregisteredErrors,
+		ReceiveErrors:       health.ReceiveErrors,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		LastError:           health.LastError,
+		LastErrorAt:         health.LastErrorAt,
+		LastSuccessAt:       health.LastSuccessAt,
+		UpdatedAt:           health.UpdatedAt,
+	}
+}
@@ -0,0 +1,117 @@
+package signal
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimitChallengeClient is the subset of the signal client used to lift rate limits
+type RateLimitChallengeClient interface {
+	SubmitRateLimitChallenge(number string, challengeToken string, captcha string) error
+}
+
+// RateLimitedMessageReleaser moves the messages blocked by a rate limit back to pending
+type RateLimitedMessageReleaser interface {
+	ReleaseRateLimitedMessages() (int, error)
+}
+
+type IRateLimitChallengeController interface {
+	GetRateLimitChallenges(ctx *gin.Context)
+	SubmitRateLimitChallenge(ctx *gin.Context)
+}
+
+type RateLimitChallengeController struct {
+	signalClient                 RateLimitChallengeClient
+	rateLimitChallengeRepository signalRepo.RateLimitChallengeRepositoryInterface
+	messageReleaser              RateLimitedMessageReleaser
+	Logger                       *logger.Logger
+}
+
+// NewRateLimitChallengeController creates a new RateLimitChallengeController
+func NewRateLimitChallengeController(signalClient RateLimitChallengeClient, rateLimitChallengeRepository signalRepo.RateLimitChallengeRepositoryInterface, messageReleaser RateLimitedMessageReleaser, loggerInstance *logger.Logger) IRateLimitChallengeController {
+	return &RateLimitChallengeController{
+		signalClient:                 signalClient,
+		rateLimitChallengeRepository: rateLimitChallengeRepository,
+		messageReleaser:              messageReleaser,
+		Logger:                       loggerInstance,
+	}
+}
+
+// GetRateLimitChallenges returns the challenge tokens of a number that weren't lifted yet
+func (c *RateLimitChallengeController) GetRateLimitChallenges(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	challenges, err := c.rateLimitChallengeRepository.GetPending(number)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get rate limit challenges"})
+		return
+	}
+
+	response := RateLimitChallengesResponse{Number: number, RateLimited: len(*challenges) > 0, Challenges: []RateLimitChallengeResponse{}}
+	for _, challenge := range *challenges {
+		response.Challenges = append(response.Challenges, RateLimitChallengeResponse{Token: challenge.Token, CreatedAt: challenge.CreatedAt})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SubmitRateLimitChallenge submits a solved captcha for a challenge of a number. Once Signal accepts it the
+// pending challenges of the number are lifted and the messages blocked by the rate limit are sent again.
+func (c *RateLimitChallengeController) SubmitRateLimitChallenge(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req SubmitRateLimitChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide the solved captcha"})
+		return
+	}
+
+	// Without a token the newest pending challenge of the number is answered
+	challengeToken := req.ChallengeToken
+	if challengeToken == "" {
+		challenges, err := c.rateLimitChallengeRepository.GetPending(number)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get rate limit challenges"})
+			return
+		}
+		if len(*challenges) == 0 {
+			ctx.JSON(http.StatusNotFound, Error{Msg: "No pending rate limit challenge for the number, provide a challenge_token"})
+			return
+		}
+		challengeToken = (*challenges)[0].Token
+	}
+
+	if err := c.signalClient.SubmitRateLimitChallenge(number, challengeToken, req.Captcha); err != nil {
+		c.Logger.Error("Error submitting rate limit challenge", zap.Error(err), zap.String("number", number))
+		ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+		return
+	}
+
+	lifted, err := c.rateLimitChallengeRepository.MarkLifted(number, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Rate limit challenge was accepted but couldn't be stored"})
+		return
+	}
+	released, err := c.messageReleaser.ReleaseRateLimitedMessages()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Rate limit challenge was accepted but the blocked messages couldn't be released"})
+		return
+	}
+
+	c.Logger.Info("Rate limit challenge lifted", zap.String("number", number), zap.Int("liftedChallenges", lifted), zap.Int("releasedMessages", released))
+	ctx.JSON(http.StatusOK, SubmitRateLimitChallengeResponse{Number: number, LiftedChallenges: lifted, ReleasedMessages: released})
+}
@@ -0,0 +1,151 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainSignalEntities "go-multi-chat-api/src/domain/signal"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockRateLimitChallengeClient implements RateLimitChallengeClient for testing
+type MockRateLimitChallengeClient struct {
+	submitted []string
+	err       error
+}
+
+func (m *MockRateLimitChallengeClient) SubmitRateLimitChallenge(number string, challengeToken string, captcha string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.submitted = append(m.submitted, challengeToken+"/"+captcha)
+	return nil
+}
+
+// MockRateLimitChallengeRepository is an in-memory RateLimitChallengeRepositoryInterface
+type MockRateLimitChallengeRepository struct {
+	challenges []domainSignalEntities.RateLimitChallenge
+}
+
+func (m *MockRateLimitChallengeRepository) Save(account string, tokens []string) error {
+	for _, token := range tokens {
+		m.challenges = append(m.challenges, domainSignalEntities.RateLimitChallenge{Account: account, Token: token, CreatedAt: time.Now()})
+	}
+	return nil
+}
+
+func (m *MockRateLimitChallengeRepository) GetPending(account string) (*[]domainSignalEntities.RateLimitChallenge, error) {
+	pending := []domainSignalEntities.RateLimitChallenge{}
+	for i := len(m.challenges) - 1; i >= 0; i-- {
+		if m.challenges[i].Account == account && m.challenges[i].LiftedAt == nil {
+			pending = append(pending, m.challenges[i])
+		}
+	}
+	return &pending, nil
+}
+
+func (m *MockRateLimitChallengeRepository) MarkLifted(account string, liftedAt time.Time) (int, error) {
+	lifted := 0
+	for i := range m.challenges {
+		if m.challenges[i].Account == account && m.challenges[i].LiftedAt == nil {
+			m.challenges[i].LiftedAt = &liftedAt
+			lifted++
+		}
+	}
+	return lifted, nil
+}
+
+type MockRateLimitedMessageReleaser struct {
+	released int
+}
+
+func (m *MockRateLimitedMessageReleaser) ReleaseRateLimitedMessages() (int, error) {
+	m.released++
+	return 3, nil
+}
+
+func newRateLimitChallengeTestContext(method string, body []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/signal/accounts/+1234567890/rate-limit-challenge", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "number", Value: "+1234567890"}}
+	return c, w
+}
+
+func TestRateLimitChallengeController_Submit_AnswersNewestChallengeAndReleasesMessages(t *testing.T) {
+	client := &MockRateLimitChallengeClient{}
+	repository := &MockRateLimitChallengeRepository{}
+	_ = repository.Save("+1234567890", []string{"old-token", "new-token"})
+	releaser := &MockRateLimitedMessageReleaser{}
+	controller := NewRateLimitChallengeController(client, repository, releaser, setupLogger(t))
+
+	body, _ := json.Marshal(SubmitRateLimitChallengeRequest{Captcha: "signalcaptcha://solved"})
+	c, w := newRateLimitChallengeTestContext("POST", body)
+	controller.SubmitRateLimitChallenge(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"new-token/signalcaptcha://solved"}, client.submitted)
+	assert.Equal(t, 1, releaser.released)
+
+	var response SubmitRateLimitChallengeResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.LiftedChallenges)
+	assert.Equal(t, 3, response.ReleasedMessages)
+
+	pending, _ := repository.GetPending("+1234567890")
+	assert.Empty(t, *pending)
+}
+
+func TestRateLimitChallengeController_Submit_Rejected(t *testing.T) {
+	client := &MockRateLimitChallengeClient{err: errors.New("invalid captcha")}
+	repository := &MockRateLimitChallengeRepository{}
+	_ = repository.Save("+1234567890", []string{"token"})
+	releaser := &MockRateLimitedMessageReleaser{}
+	controller := NewRateLimitChallengeController(client, repository, releaser, setupLogger(t))
+
+	body, _ := json.Marshal(SubmitRateLimitChallengeRequest{Captcha: "wrong"})
+	c, w := newRateLimitChallengeTestContext("POST", body)
+	controller.SubmitRateLimitChallenge(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, releaser.released)
+	pending, _ := repository.GetPending("+1234567890")
+	assert.Len(t, *pending, 1)
+}
+
+func TestRateLimitChallengeController_Submit_NoPendingChallenge(t *testing.T) {
+	controller := NewRateLimitChallengeController(&MockRateLimitChallengeClient{}, &MockRateLimitChallengeRepository{}, &MockRateLimitedMessageReleaser{}, setupLogger(t))
+
+	body, _ := json.Marshal(SubmitRateLimitChallengeRequest{Captcha: "signalcaptcha://solved"})
+	c, w := newRateLimitChallengeTestContext("POST", body)
+	controller.SubmitRateLimitChallenge(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRateLimitChallengeController_GetRateLimitChallenges(t *testing.T) {
+	repository := &MockRateLimitChallengeRepository{}
+	_ = repository.Save("+1234567890", []string{"token"})
+	_ = repository.Save("+1999999999", []string{"other"})
+	controller := NewRateLimitChallengeController(&MockRateLimitChallengeClient{}, repository, &MockRateLimitedMessageReleaser{}, setupLogger(t))
+
+	c, w := newRateLimitChallengeTestContext("GET", nil)
+	controller.GetRateLimitChallenges(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response RateLimitChallengesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.RateLimited)
+	assert.Len(t, response.Challenges, 1)
+	assert.Equal(t, "token", response.Challenges[0].Token)
+}
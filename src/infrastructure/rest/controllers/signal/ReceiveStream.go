@@ -0,0 +1,124 @@
+package signal
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/maintenance"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/receivestream"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// maintenanceCheckInterval is how often an open receive stream checks whether another instance enabled
+// maintenance mode
+const maintenanceCheckInterval = 5 * time.Second
+
+type IReceiveStreamController interface {
+	StreamReceived(ctx *gin.Context)
+}
+
+type ReceiveStreamController struct {
+	stream             *receivestream.Stream
+	maintenanceUseCase maintenance.IMaintenanceUseCase
+	checkInterval      time.Duration
+	Logger             *logger.Logger
+}
+
+// NewReceiveStreamController creates a new ReceiveStreamController
+func NewReceiveStreamController(stream *receivestream.Stream, maintenanceUseCase maintenance.IMaintenanceUseCase, loggerInstance *logger.Logger) IReceiveStreamController {
+	return &ReceiveStreamController{
+		stream:             stream,
+		maintenanceUseCase: maintenanceUseCase,
+		checkInterval:      maintenanceCheckInterval,
+		Logger:             loggerInstance,
+	}
+}
+
+// StreamReceived upgrades the request to a WebSocket streaming the messages received by the Signal numbers as they
+// are routed, filtered by the numbers given and with their contents redacted if asked for. The stream is closed
+// when maintenance mode is enabled, and refused while it is.
+func (c *ReceiveStreamController) StreamReceived(ctx *gin.Context) {
+	var filter receivestream.Filter
+	for _, numbers := range ctx.QueryArray("numbers") {
+		for _, number := range strings.Split(numbers, ",") {
+			if number = strings.TrimSpace(number); number != "" {
+				filter.Numbers = append(filter.Numbers, number)
+			}
+		}
+	}
+	redact := false
+	if value := ctx.Query("redact"); value != "" {
+		var err error
+		if redact, err = strconv.ParseBool(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - redact must be true or false"})
+			return
+		}
+	}
+
+	mode, err := c.maintenanceUseCase.Get()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get maintenance mode"})
+		return
+	}
+	if mode.Enabled {
+		ctx.JSON(http.StatusServiceUnavailable, Error{Msg: maintenance.DisconnectReason(mode)})
+		return
+	}
+
+	userID, _ := ctx.Get("userID")
+	c.Logger.Info("Opened receive stream", zap.Any("userID", userID), zap.Strings("numbers", filter.Numbers), zap.Bool("redact", redact))
+	// Admins connect with their own tools rather than a browser page, so the origin isn't checked
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		c.serve(conn, filter, redact)
+	}}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+	c.Logger.Info("Closed receive stream", zap.Any("userID", userID))
+}
+
+// serve sends the events of a subscription to the WebSocket until the client goes away, the stream closes the
+// subscription or maintenance mode is enabled
+func (c *ReceiveStreamController) serve(conn *websocket.Conn, filter receivestream.Filter, redact bool) {
+	subscription := c.stream.Subscribe(filter, redact)
+	defer c.stream.Unsubscribe(subscription)
+
+	// The client doesn't send anything, reading only notices it going away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discarded string
+		for websocket.Message.Receive(conn, &discarded) == nil {
+		}
+	}()
+
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-subscription.Events():
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				if websocket.JSON.Send(conn, ReceiveStreamNotice{Type: ReceiveStreamNoticeDropped, Count: dropped}) != nil {
+					return
+				}
+			}
+			if websocket.JSON.Send(conn, event) != nil {
+				return
+			}
+		case <-subscription.Done():
+			_ = websocket.JSON.Send(conn, ReceiveStreamNotice{Type: ReceiveStreamNoticeClosed, Reason: subscription.Reason()})
+			return
+		case <-ticker.C:
+			if mode, err := c.maintenanceUseCase.Get(); err == nil && mode.Enabled {
+				_ = websocket.JSON.Send(conn, ReceiveStreamNotice{Type: ReceiveStreamNoticeClosed, Reason: maintenance.DisconnectReason(mode)})
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package signal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/maintenance"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	domainSignalEntities "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/receivestream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// MockMaintenanceUseCase implements maintenance.IMaintenanceUseCase for testing
+type MockMaintenanceUseCase struct {
+	maintenance.IMaintenanceUseCase
+	mutex sync.Mutex
+	mode  domainProvider.MaintenanceMode
+}
+
+func (m *MockMaintenanceUseCase) Get() (*domainProvider.MaintenanceMode, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	mode := m.mode
+	return &mode, nil
+}
+
+func (m *MockMaintenanceUseCase) enable(reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.mode = domainProvider.MaintenanceMode{Enabled: true, Reason: reason}
+}
+
+func newReceiveStreamServer(t *testing.T, maintenanceUseCase *MockMaintenanceUseCase) (*receivestream.Stream, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	loggerInstance := setupLogger(t)
+	stream := receivestream.New(loggerInstance)
+	controller := NewReceiveStreamController(stream, maintenanceUseCase, loggerInstance).(*ReceiveStreamController)
+	controller.checkInterval = 20 * time.Millisecond
+	router := gin.New()
+	router.GET("/signal/receive/stream", controller.StreamReceived)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return stream, server
+}
+
+func dialReceiveStream(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/signal/receive/stream" + query
+	conn, err := websocket.Dial(url, "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestReceiveStreamController_StreamsUntilMaintenance(t *testing.T) {
+	maintenanceUseCase := &MockMaintenanceUseCase{}
+	stream, server := newReceiveStreamServer(t, maintenanceUseCase)
+	conn := dialReceiveStream(t, server, "?numbers=%2B4922&redact=true")
+
+	text := "secret"
+	received := &domainSignalEntities.ReceivedMessage{Account: "+4911", Envelope: domainSignalEntities.Envelope{
+		Source: "+4922", Timestamp: 1700000000000, DataMessage: &domainSignalEntities.DataMessage{Message: &text},
+	}}
+	// The subscription is made once the connection is upgraded, publish until the event arrives
+	var event receivestream.Event
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	go func() {
+		for i := 0; i < 50; i++ {
+			stream.Publish(received)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	assert.Equal(t, "data_message", event.Type)
+	assert.True(t, event.Redacted)
+	assert.NotContains(t, string(event.Message), "secret")
+
+	maintenanceUseCase.enable("upgrade")
+	for {
+		var notice ReceiveStreamNotice
+		require.NoError(t, websocket.JSON.Receive(conn, &notice))
+		if notice.Type == ReceiveStreamNoticeClosed {
+			assert.Equal(t, "maintenance mode enabled: upgrade", notice.Reason)
+			break
+		}
+	}
+}
+
+func TestReceiveStreamController_RefusedDuringMaintenance(t *testing.T) {
+	maintenanceUseCase := &MockMaintenanceUseCase{}
+	maintenanceUseCase.enable("")
+	_, server := newReceiveStreamServer(t, maintenanceUseCase)
+
+	response, err := http.Get(server.URL + "/signal/receive/stream")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	response, err = http.Get(server.URL + "/signal/receive/stream?redact=maybe")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
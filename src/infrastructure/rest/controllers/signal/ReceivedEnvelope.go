@@ -0,0 +1,59 @@
+package signal
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-multi-chat-api/src/application/usecases/receivedenvelope"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IReceivedEnvelopeController interface {
+	GetReceivedEnvelopes(ctx *gin.Context)
+}
+
+type ReceivedEnvelopeController struct {
+	receivedEnvelopeUseCase receivedenvelope.IReceivedEnvelopeUseCase
+	Logger                  *logger.Logger
+}
+
+// NewReceivedEnvelopeController creates a new ReceivedEnvelopeController
+func NewReceivedEnvelopeController(receivedEnvelopeUseCase receivedenvelope.IReceivedEnvelopeUseCase, loggerInstance *logger.Logger) IReceivedEnvelopeController {
+	return &ReceivedEnvelopeController{receivedEnvelopeUseCase: receivedEnvelopeUseCase, Logger: loggerInstance}
+}
+
+// GetReceivedEnvelopes returns the latest contact cards, payment notifications and reactions received by the
+// numbers, optionally of one number and type
+func (c *ReceivedEnvelopeController) GetReceivedEnvelopes(ctx *gin.Context) {
+	limit := 0
+	if value := ctx.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - limit must be a positive integer"})
+			return
+		}
+	}
+
+	envelopes, err := c.receivedEnvelopeUseCase.GetEnvelopes(ctx.Query("number"), ctx.Query("type"), limit)
+	if err != nil {
+		c.Logger.Info("Error getting received envelopes", zap.Error(err))
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	response := make([]ReceivedEnvelopeResponse, len(envelopes))
+	for i, envelope := range envelopes {
+		response[i] = ReceivedEnvelopeResponse{
+			ID:        envelope.ID,
+			Account:   envelope.Account,
+			Source:    envelope.Source,
+			Timestamp: envelope.Timestamp,
+			Type:      envelope.Type,
+			Content:   envelope.Content,
+			CreatedAt: envelope.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,54 @@
+package signal
+
+import (
+	"net/http"
+	"net/url"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RecipientClient is the subset of the signal client used to resolve recipients
+type RecipientClient interface {
+	ResolveRecipients(number string, recipients []string) ([]domainSignal.ResolvedRecipient, error)
+}
+
+type IRecipientController interface {
+	ResolveRecipients(ctx *gin.Context)
+}
+
+type RecipientController struct {
+	signalClient RecipientClient
+	Logger       *logger.Logger
+}
+
+func NewRecipientController(signalClient RecipientClient, loggerInstance *logger.Logger) IRecipientController {
+	return &RecipientController{signalClient: signalClient, Logger: loggerInstance}
+}
+
+// ResolveRecipients looks up the Signal accounts of phone numbers, usernames and phone number identities, so
+// clients can check a recipient is reachable before sending to it
+func (c *RecipientController) ResolveRecipients(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req ResolveRecipientsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide at least one recipient"})
+		return
+	}
+
+	resolved, err := c.signalClient.ResolveRecipients(number, req.Recipients)
+	if err != nil {
+		c.Logger.Error("Error resolving recipients", zap.Error(err), zap.String("number", number))
+		ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, ResolveRecipientsResponse{Number: number, Recipients: resolved})
+}
@@ -0,0 +1,70 @@
+package signal
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockRecipientClient implements RecipientClient for testing
+type MockRecipientClient struct {
+	recipients []string
+	err        error
+}
+
+func (m *MockRecipientClient) ResolveRecipients(number string, recipients []string) ([]domainSignal.ResolvedRecipient, error) {
+	m.recipients = recipients
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []domainSignal.ResolvedRecipient{{Recipient: "u:alice.42", UUID: "0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11", Registered: true}}, nil
+}
+
+func newRecipientTestRouter(t *testing.T, client *MockRecipientClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewRecipientController(client, setupLogger(t))
+	router := gin.New()
+	router.POST("/signal/accounts/:number/resolve", controller.ResolveRecipients)
+	return router
+}
+
+func resolvePath() string {
+	return "/signal/accounts/" + url.PathEscape("+1234567890") + "/resolve"
+}
+
+func TestRecipientController_ResolveRecipients(t *testing.T) {
+	client := &MockRecipientClient{}
+	router := newRecipientTestRouter(t, client)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, resolvePath(), bytes.NewBufferString(`{"recipients":["u:alice.42"]}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"u:alice.42"}, client.recipients)
+	assert.JSONEq(t, `{"number":"+1234567890","recipients":[{"recipient":"u:alice.42","uuid":"0c5d3b0e-0f3a-4a47-9c6d-2f4b9a8e1c11","registered":true}]}`, w.Body.String())
+}
+
+func TestRecipientController_ResolveRecipients_Invalid(t *testing.T) {
+	router := newRecipientTestRouter(t, &MockRecipientClient{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, resolvePath(), bytes.NewBufferString(`{"recipients":[]}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	router = newRecipientTestRouter(t, &MockRecipientClient{err: errors.New("Invalid username u:")})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, resolvePath(), bytes.NewBufferString(`{"recipients":["u:"]}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"Invalid username u:"}`, w.Body.String())
+}
@@ -0,0 +1,136 @@
+package signal
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	signalRepo "go-multi-chat-api/src/infrastructure/repository/mysql/signal"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RegistrationLockClient is the subset of the signal client used to manage registration lock PINs
+type RegistrationLockClient interface {
+	SetPin(number string, registrationLockPin string) error
+	RemovePin(number string) error
+}
+
+type IRegistrationLockController interface {
+	GetRegistrationLock(ctx *gin.Context)
+	SetRegistrationLockPin(ctx *gin.Context)
+	RemoveRegistrationLockPin(ctx *gin.Context)
+}
+
+type RegistrationLockController struct {
+	signalClient               RegistrationLockClient
+	registrationLockRepository signalRepo.RegistrationLockRepositoryInterface
+	credentialCipher           security.ICredentialCipher
+	Logger                     *logger.Logger
+}
+
+// NewRegistrationLockController creates a new RegistrationLockController. credentialCipher may be nil when
+// no encryption key is configured, in which case setting a PIN is refused so it is never stored in plain text.
+func NewRegistrationLockController(signalClient RegistrationLockClient, registrationLockRepository signalRepo.RegistrationLockRepositoryInterface, credentialCipher security.ICredentialCipher, loggerInstance *logger.Logger) IRegistrationLockController {
+	return &RegistrationLockController{
+		signalClient:               signalClient,
+		registrationLockRepository: registrationLockRepository,
+		credentialCipher:           credentialCipher,
+		Logger:                     loggerInstance,
+	}
+}
+
+// GetRegistrationLock returns whether the registration lock is enabled for a number
+func (c *RegistrationLockController) GetRegistrationLock(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	lock, err := c.registrationLockRepository.GetByNumber(number)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			ctx.JSON(http.StatusOK, RegistrationLockResponse{Number: number, Enabled: false})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't get registration lock status"})
+		return
+	}
+
+	response := RegistrationLockResponse{Number: lock.Number, Enabled: lock.Enabled, UpdatedAt: &lock.UpdatedAt}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetRegistrationLockPin sets or changes the registration lock PIN of a number
+func (c *RegistrationLockController) SetRegistrationLockPin(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req SetRegistrationLockPinRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide a pin with at least 4 characters"})
+		return
+	}
+
+	if c.credentialCipher == nil {
+		c.Logger.Error("Refusing to set registration lock PIN, credential encryption is not configured", zap.String("number", number))
+		ctx.JSON(http.StatusServiceUnavailable, Error{Msg: "Credential encryption is not configured, set CREDENTIAL_ENCRYPTION_KEY to manage registration lock PINs"})
+		return
+	}
+
+	encryptedPin, err := c.credentialCipher.Encrypt(req.Pin)
+	if err != nil {
+		c.Logger.Error("Error encrypting registration lock PIN", zap.Error(err), zap.String("number", number))
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Couldn't encrypt registration lock PIN"})
+		return
+	}
+
+	if err := c.signalClient.SetPin(number, req.Pin); err != nil {
+		c.Logger.Error("Error setting registration lock PIN", zap.Error(err), zap.String("number", number))
+		ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+		return
+	}
+
+	lock, err := c.registrationLockRepository.Save(&domainSignal.RegistrationLock{Number: number, Enabled: true, EncryptedPin: encryptedPin})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Registration lock PIN was set but couldn't be stored"})
+		return
+	}
+
+	c.Logger.Info("Registration lock PIN set", zap.String("number", number))
+	ctx.JSON(http.StatusOK, RegistrationLockResponse{Number: lock.Number, Enabled: lock.Enabled, UpdatedAt: &lock.UpdatedAt})
+}
+
+// RemoveRegistrationLockPin removes the registration lock PIN of a number
+func (c *RegistrationLockController) RemoveRegistrationLockPin(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	if err := c.signalClient.RemovePin(number); err != nil {
+		c.Logger.Error("Error removing registration lock PIN", zap.Error(err), zap.String("number", number))
+		ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+		return
+	}
+
+	lock, err := c.registrationLockRepository.Save(&domainSignal.RegistrationLock{Number: number, Enabled: false})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "Registration lock PIN was removed but the lock status couldn't be stored"})
+		return
+	}
+
+	c.Logger.Info("Registration lock PIN removed", zap.String("number", number))
+	ctx.JSON(http.StatusOK, RegistrationLockResponse{Number: lock.Number, Enabled: lock.Enabled, UpdatedAt: &lock.UpdatedAt})
+}
@@ -0,0 +1,169 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainSignalEntities "go-multi-chat-api/src/domain/signal"
+	"go-multi-chat-api/src/infrastructure/security"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockRegistrationLockClient implements RegistrationLockClient for testing
+type MockRegistrationLockClient struct {
+	setPinFunc    func(number string, registrationLockPin string) error
+	removePinFunc func(number string) error
+}
+
+func (m *MockRegistrationLockClient) SetPin(number string, registrationLockPin string) error {
+	if m.setPinFunc != nil {
+		return m.setPinFunc(number, registrationLockPin)
+	}
+	return nil
+}
+
+func (m *MockRegistrationLockClient) RemovePin(number string) error {
+	if m.removePinFunc != nil {
+		return m.removePinFunc(number)
+	}
+	return nil
+}
+
+// MockRegistrationLockRepository is an in-memory RegistrationLockRepositoryInterface
+type MockRegistrationLockRepository struct {
+	locks map[string]domainSignalEntities.RegistrationLock
+}
+
+func (m *MockRegistrationLockRepository) GetByNumber(number string) (*domainSignalEntities.RegistrationLock, error) {
+	lock, ok := m.locks[number]
+	if !ok {
+		return &domainSignalEntities.RegistrationLock{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return &lock, nil
+}
+
+func (m *MockRegistrationLockRepository) Save(lock *domainSignalEntities.RegistrationLock) (*domainSignalEntities.RegistrationLock, error) {
+	lock.UpdatedAt = time.Now()
+	m.locks[lock.Number] = *lock
+	return lock, nil
+}
+
+func newRegistrationLockTestContext(method string, body []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/signal/accounts/+1234567890/pin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "number", Value: "+1234567890"}}
+	return c, w
+}
+
+func TestRegistrationLockController_SetPin_StoresEncryptedPin(t *testing.T) {
+	var pinSent string
+	mockClient := &MockRegistrationLockClient{
+		setPinFunc: func(number string, registrationLockPin string) error {
+			pinSent = registrationLockPin
+			return nil
+		},
+	}
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{}}
+	credentialCipher, _ := security.NewCredentialCipher("test_secret")
+	controller := NewRegistrationLockController(mockClient, repository, credentialCipher, setupLogger(t))
+
+	body, _ := json.Marshal(SetRegistrationLockPinRequest{Pin: "975310"})
+	c, w := newRegistrationLockTestContext("POST", body)
+	controller.SetRegistrationLockPin(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "975310")
+	assert.Equal(t, "975310", pinSent)
+
+	stored := repository.locks["+1234567890"]
+	assert.True(t, stored.Enabled)
+	assert.NotEqual(t, "975310", stored.EncryptedPin)
+	decrypted, err := credentialCipher.Decrypt(stored.EncryptedPin)
+	assert.NoError(t, err)
+	assert.Equal(t, "975310", decrypted)
+}
+
+func TestRegistrationLockController_SetPin_WithoutCipher(t *testing.T) {
+	mockClient := &MockRegistrationLockClient{
+		setPinFunc: func(number string, registrationLockPin string) error {
+			t.Error("Expected SetPin not to be called without credential encryption")
+			return nil
+		},
+	}
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{}}
+	controller := NewRegistrationLockController(mockClient, repository, nil, setupLogger(t))
+
+	body, _ := json.Marshal(SetRegistrationLockPinRequest{Pin: "975310"})
+	c, w := newRegistrationLockTestContext("POST", body)
+	controller.SetRegistrationLockPin(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestRegistrationLockController_SetPin_InvalidPin(t *testing.T) {
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{}}
+	credentialCipher, _ := security.NewCredentialCipher("test_secret")
+	controller := NewRegistrationLockController(&MockRegistrationLockClient{}, repository, credentialCipher, setupLogger(t))
+
+	body, _ := json.Marshal(SetRegistrationLockPinRequest{Pin: "12"})
+	c, w := newRegistrationLockTestContext("POST", body)
+	controller.SetRegistrationLockPin(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRegistrationLockController_RemovePin(t *testing.T) {
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{
+		"+1234567890": {Number: "+1234567890", Enabled: true, EncryptedPin: "secret"},
+	}}
+	controller := NewRegistrationLockController(&MockRegistrationLockClient{}, repository, nil, setupLogger(t))
+
+	c, w := newRegistrationLockTestContext("DELETE", nil)
+	controller.RemoveRegistrationLockPin(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, repository.locks["+1234567890"].Enabled)
+	assert.Empty(t, repository.locks["+1234567890"].EncryptedPin)
+}
+
+func TestRegistrationLockController_RemovePin_Error(t *testing.T) {
+	mockClient := &MockRegistrationLockClient{
+		removePinFunc: func(number string) error {
+			return errors.New("signal-cli error")
+		},
+	}
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{}}
+	controller := NewRegistrationLockController(mockClient, repository, nil, setupLogger(t))
+
+	c, w := newRegistrationLockTestContext("DELETE", nil)
+	controller.RemoveRegistrationLockPin(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, repository.locks)
+}
+
+func TestRegistrationLockController_GetRegistrationLock_NotSet(t *testing.T) {
+	repository := &MockRegistrationLockRepository{locks: map[string]domainSignalEntities.RegistrationLock{}}
+	controller := NewRegistrationLockController(&MockRegistrationLockClient{}, repository, nil, setupLogger(t))
+
+	c, w := newRegistrationLockTestContext("GET", nil)
+	controller.GetRegistrationLock(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response RegistrationLockResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Enabled)
+	assert.Equal(t, "+1234567890", response.Number)
+}
@@ -0,0 +1,111 @@
+package signal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RemoteDeleteClient is the subset of the signal client used to delete sent messages
+type RemoteDeleteClient interface {
+	RemoteDelete(number string, recipients []string, timestamp int64) error
+}
+
+// RevokedMessageNotifier reports a revoked message to the webhooks and REST hook subscriptions of its user
+type RevokedMessageNotifier interface {
+	NotifyRevoked(msg *provider.MessageTransaction)
+}
+
+type IRemoteDeleteController interface {
+	RemoteDelete(ctx *gin.Context)
+}
+
+type RemoteDeleteController struct {
+	signalClient                 RemoteDeleteClient
+	messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface
+	notifier                     RevokedMessageNotifier
+	Logger                       *logger.Logger
+}
+
+// NewRemoteDeleteController creates a new RemoteDeleteController
+func NewRemoteDeleteController(signalClient RemoteDeleteClient, messageTransactionRepository providerRepo.MessageTransactionRepositoryInterface, notifier RevokedMessageNotifier, loggerInstance *logger.Logger) IRemoteDeleteController {
+	return &RemoteDeleteController{
+		signalClient:                 signalClient,
+		messageTransactionRepository: messageTransactionRepository,
+		notifier:                     notifier,
+		Logger:                       loggerInstance,
+	}
+}
+
+// RemoteDelete deletes a sent message for its recipients and groups. When the message was sent through the
+// message queue of the user, its transaction is marked as revoked and the revoked event is sent.
+func (c *RemoteDeleteController) RemoteDelete(ctx *gin.Context) {
+	timestamp, err := strconv.ParseInt(ctx.Param("timestamp"), 10, 64)
+	if err != nil || timestamp <= 0 {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - the timestamp needs to be a positive integer"})
+		return
+	}
+
+	var req RemoteDeleteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide the number that sent the message"})
+		return
+	}
+	if req.Recipient != "" {
+		req.Recipients = append(req.Recipients, req.Recipient)
+	}
+	if len(req.Recipients) == 0 {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide at least one recipient"})
+		return
+	}
+
+	if err := c.signalClient.RemoteDelete(req.Number, req.Recipients, timestamp); err != nil {
+		var notFoundErr *signalClient.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			ctx.JSON(http.StatusNotFound, Error{Msg: err.Error()})
+			return
+		}
+		c.Logger.Error("Error deleting sent message", zap.Error(err), zap.String("number", req.Number), zap.Int64("timestamp", timestamp))
+		ctx.JSON(http.StatusBadRequest, Error{Msg: err.Error()})
+		return
+	}
+
+	response := RemoteDeleteResponse{Timestamp: strconv.FormatInt(timestamp, 10)}
+	userIdentity, _ := ctx.Get("userID")
+	userID, _ := userIdentity.(float64)
+	msg, err := c.messageTransactionRepository.GetBySignalTimestamp(int(userID), timestamp)
+	if err != nil {
+		var appErr *domainErrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == domainErrors.NotFound {
+			// The message was sent directly, not through the message queue
+			c.Logger.Info("Sent message deleted", zap.String("number", req.Number), zap.Int64("timestamp", timestamp))
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, Error{Msg: "The message was deleted but its transaction couldn't be found"})
+		return
+	}
+
+	response.MessageID = msg.ID
+	// A message revoked before is left alone
+	if provider.CanTransition(msg.Status, provider.MessageStatusRevoked) {
+		msg, err = c.messageTransactionRepository.Update(msg.ID, map[string]interface{}{"status": provider.MessageStatusRevoked})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, Error{Msg: "The message was deleted but its transaction couldn't be marked as revoked"})
+			return
+		}
+		c.notifier.NotifyRevoked(msg)
+	}
+
+	c.Logger.Info("Sent message deleted", zap.String("number", req.Number), zap.Int64("timestamp", timestamp), zap.Int("messageID", msg.ID))
+	ctx.JSON(http.StatusOK, response)
+}
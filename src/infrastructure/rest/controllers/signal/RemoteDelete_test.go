@@ -0,0 +1,115 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockRemoteDeleteClient implements RemoteDeleteClient for testing
+type MockRemoteDeleteClient struct {
+	recipients []string
+	err        error
+}
+
+func (m *MockRemoteDeleteClient) RemoteDelete(number string, recipients []string, timestamp int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.recipients = append(m.recipients, recipients...)
+	return nil
+}
+
+// MockRevokeRepository keeps the messages of user 1 by their Signal timestamp
+type MockRevokeRepository struct {
+	providerRepo.MessageTransactionRepositoryInterface
+	messages map[int64]*provider.MessageTransaction
+}
+
+func (m *MockRevokeRepository) GetBySignalTimestamp(userID int, timestamp int64) (*provider.MessageTransaction, error) {
+	msg, ok := m.messages[timestamp]
+	if !ok || userID != 1 {
+		return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+	}
+	return msg, nil
+}
+
+func (m *MockRevokeRepository) Update(id int, messageTransactionMap map[string]interface{}) (*provider.MessageTransaction, error) {
+	for _, msg := range m.messages {
+		if msg.ID == id {
+			msg.Status = messageTransactionMap["status"].(string)
+			return msg, nil
+		}
+	}
+	return nil, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+type MockRevokedMessageNotifier struct {
+	revoked []int
+}
+
+func (m *MockRevokedMessageNotifier) NotifyRevoked(msg *provider.MessageTransaction) {
+	m.revoked = append(m.revoked, msg.ID)
+}
+
+func TestRemoteDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	loggerInstance, _ := logger.NewLogger()
+	client := &MockRemoteDeleteClient{}
+	repository := &MockRevokeRepository{messages: map[int64]*provider.MessageTransaction{
+		1700000000000: {ID: 7, UserID: 1, Status: "success"},
+	}}
+	notifier := &MockRevokedMessageNotifier{}
+	controller := NewRemoteDeleteController(client, repository, notifier, loggerInstance)
+
+	request := func(timestamp string, body map[string]interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/signal/messages/"+timestamp, bytes.NewBuffer(data))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "timestamp", Value: timestamp}}
+		c.Set("userID", float64(1))
+		controller.RemoteDelete(c)
+		return w
+	}
+
+	w := request("1700000000000", map[string]interface{}{"number": "+1234567890", "recipients": []string{"+1987654321", "group.abc"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response RemoteDeleteResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, RemoteDeleteResponse{Timestamp: "1700000000000", MessageID: 7}, response)
+	assert.Equal(t, "revoked", repository.messages[1700000000000].Status)
+	assert.Equal(t, []string{"+1987654321", "group.abc"}, client.recipients)
+	assert.Equal(t, []int{7}, notifier.revoked)
+
+	// Revoking again deletes the message again without a second event
+	w = request("1700000000000", map[string]interface{}{"number": "+1234567890", "recipient": "+1987654321"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{7}, notifier.revoked)
+
+	// Messages sent directly have no transaction
+	w = request("1700000000001", map[string]interface{}{"number": "+1234567890", "recipient": "+1987654321"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	response = RemoteDeleteResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, RemoteDeleteResponse{Timestamp: "1700000000001"}, response)
+
+	assert.Equal(t, http.StatusBadRequest, request("yesterday", map[string]interface{}{"number": "+1234567890", "recipient": "+1987654321"}).Code)
+	assert.Equal(t, http.StatusBadRequest, request("1700000000000", map[string]interface{}{"number": "+1234567890"}).Code)
+	assert.Equal(t, http.StatusBadRequest, request("1700000000000", map[string]interface{}{"recipient": "+1987654321"}).Code)
+
+	client.err = errors.New("signal-cli failed")
+	assert.Equal(t, http.StatusBadRequest, request("1700000000000", map[string]interface{}{"number": "+1234567890", "recipient": "+1987654321"}).Code)
+}
@@ -6,11 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"go-multi-chat-api/src/domain/common"
+	domainSignal "go-multi-chat-api/src/domain/signal"
 	"go-multi-chat-api/src/infrastructure/alerting/provider"
 	"go-multi-chat-api/src/infrastructure/alerting/provider/email"
 	logger "go-multi-chat-api/src/infrastructure/logger"
-	domainSignal "go-multi-chat-api/src/infrastructure/repository/signal-client"
-	"go-multi-chat-api/src/infrastructure/utils"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -29,12 +28,12 @@ type ISignalController interface {
 }
 
 type SignalController struct {
-	signalService *domainSignal.SignalClient
+	signalService domainSignal.ISignalService
 	commonService common.CommonService
 	Logger        *logger.Logger
 }
 
-func NewSignalController(signalService *domainSignal.SignalClient, commonService common.CommonService, loggerInstance *logger.Logger) ISignalController {
+func NewSignalController(signalService domainSignal.ISignalService, commonService common.CommonService, loggerInstance *logger.Logger) ISignalController {
 	return &SignalController{signalService: signalService, commonService: commonService, Logger: loggerInstance}
 }
 
@@ -178,29 +177,36 @@ func (c *SignalController) Send(ctx *gin.Context) {
 		return
 	}
 
-	textMode := req.TextMode
-	if textMode == nil {
-		defaultSignalTextMode := utils.GetEnv("DEFAULT_SIGNAL_TEXT_MODE", "normal")
-		if defaultSignalTextMode == "styled" {
-			styledStr := "styled"
-			textMode = &styledStr
-		}
-	}
-
 	if req.ViewOnce != nil && *req.ViewOnce && (len(req.Base64Attachments) == 0) {
 		ctx.JSON(400, Error{Msg: "'view_once' can only be set for image attachments!"})
 		return
 	}
 
-	data, err := c.signalService.SendV2(
-		req.Number, req.Message, req.Recipients, req.Base64Attachments, req.Sticker,
-		req.Mentions, req.QuoteTimestamp, req.QuoteAuthor, req.QuoteMessage, req.QuoteMentions,
-		textMode, req.EditTimestamp, req.NotifySelf, req.LinkPreview, req.ViewOnce)
+	if req.ResolveMentions {
+		if len(req.Recipients) != 1 || !domainSignal.IsGroupRecipient(req.Recipients[0]) {
+			ctx.JSON(400, Error{Msg: "Couldn't process request - 'resolve_mentions' needs a single group recipient"})
+			return
+		}
+		members, err := c.signalService.GetGroupMembers(req.Number, req.Recipients[0])
+		if err != nil {
+			c.Logger.Error("Couldn't get the group members to resolve mentions", zap.Error(err), zap.String("groupId", req.Recipients[0]))
+			ctx.JSON(400, Error{Msg: err.Error()})
+			return
+		}
+		mentions, ambiguous := domainSignal.ResolveMentions(req.Message, members)
+		if len(ambiguous) > 0 {
+			ctx.JSON(400, Error{Msg: "Couldn't process request - ambiguous mentions, several group members are named " + strings.Join(ambiguous, ", ")})
+			return
+		}
+		req.Mentions = append(req.Mentions, mentions...)
+	}
+
+	data, err := c.signalService.Send(req.ToSendRequest())
 	if err != nil {
 		switch err.(type) {
-		case *domainSignal.RateLimitErrorType:
-			if rateLimitError, ok := err.(*domainSignal.RateLimitErrorType); ok {
-				extendedError := errors.New(err.Error() + ". Use the attached challenge tokens to lift the rate limit restrictions via the '/v1/accounts/{number}/rate-limit-challenge' endpoint.")
+		case *domainSignal.RateLimitError:
+			if rateLimitError, ok := err.(*domainSignal.RateLimitError); ok {
+				extendedError := errors.New(err.Error() + ". Use the attached challenge tokens to lift the rate limit restrictions via the '/v1/signal/accounts/{number}/rate-limit-challenge' endpoint.")
 				ctx.JSON(429, SendMessageError{Msg: extendedError.Error(), ChallengeTokens: rateLimitError.ChallengeTokens, Account: req.Number})
 				return
 			} else {
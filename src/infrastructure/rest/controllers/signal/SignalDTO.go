@@ -1,6 +1,12 @@
 package signal
 
-import ds "go-multi-chat-api/src/infrastructure/datastructs"
+import (
+	"time"
+
+	domainSignal "go-multi-chat-api/src/domain/signal"
+	ds "go-multi-chat-api/src/infrastructure/datastructs"
+	signalClient "go-multi-chat-api/src/infrastructure/repository/signal-client"
+)
 
 type MessageRequest struct {
 	Type       string   `json:"type" binding:"required"`
@@ -25,6 +31,29 @@ type SendMessage struct {
 	NotifySelf        *bool               `json:"notify_self"`
 	LinkPreview       *ds.LinkPreviewType `json:"link_preview"`
 	ViewOnce          *bool               `json:"view_once"`
+	// ResolveMentions mentions the members of the group recipient named by @name tokens in the message
+	ResolveMentions bool `json:"resolve_mentions"`
+}
+
+// ToSendRequest converts the request body to the request sent by the Signal client
+func (m *SendMessage) ToSendRequest() signalClient.SendRequest {
+	return signalClient.SendRequest{
+		Number:            m.Number,
+		Recipients:        m.Recipients,
+		Message:           m.Message,
+		Base64Attachments: m.Base64Attachments,
+		Sticker:           m.Sticker,
+		Mentions:          m.Mentions,
+		QuoteTimestamp:    m.QuoteTimestamp,
+		QuoteAuthor:       m.QuoteAuthor,
+		QuoteMessage:      m.QuoteMessage,
+		QuoteMentions:     m.QuoteMentions,
+		TextMode:          m.TextMode,
+		EditTimestamp:     m.EditTimestamp,
+		NotifySelf:        m.NotifySelf,
+		LinkPreview:       m.LinkPreview,
+		ViewOnce:          m.ViewOnce,
+	}
 }
 
 type SendMessageResponse struct {
@@ -49,3 +78,163 @@ type RegisterNumberRequest struct {
 type VerifyNumberSettings struct {
 	Pin string `json:"pin"`
 }
+
+type StartVerificationRequest struct {
+	Captcha string `json:"captcha"`
+}
+
+type VerifyVerificationRequest struct {
+	Code string `json:"code" binding:"required"`
+	Pin  string `json:"pin"`
+}
+
+type VerificationAttemptResponse struct {
+	Channel   string    `json:"channel"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VerificationResponse is the state of the verification of a number, with its attempts newest first
+type VerificationResponse struct {
+	Number          string                        `json:"number"`
+	Status          string                        `json:"status,omitempty"`
+	Channel         string                        `json:"channel,omitempty"`
+	VoiceFallbackAt *time.Time                    `json:"voice_fallback_at,omitempty"`
+	NextAttemptAt   *time.Time                    `json:"next_attempt_at,omitempty"`
+	Attempts        []VerificationAttemptResponse `json:"attempts"`
+}
+
+type SetRegistrationLockPinRequest struct {
+	Pin string `json:"pin" binding:"required,min=4"`
+}
+
+// RegistrationLockResponse is the registration lock status returned to clients, it never contains the PIN
+type RegistrationLockResponse struct {
+	Number    string     `json:"number"`
+	Enabled   bool       `json:"enabled"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type SubmitRateLimitChallengeRequest struct {
+	Captcha string `json:"captcha" binding:"required"`
+	// ChallengeToken defaults to the newest pending challenge of the number
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type SubmitRateLimitChallengeResponse struct {
+	Number           string `json:"number"`
+	LiftedChallenges int    `json:"lifted_challenges"`
+	ReleasedMessages int    `json:"released_messages"`
+}
+
+type RateLimitChallengeResponse struct {
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RateLimitChallengesResponse struct {
+	Number      string                       `json:"number"`
+	RateLimited bool                         `json:"rate_limited"`
+	Challenges  []RateLimitChallengeResponse `json:"challenges"`
+}
+
+type GroupInviteLinkResponse struct {
+	InviteLink string `json:"invite_link"`
+}
+
+type JoinGroupRequest struct {
+	Uri string `json:"uri" binding:"required,startswith=https://signal.group/"`
+}
+
+type ResolveRecipientsRequest struct {
+	Recipients []string `json:"recipients" binding:"required,min=1,max=100,dive,required"`
+}
+
+type ResolveRecipientsResponse struct {
+	Number     string                           `json:"number"`
+	Recipients []domainSignal.ResolvedRecipient `json:"recipients"`
+}
+
+type RemoteDeleteRequest struct {
+	Number     string   `json:"number" binding:"required"`
+	Recipients []string `json:"recipients"`
+	Recipient  string   `json:"recipient"`
+}
+
+type RemoteDeleteResponse struct {
+	Timestamp string `json:"timestamp"`
+	// MessageID is the revoked message transaction, unset when the message wasn't sent through the message queue
+	MessageID int `json:"message_id,omitempty"`
+}
+
+type StartDeviceLinkRequest struct {
+	DeviceName    string `json:"device_name" binding:"required"`
+	QrCodeVersion int    `json:"qrcode_version"`
+}
+
+type RelinkDeviceRequest struct {
+	QrCodeVersion int `json:"qrcode_version"`
+}
+
+type DeviceLinkAttemptResponse struct {
+	DeviceName   string    `json:"device_name"`
+	Number       string    `json:"number,omitempty"`
+	Status       string    `json:"status"`
+	StartedAt    time.Time `json:"started_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	LinkedNumber string    `json:"linked_number,omitempty"`
+}
+
+// AccountModeResponse is the mode a number operates in, the link fields are set for linked devices
+type AccountModeResponse struct {
+	Number     string     `json:"number"`
+	Mode       string     `json:"mode"`
+	Status     string     `json:"status,omitempty"`
+	DeviceName string     `json:"device_name,omitempty"`
+	LinkedAt   *time.Time `json:"linked_at,omitempty"`
+	UnlinkedAt *time.Time `json:"unlinked_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// NumberHealthResponse is the health of a number, guidance says how to take the action of its action code
+type NumberHealthResponse struct {
+	Number              string     `json:"number"`
+	Status              string     `json:"status"`
+	ActionCode          string     `json:"action_code,omitempty"`
+	Guidance            string     `json:"guidance,omitempty"`
+	SendFailures        int        `json:"send_failures"`
+	UnregisteredErrors  int        `json:"unregistered_errors"`
+	ReceiveErrors       int        `json:"receive_errors"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Types of the notices a receive stream sends between the events
+const (
+	ReceiveStreamNoticeDropped = "dropped"
+	ReceiveStreamNoticeClosed  = "closed"
+)
+
+// ReceiveStreamNotice tells a receive stream client that events were dropped because it read too slowly, or
+// why the stream is closed
+type ReceiveStreamNotice struct {
+	Type   string `json:"type"`
+	Count  int    `json:"count,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReceivedEnvelopeResponse is the stored normalized content of a received contact card, payment notification or
+// reaction
+type ReceivedEnvelopeResponse struct {
+	ID        int                          `json:"id"`
+	Account   string                       `json:"account"`
+	Source    string                       `json:"source"`
+	Timestamp int64                        `json:"timestamp"`
+	Type      domainSignal.EnvelopeType    `json:"type"`
+	Content   domainSignal.EnvelopeContent `json:"content"`
+	CreatedAt time.Time                    `json:"created_at"`
+}
@@ -15,7 +15,6 @@ import (
 	ds "go-multi-chat-api/src/infrastructure/datastructs"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 	domainSignal "go-multi-chat-api/src/infrastructure/repository/signal-client"
-	"go-multi-chat-api/src/infrastructure/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -27,7 +26,7 @@ type TestSignalClient interface {
 	RegisterNumber(number string, useVoice bool, captcha string) error
 	VerifyRegisteredNumber(number, token, pin string) error
 	GetQrCodeLink(deviceName string, qrCodeVersion int) ([]byte, error)
-	SendV2(number, message string, recipients, base64Attachments []string, sticker string, mentions []interface{}, quoteTimestamp *int64, quoteAuthor, quoteMessage *string, quoteMentions []interface{}, textMode *string, editTimestamp *int64, notifySelf *bool, linkPreview *interface{}, viewOnce *bool) (*[]domainSignal.SendResponse, error)
+	Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error)
 }
 
 // TestSignalController is a wrapper around SignalController for testing purposes
@@ -187,42 +186,12 @@ func (c *TestSignalController) Send(ctx *gin.Context) {
 		return
 	}
 
-	textMode := req.TextMode
-	if textMode == nil {
-		defaultSignalTextMode := utils.GetEnv("DEFAULT_SIGNAL_TEXT_MODE", "normal")
-		if defaultSignalTextMode == "styled" {
-			styledStr := "styled"
-			textMode = &styledStr
-		}
-	}
-
 	if req.ViewOnce != nil && *req.ViewOnce && (len(req.Base64Attachments) == 0) {
 		ctx.JSON(400, Error{Msg: "'view_once' can only be set for image attachments!"})
 		return
 	}
 
-	// Convert ds.MessageMention to []interface{}
-	var mentions []interface{}
-	for _, m := range req.Mentions {
-		mentions = append(mentions, m)
-	}
-
-	var quoteMentions []interface{}
-	for _, m := range req.QuoteMentions {
-		quoteMentions = append(quoteMentions, m)
-	}
-
-	// Convert *ds.LinkPreviewType to *interface{}
-	var linkPreview *interface{}
-	if req.LinkPreview != nil {
-		var lp interface{} = req.LinkPreview
-		linkPreview = &lp
-	}
-
-	data, err := c.signalClient.SendV2(
-		req.Number, req.Message, req.Recipients, req.Base64Attachments, req.Sticker,
-		mentions, req.QuoteTimestamp, req.QuoteAuthor, req.QuoteMessage, quoteMentions,
-		textMode, req.EditTimestamp, req.NotifySelf, linkPreview, req.ViewOnce)
+	data, err := c.signalClient.Send(req.ToSendRequest())
 	if err != nil {
 		ctx.JSON(400, Error{Msg: err.Error()})
 		return
@@ -236,7 +205,7 @@ type MockSignalClient struct {
 	registerNumberFunc         func(string, bool, string) error
 	verifyRegisteredNumberFunc func(string, string, string) error
 	getQrCodeLinkFunc          func(string, int) ([]byte, error)
-	sendV2Func                 func(string, string, []string, []string, string, []interface{}, *int64, *string, *string, []interface{}, *string, *int64, *bool, *interface{}, *bool) (*[]domainSignal.SendResponse, error)
+	sendFunc                   func(domainSignal.SendRequest) (*[]domainSignal.SendResponse, error)
 }
 
 func (m *MockSignalClient) RegisterNumber(number string, useVoice bool, captcha string) error {
@@ -260,9 +229,9 @@ func (m *MockSignalClient) GetQrCodeLink(deviceName string, qrCodeVersion int) (
 	return []byte{}, nil
 }
 
-func (m *MockSignalClient) SendV2(number, message string, recipients, base64Attachments []string, sticker string, mentions []interface{}, quoteTimestamp *int64, quoteAuthor, quoteMessage *string, quoteMentions []interface{}, textMode *string, editTimestamp *int64, notifySelf *bool, linkPreview *interface{}, viewOnce *bool) (*[]domainSignal.SendResponse, error) {
-	if m.sendV2Func != nil {
-		return m.sendV2Func(number, message, recipients, base64Attachments, sticker, mentions, quoteTimestamp, quoteAuthor, quoteMessage, quoteMentions, textMode, editTimestamp, notifySelf, linkPreview, viewOnce)
+func (m *MockSignalClient) Send(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+	if m.sendFunc != nil {
+		return m.sendFunc(request)
 	}
 	return &[]domainSignal.SendResponse{}, nil
 }
@@ -498,8 +467,10 @@ func TestSignalController_Send_Success(t *testing.T) {
 
 	// Create mock signal client
 	timestamp := int64(1234567890)
+	var sent domainSignal.SendRequest
 	mockSignalClient := &MockSignalClient{
-		sendV2Func: func(number, message string, recipients, base64Attachments []string, sticker string, mentions []interface{}, quoteTimestamp *int64, quoteAuthor, quoteMessage *string, quoteMentions []interface{}, textMode *string, editTimestamp *int64, notifySelf *bool, linkPreview *interface{}, viewOnce *bool) (*[]domainSignal.SendResponse, error) {
+		sendFunc: func(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
+			sent = request
 			return &[]domainSignal.SendResponse{{Timestamp: timestamp}}, nil
 		},
 	}
@@ -516,6 +487,7 @@ func TestSignalController_Send_Success(t *testing.T) {
 		Number:     "+1234567890",
 		Recipients: []string{"+9876543210"},
 		Message:    "Test message",
+		Mentions:   []ds.MessageMention{{Start: 0, Length: 4, Author: "+9876543210"}},
 	}
 
 	requestBody, _ := json.Marshal(sendRequest)
@@ -537,6 +509,11 @@ func TestSignalController_Send_Success(t *testing.T) {
 		t.Errorf("Expected status 201, got %d", w.Code)
 	}
 
+	// The typed request reaches the client unchanged
+	if sent.Number != sendRequest.Number || sent.Message != sendRequest.Message || len(sent.Mentions) != 1 || sent.Mentions[0].Author != "+9876543210" {
+		t.Errorf("Expected the request to be passed to the client, got %+v", sent)
+	}
+
 	// Parse response
 	var response SendMessageResponse
 	err := json.Unmarshal(w.Body.Bytes(), &response)
@@ -557,7 +534,7 @@ func TestSignalController_Send_Error(t *testing.T) {
 
 	// Create mock signal client with error
 	mockSignalClient := &MockSignalClient{
-		sendV2Func: func(number, message string, recipients, base64Attachments []string, sticker string, mentions []interface{}, quoteTimestamp *int64, quoteAuthor, quoteMessage *string, quoteMentions []interface{}, textMode *string, editTimestamp *int64, notifySelf *bool, linkPreview *interface{}, viewOnce *bool) (*[]domainSignal.SendResponse, error) {
+		sendFunc: func(request domainSignal.SendRequest) (*[]domainSignal.SendResponse, error) {
 			return nil, errors.New("send failed")
 		},
 	}
@@ -0,0 +1,116 @@
+package signal
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go-multi-chat-api/src/application/usecases/verification"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type IVerificationController interface {
+	StartVerification(ctx *gin.Context)
+	GetVerification(ctx *gin.Context)
+	VerifyVerification(ctx *gin.Context)
+}
+
+type VerificationController struct {
+	verificationUseCase verification.IVerificationUseCase
+	Logger              *logger.Logger
+}
+
+// NewVerificationController creates a new VerificationController
+func NewVerificationController(verificationUseCase verification.IVerificationUseCase, loggerInstance *logger.Logger) IVerificationController {
+	return &VerificationController{verificationUseCase: verificationUseCase, Logger: loggerInstance}
+}
+
+// StartVerification requests a code for a number by SMS, the number is called instead when the SMS is refused or
+// its code isn't verified in time. Requests within the cool-down of the number are answered with 429.
+func (c *VerificationController) StartVerification(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req StartVerificationRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - invalid request."})
+			return
+		}
+	}
+
+	state, err := c.verificationUseCase.Start(number, req.Captcha)
+	var cooldownErr *verification.CooldownError
+	if errors.As(err, &cooldownErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(cooldownErr.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, Error{Msg: err.Error()})
+		return
+	}
+	if err != nil {
+		c.Logger.Info("Error starting verification", zap.String("number", number), zap.Error(err))
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, verificationResponse(state))
+}
+
+// GetVerification returns the state of the verification of a number and its attempts within the window
+func (c *VerificationController) GetVerification(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	state, err := c.verificationUseCase.Get(number)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, verificationResponse(state))
+}
+
+// VerifyVerification registers a number with the code it was sent, by SMS or by the voice call
+func (c *VerificationController) VerifyVerification(ctx *gin.Context) {
+	number, err := url.PathUnescape(ctx.Param("number"))
+	if err != nil || number == "" {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - malformed number"})
+		return
+	}
+
+	var req VerifyVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, Error{Msg: "Couldn't process request - please provide the verification code"})
+		return
+	}
+
+	state, err := c.verificationUseCase.Verify(number, req.Code, req.Pin)
+	if err != nil {
+		ctx.JSON(deviceLinkErrorStatus(err), Error{Msg: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, verificationResponse(state))
+}
+
+func verificationResponse(state *verification.Verification) VerificationResponse {
+	response := VerificationResponse{
+		Number:          state.Number,
+		Status:          state.Status,
+		Channel:         state.Channel,
+		VoiceFallbackAt: state.VoiceFallbackAt,
+		NextAttemptAt:   state.NextAttemptAt,
+		Attempts:        make([]VerificationAttemptResponse, len(state.Attempts)),
+	}
+	for i, attempt := range state.Attempts {
+		response.Attempts[i] = VerificationAttemptResponse{Channel: attempt.Channel, Status: attempt.Status, Error: attempt.Error, CreatedAt: attempt.CreatedAt}
+	}
+	return response
+}
@@ -0,0 +1,54 @@
+package signal
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/verification"
+	domainSignalEntities "go-multi-chat-api/src/domain/signal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockVerificationUseCase implements verification.IVerificationUseCase for testing
+type MockVerificationUseCase struct {
+	verification.IVerificationUseCase
+	startErr error
+}
+
+func (m *MockVerificationUseCase) Start(number string, captcha string) (*verification.Verification, error) {
+	if m.startErr != nil {
+		return nil, m.startErr
+	}
+	fallbackAt := time.Date(2026, time.October, 16, 12, 2, 0, 0, time.UTC)
+	return &verification.Verification{
+		Number:          number,
+		Status:          domainSignalEntities.VerificationAttemptSent,
+		Channel:         domainSignalEntities.VerificationChannelSMS,
+		VoiceFallbackAt: &fallbackAt,
+		Attempts:        []domainSignalEntities.VerificationAttempt{{Number: number, Channel: domainSignalEntities.VerificationChannelSMS, Status: domainSignalEntities.VerificationAttemptSent}},
+	}, nil
+}
+
+func TestVerificationController_StartVerification(t *testing.T) {
+	useCase := &MockVerificationUseCase{}
+	controller := NewVerificationController(useCase, setupLogger(t))
+
+	c, w := newDeviceLinkTestContext(http.MethodPost, "/signal/verifications/+1234567890", nil, "+1234567890")
+	controller.StartVerification(c)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response VerificationResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "sms", response.Channel)
+	assert.NotNil(t, response.VoiceFallbackAt)
+	assert.Len(t, response.Attempts, 1)
+
+	useCase.startErr = &verification.CooldownError{Number: "+1234567890", RetryAfter: 90500 * time.Millisecond}
+	c, w = newDeviceLinkTestContext(http.MethodPost, "/signal/verifications/+1234567890", []byte(`{"captcha":"token"}`), "+1234567890")
+	controller.StartVerification(c)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "91", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,197 @@
+package status
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	statusUseCase "go-multi-chat-api/src/application/usecases/status"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IStatusController interface {
+	GetStatus(ctx *gin.Context)
+	GetBanners(ctx *gin.Context)
+	CreateBanner(ctx *gin.Context)
+	UpdateBanner(ctx *gin.Context)
+	DeleteBanner(ctx *gin.Context)
+}
+
+type StatusController struct {
+	statusUseCase statusUseCase.IStatusUseCase
+	Logger        *logger.Logger
+}
+
+func NewStatusController(statusUseCase statusUseCase.IStatusUseCase, loggerInstance *logger.Logger) IStatusController {
+	return &StatusController{statusUseCase: statusUseCase, Logger: loggerInstance}
+}
+
+// GetStatus returns the public status page
+func (c *StatusController) GetStatus(ctx *gin.Context) {
+	status, err := c.statusUseCase.GetStatus()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	response := StatusResponse{
+		Status:    status.Status,
+		Providers: make([]ProviderHealthResponse, len(status.Providers)),
+		Banners:   make([]BannerResponse, len(status.Banners)),
+		UpdatedAt: status.UpdatedAt,
+	}
+	for i, health := range status.Providers {
+		response.Providers[i] = ProviderHealthResponse{Type: health.Type, Status: health.Status}
+	}
+	for i := range status.Banners {
+		response.Banners[i] = bannerToResponse(&status.Banners[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetBanners returns every banner, including the ended and scheduled ones
+func (c *StatusController) GetBanners(ctx *gin.Context) {
+	banners, err := c.statusUseCase.GetBanners()
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	response := make([]AdminBannerResponse, len(*banners))
+	for i := range *banners {
+		response[i] = bannerToAdminResponse(&(*banners)[i])
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// CreateBanner publishes an incident or maintenance banner on the status page
+func (c *StatusController) CreateBanner(ctx *gin.Context) {
+	adminID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var request CreateBannerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	banner := &provider.StatusBanner{
+		Title:        request.Title,
+		Message:      request.Message,
+		Severity:     request.Severity,
+		ProviderType: request.ProviderType,
+		EndsAt:       request.EndsAt,
+		CreatedBy:    adminID,
+	}
+	if request.StartsAt != nil {
+		banner.StartsAt = *request.StartsAt
+	}
+
+	created, err := c.statusUseCase.CreateBanner(banner)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, bannerToAdminResponse(created))
+}
+
+// UpdateBanner changes a banner, e.g. its severity as an incident evolves or its end once it is resolved
+func (c *StatusController) UpdateBanner(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+
+	var request UpdateBannerRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	bannerMap := make(map[string]interface{})
+	if request.Title != nil {
+		bannerMap["title"] = *request.Title
+	}
+	if request.Message != nil {
+		bannerMap["message"] = *request.Message
+	}
+	if request.Severity != nil {
+		bannerMap["severity"] = *request.Severity
+	}
+	if request.ProviderType != nil {
+		bannerMap["providerType"] = *request.ProviderType
+	}
+	if request.StartsAt != nil {
+		bannerMap["startsAt"] = *request.StartsAt
+	}
+	if request.EndsAt != nil {
+		bannerMap["endsAt"] = *request.EndsAt
+	}
+	if len(bannerMap) == 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("no fields to update"), domainErrors.ValidationError))
+		return
+	}
+
+	banner, err := c.statusUseCase.UpdateBanner(id, bannerMap)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, bannerToAdminResponse(banner))
+}
+
+// DeleteBanner removes a banner, ended banners can be kept for the record instead
+func (c *StatusController) DeleteBanner(ctx *gin.Context) {
+	id, ok := idParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.statusUseCase.DeleteBanner(id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		_ = ctx.Error(domainErrors.NewAppError(errors.New("id must be a positive integer"), domainErrors.ValidationError))
+		return 0, false
+	}
+	return id, true
+}
+
+func currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func bannerToResponse(banner *provider.StatusBanner) BannerResponse {
+	return BannerResponse{
+		ID:           banner.ID,
+		Title:        banner.Title,
+		Message:      banner.Message,
+		Severity:     banner.Severity,
+		ProviderType: banner.ProviderType,
+		StartsAt:     banner.StartsAt,
+		EndsAt:       banner.EndsAt,
+	}
+}
+
+func bannerToAdminResponse(banner *provider.StatusBanner) AdminBannerResponse {
+	return AdminBannerResponse{
+		BannerResponse: bannerToResponse(banner),
+		CreatedBy:      banner.CreatedBy,
+		CreatedAt:      banner.CreatedAt,
+		UpdatedAt:      banner.UpdatedAt,
+	}
+}
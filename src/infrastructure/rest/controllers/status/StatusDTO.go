@@ -0,0 +1,53 @@
+package status
+
+import "time"
+
+type CreateBannerRequest struct {
+	Title        string     `json:"title" binding:"required,max=200"`
+	Message      string     `json:"message"`
+	Severity     string     `json:"severity" binding:"required,oneof=info degraded outage"`
+	ProviderType string     `json:"provider_type"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
+
+// UpdateBannerRequest changes the fields that are set, e.g. ends_at to end an incident
+type UpdateBannerRequest struct {
+	Title        *string    `json:"title" binding:"omitempty,max=200"`
+	Message      *string    `json:"message"`
+	Severity     *string    `json:"severity" binding:"omitempty,oneof=info degraded outage"`
+	ProviderType *string    `json:"provider_type"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
+
+// BannerResponse is a banner as shown on the public status page
+type BannerResponse struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Message      string     `json:"message,omitempty"`
+	Severity     string     `json:"severity"`
+	ProviderType string     `json:"provider_type,omitempty"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+}
+
+// AdminBannerResponse is a banner with who published it
+type AdminBannerResponse struct {
+	BannerResponse
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProviderHealthResponse struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+type StatusResponse struct {
+	Status    string                   `json:"status"`
+	Providers []ProviderHealthResponse `json:"providers"`
+	Banners   []BannerResponse         `json:"banners"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
@@ -0,0 +1,161 @@
+package syncfeed
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	syncUseCase "go-multi-chat-api/src/application/usecases/syncfeed"
+	"go-multi-chat-api/src/domain"
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// errInvalidCursor answers sync cursors not issued by the API
+var errInvalidCursor = errors.New("invalid sync cursor")
+
+type ISyncController interface {
+	Sync(ctx *gin.Context)
+}
+
+type SyncController struct {
+	syncUseCase syncUseCase.ISyncUseCase
+	Logger      *logger.Logger
+}
+
+func NewSyncController(syncUseCase syncUseCase.ISyncUseCase, loggerInstance *logger.Logger) ISyncController {
+	return &SyncController{syncUseCase: syncUseCase, Logger: loggerInstance}
+}
+
+// Sync returns the changes of the authenticated user since the cursor of its previous sync
+func (c *SyncController) Sync(ctx *gin.Context) {
+	userID, ok := c.currentUserID(ctx)
+	if !ok {
+		return
+	}
+	var request SyncRequest
+	if err := ctx.ShouldBindQuery(&request); err != nil {
+		_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+		return
+	}
+	var cursor provider.SyncCursor
+	if request.Since != "" {
+		decoded, err := DecodeSyncCursor(request.Since)
+		if err != nil {
+			_ = ctx.Error(domainErrors.NewAppError(err, domainErrors.ValidationError))
+			return
+		}
+		cursor = *decoded
+	}
+	limit := domain.PageRequest{Limit: request.Limit}.WithDefaults().Limit
+
+	result, err := c.syncUseCase.Sync(userID, cursor, limit)
+	if err != nil {
+		c.Logger.Error("Error syncing changes", zap.Error(err), zap.Int("userID", userID))
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, syncResultToResponse(result))
+}
+
+// EncodeSyncCursor turns a sync cursor into an opaque token. Clients pass it back as is, its format may change.
+func EncodeSyncCursor(cursor provider.SyncCursor) string {
+	var configNanos int64
+	if !cursor.ConfigUpdatedAt.IsZero() {
+		configNanos = cursor.ConfigUpdatedAt.UnixNano()
+	}
+	raw := fmt.Sprintf("%d:%d:%d:%d", cursor.StatusEventID, cursor.InboundMessageID, configNanos, cursor.ConfigID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeSyncCursor reads a token of EncodeSyncCursor
+func DecodeSyncCursor(token string) (*provider.SyncCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor provider.SyncCursor
+	var configNanos int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d:%d:%d", &cursor.StatusEventID, &cursor.InboundMessageID, &configNanos, &cursor.ConfigID); err != nil {
+		return nil, errInvalidCursor
+	}
+	if cursor.StatusEventID < 0 || cursor.InboundMessageID < 0 || configNanos < 0 || cursor.ConfigID < 0 {
+		return nil, errInvalidCursor
+	}
+	if configNanos > 0 {
+		cursor.ConfigUpdatedAt = time.Unix(0, configNanos).UTC()
+	}
+	return &cursor, nil
+}
+
+func (c *SyncController) currentUserID(ctx *gin.Context) (int, bool) {
+	userIdentity, exists := ctx.Get("userID")
+	userID, ok := userIdentity.(float64)
+	if !exists || !ok {
+		_ = ctx.Error(domainErrors.NewAppErrorWithType(domainErrors.NotAuthenticated))
+		return 0, false
+	}
+	return int(userID), true
+}
+
+func syncResultToResponse(result *syncUseCase.SyncResult) SyncResponse {
+	response := SyncResponse{
+		MessageStatusChanges: make([]MessageStatusChangeResponse, len(result.StatusChanges)),
+		InboundMessages:      make([]InboundMessageResponse, len(result.InboundMessages)),
+		ProviderConfigs:      make([]ProviderConfigResponse, len(result.ProviderConfigs)),
+		ProviderConfigIDs:    append([]int{}, result.ProviderConfigIDs...),
+		Cursor:               EncodeSyncCursor(result.Cursor),
+		HasMore:              result.HasMore,
+	}
+	for i, change := range result.StatusChanges {
+		response.MessageStatusChanges[i] = MessageStatusChangeResponse{
+			MessageID:    change.MessageID,
+			ProviderID:   change.ProviderID,
+			EventType:    change.EventType,
+			Status:       change.Status,
+			ErrorCode:    change.ErrorCode,
+			ErrorMessage: change.ErrorMessage,
+			RetryCount:   change.RetryCount,
+			OccurredAt:   change.OccurredAt,
+		}
+	}
+	for i, message := range result.InboundMessages {
+		response.InboundMessages[i] = InboundMessageResponse{
+			ID:             message.ID,
+			ConversationID: message.ConversationID,
+			Channel:        message.Channel,
+			Participant:    message.Participant,
+			ExternalID:     message.ExternalID,
+			Body:           message.Body,
+			OccurredAt:     message.OccurredAt,
+		}
+	}
+	for i, config := range result.ProviderConfigs {
+		response.ProviderConfigs[i] = ProviderConfigResponse{
+			ID:               config.ID,
+			ProviderID:       config.ProviderID,
+			Priority:         config.Priority,
+			Config:           rawConfig(config.Config),
+			Status:           config.Status,
+			Suspended:        config.Suspended,
+			Version:          config.Version,
+			CredentialStatus: config.CredentialStatus,
+			UpdatedAt:        config.UpdatedAt,
+		}
+	}
+	return response
+}
+
+func rawConfig(config string) json.RawMessage {
+	if config == "" || !json.Valid([]byte(config)) {
+		return nil
+	}
+	return json.RawMessage(config)
+}
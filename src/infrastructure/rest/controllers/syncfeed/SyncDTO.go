@@ -0,0 +1,54 @@
+package syncfeed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SyncRequest struct {
+	Since string `form:"since"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type MessageStatusChangeResponse struct {
+	MessageID    int       `json:"message_id"`
+	ProviderID   int       `json:"provider_id"`
+	EventType    string    `json:"event_type"`
+	Status       string    `json:"status"`
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	RetryCount   int       `json:"retry_count"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+type InboundMessageResponse struct {
+	ID             int       `json:"id"`
+	ConversationID int       `json:"conversation_id"`
+	Channel        string    `json:"channel"`
+	Participant    string    `json:"participant"`
+	ExternalID     string    `json:"external_id,omitempty"`
+	Body           string    `json:"body"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+type ProviderConfigResponse struct {
+	ID               int             `json:"id"`
+	ProviderID       int             `json:"provider_id"`
+	Priority         int             `json:"priority"`
+	Config           json.RawMessage `json:"config,omitempty"`
+	Status           bool            `json:"status"`
+	Suspended        bool            `json:"suspended"`
+	Version          int             `json:"version"`
+	CredentialStatus string          `json:"credential_status,omitempty"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+type SyncResponse struct {
+	MessageStatusChanges []MessageStatusChangeResponse `json:"message_status_changes"`
+	InboundMessages      []InboundMessageResponse      `json:"inbound_messages"`
+	ProviderConfigs      []ProviderConfigResponse      `json:"provider_configs"`
+	// ProviderConfigIDs are the configs the user has now, configs missing from it were deleted
+	ProviderConfigIDs []int  `json:"provider_config_ids"`
+	Cursor            string `json:"cursor"`
+	HasMore           bool   `json:"has_more"`
+}
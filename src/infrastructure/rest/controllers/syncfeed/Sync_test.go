@@ -0,0 +1,27 @@
+package syncfeed
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncCursor_RoundTrip(t *testing.T) {
+	for _, cursor := range []provider.SyncCursor{
+		{},
+		{StatusEventID: 42, InboundMessageID: 7, ConfigUpdatedAt: time.Date(2026, 10, 16, 8, 0, 0, 123000000, time.UTC), ConfigID: 3},
+	} {
+		decoded, err := DecodeSyncCursor(EncodeSyncCursor(cursor))
+		require.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+	}
+
+	for _, token := range []string{"not base64!", "MTI", "LTE6MDowOjA"} {
+		_, err := DecodeSyncCursor(token)
+		assert.Error(t, err, token)
+	}
+}
@@ -25,18 +25,30 @@ type NewUserRequest struct {
 	LastName  string `json:"lastName" binding:"required"`
 	Password  string `json:"password" binding:"required"`
 	Role      string `json:"role" binding:"required"`
+	Locale    string `json:"locale"`
+	// EngagementTracking records the deliveries, reads and clicks of each recipient of the user's messages
+	EngagementTracking bool `json:"engagementTracking"`
+	// RecipientHourlyCap and RecipientDailyCap cap the messages sent to the same recipient, 0 applies the global
+	// caps and -1 lifts them
+	RecipientHourlyCap int `json:"recipientHourlyCap" binding:"min=-1"`
+	RecipientDailyCap  int `json:"recipientDailyCap" binding:"min=-1"`
 }
 
 type ResponseUser struct {
-	ID        int       `json:"id"`
-	UserName  string    `json:"user"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Status    bool      `json:"status"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	ID                 int        `json:"id"`
+	UserName           string     `json:"user"`
+	Email              string     `json:"email"`
+	FirstName          string     `json:"firstName"`
+	LastName           string     `json:"lastName"`
+	Status             bool       `json:"status"`
+	Role               string     `json:"role"`
+	Locale             string     `json:"locale,omitempty"`
+	EngagementTracking bool       `json:"engagementTracking"`
+	RecipientHourlyCap int        `json:"recipientHourlyCap"`
+	RecipientDailyCap  int        `json:"recipientDailyCap"`
+	DeactivatedAt      *time.Time `json:"deactivatedAt,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt          time.Time  `json:"updatedAt,omitempty"`
 }
 
 type IUserController interface {
@@ -67,6 +79,10 @@ func (c *UserController) NewUser(ctx *gin.Context) {
 		_ = ctx.Error(appError)
 		return
 	}
+	if err := localeValidation(request.Locale); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
 	userModel, err := c.userService.Create(toUsecaseMapper(&request))
 	if err != nil {
 		c.Logger.Error("Error creating user", zap.Error(err), zap.String("email", request.Email))
@@ -301,15 +317,20 @@ func (c *UserController) SearchByProperty(ctx *gin.Context) {
 // Mappers
 func domainToResponseMapper(domainUser *domainUser.User) *ResponseUser {
 	return &ResponseUser{
-		ID:        domainUser.ID,
-		UserName:  domainUser.UserName,
-		Email:     domainUser.Email,
-		FirstName: domainUser.FirstName,
-		LastName:  domainUser.LastName,
-		Status:    domainUser.Status,
-		Role:      domainUser.Role,
-		CreatedAt: domainUser.CreatedAt,
-		UpdatedAt: domainUser.UpdatedAt,
+		ID:                 domainUser.ID,
+		UserName:           domainUser.UserName,
+		Email:              domainUser.Email,
+		FirstName:          domainUser.FirstName,
+		LastName:           domainUser.LastName,
+		Status:             domainUser.Status,
+		Role:               domainUser.Role,
+		Locale:             domainUser.Locale,
+		EngagementTracking: domainUser.EngagementTracking,
+		RecipientHourlyCap: domainUser.RecipientHourlyCap,
+		RecipientDailyCap:  domainUser.RecipientDailyCap,
+		DeactivatedAt:      domainUser.DeactivatedAt,
+		CreatedAt:          domainUser.CreatedAt,
+		UpdatedAt:          domainUser.UpdatedAt,
 	}
 }
 
@@ -323,11 +344,15 @@ func arrayDomainToResponseMapper(users *[]domainUser.User) *[]ResponseUser {
 
 func toUsecaseMapper(req *NewUserRequest) *domainUser.User {
 	return &domainUser.User{
-		UserName:  req.UserName,
-		Email:     req.Email,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Password:  req.Password,
-		Role:      req.Role,
+		UserName:           req.UserName,
+		Email:              req.Email,
+		FirstName:          req.FirstName,
+		LastName:           req.LastName,
+		Password:           req.Password,
+		Role:               req.Role,
+		Locale:             req.Locale,
+		EngagementTracking: req.EngagementTracking,
+		RecipientHourlyCap: req.RecipientHourlyCap,
+		RecipientDailyCap:  req.RecipientDailyCap,
 	}
 }
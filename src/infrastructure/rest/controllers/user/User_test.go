@@ -220,6 +220,11 @@ func TestUpdateValidation(t *testing.T) {
 
 	err = updateValidation(longFirstNameRequest)
 	assert.Error(t, err)
+
+	// Test recipient caps, -1 lifts a cap
+	assert.NoError(t, updateValidation(map[string]any{"recipientHourlyCap": float64(10), "recipientDailyCap": float64(-1)}))
+	assert.Error(t, updateValidation(map[string]any{"recipientHourlyCap": float64(-2)}))
+	assert.Error(t, updateValidation(map[string]any{"recipientDailyCap": 2.5}))
 }
 
 func setupGinContext() (*gin.Context, *httptest.ResponseRecorder) {
@@ -3,9 +3,11 @@ package user
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/infrastructure/i18n"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -53,8 +55,39 @@ func updateValidation(request map[string]any) error {
 	if err != nil {
 		return domainErrors.NewAppError(err, domainErrors.UnknownError)
 	}
+	if locale, exists := request["locale"]; exists {
+		if s, ok := locale.(string); !ok || !i18n.IsSupported(s) {
+			errorsValidation = append(errorsValidation, "locale must be one of "+strings.Join(i18n.Supported(), ", "))
+		}
+	}
+	if engagementTracking, exists := request["engagementTracking"]; exists {
+		if _, ok := engagementTracking.(bool); !ok {
+			errorsValidation = append(errorsValidation, "engagementTracking must be a boolean")
+		}
+	}
+	for _, field := range []string{"recipientHourlyCap", "recipientDailyCap"} {
+		if value, exists := request[field]; exists {
+			if n, ok := value.(float64); !ok || n != math.Trunc(n) || n < -1 {
+				errorsValidation = append(errorsValidation, field+" must be an integer of at least -1")
+			}
+		}
+	}
+	// Deactivating a user cascades to the user's tokens, providers and messages, it has endpoints of its own
+	for _, field := range []string{"status", "deactivatedAt"} {
+		if _, exists := request[field]; exists {
+			errorsValidation = append(errorsValidation, field+" is changed through /admin/users/:id/deactivate and /admin/users/:id/reactivate")
+		}
+	}
 	if len(errorsValidation) > 0 {
 		return domainErrors.NewAppError(errors.New(strings.Join(errorsValidation, ", ")), domainErrors.ValidationError)
 	}
 	return nil
 }
+
+// localeValidation checks the locale of a new user, which is optional
+func localeValidation(locale string) error {
+	if locale != "" && !i18n.IsSupported(locale) {
+		return domainErrors.NewAppError(errors.New("locale must be one of "+strings.Join(i18n.Supported(), ", ")), domainErrors.ValidationError)
+	}
+	return nil
+}
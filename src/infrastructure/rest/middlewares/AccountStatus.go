@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	domainUser "go-multi-chat-api/src/domain/user"
+	"go-multi-chat-api/src/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const accountStatusKey = "accountStatus"
+
+// AccountStatus lets the login and role middlewares refuse the tokens of deactivated users. active reports
+// whether a user may call the API with a token issued at issuedAt, it is only asked once the token is verified.
+func AccountStatus(active func(userID int, issuedAt time.Time) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(accountStatusKey, active)
+		c.Next()
+	}
+}
+
+// rejectDeactivated aborts the request when the user of a verified token was deactivated or the token was
+// issued before the last deactivation. Tokens issued before tokens carried their issue time count as issued at
+// the epoch. It returns whether the request was aborted.
+func rejectDeactivated(c *gin.Context, userID int, claims jwt.MapClaims) bool {
+	active, ok := c.Value(accountStatusKey).(func(userID int, issuedAt time.Time) bool)
+	if !ok {
+		return false
+	}
+	var issuedAt time.Time
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.Unix(int64(iat), 0)
+	} else {
+		issuedAt = time.Unix(0, 0)
+	}
+	if active(userID, issuedAt) {
+		return false
+	}
+	locale := Locale(c)
+	c.Header("Content-Language", locale)
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": i18n.Translate(locale, "Account deactivated"),
+		"code":  domainUser.ErrorCodeDeactivated,
+	})
+	c.Abort()
+	return true
+}
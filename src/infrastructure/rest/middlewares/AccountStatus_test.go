@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountStatus(t *testing.T) {
+	originalSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
+	os.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
+	defer os.Setenv("JWT_ACCESS_SECRET_KEY", originalSecret)
+
+	// User 1 was deactivated an hour ago, user 2 is active
+	deactivatedAt := time.Now().Add(-time.Hour)
+	active := func(userID int, issuedAt time.Time) bool {
+		return userID == 2 || issuedAt.After(deactivatedAt)
+	}
+	request := func(userID int, issuedAt *time.Time, middleware gin.HandlerFunc) *httptest.ResponseRecorder {
+		claims := jwt.MapClaims{
+			"exp":  time.Now().Add(time.Hour).Unix(),
+			"type": "access",
+			"id":   userID,
+			"role": "admin",
+		}
+		if issuedAt != nil {
+			claims["iat"] = issuedAt.Unix()
+		}
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/protected", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+		AccountStatus(active)(c)
+		middleware(c)
+		return w
+	}
+	issuedBefore := deactivatedAt.Add(-time.Minute)
+	issuedAfter := time.Now()
+
+	for name, middleware := range map[string]gin.HandlerFunc{
+		"login": AuthJWTMiddleware(),
+		"role":  RequiresRoleMiddleware("admin", nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := request(1, &issuedBefore, middleware)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "Account deactivated", response["error"])
+			assert.Equal(t, "user_deactivated", response["code"])
+
+			// Tokens without an issue time predate every deactivation
+			assert.Equal(t, http.StatusForbidden, request(1, nil, middleware).Code)
+
+			assert.Equal(t, http.StatusOK, request(1, &issuedAfter, middleware).Code)
+			assert.Equal(t, http.StatusOK, request(2, nil, middleware).Code)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const externalTokensKey = "externalTokens"
+
+// ExternalTokens lets the login and role middlewares accept the access tokens of an external identity provider,
+// e.g. Azure AD. claims verifies a token not signed by the API and returns the claims of an access token of its
+// user: id, role, type, exp and iat.
+func ExternalTokens(claims func(tokenString string) (jwt.MapClaims, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(externalTokensKey, claims)
+		c.Next()
+	}
+}
+
+// externalClaims returns the claims of a token signed with anything but the HMAC of the API when external tokens
+// are accepted. It reports false for the tokens of the API, they are verified with its secret.
+func externalClaims(c *gin.Context, tokenString string) (jwt.MapClaims, bool, error) {
+	claimsOf, ok := c.Value(externalTokensKey).(func(tokenString string) (jwt.MapClaims, error))
+	if !ok {
+		return nil, false, nil
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil || token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return nil, false, nil
+	}
+	claims, err := claimsOf(tokenString)
+	return claims, true, err
+}
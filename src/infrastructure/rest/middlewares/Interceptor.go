@@ -2,46 +2,206 @@ package middlewares
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
-	"time"
+	"mime"
+	"net/url"
+	"strings"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// maxBufferedBodyBytes is the largest body buffered for scrubbing. Larger bodies, e.g. attachment uploads, are
+// passed through and only their size is logged.
+const maxBufferedBodyBytes = 1 << 20
+
+// redacted replaces the values of scrubbed fields
+const redacted = "[REDACTED]"
+
+// DefaultScrubFields are the fields always scrubbed from logged bodies. A field is scrubbed when its name contains
+// one of them, ignoring case, so "password" covers "new_password" and "attachment" covers "base64_attachments".
+var DefaultScrubFields = []string{"password", "token", "captcha", "attachment"}
+
+// BodyLogConfig selects the requests whose bodies are logged and how
+type BodyLogConfig struct {
+	// Routes are the path prefixes of the route groups to log, e.g. "/v1/send". Empty logs every route.
+	Routes []string
+	// MaxBytes caps each logged body after scrubbing, 0 uses 4096
+	MaxBytes int
+	// ScrubFields are scrubbed in addition to DefaultScrubFields
+	ScrubFields []string
+}
+
 type bodyLogWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
+	size int
 }
 
-func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.size += len(b)
+	if w.body.Len()+len(b) <= maxBufferedBodyBytes {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
-func GinBodyLogMiddleware(c *gin.Context) {
-	blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
-	c.Writer = blw
+// BodyLog logs the request and response bodies of the routes of the config. Fields with passwords, tokens, captcha
+// answers and attachments are scrubbed from JSON and form bodies before they are logged, and multipart bodies are
+// left out.
+func BodyLog(loggerInstance *logger.Logger, config BodyLogConfig) gin.HandlerFunc {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 4096
+	}
+	scrubFields := make([]string, 0, len(DefaultScrubFields)+len(config.ScrubFields))
+	for _, field := range append(append([]string{}, DefaultScrubFields...), config.ScrubFields...) {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			scrubFields = append(scrubFields, field)
+		}
+	}
+
+	return func(c *gin.Context) {
+		if !logsRoute(config.Routes, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		requestBody, requestSize := readRequestBody(c)
+		blw := &bodyLogWriter{body: &bytes.Buffer{}, ResponseWriter: c.Writer}
+		c.Writer = blw
+
+		c.Next()
 
-	buf := make([]byte, 4096)
-	num, err := c.Request.Body.Read(buf)
-	if err != nil && err.Error() != "EOF" {
-		_ = fmt.Errorf("error reading buffer: %s", err.Error())
+		var responseBody []byte
+		if blw.size <= maxBufferedBodyBytes {
+			responseBody = blw.body.Bytes()
+		}
+		loggerInstance.Info("HTTP body",
+			zap.String("route", c.FullPath()),
+			zap.String("request_uri", c.Request.RequestURI),
+			zap.Int("status_code", c.Writer.Status()),
+			zap.String("request_body", logBody(c.Request.Header.Get("Content-Type"), requestBody, requestSize, scrubFields, config.MaxBytes)),
+			zap.String("response_body", logBody(c.Writer.Header().Get("Content-Type"), responseBody, blw.size, scrubFields, config.MaxBytes)),
+			zap.Strings("errors", c.Errors.Errors()),
+		)
 	}
-	reqBody := string(buf[0:num])
-	c.Request.Body = io.NopCloser(bytes.NewBuffer([]byte(reqBody)))
+}
 
-	c.Next()
+// logsRoute reports whether path is below one of the route prefixes
+func logsRoute(routes []string, path string) bool {
+	if len(routes) == 0 {
+		return true
+	}
+	for _, route := range routes {
+		if path == route || strings.HasPrefix(path, strings.TrimSuffix(route, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
 
-	loc, _ := time.LoadLocation("Europe/Prague")
-	allDataIO := map[string]any{
-		"ruta":          c.FullPath(),
-		"request_uri":   c.Request.RequestURI,
-		"raw_request":   reqBody,
-		"status_code":   c.Writer.Status(),
-		"body_response": blw.body.String(),
-		"errors":        c.Errors.Errors(),
-		"created_at":    time.Now().In(loc).Format("2006-01-02T15:04:05"),
+// readRequestBody buffers the request body for the log and hands the handler the whole body. It returns nil for
+// bodies too large to buffer, which are passed through unread, and the size of the body when it is known.
+func readRequestBody(c *gin.Context) ([]byte, int) {
+	if c.Request.Body == nil {
+		return nil, 0
+	}
+	if isMultipart(c.Request.Header.Get("Content-Type")) || c.Request.ContentLength > maxBufferedBodyBytes {
+		return nil, int(c.Request.ContentLength)
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBufferedBodyBytes+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	if err != nil || len(body) > maxBufferedBodyBytes {
+		return nil, -1
 	}
-	_ = fmt.Sprintf("%v", allDataIO)
+	return body, len(body)
+}
+
+// logBody returns the loggable form of a body: scrubbed and capped to maxBytes
+func logBody(contentType string, body []byte, size int, scrubFields []string, maxBytes int) string {
+	if size == 0 {
+		return ""
+	}
+	if isMultipart(contentType) {
+		return "[multipart body omitted]"
+	}
+	if body == nil {
+		if size < 0 {
+			return "[body too large to log]"
+		}
+		return fmt.Sprintf("[body of %d bytes too large to log]", size)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	var logged string
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("[unreadable form body of %d bytes omitted]", size)
+		}
+		for key := range values {
+			if scrubbed(key, scrubFields) {
+				values[key] = []string{redacted}
+			}
+		}
+		logged = values.Encode()
+	case json.Valid(body):
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		_ = decoder.Decode(&value)
+		data, _ := json.Marshal(scrubJSON(value, scrubFields))
+		logged = string(data)
+	case strings.Contains(mediaType, "json"):
+		// A malformed JSON body can't be scrubbed
+		return fmt.Sprintf("[invalid JSON body of %d bytes omitted]", size)
+	default:
+		logged = string(body)
+	}
+
+	if len(logged) > maxBytes {
+		return fmt.Sprintf("%s... [truncated, %d bytes]", logged[:maxBytes], len(logged))
+	}
+	return logged
+}
+
+// scrubJSON replaces the values of the scrubbed fields of a decoded JSON value
+func scrubJSON(value interface{}, scrubFields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if scrubbed(key, scrubFields) {
+				v[key] = redacted
+			} else {
+				v[key] = scrubJSON(field, scrubFields)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = scrubJSON(v[i], scrubFields)
+		}
+	}
+	return value
+}
+
+func scrubbed(key string, scrubFields []string) bool {
+	key = strings.ToLower(key)
+	for _, field := range scrubFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMultipart(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), "multipart/")
 }
@@ -10,7 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockResponseWriter implements gin.ResponseWriter for testing
@@ -62,7 +66,7 @@ func TestGinBodyLogMiddleware(t *testing.T) {
 
 	// Create a new Gin router
 	router := gin.New()
-	router.Use(GinBodyLogMiddleware)
+	router.Use(BodyLog(logger.NewCapture().Logger, BodyLogConfig{}))
 
 	// Add a test route
 	router.POST("/test", func(c *gin.Context) {
@@ -98,7 +102,7 @@ func TestGinBodyLogMiddleware_EmptyBody(t *testing.T) {
 
 	// Create a new Gin router
 	router := gin.New()
-	router.Use(GinBodyLogMiddleware)
+	router.Use(BodyLog(logger.NewCapture().Logger, BodyLogConfig{}))
 
 	// Add a test route
 	router.GET("/test", func(c *gin.Context) {
@@ -168,7 +172,7 @@ func TestGinBodyLogMiddleware_LargeBody(t *testing.T) {
 
 	// Create a new Gin router
 	router := gin.New()
-	router.Use(GinBodyLogMiddleware)
+	router.Use(BodyLog(logger.NewCapture().Logger, BodyLogConfig{}))
 
 	// Add a test route
 	router.POST("/test", func(c *gin.Context) {
@@ -177,10 +181,20 @@ func TestGinBodyLogMiddleware_LargeBody(t *testing.T) {
 
 	// Create a large request body (larger than the 4096 buffer)
 	largeBody := strings.Repeat("a", 5000)
+	var received int
+	router.POST("/large", func(c *gin.Context) {
+		data, _ := io.ReadAll(c.Request.Body)
+		received = len(data)
+		c.Status(http.StatusOK)
+	})
+	req, _ := http.NewRequest("POST", "/large", strings.NewReader(largeBody))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	// The handler reads the whole body, not just the logged part
+	assert.Equal(t, len(largeBody), received)
 
 	// Create a test request
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/test", strings.NewReader(largeBody))
+	req, _ = http.NewRequest("POST", "/test", strings.NewReader(largeBody))
 
 	// Serve the request
 	router.ServeHTTP(w, req)
@@ -190,3 +204,43 @@ func TestGinBodyLogMiddleware_LargeBody(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+func TestBodyLog_ScrubsSensitiveFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	capture := logger.NewCapture()
+	router := gin.New()
+	router.Use(BodyLog(capture.Logger, BodyLogConfig{Routes: []string{"/v1/auth"}, ScrubFields: []string{"otp"}}))
+	router.POST("/v1/auth/login", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"accessToken": "secret-token", "user": gin.H{"email": "a@example.org"}})
+	})
+	router.POST("/v1/send/message", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	post := func(target string, contentType string, body string) {
+		req, _ := http.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post("/v1/auth/login", "application/json", `{"email":"a@example.org","password":"hunter2","captcha_answer":"42","otp_code":"123456","nested":[{"base64_attachments":["aGk="]}],"id":12345678901234567}`)
+	// Routes outside the configured groups aren't logged
+	post("/v1/send/message", "application/json", `{"message":"hello"}`)
+
+	entries := capture.Entries("")
+	require.Len(t, entries, 1)
+	fields := entries[0].Fields
+	assert.Equal(t, "/v1/auth/login", fields["route"])
+	assert.JSONEq(t, `{"email":"a@example.org","password":"[REDACTED]","captcha_answer":"[REDACTED]","otp_code":"[REDACTED]","nested":[{"base64_attachments":"[REDACTED]"}],"id":12345678901234567}`, fields["request_body"].(string))
+	assert.JSONEq(t, `{"accessToken":"[REDACTED]","user":{"email":"a@example.org"}}`, fields["response_body"].(string))
+}
+
+func TestLogBody(t *testing.T) {
+	scrubFields := DefaultScrubFields
+
+	assert.Equal(t, "email=a%40example.org&password=%5BREDACTED%5D", logBody("application/x-www-form-urlencoded", []byte("email=a%40example.org&password=hunter2"), 38, scrubFields, 4096))
+	assert.Equal(t, "[multipart body omitted]", logBody("multipart/form-data; boundary=x", nil, 2048, scrubFields, 4096))
+	assert.Equal(t, "[invalid JSON body of 20 bytes omitted]", logBody("application/json", []byte(`{"password":"hunter2`), 20, scrubFields, 4096))
+	assert.Equal(t, "[body of 2097152 bytes too large to log]", logBody("application/json", nil, 2<<20, scrubFields, 4096))
+	assert.Equal(t, "aaaa... [truncated, 10 bytes]", logBody("text/plain", []byte("aaaaaaaaaa"), 10, scrubFields, 4))
+	assert.Equal(t, "", logBody("", nil, 0, scrubFields, 4096))
+}
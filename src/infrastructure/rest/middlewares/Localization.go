@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"go-multi-chat-api/src/infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userLocaleKey = "userLocale"
+
+// Localization lets the error messages of a request be translated to the locale of the user when the
+// Accept-Language header names no supported locale. The user is only known once authenticated, so the
+// locale is looked up when an error is written.
+func Localization(userLocale func(userID int) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(userLocaleKey, userLocale)
+		c.Next()
+	}
+}
+
+// Locale returns the locale of the response, from the Accept-Language header, the locale of the
+// authenticated user or the default locale, in that order
+func Locale(c *gin.Context) string {
+	if locale := i18n.Negotiate(c.GetHeader("Accept-Language")); locale != "" {
+		return locale
+	}
+	userLocale, ok := c.Value(userLocaleKey).(func(userID int) string)
+	if !ok {
+		return i18n.DefaultLocale
+	}
+	// The login middleware stores the user ID as float64, the role middleware as int
+	switch userID := c.Value("userID").(type) {
+	case int:
+		return userLocale(userID)
+	case float64:
+		return userLocale(int(userID))
+	}
+	return i18n.DefaultLocale
+}
+
+// abortWithError writes an error message of a middleware in the locale of the request
+func abortWithError(c *gin.Context, status int, message string) {
+	locale := Locale(c)
+	c.Header("Content-Language", locale)
+	c.JSON(status, gin.H{"error": i18n.Translate(locale, message)})
+	c.Abort()
+}
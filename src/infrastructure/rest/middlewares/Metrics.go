@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRecorder receives the outcome of every request, e.g. to update Prometheus counters and histograms.
+// The route is the pattern the request matched, like /v1/user/:id, so it can be used as a label; requests
+// matching no route are recorded with an empty route.
+type MetricsRecorder interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
+// Metrics records every request with the recorder once it is handled
+func Metrics(recorder MetricsRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		recorder.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idleBucketTTL is how long the bucket of a client that sends no requests is kept
+const idleBucketTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// clientLimiter keeps a token bucket per client IP
+type clientLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	perSecond float64
+	burst     float64
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newClientLimiter(perMinute int, burst int) *clientLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &clientLimiter{
+		buckets:   make(map[string]*bucket),
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(burst),
+		now:       time.Now,
+	}
+}
+
+// allow takes a token of the client. When none is left it returns how long until the next one.
+func (l *clientLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst}
+		l.buckets[client] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.perSecond)
+	}
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets of idle clients, they are full again anyway
+func (l *clientLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= idleBucketTTL {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit limits every client IP to perMinute requests, allowing bursts of up to burst requests. Limited
+// requests are answered with 429 Too Many Requests and a Retry-After header.
+func RateLimit(perMinute int, burst int) gin.HandlerFunc {
+	limiter := newClientLimiter(perMinute, burst)
+	return func(c *gin.Context) {
+		if ok, retryAfter := limiter.allow(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, "Too many requests")
+			return
+		}
+		c.Next()
+	}
+}
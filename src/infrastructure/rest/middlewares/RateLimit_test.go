@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientLimiter_RefillsOverTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newClientLimiter(60, 2)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, retryAfter := limiter.allow("10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// Other clients have buckets of their own
+	ok, _ = limiter.allow("10.0.0.2")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	ok, _ = limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestClientLimiter_DropsIdleBuckets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newClientLimiter(60, 1)
+	limiter.now = func() time.Time { return now }
+
+	limiter.allow("10.0.0.1")
+	now = now.Add(idleBucketTTL)
+	limiter.allow("10.0.0.2")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "10.0.0.2")
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(1, 1))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept-Language", "de")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"Zu viele Anfragen"}`, w.Body.String())
+}
@@ -5,66 +5,88 @@ import (
 	"os"
 	"strings"
 
+	"go-multi-chat-api/src/infrastructure/security"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 func AuthJWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token not provided"})
-			c.Abort()
+		claims, ok := accessClaims(c)
+		if !ok {
 			return
 		}
 
+		userID, ok := claims["id"].(float64)
+		if ok {
+			c.Set("userID", userID)
+			if rejectDeactivated(c, int(userID), claims) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// accessClaims verifies the access token of the request and returns its claims. Tokens of the API must carry the
+// configured issuer, audience, tenant and org, tokens of an external identity provider are verified by it. It
+// aborts the request and reports false when the token is refused.
+func accessClaims(c *gin.Context) (jwt.MapClaims, bool) {
+	tokenString := c.GetHeader("Authorization")
+	if tokenString == "" {
+		abortWithError(c, http.StatusUnauthorized, "Token not provided")
+		return nil, false
+	}
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+
+	claims, external, err := externalClaims(c, tokenString)
+	if err != nil {
+		abortWithError(c, http.StatusUnauthorized, "Invalid token")
+		return nil, false
+	}
+	if !external {
 		accessSecret := os.Getenv("JWT_ACCESS_SECRET_KEY")
 		if accessSecret == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "JWT_ACCESS_SECRET_KEY not configured"})
-			c.Abort()
-			return
+			abortWithError(c, http.StatusUnauthorized, "JWT_ACCESS_SECRET_KEY not configured")
+			return nil, false
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		claims := jwt.MapClaims{}
+		claims = jwt.MapClaims{}
 		_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
 			return []byte(accessSecret), nil
 		})
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
+			abortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return nil, false
 		}
-
-		if exp, ok := claims["exp"].(float64); ok {
-			if int64(exp) < jwt.TimeFunc().Unix() {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-				c.Abort()
-				return
-			}
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+		if err := security.LoadJWTConfig().VerifyClaims(claims); err != nil {
+			abortWithError(c, http.StatusUnauthorized, "Invalid token")
+			return nil, false
 		}
+	}
 
-		if t, ok := claims["type"].(string); ok {
-			if t != "access" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Token type mismatch"})
-				c.Abort()
-				return
-			}
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Missing token type"})
-			c.Abort()
-			return
+	// Check token expiration
+	if exp, ok := claims["exp"].(float64); ok {
+		if int64(exp) < jwt.TimeFunc().Unix() {
+			abortWithError(c, http.StatusUnauthorized, "Token expired")
+			return nil, false
 		}
+	} else {
+		abortWithError(c, http.StatusUnauthorized, "Invalid token claims")
+		return nil, false
+	}
 
-		userID, ok := claims["id"].(float64)
-		if ok {
-			c.Set("userID", userID)
+	// Check token type
+	if t, ok := claims["type"].(string); ok {
+		if t != "access" {
+			abortWithError(c, http.StatusForbidden, "Token type mismatch")
+			return nil, false
 		}
-
-		c.Next()
+	} else {
+		abortWithError(c, http.StatusForbidden, "Missing token type")
+		return nil, false
 	}
+	return claims, true
 }
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -243,3 +244,59 @@ func TestAuthJWTMiddleware_TokenWithoutBearer(t *testing.T) {
 	// because strings.TrimPrefix handles this case
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAuthJWTMiddleware_IssuerAndAudience(t *testing.T) {
+	t.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
+	t.Setenv("JWT_ISSUER", "go-multi-chat-api")
+	t.Setenv("JWT_AUDIENCE", "chat-api")
+
+	request := func(claims jwt.MapClaims) int {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		claims["type"] = "access"
+		claims["id"] = 1
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/protected", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+		AuthJWTMiddleware()(c)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(jwt.MapClaims{"iss": "go-multi-chat-api", "aud": []string{"chat-api"}}))
+	assert.Equal(t, http.StatusUnauthorized, request(jwt.MapClaims{"iss": "other", "aud": "chat-api"}))
+	assert.Equal(t, http.StatusUnauthorized, request(jwt.MapClaims{"iss": "go-multi-chat-api", "aud": "other"}))
+	assert.Equal(t, http.StatusUnauthorized, request(jwt.MapClaims{}))
+}
+
+func TestAuthJWTMiddleware_ExternalTokens(t *testing.T) {
+	t.Setenv("JWT_ACCESS_SECRET_KEY", "test-secret")
+	externalToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"sub": "jane"}).SignedString([]byte("provider"))
+	claimsOf := func(tokenString string) (jwt.MapClaims, error) {
+		if tokenString != externalToken {
+			return nil, errors.New("invalid token")
+		}
+		return jwt.MapClaims{"id": float64(7), "type": "access", "role": "member", "exp": float64(time.Now().Add(time.Hour).Unix())}, nil
+	}
+	request := func(tokenString string) (*gin.Context, int) {
+		c, w := setupGinContext()
+		c.Request = httptest.NewRequest("GET", "/protected", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+		ExternalTokens(claimsOf)(c)
+		AuthJWTMiddleware()(c)
+		return c, w.Code
+	}
+
+	c, code := request(externalToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(7), c.Value("userID"))
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"sub": "eve"}).SignedString([]byte("forged"))
+	_, code = request(forged)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Tokens of the API are still verified with its secret
+	own, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1, "type": "access", "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("test-secret"))
+	_, code = request(own)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TraceIDKey is the context key of the trace ID of a request
+const TraceIDKey = "traceID"
+
+// traceparentPattern matches a W3C traceparent header: version, trace ID, parent span ID and flags
+var traceparentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// Tracing joins the trace of the caller named by the W3C traceparent header, or starts a new one. Every request
+// gets a span of its own; the trace ID is stored in the context for logging and, with the span, returned in
+// the traceparent and X-Trace-ID response headers.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID, flags := "", "01"
+		if match := traceparentPattern.FindStringSubmatch(c.GetHeader("traceparent")); match != nil && match[1] != zeroTraceID {
+			traceID, flags = match[1], match[3]
+		} else {
+			traceID = randomHex(16)
+		}
+		spanID := randomHex(8)
+
+		c.Set(TraceIDKey, traceID)
+		c.Header("traceparent", "00-"+traceID+"-"+spanID+"-"+flags)
+		c.Header("X-Trace-ID", traceID)
+		c.Next()
+	}
+}
+
+// zeroTraceID is invalid according to the W3C trace context
+const zeroTraceID = "00000000000000000000000000000000"
+
+func randomHex(bytes int) string {
+	b := make([]byte, bytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func tracedRequest(traceparent string) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Tracing())
+	var traceID string
+	router.GET("/test", func(c *gin.Context) {
+		traceID = c.GetString(TraceIDKey)
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if traceparent != "" {
+		req.Header.Set("traceparent", traceparent)
+	}
+	router.ServeHTTP(w, req)
+	return w, traceID
+}
+
+func TestTracing_JoinsTraceOfCaller(t *testing.T) {
+	w, traceID := tracedRequest("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, traceID, w.Header().Get("X-Trace-ID"))
+	match := traceparentPattern.FindStringSubmatch(w.Header().Get("traceparent"))
+	if assert.NotNil(t, match) {
+		assert.Equal(t, traceID, match[1])
+		assert.NotEqual(t, "00f067aa0ba902b7", match[2], "the request gets a span of its own")
+		assert.Equal(t, "00", match[3])
+	}
+}
+
+func TestTracing_StartsTrace(t *testing.T) {
+	for _, traceparent := range []string{"", "garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		w, traceID := tracedRequest(traceparent)
+
+		assert.Len(t, traceID, 32, traceparent)
+		assert.NotEqual(t, zeroTraceID, traceID, traceparent)
+		assert.Regexp(t, "^00-"+traceID+"-[0-9a-f]{16}-01$", w.Header().Get("traceparent"), traceparent)
+	}
+}
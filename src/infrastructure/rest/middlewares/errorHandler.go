@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/infrastructure/i18n"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,13 +15,17 @@ func ErrorHandler() gin.HandlerFunc {
 		c.Next()
 
 		if len(c.Errors) > 0 {
+			// Messages without a translation, like most detailed validation errors, are answered in English
+			locale := Locale(c)
+			c.Header("Content-Language", locale)
+
 			err := c.Errors.Last().Err
 			var appErr *domainErrors.AppError
 			if errors.As(err, &appErr) {
 				status, message := domainErrors.AppErrorToHTTP(appErr)
-				c.JSON(status, gin.H{"error": message})
+				c.JSON(status, gin.H{"error": i18n.Translate(locale, message)})
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Translate(locale, "Internal Server Error")})
 			}
 		}
 	}
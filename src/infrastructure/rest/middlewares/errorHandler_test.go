@@ -266,3 +266,53 @@ func TestErrorHandler_NonAppError(t *testing.T) {
 		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
 	}
 }
+
+func TestErrorHandler_TranslatesToAcceptLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/test", func(c *gin.Context) {
+		_ = c.Error(domainErrors.NewAppErrorWithType(domainErrors.NotFound))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept-Language", "de-CH, fr;q=0.8")
+	router.ServeHTTP(w, req)
+
+	expectedBody := `{"error":"Datensatz nicht gefunden"}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+	if w.Header().Get("Content-Language") != "de" {
+		t.Errorf("Expected Content-Language de, got %s", w.Header().Get("Content-Language"))
+	}
+}
+
+func TestErrorHandler_FallsBackToUserLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Localization(func(userID int) string {
+		if userID == 7 {
+			return "es"
+		}
+		return "en"
+	}))
+	router.Use(ErrorHandler())
+	router.GET("/test", func(c *gin.Context) {
+		c.Set("userID", float64(7))
+		_ = c.Error(errors.New("regular error"))
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept-Language", "ja")
+	router.ServeHTTP(w, req)
+
+	expectedBody := `{"error":"Error interno del servidor"}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/action"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ActionRoutes(router *gin.RouterGroup, controller action.IActionController) {
+	// Vendors authenticate interactions with their signature
+	router.POST("/interactions/:vendor/:id", controller.ReceiveInteraction)
+}
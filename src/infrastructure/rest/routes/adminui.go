@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/adminui"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUIRoutes serves the single-page admin UI when it is enabled. The UI itself is public, it calls the admin
+// endpoints of the API with the token of the user logging in.
+func AdminUIRoutes(router *gin.Engine, config adminui.Config) {
+	if !config.Enabled {
+		return
+	}
+	handler := adminui.Handler()
+	router.GET(adminui.Path, handler)
+	router.GET(adminui.Path+"/*filepath", handler)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/analytics"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AnalyticsRoutes(router *gin.RouterGroup, controller analytics.IAnalyticsController) {
+	analyticsRoute := router.Group("/analytics")
+	analyticsRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		analyticsRoute.GET("/tags", controller.GetTagRollup)
+		analyticsRoute.GET("/engagement", controller.GetEngagement)
+		analyticsRoute.GET("/recipient-caps", controller.GetRecipientCaps)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/anonymize"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AnonymizeRoutes(router *gin.RouterGroup, controller anonymize.IAnonymizeController, appContext *di.ApplicationContext) {
+	anonymizeRoute := router.Group("/admin/anonymize")
+	// Anonymizing rewrites every message and user of the database, only admins can start it
+	anonymizeRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		anonymizeRoute.POST("", controller.Start)
+	}
+}
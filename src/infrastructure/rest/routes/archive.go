@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/archive"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ArchiveRoutes(router *gin.RouterGroup, controller archive.IArchiveController, appContext *di.ApplicationContext) {
+	// Clients ask where a range of the history is before querying it
+	locationRoute := router.Group("/archive")
+	locationRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		locationRoute.GET("/location", controller.Locate)
+	}
+
+	// The archived files hold the messages of every user, only admins run the archive and list them
+	adminRoute := router.Group("/admin/archive")
+	adminRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		adminRoute.POST("", controller.Start)
+		adminRoute.GET("", controller.GetBatches)
+	}
+}
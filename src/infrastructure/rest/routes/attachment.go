@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/attachment"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AttachmentRoutes(router *gin.RouterGroup, controller attachment.IAttachmentController) {
+	attachmentRoute := router.Group("/attachments")
+	attachmentRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		attachmentRoute.POST("", controller.Start)
+		attachmentRoute.GET("/:id", controller.GetAttachment)
+		attachmentRoute.PUT("/:id/parts/:part", controller.UploadPart)
+		attachmentRoute.POST("/:id/complete", controller.Complete)
+		attachmentRoute.DELETE("/:id", controller.Abort)
+	}
+}
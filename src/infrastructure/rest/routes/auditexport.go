@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/auditexport"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuditExportRoutes(router *gin.RouterGroup, controller auditexport.IAuditExportController, appContext *di.ApplicationContext) {
+	auditExportRoute := router.Group("/admin/audit-exports")
+	// The audit logs name the users and operators of every account, only admins can export them
+	auditExportRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		auditExportRoute.POST("", controller.Start)
+		auditExportRoute.GET("", controller.GetBatches)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/bootstrap"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func BootstrapRoutes(router *gin.RouterGroup, controller bootstrap.IBootstrapController, appContext *di.ApplicationContext) {
+	// Bootstrapping creates users and admins, only admins can apply documents
+	router.POST("/admin/bootstrap", middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger), controller.Apply)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/bulkoperation"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func BulkOperationRoutes(router *gin.RouterGroup, controller bulkoperation.IBulkOperationController, appContext *di.ApplicationContext) {
+	bulkOperationRoute := router.Group("/bulk-operations")
+	// Bulk operations change the messages of every user, only admins can run them
+	bulkOperationRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		bulkOperationRoute.POST("", controller.Start)
+	}
+}
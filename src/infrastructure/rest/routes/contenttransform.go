@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/contenttransform"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ContentTransformRoutes(router *gin.RouterGroup, controller contenttransform.IContentTransformController) {
+	contentTransformRoute := router.Group("/content-transforms")
+	contentTransformRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		contentTransformRoute.GET("", controller.GetTransforms)
+		contentTransformRoute.PUT("", controller.SetTransforms)
+		contentTransformRoute.POST("/test", controller.TestTransforms)
+	}
+}
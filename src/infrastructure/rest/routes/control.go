@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/control"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ControlRoutes(router *gin.RouterGroup, controller control.IControlController, appContext *di.ApplicationContext) {
+	controlRoute := router.Group("/control")
+	// The audit log of control commands names the operators, only admins can read it
+	controlRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		controlRoute.GET("/commands", controller.GetCommands)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/conversation"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ConversationRoutes(router *gin.RouterGroup, controller conversation.IConversationController, appContext *di.ApplicationContext) {
+	conversationRoute := router.Group("/conversations")
+	conversationRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		conversationRoute.GET("", controller.GetConversations)
+		conversationRoute.GET("/:id/messages", controller.GetMessages)
+
+		// Rebuilding replays the messages of every user, only admins can start it
+		adminCheck := middlewares.RequiresRoleMiddleware("admin", appContext.Logger)
+		conversationRoute.POST("/rebuild", adminCheck, controller.Rebuild)
+		conversationRoute.GET("/rebuild", adminCheck, controller.GetRebuildStatus)
+	}
+}
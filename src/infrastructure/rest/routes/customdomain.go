@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/customdomain"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CustomDomainRoutes(router *gin.RouterGroup, controller customdomain.ICustomDomainController, appContext *di.ApplicationContext) {
+	customDomainRoute := router.Group("/admin/users/:id/custom-domain")
+	// Custom domains change where the links and hooks of an account point to, only admins can set them up
+	customDomainRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		customDomainRoute.GET("", controller.GetDomain)
+		customDomainRoute.PUT("", controller.SetDomain)
+		customDomainRoute.POST("/verify", controller.Verify)
+		customDomainRoute.DELETE("", controller.RemoveDomain)
+	}
+}
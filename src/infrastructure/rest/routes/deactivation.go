@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/deactivation"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeactivationRoutes(router *gin.RouterGroup, controller deactivation.IDeactivationController, appContext *di.ApplicationContext) {
+	deactivationRoute := router.Group("/admin/users/:id")
+	deactivationRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		deactivationRoute.POST("/deactivate", controller.Deactivate)
+		deactivationRoute.POST("/reactivate", controller.Reactivate)
+	}
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/delivery"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeliveryRoutes(router *gin.RouterGroup, controller delivery.IDeliveryController) {
+	// Vendors authenticate delivery callbacks with their signature
+	router.POST("/callbacks/:vendor/:id", controller.ReceiveCallback)
+}
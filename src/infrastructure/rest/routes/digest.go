@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/digest"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DigestRoutes(router *gin.RouterGroup, controller digest.IDigestController) {
+	digestRoute := router.Group("/digests")
+	digestRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		digestRoute.GET("", controller.GetDigests)
+		digestRoute.GET("/subscription", controller.GetSubscription)
+		digestRoute.PUT("/subscription", controller.UpdateSubscription)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/distributionlist"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DistributionListRoutes(router *gin.RouterGroup, controller distributionlist.IDistributionListController, appContext *di.ApplicationContext) {
+	distributionListRoute := router.Group("/distribution-lists")
+	distributionListRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		distributionListRoute.POST("", controller.CreateList)
+		distributionListRoute.GET("", controller.GetLists)
+		distributionListRoute.GET("/:id", controller.GetList)
+		distributionListRoute.PUT("/:id", controller.UpdateList)
+		distributionListRoute.DELETE("/:id", controller.DeleteList)
+		distributionListRoute.POST("/:id/send", controller.Send)
+
+		// Only admin can review and sync the lists of all users
+		adminCheck := middlewares.RequiresRoleMiddleware("admin", appContext.Logger)
+		distributionListRoute.GET("/drift", adminCheck, controller.GetDrifted)
+		distributionListRoute.POST("/:id/sync", adminCheck, controller.SyncList)
+	}
+}
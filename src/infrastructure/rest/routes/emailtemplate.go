@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/emailtemplate"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EmailTemplateRoutes(router *gin.RouterGroup, controller emailtemplate.IEmailTemplateController) {
+	emailTemplateRoute := router.Group("/email-templates")
+	emailTemplateRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		emailTemplateRoute.POST("", controller.CreateTemplate)
+		emailTemplateRoute.GET("", controller.GetTemplates)
+		emailTemplateRoute.POST("/preview", controller.PreviewDraft)
+		emailTemplateRoute.GET("/:id", controller.GetTemplate)
+		emailTemplateRoute.PUT("/:id", controller.UpdateTemplate)
+		emailTemplateRoute.DELETE("/:id", controller.DeleteTemplate)
+		emailTemplateRoute.POST("/:id/preview", controller.Preview)
+	}
+}
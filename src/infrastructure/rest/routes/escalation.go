@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/escalation"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EscalationRoutes(router *gin.RouterGroup, controller escalation.IEscalationController) {
+	escalationRoute := router.Group("/escalations")
+	escalationRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		escalationRoute.POST("/chains", controller.CreateChain)
+		escalationRoute.GET("/chains", controller.GetChains)
+		escalationRoute.DELETE("/chains/:id", controller.DeleteChain)
+		escalationRoute.POST("", controller.Trigger)
+		escalationRoute.GET("", controller.GetEscalations)
+		escalationRoute.GET("/:id", controller.GetEscalation)
+		escalationRoute.POST("/:id/ack", controller.Acknowledge)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/hook"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HookRoutes(router *gin.RouterGroup, controller hook.IHookController) {
+	hookRoute := router.Group("/hooks")
+	hookRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		hookRoute.POST("", controller.Subscribe)
+		hookRoute.GET("", controller.GetSubscriptions)
+		hookRoute.DELETE("/:id", controller.Unsubscribe)
+		hookRoute.POST("/:id/rotate-secret", controller.RotateSecret)
+	}
+
+	webhookRoute := router.Group("/webhooks")
+	webhookRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		webhookRoute.GET("/events", controller.GetEvents)
+		webhookRoute.POST("/events/:id/replay", controller.ReplayEvent)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/inboundnumber"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InboundNumberRoutes(router *gin.RouterGroup, controller inboundnumber.IInboundNumberController) {
+	numberRoute := router.Group("/providers/:id/numbers")
+	numberRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		numberRoute.GET("", controller.GetNumbers)
+		numberRoute.GET("/available", controller.SearchNumbers)
+		numberRoute.POST("", controller.ProvisionNumber)
+		numberRoute.DELETE("/:number", controller.ReleaseNumber)
+	}
+
+	// SMS vendors authenticate inbound webhooks with their signature
+	router.POST("/sms/inbound/:id", controller.ReceiveSMS)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/job"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func JobRoutes(router *gin.RouterGroup, controller job.IJobController) {
+	jobRoute := router.Group("/jobs")
+	jobRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		jobRoute.GET("", controller.GetJobs)
+		jobRoute.GET("/:id", controller.GetJob)
+		jobRoute.POST("/:id/cancel", controller.Cancel)
+		jobRoute.POST("/:id/retry", controller.Retry)
+	}
+}
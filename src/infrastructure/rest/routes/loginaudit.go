@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/loginaudit"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LoginAuditRoutes(router *gin.RouterGroup, controller loginaudit.ILoginAuditController) {
+	loginActivityRoute := router.Group("/login-activity")
+	loginActivityRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		loginActivityRoute.GET("", controller.GetActivity)
+		loginActivityRoute.GET("/settings", controller.GetSettings)
+		loginActivityRoute.PUT("/settings", controller.UpdateSettings)
+	}
+}
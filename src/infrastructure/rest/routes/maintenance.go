@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/maintenance"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MaintenanceRoutes(router *gin.RouterGroup, controller maintenance.IMaintenanceController, appContext *di.ApplicationContext) {
+	maintenanceRoute := router.Group("/admin/maintenance")
+	maintenanceRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		maintenanceRoute.GET("", controller.Get)
+		maintenanceRoute.PUT("", controller.Set)
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/provider"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ProviderRoutes(router *gin.RouterGroup, controller provider.IProviderController, appContext *di.ApplicationContext) {
+	providerRoute := router.Group("/providers")
+	providerRoute.Use(middlewares.AuthJWTMiddleware())
+	{
+		providerRoute.GET("/types", controller.GetProviderTypes)
+		providerRoute.POST("/:id/test", controller.TestSend)
+		providerRoute.PUT("/:id/config", controller.UpdateUserProviderConfig)
+		providerRoute.POST("/:id/validate", controller.ValidateUserProviderCredentials)
+
+		// Only admin can set up providers
+		adminCheck := middlewares.RequiresRoleMiddleware("admin", appContext.Logger)
+		providerRoute.GET("/", adminCheck, controller.GetProviders)
+		providerRoute.POST("/", adminCheck, controller.CreateProvider)
+		providerRoute.PUT("/:id", adminCheck, controller.UpdateProvider)
+
+		// Failover drills simulate provider outages for a user
+		providerRoute.POST("/:id/drills", adminCheck, controller.StartDrill)
+		providerRoute.GET("/drills", adminCheck, controller.GetActiveDrills)
+		providerRoute.DELETE("/drills/:id", adminCheck, controller.EndDrill)
+	}
+
+	// Declarative provider setup, e.g. applied by a deployment pipeline, is admin only
+	adminRoute := router.Group("/admin/providers")
+	adminRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		adminRoute.PUT("/by-name/:name", controller.UpsertProvider)
+		adminRoute.POST("/apply", controller.ApplyDesiredState)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/queuesnapshot"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func QueueSnapshotRoutes(router *gin.RouterGroup, controller queuesnapshot.IQueueSnapshotController, appContext *di.ApplicationContext) {
+	snapshotRoute := router.Group("/admin/queue-snapshots")
+	// Snapshots hold the messages of every user and replaying them sends them again, only admins can use them
+	snapshotRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		snapshotRoute.POST("", controller.StartSnapshot)
+		snapshotRoute.GET("", controller.GetSnapshots)
+		snapshotRoute.GET("/:name", controller.DownloadSnapshot)
+		snapshotRoute.POST("/:name/replay", controller.StartReplay)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/retention"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RetentionRoutes(router *gin.RouterGroup, controller retention.IRetentionController, appContext *di.ApplicationContext) {
+	retentionRoute := router.Group("/admin/retention")
+	retentionRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		retentionRoute.GET("", controller.GetPolicy)
+		retentionRoute.PUT("", controller.SetPolicy)
+	}
+}
@@ -20,6 +20,37 @@ func ApplicationRouter(router *gin.Engine, appContext *di.ApplicationContext) {
 
 	AuthRoutes(v1, appContext.AuthController)
 	UserRoutes(v1, appContext.UserController, appContext)
-	SignalRoutes(v1, appContext.SignalController)
-	SendRoutes(v1, appContext.SendController)
+	SignalRoutes(v1, appContext.SignalController, appContext)
+	SendRoutes(v1, appContext.SendController, appContext)
+	DigestRoutes(v1, appContext.DigestController)
+	AnalyticsRoutes(v1, appContext.AnalyticsController)
+	HookRoutes(v1, appContext.HookController)
+	EscalationRoutes(v1, appContext.EscalationController)
+	ProviderRoutes(v1, appContext.ProviderController, appContext)
+	LoginAuditRoutes(v1, appContext.LoginAuditController)
+	DistributionListRoutes(v1, appContext.DistributionListController, appContext)
+	EmailTemplateRoutes(v1, appContext.EmailTemplateController)
+	ContentTransformRoutes(v1, appContext.ContentTransformController)
+	InboundNumberRoutes(v1, appContext.InboundNumberController)
+	ConversationRoutes(v1, appContext.ConversationController, appContext)
+	BulkOperationRoutes(v1, appContext.BulkOperationController, appContext)
+	JobRoutes(v1, appContext.JobController)
+	DeliveryRoutes(v1, appContext.DeliveryController)
+	ActionRoutes(v1, appContext.ActionController)
+	ControlRoutes(v1, appContext.ControlController, appContext)
+	CustomDomainRoutes(v1, appContext.CustomDomainController, appContext)
+	DeactivationRoutes(v1, appContext.DeactivationController, appContext)
+	AuditExportRoutes(v1, appContext.AuditExportController, appContext)
+	ArchiveRoutes(v1, appContext.ArchiveController, appContext)
+	AnonymizeRoutes(v1, appContext.AnonymizeController, appContext)
+	QueueSnapshotRoutes(v1, appContext.QueueSnapshotController, appContext)
+	MaintenanceRoutes(v1, appContext.MaintenanceController, appContext)
+	RetentionRoutes(v1, appContext.RetentionController, appContext)
+	AttachmentRoutes(v1, appContext.AttachmentController)
+	StatusRoutes(v1, appContext.StatusController, appContext)
+	SyncRoutes(v1, appContext.SyncController)
+	BootstrapRoutes(v1, appContext.BootstrapController, appContext)
+
+	ShortLinkRoutes(router, appContext.ShortLinkController)
+	AdminUIRoutes(router, appContext.AdminUIConfig)
 }
@@ -1,17 +1,27 @@
 package routes
 
 import (
+	"go-multi-chat-api/src/infrastructure/di"
 	"go-multi-chat-api/src/infrastructure/rest/controllers/send"
 	"go-multi-chat-api/src/infrastructure/rest/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SendRoutes(router *gin.RouterGroup, controller send.ISendController) {
+func SendRoutes(router *gin.RouterGroup, controller send.ISendController, appContext *di.ApplicationContext) {
 	signalRoute := router.Group("/send")
 	signalRoute.Use(middlewares.AuthJWTMiddleware())
 	{
 		signalRoute.POST("/message", controller.Message)
+		signalRoute.POST("/preview", controller.Preview)
 		signalRoute.GET("/message/:id/status", controller.GetMessageStatus)
+		signalRoute.PUT("/message/:id", controller.EditMessage)
+		signalRoute.GET("/messages", controller.GetMessageHistory)
+		signalRoute.GET("/queue", controller.GetQueueStats)
+
+		// Admins search the messages of any user and see the processor load of each user
+		adminCheck := middlewares.RequiresRoleMiddleware("admin", appContext.Logger)
+		signalRoute.GET("/users/:id/messages", adminCheck, controller.GetUserMessageHistory)
+		signalRoute.GET("/queue/users", adminCheck, controller.GetUserLoads)
 	}
 }
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/shortlink"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShortLinkRoutes serves the short links of tracked messages at the root, keeping them short. Recipients open
+// them without authentication, the links are signed.
+func ShortLinkRoutes(router *gin.Engine, controller shortlink.IShortLinkController) {
+	router.GET("/l/:token", controller.Follow)
+}
@@ -1,19 +1,68 @@
 package routes
 
 import (
+	"go-multi-chat-api/src/infrastructure/di"
 	"go-multi-chat-api/src/infrastructure/rest/controllers/signal"
 	"go-multi-chat-api/src/infrastructure/rest/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SignalRoutes(router *gin.RouterGroup, controller signal.ISignalController) {
+func SignalRoutes(router *gin.RouterGroup, controller signal.ISignalController, appContext *di.ApplicationContext) {
 	signalRoute := router.Group("/signal")
 	signalRoute.Use(middlewares.AuthJWTMiddleware())
 	{
 		signalRoute.POST("/register/:number", controller.RegisterNumber)
 		signalRoute.POST("/register/:number/verify/:token", controller.VerifyRegisteredNumber)
 		signalRoute.GET("/qrcode", controller.GetQrCodeLink)
+
+		// Verification by SMS with the voice call fallback, cooling down between the codes requested for a number
+		verificationController := appContext.VerificationController
+		signalRoute.POST("/verifications/:number", verificationController.StartVerification)
+		signalRoute.GET("/verifications/:number", verificationController.GetVerification)
+		signalRoute.POST("/verifications/:number/verify", verificationController.VerifyVerification)
+
 		signalRoute.POST("/send", controller.Send)
+		signalRoute.DELETE("/messages/:timestamp", appContext.RemoteDeleteController.RemoteDelete)
+		signalRoute.POST("/accounts/:number/resolve", appContext.RecipientController.ResolveRecipients)
+
+		// Group invite links
+		groupLinkController := appContext.GroupLinkController
+		signalRoute.GET("/groups/:number/:groupid/link", groupLinkController.GetInviteLink)
+		signalRoute.POST("/groups/:number/:groupid/link/reset", groupLinkController.ResetInviteLink)
+		signalRoute.GET("/groups/:number/:groupid/link/qrcode", groupLinkController.GetInviteLinkQrCode)
+		signalRoute.POST("/groups/:number/join", groupLinkController.JoinGroup)
+
+		// Registration lock management - only admin can view or change the PIN of a number
+		adminCheck := middlewares.RequiresRoleMiddleware("admin", appContext.Logger)
+		lockController := appContext.RegistrationLockController
+		signalRoute.GET("/accounts/:number/pin", adminCheck, lockController.GetRegistrationLock)
+		signalRoute.POST("/accounts/:number/pin", adminCheck, lockController.SetRegistrationLockPin)
+		signalRoute.DELETE("/accounts/:number/pin", adminCheck, lockController.RemoveRegistrationLockPin)
+
+		// Rate limit challenges - only admin can lift the rate limit of a number
+		challengeController := appContext.RateLimitChallengeController
+		signalRoute.GET("/accounts/:number/rate-limit-challenges", adminCheck, challengeController.GetRateLimitChallenges)
+		signalRoute.POST("/accounts/:number/rate-limit-challenge", adminCheck, challengeController.SubmitRateLimitChallenge)
+
+		// Linked devices - only admin can link the backend to an account as a device
+		deviceLinkController := appContext.DeviceLinkController
+		signalRoute.POST("/devices/link", adminCheck, deviceLinkController.StartDeviceLink)
+		signalRoute.GET("/devices/link", adminCheck, deviceLinkController.GetDeviceLinkAttempt)
+		signalRoute.GET("/devices", adminCheck, deviceLinkController.GetDevices)
+		signalRoute.POST("/accounts/:number/relink", adminCheck, deviceLinkController.RelinkDevice)
+		signalRoute.GET("/accounts/:number/mode", adminCheck, deviceLinkController.GetAccountMode)
+
+		// Number health - only admin can see which numbers need attention
+		numberHealthController := appContext.NumberHealthController
+		signalRoute.GET("/numbers/health", adminCheck, numberHealthController.GetNumberHealth)
+		signalRoute.GET("/accounts/:number/health", adminCheck, numberHealthController.GetNumberHealthOfNumber)
+		signalRoute.POST("/accounts/:number/health/resolve", adminCheck, numberHealthController.ResolveNumberHealth)
+
+		// Receive stream - only admin can watch the messages received by the numbers
+		signalRoute.GET("/receive/stream", adminCheck, appContext.ReceiveStreamController.StreamReceived)
+
+		// Received envelopes - only admin can read the contact cards, payment notifications and reactions received
+		signalRoute.GET("/receive/envelopes", adminCheck, appContext.ReceivedEnvelopeController.GetReceivedEnvelopes)
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/di"
+	"go-multi-chat-api/src/infrastructure/rest/controllers/status"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StatusRoutes(router *gin.RouterGroup, controller status.IStatusController, appContext *di.ApplicationContext) {
+	// The status page is public when enabled, rate limited per client on top of the limit of the whole API
+	if appContext.StatusPageConfig.Enabled {
+		router.GET("/status", middlewares.RateLimit(appContext.StatusPageConfig.RateLimitPerMinute, appContext.StatusPageConfig.RateLimitPerMinute), controller.GetStatus)
+	}
+
+	// Banners are published by admins
+	bannerRoute := router.Group("/admin/status/banners")
+	bannerRoute.Use(middlewares.AuthJWTMiddleware(), middlewares.RequiresRoleMiddleware("admin", appContext.Logger))
+	{
+		bannerRoute.GET("", controller.GetBanners)
+		bannerRoute.POST("", controller.CreateBanner)
+		bannerRoute.PUT("/:id", controller.UpdateBanner)
+		bannerRoute.DELETE("/:id", controller.DeleteBanner)
+	}
+}
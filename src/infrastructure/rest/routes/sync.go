@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"go-multi-chat-api/src/infrastructure/rest/controllers/syncfeed"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SyncRoutes(router *gin.RouterGroup, controller syncfeed.ISyncController) {
+	router.GET("/sync", middlewares.AuthJWTMiddleware(), controller.Sync)
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go-multi-chat-api/src/infrastructure/di"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+	"go-multi-chat-api/src/infrastructure/rest/routes"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Option enables, disables or adds a middleware of the router
+type Option func(*settings)
+
+// settings are the middlewares of a router. Recovery, localization, error handling and the common headers are
+// always on, the API depends on them.
+type settings struct {
+	cors               bool
+	accessLog          bool
+	bodyLog            *middlewares.BodyLogConfig
+	tracing            bool
+	metrics            middlewares.MetricsRecorder
+	rateLimitPerMinute int
+	rateLimitBurst     int
+	accountStatus      func(userID int, issuedAt time.Time) bool
+	externalTokens     func(tokenString string) (jwt.MapClaims, error)
+	custom             []gin.HandlerFunc
+}
+
+func defaultSettings() settings {
+	return settings{cors: true, accessLog: true}
+}
+
+// WithMetrics records every request with the recorder
+func WithMetrics(recorder middlewares.MetricsRecorder) Option {
+	return func(s *settings) {
+		s.metrics = recorder
+	}
+}
+
+// WithTracing propagates W3C trace context and adds the trace ID to the access log
+func WithTracing() Option {
+	return func(s *settings) {
+		s.tracing = true
+	}
+}
+
+// WithRateLimit limits every client IP to perMinute requests with bursts of up to burst requests, 0 disables it
+func WithRateLimit(perMinute int, burst int) Option {
+	return func(s *settings) {
+		s.rateLimitPerMinute = perMinute
+		s.rateLimitBurst = burst
+	}
+}
+
+// WithAccountStatus refuses the tokens of users active reports as deactivated
+func WithAccountStatus(active func(userID int, issuedAt time.Time) bool) Option {
+	return func(s *settings) {
+		s.accountStatus = active
+	}
+}
+
+// WithExternalTokens accepts the access tokens of an external identity provider, claims maps them to the claims
+// of an access token of their user
+func WithExternalTokens(claims func(tokenString string) (jwt.MapClaims, error)) Option {
+	return func(s *settings) {
+		s.externalTokens = claims
+	}
+}
+
+// WithBodyLog logs the scrubbed request and response bodies of the route groups of the config. It buffers the
+// responses it logs in memory, so it is off by default.
+func WithBodyLog(config middlewares.BodyLogConfig) Option {
+	return func(s *settings) {
+		s.bodyLog = &config
+	}
+}
+
+// WithoutCORS leaves out the default CORS middleware, e.g. when a proxy in front handles CORS
+func WithoutCORS() Option {
+	return func(s *settings) {
+		s.cors = false
+	}
+}
+
+// WithoutAccessLog leaves out the log line of every request
+func WithoutAccessLog() Option {
+	return func(s *settings) {
+		s.accessLog = false
+	}
+}
+
+// WithMiddleware adds middlewares of the embedder, they run after the built-in ones and before the routes
+func WithMiddleware(handlers ...gin.HandlerFunc) Option {
+	return func(s *settings) {
+		s.custom = append(s.custom, handlers...)
+	}
+}
+
+// Config holds the middleware settings of the HTTP server
+type Config struct {
+	BodyLog            bool
+	BodyLogConfig      middlewares.BodyLogConfig
+	Tracing            bool
+	RateLimitPerMinute int
+	RateLimitBurst     int
+}
+
+// LoadConfig loads the middleware settings from environment variables
+func LoadConfig() (Config, error) {
+	perMinute, err := utils.GetIntEnv("HTTP_RATE_LIMIT_PER_MINUTE", 0)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid HTTP_RATE_LIMIT_PER_MINUTE: %w", err)
+	}
+	burst, err := utils.GetIntEnv("HTTP_RATE_LIMIT_BURST", perMinute)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid HTTP_RATE_LIMIT_BURST: %w", err)
+	}
+	if perMinute < 0 || burst < 0 {
+		return Config{}, fmt.Errorf("invalid HTTP_RATE_LIMIT_PER_MINUTE or HTTP_RATE_LIMIT_BURST: must not be negative")
+	}
+	bodyLogMaxBytes, err := utils.GetIntEnv("HTTP_BODY_LOG_MAX_BYTES", 4096)
+	if err != nil || bodyLogMaxBytes <= 0 {
+		return Config{}, fmt.Errorf("invalid HTTP_BODY_LOG_MAX_BYTES: must be a positive number")
+	}
+	return Config{
+		BodyLog: utils.GetEnv("HTTP_BODY_LOG_ENABLED", "false") == "true",
+		BodyLogConfig: middlewares.BodyLogConfig{
+			Routes:      envList("HTTP_BODY_LOG_ROUTES"),
+			MaxBytes:    bodyLogMaxBytes,
+			ScrubFields: envList("HTTP_BODY_LOG_SCRUB_FIELDS"),
+		},
+		Tracing:            utils.GetEnv("HTTP_TRACING_ENABLED", "false") == "true",
+		RateLimitPerMinute: perMinute,
+		RateLimitBurst:     burst,
+	}, nil
+}
+
+// envList reads a comma separated list from an environment variable
+func envList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(utils.GetEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Options returns the router options of the settings
+func (c Config) Options() []Option {
+	options := []Option{WithRateLimit(c.RateLimitPerMinute, c.RateLimitBurst)}
+	if c.BodyLog {
+		options = append(options, WithBodyLog(c.BodyLogConfig))
+	}
+	if c.Tracing {
+		options = append(options, WithTracing())
+	}
+	return options
+}
+
+// NewRouter builds the router of the API: the middlewares chosen by the options and every route
+func NewRouter(appContext *di.ApplicationContext, loggerInstance *logger.Logger, options ...Option) *gin.Engine {
+	if appContext.DeactivationUseCase != nil {
+		options = append([]Option{WithAccountStatus(appContext.DeactivationUseCase.Active)}, options...)
+	}
+	if appContext.ExternalTokenValidator != nil {
+		options = append([]Option{WithExternalTokens(appContext.AuthUseCase.ExternalTokenClaims)}, options...)
+	}
+	router := NewEngine(loggerInstance, appContext.UserLocales.UserLocale, options...)
+	routes.ApplicationRouter(router, appContext)
+	return router
+}
+
+// NewEngine builds a router with the middlewares chosen by the options but no routes, for embedders serving
+// routes of their own. userLocale looks up the locale of a user for error messages.
+func NewEngine(loggerInstance *logger.Logger, userLocale func(userID int) string, options ...Option) *gin.Engine {
+	s := defaultSettings()
+	for _, option := range options {
+		option(&s)
+	}
+
+	if utils.GetEnv("GO_ENV", "development") == "development" {
+		loggerInstance.SetupGinWithZapLoggerInDevelopment()
+	} else {
+		loggerInstance.SetupGinWithZapLogger()
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	// Tracing and metrics come first, so they cover the requests the other middlewares reject
+	if s.tracing {
+		router.Use(middlewares.Tracing())
+	}
+	if s.metrics != nil {
+		router.Use(middlewares.Metrics(s.metrics))
+	}
+	if s.cors {
+		router.Use(cors.Default())
+	}
+	router.Use(middlewares.Localization(userLocale))
+	if s.accountStatus != nil {
+		router.Use(middlewares.AccountStatus(s.accountStatus))
+	}
+	if s.externalTokens != nil {
+		router.Use(middlewares.ExternalTokens(s.externalTokens))
+	}
+	router.Use(middlewares.ErrorHandler())
+	if s.bodyLog != nil {
+		router.Use(middlewares.BodyLog(loggerInstance, *s.bodyLog))
+	}
+	router.Use(middlewares.CommonHeaders)
+	if s.accessLog {
+		router.Use(loggerInstance.GinZapLogger())
+	}
+	// Limited requests are still logged
+	if s.rateLimitPerMinute > 0 {
+		router.Use(middlewares.RateLimit(s.rateLimitPerMinute, s.rateLimitBurst))
+	}
+	router.Use(s.custom...)
+	return router
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/rest/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type fakeRecorder struct {
+	requests []recordedRequest
+}
+
+func (r *fakeRecorder) ObserveRequest(method string, route string, status int, _ time.Duration) {
+	r.requests = append(r.requests, recordedRequest{method: method, route: route, status: status})
+}
+
+func newTestEngine(t *testing.T, options ...Option) *gin.Engine {
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+	router := NewEngine(loggerInstance, func(int) string { return "en" }, options...)
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "item")
+	})
+	return router
+}
+
+func get(router *gin.Engine, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestNewEngine_Defaults(t *testing.T) {
+	router := newTestEngine(t)
+
+	w := get(router, "/items/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+	assert.Empty(t, w.Header().Get("traceparent"), "tracing is opt-in")
+}
+
+func TestNewEngine_Options(t *testing.T) {
+	recorder := &fakeRecorder{}
+	var customRan bool
+	router := newTestEngine(t,
+		WithTracing(),
+		WithMetrics(recorder),
+		WithRateLimit(1, 1),
+		WithMiddleware(func(c *gin.Context) {
+			customRan = true
+			c.Next()
+		}),
+	)
+
+	w := get(router, "/items/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("X-Trace-ID"))
+	assert.True(t, customRan)
+
+	w = get(router, "/items/2")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	assert.Equal(t, []recordedRequest{
+		{method: http.MethodGet, route: "/items/:id", status: http.StatusOK},
+		{method: http.MethodGet, route: "/items/:id", status: http.StatusTooManyRequests},
+	}, recorder.requests)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("HTTP_RATE_LIMIT_PER_MINUTE", "120")
+	t.Setenv("HTTP_BODY_LOG_ENABLED", "true")
+	t.Setenv("HTTP_BODY_LOG_ROUTES", "/v1/auth, /v1/send")
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		BodyLog:            true,
+		BodyLogConfig:      middlewares.BodyLogConfig{Routes: []string{"/v1/auth", "/v1/send"}, MaxBytes: 4096},
+		RateLimitPerMinute: 120,
+		RateLimitBurst:     120,
+	}, config)
+	assert.Len(t, config.Options(), 2)
+
+	t.Setenv("HTTP_BODY_LOG_MAX_BYTES", "0")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+	t.Setenv("HTTP_BODY_LOG_MAX_BYTES", "4096")
+
+	t.Setenv("HTTP_RATE_LIMIT_BURST", "-1")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
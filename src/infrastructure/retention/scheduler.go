@@ -0,0 +1,74 @@
+package retention
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/retention"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically queues a message purge job, on the leader instance only
+type Scheduler struct {
+	retentionUseCase retention.IRetentionUseCase
+	elector          leader.Elector
+	Logger           *logger.Logger
+	interval         time.Duration
+	shutdown         chan struct{}
+	done             chan struct{}
+}
+
+// NewScheduler creates a new message purge scheduler and starts it
+func NewScheduler(retentionUseCase retention.IRetentionUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 24 * time.Hour // Default to purging once a day if not specified
+	}
+
+	scheduler := &Scheduler{
+		retentionUseCase: retentionUseCase,
+		elector:          elector,
+		Logger:           loggerInstance,
+		interval:         interval,
+		shutdown:         make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting message purge scheduler", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			s.queuePurge()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) queuePurge() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if _, err := s.retentionUseCase.Start(0); err != nil {
+		s.Logger.Error("Error queueing message purge", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
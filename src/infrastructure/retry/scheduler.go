@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/message"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically retries the failed messages whose retry time passed, through the same or the next
+// provider as the retry policy of their error code decides, on the leader instance only
+type Scheduler struct {
+	messageUseCase message.IMessageUseCase
+	elector        leader.Elector
+	Logger         *logger.Logger
+	interval       time.Duration
+	shutdown       chan struct{}
+	done           chan struct{}
+}
+
+// NewScheduler creates a new failed message retry scheduler and starts it
+func NewScheduler(messageUseCase message.IMessageUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 60 * time.Second // Default to checking every 60 seconds if not specified
+	}
+
+	scheduler := &Scheduler{
+		messageUseCase: messageUseCase,
+		elector:        elector,
+		Logger:         loggerInstance,
+		interval:       interval,
+		shutdown:       make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting failed message retry scheduler", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			s.retryFailedMessages()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) retryFailedMessages() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.messageUseCase.RetryFailedMessages(); err != nil {
+		s.Logger.Error("Error retrying failed messages", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}
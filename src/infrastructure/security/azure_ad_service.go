@@ -12,6 +12,7 @@ import (
 
 	domainErrors "go-multi-chat-api/src/domain/errors"
 	domainUser "go-multi-chat-api/src/domain/user"
+	"go-multi-chat-api/src/infrastructure/httpclient"
 	logger "go-multi-chat-api/src/infrastructure/logger"
 
 	"go.uber.org/zap"
@@ -83,9 +84,7 @@ func NewAzureADService(config AzureADConfig, loggerInstance *logger.Logger) IAzu
 	return &AzureADService{
 		Config: config,
 		Logger: loggerInstance,
-		Client: &http.Client{
-			Timeout: time.Second * 30,
-		},
+		Client: httpclient.New(30 * time.Second),
 	}
 }
 
@@ -0,0 +1,78 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// ICredentialCipher defines the interface for encrypting secrets before they are persisted
+type ICredentialCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// CredentialCipher implements ICredentialCipher with AES-256-GCM
+type CredentialCipher struct {
+	aead cipher.AEAD
+}
+
+// NewCredentialCipher creates a credential cipher from the given secret. The secret is hashed with
+// SHA-256 so any passphrase length results in a valid AES-256 key.
+func NewCredentialCipher(secret string) (ICredentialCipher, error) {
+	if secret == "" {
+		return nil, errors.New("credential encryption key is empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CredentialCipher{aead: aead}, nil
+}
+
+// NewCredentialCipherFromEnv creates a credential cipher using the CREDENTIAL_ENCRYPTION_KEY environment variable
+func NewCredentialCipherFromEnv() (ICredentialCipher, error) {
+	return NewCredentialCipher(getEnvOrDefault("CREDENTIAL_ENCRYPTION_KEY", ""))
+}
+
+// Encrypt encrypts the plaintext and returns the base64 encoded nonce and ciphertext
+func (c *CredentialCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value previously returned by Encrypt
+func (c *CredentialCipher) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,47 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialCipher_EncryptDecrypt(t *testing.T) {
+	credentialCipher, err := NewCredentialCipher("test_secret")
+	require.NoError(t, err)
+
+	ciphertext, err := credentialCipher.Encrypt("123456")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "123456")
+
+	plaintext, err := credentialCipher.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "123456", plaintext)
+
+	// Every encryption uses a fresh nonce
+	otherCiphertext, err := credentialCipher.Encrypt("123456")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, otherCiphertext)
+}
+
+func TestCredentialCipher_WrongKey(t *testing.T) {
+	credentialCipher, err := NewCredentialCipher("test_secret")
+	require.NoError(t, err)
+	otherCipher, err := NewCredentialCipher("other_secret")
+	require.NoError(t, err)
+
+	ciphertext, err := credentialCipher.Encrypt("123456")
+	require.NoError(t, err)
+
+	_, err = otherCipher.Decrypt(ciphertext)
+	assert.Error(t, err)
+
+	_, err = credentialCipher.Decrypt("not-base64!")
+	assert.Error(t, err)
+}
+
+func TestNewCredentialCipher_EmptyKey(t *testing.T) {
+	_, err := NewCredentialCipher("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package security
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// jwksRefreshInterval is how long fetched signing keys are used before they are fetched again. A token signed
+// with an unknown key fetches them at most once per jwksMinRefreshInterval, keys are rotated without notice.
+const (
+	jwksRefreshInterval    = 24 * time.Hour
+	jwksMinRefreshInterval = time.Minute
+)
+
+// ExternalTokenConfig holds the configuration for verifying the access tokens of an external identity provider
+type ExternalTokenConfig struct {
+	// Issuer and Audience are required of every token, the audience is the ID or app ID URI of this API
+	Issuer   string
+	Audience string
+	// JWKSURL serves the public keys the tokens are signed with
+	JWKSURL string
+	// TenantID, when set, is required in the tid claim of every token
+	TenantID string
+}
+
+// AzureADTokenConfig returns the configuration for verifying v2.0 access tokens issued by Azure AD for the API,
+// the audience defaults to the client ID of the app registration
+func AzureADTokenConfig(azureADConfig AzureADConfig, audience string) ExternalTokenConfig {
+	if audience == "" {
+		audience = azureADConfig.ClientID
+	}
+	return ExternalTokenConfig{
+		Issuer:   fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", azureADConfig.TenantID),
+		Audience: audience,
+		JWKSURL:  fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/v2.0/keys", azureADConfig.TenantID),
+		TenantID: azureADConfig.TenantID,
+	}
+}
+
+// ExternalIdentity is the identity an external access token was issued to
+type ExternalIdentity struct {
+	Subject   string
+	Email     string
+	TenantID  string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IExternalTokenValidator defines the interface for verifying the access tokens of an external identity provider
+type IExternalTokenValidator interface {
+	Validate(tokenString string) (*ExternalIdentity, error)
+}
+
+// ExternalTokenValidator verifies RS256 access tokens against the published keys of their issuer
+type ExternalTokenValidator struct {
+	Config ExternalTokenConfig
+	Logger *logger.Logger
+	Client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewExternalTokenValidator creates a new ExternalTokenValidator
+func NewExternalTokenValidator(config ExternalTokenConfig, loggerInstance *logger.Logger) (IExternalTokenValidator, error) {
+	if config.Issuer == "" || config.Audience == "" || config.JWKSURL == "" {
+		return nil, errors.New("external tokens need an issuer, an audience and a JWKS URL")
+	}
+	return &ExternalTokenValidator{
+		Config: config,
+		Logger: loggerInstance,
+		Client: httpclient.New(10 * time.Second),
+		now:    time.Now,
+	}, nil
+}
+
+// Validate verifies the signature, issuer, audience, tenant and lifetime of a token and returns its identity.
+// The email is the email claim, or the preferred_username or upn claim Azure AD sets instead.
+func (v *ExternalTokenValidator) Validate(tokenString string) (*ExternalIdentity, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(kid)
+	})
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.NotAuthenticated)
+	}
+
+	now := v.now().Unix()
+	if !claims.VerifyExpiresAt(now, true) || !claims.VerifyNotBefore(now, false) {
+		return nil, domainErrors.NewAppError(errors.New("token expired or not yet valid"), domainErrors.NotAuthenticated)
+	}
+	if !claims.VerifyIssuer(v.Config.Issuer, true) {
+		return nil, domainErrors.NewAppError(errors.New("invalid token issuer"), domainErrors.NotAuthenticated)
+	}
+	if !claims.VerifyAudience(v.Config.Audience, true) {
+		return nil, domainErrors.NewAppError(errors.New("invalid token audience"), domainErrors.NotAuthenticated)
+	}
+	tenantID, _ := claims["tid"].(string)
+	if v.Config.TenantID != "" && tenantID != v.Config.TenantID {
+		return nil, domainErrors.NewAppError(errors.New("invalid token tenant"), domainErrors.NotAuthenticated)
+	}
+
+	identity := &ExternalIdentity{TenantID: tenantID}
+	identity.Subject, _ = claims["sub"].(string)
+	for _, claim := range []string{"email", "preferred_username", "upn"} {
+		if email, ok := claims[claim].(string); ok && email != "" {
+			identity.Email = email
+			break
+		}
+	}
+	if identity.Email == "" {
+		return nil, domainErrors.NewAppError(errors.New("token has no email claim"), domainErrors.NotAuthenticated)
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		identity.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		identity.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	return identity, nil
+}
+
+// key returns the public key of the key ID, fetching the keys when they are stale or don't have it
+func (v *ExternalTokenValidator) key(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	key, ok := v.keys[kid]
+	stale := now.Sub(v.fetchedAt) > jwksRefreshInterval
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || now.Sub(v.fetchedAt) > jwksMinRefreshInterval {
+		keys, err := v.fetchKeys()
+		if err != nil {
+			v.Logger.Error("Error fetching token signing keys", zap.Error(err), zap.String("url", v.Config.JWKSURL))
+			// Keep verifying with the known keys while the issuer can't be reached
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		v.keys, v.fetchedAt = keys, now
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+type jsonWebKeySet struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// fetchKeys fetches the RSA keys of the JWKS URL by key ID
+func (v *ExternalTokenValidator) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.Client.Get(v.Config.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed with status %d", resp.StatusCode)
+	}
+
+	var keySet jsonWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keySet); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %q: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
@@ -0,0 +1,86 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExternalTokenValidator_Validate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	config := AzureADTokenConfig(AzureADConfig{TenantID: "tenant-1", ClientID: "client-1"}, "")
+	config.JWKSURL = server.URL
+	validator, err := NewExternalTokenValidator(config, &logger.Logger{Log: zap.NewNop()})
+	require.NoError(t, err)
+
+	sign := func(kid string, claims jwt.MapClaims) string {
+		base := jwt.MapClaims{
+			"iss":                "https://login.microsoftonline.com/tenant-1/v2.0",
+			"aud":                "client-1",
+			"tid":                "tenant-1",
+			"sub":                "subject-1",
+			"preferred_username": "jane@example.com",
+			"iat":                time.Now().Unix(),
+			"exp":                time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range claims {
+			base[name] = value
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, base)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	identity, err := validator.Validate(sign("key-1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", identity.Email)
+	assert.Equal(t, "subject-1", identity.Subject)
+	assert.Equal(t, "tenant-1", identity.TenantID)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"issuer":   {"iss": "https://login.microsoftonline.com/tenant-2/v2.0"},
+		"audience": {"aud": "another-api"},
+		"tenant":   {"tid": "tenant-2"},
+		"expired":  {"exp": time.Now().Add(-time.Minute).Unix()},
+		"email":    {"preferred_username": ""},
+	} {
+		_, err := validator.Validate(sign("key-1", claims))
+		assert.Error(t, err, name)
+	}
+
+	// The app's own HS256 tokens and tokens of unknown keys are refused, the keys are fetched once
+	hs256, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1}).SignedString([]byte("secret"))
+	_, err = validator.Validate(hs256)
+	assert.Error(t, err)
+	_, err = validator.Validate(sign("key-2", nil))
+	assert.Error(t, err)
+	assert.Equal(t, 1, fetches)
+}
@@ -24,9 +24,11 @@ type AppToken struct {
 }
 
 type Claims struct {
-	ID   int    `json:"id"`
-	Type string `json:"type"`
-	Role string `json:"role"`
+	ID     int    `json:"id"`
+	Type   string `json:"type"`
+	Role   string `json:"role"`
+	Tenant string `json:"tenant,omitempty"`
+	Org    string `json:"org,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -36,6 +38,12 @@ type JWTConfig struct {
 	RefreshSecret string
 	AccessTime    int64
 	RefreshTime   int64
+	// Issuer, Audience, Tenant and Org are set on every issued token and required of every verified token when
+	// configured, so deployments sharing a secret don't accept each other's tokens
+	Issuer   string
+	Audience string
+	Tenant   string
+	Org      string
 }
 
 // IJWTService defines the interface for JWT operations
@@ -51,7 +59,7 @@ type JWTService struct {
 
 // NewJWTService creates a new JWT service instance
 func NewJWTService() IJWTService {
-	config := loadJWTConfig()
+	config := LoadJWTConfig()
 	return &JWTService{
 		config: config,
 	}
@@ -64,16 +72,38 @@ func NewJWTServiceWithConfig(config JWTConfig) IJWTService {
 	}
 }
 
-// loadJWTConfig loads JWT configuration from environment variables
-func loadJWTConfig() JWTConfig {
+// LoadJWTConfig loads JWT configuration from environment variables
+func LoadJWTConfig() JWTConfig {
 	return JWTConfig{
 		AccessSecret:  getEnvOrDefault("JWT_ACCESS_SECRET_KEY", "default_access_secret"),
 		RefreshSecret: getEnvOrDefault("JWT_REFRESH_SECRET_KEY", "default_refresh_secret"),
 		AccessTime:    getEnvAsInt64OrDefault("JWT_ACCESS_TIME_MINUTE", 60),
 		RefreshTime:   getEnvAsInt64OrDefault("JWT_REFRESH_TIME_HOUR", 24),
+		Issuer:        os.Getenv("JWT_ISSUER"),
+		Audience:      os.Getenv("JWT_AUDIENCE"),
+		Tenant:        os.Getenv("JWT_TENANT"),
+		Org:           os.Getenv("JWT_ORG"),
 	}
 }
 
+// VerifyClaims checks the issuer, audience, tenant and org of the claims of a token against the configured ones,
+// claims that aren't configured aren't checked
+func (c JWTConfig) VerifyClaims(claims jwt.MapClaims) error {
+	if c.Issuer != "" && !claims.VerifyIssuer(c.Issuer, true) {
+		return errors.New("invalid token issuer")
+	}
+	if c.Audience != "" && !claims.VerifyAudience(c.Audience, true) {
+		return errors.New("invalid token audience")
+	}
+	if c.Tenant != "" && claims["tenant"] != c.Tenant {
+		return errors.New("invalid token tenant")
+	}
+	if c.Org != "" && claims["org"] != c.Org {
+		return errors.New("invalid token org")
+	}
+	return nil
+}
+
 // GenerateJWTToken generates a JWT token for the given user ID, type, and role
 func (s *JWTService) GenerateJWTToken(userID int, tokenType string, role string) (*AppToken, error) {
 	var secretKey string
@@ -94,13 +124,21 @@ func (s *JWTService) GenerateJWTToken(userID int, tokenType string, role string)
 	expirationTokenTime := nowTime.Add(duration)
 
 	tokenClaims := &Claims{
-		ID:   userID,
-		Type: tokenType,
-		Role: role,
+		ID:     userID,
+		Type:   tokenType,
+		Role:   role,
+		Tenant: s.config.Tenant,
+		Org:    s.config.Org,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.config.Issuer,
 			ExpiresAt: jwt.NewNumericDate(expirationTokenTime),
+			// Deactivating a user revokes the tokens issued before
+			IssuedAt: jwt.NewNumericDate(nowTime),
 		},
 	}
+	if s.config.Audience != "" {
+		tokenClaims.Audience = jwt.ClaimStrings{s.config.Audience}
+	}
 	tokenWithClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims)
 
 	tokenStr, err := tokenWithClaims.SignedString([]byte(secretKey))
@@ -148,6 +186,10 @@ func (s *JWTService) GetClaimsAndVerifyToken(tokenString string, tokenType strin
 		return nil, domainErrors.NewAppError(errors.New("invalid token type"), domainErrors.NotAuthenticated)
 	}
 
+	if err := s.config.VerifyClaims(claims); err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.NotAuthenticated)
+	}
+
 	expVal, ok := claims["exp"]
 	if !ok || expVal == nil {
 		return nil, domainErrors.NewAppError(errors.New("token missing expiration (exp) claim"), domainErrors.NotAuthenticated)
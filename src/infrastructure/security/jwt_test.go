@@ -35,7 +35,7 @@ func TestLoadJWTConfig(t *testing.T) {
 	os.Setenv("JWT_ACCESS_TIME_MINUTE", "45")
 	os.Setenv("JWT_REFRESH_TIME_HOUR", "48")
 
-	config := loadJWTConfig()
+	config := LoadJWTConfig()
 	assert.Equal(t, "custom_access_secret", config.AccessSecret)
 	assert.Equal(t, "custom_refresh_secret", config.RefreshSecret)
 	assert.Equal(t, int64(45), config.AccessTime)
@@ -601,4 +601,38 @@ func TestGetClaimsAndVerifyToken_InvalidClaimsType(t *testing.T) {
 	result, err := service.GetClaimsAndVerifyToken(tokenString, Access)
 	assert.Error(t, err)
 	assert.Nil(t, result)
-}
\ No newline at end of file
+}
+func TestGetClaimsAndVerifyToken_IssuerAudienceTenant(t *testing.T) {
+	config := JWTConfig{
+		AccessSecret:  "test_access_secret",
+		RefreshSecret: "test_refresh_secret",
+		AccessTime:    30,
+		RefreshTime:   24,
+		Issuer:        "go-multi-chat-api",
+		Audience:      "chat-api",
+		Tenant:        "acme",
+		Org:           "ops",
+	}
+	service := NewJWTServiceWithConfig(config)
+
+	token, err := service.GenerateJWTToken(123, Access, "member")
+	require.NoError(t, err)
+	claims, err := service.GetClaimsAndVerifyToken(token.Token, Access)
+	require.NoError(t, err)
+	assert.Equal(t, "go-multi-chat-api", claims["iss"])
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, "ops", claims["org"])
+
+	// A token of another deployment sharing the secret is refused
+	for _, other := range []JWTConfig{
+		{AccessSecret: config.AccessSecret, AccessTime: 30, Issuer: "other", Audience: "chat-api", Tenant: "acme", Org: "ops"},
+		{AccessSecret: config.AccessSecret, AccessTime: 30, Issuer: "go-multi-chat-api", Audience: "other", Tenant: "acme", Org: "ops"},
+		{AccessSecret: config.AccessSecret, AccessTime: 30, Issuer: "go-multi-chat-api", Audience: "chat-api", Tenant: "globex", Org: "ops"},
+		{AccessSecret: config.AccessSecret, AccessTime: 30},
+	} {
+		token, err := NewJWTServiceWithConfig(other).GenerateJWTToken(123, Access, "member")
+		require.NoError(t, err)
+		_, err = service.GetClaimsAndVerifyToken(token.Token, Access)
+		assert.Error(t, err, other)
+	}
+}
@@ -0,0 +1,102 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// userSecretPrefix marks the values encrypted by a UserSecretCipher, values stored before secrets were encrypted
+// don't have it
+const userSecretPrefix = "enc:v1:"
+
+// IUserSecretCipher defines the interface for encrypting the secrets of a user before they are persisted
+type IUserSecretCipher interface {
+	Encrypt(userID int, plaintext string) (string, error)
+	Decrypt(userID int, ciphertext string) (string, error)
+}
+
+// UserSecretCipher implements IUserSecretCipher with AES-256-GCM and a key per user, derived from the credential
+// encryption key. The user is also bound as additional data, so a secret copied to the row of another user can't
+// be decrypted.
+type UserSecretCipher struct {
+	masterKey [32]byte
+}
+
+// NewUserSecretCipher creates a user secret cipher from the given secret, hashed with SHA-256 like the credential
+// cipher
+func NewUserSecretCipher(secret string) (IUserSecretCipher, error) {
+	if secret == "" {
+		return nil, errors.New("credential encryption key is empty")
+	}
+	return &UserSecretCipher{masterKey: sha256.Sum256([]byte(secret))}, nil
+}
+
+// NewUserSecretCipherFromEnv creates a user secret cipher using the CREDENTIAL_ENCRYPTION_KEY environment variable
+func NewUserSecretCipherFromEnv() (IUserSecretCipher, error) {
+	return NewUserSecretCipher(getEnvOrDefault("CREDENTIAL_ENCRYPTION_KEY", ""))
+}
+
+// IsEncryptedUserSecret reports whether a stored value was encrypted by a UserSecretCipher
+func IsEncryptedUserSecret(value string) bool {
+	return strings.HasPrefix(value, userSecretPrefix)
+}
+
+// Encrypt encrypts the plaintext with the key of the user and returns the prefixed, base64 encoded nonce and
+// ciphertext
+func (c *UserSecretCipher) Encrypt(userID int, plaintext string) (string, error) {
+	aead, err := c.userAEAD(userID)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(strconv.Itoa(userID)))
+	return userSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value previously returned by Encrypt for the same user
+func (c *UserSecretCipher) Decrypt(userID int, ciphertext string) (string, error) {
+	if !IsEncryptedUserSecret(ciphertext) {
+		return "", errors.New("value is not an encrypted user secret")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, userSecretPrefix))
+	if err != nil {
+		return "", err
+	}
+	aead, err := c.userAEAD(userID)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(strconv.Itoa(userID)))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// userAEAD derives the key of a user as the HMAC-SHA256 of the user id keyed with the master key
+func (c *UserSecretCipher) userAEAD(userID int) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, c.masterKey[:])
+	mac.Write([]byte("user-secret:" + strconv.Itoa(userID)))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
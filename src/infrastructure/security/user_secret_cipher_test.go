@@ -0,0 +1,46 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSecretCipher_EncryptDecrypt(t *testing.T) {
+	userCipher, err := NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+
+	ciphertext, err := userCipher.Encrypt(7, "hook-secret")
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedUserSecret(ciphertext))
+	assert.NotContains(t, ciphertext, "hook-secret")
+
+	plaintext, err := userCipher.Decrypt(7, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "hook-secret", plaintext)
+
+	// The secret of one user can't be decrypted as the secret of another
+	_, err = userCipher.Decrypt(8, ciphertext)
+	assert.Error(t, err)
+}
+
+func TestUserSecretCipher_RejectsOtherValues(t *testing.T) {
+	userCipher, err := NewUserSecretCipher("test_secret")
+	require.NoError(t, err)
+	otherCipher, err := NewUserSecretCipher("other_secret")
+	require.NoError(t, err)
+
+	ciphertext, err := userCipher.Encrypt(7, "hook-secret")
+	require.NoError(t, err)
+	_, err = otherCipher.Decrypt(7, ciphertext)
+	assert.Error(t, err)
+
+	// Plaintext stored before secrets were encrypted isn't decrypted
+	assert.False(t, IsEncryptedUserSecret("3f9a0c"))
+	_, err = userCipher.Decrypt(7, "3f9a0c")
+	assert.Error(t, err)
+
+	_, err = NewUserSecretCipher("")
+	assert.Error(t, err)
+}
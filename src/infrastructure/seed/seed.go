@@ -0,0 +1,392 @@
+package seed
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-multi-chat-api/src/infrastructure/i18n"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	"go-multi-chat-api/src/infrastructure/providerconfig"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	userRepo "go-multi-chat-api/src/infrastructure/repository/mysql/user"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// demoFixtures is the demo dataset loaded with SEED_DEMO in development
+//
+//go:embed demo.yaml
+var demoFixtures []byte
+
+// roles are the roles users can have
+var roles = []string{"admin", "member"}
+
+// Config holds the seed settings
+type Config struct {
+	// File is the YAML fixture file applied on startup, empty to apply none
+	File string
+	// Demo applies the demo dataset, only allowed in development
+	Demo bool
+}
+
+// LoadConfig loads the seed settings from environment variables
+func LoadConfig() (Config, error) {
+	config := Config{
+		File: utils.GetEnv("SEED_FILE", ""),
+		Demo: utils.GetEnv("SEED_DEMO", "false") == "true",
+	}
+	if env := utils.GetEnv("GO_ENV", "development"); config.Demo && env != "development" {
+		return Config{}, fmt.Errorf("invalid SEED_DEMO: the demo dataset is only allowed in development, GO_ENV is %q", env)
+	}
+	return config, nil
+}
+
+// Fixtures are the users, providers and user providers of a seed file. Roles are assigned with the role of
+// each user.
+type Fixtures struct {
+	Users         []User         `yaml:"users"`
+	Providers     []Provider     `yaml:"providers"`
+	UserProviders []UserProvider `yaml:"user_providers"`
+}
+
+// User is a user of a seed file, with a plain password or a bcrypt hash of it
+type User struct {
+	Email              string `yaml:"email"`
+	UserName           string `yaml:"user_name"`
+	FirstName          string `yaml:"first_name"`
+	LastName           string `yaml:"last_name"`
+	Password           string `yaml:"password"`
+	PasswordHash       string `yaml:"password_hash"`
+	Role               string `yaml:"role"`
+	Locale             string `yaml:"locale"`
+	EngagementTracking bool   `yaml:"engagement_tracking"`
+	Status             *bool  `yaml:"status"`
+	MessageRateLimit   int    `yaml:"message_rate_limit"`
+}
+
+// Provider is a provider of a seed file, its config is validated against the schema of its type
+type Provider struct {
+	Name        string                 `yaml:"name"`
+	Type        string                 `yaml:"type"`
+	Description string                 `yaml:"description"`
+	Config      map[string]interface{} `yaml:"config"`
+	Status      *bool                  `yaml:"status"`
+}
+
+// UserProvider links a user, by email, to a provider, by name. Both may be in the seed file or in the database.
+type UserProvider struct {
+	User     string                 `yaml:"user"`
+	Provider string                 `yaml:"provider"`
+	Priority int                    `yaml:"priority"`
+	Config   map[string]interface{} `yaml:"config"`
+	Status   *bool                  `yaml:"status"`
+}
+
+// Parse decodes and validates fixtures, rejecting unknown fields so typos don't go unnoticed
+func Parse(data []byte) (*Fixtures, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var fixtures Fixtures
+	if err := decoder.Decode(&fixtures); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid seed file: %w", err)
+	}
+	if err := fixtures.Validate(); err != nil {
+		return nil, err
+	}
+	return &fixtures, nil
+}
+
+// LoadFile reads and parses a seed file
+func LoadFile(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read seed file %s: %w", path, err)
+	}
+	fixtures, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// Demo returns the demo dataset
+func Demo() (*Fixtures, error) {
+	return Parse(demoFixtures)
+}
+
+// Load returns the fixtures the config asks for, the demo dataset first. It returns nothing when seeding is disabled.
+func (c Config) Load() ([]*Fixtures, error) {
+	var all []*Fixtures
+	if c.Demo {
+		fixtures, err := Demo()
+		if err != nil {
+			return nil, fmt.Errorf("demo dataset: %w", err)
+		}
+		all = append(all, fixtures)
+	}
+	if c.File != "" {
+		fixtures, err := LoadFile(c.File)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, fixtures)
+	}
+	return all, nil
+}
+
+// Validate reports the first invalid entry of the fixtures
+func (f *Fixtures) Validate() error {
+	emails := make(map[string]bool)
+	userNames := make(map[string]bool)
+	for i, u := range f.Users {
+		field := fmt.Sprintf("users[%d]", i)
+		switch {
+		case u.Email == "":
+			return fmt.Errorf("%s: email is required", field)
+		case emails[u.Email]:
+			return fmt.Errorf("%s: duplicate email %s", field, u.Email)
+		case u.UserName == "":
+			return fmt.Errorf("%s: user_name is required", field)
+		case userNames[u.UserName]:
+			return fmt.Errorf("%s: duplicate user_name %s", field, u.UserName)
+		case (u.Password == "") == (u.PasswordHash == ""):
+			return fmt.Errorf("%s: exactly one of password and password_hash is required", field)
+		case u.Role != "" && !contains(roles, u.Role):
+			return fmt.Errorf("%s: role must be one of %s", field, strings.Join(roles, ", "))
+		case u.Locale != "" && !i18n.IsSupported(u.Locale):
+			return fmt.Errorf("%s: locale must be one of %s", field, strings.Join(i18n.Supported(), ", "))
+		case u.MessageRateLimit < 0:
+			return fmt.Errorf("%s: message_rate_limit must be at least 0", field)
+		}
+		if u.PasswordHash != "" {
+			if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+				return fmt.Errorf("%s: password_hash is not a bcrypt hash", field)
+			}
+		}
+		emails[u.Email] = true
+		userNames[u.UserName] = true
+	}
+
+	names := make(map[string]bool)
+	for i, p := range f.Providers {
+		field := fmt.Sprintf("providers[%d]", i)
+		switch {
+		case p.Name == "":
+			return fmt.Errorf("%s: name is required", field)
+		case names[p.Name]:
+			return fmt.Errorf("%s: duplicate name %s", field, p.Name)
+		case p.Type == "":
+			return fmt.Errorf("%s: type is required", field)
+		}
+		config, err := configJSON(p.Config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if err := schemasOf(p.Type).ProviderSchema.Validate(config); err != nil {
+			return fmt.Errorf("%s: invalid config: %w", field, err)
+		}
+		names[p.Name] = true
+	}
+
+	links := make(map[string]bool)
+	for i, up := range f.UserProviders {
+		field := fmt.Sprintf("user_providers[%d]", i)
+		switch {
+		case up.User == "":
+			return fmt.Errorf("%s: user is required", field)
+		case up.Provider == "":
+			return fmt.Errorf("%s: provider is required", field)
+		case links[up.User+"\x00"+up.Provider]:
+			return fmt.Errorf("%s: duplicate user provider %s of %s", field, up.Provider, up.User)
+		}
+		config, err := configJSON(up.Config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		for _, p := range f.Providers {
+			if p.Name == up.Provider {
+				if err := schemasOf(p.Type).UserProviderSchema.Validate(config); err != nil {
+					return fmt.Errorf("%s: invalid config: %w", field, err)
+				}
+			}
+		}
+		links[up.User+"\x00"+up.Provider] = true
+	}
+	return nil
+}
+
+// Apply creates the users, providers and user providers of the fixtures that don't exist yet, in one
+// transaction. Existing rows are matched by email, provider name and user and provider, and left as they are,
+// so changes made through the API survive restarts.
+func Apply(db *gorm.DB, fixtures *Fixtures, loggerInstance *logger.Logger) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, u := range fixtures.Users {
+			if err := applyUser(tx, u, loggerInstance); err != nil {
+				return err
+			}
+		}
+		for _, p := range fixtures.Providers {
+			if err := applyProvider(tx, p, loggerInstance); err != nil {
+				return err
+			}
+		}
+		for _, up := range fixtures.UserProviders {
+			if err := applyUserProvider(tx, up, loggerInstance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func applyUser(tx *gorm.DB, u User, loggerInstance *logger.Logger) error {
+	exists, err := found(tx.Where("email = ?", u.Email).Take(&userRepo.User{}))
+	if err != nil || exists {
+		return err
+	}
+
+	hash := u.PasswordHash
+	if hash == "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("couldn't hash the password of %s: %w", u.Email, err)
+		}
+		hash = string(hashed)
+	}
+	model := userRepo.User{
+		Email:              u.Email,
+		UserName:           u.UserName,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		HashPassword:       hash,
+		Role:               u.Role,
+		Locale:             u.Locale,
+		EngagementTracking: u.EngagementTracking,
+		Status:             enabled(u.Status),
+		MessageRateLimit:   u.MessageRateLimit,
+	}
+	if model.Role == "" {
+		model.Role = "member"
+	}
+	if model.MessageRateLimit == 0 {
+		model.MessageRateLimit = 1000
+	}
+	if err := tx.Create(&model).Error; err != nil {
+		return fmt.Errorf("couldn't create user %s: %w", u.Email, err)
+	}
+	loggerInstance.Info("Seeded user", zap.String("email", u.Email), zap.String("role", model.Role))
+	return nil
+}
+
+func applyProvider(tx *gorm.DB, p Provider, loggerInstance *logger.Logger) error {
+	exists, err := found(tx.Where("name = ?", p.Name).Take(&providerRepo.Provider{}))
+	if err != nil || exists {
+		return err
+	}
+
+	config, err := configJSON(p.Config)
+	if err != nil {
+		return err
+	}
+	model := providerRepo.Provider{
+		Name:        p.Name,
+		Type:        p.Type,
+		Description: p.Description,
+		Config:      config,
+		Status:      enabled(p.Status),
+	}
+	if err := tx.Create(&model).Error; err != nil {
+		return fmt.Errorf("couldn't create provider %s: %w", p.Name, err)
+	}
+	loggerInstance.Info("Seeded provider", zap.String("provider", p.Name), zap.String("type", p.Type))
+	return nil
+}
+
+func applyUserProvider(tx *gorm.DB, up UserProvider, loggerInstance *logger.Logger) error {
+	var u userRepo.User
+	if err := tx.Where("email = ?", up.User).Take(&u).Error; err != nil {
+		return fmt.Errorf("couldn't find user %s of user provider %s: %w", up.User, up.Provider, err)
+	}
+	var p providerRepo.Provider
+	if err := tx.Where("name = ?", up.Provider).Take(&p).Error; err != nil {
+		return fmt.Errorf("couldn't find provider %s of user %s: %w", up.Provider, up.User, err)
+	}
+	exists, err := found(tx.Where("user_id = ? AND provider_id = ?", u.ID, p.ID).Take(&providerRepo.UserProvider{}))
+	if err != nil || exists {
+		return err
+	}
+
+	config, err := configJSON(up.Config)
+	if err != nil {
+		return err
+	}
+	model := providerRepo.UserProvider{
+		UserID:     u.ID,
+		ProviderID: p.ID,
+		Priority:   up.Priority,
+		Config:     config,
+		Status:     enabled(up.Status),
+	}
+	if model.Priority == 0 {
+		model.Priority = 1
+	}
+	if err := tx.Create(&model).Error; err != nil {
+		return fmt.Errorf("couldn't create user provider %s of %s: %w", up.Provider, up.User, err)
+	}
+	loggerInstance.Info("Seeded user provider", zap.String("email", up.User), zap.String("provider", up.Provider))
+	return nil
+}
+
+// found reports whether a lookup found a row, turning only unexpected errors into an error
+func found(result *gorm.DB) (bool, error) {
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return true, nil
+}
+
+// configJSON encodes a config of a seed file the way configs are stored, empty when none is given
+func configJSON(config map[string]interface{}) (string, error) {
+	if len(config) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid config: %w", err)
+	}
+	return string(data), nil
+}
+
+// schemasOf returns the config schemas of a provider type, the generic ones for types without their own
+func schemasOf(providerType string) *providerconfig.ProviderType {
+	if t, ok := providerconfig.Lookup(providerType); ok {
+		return t
+	}
+	return providerconfig.Generic()
+}
+
+func enabled(status *bool) bool {
+	return status == nil || *status
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
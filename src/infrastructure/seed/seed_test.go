@@ -0,0 +1,123 @@
+package seed
+
+import (
+	"regexp"
+	"testing"
+
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestDemo_IsValid(t *testing.T) {
+	fixtures, err := Demo()
+	require.NoError(t, err)
+	assert.NotEmpty(t, fixtures.Users)
+	assert.NotEmpty(t, fixtures.Providers)
+	assert.NotEmpty(t, fixtures.UserProviders)
+}
+
+func TestParse(t *testing.T) {
+	fixtures, err := Parse([]byte(`
+users:
+  - email: ops@example.com
+    user_name: ops
+    password: secret
+    role: admin
+providers:
+  - name: Mail
+    type: email
+    config: {from: ops@example.com, host: smtp.example.com, port: 587}
+user_providers:
+  - user: ops@example.com
+    provider: Mail
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "admin", fixtures.Users[0].Role)
+	config, err := configJSON(fixtures.Providers[0].Config)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"from": "ops@example.com", "host": "smtp.example.com", "port": 587}`, config)
+
+	empty, err := Parse(nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty.Users)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown field", "users:\n  - email: a@example.com\n    username: a\n", "field username not found"},
+		{"missing password", "users:\n  - {email: a@example.com, user_name: a}\n", "users[0]: exactly one of password and password_hash is required"},
+		{"unknown role", "users:\n  - {email: a@example.com, user_name: a, password: x, role: owner}\n", "users[0]: role must be one of admin, member"},
+		{"bad hash", "users:\n  - {email: a@example.com, user_name: a, password_hash: plain}\n", "users[0]: password_hash is not a bcrypt hash"},
+		{"duplicate email", "users:\n  - {email: a@example.com, user_name: a, password: x}\n  - {email: a@example.com, user_name: b, password: x}\n", "users[1]: duplicate email"},
+		{"missing type", "providers:\n  - {name: Mail}\n", "providers[0]: type is required"},
+		{"invalid config", "providers:\n  - {name: Mail, type: email, config: {host: smtp.example.com}}\n", "providers[0]: invalid config"},
+		{"missing provider", "user_providers:\n  - {user: a@example.com}\n", "user_providers[0]: provider is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("SEED_FILE", "seed.yaml")
+	t.Setenv("SEED_DEMO", "true")
+	t.Setenv("GO_ENV", "development")
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{File: "seed.yaml", Demo: true}, config)
+
+	t.Setenv("GO_ENV", "production")
+	_, err = LoadConfig()
+	assert.ErrorContains(t, err, "only allowed in development")
+}
+
+func TestApply_SkipsExistingRows(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	require.NoError(t, err)
+	loggerInstance, err := logger.NewLogger()
+	require.NoError(t, err)
+
+	fixtures := &Fixtures{
+		Users:         []User{{Email: "ops@example.com", UserName: "ops", Password: "secret"}},
+		Providers:     []Provider{{Name: "Mail", Type: "email"}},
+		UserProviders: []UserProvider{{User: "ops@example.com", Provider: "Mail"}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE email = ?")).
+		WithArgs("ops@example.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(3, "ops@example.com"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `providers` WHERE name = ?")).
+		WithArgs("Mail", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `providers`")).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE email = ?")).
+		WithArgs("ops@example.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(3, "ops@example.com"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `providers` WHERE name = ?")).
+		WithArgs("Mail", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Mail"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_providers` WHERE user_id = ? AND provider_id = ?")).
+		WithArgs(3, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	require.NoError(t, Apply(db, fixtures, loggerInstance))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
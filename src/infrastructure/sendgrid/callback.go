@@ -0,0 +1,154 @@
+package sendgrid
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+const (
+	// SignatureHeader carries the signature of the event webhooks SendGrid sends
+	SignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	// TimestampHeader carries the time the event webhook was signed, it is part of the signed data
+	TimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+	// MessageIDArg is the custom argument emails are sent with to match their events to the message transaction
+	MessageIDArg = "message_id"
+)
+
+// ErrInvalidSignature is returned for event webhooks that weren't signed with the key of the provider
+var ErrInvalidSignature = errors.New("invalid sendgrid signature")
+
+// deliveryStatuses maps the SendGrid events reporting on the delivery of an email to delivery statuses, deferred
+// deliveries are retried by SendGrid and engagement events other than opens are not reported
+var deliveryStatuses = map[string]string{
+	"processed": providerRepo.DeliveryStatusSent,
+	"delivered": providerRepo.DeliveryStatusDelivered,
+	"open":      providerRepo.DeliveryStatusRead,
+	"bounce":    providerRepo.DeliveryStatusFailed,
+	"dropped":   providerRepo.DeliveryStatusFailed,
+}
+
+// Config is the event webhook of an email provider sending through SendGrid, stored in the provider config
+type Config struct {
+	EventWebhookPublicKey string `json:"event_webhook_public_key"`
+}
+
+// ParseConfig reads the event webhook settings from the config of a provider
+func ParseConfig(providerConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid sendgrid config: %w", err)
+		}
+	}
+	if config.EventWebhookPublicKey == "" {
+		return Config{}, errors.New("sendgrid config needs an event_webhook_public_key to verify delivery callbacks")
+	}
+	return config, nil
+}
+
+// ValidSignature reports whether an event webhook was signed by SendGrid. The signature is an ECDSA signature of
+// the timestamp followed by the raw body, verified with the public key shown in the SendGrid event webhook settings.
+func ValidSignature(publicKey string, timestamp string, body []byte, signature string) bool {
+	keyData, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return false
+	}
+	key, err := x509.ParsePKIXPublicKey(keyData)
+	if err != nil {
+		return false
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	signatureData, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	return ecdsa.VerifyASN1(ecdsaKey, digest[:], signatureData)
+}
+
+// event is a SendGrid event, custom arguments of the email are merged into it
+type event struct {
+	Email     string          `json:"email"`
+	Event     string          `json:"event"`
+	Reason    string          `json:"reason"`
+	Status    string          `json:"status"`
+	MessageID json.RawMessage `json:"message_id"`
+}
+
+// DeliveryCallbacks reads the event webhooks SendGrid posts for the emails of providers sending through SendGrid
+type DeliveryCallbacks struct{}
+
+// NewDeliveryCallbacks creates a new reader of SendGrid event webhooks
+func NewDeliveryCallbacks() *DeliveryCallbacks {
+	return &DeliveryCallbacks{}
+}
+
+// ParseCallback verifies an event webhook with the public key of the provider and returns the delivery statuses
+// of its events. Events are matched to the message by the message_id custom argument and the recipient.
+func (d *DeliveryCallbacks) ParseCallback(providerConfig string, callbackURL string, header http.Header, body []byte) ([]domainProvider.DeliveryUpdate, error) {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !ValidSignature(config.EventWebhookPublicKey, header.Get(TimestampHeader), body, header.Get(SignatureHeader)) {
+		return nil, domainErrors.NewAppError(ErrInvalidSignature, domainErrors.NotAuthenticated)
+	}
+
+	var events []event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, domainErrors.NewAppError(fmt.Errorf("invalid sendgrid events: %w", err), domainErrors.ValidationError)
+	}
+	updates := make([]domainProvider.DeliveryUpdate, 0, len(events))
+	for _, e := range events {
+		status, ok := deliveryStatuses[e.Event]
+		messageID := messageTransactionID(e.MessageID)
+		if !ok || messageID == 0 {
+			continue
+		}
+		update := domainProvider.DeliveryUpdate{
+			MessageTransactionID: messageID,
+			Recipient:            e.Email,
+			Status:               status,
+		}
+		if status == providerRepo.DeliveryStatusFailed {
+			update.ErrorCode = e.Status
+			update.ErrorMessage = e.Reason
+			if update.ErrorMessage == "" {
+				update.ErrorMessage = "sendgrid reported the email " + e.Event
+			}
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// messageTransactionID reads the message_id custom argument, which SendGrid passes on as a string or a number
+func messageTransactionID(raw json.RawMessage) int {
+	var value interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &value) != nil {
+		return 0
+	}
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		id, _ := strconv.Atoi(v)
+		return id
+	}
+	return 0
+}
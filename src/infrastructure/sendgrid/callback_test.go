@@ -0,0 +1,70 @@
+package sendgrid
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedHeader signs a body like SendGrid signs its event webhooks
+func signedHeader(t *testing.T, key *ecdsa.PrivateKey, timestamp string, body []byte) http.Header {
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(signature))
+	header.Set(TimestampHeader, timestamp)
+	return header
+}
+
+func setupKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, `{"from":"ops@example.com","event_webhook_public_key":"` + base64.StdEncoding.EncodeToString(publicKey) + `"}`
+}
+
+func TestDeliveryCallbacks_ParseCallback(t *testing.T) {
+	key, config := setupKey(t)
+	body := []byte(`[
+		{"email":"a@example.com","event":"delivered","message_id":"42","sg_message_id":"abc.filter0001"},
+		{"email":"b@example.com","event":"bounce","message_id":42,"status":"5.1.1","reason":"mailbox unavailable"},
+		{"email":"c@example.com","event":"deferred","message_id":"42"},
+		{"email":"d@example.com","event":"delivered"}
+	]`)
+
+	updates, err := NewDeliveryCallbacks().ParseCallback(config, "", signedHeader(t, key, "1760000000", body), body)
+	require.NoError(t, err)
+	assert.Equal(t, []domainProvider.DeliveryUpdate{
+		{MessageTransactionID: 42, Recipient: "a@example.com", Status: "delivered"},
+		{MessageTransactionID: 42, Recipient: "b@example.com", Status: "failed", ErrorCode: "5.1.1", ErrorMessage: "mailbox unavailable"},
+	}, updates)
+}
+
+func TestDeliveryCallbacks_RejectsInvalidSignature(t *testing.T) {
+	key, config := setupKey(t)
+	body := []byte(`[{"email":"a@example.com","event":"delivered","message_id":"42"}]`)
+	header := signedHeader(t, key, "1760000000", body)
+
+	// The timestamp is part of the signed data
+	header.Set(TimestampHeader, "1760000001")
+	_, err := NewDeliveryCallbacks().ParseCallback(config, "", header, body)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+
+	_, err = NewDeliveryCallbacks().ParseCallback(`{"from":"ops@example.com"}`, "", header, body)
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+package shortlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Path is the path short links are served at, below the base URL
+	Path = "/l/"
+	// signatureLength is the number of HMAC bytes kept in a token, enough that links can't be guessed
+	signatureLength = 9
+	minSecretLength = 16
+)
+
+// urlPattern finds the http and https URLs of a message
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// ErrInvalidToken is returned for tokens that weren't signed with the secret
+var ErrInvalidToken = errors.New("invalid short link")
+
+// Config controls link tracking, it is enabled when BaseURL is set
+type Config struct {
+	// BaseURL is the public base URL short links are served below, e.g. https://go.example.com
+	BaseURL string
+	// Secret signs the short links, so they can't be guessed or altered
+	Secret string
+}
+
+// LoadConfig loads the link tracking settings from environment variables
+func LoadConfig() (Config, error) {
+	config := Config{
+		BaseURL: strings.TrimSuffix(utils.GetEnv("SHORT_LINK_BASE_URL", ""), "/"),
+		Secret:  utils.GetEnv("SHORT_LINK_SECRET", ""),
+	}
+	if config.BaseURL != "" && len(config.Secret) < minSecretLength {
+		return Config{}, fmt.Errorf("invalid SHORT_LINK_SECRET: must be at least %d characters when SHORT_LINK_BASE_URL is set", minSecretLength)
+	}
+	return config, nil
+}
+
+// Enabled reports whether messages can track links
+func (c Config) Enabled() bool {
+	return c.BaseURL != ""
+}
+
+// Token identifies the short link of one recipient for a tracked URL
+type Token struct {
+	LinkID         int
+	RecipientIndex int // index of the recipient in the recipients of the message
+}
+
+// Sign encodes a token as <link id>-<recipient index>.<signature>, the ids in base 36
+func (c Config) Sign(token Token) string {
+	payload := strconv.FormatInt(int64(token.LinkID), 36) + "-" + strconv.FormatInt(int64(token.RecipientIndex), 36)
+	return payload + "." + c.signature(payload)
+}
+
+// Parse verifies a signed token and decodes it
+func (c Config) Parse(signed string) (Token, error) {
+	payload, signature, ok := strings.Cut(signed, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.signature(payload))) {
+		return Token{}, ErrInvalidToken
+	}
+	link, recipient, ok := strings.Cut(payload, "-")
+	linkID, linkErr := strconv.ParseInt(link, 36, 64)
+	recipientIndex, recipientErr := strconv.ParseInt(recipient, 36, 64)
+	if !ok || linkErr != nil || recipientErr != nil {
+		return Token{}, ErrInvalidToken
+	}
+	return Token{LinkID: int(linkID), RecipientIndex: int(recipientIndex)}, nil
+}
+
+// URL returns the short link of a token
+func (c Config) URL(token Token) string {
+	return c.BaseURL + Path + c.Sign(token)
+}
+
+func (c Config) signature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(c.Secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureLength])
+}
+
+// FindURLs returns the URLs of a message in the order they appear, punctuation ending a sentence isn't
+// part of a URL
+func FindURLs(message string) [][]int {
+	matches := urlPattern.FindAllStringIndex(message, -1)
+	for _, match := range matches {
+		for match[1] > match[0] && strings.ContainsRune(".,;:!?)]}", rune(message[match[1]-1])) {
+			// A closing parenthesis belongs to URLs like https://en.wikipedia.org/wiki/Go_(game)
+			if message[match[1]-1] == ')' && strings.Count(message[match[0]:match[1]], "(") >= strings.Count(message[match[0]:match[1]], ")") {
+				break
+			}
+			match[1]--
+		}
+	}
+	return matches
+}
+
+// DomainResolver returns the verified custom domain of a user, or an empty string if they have none
+type DomainResolver interface {
+	VerifiedDomain(userID int) (string, error)
+}
+
+// Tracker replaces the URLs of messages with signed short links for each recipient
+type Tracker struct {
+	config     Config
+	repository providerRepo.ShortLinkRepositoryInterface
+	domains    DomainResolver
+	Logger     *logger.Logger
+}
+
+// NewTracker creates a new Tracker. The short links of users with a verified custom domain are served on that
+// domain when domains is set, the others on the base URL.
+func NewTracker(config Config, repository providerRepo.ShortLinkRepositoryInterface, domains DomainResolver, loggerInstance *logger.Logger) *Tracker {
+	return &Tracker{config: config, repository: repository, domains: domains, Logger: loggerInstance}
+}
+
+// Enabled reports whether messages can track links
+func (t *Tracker) Enabled() bool {
+	return t.config.Enabled()
+}
+
+// Track stores the URLs of a message so they can be sent as short links, returning how many it found. Links
+// of the tracker itself are left alone.
+func (t *Tracker) Track(msg *provider.MessageTransaction) (int, error) {
+	baseURL := t.baseURL(msg.UserID)
+	var links []provider.ShortLink
+	for i, match := range FindURLs(msg.Message) {
+		url := msg.Message[match[0]:match[1]]
+		if strings.HasPrefix(url, t.config.BaseURL+Path) || strings.HasPrefix(url, baseURL+Path) {
+			continue
+		}
+		links = append(links, provider.ShortLink{
+			MessageID:  msg.ID,
+			UserID:     msg.UserID,
+			Index:      i,
+			URL:        url,
+			Recipients: msg.Recipients,
+		})
+	}
+	if _, err := t.repository.CreateBatch(links); err != nil {
+		return 0, err
+	}
+	return len(links), nil
+}
+
+// Shorten replaces the URLs of a message of a user with short links shared by all its recipients, before the
+// message is stored. Clicks on them are recorded without a recipient. Links of the tracker itself are left alone.
+func (t *Tracker) Shorten(userID int, message string) (string, error) {
+	baseURL := t.baseURL(userID)
+	matches := FindURLs(message)
+	var links []provider.ShortLink
+	var shortened [][]int
+	for i, match := range matches {
+		url := message[match[0]:match[1]]
+		if strings.HasPrefix(url, t.config.BaseURL+Path) || strings.HasPrefix(url, baseURL+Path) {
+			continue
+		}
+		links = append(links, provider.ShortLink{UserID: userID, Index: i, URL: url})
+		shortened = append(shortened, match)
+	}
+	created, err := t.repository.CreateBatch(links)
+	if err != nil {
+		return "", err
+	}
+
+	config := t.config
+	config.BaseURL = baseURL
+	var text strings.Builder
+	end := 0
+	for i, match := range shortened {
+		text.WriteString(message[end:match[0]])
+		text.WriteString(config.URL(Token{LinkID: (*created)[i].ID}))
+		end = match[1]
+	}
+	text.WriteString(message[end:])
+	return text.String(), nil
+}
+
+// Personalize returns the text of a message for each of its recipients, with the tracked URLs replaced by the
+// short links of the recipient
+func (t *Tracker) Personalize(messageID int, message string, recipients []string) ([]string, error) {
+	links, err := t.repository.GetByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	byIndex := make(map[int]provider.ShortLink, len(*links))
+	for _, link := range *links {
+		byIndex[link.Index] = link
+	}
+
+	config := t.config
+	if len(*links) > 0 {
+		config.BaseURL = t.baseURL((*links)[0].UserID)
+	}
+	matches := FindURLs(message)
+	texts := make([]string, len(recipients))
+	for recipientIndex := range recipients {
+		var text strings.Builder
+		end := 0
+		for i, match := range matches {
+			link, ok := byIndex[i]
+			if !ok || link.URL != message[match[0]:match[1]] {
+				continue
+			}
+			text.WriteString(message[end:match[0]])
+			text.WriteString(config.URL(Token{LinkID: link.ID, RecipientIndex: recipientIndex}))
+			end = match[1]
+		}
+		text.WriteString(message[end:])
+		texts[recipientIndex] = text.String()
+	}
+	return texts, nil
+}
+
+// baseURL returns the base URL the short links of a user are served below, the custom domain of the user if it
+// was verified. Links fall back to the base URL if the domain can't be looked up.
+func (t *Tracker) baseURL(userID int) string {
+	if t.domains == nil {
+		return t.config.BaseURL
+	}
+	domain, err := t.domains.VerifiedDomain(userID)
+	if err != nil {
+		t.Logger.Error("Error getting custom domain of short links", zap.Error(err), zap.Int("userID", userID))
+		return t.config.BaseURL
+	}
+	if domain == "" {
+		return t.config.BaseURL
+	}
+	return "https://" + domain
+}
+
+// ClickStats summarizes the clicks on the tracked links of a message
+func (t *Tracker) ClickStats(messageID int) (*provider.LinkClickStats, error) {
+	return t.repository.GetMessageClickStats(messageID)
+}
+
+// Resolve verifies a short link token and returns the tracked URL and the recipient it was sent to
+func (t *Tracker) Resolve(signed string) (*provider.ShortLink, string, error) {
+	token, err := t.config.Parse(signed)
+	if err != nil {
+		return nil, "", err
+	}
+	link, err := t.repository.GetByID(token.LinkID)
+	if err != nil {
+		return nil, "", err
+	}
+	if link.Recipients == "" && token.RecipientIndex == 0 {
+		// A link shortened before its message was stored is shared by the recipients
+		return link, "", nil
+	}
+	var recipients []string
+	if err := json.Unmarshal([]byte(link.Recipients), &recipients); err != nil || token.RecipientIndex >= len(recipients) {
+		t.Logger.Warn("Short link refers to an unknown recipient", zap.Int("linkID", link.ID), zap.Int("recipientIndex", token.RecipientIndex))
+		return nil, "", ErrInvalidToken
+	}
+	return link, recipients[token.RecipientIndex], nil
+}
@@ -0,0 +1,187 @@
+package shortlink
+
+import (
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockShortLinkRepository struct {
+	links []provider.ShortLink
+}
+
+func (m *mockShortLinkRepository) CreateBatch(links []provider.ShortLink) (*[]provider.ShortLink, error) {
+	for _, link := range links {
+		link.ID = len(m.links) + 1
+		m.links = append(m.links, link)
+	}
+	return &m.links, nil
+}
+
+func (m *mockShortLinkRepository) GetByID(id int) (*provider.ShortLink, error) {
+	for _, link := range m.links {
+		if link.ID == id {
+			return &link, nil
+		}
+	}
+	return &provider.ShortLink{}, domainErrors.NewAppErrorWithType(domainErrors.NotFound)
+}
+
+func (m *mockShortLinkRepository) GetByMessageID(messageID int) (*[]provider.ShortLink, error) {
+	var links []provider.ShortLink
+	for _, link := range m.links {
+		if link.MessageID == messageID {
+			links = append(links, link)
+		}
+	}
+	return &links, nil
+}
+
+func (m *mockShortLinkRepository) RecordClick(click *provider.LinkClick) error {
+	return nil
+}
+
+func (m *mockShortLinkRepository) GetMessageClickStats(messageID int) (*provider.LinkClickStats, error) {
+	return &provider.LinkClickStats{}, nil
+}
+
+func (m *mockShortLinkRepository) CountUserClicks(userID int, from time.Time, to time.Time) (int, int, error) {
+	return 0, 0, nil
+}
+
+func setupLogger(t *testing.T) *logger.Logger {
+	loggerInstance, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return loggerInstance
+}
+
+var testConfig = Config{BaseURL: "https://go.example.com", Secret: "0123456789abcdef"}
+
+func TestSignAndParse(t *testing.T) {
+	token := Token{LinkID: 12345, RecipientIndex: 7}
+	signed := testConfig.Sign(token)
+
+	parsed, err := testConfig.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, token, parsed)
+	assert.Equal(t, "https://go.example.com/l/"+signed, testConfig.URL(token))
+
+	t.Run("Altered tokens are rejected", func(t *testing.T) {
+		_, err := testConfig.Parse("9ix-8" + signed[len("9ix-7"):])
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Tokens of another secret are rejected", func(t *testing.T) {
+		other := Config{BaseURL: testConfig.BaseURL, Secret: "fedcba9876543210"}
+		_, err := testConfig.Parse(other.Sign(token))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Malformed tokens are rejected", func(t *testing.T) {
+		for _, signed := range []string{"", "abc", "abc.", ".abc"} {
+			_, err := testConfig.Parse(signed)
+			assert.ErrorIs(t, err, ErrInvalidToken, signed)
+		}
+	})
+}
+
+func TestFindURLs(t *testing.T) {
+	cases := map[string][]string{
+		"No links here":                                  nil,
+		"See https://example.com/a?b=c.":                 {"https://example.com/a?b=c"},
+		"Open http://example.com, then https://x.io/y!":  {"http://example.com", "https://x.io/y"},
+		"(details at https://example.com/status)":        {"https://example.com/status"},
+		"Read https://en.wikipedia.org/wiki/Go_(game).":  {"https://en.wikipedia.org/wiki/Go_(game)"},
+		"Link: <https://example.com/path> and more text": {"https://example.com/path"},
+	}
+	for message, expected := range cases {
+		var urls []string
+		for _, match := range FindURLs(message) {
+			urls = append(urls, message[match[0]:match[1]])
+		}
+		assert.Equal(t, expected, urls, message)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	repository := &mockShortLinkRepository{}
+	tracker := NewTracker(testConfig, repository, nil, setupLogger(t))
+	message := "Status: https://status.example.com. Already short: https://go.example.com/l/1-0.abc Docs: https://docs.example.com"
+	msg := &provider.MessageTransaction{ID: 3, UserID: 9, Message: message, Recipients: `["+491111","+492222"]`}
+
+	count, err := tracker.Track(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 0, repository.links[0].Index)
+	assert.Equal(t, 2, repository.links[1].Index)
+
+	texts, err := tracker.Personalize(3, message, []string{"+491111", "+492222"})
+	assert.NoError(t, err)
+	assert.Len(t, texts, 2)
+	assert.NotEqual(t, texts[0], texts[1])
+	assert.NotContains(t, texts[1], "https://status.example.com")
+	assert.Contains(t, texts[1], "Status: "+testConfig.URL(Token{LinkID: 1, RecipientIndex: 1})+". Already short: https://go.example.com/l/1-0.abc")
+	assert.Contains(t, texts[1], "Docs: "+testConfig.URL(Token{LinkID: 2, RecipientIndex: 1}))
+
+	link, recipient, err := tracker.Resolve(testConfig.Sign(Token{LinkID: 2, RecipientIndex: 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://docs.example.com", link.URL)
+	assert.Equal(t, "+492222", recipient)
+
+	_, _, err = tracker.Resolve(testConfig.Sign(Token{LinkID: 2, RecipientIndex: 5}))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+type mockDomainResolver map[int]string
+
+func (m mockDomainResolver) VerifiedDomain(userID int) (string, error) {
+	return m[userID], nil
+}
+
+func TestTrackerCustomDomain(t *testing.T) {
+	repository := &mockShortLinkRepository{}
+	tracker := NewTracker(testConfig, repository, mockDomainResolver{9: "links.acme.com"}, setupLogger(t))
+	customConfig := Config{BaseURL: "https://links.acme.com", Secret: testConfig.Secret}
+
+	message := "Docs: https://docs.example.com Already short: https://links.acme.com/l/1-0.abc"
+	count, err := tracker.Track(&provider.MessageTransaction{ID: 3, UserID: 9, Message: message, Recipients: `["+491111"]`})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	texts, err := tracker.Personalize(3, message, []string{"+491111"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Docs: "+customConfig.URL(Token{LinkID: 1, RecipientIndex: 0})+" Already short: https://links.acme.com/l/1-0.abc", texts[0])
+
+	// Users without a verified domain keep the base URL
+	_, err = tracker.Track(&provider.MessageTransaction{ID: 4, UserID: 5, Message: "https://docs.example.com", Recipients: `["+491111"]`})
+	assert.NoError(t, err)
+	texts, err = tracker.Personalize(4, "https://docs.example.com", []string{"+491111"})
+	assert.NoError(t, err)
+	assert.Equal(t, testConfig.URL(Token{LinkID: 2, RecipientIndex: 0}), texts[0])
+}
+
+func TestTrackerShorten(t *testing.T) {
+	repository := &mockShortLinkRepository{}
+	tracker := NewTracker(testConfig, repository, nil, setupLogger(t))
+
+	text, err := tracker.Shorten(9, "Status: https://status.example.com. Already short: https://go.example.com/l/1-0.abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "Status: "+testConfig.URL(Token{LinkID: 1})+". Already short: https://go.example.com/l/1-0.abc", text)
+	assert.Equal(t, 0, repository.links[0].MessageID)
+
+	// The link is shared by the recipients, clicks have no recipient
+	link, recipient, err := tracker.Resolve(testConfig.Sign(Token{LinkID: 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://status.example.com", link.URL)
+	assert.Empty(t, recipient)
+
+	_, _, err = tracker.Resolve(testConfig.Sign(Token{LinkID: 1, RecipientIndex: 1}))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
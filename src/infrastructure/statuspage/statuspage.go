@@ -0,0 +1,84 @@
+// Package statuspage configures the public status page, which reports the coarse health of the provider types
+// and the incident banners of the admins, e.g. for teams embedding it into their own status pages.
+package statuspage
+
+import (
+	"fmt"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/utils"
+)
+
+// Config controls the status page
+type Config struct {
+	// Enabled serves the status page without authentication, it is off by default
+	Enabled            bool
+	RateLimitPerMinute int
+	// Window is how far back the processed messages are counted for the health of a provider type
+	Window time.Duration
+	// CacheTTL is how long the reported status is reused, so requests to the page don't query the database
+	CacheTTL time.Duration
+	// MinMessages is the number of messages below which a provider type is reported operational, too few
+	// failures say nothing about its health
+	MinMessages            int
+	DegradedFailurePercent int
+	OutageFailurePercent   int
+}
+
+// LoadConfig loads the status page settings from environment variables
+func LoadConfig() (Config, error) {
+	config := Config{Enabled: utils.GetEnv("STATUS_PAGE_ENABLED", "false") == "true"}
+	settings := []struct {
+		key          string
+		defaultValue int
+		target       *int
+	}{
+		{"STATUS_PAGE_RATE_LIMIT_PER_MINUTE", 60, &config.RateLimitPerMinute},
+		{"STATUS_PAGE_MIN_MESSAGES", 10, &config.MinMessages},
+		{"STATUS_PAGE_DEGRADED_FAILURE_PERCENT", 10, &config.DegradedFailurePercent},
+		{"STATUS_PAGE_OUTAGE_FAILURE_PERCENT", 50, &config.OutageFailurePercent},
+	}
+	for _, setting := range settings {
+		value, err := utils.GetIntEnv(setting.key, setting.defaultValue)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		if value <= 0 {
+			return Config{}, fmt.Errorf("invalid %s: must be positive", setting.key)
+		}
+		*setting.target = value
+	}
+	if config.DegradedFailurePercent > config.OutageFailurePercent || config.OutageFailurePercent > 100 {
+		return Config{}, fmt.Errorf("invalid STATUS_PAGE_DEGRADED_FAILURE_PERCENT or STATUS_PAGE_OUTAGE_FAILURE_PERCENT: must be ordered and at most 100")
+	}
+
+	window, err := utils.GetIntEnv("STATUS_PAGE_WINDOW_MINUTES", 15)
+	if err != nil || window <= 0 {
+		return Config{}, fmt.Errorf("invalid STATUS_PAGE_WINDOW_MINUTES: must be a positive number")
+	}
+	cacheTTL, err := utils.GetIntEnv("STATUS_PAGE_CACHE_SECONDS", 60)
+	if err != nil || cacheTTL < 0 {
+		return Config{}, fmt.Errorf("invalid STATUS_PAGE_CACHE_SECONDS: must not be negative")
+	}
+	config.Window = time.Duration(window) * time.Minute
+	config.CacheTTL = time.Duration(cacheTTL) * time.Second
+	return config, nil
+}
+
+// Health rates a provider type by the share of its messages that failed within the window
+func (c Config) Health(outcomes provider.ProviderTypeOutcomes) string {
+	total := outcomes.Sent + outcomes.Failed
+	if total == 0 || total < c.MinMessages {
+		return provider.HealthOperational
+	}
+	failedPercent := outcomes.Failed * 100 / total
+	switch {
+	case failedPercent >= c.OutageFailurePercent:
+		return provider.HealthOutage
+	case failedPercent >= c.DegradedFailurePercent:
+		return provider.HealthDegraded
+	default:
+		return provider.HealthOperational
+	}
+}
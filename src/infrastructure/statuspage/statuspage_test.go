@@ -0,0 +1,39 @@
+package statuspage
+
+import (
+	"testing"
+	"time"
+
+	"go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.False(t, config.Enabled)
+	assert.Equal(t, 15*time.Minute, config.Window)
+	assert.Equal(t, time.Minute, config.CacheTTL)
+
+	t.Setenv("STATUS_PAGE_DEGRADED_FAILURE_PERCENT", "60")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+
+	t.Setenv("STATUS_PAGE_DEGRADED_FAILURE_PERCENT", "10")
+	t.Setenv("STATUS_PAGE_WINDOW_MINUTES", "0")
+	_, err = LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestConfig_Health(t *testing.T) {
+	config := Config{MinMessages: 10, DegradedFailurePercent: 10, OutageFailurePercent: 50}
+
+	assert.Equal(t, provider.HealthOperational, config.Health(provider.ProviderTypeOutcomes{}))
+	// Too few messages to judge
+	assert.Equal(t, provider.HealthOperational, config.Health(provider.ProviderTypeOutcomes{Failed: 5}))
+	assert.Equal(t, provider.HealthOperational, config.Health(provider.ProviderTypeOutcomes{Sent: 91, Failed: 9}))
+	assert.Equal(t, provider.HealthDegraded, config.Health(provider.ProviderTypeOutcomes{Sent: 90, Failed: 10}))
+	assert.Equal(t, provider.HealthOutage, config.Health(provider.ProviderTypeOutcomes{Sent: 5, Failed: 5}))
+}
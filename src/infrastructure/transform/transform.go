@@ -0,0 +1,328 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxTransforms is the most transformations a user can have
+	MaxTransforms = 10
+	// maxResponseSize bounds the body read from an external transformer
+	maxResponseSize = 1 << 20
+	// defaultSignatureSeparator separates a signature from the message, a blank line
+	defaultSignatureSeparator = "\n\n"
+)
+
+// shortcodePattern finds emoji shortcodes like :thumbsup: or :+1:
+var shortcodePattern = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+
+// emojis are the built-in shortcodes of the emoji transformation
+var emojis = map[string]string{
+	"smile":            "😄",
+	"grin":             "😁",
+	"joy":              "😂",
+	"wink":             "😉",
+	"blush":            "😊",
+	"heart_eyes":       "😍",
+	"thinking":         "🤔",
+	"neutral_face":     "😐",
+	"cry":              "😢",
+	"sob":              "😭",
+	"angry":            "😠",
+	"scream":           "😱",
+	"sweat_smile":      "😅",
+	"thumbsup":         "👍",
+	"+1":               "👍",
+	"thumbsdown":       "👎",
+	"-1":               "👎",
+	"ok_hand":          "👌",
+	"clap":             "👏",
+	"wave":             "👋",
+	"pray":             "🙏",
+	"muscle":           "💪",
+	"raised_hands":     "🙌",
+	"eyes":             "👀",
+	"heart":            "❤️",
+	"broken_heart":     "💔",
+	"fire":             "🔥",
+	"star":             "⭐",
+	"sparkles":         "✨",
+	"tada":             "🎉",
+	"rocket":           "🚀",
+	"warning":          "⚠️",
+	"rotating_light":   "🚨",
+	"bell":             "🔔",
+	"check":            "✔️",
+	"white_check_mark": "✅",
+	"x":                "❌",
+	"question":         "❓",
+	"exclamation":      "❗",
+	"calendar":         "📅",
+	"clock":            "🕒",
+	"hourglass":        "⌛",
+	"phone":            "📱",
+	"email":            "📧",
+	"link":             "🔗",
+	"lock":             "🔒",
+	"key":              "🔑",
+	"package":          "📦",
+	"truck":            "🚚",
+	"moneybag":         "💰",
+	"chart":            "📈",
+	"memo":             "📝",
+	"bulb":             "💡",
+	"coffee":           "☕",
+}
+
+// Shortener replaces the URLs of a message of a user with short links before it is stored
+type Shortener interface {
+	Enabled() bool
+	Shorten(userID int, message string) (string, error)
+}
+
+// EmojiConfig are the settings of the emoji transformation
+type EmojiConfig struct {
+	// Shortcodes are expanded next to the built-in ones and replace them, without the colons
+	Shortcodes map[string]string `json:"shortcodes,omitempty"`
+}
+
+// SignatureConfig are the settings of the signature transformation
+type SignatureConfig struct {
+	Text string `json:"text"`
+	// Separator goes between the message and the signature, a blank line when empty
+	Separator string `json:"separator,omitempty"`
+}
+
+// HTTPConfig are the settings of the external HTTP transformation
+type HTTPConfig struct {
+	URL string `json:"url"`
+	// Headers are sent with every request, e.g. to authenticate against the transformer
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// HTTPRequest is posted to an external transformer, it answers with an HTTPResponse
+type HTTPRequest struct {
+	UserID  int    `json:"user_id"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// HTTPResponse is the answer of an external transformer
+type HTTPResponse struct {
+	Message string `json:"message"`
+}
+
+// Error is the failure of a transformation of a message, the message isn't stored then
+type Error struct {
+	Position int
+	Type     string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("content transform %d (%s) failed: %v", e.Position, e.Type, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline applies the content transformations of users to their messages
+type Pipeline struct {
+	repository providerRepo.ContentTransformRepositoryInterface
+	shortener  Shortener
+	client     *http.Client
+	Logger     *logger.Logger
+}
+
+// NewPipeline creates a new Pipeline. shortener may be nil, URLs can't be shortened then. External transformers
+// are given timeout to answer.
+func NewPipeline(repository providerRepo.ContentTransformRepositoryInterface, shortener Shortener, timeout time.Duration, loggerInstance *logger.Logger) *Pipeline {
+	return &Pipeline{
+		repository: repository,
+		shortener:  shortener,
+		client:     httpclient.New(timeout),
+		Logger:     loggerInstance,
+	}
+}
+
+// Transform applies the enabled transformations of a user to a message in their order. On a dry run the
+// transformations that store something leave the message alone, URLs aren't shortened.
+func (p *Pipeline) Transform(userID int, messageType string, message string, dryRun bool) (string, error) {
+	transforms, err := p.repository.GetByUserID(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range *transforms {
+		if t.Disabled {
+			continue
+		}
+		transformed, err := p.apply(&t, messageType, message, dryRun)
+		if err != nil {
+			p.Logger.Warn("Content transform failed",
+				zap.Error(err),
+				zap.Int("userID", userID),
+				zap.Int("position", t.Position),
+				zap.String("type", t.Type))
+			return "", &Error{Position: t.Position, Type: t.Type, Err: err}
+		}
+		message = transformed
+	}
+	return message, nil
+}
+
+// Validate checks the type and the config of a transformation
+func (p *Pipeline) Validate(t *provider.ContentTransform) error {
+	var err error
+	switch t.Type {
+	case provider.ContentTransformEmoji:
+		_, err = decodeConfig[EmojiConfig](t.Config)
+	case provider.ContentTransformShortenURLs:
+		if p.shortener == nil || !p.shortener.Enabled() {
+			err = errors.New("short links aren't configured, set SHORT_LINK_BASE_URL")
+		}
+	case provider.ContentTransformSignature:
+		var config *SignatureConfig
+		if config, err = decodeConfig[SignatureConfig](t.Config); err == nil && strings.TrimSpace(config.Text) == "" {
+			err = errors.New("config.text is required")
+		}
+	case provider.ContentTransformHTTP:
+		var config *HTTPConfig
+		if config, err = decodeConfig[HTTPConfig](t.Config); err == nil {
+			err = validateURL(config.URL)
+		}
+	default:
+		err = fmt.Errorf("type must be one of %s, %s, %s, %s", provider.ContentTransformEmoji,
+			provider.ContentTransformShortenURLs, provider.ContentTransformSignature, provider.ContentTransformHTTP)
+	}
+	if err != nil {
+		return domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	return nil
+}
+
+func (p *Pipeline) apply(t *provider.ContentTransform, messageType string, message string, dryRun bool) (string, error) {
+	switch t.Type {
+	case provider.ContentTransformEmoji:
+		config, err := decodeConfig[EmojiConfig](t.Config)
+		if err != nil {
+			return "", err
+		}
+		return ExpandEmoji(message, config.Shortcodes), nil
+	case provider.ContentTransformShortenURLs:
+		if dryRun {
+			return message, nil
+		}
+		if p.shortener == nil || !p.shortener.Enabled() {
+			return "", errors.New("short links aren't configured")
+		}
+		return p.shortener.Shorten(t.UserID, message)
+	case provider.ContentTransformSignature:
+		config, err := decodeConfig[SignatureConfig](t.Config)
+		if err != nil {
+			return "", err
+		}
+		return AppendSignature(message, config), nil
+	case provider.ContentTransformHTTP:
+		config, err := decodeConfig[HTTPConfig](t.Config)
+		if err != nil {
+			return "", err
+		}
+		return p.callTransformer(config, &HTTPRequest{UserID: t.UserID, Type: messageType, Message: message})
+	}
+	return "", fmt.Errorf("unknown type %s", t.Type)
+}
+
+// callTransformer posts a message to an external transformer and returns the message it answered with
+func (p *Pipeline) callTransformer(config *HTTPConfig, request *HTTPRequest) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("transformer answered with status %d", resp.StatusCode)
+	}
+	var response HTTPResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&response); err != nil {
+		return "", fmt.Errorf("invalid transformer response: %w", err)
+	}
+	if strings.TrimSpace(response.Message) == "" {
+		return "", errors.New("transformer answered with an empty message")
+	}
+	return response.Message, nil
+}
+
+// ExpandEmoji replaces the emoji shortcodes of a message, shortcodes that aren't known are left alone
+func ExpandEmoji(message string, shortcodes map[string]string) string {
+	return shortcodePattern.ReplaceAllStringFunc(message, func(code string) string {
+		name := code[1 : len(code)-1]
+		if emoji, ok := shortcodes[name]; ok {
+			return emoji
+		}
+		if emoji, ok := emojis[name]; ok {
+			return emoji
+		}
+		return code
+	})
+}
+
+// AppendSignature appends a signature to a message
+func AppendSignature(message string, config *SignatureConfig) string {
+	separator := config.Separator
+	if separator == "" {
+		separator = defaultSignatureSeparator
+	}
+	return message + separator + config.Text
+}
+
+// decodeConfig decodes the config of a transformation, an empty config decodes to the zero settings
+func decodeConfig[T any](config string) (*T, error) {
+	var settings T
+	if strings.TrimSpace(config) == "" {
+		return &settings, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(config))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &settings, nil
+}
+
+func validateURL(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("config.url must be an absolute http or https URL")
+	}
+	return nil
+}
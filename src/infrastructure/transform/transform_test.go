@@ -0,0 +1,137 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	"go-multi-chat-api/src/domain/provider"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockContentTransformRepository struct {
+	transforms []provider.ContentTransform
+}
+
+func (m *mockContentTransformRepository) GetByUserID(userID int) (*[]provider.ContentTransform, error) {
+	return &m.transforms, nil
+}
+
+func (m *mockContentTransformRepository) ReplaceForUser(userID int, transforms []provider.ContentTransform) (*[]provider.ContentTransform, error) {
+	m.transforms = transforms
+	return &m.transforms, nil
+}
+
+type mockShortener struct {
+	calls int
+}
+
+func (m *mockShortener) Enabled() bool {
+	return true
+}
+
+func (m *mockShortener) Shorten(userID int, message string) (string, error) {
+	m.calls++
+	return "short: " + message, nil
+}
+
+func setupPipeline(transforms ...provider.ContentTransform) (*Pipeline, *mockShortener) {
+	for i := range transforms {
+		transforms[i].UserID, transforms[i].Position = 7, i+1
+	}
+	shortener := &mockShortener{}
+	pipeline := NewPipeline(&mockContentTransformRepository{transforms: transforms}, shortener, time.Second, &logger.Logger{Log: zap.NewNop()})
+	return pipeline, shortener
+}
+
+func TestTransform_AppliesInOrder(t *testing.T) {
+	pipeline, shortener := setupPipeline(
+		provider.ContentTransform{Type: provider.ContentTransformEmoji, Config: `{"shortcodes":{"ship":"🚢"}}`},
+		provider.ContentTransform{Type: provider.ContentTransformSignature, Config: `{"text":"-- Ops"}`, Disabled: true},
+		provider.ContentTransform{Type: provider.ContentTransformShortenURLs},
+		provider.ContentTransform{Type: provider.ContentTransformSignature, Config: `{"text":"-- Acme","separator":"\n"}`},
+	)
+
+	message, err := pipeline.Transform(7, "signal", "Deployed :rocket: :ship: :unknown:", false)
+	require.NoError(t, err)
+	assert.Equal(t, "short: Deployed 🚀 🚢 :unknown:\n-- Acme", message)
+	assert.Equal(t, 1, shortener.calls)
+
+	// A dry run doesn't store short links
+	message, err = pipeline.Transform(7, "signal", "Deployed", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Deployed\n-- Acme", message)
+	assert.Equal(t, 1, shortener.calls)
+}
+
+func TestTransform_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var request HTTPRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, 7, request.UserID)
+		assert.Equal(t, "sms", request.Type)
+		if request.Message == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(HTTPResponse{Message: "[" + request.Message + "]"})
+	}))
+	defer server.Close()
+	config := `{"url":"` + server.URL + `","headers":{"Authorization":"Bearer token"}}`
+	pipeline, _ := setupPipeline(provider.ContentTransform{Type: provider.ContentTransformHTTP, Config: config})
+
+	message, err := pipeline.Transform(7, "sms", "hello", false)
+	require.NoError(t, err)
+	assert.Equal(t, "[hello]", message)
+
+	_, err = pipeline.Transform(7, "sms", "fail", false)
+	var transformErr *Error
+	require.True(t, errors.As(err, &transformErr))
+	assert.Equal(t, 1, transformErr.Position)
+	assert.Equal(t, provider.ContentTransformHTTP, transformErr.Type)
+	assert.Contains(t, err.Error(), "status 500")
+}
+
+func TestValidate(t *testing.T) {
+	pipeline, _ := setupPipeline()
+	tests := []struct {
+		name      string
+		transform provider.ContentTransform
+		want      string
+	}{
+		{"emoji without config", provider.ContentTransform{Type: provider.ContentTransformEmoji}, ""},
+		{"shorten urls", provider.ContentTransform{Type: provider.ContentTransformShortenURLs}, ""},
+		{"unknown type", provider.ContentTransform{Type: "translate"}, "type must be one of"},
+		{"signature without text", provider.ContentTransform{Type: provider.ContentTransformSignature, Config: `{"separator":" "}`}, "config.text is required"},
+		{"unknown setting", provider.ContentTransform{Type: provider.ContentTransformSignature, Config: `{"txt":"x"}`}, "invalid config"},
+		{"relative url", provider.ContentTransform{Type: provider.ContentTransformHTTP, Config: `{"url":"/transform"}`}, "config.url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pipeline.Validate(&tt.transform)
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var appErr *domainErrors.AppError
+			require.True(t, errors.As(err, &appErr))
+			assert.Equal(t, domainErrors.ValidationError, appErr.Type)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+
+	t.Run("shorten urls without short links", func(t *testing.T) {
+		pipeline := NewPipeline(&mockContentTransformRepository{}, nil, time.Second, &logger.Logger{Log: zap.NewNop()})
+		err := pipeline.Validate(&provider.ContentTransform{Type: provider.ContentTransformShortenURLs})
+		assert.ErrorContains(t, err, "SHORT_LINK_BASE_URL")
+	})
+}
@@ -0,0 +1,59 @@
+package twilio
+
+import (
+	"net/http"
+	"net/url"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	providerRepo "go-multi-chat-api/src/infrastructure/repository/mysql/provider"
+)
+
+// deliveryStatuses maps the final and notable statuses of a Twilio message to delivery statuses, the statuses of a
+// message still on its way, like queued or sending, are not reported
+var deliveryStatuses = map[string]string{
+	"sent":        providerRepo.DeliveryStatusSent,
+	"delivered":   providerRepo.DeliveryStatusDelivered,
+	"read":        providerRepo.DeliveryStatusRead,
+	"undelivered": providerRepo.DeliveryStatusFailed,
+	"failed":      providerRepo.DeliveryStatusFailed,
+}
+
+// DeliveryCallbacks reads the status callbacks Twilio posts for the SMS of providers sending through Twilio
+type DeliveryCallbacks struct{}
+
+// NewDeliveryCallbacks creates a new reader of Twilio status callbacks
+func NewDeliveryCallbacks() *DeliveryCallbacks {
+	return &DeliveryCallbacks{}
+}
+
+// ParseCallback verifies a status callback posted to callbackURL with the auth token of the provider and returns
+// the delivery status it reports, if any
+func (d *DeliveryCallbacks) ParseCallback(providerConfig string, callbackURL string, header http.Header, body []byte) ([]domainProvider.DeliveryUpdate, error) {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, domainErrors.NewAppError(err, domainErrors.ValidationError)
+	}
+	if !ValidSignature(config.AuthToken, callbackURL, form, header.Get(SignatureHeader)) {
+		return nil, domainErrors.NewAppError(ErrInvalidSignature, domainErrors.NotAuthenticated)
+	}
+
+	status, ok := deliveryStatuses[form.Get("MessageStatus")]
+	if !ok || form.Get("MessageSid") == "" {
+		return nil, nil
+	}
+	update := domainProvider.DeliveryUpdate{
+		ProviderMessageID: form.Get("MessageSid"),
+		Recipient:         form.Get("To"),
+		Status:            status,
+	}
+	if status == providerRepo.DeliveryStatusFailed {
+		update.ErrorCode = form.Get("ErrorCode")
+		update.ErrorMessage = "twilio reported the message " + form.Get("MessageStatus")
+	}
+	return []domainProvider.DeliveryUpdate{update}, nil
+}
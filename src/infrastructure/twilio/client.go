@@ -0,0 +1,278 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domainProvider "go-multi-chat-api/src/domain/provider"
+	"go-multi-chat-api/src/infrastructure/httpclient"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Twilio REST API
+	DefaultAPIURL = "https://api.twilio.com"
+	// SignatureHeader carries the signature of the webhooks Twilio sends
+	SignatureHeader = "X-Twilio-Signature"
+)
+
+// Config is the Twilio account of an SMS provider, stored in the provider config
+type Config struct {
+	AccountSID string `json:"account_sid"`
+	AuthToken  string `json:"auth_token"`
+	From       string `json:"from"` // number SMS are sent from
+}
+
+// ParseConfig reads the Twilio account from the config of a provider
+func ParseConfig(providerConfig string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(providerConfig) != "" {
+		if err := json.Unmarshal([]byte(providerConfig), &config); err != nil {
+			return Config{}, fmt.Errorf("invalid twilio config: %w", err)
+		}
+	}
+	if config.AccountSID == "" || config.AuthToken == "" {
+		return Config{}, errors.New("twilio config needs an account_sid and an auth_token")
+	}
+	return config, nil
+}
+
+// Error is an error answered by the Twilio API
+type Error struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	MoreInfo   string `json:"more_info"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("twilio answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("twilio answered %d: %s (code %d)", e.StatusCode, e.Message, e.Code)
+}
+
+// ErrorCode classifies an error of a send by the Twilio error code, see https://www.twilio.com/docs/api/errors.
+// It returns an empty code for errors that weren't answered by Twilio.
+func ErrorCode(err error) string {
+	var twilioErr *Error
+	if !errors.As(err, &twilioErr) {
+		return ""
+	}
+	switch twilioErr.Code {
+	case 21211, 21214, 21217, 21401, 21407, 21610, 21612, 21614:
+		// invalid, unreachable or non-mobile To number, or a recipient who replied STOP
+		return domainProvider.ErrorCodeInvalidRecipient
+	case 20003, 20005:
+		return domainProvider.ErrorCodeAuthFailed
+	case 14107, 20429:
+		return domainProvider.ErrorCodeRateLimited
+	}
+	switch {
+	case twilioErr.StatusCode == http.StatusUnauthorized || twilioErr.StatusCode == http.StatusForbidden:
+		return domainProvider.ErrorCodeAuthFailed
+	case twilioErr.StatusCode == http.StatusTooManyRequests:
+		return domainProvider.ErrorCodeRateLimited
+	case twilioErr.StatusCode >= 500:
+		return domainProvider.ErrorCodeNetwork
+	}
+	return domainProvider.ErrorCodeUnknown
+}
+
+// AvailableNumber is a phone number that can be bought
+type AvailableNumber struct {
+	PhoneNumber  string `json:"phone_number"`
+	FriendlyName string `json:"friendly_name"`
+	Locality     string `json:"locality"`
+	Region       string `json:"region"`
+	ISOCountry   string `json:"iso_country"`
+	Capabilities struct {
+		SMS   bool `json:"SMS"`
+		MMS   bool `json:"MMS"`
+		Voice bool `json:"voice"`
+	} `json:"capabilities"`
+}
+
+// SearchQuery narrows the search for available numbers
+type SearchQuery struct {
+	Country  string // ISO country code, e.g. US
+	AreaCode string
+	Contains string // digits or a pattern like 555*** the number must contain
+	Limit    int
+}
+
+// IncomingNumber is a phone number bought for the account
+type IncomingNumber struct {
+	SID         string `json:"sid"`
+	PhoneNumber string `json:"phone_number"`
+	SMSURL      string `json:"sms_url"`
+}
+
+// Client manages the phone numbers of Twilio accounts
+type Client struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewClient creates a new client calling the Twilio API at apiURL
+func NewClient(apiURL string, timeout time.Duration) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		client: httpclient.New(timeout),
+	}
+}
+
+// SearchNumbers lists local numbers of a country that can receive SMS and are available to buy
+func (c *Client) SearchNumbers(config Config, query SearchQuery) ([]AvailableNumber, error) {
+	params := url.Values{"SmsEnabled": {"true"}}
+	if query.AreaCode != "" {
+		params.Set("AreaCode", query.AreaCode)
+	}
+	if query.Contains != "" {
+		params.Set("Contains", query.Contains)
+	}
+	if query.Limit > 0 {
+		params.Set("PageSize", strconv.Itoa(query.Limit))
+	}
+
+	var result struct {
+		AvailablePhoneNumbers []AvailableNumber `json:"available_phone_numbers"`
+	}
+	path := "/AvailablePhoneNumbers/" + url.PathEscape(strings.ToUpper(query.Country)) + "/Local.json?" + params.Encode()
+	if err := c.do(config, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.AvailablePhoneNumbers, nil
+}
+
+// ProvisionNumber buys a number for the account, the SMS it receives are posted to smsURL
+func (c *Client) ProvisionNumber(config Config, phoneNumber string, smsURL string) (*IncomingNumber, error) {
+	form := url.Values{"PhoneNumber": {phoneNumber}, "SmsUrl": {smsURL}, "SmsMethod": {http.MethodPost}}
+	var number IncomingNumber
+	if err := c.do(config, http.MethodPost, "/IncomingPhoneNumbers.json", form, &number); err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+// SendResult is the message Twilio created for a recipient of an SMS
+type SendResult struct {
+	Recipient  string `json:"recipient"`
+	MessageSID string `json:"message_sid"`
+	Status     string `json:"status"`
+}
+
+// SendSMS sends an SMS to each recipient from the number of the account. Twilio posts the delivery status of each
+// message to statusCallback, when set.
+func (c *Client) SendSMS(config Config, recipients []string, body string, statusCallback string) ([]SendResult, error) {
+	if config.From == "" {
+		return nil, errors.New("twilio config needs a from number to send sms")
+	}
+	results := make([]SendResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		form := url.Values{"To": {recipient}, "From": {config.From}, "Body": {body}}
+		if statusCallback != "" {
+			form.Set("StatusCallback", statusCallback)
+		}
+		var message struct {
+			SID    string `json:"sid"`
+			Status string `json:"status"`
+		}
+		if err := c.do(config, http.MethodPost, "/Messages.json", form, &message); err != nil {
+			return results, fmt.Errorf("couldn't send to %s: %w", recipient, err)
+		}
+		results = append(results, SendResult{Recipient: recipient, MessageSID: message.SID, Status: message.Status})
+	}
+	return results, nil
+}
+
+// ReleaseNumber gives a number of the account back, it can't receive SMS afterwards
+func (c *Client) ReleaseNumber(config Config, sid string) error {
+	return c.do(config, http.MethodDelete, "/IncomingPhoneNumbers/"+url.PathEscape(sid)+".json", nil, nil)
+}
+
+// VerifyCredentials fetches the account, which fails with an authentication error for a wrong account SID or
+// auth token
+func (c *Client) VerifyCredentials(config Config) error {
+	return c.do(config, http.MethodGet, ".json", nil, nil)
+}
+
+func (c *Client) do(config Config, method string, path string, form url.Values, result interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	request, err := http.NewRequest(method, c.apiURL+"/2010-04-01/Accounts/"+url.PathEscape(config.AccountSID)+path, body)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	request.SetBasicAuth(config.AccountSID, config.AuthToken)
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		twilioErr := &Error{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(responseBody, twilioErr)
+		return twilioErr
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(responseBody, result)
+}
+
+// ValidSignature reports whether a webhook was signed by Twilio with the auth token of the account. The
+// signature covers the full URL the webhook was sent to and, for form posts, every parameter.
+func ValidSignature(authToken string, webhookURL string, params url.Values, signature string) bool {
+	return hmac.Equal([]byte(sign(authToken, webhookURL, params)), []byte(signature))
+}
+
+// sign computes the signature Twilio sends with a webhook: the HMAC-SHA1 of the URL followed by the parameters
+// sorted by name, each as its name and value
+func sign(authToken string, webhookURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(webhookURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
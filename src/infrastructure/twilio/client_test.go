@@ -0,0 +1,217 @@
+package twilio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `{"account_sid":"AC123","auth_token":"secret"}`
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(`{"vendor":"twilio","account_sid":"AC123","auth_token":"secret","warmup":{"enabled":false}}`)
+	require.NoError(t, err)
+	assert.Equal(t, Config{AccountSID: "AC123", AuthToken: "secret"}, config)
+
+	_, err = ParseConfig(`{"account_sid":"AC123"}`)
+	assert.Error(t, err)
+}
+
+func TestClient_SearchNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/AvailablePhoneNumbers/US/Local.json", r.URL.Path)
+		assert.Equal(t, "415", r.URL.Query().Get("AreaCode"))
+		assert.Equal(t, "5", r.URL.Query().Get("PageSize"))
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", password)
+		_, _ = w.Write([]byte(`{"available_phone_numbers":[{"phone_number":"+14155550100","locality":"San Francisco","region":"CA","iso_country":"US","capabilities":{"SMS":true,"MMS":true,"voice":true}}]}`))
+	}))
+	defer server.Close()
+
+	numbers, err := NewProvisioner(NewClient(server.URL, time.Second)).Search(testConfig, domainProvider.NumberSearch{Country: "us", AreaCode: "415", Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []domainProvider.AvailableNumber{{PhoneNumber: "+14155550100", Locality: "San Francisco", Region: "CA", Country: "US", MMS: true}}, numbers)
+}
+
+func TestClient_ProvisionAndReleaseNumber(t *testing.T) {
+	released := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			assert.Equal(t, "/2010-04-01/Accounts/AC123/IncomingPhoneNumbers.json", r.URL.Path)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "+14155550100", r.PostForm.Get("PhoneNumber"))
+			assert.Equal(t, "https://api.example.com/v1/sms/inbound/3", r.PostForm.Get("SmsUrl"))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"sid":"PN123","phone_number":"+14155550100"}`))
+		case http.MethodDelete:
+			assert.Equal(t, "/2010-04-01/Accounts/AC123/IncomingPhoneNumbers/PN123.json", r.URL.Path)
+			if released {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"code":20404,"message":"The requested resource was not found"}`))
+				return
+			}
+			released = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	provisioner := NewProvisioner(NewClient(server.URL, time.Second))
+
+	number, err := provisioner.Provision(testConfig, "+14155550100", "https://api.example.com/v1/sms/inbound/3")
+	require.NoError(t, err)
+	assert.Equal(t, "PN123", number.ID)
+	assert.Equal(t, "+14155550100", number.PhoneNumber)
+
+	require.NoError(t, provisioner.Release(testConfig, *number))
+	// A number released already, e.g. in the Twilio console, is released
+	assert.NoError(t, provisioner.Release(testConfig, *number))
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":21422,"message":"PhoneNumber is not available","more_info":"https://www.twilio.com/docs/errors/21422"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, time.Second).ProvisionNumber(Config{AccountSID: "AC123", AuthToken: "secret"}, "+14155550100", "")
+	assert.EqualError(t, err, "twilio answered 400: PhoneNumber is not available (code 21422)")
+}
+
+func TestClient_VerifyCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/2010-04-01/Accounts/AC123.json", r.URL.Path)
+		if _, password, _ := r.BasicAuth(); password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":20003,"message":"Authenticate"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"sid":"AC123","status":"active"}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, time.Second)
+
+	assert.NoError(t, client.VerifyCredentials(Config{AccountSID: "AC123", AuthToken: "secret"}))
+	err := client.VerifyCredentials(Config{AccountSID: "AC123", AuthToken: "wrong"})
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(err))
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Code: 21211}))
+	assert.Equal(t, domainProvider.ErrorCodeInvalidRecipient, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Code: 21610}))
+	assert.Equal(t, domainProvider.ErrorCodeAuthFailed, ErrorCode(&Error{StatusCode: http.StatusUnauthorized, Code: 20003}))
+	assert.Equal(t, domainProvider.ErrorCodeRateLimited, ErrorCode(&Error{StatusCode: http.StatusTooManyRequests, Code: 20429}))
+	assert.Equal(t, domainProvider.ErrorCodeNetwork, ErrorCode(&Error{StatusCode: http.StatusServiceUnavailable}))
+	assert.Equal(t, domainProvider.ErrorCodeUnknown, ErrorCode(&Error{StatusCode: http.StatusBadRequest, Code: 21422}))
+	assert.Equal(t, "", ErrorCode(errors.New("connection refused")))
+}
+
+func TestValidSignature(t *testing.T) {
+	// The example of the Twilio webhook security documentation
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	webhookURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	assert.True(t, ValidSignature("12345", webhookURL, params, "0/KCTR6DLpKmkAf8muzZqo1nDgQ="))
+	assert.False(t, ValidSignature("54321", webhookURL, params, "0/KCTR6DLpKmkAf8muzZqo1nDgQ="))
+
+	params.Set("Digits", "4321")
+	assert.False(t, ValidSignature("12345", webhookURL, params, "0/KCTR6DLpKmkAf8muzZqo1nDgQ="))
+}
+
+func TestProvisioner_ParseInbound(t *testing.T) {
+	webhookURL := "https://api.example.com/v1/sms/inbound/3"
+	form := url.Values{
+		"MessageSid": {"SM123"},
+		"From":       {"+14155550199"},
+		"To":         {"+14155550100"},
+		"Body":       {"ACK"},
+		"NumMedia":   {"1"},
+		"MediaUrl0":  {"https://api.twilio.com/media/ME123"},
+	}
+	header := http.Header{}
+	header.Set(SignatureHeader, sign("secret", webhookURL, form))
+
+	sms, err := NewProvisioner(nil).ParseInbound(testConfig, webhookURL, header, form)
+	require.NoError(t, err)
+	assert.Equal(t, "SM123", sms.ID)
+	assert.Equal(t, "+14155550199", sms.From)
+	assert.Equal(t, "+14155550100", sms.To)
+	assert.Equal(t, "ACK", sms.Body)
+	assert.Equal(t, []string{"https://api.twilio.com/media/ME123"}, sms.MediaURLs)
+
+	_, err = NewProvisioner(nil).ParseInbound(testConfig, "https://attacker.example.com/v1/sms/inbound/3", header, form)
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+}
+
+func TestClient_SendSMS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "+14155550100", r.PostForm.Get("From"))
+		assert.Equal(t, "Disk full", r.PostForm.Get("Body"))
+		assert.Equal(t, "https://api.example.com/v1/callbacks/twilio/3", r.PostForm.Get("StatusCallback"))
+		if r.PostForm.Get("To") == "+14155550102" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid":"SM` + r.PostForm.Get("To")[1:] + `","status":"queued"}`))
+	}))
+	defer server.Close()
+	config := Config{AccountSID: "AC123", AuthToken: "secret", From: "+14155550100"}
+
+	results, err := NewClient(server.URL, time.Second).SendSMS(config, []string{"+14155550101", "+14155550102"}, "Disk full", "https://api.example.com/v1/callbacks/twilio/3")
+	assert.EqualError(t, err, "couldn't send to +14155550102: twilio answered 400: Invalid 'To' Phone Number (code 21211)")
+	assert.Equal(t, []SendResult{{Recipient: "+14155550101", MessageSID: "SM14155550101", Status: "queued"}}, results)
+
+	_, err = NewClient(server.URL, time.Second).SendSMS(Config{AccountSID: "AC123", AuthToken: "secret"}, []string{"+14155550101"}, "Disk full", "")
+	assert.Error(t, err)
+}
+
+func TestDeliveryCallbacks_ParseCallback(t *testing.T) {
+	callbackURL := "https://api.example.com/v1/callbacks/twilio/3"
+	parse := func(form url.Values, signedURL string) ([]domainProvider.DeliveryUpdate, error) {
+		header := http.Header{}
+		header.Set(SignatureHeader, sign("secret", signedURL, form))
+		return NewDeliveryCallbacks().ParseCallback(testConfig, callbackURL, header, []byte(form.Encode()))
+	}
+
+	updates, err := parse(url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}, "To": {"+14155550101"}}, callbackURL)
+	require.NoError(t, err)
+	assert.Equal(t, []domainProvider.DeliveryUpdate{{ProviderMessageID: "SM1", Recipient: "+14155550101", Status: "delivered"}}, updates)
+
+	updates, err = parse(url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}, callbackURL)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", updates[0].Status)
+	assert.Equal(t, "30003", updates[0].ErrorCode)
+
+	// Statuses of a message still on its way are not reported
+	updates, err = parse(url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"sending"}}, callbackURL)
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+
+	_, err = parse(url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}}, "https://attacker.example.com/v1/callbacks/twilio/3")
+	var appErr *domainErrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, domainErrors.NotAuthenticated, appErr.Type)
+}
@@ -0,0 +1,98 @@
+package twilio
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	domainErrors "go-multi-chat-api/src/domain/errors"
+	domainProvider "go-multi-chat-api/src/domain/provider"
+)
+
+// ErrInvalidSignature is returned for webhooks that weren't signed with the auth token of the provider
+var ErrInvalidSignature = errors.New("invalid twilio signature")
+
+// Provisioner provisions inbound numbers and reads inbound SMS for SMS providers sending through Twilio
+type Provisioner struct {
+	client *Client
+}
+
+// NewProvisioner creates a new Twilio provisioner
+func NewProvisioner(client *Client) *Provisioner {
+	return &Provisioner{client: client}
+}
+
+func (p *Provisioner) Search(providerConfig string, search domainProvider.NumberSearch) ([]domainProvider.AvailableNumber, error) {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	numbers, err := p.client.SearchNumbers(config, SearchQuery(search))
+	if err != nil {
+		return nil, err
+	}
+	available := make([]domainProvider.AvailableNumber, len(numbers))
+	for i, number := range numbers {
+		available[i] = domainProvider.AvailableNumber{
+			PhoneNumber: number.PhoneNumber,
+			Locality:    number.Locality,
+			Region:      number.Region,
+			Country:     number.ISOCountry,
+			MMS:         number.Capabilities.MMS,
+		}
+	}
+	return available, nil
+}
+
+func (p *Provisioner) Provision(providerConfig string, phoneNumber string, webhookURL string) (*domainProvider.InboundNumber, error) {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	number, err := p.client.ProvisionNumber(config, phoneNumber, webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	return &domainProvider.InboundNumber{ID: number.SID, PhoneNumber: number.PhoneNumber, ProvisionedAt: time.Now().UTC()}, nil
+}
+
+func (p *Provisioner) Release(providerConfig string, number domainProvider.InboundNumber) error {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return err
+	}
+	err = p.client.ReleaseNumber(config, number.ID)
+	var twilioErr *Error
+	if errors.As(err, &twilioErr) && twilioErr.StatusCode == http.StatusNotFound {
+		// Released already, e.g. in the Twilio console
+		return nil
+	}
+	return err
+}
+
+func (p *Provisioner) ParseInbound(providerConfig string, webhookURL string, header http.Header, form url.Values) (*domainProvider.InboundSMS, error) {
+	config, err := ParseConfig(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !ValidSignature(config.AuthToken, webhookURL, form, header.Get(SignatureHeader)) {
+		return nil, domainErrors.NewAppError(ErrInvalidSignature, domainErrors.NotAuthenticated)
+	}
+
+	sms := &domainProvider.InboundSMS{
+		ID:         form.Get("MessageSid"),
+		From:       form.Get("From"),
+		To:         form.Get("To"),
+		Body:       form.Get("Body"),
+		ReceivedAt: time.Now().UTC(),
+	}
+	numMedia, _ := strconv.Atoi(form.Get("NumMedia"))
+	for i := 0; i < numMedia; i++ {
+		if mediaURL := form.Get("MediaUrl" + strconv.Itoa(i)); mediaURL != "" {
+			sms.MediaURLs = append(sms.MediaURLs, mediaURL)
+		}
+	}
+	return sms, nil
+}
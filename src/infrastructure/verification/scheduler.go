@@ -0,0 +1,75 @@
+package verification
+
+import (
+	"time"
+
+	"go-multi-chat-api/src/application/usecases/verification"
+	"go-multi-chat-api/src/infrastructure/leader"
+	logger "go-multi-chat-api/src/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler periodically calls the numbers whose code sent by SMS wasn't verified in time, on the leader
+// instance only
+type Scheduler struct {
+	verificationUseCase verification.IVerificationUseCase
+	elector             leader.Elector
+	Logger              *logger.Logger
+	interval            time.Duration
+	shutdown            chan struct{}
+	done                chan struct{}
+}
+
+// NewScheduler creates a new verification scheduler and starts it
+func NewScheduler(verificationUseCase verification.IVerificationUseCase, elector leader.Elector, loggerInstance *logger.Logger, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = 15 * time.Second // Default to checking every 15 seconds if not specified
+	}
+
+	scheduler := &Scheduler{
+		verificationUseCase: verificationUseCase,
+		elector:             elector,
+		Logger:              loggerInstance,
+		interval:            interval,
+		shutdown:            make(chan struct{}),
+		done:                make(chan struct{}),
+	}
+
+	go scheduler.run()
+
+	return scheduler
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Logger.Info("Starting verification scheduler", zap.Duration("interval", s.interval))
+
+	for {
+		select {
+		case <-ticker.C:
+			s.fallBackToVoice()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) fallBackToVoice() {
+	if !s.elector.IsLeader() {
+		return
+	}
+	if err := s.verificationUseCase.FallBackToVoice(time.Now()); err != nil {
+		s.Logger.Error("Error falling back to voice verification", zap.Error(err))
+	}
+}
+
+// Shutdown stops the scheduler
+func (s *Scheduler) Shutdown() {
+	close(s.shutdown)
+	<-s.done
+}